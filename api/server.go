@@ -4,23 +4,32 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof" //nolint:gosec // Register pprof handlers
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	v1 "go.k6.io/k6/api/v1"
+	v2 "go.k6.io/k6/api/v2"
 	"go.k6.io/k6/execution"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/metrics/engine"
 )
 
-func newHandler(cs *v1.ControlSurface, profilingEnabled bool) http.Handler {
+func newHandler(cs *v1.ControlSurface, stream *v2.MetricsStream, profilingEnabled bool) http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/v1/", v1.NewHandler(cs))
+	mux.Handle("/v2/", v2.NewHandler(stream))
 	mux.Handle("/ping", handlePing(cs.RunState.Logger))
 	mux.Handle("/", handlePing(cs.RunState.Logger))
 
@@ -44,7 +53,63 @@ func injectProfilerHandler(mux *http.ServeMux, profilingEnabled bool) {
 	mux.Handle("/debug/pprof/", handler)
 }
 
-// GetServer returns a http.Server instance that can serve k6's REST API.
+// ServerConfig contains the TLS and authentication options of the REST API
+// server.
+type ServerConfig struct {
+	// Token, if set, has to be sent as a bearer token with every request.
+	Token string
+
+	// TLSCert and TLSKey are the PEM-encoded certificate and private key of
+	// the server, which enable HTTPS.
+	TLSCert []byte
+	TLSKey  []byte
+
+	// ClientCA contains PEM-encoded CA certificates and enables mutual TLS
+	// authentication, i.e. the clients have to present a certificate that is
+	// signed by one of them.
+	ClientCA []byte
+}
+
+// IsAuthenticated returns whether all requests to the server have to be
+// authenticated, either with a token or with a client certificate.
+func (sc ServerConfig) IsAuthenticated() bool {
+	return sc.Token != "" || len(sc.ClientCA) > 0
+}
+
+// getTLSConfig returns the TLS config of the server or nil if it should
+// serve plaintext HTTP.
+func (sc ServerConfig) getTLSConfig() (*tls.Config, error) {
+	if len(sc.TLSCert) == 0 && len(sc.TLSKey) == 0 {
+		if len(sc.ClientCA) > 0 {
+			return nil, errors.New("a TLS certificate and key are required for the REST API client authentication")
+		}
+		return nil, nil //nolint:nilnil
+	}
+	if len(sc.TLSCert) == 0 || len(sc.TLSKey) == 0 {
+		return nil, errors.New("both a TLS certificate and key are required for the REST API server")
+	}
+
+	cert, err := tls.X509KeyPair(sc.TLSCert, sc.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate of the REST API server: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if len(sc.ClientCA) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(sc.ClientCA) {
+			return nil, errors.New("no valid certificates were found in the client CA of the REST API server")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// GetServer returns a http.Server instance that can serve k6's REST API. If
+// its TLSConfig is set, it should be started with ListenAndServeTLS("", "").
 func GetServer(
 	runCtx context.Context,
 	addr string,
@@ -53,7 +118,14 @@ func GetServer(
 	samples chan metrics.SampleContainer,
 	me *engine.MetricsEngine,
 	es *execution.Scheduler,
-) *http.Server {
+	stream *v2.MetricsStream,
+	conf ServerConfig,
+) (*http.Server, error) {
+	tlsConfig, err := conf.getTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: reduce the control surface as much as possible? For example, if
 	// we refactor the Runner API, we won't need to send the Samples channel.
 	cs := &v1.ControlSurface{
@@ -62,10 +134,58 @@ func GetServer(
 		MetricsEngine: me,
 		Scheduler:     es,
 		RunState:      runState,
+		Authenticated: conf.IsAuthenticated(),
+	}
+
+	handler := withAuthHandler(conf.Token, newHandler(cs, stream, profilingEnabled))
+	mux := withLoggingHandler(runState.Logger, handler)
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}, nil
+}
+
+// NewServer returns a http.Server for a handler other than the REST API's,
+// like the one of the coordinator of the distributed tests, that has the same
+// TLS and authentication as the REST API server. If its TLSConfig is set, it
+// should be started with ServeTLS or ListenAndServeTLS with empty file names.
+func (sc ServerConfig) NewServer(addr string, handler http.Handler) (*http.Server, error) {
+	tlsConfig, err := sc.getTLSConfig()
+	if err != nil {
+		return nil, err
 	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           withAuthHandler(sc.Token, handler),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}, nil
+}
 
-	mux := withLoggingHandler(runState.Logger, newHandler(cs, profilingEnabled))
-	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+// withAuthHandler returns the middleware which requires the given token as a
+// bearer token for all requests, except the ones for /ping, so it can still
+// be used for health checks. It doesn't do anything if the token is empty.
+func withAuthHandler(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		reqToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if r.URL.Path != "/ping" && subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+			rw.Header().Set("WWW-Authenticate", "Bearer")
+			rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+			rw.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(rw).Encode(v1.ErrorResponse{Errors: []v1.Error{{
+				Status: strconv.Itoa(http.StatusUnauthorized),
+				Title:  "Unauthorized",
+				Detail: "a valid API token is required",
+			}}})
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
 }
 
 type wrappedResponseWriter struct {
@@ -1,14 +1,25 @@
 package api
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"go.k6.io/k6/lib/testutils"
 )
@@ -71,3 +82,129 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, []byte{'o', 'k'}, rw.Body.Bytes())
 	assert.NoError(t, res.Body.Close())
 }
+
+func TestAuthHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path, header string
+		expStatus    int
+	}{
+		{path: "/v1/status", header: "", expStatus: http.StatusUnauthorized},
+		{path: "/v1/status", header: "Bearer wrong", expStatus: http.StatusUnauthorized},
+		{path: "/v1/status", header: "secret", expStatus: http.StatusOK},
+		{path: "/v1/status", header: "Bearer secret", expStatus: http.StatusOK},
+		{path: "/ping", header: "", expStatus: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		withAuthHandler("secret", http.HandlerFunc(testHTTPHandler)).ServeHTTP(rw, r)
+		res := rw.Result()
+		assert.Equal(t, tc.expStatus, res.StatusCode, "%s with '%s'", tc.path, tc.header)
+		assert.NoError(t, res.Body.Close())
+	}
+}
+
+// generateTestCert returns a self-signed PEM-encoded certificate and key,
+// that can be used both by servers and clients.
+func generateTestCert(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "k6 test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestServerConfigTLS(t *testing.T) {
+	t.Parallel()
+
+	cert, key := generateTestCert(t)
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ServerConfig{TLSCert: cert}.getTLSConfig()
+		assert.ErrorContains(t, err, "both a TLS certificate and key are required")
+		_, err = ServerConfig{ClientCA: cert}.getTLSConfig()
+		assert.ErrorContains(t, err, "a TLS certificate and key are required for the REST API client authentication")
+		_, err = ServerConfig{TLSCert: cert, TLSKey: key, ClientCA: []byte("invalid")}.getTLSConfig()
+		assert.ErrorContains(t, err, "no valid certificates were found")
+
+		tlsConfig, err := ServerConfig{Token: "secret"}.getTLSConfig()
+		require.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+	})
+
+	t.Run("mTLS", func(t *testing.T) {
+		t.Parallel()
+
+		conf := ServerConfig{TLSCert: cert, TLSKey: key, ClientCA: cert}
+		assert.True(t, conf.IsAuthenticated())
+		tlsConfig, err := conf.getTLSConfig()
+		require.NoError(t, err)
+
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(testHTTPHandler))
+		srv.TLS = tlsConfig
+		srv.StartTLS()
+		t.Cleanup(srv.Close)
+
+		clientCert, err := tls.X509KeyPair(cert, key)
+		require.NoError(t, err)
+		pool := x509.NewCertPool()
+		require.True(t, pool.AppendCertsFromPEM(cert))
+		newClient := func(certs ...tls.Certificate) *http.Client {
+			return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs: pool, Certificates: certs, MinVersion: tls.VersionTLS12,
+			}}}
+		}
+
+		res, err := newClient(clientCert).Get(srv.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, res.Body.Close())
+
+		_, err = newClient().Get(srv.URL) //nolint:bodyclose
+		assert.Error(t, err)
+	})
+}
+
+func TestServerConfigNewServer(t *testing.T) {
+	t.Parallel()
+
+	_, err := ServerConfig{TLSCert: []byte("invalid")}.NewServer("", http.HandlerFunc(testHTTPHandler))
+	assert.ErrorContains(t, err, "both a TLS certificate and key are required")
+
+	srv, err := ServerConfig{Token: "secret"}.NewServer("", http.HandlerFunc(testHTTPHandler))
+	require.NoError(t, err)
+	assert.Nil(t, srv.TLSConfig)
+
+	for header, expStatus := range map[string]int{"": http.StatusUnauthorized, "Bearer secret": http.StatusOK} {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/register", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		srv.Handler.ServeHTTP(rw, r)
+		res := rw.Result()
+		assert.Equal(t, expStatus, res.StatusCode, "with '%s'", header)
+		assert.NoError(t, res.Body.Close())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -19,15 +20,20 @@ type Client struct {
 	BaseURL    *url.URL
 	httpClient *http.Client
 	logger     *logrus.Entry
+	authToken  string
 }
 
 // Option function are helpers that enable the flexible configuration of the
 // REST API client.
 type Option func(*Client)
 
-// New returns a newly configured REST API Client.
+// New returns a newly configured REST API Client. The base address can have
+// an http:// or https:// scheme, by default it's http://.
 func New(base string, options ...Option) (*Client, error) {
-	baseURL, err := url.Parse("http://" + base)
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "http://" + base
+	}
+	baseURL, err := url.Parse(base)
 	if err != nil {
 		return nil, err
 	}
@@ -58,6 +64,14 @@ func WithLogger(logger *logrus.Entry) Option {
 	})
 }
 
+// WithAuthToken sets the API token that is sent with the requests, which is
+// required by the endpoints that change the configuration of the test.
+func WithAuthToken(token string) Option {
+	return Option(func(c *Client) {
+		c.authToken = token
+	})
+}
+
 // CallAPI executes the desired REST API request.
 // it's expected that the body and out are the structs that follows the JSON:API
 func (c *Client) CallAPI(ctx context.Context, method string, rel *url.URL, body, out interface{}) (err error) {
@@ -93,6 +107,9 @@ func (c *Client) CallAPI(ctx context.Context, method string, rel *url.URL, body,
 		Body:   bodyReader,
 	}
 	req = req.WithContext(ctx)
+	if c.authToken != "" {
+		req.Header = http.Header{"Authorization": []string{"Bearer " + c.authToken}}
+	}
 
 	res, err := c.httpClient.Do(req)
 	if err != nil {
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	v1 "go.k6.io/k6/api/v1"
+)
+
+// LiveConfig returns the parts of the test configuration that can be changed
+// while the test is running.
+func (c *Client) LiveConfig(ctx context.Context) (ret v1.LiveConfig, err error) {
+	var resp v1.LiveConfigJSONAPI
+
+	if err = c.CallAPI(ctx, http.MethodGet, &url.URL{Path: "/v1/live-config"}, nil, &resp); err != nil {
+		return ret, err
+	}
+
+	return resp.LiveConfig(), nil
+}
+
+// SetLiveConfig changes the tags, test parameters and log level of the
+// running test and returns the new configuration if it was successful.
+func (c *Client) SetLiveConfig(ctx context.Context, patch v1.LiveConfig) (ret v1.LiveConfig, err error) {
+	var resp v1.LiveConfigJSONAPI
+
+	err = c.CallAPI(ctx, http.MethodPatch, &url.URL{Path: "/v1/live-config"}, v1.NewLiveConfigJSONAPI(patch), &resp)
+	if err != nil {
+		return ret, err
+	}
+
+	return resp.LiveConfig(), nil
+}
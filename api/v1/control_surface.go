@@ -17,4 +17,9 @@ type ControlSurface struct {
 	MetricsEngine *engine.MetricsEngine
 	Scheduler     *execution.Scheduler
 	RunState      *lib.TestRunState
+
+	// Authenticated is set when all requests to the server have to be
+	// authenticated. The endpoints that change the configuration of the
+	// running test are disabled if it isn't.
+	Authenticated bool
 }
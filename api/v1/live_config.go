@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+)
+
+// LiveConfig represents the parts of the configuration of the test run that
+// can be changed while it's running.
+type LiveConfig struct {
+	// Tags are set on the metric samples emitted by the VUs, starting from
+	// their next iteration. When patching, only the given tags are changed and
+	// setting a tag to null removes it.
+	Tags map[string]*string `json:"tags" yaml:"tags"`
+
+	// Parameters are user-defined values that the scripts can read through
+	// exec.test.parameters. When patching, only the given parameters are
+	// changed and setting a parameter to null removes it.
+	Parameters map[string]interface{} `json:"parameters" yaml:"parameters"`
+
+	// LogLevel is the current level of the k6 logger, e.g. "debug".
+	LogLevel null.String `json:"log-level" yaml:"log-level"`
+}
+
+// levelLogger is implemented by the loggers that allow changing their level.
+type levelLogger interface {
+	GetLevel() logrus.Level
+	SetLevel(level logrus.Level)
+}
+
+func newLiveConfig(cs *ControlSurface) (LiveConfig, error) {
+	liveConfig := cs.Scheduler.GetState().LiveConfig
+	tags, _ := liveConfig.GetTags()
+	conf := LiveConfig{
+		Tags:       make(map[string]*string, len(tags)),
+		Parameters: make(map[string]interface{}),
+	}
+	for k, v := range tags {
+		v := v
+		conf.Tags[k] = &v
+	}
+	for k, data := range liveConfig.GetParameters() {
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return conf, fmt.Errorf("invalid value of parameter '%s': %w", k, err)
+		}
+		conf.Parameters[k] = v
+	}
+	if logger, ok := cs.RunState.Logger.(levelLogger); ok {
+		conf.LogLevel = null.StringFrom(logger.GetLevel().String())
+	}
+	return conf, nil
+}
+
+// applyLiveConfig validates the whole patch before changing anything, so
+// that invalid patches are rejected without being partially applied.
+func applyLiveConfig(cs *ControlSurface, patch LiveConfig) error {
+	var (
+		logger levelLogger
+		level  logrus.Level
+	)
+	if patch.LogLevel.Valid {
+		var ok bool
+		if logger, ok = cs.RunState.Logger.(levelLogger); !ok {
+			return errors.New("the log level of this test run can't be changed")
+		}
+		var err error
+		if level, err = logrus.ParseLevel(patch.LogLevel.String); err != nil {
+			return err
+		}
+	}
+
+	setTags := make(map[string]string)
+	var deletedTags []string
+	for k, v := range patch.Tags {
+		if k == "" {
+			return errors.New("the tag names can't be empty")
+		}
+		if v == nil {
+			deletedTags = append(deletedTags, k)
+		} else {
+			setTags[k] = *v
+		}
+	}
+
+	setParams := make(map[string][]byte)
+	var deletedParams []string
+	for k, v := range patch.Parameters {
+		if k == "" {
+			return errors.New("the parameter names can't be empty")
+		}
+		if v == nil {
+			deletedParams = append(deletedParams, k)
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("invalid value of parameter '%s': %w", k, err)
+		}
+		setParams[k] = data
+	}
+
+	liveConfig := cs.Scheduler.GetState().LiveConfig
+	if patch.Tags != nil {
+		liveConfig.UpdateTags(setTags, deletedTags)
+	}
+	if patch.Parameters != nil {
+		liveConfig.UpdateParameters(setParams, deletedParams)
+	}
+	if logger != nil {
+		logger.SetLevel(level)
+		cs.RunState.Logger.Infof("The log level was changed to %s through the REST API", level)
+	}
+	return nil
+}
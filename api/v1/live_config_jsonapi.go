@@ -0,0 +1,28 @@
+package v1
+
+// LiveConfigJSONAPI is JSON API envelop for the live config
+type LiveConfigJSONAPI struct {
+	Data liveConfigData `json:"data"`
+}
+
+type liveConfigData struct {
+	Type       string     `json:"type"`
+	ID         string     `json:"id"`
+	Attributes LiveConfig `json:"attributes"`
+}
+
+// NewLiveConfigJSONAPI creates the JSON API live config envelop
+func NewLiveConfigJSONAPI(c LiveConfig) LiveConfigJSONAPI {
+	return LiveConfigJSONAPI{
+		Data: liveConfigData{
+			Type:       "live-config",
+			ID:         "default",
+			Attributes: c,
+		},
+	}
+}
+
+// LiveConfig extract the v1.LiveConfig from the JSON API envelop
+func (c LiveConfigJSONAPI) LiveConfig() LiveConfig {
+	return c.Data.Attributes
+}
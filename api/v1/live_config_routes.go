@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// isAuthenticated checks that the requests to the server are authenticated
+// and responds with an error if they aren't, since the endpoints that use it
+// can change how the test runs.
+func isAuthenticated(cs *ControlSurface, rw http.ResponseWriter) bool {
+	if !cs.Authenticated {
+		apiError(rw, "Forbidden", "this endpoint is available only if the REST API requires authentication, "+
+			"with an --api-token or an --api-tls-ca for client certificates", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func handleGetLiveConfig(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !isAuthenticated(cs, rw) {
+		return
+	}
+
+	conf, err := newLiveConfig(cs)
+	if err != nil {
+		apiError(rw, "Live config error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(NewLiveConfigJSONAPI(conf))
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
+
+func handlePatchLiveConfig(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !isAuthenticated(cs, rw) {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apiError(rw, "Couldn't read request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var envelop LiveConfigJSONAPI
+	if err = json.Unmarshal(body, &envelop); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = applyLiveConfig(cs, envelop.LiveConfig()); err != nil {
+		apiError(rw, "Live config error", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conf, err := newLiveConfig(cs)
+	if err != nil {
+		apiError(rw, "Live config error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(NewLiveConfigJSONAPI(conf))
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
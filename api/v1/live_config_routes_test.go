@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+)
+
+func TestLiveConfigRequiresAuthentication(t *testing.T) {
+	t.Parallel()
+
+	for _, authenticated := range []bool{false, true} {
+		cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+		cs.Authenticated = authenticated
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/live-config", nil))
+		res := rw.Result()
+		require.NoError(t, res.Body.Close())
+		if authenticated {
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		} else {
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		}
+	}
+}
+
+func TestPatchLiveConfig(t *testing.T) {
+	t.Parallel()
+
+	cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+	cs.Authenticated = true
+	liveConfig := cs.Scheduler.GetState().LiveConfig
+	liveConfig.UpdateTags(map[string]string{"old": "tag"}, nil)
+
+	patch := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/live-config", bytes.NewBufferString(body))
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, req)
+		t.Cleanup(func() {
+			assert.NoError(t, rw.Result().Body.Close())
+		})
+		return rw
+	}
+
+	rw := patch(t, `{"data":{"type":"live-config","id":"default","attributes":{
+		"tags": {"flag": "beta", "old": null},
+		"parameters": {"rate": 5, "modes": ["a", "b"]},
+		"log-level": "debug"
+	}}}`)
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+
+	var envelop LiveConfigJSONAPI
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &envelop))
+	conf := envelop.LiveConfig()
+	require.Len(t, conf.Tags, 1)
+	assert.Equal(t, "beta", *conf.Tags["flag"])
+	assert.Equal(t, map[string]interface{}{"rate": 5.0, "modes": []interface{}{"a", "b"}}, conf.Parameters)
+	assert.Equal(t, "debug", conf.LogLevel.String)
+
+	tags, _ := liveConfig.GetTags()
+	assert.Equal(t, map[string]string{"flag": "beta"}, tags)
+	assert.Equal(t, `5`, string(liveConfig.GetParameters()["rate"]))
+
+	rw = patch(t, `{"data":{"attributes":{"parameters": {"rate": null}, "log-level": "verbose"}}}`)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.Contains(t, rw.Body.String(), `not a valid logrus Level: \"verbose\"`)
+	assert.Len(t, liveConfig.GetParameters(), 2, "an invalid patch shouldn't be partially applied")
+
+	rw = patch(t, `{"data":{"attributes":{"parameters": {"rate": null}}}}`)
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+	params := liveConfig.GetParameters()
+	assert.Len(t, params, 1)
+	assert.Contains(t, params, "modes")
+}
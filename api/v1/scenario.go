@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+)
+
+// Scenario represents the current state of a scenario of the test run.
+type Scenario struct {
+	Name     string    `json:"name" yaml:"name"`
+	Executor string    `json:"executor" yaml:"executor"`
+	Paused   null.Bool `json:"paused" yaml:"paused"`
+
+	// Stages are only available for ramping-vus scenarios. Changing them
+	// replaces the remaining stages of the running scenario, starting from the
+	// current moment and number of VUs.
+	Stages []executor.Stage `json:"stages,omitempty" yaml:"stages,omitempty"`
+}
+
+func newScenario(exec lib.Executor) Scenario {
+	conf := exec.GetConfig()
+	scenario := Scenario{
+		Name:     conf.GetName(),
+		Executor: conf.GetType(),
+	}
+	if pausableExecutor, ok := exec.(lib.ScenarioPausableExecutor); ok {
+		scenario.Paused = null.BoolFrom(pausableExecutor.IsScenarioPaused())
+	}
+	if rampingVUs, ok := exec.(*executor.RampingVUs); ok {
+		scenario.Stages = rampingVUs.GetCurrentStages()
+	}
+	return scenario
+}
@@ -0,0 +1,60 @@
+package v1
+
+// ScenarioJSONAPI is JSON API envelop for a scenario
+type ScenarioJSONAPI struct {
+	Data scenarioData `json:"data"`
+}
+
+// ScenariosJSONAPI is JSON API envelop for scenarios
+type ScenariosJSONAPI struct {
+	Data []scenarioData `json:"data"`
+}
+
+type scenarioData struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Attributes Scenario `json:"attributes"`
+}
+
+// NewScenarioJSONAPI creates the JSON API scenario envelop
+func NewScenarioJSONAPI(s Scenario) ScenarioJSONAPI {
+	return ScenarioJSONAPI{
+		Data: newScenarioData(s),
+	}
+}
+
+func newScenariosJSONAPI(scenarios []Scenario) ScenariosJSONAPI {
+	envelop := ScenariosJSONAPI{
+		Data: make([]scenarioData, 0, len(scenarios)),
+	}
+	for _, s := range scenarios {
+		envelop.Data = append(envelop.Data, newScenarioData(s))
+	}
+	return envelop
+}
+
+func newScenarioData(s Scenario) scenarioData {
+	return scenarioData{
+		Type:       "scenarios",
+		ID:         s.Name,
+		Attributes: s,
+	}
+}
+
+// Scenario extract the v1.Scenario from the JSON API envelop
+func (s ScenarioJSONAPI) Scenario() Scenario {
+	scenario := s.Data.Attributes
+	scenario.Name = s.Data.ID
+	return scenario
+}
+
+// Scenarios extract the []v1.Scenario from the JSON API envelop
+func (s ScenariosJSONAPI) Scenarios() []Scenario {
+	list := make([]Scenario, 0, len(s.Data))
+	for _, data := range s.Data {
+		scenario := data.Attributes
+		scenario.Name = data.ID
+		list = append(list, scenario)
+	}
+	return list
+}
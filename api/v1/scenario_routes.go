@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go.k6.io/k6/lib/executor"
+)
+
+func handleGetScenarios(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request) {
+	executors := cs.Scheduler.GetExecutors()
+	scenarios := make([]Scenario, 0, len(executors))
+	for _, exec := range executors {
+		scenarios = append(scenarios, newScenario(exec))
+	}
+
+	data, err := json.Marshal(newScenariosJSONAPI(scenarios))
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = rw.Write(data)
+}
+
+func handleGetScenario(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request, name string) {
+	exec := cs.Scheduler.GetExecutor(name)
+	if exec == nil {
+		apiError(rw, "Not Found", "No scenario with that name was found", http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(NewScenarioJSONAPI(newScenario(exec)))
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = rw.Write(data)
+}
+
+func handlePatchScenario(cs *ControlSurface, rw http.ResponseWriter, r *http.Request, name string) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	exec := cs.Scheduler.GetExecutor(name)
+	if exec == nil {
+		apiError(rw, "Not Found", "No scenario with that name was found", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apiError(rw, "Couldn't read request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var scenarioEnvelop ScenarioJSONAPI
+	if err = json.Unmarshal(body, &scenarioEnvelop); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scenario := scenarioEnvelop.Scenario()
+
+	if scenario.Paused.Valid {
+		if err = cs.Scheduler.SetScenarioPaused(name, scenario.Paused.Bool); err != nil {
+			apiError(rw, "Pause error", err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if scenario.Stages != nil {
+		rampingVUs, ok := exec.(*executor.RampingVUs)
+		if !ok {
+			apiError(rw, "Execution config error",
+				"the stages can be changed only for ramping-vus scenarios", http.StatusBadRequest)
+			return
+		}
+		if err = rampingVUs.UpdateConfig(r.Context(), scenario.Stages); err != nil {
+			apiError(rw, "Config update error", err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	data, err := json.Marshal(NewScenarioJSONAPI(newScenario(exec)))
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
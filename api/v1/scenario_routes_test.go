@@ -0,0 +1,162 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+)
+
+func getScenariosTestControlSurface(t *testing.T) *ControlSurface {
+	scenarios := lib.ScenarioConfigs{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"browse": {"executor": "ramping-vus", "stages": [{"duration": "10s", "target": 5}]},
+		"checkout": {"executor": "constant-vus", "vus": 2, "duration": "10s"}
+	}`), &scenarios))
+	testState := getTestRunState(t, lib.Options{Scenarios: scenarios}, &minirunner.MiniRunner{})
+	return getControlSurface(t, testState)
+}
+
+func TestGetScenarios(t *testing.T) {
+	t.Parallel()
+
+	cs := getScenariosTestControlSurface(t)
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/scenarios", nil))
+	res := rw.Result()
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var envelop ScenariosJSONAPI
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &envelop))
+	require.Len(t, envelop.Data, 2)
+	assert.Equal(t, "scenarios", envelop.Data[0].Type)
+
+	scenarios := envelop.Scenarios()
+	assert.Equal(t, "browse", scenarios[0].Name)
+	assert.Equal(t, "ramping-vus", scenarios[0].Executor)
+	assert.Equal(t, null.BoolFrom(false), scenarios[0].Paused)
+	assert.Len(t, scenarios[0].Stages, 1)
+	assert.Equal(t, "checkout", scenarios[1].Name)
+	assert.Empty(t, scenarios[1].Stages)
+}
+
+func TestGetScenario(t *testing.T) {
+	t.Parallel()
+
+	cs := getScenariosTestControlSurface(t)
+
+	t.Run("existing", func(t *testing.T) {
+		t.Parallel()
+
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/scenarios/checkout", nil))
+		res := rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var envelop ScenarioJSONAPI
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &envelop))
+		assert.Equal(t, Scenario{Name: "checkout", Executor: "constant-vus", Paused: null.BoolFrom(false)}, envelop.Scenario())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/scenarios/missing", nil))
+		res := rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestPatchScenario(t *testing.T) {
+	t.Parallel()
+
+	testData := map[string]struct {
+		Scenario           string
+		Payload            []byte
+		ExpectedStatusCode int
+		ExpectedPaused     null.Bool
+	}{
+		"nothing": {
+			Scenario:           "checkout",
+			Payload:            []byte(`{"data":{"type":"scenarios","id":"checkout","attributes":{"paused":null}}}`),
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedPaused:     null.BoolFrom(false),
+		},
+		"paused": {
+			Scenario:           "checkout",
+			Payload:            []byte(`{"data":{"type":"scenarios","id":"checkout","attributes":{"paused":true}}}`),
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedPaused:     null.BoolFrom(true),
+		},
+		"not paused": {
+			Scenario:           "checkout",
+			Payload:            []byte(`{"data":{"type":"scenarios","id":"checkout","attributes":{"paused":false}}}`),
+			ExpectedStatusCode: http.StatusInternalServerError,
+		},
+		"missing": {
+			Scenario:           "missing",
+			Payload:            []byte(`{"data":{"type":"scenarios","id":"missing","attributes":{"paused":true}}}`),
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		"invalid": {
+			Scenario:           "checkout",
+			Payload:            []byte(`{"data":`),
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"stages of constant-vus": {
+			Scenario:           "checkout",
+			Payload:            []byte(`{"data":{"type":"scenarios","id":"checkout","attributes":{"stages":[{"duration":"10s","target":1}]}}}`),
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"stages before the start": {
+			Scenario:           "browse",
+			Payload:            []byte(`{"data":{"type":"scenarios","id":"browse","attributes":{"stages":[{"duration":"10s","target":1}]}}}`),
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, testCase := range testData {
+		name, testCase := name, testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cs := getScenariosTestControlSurface(t)
+			rw := httptest.NewRecorder()
+			url := "/v1/scenarios/" + testCase.Scenario
+			NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPatch, url, bytes.NewReader(testCase.Payload)))
+			res := rw.Result()
+			t.Cleanup(func() {
+				assert.NoError(t, res.Body.Close())
+			})
+
+			require.Equal(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"))
+			require.Equal(t, testCase.ExpectedStatusCode, res.StatusCode)
+			if testCase.ExpectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var envelop ScenarioJSONAPI
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &envelop))
+			assert.Equal(t, testCase.ExpectedPaused, envelop.Scenario().Paused)
+			assert.Equal(t, testCase.ExpectedPaused, newScenario(cs.Scheduler.GetExecutor(testCase.Scenario)).Paused)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	v1 "go.k6.io/k6/api/v1"
+)
+
+func apiError(rw http.ResponseWriter, title, detail string, status int) {
+	doc := v1.ErrorResponse{
+		Errors: []v1.Error{
+			{
+				Status: strconv.Itoa(status),
+				Title:  title,
+				Detail: detail,
+			},
+		},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(data)
+}
@@ -0,0 +1,22 @@
+// Package v2 implements the v2 of the k6's REST API
+package v2
+
+import (
+	"net/http"
+)
+
+// NewHandler returns the top handler for the v2 REST APIs
+func NewHandler(stream *MetricsStream) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v2/metrics/stream", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handleMetricsStream(stream, rw, r)
+	})
+
+	return mux
+}
@@ -0,0 +1,118 @@
+package v2
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// StreamDescription is a short description for the metrics stream output.
+const StreamDescription = "REST API Metrics Stream"
+
+// subscriberBufferSize is the number of sample batches that are buffered for
+// every subscriber. If a subscriber can't keep up, newer batches are dropped.
+const subscriberBufferSize = 100
+
+// MetricsStream is an output that fans out all of the metric samples of the
+// test run to the subscribed REST API clients, so they can receive them in
+// real time. It doesn't do anything if there aren't any subscribers.
+type MetricsStream struct {
+	logger logrus.FieldLogger
+
+	mx          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	stopped     bool
+}
+
+type subscriber struct {
+	samples chan []metrics.Sample
+	done    chan struct{}
+	dropped uint64
+}
+
+var _ output.Output = &MetricsStream{}
+
+// NewMetricsStream returns a new MetricsStream without any subscribers.
+func NewMetricsStream(logger logrus.FieldLogger) *MetricsStream {
+	return &MetricsStream{
+		logger:      logger.WithField("component", "metrics-stream"),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Description returns a human-readable description of the output.
+func (ms *MetricsStream) Description() string {
+	return StreamDescription
+}
+
+// Start doesn't do anything, the samples are sent only to the subscribers.
+func (ms *MetricsStream) Start() error {
+	return nil
+}
+
+// Stop notifies all of the subscribers that there won't be any more samples.
+func (ms *MetricsStream) Stop() error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+
+	ms.stopped = true
+	for s := range ms.subscribers {
+		close(s.done)
+		delete(ms.subscribers, s)
+	}
+	return nil
+}
+
+// AddMetricSamples sends the samples to all of the subscribers, without
+// blocking if any of them are slow.
+func (ms *MetricsStream) AddMetricSamples(containers []metrics.SampleContainer) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	if len(ms.subscribers) == 0 {
+		return
+	}
+
+	var samples []metrics.Sample
+	for _, sc := range containers {
+		samples = append(samples, sc.GetSamples()...)
+	}
+	if len(samples) == 0 {
+		return
+	}
+	for s := range ms.subscribers {
+		select {
+		case s.samples <- samples:
+		default:
+			if atomic.AddUint64(&s.dropped, 1) == 1 {
+				ms.logger.Warn("A metrics stream client can't keep up with the samples, some of them will be dropped")
+			}
+		}
+	}
+}
+
+// subscribe returns the channel that receives all new metric samples and one
+// that is closed when the stream is stopped, as well as the function that has
+// to be called to unsubscribe.
+func (ms *MetricsStream) subscribe() (samples <-chan []metrics.Sample, done <-chan struct{}, unsubscribe func()) {
+	s := &subscriber{
+		samples: make(chan []metrics.Sample, subscriberBufferSize),
+		done:    make(chan struct{}),
+	}
+
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	if ms.stopped {
+		close(s.done)
+		return s.samples, s.done, func() {}
+	}
+	ms.subscribers[s] = struct{}{}
+	return s.samples, s.done, func() {
+		ms.mx.Lock()
+		defer ms.mx.Unlock()
+		delete(ms.subscribers, s)
+	}
+}
@@ -0,0 +1,216 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+const defaultAggregationInterval = time.Second
+
+// streamOptions are the query parameters of the metrics stream endpoint.
+type streamOptions struct {
+	// Whether to send the aggregated values of the samples in every interval,
+	// instead of the samples themselves.
+	aggregated bool
+	interval   time.Duration
+
+	// Only the samples of these metrics are sent, unless it's empty.
+	metrics map[string]bool
+
+	// The aggregated values are broken down by the values of this tag, e.g.
+	// "scenario" or "group", if it's not empty.
+	breakdown string
+}
+
+func parseStreamOptions(query url.Values) (streamOptions, error) {
+	opts := streamOptions{
+		interval:  defaultAggregationInterval,
+		breakdown: query.Get("breakdown"),
+	}
+
+	switch mode := query.Get("mode"); mode {
+	case "", "samples":
+	case "aggregated":
+		opts.aggregated = true
+	default:
+		return opts, fmt.Errorf("invalid mode '%s', it has to be either 'samples' or 'aggregated'", mode)
+	}
+
+	if interval := query.Get("interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return opts, fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < 100*time.Millisecond {
+			return opts, errors.New("the interval can't be less than 100ms")
+		}
+		opts.interval = d
+	}
+	if opts.breakdown != "" && !opts.aggregated {
+		return opts, errors.New("the breakdown can be used only with the aggregated mode")
+	}
+
+	if names := query["metric"]; len(names) > 0 {
+		opts.metrics = make(map[string]bool, len(names))
+		for _, name := range names {
+			opts.metrics[name] = true
+		}
+	}
+	return opts, nil
+}
+
+// sampleEvent is the data of the "samples" events.
+type sampleEvent struct {
+	Metric string          `json:"metric"`
+	Type   string          `json:"type"`
+	Time   time.Time       `json:"time"`
+	Value  float64         `json:"value"`
+	Tags   *metrics.TagSet `json:"tags"`
+}
+
+// aggregateEvent is the data of the "aggregates" events.
+type aggregateEvent struct {
+	Time    time.Time         `json:"time"`
+	Metrics []metricAggregate `json:"metrics"`
+}
+
+type metricAggregate struct {
+	Metric string             `json:"metric"`
+	Type   string             `json:"type"`
+	Tags   map[string]string  `json:"tags,omitempty"`
+	Values map[string]float64 `json:"values"`
+}
+
+type aggregateKey struct {
+	metric    string
+	breakdown string
+}
+
+// handleMetricsStream sends the metric samples as server-sent events, until
+// the client disconnects or the test run finishes.
+func handleMetricsStream(stream *MetricsStream, rw http.ResponseWriter, r *http.Request) {
+	opts, err := parseStreamOptions(r.URL.Query())
+	if err != nil {
+		apiError(rw, "Invalid query", err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		apiError(rw, "Streaming error", "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+
+	samplesCh, done, unsubscribe := stream.subscribe()
+	defer unsubscribe()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	writeEvent := func(event string, data interface{}) bool {
+		b, encErr := json.Marshal(data)
+		if encErr != nil {
+			stream.logger.WithError(encErr).Error("Couldn't encode a metrics stream event")
+			return true
+		}
+		if _, writeErr := fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event, b); writeErr != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	var (
+		sinks   = make(map[aggregateKey]metrics.Sink)
+		types   = make(map[string]metrics.MetricType)
+		tickerC <-chan time.Time
+	)
+	if opts.aggregated {
+		ticker := time.NewTicker(opts.interval)
+		defer ticker.Stop()
+		tickerC = ticker.C
+	}
+	flushAggregates := func() bool {
+		if len(sinks) == 0 {
+			return true
+		}
+		event := aggregateEvent{Time: time.Now(), Metrics: make([]metricAggregate, 0, len(sinks))}
+		for key, sink := range sinks {
+			aggregate := metricAggregate{
+				Metric: key.metric,
+				Type:   types[key.metric].String(),
+				Values: sink.Format(opts.interval),
+			}
+			if opts.breakdown != "" {
+				aggregate.Tags = map[string]string{opts.breakdown: key.breakdown}
+			}
+			event.Metrics = append(event.Metrics, aggregate)
+		}
+		sort.Slice(event.Metrics, func(i, j int) bool {
+			mi, mj := event.Metrics[i], event.Metrics[j]
+			if mi.Metric != mj.Metric {
+				return mi.Metric < mj.Metric
+			}
+			return mi.Tags[opts.breakdown] < mj.Tags[opts.breakdown]
+		})
+		sinks = make(map[aggregateKey]metrics.Sink)
+		return writeEvent("aggregates", event)
+	}
+
+	for {
+		select {
+		case samples := <-samplesCh:
+			events := make([]sampleEvent, 0, len(samples))
+			for _, s := range samples {
+				if opts.metrics != nil && !opts.metrics[s.Metric.Name] {
+					continue
+				}
+				if !opts.aggregated {
+					events = append(events, sampleEvent{
+						Metric: s.Metric.Name,
+						Type:   s.Metric.Type.String(),
+						Time:   s.Time,
+						Value:  s.Value,
+						Tags:   s.Tags,
+					})
+					continue
+				}
+				key := aggregateKey{metric: s.Metric.Name}
+				if opts.breakdown != "" {
+					key.breakdown, _ = s.Tags.Get(opts.breakdown)
+				}
+				sink, ok := sinks[key]
+				if !ok {
+					sink = metrics.NewSink(s.Metric.Type)
+					sinks[key] = sink
+					types[key.metric] = s.Metric.Type
+				}
+				sink.Add(s)
+			}
+			if len(events) > 0 && !writeEvent("samples", events) {
+				return
+			}
+		case <-tickerC:
+			if !flushAggregates() {
+				return
+			}
+		case <-done:
+			if opts.aggregated {
+				flushAggregates()
+			}
+			writeEvent("end", struct{}{})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+type testStreamEvent struct {
+	name string
+	data string
+}
+
+// startTestStream connects to the metrics stream with the given query and
+// returns a function that reads the next event.
+func startTestStream(t *testing.T, stream *MetricsStream, query string) func() testStreamEvent {
+	srv := httptest.NewServer(NewHandler(stream))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v2/metrics/stream?"+query, nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req) //nolint:bodyclose // closed in the cleanup
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = res.Body.Close() })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	require.Eventually(t, func() bool {
+		stream.mx.RLock()
+		defer stream.mx.RUnlock()
+		return len(stream.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	reader := bufio.NewReader(res.Body)
+	return func() testStreamEvent {
+		var event testStreamEvent
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return event
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+}
+
+func getTestSamples(registry *metrics.Registry, values map[string]float64) metrics.Samples {
+	counter := registry.MustNewMetric("test_counter", metrics.Counter)
+	trend := registry.MustNewMetric("test_trend", metrics.Trend)
+	var samples metrics.Samples
+	for scenario, value := range values {
+		tags := registry.RootTagSet().With("scenario", scenario)
+		samples = append(samples,
+			metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: counter, Tags: tags}, Time: time.Now(), Value: value},
+			metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: trend, Tags: tags}, Time: time.Now(), Value: value},
+		)
+	}
+	return samples
+}
+
+func TestMetricsStreamSamples(t *testing.T) {
+	t.Parallel()
+
+	stream := NewMetricsStream(testutils.NewLogger(t))
+	next := startTestStream(t, stream, "metric=test_counter")
+
+	registry := metrics.NewRegistry()
+	stream.AddMetricSamples([]metrics.SampleContainer{getTestSamples(registry, map[string]float64{"a": 5})})
+
+	event := next()
+	assert.Equal(t, "samples", event.name)
+	var samples []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(event.data), &samples))
+	require.Len(t, samples, 1)
+	assert.Equal(t, "test_counter", samples[0]["metric"])
+	assert.Equal(t, "counter", samples[0]["type"])
+	assert.Equal(t, 5.0, samples[0]["value"])
+	assert.Equal(t, map[string]interface{}{"scenario": "a"}, samples[0]["tags"])
+
+	require.NoError(t, stream.Stop())
+	assert.Equal(t, "end", next().name)
+}
+
+func TestMetricsStreamAggregated(t *testing.T) {
+	t.Parallel()
+
+	stream := NewMetricsStream(testutils.NewLogger(t))
+	next := startTestStream(t, stream, "mode=aggregated&interval=200ms&breakdown=scenario")
+
+	registry := metrics.NewRegistry()
+	stream.AddMetricSamples([]metrics.SampleContainer{
+		getTestSamples(registry, map[string]float64{"a": 1, "b": 2}),
+		getTestSamples(registry, map[string]float64{"a": 3}),
+	})
+
+	event := next()
+	assert.Equal(t, "aggregates", event.name)
+	var aggregates aggregateEvent
+	require.NoError(t, json.Unmarshal([]byte(event.data), &aggregates))
+	require.Len(t, aggregates.Metrics, 4)
+
+	counterA := aggregates.Metrics[0]
+	assert.Equal(t, "test_counter", counterA.Metric)
+	assert.Equal(t, "counter", counterA.Type)
+	assert.Equal(t, map[string]string{"scenario": "a"}, counterA.Tags)
+	assert.Equal(t, 4.0, counterA.Values["count"])
+	assert.Equal(t, 2.0, aggregates.Metrics[1].Values["count"])
+
+	trendA := aggregates.Metrics[2]
+	assert.Equal(t, "test_trend", trendA.Metric)
+	assert.Equal(t, map[string]string{"scenario": "a"}, trendA.Tags)
+	assert.Equal(t, 3.0, trendA.Values["max"])
+	assert.Equal(t, 2.0, trendA.Values["avg"])
+}
+
+func TestMetricsStreamInvalidQuery(t *testing.T) {
+	t.Parallel()
+
+	stream := NewMetricsStream(testutils.NewLogger(t))
+	for _, query := range []string{"mode=other", "interval=1ms", "interval=abc", "breakdown=scenario"} {
+		rw := httptest.NewRecorder()
+		NewHandler(stream).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v2/metrics/stream?"+query, nil))
+		res := rw.Result()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		assert.NoError(t, res.Body.Close())
+	}
+}
+
+func TestMetricsStreamSlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	stream := NewMetricsStream(testutils.NewLogger(t))
+	registry := metrics.NewRegistry()
+	containers := []metrics.SampleContainer{getTestSamples(registry, map[string]float64{"a": 1})}
+	stream.AddMetricSamples(containers) // no subscribers
+
+	samples, done, unsubscribe := stream.subscribe()
+	for i := 0; i < subscriberBufferSize+10; i++ {
+		stream.AddMetricSamples(containers)
+	}
+	assert.Len(t, samples, subscriberBufferSize)
+
+	unsubscribe()
+	stream.AddMetricSamples(containers)
+	assert.Len(t, samples, subscriberBufferSize)
+	require.NoError(t, stream.Stop())
+	select {
+	case <-done:
+		t.Fatal("the unsubscribed client was notified about the stop")
+	default:
+	}
+
+	_, done, _ = stream.subscribe()
+	select {
+	case <-done:
+	default:
+		t.Fatal("subscribing to a stopped stream should finish immediately")
+	}
+}
@@ -43,6 +43,14 @@ type Config struct {
 	// This is how many concurrent pushes will be done at the same time to the cloud
 	MetricPushConcurrency null.Int `json:"metricPushConcurrency" envconfig:"K6_CLOUD_METRIC_PUSH_CONCURRENCY"`
 
+	// The max number of batches of metrics kept locally while the cloud can't be reached,
+	// which are sent when it's reachable again. The oldest ones are dropped when it's full.
+	MetricBufferSize null.Int `json:"metricBufferSize" envconfig:"K6_CLOUD_METRIC_BUFFER_SIZE"`
+
+	// The time interval between the checks of the test run status in the cloud, for the
+	// local test runs to be stopped from the cloud. A zero value disables the checks.
+	TestRunStatusInterval types.NullDuration `json:"testRunStatusInterval" envconfig:"K6_CLOUD_TEST_RUN_STATUS_INTERVAL"`
+
 	// Indicates whether to send traces to the k6 Insights backend service.
 	TracesEnabled null.Bool `json:"tracesEnabled" envconfig:"K6_CLOUD_TRACES_ENABLED"`
 
@@ -168,6 +176,8 @@ func NewConfig() Config {
 		WebAppURL:             null.NewString("https://app.k6.io", false),
 		MetricPushInterval:    types.NewNullDuration(1*time.Second, false),
 		MetricPushConcurrency: null.NewInt(1, false),
+		MetricBufferSize:      null.NewInt(100, false),
+		TestRunStatusInterval: types.NewNullDuration(10*time.Second, false),
 
 		TracesEnabled:         null.NewBool(true, false),
 		TracesHost:            null.NewString("grpc-k6-api-prod-prod-us-east-0.grafana.net:443", false),
@@ -264,6 +274,12 @@ func (c Config) Apply(cfg Config) Config {
 	if cfg.MetricPushConcurrency.Valid {
 		c.MetricPushConcurrency = cfg.MetricPushConcurrency
 	}
+	if cfg.MetricBufferSize.Valid {
+		c.MetricBufferSize = cfg.MetricBufferSize
+	}
+	if cfg.TestRunStatusInterval.Valid {
+		c.TestRunStatusInterval = cfg.TestRunStatusInterval
+	}
 	if cfg.TracesEnabled.Valid {
 		c.TracesEnabled = cfg.TracesEnabled
 	}
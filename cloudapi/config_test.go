@@ -39,6 +39,8 @@ func TestConfigApply(t *testing.T) {
 		MaxTimeSeriesInBatch:            null.NewInt(3, true),
 		MetricPushInterval:              types.NewNullDuration(1*time.Second, true),
 		MetricPushConcurrency:           null.NewInt(3, true),
+		MetricBufferSize:                null.NewInt(7, true),
+		TestRunStatusInterval:           types.NewNullDuration(4*time.Second, true),
 		TracesEnabled:                   null.NewBool(true, true),
 		TracesHost:                      null.NewString("TracesHost", true),
 		TracesPushInterval:              types.NewNullDuration(10*time.Second, true),
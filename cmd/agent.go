@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/distributed"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/loader"
+	"go.k6.io/k6/output"
+	"go.k6.io/k6/secretsource"
+)
+
+// cmdAgent handles the `k6 agent` sub-command
+type cmdAgent struct {
+	gs  *state.GlobalState
+	run *cmdRun
+}
+
+func (c *cmdAgent) loadConfiguredTest(
+	cmd *cobra.Command, args []string,
+) (*loadedAndConfiguredTest, execution.Controller, error) {
+	// The agents use the same token and TLS options as the clients of the
+	// REST API, since the coordinator uses the ones of its server.
+	tlsConfig, err := getAPIClientTLSConfig(c.gs)
+	if err != nil {
+		return nil, nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+	address, httpClient := args[0], http.DefaultClient
+	if tlsConfig != nil {
+		address = withHTTPSScheme(address)
+		httpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	}
+
+	c.gs.Logger.Debugf("Registering with the coordinator at '%s'...", address)
+	controller, resp, err := distributed.Register(c.gs.Ctx, httpClient, address, c.gs.Flags.APIToken, c.gs.Logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.gs.Logger.Debugf(
+		"Registered as instance %d with execution segment '%s'", resp.InstanceID, resp.ExecutionSegment,
+	)
+	c.run.extraOutputs = []output.Output{controller.NewMetricsOutput()}
+
+	segment, err := lib.NewExecutionSegmentFromString(resp.ExecutionSegment)
+	if err != nil {
+		return nil, nil, err
+	}
+	sequence, err := lib.NewExecutionSegmentSequenceFromString(resp.ExecutionSegmentSequence)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	src := &loader.SourceData{
+		URL:  &url.URL{Scheme: "file", Path: "/archive.tar"},
+		Data: resp.Archive,
+	}
+	// The thresholds and the end-of-test summary are handled by the
+	// coordinator, since they need the metrics of all instances.
+	runtimeOptions := lib.RuntimeOptions{
+		TestType:     null.StringFrom(testTypeArchive),
+		NoThresholds: null.BoolFrom(true),
+		NoSummary:    null.BoolFrom(true),
+		TracesOutput: null.StringFrom("none"),
+		Env:          make(map[string]string),
+	}
+	test, err := loadTest(c.gs, args[0], src, loader.CreateFilesystems(c.gs.FS), pwd, runtimeOptions, secretsource.NewManager(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	configuredTest, err := test.consolidateDeriveAndValidateConfig(c.gs, cmd,
+		func(_ *pflag.FlagSet) (Config, error) {
+			return Config{Options: lib.Options{
+				ExecutionSegment:         segment,
+				ExecutionSegmentSequence: &sequence,
+			}}, nil
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return configuredTest, controller, nil
+}
+
+func getCmdAgent(gs *state.GlobalState) *cobra.Command {
+	c := &cmdAgent{gs: gs}
+	c.run = &cmdRun{
+		gs:                 gs,
+		loadConfiguredTest: c.loadConfiguredTest,
+	}
+
+	exampleText := getExampleText(gs, `
+  # Start a coordinator for 2 instances.
+  {{.}} coordinator --instance-count 2 script.js
+
+  # Start the 2 agents, on the same or on different machines.
+  {{.}} agent localhost:6566
+  {{.}} agent localhost:6566`[1:])
+
+	agentCmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Run a part of a distributed test",
+		Long: `Run a part of a distributed test.
+
+The agent registers with the coordinator at the given address and receives the
+test and its execution segment from it. It waits for all other agents before
+every stage of the test and sends its metrics to the coordinator, which
+evaluates the thresholds and shows the end-of-test summary.
+
+The --api-token and the --api-tls-* options of the coordinator's server have
+to be matched by the agents, like by the clients of the REST API.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should be the address of the coordinator"),
+		RunE:    c.run.run,
+	}
+
+	return agentCmd
+}
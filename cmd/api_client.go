@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.k6.io/k6/api"
+	"go.k6.io/k6/api/v1/client"
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// readAPITLSFiles reads the certificate, key and CA files from the TLS flags
+// of the REST API, the ones that weren't set are left empty.
+func readAPITLSFiles(gs *state.GlobalState) (cert, key, ca []byte, err error) {
+	files := []struct {
+		flag, path string
+		data       *[]byte
+	}{
+		{"api-tls-cert", gs.Flags.APITLSCert, &cert},
+		{"api-tls-key", gs.Flags.APITLSKey, &key},
+		{"api-tls-ca", gs.Flags.APITLSCA, &ca},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if *f.data, err = fsext.ReadFile(gs.FS, f.path); err != nil {
+			return nil, nil, nil, fmt.Errorf("couldn't read the --%s file: %w", f.flag, err)
+		}
+	}
+	return cert, key, ca, nil
+}
+
+// getAPIServerConfig returns the TLS and authentication options of the REST
+// API server from the global flags.
+func getAPIServerConfig(gs *state.GlobalState) (api.ServerConfig, error) {
+	cert, key, ca, err := readAPITLSFiles(gs)
+	if err != nil {
+		return api.ServerConfig{}, err
+	}
+	return api.ServerConfig{
+		Token:    gs.Flags.APIToken,
+		TLSCert:  cert,
+		TLSKey:   key,
+		ClientCA: ca,
+	}, nil
+}
+
+// getAPIClientTLSConfig returns the TLS config of the clients of the REST API
+// server and of the coordinator from the global flags, or nil if none of the
+// TLS options are set and the server should be contacted over plaintext HTTP.
+func getAPIClientTLSConfig(gs *state.GlobalState) (*tls.Config, error) {
+	cert, key, ca, err := readAPITLSFiles(gs)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil && key == nil && ca == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if ca != nil {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no valid certificates were found in the --api-tls-ca file")
+		}
+	}
+	if cert != nil || key != nil {
+		clientCert, certErr := tls.X509KeyPair(cert, key)
+		if certErr != nil {
+			return nil, fmt.Errorf("invalid REST API client certificate: %w", certErr)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return tlsConfig, nil
+}
+
+// withHTTPSScheme returns the address with the https:// scheme, replacing the
+// http:// one if it has it.
+func withHTTPSScheme(address string) string {
+	if strings.HasPrefix(address, "https://") {
+		return address
+	}
+	return "https://" + strings.TrimPrefix(address, "http://")
+}
+
+// newAPIClient returns a client for the REST API server at the --address,
+// that uses the token and the TLS options from the global flags. If any of
+// the TLS options are set, the server is contacted over HTTPS.
+func newAPIClient(gs *state.GlobalState) (*client.Client, error) {
+	tlsConfig, err := getAPIClientTLSConfig(gs)
+	if err != nil {
+		return nil, err
+	}
+	options := []client.Option{client.WithAuthToken(gs.Flags.APIToken)}
+	if tlsConfig == nil {
+		return client.New(gs.Flags.Address, options...)
+	}
+
+	options = append(options, client.WithHTTPClient(&http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}))
+	return client.New(withHTTPSScheme(gs.Flags.Address), options...)
+}
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"bytes"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
 )
 
 // cmdArchive handles the `k6 archive` sub-command
@@ -13,6 +17,7 @@ type cmdArchive struct {
 
 	archiveOut     string
 	excludeEnvVars bool
+	encrypt        string
 }
 
 func (c *cmdArchive) run(cmd *cobra.Command, args []string) error {
@@ -31,26 +36,61 @@ func (c *cmdArchive) run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var secret []byte
+	if c.encrypt != "" {
+		if secret, err = getArchiveEncryptionSecret(c.gs, c.encrypt); err != nil {
+			return err
+		}
+	}
+
 	// Archive.
 	arc := testRunState.Runner.MakeArchive()
-	f, err := c.gs.FS.Create(c.archiveOut)
-	if err != nil {
-		return err
-	}
 
 	if c.excludeEnvVars {
 		c.gs.Logger.Debug("environment variables will be excluded from the archive")
 
 		arc.Env = nil
 	}
+	redactSecretEnvVars(c.gs, arc, test.preInitState.RuntimeOptions.SecretEnvVars)
+
+	buf := &bytes.Buffer{}
+	if err = arc.Write(buf); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if secret != nil {
+		if data, err = lib.EncryptArchive(data, secret); err != nil {
+			return err
+		}
+	}
 
-	err = arc.Write(f)
+	f, err := c.gs.FS.Create(c.archiveOut)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
 	if cerr := f.Close(); err == nil && cerr != nil {
 		err = cerr
 	}
 	return err
 }
 
+// redactSecretEnvVars removes the environment variables of the secret sources
+// from the archive, they have to be given again when it's run.
+func redactSecretEnvVars(gs *state.GlobalState, arc *lib.Archive, secrets []string) {
+	redacted := make([]string, 0, len(secrets))
+	for _, name := range secrets {
+		if _, ok := arc.Env[name]; ok {
+			delete(arc.Env, name)
+			redacted = append(redacted, name)
+		}
+	}
+	if len(redacted) > 0 {
+		gs.Logger.Infof("The secret environment variables %s were redacted from the archive, "+
+			"use --secret-source again when running it", strings.Join(redacted, ", "))
+	}
+}
+
 func (c *cmdArchive) flagSet() *pflag.FlagSet {
 	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
 	flags.SortFlags = false
@@ -64,6 +104,10 @@ func (c *cmdArchive) flagSet() *pflag.FlagSet {
 		false,
 		"do not embed any environment variables (either from --env or the actual environment) in the archive metadata",
 	)
+	flags.StringVar(&c.encrypt, "archive-encrypt", "",
+		"encrypt the archive with AES-256-GCM, with the `passphrase` of the "+archivePassphraseEnvVar+
+			" environment variable or with key-file=<path>; set "+archivePassphraseEnvVar+" or "+
+			archiveKeyFileEnvVar+" to run it")
 
 	return flags
 }
@@ -79,14 +123,18 @@ func getCmdArchive(gs *state.GlobalState) *cobra.Command {
   {{.}} archive -u 10 -d 10s -O myarchive.tar script.js
   
   # Run the resulting archive.
-  {{.}} run myarchive.tar`[1:])
+  {{.}} run myarchive.tar
+
+  # Verify the resulting archive.
+  {{.}} archive verify myarchive.tar`[1:])
 
 	archiveCmd := &cobra.Command{
 		Use:   "archive",
 		Short: "Create an archive",
 		Long: `Create an archive.
 
-An archive is a fully self-contained test run, and can be executed identically elsewhere.`,
+An archive is a fully self-contained test run, and can be executed identically elsewhere.
+The archives are reproducible, and have a manifest with the checksums of their files.`,
 		Example: exampleText,
 		Args:    cobra.ExactArgs(1),
 		RunE:    c.run,
@@ -94,6 +142,7 @@ An archive is a fully self-contained test run, and can be executed identically e
 
 	archiveCmd.Flags().SortFlags = false
 	archiveCmd.Flags().AddFlagSet(c.flagSet())
+	archiveCmd.AddCommand(getCmdArchiveVerify(gs))
 
 	return archiveCmd
 }
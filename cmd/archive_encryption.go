@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// The environment variables with the secret of the encrypted archives.
+const (
+	archivePassphraseEnvVar = "K6_ARCHIVE_PASSPHRASE"
+	archiveKeyFileEnvVar    = "K6_ARCHIVE_KEY_FILE"
+)
+
+// getArchiveEncryptionSecret returns the secret of the --archive-encrypt flag,
+// either the passphrase of the K6_ARCHIVE_PASSPHRASE environment variable, or
+// the content of the key file of key-file=<path>.
+func getArchiveEncryptionSecret(gs *state.GlobalState, spec string) ([]byte, error) {
+	if spec == "passphrase" {
+		return getArchivePassphrase(gs)
+	}
+	if kind, path, _ := strings.Cut(spec, "="); kind == "key-file" && path != "" {
+		return readArchiveKeyFile(gs, path)
+	}
+	return nil, fmt.Errorf("invalid --archive-encrypt value '%s', it should be passphrase or key-file=<path>", spec)
+}
+
+// decryptArchiveSource decrypts an encrypted archive with the key file of the
+// K6_ARCHIVE_KEY_FILE environment variable, or with the passphrase of the
+// K6_ARCHIVE_PASSPHRASE one.
+func decryptArchiveSource(gs *state.GlobalState, data []byte) ([]byte, error) {
+	var secret []byte
+	var err error
+	if path := gs.Env[archiveKeyFileEnvVar]; path != "" {
+		secret, err = readArchiveKeyFile(gs, path)
+	} else {
+		secret, err = getArchivePassphrase(gs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("the archive is encrypted: %w", err)
+	}
+	return lib.DecryptArchive(data, secret)
+}
+
+func getArchivePassphrase(gs *state.GlobalState) ([]byte, error) {
+	passphrase := gs.Env[archivePassphraseEnvVar]
+	if passphrase == "" {
+		return nil, errors.New("the passphrase should be set with the " + archivePassphraseEnvVar +
+			" environment variable, or a key file with " + archiveKeyFileEnvVar)
+	}
+	return []byte(passphrase), nil
+}
+
+func readArchiveKeyFile(gs *state.GlobalState, path string) ([]byte, error) {
+	if !filepath.IsAbs(path) {
+		pwd, err := gs.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(pwd, path)
+	}
+	key, err := fsext.ReadFile(gs.FS, path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the key file of the archive: %w", err)
+	}
+	return key, nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -149,3 +150,159 @@ func TestArchiveNotContainsEnv(t *testing.T) {
 	require.NoError(t, json.Unmarshal(data, &metadata))
 	require.Len(t, metadata.Env, 0)
 }
+
+func TestArchiveVerify(t *testing.T) {
+	t.Parallel()
+
+	fileName := "script.js"
+	testScript := []byte(`export default function () {}`)
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, fileName), testScript, 0o644))
+
+	ts.CmdArgs = []string{"k6", "archive", fileName}
+	newRootCommand(ts.GlobalState).execute()
+	first, err := fsext.ReadFile(ts.FS, "archive.tar")
+	require.NoError(t, err)
+
+	ts.CmdArgs = []string{"k6", "archive", "-O", "again.tar", fileName}
+	newRootCommand(ts.GlobalState).execute()
+	second, err := fsext.ReadFile(ts.FS, "again.tar")
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	ts.CmdArgs = []string{"k6", "archive", "verify", "archive.tar"}
+	newRootCommand(ts.GlobalState).execute()
+	require.Contains(t, ts.Stdout.String(), "The 2 files of 'archive.tar' match its manifest")
+}
+
+func TestArchiveVerifyTampered(t *testing.T) {
+	t.Parallel()
+
+	fileName := "script.js"
+	testScript := []byte(`export default function () { return "original" }`)
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, fileName), testScript, 0o644))
+
+	ts.CmdArgs = []string{"k6", "archive", fileName}
+	newRootCommand(ts.GlobalState).execute()
+
+	// the script is changed in place, with the same length
+	data, err := fsext.ReadFile(ts.FS, "archive.tar")
+	require.NoError(t, err)
+	tampered := bytes.Replace(data, []byte(`"original"`), []byte(`"tampered"`), 1)
+	require.NotEqual(t, data, tampered)
+	require.NoError(t, fsext.WriteFile(ts.FS, "archive.tar", tampered, 0o644))
+
+	ts.CmdArgs = []string{"k6", "archive", "verify", "archive.tar"}
+	ts.ExpectedExitCode = -1
+	newRootCommand(ts.GlobalState).execute()
+	require.Contains(t, ts.Stderr.String(), "the archive doesn't match its manifest")
+}
+
+func TestArchiveEncrypted(t *testing.T) {
+	t.Parallel()
+
+	fileName := "script.js"
+	testScript := []byte(`export default function () { return "plain text" }`)
+
+	t.Run("passphrase", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, fileName), testScript, 0o644))
+		ts.Env["K6_ARCHIVE_PASSPHRASE"] = "correct horse"
+		ts.CmdArgs = []string{"k6", "archive", "--archive-encrypt", "passphrase", fileName}
+		newRootCommand(ts.GlobalState).execute()
+
+		data, err := fsext.ReadFile(ts.FS, "archive.tar")
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "plain text")
+
+		ts.CmdArgs = []string{"k6", "archive", "verify", "archive.tar"}
+		newRootCommand(ts.GlobalState).execute()
+		require.Contains(t, ts.Stdout.String(), "The 2 files of 'archive.tar' match its manifest")
+
+		// the archive is run from another directory
+		ts = tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), data, 0o644))
+		ts.Env["K6_ARCHIVE_PASSPHRASE"] = "correct horse"
+		ts.CmdArgs = []string{"k6", "inspect", "archive.tar"}
+		newRootCommand(ts.GlobalState).execute()
+		require.Contains(t, ts.Stdout.String(), `"paused": null`)
+
+		ts = tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), data, 0o644))
+		ts.Env["K6_ARCHIVE_PASSPHRASE"] = "wrong horse"
+		ts.CmdArgs = []string{"k6", "inspect", "archive.tar"}
+		ts.ExpectedExitCode = -1
+		newRootCommand(ts.GlobalState).execute()
+		require.Contains(t, ts.Stderr.String(), "couldn't decrypt the archive, the passphrase or the key file is wrong")
+	})
+
+	t.Run("key file", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, fileName), testScript, 0o644))
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.key"), []byte("0123456789"), 0o600))
+		ts.CmdArgs = []string{"k6", "archive", "--archive-encrypt", "key-file=archive.key", fileName}
+		newRootCommand(ts.GlobalState).execute()
+
+		ts.CmdArgs = []string{"k6", "archive", "verify", "archive.tar"}
+		ts.ExpectedExitCode = -1
+		newRootCommand(ts.GlobalState).execute()
+		require.Contains(t, ts.Stderr.String(), "the archive is encrypted: the passphrase should be set")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, fileName), testScript, 0o644))
+		ts.CmdArgs = []string{"k6", "archive", "--archive-encrypt", "key-file=", fileName}
+		ts.ExpectedExitCode = -1
+		newRootCommand(ts.GlobalState).execute()
+		require.Contains(t, ts.Stderr.String(), "invalid --archive-encrypt value 'key-file='")
+	})
+}
+
+func TestArchiveRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	fileName := "script.js"
+	testScript := []byte(`export default function () {}`)
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, fileName), testScript, 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "secrets.env"),
+		[]byte("# the API tokens\nAPI_TOKEN=abc=def\n\nOTHER_TOKEN=ghi\n"), 0o600))
+	ts.Env["DB_PASSWORD"] = "hunter2"
+
+	ts.CmdArgs = []string{
+		"k6", "--env", "ENV1=lorem", "--env", "OTHER_TOKEN=overridden", "archive",
+		"--secret-source", "file=secrets.env", "--secret-source", "env=DB_PASSWORD", fileName,
+	}
+	newRootCommand(ts.GlobalState).execute()
+	require.Contains(t, ts.Stderr.String(),
+		"The secret environment variables API_TOKEN, OTHER_TOKEN, DB_PASSWORD were redacted from the archive")
+	require.NoError(t, testutils.Untar(t, ts.FS, "archive.tar", "tmp/"))
+
+	data, err := fsext.ReadFile(ts.FS, "tmp/metadata.json")
+	require.NoError(t, err)
+	metadata := struct {
+		Env map[string]string
+	}{}
+	require.NoError(t, json.Unmarshal(data, &metadata))
+	require.Equal(t, map[string]string{"ENV1": "lorem"}, metadata.Env)
+}
+
+func TestArchiveInvalidSecretSource(t *testing.T) {
+	t.Parallel()
+
+	fileName := "script.js"
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, fileName), []byte(`export default function () {}`), 0o644))
+	ts.CmdArgs = []string{"k6", "archive", "--secret-source", "env=MISSING", fileName}
+	ts.ExpectedExitCode = -1
+	newRootCommand(ts.GlobalState).execute()
+	require.Contains(t, ts.Stderr.String(), "the environment variable 'MISSING' of the secret source isn't set")
+}
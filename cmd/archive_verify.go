@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// cmdArchiveVerify handles the `k6 archive verify` sub-command
+type cmdArchiveVerify struct {
+	gs *state.GlobalState
+}
+
+func (c *cmdArchiveVerify) run(_ *cobra.Command, args []string) error {
+	data, err := fsext.ReadFile(c.gs.FS, args[0])
+	if err != nil {
+		return err
+	}
+	if lib.IsEncryptedArchive(data) {
+		if data, err = decryptArchiveSource(c.gs, data); err != nil {
+			return err
+		}
+	}
+
+	manifest, err := lib.VerifyArchive(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("couldn't verify the archive '%s': %w", args[0], err)
+	}
+	for _, file := range manifest.Files {
+		c.gs.Logger.Debugf("Verified '%s' (sha256:%s)", file.Path, file.SHA256)
+	}
+	printToStdout(c.gs, fmt.Sprintf("The %d files of '%s' match its manifest\n", len(manifest.Files), args[0]))
+	return nil
+}
+
+func getCmdArchiveVerify(gs *state.GlobalState) *cobra.Command {
+	c := &cmdArchiveVerify{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Verify an archive before running it.
+  {{.}} archive verify myarchive.tar`[1:])
+
+	return &cobra.Command{
+		Use:   "verify [archive]",
+		Short: "Verify the content of an archive",
+		Long: `Verify the content of an archive.
+
+The archives have a manifest with the SHA-256 checksum of each of their files,
+the command fails if a file was changed, removed or added since the archive was
+created.`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.run,
+	}
+}
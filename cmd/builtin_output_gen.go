@@ -7,11 +7,11 @@ import (
 	"strings"
 )
 
-const _builtinOutputName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsd"
+const _builtinOutputName = "clickhousecloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkaopentelemetryopentelemetry-tracesparquetprometheusstatsdtimescaledb"
 
-var _builtinOutputIndex = [...]uint8{0, 5, 8, 15, 41, 49, 53, 58, 64}
+var _builtinOutputIndex = [...]uint8{0, 10, 15, 18, 25, 51, 59, 63, 68, 81, 101, 108, 118, 124, 135}
 
-const _builtinOutputLowerName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsd"
+const _builtinOutputLowerName = "clickhousecloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkaopentelemetryopentelemetry-tracesparquetprometheusstatsdtimescaledb"
 
 func (i builtinOutput) String() string {
 	if i >= builtinOutput(len(_builtinOutputIndex)-1) {
@@ -24,46 +24,70 @@ func (i builtinOutput) String() string {
 // Re-run the stringer command to generate them again.
 func _builtinOutputNoOp() {
 	var x [1]struct{}
-	_ = x[builtinOutputCloud-(0)]
-	_ = x[builtinOutputCSV-(1)]
-	_ = x[builtinOutputDatadog-(2)]
-	_ = x[builtinOutputExperimentalPrometheusRW-(3)]
-	_ = x[builtinOutputInfluxdb-(4)]
-	_ = x[builtinOutputJSON-(5)]
-	_ = x[builtinOutputKafka-(6)]
-	_ = x[builtinOutputStatsd-(7)]
+	_ = x[builtinOutputClickhouse-(0)]
+	_ = x[builtinOutputCloud-(1)]
+	_ = x[builtinOutputCSV-(2)]
+	_ = x[builtinOutputDatadog-(3)]
+	_ = x[builtinOutputExperimentalPrometheusRW-(4)]
+	_ = x[builtinOutputInfluxdb-(5)]
+	_ = x[builtinOutputJSON-(6)]
+	_ = x[builtinOutputKafka-(7)]
+	_ = x[builtinOutputOpentelemetry-(8)]
+	_ = x[builtinOutputOpentelemetryTraces-(9)]
+	_ = x[builtinOutputParquet-(10)]
+	_ = x[builtinOutputPrometheus-(11)]
+	_ = x[builtinOutputStatsd-(12)]
+	_ = x[builtinOutputTimescaledb-(13)]
 }
 
-var _builtinOutputValues = []builtinOutput{builtinOutputCloud, builtinOutputCSV, builtinOutputDatadog, builtinOutputExperimentalPrometheusRW, builtinOutputInfluxdb, builtinOutputJSON, builtinOutputKafka, builtinOutputStatsd}
+var _builtinOutputValues = []builtinOutput{builtinOutputClickhouse, builtinOutputCloud, builtinOutputCSV, builtinOutputDatadog, builtinOutputExperimentalPrometheusRW, builtinOutputInfluxdb, builtinOutputJSON, builtinOutputKafka, builtinOutputOpentelemetry, builtinOutputOpentelemetryTraces, builtinOutputParquet, builtinOutputPrometheus, builtinOutputStatsd, builtinOutputTimescaledb}
 
 var _builtinOutputNameToValueMap = map[string]builtinOutput{
-	_builtinOutputName[0:5]:        builtinOutputCloud,
-	_builtinOutputLowerName[0:5]:   builtinOutputCloud,
-	_builtinOutputName[5:8]:        builtinOutputCSV,
-	_builtinOutputLowerName[5:8]:   builtinOutputCSV,
-	_builtinOutputName[8:15]:       builtinOutputDatadog,
-	_builtinOutputLowerName[8:15]:  builtinOutputDatadog,
-	_builtinOutputName[15:41]:      builtinOutputExperimentalPrometheusRW,
-	_builtinOutputLowerName[15:41]: builtinOutputExperimentalPrometheusRW,
-	_builtinOutputName[41:49]:      builtinOutputInfluxdb,
-	_builtinOutputLowerName[41:49]: builtinOutputInfluxdb,
-	_builtinOutputName[49:53]:      builtinOutputJSON,
-	_builtinOutputLowerName[49:53]: builtinOutputJSON,
-	_builtinOutputName[53:58]:      builtinOutputKafka,
-	_builtinOutputLowerName[53:58]: builtinOutputKafka,
-	_builtinOutputName[58:64]:      builtinOutputStatsd,
-	_builtinOutputLowerName[58:64]: builtinOutputStatsd,
+	_builtinOutputName[0:10]:         builtinOutputClickhouse,
+	_builtinOutputLowerName[0:10]:    builtinOutputClickhouse,
+	_builtinOutputName[10:15]:        builtinOutputCloud,
+	_builtinOutputLowerName[10:15]:   builtinOutputCloud,
+	_builtinOutputName[15:18]:        builtinOutputCSV,
+	_builtinOutputLowerName[15:18]:   builtinOutputCSV,
+	_builtinOutputName[18:25]:        builtinOutputDatadog,
+	_builtinOutputLowerName[18:25]:   builtinOutputDatadog,
+	_builtinOutputName[25:51]:        builtinOutputExperimentalPrometheusRW,
+	_builtinOutputLowerName[25:51]:   builtinOutputExperimentalPrometheusRW,
+	_builtinOutputName[51:59]:        builtinOutputInfluxdb,
+	_builtinOutputLowerName[51:59]:   builtinOutputInfluxdb,
+	_builtinOutputName[59:63]:        builtinOutputJSON,
+	_builtinOutputLowerName[59:63]:   builtinOutputJSON,
+	_builtinOutputName[63:68]:        builtinOutputKafka,
+	_builtinOutputLowerName[63:68]:   builtinOutputKafka,
+	_builtinOutputName[68:81]:        builtinOutputOpentelemetry,
+	_builtinOutputLowerName[68:81]:   builtinOutputOpentelemetry,
+	_builtinOutputName[81:101]:       builtinOutputOpentelemetryTraces,
+	_builtinOutputLowerName[81:101]:  builtinOutputOpentelemetryTraces,
+	_builtinOutputName[101:108]:      builtinOutputParquet,
+	_builtinOutputLowerName[101:108]: builtinOutputParquet,
+	_builtinOutputName[108:118]:      builtinOutputPrometheus,
+	_builtinOutputLowerName[108:118]: builtinOutputPrometheus,
+	_builtinOutputName[118:124]:      builtinOutputStatsd,
+	_builtinOutputLowerName[118:124]: builtinOutputStatsd,
+	_builtinOutputName[124:135]:      builtinOutputTimescaledb,
+	_builtinOutputLowerName[124:135]: builtinOutputTimescaledb,
 }
 
 var _builtinOutputNames = []string{
-	_builtinOutputName[0:5],
-	_builtinOutputName[5:8],
-	_builtinOutputName[8:15],
-	_builtinOutputName[15:41],
-	_builtinOutputName[41:49],
-	_builtinOutputName[49:53],
-	_builtinOutputName[53:58],
-	_builtinOutputName[58:64],
+	_builtinOutputName[0:10],
+	_builtinOutputName[10:15],
+	_builtinOutputName[15:18],
+	_builtinOutputName[18:25],
+	_builtinOutputName[25:51],
+	_builtinOutputName[51:59],
+	_builtinOutputName[59:63],
+	_builtinOutputName[63:68],
+	_builtinOutputName[68:81],
+	_builtinOutputName[81:101],
+	_builtinOutputName[101:108],
+	_builtinOutputName[108:118],
+	_builtinOutputName[118:124],
+	_builtinOutputName[124:135],
 }
 
 // builtinOutputString retrieves an enum value from the enum constants string name.
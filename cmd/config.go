@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -22,6 +23,8 @@ import (
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+	testreport "go.k6.io/k6/report"
 )
 
 // configFlagSet returns a FlagSet with the default run configuration flags.
@@ -31,6 +34,16 @@ func configFlagSet() *pflag.FlagSet {
 	flags.StringArrayP("out", "o", []string{}, "`uri` for an external metrics database")
 	flags.BoolP("linger", "l", false, "keep the API server alive past test end")
 	flags.Bool("no-usage-report", false, "don't send anonymous stats to the developers")
+	flags.Bool("web-dashboard", false, "serve a web dashboard with the live charts of the test's metrics")
+	flags.String("web-dashboard-export", "", "`path` of an HTML file to export the web dashboard's final state to")
+	flags.String("profile", "", "`name` of the profile of outputs and reports, from the config file, to use")
+	flags.StringArray("report", []string{}, "`type=path` of an end-of-test report to generate, e.g. html=report.html or junit=junit.xml")
+	flags.String("summary-template", "",
+		"`path` of the text/template of the template reports, e.g. of --summary-export template=comment.md")
+	flags.String("baseline", "", "`path` of the JSON summary of a previous test run to compare this one with")
+	flags.StringArray("baseline-tolerance", []string{},
+		"`metric.stat=percent%` change allowed from the baseline, negative when a decrease is a regression "+
+			"(default http_req_duration.p(95)=10%)")
 	return flags
 }
 
@@ -38,20 +51,74 @@ func configFlagSet() *pflag.FlagSet {
 type Config struct {
 	lib.Options
 
-	Out           []string  `json:"out" envconfig:"K6_OUT"`
-	Linger        null.Bool `json:"linger" envconfig:"K6_LINGER"`
-	NoUsageReport null.Bool `json:"noUsageReport" envconfig:"K6_NO_USAGE_REPORT"`
-	WebDashboard  null.Bool `json:"webDashboard" envconfig:"K6_WEB_DASHBOARD"`
+	Out                []string    `json:"out" envconfig:"K6_OUT"`
+	Linger             null.Bool   `json:"linger" envconfig:"K6_LINGER"`
+	NoUsageReport      null.Bool   `json:"noUsageReport" envconfig:"K6_NO_USAGE_REPORT"`
+	WebDashboard       null.Bool   `json:"webDashboard" envconfig:"K6_WEB_DASHBOARD"`
+	WebDashboardExport null.String `json:"webDashboardExport" envconfig:"K6_WEB_DASHBOARD_EXPORT"`
+	Report             []string    `json:"report" envconfig:"K6_REPORT"`
+	// SummaryTemplate is the path of the text/template the template reports
+	// are generated with.
+	SummaryTemplate null.String `json:"summaryTemplate" envconfig:"K6_SUMMARY_TEMPLATE"`
+
+	// Baseline is the path of the JSON summary of a previous test run which
+	// the statistics of the BaselineTolerance are compared with.
+	Baseline          null.String `json:"baseline" envconfig:"K6_BASELINE"`
+	BaselineTolerance []string    `json:"baselineTolerance" envconfig:"K6_BASELINE_TOLERANCE"`
+
+	// Profile is the name of the profile, among the Profiles, that is used.
+	Profile  null.String        `json:"profile" envconfig:"K6_PROFILE"`
+	Profiles map[string]Profile `json:"profiles"`
+
+	// OutputFilters are the filters of the samples of the outputs, by output type.
+	OutputFilters map[string]output.FilterConfig `json:"outputFilters"`
+	// OutputAggregation are the aggregations of the samples of the outputs, by output type.
+	OutputAggregation map[string]output.AggregationConfig `json:"outputAggregation"`
 
 	// TODO: deprecate
 	Collectors map[string]json.RawMessage `json:"collectors"`
 }
 
+// Profile is a named set of options, outputs and reports, e.g. for the dev,
+// staging and prod environments, to select them all at once with the --profile
+// option instead of repeating them.
+type Profile struct {
+	// Options override the options of the config file and of the script, but
+	// not the ones of the environment variables and the CLI flags.
+	Options lib.Options `json:"options"`
+
+	Out                []string                       `json:"out"`
+	Report             []string                       `json:"report"`
+	WebDashboard       null.Bool                      `json:"webDashboard"`
+	WebDashboardExport null.String                    `json:"webDashboardExport"`
+	OutputFilters      map[string]output.FilterConfig `json:"outputFilters"`
+}
+
 // Validate checks if all of the specified options make sense
 func (c Config) Validate() []error {
 	errors := c.Options.Validate()
 	// TODO: validate all of the other options... that we should have already been validating...
 	// TODO: maybe integrate an external validation lib: https://github.com/avelino/awesome-go#validation
+	for _, arg := range c.Report {
+		if _, err := testreport.Parse(arg); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	for _, tolerance := range c.BaselineTolerance {
+		if _, err := metrics.ParseBaselineTolerance(tolerance); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	for typ, filter := range c.OutputFilters {
+		if err := filter.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid filter of the '%s' output: %w", typ, err))
+		}
+	}
+	for typ, aggregation := range c.OutputAggregation {
+		if err := aggregation.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid aggregation of the '%s' output: %w", typ, err))
+		}
+	}
 
 	return errors
 }
@@ -71,6 +138,33 @@ func (c Config) Apply(cfg Config) Config {
 	if cfg.WebDashboard.Valid {
 		c.WebDashboard = cfg.WebDashboard
 	}
+	if cfg.WebDashboardExport.Valid {
+		c.WebDashboardExport = cfg.WebDashboardExport
+	}
+	if len(cfg.Report) > 0 {
+		c.Report = cfg.Report
+	}
+	if cfg.SummaryTemplate.Valid {
+		c.SummaryTemplate = cfg.SummaryTemplate
+	}
+	if cfg.Baseline.Valid {
+		c.Baseline = cfg.Baseline
+	}
+	if len(cfg.BaselineTolerance) > 0 {
+		c.BaselineTolerance = cfg.BaselineTolerance
+	}
+	if cfg.Profile.Valid {
+		c.Profile = cfg.Profile
+	}
+	if len(cfg.Profiles) > 0 {
+		c.Profiles = cfg.Profiles
+	}
+	if len(cfg.OutputFilters) > 0 {
+		c.OutputFilters = cfg.OutputFilters
+	}
+	if len(cfg.OutputAggregation) > 0 {
+		c.OutputAggregation = cfg.OutputAggregation
+	}
 	if len(cfg.Collectors) > 0 {
 		c.Collectors = cfg.Collectors
 	}
@@ -97,11 +191,26 @@ func getConfig(flags *pflag.FlagSet) (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	reports, err := flags.GetStringArray("report")
+	if err != nil {
+		return Config{}, err
+	}
+	baselineTolerance, err := flags.GetStringArray("baseline-tolerance")
+	if err != nil {
+		return Config{}, err
+	}
 	return Config{
-		Options:       opts,
-		Out:           out,
-		Linger:        getNullBool(flags, "linger"),
-		NoUsageReport: getNullBool(flags, "no-usage-report"),
+		Options:            opts,
+		Out:                out,
+		Report:             reports,
+		SummaryTemplate:    getNullString(flags, "summary-template"),
+		Linger:             getNullBool(flags, "linger"),
+		NoUsageReport:      getNullBool(flags, "no-usage-report"),
+		WebDashboard:       getNullBool(flags, "web-dashboard"),
+		WebDashboardExport: getNullString(flags, "web-dashboard-export"),
+		Profile:            getNullString(flags, "profile"),
+		Baseline:           getNullString(flags, "baseline"),
+		BaselineTolerance:  baselineTolerance,
 	}, nil
 }
 
@@ -158,32 +267,66 @@ func readEnvConfig(envMap map[string]string) (Config, error) {
 	return conf, err
 }
 
+// configLayer is a source of the configuration, its options have priority over
+// the ones of the previous layers.
+type configLayer struct {
+	source string
+	conf   Config
+}
+
+// getConfigLayers returns the layers of the configuration, from the lowest
+// priority to the highest one:
+// - the global file config options
+// - the Runner-provided options (they may come from Bundle too if applicable)
+// - the options of the selected profile
+// - the environment variables
+// - the user-supplied CLI flags
+func getConfigLayers(gs *state.GlobalState, cliConf Config, runnerOpts lib.Options) ([]configLayer, error) {
+	fileConf, err := readDiskConfig(gs)
+	if err != nil {
+		return nil, err
+	}
+	envConf, err := readEnvConfig(gs.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []configLayer{
+		{source: "config file " + gs.Flags.ConfigFilePath, conf: fileConf},
+		{source: "script options", conf: Config{Options: runnerOpts}},
+	}
+	// the invalid profiles are reported by applyProfile
+	selected := fileConf.Apply(envConf).Apply(cliConf)
+	if profile, ok := selected.Profiles[selected.Profile.String]; ok && selected.Profile.String != "" {
+		layers = append(layers, configLayer{
+			source: "profile " + selected.Profile.String,
+			conf:   Config{Options: profile.Options},
+		})
+	}
+	return append(layers,
+		configLayer{source: "environment variables", conf: envConf},
+		configLayer{source: "command-line flags", conf: cliConf},
+	), nil
+}
+
 // Assemble the final consolidated configuration from all of the different sources:
 // - start with the CLI-provided options to get shadowed (non-Valid) defaults in there
-// - add the global file config options
-// - add the Runner-provided options (they may come from Bundle too if applicable)
-// - add the environment variables
-// - merge the user-supplied CLI flags back in on top, to give them the greatest priority
+// - add the layers of getConfigLayers, the CLI flags are last to give them the greatest priority
+// - add the outputs and the reports of the selected profile
 // - set some defaults if they weren't previously specified
 // TODO: add better validation, more explicit default values and improve consistency between formats
 // TODO: accumulate all errors and differentiate between the layers?
 func getConsolidatedConfig(gs *state.GlobalState, cliConf Config, runnerOpts lib.Options) (conf Config, err error) {
 	// TODO: use errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig) where it makes sense?
 
-	fileConf, err := readDiskConfig(gs)
+	layers, err := getConfigLayers(gs, cliConf, runnerOpts)
 	if err != nil {
 		return conf, err
 	}
-	envConf, err := readEnvConfig(gs.Env)
-	if err != nil {
+	conf = consolidateConfigLayers(cliConf, layers)
+	if conf, err = applyProfile(conf); err != nil {
 		return conf, err
 	}
-
-	conf = cliConf.Apply(fileConf)
-
-	conf = conf.Apply(Config{Options: runnerOpts})
-
-	conf = conf.Apply(envConf).Apply(cliConf)
 	conf = applyDefault(conf)
 
 	// TODO(imiric): Move this validation where it makes sense in the configuration
@@ -194,7 +337,60 @@ func getConsolidatedConfig(gs *state.GlobalState, cliConf Config, runnerOpts lib
 	if _, err = metrics.GetResolversForTrendColumns(conf.SummaryTrendStats); err != nil {
 		return conf, err
 	}
+	for name, stats := range conf.SummaryTrendStatsPerMetric {
+		if _, err = metrics.GetResolversForTrendColumns(stats); err != nil {
+			return conf, fmt.Errorf("invalid summary trend stats of the metric '%s': %w", name, err)
+		}
+	}
+
+	return conf, nil
+}
+
+func consolidateConfigLayers(base Config, layers []configLayer) Config {
+	conf := base
+	for _, layer := range layers {
+		conf = conf.Apply(layer.conf)
+	}
+	return conf
+}
+
+// applyProfile adds the outputs and the reports of the selected profile to the
+// ones configured directly, which have the priority over the profile's.
+func applyProfile(conf Config) (Config, error) {
+	if conf.Profile.String == "" {
+		return conf, nil
+	}
+	profile, ok := conf.Profiles[conf.Profile.String]
+	if !ok {
+		names := make([]string, 0, len(conf.Profiles))
+		for name := range conf.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return conf, errext.WithExitCodeIfNone(
+			fmt.Errorf("invalid profile '%s', available profiles are: %s", conf.Profile.String, strings.Join(names, ", ")),
+			exitcodes.InvalidConfig,
+		)
+	}
 
+	conf.Out = append(append([]string{}, profile.Out...), conf.Out...)
+	conf.Report = append(append([]string{}, profile.Report...), conf.Report...)
+	if !conf.WebDashboard.Valid {
+		conf.WebDashboard = profile.WebDashboard
+	}
+	if !conf.WebDashboardExport.Valid {
+		conf.WebDashboardExport = profile.WebDashboardExport
+	}
+	if len(profile.OutputFilters) > 0 {
+		filters := make(map[string]output.FilterConfig, len(profile.OutputFilters)+len(conf.OutputFilters))
+		for typ, filter := range profile.OutputFilters {
+			filters[typ] = filter
+		}
+		for typ, filter := range conf.OutputFilters {
+			filters[typ] = filter
+		}
+		conf.OutputFilters = filters
+	}
 	return conf, nil
 }
 
@@ -269,5 +465,11 @@ func validateScenarioConfig(conf lib.ExecutorConfig, isExecutable func(string) b
 	if !isExecutable(execFn) {
 		return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), execFn)
 	}
+	lifecycle := conf.GetLifecycle()
+	for _, fn := range []string{lifecycle.Setup, lifecycle.Teardown} {
+		if fn != "" && !isExecutable(fn) {
+			return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), fn)
+		}
+	}
 	return nil
 }
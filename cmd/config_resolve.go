@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+)
+
+// defaultConfigSource is the source of the values which aren't set by any of
+// the config layers.
+const defaultConfigSource = "default"
+
+// resolvedConfigValue is an option of the consolidated config, with the layer
+// it comes from.
+type resolvedConfigValue struct {
+	Value  json.RawMessage `json:"value"`
+	Source string          `json:"source"`
+}
+
+// resolvedConfig is the output of `k6 config resolve`.
+type resolvedConfig struct {
+	// Layers are the sources of the options, from the lowest priority to the highest one.
+	Layers  []string                       `json:"layers"`
+	Options map[string]resolvedConfigValue `json:"options"`
+}
+
+// cmdConfigResolve handles the `k6 config resolve` sub-command
+type cmdConfigResolve struct {
+	gs     *state.GlobalState
+	asJSON bool
+}
+
+func (c *cmdConfigResolve) run(cmd *cobra.Command, args []string) error {
+	var runnerOpts lib.Options
+	if len(args) > 0 {
+		test, err := loadLocalTest(c.gs, cmd, args)
+		if err != nil {
+			return err
+		}
+		runnerOpts = test.initRunner.GetOptions()
+	}
+	cliConf, err := getConfig(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	layers, err := getConfigLayers(c.gs, cliConf, runnerOpts)
+	if err != nil {
+		return err
+	}
+	conf, err := getConsolidatedConfig(c.gs, cliConf, runnerOpts)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveConfigSources(conf, layers)
+	if err != nil {
+		return err
+	}
+
+	if c.asJSON {
+		data, err := json.MarshalIndent(resolved, "", "  ")
+		if err != nil {
+			return err
+		}
+		printToStdout(c.gs, string(data)+"\n")
+		return nil
+	}
+	printToStdout(c.gs, formatResolvedConfig(resolved))
+	return nil
+}
+
+// resolveConfigSources returns the options of the consolidated config which
+// are set, each with the last of the layers which set it. The outputs and the
+// reports of the profile are added to the other ones, so they have both
+// sources.
+func resolveConfigSources(conf Config, layers []configLayer) (*resolvedConfig, error) {
+	resolved := &resolvedConfig{
+		Layers:  []string{defaultConfigSource},
+		Options: make(map[string]resolvedConfigValue),
+	}
+	sources := make(map[string]string)
+	for _, layer := range layers {
+		resolved.Layers = append(resolved.Layers, layer.source)
+		keys, err := setConfigKeys(layer.conf)
+		if err != nil {
+			return nil, err
+		}
+		for key := range keys {
+			sources[key] = layer.source
+		}
+	}
+
+	if profile, ok := conf.Profiles[conf.Profile.String]; ok && conf.Profile.String != "" {
+		source := "profile " + conf.Profile.String
+		keys, err := setConfigKeys(Config{
+			Out:                profile.Out,
+			Report:             profile.Report,
+			WebDashboard:       profile.WebDashboard,
+			WebDashboardExport: profile.WebDashboardExport,
+			OutputFilters:      profile.OutputFilters,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for key := range keys {
+			if existing, ok := sources[key]; !ok {
+				sources[key] = source
+			} else if key == "out" || key == "report" || key == "outputFilters" {
+				sources[key] = source + " and " + existing
+			}
+		}
+	}
+
+	values, err := setConfigKeys(conf)
+	if err != nil {
+		return nil, err
+	}
+	delete(values, "profiles")
+	for key, value := range values {
+		source, ok := sources[key]
+		if !ok {
+			source = defaultConfigSource
+		}
+		resolved.Options[key] = resolvedConfigValue{Value: value, Source: source}
+	}
+	return resolved, nil
+}
+
+// setConfigKeys returns the JSON values of the options of the config which
+// are set, by JSON key.
+func setConfigKeys(conf Config) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err = json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		switch string(value) {
+		case "null", "[]", "{}":
+			delete(values, key)
+		}
+	}
+	return values, nil
+}
+
+func formatResolvedConfig(resolved *resolvedConfig) string {
+	keys := make([]string, 0, len(resolved.Options))
+	width := 0
+	for key := range resolved.Options {
+		keys = append(keys, key)
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("The layers of the config, from the lowest priority to the highest one:\n  ")
+	b.WriteString(strings.Join(resolved.Layers, ", "))
+	b.WriteString("\n\n")
+	for _, key := range keys {
+		option := resolved.Options[key]
+		fmt.Fprintf(&b, "  %-*s  %s  (%s)\n", width, key, option.Value, option.Source)
+	}
+	return b.String()
+}
+
+func (c *cmdConfigResolve) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.AddFlagSet(optionFlagSet())
+	flags.AddFlagSet(runtimeOptionFlagSet(false))
+	flags.AddFlagSet(configFlagSet())
+	flags.BoolVar(&c.asJSON, "json", false, "print the options and their sources as JSON")
+	return flags
+}
+
+func getCmdConfigResolve(gs *state.GlobalState) *cobra.Command {
+	c := &cmdConfigResolve{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Show the options of a script with the staging profile, and where they come from.
+  {{.}} config resolve --profile staging script.js
+
+  # Show the options of the config file, the environment variables and the flags.
+  {{.}} config resolve -u 10`[1:])
+
+	resolveCmd := &cobra.Command{
+		Use:   "resolve [file]",
+		Short: "Show the final options and the source of each of them",
+		Long: `Show the final options and the source of each of them.
+
+The options are merged from the layers of the config, each of them overriding
+the previous ones: the config file, the options of the script, the options of
+the selected profile, the environment variables and the command-line flags.`,
+		Example: exampleText,
+		Args:    cobra.MaximumNArgs(1),
+		RunE:    c.run,
+	}
+	resolveCmd.Flags().SortFlags = false
+	resolveCmd.Flags().AddFlagSet(c.flagSet())
+	return resolveCmd
+}
+
+func getCmdConfig(gs *state.GlobalState) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+		Long:  `Inspect how the configuration of the tests is resolved.`,
+		Args:  cobra.NoArgs,
+	}
+	configCmd.AddCommand(getCmdConfigResolve(gs))
+	return configCmd
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func getConfigResolveTestState(t *testing.T, args ...string) *tests.GlobalTestState {
+	t.Helper()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "script.js"), []byte(`
+		export const options = { vus: 2, duration: '10s', userAgent: 'script' };
+		export default function () {}
+	`), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, "config.json", []byte(`{
+		"noConnectionReuse": true,
+		"profiles": {
+			"staging": {"options": {"vus": 5, "duration": "20s", "userAgent": "staging"}, "out": ["json=staging.json"]}
+		}
+	}`), 0o644))
+	ts.Env["K6_DURATION"] = "30s"
+	ts.CmdArgs = append([]string{"k6", "--config", "config.json", "config", "resolve"}, args...)
+	return ts
+}
+
+func TestConfigResolve(t *testing.T) {
+	t.Parallel()
+
+	ts := getConfigResolveTestState(t, "--profile", "staging", "--vus", "7", "script.js")
+	newRootCommand(ts.GlobalState).execute()
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "The layers of the config, from the lowest priority to the highest one:\n"+
+		"  default, config file config.json, script options, profile staging, environment variables, command-line flags\n")
+	assert.Regexp(t, `\n  vus +7  \(command-line flags\)\n`, stdout)
+	assert.Regexp(t, `\n  duration +"30s"  \(environment variables\)\n`, stdout)
+	assert.Regexp(t, `\n  userAgent +"staging"  \(profile staging\)\n`, stdout)
+	assert.Regexp(t, `\n  noConnectionReuse +true  \(config file config.json\)\n`, stdout)
+	assert.Regexp(t, `\n  out +\["json=staging.json"\]  \(profile staging\)\n`, stdout)
+	assert.Regexp(t, `\n  summaryTrendStats +\[.*\]  \(default\)\n`, stdout)
+	assert.NotContains(t, stdout, "profiles")
+}
+
+func TestConfigResolveJSON(t *testing.T) {
+	t.Parallel()
+
+	ts := getConfigResolveTestState(t, "--json", "--out", "csv", "script.js")
+	ts.Env["K6_PROFILE"] = "staging"
+	newRootCommand(ts.GlobalState).execute()
+
+	var resolved resolvedConfig
+	require.NoError(t, json.Unmarshal(ts.Stdout.Bytes(), &resolved))
+	for key, expected := range map[string]resolvedConfigValue{
+		"vus":     {Value: json.RawMessage(`5`), Source: "profile staging"},
+		"out":     {Value: json.RawMessage(`["json=staging.json","csv"]`), Source: "profile staging and command-line flags"},
+		"profile": {Value: json.RawMessage(`"staging"`), Source: "environment variables"},
+	} {
+		assert.JSONEq(t, string(expected.Value), string(resolved.Options[key].Value), key)
+		assert.Equal(t, expected.Source, resolved.Options[key].Source, key)
+	}
+}
+
+func TestConfigResolveWithoutScript(t *testing.T) {
+	t.Parallel()
+
+	ts := getConfigResolveTestState(t)
+	newRootCommand(ts.GlobalState).execute()
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "  default, config file config.json, script options, environment variables, command-line flags\n")
+	assert.Regexp(t, `\n  duration +"30s"  \(environment variables\)\n`, stdout)
+	assert.NotContains(t, stdout, "userAgent")
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/mstoykov/envconfig"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/errext"
@@ -13,6 +14,7 @@ import (
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/executor"
 	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/output"
 )
 
 type testCmdData struct {
@@ -91,6 +93,10 @@ func TestConfigEnv(t *testing.T) {
 			"true":  func(c Config) { assert.Equal(t, null.BoolFrom(true), c.WebDashboard) },
 			"false": func(c Config) { assert.Equal(t, null.BoolFrom(false), c.WebDashboard) },
 		},
+		{"WebDashboardExport", "K6_WEB_DASHBOARD_EXPORT"}: {
+			"":            func(c Config) { assert.Equal(t, null.String{}, c.WebDashboardExport) },
+			"report.html": func(c Config) { assert.Equal(t, null.StringFrom("report.html"), c.WebDashboardExport) },
+		},
 		{"Out", "K6_OUT"}: {
 			"":         func(c Config) { assert.Equal(t, []string{}, c.Out) },
 			"influxdb": func(c Config) { assert.Equal(t, []string{"influxdb"}, c.Out) },
@@ -140,6 +146,43 @@ func TestConfigApply(t *testing.T) {
 	})
 }
 
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	profiles := map[string]Profile{
+		"ci": {
+			Out:           []string{"json=results.json"},
+			Report:        []string{"junit"},
+			WebDashboard:  null.BoolFrom(true),
+			OutputFilters: map[string]output.FilterConfig{"json": {DropTags: []string{"url"}}},
+		},
+		"live": {Out: []string{"prometheus"}},
+	}
+
+	conf, err := applyProfile(Config{Out: []string{"csv"}, Profiles: profiles})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"csv"}, conf.Out)
+
+	conf, err = applyProfile(Config{
+		Out:           []string{"csv"},
+		WebDashboard:  null.BoolFrom(false),
+		Profile:       null.StringFrom("ci"),
+		Profiles:      profiles,
+		OutputFilters: map[string]output.FilterConfig{"csv": {DropTags: []string{"vu"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"json=results.json", "csv"}, conf.Out)
+	assert.Equal(t, []string{"junit"}, conf.Report)
+	assert.Equal(t, null.BoolFrom(false), conf.WebDashboard)
+	assert.Equal(t, map[string]output.FilterConfig{
+		"json": {DropTags: []string{"url"}},
+		"csv":  {DropTags: []string{"vu"}},
+	}, conf.OutputFilters)
+
+	_, err = applyProfile(Config{Profile: null.StringFrom("nightly"), Profiles: profiles})
+	assert.EqualError(t, err, "invalid profile 'nightly', available profiles are: ci, live")
+}
+
 func TestDeriveAndValidateConfig(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/converter/postman"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// cmdConvert handles the `k6 convert` sub-command
+type cmdConvert struct {
+	gs          *state.GlobalState
+	output      string
+	environment string
+	convert     convertOptions
+}
+
+// convertOptions are the options of the conversion of the HAR recordings to
+// scripts, shared by `k6 convert` and `k6 record`.
+type convertOptions struct {
+	noCorrelate  bool
+	minThinkTime time.Duration
+	maxThinkTime time.Duration
+}
+
+func (co *convertOptions) harOptions() har.Options {
+	return har.Options{
+		Correlate:    !co.noCorrelate,
+		MinThinkTime: co.minThinkTime,
+		MaxThinkTime: co.maxThinkTime,
+	}
+}
+
+func (co *convertOptions) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.BoolVar(&co.noCorrelate, "no-correlate", false,
+		"keep the recorded values of the requests instead of extracting the dynamic ones from the responses")
+	flags.DurationVar(&co.minThinkTime, "min-think-time", 500*time.Millisecond,
+		"minimum pause between two requests for a sleep() between them")
+	flags.DurationVar(&co.maxThinkTime, "max-think-time", 0, "maximum duration of the sleep() calls, 0 for no limit")
+	return flags
+}
+
+func (c *cmdConvert) run(_ *cobra.Command, args []string) error {
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return err
+	}
+	absPath := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(pwd, path)
+	}
+
+	data, err := fsext.ReadFile(c.gs.FS, absPath(args[0]))
+	if err != nil {
+		return fmt.Errorf("couldn't read the file '%s': %w", args[0], err)
+	}
+	var script string
+	if postman.IsCollection(data) {
+		script, err = c.convertPostman(data, absPath)
+	} else {
+		script, err = c.convertHAR(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.output == "" {
+		printToStdout(c.gs, script)
+		return nil
+	}
+	return fsext.WriteFile(c.gs.FS, absPath(c.output), []byte(script), 0o644)
+}
+
+func (c *cmdConvert) convertHAR(data []byte) (string, error) {
+	if c.environment != "" {
+		return "", errors.New("the --postman-environment option is only for the Postman collections")
+	}
+	recording, err := har.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	return har.Convert(recording, c.convert.harOptions())
+}
+
+func (c *cmdConvert) convertPostman(data []byte, absPath func(string) string) (string, error) {
+	collection, err := postman.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	var opts postman.Options
+	if c.environment != "" {
+		envData, err := fsext.ReadFile(c.gs.FS, absPath(c.environment))
+		if err != nil {
+			return "", fmt.Errorf("couldn't read the Postman environment '%s': %w", c.environment, err)
+		}
+		env, err := postman.ParseEnvironment(envData)
+		if err != nil {
+			return "", err
+		}
+		opts.Environment = &env
+	}
+	return postman.Convert(collection, opts)
+}
+
+func (c *cmdConvert) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVarP(&c.output, "output", "O", "", "write the script to the file instead of the standard output")
+	flags.AddFlagSet(c.convert.flagSet())
+	flags.StringVar(&c.environment, "postman-environment", "",
+		"exported Postman environment whose variables override the ones of the collection")
+	return flags
+}
+
+func getCmdConvert(gs *state.GlobalState) *cobra.Command {
+	c := &cmdConvert{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Convert a HAR recording to a script.
+  {{.}} convert -O script.js session.har
+
+  # Keep the recorded values and the pauses of at most 5s.
+  {{.}} convert --no-correlate --max-think-time 5s session.har
+
+  # Convert a Postman collection with the variables of an environment.
+  {{.}} convert --postman-environment staging.json -O script.js collection.json`[1:])
+
+	convertCmd := &cobra.Command{
+		Use:   "convert [file]",
+		Short: "Convert a HAR recording or a Postman collection to a script",
+		Long: `Convert a HAR recording of a browser session, or a Postman collection, to a
+script.
+
+The requests of each page of the recording are in a group named after it, and
+the pauses between the requests are sleep() calls. The cookies aren't in the
+script, since they are handled by the cookie jar of k6.
+
+The dynamic values of the responses which are used by the later requests, like
+the session tokens and the CSRF fields, are extracted into variables. They are
+looked for in the token headers, the JSON bodies and the hidden inputs of the
+HTML forms.
+
+The Postman collections, in the v2.1 format, are converted to a scenario for
+each of their top-level folders, whose nested folders are groups. Their
+variables, and the ones of the --postman-environment, can be overridden with
+the environment variables of k6. Their auth helpers are converted to headers,
+and the statements of their scripts which have an equivalent in k6, like the
+ones setting variables and testing the status, are translated. The others are
+left as TODO comments.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should be the path to a HAR recording or to a Postman collection"),
+		RunE:    c.run,
+	}
+	convertCmd.Flags().SortFlags = false
+	convertCmd.Flags().AddFlagSet(c.flagSet())
+	return convertCmd
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const convertTestHAR = `{
+  "log": {
+    "entries": [
+      {
+        "startedDateTime": "2024-01-01T10:00:00.000Z",
+        "time": 100,
+        "request": { "method": "POST", "url": "https://example.com/login", "headers": [] },
+        "response": {
+          "status": 200,
+          "headers": [],
+          "content": { "mimeType": "application/json", "text": "{\"token\": \"0123456789abcdef\"}" }
+        }
+      },
+      {
+        "startedDateTime": "2024-01-01T10:00:01.100Z",
+        "time": 100,
+        "request": { "method": "GET", "url": "https://example.com/me?token=0123456789abcdef", "headers": [] },
+        "response": { "status": 200, "headers": [], "content": { "mimeType": "", "text": "" } }
+      }
+    ]
+  }
+}`
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "session.har"), []byte(convertTestHAR), 0o644))
+	ts.CmdArgs = []string{"k6", "convert", "-O", "script.js", "session.har"}
+	newRootCommand(ts.GlobalState).execute()
+
+	script, err := fsext.ReadFile(ts.FS, filepath.Join(ts.Cwd, "script.js"))
+	require.NoError(t, err)
+	assert.Equal(t, `// Converted from a HAR recording by k6 convert
+
+import { sleep } from 'k6';
+import http from 'k6/http';
+
+export default function () {
+  let res;
+  const vars = {};
+
+  res = http.post("https://example.com/login");
+  vars["token"] = res.json("token");
+  sleep(1);
+  http.get(`+"`https://example.com/me?token=${vars[\"token\"]}`"+`);
+}
+`, string(script))
+	assert.Empty(t, ts.Stdout.String())
+}
+
+func TestConvertNoCorrelate(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "session.har"), []byte(convertTestHAR), 0o644))
+	ts.CmdArgs = []string{"k6", "convert", "--no-correlate", "--min-think-time", "2s", "session.har"}
+	newRootCommand(ts.GlobalState).execute()
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, `http.get("https://example.com/me?token=0123456789abcdef");`)
+	assert.NotContains(t, stdout, "vars")
+	assert.NotContains(t, stdout, "sleep")
+}
+
+func TestConvertInvalidRecording(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "session.har"), []byte(`{"log": {}}`), 0o644))
+	ts.CmdArgs = []string{"k6", "convert", "session.har"}
+	ts.ExpectedExitCode = -1
+	newRootCommand(ts.GlobalState).execute()
+
+	assert.Contains(t, ts.Stderr.String(), "the HAR recording has no entries")
+}
+
+func TestConvertPostmanCollection(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	collection := `{
+  "info": {"name": "API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "variable": [{"key": "baseUrl", "value": "https://example.com"}],
+  "item": [{"name": "Me", "request": {"method": "GET", "url": "{{baseUrl}}/me"}}]
+}`
+	env := `{"name": "staging", "values": [{"key": "baseUrl", "value": "https://staging.example.com"}]}`
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "api.json"), []byte(collection), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "staging.json"), []byte(env), 0o644))
+	ts.CmdArgs = []string{"k6", "convert", "--postman-environment", "staging.json", "api.json"}
+	newRootCommand(ts.GlobalState).execute()
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, `// Converted from the Postman collection "API" by k6 convert`)
+	assert.Contains(t, stdout, `"baseUrl": __ENV["baseUrl"] || "https://staging.example.com",`)
+	assert.Contains(t, stdout, "http.get(`${vars[\"baseUrl\"]}/me`);")
+}
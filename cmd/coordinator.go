@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution/distributed"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics/engine"
+)
+
+// cmdCoordinator handles the `k6 coordinator` sub-command
+type cmdCoordinator struct {
+	gs *state.GlobalState
+
+	instanceCount int
+	address       string
+	agentTimeout  time.Duration
+}
+
+//nolint:funlen
+func (c *cmdCoordinator) run(cmd *cobra.Command, args []string) (err error) {
+	logger := c.gs.Logger
+	test, err := loadAndConfigureLocalTest(c.gs, cmd, args, getPartialConfig)
+	if err != nil {
+		return err
+	}
+
+	// Only the consolidated options are sent to the agents, like in the
+	// archive command, since they will derive the rest themselves.
+	testRunState, err := test.buildTestRunState(test.consolidatedConfig.Options)
+	if err != nil {
+		return err
+	}
+	archive := &bytes.Buffer{}
+	if err = testRunState.Runner.MakeArchive().Write(archive); err != nil {
+		return err
+	}
+
+	metricsEngine, err := engine.NewMetricsEngine(testRunState.Registry, logger)
+	if err != nil {
+		return err
+	}
+	noThresholds := testRunState.RuntimeOptions.NoThresholds.Bool
+	if err = metricsEngine.InitSubMetricsAndThresholds(test.derivedConfig.Options, noThresholds); err != nil {
+		return err
+	}
+	ingester := metricsEngine.CreateIngester()
+	if err = ingester.Start(); err != nil {
+		return err
+	}
+
+	coordinator, err := distributed.NewCoordinator(distributed.CoordinatorConfig{
+		InstanceCount: c.instanceCount,
+		AgentTimeout:  c.agentTimeout,
+		Archive:       archive.Bytes(),
+		Registry:      testRunState.Registry,
+		Ingester:      ingester,
+		RootGroup:     testRunState.Runner.GetDefaultGroup(),
+	}, logger)
+	if err != nil {
+		return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+
+	var finalizeThresholds func() []string
+	if !noThresholds {
+		finalizeThresholds = metricsEngine.StartThresholdCalculations(
+			ingester, coordinator.Abort, coordinator.GetCurrentTestRunDuration,
+		)
+	}
+
+	// The agents get the whole test from the coordinator, including its files
+	// and environment variables, so its server has the same token and TLS
+	// options as the REST API server.
+	srvConf, err := getAPIServerConfig(c.gs)
+	if err != nil {
+		return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+	srv, err := srvConf.NewServer(c.address, coordinator.Handler())
+	if err != nil {
+		return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+	switch {
+	case !srvConf.IsAuthenticated():
+		logger.Warn("Anyone who can connect to the coordinator can get the test and send metrics to it, " +
+			"use --api-token or --api-tls-ca to require the agents to authenticate")
+	case srvConf.Token != "" && srv.TLSConfig == nil:
+		logger.Warn("The coordinator token will be sent in plaintext, use --api-tls-cert and --api-tls-key to enable TLS")
+	}
+
+	listener, err := net.Listen("tcp", c.address)
+	if err != nil {
+		return err
+	}
+	serve := srv.Serve
+	if srv.TLSConfig != nil {
+		serve = func(l net.Listener) error { return srv.ServeTLS(l, "", "") }
+	}
+	go func() {
+		if srvErr := serve(listener); srvErr != nil && !errors.Is(srvErr, http.ErrServerClosed) {
+			logger.WithError(srvErr).Error("Error from the coordinator server")
+		}
+	}()
+	defer func() {
+		if srvErr := srv.Close(); srvErr != nil {
+			logger.WithError(srvErr).Debug("The coordinator server did not shut down correctly")
+		}
+	}()
+	printToStdout(c.gs, fmt.Sprintf(
+		"The coordinator is waiting for %d agents on %s\n", c.instanceCount, listener.Addr(),
+	))
+
+	waitCtx, waitCancel := context.WithCancel(c.gs.Ctx)
+	defer waitCancel()
+	gracefulStop := func(sig os.Signal) {
+		logger.WithField("sig", sig).Debug("Stopping the test on all agents in response to signal...")
+		coordinator.Abort(errext.WithAbortReasonIfNone(
+			errext.WithExitCodeIfNone(
+				fmt.Errorf("test run was aborted because the coordinator received a '%s' signal", sig),
+				exitcodes.ExternalAbort,
+			), errext.AbortedByUser,
+		))
+	}
+	onHardStop := func(sig os.Signal) {
+		logger.WithField("sig", sig).Error("Aborting the coordinator in response to signal")
+		waitCancel()
+	}
+	stopSignalHandling := handleTestAbortSignals(c.gs, gracefulStop, onHardStop)
+	defer stopSignalHandling()
+
+	err = coordinator.Wait(waitCtx)
+
+	logger.Debug("All agents have finished, finalizing the metrics...")
+	if finalizeThresholds != nil {
+		breachedThresholds := finalizeThresholds()
+		if len(breachedThresholds) > 0 && err == nil {
+			err = errext.WithAbortReasonIfNone(
+				errext.WithExitCodeIfNone(
+					fmt.Errorf("thresholds on metrics '%s' have been crossed", strings.Join(breachedThresholds, ", ")),
+					exitcodes.ThresholdsHaveFailed,
+				), errext.AbortedByThresholdsAfterTestEnd)
+		}
+	} else if stopErr := ingester.Stop(); stopErr != nil {
+		logger.WithError(stopErr).Warn("There was a problem stopping the output ingester")
+	}
+
+	if !testRunState.RuntimeOptions.NoSummary.Bool {
+		summaryResult, hsErr := test.initRunner.HandleSummary(c.gs.Ctx, &lib.Summary{
+			Metrics:         metricsEngine.ObservedMetrics,
+			RootGroup:       testRunState.Runner.GetDefaultGroup(),
+			TestRunDuration: coordinator.GetCurrentTestRunDuration(),
+			NoColor:         c.gs.Flags.NoColor,
+			UIState: lib.UIState{
+				IsStdOutTTY: c.gs.Stdout.IsTTY,
+				IsStdErrTTY: c.gs.Stderr.IsTTY,
+			},
+			TimeSeries:          metricsEngine.SummaryTimeSeries(),
+			CompositeThresholds: metricsEngine.CompositeThresholds(),
+		})
+		if hsErr == nil {
+			hsErr = handleSummaryResult(c.gs.FS, c.gs.Stdout, c.gs.Stderr, summaryResult)
+		}
+		if hsErr != nil {
+			logger.WithError(hsErr).Error("failed to handle the end-of-test summary")
+		}
+	}
+
+	return err
+}
+
+func (c *cmdCoordinator) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.AddFlagSet(optionFlagSet())
+	flags.AddFlagSet(runtimeOptionFlagSet(false))
+	flags.IntVar(&c.instanceCount, "instance-count", c.instanceCount, "number of agents that will run the test")
+	flags.StringVar(&c.address, "coordinator-address", c.address, "address on which the coordinator listens for agents")
+	flags.DurationVar(&c.agentTimeout, "agent-timeout", c.agentTimeout,
+		"how long an agent can go without contacting the coordinator before the test fails")
+	return flags
+}
+
+func getCmdCoordinator(gs *state.GlobalState) *cobra.Command {
+	c := &cmdCoordinator{
+		gs:            gs,
+		instanceCount: 1,
+		address:       "localhost:6566",
+		agentTimeout:  distributed.DefaultAgentTimeout,
+	}
+
+	exampleText := getExampleText(gs, `
+  # Distribute a test with 100 VUs between 4 agents.
+  {{.}} coordinator --instance-count 4 -u 100 -d 1m script.js
+
+  # Start each of the 4 agents, usually on different machines.
+  {{.}} agent coordinator-host:6566`[1:])
+
+	coordinatorCmd := &cobra.Command{
+		Use:   "coordinator",
+		Short: "Coordinate a distributed test",
+		Long: `Coordinate a distributed test.
+
+The coordinator splits the test into equal execution segments for the given
+number of agents and waits for them to register. It synchronizes the agents
+between the stages of the test, shares the setup() data between them and
+aggregates their metrics, so the thresholds are evaluated for the whole test
+and a single end-of-test summary is shown.
+
+The agents can be required to authenticate with the --api-token and the
+--api-tls-* options, which also enable TLS, like for the REST API server.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should either be \"-\", if reading script from stdin, or a path to a script file"),
+		RunE:    c.run,
+	}
+
+	coordinatorCmd.Flags().SortFlags = false
+	coordinatorCmd.Flags().AddFlagSet(c.flagSet())
+
+	return coordinatorCmd
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/loader"
+)
+
+// cmdDeps handles the `k6 deps` sub-command
+type cmdDeps struct {
+	gs *state.GlobalState
+}
+
+func (c *cmdDeps) run(cmd *cobra.Command, args []string) error {
+	// the lockfile isn't used, so the remote modules are fetched again and
+	// the lockfile is updated with their current content
+	test, err := loadLocalTestWithLockfile(c.gs, cmd, args, false)
+	if err != nil {
+		return err
+	}
+	if detectTestType(test.source.Data) == testTypeArchive {
+		return errors.New("the archives already contain all of their modules, so they don't need a lockfile")
+	}
+
+	specifiers := append(test.moduleResolver.Imported(), test.source.URL.String())
+	lockPath := lockfilePath(c.gs, test.sourceRootPath, test.pwd)
+	cacheDir := filepath.Join(filepath.Dir(lockPath), loader.ModulesCacheDir)
+
+	lock := loader.NewLockfile()
+	locked, err := lock.LockModules(test.fileSystems, c.gs.FS, cacheDir, specifiers)
+	if err != nil {
+		return err
+	}
+	if err = lock.Write(c.gs.FS, lockPath); err != nil {
+		return err
+	}
+
+	for _, specifier := range locked {
+		c.gs.Logger.Debugf("Locked the remote module '%s'", specifier)
+	}
+	printToStdout(c.gs, fmt.Sprintf("Locked %d remote modules in '%s'\n", len(locked), lockPath))
+	return nil
+}
+
+func (c *cmdDeps) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.AddFlagSet(runtimeOptionFlagSet(false))
+	return flags
+}
+
+func getCmdDeps(gs *state.GlobalState) *cobra.Command {
+	c := &cmdDeps{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Lock the remote modules of a script.
+  {{.}} deps script.js
+
+  # Run the script only with the locked remote modules.
+  {{.}} run --frozen-lockfile script.js`[1:])
+
+	depsCmd := &cobra.Command{
+		Use:   "deps [file]",
+		Short: "Lock the remote modules of a script",
+		Long: `Lock the remote modules of a script.
+
+The remote modules (e.g. from https URLs or github.com), which are imported by
+the script in its init context, are written with their integrity hashes to the
+k6.lock lockfile next to the script, and cached in the .k6/modules directory,
+so they can be loaded offline. The tests with a lockfile always use the locked
+content of the modules, and fail if it changes. With --frozen-lockfile, the
+remote modules that aren't in the lockfile can't be loaded.
+
+Running it again updates the lockfile with the current content of the modules.`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.run,
+	}
+
+	depsCmd.Flags().SortFlags = false
+	depsCmd.Flags().AddFlagSet(c.flagSet())
+
+	return depsCmd
+}
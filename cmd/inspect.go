@@ -2,17 +2,20 @@ package cmd
 
 import (
 	"encoding/json"
+	"math"
 
 	"github.com/spf13/cobra"
 
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/limits"
 	"go.k6.io/k6/lib/types"
 )
 
 // TODO: split apart like `k6 run` and `k6 archive`
 func getCmdInspect(gs *state.GlobalState) *cobra.Command {
-	var addExecReqs bool
+	var addExecReqs, addExecPlan bool
 
 	// inspectCmd represents the inspect command
 	inspectCmd := &cobra.Command{
@@ -26,12 +29,14 @@ func getCmdInspect(gs *state.GlobalState) *cobra.Command {
 				return err
 			}
 
-			// At the moment, `k6 inspect` output can take 2 forms: standard
-			// (equal to the lib.Options struct) and extended, with additional
-			// fields with execution requirements.
+			// At the moment, `k6 inspect` output can take 3 forms: standard
+			// (equal to the lib.Options struct), extended, with the
+			// consolidated options and additional fields with execution
+			// requirements, and extended with the execution plan of each
+			// scenario too.
 			var inspectOutput interface{}
-			if addExecReqs {
-				inspectOutput, err = inspectOutputWithExecRequirements(gs, cmd, test)
+			if addExecReqs || addExecPlan {
+				inspectOutput, err = inspectOutputWithExecRequirements(gs, cmd, test, addExecPlan)
 				if err != nil {
 					return err
 				}
@@ -51,21 +56,28 @@ func getCmdInspect(gs *state.GlobalState) *cobra.Command {
 
 	inspectCmd.Flags().SortFlags = false
 	inspectCmd.Flags().AddFlagSet(runtimeOptionFlagSet(false))
+	inspectCmd.Flags().AddFlagSet(optionFlagSet())
 	inspectCmd.Flags().BoolVar(&addExecReqs,
 		"execution-requirements",
 		false,
-		"include calculations of execution requirements for the test")
+		"include calculations of execution requirements for the test, with the options of the config file, "+
+			"the environment variables and the flags")
+	inspectCmd.Flags().BoolVar(&addExecPlan,
+		"execution-plan",
+		false,
+		"include the execution requirements and the plan of the VUs over time of each scenario")
 
 	return inspectCmd
 }
 
-// If --execution-requirements is enabled, this will consolidate the config,
-// derive the value of `scenarios` and calculate the max test duration and VUs.
+// If --execution-requirements or --execution-plan is enabled, this will
+// consolidate the config, derive the value of `scenarios` and calculate the max
+// test duration, VUs and iterations, check the limits of the system for the VUs,
+// and with withPlan the plan of each scenario.
 func inspectOutputWithExecRequirements(
-	gs *state.GlobalState, cmd *cobra.Command, test *loadedTest,
+	gs *state.GlobalState, cmd *cobra.Command, test *loadedTest, withPlan bool,
 ) (interface{}, error) {
-	// we don't actually support CLI flags here, so we pass nil as the getter
-	configuredTest, err := test.consolidateDeriveAndValidateConfig(gs, cmd, nil)
+	configuredTest, err := test.consolidateDeriveAndValidateConfig(gs, cmd, getPartialConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -81,13 +93,115 @@ func inspectOutputWithExecRequirements(
 	executionPlan := configuredTest.derivedConfig.Scenarios.GetFullExecutionRequirements(et)
 	duration, _ := lib.GetEndOffset(executionPlan)
 
-	return struct {
+	output := struct {
 		lib.Options
-		TotalDuration types.NullDuration `json:"totalDuration"`
-		MaxVUs        uint64             `json:"maxVUs"`
+		TotalDuration types.NullDuration             `json:"totalDuration"`
+		MaxVUs        uint64                         `json:"maxVUs"`
+		MaxIterations *int64                         `json:"maxIterations,omitempty"`
+		ExecutionPlan map[string]inspectScenarioPlan `json:"executionPlan,omitempty"`
+		Limits        limits.Report                  `json:"limits"`
 	}{
-		configuredTest.derivedConfig.Options,
-		types.NewNullDuration(duration, true),
-		lib.GetMaxPossibleVUs(executionPlan),
-	}, nil
+		Options:       configuredTest.derivedConfig.Options,
+		TotalDuration: types.NewNullDuration(duration, true),
+		MaxVUs:        lib.GetMaxPossibleVUs(executionPlan),
+		Limits:        limits.Check(lib.GetMaxPossibleVUs(executionPlan), false),
+	}
+	if !withPlan {
+		return output, nil
+	}
+
+	output.ExecutionPlan = make(map[string]inspectScenarioPlan, len(configuredTest.derivedConfig.Scenarios))
+	var totalIterations int64
+	allEstimated := true
+	for name, conf := range configuredTest.derivedConfig.Scenarios {
+		plan := getScenarioPlan(conf, et)
+		output.ExecutionPlan[name] = plan
+		if plan.MaxIterations == nil {
+			allEstimated = false
+			continue
+		}
+		totalIterations += *plan.MaxIterations
+	}
+	if allEstimated {
+		output.MaxIterations = &totalIterations
+	}
+	return output, nil
+}
+
+// inspectScenarioPlan is the execution plan of a scenario.
+type inspectScenarioPlan struct {
+	Executor    string         `json:"executor"`
+	Description string         `json:"description"`
+	StartTime   types.Duration `json:"startTime"`
+	// Duration is the longest the scenario can take, with its graceful stop.
+	Duration types.Duration `json:"duration"`
+	MaxVUs   uint64         `json:"maxVUs"`
+	// MaxIterations is the most iterations the scenario can run, which is the
+	// most rows of a data set it can use; it's only known for the scenarios
+	// whose number of iterations doesn't depend on the duration of the
+	// iterations.
+	MaxIterations *int64 `json:"maxIterations"`
+	// Steps are the VUs the scenario needs over time, from its start time.
+	Steps []inspectExecutionStep `json:"steps"`
+}
+
+// inspectExecutionStep is a change of the VUs a scenario needs.
+type inspectExecutionStep struct {
+	TimeOffset      types.Duration `json:"timeOffset"`
+	PlannedVUs      uint64         `json:"plannedVUs"`
+	MaxUnplannedVUs uint64         `json:"maxUnplannedVUs"`
+}
+
+func getScenarioPlan(conf lib.ExecutorConfig, et *lib.ExecutionTuple) inspectScenarioPlan {
+	steps := conf.GetExecutionRequirements(et)
+	duration, _ := lib.GetEndOffset(steps)
+	plan := inspectScenarioPlan{
+		Executor:    conf.GetType(),
+		Description: conf.GetDescription(et),
+		StartTime:   types.Duration(conf.GetStartTime()),
+		Duration:    types.Duration(duration),
+		MaxVUs:      lib.GetMaxPossibleVUs(steps),
+		Steps:       make([]inspectExecutionStep, 0, len(steps)),
+	}
+	if iterations, ok := estimateMaxIterations(conf, et); ok {
+		plan.MaxIterations = &iterations
+	}
+	for _, step := range steps {
+		plan.Steps = append(plan.Steps, inspectExecutionStep{
+			TimeOffset:      types.Duration(step.TimeOffset),
+			PlannedVUs:      step.PlannedVUs,
+			MaxUnplannedVUs: step.MaxUnplannedVUs,
+		})
+	}
+	return plan
+}
+
+// estimateMaxIterations returns the most iterations the iterations-based and
+// the arrival-rate executors can run. The arrival-rate ones can run less of
+// them, if they don't have enough VUs.
+func estimateMaxIterations(conf lib.ExecutorConfig, et *lib.ExecutionTuple) (int64, bool) {
+	switch c := conf.(type) {
+	case *executor.SharedIterationsConfig:
+		return estimateMaxIterations(*c, et)
+	case *executor.PerVUIterationsConfig:
+		return estimateMaxIterations(*c, et)
+	case executor.SharedIterationsConfig:
+		return c.GetIterations(et), true
+	case executor.PerVUIterationsConfig:
+		return c.GetVUs(et) * c.GetIterations(), true
+	case *executor.ConstantArrivalRateConfig:
+		rate := float64(et.ScaleInt64(c.Rate.Int64)) / float64(c.TimeUnit.TimeDuration())
+		return int64(math.Ceil(rate * float64(c.Duration.TimeDuration()))), true
+	case *executor.RampingArrivalRateConfig:
+		var iterations float64
+		from := float64(et.ScaleInt64(c.StartRate.Int64))
+		for _, stage := range c.Stages {
+			to := float64(et.ScaleInt64(stage.Target.Int64))
+			iterations += (from + to) / 2 * float64(stage.Duration.TimeDuration()) / float64(c.TimeUnit.TimeDuration())
+			from = to
+		}
+		return int64(math.Ceil(iterations)), true
+	default:
+		return 0, false
+	}
 }
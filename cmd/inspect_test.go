@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/limits"
+	"go.k6.io/k6/lib/types"
+)
+
+type inspectPlanOutput struct {
+	VUs           int64                          `json:"vus"`
+	TotalDuration string                         `json:"totalDuration"`
+	MaxVUs        uint64                         `json:"maxVUs"`
+	MaxIterations *int64                         `json:"maxIterations"`
+	ExecutionPlan map[string]inspectScenarioPlan `json:"executionPlan"`
+	Limits        *limits.Report                 `json:"limits"`
+}
+
+func runInspect(t *testing.T, script string, args ...string) []byte {
+	t.Helper()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "script.js"), []byte(script), 0o644))
+	ts.CmdArgs = append(append([]string{"k6", "inspect"}, args...), "script.js")
+	newRootCommand(ts.GlobalState).execute()
+	return ts.Stdout.Bytes()
+}
+
+func TestInspectExecutionPlan(t *testing.T) {
+	t.Parallel()
+
+	stdout := runInspect(t, `
+		export const options = {
+			scenarios: {
+				shared: { executor: 'shared-iterations', vus: 2, iterations: 10, maxDuration: '10s' },
+				rate: {
+					executor: 'constant-arrival-rate', rate: 5, duration: '10s',
+					preAllocatedVUs: 3, maxVUs: 5, startTime: '10s',
+				},
+			},
+		};
+		export default function () {}
+	`, "--execution-plan")
+
+	var output inspectPlanOutput
+	require.NoError(t, json.Unmarshal(stdout, &output))
+	assert.Equal(t, "50s", output.TotalDuration)
+	assert.Equal(t, uint64(7), output.MaxVUs) // the scenarios overlap, with the graceful stop of shared
+	require.NotNil(t, output.MaxIterations)
+	assert.Equal(t, int64(60), *output.MaxIterations)
+
+	shared := output.ExecutionPlan["shared"]
+	assert.Equal(t, "shared-iterations", shared.Executor)
+	assert.Equal(t, uint64(2), shared.MaxVUs)
+	require.NotNil(t, shared.MaxIterations)
+	assert.Equal(t, int64(10), *shared.MaxIterations)
+	assert.Equal(t, []inspectExecutionStep{
+		{TimeOffset: 0, PlannedVUs: 2},
+		{TimeOffset: types.Duration(40 * time.Second), PlannedVUs: 0},
+	}, shared.Steps)
+
+	rate := output.ExecutionPlan["rate"]
+	assert.Equal(t, "constant-arrival-rate", rate.Executor)
+	assert.Equal(t, "10s", rate.StartTime.String())
+	assert.Equal(t, uint64(5), rate.MaxVUs)
+	require.NotNil(t, rate.MaxIterations)
+	assert.Equal(t, int64(50), *rate.MaxIterations)
+	require.NotEmpty(t, rate.Steps)
+	assert.Equal(t, inspectExecutionStep{PlannedVUs: 3, MaxUnplannedVUs: 2}, rate.Steps[0])
+}
+
+func TestInspectExecutionRequirementsWithFlags(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = { vus: 2, duration: '10s' };
+		export default function () {}
+	`
+	var output inspectPlanOutput
+	require.NoError(t, json.Unmarshal(runInspect(t, script, "--execution-plan", "--vus", "4"), &output))
+	assert.Equal(t, int64(4), output.VUs)
+	assert.Equal(t, uint64(4), output.MaxVUs)
+	assert.Nil(t, output.MaxIterations)
+	require.Contains(t, output.ExecutionPlan, "default")
+	assert.Equal(t, "constant-vus", output.ExecutionPlan["default"].Executor)
+	assert.Nil(t, output.ExecutionPlan["default"].MaxIterations)
+	require.NotNil(t, output.Limits)
+	assert.Equal(t, uint64(4), output.Limits.MaxVUs)
+	assert.Equal(t, uint64(72), output.Limits.NeededFiles)
+
+	// the plain output has the options of the script, and no plan
+	output = inspectPlanOutput{}
+	require.NoError(t, json.Unmarshal(runInspect(t, script, "--vus", "4"), &output))
+	assert.Equal(t, int64(2), output.VUs)
+	assert.Nil(t, output.ExecutionPlan)
+	assert.Nil(t, output.Limits)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/js/lint"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// lintedScript is a script and its issues, in the JSON output of `k6 lint`.
+type lintedScript struct {
+	Path   string       `json:"path"`
+	Issues []lint.Issue `json:"issues"`
+}
+
+// cmdLint handles the `k6 lint` sub-command
+type cmdLint struct {
+	gs     *state.GlobalState
+	asJSON bool
+	strict bool
+}
+
+func (c *cmdLint) run(_ *cobra.Command, args []string) error {
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return err
+	}
+	scripts := make([]lintedScript, 0, len(args))
+	var errorCount, warningCount int
+	for _, path := range args {
+		absPath := path
+		if !filepath.IsAbs(absPath) {
+			absPath = filepath.Join(pwd, path)
+		}
+		src, err := fsext.ReadFile(c.gs.FS, absPath)
+		if err != nil {
+			return fmt.Errorf("couldn't read the script '%s': %w", path, err)
+		}
+		issues, err := lint.Lint(string(src), path)
+		if err != nil {
+			return fmt.Errorf("couldn't parse the script '%s': %w", path, err)
+		}
+		for _, issue := range issues {
+			if issue.Severity == lint.SeverityError {
+				errorCount++
+			} else {
+				warningCount++
+			}
+		}
+		if issues == nil {
+			issues = []lint.Issue{}
+		}
+		scripts = append(scripts, lintedScript{Path: path, Issues: issues})
+	}
+
+	if c.asJSON {
+		data, err := json.MarshalIndent(scripts, "", "  ")
+		if err != nil {
+			return err
+		}
+		printToStdout(c.gs, string(data)+"\n")
+	} else {
+		printToStdout(c.gs, formatLintedScripts(scripts, errorCount, warningCount))
+	}
+
+	if errorCount > 0 || (c.strict && warningCount > 0) {
+		return errext.WithExitCodeIfNone(
+			errors.New("the scripts have issues"), exitcodes.LintFailed)
+	}
+	return nil
+}
+
+func formatLintedScripts(scripts []lintedScript, errorCount, warningCount int) string {
+	var b strings.Builder
+	for _, script := range scripts {
+		for _, issue := range script.Issues {
+			position := script.Path
+			if issue.Line > 0 {
+				position += fmt.Sprintf(":%d:%d", issue.Line, issue.Column)
+			}
+			fmt.Fprintf(&b, "%s: %s: %s (%s)\n", position, issue.Severity, issue.Message, issue.Rule)
+		}
+	}
+	if errorCount+warningCount == 0 {
+		fmt.Fprintf(&b, "No issues in %s\n", pluralize(len(scripts), "script"))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n%s in %s: %s, %s\n", pluralize(errorCount+warningCount, "issue"),
+		pluralize(len(scripts), "script"), pluralize(errorCount, "error"), pluralize(warningCount, "warning"))
+	return b.String()
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+func (c *cmdLint) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.BoolVar(&c.asJSON, "json", false, "print the issues as JSON")
+	flags.BoolVar(&c.strict, "strict", false, "fail on the warnings too, not only on the errors")
+	return flags
+}
+
+func getCmdLint(gs *state.GlobalState) *cobra.Command {
+	c := &cmdLint{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Check a script for the common issues.
+  {{.}} lint script.js
+
+  # Fail on the warnings too, e.g. in CI.
+  {{.}} lint --strict script.js other.js`[1:])
+
+	lintCmd := &cobra.Command{
+		Use:   "lint [file...]",
+		Short: "Check scripts for common issues",
+		Long: `Check scripts for common issues, without running them.
+
+The scripts are parsed, but neither their imports are loaded nor any code is
+run. The issues are:
+  - unawaited-promise: the promise of an async function isn't awaited (warning)
+  - open-outside-init: open() is called in a function which isn't run in the init context (error)
+  - url-cardinality: a request has a dynamic URL without a name tag (warning)
+  - deprecated-api: a deprecated module or API is used (warning)
+  - missing-thresholds: the options don't have thresholds (warning)
+
+The command fails if there are errors, or warnings too with --strict.`,
+		Example: exampleText,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    c.run,
+	}
+	lintCmd.Flags().SortFlags = false
+	lintCmd.Flags().AddFlagSet(c.flagSet())
+	return lintCmd
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/js/lint"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const lintTestScript = `import http from 'k6/http';
+
+export default function () {
+	http.get(` + "`https://test.k6.io/${__VU}`" + `);
+	open('data.json');
+}
+`
+
+func newLintTestState(t *testing.T, script string, args ...string) *tests.GlobalTestState {
+	t.Helper()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "script.js"), []byte(script), 0o644))
+	ts.CmdArgs = append([]string{"k6", "lint"}, args...)
+	return ts
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	ts := newLintTestState(t, lintTestScript, "script.js")
+	ts.ExpectedExitCode = int(exitcodes.LintFailed)
+	newRootCommand(ts.GlobalState).execute()
+
+	assert.Equal(t, "script.js: warning: the script doesn't export options with thresholds, the test can't fail "+
+		"when the performance is too bad (missing-thresholds)\n"+
+		"script.js:4:11: warning: the URL of k6/http.get() is dynamic, each of its values is a separate time series "+
+		"of the metrics, set the name tag or use the http.url template (url-cardinality)\n"+
+		"script.js:5:2: error: open() can only be called in the init context, not in the default() function "+
+		"(open-outside-init)\n"+
+		"\n3 issues in 1 script: 1 error, 2 warnings\n", ts.Stdout.String())
+}
+
+func TestLintWarnings(t *testing.T) {
+	t.Parallel()
+
+	script := "export const options = { vus: 1 };\nexport default function () {}\n"
+
+	ts := newLintTestState(t, script, "--json", "script.js")
+	newRootCommand(ts.GlobalState).execute()
+	var scripts []lintedScript
+	require.NoError(t, json.Unmarshal(ts.Stdout.Bytes(), &scripts))
+	require.Len(t, scripts, 1)
+	require.Len(t, scripts[0].Issues, 1)
+	assert.Equal(t, lint.RuleMissingThresholds, scripts[0].Issues[0].Rule)
+
+	ts = newLintTestState(t, script, "--strict", "script.js")
+	ts.ExpectedExitCode = int(exitcodes.LintFailed)
+	newRootCommand(ts.GlobalState).execute()
+
+	ts = newLintTestState(t, "export const options = { thresholds: {} };\nexport default function () {}\n", "script.js")
+	newRootCommand(ts.GlobalState).execute()
+	assert.Equal(t, "No issues in 1 script\n", ts.Stdout.String())
+}
+
+func TestLintInvalidScript(t *testing.T) {
+	t.Parallel()
+
+	ts := newLintTestState(t, "export default function () {", "script.js", "missing.js")
+	ts.ExpectedExitCode = -1
+	newRootCommand(ts.GlobalState).execute()
+	assert.Contains(t, ts.Stderr.String(), "couldn't parse the script 'script.js'")
+}
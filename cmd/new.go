@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 	"path"
+	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/fatih/color"
@@ -86,17 +88,25 @@ type initScriptTemplateArgs struct {
 type newScriptCmd struct {
 	gs             *state.GlobalState
 	overwriteFiles bool
+	templateName   string
 }
 
 func (c *newScriptCmd) flagSet() *pflag.FlagSet {
 	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
 	flags.SortFlags = false
 	flags.BoolVarP(&c.overwriteFiles, "force", "f", false, "Overwrite existing files")
+	flags.StringVarP(&c.templateName, "template", "t", defaultNewTemplate, fmt.Sprintf(
+		"Template to use for the new script or project, one of: %s", strings.Join(templateNames(), ", "),
+	))
 
 	return flags
 }
 
 func (c *newScriptCmd) run(cmd *cobra.Command, args []string) error { //nolint:revive
+	if c.templateName != defaultNewTemplate {
+		return c.createProject(args)
+	}
+
 	target := defaultNewScriptName
 	if len(args) > 0 {
 		target = args[0]
@@ -136,6 +146,43 @@ func (c *newScriptCmd) run(cmd *cobra.Command, args []string) error { //nolint:r
 	return nil
 }
 
+// createProject creates a new project from one of the project templates, in
+// the directory specified by the first argument or in the current one.
+func (c *newScriptCmd) createProject(args []string) error {
+	pt, ok := projectTemplates[c.templateName]
+	if !ok {
+		return fmt.Errorf("unknown template '%s', it should be one of: %s",
+			c.templateName, strings.Join(templateNames(), ", "))
+	}
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return err
+	}
+	fullDir := dir
+	if !filepath.IsAbs(fullDir) {
+		fullDir = filepath.Join(pwd, fullDir)
+	}
+
+	tmplArgs := projectTemplateArgs{ProjectName: filepath.Base(fullDir)}
+	if err = createProject(c.gs.FS, c.templateName, fullDir, tmplArgs, c.overwriteFiles); err != nil {
+		return err
+	}
+
+	valueColor := getColor(c.gs.Flags.NoColor || !c.gs.Stdout.IsTTY, color.Bold)
+	runCommand := fmt.Sprintf(pt.runCommand, c.gs.BinaryName, filepath.Join(dir, pt.runTarget))
+	printToStdout(c.gs, fmt.Sprintf(
+		"Initialized a new k6 %s project in %s. You can now execute it by running `%s`.\n",
+		c.templateName, valueColor.Sprint(dir), runCommand,
+	))
+
+	return nil
+}
+
 func getCmdNewScript(gs *state.GlobalState) *cobra.Command {
 	c := &newScriptCmd{gs: gs}
 
@@ -147,7 +194,10 @@ func getCmdNewScript(gs *state.GlobalState) *cobra.Command {
   {{.}} new test.js
 
   # Overwrite existing test.js with a minimal k6 script
-  {{.}} new -f test.js`[1:])
+  {{.}} new -f test.js
+
+  # Create a new project for a test suite in the my-suite directory
+  {{.}} new --template suite my-suite`[1:])
 
 	initCmd := &cobra.Command{
 		Use:   "new",
@@ -158,6 +208,10 @@ This command will create a minimal k6 script in the current directory and
 store it in the file specified by the first argument. If no argument is
 provided, the script will be stored in script.js.
 
+With the --template flag, a whole project is created instead, in the directory
+specified by the first argument or in the current directory. The templates are:
+
+` + templateDescriptions() + `
 This command will not overwrite existing files.`,
 		Example: exampleText,
 		Args:    cobra.MaximumNArgs(1),
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+const defaultNewTemplate = "minimal"
+
+//go:embed newtemplates
+var newTemplatesFS embed.FS
+
+// projectTemplate is a template for a whole k6 project, with all of its files
+// in the newtemplates directory.
+type projectTemplate struct {
+	description string
+	// runCommand is how the created project can be run, with %[1]s for the
+	// k6 binary and %[2]s for the runTarget in the project directory.
+	runCommand string
+	runTarget  string
+}
+
+//nolint:gochecknoglobals
+var projectTemplates = map[string]projectTemplate{
+	"protocol": {
+		description: "an HTTP test with checks, thresholds and environment variables",
+		runCommand:  "%[1]s run %[2]s",
+		runTarget:   "script.js",
+	},
+	"browser": {
+		description: "a browser test with web vitals thresholds",
+		runCommand:  "%[1]s run %[2]s",
+		runTarget:   "script.js",
+	},
+	"suite": {
+		description: "a test suite with a smoke test and a multi-scenario load test",
+		runCommand:  "%[1]s run --suite %[2]s",
+		runTarget:   "suite.json",
+	},
+	"typescript": {
+		description: "a TypeScript project that is bundled with esbuild",
+		runCommand:  "cd %[2]s && npm install && npm test",
+	},
+}
+
+type projectTemplateArgs struct {
+	ProjectName string
+}
+
+// templateNames returns the names of all templates, for the help and errors.
+func templateNames() []string {
+	names := []string{defaultNewTemplate}
+	for name := range projectTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// templateDescriptions returns the list of the project templates for the
+// help of the command.
+func templateDescriptions() string {
+	buf := &strings.Builder{}
+	for _, name := range templateNames()[1:] {
+		fmt.Fprintf(buf, "  %-12s %s\n", name, projectTemplates[name].description)
+	}
+	return buf.String()
+}
+
+// templateFiles returns the paths of the files of the given project template,
+// relative to its directory.
+func templateFiles(name string) ([]string, error) {
+	root := path.Join("newtemplates", name)
+	var files []string
+	err := fs.WalkDir(newTemplatesFS, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		files = append(files, strings.TrimPrefix(p, root+"/"))
+		return nil
+	})
+	return files, err
+}
+
+// createProject writes all of the files of the given project template in the
+// target directory. If any of them already exists, nothing is written, unless
+// overwrite is set.
+func createProject(fsys fsext.Fs, name, dir string, args projectTemplateArgs, overwrite bool) error {
+	files, err := templateFiles(name)
+	if err != nil {
+		return err
+	}
+
+	if !overwrite {
+		for _, file := range files {
+			target := filepath.Join(dir, filepath.FromSlash(file))
+			exists, existsErr := fsext.Exists(fsys, target)
+			if existsErr != nil {
+				return existsErr
+			}
+			if exists {
+				return fmt.Errorf("%s already exists, please use the `--force` flag if you want overwrite it", target)
+			}
+		}
+	}
+
+	for _, file := range files {
+		tmpl, parseErr := template.ParseFS(newTemplatesFS, path.Join("newtemplates", name, file))
+		if parseErr != nil {
+			return parseErr
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(file))
+		if err = fsys.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		fd, createErr := fsys.Create(target)
+		if createErr != nil {
+			return createErr
+		}
+		err = tmpl.Execute(fd, args)
+		if closeErr := fd.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -95,3 +96,89 @@ func TestNewScriptCmd_FileExists_Overwrite(t *testing.T) {
 	assert.Contains(t, string(data), "export const options = {")
 	assert.Contains(t, string(data), "export default function() {")
 }
+
+func TestNewScriptCmd_ProjectTemplates(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string][]string{
+		"protocol":   {"script.js"},
+		"browser":    {"script.js"},
+		"suite":      {"suite.json", "smoke.js", "load.js"},
+		"typescript": {"package.json", "tsconfig.json", "src/script.ts"},
+	}
+
+	for name, files := range testCases {
+		name, files := name, files
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := tests.NewGlobalTestState(t)
+			ts.CmdArgs = []string{"k6", "new", "--template", name, "myproject"}
+
+			newRootCommand(ts.GlobalState).execute()
+
+			for _, file := range files {
+				data, err := fsext.ReadFile(ts.FS, filepath.Join(ts.Cwd, "myproject", file))
+				require.NoError(t, err)
+				assert.NotEmpty(t, data)
+				assert.NotContains(t, string(data), "{{")
+			}
+			assert.Contains(t, ts.Stdout.String(), fmt.Sprintf("Initialized a new k6 %s project in myproject", name))
+		})
+	}
+}
+
+func TestNewScriptCmd_ProjectTemplateName(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "new", "-t", "protocol"}
+
+	newRootCommand(ts.GlobalState).execute()
+
+	data, err := fsext.ReadFile(ts.FS, filepath.Join(ts.Cwd, "script.js"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), filepath.Base(ts.Cwd))
+	assert.Contains(t, ts.Stdout.String(), "k6 run script.js")
+}
+
+func TestNewScriptCmd_ProjectExists(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	existing := filepath.Join(ts.Cwd, "myproject", "load.js")
+	require.NoError(t, fsext.WriteFile(ts.FS, existing, []byte("untouched"), 0o644))
+	ts.CmdArgs = []string{"k6", "new", "--template", "suite", "myproject"}
+	ts.ExpectedExitCode = -1
+
+	newRootCommand(ts.GlobalState).execute()
+
+	data, err := fsext.ReadFile(ts.FS, existing)
+	require.NoError(t, err)
+	assert.Equal(t, "untouched", string(data))
+	exists, err := fsext.Exists(ts.FS, filepath.Join(ts.Cwd, "myproject", "suite.json"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	ts = tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, existing, []byte("untouched"), 0o644))
+	ts.CmdArgs = []string{"k6", "new", "--template", "suite", "--force", "myproject"}
+
+	newRootCommand(ts.GlobalState).execute()
+
+	data, err = fsext.ReadFile(ts.FS, existing)
+	require.NoError(t, err)
+	assert.NotEqual(t, "untouched", string(data))
+}
+
+func TestNewScriptCmd_UnknownTemplate(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "new", "--template", "unknown"}
+	ts.ExpectedExitCode = -1
+
+	newRootCommand(ts.GlobalState).execute()
+
+	assert.Contains(t, ts.Stderr.String(), "unknown template 'unknown'")
+}
@@ -0,0 +1,46 @@
+import { browser } from 'k6/experimental/browser';
+import { check } from 'k6';
+
+// The environment specific values are read from environment variables, so the
+// same script can be used for all environments, e.g.
+// `k6 run -e BASE_URL=https://staging.example.com script.js`.
+const BASE_URL = __ENV.BASE_URL || 'https://test.k6.io';
+
+export const options = {
+  scenarios: {
+    ui: {
+      // Browser tests need a scenario with the browser type option.
+      //
+      // See https://grafana.com/docs/k6/latest/using-k6-browser/ to learn more.
+      executor: 'shared-iterations',
+      vus: 1,
+      iterations: 1,
+      options: {
+        browser: {
+          type: 'chromium',
+        },
+      },
+    },
+  },
+
+  // The thresholds are the pass/fail criteria of the test.
+  thresholds: {
+    // All of the checks should pass.
+    checks: ['rate==1'],
+    // The largest contentful paint should be good for 75% of the page loads.
+    browser_web_vital_lcp: ['p(75)<2500'],
+  },
+};
+
+export default async function () {
+  const page = browser.newPage();
+
+  try {
+    await page.goto(BASE_URL);
+    check(page, {
+      'header is shown': (p) => p.locator('h1').textContent() !== '',
+    });
+  } finally {
+    page.close();
+  }
+}
@@ -0,0 +1,39 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+
+// The environment specific values are read from environment variables, so the
+// same script can be used for all environments, e.g.
+// `k6 run -e BASE_URL=https://staging.example.com script.js`.
+const BASE_URL = __ENV.BASE_URL || 'https://test.k6.io';
+
+export const options = {
+  vus: 10,
+  duration: '30s',
+
+  // The thresholds are the pass/fail criteria of the test. If any of them
+  // fails, k6 exits with a non-zero exit code, which fails CI pipelines.
+  //
+  // See https://grafana.com/docs/k6/latest/using-k6/thresholds/ to learn more.
+  thresholds: {
+    // 95% of the requests should be faster than 500ms.
+    http_req_duration: ['p(95)<500'],
+    // Less than 1% of the requests should fail.
+    http_req_failed: ['rate<0.01'],
+    // All of the checks should pass.
+    checks: ['rate==1'],
+  },
+
+  ext: {
+    loadimpact: {
+      name: '{{ .ProjectName }}',
+    },
+  },
+};
+
+export default function () {
+  const res = http.get(`${BASE_URL}/`);
+  check(res, {
+    'status is 200': (r) => r.status === 200,
+  });
+  sleep(1);
+}
@@ -0,0 +1,50 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+
+const BASE_URL = __ENV.BASE_URL || 'https://test.k6.io';
+
+// The load test has multiple scenarios, which run at the same time and model
+// different kinds of users.
+//
+// See https://grafana.com/docs/k6/latest/using-k6/scenarios/ to learn more.
+export const options = {
+  scenarios: {
+    browsing: {
+      executor: 'ramping-vus',
+      exec: 'browse',
+      stages: [
+        { duration: '30s', target: 10 },
+        { duration: '1m', target: 10 },
+        { duration: '30s', target: 0 },
+      ],
+    },
+    api: {
+      executor: 'constant-arrival-rate',
+      exec: 'api',
+      rate: 5,
+      timeUnit: '1s',
+      duration: '2m',
+      preAllocatedVUs: 10,
+    },
+  },
+  thresholds: {
+    // Each scenario can have its own thresholds, with the scenario tag.
+    'http_req_duration{scenario:browsing}': ['p(95)<1000'],
+    'http_req_duration{scenario:api}': ['p(95)<300'],
+  },
+};
+
+export function browse() {
+  const res = http.get(`${BASE_URL}/`);
+  check(res, {
+    'status is 200': (r) => r.status === 200,
+  });
+  sleep(1);
+}
+
+export function api() {
+  const res = http.get(`${BASE_URL}/contacts.php`);
+  check(res, {
+    'status is 200': (r) => r.status === 200,
+  });
+}
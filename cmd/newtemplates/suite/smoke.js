@@ -0,0 +1,24 @@
+import http from 'k6/http';
+import { check } from 'k6';
+
+// The environment specific values are read from environment variables, so the
+// same tests can be used for all environments, e.g.
+// `k6 run -e BASE_URL=https://staging.example.com --suite suite.json`.
+const BASE_URL = __ENV.BASE_URL || 'https://test.k6.io';
+
+// The smoke test checks that the system works with minimal load, before the
+// load test of the suite starts.
+export const options = {
+  vus: 1,
+  iterations: 5,
+  thresholds: {
+    checks: ['rate==1'],
+  },
+};
+
+export default function () {
+  const res = http.get(`${BASE_URL}/`);
+  check(res, {
+    'status is 200': (r) => r.status === 200,
+  });
+}
@@ -0,0 +1,30 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { Options } from 'k6/options';
+
+// The environment specific values are read from environment variables, so the
+// same script can be used for all environments, e.g.
+// `k6 run -e BASE_URL=https://staging.example.com dist/script.js`.
+const BASE_URL: string = __ENV.BASE_URL || 'https://test.k6.io';
+
+export const options: Options = {
+  vus: 10,
+  duration: '30s',
+
+  // The thresholds are the pass/fail criteria of the test.
+  thresholds: {
+    http_req_duration: ['p(95)<500'],
+    http_req_failed: ['rate<0.01'],
+    checks: ['rate==1'],
+  },
+};
+
+// The scripts are bundled with esbuild by `npm run build`, so they can
+// import other TypeScript files and npm packages.
+export default function (): void {
+  const res = http.get(`${BASE_URL}/`);
+  check(res, {
+    'status is 200': (r) => r.status === 200,
+  });
+  sleep(1);
+}
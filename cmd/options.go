@@ -38,9 +38,15 @@ func optionFlagSet() *pflag.FlagSet {
 	flags.Int64("batch-per-host", 6, "max parallel batch reqs per host")
 	flags.Int64("rps", 0, "limit requests per second")
 	flags.String("user-agent", fmt.Sprintf("k6/%s (https://k6.io/)", consts.Version), "user agent for http requests")
+	flags.String("correlation-id-header", lib.DefaultCorrelationIDHeader,
+		"header in which the iteration correlation ID is sent with every request, empty to disable")
+	flags.String("trace-propagator", "",
+		"propagate a new trace context with every HTTP request, in one of the w3c, b3 or jaeger formats")
+	flags.Float64("trace-sampling", 1.0, "the rate between 0.0 and 1.0 at which the propagated traces are sampled")
 	flags.String("http-debug", "", "log all HTTP requests and responses. Excludes body by default. To include body use '--http-debug=full'") //nolint:lll
 	flags.Lookup("http-debug").NoOptDefVal = "headers"
 	flags.Bool("insecure-skip-tls-verify", false, "skip verification of TLS certificates")
+	flags.Bool("tls-session-resumption", false, "resume the TLS sessions of the previous connections of each VU")
 	flags.Bool("no-connection-reuse", false, "disable keep-alive connections")
 	flags.Bool("no-vu-connection-reuse", false, "don't reuse connections between iterations")
 	flags.Duration("min-iteration-duration", 0, "minimum amount of time k6 will take executing a single iteration")
@@ -52,10 +58,26 @@ func optionFlagSet() *pflag.FlagSet {
 	// The comment about system-tags also applies for summary-trend-stats. The default values
 	// are set in applyDefault().
 	sumTrendStatsHelp := fmt.Sprintf(
-		"define `stats` for trend metrics (response times), one or more as 'avg,p(95),...' (default '%s')",
+		"define `stats` for trend metrics (response times), one or more as 'avg,p(95),stddev,iqr,tmean(10),...' "+
+			"(default '%s')",
 		strings.Join(lib.DefaultSummaryTrendStats, ","),
 	)
 	flags.StringSlice("summary-trend-stats", nil, sumTrendStatsHelp)
+	flags.StringSlice("summary-breakdown", nil,
+		"break the metrics in the summary down by these `tags`, one or both of 'scenario,group'")
+	flags.Int("trend-significant-digits", metrics.DefaultHistogramSignificantDigits,
+		"precision of the histograms of the trend metrics with too many values to keep all of them")
+	flags.Int("max-time-series", 0,
+		"limit of the unique time series (metric and tags combinations), disabled with 0")
+	flags.String("max-time-series-action", metrics.CardinalityWarn,
+		"what to do with the samples of the time series over the limit: 'warn', 'drop' or 'overflow'")
+	flags.Int("max-memory", 0,
+		"stop the test run when k6 uses more than this memory, in `megabytes`, disabled with 0")
+	flags.Bool("generator-metrics", false,
+		"emit the health metrics of the load generator, like its CPU and memory usage and the event loop lag")
+	flags.Int64("warm-vus", 0,
+		"unplanned VUs to initialize in the background from the start instead of when they are needed, "+
+			"only the ones of the arrival-rate and traffic-replay executors over their preAllocatedVUs")
 	flags.String("summary-time-unit", "", "define the time unit used to display the trend stats. Possible units are: 's', 'ms' and 'us'") //nolint:lll
 	// system-tags must have a default value, but we can't specify it here, otherwiese, it will always override others.
 	// set it to nil here, and add the default in applyDefault() instead.
@@ -67,6 +89,12 @@ func optionFlagSet() *pflag.FlagSet {
 	flags.StringSlice("tag", nil, "add a `tag` to be applied to all samples, as `[name]=[value]`")
 	flags.String("console-output", "", "redirects the console logging to the provided output file")
 	flags.Bool("discard-response-bodies", false, "Read but don't process or save HTTP response bodies")
+	flags.String("capture-failures", "",
+		"write the requests and responses of the failed checks and of the non-2xx statuses to the provided `file`")
+	flags.Int64("capture-failures-limit", lib.DefaultCaptureFailuresLimit,
+		"number of the captured requests per failing check or status")
+	flags.Int64("capture-failures-body-size", lib.DefaultCaptureFailuresBodySize,
+		"number of the bytes of the captured request and response bodies")
 	flags.String("local-ips", "", "Client IP Ranges and/or CIDRs from which each VU will be making requests, "+
 		"e.g. '192.168.220.1,192.168.0.10-192.168.0.25', 'fd:1::0/120', etc.")
 	flags.String("dns", types.DefaultDNSConfig().String(), "DNS resolver configuration. Possible ttl values are: 'inf' "+
@@ -81,6 +109,7 @@ func optionFlagSet() *pflag.FlagSet {
 func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 	opts := lib.Options{
 		VUs:                     getNullInt64(flags, "vus"),
+		WarmVUs:                 getNullInt64(flags, "warm-vus"),
 		Duration:                getNullDuration(flags, "duration"),
 		Iterations:              getNullInt64(flags, "iterations"),
 		Paused:                  getNullBool(flags, "paused"),
@@ -91,13 +120,21 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		BatchPerHost:            getNullInt64(flags, "batch-per-host"),
 		RPS:                     getNullInt64(flags, "rps"),
 		UserAgent:               getNullString(flags, "user-agent"),
+		CorrelationIDHeader:     getNullString(flags, "correlation-id-header"),
+		TracePropagator:         getNullString(flags, "trace-propagator"),
+		TraceSampling:           getNullFloat64(flags, "trace-sampling"),
 		HTTPDebug:               getNullString(flags, "http-debug"),
 		InsecureSkipTLSVerify:   getNullBool(flags, "insecure-skip-tls-verify"),
+		TLSSessionResumption:    getNullBool(flags, "tls-session-resumption"),
 		NoConnectionReuse:       getNullBool(flags, "no-connection-reuse"),
 		NoVUConnectionReuse:     getNullBool(flags, "no-vu-connection-reuse"),
 		MinIterationDuration:    getNullDuration(flags, "min-iteration-duration"),
+		GeneratorMetrics:        getNullBool(flags, "generator-metrics"),
 		Throw:                   getNullBool(flags, "throw"),
 		DiscardResponseBodies:   getNullBool(flags, "discard-response-bodies"),
+		CaptureFailures:         getNullString(flags, "capture-failures"),
+		CaptureFailuresLimit:    getNullInt64(flags, "capture-failures-limit"),
+		CaptureFailuresBodySize: getNullInt64(flags, "capture-failures-body-size"),
 		MetricSamplesBufferSize: null.NewInt(1000, false),
 	}
 
@@ -199,6 +236,46 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		opts.SummaryTrendStats = trendStats
 	}
 
+	if flags.Changed("summary-breakdown") {
+		breakdown, errBreakdown := flags.GetStringSlice("summary-breakdown")
+		if errBreakdown != nil {
+			return opts, errBreakdown
+		}
+		opts.SummaryBreakdown = breakdown
+	}
+
+	if flags.Changed("trend-significant-digits") {
+		digits, errDigits := flags.GetInt("trend-significant-digits")
+		if errDigits != nil {
+			return opts, errDigits
+		}
+		opts.TrendSignificantDigits = null.IntFrom(int64(digits))
+	}
+
+	if flags.Changed("max-time-series") {
+		maxTimeSeries, errMax := flags.GetInt("max-time-series")
+		if errMax != nil {
+			return opts, errMax
+		}
+		opts.MaxTimeSeries = null.IntFrom(int64(maxTimeSeries))
+	}
+
+	if flags.Changed("max-time-series-action") {
+		action, errAction := flags.GetString("max-time-series-action")
+		if errAction != nil {
+			return opts, errAction
+		}
+		opts.MaxTimeSeriesAction = null.StringFrom(action)
+	}
+
+	if flags.Changed("max-memory") {
+		maxMemory, errMax := flags.GetInt("max-memory")
+		if errMax != nil {
+			return opts, errMax
+		}
+		opts.MaxMemory = null.IntFrom(int64(maxMemory))
+	}
+
 	summaryTimeUnit, err := flags.GetString("summary-time-unit")
 	if err != nil {
 		return opts, err
@@ -3,6 +3,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -10,14 +11,20 @@ import (
 	"go.k6.io/k6/ext"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/output"
+	"go.k6.io/k6/output/clickhouse"
 	"go.k6.io/k6/output/cloud"
 	"go.k6.io/k6/output/csv"
 	"go.k6.io/k6/output/influxdb"
 	"go.k6.io/k6/output/json"
+	"go.k6.io/k6/output/otelmetrics"
+	"go.k6.io/k6/output/oteltraces"
+	"go.k6.io/k6/output/parquet"
+	"go.k6.io/k6/output/prometheus"
+	"go.k6.io/k6/output/prometheusrw/remotewrite"
 	"go.k6.io/k6/output/statsd"
+	"go.k6.io/k6/output/timescaledb"
 
 	"github.com/grafana/xk6-dashboard/dashboard"
-	"github.com/grafana/xk6-output-prometheus-remote/pkg/remotewrite"
 )
 
 // builtinOutput marks the available builtin outputs.
@@ -26,14 +33,20 @@ import (
 type builtinOutput uint32
 
 const (
-	builtinOutputCloud builtinOutput = iota
+	builtinOutputClickhouse builtinOutput = iota
+	builtinOutputCloud
 	builtinOutputCSV
 	builtinOutputDatadog
 	builtinOutputExperimentalPrometheusRW
 	builtinOutputInfluxdb
 	builtinOutputJSON
 	builtinOutputKafka
+	builtinOutputOpentelemetry
+	builtinOutputOpentelemetryTraces
+	builtinOutputParquet
+	builtinOutputPrometheus
 	builtinOutputStatsd
+	builtinOutputTimescaledb
 )
 
 // TODO: move this to an output sub-module after we get rid of the old collectors?
@@ -48,17 +61,14 @@ func getAllOutputConstructors() (map[string]output.Constructor, error) {
 			return nil, errors.New("the kafka output was deprecated in k6 v0.32.0 and removed in k6 v0.34.0, " +
 				"please use the new xk6 kafka output extension instead - https://github.com/k6io/xk6-output-kafka")
 		},
-		builtinOutputStatsd.String(): func(params output.Params) (output.Output, error) {
-			params.Logger.Warn("The statsd output is deprecated, and will be removed in a future k6 version. " +
-				"Please use the new xk6 statsd output extension instead. " +
-				"It can be found at https://github.com/LeonAdato/xk6-output-statsd and " +
-				"more info at https://github.com/grafana/k6/issues/2982.")
-			return statsd.New(params)
-		},
-		builtinOutputDatadog.String(): func(params output.Params) (output.Output, error) {
-			return nil, errors.New("the datadog output was deprecated in k6 v0.32.0 and removed in k6 v0.34.0, " +
-				"please use the statsd output with env. variable K6_STATSD_ENABLE_TAGS=true instead")
-		},
+		builtinOutputOpentelemetry.String():       otelmetrics.New,
+		builtinOutputOpentelemetryTraces.String(): oteltraces.New,
+		builtinOutputParquet.String():             parquet.New,
+		builtinOutputPrometheus.String():          prometheus.New,
+		builtinOutputClickhouse.String():          clickhouse.New,
+		builtinOutputTimescaledb.String():         timescaledb.New,
+		builtinOutputStatsd.String():              statsd.New,
+		builtinOutputDatadog.String():             statsd.NewDatadog,
 		builtinOutputExperimentalPrometheusRW.String(): func(params output.Params) (output.Output, error) {
 			return remotewrite.New(params)
 		},
@@ -113,7 +123,11 @@ func createOutputs(
 
 	outputs := test.derivedConfig.Out
 	if test.derivedConfig.WebDashboard.Bool {
-		outputs = append(outputs, dashboard.OutputName)
+		dashboardOutput := dashboard.OutputName
+		if export := test.derivedConfig.WebDashboardExport.String; export != "" {
+			dashboardOutput += "=export=" + url.QueryEscape(export)
+		}
+		outputs = append(outputs, dashboardOutput)
 	}
 
 	result := make([]output.Output, 0, len(outputs))
@@ -146,6 +160,20 @@ func createOutputs(
 			builtinMetricOut.SetBuiltinMetrics(test.preInitState.BuiltinMetrics)
 		}
 
+		if aggregation, ok := test.derivedConfig.OutputAggregation[outputType]; ok {
+			out, err = output.NewAggregatedOutput(out, aggregation)
+			if err != nil {
+				return nil, fmt.Errorf("could not aggregate the '%s' output: %w", outputType, err)
+			}
+		}
+
+		if filter, ok := test.derivedConfig.OutputFilters[outputType]; ok && !filter.IsEmpty() {
+			out, err = output.NewFilteredOutput(out, filter)
+			if err != nil {
+				return nil, fmt.Errorf("could not filter the '%s' output: %w", outputType, err)
+			}
+		}
+
 		result = append(result, out)
 	}
 
@@ -9,8 +9,9 @@ import (
 func TestBuiltinOutputString(t *testing.T) {
 	t.Parallel()
 	exp := []string{
-		"cloud", "csv", "datadog", "experimental-prometheus-rw",
-		"influxdb", "json", "kafka", "statsd",
+		"clickhouse", "cloud", "csv", "datadog", "experimental-prometheus-rw",
+		"influxdb", "json", "kafka", "opentelemetry", "opentelemetry-traces", "parquet", "prometheus",
+		"statsd", "timescaledb",
 	}
 	assert.Equal(t, exp, builtinOutputStrings())
 }
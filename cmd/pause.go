@@ -5,7 +5,6 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	v1 "go.k6.io/k6/api/v1"
-	"go.k6.io/k6/api/v1/client"
 	"go.k6.io/k6/cmd/state"
 )
 
@@ -16,12 +15,22 @@ func getCmdPause(gs *state.GlobalState) *cobra.Command {
 		Short: "Pause a running test",
 		Long: `Pause a running test.
 
-  Use the global --address flag to specify the URL to the API server.`,
+  Use the --scenario flag to pause only a single scenario, while the others keep
+  running, and the global --address flag to specify the URL to the API server.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c, err := client.New(gs.Flags.Address)
+			c, err := newAPIClient(gs)
 			if err != nil {
 				return err
 			}
+			if scenario, _ := cmd.Flags().GetString("scenario"); scenario != "" {
+				s, setErr := c.SetScenario(gs.Ctx, scenario, v1.Scenario{
+					Paused: null.BoolFrom(true),
+				})
+				if setErr != nil {
+					return setErr
+				}
+				return yamlPrint(gs.Stdout, s)
+			}
 			status, err := c.SetStatus(gs.Ctx, v1.Status{
 				Paused: null.BoolFrom(true),
 			})
@@ -31,5 +40,8 @@ func getCmdPause(gs *state.GlobalState) *cobra.Command {
 			return yamlPrint(gs.Stdout, status)
 		},
 	}
+
+	pauseCmd.Flags().String("scenario", "", "pause only the scenario with this `name`")
+
 	return pauseCmd
 }
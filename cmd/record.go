@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/recorder"
+)
+
+// The formats of the output of `k6 record`.
+const (
+	recordFormatScript = "script"
+	recordFormatHAR    = "har"
+)
+
+// cmdRecord handles the `k6 record` sub-command
+type cmdRecord struct {
+	gs                    *state.GlobalState
+	listen                string
+	output                string
+	format                string
+	caCert, caKey         string
+	insecureSkipTLSVerify bool
+	convert               convertOptions
+}
+
+func (c *cmdRecord) run(_ *cobra.Command, _ []string) error {
+	if c.format != recordFormatScript && c.format != recordFormatHAR {
+		return fmt.Errorf("invalid format '%s', it should be either '%s' or '%s'",
+			c.format, recordFormatScript, recordFormatHAR)
+	}
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return err
+	}
+	absPath := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(pwd, path)
+	}
+
+	ca, err := c.loadOrCreateCA(absPath(c.caCert), absPath(c.caKey))
+	if err != nil {
+		return err
+	}
+	transport := &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:   true,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: c.insecureSkipTLSVerify, //nolint:gosec
+			MinVersion:         tls.VersionTLS12,
+		},
+	}
+	rec, err := recorder.New(ca, transport, c.gs.Logger)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", c.listen)
+	if err != nil {
+		return fmt.Errorf("couldn't start the recording proxy: %w", err)
+	}
+	srv := &http.Server{Handler: rec, ReadHeaderTimeout: 10 * time.Second}
+	srvErr := make(chan error, 1)
+	go func() {
+		srvErr <- srv.Serve(listener)
+	}()
+
+	ctx, cancel := context.WithCancel(c.gs.Ctx)
+	defer cancel()
+	stopSignalHandling := handleTestAbortSignals(c.gs, func(os.Signal) { cancel() }, nil)
+	defer stopSignalHandling()
+
+	c.gs.Logger.Infof("Recording the traffic through the proxy at http://%s, press Ctrl+C to stop...",
+		listener.Addr())
+	select {
+	case <-ctx.Done():
+	case err = <-srvErr:
+		return fmt.Errorf("the recording proxy failed: %w", err)
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err = srv.Shutdown(shutdownCtx); err != nil {
+		c.gs.Logger.WithError(err).Debug("The recording proxy didn't shut down gracefully")
+	}
+	if err = rec.Close(); err != nil {
+		c.gs.Logger.WithError(err).Debug("Couldn't close the tunnels of the recording proxy")
+	}
+
+	recording := rec.HAR()
+	if len(recording.Log.Entries) == 0 {
+		return errors.New("no requests were recorded")
+	}
+	c.gs.Logger.Infof("Recorded %d requests", len(recording.Log.Entries))
+
+	var data []byte
+	if c.format == recordFormatHAR {
+		data, err = json.MarshalIndent(recording, "", "  ")
+		data = append(data, '\n')
+	} else {
+		var script string
+		script, err = har.Convert(recording, c.convert.harOptions())
+		data = []byte(script)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.output == "" {
+		printToStdout(c.gs, string(data))
+		return nil
+	}
+	return fsext.WriteFile(c.gs.FS, absPath(c.output), data, 0o644)
+}
+
+// loadOrCreateCA loads the CA of the recorder, or generates it when neither
+// its certificate nor its key exist yet.
+func (c *cmdRecord) loadOrCreateCA(certPath, keyPath string) (tls.Certificate, error) {
+	certExists, err := fsext.Exists(c.gs.FS, certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyExists, err := fsext.Exists(c.gs.FS, keyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	var certPEM, keyPEM []byte
+	switch {
+	case certExists && keyExists:
+		if certPEM, err = fsext.ReadFile(c.gs.FS, certPath); err != nil {
+			return tls.Certificate{}, err
+		}
+		if keyPEM, err = fsext.ReadFile(c.gs.FS, keyPath); err != nil {
+			return tls.Certificate{}, err
+		}
+	case !certExists && !keyExists:
+		if certPEM, keyPEM, err = recorder.NewCA(); err != nil {
+			return tls.Certificate{}, err
+		}
+		if err = fsext.WriteFile(c.gs.FS, certPath, certPEM, 0o644); err != nil {
+			return tls.Certificate{}, err
+		}
+		if err = fsext.WriteFile(c.gs.FS, keyPath, keyPEM, 0o600); err != nil {
+			return tls.Certificate{}, err
+		}
+		c.gs.Logger.Infof("Generated the CA of the recorder, the browser has to trust %s to record the HTTPS traffic",
+			certPath)
+	default:
+		return tls.Certificate{}, fmt.Errorf("either both the certificate '%s' and the key '%s' of the recorder CA "+
+			"have to exist, or neither of them to generate them", certPath, keyPath)
+	}
+	return recorder.LoadCA(certPEM, keyPEM)
+}
+
+func (c *cmdRecord) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVar(&c.listen, "listen", "localhost:8888", "address of the recording proxy")
+	flags.StringVarP(&c.output, "output", "O", "", "write the recording to the file instead of the standard output")
+	flags.StringVar(&c.format, "format", recordFormatScript, "format of the recording, either script or har")
+	flags.StringVar(&c.caCert, "ca-cert", "k6-recorder-ca.pem",
+		"certificate of the CA signing the certificates of the HTTPS hosts, generated if it doesn't exist")
+	flags.StringVar(&c.caKey, "ca-key", "k6-recorder-ca-key.pem", "key of the CA, generated if it doesn't exist")
+	flags.BoolVar(&c.insecureSkipTLSVerify, "insecure-skip-tls-verify", false,
+		"skip the verification of the certificates of the recorded HTTPS hosts")
+	flags.AddFlagSet(c.convert.flagSet())
+	return flags
+}
+
+func getCmdRecord(gs *state.GlobalState) *cobra.Command {
+	c := &cmdRecord{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Record a script through the proxy at localhost:8888.
+  {{.}} record -O script.js
+
+  # Record a HAR file through the proxy at port 9000 of all the interfaces.
+  {{.}} record --listen :9000 --format har -O session.har`[1:])
+
+	recordCmd := &cobra.Command{
+		Use:   "record",
+		Short: "Record a script through a proxy",
+		Long: `Record a script through a proxy.
+
+The browser has to use the proxy for both HTTP and HTTPS while you click
+through the application, and the recording is written when k6 is stopped with
+Ctrl+C. It's either a script, converted the same way as with k6 convert, or a
+HAR file.
+
+The HTTPS traffic is decrypted with certificates signed by the CA of the
+recorder, which the browser has to trust. The CA is generated the first time,
+at the paths of --ca-cert and --ca-key, and it's reused by the next recordings.`,
+		Example: exampleText,
+		Args:    cobra.NoArgs,
+		RunE:    c.run,
+	}
+	recordCmd.Flags().SortFlags = false
+	recordCmd.Flags().AddFlagSet(c.flagSet())
+	return recordCmd
+}
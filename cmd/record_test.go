@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// recordThroughProxy runs k6 record with the args, makes a request to the
+// server through its proxy and stops it.
+func recordThroughProxy(t *testing.T, ts *tests.GlobalTestState, srvURL string, args ...string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	ts.CmdArgs = append([]string{"k6", "record", "--listen", addr}, args...)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		newRootCommand(ts.GlobalState).execute()
+	}()
+
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: addr})}}
+	require.Eventually(t, func() bool {
+		res, err := client.Get(srvURL + "/api/me") //nolint:noctx
+		if err != nil {
+			return false
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		return res.StatusCode == http.StatusOK
+	}, 10*time.Second, 50*time.Millisecond)
+
+	ts.Cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("k6 record didn't stop")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "someone"}`))
+	}))
+	defer srv.Close()
+
+	ts := tests.NewGlobalTestState(t)
+	recordThroughProxy(t, ts, srv.URL, "--format", "har", "-O", "session.har")
+
+	data, err := fsext.ReadFile(ts.FS, filepath.Join(ts.Cwd, "session.har"))
+	require.NoError(t, err)
+	var recording har.HAR
+	require.NoError(t, json.Unmarshal(data, &recording))
+	require.NotEmpty(t, recording.Log.Entries)
+	entry := recording.Log.Entries[len(recording.Log.Entries)-1]
+	assert.Equal(t, srv.URL+"/api/me", entry.Request.URL)
+	assert.Equal(t, `{"name": "someone"}`, entry.Response.Content.Text)
+
+	for _, path := range []string{"k6-recorder-ca.pem", "k6-recorder-ca-key.pem"} {
+		exists, err := fsext.Exists(ts.FS, filepath.Join(ts.Cwd, path))
+		require.NoError(t, err)
+		assert.True(t, exists, path)
+	}
+}
+
+func TestRecordScript(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ts := tests.NewGlobalTestState(t)
+	recordThroughProxy(t, ts, srv.URL)
+
+	assert.Contains(t, ts.Stdout.String(), "import http from 'k6/http';")
+	assert.Contains(t, ts.Stdout.String(), `http.get("`+srv.URL+`/api/me"`)
+}
+
+func TestRecordInvalidCA(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "ca.pem"), []byte("invalid"), 0o644))
+	ts.CmdArgs = []string{"k6", "record", "--listen", "127.0.0.1:0", "--ca-cert", "ca.pem"}
+	ts.ExpectedExitCode = -1
+	newRootCommand(ts.GlobalState).execute()
+
+	assert.Contains(t, ts.Stderr.String(), "either both the certificate")
+}
@@ -5,7 +5,6 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	v1 "go.k6.io/k6/api/v1"
-	"go.k6.io/k6/api/v1/client"
 	"go.k6.io/k6/cmd/state"
 )
 
@@ -16,12 +15,22 @@ func getCmdResume(gs *state.GlobalState) *cobra.Command {
 		Short: "Resume a paused test",
 		Long: `Resume a paused test.
 
-  Use the global --address flag to specify the URL to the API server.`,
+  Use the --scenario flag to resume only a single scenario, while the others keep
+  running, and the global --address flag to specify the URL to the API server.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c, err := client.New(gs.Flags.Address)
+			c, err := newAPIClient(gs)
 			if err != nil {
 				return err
 			}
+			if scenario, _ := cmd.Flags().GetString("scenario"); scenario != "" {
+				s, setErr := c.SetScenario(gs.Ctx, scenario, v1.Scenario{
+					Paused: null.BoolFrom(false),
+				})
+				if setErr != nil {
+					return setErr
+				}
+				return yamlPrint(gs.Stdout, s)
+			}
 			status, err := c.SetStatus(gs.Ctx, v1.Status{
 				Paused: null.BoolFrom(false),
 			})
@@ -32,5 +41,8 @@ func getCmdResume(gs *state.GlobalState) *cobra.Command {
 			return yamlPrint(gs.Stdout, status)
 		},
 	}
+
+	resumeCmd.Flags().String("scenario", "", "resume only the scenario with this `name`")
+
 	return resumeCmd
 }
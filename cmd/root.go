@@ -61,9 +61,9 @@ func newRootCommand(gs *state.GlobalState) *rootCommand {
 	rootCmd.SetIn(gs.Stdin)
 
 	subCommands := []func(*state.GlobalState) *cobra.Command{
-		getCmdArchive, getCmdCloud, getCmdNewScript, getCmdInspect,
-		getCmdLogin, getCmdPause, getCmdResume, getCmdScale, getCmdRun,
-		getCmdStats, getCmdStatus, getCmdVersion,
+		getCmdAgent, getCmdArchive, getCmdCloud, getCmdConfig, getCmdConvert, getCmdCoordinator, getCmdDeps,
+		getCmdNewScript, getCmdInspect, getCmdLint, getCmdLogin, getCmdPause, getCmdRecord, getCmdResume, getCmdScale,
+		getCmdRun, getCmdStats, getCmdStatus, getCmdVersion,
 	}
 
 	for _, sc := range subCommands {
@@ -190,6 +190,23 @@ func rootCmdPersistentFlagSet(gs *state.GlobalState) *pflag.FlagSet {
 		gs.DefaultFlags.ProfilingEnabled,
 		"enable profiling (pprof) endpoints, k6's REST API should be enabled as well",
 	)
+	flags.StringVar(
+		&gs.Flags.APIToken, "api-token", gs.Flags.APIToken,
+		"token that's required for all requests to the REST API server and sent by the commands that use it",
+	)
+	flags.Lookup("api-token").DefValue = "" // don't show the token from K6_API_TOKEN in the usage message
+	flags.StringVar(
+		&gs.Flags.APITLSCert, "api-tls-cert", gs.Flags.APITLSCert,
+		"TLS certificate `file` of the REST API server, or the client certificate for the commands that use it",
+	)
+	flags.StringVar(
+		&gs.Flags.APITLSKey, "api-tls-key", gs.Flags.APITLSKey,
+		"`file` with the private key of the --api-tls-cert certificate",
+	)
+	flags.StringVar(
+		&gs.Flags.APITLSCA, "api-tls-ca", gs.Flags.APITLSCA,
+		"CA certificates `file` for verifying the REST API clients with mTLS, or the server for the commands that use it",
+	)
 
 	return flags
 }
@@ -7,16 +7,20 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/api"
+	v2 "go.k6.io/k6/api/v2"
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/errext"
 	"go.k6.io/k6/errext/exitcodes"
@@ -25,11 +29,17 @@ import (
 	"go.k6.io/k6/execution/local"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/lib/executor"
 	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/limits"
 	"go.k6.io/k6/lib/trace"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/metrics/engine"
+	"go.k6.io/k6/orchestrator"
 	"go.k6.io/k6/output"
+	testreport "go.k6.io/k6/report"
 	"go.k6.io/k6/ui/pb"
 )
 
@@ -39,6 +49,22 @@ type cmdRun struct {
 
 	// TODO: figure out something more elegant?
 	loadConfiguredTest func(cmd *cobra.Command, args []string) (*loadedAndConfiguredTest, execution.Controller, error)
+
+	// extraOutputs are used in addition to the ones configured by the user,
+	// e.g. for sending the metrics of an agent to the coordinator.
+	extraOutputs []output.Output
+
+	// suitePath is the path to the suite manifest, set with --suite.
+	suitePath string
+
+	// dryRun is set with --dry-run, to run a single iteration of each
+	// scenario as a check of the script.
+	dryRun bool
+
+	// lifecycleHooks are the URLs notified of the lifecycle events of the
+	// test run, set with --lifecycle-hook.
+	lifecycleHooks       []string
+	lifecycleHookTimeout time.Duration
 }
 
 const (
@@ -123,6 +149,11 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		}
 	}
 
+	if c.dryRun {
+		logger.Info("Dry run, each scenario runs a single iteration with 1 VU and the thresholds aren't evaluated")
+		test.derivedConfig.Scenarios = dryRunScenarios(test.derivedConfig.Scenarios)
+	}
+
 	// Write the full consolidated *and derived* options back to the Runner.
 	conf := test.derivedConfig
 	testRunState, err := test.buildTestRunState(conf.Options)
@@ -130,6 +161,19 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
+	// The baseline is loaded before the test run, so that a wrong path is
+	// reported right away instead of after the whole test.
+	baseline, baselineTolerances, err := loadBaseline(c.gs.FS, conf)
+	if err != nil {
+		return err
+	}
+	// And so are the reports, with the ones of the summary export, and the
+	// template of the template reports.
+	reports, summaryTemplate, err := loadReports(c.gs.FS, conf, testRunState.RuntimeOptions)
+	if err != nil {
+		return err
+	}
+
 	// Create a local execution scheduler wrapping the runner.
 	logger.Debug("Initializing the execution scheduler...")
 	execScheduler, err := execution.NewScheduler(testRunState, controller)
@@ -160,10 +204,31 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 
 	// Create all outputs.
 	executionPlan := execScheduler.GetExecutionPlan()
+
+	// Running out of the open files or of the ephemeral ports fails the
+	// requests in the middle of the test run, so their limits are checked
+	// against the VUs, and the open files one raised if possible, beforehand.
+	limitsReport := limits.Check(lib.GetMaxPossibleVUs(executionPlan), true)
+	if limitsReport.OpenFiles != nil && limitsReport.OpenFiles.Raised {
+		logger.Debugf("Raised the limit of the open files to %d", limitsReport.OpenFiles.Soft)
+	}
+	for _, warning := range limitsReport.Warnings {
+		logger.Warn(warning)
+	}
+
 	outputs, err := createOutputs(c.gs, test, executionPlan)
 	if err != nil {
 		return err
 	}
+	outputs = append(outputs, c.extraOutputs...)
+
+	// The reports need the metrics over time and per scenario, which aren't
+	// in the end-of-test summary, so they are collected with an output.
+	var reportCollector *testreport.Collector
+	if len(reports) > 0 {
+		reportCollector = testreport.NewCollector(logger, executionPlan)
+		outputs = append(outputs, reportCollector)
+	}
 
 	metricsEngine, err := engine.NewMetricsEngine(testRunState.Registry, logger)
 	if err != nil {
@@ -173,7 +238,8 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	// We'll need to pipe metrics to the MetricsEngine and process them if any
 	// of these are enabled: thresholds, end-of-test summary
 	shouldProcessMetrics := (!testRunState.RuntimeOptions.NoSummary.Bool ||
-		!testRunState.RuntimeOptions.NoThresholds.Bool)
+		!testRunState.RuntimeOptions.NoThresholds.Bool ||
+		hasStartWhenConditions(conf.Scenarios) || len(reports) > 0 || baseline != nil)
 	var metricsIngester *engine.OutputIngester
 	if shouldProcessMetrics {
 		err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, testRunState.RuntimeOptions.NoThresholds.Bool)
@@ -184,9 +250,55 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// thresholds or the end-of-test summary are enabled.
 		metricsIngester = metricsEngine.CreateIngester()
 		outputs = append(outputs, metricsIngester)
+		execScheduler.SetMetricConditionChecker(metricsEngine)
+	}
+
+	// The metric samples are streamed to the REST API clients that want them.
+	var metricsStream *v2.MetricsStream
+	if c.gs.Flags.Address != "" {
+		metricsStream = v2.NewMetricsStream(logger)
+		outputs = append(outputs, metricsStream)
+	}
+
+	// The unique time series are limited before the samples reach any output,
+	// including the MetricsEngine, since too many of them exhaust the memory.
+	var cardinalityGuard *metrics.CardinalityGuard
+	if conf.MaxTimeSeries.Int64 > 0 {
+		maxTimeSeriesAction := metrics.CardinalityWarn
+		if conf.MaxTimeSeriesAction.Valid {
+			maxTimeSeriesAction = conf.MaxTimeSeriesAction.String
+		}
+		cardinalityGuard, err = metrics.NewCardinalityGuard(
+			testRunState.Registry, int(conf.MaxTimeSeries.Int64), maxTimeSeriesAction, logger)
+		if err != nil {
+			return err
+		}
 	}
 
 	executionState := execScheduler.GetState()
+
+	// The external controllers registered as lifecycle hooks are notified of
+	// the start and the end of the test run, of its scenarios and their
+	// stages, and of the crossed thresholds.
+	var notifier *orchestrator.Notifier
+	if len(c.lifecycleHooks) > 0 {
+		hooks := make([]orchestrator.Hook, 0, len(c.lifecycleHooks))
+		for _, hookURL := range c.lifecycleHooks {
+			hook, hErr := orchestrator.NewWebhook(hookURL, c.lifecycleHookTimeout)
+			if hErr != nil {
+				return errext.WithExitCodeIfNone(hErr, exitcodes.InvalidConfig)
+			}
+			hooks = append(hooks, hook)
+		}
+		notifier = orchestrator.NewNotifier(logger, executionState.GetCurrentTestRunDuration, hooks...)
+		execScheduler.SetScenarioObserver(notifier)
+		metricsEngine.OnThresholdsCrossed(func(crossed []string) {
+			notifier.NotifyAsync(globalCtx, orchestrator.Event{Type: orchestrator.ThresholdCrossed, Thresholds: crossed})
+		})
+	}
+	// baselineReport is set once all the metrics have been processed, before
+	// the end-of-test summary is generated.
+	var baselineReport *metrics.BaselineReport
 	if !testRunState.RuntimeOptions.NoSummary.Bool {
 		defer func() {
 			logger.Debug("Generating the end-of-test summary...")
@@ -199,7 +311,15 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 					IsStdOutTTY: c.gs.Stdout.IsTTY,
 					IsStdErrTTY: c.gs.Stderr.IsTTY,
 				},
+				Cardinality:         cardinalityReport(cardinalityGuard),
+				Baseline:            baselineReport,
+				Abort:               summaryAbort(executionState),
+				TimeSeries:          metricsEngine.SummaryTimeSeries(),
+				CompositeThresholds: metricsEngine.CompositeThresholds(),
 			})
+			if hsErr == nil {
+				summaryResult, hsErr = redactSummaryResult(testRunState.Secrets, summaryResult)
+			}
 			if hsErr == nil {
 				hsErr = handleSummaryResult(c.gs.FS, c.gs.Stdout, c.gs.Stderr, summaryResult)
 			}
@@ -209,6 +329,76 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		}()
 	}
 
+	if reportCollector != nil {
+		defer func() {
+			logger.Debug("Generating the end-of-test reports...")
+			rErr := generateReports(c.gs.FS, reports, &testreport.Data{
+				Summary: &lib.Summary{
+					Metrics:         metricsEngine.ObservedMetrics,
+					RootGroup:       testRunState.Runner.GetDefaultGroup(),
+					TestRunDuration: executionState.GetCurrentTestRunDuration(),
+				},
+				Collector:  reportCollector,
+				ScriptPath: test.sourceRootPath,
+				Time:       time.Now(),
+				Template:   summaryTemplate,
+			})
+			if rErr != nil {
+				logger.WithError(rErr).Error("failed to generate the end-of-test reports")
+			}
+		}()
+	}
+
+	// This is deferred before the outputs are stopped, so it runs once all the
+	// metrics have been processed and after the final threshold calculation,
+	// which has the priority for the exit code.
+	if baseline != nil {
+		defer func() {
+			logger.Debug("Comparing the metrics with the baseline...")
+			baselineReport = metrics.CompareBaseline(
+				baseline, baselineTolerances, metricsEngine.ObservedMetrics,
+				executionState.GetCurrentTestRunDuration(),
+			)
+			baselineReport.Path = conf.Baseline.String
+			if len(baselineReport.Missing) > 0 {
+				logger.Warnf("The statistics '%s' couldn't be compared, they are missing from the baseline or the test run",
+					strings.Join(baselineReport.Missing, "', '"))
+			}
+			regressions := baselineReport.Regressions()
+			if len(regressions) == 0 {
+				return
+			}
+			bErr := errext.WithExitCodeIfNone(
+				fmt.Errorf("the metrics have regressed compared to the baseline beyond the tolerances '%s'",
+					strings.Join(regressions, "', '")),
+				exitcodes.BaselineRegressed,
+			)
+			if err == nil {
+				err = bErr
+			} else {
+				logger.WithError(bErr).Debug("Regressed from the baseline, but test already exited with another error")
+			}
+		}()
+	}
+
+	// This is deferred before the final threshold calculation, so the
+	// notification of the end of the test has its results.
+	var testStartNotified bool
+	var breachedThresholds []string
+	if notifier != nil {
+		defer func() {
+			notifier.Wait()
+			if !testStartNotified {
+				return
+			}
+			evt := orchestrator.Event{Type: orchestrator.TestEnd, Thresholds: breachedThresholds}
+			if err != nil {
+				evt.Error = err.Error()
+			}
+			_ = notifier.Notify(globalCtx, evt)
+		}()
+	}
+
 	waitInitDone := emitEvent(&event.Event{Type: event.Init})
 
 	// Create and start the outputs. We do it quite early to get any output URLs
@@ -222,6 +412,9 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// TODO: attach run status and exit code?
 		runAbort(err)
 	})
+	if cardinalityGuard != nil {
+		outputManager.SetCardinalityGuard(cardinalityGuard)
+	}
 	samples := make(chan metrics.SampleContainer, test.derivedConfig.MetricSamplesBufferSize.Int64)
 	waitOutputsFlushed, stopOutputs, err := outputManager.Start(samples)
 	if err != nil {
@@ -236,7 +429,9 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		stopOutputs(err)
 	}()
 
-	if !testRunState.RuntimeOptions.NoThresholds.Bool {
+	// The thresholds of a dry run are only parsed, the single iterations
+	// aren't enough for their results to mean anything.
+	if !testRunState.RuntimeOptions.NoThresholds.Bool && !c.dryRun {
 		finalizeThresholds := metricsEngine.StartThresholdCalculations(
 			metricsIngester, runAbort, executionState.GetCurrentTestRunDuration,
 		)
@@ -246,7 +441,7 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 			// outputs (including MetricsEngine's ingester). So we are sure
 			// there won't be any more metrics being sent.
 			logger.Debug("Finalizing thresholds...")
-			breachedThresholds := finalizeThresholds()
+			breachedThresholds = finalizeThresholds()
 			if len(breachedThresholds) == 0 {
 				return
 			}
@@ -293,6 +488,27 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	if c.gs.Flags.Address != "" { //nolint:nestif
 		initBar.Modify(pb.WithConstProgress(0, "Init API server"))
 
+		srvConf, srvErr := getAPIServerConfig(c.gs)
+		if srvErr != nil {
+			return errext.WithExitCodeIfNone(srvErr, exitcodes.CannotStartRESTAPI)
+		}
+		srv, srvErr := api.GetServer(
+			runCtx,
+			c.gs.Flags.Address, c.gs.Flags.ProfilingEnabled,
+			testRunState,
+			samples,
+			metricsEngine,
+			execScheduler,
+			metricsStream,
+			srvConf,
+		)
+		if srvErr != nil {
+			return errext.WithExitCodeIfNone(srvErr, exitcodes.CannotStartRESTAPI)
+		}
+		if srvConf.Token != "" && srv.TLSConfig == nil {
+			logger.Warn("The REST API token will be sent in plaintext, use --api-tls-cert and --api-tls-key to enable TLS")
+		}
+
 		// We cannot use backgroundProcesses here, since we need the REST API to
 		// be down before we can close the samples channel above and finish the
 		// processing the metrics pipeline.
@@ -303,21 +519,17 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		srvCtx, srvCancel := context.WithCancel(globalCtx)
 		defer srvCancel()
 
-		srv := api.GetServer(
-			runCtx,
-			c.gs.Flags.Address, c.gs.Flags.ProfilingEnabled,
-			testRunState,
-			samples,
-			metricsEngine,
-			execScheduler,
-		)
 		go func() {
 			defer apiWG.Done()
 			logger.Debugf("Starting the REST API server on %s", c.gs.Flags.Address)
 			if c.gs.Flags.ProfilingEnabled {
 				logger.Debugf("Profiling exposed on http://%s/debug/pprof/", c.gs.Flags.Address)
 			}
-			if aerr := srv.ListenAndServe(); aerr != nil && !errors.Is(aerr, http.ErrServerClosed) {
+			listenAndServe := srv.ListenAndServe
+			if srv.TLSConfig != nil {
+				listenAndServe = func() error { return srv.ListenAndServeTLS("", "") }
+			}
+			if aerr := listenAndServe(); aerr != nil && !errors.Is(aerr, http.ErrServerClosed) {
 				// Only exit k6 if the user has explicitly set the REST API address
 				if cmd.Flags().Lookup("address").Changed {
 					logger.WithError(aerr).Error("Error from API server")
@@ -362,6 +574,7 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	defer stopSignalHandling()
 
 	// Initialize the VUs and executors
+	execScheduler.EnableSampleBatching()
 	stopVUEmission, err := execScheduler.Init(runCtx, samples)
 	if err != nil {
 		return err
@@ -390,6 +603,22 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	waitTestStartDone := emitEvent(&event.Event{Type: event.TestStart})
 	waitTestStartDone()
 
+	// The lifecycle hooks can prevent the test run from starting, e.g. when
+	// the system under test isn't ready.
+	if notifier != nil {
+		testStartNotified = true
+		scenarios := make([]string, 0, len(conf.Scenarios))
+		for _, sc := range conf.Scenarios.GetSortedConfigs() {
+			scenarios = append(scenarios, sc.GetName())
+		}
+		if hErr := notifier.Notify(runCtx, orchestrator.Event{Type: orchestrator.TestStart, Scenarios: scenarios}); hErr != nil {
+			return errext.WithExitCodeIfNone(
+				fmt.Errorf("the test run was prevented from starting by a lifecycle hook: %w", hErr),
+				exitcodes.LifecycleHookFailed,
+			)
+		}
+	}
+
 	// Start the test! However, we won't immediately return if there was an
 	// error, we still have things to do.
 	err = execScheduler.Run(globalCtx, runCtx, samples)
@@ -427,17 +656,46 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		logger.Warn("No script iterations fully finished, consider making the test duration longer")
 	}
 
+	if failed := executionState.GetFailedIterationCount(); c.dryRun && failed > 0 {
+		return errext.WithExitCodeIfNone(
+			fmt.Errorf("%d of the iterations of the dry run failed", failed), exitcodes.ScriptException,
+		)
+	}
+
 	logger.Debug("Test finished cleanly")
 
 	return nil
 }
 
+// runTestOrSuite runs a single test, or a suite if there were multiple
+// scripts or a suite manifest given.
+func (c *cmdRun) runTestOrSuite(cmd *cobra.Command, args []string) error {
+	if c.suitePath != "" {
+		manifest, err := readSuiteManifest(c.gs, c.suitePath)
+		if err != nil {
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+		return c.runSuite(cmd, manifest)
+	}
+	if len(args) > 1 {
+		return c.runSuite(cmd, newSuiteFromArgs(args))
+	}
+	return c.run(cmd, args)
+}
+
 func (c *cmdRun) flagSet() *pflag.FlagSet {
 	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
 	flags.SortFlags = false
 	flags.AddFlagSet(optionFlagSet())
 	flags.AddFlagSet(runtimeOptionFlagSet(true))
 	flags.AddFlagSet(configFlagSet())
+	flags.StringVar(&c.suitePath, "suite", "", "run the tests from the given suite manifest")
+	flags.BoolVar(&c.dryRun, "dry-run", false, "run a single iteration of each scenario with 1 VU, to check the "+
+		"script, the thresholds and the outputs")
+	flags.StringArrayVar(&c.lifecycleHooks, "lifecycle-hook", nil, "URL notified with a POST request of the start, "+
+		"the scenarios, the stages, the crossed thresholds and the end of the test run, can be used multiple times")
+	flags.DurationVar(&c.lifecycleHookTimeout, "lifecycle-hook-timeout", 10*time.Second,
+		"time the test run waits for the response of each lifecycle hook")
 	return flags
 }
 
@@ -483,7 +741,16 @@ func getCmdRun(gs *state.GlobalState) *cobra.Command {
   {{.}} run -u 0 -s 10s:100 -s 60s:100 -s 10s:0
 
   # Send metrics to an influxdb server
-  {{.}} run -o influxdb=http://1.2.3.4:8086/k6`[1:])
+  {{.}} run -o influxdb=http://1.2.3.4:8086/k6
+
+  # Run several tests one after the other, with a combined summary at the end.
+  {{.}} run login.js checkout.js
+
+  # Run the tests from a suite manifest.
+  {{.}} run --suite suite.json
+
+  # Check the script with a single iteration of each scenario.
+  {{.}} run --dry-run script.js`[1:])
 
 	runCmd := &cobra.Command{
 		Use:   "run",
@@ -491,10 +758,24 @@ func getCmdRun(gs *state.GlobalState) *cobra.Command {
 		Long: `Start a test.
 
 This also exposes a REST API to interact with it. Various k6 subcommands offer
-a commandline interface for interacting with it.`,
+a commandline interface for interacting with it.
+
+If multiple scripts or a suite manifest with --suite are given, the tests are
+run as a suite and their combined results are shown at the end. The manifest
+is a JSON file with the tests, the options that are shared between them and
+whether they should run in parallel:
+
+  {
+    "parallel": false,
+    "options": { "vus": 10 },
+    "tests": [
+      { "path": "login.js" },
+      { "name": "checkout", "path": "checkout.js", "options": { "duration": "1m" } }
+    ]
+  }`,
 		Example: exampleText,
-		Args:    exactArgsWithMsg(1, "arg should either be \"-\", if reading script from stdin, or a path to a script file"),
-		RunE:    c.run,
+		Args:    c.checkArgs,
+		RunE:    c.runTestOrSuite,
 	}
 
 	runCmd.Flags().SortFlags = false
@@ -503,6 +784,178 @@ a commandline interface for interacting with it.`,
 	return runCmd
 }
 
+func (c *cmdRun) checkArgs(cmd *cobra.Command, args []string) error {
+	if c.suitePath != "" {
+		return exactArgsWithMsg(0, "the tests are read from the suite manifest")(cmd, args)
+	}
+	if len(args) < 1 {
+		return errors.New("requires at least 1 arg, received 0: " +
+			"arg should either be \"-\", if reading script from stdin, or a path to a script file")
+	}
+	return nil
+}
+
+// hasStartWhenConditions reports whether any of the scenarios has a startWhen
+// condition, which needs the metrics to be processed even without thresholds
+// and the end-of-test summary.
+func hasStartWhenConditions(scenarios lib.ScenarioConfigs) bool {
+	for _, sc := range scenarios {
+		if sc.GetStartConditions().StartWhen != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunScenarios replaces the scenarios with ones running a single iteration
+// with 1 VU, which keep the function, the environment variables, the tags, the
+// options and the setup and teardown of the originals. They all start right
+// away, without waiting for their start time or their start conditions.
+func dryRunScenarios(scenarios lib.ScenarioConfigs) lib.ScenarioConfigs {
+	result := make(lib.ScenarioConfigs, len(scenarios))
+	for name, sc := range scenarios {
+		conf := executor.NewPerVUIterationsConfig(name)
+		if exec := sc.GetExec(); exec != consts.DefaultFn {
+			conf.Exec = null.StringFrom(exec)
+		}
+		conf.Env = sc.GetEnv()
+		conf.Tags = sc.GetTags()
+		conf.Options = sc.GetScenarioOptions()
+
+		lifecycle := sc.GetLifecycle()
+		conf.Setup, conf.Teardown = lifecycle.Setup, lifecycle.Teardown
+		conf.SetupTimeout = types.Duration(lifecycle.SetupTimeout)
+		conf.TeardownTimeout = types.Duration(lifecycle.TeardownTimeout)
+		result[name] = conf
+	}
+	return result
+}
+
+// loadBaseline reads the baseline summary and parses the tolerances of the
+// comparison with it, if one is configured.
+func loadBaseline(fs fsext.Fs, conf Config) (metrics.Baseline, []metrics.BaselineTolerance, error) {
+	if conf.Baseline.String == "" {
+		return nil, nil, nil
+	}
+	data, err := fsext.ReadFile(fs, conf.Baseline.String)
+	if err != nil {
+		return nil, nil, errext.WithExitCodeIfNone(
+			fmt.Errorf("couldn't read the baseline '%s': %w", conf.Baseline.String, err), exitcodes.InvalidConfig)
+	}
+	baseline, err := metrics.ParseBaseline(data)
+	if err != nil {
+		return nil, nil, errext.WithExitCodeIfNone(
+			fmt.Errorf("couldn't load the baseline '%s': %w", conf.Baseline.String, err), exitcodes.InvalidConfig)
+	}
+
+	sources := conf.BaselineTolerance
+	if len(sources) == 0 {
+		sources = metrics.DefaultBaselineTolerances
+	}
+	tolerances := make([]metrics.BaselineTolerance, len(sources))
+	for i, source := range sources {
+		// the tolerances have already been validated with the config
+		if tolerances[i], err = metrics.ParseBaselineTolerance(source); err != nil {
+			return nil, nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+	}
+	return baseline, tolerances, nil
+}
+
+// loadReports returns the reports of the config and of the summary export,
+// and parses the template of the template reports, if there is one.
+func loadReports(
+	fs fsext.Fs, conf Config, runtimeOptions lib.RuntimeOptions,
+) ([]string, *template.Template, error) {
+	_, exportReports := runtimeOptions.SummaryExportTargets()
+	reports := append(append([]string{}, conf.Report...), exportReports...)
+	hasTemplateReport := false
+	for _, arg := range exportReports {
+		r, err := testreport.Parse(arg)
+		if err != nil {
+			return nil, nil, errext.WithExitCodeIfNone(
+				fmt.Errorf("invalid summary export target: %w", err), exitcodes.InvalidConfig)
+		}
+		hasTemplateReport = hasTemplateReport || r.Type == "template"
+	}
+	for _, arg := range conf.Report {
+		// the reports of the config have already been validated with it
+		r, _ := testreport.Parse(arg)
+		hasTemplateReport = hasTemplateReport || r.Type == "template"
+	}
+
+	if conf.SummaryTemplate.String == "" {
+		if hasTemplateReport {
+			return nil, nil, errext.WithExitCodeIfNone(
+				errors.New("the template reports need a template, set with the --summary-template option"),
+				exitcodes.InvalidConfig)
+		}
+		return reports, nil, nil
+	}
+	source, err := fsext.ReadFile(fs, conf.SummaryTemplate.String)
+	if err != nil {
+		return nil, nil, errext.WithExitCodeIfNone(
+			fmt.Errorf("couldn't read the summary template '%s': %w", conf.SummaryTemplate.String, err),
+			exitcodes.InvalidConfig)
+	}
+	tmpl, err := testreport.ParseTemplate(filepath.Base(conf.SummaryTemplate.String), string(source))
+	if err != nil {
+		return nil, nil, errext.WithExitCodeIfNone(
+			fmt.Errorf("invalid summary template '%s': %w", conf.SummaryTemplate.String, err), exitcodes.InvalidConfig)
+	}
+	return reports, tmpl, nil
+}
+
+// cardinalityReport returns the report of the guard for the end-of-test
+// summary, only if its limit has been reached.
+func cardinalityReport(guard *metrics.CardinalityGuard) *metrics.CardinalityReport {
+	if guard == nil {
+		return nil
+	}
+	report := guard.Report()
+	if !report.LimitReached {
+		return nil
+	}
+	return &report
+}
+
+// summaryAbort returns the graceful abort of the test by its script for the
+// end-of-test summary, if there was one.
+func summaryAbort(executionState *lib.ExecutionState) *lib.SummaryAbort {
+	var interruptErr *errext.InterruptError
+	if !errors.As(executionState.GetGracefulAbortReason(), &interruptErr) {
+		return nil
+	}
+	return &lib.SummaryAbort{Reason: interruptErr.Reason, ExitCode: int(interruptErr.ExitCode())}
+}
+
+// generateReports writes the requested reports, the arguments have already
+// been validated with the config.
+func generateReports(fs fsext.Fs, args []string, data *testreport.Data) error {
+	var errs []error
+	for _, arg := range args {
+		r, err := testreport.Parse(arg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f, err := fs.OpenFile(r.Path, syscall.O_WRONLY|syscall.O_CREAT|syscall.O_TRUNC, 0o666)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not open '%s': %w", r.Path, err))
+			continue
+		}
+		err = r.Generate(f, data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error generating the %s report to '%s': %w", r.Type, r.Path, err))
+		}
+	}
+
+	return consolidateErrorMessage(errs, "Could not generate some reports:")
+}
+
 func handleSummaryResult(fs fsext.Fs, stdOut, stdErr io.Writer, result map[string]io.Reader) error {
 	var errs []error
 
@@ -28,14 +28,25 @@ base: pure goja - Golang JS VM supporting ES5.1+
 extended: base + Babel with parts of ES2015 preset
 		  slower to compile in case the script uses syntax unsupported by base
 `)
+	flags.String("module-resolution", "k6",
+		`how the bare module specifiers (e.g. "lodash") are resolved, "k6" or "node"
+k6: only with the remote loaders, e.g. for github.com
+node: also from the node_modules directories, which are bundled in the archives
+`)
+	flags.Bool("frozen-lockfile", false, "only load the remote modules that are in the k6.lock lockfile")
 	flags.StringP("type", "t", "", "override test type, \"js\" or \"archive\"")
 	flags.StringArrayP("env", "e", nil, "add/override environment variable with `VAR=value`")
+	flags.StringArray("secret-source", nil,
+		"add a source of the secrets of k6/secrets, `type=config`, either file=path with VAR=value lines, "+
+			"env=VAR, vault=path=...[,address=...] or aws=[region=...,prefix=...], the values of the files and "+
+			"env=VAR are also environment variables, and the secrets are masked in the logs, the summary and the archives")
 	flags.Bool("no-thresholds", false, "don't run thresholds")
 	flags.Bool("no-summary", false, "don't show the summary at the end of the test")
 	flags.String(
 		"summary-export",
 		"",
-		"output the end-of-test summary report to JSON file",
+		"comma-separated `targets` of the end-of-test summary, a JSON file path or type=path with "+
+			"the json, html, junit, markdown or template types, e.g. summary.json,junit=junit.xml,markdown=summary.md",
 	)
 	flags.String("traces-output", "none",
 		"set the output for k6 traces, possible values are none,otel[=host:port]")
@@ -65,6 +76,8 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		TestType:             getNullString(flags, "type"),
 		IncludeSystemEnvVars: getNullBool(flags, "include-system-env-vars"),
 		CompatibilityMode:    getNullString(flags, "compatibility-mode"),
+		ModuleResolution:     getNullString(flags, "module-resolution"),
+		FrozenLockfile:       getNullBool(flags, "frozen-lockfile"),
 		NoThresholds:         getNullBool(flags, "no-thresholds"),
 		NoSummary:            getNullBool(flags, "no-summary"),
 		SummaryExport:        getNullString(flags, "summary-export"),
@@ -85,9 +98,20 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		return opts, err
 	}
 
+	if envVar, ok := environment["K6_MODULE_RESOLUTION"]; ok && !opts.ModuleResolution.Valid {
+		// Only override if not explicitly set via the CLI flag
+		opts.ModuleResolution = null.StringFrom(envVar)
+	}
+	if _, err := lib.ValidateModuleResolution(opts.ModuleResolution.String); err != nil {
+		return opts, err
+	}
+
 	if err := saveBoolFromEnv(environment, "K6_INCLUDE_SYSTEM_ENV_VARS", &opts.IncludeSystemEnvVars); err != nil {
 		return opts, err
 	}
+	if err := saveBoolFromEnv(environment, "K6_FROZEN_LOCKFILE", &opts.FrozenLockfile); err != nil {
+		return opts, err
+	}
 	if err := saveBoolFromEnv(environment, "K6_NO_THRESHOLDS", &opts.NoThresholds); err != nil {
 		return opts, err
 	}
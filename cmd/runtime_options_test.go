@@ -110,6 +110,7 @@ func TestRuntimeOptions(t *testing.T) {
 		baseCompatMode      = null.NewString("base", true)
 		extendedCompatMode  = null.NewString("extended", true)
 		defaultTracesOutput = null.NewString("none", false)
+		defaultModuleRes    = null.NewString("k6", false)
 	)
 
 	runtimeOptionsTestCases := map[string]runtimeOptionsTestCase{
@@ -119,6 +120,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  nil,
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -129,6 +131,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -139,6 +142,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    extendedCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -150,6 +154,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, true),
 				CompatibilityMode:    baseCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -161,6 +166,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, true),
 				CompatibilityMode:    baseCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -171,6 +177,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, true),
 				CompatibilityMode:    extendedCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -181,6 +188,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, true),
 				CompatibilityMode:    extendedCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"K6_INCLUDE_SYSTEM_ENV_VARS": "true", "K6_COMPATIBILITY_MODE": "extended"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -192,6 +200,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "val1"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -203,6 +212,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, true),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "val1"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -214,6 +224,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, true),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "val1"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -225,6 +236,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "val1"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -236,6 +248,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "val1", "test2": "", "test3": "val3", "test4": "", "test5": ""},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -247,6 +260,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, true),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "val1", "test2": "", "test3": "val3", "test4": "", "test5": ""},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -258,6 +272,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test2": "val2"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -269,6 +284,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "val1cli"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -286,6 +302,31 @@ func TestRuntimeOptions(t *testing.T) {
 			cliFlags: []string{"--compatibility-mode", "whatever"},
 			expErr:   true,
 		},
+		"node module resolution env var": {
+			systemEnv: map[string]string{"K6_MODULE_RESOLUTION": "node"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars: null.NewBool(false, false),
+				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     null.NewString("node", true),
+				Env:                  map[string]string{},
+				TracesOutput:         defaultTracesOutput,
+			},
+		},
+		"module resolution cli flag overrides env var": {
+			systemEnv: map[string]string{"K6_MODULE_RESOLUTION": "node"},
+			cliFlags:  []string{"--module-resolution", "k6"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars: null.NewBool(false, false),
+				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     null.NewString("k6", true),
+				Env:                  map[string]string{},
+				TracesOutput:         defaultTracesOutput,
+			},
+		},
+		"error wrong module resolution cli flag value": {
+			cliFlags: []string{"--module-resolution", "deno"},
+			expErr:   true,
+		},
 		"error invalid cli var name 1": {
 			useSysEnv: true,
 			systemEnv: map[string]string{},
@@ -311,6 +352,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "value 1", "test2": "value 2"},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -322,6 +364,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(true, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{"test1": "value 1", "test2": "value,2", "test3": ` ,  ,,, value, ,, 2!'@#,"`},
 				TracesOutput:         defaultTracesOutput,
 			},
@@ -332,6 +375,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				NoThresholds:         null.NewBool(false, true),
 				NoSummary:            null.NewBool(false, true),
@@ -346,6 +390,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				NoThresholds:         null.NewBool(true, true),
 				NoSummary:            null.NewBool(true, true),
@@ -370,6 +415,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         null.NewString("none", false),
 			},
@@ -380,6 +426,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         null.NewString("foo", true),
 			},
@@ -391,6 +438,7 @@ func TestRuntimeOptions(t *testing.T) {
 			expRTOpts: lib.RuntimeOptions{
 				IncludeSystemEnvVars: null.NewBool(false, false),
 				CompatibilityMode:    defaultCompatMode,
+				ModuleResolution:     defaultModuleRes,
 				Env:                  map[string]string{},
 				TracesOutput:         null.NewString("bar", true),
 			},
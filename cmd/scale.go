@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/spf13/cobra"
 
 	v1 "go.k6.io/k6/api/v1"
-	"go.k6.io/k6/api/v1/client"
 	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
 )
 
 func getCmdScale(gs *state.GlobalState) *cobra.Command {
@@ -17,18 +19,44 @@ func getCmdScale(gs *state.GlobalState) *cobra.Command {
 		Short: "Scale a running test",
 		Long: `Scale a running test.
 
-  Use the global --address flag to specify the URL to the API server.`,
+  Use the --scenario and --stage flags to replace the remaining stages of a
+  running ramping-vus scenario, starting from the current moment and number of
+  VUs, and the global --address flag to specify the URL to the API server.`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			vus := getNullInt64(cmd.Flags(), "vus")
 			max := getNullInt64(cmd.Flags(), "max")
-			if !vus.Valid && !max.Valid {
-				return errors.New("Specify either -u/--vus or -m/--max") //nolint:golint,stylecheck
+			scenario, err := cmd.Flags().GetString("scenario")
+			if err != nil {
+				return err
+			}
+			stages, err := getScaleStages(cmd)
+			if err != nil {
+				return err
+			}
+			if scenario != "" {
+				if vus.Valid || max.Valid || stages == nil {
+					return errors.New("Specify -s/--stage for the scenario") //nolint:golint,stylecheck
+				}
+			} else {
+				if stages != nil {
+					return errors.New("Specify the --scenario of the stages") //nolint:golint,stylecheck
+				}
+				if !vus.Valid && !max.Valid {
+					return errors.New("Specify either -u/--vus or -m/--max") //nolint:golint,stylecheck
+				}
 			}
 
-			c, err := client.New(gs.Flags.Address)
+			c, err := newAPIClient(gs)
 			if err != nil {
 				return err
 			}
+			if scenario != "" {
+				s, setErr := c.SetScenario(gs.Ctx, scenario, v1.Scenario{Stages: stages})
+				if setErr != nil {
+					return setErr
+				}
+				return yamlPrint(gs.Stdout, s)
+			}
 			status, err := c.SetStatus(gs.Ctx, v1.Status{VUs: vus, VUsMax: max})
 			if err != nil {
 				return err
@@ -40,6 +68,30 @@ func getCmdScale(gs *state.GlobalState) *cobra.Command {
 
 	scaleCmd.Flags().Int64P("vus", "u", 1, "number of virtual users")
 	scaleCmd.Flags().Int64P("max", "m", 0, "max available virtual users")
+	scaleCmd.Flags().String("scenario", "", "change the stages of the ramping-vus scenario with this `name`")
+	scaleCmd.Flags().StringSliceP("stage", "s", nil, "add a remaining `stage`, as `[duration]:[target]`")
 
 	return scaleCmd
 }
+
+// getScaleStages returns the stages from the --stage flags, or nil if there
+// weren't any.
+func getScaleStages(cmd *cobra.Command) ([]executor.Stage, error) {
+	stageStrings, err := cmd.Flags().GetStringSlice("stage")
+	if err != nil {
+		return nil, err
+	}
+
+	var stages []executor.Stage
+	for i, s := range stageStrings {
+		var stage lib.Stage
+		if err := stage.UnmarshalText([]byte(s)); err != nil {
+			return nil, fmt.Errorf("error for stage %d: %w", i, err)
+		}
+		if !stage.Duration.Valid {
+			return nil, fmt.Errorf("stage %d doesn't have a specified duration", i)
+		}
+		stages = append(stages, executor.Stage{Duration: stage.Duration, Target: stage.Target})
+	}
+	return stages, nil
+}
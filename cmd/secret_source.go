@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/secretsource"
+)
+
+// applySecretSources creates the sources of the --secret-source flags and
+// returns their manager. The secrets of the sources which also are
+// environment variables, like the files and env=VAR, are added to the runtime
+// options, and their names recorded, so their values can be redacted from the
+// archives.
+func applySecretSources(
+	gs *state.GlobalState, flags *pflag.FlagSet, opts *lib.RuntimeOptions,
+) (*secretsource.Manager, error) {
+	args, err := flags.GetStringArray("secret-source")
+	if err != nil {
+		return nil, err
+	}
+	constructors, err := secretsource.GetConstructors()
+	if err != nil {
+		return nil, err
+	}
+	pwd, err := gs.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]secretsource.Source, 0, len(args))
+	for _, arg := range args {
+		kind, configArg, _ := strings.Cut(arg, "=")
+		constructor, ok := constructors[kind]
+		if !ok {
+			return nil, fmt.Errorf("invalid secret source '%s', the type should be one of %s",
+				arg, strings.Join(secretSourceTypes(constructors), ", "))
+		}
+		source, err := constructor(secretsource.Params{
+			ConfigArgument: configArg,
+			Logger:         gs.Logger.WithField("secret_source", kind),
+			Environment:    gs.Env,
+			FS:             gs.FS,
+			Cwd:            pwd,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if envSource, ok := source.(secretsource.EnvSource); ok {
+			envVars := envSource.EnvVars()
+			names := make([]string, 0, len(envVars))
+			for name := range envVars {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				addSecretEnvVar(opts, name, envVars[name])
+			}
+		}
+		sources = append(sources, source)
+	}
+	return secretsource.NewManager(sources), nil
+}
+
+// redactSummaryResult masks the values of the secrets in the outputs of the
+// end-of-test summary.
+func redactSummaryResult(
+	secrets *secretsource.Manager, result map[string]io.Reader,
+) (map[string]io.Reader, error) {
+	if secrets == nil {
+		return result, nil
+	}
+	redacted := make(map[string]io.Reader, len(result))
+	for path, value := range result {
+		data, err := io.ReadAll(value)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the summary of '%s': %w", path, err)
+		}
+		redacted[path] = strings.NewReader(secrets.Redact(string(data)))
+	}
+	return redacted, nil
+}
+
+func secretSourceTypes(constructors map[string]secretsource.Constructor) []string {
+	types := make([]string, 0, len(constructors))
+	for t := range constructors {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func addSecretEnvVar(opts *lib.RuntimeOptions, name, value string) {
+	if !isSecretEnvVar(opts, name) {
+		opts.SecretEnvVars = append(opts.SecretEnvVars, name)
+	}
+	opts.Env[name] = value
+}
+
+func isSecretEnvVar(opts *lib.RuntimeOptions, name string) bool {
+	for _, secret := range opts.SecretEnvVars {
+		if secret == name {
+			return true
+		}
+	}
+	return false
+}
@@ -139,6 +139,10 @@ type GlobalFlags struct {
 	NoColor          bool
 	Address          string
 	ProfilingEnabled bool
+	APIToken         string
+	APITLSCert       string
+	APITLSKey        string
+	APITLSCA         string
 	LogOutput        string
 	LogFormat        string
 	Verbose          bool
@@ -163,6 +167,18 @@ func getFlags(defaultFlags GlobalFlags, env map[string]string) GlobalFlags {
 	if val, ok := env["K6_CONFIG"]; ok {
 		result.ConfigFilePath = val
 	}
+	if val, ok := env["K6_API_TOKEN"]; ok {
+		result.APIToken = val
+	}
+	if val, ok := env["K6_API_TLS_CERT"]; ok {
+		result.APITLSCert = val
+	}
+	if val, ok := env["K6_API_TLS_KEY"]; ok {
+		result.APITLSKey = val
+	}
+	if val, ok := env["K6_API_TLS_CA"]; ok {
+		result.APITLSCA = val
+	}
 	if val, ok := env["K6_LOG_OUTPUT"]; ok {
 		result.LogOutput = val
 	}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
-	"go.k6.io/k6/api/v1/client"
 	"go.k6.io/k6/cmd/state"
 )
 
@@ -16,7 +15,7 @@ func getCmdStats(gs *state.GlobalState) *cobra.Command {
 
   Use the global --address flag to specify the URL to the API server.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c, err := client.New(gs.Flags.Address)
+			c, err := newAPIClient(gs)
 			if err != nil {
 				return err
 			}
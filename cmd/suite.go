@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/event"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/local"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// suiteManifest is the JSON file given with `k6 run --suite`, which describes
+// the tests of the suite and how they should be run.
+type suiteManifest struct {
+	// Parallel makes all of the tests run at the same time, instead of one
+	// after the other.
+	Parallel bool `json:"parallel"`
+
+	// Options are applied to all of the tests, with the same priority as the
+	// CLI flags, which override them.
+	Options lib.Options `json:"options"`
+
+	Tests []suiteTest `json:"tests"`
+}
+
+// suiteTest is a single test of the suite. Its path is relative to the
+// directory of the suite manifest.
+type suiteTest struct {
+	Name    string      `json:"name"`
+	Path    string      `json:"path"`
+	Options lib.Options `json:"options"`
+}
+
+// suiteTestResult is the outcome of a single test of the suite.
+type suiteTestResult struct {
+	test     suiteTest
+	duration time.Duration
+	err      error
+}
+
+// readSuiteManifest reads and validates the suite manifest at the given path.
+func readSuiteManifest(gs *state.GlobalState, path string) (*suiteManifest, error) {
+	pwd, err := gs.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	fullPath := path
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(pwd, fullPath)
+	}
+
+	data, err := fsext.ReadFile(gs.FS, fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the suite manifest '%s': %w", path, err)
+	}
+	manifest := &suiteManifest{}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("couldn't parse the suite manifest '%s': %w", path, err)
+	}
+	if len(manifest.Tests) == 0 {
+		return nil, fmt.Errorf("the suite manifest '%s' doesn't contain any tests", path)
+	}
+
+	dir := filepath.Dir(fullPath)
+	for i, test := range manifest.Tests {
+		if test.Path == "" {
+			return nil, fmt.Errorf("test %d in the suite manifest '%s' doesn't have a path", i, path)
+		}
+		if !filepath.IsAbs(test.Path) {
+			manifest.Tests[i].Path = filepath.Join(dir, test.Path)
+		}
+		if test.Name == "" {
+			manifest.Tests[i].Name = test.Path
+		}
+	}
+	return manifest, nil
+}
+
+// newSuiteFromArgs returns a sequential suite for the scripts that were
+// given as arguments to `k6 run`.
+func newSuiteFromArgs(args []string) *suiteManifest {
+	manifest := &suiteManifest{Tests: make([]suiteTest, len(args))}
+	for i, arg := range args {
+		manifest.Tests[i] = suiteTest{Name: arg, Path: arg}
+	}
+	return manifest
+}
+
+// runSuite runs all of the tests of the suite and prints the combined results
+// at the end. It returns the error of the first test that failed, so that its
+// exit code is used for the whole suite.
+func (c *cmdRun) runSuite(cmd *cobra.Command, manifest *suiteManifest) error {
+	results := make([]suiteTestResult, len(manifest.Tests))
+	runTest := func(gs *state.GlobalState, i int) {
+		test := manifest.Tests[i]
+		testRun := &cmdRun{
+			gs:                   gs,
+			dryRun:               c.dryRun,
+			lifecycleHooks:       c.lifecycleHooks,
+			lifecycleHookTimeout: c.lifecycleHookTimeout,
+			loadConfiguredTest: func(cmd *cobra.Command, args []string) (
+				*loadedAndConfiguredTest, execution.Controller, error,
+			) {
+				lt, err := loadAndConfigureLocalTest(gs, cmd, args, func(flags *pflag.FlagSet) (Config, error) {
+					conf, confErr := getConfig(flags)
+					// The flags are applied again on top of the suite options,
+					// so their unset values keep the flag defaults.
+					conf.Options = conf.Options.Apply(manifest.Options).Apply(test.Options).Apply(conf.Options)
+					return conf, confErr
+				})
+				return lt, local.NewController(), err
+			},
+		}
+
+		gs.Logger.Debugf("Running test '%s' of the suite...", test.Name)
+		start := time.Now()
+		err := testRun.run(cmd, []string{test.Path})
+		results[i] = suiteTestResult{test: test, duration: time.Since(start), err: err}
+	}
+
+	if manifest.Parallel {
+		// The tests can't share the REST API address, the progress bars and
+		// the event system, so they are disabled or separate for each test.
+		wg := &sync.WaitGroup{}
+		for i := range manifest.Tests {
+			gs := *c.gs
+			gs.Flags.Address = ""
+			gs.Flags.Quiet = true
+			gs.Events = event.NewEventSystem(100, c.gs.Logger)
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				runTest(&gs, i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range manifest.Tests {
+			runTest(c.gs, i)
+			if isAbortedByUser(results[i].err) || c.gs.Ctx.Err() != nil {
+				results = results[:i+1] // the suite was interrupted, so the rest of the tests are skipped
+				break
+			}
+		}
+	}
+
+	printSuiteSummary(c.gs, results, len(manifest.Tests))
+
+	for _, result := range results {
+		if result.err != nil {
+			return fmt.Errorf("test '%s' of the suite failed: %w", result.test.Name, result.err)
+		}
+	}
+	if len(results) < len(manifest.Tests) {
+		return errext.WithExitCodeIfNone(errors.New("the suite was interrupted"), exitcodes.ExternalAbort)
+	}
+	return nil
+}
+
+func isAbortedByUser(err error) bool {
+	var arErr errext.HasAbortReason
+	return errors.As(err, &arErr) && arErr.AbortReason() == errext.AbortedByUser
+}
+
+// printSuiteSummary prints the results of all tests in the suite.
+func printSuiteSummary(gs *state.GlobalState, results []suiteTestResult, total int) {
+	noColor := gs.Flags.NoColor || !gs.Stdout.IsTTY
+	successColor := getColor(noColor, color.FgGreen)
+	failColor := getColor(noColor, color.FgRed)
+	valueColor := getColor(noColor, color.FgCyan)
+
+	var passed int
+	for _, result := range results {
+		if result.err == nil {
+			passed++
+		}
+	}
+
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "\n     suite: %s\n\n", valueColor.Sprintf(
+		"%d tests, %d passed, %d failed, %d skipped", total, passed, len(results)-passed, total-len(results),
+	))
+	for _, result := range results {
+		duration := result.duration.Round(time.Millisecond)
+		if result.err == nil {
+			fmt.Fprintf(buf, "     %s %s (%s)\n", successColor.Sprint("✓"), result.test.Name, duration)
+			continue
+		}
+		errMsg, _ := errext.Format(result.err)
+		fmt.Fprintf(buf, "     %s %s (%s): %s\n", failColor.Sprint("✗"), result.test.Name, duration, errMsg)
+	}
+	printToStdout(gs, buf.String()+"\n")
+}
@@ -4,8 +4,10 @@ import (
 	"archive/tar"
 	"bytes"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"path/filepath"
 	"sync"
 	"syscall"
@@ -22,6 +24,7 @@ import (
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/loader"
 	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/secretsource"
 )
 
 const (
@@ -44,28 +47,56 @@ type loadedTest struct {
 }
 
 func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*loadedTest, error) {
+	return loadLocalTestWithLockfile(gs, cmd, args, true)
+}
+
+// loadLocalTestWithLockfile loads the local test, with the remote modules in
+// its lockfile, if useLockfile is set and the test has one.
+func loadLocalTestWithLockfile(
+	gs *state.GlobalState, cmd *cobra.Command, args []string, useLockfile bool,
+) (*loadedTest, error) {
 	if len(args) < 1 {
 		return nil, fmt.Errorf("k6 needs at least one argument to load the test")
 	}
 
+	gs.Logger.Debugf("Gathering k6 runtime options...")
+	runtimeOptions, err := getRuntimeOptions(cmd.Flags(), gs.Env)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := applySecretSources(gs, cmd.Flags(), &runtimeOptions)
+	if err != nil {
+		return nil, err
+	}
+	secrets.InstallHook(gs.Logger)
+
 	sourceRootPath := args[0]
 	gs.Logger.Debugf("Resolving and reading test '%s'...", sourceRootPath)
-	src, fileSystems, pwd, err := readSource(gs, sourceRootPath)
+	src, fileSystems, pwd, err := readSource(gs, sourceRootPath, useLockfile, runtimeOptions.FrozenLockfile.Bool)
 	if err != nil {
 		return nil, err
 	}
+	if lib.IsEncryptedArchive(src.Data) {
+		gs.Logger.Debugf("Decrypting the archive '%s'...", sourceRootPath)
+		if src.Data, err = decryptArchiveSource(gs, src.Data); err != nil {
+			return nil, err
+		}
+	}
 	resolvedPath := src.URL.String()
 	gs.Logger.Debugf(
 		"'%s' resolved to '%s' and successfully loaded %d bytes!",
 		sourceRootPath, resolvedPath, len(src.Data),
 	)
 
-	gs.Logger.Debugf("Gathering k6 runtime options...")
-	runtimeOptions, err := getRuntimeOptions(cmd.Flags(), gs.Env)
-	if err != nil {
-		return nil, err
-	}
+	return loadTest(gs, sourceRootPath, src, fileSystems, pwd, runtimeOptions, secrets)
+}
 
+// loadTest initializes the first runner for the already read test source.
+func loadTest(
+	gs *state.GlobalState, sourceRootPath string, src *loader.SourceData,
+	fileSystems map[string]fsext.Fs, pwd string, runtimeOptions lib.RuntimeOptions,
+	secrets *secretsource.Manager,
+) (*loadedTest, error) {
 	registry := metrics.NewRegistry()
 	state := &lib.TestPreInitState{
 		Logger:         gs.Logger,
@@ -73,6 +104,7 @@ func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*l
 		Registry:       registry,
 		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
 		Events:         gs.Events,
+		Secrets:        secrets,
 		LookupEnv: func(key string) (string, bool) {
 			val, ok := gs.Env[key]
 			return val, ok
@@ -88,7 +120,7 @@ func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*l
 		preInitState:   state,
 	}
 
-	gs.Logger.Debugf("Initializing k6 runner for '%s' (%s)...", sourceRootPath, resolvedPath)
+	gs.Logger.Debugf("Initializing k6 runner for '%s' (%s)...", sourceRootPath, src.URL)
 	if err := test.initializeFirstRunner(gs); err != nil {
 		return nil, fmt.Errorf("could not initialize '%s': %w", sourceRootPath, err)
 	}
@@ -164,18 +196,61 @@ func (lt *loadedTest) initializeFirstRunner(gs *state.GlobalState) error {
 }
 
 // readSource is a small wrapper around loader.ReadSource returning
-// result of the load and filesystems map
-func readSource(gs *state.GlobalState, filename string) (*loader.SourceData, map[string]fsext.Fs, string, error) {
+// result of the load and filesystems map. If useLockfile is set, the remote
+// modules are loaded from the lockfile of the test, if it has one.
+func readSource(
+	gs *state.GlobalState, filename string, useLockfile, frozenLockfile bool,
+) (*loader.SourceData, map[string]fsext.Fs, string, error) {
 	pwd, err := gs.Getwd()
 	if err != nil {
 		return nil, nil, "", err
 	}
 
 	filesystems := loader.CreateFilesystems(gs.FS)
+	if useLockfile {
+		if err = useTestLockfile(gs, filename, pwd, filesystems, frozenLockfile); err != nil {
+			return nil, nil, "", err
+		}
+	}
 	src, err := loader.ReadSource(gs.Logger, filename, pwd, filesystems, gs.Stdin)
 	return src, filesystems, pwd, err
 }
 
+// lockfilePath returns the path of the lockfile of the test, which is next to
+// its script, or in pwd if the script isn't a local file (e.g. it's stdin).
+func lockfilePath(gs *state.GlobalState, filename, pwd string) string {
+	scriptPath := filename
+	if !filepath.IsAbs(scriptPath) {
+		scriptPath = filepath.Join(pwd, scriptPath)
+	}
+	if ok, _ := fsext.Exists(gs.FS, scriptPath); ok && filename != "-" {
+		return filepath.Join(filepath.Dir(scriptPath), loader.LockfileName)
+	}
+	return filepath.Join(pwd, loader.LockfileName)
+}
+
+// useTestLockfile makes the remote modules of the test be loaded from its
+// lockfile. With a frozen lockfile, a missing lockfile is the same as an
+// empty one, so no remote modules can be loaded.
+func useTestLockfile(
+	gs *state.GlobalState, filename, pwd string, filesystems map[string]fsext.Fs, frozen bool,
+) error {
+	lockPath := lockfilePath(gs, filename, pwd)
+	lock, err := loader.ReadLockfile(gs.FS, lockPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if !frozen {
+			return nil
+		}
+		lock = loader.NewLockfile()
+	case err != nil:
+		return err
+	}
+	gs.Logger.Debugf("Using the lockfile '%s' with %d remote modules...", lockPath, len(lock.Modules))
+	cacheDir := filepath.Join(filepath.Dir(lockPath), loader.ModulesCacheDir)
+	return loader.UseLockfile(gs.Logger, filesystems, lock, gs.FS, cacheDir, frozen)
+}
+
 func detectTestType(data []byte) string {
 	if _, err := tar.NewReader(bytes.NewReader(data)).Next(); err == nil {
 		return testTypeArchive
@@ -219,6 +294,24 @@ func (lt *loadedTest) consolidateDeriveAndValidateConfig(
 				return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
 			}
 		}
+
+		for name, compositeThresholds := range consolidatedConfig.Options.CompositeThresholds {
+			err = compositeThresholds.Parse()
+			if err != nil {
+				return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+
+			err = compositeThresholds.Validate(name, lt.preInitState.Registry)
+			if err != nil {
+				return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+		}
+	}
+
+	for name, slo := range consolidatedConfig.Options.SLOs {
+		if err = slo.Validate(name, lt.preInitState.Registry); err != nil {
+			return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
 	}
 
 	derivedConfig, err := deriveAndValidateConfig(consolidatedConfig, lt.initRunner.IsExecutable, gs.Logger)
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"go.k6.io/k6/cmd"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/testutils"
+)
+
+// runDistributedTest runs the coordinator and starts the agents as soon as it
+// is ready for them, then waits for all of them to finish.
+func runDistributedTest(t *testing.T, coordinator *GlobalTestState, agents []*GlobalTestState) {
+	t.Helper()
+
+	wg := &sync.WaitGroup{}
+	asyncWaitForStdoutAndRun(t, coordinator, 50, 100*time.Millisecond, "The coordinator is waiting for", func() {
+		for _, agent := range agents {
+			wg.Add(1)
+			go func(ts *GlobalTestState) {
+				defer wg.Done()
+				cmd.ExecuteWithGlobalState(ts.GlobalState)
+			}(agent)
+		}
+	})
+	cmd.ExecuteWithGlobalState(coordinator.GlobalState)
+	wg.Wait()
+}
+
+func TestDistributedExecution(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { check } from 'k6';
+		import { Counter } from 'k6/metrics';
+
+		const iterations = new Counter('distributed_iterations');
+
+		export const options = {
+			scenarios: {
+				shared: {
+					executor: 'shared-iterations',
+					vus: 2,
+					iterations: 10,
+				},
+			},
+			thresholds: {
+				distributed_iterations: ['count == 10'],
+				checks: ['rate == 1'],
+			},
+		};
+
+		export function setup() {
+			return { value: 'from setup' };
+		}
+
+		export default function (data) {
+			iterations.add(1);
+			check(data, { 'has setup data': (d) => d.value === 'from setup' });
+		}
+	`
+
+	coordinatorAddress := getFreeBindAddr(t)
+	coordinator := NewGlobalTestState(t)
+	coordinator.CmdArgs = []string{
+		"k6", "coordinator", "--instance-count", "2", "--coordinator-address", coordinatorAddress, "-",
+	}
+	coordinator.Stdin = bytes.NewBufferString(script)
+
+	agents := make([]*GlobalTestState, 2)
+	for i := range agents {
+		agents[i] = NewGlobalTestState(t)
+		agents[i].CmdArgs = []string{"k6", "agent", coordinatorAddress}
+	}
+	runDistributedTest(t, coordinator, agents)
+
+	for _, agent := range agents {
+		stdout := agent.Stdout.String()
+		t.Log(stdout)
+		assert.Contains(t, stdout, "execution: local")
+		assert.Contains(t, stdout, "1 VUs")
+		assert.NotContains(t, stdout, "distributed_iterations")
+	}
+
+	stdout := coordinator.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "✓ has setup data")
+	assert.Contains(t, stdout, "✓ distributed_iterations")
+	assert.Contains(t, stdout, "✓ checks")
+}
+
+func TestDistributedExecutionThresholdAbort(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { sleep } from 'k6';
+		import { Counter } from 'k6/metrics';
+
+		const iterations = new Counter('distributed_iterations');
+
+		export const options = {
+			vus: 2,
+			duration: '1m',
+			thresholds: {
+				distributed_iterations: [{ threshold: 'count < 5', abortOnFail: true }],
+			},
+		};
+
+		export default function () {
+			iterations.add(1);
+			sleep(0.1);
+		}
+	`
+
+	coordinatorAddress := getFreeBindAddr(t)
+	coordinator := NewGlobalTestState(t)
+	coordinator.CmdArgs = []string{
+		"k6", "coordinator", "--instance-count", "2", "--coordinator-address", coordinatorAddress, "-",
+	}
+	coordinator.Stdin = bytes.NewBufferString(script)
+	coordinator.ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+
+	agents := make([]*GlobalTestState, 2)
+	for i := range agents {
+		agents[i] = NewGlobalTestState(t)
+		agents[i].CmdArgs = []string{"k6", "agent", coordinatorAddress}
+		agents[i].ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+	}
+	runDistributedTest(t, coordinator, agents)
+
+	stdout := coordinator.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "✗ distributed_iterations")
+	assert.True(t, testutils.LogContains(coordinator.LoggerHook.Drain(), logrus.ErrorLevel, "thresholds on metrics"))
+}
+
+func TestDistributedExecutionToken(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = { vus: 1, iterations: 2 };
+		export default function () {}
+	`
+
+	coordinatorAddress := getFreeBindAddr(t)
+	coordinator := NewGlobalTestState(t)
+	coordinator.CmdArgs = []string{
+		"k6", "coordinator", "--api-token", "secret", "--coordinator-address", coordinatorAddress, "-",
+	}
+	coordinator.Stdin = bytes.NewBufferString(script)
+
+	unauthenticated := NewGlobalTestState(t)
+	unauthenticated.CmdArgs = []string{"k6", "agent", coordinatorAddress}
+	unauthenticated.ExpectedExitCode = -1
+	agent := NewGlobalTestState(t)
+	agent.CmdArgs = []string{"k6", "agent", "--api-token", "secret", coordinatorAddress}
+	runDistributedTest(t, coordinator, []*GlobalTestState{unauthenticated, agent})
+
+	assert.True(t, testutils.LogContains(unauthenticated.LoggerHook.Drain(), logrus.ErrorLevel, "coordinator error (401)"))
+	assert.True(t, testutils.LogContains(coordinator.LoggerHook.Drain(), logrus.WarnLevel, "sent in plaintext"))
+	stdout := coordinator.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "iterations")
+}
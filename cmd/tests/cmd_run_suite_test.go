@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const (
+	suitePassingScript = `
+		import { Counter } from 'k6/metrics';
+		const c = new Counter('suite_iterations');
+		export const options = { thresholds: { suite_iterations: ['count > 0'] } };
+		export default function () { c.add(1); }
+	`
+	suiteFailingScript = `
+		import { Counter } from 'k6/metrics';
+		const c = new Counter('suite_iterations');
+		export const options = { thresholds: { suite_iterations: ['count > 1000'] } };
+		export default function () { c.add(1); }
+	`
+)
+
+func TestRunSuiteFromArgs(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "a.js"), []byte(suitePassingScript), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "b.js"), []byte(suiteFailingScript), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "--iterations", "3", "a.js", "b.js"}
+	ts.ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Equal(t, 2, strings.Count(stdout, "suite_iterations..."))
+	assert.Contains(t, stdout, "suite: 2 tests, 1 passed, 1 failed, 0 skipped")
+	assert.Contains(t, stdout, "✓ a.js")
+	assert.Contains(t, stdout, "✗ b.js")
+	assert.Contains(t, stdout, "thresholds on metrics 'suite_iterations' have been crossed")
+}
+
+func TestRunSuiteFromManifest(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "suite", "a.js"), []byte(suitePassingScript), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "suite", "b.js"), []byte(suitePassingScript), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "suite", "suite.json"), []byte(`{
+		"parallel": true,
+		"options": { "iterations": 2 },
+		"tests": [
+			{ "name": "first", "path": "a.js" },
+			{ "name": "second", "path": "b.js", "options": { "iterations": 5 } }
+		]
+	}`), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "--suite", "suite/suite.json"}
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "suite: 2 tests, 2 passed, 0 failed, 0 skipped")
+	assert.Contains(t, stdout, "✓ first")
+	assert.Contains(t, stdout, "✓ second")
+	assert.Contains(t, stdout, "iterations...........: 2 ")
+	assert.Contains(t, stdout, "iterations...........: 5 ")
+}
+
+func TestRunSuiteInvalidManifest(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "suite.json"), []byte(`{"tests": []}`), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "--suite", "suite.json"}
+	ts.ExpectedExitCode = int(exitcodes.InvalidConfig)
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stderr.String(), "the suite manifest 'suite.json' doesn't contain any tests")
+}
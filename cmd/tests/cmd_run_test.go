@@ -2,6 +2,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -28,10 +29,12 @@ import (
 	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/event"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/lib/testutils"
 	"go.k6.io/k6/lib/testutils/httpmultibin"
+	"go.k6.io/k6/loader"
 )
 
 func TestVersion(t *testing.T) {
@@ -440,7 +443,7 @@ func TestExecutionTestOptionsDefaultValues(t *testing.T) {
 	loglines := ts.LoggerHook.Drain()
 	require.Len(t, loglines, 1)
 
-	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null}},"localIPs":null}`
+	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"correlationIdHeader":null,"tracePropagator":null,"traceSampling":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsCurves":null,"tlsVersion":null,"tlsAuth":null,"tlsSessionResumption":null,"throw":null,"thresholds":null,"compositeThresholds":null,"slos":null,"abortOnErrorRate":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTrendStatsPerMetric":null,"summaryBreakdown":null,"summaryTimeSeries":null,"summaryTimeUnit":null,"trendSignificantDigits":null,"maxTimeSeries":null,"maxTimeSeriesAction":null,"maxMemory":null,"generatorMetrics":null,"warmVUs":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"captureFailures":null,"captureFailuresLimit":null,"captureFailuresBodySize":null,"faults":null,"vuBandwidth":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null,"dependsOn":null,"startWhen":null,"abortOnErrorRate":null,"discardResponseBodies":null,"vuBandwidth":null,"bandwidth":null,"localIPs":null,"proxy":null,"http2":null}},"localIPs":null}`
 	assert.JSONEq(t, expected, loglines[0].Message)
 }
 
@@ -863,6 +866,50 @@ func asyncWaitForStdoutAndStopTestFromRESTAPI(
 	})
 }
 
+func TestRESTAPIWithToken(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{
+		"k6", "run", "--api-token", "secret", "-v", "--log-output=stdout", "--iterations", "20", "-",
+	}
+	ts.Stdin = bytes.NewBufferString(`
+		import { sleep } from 'k6';
+		export default function () {
+			console.log('a simple iteration');
+			sleep(1);
+		};
+	`)
+	ts.ExpectedExitCode = int(exitcodes.ScriptStoppedFromRESTAPI)
+
+	asyncWaitForStdoutAndRun(t, ts, 15, time.Second, "a simple iteration", func() {
+		stop := func(token string) int {
+			req, err := http.NewRequestWithContext(
+				context.Background(), http.MethodPatch, fmt.Sprintf("http://%s/v1/status", ts.Flags.Address),
+				bytes.NewBufferString(`{"data":{"type":"status","id":"default","attributes":{"stopped":true}}}`),
+			)
+			require.NoError(t, err)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+			return resp.StatusCode
+		}
+		assert.Equal(t, http.StatusUnauthorized, stop(""))
+		assert.Equal(t, http.StatusUnauthorized, stop("wrong"))
+		assert.Equal(t, http.StatusOK, stop("secret"))
+	})
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `The REST API token will be sent in plaintext`)
+	assert.Contains(t, stdout, `level=error msg="test run stopped from REST API`)
+}
+
 // TODO: add more abort scenario tests, see
 // https://github.com/grafana/k6/issues/2804
 
@@ -1071,6 +1118,71 @@ func TestAbortedByTestAbortFirstInitCode(t *testing.T) {
 	assert.NotContains(t, stdout, "bogus summary")
 }
 
+func TestAbortedByTestAbortGracefully(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+		import { sleep } from 'k6';
+
+		export const options = {
+			scenarios: {
+				main: { executor: 'constant-vus', vus: 2, duration: '1m', gracefulStop: '10s' },
+				later: { executor: 'constant-vus', vus: 1, duration: '1m', startTime: '30s' },
+			},
+		};
+
+		export default function () {
+			if (exec.vu.idInTest === 1 && exec.vu.iterationInScenario === 1) {
+				exec.test.abort({ reason: 'mayday', exitCode: 42, graceful: true });
+				console.log('the aborting iteration continued');
+			}
+			sleep(0.5);
+		};
+
+		export function teardown() {
+			console.log('teardown ran');
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.ExitCode(42))
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "the aborting iteration continued")
+	assert.Contains(t, stdout, "teardown ran")
+	assert.Contains(t, stdout, "✗ test aborted: mayday, gracefully (exit code 42)")
+	assert.Contains(t, stdout, "0 interrupted iterations")
+}
+
+func TestSecretSourcesAreMasked(t *testing.T) {
+	t.Parallel()
+	script := `
+		import secrets from 'k6/secrets';
+		import { check } from 'k6';
+
+		export const options = { iterations: 1 };
+
+		export default async function () {
+			const token = await secrets.get('API_TOKEN');
+			console.log('the token is ' + token + ' and the password ' + __ENV.DB_PASSWORD);
+			check(token, { ['token ' + token]: (t) => t.length > 0 });
+		};
+	`
+
+	ts := getSingleFileTestState(t, script,
+		[]string{"--log-output=stdout", "--secret-source", "file=secrets.env", "--secret-source", "env=DB_PASSWORD"}, 0)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "secrets.env"), []byte("API_TOKEN=t0k3n-value\n"), 0o600))
+	ts.Env["DB_PASSWORD"] = "hunter2-value"
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "the token is ***SECRET_REDACTED*** and the password ***SECRET_REDACTED***")
+	assert.Contains(t, stdout, "✓ token ***SECRET_REDACTED***")
+	assert.NotContains(t, stdout, "t0k3n-value")
+	assert.NotContains(t, stdout, "hunter2-value")
+}
+
 func TestAbortedByTestAbortInNonFirstInitCode(t *testing.T) {
 	t.Parallel()
 	script := `
@@ -1903,6 +2015,19 @@ func TestUIRenderWebDashboard(t *testing.T) {
 	}
 }
 
+func TestRunWebDashboardExport(t *testing.T) {
+	t.Parallel()
+
+	ts := getSingleFileTestState(t, `export default function () {}`,
+		[]string{"--quiet", "--web-dashboard", "--web-dashboard-export", "dashboard.html"}, 0)
+	ts.Env["K6_WEB_DASHBOARD_PORT"] = "0"
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := fsext.ReadFile(ts.FS, "dashboard.html")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<html")
+}
+
 // TestRunStaticArchives tests that the static archives are working as expected.
 // each archive contains the following files/catalogs:
 // ├── a.js
@@ -2301,3 +2426,1057 @@ func TestSetupTimeout(t *testing.T) {
 	t.Log(stderr)
 	assert.Contains(t, stderr, "setup() execution timed out after 1 seconds")
 }
+
+func TestRunTypeScript(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "lib.ts"), []byte(`
+		export function greet(name: string): string {
+			return "hello " + name;
+		}
+	`), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.ts"), []byte(`
+		import { Options } from "k6/options";
+		import { greet } from "./lib.ts";
+
+		export const options: Options = { iterations: 1 };
+
+		export default function (): void {
+			console.log(greet("typescript"));
+			const answer: number = 42;
+			throw new Error("oops " + answer);
+		}
+	`), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "-v", "--log-output=stdout", "test.ts"}
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `level=info msg="hello typescript" source=console`)
+	assert.Contains(t, stdout, `level=error msg="Error: oops 42\n\tat test_default (file:///test/test.ts:10:`)
+}
+
+func TestRunTopLevelAwaitAndDynamicImport(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "lib", "config.js"), []byte(`
+		import { setTimeout } from "k6/experimental/timers";
+
+		const name = "greeter";
+		const { greet } = await import(`+"`./${name}.js`"+`);
+		await new Promise((resolve) => setTimeout(resolve, 10));
+		export const greeting = greet("modules");
+	`), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "lib", "greeter.js"), []byte(`
+		export function greet(name) {
+			return "hello " + name;
+		}
+	`), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(`
+		import { greeting } from "./lib/config.js";
+
+		const message = greeting.toUpperCase(); // the import is evaluated before this module
+		export const options = { iterations: 1 };
+
+		export default function () {
+			console.log(message);
+		}
+	`), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "-v", "--log-output=stdout", "test.js"}
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `level=info msg="HELLO MODULES" source=console`)
+}
+
+func TestRunTopLevelAwaitNeverFinished(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(`
+		await new Promise(() => {});
+		export default function () {}
+	`), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "test.js"}
+	ts.ExpectedExitCode = -1
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stderr.String(), "the top-level await of the script never finished")
+}
+
+func TestRunNodeModules(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"node_modules/greeter/package.json": `{"name": "greeter", "exports": {"import": "./esm/index.js"}}`,
+		"node_modules/greeter/esm/index.js": `export function greet(name) { return "hello " + name; }`,
+		"node_modules/shout/package.json":   `{"name": "shout", "main": "lib/shout"}`,
+		"node_modules/shout/lib/shout.js":   `module.exports = function (s) { return s.toUpperCase(); };`,
+		"test.js": `
+			import { greet } from "greeter";
+			import shout from "shout";
+
+			export const options = { iterations: 1 };
+
+			export default function () {
+				console.log(shout(greet("node modules")));
+			}
+		`,
+	}
+	ts := NewGlobalTestState(t)
+	for name, data := range files {
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, name), []byte(data), 0o644))
+	}
+
+	ts.CmdArgs = []string{"k6", "run", "--log-output=stdout", "test.js"}
+	ts.ExpectedExitCode = int(exitcodes.ScriptException)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Contains(t, ts.Stdout.String(), `The moduleSpecifier \"greeter\" couldn't be recognised as something k6 supports.`)
+
+	ts = NewGlobalTestState(t)
+	for name, data := range files {
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, name), []byte(data), 0o644))
+	}
+	ts.CmdArgs = []string{"k6", "archive", "--module-resolution=node", "test.js"}
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	archive, err := fsext.ReadFile(ts.FS, "archive.tar")
+	require.NoError(t, err)
+
+	// the archive is run without the node_modules directory
+	ts = NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), archive, 0o644))
+	ts.CmdArgs = []string{"k6", "run", "--log-output=stdout", "archive.tar"}
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `level=info msg="HELLO NODE MODULES" source=console`)
+}
+
+func TestRunWithLockfile(t *testing.T) {
+	t.Parallel()
+
+	const libURL = "https://example.com/lib.js"
+	script := `
+		import { greeting } from "https://example.com/lib.js";
+
+		export const options = { iterations: 1 };
+
+		export default function () {
+			console.log(greeting);
+		}
+	`
+	newState := func(t *testing.T, script string) *GlobalTestState {
+		ts := NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+
+		// the locked module is only in the cache, so it's never fetched
+		filesystems := map[string]fsext.Fs{"https": fsext.NewMemMapFs()}
+		data := []byte(`export const greeting = "hello from the lockfile";`)
+		require.NoError(t, fsext.WriteFile(filesystems["https"], "/example.com/lib.js", data, 0o644))
+		lock := loader.NewLockfile()
+		_, err := lock.LockModules(filesystems, ts.FS, filepath.Join(ts.Cwd, loader.ModulesCacheDir), []string{libURL})
+		require.NoError(t, err)
+		require.NoError(t, lock.Write(ts.FS, filepath.Join(ts.Cwd, loader.LockfileName)))
+		return ts
+	}
+
+	t.Run("locked", func(t *testing.T) {
+		t.Parallel()
+		ts := newState(t, script)
+		ts.CmdArgs = []string{"k6", "run", "--log-output=stdout", "--frozen-lockfile", "test.js"}
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stdout.String(), `level=info msg="hello from the lockfile" source=console`)
+	})
+
+	t.Run("not locked", func(t *testing.T) {
+		t.Parallel()
+		ts := newState(t, `import "https://example.com/other.js";`+script)
+		ts.CmdArgs = []string{"k6", "run", "--log-output=stdout", "test.js"}
+		ts.Env["K6_FROZEN_LOCKFILE"] = "true"
+		ts.ExpectedExitCode = int(exitcodes.ScriptException)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stdout.String(), "the remote module 'example.com/other.js' isn't in the lockfile")
+	})
+
+	t.Run("deps", func(t *testing.T) {
+		t.Parallel()
+		ts := NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(`
+			import http from "k6/http";
+			export default function () {}
+		`), 0o644))
+		ts.CmdArgs = []string{"k6", "deps", "test.js"}
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stdout.String(), "Locked 0 remote modules")
+
+		lock, err := loader.ReadLockfile(ts.FS, filepath.Join(ts.Cwd, loader.LockfileName))
+		require.NoError(t, err)
+		assert.Empty(t, lock.Modules)
+	})
+}
+
+func TestRunHTMLReport(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { check } from 'k6';
+
+		export const options = {
+			iterations: 3,
+			thresholds: {
+				checks: ['rate == 1'],
+				iteration_duration: ['max < 0'],
+			},
+		};
+
+		export default function () {
+			check(null, { 'is null': (v) => v === null });
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--no-summary", "--report", "html=results.html"},
+		exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := fsext.ReadFile(ts.FS, "results.html")
+	require.NoError(t, err)
+	html := string(data)
+	assert.Contains(t, html, `<span class="badge fail">failed</span>`)
+	assert.Contains(t, html, `<td><code>rate == 1</code></td><td class="ok">✓ passed</td>`)
+	assert.Contains(t, html, `<td><code>max &lt; 0</code></td><td class="fail">✗ failed</td>`)
+	assert.Contains(t, html, `<td>is null</td><td class="num">3</td><td class="num">0</td>`)
+	assert.Contains(t, html, `<td>default</td><td class="num">3</td>`)
+}
+
+func TestRunJUnitReport(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { check } from 'k6';
+
+		export const options = {
+			iterations: 2,
+			thresholds: {
+				iteration_duration: ['max < 0'],
+			},
+		};
+
+		export default function () {
+			check(null, { 'is null': (v) => v === null });
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--no-summary", "--report", "junit"},
+		exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := fsext.ReadFile(ts.FS, "junit.xml")
+	require.NoError(t, err)
+	xml := string(data)
+	assert.Contains(t, xml, `<testsuites name="k6" tests="2" failures="1"`)
+	assert.Contains(t, xml, `<testcase name="max &lt; 0" classname="iteration_duration"`)
+	assert.Contains(t, xml, `type="threshold"`)
+	assert.Contains(t, xml, `<testcase name="is null" classname="checks" time="0">`)
+}
+
+func TestRunSummaryExportTargets(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { check } from 'k6';
+
+		export const options = { iterations: 2 };
+
+		export default function () {
+			check(null, { 'is null': (v) => v === null });
+		}
+	`
+
+	t.Run("Multiple", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script, []string{
+			"--quiet", "--summary-export", "summary.json,junit=junit.xml,markdown=summary.md,template=comment.txt",
+			"--summary-template", "ci.tmpl",
+		}, 0)
+		require.NoError(t, fsext.WriteFile(ts.FS, "ci.tmpl", []byte(
+			`{{ if .Passed }}passed{{ end }}{{ range .Checks }} {{ .Name }}: {{ .Passes }}{{ end }}`), 0o644))
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		data, err := fsext.ReadFile(ts.FS, "summary.json")
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"is null": {`)
+		data, err = fsext.ReadFile(ts.FS, "junit.xml")
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `<testcase name="is null" classname="checks" time="0">`)
+		data, err = fsext.ReadFile(ts.FS, "summary.md")
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "|  | ✅ is null | 2 | 0 |")
+		data, err = fsext.ReadFile(ts.FS, "comment.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "passed is null: 2", string(data))
+	})
+
+	t.Run("MissingTemplate", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script, []string{"--summary-export", "template=comment.txt"},
+			exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stderr.String(), "the template reports need a template, set with the --summary-template option")
+	})
+
+	t.Run("InvalidType", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script, []string{"--summary-export", "pdf=summary.pdf"}, exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stderr.String(), "invalid summary export target: invalid report type 'pdf'")
+	})
+}
+
+func TestRunOutputFilters(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const kept = new Counter('kept_counter');
+		const dropped = new Counter('dropped_counter');
+
+		export const options = { iterations: 1 };
+
+		export default function () {
+			kept.add(1, { secret: 'abc' });
+			dropped.add(1);
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--no-summary", "--out", "json=results.json",
+		"--config", "config.json"}, 0)
+	config := `{"outputFilters": {"json": {
+		"includeMetrics": ["kept_*"],
+		"dropTags": ["secret"],
+		"renameMetrics": {"kept_counter": "renamed_counter"}
+	}}}`
+	require.NoError(t, fsext.WriteFile(ts.FS, "config.json", []byte(config), 0o644))
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(jsonResults), `"metric":"renamed_counter"`)
+	assert.NotContains(t, string(jsonResults), "kept_counter")
+	assert.NotContains(t, string(jsonResults), "dropped_counter")
+	assert.NotContains(t, string(jsonResults), "secret")
+}
+
+func TestRunOutputAggregation(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Trend } from 'k6/metrics';
+
+		const trend = new Trend('my_trend');
+
+		export const options = { iterations: 20 };
+
+		export default function () {
+			trend.add(__ITER);
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--no-summary", "--out", "json=results.json",
+		"--config", "config.json"}, 0)
+	config := `{"outputAggregation": {"json": {"window": "1h", "significantDigits": 2}}}`
+	require.NoError(t, fsext.WriteFile(ts.FS, "config.json", []byte(config), 0o644))
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.NotContains(t, string(jsonResults), `"type":"Point"`)
+	assert.Contains(t, string(jsonResults), `"type":"AggregatedPoint"`)
+	assert.Regexp(t, `"window":3600,"tags":\{[^}]*\},"count":20,"value":9.5,"min":0,"max":19,"percentiles":\{`,
+		string(jsonResults))
+}
+
+func TestRunProfile(t *testing.T) {
+	t.Parallel()
+
+	config := `{"profiles": {
+		"ci": {"out": ["json=results.json"], "report": ["junit=results.xml"]},
+		"live": {"out": ["prometheus"]}
+	}}`
+
+	t.Run("selected", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, `export default function () {}`,
+			[]string{"--quiet", "--no-summary", "--config", "config.json", "--profile", "ci"}, 0)
+		require.NoError(t, fsext.WriteFile(ts.FS, "config.json", []byte(config), 0o644))
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+		require.NoError(t, err)
+		assert.Contains(t, string(jsonResults), `"metric":"iterations"`)
+		exists, err := fsext.Exists(ts.FS, "results.xml")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, `export default function () {}`,
+			[]string{"--config", "config.json"}, exitcodes.InvalidConfig)
+		ts.Env["K6_PROFILE"] = "nightly"
+		require.NoError(t, fsext.WriteFile(ts.FS, "config.json", []byte(config), 0o644))
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stderr.String(), "invalid profile 'nightly', available profiles are: ci, live")
+	})
+}
+
+func TestRunInvalidReport(t *testing.T) {
+	t.Parallel()
+
+	ts := getSingleFileTestState(t, `export default function () {}`, []string{"--report", "pdf=report.pdf"},
+		exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Contains(t, ts.Stderr.String(), "invalid report type 'pdf', available types are: html, junit, markdown, template")
+}
+
+func TestRunMaxTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const counter = new Counter('my_counter');
+
+		export const options = { iterations: 1 };
+
+		export default function () {
+			for (let i = 0; i < 20; i++) {
+				counter.add(1, { id: String(i) });
+			}
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--out", "json=results.json",
+		"--max-time-series", "10", "--max-time-series-action", "overflow"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.WarnLevel,
+		"The test has more than 10 unique time series"))
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "more than 10 unique time series, the new ones were handled with the overflow action")
+	assert.Contains(t, stdout, "my_counter...........: 20")
+	assert.Contains(t, stdout, "top metrics........: my_counter=11")
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(jsonResults), `"__cardinality_overflow__":"true"`)
+}
+
+func TestRunThresholdsOverWindows(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const counter = new Counter('my_counter');
+
+		export const options = {
+			iterations: 10,
+			thresholds: {
+				my_counter: ['count < 5 over 1m', 'count < 100'],
+			},
+		};
+
+		export default function () {
+			counter.add(1);
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet"}, exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "✗ my_counter")
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"thresholds on metrics 'my_counter' have been crossed"))
+}
+
+func TestRunCompositeThresholds(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const errors = new Counter('errors');
+
+		export const options = {
+			iterations: 10,
+			compositeThresholds: {
+				error_ratio: ['errors.count / iterations.count < 0.1'],
+			},
+		};
+
+		export default function () {
+			if (__ITER % 2 == 0) {
+				errors.add(1);
+			}
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet"}, exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"thresholds on metrics 'error_ratio' have been crossed"))
+	assert.Regexp(t, `✗ error_ratio\.+: errors\.count / iterations\.count < 0\.1`, ts.Stdout.String())
+}
+
+func TestRunInvalidCompositeThresholds(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = {
+			compositeThresholds: {
+				error_ratio: ['errors.count / iterations.count < 0.1'],
+			},
+		};
+
+		export default function () {}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--no-summary"}, exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stderr.String(), `no metric name \"errors\" found`)
+}
+
+func TestRunSLOs(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Trend } from 'k6/metrics';
+
+		const latency = new Trend('latency', true);
+
+		export const options = {
+			iterations: 10,
+			slos: {
+				api: { metric: 'latency', target: '100ms', errorBudget: 0.1, windows: ['1m'] },
+			},
+			thresholds: {
+				'slo_apdex{slo:api}': ['value>0.9'],
+				'slo_burn_rate{slo:api,window:1m}': ['value<10'],
+			},
+		};
+
+		export default function () {
+			latency.add(__ITER < 5 ? 50 : 1000);
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet"}, exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Regexp(t, `✗ { slo:api }\.*: 0\.5 `, stdout)
+	assert.Regexp(t, `✓ { slo:api,window:1m }\.*: 5 `, stdout)
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"thresholds on metrics 'slo_apdex{slo:api}' have been crossed"))
+}
+
+func TestRunInvalidSLOs(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = {
+			slos: {
+				api: { metric: 'iterations', target: '100ms', errorBudget: 0.1 },
+			},
+		};
+
+		export default function () {}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--no-summary"}, exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stderr.String(), `\"iterations\" isn't a trend of durations`)
+}
+
+func TestRunBaseline(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Trend, Rate } from 'k6/metrics';
+
+		const trend = new Trend('my_trend', true);
+		const rate = new Rate('my_rate');
+
+		export const options = { iterations: 10 };
+
+		export default function () {
+			trend.add(100);
+			rate.add(true);
+		}
+	`
+	baseline := `{"metrics": {
+		"my_trend": {"type": "trend", "contains": "time", "values": {"avg": 100, "p(95)": 50}},
+		"my_rate": {"value": 0.9, "passes": 9, "fails": 1}
+	}}`
+
+	t.Run("Regressed", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script, []string{
+			"--quiet", "--baseline", "previous.json",
+			"--baseline-tolerance", "my_trend.p(95)=10%", "--baseline-tolerance", "my_rate.rate=-5%",
+			"--baseline-tolerance", "my_trend.p(99)=10%",
+		}, exitcodes.BaselineRegressed)
+		require.NoError(t, fsext.WriteFile(ts.FS, "previous.json", []byte(baseline), 0o644))
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		stdout := ts.Stdout.String()
+		assert.Contains(t, stdout, "compared with the baseline previous.json:")
+		assert.Contains(t, stdout, "✗ my_trend.p(95)...: 50ms → 100ms (+100%, tolerance +10%)")
+		assert.Contains(t, stdout, "✓ my_rate.rate.....: 90.00% → 100.00% (+11.11%, tolerance -5%)")
+
+		logs := ts.LoggerHook.Drain()
+		assert.True(t, testutils.LogContains(logs, logrus.WarnLevel,
+			"The statistics 'my_trend.p(99)=10%' couldn't be compared"))
+		assert.True(t, testutils.LogContains(logs, logrus.ErrorLevel,
+			"the metrics have regressed compared to the baseline beyond the tolerances 'my_trend.p(95)=10%'"))
+	})
+
+	t.Run("Passed", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script, []string{
+			"--quiet", "--baseline", "previous.json", "--baseline-tolerance", "my_trend.avg=0%",
+		}, 0)
+		require.NoError(t, fsext.WriteFile(ts.FS, "previous.json", []byte(baseline), 0o644))
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		assert.Contains(t, ts.Stdout.String(), "✓ my_trend.avg...: 100ms → 100ms (0%, tolerance 0%)")
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script, []string{"--quiet", "--baseline", "missing.json"}, exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		assert.Contains(t, ts.Stderr.String(), "couldn't read the baseline 'missing.json'")
+	})
+}
+
+func TestRunSummaryTrendStatsPerMetric(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Trend } from 'k6/metrics';
+
+		const trend = new Trend('my_trend');
+
+		export const options = {
+			iterations: 10,
+			summaryTrendStats: ['avg', 'max'],
+			summaryTrendStatsPerMetric: { my_trend: ['tmean(10)', 'iqr'] },
+			thresholds: {
+				my_trend: ['tmean(10) < 10', 'stddev > 1'],
+			},
+		};
+
+		export default function () {
+			trend.add(__ITER === 9 ? 1000 : __ITER);
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Regexp(t, `✓ my_trend\.+: avg=103\.6 +max=1000 +tmean\(10\)=4\.5 iqr=4\.5\n`, stdout)
+	assert.Regexp(t, `iteration_duration\.+: avg=\S+ max=\S+\n`, stdout)
+}
+
+func TestRunSummaryBreakdown(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { group } from 'k6';
+
+		export const options = {
+			scenarios: {
+				browse: { executor: 'shared-iterations', iterations: 3, exec: 'browse' },
+				checkout: { executor: 'shared-iterations', iterations: 2, exec: 'checkout' },
+			},
+		};
+
+		export function browse() {}
+
+		export function checkout() {
+			group('pay', function () {});
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--summary-breakdown", "scenario,group"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Regexp(t, `iterations\.+: 5 .*\n +{ scenario:browse }\.+: 3 .*\n +{ scenario:checkout }\.+: 2 `, stdout)
+	assert.Regexp(t, `group_duration\.+: .*\n +{ group:::pay }\.+: avg=`, stdout)
+	assert.Regexp(t, `group_duration.*\n(.*\n)* +{ scenario:checkout }\.+: avg=`, stdout)
+}
+
+func TestRunCaptureFailures(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	captureFile := filepath.Join(t.TempDir(), "failures.jsonl")
+	script := tb.Replacer.Replace(`
+		import http from 'k6/http';
+		import { check } from 'k6';
+
+		export const options = { iterations: 3 };
+
+		export default function () {
+			http.get('HTTPBIN_IP_URL/status/503');
+			const res = http.post('HTTPBIN_IP_URL/post', 'a long enough request body');
+			check(res, { 'is teapot': (r) => r.status === 418, 'is ok': (r) => r.status === 200 });
+		}
+	`)
+	ts := getSingleFileTestState(t, script, []string{
+		"--quiet", "--capture-failures", captureFile, "--capture-failures-limit", "2",
+		"--capture-failures-body-size", "10",
+	}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := os.ReadFile(captureFile) //nolint:forbidigo
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4)
+
+	var statuses, checks int
+	for _, line := range lines {
+		var failure lib.CapturedFailure
+		require.NoError(t, json.Unmarshal([]byte(line), &failure))
+		switch failure.Reason {
+		case "status":
+			statuses++
+			assert.Equal(t, 503, failure.Response.Status)
+			assert.Equal(t, tb.Replacer.Replace("HTTPBIN_IP_URL/status/503"), failure.Request.URL)
+		case "check":
+			checks++
+			assert.Equal(t, "is teapot", failure.Check)
+			assert.Equal(t, "POST", failure.Request.Method)
+			assert.Equal(t, "a long eno", failure.Request.Body)
+			assert.True(t, failure.Request.BodyTruncated)
+			assert.Equal(t, 200, failure.Response.Status)
+			assert.Len(t, failure.Response.Body, 10)
+			assert.True(t, failure.Response.BodyTruncated)
+			assert.NotEmpty(t, failure.Response.Headers["Content-Type"])
+		}
+	}
+	assert.Equal(t, 2, statuses)
+	assert.Equal(t, 2, checks)
+}
+
+func TestRunAbortOnErrorRate(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import http from 'k6/http';
+
+		export const options = {
+			scenarios: {
+				broken: { executor: 'constant-vus', vus: 2, duration: '1m', gracefulStop: '0s' },
+			},
+			abortOnErrorRate: { rate: 0.5, window: '10s' },
+		};
+
+		export default function () {
+			http.get('http://127.0.0.1:1');
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet"}, exitcodes.ErrorRateExceeded)
+	start := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Less(t, time.Since(start), 30*time.Second)
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"100.00% of the requests failed over the last 10s, more than the 50% over 10s of abortOnErrorRate"))
+}
+
+func TestRunDryRun(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const calls = new Counter('calls');
+
+		export const options = {
+			scenarios: {
+				load: { executor: 'constant-vus', vus: 10, duration: '1h', startTime: '1h' },
+				other: { executor: 'ramping-arrival-rate', exec: 'other', preAllocatedVUs: 5,
+					stages: [{ target: 100, duration: '1h' }], tags: { kind: 'other' } },
+			},
+			thresholds: { calls: ['count>100'] },
+		};
+
+		export default function () {
+			calls.add(1);
+		}
+
+		export function other() {
+			calls.add(1, { exec: 'other' });
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--dry-run", "--quiet"}, 0)
+	start := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Less(t, time.Since(start), 30*time.Second)
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "calls................: 2 ")
+	assert.Contains(t, stdout, "iterations...........: 2 ")
+}
+
+func TestRunDryRunErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("script exception", func(t *testing.T) {
+		t.Parallel()
+
+		script := `
+			export const options = { vus: 10, iterations: 100 };
+
+			export default function () {
+				throw new Error('broken');
+			}
+		`
+		ts := getSingleFileTestState(t, script, []string{"--dry-run", "--quiet"}, exitcodes.ScriptException)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+			"1 of the iterations of the dry run failed"))
+	})
+
+	t.Run("invalid threshold", func(t *testing.T) {
+		t.Parallel()
+
+		script := `
+			export const options = { thresholds: { http_req_duration: ['p(95)<<200'] } };
+
+			export default function () {}
+		`
+		ts := getSingleFileTestState(t, script, []string{"--dry-run", "--quiet"}, exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+	})
+}
+
+func TestRunLifecycleHooks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		events []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evt map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&evt))
+		assert.Equal(t, evt["type"], r.Header.Get("K6-Lifecycle-Event"))
+		mu.Lock()
+		events = append(events, evt)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const calls = new Counter('calls');
+
+		export const options = {
+			scenarios: {
+				ramp: { executor: 'ramping-vus', stages: [{ target: 2, duration: '1s' }, { target: 0, duration: '1s' }] },
+			},
+			thresholds: { calls: ['count<1'] },
+		};
+
+		export default function () {
+			calls.add(1);
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--lifecycle-hook", srv.URL},
+		exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// the thresholds are evaluated periodically, so they can be crossed
+	// at any point of the test run
+	var lifecycle []map[string]any
+	for _, evt := range events {
+		if evt["type"] == "threshold-crossed" {
+			assert.Equal(t, []any{"calls"}, evt["thresholds"])
+			continue
+		}
+		lifecycle = append(lifecycle, evt)
+	}
+	types := make([]any, 0, len(lifecycle))
+	for _, evt := range lifecycle {
+		types = append(types, evt["type"])
+	}
+	require.Equal(t, []any{"test-start", "scenario-start", "stage-change", "stage-change", "scenario-end", "test-end"},
+		types)
+	assert.Equal(t, []any{"ramp"}, lifecycle[0]["scenarios"])
+	assert.Equal(t, "ramp", lifecycle[1]["scenario"])
+	assert.Equal(t, map[string]any{"index": 0.0, "duration": "1s", "target": 2.0}, lifecycle[2]["stage"])
+	assert.Equal(t, map[string]any{"index": 1.0, "duration": "1s", "target": 0.0}, lifecycle[3]["stage"])
+	assert.Equal(t, []any{"calls"}, lifecycle[5]["thresholds"])
+	assert.Contains(t, lifecycle[5]["error"], "thresholds on metrics 'calls' have been crossed")
+}
+
+func TestRunLifecycleHookFailedStart(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	script := `export default function () {}`
+	ts := getSingleFileTestState(t, script, []string{"--quiet", "--lifecycle-hook", srv.URL},
+		exitcodes.LifecycleHookFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	logs := ts.LoggerHook.Drain()
+	assert.True(t, testutils.LogContains(logs, logrus.ErrorLevel,
+		"the test run was prevented from starting by a lifecycle hook"))
+	assert.True(t, testutils.LogContains(logs, logrus.ErrorLevel, "not ready"))
+}
+
+func TestRunFaults(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	script := fmt.Sprintf(`
+		import http from 'k6/http';
+		import { check } from 'k6';
+
+		export const options = {
+			scenarios: {
+				healthy: { executor: 'per-vu-iterations', exec: 'healthy' },
+				dns: { executor: 'per-vu-iterations', exec: 'dns' },
+				reset: { executor: 'per-vu-iterations', exec: 'reset' },
+			},
+			faults: [
+				{ type: 'dns', percentage: 100, scenarios: ['dns'] },
+				{ type: 'reset', percentage: 100, scenarios: ['reset'] },
+			],
+			thresholds: { checks: ['rate==1'] },
+		};
+
+		export function healthy() {
+			check(http.get('%[1]s'), { 'healthy': (r) => r.status === 200 });
+		}
+
+		export function dns() {
+			check(http.get('%[1]s'), { 'dns failure': (r) => r.error_code === 1101 });
+		}
+
+		export function reset() {
+			check(http.get('%[1]s'), { 'connection reset': (r) => r.error_code === 1220 });
+		}
+	`, srv.URL)
+	ts := getSingleFileTestState(t, script, []string{"--quiet"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "checks.........................: 100.00%")
+}
+
+func TestRunInvalidFaults(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = { faults: [{ type: 'loss', percentage: 10 }] };
+		export default function () {}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--quiet"}, exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, "unknown fault type 'loss'"))
+}
+
+func TestRunMaxMemory(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = { vus: 1, duration: '30s' };
+		export default function () {}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--max-memory", "1"}, exitcodes.MemoryLimitExceeded)
+	start := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, "more than the 1MB of maxMemory"))
+	stdout := ts.Stdout.String()
+	for _, name := range generatorMetricNames {
+		assert.NotContains(t, stdout, name)
+	}
+}
+
+//nolint:gochecknoglobals
+var generatorMetricNames = []string{
+	"event_loop_lag", "generator_cpu", "generator_memory", "sample_buffer_fill", "sample_buffer_full",
+}
+
+func TestRunGeneratorMetrics(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = { vus: 1, duration: '1500ms' };
+		export default function () {}
+	`
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	stdout := ts.Stdout.String()
+	for _, name := range generatorMetricNames {
+		assert.NotContains(t, stdout, name)
+	}
+
+	ts = getSingleFileTestState(t, script, []string{"--generator-metrics"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	stdout = ts.Stdout.String()
+	t.Log(stdout)
+	for _, name := range generatorMetricNames {
+		assert.Contains(t, stdout, name)
+	}
+}
+
+func TestRunWarmVUs(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = {
+			scenarios: {
+				rate: {
+					executor: 'constant-arrival-rate', rate: 1, duration: '1s',
+					preAllocatedVUs: 1, maxVUs: 4,
+				},
+			},
+		};
+		export default function () {}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--warm-vus", "3", "--verbose"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.DebugLevel,
+		"Warming up 3 unplanned VUs in the background..."))
+	assert.Regexp(t, `vus_max[.:\s]+4\s`, ts.Stdout.String())
+}
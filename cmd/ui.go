@@ -17,6 +17,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	v2 "go.k6.io/k6/api/v2"
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/consts"
@@ -114,7 +115,7 @@ func printExecutionDescription(
 	default:
 		for _, out := range outputs {
 			desc := out.Description()
-			if desc == engine.IngesterDescription {
+			if desc == engine.IngesterDescription || desc == v2.StreamDescription {
 				continue
 			}
 			if strings.HasPrefix(desc, dashboard.OutputName) {
@@ -0,0 +1,281 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options are the options of the conversion.
+type Options struct {
+	// Correlate enables the extraction of the dynamic values of the responses,
+	// like the session tokens and the CSRF fields, which are used by the later
+	// requests instead of their recorded values.
+	Correlate bool
+	// MinThinkTime is the pause between two requests under which there is no
+	// sleep() between them, like for the requests made in parallel by browsers.
+	MinThinkTime time.Duration
+	// MaxThinkTime is the maximum duration of the sleep() between two
+	// requests, there isn't any when it's zero.
+	MaxThinkTime time.Duration
+}
+
+// ignoredHeaders are the request headers which aren't in the script, since
+// they are either set by k6 itself or, for the cookies, by its cookie jar.
+//
+//nolint:gochecknoglobals
+var ignoredHeaders = map[string]bool{
+	"connection":     true,
+	"content-length": true,
+	"cookie":         true,
+	"host":           true,
+}
+
+// Convert returns the source of a k6 script making the requests of the HAR
+// recording, in the order they were made. The requests of each page are in a
+// group named after it, and the pauses between the requests are sleep() calls.
+func Convert(har HAR, opts Options) (string, error) {
+	entries := make([]Entry, len(har.Log.Entries))
+	copy(entries, har.Log.Entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	if len(entries) == 0 {
+		return "", errors.New("the HAR recording has no entries")
+	}
+
+	extractions := make([][]*correlation, len(entries))
+	if opts.Correlate {
+		extractions = findCorrelations(entries)
+	}
+	pageTitles := make(map[string]string, len(har.Log.Pages))
+	for _, page := range har.Log.Pages {
+		pageTitles[page.ID] = page.Title
+	}
+
+	sw := &scriptWriter{}
+	var (
+		known   []*correlation
+		lastEnd time.Time
+		page    string
+		inGroup bool
+	)
+	for _, e := range extractions {
+		if len(e) > 0 {
+			sw.line(1, "let res;")
+			sw.line(1, "const vars = {};")
+			sw.blank()
+			break
+		}
+	}
+	for i, e := range entries {
+		thinkTime := time.Duration(0)
+		if i > 0 {
+			thinkTime = opts.thinkTime(e.StartedDateTime.Sub(lastEnd))
+		}
+		if end := e.StartedDateTime.Add(time.Duration(e.Time * float64(time.Millisecond))); end.After(lastEnd) {
+			lastEnd = end
+		}
+
+		if i == 0 || e.Pageref != page {
+			if inGroup {
+				sw.line(1, "});")
+				sw.blank()
+			}
+			if thinkTime > 0 {
+				sw.sleep(1, thinkTime)
+			}
+			page, inGroup = e.Pageref, e.Pageref != ""
+			if inGroup {
+				title := pageTitles[page]
+				if title == "" {
+					title = page
+				}
+				sw.blank()
+				sw.line(1, "group("+jsString(title)+", function () {")
+				sw.usesGroup = true
+			}
+		} else if thinkTime > 0 {
+			sw.sleep(sw.indent(inGroup), thinkTime)
+		}
+
+		indent := sw.indent(inGroup)
+		sw.request(indent, e.Request, known, len(extractions[i]) > 0)
+		for _, c := range extractions[i] {
+			sw.line(indent, fmt.Sprintf("vars[%s] = %s;", jsString(c.name), c.extractor))
+		}
+		known = append(known, extractions[i]...)
+	}
+	if inGroup {
+		sw.line(1, "});")
+	}
+
+	return sw.script(), nil
+}
+
+// thinkTime returns the duration of the sleep() for the pause between two
+// requests, or zero if there shouldn't be one.
+func (opts Options) thinkTime(pause time.Duration) time.Duration {
+	pause = pause.Round(10 * time.Millisecond)
+	if pause <= 0 || pause < opts.MinThinkTime {
+		return 0
+	}
+	if opts.MaxThinkTime > 0 && pause > opts.MaxThinkTime {
+		return opts.MaxThinkTime
+	}
+	return pause
+}
+
+// scriptWriter writes the body of the default function of the script.
+type scriptWriter struct {
+	body      strings.Builder
+	usesGroup bool
+	usesSleep bool
+}
+
+func (sw *scriptWriter) indent(inGroup bool) int {
+	if inGroup {
+		return 2
+	}
+	return 1
+}
+
+func (sw *scriptWriter) line(indent int, code string) {
+	if code != "" {
+		sw.body.WriteString(strings.Repeat("  ", indent) + code)
+	}
+	sw.body.WriteString("\n")
+}
+
+// blank writes an empty line, unless it's the first one or there's already one.
+func (sw *scriptWriter) blank() {
+	if body := sw.body.String(); body != "" && !strings.HasSuffix(body, "\n\n") {
+		sw.body.WriteString("\n")
+	}
+}
+
+func (sw *scriptWriter) sleep(indent int, d time.Duration) {
+	sw.usesSleep = true
+	sw.line(indent, "sleep("+strconv.FormatFloat(d.Seconds(), 'f', -1, 64)+");")
+}
+
+// request writes the call of the k6/http function making the request, whose
+// response is assigned to res when it has values to extract.
+func (sw *scriptWriter) request(indent int, req Request, known []*correlation, assign bool) {
+	method := strings.ToUpper(req.Method)
+	args := []string{literal(req.URL, known)}
+	var fn string
+	switch method {
+	case "GET", "HEAD":
+		fn = "http." + strings.ToLower(method)
+	case "POST", "PUT", "PATCH", "OPTIONS", "DELETE":
+		fn = "http." + strings.ToLower(method)
+		if method == "DELETE" {
+			fn = "http.del"
+		}
+		args = append(args, requestBody(req, known))
+	default:
+		fn = "http.request"
+		args = append([]string{jsString(method)}, args[0], requestBody(req, known))
+	}
+
+	call := fn + "(" + strings.Join(args, ", ")
+	if assign {
+		call = "res = " + call
+	}
+	headers := scriptHeaders(req.Headers)
+	if len(headers) == 0 {
+		if args[len(args)-1] == "null" {
+			call = strings.TrimSuffix(call, ", null")
+		}
+		sw.line(indent, call+");")
+		return
+	}
+	sw.line(indent, call+", {")
+	sw.line(indent+1, "headers: {")
+	for _, h := range headers {
+		sw.line(indent+2, jsString(h.Name)+": "+literal(h.Value, known)+",")
+	}
+	sw.line(indent+1, "},")
+	sw.line(indent, "});")
+}
+
+func requestBody(req Request, known []*correlation) string {
+	if req.PostData == nil || req.PostData.Text == "" {
+		return "null"
+	}
+	return literal(req.PostData.Text, known)
+}
+
+// scriptHeaders returns the request headers which are set by the script, the
+// HTTP/2 pseudo-headers can't be sent as normal ones.
+func scriptHeaders(headers []Header) []Header {
+	result := make([]Header, 0, len(headers))
+	for _, h := range headers {
+		if !strings.HasPrefix(h.Name, ":") && !ignoredHeaders[strings.ToLower(h.Name)] {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+func (sw *scriptWriter) script() string {
+	var b strings.Builder
+	b.WriteString("// Converted from a HAR recording by k6 convert\n\n")
+	var k6Imports []string
+	if sw.usesGroup {
+		k6Imports = append(k6Imports, "group")
+	}
+	if sw.usesSleep {
+		k6Imports = append(k6Imports, "sleep")
+	}
+	if len(k6Imports) > 0 {
+		b.WriteString("import { " + strings.Join(k6Imports, ", ") + " } from 'k6';\n")
+	}
+	b.WriteString("import http from 'k6/http';\n\n")
+	b.WriteString("export default function () {\n")
+	b.WriteString(sw.body.String())
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// literal returns the JavaScript string of the value, which is a template
+// literal using the vars of the correlated values found in it, either as they
+// are or URL encoded.
+func literal(s string, known []*correlation) string {
+	sorted := make([]*correlation, len(known))
+	copy(sorted, known)
+	// the longest values are replaced first, if several of them match
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].value) > len(sorted[j].value) })
+
+	var replacements []string
+	for _, c := range sorted {
+		variable := "vars[" + jsString(c.name) + "]"
+		if strings.Contains(s, c.value) {
+			replacements = append(replacements, c.value, "${"+variable+"}")
+		}
+		if escaped := url.QueryEscape(c.value); escaped != c.value && strings.Contains(s, escaped) {
+			replacements = append(replacements, escaped, "${encodeURIComponent("+variable+")}")
+		}
+	}
+	if len(replacements) == 0 {
+		return jsString(s)
+	}
+	replacements = append(replacements, "\\", "\\\\", "`", "\\`", "${", "\\${", "\r", "\\r")
+	return "`" + strings.NewReplacer(replacements...).Replace(s) + "`"
+}
+
+// jsString returns the JavaScript string literal of s.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s) // strings can always be encoded
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,171 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHAR = `{
+  "log": {
+    "pages": [
+      { "id": "page_1", "title": "Login" },
+      { "id": "page_2", "title": "Orders" }
+    ],
+    "entries": [
+      {
+        "pageref": "page_1",
+        "startedDateTime": "2024-01-01T10:00:00.000Z",
+        "time": 100,
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/login",
+          "headers": [
+            { "name": ":authority", "value": "example.com" },
+            { "name": "Accept", "value": "text/html" },
+            { "name": "Cookie", "value": "tracking=1" }
+          ]
+        },
+        "response": {
+          "status": 200,
+          "headers": [],
+          "content": {
+            "mimeType": "text/html; charset=utf-8",
+            "text": "<form><input type=\"hidden\" name=\"csrf_token\" value=\"c5rf+t0ken/value\"><input name=\"user\" value=\"someone-else\"></form>"
+          }
+        }
+      },
+      {
+        "pageref": "page_1",
+        "startedDateTime": "2024-01-01T10:00:00.050Z",
+        "time": 50,
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/style.css",
+          "headers": []
+        },
+        "response": { "status": 200, "headers": [], "content": { "mimeType": "text/css", "text": "" } }
+      },
+      {
+        "pageref": "page_1",
+        "startedDateTime": "2024-01-01T10:00:02.600Z",
+        "time": 200,
+        "request": {
+          "method": "POST",
+          "url": "https://example.com/api/login",
+          "headers": [{ "name": "Content-Type", "value": "application/x-www-form-urlencoded" }],
+          "postData": {
+            "mimeType": "application/x-www-form-urlencoded",
+            "text": "csrf_token=c5rf%2Bt0ken%2Fvalue&user=someone-else"
+          }
+        },
+        "response": {
+          "status": 200,
+          "headers": [{ "name": "x-session-token", "value": "0123456789abcdef" }],
+          "content": {
+            "mimeType": "application/json",
+            "text": "{\"user\": {\"id\": \"user-000042\", \"name\": \"someone-else\"}, \"items\": [\"order-000001\"]}"
+          }
+        }
+      },
+      {
+        "pageref": "page_2",
+        "startedDateTime": "2024-01-01T10:00:30.000Z",
+        "time": 100,
+        "request": {
+          "method": "DELETE",
+          "url": "https://example.com/api/users/user-000042/orders/order-000001",
+          "headers": [{ "name": "Authorization", "value": "Bearer 0123456789abcdef" }]
+        },
+        "response": { "status": 204, "headers": [], "content": { "mimeType": "", "text": "" } }
+      }
+    ]
+  }
+}`
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	har, err := Parse([]byte(testHAR))
+	require.NoError(t, err)
+
+	opts := Options{Correlate: true, MinThinkTime: 500 * time.Millisecond, MaxThinkTime: 10 * time.Second}
+	script, err := Convert(har, opts)
+	require.NoError(t, err)
+	assert.Equal(t, `// Converted from a HAR recording by k6 convert
+
+import { group, sleep } from 'k6';
+import http from 'k6/http';
+
+export default function () {
+  let res;
+  const vars = {};
+
+  group("Login", function () {
+    res = http.get("https://example.com/login", {
+      headers: {
+        "Accept": "text/html",
+      },
+    });
+    vars["csrf_token"] = res.html().find("input[name=\"csrf_token\"]").first().attr("value");
+    http.get("https://example.com/style.css");
+    sleep(2.5);
+    res = http.post("https://example.com/api/login", `+"`csrf_token=${encodeURIComponent(vars[\"csrf_token\"])}&user=someone-else`"+`, {
+      headers: {
+        "Content-Type": "application/x-www-form-urlencoded",
+      },
+    });
+    vars["x_session_token"] = res.headers["X-Session-Token"];
+    vars["items"] = res.json("items.0");
+    vars["id"] = res.json("user.id");
+  });
+
+  sleep(10);
+
+  group("Orders", function () {
+    http.del(`+"`https://example.com/api/users/${vars[\"id\"]}/orders/${vars[\"items\"]}`"+`, null, {
+      headers: {
+        "Authorization": `+"`Bearer ${vars[\"x_session_token\"]}`"+`,
+      },
+    });
+  });
+}
+`, script)
+}
+
+func TestConvertWithoutCorrelation(t *testing.T) {
+	t.Parallel()
+
+	har, err := Parse([]byte(testHAR))
+	require.NoError(t, err)
+
+	script, err := Convert(har, Options{})
+	require.NoError(t, err)
+	assert.NotContains(t, script, "vars")
+	assert.Contains(t, script, `http.del("https://example.com/api/users/user-000042/orders/order-000001", null, {`)
+	assert.Contains(t, script, "    http.get(\"https://example.com/style.css\");\n    sleep(2.5);\n")
+	assert.Contains(t, script, "sleep(27.2);")
+}
+
+func TestLiteral(t *testing.T) {
+	t.Parallel()
+
+	known := []*correlation{
+		{name: "short", value: "abcdefgh"},
+		{name: "long", value: "abcdefgh-ijkl"},
+	}
+	assert.Equal(t, `"plain \"text\""`, literal(`plain "text"`, known))
+	assert.Equal(t, "`${vars[\"long\"]} and ${vars[\"short\"]}, \\`\\${x}\\\\`",
+		literal("abcdefgh-ijkl and abcdefgh, `${x}\\", known))
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte(`{"log": `))
+	assert.ErrorContains(t, err, "invalid HAR recording")
+	_, err = Parse([]byte(`{"log": {"entries": []}}`))
+	assert.EqualError(t, err, "the HAR recording has no entries")
+}
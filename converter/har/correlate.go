@@ -0,0 +1,211 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// minCorrelatedValueLength is the length under which the values of the
+// responses are never correlated, the shorter ones are too likely to be found
+// in the later requests by chance.
+const minCorrelatedValueLength = 8
+
+//nolint:gochecknoglobals
+var (
+	// tokenHeaderRegex matches the names of the response headers whose values
+	// are likely to be dynamic, like X-CSRF-Token.
+	tokenHeaderRegex = regexp.MustCompile(`(?i)token|csrf|xsrf|session|auth|nonce`)
+
+	inputTagRegex       = regexp.MustCompile(`(?is)<input\b[^>]*>`)
+	tagAttributeRegex   = regexp.MustCompile(`(?s)([a-zA-Z][\w-]*)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	invalidVarNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+)
+
+// correlation is a dynamic value of a response which is used by the later
+// requests, it's extracted into the vars object of the script.
+type correlation struct {
+	// key is where the value was found, e.g. the name of a header, which the
+	// name of the variable is derived from
+	key   string
+	name  string
+	value string
+	// extractor is the JavaScript expression extracting the value from the
+	// res response, e.g. `res.json("token")`
+	extractor string
+}
+
+// findCorrelations returns, by entry, the values of its response which have to
+// be extracted since they are used by the later requests. The values which
+// were already sent by the earlier requests aren't dynamic, so they're never
+// correlated.
+func findCorrelations(entries []Entry) [][]*correlation {
+	extractions := make([][]*correlation, len(entries))
+
+	type candidate struct {
+		*correlation
+		entry int
+		used  bool
+	}
+	var (
+		candidates []*candidate
+		values     = make(map[string]bool)
+		names      = make(map[string]int)
+		sent       strings.Builder
+	)
+	for i, e := range entries {
+		text := requestText(e.Request)
+		for _, c := range candidates {
+			if c.used || !containsValue(text, c.value) {
+				continue
+			}
+			c.used = true
+			c.name = uniqueVarName(names, c.key)
+			extractions[c.entry] = append(extractions[c.entry], c.correlation)
+		}
+
+		sent.WriteString(text)
+		for _, c := range responseCandidates(e.Response) {
+			if values[c.value] || strings.Contains(sent.String(), c.value) {
+				continue
+			}
+			values[c.value] = true
+			candidates = append(candidates, &candidate{correlation: c, entry: i})
+		}
+	}
+	return extractions
+}
+
+// requestText returns the parts of the request which are in the script, where
+// the correlated values are looked for.
+func requestText(req Request) string {
+	var b strings.Builder
+	b.WriteString(req.URL)
+	for _, h := range scriptHeaders(req.Headers) {
+		b.WriteString("\n" + h.Value)
+	}
+	if req.PostData != nil {
+		b.WriteString("\n" + req.PostData.Text)
+	}
+	return b.String()
+}
+
+func containsValue(text, value string) bool {
+	return strings.Contains(text, value) || strings.Contains(text, url.QueryEscape(value))
+}
+
+// responseCandidates returns the values of the response which could be
+// dynamic: those of the token headers, the strings of the JSON bodies and the
+// hidden inputs of the HTML forms.
+func responseCandidates(res Response) []*correlation {
+	var candidates []*correlation
+	for _, h := range res.Headers {
+		if len(h.Value) < minCorrelatedValueLength || !tokenHeaderRegex.MatchString(h.Name) {
+			continue
+		}
+		candidates = append(candidates, &correlation{
+			key:       h.Name,
+			value:     h.Value,
+			extractor: fmt.Sprintf("res.headers[%s]", jsString(textproto.CanonicalMIMEHeaderKey(h.Name))),
+		})
+	}
+
+	if res.Content.Encoding != "" {
+		return candidates // the binary bodies are base64 encoded
+	}
+	switch mimeType := strings.ToLower(res.Content.MimeType); {
+	case strings.Contains(mimeType, "json"):
+		var body any
+		if err := json.Unmarshal([]byte(res.Content.Text), &body); err == nil {
+			candidates = appendJSONCandidates(candidates, nil, body)
+		}
+	case strings.Contains(mimeType, "html"):
+		candidates = appendHiddenInputCandidates(candidates, res.Content.Text)
+	}
+	return candidates
+}
+
+// appendJSONCandidates appends the strings of the JSON value, which are
+// extracted with their GJSON path, like `res.json("user.tokens.0")`.
+func appendJSONCandidates(candidates []*correlation, path []string, value any) []*correlation {
+	switch value := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			// the keys with the special characters of GJSON would need escaping
+			if key != "" && !strings.ContainsAny(key, `.*?#|@\!=<>%`) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			candidates = appendJSONCandidates(candidates, append(path, key), value[key])
+		}
+	case []any:
+		for i, element := range value {
+			candidates = appendJSONCandidates(candidates, append(path, strconv.Itoa(i)), element)
+		}
+	case string:
+		if len(path) == 0 || len(value) < minCorrelatedValueLength {
+			break
+		}
+		key := path[len(path)-1]
+		for i := len(path) - 1; i > 0 && isIndex(key); i-- {
+			key = path[i-1] // the name of the array is better than its index
+		}
+		candidates = append(candidates, &correlation{
+			key:       key,
+			value:     value,
+			extractor: fmt.Sprintf("res.json(%s)", jsString(strings.Join(path, "."))),
+		})
+	}
+	return candidates
+}
+
+func isIndex(key string) bool {
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
+
+// appendHiddenInputCandidates appends the values of the hidden inputs of the
+// HTML forms, like the CSRF tokens.
+func appendHiddenInputCandidates(candidates []*correlation, body string) []*correlation {
+	for _, tag := range inputTagRegex.FindAllString(body, -1) {
+		attributes := make(map[string]string)
+		for _, match := range tagAttributeRegex.FindAllStringSubmatch(tag, -1) {
+			attributes[strings.ToLower(match[1])] = html.UnescapeString(match[2] + match[3])
+		}
+		name, value := attributes["name"], attributes["value"]
+		if !strings.EqualFold(attributes["type"], "hidden") || name == "" || strings.ContainsAny(name, `"\`) ||
+			len(value) < minCorrelatedValueLength {
+			continue
+		}
+		candidates = append(candidates, &correlation{
+			key:   name,
+			value: value,
+			extractor: fmt.Sprintf(`res.html().find(%s).first().attr("value")`,
+				jsString(`input[name="`+name+`"]`)),
+		})
+	}
+	return candidates
+}
+
+// uniqueVarName returns a name for the variable of the key which isn't
+// already used, e.g. "csrf_token_2" for the second CSRF token.
+func uniqueVarName(names map[string]int, key string) string {
+	name := strings.Trim(invalidVarNameRegex.ReplaceAllString(key, "_"), "_")
+	if name == "" {
+		name = "value"
+	}
+	names[name]++
+	if count := names[name]; count > 1 {
+		return name + "_" + strconv.Itoa(count)
+	}
+	return name
+}
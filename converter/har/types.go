@@ -0,0 +1,94 @@
+// Package har converts the HTTP Archive (HAR) recordings of browser sessions
+// to k6 scripts.
+package har
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HAR is the subset of the HTTP Archive 1.2 format that is used by the
+// converter and the recorder, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log is the root of the recording.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Pages   []Page  `json:"pages"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator is the application which made the recording.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Page is a page which was loaded during the recording.
+type Page struct {
+	ID              string    `json:"id"`
+	Title           string    `json:"title"`
+	StartedDateTime time.Time `json:"startedDateTime"`
+}
+
+// Entry is a recorded request and its response.
+type Entry struct {
+	Pageref         string    `json:"pageref"`
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the total time of the request in milliseconds
+	Time     float64  `json:"time"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	Headers  []Header  `json:"headers"`
+	PostData *PostData `json:"postData"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status  int      `json:"status"`
+	Headers []Header `json:"headers"`
+	Content Content  `json:"content"`
+}
+
+// Header is a header of a request or a response.
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is the body of a request.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is the body of a response, its text is encoded as told by its
+// encoding, e.g. "base64", when it's not empty.
+type Content struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding"`
+}
+
+// Parse parses a HAR recording.
+func Parse(data []byte) (HAR, error) {
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return har, fmt.Errorf("invalid HAR recording: %w", err)
+	}
+	if len(har.Log.Entries) == 0 {
+		return har, errors.New("the HAR recording has no entries")
+	}
+	return har, nil
+}
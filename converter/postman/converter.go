@@ -0,0 +1,542 @@
+package postman
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Options are the options of the conversion.
+type Options struct {
+	// Environment is the Postman environment whose variables override the
+	// ones of the collection, if any.
+	Environment *Environment
+}
+
+// dynamicVariables are the JavaScript expressions of the dynamic variables of
+// Postman which have an equivalent in k6.
+//
+//nolint:gochecknoglobals
+var dynamicVariables = map[string]string{
+	"$guid":         "crypto.randomUUID()",
+	"$randomUUID":   "crypto.randomUUID()",
+	"$timestamp":    "Math.floor(Date.now() / 1000)",
+	"$isoTimestamp": "new Date().toISOString()",
+	"$randomInt":    "Math.floor(Math.random() * 1001)",
+}
+
+var (
+	variableRegexp   = regexp.MustCompile(`\{\{([^{}]+)\}\}`)  //nolint:gochecknoglobals
+	identifierRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]+`)    //nolint:gochecknoglobals
+	pathParamRegexp  = regexp.MustCompile(`/:([a-zA-Z0-9_]+)`) //nolint:gochecknoglobals
+)
+
+// Convert returns the source of a k6 script making the requests of the Postman
+// collection. Each top-level folder is a scenario running an exported function
+// with its requests, whose nested folders are groups, and the top-level
+// requests are in the default function. The variables of the collection and of
+// the environment are in the vars object, and can be overridden with the
+// environment variables of k6.
+func Convert(c Collection, opts Options) (string, error) {
+	conv := &converter{functions: make(map[string]bool), untranslated: make(map[string]bool)}
+	chain := eventChain{events: c.Events}
+
+	var defaultItems []Item
+	var folders []*function
+	for _, item := range c.Items {
+		if !item.IsFolder() {
+			defaultItems = append(defaultItems, item)
+			continue
+		}
+		fn := conv.newFunction(item.Name)
+		fn.items(1, item.Items, chain.with(item.Events), inheritAuth(c.Auth, item.Auth))
+		folders = append(folders, fn)
+	}
+	var defaultFn *function
+	if len(defaultItems) > 0 {
+		defaultFn = &function{conv: conv, name: "default"}
+		defaultFn.items(1, defaultItems, chain, c.Auth)
+	}
+	if defaultFn == nil && len(folders) == 0 {
+		return "", fmt.Errorf("the Postman collection %q has no requests", c.Info.Name)
+	}
+
+	return conv.script(c, opts, defaultFn, folders), nil
+}
+
+// converter writes the script, from the bodies of its functions.
+type converter struct {
+	functions    map[string]bool
+	untranslated map[string]bool
+	// referenced are the variables used by the requests, in order.
+	referenced []string
+
+	usesVars     bool
+	usesCheck    bool
+	usesGroup    bool
+	usesEncoding bool
+	usesCrypto   bool
+}
+
+// newFunction returns an exported function named after the folder, whose
+// name is unique in the script.
+func (conv *converter) newFunction(folder string) *function {
+	base := strings.Trim(identifierRegexp.ReplaceAllString(folder, "_"), "_")
+	if base == "" || (base[0] >= '0' && base[0] <= '9') || base == "default" {
+		base = "folder_" + base
+	}
+	name := base
+	for i := 2; conv.functions[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	conv.functions[name] = true
+	return &function{conv: conv, name: name, folder: folder}
+}
+
+// eventChain are the events of an item and of its folders, from the
+// collection's ones to the item's ones, which are all run by Postman.
+type eventChain struct {
+	events []Event
+}
+
+func (ec eventChain) with(events []Event) eventChain {
+	all := make([]Event, 0, len(ec.events)+len(events))
+	return eventChain{events: append(append(all, ec.events...), events...)}
+}
+
+// script returns the lines of the scripts of the events of the type.
+func (ec eventChain) script(listen string) []string {
+	var lines []string
+	for _, e := range ec.events {
+		if e.Listen == listen {
+			lines = append(lines, e.Script.Exec...)
+		}
+	}
+	return lines
+}
+
+// inheritAuth returns the auth of an item, which inherits its folder's one
+// when it doesn't have its own.
+func inheritAuth(parent, auth *Auth) *Auth {
+	if auth == nil || auth.Type == "inherit" {
+		return parent
+	}
+	return auth
+}
+
+// function is an exported function of the script.
+type function struct {
+	conv    *converter
+	name    string
+	folder  string
+	body    strings.Builder
+	usesRes bool
+}
+
+func (fn *function) line(indent int, code string) {
+	if code != "" {
+		fn.body.WriteString(strings.Repeat("  ", indent) + code)
+	}
+	fn.body.WriteString("\n")
+}
+
+// items writes the requests of the items, and the groups of their folders.
+func (fn *function) items(indent int, items []Item, chain eventChain, auth *Auth) {
+	for i, item := range items {
+		if i > 0 {
+			fn.line(0, "")
+		}
+		itemChain := chain.with(item.Events)
+		if item.IsFolder() {
+			fn.conv.usesGroup = true
+			fn.line(indent, "group("+jsString(item.Name)+", function () {")
+			fn.items(indent+1, item.Items, itemChain, inheritAuth(auth, item.Auth))
+			fn.line(indent, "});")
+			continue
+		}
+		fn.request(indent, item, itemChain, inheritAuth(auth, item.Request.Auth))
+	}
+}
+
+// request writes the call of the k6/http function making the request of the
+// item, with the translations of its pre-request and test scripts before and
+// after it.
+func (fn *function) request(indent int, item Item, chain eventChain, auth *Auth) {
+	conv := fn.conv
+	req := item.Request
+	if item.Name != "" {
+		fn.line(indent, "// "+strings.ReplaceAll(item.Name, "\n", " "))
+	}
+	fn.script(indent, chain.script("prerequest"))
+
+	url := req.URL.Raw
+	for _, v := range req.URL.Variables {
+		url = pathParamRegexp.ReplaceAllStringFunc(url, func(param string) string {
+			if param[2:] == v.Key && v.Value != "" {
+				return "/" + string(v.Value)
+			}
+			return param
+		})
+	}
+	headers := make([]header, 0, len(req.Headers))
+	for _, h := range enabled(req.Headers) {
+		headers = append(headers, header{name: h.Key, value: conv.literal(string(h.Value))})
+	}
+	body, fields := fn.requestBody(indent, req.Body, &headers)
+	headers, url = fn.authenticate(indent, auth, headers, url)
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	urlArg := conv.literal(url)
+	var call string
+	switch method {
+	case "GET", "HEAD":
+		call = "http." + strings.ToLower(method) + "(" + urlArg
+	case "POST", "PUT", "PATCH", "OPTIONS", "DELETE":
+		call = "http." + strings.ToLower(method) + "(" + urlArg
+		if method == "DELETE" {
+			call = "http.del(" + urlArg
+		}
+	default:
+		call = "http.request(" + jsString(method) + ", " + urlArg
+	}
+	hasBody := method != "GET" && method != "HEAD"
+
+	tests := translate(chain.script("test"), conv.literal)
+	if tests.usesRes {
+		call = "res = " + call
+	}
+	switch {
+	case hasBody && fields != nil:
+		fn.line(indent, call+", {")
+		for _, f := range fields {
+			fn.line(indent+1, jsString(f.Key)+": "+conv.literal(string(f.Value))+",")
+		}
+		call = "}"
+	case hasBody && (body != "" || len(headers) > 0):
+		if body == "" {
+			body = "null"
+		}
+		call += ", " + body
+	}
+	if len(headers) > 0 {
+		fn.line(indent, call+", {")
+		fn.line(indent+1, "headers: {")
+		for _, h := range headers {
+			fn.line(indent+2, jsString(h.name)+": "+h.value+",")
+		}
+		fn.line(indent+1, "},")
+		call = "}"
+	}
+	fn.line(indent, call+");")
+
+	fn.translated(indent, tests)
+}
+
+// header is a header of a request, whose value is a JavaScript expression.
+type header struct {
+	name, value string
+}
+
+// requestBody returns the body of the request, either as a JavaScript
+// expression or as the fields of a form, and adds the Content-Type header
+// Postman sets for it when it's missing.
+func (fn *function) requestBody(indent int, body *Body, headers *[]header) (string, []KeyValue) {
+	if body == nil {
+		return "", nil
+	}
+	contentType := ""
+	var expr string
+	var fields []KeyValue
+	switch body.Mode {
+	case "raw":
+		if body.Raw == "" {
+			return "", nil
+		}
+		expr = fn.conv.literal(body.Raw)
+		if body.Options.Raw.Language == "json" {
+			contentType = "application/json"
+		}
+	case "urlencoded":
+		fields = enabled(body.URLEncoded)
+		contentType = "application/x-www-form-urlencoded"
+	case "formdata":
+		for _, f := range enabled(body.FormData) {
+			if f.Type == "file" {
+				fn.line(indent, "// TODO: upload the file of the form field "+jsString(f.Key)+" with http.file()")
+				continue
+			}
+			fields = append(fields, f)
+		}
+	case "graphql":
+		if body.GraphQL == nil {
+			return "", nil
+		}
+		expr = "JSON.stringify({ query: " + fn.conv.literal(body.GraphQL.Query)
+		if strings.TrimSpace(body.GraphQL.Variables) != "" {
+			expr += ", variables: JSON.parse(" + fn.conv.literal(body.GraphQL.Variables) + ")"
+		}
+		expr += " })"
+		contentType = "application/json"
+	default:
+		if body.Mode != "" {
+			fn.line(indent, "// TODO: send the "+body.Mode+" body of the request")
+		}
+		return "", nil
+	}
+	if contentType != "" && !hasHeader(*headers, "Content-Type") {
+		*headers = append(*headers, header{name: "Content-Type", value: jsString(contentType)})
+	}
+	return expr, fields
+}
+
+// authenticate returns the headers and the URL of a request with the
+// credentials of the auth helper.
+func (fn *function) authenticate(indent int, auth *Auth, headers []header, url string) ([]header, string) {
+	conv := fn.conv
+	if auth == nil {
+		return headers, url
+	}
+	switch auth.Type {
+	case "noauth", "":
+	case "bearer":
+		headers = append(headers, header{
+			name: "Authorization", value: conv.literal("Bearer " + auth.param(auth.Bearer, "token")),
+		})
+	case "basic":
+		conv.usesEncoding = true
+		credentials := conv.literal(auth.param(auth.Basic, "username") + ":" + auth.param(auth.Basic, "password"))
+		headers = append(headers, header{
+			name: "Authorization", value: "`Basic ${encoding.b64encode(" + credentials + ")}`",
+		})
+	case "apikey":
+		key, value := auth.param(auth.APIKey, "key"), auth.param(auth.APIKey, "value")
+		if auth.param(auth.APIKey, "in") == "query" {
+			separator := "?"
+			if strings.Contains(url, "?") {
+				separator = "&"
+			}
+			return headers, url + separator + key + "=" + value
+		}
+		headers = append(headers, header{name: key, value: conv.literal(value)})
+	default:
+		fn.line(indent, "// TODO: authenticate the request like the "+auth.Type+" auth of Postman")
+	}
+	return headers, url
+}
+
+// script writes the translation of the lines of a Postman script.
+func (fn *function) script(indent int, lines []string) {
+	fn.translated(indent, translate(lines, fn.conv.literal))
+}
+
+func (fn *function) translated(indent int, t translation) {
+	if t.usesVars {
+		fn.conv.usesVars = true
+	}
+	if t.usesCheck {
+		fn.conv.usesCheck = true
+	}
+	if t.usesRes {
+		fn.usesRes = true
+	}
+	if t.declares {
+		fn.line(indent, "{")
+		indent++
+	}
+	for _, l := range t.lines {
+		fn.line(indent, l)
+	}
+	if t.declares {
+		fn.line(indent-1, "}")
+	}
+}
+
+func (conv *converter) script(c Collection, opts Options, defaultFn *function, folders []*function) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Converted from the Postman collection %s by k6 convert\n", jsString(c.Info.Name))
+	if len(conv.untranslated) > 0 {
+		names := make([]string, 0, len(conv.untranslated))
+		for name := range conv.untranslated {
+			names = append(names, "{{"+name+"}}")
+		}
+		sort.Strings(names)
+		b.WriteString("// TODO: replace the Postman dynamic variables " + strings.Join(names, ", ") +
+			", which are left as they are\n")
+	}
+	b.WriteString("\n")
+
+	var k6Imports []string
+	if conv.usesCheck {
+		k6Imports = append(k6Imports, "check")
+	}
+	if conv.usesGroup {
+		k6Imports = append(k6Imports, "group")
+	}
+	if len(k6Imports) > 0 {
+		b.WriteString("import { " + strings.Join(k6Imports, ", ") + " } from 'k6';\n")
+	}
+	if conv.usesEncoding {
+		b.WriteString("import encoding from 'k6/encoding';\n")
+	}
+	if conv.usesCrypto {
+		b.WriteString("import { crypto } from 'k6/experimental/webcrypto';\n")
+	}
+	b.WriteString("import http from 'k6/http';\n")
+
+	if len(folders) > 0 {
+		b.WriteString("\nexport const options = {\n  scenarios: {\n")
+		if defaultFn != nil {
+			b.WriteString("    default: { executor: \"per-vu-iterations\", vus: 1, iterations: 1 },\n")
+		}
+		for _, fn := range folders {
+			fmt.Fprintf(&b, "    %s: { executor: \"per-vu-iterations\", exec: %s, vus: 1, iterations: 1 },\n",
+				fn.name, jsString(fn.name))
+		}
+		b.WriteString("  },\n};\n")
+	}
+
+	variables := mergeVariables(c.Variables, opts.Environment)
+	if len(variables) > 0 || conv.usesVars {
+		b.WriteString("\n// the variables of the collection, which can be set or overridden with the environment variables\n")
+		b.WriteString("const vars = {\n")
+		defined := make([]string, 0, len(variables))
+		for _, v := range variables {
+			fmt.Fprintf(&b, "  %s: __ENV[%s] || %s,\n", jsString(v.Key), jsString(v.Key), jsString(string(v.Value)))
+			defined = append(defined, v.Key)
+		}
+		for _, name := range conv.referenced {
+			if !contains(defined, name) {
+				fmt.Fprintf(&b, "  %s: __ENV[%s],\n", jsString(name), jsString(name))
+			}
+		}
+		b.WriteString("};\n")
+	}
+
+	functions := folders
+	if defaultFn != nil {
+		functions = append([]*function{defaultFn}, folders...)
+	}
+	for _, fn := range functions {
+		b.WriteString("\n")
+		if fn.folder != "" && fn.folder != fn.name {
+			b.WriteString("// " + strings.ReplaceAll(fn.folder, "\n", " ") + "\n")
+		}
+		if fn.name == "default" {
+			b.WriteString("export default function () {\n")
+		} else {
+			b.WriteString("export function " + fn.name + "() {\n")
+		}
+		if fn.usesRes {
+			b.WriteString("  let res;\n\n")
+		}
+		b.WriteString(fn.body.String())
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// mergeVariables returns the variables of the collection, overridden and
+// completed by the enabled ones of the environment.
+func mergeVariables(variables []KeyValue, env *Environment) []KeyValue {
+	result := enabled(variables)
+	if env == nil {
+		return result
+	}
+	for _, v := range env.Values {
+		if v.Enabled != nil && !*v.Enabled {
+			continue
+		}
+		found := false
+		for i := range result {
+			if result[i].Key == v.Key {
+				result[i].Value, found = v.Value, true
+			}
+		}
+		if !found {
+			result = append(result, KeyValue{Key: v.Key, Value: v.Value})
+		}
+	}
+	return result
+}
+
+// literal returns the JavaScript string of the value, which is a template
+// literal using the vars and the equivalents of the dynamic variables when it
+// has {{variables}}.
+func (conv *converter) literal(s string) string {
+	if !variableRegexp.MatchString(s) {
+		return jsString(s)
+	}
+	var b strings.Builder
+	b.WriteString("`")
+	escaper := strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${", "\r", "\\r")
+	last := 0
+	for _, m := range variableRegexp.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(escaper.Replace(s[last:m[0]]))
+		last = m[1]
+		name := strings.TrimSpace(s[m[2]:m[3]])
+		if !strings.HasPrefix(name, "$") {
+			conv.usesVars = true
+			if !contains(conv.referenced, name) {
+				conv.referenced = append(conv.referenced, name)
+			}
+			b.WriteString("${vars[" + jsString(name) + "]}")
+			continue
+		}
+		expr, ok := dynamicVariables[name]
+		if !ok {
+			conv.untranslated[name] = true
+			b.WriteString(escaper.Replace(s[m[0]:m[1]]))
+			continue
+		}
+		if strings.HasPrefix(expr, "crypto.") {
+			conv.usesCrypto = true
+		}
+		b.WriteString("${" + expr + "}")
+	}
+	b.WriteString(escaper.Replace(s[last:]))
+	b.WriteString("`")
+	return b.String()
+}
+
+func enabled(values []KeyValue) []KeyValue {
+	result := make([]KeyValue, 0, len(values))
+	for _, v := range values {
+		if !v.Disabled {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasHeader(headers []header, name string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsString returns the JavaScript string literal of s.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s) // strings can always be encoded
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,250 @@
+package postman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCollection = `{
+  "info": {
+    "name": "Shop API",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "auth": { "type": "bearer", "bearer": [{ "key": "token", "value": "{{token}}", "type": "string" }] },
+  "variable": [
+    { "key": "baseUrl", "value": "https://shop.example.com" },
+    { "key": "pageSize", "value": 20 }
+  ],
+  "item": [
+    {
+      "name": "Health",
+      "request": { "method": "GET", "url": "{{baseUrl}}/health", "auth": { "type": "noauth" } }
+    },
+    {
+      "name": "Auth",
+      "item": [
+        {
+          "name": "Login",
+          "event": [
+            {
+              "listen": "prerequest",
+              "script": { "exec": ["pm.environment.set(\"requestId\", pm.variables.replaceIn(\"{{$guid}}\"));"] }
+            },
+            {
+              "listen": "test",
+              "script": {
+                "exec": [
+                  "pm.test(\"Status code is 200\", function () {",
+                  "    pm.response.to.have.status(200);",
+                  "});",
+                  "var jsonData = pm.response.json();",
+                  "pm.environment.set(\"token\", jsonData.token);",
+                  "pm.expect(jsonData.user).to.be.an('object');"
+                ]
+              }
+            }
+          ],
+          "request": {
+            "method": "POST",
+            "header": [
+              { "key": "X-Request-Id", "value": "{{requestId}}" },
+              { "key": "X-Debug", "value": "1", "disabled": true }
+            ],
+            "body": {
+              "mode": "raw",
+              "raw": "{\"user\": \"{{user}}\", \"password\": \"{{password}}\"}",
+              "options": { "raw": { "language": "json" } }
+            },
+            "url": { "raw": "{{baseUrl}}/login", "host": ["{{baseUrl}}"], "path": ["login"] }
+          }
+        }
+      ]
+    },
+    {
+      "name": "Orders",
+      "auth": { "type": "apikey", "apikey": [
+        { "key": "key", "value": "api_key" }, { "key": "value", "value": "{{apiKey}}" }, { "key": "in", "value": "query" }
+      ] },
+      "item": [
+        {
+          "name": "List orders",
+          "request": { "method": "GET", "url": { "raw": "{{baseUrl}}/orders?limit={{pageSize}}" } }
+        },
+        {
+          "name": "Admin",
+          "item": [
+            {
+              "name": "Delete order",
+              "request": {
+                "method": "DELETE",
+                "auth": { "type": "basic", "basic": [
+                  { "key": "username", "value": "admin" }, { "key": "password", "value": "{{adminPassword}}" }
+                ] },
+                "url": { "raw": "{{baseUrl}}/orders/:id", "variable": [{ "key": "id", "value": "42" }] }
+              }
+            },
+            {
+              "name": "Import orders",
+              "request": {
+                "method": "POST",
+                "body": { "mode": "formdata", "formdata": [
+                  { "key": "source", "value": "api", "type": "text" },
+                  { "key": "file", "src": "orders.csv", "type": "file" }
+                ] },
+                "url": "{{baseUrl}}/orders/import?at={{$timestamp}}&seed={{$randomColor}}"
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	collection, err := Parse([]byte(testCollection))
+	require.NoError(t, err)
+
+	script, err := Convert(collection, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, `// Converted from the Postman collection "Shop API" by k6 convert
+// TODO: replace the Postman dynamic variables {{$randomColor}}, which are left as they are
+
+import { check, group } from 'k6';
+import encoding from 'k6/encoding';
+import { crypto } from 'k6/experimental/webcrypto';
+import http from 'k6/http';
+
+export const options = {
+  scenarios: {
+    default: { executor: "per-vu-iterations", vus: 1, iterations: 1 },
+    Auth: { executor: "per-vu-iterations", exec: "Auth", vus: 1, iterations: 1 },
+    Orders: { executor: "per-vu-iterations", exec: "Orders", vus: 1, iterations: 1 },
+  },
+};
+
+// the variables of the collection, which can be set or overridden with the environment variables
+const vars = {
+  "baseUrl": __ENV["baseUrl"] || "https://shop.example.com",
+  "pageSize": __ENV["pageSize"] || "20",
+  "requestId": __ENV["requestId"],
+  "user": __ENV["user"],
+  "password": __ENV["password"],
+  "token": __ENV["token"],
+  "apiKey": __ENV["apiKey"],
+  "adminPassword": __ENV["adminPassword"],
+};
+
+export default function () {
+  // Health
+  http.get(`+"`"+`${vars["baseUrl"]}/health`+"`"+`);
+}
+
+export function Auth() {
+  let res;
+
+  // Login
+  vars["requestId"] = `+"`"+`${crypto.randomUUID()}`+"`"+`;
+  res = http.post(`+"`"+`${vars["baseUrl"]}/login`+"`"+`, `+"`"+`{"user": "${vars["user"]}", "password": "${vars["password"]}"}`+"`"+`, {
+    headers: {
+      "X-Request-Id": `+"`"+`${vars["requestId"]}`+"`"+`,
+      "Content-Type": "application/json",
+      "Authorization": `+"`"+`Bearer ${vars["token"]}`+"`"+`,
+    },
+  });
+  {
+    check(res, { "Status code is 200": (r) => r.status === 200 });
+    const jsonData = res.json();
+    vars["token"] = jsonData.token;
+    // TODO: translate the Postman script: pm.expect(jsonData.user).to.be.an('object');
+  }
+}
+
+export function Orders() {
+  // List orders
+  http.get(`+"`"+`${vars["baseUrl"]}/orders?limit=${vars["pageSize"]}&api_key=${vars["apiKey"]}`+"`"+`);
+
+  group("Admin", function () {
+    // Delete order
+    http.del(`+"`"+`${vars["baseUrl"]}/orders/42`+"`"+`, null, {
+      headers: {
+        "Authorization": `+"`"+`Basic ${encoding.b64encode(`+"`"+`admin:${vars["adminPassword"]}`+"`"+`)}`+"`"+`,
+      },
+    });
+
+    // Import orders
+    // TODO: upload the file of the form field "file" with http.file()
+    http.post(`+"`"+`${vars["baseUrl"]}/orders/import?at=${Math.floor(Date.now() / 1000)}&seed={{$randomColor}}&api_key=${vars["apiKey"]}`+"`"+`, {
+      "source": "api",
+    });
+  });
+}
+`, script)
+}
+
+func TestConvertEnvironment(t *testing.T) {
+	t.Parallel()
+
+	collection, err := Parse([]byte(testCollection))
+	require.NoError(t, err)
+	env, err := ParseEnvironment([]byte(`{"name": "staging", "values": [
+		{"key": "baseUrl", "value": "https://staging.example.com", "enabled": true},
+		{"key": "user", "value": "tester"},
+		{"key": "password", "value": "secret", "enabled": false}
+	]}`))
+	require.NoError(t, err)
+
+	script, err := Convert(collection, Options{Environment: &env})
+	require.NoError(t, err)
+	assert.Contains(t, script, `  "baseUrl": __ENV["baseUrl"] || "https://staging.example.com",
+  "pageSize": __ENV["pageSize"] || "20",
+  "user": __ENV["user"] || "tester",
+  "requestId": __ENV["requestId"],
+  "password": __ENV["password"],
+`)
+}
+
+func TestTranslate(t *testing.T) {
+	t.Parallel()
+
+	literal := (&converter{untranslated: make(map[string]bool)}).literal
+	tr := translate([]string{
+		"// keep the session",
+		"pm.collectionVariables.set('session', pm.response.headers.get('x-session'));",
+		"pm.globals.unset(\"old\");",
+		"pm.test(\"created\", () => { pm.response.to.have.status(201); });",
+		"pm.response.to.have.status(201)",
+		"pm.environment.set(\"next\", pm.environment.get(\"page\") + 1);",
+		"pm.sendRequest(\"https://example.com\");",
+	}, literal)
+	assert.Equal(t, []string{
+		"// keep the session",
+		`vars["session"] = res.headers["X-Session"];`,
+		`delete vars["old"];`,
+		`check(res, { "created": (r) => r.status === 201 });`,
+		`check(res, { "status is 201": (r) => r.status === 201 });`,
+		`vars["next"] = vars["page"] + 1;`,
+		`// TODO: translate the Postman script: pm.sendRequest("https://example.com");`,
+	}, tr.lines)
+	assert.True(t, tr.usesRes)
+	assert.True(t, tr.usesCheck)
+	assert.False(t, tr.declares)
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte(`{"info": `))
+	assert.ErrorContains(t, err, "invalid Postman collection")
+	_, err = Parse([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"}}`))
+	assert.ErrorContains(t, err, "only the v2.1 ones are supported")
+	_, err = Parse([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"}}`))
+	assert.EqualError(t, err, "the Postman collection has no requests")
+
+	assert.True(t, IsCollection([]byte(testCollection)))
+	assert.False(t, IsCollection([]byte(`{"log": {"entries": []}}`)))
+}
@@ -0,0 +1,139 @@
+package postman
+
+import (
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// The statements of the Postman scripts which have an equivalent in k6, the
+// others are left as TODO comments.
+//
+//nolint:gochecknoglobals
+var (
+	setRegexp = regexp.MustCompile(
+		`^pm\.(?:environment|variables|collectionVariables|globals)\.set\(\s*(["'])([^"']+)["']\s*,\s*(.+?)\s*\);?$`)
+	unsetRegexp = regexp.MustCompile(
+		`^pm\.(?:environment|variables|collectionVariables|globals)\.unset\(\s*["']([^"']+)["']\s*\);?$`)
+	getRegexp = regexp.MustCompile(
+		`pm\.(?:environment|variables|collectionVariables|globals)\.get\(\s*["']([^"']+)["']\s*\)`)
+	declarationRegexp    = regexp.MustCompile(`^(?:const|let|var)\s+([a-zA-Z_$][a-zA-Z0-9_$]*)\s*=\s*(.+?);?$`)
+	statusRegexp         = regexp.MustCompile(`^pm\.response\.to\.have\.status\((\d+)\);?$`)
+	replaceInRegexp      = regexp.MustCompile(`pm\.variables\.replaceIn\(\s*(["'])([^"']*)["']\s*\)`)
+	responseHeaderRegexp = regexp.MustCompile(`pm\.response\.headers\.get\(\s*["']([^"']+)["']\s*\)`)
+	statusTestRegexp     = regexp.MustCompile(`(?s)pm\.test\(\s*(["'])([^"'\n]+)["']\s*,\s*` +
+		`(?:function\s*\(\s*\)|\(\s*\)\s*=>)\s*\{\s*pm\.response\.to\.have\.status\((\d+)\);?\s*\}\s*\);?`)
+)
+
+// expressionReplacer translates the expressions of the Postman scripts on the
+// response of the request.
+var expressionReplacer = strings.NewReplacer( //nolint:gochecknoglobals
+	"pm.response.json()", "res.json()",
+	"JSON.parse(responseBody)", "res.json()",
+	"pm.response.text()", "res.body",
+	"responseBody", "res.body",
+	"pm.response.code", "res.status",
+	"pm.response.responseTime", "res.timings.duration",
+)
+
+// translation is the translation of a Postman script.
+type translation struct {
+	// literal returns the JavaScript string of a value with {{variables}}.
+	literal   func(string) string
+	lines     []string
+	usesRes   bool
+	usesVars  bool
+	usesCheck bool
+	// declares is whether the script declares variables, which are scoped
+	// in a block, since the script of each request can declare the same ones.
+	declares bool
+}
+
+// translate translates the statements of a Postman script which have an
+// equivalent in k6, like the ones setting variables and the status tests. The
+// others are left as TODO comments.
+func translate(lines []string, literal func(string) string) translation {
+	t := translation{literal: literal}
+	source := strings.Join(lines, "\n")
+	last := 0
+	for _, m := range statusTestRegexp.FindAllStringSubmatchIndex(source, -1) {
+		t.statements(source[last:m[0]])
+		t.check(source[m[4]:m[5]], source[m[6]:m[7]])
+		last = m[1]
+	}
+	t.statements(source[last:])
+	return t
+}
+
+func (t *translation) statements(source string) {
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line == ";":
+		case strings.HasPrefix(line, "//"):
+			t.lines = append(t.lines, line)
+		case statusRegexp.MatchString(line):
+			status := statusRegexp.FindStringSubmatch(line)[1]
+			t.check("status is "+status, status)
+		default:
+			if translated, ok := t.statement(line); ok {
+				t.lines = append(t.lines, translated)
+			} else {
+				t.lines = append(t.lines, "// TODO: translate the Postman script: "+line)
+			}
+		}
+	}
+}
+
+// statement returns the translation of a statement, if it has one.
+func (t *translation) statement(line string) (string, bool) {
+	if m := setRegexp.FindStringSubmatch(line); m != nil {
+		expr, ok := t.expression(m[3])
+		if !ok {
+			return "", false
+		}
+		t.usesVars = true
+		return "vars[" + jsString(m[2]) + "] = " + expr + ";", true
+	}
+	if m := unsetRegexp.FindStringSubmatch(line); m != nil {
+		t.usesVars = true
+		return "delete vars[" + jsString(m[1]) + "];", true
+	}
+	if m := declarationRegexp.FindStringSubmatch(line); m != nil {
+		expr, ok := t.expression(m[2])
+		if !ok {
+			return "", false
+		}
+		t.declares = true
+		return "const " + m[1] + " = " + expr + ";", true
+	}
+	return "", false
+}
+
+// expression returns the translation of an expression, if it has one.
+func (t *translation) expression(expr string) (string, bool) {
+	expr = replaceInRegexp.ReplaceAllStringFunc(expr, func(replaceIn string) string {
+		return t.literal(replaceInRegexp.FindStringSubmatch(replaceIn)[2])
+	})
+	expr = getRegexp.ReplaceAllStringFunc(expr, func(get string) string {
+		t.usesVars = true
+		return "vars[" + jsString(getRegexp.FindStringSubmatch(get)[1]) + "]"
+	})
+	expr = responseHeaderRegexp.ReplaceAllStringFunc(expr, func(get string) string {
+		name := textproto.CanonicalMIMEHeaderKey(responseHeaderRegexp.FindStringSubmatch(get)[1])
+		return "res.headers[" + jsString(name) + "]"
+	})
+	expr = expressionReplacer.Replace(expr)
+	if strings.Contains(expr, "pm.") || strings.Contains(expr, "postman.") {
+		return "", false
+	}
+	if strings.Contains(expr, "res.") {
+		t.usesRes = true
+	}
+	return expr, true
+}
+
+func (t *translation) check(name, status string) {
+	t.usesRes, t.usesCheck = true, true
+	t.lines = append(t.lines, "check(res, { "+jsString(name)+": (r) => r.status === "+status+" });")
+}
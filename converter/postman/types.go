@@ -0,0 +1,215 @@
+// Package postman converts the Postman collections to k6 scripts.
+package postman
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Collection is the subset of the Postman collection v2.1 format that is used
+// by the converter, see https://schema.postman.com/collection/json/v2.1.0/draft-07/docs/index.html
+type Collection struct {
+	Info      Info       `json:"info"`
+	Items     []Item     `json:"item"`
+	Variables []KeyValue `json:"variable"`
+	Auth      *Auth      `json:"auth"`
+	Events    []Event    `json:"event"`
+}
+
+// Info describes the collection.
+type Info struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// Item is either a folder, with its own items, or a request.
+type Item struct {
+	Name    string   `json:"name"`
+	Items   []Item   `json:"item"`
+	Request *Request `json:"request"`
+	Auth    *Auth    `json:"auth"`
+	Events  []Event  `json:"event"`
+}
+
+// IsFolder returns whether the item is a folder.
+func (i Item) IsFolder() bool {
+	return i.Request == nil
+}
+
+// Request is the request of an item.
+type Request struct {
+	Method  string     `json:"method"`
+	URL     URL        `json:"url"`
+	Headers []KeyValue `json:"header"`
+	Body    *Body      `json:"body"`
+	Auth    *Auth      `json:"auth"`
+}
+
+// URL is the URL of a request, which is either a string or an object in the
+// collections.
+type URL struct {
+	Raw string `json:"raw"`
+	// Variables are the values of the path variables, like :id.
+	Variables []KeyValue `json:"variable"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *URL) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*u = URL{}
+		return json.Unmarshal(data, &u.Raw)
+	}
+	type plain URL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+// Body is the body of a request, whose mode tells which of its fields is set.
+type Body struct {
+	Mode       string      `json:"mode"`
+	Raw        string      `json:"raw"`
+	URLEncoded []KeyValue  `json:"urlencoded"`
+	FormData   []KeyValue  `json:"formdata"`
+	GraphQL    *GraphQL    `json:"graphql"`
+	Options    BodyOptions `json:"options"`
+}
+
+// BodyOptions are the options of a body, like the language of the raw ones.
+type BodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+// GraphQL is the body of a GraphQL request, its variables are JSON.
+type GraphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables"`
+}
+
+// Auth is the authentication helper of a collection, a folder or a request,
+// whose type tells which of its fields is set.
+type Auth struct {
+	Type   string     `json:"type"`
+	Bearer []KeyValue `json:"bearer"`
+	Basic  []KeyValue `json:"basic"`
+	APIKey []KeyValue `json:"apikey"`
+}
+
+// param returns the value of the parameter of the helper.
+func (a *Auth) param(params []KeyValue, key string) string {
+	for _, p := range params {
+		if p.Key == key {
+			return string(p.Value)
+		}
+	}
+	return ""
+}
+
+// KeyValue is a header, a variable, a field of a form or a parameter.
+type KeyValue struct {
+	Key      string `json:"key"`
+	Value    Value  `json:"value"`
+	Disabled bool   `json:"disabled"`
+	// Type is "file" for the file fields of the forms.
+	Type string `json:"type"`
+}
+
+// Value is a value of a KeyValue, the non-string ones, like the numbers of
+// the variables, are kept as they are in the JSON.
+type Value string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = Value(s)
+		return nil
+	}
+	if string(data) == "null" {
+		*v = ""
+		return nil
+	}
+	*v = Value(data)
+	return nil
+}
+
+// Event is a script run before (prerequest) or after (test) the requests.
+type Event struct {
+	Listen string `json:"listen"`
+	Script Script `json:"script"`
+}
+
+// Script is the source of an event.
+type Script struct {
+	Exec Lines `json:"exec"`
+}
+
+// Lines are the lines of a script, which is either a string or an array of
+// lines in the collections.
+type Lines []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *Lines) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = strings.Split(s, "\n")
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// Environment is an exported Postman environment.
+type Environment struct {
+	Name   string             `json:"name"`
+	Values []EnvironmentValue `json:"values"`
+}
+
+// EnvironmentValue is a variable of an environment, it's enabled when Enabled
+// isn't set.
+type EnvironmentValue struct {
+	Key     string `json:"key"`
+	Value   Value  `json:"value"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// IsCollection returns whether the JSON is a Postman collection, rather than
+// another format like a HAR recording.
+func IsCollection(data []byte) bool {
+	var c struct {
+		Info struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return false
+	}
+	return strings.Contains(c.Info.Schema, "postman.com")
+}
+
+// Parse parses and validates a Postman collection.
+func Parse(data []byte) (Collection, error) {
+	var c Collection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid Postman collection: %w", err)
+	}
+	if !strings.Contains(c.Info.Schema, "/collection/v2.1") {
+		return c, fmt.Errorf("unsupported schema %q of the Postman collection, only the v2.1 ones are supported, "+
+			"they can be exported from Postman", c.Info.Schema)
+	}
+	if len(c.Items) == 0 {
+		return c, errors.New("the Postman collection has no requests")
+	}
+	return c, nil
+}
+
+// ParseEnvironment parses an exported Postman environment.
+func ParseEnvironment(data []byte) (Environment, error) {
+	var env Environment
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, fmt.Errorf("invalid Postman environment: %w", err)
+	}
+	return env, nil
+}
@@ -15,6 +15,8 @@ const (
 	AbortedByScriptAbort
 	AbortedByTimeout
 	AbortedByOutput
+	AbortedByErrorRate
+	AbortedByMemoryLimit
 )
 
 // HasAbortReason is a wrapper around an error with an attached abort reason.
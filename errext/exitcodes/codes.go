@@ -53,4 +53,24 @@ const (
 
 	// GoPanic indicates the script was aborted by a panic in the Go runtime.
 	GoPanic ExitCode = 109
+
+	// BaselineRegressed indicates that one or more metrics have regressed
+	// compared to the baseline beyond their tolerance.
+	BaselineRegressed ExitCode = 110
+
+	// ErrorRateExceeded indicates that the test was aborted because the rate
+	// of the failed requests was higher than the abortOnErrorRate limit.
+	ErrorRateExceeded ExitCode = 111
+
+	// LintFailed indicates that `k6 lint` found errors in the scripts, or
+	// warnings too with --strict.
+	LintFailed ExitCode = 112
+
+	// LifecycleHookFailed indicates that the test run was prevented from
+	// starting by a lifecycle hook which failed to handle its start.
+	LifecycleHookFailed ExitCode = 113
+
+	// MemoryLimitExceeded indicates that the test was stopped because k6
+	// used more memory than the maxMemory limit.
+	MemoryLimitExceeded ExitCode = 114
 )
@@ -9,6 +9,10 @@ import (
 // InterruptError is an error that halts engine execution
 type InterruptError struct {
 	Reason string
+	// Code is the exit code requested by the script, ScriptAborted if empty.
+	Code exitcodes.ExitCode
+	// Graceful is true if the running iterations were allowed to finish.
+	Graceful bool
 }
 
 var _ interface {
@@ -23,6 +27,9 @@ func (i *InterruptError) Error() string {
 
 // ExitCode returns the status code used when the k6 process exits.
 func (i *InterruptError) ExitCode() exitcodes.ExitCode {
+	if i.Code != 0 {
+		return i.Code
+	}
 	return exitcodes.ScriptAborted
 }
 
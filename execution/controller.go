@@ -1,5 +1,7 @@
 package execution
 
+import "go.k6.io/k6/lib"
+
 // Controller implementations are used to control the k6 execution of a test or
 // test suite, either locally or in a distributed environment.
 type Controller interface {
@@ -25,6 +27,14 @@ type Controller interface {
 	Subscribe(eventID string) (wait func() error)
 }
 
+// SharedStoreController can be implemented by controllers that provide a
+// key-value store that is shared between all instances of the test. If it
+// isn't implemented, every instance has its own in-memory store.
+type SharedStoreController interface {
+	Controller
+	SharedStore() lib.SharedStore
+}
+
 // SignalAndWait implements a rendezvous point / barrier, a way for all
 // instances to reach the same execution point and wait for each other, before
 // they all ~simultaneously continue with the execution.
@@ -0,0 +1,293 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/output"
+)
+
+// metricsPushInterval is how often the agents send their metric samples to
+// the coordinator.
+const metricsPushInterval = time.Second
+
+type client struct {
+	ctx        context.Context //nolint:containedctx
+	httpClient *http.Client
+	address    string
+	token      string
+}
+
+// call sends the request to the given coordinator endpoint and decodes its
+// response in resp.
+func (c *client) call(path string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(c.ctx, http.MethodPost, c.address+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(httpResp.Body)
+		return fmt.Errorf("coordinator error (%d): %s", httpResp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
+
+// AgentController implements the execution.Controller and the
+// execution.SharedStoreController interfaces for an agent instance, by
+// forwarding everything to the coordinator.
+type AgentController struct {
+	client     *client
+	instanceID int
+	logger     logrus.FieldLogger
+	store      *remoteSharedStore
+}
+
+// Register registers a new agent instance with the coordinator at the given
+// address and returns its controller, as well as the test that it has to run.
+// The context is used for all of the future requests to the coordinator, and
+// the token, if set, is sent with them as a bearer token.
+func Register(
+	ctx context.Context, httpClient *http.Client, address, token string, logger logrus.FieldLogger,
+) (*AgentController, *RegisterResponse, error) {
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		address = "http://" + address
+	}
+	c := &client{ctx: ctx, httpClient: httpClient, address: strings.TrimSuffix(address, "/"), token: token}
+
+	resp := &RegisterResponse{}
+	if err := c.call(registerPath, struct{}{}, resp); err != nil {
+		return nil, nil, fmt.Errorf("couldn't register with the coordinator: %w", err)
+	}
+
+	ac := &AgentController{
+		client:     c,
+		instanceID: resp.InstanceID,
+		logger:     logger.WithField("component", "agent"),
+		store:      &remoteSharedStore{client: c, instanceID: resp.InstanceID},
+	}
+	if resp.AgentTimeout > 0 {
+		go ac.notifyAlive(resp.AgentTimeout / 3)
+	}
+	return ac, resp, nil
+}
+
+// notifyAlive periodically lets the coordinator know that this instance is
+// still alive, even if it isn't sending any other requests, until the
+// context of the client is done.
+func (ac *AgentController) notifyAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			req := instanceRequest{InstanceID: ac.instanceID}
+			if err := ac.client.call(alivePath, req, &errorResponse{}); err != nil {
+				ac.logger.WithError(err).Debug("Couldn't notify the coordinator that the instance is alive")
+			}
+		case <-ac.client.ctx.Done():
+			return
+		}
+	}
+}
+
+// GetOrCreateData requests the data with the given ID from the coordinator.
+// If no other instance has created it yet, the callback is called and its
+// results are sent to the coordinator, so they can be shared with the others.
+func (ac *AgentController) GetOrCreateData(id string, callback func() ([]byte, error)) ([]byte, error) {
+	var resp dataResponse
+	if err := ac.client.call(dataGetPath, dataRequest{InstanceID: ac.instanceID, ID: id}, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Create {
+		return resp.Data, stringToError(resp.Error)
+	}
+
+	ac.logger.Debugf("Creating the data for '%s'", id)
+	data, callbackErr := callback()
+	req := dataRequest{InstanceID: ac.instanceID, ID: id, Data: data, Error: errorToString(callbackErr)}
+	if err := ac.client.call(dataSetPath, req, &errorResponse{}); err != nil {
+		return nil, err
+	}
+	return data, callbackErr
+}
+
+// Signal notifies the coordinator that this instance has reached the given
+// event, or that it has had an error.
+func (ac *AgentController) Signal(eventID string, err error) error {
+	req := signalRequest{InstanceID: ac.instanceID, Event: eventID, Error: errorToString(err)}
+	var resp errorResponse
+	if callErr := ac.client.call(signalPath, req, &resp); callErr != nil {
+		return callErr
+	}
+	return stringToError(resp.Error)
+}
+
+// Subscribe returns a function that waits until all instances have reached
+// the given event, or until one of them has had an error.
+func (ac *AgentController) Subscribe(eventID string) func() error {
+	return func() error {
+		var resp errorResponse
+		req := waitRequest{InstanceID: ac.instanceID, Event: eventID}
+		if err := ac.client.call(waitPath, req, &resp); err != nil {
+			return err
+		}
+		return stringToError(resp.Error)
+	}
+}
+
+// SharedStore returns the key-value store of the coordinator, which is shared
+// by all instances.
+func (ac *AgentController) SharedStore() lib.SharedStore {
+	return ac.store
+}
+
+// NewMetricsOutput returns an output that sends the metric samples of this
+// instance to the coordinator.
+func (ac *AgentController) NewMetricsOutput() *MetricsOutput {
+	return &MetricsOutput{
+		client:     ac.client,
+		instanceID: ac.instanceID,
+		logger:     ac.logger,
+	}
+}
+
+type remoteSharedStore struct {
+	client     *client
+	instanceID int
+}
+
+var _ lib.SharedStore = &remoteSharedStore{}
+
+func (s *remoteSharedStore) call(req storeRequest) (storeResponse, error) {
+	var resp storeResponse
+	req.InstanceID = s.instanceID
+	if err := s.client.call(storePath, req, &resp); err != nil {
+		return resp, err
+	}
+	return resp, stringToError(resp.Error)
+}
+
+func (s *remoteSharedStore) Get(key string) ([]byte, bool, error) {
+	resp, err := s.call(storeRequest{Op: "get", Key: key})
+	return resp.Value, resp.OK, err
+}
+
+func (s *remoteSharedStore) Set(key string, value []byte) error {
+	_, err := s.call(storeRequest{Op: "set", Key: key, Value: value})
+	return err
+}
+
+func (s *remoteSharedStore) Delete(key string) error {
+	_, err := s.call(storeRequest{Op: "delete", Key: key})
+	return err
+}
+
+func (s *remoteSharedStore) Incr(key string, delta int64) (int64, error) {
+	resp, err := s.call(storeRequest{Op: "incr", Key: key, Delta: delta})
+	return resp.Result, err
+}
+
+func (s *remoteSharedStore) CompareAndSwap(key string, oldValue, newValue []byte) (bool, error) {
+	resp, err := s.call(storeRequest{Op: "compareAndSwap", Key: key, OldValue: oldValue, Value: newValue})
+	return resp.OK, err
+}
+
+// MetricsOutput is an output that periodically sends the metric samples of
+// an agent to the coordinator. If the coordinator has aborted the test, e.g.
+// because of a failed threshold, it stops the test run of the agent.
+type MetricsOutput struct {
+	output.SampleBuffer
+
+	client          *client
+	instanceID      int
+	logger          logrus.FieldLogger
+	periodicFlusher *output.PeriodicFlusher
+	testRunStopCb   func(error)
+	abortOnce       sync.Once
+}
+
+var _ interface {
+	output.Output
+	output.WithTestRunStop
+} = &MetricsOutput{}
+
+// Description returns a human-readable description of the output.
+func (o *MetricsOutput) Description() string {
+	return fmt.Sprintf("coordinator (%s)", o.client.address)
+}
+
+// SetTestRunStopCallback receives the function that stops the test run.
+func (o *MetricsOutput) SetTestRunStopCallback(cb func(error)) {
+	o.testRunStopCb = cb
+}
+
+// Start starts the periodic sending of the metric samples.
+func (o *MetricsOutput) Start() error {
+	pf, err := output.NewPeriodicFlusher(metricsPushInterval, func() { _ = o.push(false) })
+	if err != nil {
+		return err
+	}
+	o.periodicFlusher = pf
+	return nil
+}
+
+// Stop sends the remaining metric samples and notifies the coordinator that
+// this instance has finished.
+func (o *MetricsOutput) Stop() error {
+	o.periodicFlusher.Stop()
+	return o.push(true)
+}
+
+func (o *MetricsOutput) push(final bool) error {
+	req := metricsRequest{InstanceID: o.instanceID, Final: final}
+	for _, sc := range o.GetBufferedSamples() {
+		for _, s := range sc.GetSamples() {
+			req.Samples = append(req.Samples, newSample(s))
+		}
+	}
+
+	// The request is sent even when there are no new samples, since its
+	// response is how the agent finds out whether the test was aborted.
+	var resp metricsResponse
+	if err := o.client.call(metricsPath, req, &resp); err != nil {
+		o.logger.WithError(err).Error("Couldn't send the metrics to the coordinator")
+		return err
+	}
+	if resp.Abort != "" && o.testRunStopCb != nil {
+		o.abortOnce.Do(func() {
+			err := stringToError(resp.Abort)
+			if resp.AbortExitCode != 0 {
+				err = errext.WithExitCodeIfNone(err, exitcodes.ExitCode(resp.AbortExitCode))
+			}
+			o.testRunStopCb(err)
+		})
+	}
+	return nil
+}
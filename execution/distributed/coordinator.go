@@ -0,0 +1,546 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// The events of the execution.Scheduler that mark the start and the end of
+// the test run, which are used for measuring its duration.
+const (
+	runStartEvent = "scheduler-run-start"
+	runEndEvent   = "execution-done"
+)
+
+// DefaultAgentTimeout is how long the coordinator waits by default for an
+// agent that doesn't send any requests before it considers it dead.
+const DefaultAgentTimeout = 30 * time.Second
+
+// Ingester receives the metric samples of all agents, e.g. the output
+// ingester of a metrics engine, which evaluates the thresholds.
+type Ingester interface {
+	AddMetricSamples(samples []metrics.SampleContainer)
+}
+
+// CoordinatorConfig contains everything the coordinator needs to distribute
+// the test between the agents and to aggregate their metrics.
+type CoordinatorConfig struct {
+	// InstanceCount is the number of agents that have to register before
+	// the test can start.
+	InstanceCount int
+
+	// Archive is the test archive that is sent to the agents.
+	Archive []byte
+
+	// Registry is used for the metrics of the samples that are sent by the
+	// agents, before they are passed to the Ingester.
+	Registry *metrics.Registry
+	Ingester Ingester
+
+	// RootGroup, if set, gets the results of the checks of all agents, so
+	// they can be shown in the end-of-test summary.
+	RootGroup *lib.Group
+
+	// AgentTimeout is how long an agent can go without sending any requests
+	// before the test fails, since it has probably died. DefaultAgentTimeout
+	// is used if it isn't set.
+	AgentTimeout time.Duration
+}
+
+type eventState struct {
+	signaled map[int]bool
+	err      error
+	done     chan struct{}
+}
+
+type dataState struct {
+	done chan struct{}
+	data []byte
+	err  string
+}
+
+// Coordinator distributes the test between the agents that register with it,
+// synchronizes them and collects their metrics. It implements the server side
+// of the execution.Controller that the agents use.
+type Coordinator struct {
+	conf     CoordinatorConfig
+	sequence lib.ExecutionSegmentSequence
+	logger   logrus.FieldLogger
+	store    *lib.MemorySharedStore
+
+	mx          sync.Mutex
+	registered  int
+	lastSeen    map[int]time.Time
+	finished    map[int]bool
+	allFinished chan struct{}
+	events      map[string]*eventState
+	data        map[string]*dataState
+	instanceErr error
+	abortErr    error
+	runStart    time.Time
+	runEnd      time.Time
+}
+
+// NewCoordinator returns a new Coordinator, that splits the test into equal
+// execution segments for the agents.
+func NewCoordinator(conf CoordinatorConfig, logger logrus.FieldLogger) (*Coordinator, error) {
+	if conf.InstanceCount < 1 {
+		return nil, fmt.Errorf("the instance count must be at least 1, but it's %d", conf.InstanceCount)
+	}
+	if conf.AgentTimeout == 0 {
+		conf.AgentTimeout = DefaultAgentTimeout
+	}
+	segments := make([]*lib.ExecutionSegment, conf.InstanceCount)
+	for i := range segments {
+		segment, err := lib.NewExecutionSegment(
+			big.NewRat(int64(i), int64(conf.InstanceCount)),
+			big.NewRat(int64(i+1), int64(conf.InstanceCount)),
+		)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = segment
+	}
+	sequence, err := lib.NewExecutionSegmentSequence(segments...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Coordinator{
+		conf:        conf,
+		sequence:    sequence,
+		logger:      logger.WithField("component", "coordinator"),
+		store:       lib.NewMemorySharedStore(),
+		lastSeen:    make(map[int]time.Time),
+		finished:    make(map[int]bool),
+		allFinished: make(chan struct{}),
+		events:      make(map[string]*eventState),
+		data:        make(map[string]*dataState),
+	}, nil
+}
+
+// Handler returns the HTTP handler for the requests of the agents.
+func (c *Coordinator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	handle := func(path string, fn func(ctx context.Context, body []byte) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				rw.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, fnErr := fn(r.Context(), body)
+			if fnErr != nil {
+				http.Error(rw, fnErr.Error(), http.StatusBadRequest)
+				return
+			}
+			rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_ = json.NewEncoder(rw).Encode(resp)
+		})
+	}
+	handle(registerPath, c.handleRegister)
+	handle(dataGetPath, c.handleDataGet)
+	handle(dataSetPath, c.handleDataSet)
+	handle(signalPath, c.handleSignal)
+	handle(waitPath, c.handleWait)
+	handle(storePath, c.handleStore)
+	handle(metricsPath, c.handleMetrics)
+	handle(alivePath, c.handleAlive)
+	return mux
+}
+
+func (c *Coordinator) handleRegister(_ context.Context, _ []byte) (interface{}, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.registered >= c.conf.InstanceCount {
+		return nil, fmt.Errorf("all %d instances have already registered", c.conf.InstanceCount)
+	}
+	id := c.registered
+	c.registered++
+	c.lastSeen[id] = time.Now()
+	c.logger.Infof("Instance %d of %d registered", id+1, c.conf.InstanceCount)
+
+	return RegisterResponse{
+		InstanceID:               id,
+		Archive:                  c.conf.Archive,
+		ExecutionSegment:         c.sequence[id].String(),
+		ExecutionSegmentSequence: c.sequence.String(),
+		AgentTimeout:             c.conf.AgentTimeout,
+	}, nil
+}
+
+// checkInstance returns an error if no instance has registered with the ID,
+// otherwise it records that the instance is still alive.
+func (c *Coordinator) checkInstance(id int) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.checkInstanceLocked(id)
+}
+
+// checkInstanceLocked is checkInstance for callers that hold the lock.
+func (c *Coordinator) checkInstanceLocked(id int) error {
+	if id < 0 || id >= c.registered {
+		return fmt.Errorf("instance %d hasn't registered", id)
+	}
+	c.lastSeen[id] = time.Now()
+	return nil
+}
+
+// checkAlive returns an error if one of the instances that haven't finished
+// hasn't sent any requests for longer than the AgentTimeout. The error is
+// also recorded as the error of the test.
+func (c *Coordinator) checkAlive() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	for id := 0; id < c.registered; id++ {
+		if c.finished[id] || time.Since(c.lastSeen[id]) <= c.conf.AgentTimeout {
+			continue
+		}
+		err := fmt.Errorf("instance %d hasn't sent any requests for more than %s", id, c.conf.AgentTimeout)
+		if c.instanceErr == nil {
+			c.instanceErr = err
+			c.logger.WithError(err).Error("An instance has probably died")
+		}
+		return err
+	}
+	return nil
+}
+
+// waitFor waits until done is closed, returning an error if the context is
+// done first or if one of the instances dies in the meantime.
+func (c *Coordinator) waitFor(ctx context.Context, done <-chan struct{}) error {
+	ticker := time.NewTicker(c.conf.AgentTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := c.checkAlive(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *Coordinator) handleAlive(_ context.Context, body []byte) (interface{}, error) {
+	var req instanceRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if err := c.checkInstance(req.InstanceID); err != nil {
+		return nil, err
+	}
+	return errorResponse{}, nil
+}
+
+func (c *Coordinator) handleDataGet(ctx context.Context, body []byte) (interface{}, error) {
+	var req dataRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+
+	c.mx.Lock()
+	if err := c.checkInstanceLocked(req.InstanceID); err != nil {
+		c.mx.Unlock()
+		return nil, err
+	}
+	ds, ok := c.data[req.ID]
+	if !ok {
+		c.data[req.ID] = &dataState{done: make(chan struct{})}
+		c.mx.Unlock()
+		return dataResponse{Create: true}, nil
+	}
+	c.mx.Unlock()
+
+	if err := c.waitFor(ctx, ds.done); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return dataResponse{Error: err.Error()}, nil
+	}
+	return dataResponse{Data: ds.data, Error: ds.err}, nil
+}
+
+func (c *Coordinator) handleDataSet(_ context.Context, body []byte) (interface{}, error) {
+	var req dataRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if err := c.checkInstanceLocked(req.InstanceID); err != nil {
+		return nil, err
+	}
+	ds, ok := c.data[req.ID]
+	if !ok {
+		return nil, fmt.Errorf("the data with ID '%s' wasn't requested", req.ID)
+	}
+	select {
+	case <-ds.done:
+		return nil, fmt.Errorf("the data with ID '%s' was already set", req.ID)
+	default:
+	}
+	ds.data, ds.err = req.Data, req.Error
+	close(ds.done)
+	return errorResponse{}, nil
+}
+
+// getEvent returns the state of the event with the given ID, creating it if
+// necessary. It has to be called with the lock held.
+func (c *Coordinator) getEvent(id string) *eventState {
+	es, ok := c.events[id]
+	if !ok {
+		es = &eventState{signaled: make(map[int]bool), done: make(chan struct{})}
+		c.events[id] = es
+	}
+	return es
+}
+
+func (c *Coordinator) handleSignal(_ context.Context, body []byte) (interface{}, error) {
+	var req signalRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if err := c.checkInstanceLocked(req.InstanceID); err != nil {
+		return nil, err
+	}
+	es := c.getEvent(req.Event)
+	select {
+	case <-es.done:
+		return errorResponse{}, nil // the event already had an error
+	default:
+	}
+
+	if req.Error != "" {
+		es.err = fmt.Errorf("instance %d: %s", req.InstanceID, req.Error)
+		if c.instanceErr == nil {
+			c.instanceErr = es.err
+		}
+		c.logger.WithError(es.err).Errorf("Instance %d had an error at '%s'", req.InstanceID, req.Event)
+		close(es.done)
+		return errorResponse{}, nil
+	}
+
+	es.signaled[req.InstanceID] = true
+	if len(es.signaled) < c.conf.InstanceCount {
+		return errorResponse{}, nil
+	}
+	switch req.Event {
+	case runStartEvent:
+		c.runStart = time.Now()
+	case runEndEvent:
+		c.runEnd = time.Now()
+	}
+	c.logger.Debugf("All instances reached '%s'", req.Event)
+	close(es.done)
+	return errorResponse{}, nil
+}
+
+func (c *Coordinator) handleWait(ctx context.Context, body []byte) (interface{}, error) {
+	var req waitRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+
+	c.mx.Lock()
+	if err := c.checkInstanceLocked(req.InstanceID); err != nil {
+		c.mx.Unlock()
+		return nil, err
+	}
+	es := c.getEvent(req.Event)
+	c.mx.Unlock()
+
+	if err := c.waitFor(ctx, es.done); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return errorResponse{Error: err.Error()}, nil
+	}
+	return errorResponse{Error: errorToString(es.err)}, nil
+}
+
+func (c *Coordinator) handleStore(_ context.Context, body []byte) (interface{}, error) {
+	var req storeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if err := c.checkInstance(req.InstanceID); err != nil {
+		return nil, err
+	}
+
+	var (
+		resp storeResponse
+		err  error
+	)
+	switch req.Op {
+	case "get":
+		resp.Value, resp.OK, err = c.store.Get(req.Key)
+	case "set":
+		err = c.store.Set(req.Key, req.Value)
+	case "delete":
+		err = c.store.Delete(req.Key)
+	case "incr":
+		resp.Result, err = c.store.Incr(req.Key, req.Delta)
+	case "compareAndSwap":
+		resp.OK, err = c.store.CompareAndSwap(req.Key, req.OldValue, req.Value)
+	default:
+		return nil, fmt.Errorf("unknown store operation '%s'", req.Op)
+	}
+	resp.Error = errorToString(err)
+	return resp, nil
+}
+
+func (c *Coordinator) handleMetrics(_ context.Context, body []byte) (interface{}, error) {
+	var req metricsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if err := c.checkInstance(req.InstanceID); err != nil {
+		return nil, err
+	}
+
+	if len(req.Samples) > 0 {
+		samples := make(metrics.Samples, 0, len(req.Samples))
+		for _, s := range req.Samples {
+			ms, err := s.toSample(c.conf.Registry)
+			if err != nil {
+				c.logger.WithError(err).Warnf("Invalid metric sample from instance %d", req.InstanceID)
+				continue
+			}
+			samples = append(samples, ms)
+		}
+		c.recordChecks(samples)
+		c.conf.Ingester.AddMetricSamples([]metrics.SampleContainer{samples})
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if req.Final && !c.finished[req.InstanceID] {
+		c.finished[req.InstanceID] = true
+		c.logger.Debugf("Instance %d finished", req.InstanceID)
+		if len(c.finished) == c.conf.InstanceCount {
+			close(c.allFinished)
+		}
+	}
+
+	var resp metricsResponse
+	if c.abortErr != nil {
+		resp.Abort = c.abortErr.Error()
+		var ecErr errext.HasExitCode
+		if errors.As(c.abortErr, &ecErr) {
+			resp.AbortExitCode = int(ecErr.ExitCode())
+		}
+	}
+	return resp, nil
+}
+
+// recordChecks adds the results of the checks in the samples to the groups
+// of the RootGroup, since they aren't built by the coordinator's own VUs.
+func (c *Coordinator) recordChecks(samples metrics.Samples) {
+	if c.conf.RootGroup == nil {
+		return
+	}
+	for _, s := range samples {
+		if s.Metric.Name != metrics.ChecksName {
+			continue
+		}
+		checkName, ok := s.Tags.Get("check")
+		if !ok {
+			continue
+		}
+		groupPath, _ := s.Tags.Get("group")
+		check, err := c.getCheck(groupPath, checkName)
+		if err != nil {
+			c.logger.WithError(err).Debug("Couldn't record a check result")
+			continue
+		}
+		if s.Value != 0 {
+			atomic.AddInt64(&check.Passes, 1)
+		} else {
+			atomic.AddInt64(&check.Fails, 1)
+		}
+	}
+}
+
+func (c *Coordinator) getCheck(groupPath, name string) (*lib.Check, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	group := c.conf.RootGroup
+	if groupPath != "" {
+		for _, groupName := range strings.Split(strings.TrimPrefix(groupPath, lib.GroupSeparator), lib.GroupSeparator) {
+			var err error
+			if group, err = group.Group(groupName); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return group.Check(name)
+}
+
+// Abort aborts the test on all agents with the given error, which they
+// receive with the response to their next metrics request.
+func (c *Coordinator) Abort(err error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.abortErr == nil {
+		c.logger.WithError(err).Debug("Aborting the test on all instances")
+		c.abortErr = err
+	}
+}
+
+// GetCurrentTestRunDuration returns how long the test has been running on
+// the agents, or how long it ran if it has finished.
+func (c *Coordinator) GetCurrentTestRunDuration() time.Duration {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	switch {
+	case c.runStart.IsZero():
+		return 0
+	case c.runEnd.IsZero():
+		return time.Since(c.runStart)
+	default:
+		return c.runEnd.Sub(c.runStart)
+	}
+}
+
+// Wait blocks until all agents have finished and sent their last metrics,
+// or until the context is done. It returns the error with which the test was
+// aborted or the first error that an agent has had, if there was one.
+func (c *Coordinator) Wait(ctx context.Context) error {
+	if err := c.waitFor(ctx, c.allFinished); err != nil {
+		return err
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.abortErr != nil {
+		return c.abortErr
+	}
+	return c.instanceErr
+}
@@ -0,0 +1,169 @@
+// Package distributed implements the execution.Controller interface for
+// distributed tests, where a coordinator partitions the test between several
+// agent instances with execution segments. The agents are synchronized through
+// the coordinator and they send their metric samples to it, so the metrics can
+// be aggregated and the thresholds can be evaluated for the whole test.
+//
+// The coordinator and the agents communicate with JSON-encoded messages over
+// HTTP. Some of the requests, like the ones for waiting on an event, are
+// long-polling ones, which are answered only when their condition is met.
+package distributed
+
+import (
+	"errors"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// The paths of the coordinator endpoints.
+const (
+	registerPath = "/v1/register"
+	dataGetPath  = "/v1/data/get"
+	dataSetPath  = "/v1/data/set"
+	signalPath   = "/v1/signal"
+	waitPath     = "/v1/wait"
+	storePath    = "/v1/store"
+	metricsPath  = "/v1/metrics"
+	alivePath    = "/v1/alive"
+)
+
+// RegisterResponse is what the coordinator returns to the agents when they
+// register, i.e. everything that they need to run their part of the test.
+type RegisterResponse struct {
+	InstanceID               int    `json:"instanceID"`
+	Archive                  []byte `json:"archive"`
+	ExecutionSegment         string `json:"executionSegment"`
+	ExecutionSegmentSequence string `json:"executionSegmentSequence"`
+
+	// AgentTimeout is how long the coordinator waits for an agent that
+	// doesn't send any requests before it considers it dead, so the agents
+	// notify it that they are alive more often than that.
+	AgentTimeout time.Duration `json:"agentTimeout"`
+}
+
+// instanceRequest is the request of an agent that is only notifying the
+// coordinator that it's still alive.
+type instanceRequest struct {
+	InstanceID int `json:"instanceID"`
+}
+
+type dataRequest struct {
+	InstanceID int    `json:"instanceID"`
+	ID         string `json:"id"`
+	Data       []byte `json:"data,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type dataResponse struct {
+	// Create is set when the agent that requested the data has to create it
+	// and send it back, because no other agent has done so yet.
+	Create bool   `json:"create,omitempty"`
+	Data   []byte `json:"data,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type signalRequest struct {
+	InstanceID int    `json:"instanceID"`
+	Event      string `json:"event"`
+	Error      string `json:"error,omitempty"`
+}
+
+type waitRequest struct {
+	InstanceID int    `json:"instanceID"`
+	Event      string `json:"event"`
+}
+
+type errorResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+type storeRequest struct {
+	InstanceID int    `json:"instanceID"`
+	Op         string `json:"op"`
+	Key        string `json:"key"`
+	Value      []byte `json:"value,omitempty"`
+	OldValue   []byte `json:"oldValue,omitempty"`
+	Delta      int64  `json:"delta,omitempty"`
+}
+
+type storeResponse struct {
+	Value  []byte `json:"value,omitempty"`
+	OK     bool   `json:"ok,omitempty"`
+	Result int64  `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type metricsRequest struct {
+	InstanceID int      `json:"instanceID"`
+	Samples    []sample `json:"samples"`
+
+	// Final is set when the agent has finished and these are its last
+	// samples.
+	Final bool `json:"final,omitempty"`
+}
+
+type metricsResponse struct {
+	// Abort is the error with which the coordinator aborted the test, e.g.
+	// because of a threshold with abortOnFail, if it did.
+	Abort         string `json:"abort,omitempty"`
+	AbortExitCode int    `json:"abortExitCode,omitempty"`
+}
+
+// sample is a metric sample in a form that can be sent to the coordinator
+// and registered in its own metrics registry.
+type sample struct {
+	Metric   string             `json:"metric"`
+	Type     metrics.MetricType `json:"type"`
+	Contains metrics.ValueType  `json:"contains"`
+	Tags     map[string]string  `json:"tags,omitempty"`
+	Metadata map[string]string  `json:"metadata,omitempty"`
+	Time     int64              `json:"time"`
+	Value    float64            `json:"value"`
+}
+
+func newSample(s metrics.Sample) sample {
+	return sample{
+		Metric:   s.Metric.Name,
+		Type:     s.Metric.Type,
+		Contains: s.Metric.Contains,
+		Tags:     s.Tags.Map(),
+		Metadata: s.Metadata,
+		Time:     s.Time.UnixNano(),
+		Value:    s.Value,
+	}
+}
+
+// toSample returns the metrics.Sample with the metric from the given
+// registry, which is created if it doesn't already exist there.
+func (s sample) toSample(registry *metrics.Registry) (metrics.Sample, error) {
+	metric, err := registry.NewMetric(s.Metric, s.Type, s.Contains)
+	if err != nil {
+		return metrics.Sample{}, err
+	}
+	return metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: metric,
+			Tags:   registry.RootTagSet().WithTagsFromMap(s.Tags),
+		},
+		Time:     time.Unix(0, s.Time),
+		Value:    s.Value,
+		Metadata: s.Metadata,
+	}, nil
+}
+
+// errorToString and stringToError are used for sending errors in the JSON
+// messages.
+func errorToString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func stringToError(s string) error {
+	if s == "" {
+		return nil
+	}
+	return errors.New(s)
+}
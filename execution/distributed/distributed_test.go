@@ -0,0 +1,300 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+type testIngester struct {
+	mx      sync.Mutex
+	samples []metrics.Sample
+}
+
+func (ti *testIngester) AddMetricSamples(samples []metrics.SampleContainer) {
+	ti.mx.Lock()
+	defer ti.mx.Unlock()
+	for _, sc := range samples {
+		ti.samples = append(ti.samples, sc.GetSamples()...)
+	}
+}
+
+func newTestCoordinator(t *testing.T, instances int) (*Coordinator, *testIngester, *lib.Group, string) {
+	t.Helper()
+
+	ingester := &testIngester{}
+	rootGroup, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	coordinator, err := NewCoordinator(CoordinatorConfig{
+		InstanceCount: instances,
+		Archive:       []byte("archive"),
+		Registry:      metrics.NewRegistry(),
+		Ingester:      ingester,
+		RootGroup:     rootGroup,
+	}, testutils.NewLogger(t))
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(coordinator.Handler())
+	t.Cleanup(srv.Close)
+	return coordinator, ingester, rootGroup, srv.URL
+}
+
+func registerAgents(t *testing.T, address string, count int) []*AgentController {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	agents := make([]*AgentController, count)
+	for i := range agents {
+		agent, resp, err := Register(ctx, http.DefaultClient, address, "", testutils.NewLogger(t))
+		require.NoError(t, err)
+		assert.Equal(t, i, resp.InstanceID)
+		assert.Equal(t, []byte("archive"), resp.Archive)
+		assert.Equal(t, "0,1/2,1", resp.ExecutionSegmentSequence)
+		agents[i] = agent
+	}
+	return agents
+}
+
+func TestCoordinatorRegister(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, address := newTestCoordinator(t, 2)
+	agents := registerAgents(t, address, 2)
+	assert.Len(t, agents, 2)
+
+	_, _, err := Register(context.Background(), http.DefaultClient, address, "", testutils.NewLogger(t))
+	require.ErrorContains(t, err, "all 2 instances have already registered")
+
+	_, err = NewCoordinator(CoordinatorConfig{}, testutils.NewLogger(t))
+	require.ErrorContains(t, err, "the instance count must be at least 1")
+}
+
+func TestCoordinatorUnregisteredInstance(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, address := newTestCoordinator(t, 2)
+	agents := registerAgents(t, address, 1)
+
+	c := &client{ctx: context.Background(), httpClient: http.DefaultClient, address: address}
+	for _, id := range []int{-1, 1, 2} {
+		requests := map[string]interface{}{
+			signalPath:  signalRequest{InstanceID: id, Event: "event"},
+			waitPath:    waitRequest{InstanceID: id, Event: "event"},
+			dataGetPath: dataRequest{InstanceID: id, ID: "data"},
+			dataSetPath: dataRequest{InstanceID: id, ID: "data"},
+			storePath:   storeRequest{InstanceID: id, Op: "get", Key: "key"},
+			metricsPath: metricsRequest{InstanceID: id, Final: true},
+			alivePath:   instanceRequest{InstanceID: id},
+		}
+		for path, req := range requests {
+			err := c.call(path, req, &struct{}{})
+			assert.ErrorContains(t, err, "hasn't registered", "%s of instance %d", path, id)
+		}
+	}
+	require.NoError(t, agents[0].Signal("event", nil))
+}
+
+func TestCoordinatorDeadAgent(t *testing.T) {
+	t.Parallel()
+
+	coordinator, err := NewCoordinator(CoordinatorConfig{
+		InstanceCount: 2,
+		Registry:      metrics.NewRegistry(),
+		AgentTimeout:  300 * time.Millisecond,
+	}, testutils.NewLogger(t))
+	require.NoError(t, err)
+	srv := httptest.NewServer(coordinator.Handler())
+	t.Cleanup(srv.Close)
+
+	// The first agent keeps notifying the coordinator that it's alive, but
+	// the second one registers without ever sending anything else.
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	agent, _, err := Register(ctx, http.DefaultClient, srv.URL, "", testutils.NewLogger(t))
+	require.NoError(t, err)
+	c := &client{ctx: ctx, httpClient: http.DefaultClient, address: srv.URL}
+	require.NoError(t, c.call(registerPath, struct{}{}, &RegisterResponse{}))
+
+	err = execution.SignalAndWait(agent, "event")
+	require.ErrorContains(t, err, "instance 1 hasn't sent any requests for more than 300ms")
+	err = coordinator.Wait(context.Background())
+	require.ErrorContains(t, err, "instance 1 hasn't sent any requests for more than 300ms")
+}
+
+func TestAgentToken(t *testing.T) {
+	t.Parallel()
+
+	coordinator, _, _, _ := newTestCoordinator(t, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		coordinator.Handler().ServeHTTP(rw, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, _, err := Register(ctx, http.DefaultClient, srv.URL, "wrong", testutils.NewLogger(t))
+	require.ErrorContains(t, err, "coordinator error (401)")
+
+	agent, _, err := Register(ctx, http.DefaultClient, srv.URL, "secret", testutils.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, execution.SignalAndWait(agent, "event"))
+}
+
+func TestAgentControllerSignalAndWait(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, address := newTestCoordinator(t, 2)
+	agents := registerAgents(t, address, 2)
+
+	first := make(chan error)
+	go func() { first <- execution.SignalAndWait(agents[0], "event") }()
+	select {
+	case err := <-first:
+		t.Fatalf("the first instance didn't wait for the second one: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, execution.SignalAndWait(agents[1], "event"))
+	require.NoError(t, <-first)
+
+	go func() { first <- execution.SignalAndWait(agents[0], "other-event") }()
+	require.NoError(t, agents[1].Signal("other-event", errors.New("something went wrong")))
+	require.ErrorContains(t, <-first, "instance 1: something went wrong")
+}
+
+func TestAgentControllerGetOrCreateData(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, address := newTestCoordinator(t, 2)
+	agents := registerAgents(t, address, 2)
+
+	data, err := agents[0].GetOrCreateData("setup", func() ([]byte, error) { return []byte("data"), nil })
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	data, err = agents[1].GetOrCreateData("setup", func() ([]byte, error) {
+		t.Error("the data was created twice")
+		return nil, nil //nolint:nilnil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	_, err = agents[1].GetOrCreateData("teardown", func() ([]byte, error) { return nil, errors.New("oops") })
+	require.ErrorContains(t, err, "oops")
+	_, err = agents[0].GetOrCreateData("teardown", func() ([]byte, error) { return nil, nil }) //nolint:nilnil
+	require.ErrorContains(t, err, "oops")
+}
+
+func TestAgentControllerSharedStore(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, address := newTestCoordinator(t, 2)
+	agents := registerAgents(t, address, 2)
+	first, second := agents[0].SharedStore(), agents[1].SharedStore()
+
+	require.NoError(t, first.Set("key", []byte("value")))
+	value, ok, err := second.Get("key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), value)
+
+	swapped, err := second.CompareAndSwap("key", []byte("value"), []byte("other"))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	swapped, err = first.CompareAndSwap("key", []byte("value"), []byte("third"))
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	result, err := first.Incr("counter", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result)
+	result, err = second.Incr("counter", 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), result)
+
+	_, err = second.Incr("key", 1)
+	require.Error(t, err)
+
+	require.NoError(t, second.Delete("key"))
+	_, ok, err = first.Get("key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMetricsOutput(t *testing.T) {
+	t.Parallel()
+
+	coordinator, ingester, rootGroup, address := newTestCoordinator(t, 2)
+	agents := registerAgents(t, address, 2)
+
+	registry := metrics.NewRegistry()
+	checks := registry.MustNewMetric(metrics.ChecksName, metrics.Rate)
+	tags := registry.RootTagSet().With("group", "::login").With("check", "status is 200")
+
+	var stopErr error
+	outputs := make([]*MetricsOutput, len(agents))
+	for i, agent := range agents {
+		outputs[i] = agent.NewMetricsOutput()
+		outputs[i].SetTestRunStopCallback(func(err error) { stopErr = err })
+		require.NoError(t, outputs[i].Start())
+		outputs[i].AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: checks, Tags: tags},
+			Time:       time.Now(),
+			Value:      float64(i),
+		}})
+	}
+
+	coordinator.Abort(errext.WithExitCodeIfNone(errors.New("thresholds failed"), exitcodes.ThresholdsHaveFailed))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	waitErr := make(chan error)
+	go func() { waitErr <- coordinator.Wait(ctx) }()
+
+	require.NoError(t, outputs[0].Stop())
+	select {
+	case err := <-waitErr:
+		t.Fatalf("the coordinator didn't wait for the second instance: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, outputs[1].Stop())
+	require.ErrorContains(t, <-waitErr, "thresholds failed")
+
+	require.Error(t, stopErr)
+	assert.Equal(t, "thresholds failed", stopErr.Error())
+	var ecErr errext.HasExitCode
+	require.ErrorAs(t, stopErr, &ecErr)
+	assert.Equal(t, exitcodes.ThresholdsHaveFailed, ecErr.ExitCode())
+
+	require.Len(t, ingester.samples, 2)
+	assert.Equal(t, metrics.ChecksName, ingester.samples[0].Metric.Name)
+	assert.Equal(t, metrics.Rate, ingester.samples[0].Metric.Type)
+	assert.Equal(t, "status is 200", ingester.samples[0].Tags.Map()["check"])
+
+	group, err := rootGroup.Group("login")
+	require.NoError(t, err)
+	check, err := group.Check("status is 200")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), check.Passes)
+	assert.Equal(t, int64(1), check.Fails)
+}
@@ -0,0 +1,133 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/metrics"
+)
+
+// generatorHealth measures the health of the load generator, i.e. the CPU and
+// the memory used by the k6 process and the pauses of its garbage collector,
+// so the results skewed by its saturation can be detected.
+type generatorHealth struct {
+	lastTime    time.Time
+	lastCPUTime time.Duration
+	lastNumGC   uint32
+	memStats    runtime.MemStats
+}
+
+func newGeneratorHealth() *generatorHealth {
+	h := &generatorHealth{lastTime: time.Now(), lastCPUTime: processCPUTime()}
+	runtime.ReadMemStats(&h.memStats)
+	h.lastNumGC = h.memStats.NumGC
+	return h
+}
+
+// measure returns the percentage of the CPU cores used by the process since
+// the last measurement, the memory it got from the OS and didn't release, and
+// the pauses of the garbage collections since the last measurement.
+func (h *generatorHealth) measure(now time.Time) (cpu float64, memory uint64, gcPauses []time.Duration) {
+	cpuTime := processCPUTime()
+	if elapsed := now.Sub(h.lastTime); elapsed > 0 {
+		cpu = float64(cpuTime-h.lastCPUTime) / float64(elapsed) / float64(runtime.NumCPU()) * 100
+	}
+	h.lastTime, h.lastCPUTime = now, cpuTime
+
+	runtime.ReadMemStats(&h.memStats)
+	memory = h.memStats.Sys - h.memStats.HeapReleased
+
+	// the pauses of the last 256 collections are kept in a circular buffer
+	numGC := h.memStats.NumGC
+	if numGC-h.lastNumGC > uint32(len(h.memStats.PauseNs)) {
+		h.lastNumGC = numGC - uint32(len(h.memStats.PauseNs))
+	}
+	for i := h.lastNumGC; i < numGC; i++ {
+		gcPauses = append(gcPauses, time.Duration(h.memStats.PauseNs[i%uint32(len(h.memStats.PauseNs))]))
+	}
+	h.lastNumGC = numGC
+	return cpu, memory, gcPauses
+}
+
+// emitGeneratorHealth measures the health of the load generator every second
+// and emits its metrics, if the generatorMetrics option is enabled. When
+// maxMemory is set, the test run is stopped once k6 uses more memory than it,
+// before it runs out of it.
+func (e *Scheduler) emitGeneratorHealth(ctx context.Context, out chan<- metrics.SampleContainer) func() {
+	tags := e.state.Test.RunTags
+	builtinMetrics := e.state.Test.BuiltinMetrics
+	emit := e.state.Test.Options.GeneratorMetrics.Bool
+	var maxMemory uint64
+	if mm := e.state.Test.Options.MaxMemory; mm.Valid && mm.Int64 > 0 {
+		maxMemory = uint64(mm.Int64) * 1024 * 1024
+	}
+	if !emit && maxMemory == 0 {
+		return func() {}
+	}
+
+	health := newGeneratorHealth()
+	aborted := false
+	emitMetrics := func() {
+		t := time.Now()
+		cpu, memory, gcPauses := health.measure(t)
+		if emit {
+			samples := []metrics.Sample{
+				{
+					TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.GeneratorCPU, Tags: tags},
+					Time:       t,
+					Value:      cpu,
+				}, {
+					TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.GeneratorMemory, Tags: tags},
+					Time:       t,
+					Value:      float64(memory),
+				},
+			}
+			for _, pause := range gcPauses {
+				samples = append(samples, metrics.Sample{
+					TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.GeneratorGCPause, Tags: tags},
+					Time:       t,
+					Value:      metrics.D(pause),
+				})
+			}
+			metrics.PushIfNotDone(ctx, out, metrics.ConnectedSamples{Samples: samples, Tags: tags, Time: t})
+		}
+
+		if maxMemory > 0 && memory > maxMemory && !aborted {
+			aborted = true
+			err := fmt.Errorf(
+				"k6 uses %dMB of memory, more than the %dMB of maxMemory; stopping test prematurely",
+				memory/1024/1024, maxMemory/1024/1024,
+			)
+			e.state.Test.Logger.Error(err)
+			AbortTestRun(ctx, errext.WithAbortReasonIfNone(
+				errext.WithExitCodeIfNone(err, exitcodes.MemoryLimitExceeded), errext.AbortedByMemoryLimit,
+			))
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	ticker := time.NewTicker(1 * time.Second)
+	go func() {
+		defer func() {
+			ticker.Stop()
+			wg.Done()
+		}()
+
+		for {
+			select {
+			case <-ticker.C:
+				emitMetrics()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return wg.Wait
+}
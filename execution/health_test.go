@@ -0,0 +1,23 @@
+package execution
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratorHealthMeasure(t *testing.T) {
+	t.Parallel()
+
+	health := newGeneratorHealth()
+	runtime.GC()
+	runtime.GC()
+
+	cpu, memory, gcPauses := health.measure(time.Now().Add(time.Second))
+	assert.GreaterOrEqual(t, cpu, 0.0)
+	assert.LessOrEqual(t, cpu, 100.0)
+	assert.Greater(t, memory, uint64(0))
+	assert.GreaterOrEqual(t, len(gcPauses), 2)
+}
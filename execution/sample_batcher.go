@@ -0,0 +1,219 @@
+package execution
+
+import (
+	"sync"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	// sampleBufferSize is the number of sample containers a VU can buffer,
+	// and the size of the batches its buffer is flushed with as soon as it
+	// fills up.
+	sampleBufferSize = 256
+	// sampleFlushInterval is how often the partially filled buffers of the
+	// VUs are flushed, the same as how often the output manager sends the
+	// samples to the outputs.
+	sampleFlushInterval = 50 * time.Millisecond
+	// sampleBufferMetricsInterval is how often the backpressure metrics of
+	// the buffers are emitted.
+	sampleBufferMetricsInterval = time.Second
+)
+
+// sampleBatcher gives each VU a buffer of its own for its samples and sends
+// them as metrics.SampleBatch to the channel of the samples, so the VUs don't
+// contend for that channel on every sample.
+//
+// The samples of each VU are moved from its channel to its batch as soon as
+// they are sent, so the VUs never wait for a flush. A batch which fills up is
+// sent right away, and the partial ones are all flushed periodically as a
+// single batch. The VUs only wait when the channel of the samples is full, so
+// no samples are ever dropped.
+type sampleBatcher struct {
+	out chan<- metrics.SampleContainer
+	// stopped is closed when the batcher stops, for the buffers to stop
+	// moving the samples of their VUs.
+	stopped chan struct{}
+	drains  sync.WaitGroup
+
+	buffersMx sync.Mutex
+	buffers   []*sampleBuffer
+
+	// the backpressure since the last emission of the metrics
+	maxFill     float64
+	fullBuffers int
+}
+
+// sampleBuffer is the buffer of the samples of a VU.
+type sampleBuffer struct {
+	ch chan metrics.SampleContainer
+
+	mx    sync.Mutex
+	batch metrics.SampleBatch
+	// the backpressure since the last flush
+	maxFill float64
+	full    int
+}
+
+func newSampleBatcher(out chan<- metrics.SampleContainer) *sampleBatcher {
+	return &sampleBatcher{out: out, stopped: make(chan struct{})}
+}
+
+// newBuffer returns the buffer for the samples of a new VU.
+func (sb *sampleBatcher) newBuffer() chan<- metrics.SampleContainer {
+	buf := &sampleBuffer{ch: make(chan metrics.SampleContainer, sampleBufferSize)}
+	sb.buffersMx.Lock()
+	sb.buffers = append(sb.buffers, buf)
+	sb.buffersMx.Unlock()
+
+	sb.drains.Add(1)
+	go func() {
+		defer sb.drains.Done()
+		sb.drain(buf)
+	}()
+	return buf.ch
+}
+
+// drain moves the samples of the VU to its batch until the batcher stops, and
+// sends the batch as soon as it's full.
+func (sb *sampleBatcher) drain(buf *sampleBuffer) {
+	for {
+		select {
+		case sc := <-buf.ch:
+			if full := buf.add(sc); full != nil {
+				sb.out <- full
+			}
+		case <-sb.stopped:
+			return
+		}
+	}
+}
+
+// add adds the container to the batch, and returns the batch if it's full.
+func (buf *sampleBuffer) add(sc metrics.SampleContainer) metrics.SampleBatch {
+	// the container was in the channel with the pending ones
+	pending := len(buf.ch) + 1
+	if pending > cap(buf.ch) {
+		pending = cap(buf.ch)
+	}
+
+	buf.mx.Lock()
+	defer buf.mx.Unlock()
+	if fill := float64(pending) / float64(cap(buf.ch)); fill > buf.maxFill {
+		buf.maxFill = fill
+	}
+	if buf.batch == nil {
+		buf.batch = make(metrics.SampleBatch, 0, sampleBufferSize)
+	}
+	buf.batch = append(buf.batch, sc)
+	if len(buf.batch) < sampleBufferSize {
+		return nil
+	}
+	buf.full++
+	full := buf.batch
+	buf.batch = nil
+	return full
+}
+
+// take returns the batch and the backpressure of the buffer and resets them.
+func (buf *sampleBuffer) take() (batch metrics.SampleBatch, maxFill float64, full int) {
+	buf.mx.Lock()
+	defer buf.mx.Unlock()
+	batch, maxFill, full = buf.batch, buf.maxFill, buf.full
+	buf.batch, buf.maxFill, buf.full = nil, 0, 0
+	return batch, maxFill, full
+}
+
+// flush sends the partial batches of the VUs, and the given extra samples, to
+// the channel of the samples as a single batch.
+func (sb *sampleBatcher) flush(extra ...metrics.SampleContainer) {
+	sb.buffersMx.Lock()
+	buffers := sb.buffers
+	sb.buffersMx.Unlock()
+
+	var batch metrics.SampleBatch
+	for _, buf := range buffers {
+		partial, maxFill, full := buf.take()
+		if maxFill > sb.maxFill {
+			sb.maxFill = maxFill
+		}
+		sb.fullBuffers += full
+		batch = append(batch, partial...)
+	}
+	batch = append(batch, extra...)
+	if len(batch) > 0 {
+		sb.out <- batch
+	}
+}
+
+// backpressureSamples returns the samples of the highest fill of a buffer and
+// of the number of times a buffer filled up since the previous call.
+func (sb *sampleBatcher) backpressureSamples(
+	builtinMetrics *metrics.BuiltinMetrics, tags *metrics.TagSet, t time.Time,
+) metrics.SampleContainer {
+	samples := metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.SampleBufferFill, Tags: tags},
+				Time:       t,
+				Value:      sb.maxFill,
+			}, {
+				TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.SampleBufferFull, Tags: tags},
+				Time:       t,
+				Value:      float64(sb.fullBuffers),
+			},
+		},
+		Tags: tags,
+		Time: t,
+	}
+	sb.maxFill, sb.fullBuffers = 0, 0
+	return samples
+}
+
+// stop stops moving the samples of the VUs, and moves the ones which are still
+// in their channels to their batches.
+func (sb *sampleBatcher) stop() {
+	close(sb.stopped)
+	sb.drains.Wait()
+
+	sb.buffersMx.Lock()
+	buffers := sb.buffers
+	sb.buffersMx.Unlock()
+	for _, buf := range buffers {
+		for n := len(buf.ch); n > 0; n-- {
+			if full := buf.add(<-buf.ch); full != nil {
+				sb.out <- full
+			}
+		}
+	}
+}
+
+// run flushes the buffers periodically until stop is closed, and flushes them
+// one last time before it returns. The backpressure metrics are only emitted
+// if builtinMetrics isn't nil.
+func (sb *sampleBatcher) run(
+	stop <-chan struct{}, builtinMetrics *metrics.BuiltinMetrics, tags *metrics.TagSet,
+) {
+	flushTicker := time.NewTicker(sampleFlushInterval)
+	defer flushTicker.Stop()
+	var emitMetrics <-chan time.Time
+	if builtinMetrics != nil {
+		metricsTicker := time.NewTicker(sampleBufferMetricsInterval)
+		defer metricsTicker.Stop()
+		emitMetrics = metricsTicker.C
+	}
+
+	for {
+		select {
+		case <-flushTicker.C:
+			sb.flush()
+		case t := <-emitMetrics:
+			sb.flush(sb.backpressureSamples(builtinMetrics, tags, t))
+		case <-stop:
+			sb.stop()
+			sb.flush()
+			return
+		}
+	}
+}
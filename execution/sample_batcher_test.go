@@ -0,0 +1,129 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestSampleBatcher(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	tags := registry.RootTagSet()
+	out := make(chan metrics.SampleContainer, 10)
+	sb := newSampleBatcher(out)
+	defer sb.stop()
+
+	sample := func(vu float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.Iterations, Tags: tags},
+			Time:       time.Now(),
+			Value:      vu,
+		}
+	}
+	vu1, vu2 := sb.newBuffer(), sb.newBuffer()
+	for i := 0; i < sampleBufferSize+1; i++ {
+		vu1 <- sample(1)
+	}
+	vu2 <- sample(2)
+
+	// the full batch is sent without waiting for a flush
+	select {
+	case sc := <-out:
+		batch, ok := sc.(metrics.SampleBatch)
+		require.True(t, ok)
+		assert.Len(t, batch, sampleBufferSize)
+	case <-time.After(time.Second):
+		t.Fatal("the full buffer wasn't flushed")
+	}
+
+	// the partial ones wait for a flush
+	rest := 0
+	require.Eventually(t, func() bool {
+		sb.flush()
+		for len(out) > 0 {
+			rest += len((<-out).GetSamples())
+		}
+		return rest == 2
+	}, time.Second, time.Millisecond)
+
+	sb.flush()
+	assert.Len(t, out, 0, "empty buffers shouldn't be flushed")
+
+	backpressure := sb.backpressureSamples(builtinMetrics, tags, time.Now()).GetSamples()
+	require.Len(t, backpressure, 2)
+	assert.Greater(t, backpressure[0].Value, 0.0)
+	assert.Equal(t, 1.0, backpressure[1].Value)
+	backpressure = sb.backpressureSamples(builtinMetrics, tags, time.Now()).GetSamples()
+	assert.Equal(t, 0.0, backpressure[0].Value)
+	assert.Equal(t, 0.0, backpressure[1].Value)
+}
+
+// TestSampleBatcherThroughput checks that a VU sending samples faster than the
+// periodic flushes doesn't wait for them.
+func TestSampleBatcherThroughput(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	out := make(chan metrics.SampleContainer, 10)
+	sb := newSampleBatcher(out)
+
+	received := make(chan int)
+	go func() {
+		count := 0
+		for sc := range out {
+			count += len(sc.GetSamples())
+		}
+		received <- count
+	}()
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		sb.run(stop, builtinMetrics, registry.RootTagSet())
+	}()
+
+	// with a wait for each flush, this would take 50 flush intervals
+	const count = 50 * sampleBufferSize
+	vu := sb.newBuffer()
+	sample := metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.Iterations}}
+	start := time.Now()
+	for i := 0; i < count; i++ {
+		vu <- sample
+	}
+	elapsed := time.Since(start)
+	close(stop)
+	<-done
+	close(out)
+
+	assert.Less(t, elapsed, 10*sampleFlushInterval)
+	assert.Equal(t, count, <-received, "all the samples should be sent")
+}
+
+func TestSampleBatcherRunFlushesOnStop(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	out := make(chan metrics.SampleContainer, 10)
+	sb := newSampleBatcher(out)
+	vu := sb.newBuffer()
+	vu <- metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.Iterations}}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		sb.run(stop, builtinMetrics, registry.RootTagSet())
+	}()
+	close(stop)
+	<-done
+
+	require.Len(t, out, 1)
+	assert.Len(t, (<-out).GetSamples(), 1)
+}
@@ -29,6 +29,41 @@ type Scheduler struct {
 	maxDuration     time.Duration // cached value derived from the execution plan
 	maxPossibleVUs  uint64        // cached value derived from the execution plan
 	state           *lib.ExecutionState
+
+	// used for the dependsOn and startWhen options of the scenarios
+	scenarioEvents         map[string]*scenarioEvents
+	metricConditionChecker MetricConditionChecker
+
+	scenarioObserver ScenarioObserver
+
+	// waits for the unplanned VUs warmed up in the background, if any
+	waitForWarmVUs func()
+
+	// batches the samples of the VUs, if enabled before Init()
+	sampleBatching bool
+	sampleBatcher  *sampleBatcher
+}
+
+// ScenarioObserver is notified when the scenarios start running their
+// iterations and when they finish. The scenarios wait for its methods to
+// return.
+type ScenarioObserver interface {
+	ScenarioStarted(ctx context.Context, executor lib.Executor)
+	ScenarioFinished(ctx context.Context, executor lib.Executor, err error)
+}
+
+// SetScenarioObserver sets what is notified of the start and the end of the
+// scenarios. It has to be called before Run().
+func (e *Scheduler) SetScenarioObserver(observer ScenarioObserver) {
+	e.scenarioObserver = observer
+}
+
+// EnableSampleBatching makes the VUs buffer their samples and the scheduler
+// send them in batches to the samples channel, instead of each VU sending each
+// sample to it. The batches are metrics.SampleBatch values, unpacked by the
+// output manager. It has to be called before Init().
+func (e *Scheduler) EnableSampleBatching() {
+	e.sampleBatching = true
 }
 
 // NewScheduler creates and returns a new Scheduler instance, without
@@ -46,13 +81,20 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 	maxPossibleVUs := lib.GetMaxPossibleVUs(executionPlan)
 
 	executionState := lib.NewExecutionState(trs, et, maxPlannedVUs, maxPossibleVUs)
+	if ssc, ok := controller.(SharedStoreController); ok {
+		executionState.SharedStore = ssc.SharedStore()
+	}
 	maxDuration, _ := lib.GetEndOffset(executionPlan) // we don't care if the end offset is final
 
 	executorConfigs := options.Scenarios.GetSortedConfigs()
 	executors := make([]lib.Executor, 0, len(executorConfigs))
+	scenarioEventsMap := make(map[string]*scenarioEvents, len(executorConfigs))
 	// Only take executors which have work.
 	for _, sc := range executorConfigs {
+		events := newScenarioEvents()
+		scenarioEventsMap[sc.GetName()] = events
 		if !sc.HasWork(et) {
+			events.markFinished() // scenarios that depend on it shouldn't wait
 			trs.Logger.Warnf(
 				"Executor '%s' is disabled for segment %s due to lack of work!",
 				sc.GetName(), options.ExecutionSegment,
@@ -84,6 +126,7 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 		maxPossibleVUs:  maxPossibleVUs,
 		state:           executionState,
 		controller:      controller,
+		scenarioEvents:  scenarioEventsMap,
 	}, nil
 }
 
@@ -102,6 +145,17 @@ func (e *Scheduler) GetExecutors() []lib.Executor {
 	return e.executors
 }
 
+// GetExecutor returns the executor instance for the scenario with the given
+// name, or nil if there isn't one with work.
+func (e *Scheduler) GetExecutor(name string) lib.Executor {
+	for _, exec := range e.executors {
+		if exec.GetConfig().GetName() == name {
+			return exec
+		}
+	}
+	return nil
+}
+
 // GetExecutorConfigs returns the slice of all executor configs, sorted by
 // their (startTime, name) in an ascending order.
 func (e *Scheduler) GetExecutorConfigs() []lib.ExecutorConfig {
@@ -130,6 +184,9 @@ func (e *Scheduler) initVU(
 	// Get the VU IDs here, so that the VUs are (mostly) ordered by their
 	// number in the channel buffer
 	vuIDLocal, vuIDGlobal := e.state.GetUniqueVUIdentifiers()
+	if e.sampleBatcher != nil {
+		samplesOut = e.sampleBatcher.newBuffer()
+	}
 	vu, err := e.state.Test.Runner.NewVU(ctx, vuIDLocal, vuIDGlobal, samplesOut)
 	if err != nil {
 		return nil, errext.WithHint(err, fmt.Sprintf("error while initializing VU #%d", vuIDGlobal))
@@ -308,6 +365,12 @@ func (e *Scheduler) initVUsAndExecutors(ctx context.Context, samplesOut chan<- m
 		return e.initVU(ctx, samplesOut, logger)
 	})
 
+	if warmVUs := e.state.Test.Options.WarmVUs.Int64; warmVUs > 0 {
+		warming, wait := e.state.WarmUnplannedVUs(ctx, logger, warmVUs, runtime.GOMAXPROCS(0))
+		logger.Debugf("Warming up %d unplanned VUs in the background...", warming)
+		e.waitForWarmVUs = wait
+	}
+
 	e.state.SetExecutionStatus(lib.ExecutionStatusInitExecutors)
 	logger.Debugf("Finished initializing needed VUs, start initializing executors...")
 	for _, exec := range e.executors {
@@ -326,10 +389,14 @@ func (e *Scheduler) initVUsAndExecutors(ctx context.Context, samplesOut chan<- m
 
 // runExecutor gets called by the public Run() method once per configured
 // executor, each time in a new goroutine. It is responsible for waiting out the
-// configured startTime for the specific executor and then running its Run()
-// method.
+// configured startTime and start conditions for the specific executor and then
+// running its Run() method, surrounded by the scenario-level setup and teardown
+// functions, if there are any. Similar to the global teardown(), the scenario
+// teardown is executed with the teardownCtx, so it isn't interrupted by test
+// aborts.
 func (e *Scheduler) runExecutor(
-	runCtx context.Context, runResults chan<- error, engineOut chan<- metrics.SampleContainer, executor lib.Executor,
+	runCtx, teardownCtx context.Context, runResults chan<- error,
+	engineOut chan<- metrics.SampleContainer, executor lib.Executor,
 ) {
 	executorConfig := executor.GetConfig()
 	executorStartTime := executorConfig.GetStartTime()
@@ -339,6 +406,8 @@ func (e *Scheduler) runExecutor(
 		"startTime": executorStartTime,
 	})
 	executorProgress := executor.GetProgress()
+	events := e.scenarioEvents[executorConfig.GetName()]
+	defer events.markFinished()
 
 	// Check if we have to wait before starting the actual executor execution
 	if executorStartTime > 0 {
@@ -361,23 +430,92 @@ func (e *Scheduler) runExecutor(
 		}
 	}
 
+	// The dependencies don't change the execution plan, i.e. the VUs are
+	// allocated as if they didn't exist.
+	if conditions := executorConfig.GetStartConditions(); len(conditions.DependsOn) > 0 || conditions.StartWhen != nil {
+		executorLogger.Debugf("Waiting for executor start conditions...")
+		started, err := e.waitForStartConditions(runCtx, conditions, executorProgress)
+		if !started {
+			runResults <- err // no error if the context was done, since executor hasn't started yet
+			return
+		}
+	}
+
+	select {
+	case <-e.state.GracefulAbortNotify():
+		executorLogger.Debugf("The test was aborted gracefully, the executor isn't started")
+		runResults <- nil
+		return
+	default:
+	}
+
+	lifecycle := executorConfig.GetLifecycle()
+	if lifecycle.Setup != "" && !e.state.Test.Options.NoSetup.Bool {
+		executorProgress.Modify(pb.WithConstProgress(0, lifecycle.Setup+"()"))
+		if err := e.runScenarioSetup(runCtx, executorConfig.GetName(), engineOut); err != nil {
+			executorLogger.WithField("error", err).Debugf("%s() aborted by error", lifecycle.Setup)
+			runResults <- err
+			return
+		}
+	}
+	events.markSetupDone()
+
 	executorProgress.Modify(
 		pb.WithStatus(pb.Running),
 		pb.WithConstProgress(0, "started"),
 	)
 	executorLogger.Debugf("Starting executor")
+	if e.scenarioObserver != nil {
+		e.scenarioObserver.ScenarioStarted(runCtx, executor)
+	}
 	err := executor.Run(runCtx, engineOut) // executor should handle context cancel itself
 	if err == nil {
 		executorLogger.Debugf("Executor finished successfully")
 	} else {
 		executorLogger.WithField("error", err).Errorf("Executor error")
 	}
+	if e.scenarioObserver != nil {
+		e.scenarioObserver.ScenarioFinished(teardownCtx, executor, err)
+	}
+
+	if lifecycle.Teardown != "" && !e.state.Test.Options.NoTeardown.Bool {
+		scenario := executorConfig.GetName()
+		_, teardownErr := e.controller.GetOrCreateData("scenario-teardown-"+scenario, func() ([]byte, error) {
+			return nil, e.state.Test.Runner.ScenarioTeardown(teardownCtx, scenario, engineOut)
+		})
+		if teardownErr != nil {
+			executorLogger.WithField("error", teardownErr).Debugf("%s() aborted by error", lifecycle.Teardown)
+			if err == nil {
+				err = teardownErr
+			}
+		}
+	}
 	runResults <- err
 }
 
+// runScenarioSetup runs the setup function of the given scenario only once
+// across all instances, and shares its result with them.
+func (e *Scheduler) runScenarioSetup(
+	ctx context.Context, scenario string, engineOut chan<- metrics.SampleContainer,
+) error {
+	actuallyRanSetup := false
+	data, err := e.controller.GetOrCreateData("scenario-setup-"+scenario, func() ([]byte, error) {
+		actuallyRanSetup = true
+		return e.state.Test.Runner.ScenarioSetup(ctx, scenario, engineOut)
+	})
+	if err != nil {
+		return err
+	}
+	if !actuallyRanSetup {
+		e.state.Test.Runner.SetScenarioSetupData(scenario, data)
+	}
+	return nil
+}
+
 // Init concurrently initializes all of the planned VUs and then sequentially
 // initializes all of the configured executors. It also starts the measurement
-// and emission of the `vus` and `vus_max` metrics.
+// and emission of the `vus` and `vus_max` metrics, and of the health metrics
+// of the load generator.
 func (e *Scheduler) Init(
 	runCtx context.Context, samplesOut chan<- metrics.SampleContainer,
 ) (stopVUEmission func(), initErr error) {
@@ -392,10 +530,14 @@ func (e *Scheduler) Init(
 
 	execSchedRunCtx, execSchedRunCancel := context.WithCancel(runCtx)
 	waitForVUsMetricPush := e.emitVUsAndVUsMax(execSchedRunCtx, samplesOut)
+	waitForHealthMetricPush := e.emitGeneratorHealth(execSchedRunCtx, samplesOut)
+	waitForSamplesFlush := e.startSampleBatching(samplesOut)
 	stopVUEmission = func() {
 		logger.Debugf("Stopping vus and vux_max metrics emission...")
 		execSchedRunCancel()
 		waitForVUsMetricPush()
+		waitForHealthMetricPush()
+		waitForSamplesFlush()
 	}
 
 	defer func() {
@@ -412,6 +554,35 @@ func (e *Scheduler) Init(
 	return stopVUEmission, e.initVUsAndExecutors(execSchedRunCtx, samplesOut)
 }
 
+// startSampleBatching starts flushing the buffered samples of the VUs, if the
+// batching is enabled, and emitting the backpressure metrics of their buffers
+// with the other health metrics of the load generator, if they are enabled.
+// The returned function flushes them one last time and waits for it. The
+// flushes aren't stopped by the context of the test run, since the VUs send
+// samples in their teardown even when it's interrupted.
+func (e *Scheduler) startSampleBatching(samplesOut chan<- metrics.SampleContainer) (stopAndWait func()) {
+	if !e.sampleBatching {
+		return func() {}
+	}
+	e.sampleBatcher = newSampleBatcher(samplesOut)
+	var builtinMetrics *metrics.BuiltinMetrics
+	if e.state.Test.Options.GeneratorMetrics.Bool {
+		builtinMetrics = e.state.Test.BuiltinMetrics
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		e.sampleBatcher.run(stop, builtinMetrics, e.state.Test.RunTags)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+		<-done
+	}
+}
+
 // Run the Scheduler, funneling all generated metric samples through the supplied
 // out channel.
 //
@@ -427,6 +598,11 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 			logger.Debugf("The test run was interrupted, returning '%s' instead of '%s'", interruptErr, runErr)
 			e.state.SetExecutionStatus(lib.ExecutionStatusInterrupted)
 			runErr = interruptErr
+		} else if abortErr := e.state.GetGracefulAbortReason(); abortErr != nil {
+			e.state.SetExecutionStatus(lib.ExecutionStatusInterrupted)
+			if runErr == nil {
+				runErr = abortErr
+			}
 		}
 		runErr = SignalErrorOrWait(e.controller, "scheduler-run-done", runErr)
 	}()
@@ -496,9 +672,15 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 
 	executorsRunCtx, executorsRunCancel := context.WithCancel(withExecStateCtx)
 	defer executorsRunCancel()
-	for _, exec := range e.executors {
-		go e.runExecutor(executorsRunCtx, runResults, samplesOut, exec)
+	scenarioTeardownCtx := lib.WithExecutionState(globalCtx, e.state)
+	executorCancels := make([]context.CancelFunc, len(e.executors))
+	for i, exec := range e.executors {
+		var executorRunCtx context.Context
+		executorRunCtx, executorCancels[i] = context.WithCancel(executorsRunCtx)
+		defer executorCancels[i]()
+		go e.runExecutor(executorRunCtx, scenarioTeardownCtx, runResults, samplesOut, exec)
 	}
+	go e.stopExecutorsAfterGracefulAbort(executorsRunCtx, executorCancels)
 
 	// Wait for all executors to finish
 	var firstErr error
@@ -520,6 +702,9 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 	// Run teardown() after all executors are done, if it's not disabled
 	if !e.state.Test.Options.NoTeardown.Bool {
 		e.state.SetExecutionStatus(lib.ExecutionStatusTeardown)
+		e.initProgress.Modify(pb.WithConstProgress(1, "vuTeardown()"))
+		e.teardownVUs(lib.WithExecutionState(globalCtx, e.state))
+
 		e.initProgress.Modify(pb.WithConstProgress(1, "teardown()"))
 
 		// We run teardown() with the global context, so it isn't interrupted by
@@ -544,6 +729,74 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 	return firstErr
 }
 
+// stopExecutorsAfterGracefulAbort waits for a graceful abort of the test, then
+// stops each executor once its gracefulStop has elapsed, or all of them once
+// there are no running iterations left.
+func (e *Scheduler) stopExecutorsAfterGracefulAbort(ctx context.Context, executorCancels []context.CancelFunc) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-e.state.GracefulAbortNotify():
+	}
+	e.state.Test.Logger.WithField("reason", e.state.GetGracefulAbortReason()).
+		Debug("The test was aborted gracefully, waiting for the running iterations to finish...")
+
+	for i, exec := range e.executors {
+		timer := time.AfterFunc(exec.GetConfig().GetGracefulStop(), executorCancels[i])
+		defer timer.Stop()
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if e.state.GetRunningIterationsCount() == 0 {
+				for _, cancel := range executorCancels {
+					cancel()
+				}
+				return
+			}
+		}
+	}
+}
+
+// teardownVUs concurrently calls the Teardown() method of all initialized VUs
+// that implement it. Any errors are only logged, since they are ultimately
+// script errors in a single VU, similar to the ones in normal iterations.
+func (e *Scheduler) teardownVUs(ctx context.Context) {
+	if e.waitForWarmVUs != nil {
+		e.waitForWarmVUs()
+	}
+	vus := e.state.DrainVUs()
+	logger := e.state.Test.Logger.WithField("phase", "execution-scheduler-vu-teardown")
+	logger.Debugf("Tearing down %d VUs...", len(vus))
+
+	limiter := make(chan struct{}, runtime.GOMAXPROCS(0))
+	wg := &sync.WaitGroup{}
+	for _, vu := range vus {
+		tvu, ok := vu.(lib.TeardownableVU)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		limiter <- struct{}{}
+		go func() {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+			if err := tvu.Teardown(ctx); err != nil {
+				errText, fields := errext.Format(err)
+				logger.WithFields(fields).Error(errText)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 // SetPaused pauses the test, or start/resumes it. To check if a test is paused,
 // use GetState().IsPaused().
 //
@@ -589,3 +842,23 @@ func (e *Scheduler) SetPaused(pause bool) error {
 	}
 	return e.state.Resume()
 }
+
+// SetScenarioPaused pauses or resumes only the scenario with the given name,
+// while the other scenarios of the test keep running. Unlike SetPaused(), it
+// can be used with any executor at any time, but it only stops the VUs of the
+// scenario from starting new iterations - its duration and stages aren't
+// paused.
+func (e *Scheduler) SetScenarioPaused(name string, pause bool) error {
+	exec := e.GetExecutor(name)
+	if exec == nil {
+		return fmt.Errorf("scenario '%s' doesn't exist", name)
+	}
+	pausableExecutor, ok := exec.(lib.ScenarioPausableExecutor)
+	if !ok {
+		return fmt.Errorf(
+			"%s executor '%s' doesn't support pausing and resuming its scenario",
+			exec.GetConfig().GetType(), name,
+		)
+	}
+	return pausableExecutor.SetScenarioPaused(pause)
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/url"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -804,6 +805,110 @@ func TestSchedulerSetupTeardownRun(t *testing.T) {
 	})
 }
 
+func TestSchedulerScenarioSetupTeardown(t *testing.T) {
+	t.Parallel()
+
+	script := []byte(`
+	import { Counter } from "k6/metrics";
+
+	const errors = new Counter("errors");
+	const teardowns = new Counter("teardowns");
+
+	export const options = {
+		setupTimeout: "5s",
+		teardownTimeout: "5s",
+		scenarios: {
+			with_setup: {
+				executor: "per-vu-iterations",
+				vus: 2,
+				iterations: 2,
+				exec: "withSetup",
+				setup: "scenarioSetup",
+				teardown: "scenarioTeardown",
+				setupTimeout: "2s",
+			},
+			without_setup: {
+				executor: "per-vu-iterations",
+				vus: 1,
+				iterations: 1,
+				exec: "withoutSetup",
+			},
+		},
+	};
+
+	export function setup() {
+		return { global: true };
+	}
+
+	export function scenarioSetup(data) {
+		if (!data.global) {
+			errors.add(1);
+		}
+		return { scenario: "with_setup" };
+	}
+
+	export function withSetup(data) {
+		if (data.scenario !== "with_setup" || data.global) {
+			errors.add(1);
+		}
+	}
+
+	export function withoutSetup(data) {
+		if (data.scenario !== undefined || !data.global) {
+			errors.add(1);
+		}
+	}
+
+	export function scenarioTeardown(data) {
+		if (data.scenario !== "with_setup") {
+			errors.add(1);
+		}
+		teardowns.add(1);
+	}`)
+
+	piState := getTestPreInitState(t)
+	runner, err := js.New(
+		piState, &loader.SourceData{URL: &url.URL{Path: "/script.js"}, Data: script}, nil)
+	require.NoError(t, err)
+
+	testRunState := getTestRunState(t, piState, runner.GetOptions(), runner)
+	execScheduler, err := execution.NewScheduler(testRunState, local.NewController())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	samples := make(chan metrics.SampleContainer, 100)
+	stopEmission, err := execScheduler.Init(ctx, samples)
+	require.NoError(t, err)
+
+	go func() {
+		defer close(done)
+		defer stopEmission()
+		assert.NoError(t, execScheduler.Run(ctx, ctx, samples))
+	}()
+
+	var errorsCount, teardownsCount float64
+	for {
+		select {
+		case sc := <-samples:
+			for _, s := range sc.GetSamples() {
+				switch s.Metric.Name {
+				case "errors":
+					errorsCount += s.Value
+				case "teardowns":
+					teardownsCount += s.Value
+				}
+			}
+		case <-done:
+			assert.Zero(t, errorsCount)
+			assert.Equal(t, float64(1), teardownsCount)
+			return
+		}
+	}
+}
+
 func TestSchedulerStages(t *testing.T) {
 	t.Parallel()
 	testdata := map[string]struct {
@@ -1373,3 +1478,81 @@ func TestNewSchedulerHasWork(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "doesn't support pause and resume operations after its start")
 }
+
+type fakeMetricConditionChecker func(lib.MetricCondition) bool
+
+func (f fakeMetricConditionChecker) CheckMetricCondition(cond lib.MetricCondition, _ time.Duration) (bool, error) {
+	return f(cond), nil
+}
+
+func TestSchedulerScenarioStartConditions(t *testing.T) {
+	t.Parallel()
+
+	first := executor.NewPerVUIterationsConfig("first")
+	first.VUs = null.IntFrom(1)
+	first.Iterations = null.IntFrom(1)
+	second := executor.NewPerVUIterationsConfig("second")
+	second.VUs = null.IntFrom(1)
+	second.Iterations = null.IntFrom(1)
+	second.DependsOn = []string{"first"}
+	third := executor.NewPerVUIterationsConfig("third")
+	third.VUs = null.IntFrom(1)
+	third.Iterations = null.IntFrom(1)
+	third.DependsOn = []string{"first:setup"}
+	third.StartWhen = &lib.MetricCondition{Metric: "iterations", Condition: "count>=1"}
+
+	var (
+		mx           sync.Mutex
+		started      []string
+		firstDone    int32
+		checkedConds []lib.MetricCondition
+	)
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			name := lib.GetScenarioState(ctx).Name
+			mx.Lock()
+			started = append(started, name)
+			mx.Unlock()
+			if name == "first" {
+				time.Sleep(200 * time.Millisecond)
+				atomic.StoreInt32(&firstDone, 1)
+			}
+			return nil
+		},
+		Options: lib.Options{
+			Scenarios: lib.ScenarioConfigs{"first": first, "second": second, "third": third},
+		},
+	}
+	ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{})
+	defer cancel()
+	execScheduler.SetMetricConditionChecker(fakeMetricConditionChecker(func(cond lib.MetricCondition) bool {
+		mx.Lock()
+		checkedConds = append(checkedConds, cond)
+		mx.Unlock()
+		return atomic.LoadInt32(&firstDone) == 1
+	}))
+
+	require.NoError(t, execScheduler.Run(ctx, ctx, samples))
+	mx.Lock()
+	defer mx.Unlock()
+	require.Len(t, started, 3)
+	assert.Equal(t, "first", started[0])
+	assert.ElementsMatch(t, []string{"second", "third"}, started[1:])
+	require.NotEmpty(t, checkedConds)
+	assert.Equal(t, *third.StartWhen, checkedConds[0])
+}
+
+func TestSchedulerScenarioStartConditionsWithoutChecker(t *testing.T) {
+	t.Parallel()
+
+	exec := executor.NewPerVUIterationsConfig("waiting")
+	exec.StartWhen = &lib.MetricCondition{Metric: "iterations", Condition: "count>=1"}
+	runner := &minirunner.MiniRunner{
+		Options: lib.Options{Scenarios: lib.ScenarioConfigs{exec.GetName(): exec}},
+	}
+	ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{})
+	defer cancel()
+
+	err := execScheduler.Run(ctx, ctx, samples)
+	require.ErrorContains(t, err, "the startWhen condition on metric 'iterations' can't be checked")
+}
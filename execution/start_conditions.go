@@ -0,0 +1,94 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/ui/pb"
+)
+
+// startConditionsCheckInterval is how often the startWhen metric conditions
+// of the waiting scenarios are checked.
+const startConditionsCheckInterval = time.Second
+
+// MetricConditionChecker checks conditions on the aggregated metric values of
+// the test run, which is needed for the startWhen option of the scenarios.
+type MetricConditionChecker interface {
+	CheckMetricCondition(cond lib.MetricCondition, timeSpentInTest time.Duration) (bool, error)
+}
+
+// scenarioEvents is used to notify the scenarios that depend on a scenario
+// when it has completed its setup and when it has finished.
+type scenarioEvents struct {
+	setupDoneOnce, finishedOnce sync.Once
+	setupDone, finished         chan struct{}
+}
+
+func newScenarioEvents() *scenarioEvents {
+	return &scenarioEvents{
+		setupDone: make(chan struct{}),
+		finished:  make(chan struct{}),
+	}
+}
+
+func (se *scenarioEvents) markSetupDone() {
+	se.setupDoneOnce.Do(func() { close(se.setupDone) })
+}
+
+func (se *scenarioEvents) markFinished() {
+	se.markSetupDone()
+	se.finishedOnce.Do(func() { close(se.finished) })
+}
+
+// SetMetricConditionChecker sets what is used to check the startWhen
+// conditions of the scenarios. It has to be called before Run(), if any of
+// the scenarios have such conditions.
+func (e *Scheduler) SetMetricConditionChecker(checker MetricConditionChecker) {
+	e.metricConditionChecker = checker
+}
+
+// waitForStartConditions blocks until the given conditions for the start of a
+// scenario are met. It returns false if the context was done before that.
+func (e *Scheduler) waitForStartConditions(
+	ctx context.Context, conditions lib.ScenarioStartConditions, progress *pb.ProgressBar,
+) (bool, error) {
+	for _, dep := range conditions.DependsOn {
+		events := e.scenarioEvents[dep.Scenario]
+		waitFor, event := events.finished, "to finish"
+		if dep.OnlySetup {
+			waitFor, event = events.setupDone, "setup"
+		}
+		progress.Modify(pb.WithConstProgress(0, fmt.Sprintf("waiting for %s %s", dep.Scenario, event)))
+		select {
+		case <-waitFor:
+		case <-ctx.Done():
+			return false, nil
+		}
+	}
+
+	cond := conditions.StartWhen
+	if cond == nil {
+		return true, nil
+	}
+	if e.metricConditionChecker == nil {
+		return false, fmt.Errorf("the startWhen condition on metric '%s' can't be checked, "+
+			"because the metrics aren't processed", cond.Metric)
+	}
+	progress.Modify(pb.WithConstProgress(0, fmt.Sprintf("waiting for %s %s", cond.Metric, cond.Condition)))
+	ticker := time.NewTicker(startConditionsCheckInterval)
+	defer ticker.Stop()
+	for {
+		met, err := e.metricConditionChecker.CheckMetricCondition(*cond, e.state.GetCurrentTestRunDuration())
+		if err != nil || met {
+			return met, err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false, nil
+		}
+	}
+}
@@ -25,6 +25,7 @@ type ExtensionType uint8
 const (
 	JSExtension ExtensionType = iota + 1
 	OutputExtension
+	SecretSourceExtension
 )
 
 func (e ExtensionType) String() string {
@@ -34,6 +35,8 @@ func (e ExtensionType) String() string {
 		s = "js"
 	case OutputExtension:
 		s = "output"
+	case SecretSourceExtension:
+		s = "secret-source"
 	}
 	return s
 }
@@ -100,8 +103,8 @@ func GetAll() []*Extension {
 	mx.RLock()
 	defer mx.RUnlock()
 
-	js, out := extensions[JSExtension], extensions[OutputExtension]
-	result := make([]*Extension, 0, len(js)+len(out))
+	js, out, secrets := extensions[JSExtension], extensions[OutputExtension], extensions[SecretSourceExtension]
+	result := make([]*Extension, 0, len(js)+len(out)+len(secrets))
 
 	for _, e := range js {
 		result = append(result, e)
@@ -109,6 +112,9 @@ func GetAll() []*Extension {
 	for _, e := range out {
 		result = append(result, e)
 	}
+	for _, e := range secrets {
+		result = append(result, e)
+	}
 
 	sort.Slice(result, func(i, j int) bool {
 		if result[i].Path == result[j].Path {
@@ -157,4 +163,5 @@ func extractModuleInfo(mod interface{}) (path, version string) {
 func init() {
 	extensions[JSExtension] = make(map[string]*Extension)
 	extensions[OutputExtension] = make(map[string]*Extension)
+	extensions[SecretSourceExtension] = make(map[string]*Extension)
 }
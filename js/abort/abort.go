@@ -0,0 +1,454 @@
+// Package abort implements the AbortController and AbortSignal of the web,
+// which are shared by all the modules, so the same signal can cancel any of
+// their in-flight operations, e.g. the HTTP requests, the gRPC calls and the
+// WebSocket connections of an iteration.
+//
+// The modules accept the signals with the signal param of their operations,
+// get them with FromValue and cancel the operations when their Done channel
+// is closed, usually with the context returned by Context. When an operation
+// was aborted, they throw or reject with the reason of the signal.
+package abort
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+// classesSymbol is the key of the classes of a runtime in its global object,
+// so they are created only once for every VU.
+var classesSymbol = goja.NewSymbol("k6 abort classes") //nolint:gochecknoglobals
+
+// Classes are the AbortController and AbortSignal classes of a VU.
+type Classes struct {
+	AbortController *goja.Object
+	AbortSignal     *goja.Object
+
+	vu          modules.VU
+	signalProto *goja.Object
+	// signalSymbol is the key of the Signal in the AbortSignal objects
+	signalSymbol *goja.Symbol
+}
+
+// Signal is the state of an AbortSignal object. Done and Context can be used
+// from any goroutine, the rest of its methods only on the event loop.
+type Signal struct {
+	classes *Classes
+	obj     *goja.Object
+
+	// used only on the event loop
+	aborted   bool
+	reason    goja.Value
+	listeners []goja.Value
+	onabort   goja.Value
+	watching  bool
+
+	done       chan struct{}
+	mx         sync.Mutex
+	cause      *Signal
+	dependents []*Signal
+	timeout    bool
+}
+
+// Install defines the AbortController and AbortSignal globals, unless they
+// are already defined.
+func Install(vu modules.VU) error {
+	c := ClassesFor(vu)
+	global := vu.Runtime().GlobalObject()
+	for name, value := range map[string]*goja.Object{
+		"AbortController": c.AbortController,
+		"AbortSignal":     c.AbortSignal,
+	} {
+		if !common.IsNullish(global.Get(name)) {
+			continue
+		}
+		if err := global.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClassesFor returns the classes of the VU, creating them the first time.
+func ClassesFor(vu modules.VU) *Classes {
+	rt := vu.Runtime()
+	global := rt.GlobalObject()
+	if v := global.GetSymbol(classesSymbol); v != nil {
+		if c, ok := v.Export().(*Classes); ok {
+			return c
+		}
+	}
+
+	c := &Classes{vu: vu, signalSymbol: goja.NewSymbol("AbortSignal")}
+	c.AbortSignal = rt.ToValue(func(goja.ConstructorCall) *goja.Object {
+		panic(rt.NewTypeError("Illegal constructor"))
+	}).ToObject(rt)
+	c.signalProto = c.newPrototype(c.AbortSignal)
+	c.defineSignal()
+
+	c.AbortController = rt.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		s := c.newSignal()
+		must(rt, call.This.DefineDataProperty("signal", s.obj, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE))
+		return nil
+	}).ToObject(rt)
+	controllerProto := c.newPrototype(c.AbortController)
+	must(rt, controllerProto.Set("abort", func(call goja.FunctionCall) goja.Value {
+		s, err := c.Signal(call.This.ToObject(rt).Get("signal"))
+		if err != nil || s == nil {
+			panic(rt.NewTypeError("abort() must be called on an AbortController"))
+		}
+		s.Abort(call.Argument(0))
+		return goja.Undefined()
+	}))
+
+	must(rt, global.DefineDataPropertySymbol(
+		classesSymbol, rt.ToValue(c), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE))
+	return c
+}
+
+// FromValue returns the Signal of the AbortSignal value, or nil if the value
+// is null or undefined.
+func FromValue(vu modules.VU, v goja.Value) (*Signal, error) {
+	return ClassesFor(vu).Signal(v)
+}
+
+// Signal returns the Signal of the AbortSignal value, or nil if the value is
+// null or undefined.
+func (c *Classes) Signal(v goja.Value) (*Signal, error) {
+	if common.IsNullish(v) {
+		return nil, nil //nolint:nilnil
+	}
+	obj, ok := v.(*goja.Object)
+	if ok {
+		if sv := obj.GetSymbol(c.signalSymbol); sv != nil {
+			if s, ok := sv.Export().(*Signal); ok {
+				return s, nil
+			}
+		}
+	}
+	return nil, errors.New("the signal must be an AbortSignal")
+}
+
+func (c *Classes) newPrototype(ctor *goja.Object) *goja.Object {
+	rt := c.vu.Runtime()
+	proto := rt.NewObject()
+	must(rt, proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE))
+	must(rt, ctor.DefineDataProperty("prototype", proto, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE))
+	return proto
+}
+
+func (c *Classes) newSignal() *Signal {
+	rt := c.vu.Runtime()
+	s := &Signal{classes: c, obj: rt.CreateObject(c.signalProto), done: make(chan struct{})}
+	must(rt, s.obj.DefineDataPropertySymbol(
+		c.signalSymbol, rt.ToValue(s), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE))
+	return s
+}
+
+//nolint:funlen
+func (c *Classes) defineSignal() {
+	rt := c.vu.Runtime()
+	this := func(call goja.FunctionCall) *Signal {
+		s, err := c.Signal(call.This)
+		if err != nil || s == nil {
+			panic(rt.NewTypeError("the method must be called on an AbortSignal"))
+		}
+		return s
+	}
+	getter := func(fn func(*Signal) goja.Value) goja.Value {
+		return rt.ToValue(func(call goja.FunctionCall) goja.Value { return fn(this(call)) })
+	}
+
+	proto := c.signalProto
+	must(rt, proto.DefineAccessorProperty("aborted", getter(func(s *Signal) goja.Value {
+		return rt.ToValue(s.Aborted())
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE))
+	must(rt, proto.DefineAccessorProperty("reason", getter(func(s *Signal) goja.Value {
+		return s.Reason()
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE))
+	must(rt, proto.DefineAccessorProperty("onabort", getter(func(s *Signal) goja.Value {
+		if s.onabort == nil {
+			return goja.Null()
+		}
+		return s.onabort
+	}), rt.ToValue(func(call goja.FunctionCall) goja.Value {
+		s := this(call)
+		s.onabort = nil
+		if _, ok := goja.AssertFunction(call.Argument(0)); ok {
+			s.onabort = call.Argument(0)
+			s.watch()
+		}
+		return goja.Undefined()
+	}), goja.FLAG_FALSE, goja.FLAG_TRUE))
+
+	for name, method := range map[string]func(goja.FunctionCall) goja.Value{
+		"throwIfAborted": func(call goja.FunctionCall) goja.Value {
+			if s := this(call); s.Aborted() {
+				panic(s.Reason())
+			}
+			return goja.Undefined()
+		},
+		"addEventListener": func(call goja.FunctionCall) goja.Value {
+			s, listener := this(call), call.Argument(1)
+			if _, ok := goja.AssertFunction(listener); !ok || call.Argument(0).String() != "abort" {
+				return goja.Undefined()
+			}
+			for _, l := range s.listeners {
+				if l.SameAs(listener) {
+					return goja.Undefined()
+				}
+			}
+			s.listeners = append(s.listeners, listener)
+			s.watch()
+			return goja.Undefined()
+		},
+		"removeEventListener": func(call goja.FunctionCall) goja.Value {
+			s, listener := this(call), call.Argument(1)
+			if call.Argument(0).String() != "abort" {
+				return goja.Undefined()
+			}
+			for i, l := range s.listeners {
+				if l.SameAs(listener) {
+					s.listeners = append(s.listeners[:i:i], s.listeners[i+1:]...)
+					break
+				}
+			}
+			return goja.Undefined()
+		},
+	} {
+		must(rt, proto.Set(name, method))
+	}
+
+	for name, method := range map[string]interface{}{
+		"abort": func(reason goja.Value) *goja.Object {
+			s := c.newSignal()
+			s.Abort(reason)
+			return s.obj
+		},
+		"timeout": func(ms int64) *goja.Object {
+			if ms < 0 {
+				panic(rt.NewTypeError("the timeout must not be negative"))
+			}
+			s := c.newSignal()
+			s.timeout = true
+			time.AfterFunc(time.Duration(ms)*time.Millisecond, func() { s.close(s) })
+			return s.obj
+		},
+		"any": func(signals []goja.Value) *goja.Object {
+			s := c.newSignal()
+			for _, v := range signals {
+				source, err := c.Signal(v)
+				if err != nil || source == nil {
+					panic(rt.NewTypeError("AbortSignal.any() accepts only AbortSignals"))
+				}
+				if source.Aborted() {
+					s.Abort(source.Reason())
+					break
+				}
+				source.addDependent(s)
+			}
+			return s.obj
+		},
+	} {
+		must(rt, c.AbortSignal.Set(name, method))
+	}
+}
+
+// Object returns the AbortSignal object of the signal.
+func (s *Signal) Object() *goja.Object {
+	return s.obj
+}
+
+// Done returns a channel, which is closed when the signal is aborted. It's
+// nil for a nil signal, so it's never closed.
+func (s *Signal) Done() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.done
+}
+
+// Context returns a copy of the context, which is also canceled when the
+// signal is aborted.
+func (s *Signal) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if s == nil {
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Aborted returns whether the signal was aborted.
+func (s *Signal) Aborted() bool {
+	if s == nil {
+		return false
+	}
+	s.sync()
+	return s.aborted
+}
+
+// Reason returns the reason the signal was aborted with, or undefined if it
+// wasn't aborted.
+func (s *Signal) Reason() goja.Value {
+	if s == nil {
+		return goja.Undefined()
+	}
+	s.sync()
+	if s.reason == nil {
+		return goja.Undefined()
+	}
+	return s.reason
+}
+
+// Abort aborts the signal with the reason, or with an AbortError if the
+// reason is undefined, and runs its listeners.
+func (s *Signal) Abort(reason goja.Value) {
+	if s.aborted {
+		return
+	}
+	rt := s.classes.vu.Runtime()
+	if reason == nil || goja.IsUndefined(reason) {
+		reason = newDOMException(rt, "AbortError", "This operation was aborted")
+	}
+	s.aborted = true
+	s.reason = reason
+	s.close(s)
+
+	event := rt.NewObject()
+	must(rt, event.Set("type", "abort"))
+	must(rt, event.Set("target", s.obj))
+	listeners := s.listeners
+	s.listeners = nil
+	if s.onabort != nil {
+		listeners = append([]goja.Value{s.onabort}, listeners...)
+	}
+	for _, listener := range listeners {
+		fn, _ := goja.AssertFunction(listener)
+		if _, err := fn(s.obj, event); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+
+	s.mx.Lock()
+	dependents := s.dependents
+	s.dependents = nil
+	s.mx.Unlock()
+	for _, d := range dependents {
+		d.Abort(reason)
+	}
+}
+
+// sync aborts the signal on the event loop, if it was closed off the event
+// loop, i.e. because it or one of the signals it depends on timed out.
+func (s *Signal) sync() {
+	if s.aborted {
+		return
+	}
+	select {
+	case <-s.done:
+	default:
+		return
+	}
+	s.mx.Lock()
+	cause := s.cause
+	s.mx.Unlock()
+
+	if cause == s {
+		s.Abort(newDOMException(s.classes.vu.Runtime(), "TimeoutError", "The operation timed out"))
+		return
+	}
+	cause.sync()
+	s.Abort(cause.Reason())
+}
+
+// close closes the done channel of the signal and the signals that depend on
+// it, with the signal that caused it. It can be called from any goroutine.
+func (s *Signal) close(cause *Signal) {
+	s.mx.Lock()
+	if s.cause != nil {
+		s.mx.Unlock()
+		return
+	}
+	s.cause = cause
+	close(s.done)
+	dependents := s.dependents
+	s.mx.Unlock()
+
+	for _, d := range dependents {
+		d.close(cause)
+	}
+}
+
+// addDependent makes the signal abort the dependent signal when it's aborted.
+func (s *Signal) addDependent(d *Signal) {
+	s.mx.Lock()
+	cause, timeout := s.cause, s.timeout
+	if cause == nil {
+		s.dependents = append(s.dependents, d)
+	}
+	s.mx.Unlock()
+
+	if timeout {
+		d.mx.Lock()
+		d.timeout = true
+		d.mx.Unlock()
+	}
+	if cause != nil { // it timed out in the meantime
+		d.close(cause)
+	}
+}
+
+// watch makes sure the listeners of a signal, which can time out, run when it
+// does. This holds the event loop until then, as the listeners can't run
+// after the iteration.
+func (s *Signal) watch() {
+	s.mx.Lock()
+	timeout := s.timeout
+	s.mx.Unlock()
+	if !timeout || s.watching || s.Aborted() {
+		return
+	}
+	s.watching = true
+
+	vu := s.classes.vu
+	enqueueCallback := vu.RegisterCallback()
+	ctx := vu.Context()
+	go func() {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+		}
+		enqueueCallback(func() error {
+			s.watching = false
+			s.sync()
+			return nil
+		})
+	}()
+}
+
+// newDOMException returns an error with the name, as the DOMExceptions of the
+// web, which are not available in the runtime.
+func newDOMException(rt *goja.Runtime, name, message string) goja.Value {
+	err := rt.NewGoError(errors.New(message))
+	must(rt, err.Set("name", name))
+	return err
+}
+
+func must(rt *goja.Runtime, err error) {
+	if err != nil {
+		common.Throw(rt, err)
+	}
+}
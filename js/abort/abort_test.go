@@ -0,0 +1,142 @@
+package abort
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+)
+
+func newRuntime(t testing.TB) *modulestest.Runtime {
+	t.Helper()
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, Install(runtime.VU))
+	return runtime
+}
+
+func TestAbortController(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	_, err := runtime.RunOnEventLoop(`
+		const controller = new AbortController();
+		const signal = controller.signal;
+		if (!(signal instanceof AbortSignal) || signal.aborted || signal.reason !== undefined) {
+			throw new Error("unexpected new signal");
+		}
+
+		const events = [];
+		const listener = (e) => events.push("listener " + e.type);
+		signal.onabort = (e) => events.push("onabort " + (e.target === signal));
+		signal.addEventListener("abort", listener);
+		signal.addEventListener("abort", listener);
+		signal.addEventListener("abort", () => events.push("other"));
+		signal.removeEventListener("abort", () => {});
+		controller.abort();
+		controller.abort("again");
+
+		if (!signal.aborted || signal.reason.name !== "AbortError") {
+			throw new Error("unexpected aborted signal " + signal.reason);
+		}
+		if (JSON.stringify(events) !== '["onabort true","listener abort","other"]') {
+			throw new Error("unexpected events " + JSON.stringify(events));
+		}
+		try {
+			signal.throwIfAborted();
+			throw new Error("it should throw");
+		} catch (e) {
+			if (e !== signal.reason) throw e;
+		}
+		try {
+			new AbortSignal();
+			throw new Error("it should throw");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+	`)
+	require.NoError(t, err)
+}
+
+func TestAbortSignalStatics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("abort and any", func(t *testing.T) {
+		t.Parallel()
+		runtime := newRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(`
+			const aborted = AbortSignal.abort("reason");
+			if (!aborted.aborted || aborted.reason !== "reason") {
+				throw new Error("unexpected aborted signal");
+			}
+
+			const controller = new AbortController();
+			const any = AbortSignal.any([new AbortController().signal, controller.signal]);
+			if (any.aborted) {
+				throw new Error("it shouldn't be aborted yet");
+			}
+			controller.abort("any");
+			if (!any.aborted || any.reason !== "any") {
+				throw new Error("unexpected any signal " + any.reason);
+			}
+			if (AbortSignal.any([aborted]).reason !== "reason") {
+				throw new Error("unexpected any signal of an aborted signal");
+			}
+		`)
+		require.NoError(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		runtime := newRuntime(t)
+
+		start := time.Now()
+		_, err := runtime.RunOnEventLoop(`
+			var events = [];
+			const signal = AbortSignal.timeout(50);
+			const any = AbortSignal.any([new AbortController().signal, signal]);
+			any.addEventListener("abort", () => events.push(any.reason.name));
+			if (signal.aborted) {
+				throw new Error("it shouldn't be aborted yet");
+			}
+		`)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		assert.Equal(t, []any{"TimeoutError"}, runtime.VU.Runtime().Get("events").Export())
+	})
+}
+
+func TestSignalContext(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+	rt := runtime.VU.Runtime()
+
+	v, err := rt.RunString(`var controller = new AbortController(); controller.signal`)
+	require.NoError(t, err)
+	signal, err := FromValue(runtime.VU, v)
+	require.NoError(t, err)
+
+	ctx, cancel := signal.Context(context.Background())
+	defer cancel()
+	_, err = rt.RunString(`controller.abort()`)
+	require.NoError(t, err)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the context should be canceled")
+	}
+
+	signal, err = FromValue(runtime.VU, goja.Undefined())
+	require.NoError(t, err)
+	assert.Nil(t, signal)
+	assert.False(t, signal.Aborted())
+
+	_, err = FromValue(runtime.VU, rt.ToValue(map[string]any{"aborted": true}))
+	assert.ErrorContains(t, err, "the signal must be an AbortSignal")
+}
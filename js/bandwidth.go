@@ -0,0 +1,57 @@
+package js
+
+import (
+	"context"
+	"sync"
+
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/types"
+)
+
+// bandwidthLimiters keeps the limiters of the bandwidth of the scenarios,
+// created the first time a connection of each scenario is dialed.
+type bandwidthLimiters struct {
+	mx         sync.Mutex
+	byScenario map[string]*netext.BandwidthLimiter
+}
+
+func (bl *bandwidthLimiters) get(scenario string, bandwidth types.Bandwidth) *netext.BandwidthLimiter {
+	bl.mx.Lock()
+	defer bl.mx.Unlock()
+	if bl.byScenario == nil {
+		bl.byScenario = make(map[string]*netext.BandwidthLimiter)
+	}
+	limiter, ok := bl.byScenario[scenario]
+	if !ok {
+		limiter = netext.NewBandwidthLimiter(bandwidth)
+		bl.byScenario[scenario] = limiter
+	}
+	return limiter
+}
+
+// bandwidthLimitersFor returns the function giving the limiters of the
+// connections of a VU, whose own limiters are vuLimiters. A connection is
+// capped by the bandwidth of the VU in its scenario, and by the bandwidth of
+// all VUs of the scenario, which is shared through the runner.
+func (r *Runner) bandwidthLimitersFor(vuLimiters *bandwidthLimiters) func(context.Context) []*netext.BandwidthLimiter {
+	return func(ctx context.Context) []*netext.BandwidthLimiter {
+		scenario := scenarioName(ctx)
+		vuBandwidth := r.Bundle.Options.VUBandwidth
+		var bandwidth *types.Bandwidth
+		if config, ok := r.Bundle.Options.Scenarios[scenario]; ok {
+			if b := config.GetVUBandwidth(); b != nil {
+				vuBandwidth = b
+			}
+			bandwidth = config.GetBandwidth()
+		}
+
+		var limiters []*netext.BandwidthLimiter
+		if vuBandwidth != nil {
+			limiters = append(limiters, vuLimiters.get(scenario, *vuBandwidth))
+		}
+		if bandwidth != nil {
+			limiters = append(limiters, r.scenarioBandwidth.get(scenario, *bandwidth))
+		}
+		return limiters
+	}
+}
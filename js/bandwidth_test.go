@@ -0,0 +1,45 @@
+package js
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestBandwidthLimitersFor(t *testing.T) {
+	t.Parallel()
+
+	capped := executor.NewConstantVUsConfig("capped")
+	capped.VUBandwidth = &types.Bandwidth{Ingress: 1000}
+	capped.Bandwidth = &types.Bandwidth{Ingress: 10000}
+	r := &Runner{Bundle: &Bundle{Options: lib.Options{
+		VUBandwidth: &types.Bandwidth{Egress: 500},
+		Scenarios: lib.ScenarioConfigs{
+			"capped":  capped,
+			"default": executor.NewConstantVUsConfig("default"),
+		},
+	}}}
+	inScenario := func(name string) context.Context {
+		return lib.WithScenarioState(context.Background(), &lib.ScenarioState{Name: name})
+	}
+
+	vu1, vu2 := r.bandwidthLimitersFor(&bandwidthLimiters{}), r.bandwidthLimitersFor(&bandwidthLimiters{})
+
+	// only the global bandwidth of the VUs applies
+	defaultLimiters := vu1(inScenario("default"))
+	assert.Len(t, defaultLimiters, 1)
+	assert.Equal(t, defaultLimiters, vu1(inScenario("default")), "the limiter of the VU should be reused")
+	assert.NotSame(t, defaultLimiters[0], vu2(inScenario("default"))[0], "the VUs should have their own limiters")
+
+	// the scenario overrides the bandwidth of the VUs and caps all of them
+	capped1, capped2 := vu1(inScenario("capped")), vu2(inScenario("capped"))
+	assert.Len(t, capped1, 2)
+	assert.NotSame(t, defaultLimiters[0], capped1[0])
+	assert.NotSame(t, capped1[0], capped2[0])
+	assert.Same(t, capped1[1], capped2[1], "the limiter of the scenario should be shared by its VUs")
+}
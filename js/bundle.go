@@ -15,6 +15,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/event"
+	"go.k6.io/k6/js/abort"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/compiler"
 	"go.k6.io/k6/js/eventloop"
@@ -32,6 +33,7 @@ type Bundle struct {
 	Options    lib.Options
 
 	CompatibilityMode lib.CompatibilityMode // parsed value
+	ModuleResolution  lib.ModuleResolution  // parsed value
 	preInitState      *lib.TestPreInitState
 
 	filesystems map[string]fsext.Fs
@@ -77,12 +79,17 @@ func newBundle(
 	if err != nil {
 		return nil, err
 	}
+	moduleResolution, err := lib.ValidateModuleResolution(piState.RuntimeOptions.ModuleResolution.String)
+	if err != nil {
+		return nil, err
+	}
 
 	// Make a bundle, instantiate it into a throwaway VM to populate caches.
 	bundle := &Bundle{
 		sourceData:        src,
 		Options:           options,
 		CompatibilityMode: compatMode,
+		ModuleResolution:  moduleResolution,
 		callableExports:   make(map[string]struct{}),
 		filesystems:       filesystems,
 		pwd:               loader.Dir(src.URL),
@@ -90,6 +97,9 @@ func newBundle(
 	}
 	c := bundle.newCompiler(piState.Logger)
 	bundle.ModuleResolver = modules.NewModuleResolver(getJSModules(), generateFileLoad(bundle), c)
+	if moduleResolution == lib.ModuleResolutionNode {
+		bundle.ModuleResolver.UseNodeModules(filesystems["file"])
+	}
 
 	// Instantiate the bundle into a new VM using a bound init context. This uses a context with a
 	// runtime, but no state, to allow module-provided types to function within the init context.
@@ -127,6 +137,10 @@ func NewBundleFromArchive(piState *lib.TestPreInitState, arc *lib.Archive) (*Bun
 		// whatever value is in the archive
 		piState.RuntimeOptions.CompatibilityMode = null.StringFrom(arc.CompatibilityMode)
 	}
+	if !piState.RuntimeOptions.ModuleResolution.Valid && arc.ModuleResolution != "" {
+		// the modules from node_modules are resolved from the files in the archive
+		piState.RuntimeOptions.ModuleResolution = null.StringFrom(arc.ModuleResolution)
+	}
 	env := arc.Env
 	if env == nil {
 		// Older archives (<=0.20.0) don't have an "env" property
@@ -153,6 +167,7 @@ func (b *Bundle) makeArchive() *lib.Archive {
 		PwdURL:            b.pwd,
 		Env:               make(map[string]string, len(b.preInitState.RuntimeOptions.Env)),
 		CompatibilityMode: b.CompatibilityMode.String(),
+		ModuleResolution:  string(b.ModuleResolution),
 		K6Version:         consts.Version,
 		Goos:              runtime.GOOS,
 	}
@@ -193,6 +208,10 @@ func (b *Bundle) populateExports(updateOptions bool, exports *goja.Object) error
 			return errors.New("exported 'setup' must be a function")
 		case consts.TeardownFn:
 			return errors.New("exported 'teardown' must be a function")
+		case consts.VUSetupFn:
+			return errors.New("exported 'vuSetup' must be a function")
+		case consts.VUTeardownFn:
+			return errors.New("exported 'vuTeardown' must be a function")
 		}
 	}
 
@@ -262,12 +281,33 @@ func (b *Bundle) newCompiler(logger logrus.FieldLogger) *compiler.Compiler {
 	return c
 }
 
+// exportsPromise returns the promise for the exports of the main module, if
+// it's returned instead of them because of a top-level await in it.
+func exportsPromise(exportsV goja.Value) *goja.Promise {
+	obj, ok := exportsV.(*goja.Object)
+	if !ok || obj == nil {
+		return nil
+	}
+	p, _ := obj.Export().(*goja.Promise)
+	return p
+}
+
 func (b *Bundle) instantiate(vuImpl *moduleVUImpl, vuID uint64) (*goja.Object, error) {
 	rt := vuImpl.runtime
 	err := b.setupJSRuntime(rt, int64(vuID), b.preInitState.Logger)
 	if err != nil {
 		return nil, err
 	}
+	wasm, err := newWebAssembly(vuImpl)
+	if err != nil {
+		return nil, err
+	}
+	if err = rt.Set("WebAssembly", wasm); err != nil {
+		return nil, err
+	}
+	if err = abort.Install(vuImpl); err != nil {
+		return nil, err
+	}
 
 	initenv := &common.InitEnvironment{
 		TestPreInitState: b.preInitState,
@@ -314,6 +354,18 @@ func (b *Bundle) instantiate(vuImpl *moduleVUImpl, vuID uint64) (*goja.Object, e
 		}
 		return nil, err
 	}
+	if p := exportsPromise(exportsV); p != nil {
+		// the script uses top-level await, which should have finished with the event loop
+		switch p.State() {
+		case goja.PromiseStateFulfilled:
+			exportsV = p.Result()
+		case goja.PromiseStateRejected:
+			return nil, fmt.Errorf("the evaluation of the script failed: %s", p.Result())
+		default:
+			return nil, errors.New("the top-level await of the script never finished, " +
+				"as it waits for a promise that was never resolved")
+		}
+	}
 	if common.IsNullish(exportsV) {
 		return nil, errors.New("exports must not be set to null or undefined")
 	}
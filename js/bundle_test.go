@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -789,6 +790,35 @@ func TestBundleInstantiate(t *testing.T) {
 		require.Equal(t, int64(10), vus)
 		b.Options.VUs = optOrig
 	})
+
+	t.Run("ConcurrentRequire", func(t *testing.T) {
+		t.Parallel()
+		fs := fsext.NewMemMapFs()
+		require.NoError(t, fsext.WriteFile(fs, "/lib.js", []byte(`exports.value = __VU;`), 0o644))
+		// the module is only required by the VUs, not by the throwaway VU of the bundle
+		b, err := getSimpleBundle(t, "/script.js", `
+			const value = __VU > 0 ? require("./lib.js").value : 0;
+			export default function() { return value; }
+		`, fs)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for vuID := uint64(1); vuID <= 10; vuID++ {
+			wg.Add(1)
+			go func(vuID uint64) {
+				defer wg.Done()
+				bi, err := b.Instantiate(context.Background(), vuID)
+				if !assert.NoError(t, err) {
+					return
+				}
+				v, err := bi.getCallableExport(consts.DefaultFn)(goja.Undefined())
+				assert.NoError(t, err)
+				assert.EqualValues(t, vuID, v.Export())
+			}(vuID)
+		}
+		wg.Wait()
+		assert.Contains(t, b.ModuleResolver.Imported(), "file:///lib.js")
+	})
 }
 
 func TestBundleEnv(t *testing.T) {
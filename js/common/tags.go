@@ -37,10 +37,15 @@ func ApplyCustomUserTag(tagsAndMeta *metrics.TagsAndMeta, key string, val goja.V
 	}
 
 	switch kind {
+	case reflect.Int64:
+		// integers like the status codes are common tag values and reuse the
+		// interned strings instead of allocating one for every sample
+		tagsAndMeta.SetTag(key, metrics.IntTagValue(int(val.ToInteger())))
+		return nil
+
 	case
 		reflect.String,
 		reflect.Bool,
-		reflect.Int64,
 		reflect.Float64:
 
 		tagsAndMeta.SetTag(key, val.String())
@@ -55,6 +60,26 @@ func ApplyCustomUserTag(tagsAndMeta *metrics.TagsAndMeta, key string, val goja.V
 	}
 }
 
+// ApplyCustomUserMetadataMap modifies the given metrics.TagsAndMeta object
+// with the user specified custom metadata.
+// It expects to receive the `keyValues` object in the `{key1: value1, key2:
+// value2, ...}` format.
+func ApplyCustomUserMetadataMap(rt *goja.Runtime, tagsAndMeta *metrics.TagsAndMeta, keyValues goja.Value) error {
+	if keyValues == nil || goja.IsNull(keyValues) || goja.IsUndefined(keyValues) {
+		return nil
+	}
+
+	keyValuesObj := keyValues.ToObject(rt)
+
+	for _, key := range keyValuesObj.Keys() {
+		if err := ApplyCustomUserMetadata(tagsAndMeta, key, keyValuesObj.Get(key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ApplyCustomUserMetadata modifies the given metrics.TagsAndMeta object with the
 // given custom metadata and their value.
 func ApplyCustomUserMetadata(tagsAndMeta *metrics.TagsAndMeta, key string, val goja.Value) error {
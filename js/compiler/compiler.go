@@ -1,5 +1,7 @@
 // Package compiler implements additional functionality for k6 to compile js code.
-// more specifically transpiling through babel in case that is needed.
+// more specifically transpiling through babel in case that is needed, and
+// transforming TypeScript files and ES modules with top-level await or dynamic
+// imports with esbuild.
 package compiler
 
 import (
@@ -14,6 +16,7 @@ import (
 
 	"github.com/dop251/goja"
 	"github.com/dop251/goja/parser"
+	"github.com/evanw/esbuild/pkg/api"
 	"github.com/go-sourcemap/sourcemap"
 	"github.com/sirupsen/logrus"
 
@@ -106,6 +109,15 @@ func (c *Compiler) initializeBabel() error {
 
 // Transform the given code into ES5
 func (c *Compiler) Transform(src, filename string, inputSrcMap []byte) (code string, srcMap []byte, err error) {
+	code, srcMap, _, err = c.transform(src, filename, inputSrcMap)
+	return code, srcMap, err
+}
+
+// transform is the same as Transform, but it also returns the specifiers of
+// the static imports (and re-exports) of the transformed ES module.
+func (c *Compiler) transform(
+	src, filename string, inputSrcMap []byte,
+) (code string, srcMap []byte, imports []string, err error) {
 	if c.babel == nil {
 		onceBabel.Do(func() {
 			globalBabel, err = newBabel()
@@ -113,7 +125,7 @@ func (c *Compiler) Transform(src, filename string, inputSrcMap []byte) (code str
 		c.babel = globalBabel
 	}
 	if err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
 
 	sourceMapEnabled := c.Options.SourceMapLoader != nil
@@ -150,6 +162,22 @@ func (c *Compiler) Transform(src, filename string, inputSrcMap []byte) (code str
 	return c.babel.transformImpl(c.logger, src, filename, sourceMapEnabled, inputSrcMap)
 }
 
+// ModuleInfo is the information about a module that is needed for evaluating
+// it with the semantics of ES modules, after it was transformed into CommonJS.
+type ModuleInfo struct {
+	// Async is set when the module uses top-level await, so it's wrapped in an
+	// async function and evaluating it returns a promise.
+	Async bool
+	// Imports are the specifiers of the static imports of the module, in the
+	// order in which they are required by it.
+	Imports []string
+
+	// scopedRequire is set for the modules that were transformed by esbuild,
+	// which take their own require() function as an argument, as the dynamic
+	// imports in them are resolved after the module is evaluated.
+	scopedRequire bool
+}
+
 // Options are options to the compiler
 type Options struct {
 	CompatibilityMode lib.CompatibilityMode
@@ -172,7 +200,29 @@ type compilationState struct {
 // Compile the program in the given CompatibilityMode, wrapping it between pre and post code
 // TODO isESM will be used once goja support ESM modules natively
 func (c *Compiler) Compile(src, filename string, isESM bool) (*goja.Program, string, error) {
-	return c.compileImpl(src, filename, !isESM, c.Options.CompatibilityMode, nil)
+	return c.compile(src, filename, !isESM, &ModuleInfo{})
+}
+
+// CompileModule compiles the given module in the same way as Compile, and also
+// returns the information that is needed for evaluating it as an ES module.
+func (c *Compiler) CompileModule(src, filename string) (*goja.Program, ModuleInfo, error) {
+	info := ModuleInfo{}
+	pgm, _, err := c.compile(src, filename, true, &info)
+	return pgm, info, err
+}
+
+func (c *Compiler) compile(src, filename string, wrap bool, info *ModuleInfo) (*goja.Program, string, error) {
+	if isTypeScript(filename) {
+		code, srcMap, tsInfo, err := transformModule(src, filename, api.LoaderTS, c.Options.SourceMapLoader != nil)
+		if err != nil {
+			return nil, src, err
+		}
+		*info = tsInfo
+		info.scopedRequire = true
+		// the transformed code doesn't need babel, so the base compatibility mode is used
+		return c.compileImpl(code, filename, wrap, lib.CompatibilityModeBase, srcMap, info)
+	}
+	return c.compileImpl(src, filename, wrap, c.Options.CompatibilityMode, nil, info)
 }
 
 // sourceMapLoader is to be used with goja's WithSourceMapLoader
@@ -203,12 +253,19 @@ func (c *compilationState) sourceMapLoader(path string) ([]byte, error) {
 }
 
 func (c *Compiler) compileImpl(
-	src, filename string, wrap bool, compatibilityMode lib.CompatibilityMode, srcMap []byte,
+	src, filename string, wrap bool, compatibilityMode lib.CompatibilityMode, srcMap []byte, info *ModuleInfo,
 ) (*goja.Program, string, error) {
 	code := src
 	state := compilationState{srcMap: srcMap, compiler: c, wrapped: wrap}
 	if wrap { // the lines in the sourcemap (if available) will be fixed by increaseMappingsByOne
-		code = "(function(module, exports){\n" + code + "\n})\n"
+		switch {
+		case info.Async:
+			code = "(async function(module, exports, require){\n" + code + "\n})\n"
+		case info.scopedRequire:
+			code = "(function(module, exports, require){\n" + code + "\n})\n"
+		default:
+			code = "(function(module, exports){\n" + code + "\n})\n"
+		}
 	}
 	opts := parser.WithDisableSourceMaps
 	if c.Options.SourceMapLoader != nil {
@@ -225,12 +282,7 @@ func (c *Compiler) compileImpl(
 	}
 	if err != nil {
 		if compatibilityMode == lib.CompatibilityModeExtended {
-			code, state.srcMap, err = c.Transform(src, filename, state.srcMap)
-			if err != nil {
-				return nil, code, err
-			}
-			// the compatibility mode "decreases" here as we shouldn't transform twice
-			return c.compileImpl(code, filename, wrap, lib.CompatibilityModeBase, state.srcMap)
+			return c.compileTransformed(src, filename, wrap, state.srcMap, info)
 		}
 		return nil, code, err
 	}
@@ -238,6 +290,28 @@ func (c *Compiler) compileImpl(
 	return pgm, code, err
 }
 
+// compileTransformed compiles the given code after it's transformed by babel.
+// The code that babel doesn't support, e.g. with top-level await or dynamic
+// imports, is transformed by esbuild.
+func (c *Compiler) compileTransformed(
+	src, filename string, wrap bool, srcMap []byte, info *ModuleInfo,
+) (*goja.Program, string, error) {
+	code, transformedSrcMap, imports, err := c.transform(src, filename, srcMap)
+	if err != nil {
+		esbuildCode, esbuildSrcMap, esbuildInfo, esbuildErr := transformModule(
+			src, filename, api.LoaderJS, c.Options.SourceMapLoader != nil)
+		if esbuildErr != nil {
+			return nil, code, err // the babel errors are returned, as they are more detailed
+		}
+		*info = esbuildInfo
+		info.scopedRequire = true
+		return c.compileImpl(esbuildCode, filename, wrap, lib.CompatibilityModeBase, esbuildSrcMap, info)
+	}
+	info.Imports = imports
+	// the compatibility mode "decreases" here as we shouldn't transform twice
+	return c.compileImpl(code, filename, wrap, lib.CompatibilityModeBase, transformedSrcMap, info)
+}
+
 type babel struct {
 	vm        *goja.Runtime
 	this      goja.Value
@@ -304,7 +378,7 @@ func (c *compilationState) increaseMappingsByOne(sourceMap []byte) ([]byte, erro
 // bundle instance / Goja VM is in use at a time.
 func (b *babel) transformImpl(
 	logger logrus.FieldLogger, src, filename string, sourceMapsEnabled bool, inputSrcMap []byte,
-) (string, []byte, error) {
+) (string, []byte, []string, error) {
 	b.m.Lock()
 	defer b.m.Unlock()
 	opts := make(map[string]interface{})
@@ -320,7 +394,7 @@ func (b *babel) transformImpl(
 		if inputSrcMap != nil {
 			srcMap := new(map[string]interface{})
 			if err := json.Unmarshal(inputSrcMap, &srcMap); err != nil {
-				return "", nil, err
+				return "", nil, nil, err
 			}
 			opts["inputSourceMap"] = srcMap
 		}
@@ -330,17 +404,18 @@ func (b *babel) transformImpl(
 	startTime := time.Now()
 	v, err := b.transform(b.this, b.vm.ToValue(src), b.vm.ToValue(opts))
 	if err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
 	logger.WithField("t", time.Since(startTime)).Debug("Babel: Transformed")
 
 	vO := v.ToObject(b.vm)
 	var code string
 	if err = b.vm.ExportTo(vO.Get("code"), &code); err != nil {
-		return code, nil, err
+		return code, nil, nil, err
 	}
+	imports := b.moduleImports(vO.Get("metadata"))
 	if !sourceMapsEnabled {
-		return code, nil, nil
+		return code, nil, imports, nil
 	}
 
 	// this is to make goja try to load a sourcemap.
@@ -349,14 +424,61 @@ func (b *babel) transformImpl(
 	code += "\n//# sourceMappingURL=" + sourceMapURLFromBabel
 	stringify, err := b.vm.RunString("(function(m) { return JSON.stringify(m)})")
 	if err != nil {
-		return code, nil, err
+		return code, nil, nil, err
 	}
 	c, _ := goja.AssertFunction(stringify)
 	mapAsJSON, err := c(goja.Undefined(), vO.Get("map"))
 	if err != nil {
-		return code, nil, err
+		return code, nil, nil, err
+	}
+	return code, []byte(mapAsJSON.String()), imports, nil
+}
+
+// moduleImports returns the sources of the imports and re-exports from the
+// metadata of the module that babel transformed, in the order they are required.
+func (b *babel) moduleImports(metadata goja.Value) []string {
+	var meta struct {
+		Modules struct {
+			Imports []struct {
+				Source string `json:"source"`
+			} `json:"imports"`
+			Exports struct {
+				Specifiers []struct {
+					Kind   string `json:"kind"`
+					Source string `json:"source"`
+				} `json:"specifiers"`
+			} `json:"exports"`
+		} `json:"modules"`
+	}
+	if goja.IsUndefined(metadata) || goja.IsNull(metadata) {
+		return nil
+	}
+	data, err := json.Marshal(metadata.Export())
+	if err != nil {
+		return nil
+	}
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return nil
+	}
+	var imports []string
+	seen := make(map[string]struct{})
+	add := func(source string) {
+		if _, ok := seen[source]; ok || source == "" {
+			return
+		}
+		seen[source] = struct{}{}
+		imports = append(imports, source)
+	}
+	// babel requires the re-exported modules before the imported ones
+	for _, spec := range meta.Modules.Exports.Specifiers {
+		if spec.Kind == "external" || spec.Kind == "external-all" {
+			add(spec.Source)
+		}
+	}
+	for _, imp := range meta.Modules.Imports {
+		add(imp.Source)
 	}
-	return code, []byte(mapAsJSON.String()), nil
+	return imports
 }
 
 // Pool is a pool of compilers so it can be used easier in parallel tests as they have their own babel.
@@ -242,3 +242,151 @@ func TestMinimalSourceMap(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, hook.Drain())
 }
+
+func TestCompileTypeScript(t *testing.T) {
+	t.Parallel()
+	t.Run("types are stripped", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		src := strings.Join([]string{
+			`import { Options } from "k6/options";`,
+			`import http from "k6/http";`,
+			`interface Result { value: number }`,
+			`export const options: Options = { vus: 1 };`,
+			`export default function (): Result { http.get("url"); return { value: 1 + 2 } as Result; }`,
+		}, "\n")
+		pgm, _, err := c.Compile(src, "file:///script.ts", false)
+		require.NoError(t, err)
+
+		rt := goja.New()
+		var required []string
+		requireFn := func(s string) map[string]interface{} {
+			required = append(required, s)
+			return map[string]interface{}{"__esModule": true, "default": map[string]interface{}{"get": func(string) {}}}
+		}
+		v, err := rt.RunProgram(pgm)
+		require.NoError(t, err)
+		fn, ok := goja.AssertFunction(v)
+		require.True(t, ok, "not a function")
+		module := rt.NewObject()
+		exports := rt.NewObject()
+		require.NoError(t, module.Set("exports", exports))
+		_, err = fn(goja.Undefined(), module, exports, rt.ToValue(requireFn))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"k6/http"}, required) // the type-only import is dropped
+		def, ok := goja.AssertFunction(module.Get("exports").ToObject(rt).Get("default"))
+		require.True(t, ok, "the default export isn't a function")
+		result, err := def(goja.Undefined())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.ToObject(rt).Get("value").Export())
+	})
+
+	t.Run("stack traces use the source map", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		c.Options.SourceMapLoader = func(string) ([]byte, error) { return nil, errors.New("shouldn't be called") }
+		src := strings.Join([]string{
+			`type Name = string;`,
+			``,
+			`function fail(name: Name): never {`,
+			`    throw new Error("failed " + name);`,
+			`}`,
+			`fail("test");`,
+		}, "\n")
+		pgm, _, err := c.Compile(src, "file:///script.ts", true)
+		require.NoError(t, err)
+		_, err = goja.New().RunProgram(pgm)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed test")
+		assert.Contains(t, err.Error(), "file:///script.ts:4:")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		_, _, err := c.Compile("const a: number = ;", "file:///script.ts", false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file:///script.ts: Line 1:19 Unexpected \";\"")
+	})
+}
+
+func TestCompileModule(t *testing.T) {
+	t.Parallel()
+	t.Run("static imports", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		c.Options.CompatibilityMode = lib.CompatibilityModeExtended
+		src := `import a from "./a.js"; export * from "./b.js"; import { c } from "k6"; export default a + c;`
+		_, info, err := c.CompileModule(src, "file:///script.js")
+		require.NoError(t, err)
+		assert.False(t, info.Async)
+		assert.Equal(t, []string{"./b.js", "./a.js", "k6"}, info.Imports)
+	})
+
+	t.Run("top-level await", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		c.Options.CompatibilityMode = lib.CompatibilityModeExtended
+		src := strings.Join([]string{
+			`import { value } from "./a.js";`,
+			`export * from "./b.js";`,
+			`const m = await import("./c.js");`,
+			`export const result = (await Promise.resolve(value)) + m.default;`,
+		}, "\n")
+		pgm, info, err := c.CompileModule(src, "file:///script.js")
+		require.NoError(t, err)
+		assert.True(t, info.Async)
+		assert.Equal(t, []string{"./a.js", "./b.js"}, info.Imports)
+
+		rt := goja.New()
+		requireFn := rt.ToValue(func(s string) map[string]interface{} {
+			return map[string]interface{}{"__esModule": true, "value": 1}
+		}).ToObject(rt)
+		require.NoError(t, requireFn.Set("import", func(s string) map[string]interface{} {
+			return map[string]interface{}{"__esModule": true, "default": 2}
+		}))
+		v, err := rt.RunProgram(pgm)
+		require.NoError(t, err)
+		fn, ok := goja.AssertFunction(v)
+		require.True(t, ok, "not a function")
+		module := rt.NewObject()
+		exports := rt.NewObject()
+		require.NoError(t, module.Set("exports", exports))
+		result, err := fn(goja.Undefined(), module, exports, requireFn)
+		require.NoError(t, err)
+
+		p, ok := result.Export().(*goja.Promise)
+		require.True(t, ok, "the module doesn't return a promise")
+		assert.Equal(t, goja.PromiseStateFulfilled, p.State())
+		assert.Equal(t, int64(3), module.Get("exports").ToObject(rt).Get("result").Export())
+	})
+
+	t.Run("dynamic import", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		c.Options.CompatibilityMode = lib.CompatibilityModeExtended
+		src := "export default function (name) { return import(`./${name}.js`); }"
+		_, info, err := c.CompileModule(src, "file:///script.js")
+		require.NoError(t, err)
+		assert.False(t, info.Async)
+		assert.Empty(t, info.Imports)
+	})
+
+	t.Run("base compatibility mode", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		c.Options.CompatibilityMode = lib.CompatibilityModeBase
+		_, _, err := c.CompileModule(`await Promise.resolve(1);`, "file:///script.js")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		c.Options.CompatibilityMode = lib.CompatibilityModeExtended
+		_, _, err := c.CompileModule(`const a = ;`, "file:///script.js")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Unexpected token")
+	})
+}
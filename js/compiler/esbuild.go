@@ -0,0 +1,187 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const (
+	esbuildTopLevelAwaitError = "Top-level await is currently not supported"
+	// topLevelAwaitMarker has the same length as `await` and is also a prefix of
+	// an unary expression, so it can replace it without changing how the rest of
+	// the code is parsed, and esbuild keeps it as it is.
+	topLevelAwaitMarker = "~~~~~"
+
+	// esbuildDynamicImport is how esbuild transforms the dynamic imports, which
+	// don't wait for the modules with top-level await. So they're replaced
+	// with the same length calls to the import method of the module's require.
+	esbuildDynamicImport = "Promise.resolve().then(() => __toESM(require("
+	moduleDynamicImport  = "Promise.resolve().then(() => (require.import("
+)
+
+// hoistedImportRegexp matches the lines with the require() calls that esbuild
+// generates for the static imports and re-exports of an ES module.
+var hoistedImportRegexp = regexp.MustCompile( //nolint:gochecknoglobals
+	`^(?:var [\w$]+ = |__reExport\([\w$]+, )(?:__toESM\()?require\("((?:[^"\\]|\\.)*)"\)`)
+
+// isTypeScript returns whether the file with the given name (usually an URL)
+// is a TypeScript file, based on its extension.
+func isTypeScript(filename string) bool {
+	if u, err := url.Parse(filename); err == nil && u.Path != "" {
+		filename = u.Path
+	}
+	ext := path.Ext(filename)
+	return ext == ".ts" || ext == ".mts" || ext == ".cts"
+}
+
+// transformModule transforms the given ES module into a CommonJS one with
+// esbuild, so it can be run by goja. Unlike babel, esbuild supports the
+// dynamic import() (as a promise for the required module) and the top-level
+// await, for which the module is wrapped in an async function.
+//
+// For TypeScript, the types aren't checked and, as with tsc, the imports that
+// are only used as types are dropped, so modules with only types (e.g.
+// k6/options from @types/k6) don't need to exist at runtime.
+func transformModule(
+	src, filename string, loader api.Loader, sourceMapEnabled bool,
+) (code string, srcMap []byte, info ModuleInfo, err error) {
+	opts := api.TransformOptions{
+		Sourcefile:    filename,
+		Loader:        loader,
+		Format:        api.FormatCommonJS,
+		Target:        api.ESNext,
+		Supported:     map[string]bool{"dynamic-import": false},
+		Platform:      api.PlatformNeutral,
+		Charset:       api.CharsetUTF8,
+		LegalComments: api.LegalCommentsNone,
+		LogLevel:      api.LogLevelSilent,
+	}
+	if sourceMapEnabled {
+		opts.Sourcemap = api.SourceMapExternal
+		opts.SourcesContent = api.SourcesContentInclude
+	}
+
+	result := api.Transform(src, opts)
+	if len(result.Errors) > 0 {
+		// esbuild doesn't support the top-level await in CommonJS modules, so it
+		// is hidden from it and restored after the module is transformed
+		markedSrc, ok := markTopLevelAwaits(src, result.Errors)
+		if !ok {
+			return "", nil, info, esbuildError(result.Errors)
+		}
+		markedResult := api.Transform(markedSrc, opts)
+		if len(markedResult.Errors) > 0 {
+			return "", nil, info, esbuildError(result.Errors)
+		}
+		result = markedResult
+		info.Async = true
+	}
+
+	code = string(result.Code)
+	if info.Async {
+		code = strings.ReplaceAll(code, topLevelAwaitMarker, "await ")
+	}
+	code = strings.ReplaceAll(code, esbuildDynamicImport, moduleDynamicImport)
+	info.Imports = hoistedImports(code)
+	if !sourceMapEnabled {
+		return code, nil, info, nil
+	}
+	// this makes goja load the source map through the compilation state, the same as with babel
+	code += "\n//# sourceMappingURL=" + sourceMapURLFromBabel
+	return code, result.Map, info, nil
+}
+
+// markTopLevelAwaits replaces all of the top-level awaits, which esbuild
+// reported as errors, with topLevelAwaitMarker. It returns false if there are
+// any other errors, or if the marker can't be used.
+func markTopLevelAwaits(src string, msgs []api.Message) (string, bool) {
+	if strings.Contains(src, topLevelAwaitMarker) {
+		return "", false
+	}
+	lineOffsets := []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+
+	marked := []byte(src)
+	for _, msg := range msgs {
+		if !strings.HasPrefix(msg.Text, esbuildTopLevelAwaitError) || msg.Location == nil {
+			return "", false
+		}
+		if msg.Location.Line < 1 || msg.Location.Line > len(lineOffsets) {
+			return "", false
+		}
+		offset := lineOffsets[msg.Location.Line-1] + msg.Location.Column
+		if offset+len(topLevelAwaitMarker) > len(src) || src[offset:offset+len(topLevelAwaitMarker)] != "await" {
+			return "", false
+		}
+		copy(marked[offset:], topLevelAwaitMarker)
+	}
+	return string(marked), true
+}
+
+// hoistedImports returns the specifiers of the static imports of a module that
+// was transformed by esbuild, which puts their require() calls in a block just
+// after the exports of the module are defined.
+func hoistedImports(code string) []string {
+	lines := strings.Split(code, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "module.exports = __toCommonJS(") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil // not an ES module
+	}
+
+	var imports []string
+	for _, line := range lines[start:] {
+		match := hoistedImportRegexp.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+		if specifier, err := strconv.Unquote(`"` + match[1] + `"`); err == nil {
+			imports = append(imports, specifier)
+		}
+	}
+	return imports
+}
+
+// esbuildError converts the esbuild errors into a single error, with the
+// position of each of them in the original file.
+func esbuildError(msgs []api.Message) error {
+	errs := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Location == nil {
+			errs = append(errs, msg.Text)
+			continue
+		}
+		errs = append(errs, fmt.Sprintf("%s: Line %d:%d %s",
+			msg.Location.File, msg.Location.Line, msg.Location.Column+1, msg.Text))
+	}
+	return errors.New(strings.Join(errs, "\n"))
+}
+
+// TransformForAnalysis returns the CommonJS code of the module as it's
+// compiled, and its source map, for the static analysis of the scripts. The
+// code of the modules with top-level awaits has to be wrapped in an async
+// function to be parsed.
+func TransformForAnalysis(src, filename string) (code string, srcMap []byte, async bool, err error) {
+	loader := api.LoaderJS
+	if isTypeScript(filename) {
+		loader = api.LoaderTS
+	}
+	code, srcMap, info, err := transformModule(src, filename, loader, true)
+	return code, srcMap, info.Async, err
+}
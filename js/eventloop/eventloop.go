@@ -4,6 +4,7 @@ package eventloop
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/dop251/goja"
 	"go.k6.io/k6/js/modules"
@@ -28,6 +29,10 @@ type EventLoop struct {
 	registeredCallbacks int
 	vu                  modules.VU
 
+	// maxLag is the longest time a callback waited in the queue since the
+	// loop was started, it's only accessed from the loop.
+	maxLag time.Duration
+
 	// pendingPromiseRejections are rejected promises with no handler,
 	// if there is something in this map at an end of an event loop then it will exit with an error.
 	// It's similar to what Deno and Node do.
@@ -124,7 +129,13 @@ func (e *EventLoop) RegisterCallback() (enqueueCallback func(func() error)) {
 			panic("RegisterCallback called twice")
 		}
 		callbackCalled = true
-		e.queue = append(e.queue, f)
+		queued := time.Now()
+		e.queue = append(e.queue, func() error {
+			if lag := time.Since(queued); lag > e.maxLag {
+				e.maxLag = lag
+			}
+			return f()
+		})
 		e.registeredCallbacks--
 		e.lock.Unlock()
 		e.wakeup()
@@ -162,6 +173,7 @@ func (e *EventLoop) putInfront(queue []func() error) {
 func (e *EventLoop) Start(firstCallback func() error) error {
 	e.pendingPromiseRejections = make(map[*goja.Promise]struct{})
 	e.queue = []func() error{firstCallback}
+	e.maxLag = 0
 	for {
 		queue, awaiting := e.popAll()
 
@@ -198,6 +210,13 @@ func (e *EventLoop) Start(firstCallback func() error) error {
 	}
 }
 
+// MaxLag returns the longest time a callback waited in the queue, after it
+// was enqueued, to be run by the loop since it was last started. Long lags
+// mean that the VU is too busy to handle its async work in time.
+func (e *EventLoop) MaxLag() time.Duration {
+	return e.maxLag
+}
+
 // WaitOnRegistered waits on all registered callbacks so we know nothing is still doing work.
 // This does call back the callbacks and more can be queued over time.
 // A different mechanism needs to be used to tell the users that the event loop has errored out or winding down for a
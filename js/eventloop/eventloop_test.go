@@ -34,6 +34,22 @@ func TestBasicEventLoop(t *testing.T) {
 	require.Equal(t, 3, ran)
 }
 
+func TestEventLoopMaxLag(t *testing.T) {
+	t.Parallel()
+	loop := eventloop.New(&modulestest.VU{RuntimeField: goja.New()})
+	require.NoError(t, loop.Start(func() error {
+		enqueue := loop.RegisterCallback()
+		enqueue(func() error { return nil })
+		// the callback waits while the loop is busy with this one
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	}))
+	require.GreaterOrEqual(t, loop.MaxLag(), 200*time.Millisecond)
+
+	require.NoError(t, loop.Start(func() error { return nil }))
+	require.Zero(t, loop.MaxLag())
+}
+
 func TestEventLoopRegistered(t *testing.T) {
 	t.Parallel()
 	loop := eventloop.New(&modulestest.VU{RuntimeField: goja.New()})
@@ -0,0 +1,201 @@
+package js
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// configureHTTP2 makes the transport send the requests over HTTP/2 when the
+// servers support it, with the given settings, if any.
+func configureHTTP2(transport *http.Transport, settings *types.HTTP2, countError func(string)) {
+	h2, err := http2.ConfigureTransports(transport)
+	if err != nil {
+		return
+	}
+	h2.CountError = countError
+	if settings != nil {
+		h2.StrictMaxConcurrentStreams = settings.MaxConcurrentStreams > 0
+		h2.MaxReadFrameSize = uint32(settings.MaxReadFrameSize)
+	}
+}
+
+// newH2CTransport returns the transport of the requests sent over cleartext
+// HTTP/2 with prior knowledge.
+func newH2CTransport(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+	settings *types.HTTP2, countError func(string),
+) *http2.Transport {
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+		StrictMaxConcurrentStreams: settings.MaxConcurrentStreams > 0,
+		MaxReadFrameSize:           uint32(settings.MaxReadFrameSize),
+		CountError:                 countError,
+	}
+}
+
+// http2ErrorCounter emits the http2_stream_resets and http2_goaways metrics for
+// the errors the HTTP/2 transports of a VU count, tagged with the HTTP/2 error
+// code and the current tags of the VU.
+type http2ErrorCounter struct {
+	ctx            context.Context //nolint:containedctx // the samples are sent while the VU exists
+	samples        chan<- metrics.SampleContainer
+	builtinMetrics *metrics.BuiltinMetrics
+	tags           *lib.VUStateTags // set once the state of the VU is made
+}
+
+// count is the CountError of the transports. It's called by their goroutines
+// reading the frames, which wait for the sample to be sent.
+func (c *http2ErrorCounter) count(errType string) {
+	var metric *metrics.Metric
+	var code string
+	switch {
+	case strings.HasPrefix(errType, "recv_rststream_"):
+		metric, code = c.builtinMetrics.HTTP2StreamResets, strings.TrimPrefix(errType, "recv_rststream_")
+	case strings.HasPrefix(errType, "recv_goaway_"):
+		metric, code = c.builtinMetrics.HTTP2GoAways, strings.TrimPrefix(errType, "recv_goaway_")
+	default:
+		return
+	}
+	if c.tags == nil {
+		return
+	}
+	ctm := c.tags.GetCurrentValues()
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: ctm.Tags.With("http2_error_code", code)},
+		Time:       time.Now(),
+		Metadata:   ctm.Metadata,
+		Value:      1,
+	}
+	select {
+	case c.samples <- sample:
+	case <-c.ctx.Done():
+	}
+}
+
+// http2Transport sends the requests of the scenarios with HTTP/2 settings
+// through transports of their own, made by newTransport, and the others
+// through the base transport.
+type http2Transport struct {
+	base         *tlsOverrideTransport
+	scenarios    lib.ScenarioConfigs
+	newTransport func(*types.HTTP2) *scenarioHTTP2Transport
+
+	mx         sync.Mutex
+	byScenario map[string]*scenarioHTTP2Transport
+}
+
+// scenarioHTTP2Transport is the transport of a scenario with HTTP/2 settings.
+type scenarioHTTP2Transport struct {
+	tls      *tlsOverrideTransport
+	h2c      *http2.Transport // nil without h2c
+	settings types.HTTP2
+
+	streamsMx sync.Mutex
+	streams   map[string]chan struct{} // by host, with maxConcurrentStreams
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *http2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	scenario := scenarioName(req.Context())
+	config, ok := t.scenarios[scenario]
+	if !ok || config.GetHTTP2() == nil {
+		return t.base.RoundTrip(req)
+	}
+	return t.transportFor(scenario, config.GetHTTP2()).RoundTrip(req)
+}
+
+func (t *http2Transport) transportFor(scenario string, settings *types.HTTP2) *scenarioHTTP2Transport {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if transport, ok := t.byScenario[scenario]; ok {
+		return transport
+	}
+	transport := t.newTransport(settings)
+	if t.byScenario == nil {
+		t.byScenario = make(map[string]*scenarioHTTP2Transport)
+	}
+	t.byScenario[scenario] = transport
+	return transport
+}
+
+// CloseIdleConnections closes the idle connections of all the transports.
+func (t *http2Transport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	for _, transport := range t.byScenario {
+		transport.tls.CloseIdleConnections()
+		if transport.h2c != nil {
+			transport.h2c.CloseIdleConnections()
+		}
+	}
+}
+
+// RoundTrip implements http.RoundTripper. With maxConcurrentStreams, the
+// request waits until fewer requests to its host are in flight, and it stays
+// in flight until its response body is closed.
+func (t *scenarioHTTP2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var transport http.RoundTripper = t.tls
+	if t.h2c != nil && req.URL.Scheme == "http" {
+		transport = t.h2c
+	}
+	if t.settings.MaxConcurrentStreams <= 0 {
+		return transport.RoundTrip(req)
+	}
+
+	stream := t.streamsOf(req.URL.Host)
+	select {
+	case stream <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		<-stream
+		return nil, err
+	}
+	res.Body = &streamBody{ReadCloser: res.Body, release: func() { <-stream }}
+	return res, nil
+}
+
+func (t *scenarioHTTP2Transport) streamsOf(host string) chan struct{} {
+	t.streamsMx.Lock()
+	defer t.streamsMx.Unlock()
+	stream, ok := t.streams[host]
+	if !ok {
+		stream = make(chan struct{}, t.settings.MaxConcurrentStreams)
+		if t.streams == nil {
+			t.streams = make(map[string]chan struct{})
+		}
+		t.streams[host] = stream
+	}
+	return stream
+}
+
+// streamBody releases the stream of its request when it's closed.
+type streamBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,170 @@
+package js
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// startH2CServer starts a server of cleartext HTTP/2 with prior knowledge.
+func startH2CServer(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		server := &http2.Server{}
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+	return "http://" + l.Addr().String()
+}
+
+func newTestHTTP2Transport(settings *types.HTTP2) *http2Transport {
+	h2 := executor.NewConstantVUsConfig("h2")
+	h2.HTTP2 = settings
+	dialer := &net.Dialer{}
+	newTLSTransport := func() *tlsOverrideTransport {
+		return &tlsOverrideTransport{base: &http.Transport{DialContext: dialer.DialContext}}
+	}
+	return &http2Transport{
+		base: newTLSTransport(),
+		scenarios: lib.ScenarioConfigs{
+			"h2":      h2,
+			"default": executor.NewConstantVUsConfig("default"),
+		},
+		newTransport: func(settings *types.HTTP2) *scenarioHTTP2Transport {
+			transport := &scenarioHTTP2Transport{tls: newTLSTransport(), settings: *settings}
+			if settings.H2C {
+				transport.h2c = newH2CTransport(dialer.DialContext, settings, nil)
+			}
+			return transport
+		},
+	}
+}
+
+func scenarioRequest(t *testing.T, scenario, url string) *http.Request {
+	t.Helper()
+	ctx := lib.WithScenarioState(context.Background(), &lib.ScenarioState{Name: scenario})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestHTTP2TransportH2C(t *testing.T) {
+	t.Parallel()
+
+	proto := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	})
+	h2cURL := startH2CServer(t, proto)
+	h1 := httptest.NewServer(proto)
+	t.Cleanup(h1.Close)
+
+	transport := newTestHTTP2Transport(&types.HTTP2{H2C: true})
+	t.Cleanup(transport.CloseIdleConnections)
+	get := func(scenario, url string) string {
+		res, err := transport.RoundTrip(scenarioRequest(t, scenario, url))
+		require.NoError(t, err)
+		defer func() { _ = res.Body.Close() }()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, "HTTP/2.0", get("h2", h2cURL))
+	// the other scenarios keep sending HTTP/1.1 requests
+	assert.Equal(t, "HTTP/1.1", get("default", h1.URL))
+}
+
+func TestHTTP2TransportMaxConcurrentStreams(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, maxInFlight int64
+	url := startH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			m := atomic.LoadInt64(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt64(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = io.WriteString(w, "ok")
+	}))
+
+	transport := newTestHTTP2Transport(&types.HTTP2{H2C: true, MaxConcurrentStreams: 2})
+	t.Cleanup(transport.CloseIdleConnections)
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := transport.RoundTrip(scenarioRequest(t, "h2", url))
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(2), atomic.LoadInt64(&maxInFlight))
+}
+
+func TestHTTP2ErrorCounter(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	samples := make(chan metrics.SampleContainer, 10)
+	counter := &http2ErrorCounter{
+		ctx:            context.Background(),
+		samples:        samples,
+		builtinMetrics: builtinMetrics,
+		tags:           lib.NewVUStateTags(registry.RootTagSet().With("scenario", "h2")),
+	}
+
+	counter.count("recv_rststream_REFUSED_STREAM")
+	counter.count("recv_goaway_ENHANCE_YOUR_CALM")
+	counter.count("read_frame_eof")
+	require.Len(t, samples, 2)
+
+	for _, expected := range []struct {
+		metric *metrics.Metric
+		code   string
+	}{
+		{builtinMetrics.HTTP2StreamResets, "REFUSED_STREAM"},
+		{builtinMetrics.HTTP2GoAways, "ENHANCE_YOUR_CALM"},
+	} {
+		sample, ok := (<-samples).(metrics.Sample)
+		require.True(t, ok)
+		assert.Equal(t, expected.metric, sample.Metric)
+		assert.Equal(t, 1.0, sample.Value)
+		code, _ := sample.Tags.Get("http2_error_code")
+		assert.Equal(t, expected.code, code)
+		scenario, _ := sample.Tags.Get("scenario")
+		assert.Equal(t, "h2", scenario)
+	}
+}
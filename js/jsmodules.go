@@ -8,22 +8,31 @@ import (
 	"go.k6.io/k6/js/modules/k6/data"
 	"go.k6.io/k6/js/modules/k6/encoding"
 	"go.k6.io/k6/js/modules/k6/execution"
+	"go.k6.io/k6/js/modules/k6/experimental/expect"
+	"go.k6.io/k6/js/modules/k6/experimental/fetch"
 	"go.k6.io/k6/js/modules/k6/experimental/fs"
+	"go.k6.io/k6/js/modules/k6/experimental/oauth"
+	"go.k6.io/k6/js/modules/k6/experimental/replay"
 	"go.k6.io/k6/js/modules/k6/experimental/tracing"
+	"go.k6.io/k6/js/modules/k6/experimental/workers"
 	"go.k6.io/k6/js/modules/k6/grpc"
 	"go.k6.io/k6/js/modules/k6/html"
 	"go.k6.io/k6/js/modules/k6/http"
 	"go.k6.io/k6/js/modules/k6/metrics"
+	"go.k6.io/k6/js/modules/k6/secrets"
+	"go.k6.io/k6/js/modules/k6/timers"
 	"go.k6.io/k6/js/modules/k6/ws"
 
 	"github.com/grafana/xk6-browser/browser"
 	"github.com/grafana/xk6-redis/redis"
-	"github.com/grafana/xk6-timers/timers"
 	"github.com/grafana/xk6-webcrypto/webcrypto"
 	expws "github.com/grafana/xk6-websockets/websockets"
 )
 
 func getInternalJSModules() map[string]interface{} {
+	// the experimental timers are the same module as the stable ones, so the
+	// timers set with either of them can be cleared with the other
+	timersModule := timers.New()
 	return map[string]interface{}{
 		"k6":                         k6.New(),
 		"k6/crypto":                  crypto.New(),
@@ -35,14 +44,21 @@ func getInternalJSModules() map[string]interface{} {
 		"k6/experimental/webcrypto":  webcrypto.New(),
 		"k6/experimental/websockets": &expws.RootModule{},
 		"k6/experimental/grpc":       grpc.NewExperimental(),
-		"k6/experimental/timers":     timers.New(),
+		"k6/experimental/timers":     timersModule,
 		"k6/experimental/tracing":    tracing.New(),
+		"k6/experimental/replay":     replay.New(),
 		"k6/experimental/browser":    browser.New(),
+		"k6/experimental/expect":     expect.New(),
+		"k6/experimental/fetch":      fetch.New(),
 		"k6/experimental/fs":         fs.New(),
+		"k6/experimental/oauth":      oauth.New(),
+		"k6/experimental/workers":    workers.New(),
 		"k6/net/grpc":                grpc.New(),
 		"k6/html":                    html.New(),
 		"k6/http":                    http.New(),
 		"k6/metrics":                 metrics.New(),
+		"k6/secrets":                 secrets.New(),
+		"k6/timers":                  timersModule,
 		"k6/ws":                      ws.New(),
 	}
 }
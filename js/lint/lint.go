@@ -0,0 +1,284 @@
+// Package lint analyses the k6 scripts, without running them, for the common
+// issues, like un-awaited promises or open() calls outside of the init context.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"github.com/go-sourcemap/sourcemap"
+
+	"go.k6.io/k6/js/compiler"
+)
+
+// Severity is how serious an issue is.
+type Severity string
+
+// The severities of the issues, the errors are bugs of the scripts and the
+// warnings are likely ones.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// The rules of the issues.
+const (
+	RuleUnawaitedPromise  = "unawaited-promise"
+	RuleOpenOutsideInit   = "open-outside-init"
+	RuleURLCardinality    = "url-cardinality"
+	RuleDeprecatedAPI     = "deprecated-api"
+	RuleMissingThresholds = "missing-thresholds"
+)
+
+// Issue is an issue of a script, its line and column are 0 when it's about
+// the whole script.
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+}
+
+// Lint returns the issues of the script, sorted by their position. An error is
+// returned if the script can't be parsed.
+func Lint(src, filename string) ([]Issue, error) {
+	code, srcMap, async, err := compiler.TransformForAnalysis(src, filename)
+	if err != nil {
+		return nil, err
+	}
+	l := &linter{
+		imports:    make(map[string]string),
+		functions:  make(map[string]ast.Node),
+		asyncFuncs: make(map[string]bool),
+		variables:  make(map[string]ast.Expression),
+		exports:    make(map[string]string),
+	}
+	if async {
+		// the top-level awaits are only valid in a function
+		code = "(async function() {\n" + code + "\n})"
+		l.lineOffset = 1
+	}
+	if l.program, err = parser.ParseFile(nil, filename, code, 0, parser.WithDisableSourceMaps); err != nil {
+		return nil, err
+	}
+	if l.srcMap, err = sourcemap.Parse(filename, srcMap); err != nil {
+		return nil, fmt.Errorf("couldn't parse the source map of the script: %w", err)
+	}
+
+	l.collectDeclarations()
+	l.checkMissingThresholds()
+	l.checkOpenOutsideInit()
+	walk(l.program, l.checkNode)
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].Line != l.issues[j].Line {
+			return l.issues[i].Line < l.issues[j].Line
+		}
+		return l.issues[i].Column < l.issues[j].Column
+	})
+	return l.issues, nil
+}
+
+// linter has the top-level declarations of the CommonJS code of a script, as
+// it's transformed by esbuild: the imports are variables with the required
+// module, e.g. `var import_http = __toESM(require("k6/http"))`, and the
+// exports are getters of an __export() call.
+type linter struct {
+	program    *ast.Program
+	srcMap     *sourcemap.Consumer
+	lineOffset int
+
+	// imports are the modules of the import variables
+	imports map[string]string
+	// functions are the top-level functions, and asyncFuncs the async ones
+	functions  map[string]ast.Node
+	asyncFuncs map[string]bool
+	// variables are the initializers of the other top-level variables
+	variables map[string]ast.Expression
+	// exports are the local names of the exports
+	exports map[string]string
+
+	issues []Issue
+}
+
+func (l *linter) report(node ast.Node, rule string, severity Severity, format string, args ...any) {
+	issue := Issue{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		issue.Line, issue.Column = l.position(node)
+	}
+	l.issues = append(l.issues, issue)
+}
+
+// position returns the line and the column, in the original script, of a node.
+func (l *linter) position(node ast.Node) (int, int) {
+	pos := l.program.File.Position(int(node.Idx0()) - l.program.File.Base())
+	line, column := pos.Line-l.lineOffset, pos.Column
+	if _, _, srcLine, srcColumn, ok := l.srcMap.Source(line, column-1); ok {
+		return srcLine, srcColumn + 1
+	}
+	return line, column
+}
+
+// topLevel returns the top-level statements of the script.
+func (l *linter) topLevel() []ast.Statement {
+	if l.lineOffset == 0 || len(l.program.Body) != 1 {
+		return l.program.Body
+	}
+	if stmt, ok := l.program.Body[0].(*ast.ExpressionStatement); ok {
+		if fn, ok := stmt.Expression.(*ast.FunctionLiteral); ok {
+			return fn.Body.List
+		}
+	}
+	return l.program.Body
+}
+
+func (l *linter) collectDeclarations() {
+	for _, stmt := range l.topLevel() {
+		switch s := stmt.(type) {
+		case *ast.FunctionDeclaration:
+			if s.Function.Name != nil {
+				name := s.Function.Name.Name.String()
+				l.functions[name] = s.Function
+				l.asyncFuncs[name] = s.Function.Async
+			}
+		case *ast.VariableStatement:
+			l.collectBindings(s.List)
+		case *ast.LexicalDeclaration:
+			l.collectBindings(s.List)
+		case *ast.ExpressionStatement:
+			l.collectExports(s.Expression)
+		}
+	}
+}
+
+func (l *linter) collectBindings(bindings []*ast.Binding) {
+	for _, binding := range bindings {
+		target, ok := binding.Target.(*ast.Identifier)
+		if !ok || binding.Initializer == nil {
+			continue
+		}
+		name := target.Name.String()
+		switch init := binding.Initializer.(type) {
+		case *ast.FunctionLiteral:
+			l.functions[name], l.asyncFuncs[name] = init, init.Async
+		case *ast.ArrowFunctionLiteral:
+			l.functions[name], l.asyncFuncs[name] = init, init.Async
+		default:
+			if module, ok := requiredModule(init); ok {
+				l.imports[name] = module
+				l.checkDeprecatedModule(init, module)
+			} else {
+				l.variables[name] = init
+			}
+		}
+	}
+}
+
+// collectExports collects the exports of `__export(exports, { name: () => local })`.
+func (l *linter) collectExports(expr ast.Expression) {
+	call, ok := expr.(*ast.CallExpression)
+	if !ok || !isIdentifier(call.Callee, "__export") || len(call.ArgumentList) != 2 {
+		return
+	}
+	obj, ok := call.ArgumentList[1].(*ast.ObjectLiteral)
+	if !ok {
+		return
+	}
+	for _, prop := range obj.Value {
+		keyed, ok := prop.(*ast.PropertyKeyed)
+		if !ok {
+			continue
+		}
+		getter, ok := keyed.Value.(*ast.ArrowFunctionLiteral)
+		if !ok {
+			continue
+		}
+		body, ok := getter.Body.(*ast.ExpressionBody)
+		if !ok {
+			continue
+		}
+		if local, ok := body.Expression.(*ast.Identifier); ok {
+			l.exports[propertyName(keyed.Key)] = local.Name.String()
+		}
+	}
+}
+
+// requiredModule returns the module of `require("module")`, and of it wrapped
+// by esbuild's __toESM().
+func requiredModule(expr ast.Expression) (string, bool) {
+	call, ok := expr.(*ast.CallExpression)
+	if !ok || len(call.ArgumentList) == 0 {
+		return "", false
+	}
+	if isIdentifier(call.Callee, "__toESM") {
+		return requiredModule(call.ArgumentList[0])
+	}
+	if !isIdentifier(call.Callee, "require") {
+		return "", false
+	}
+	module, ok := call.ArgumentList[0].(*ast.StringLiteral)
+	if !ok {
+		return "", false
+	}
+	return module.Value.String(), true
+}
+
+// resolve returns the imported member an expression refers to, like
+// "k6/http.get" for `http.get` of `import http from "k6/http"`, with
+// `import { get } from "k6/http"` or with `import * as http from "k6/http"`.
+func (l *linter) resolve(expr ast.Expression) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		module, ok := l.imports[e.Name.String()]
+		return module, ok
+	case *ast.DotExpression:
+		left, ok := l.resolve(e.Left)
+		if !ok {
+			return "", false
+		}
+		if _, isModule := l.imports[identifierName(e.Left)]; isModule && e.Identifier.Name == "default" {
+			return left, true
+		}
+		return left + "." + e.Identifier.Name.String(), true
+	case *ast.SequenceExpression:
+		// esbuild calls the named imports as `(0, import_k6.sleep)()`
+		if len(e.Sequence) > 0 {
+			return l.resolve(e.Sequence[len(e.Sequence)-1])
+		}
+	}
+	return "", false
+}
+
+func isIdentifier(expr ast.Expression, name string) bool {
+	return identifierName(expr) == name
+}
+
+func identifierName(expr ast.Expression) string {
+	if id, ok := expr.(*ast.Identifier); ok {
+		return id.Name.String()
+	}
+	return ""
+}
+
+func propertyName(key ast.Expression) string {
+	switch k := key.(type) {
+	case *ast.StringLiteral:
+		return k.Value.String()
+	case *ast.Identifier:
+		return k.Name.String()
+	}
+	return ""
+}
+
+// property returns the value of the property of an object literal.
+func property(obj *ast.ObjectLiteral, name string) (ast.Expression, bool) {
+	for _, prop := range obj.Value {
+		if keyed, ok := prop.(*ast.PropertyKeyed); ok && !keyed.Computed && propertyName(keyed.Key) == name {
+			return keyed.Value, true
+		}
+	}
+	return nil, false
+}
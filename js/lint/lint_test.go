@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const thresholds = "export const options = { thresholds: { http_req_failed: ['rate<0.01'] } };"
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		script string
+		issues []Issue
+	}{
+		"clean": {
+			script: `import http from 'k6/http';
+` + thresholds + `
+const data = open('data.json');
+export default async function () {
+	await http.asyncRequest('GET', 'https://test.k6.io/');
+	http.get(` + "http.url`https://test.k6.io/${__VU}`" + `);
+	http.get('https://test.k6.io/' + __VU, { tags: { name: 'test' } });
+	http.get('https://test.k6.io/' + 'static');
+	http.asyncRequest('GET', 'https://test.k6.io/').then((res) => console.log(res.status));
+}`,
+		},
+		"unawaited promises": {
+			script: `import http from 'k6/http';
+import secrets from 'k6/secrets';
+` + thresholds + `
+async function login() {}
+const logout = async () => {};
+export default function () {
+	http.asyncRequest('GET', 'https://test.k6.io/');
+	secrets.get('token');
+	login();
+	logout();
+}`,
+			issues: []Issue{
+				{RuleUnawaitedPromise, SeverityWarning, "the promise of k6/http.asyncRequest() isn't awaited, its errors " +
+					"are lost and it may not be done by the end of the iteration", 7, 2},
+				{RuleUnawaitedPromise, SeverityWarning, "the promise of k6/secrets.get() isn't awaited, its errors " +
+					"are lost and it may not be done by the end of the iteration", 8, 2},
+				{RuleUnawaitedPromise, SeverityWarning, "the promise of login() isn't awaited, its errors " +
+					"are lost and it may not be done by the end of the iteration", 9, 2},
+				{RuleUnawaitedPromise, SeverityWarning, "the promise of logout() isn't awaited, its errors " +
+					"are lost and it may not be done by the end of the iteration", 10, 2},
+			},
+		},
+		"open outside init": {
+			script: thresholds + `
+const data = open('data.json');
+export function setup() { return open('setup.json'); }
+function scenario() { open('scenario.json'); }
+export { scenario as other };`,
+			issues: []Issue{
+				{RuleOpenOutsideInit, SeverityError, "open() can only be called in the init context, not in the setup() function", 3, 34},
+				{RuleOpenOutsideInit, SeverityError, "open() can only be called in the init context, not in the other() function", 4, 23},
+			},
+		},
+		"url cardinality": {
+			script: `import { get, request } from 'k6/http';
+` + thresholds + `
+export default function () {
+	get(` + "`https://test.k6.io/${__VU}`" + `);
+	request('GET', 'https://test.k6.io/' + __ITER, null, { tags: { type: 'api' } });
+	get('https://test.k6.io/' + __VU, getParams());
+}`,
+			issues: []Issue{
+				{RuleURLCardinality, SeverityWarning, "the URL of k6/http.get() is dynamic, each of its values is a separate " +
+					"time series of the metrics, set the name tag or use the http.url template", 4, 6},
+				{RuleURLCardinality, SeverityWarning, "the URL of k6/http.request() is dynamic, each of its values is a " +
+					"separate time series of the metrics, set the name tag or use the http.url template", 5, 17},
+			},
+		},
+		"deprecated apis": {
+			script: `import { setTimeout } from 'k6/experimental/timers';
+import exec from 'k6/execution';
+` + thresholds + `
+export default function () {
+	console.log(exec.vu.tags['scenario']);
+}`,
+			issues: []Issue{
+				{RuleDeprecatedAPI, SeverityWarning, "k6/experimental/timers is deprecated, use k6/timers instead", 1, 28},
+				{RuleDeprecatedAPI, SeverityWarning, "k6/execution.vu.tags is deprecated, use vu.metrics.tags of k6/execution instead", 5, 14},
+			},
+		},
+		"missing thresholds": {
+			script: `export const options = { vus: 10 };
+export default function () {}`,
+			issues: []Issue{
+				{RuleMissingThresholds, SeverityWarning, "the options don't have thresholds, " +
+					"the test can't fail when the performance is too bad", 1, 24},
+			},
+		},
+		"no options": {
+			script: `export default function () {}`,
+			issues: []Issue{
+				{RuleMissingThresholds, SeverityWarning, "the script doesn't export options with thresholds, " +
+					"the test can't fail when the performance is too bad", 0, 0},
+			},
+		},
+		"top-level await": {
+			script: `import secrets from 'k6/secrets';
+` + thresholds + `
+const token = await secrets.get('token');
+export default function () {
+	open('data.json');
+}`,
+			issues: []Issue{
+				{RuleOpenOutsideInit, SeverityError, "open() can only be called in the init context, not in the default() function", 5, 2},
+			},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			issues, err := Lint(tc.script, "script.js")
+			require.NoError(t, err)
+			assert.Equal(t, tc.issues, issues)
+		})
+	}
+}
+
+func TestLintSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	_, err := Lint("export default function () {", "script.js")
+	require.ErrorContains(t, err, "script.js")
+}
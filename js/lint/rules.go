@@ -0,0 +1,186 @@
+package lint
+
+import (
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/token"
+)
+
+// asyncAPIs are the functions of the k6 modules which return promises.
+//
+//nolint:gochecknoglobals
+var asyncAPIs = map[string]bool{
+	"k6/http.asyncRequest":        true,
+	"k6/secrets.get":              true,
+	"k6/experimental/fs.open":     true,
+	"k6/experimental/fetch.fetch": true,
+}
+
+// deprecatedAPIs are the deprecated modules and members, with what should be
+// used instead.
+//
+//nolint:gochecknoglobals
+var deprecatedAPIs = map[string]string{
+	"k6/experimental/timers": "k6/timers",
+	"k6/experimental/grpc":   "k6/net/grpc",
+	"k6/execution.vu.tags":   "vu.metrics.tags of k6/execution",
+}
+
+// urlArguments are the indexes of the URL and of the params arguments of the
+// functions of k6/http.
+//
+//nolint:gochecknoglobals
+var urlArguments = map[string][2]int{
+	"k6/http.get":          {0, 1},
+	"k6/http.head":         {0, 1},
+	"k6/http.post":         {0, 2},
+	"k6/http.put":          {0, 2},
+	"k6/http.patch":        {0, 2},
+	"k6/http.del":          {0, 2},
+	"k6/http.options":      {0, 2},
+	"k6/http.request":      {1, 3},
+	"k6/http.asyncRequest": {1, 3},
+}
+
+// vuFunctions are the exports, other than the scenario functions, which
+// aren't run in the init context.
+//
+//nolint:gochecknoglobals
+var initExports = map[string]bool{"options": true}
+
+func (l *linter) checkNode(node ast.Node, _ []ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.ExpressionStatement:
+		l.checkUnawaitedPromise(n)
+	case *ast.CallExpression:
+		l.checkURLCardinality(n)
+	case *ast.DotExpression:
+		if member, ok := l.resolve(n); ok {
+			if replacement, deprecated := deprecatedAPIs[member]; deprecated {
+				l.report(n, RuleDeprecatedAPI, SeverityWarning, "%s is deprecated, use %s instead", member, replacement)
+			}
+		}
+	}
+	return true
+}
+
+// checkUnawaitedPromise reports the calls of the async functions whose
+// promise is discarded, their errors are lost and they may not be done by the
+// end of the iteration.
+func (l *linter) checkUnawaitedPromise(stmt *ast.ExpressionStatement) {
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		return
+	}
+	name := identifierName(call.Callee)
+	if name == "" || !l.asyncFuncs[name] {
+		member, ok := l.resolve(call.Callee)
+		if !ok || !asyncAPIs[member] {
+			return
+		}
+		name = member
+	}
+	l.report(call, RuleUnawaitedPromise, SeverityWarning,
+		"the promise of %s() isn't awaited, its errors are lost and it may not be done by the end of the iteration",
+		name)
+}
+
+// checkOpenOutsideInit reports the open() calls of the exported functions,
+// which aren't run in the init context where it can only be used.
+func (l *linter) checkOpenOutsideInit() {
+	for export, local := range l.exports {
+		fn, ok := l.functions[local]
+		if !ok || initExports[export] {
+			continue
+		}
+		export := export
+		walk(fn, func(node ast.Node, _ []ast.Node) bool {
+			if call, ok := node.(*ast.CallExpression); ok && isIdentifier(call.Callee, "open") {
+				l.report(call, RuleOpenOutsideInit, SeverityError,
+					"open() can only be called in the init context, not in the %s() function", export)
+			}
+			return true
+		})
+	}
+}
+
+// checkURLCardinality reports the requests with a dynamic URL and without a
+// name tag, each of their URLs is a different time series of the metrics.
+func (l *linter) checkURLCardinality(call *ast.CallExpression) {
+	member, ok := l.resolve(call.Callee)
+	if !ok {
+		return
+	}
+	args, ok := urlArguments[member]
+	if !ok || len(call.ArgumentList) <= args[0] || !l.isDynamicURL(call.ArgumentList[args[0]]) {
+		return
+	}
+	if len(call.ArgumentList) > args[1] {
+		params, ok := call.ArgumentList[args[1]].(*ast.ObjectLiteral)
+		if !ok {
+			return // the tags can't be known
+		}
+		if tags, ok := property(params, "tags"); ok {
+			tagsObj, ok := tags.(*ast.ObjectLiteral)
+			if !ok {
+				return
+			}
+			if _, hasName := property(tagsObj, "name"); hasName {
+				return
+			}
+		}
+	}
+	l.report(call.ArgumentList[args[0]], RuleURLCardinality, SeverityWarning,
+		"the URL of %s() is dynamic, each of its values is a separate time series of the metrics, "+
+			"set the name tag or use the http.url template", member)
+}
+
+func (l *linter) isDynamicURL(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case *ast.TemplateLiteral:
+		if e.Tag != nil {
+			return false // e.g. http.url``, which sets the name tag
+		}
+		return len(e.Expressions) > 0
+	case *ast.BinaryExpression:
+		if e.Operator != token.PLUS {
+			return false
+		}
+		return !isLiteral(e.Left) || !isLiteral(e.Right) || l.isDynamicURL(e.Left) || l.isDynamicURL(e.Right)
+	}
+	return false
+}
+
+func isLiteral(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case *ast.StringLiteral, *ast.NumberLiteral:
+		return true
+	case *ast.BinaryExpression:
+		return e.Operator == token.PLUS && isLiteral(e.Left) && isLiteral(e.Right)
+	}
+	return false
+}
+
+func (l *linter) checkDeprecatedModule(node ast.Node, module string) {
+	if replacement, ok := deprecatedAPIs[module]; ok {
+		l.report(node, RuleDeprecatedAPI, SeverityWarning, "%s is deprecated, use %s instead", module, replacement)
+	}
+}
+
+// checkMissingThresholds reports the scripts whose options don't have
+// thresholds, they can't fail on the performance of the system under test.
+func (l *linter) checkMissingThresholds() {
+	local, ok := l.exports["options"]
+	if !ok {
+		l.report(nil, RuleMissingThresholds, SeverityWarning,
+			"the script doesn't export options with thresholds, the test can't fail when the performance is too bad")
+		return
+	}
+	options, ok := l.variables[local].(*ast.ObjectLiteral)
+	if !ok {
+		return // the options can't be known
+	}
+	if _, ok := property(options, "thresholds"); !ok {
+		l.report(options, RuleMissingThresholds, SeverityWarning,
+			"the options don't have thresholds, the test can't fail when the performance is too bad")
+	}
+}
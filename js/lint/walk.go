@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"reflect"
+
+	"github.com/dop251/goja/ast"
+)
+
+//nolint:gochecknoglobals
+var nodeType = reflect.TypeOf((*ast.Node)(nil)).Elem()
+
+// walk calls visit for the node and all of its descendants, depth-first, with
+// the ancestors of each of them. The children of a node aren't visited if
+// visit returns false.
+func walk(node ast.Node, visit func(node ast.Node, ancestors []ast.Node) bool) {
+	walkNode(node, nil, visit)
+}
+
+func walkNode(node ast.Node, ancestors []ast.Node, visit func(ast.Node, []ast.Node) bool) {
+	if node == nil || reflect.ValueOf(node).IsNil() || !visit(node, ancestors) {
+		return
+	}
+	ancestors = append(ancestors, node)
+	v := reflect.ValueOf(node).Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		// the declarations are hoisted copies of the ones in the body
+		if v.Type().Field(i).Name == "DeclarationList" {
+			continue
+		}
+		walkValue(v.Field(i), ancestors, visit)
+	}
+}
+
+func walkValue(v reflect.Value, ancestors []ast.Node, visit func(ast.Node, []ast.Node) bool) {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if v.Type().Implements(nodeType) {
+			if node, ok := v.Interface().(ast.Node); ok {
+				walkNode(node, ancestors, visit)
+			}
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			walkValue(v.Elem(), ancestors, visit)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkValue(v.Index(i), ancestors, visit)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package js
+
+import (
+	"context"
+	"net"
+)
+
+// scenarioLocalIPFor returns the function giving the local IP the connections
+// of the VU with the given index are bound to in their scenario, or nil when
+// the scenario doesn't override the global local IPs.
+func (r *Runner) scenarioLocalIPFor(ipIndex uint64) func(context.Context) net.IP {
+	return func(ctx context.Context) net.IP {
+		config, ok := r.Bundle.Options.Scenarios[scenarioName(ctx)]
+		if !ok {
+			return nil
+		}
+		if localIPs := config.GetLocalIPs(); localIPs != nil && localIPs.Valid {
+			return localIPs.Pool.GetIP(ipIndex)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package js
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestScenarioLocalIPFor(t *testing.T) {
+	t.Parallel()
+
+	bound := executor.NewConstantVUsConfig("bound")
+	bound.LocalIPs = &types.NullIPPool{}
+	require.NoError(t, bound.LocalIPs.UnmarshalText([]byte("10.0.0.1-10.0.0.2")))
+	r := &Runner{Bundle: &Bundle{Options: lib.Options{
+		Scenarios: lib.ScenarioConfigs{
+			"bound":   bound,
+			"default": executor.NewConstantVUsConfig("default"),
+		},
+	}}}
+	inScenario := func(name string) context.Context {
+		return lib.WithScenarioState(context.Background(), &lib.ScenarioState{Name: name})
+	}
+
+	assert.Nil(t, r.scenarioLocalIPFor(0)(inScenario("default")))
+	assert.Nil(t, r.scenarioLocalIPFor(0)(context.Background()))
+
+	// round-robin over the IPs of the scenario by VU
+	assert.Equal(t, net.ParseIP("10.0.0.1").To4(), r.scenarioLocalIPFor(0)(inScenario("bound")).To4())
+	assert.Equal(t, net.ParseIP("10.0.0.2").To4(), r.scenarioLocalIPFor(1)(inScenario("bound")).To4())
+	assert.Equal(t, net.ParseIP("10.0.0.1").To4(), r.scenarioLocalIPFor(2)(inScenario("bound")).To4())
+}
@@ -11,8 +11,9 @@ import (
 
 // cjsModule represents a commonJS module
 type cjsModule struct {
-	prg *goja.Program
-	url *url.URL
+	prg  *goja.Program
+	url  *url.URL
+	info compiler.ModuleInfo
 }
 
 var _ module = &cjsModule{}
@@ -21,6 +22,12 @@ type cjsModuleInstance struct {
 	mod       *cjsModule
 	moduleObj *goja.Object
 	vu        VU
+	// require is given to the module, so it can require the modules
+	// relative to itself after it was evaluated (e.g. with import()).
+	require goja.Value
+	// promise is set while the module (or any of its imports) is evaluated
+	// asynchronously and is resolved with its exports.
+	promise *goja.Promise
 }
 
 func (c *cjsModule) instantiate(vu VU) moduleInstance {
@@ -28,6 +35,16 @@ func (c *cjsModule) instantiate(vu VU) moduleInstance {
 }
 
 func (c *cjsModuleInstance) execute() error {
+	if err := c.prepare(); err != nil {
+		return err
+	}
+	_, err := c.evaluate()
+	return err
+}
+
+// prepare creates the module and exports objects, so the exports can be used
+// (e.g. in cyclic imports) before the module is evaluated.
+func (c *cjsModuleInstance) prepare() error {
 	rt := c.vu.Runtime()
 	exports := rt.NewObject()
 	c.moduleObj = rt.NewObject()
@@ -36,19 +53,27 @@ func (c *cjsModuleInstance) execute() error {
 		return fmt.Errorf("error while getting ready to import commonJS, couldn't set exports property of module: %w",
 			err)
 	}
+	return nil
+}
 
-	// Run the program.
+// evaluate runs the program of the module. It returns the result of the
+// module function, which is a promise for the modules with top-level await.
+func (c *cjsModuleInstance) evaluate() (goja.Value, error) {
+	rt := c.vu.Runtime()
 	f, err := rt.RunProgram(c.mod.prg)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if call, ok := goja.AssertFunction(f); ok {
-		if _, err = call(exports, c.moduleObj, exports); err != nil {
-			return err
-		}
+	call, ok := goja.AssertFunction(f)
+	if !ok {
+		return goja.Undefined(), nil
 	}
-
-	return nil
+	require := c.require
+	if require == nil {
+		require = goja.Undefined()
+	}
+	exports := c.moduleObj.Get("exports")
+	return call(exports, c.moduleObj, exports, require)
 }
 
 func (c *cjsModuleInstance) exports() *goja.Object {
@@ -60,14 +85,14 @@ func (c *cjsModuleInstance) exports() *goja.Object {
 }
 
 // cjsModuleFromString is a helper function which returns CJSModule given the argument it has.
-// It is mostly a wrapper around compiler.Compiler@Compile
+// It is mostly a wrapper around compiler.Compiler@CompileModule
 //
 // TODO: extract this to not make this package dependant on compilers.
 // this is potentially a moot point after ESM when the compiler will likely get mostly dropped.
 func cjsModuleFromString(fileURL *url.URL, data []byte, c *compiler.Compiler) (*cjsModule, error) {
-	pgm, _, err := c.Compile(string(data), fileURL.String(), false)
+	pgm, info, err := c.CompileModule(string(data), fileURL.String())
 	if err != nil {
 		return nil, err
 	}
-	return &cjsModule{prg: pgm, url: fileURL}, nil
+	return &cjsModule{prg: pgm, url: fileURL, info: info}, nil
 }
@@ -0,0 +1,136 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/metrics"
+)
+
+// barrier blocks the VUs that reach it, until the specified number of them
+// have done so. After that, it's released and a new one with the same name
+// can be used again.
+type barrier struct {
+	parties int64
+	arrived int64
+	done    chan struct{}
+}
+
+// barriers contains all of the currently not released barriers in the
+// instance, shared between all of the VUs.
+type barriers struct {
+	mx     sync.Mutex
+	active map[string]*barrier
+}
+
+func newBarriers() *barriers {
+	return &barriers{active: make(map[string]*barrier)}
+}
+
+// arrive registers a VU at the barrier with the given name, creating it if
+// necessary, and returns the barrier and whether it was released by this VU.
+func (bs *barriers) arrive(name string, parties int64) (*barrier, bool, error) {
+	bs.mx.Lock()
+	defer bs.mx.Unlock()
+
+	b, ok := bs.active[name]
+	if !ok {
+		b = &barrier{parties: parties, done: make(chan struct{})}
+		bs.active[name] = b
+	} else if b.parties != parties {
+		return nil, false, fmt.Errorf("barrier '%s' is already waiting for %d VUs, not %d", name, b.parties, parties)
+	}
+
+	b.arrived++
+	if b.arrived < b.parties {
+		return b, false, nil
+	}
+	close(b.done)
+	delete(bs.active, name)
+	return b, true, nil
+}
+
+// leave unregisters a VU that stopped waiting on the barrier before it was
+// released. It returns false if the barrier was released in the meantime.
+func (bs *barriers) leave(name string, b *barrier) bool {
+	bs.mx.Lock()
+	defer bs.mx.Unlock()
+
+	select {
+	case <-b.done:
+		return false
+	default:
+	}
+	b.arrived--
+	if b.arrived == 0 {
+		delete(bs.active, name)
+	}
+	return true
+}
+
+// wait blocks until the given number of VUs have reached the barrier with the
+// given name, or until the timeout (if it's more than 0) or the context expire.
+// It returns whether the barrier was released.
+func (bs *barriers) wait(ctx context.Context, name string, parties int64, timeout time.Duration) (bool, error) {
+	b, released, err := bs.arrive(name, parties)
+	if err != nil || released {
+		return released, err
+	}
+
+	timeoutCh, stop := getTimeoutChannel(timeout)
+	defer stop()
+	select {
+	case <-b.done:
+		return true, nil
+	case <-timeoutCh:
+	case <-ctx.Done():
+	}
+	return !bs.leave(name, b), nil
+}
+
+// barrier is the JS function that blocks the current VU until n VUs in the
+// instance have reached the barrier with the given name. It returns false if
+// that didn't happen before the optional timeout.
+func (mi *ModuleInstance) barrier(name string, n int64, opts goja.Value) bool {
+	rt := mi.vu.Runtime()
+	state := mi.vu.State()
+	if state == nil {
+		common.Throw(rt, errors.New("using barriers in the init context is not supported"))
+	}
+	if name == "" {
+		common.Throw(rt, errors.New("the barrier name can't be empty"))
+	}
+	if n < 1 {
+		common.Throw(rt, fmt.Errorf("the number of VUs for barrier '%s' must be at least 1", name))
+	}
+
+	timeout, err := parseTimeout(rt, opts)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid timeout for barrier '%s': %w", name, err))
+	}
+
+	ctx := mi.vu.Context()
+	startTime := time.Now()
+	released, err := mi.barriers.wait(ctx, name, n, timeout)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	ctm := state.Tags.GetCurrentValues()
+	metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: state.BuiltinMetrics.BarrierWaitDuration,
+			Tags:   ctm.Tags.With("barrier", name),
+		},
+		Time:     time.Now(),
+		Value:    metrics.D(time.Since(startTime)),
+		Metadata: ctm.Metadata,
+	})
+	return released
+}
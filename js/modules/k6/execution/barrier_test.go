@@ -0,0 +1,110 @@
+package execution
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestBarriersWait(t *testing.T) {
+	t.Parallel()
+
+	bs := newBarriers()
+	var (
+		wg       sync.WaitGroup
+		released int64
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := bs.wait(context.Background(), "herd", 3, 0)
+			assert.NoError(t, err)
+			if ok {
+				atomic.AddInt64(&released, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(3), released)
+	assert.Empty(t, bs.active)
+
+	ok, err := bs.wait(context.Background(), "herd", 2, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, bs.active)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ok, err := bs.wait(ctx, "stampede", 2, 0)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}()
+	require.Eventually(t, func() bool {
+		bs.mx.Lock()
+		defer bs.mx.Unlock()
+		return bs.active["stampede"] != nil
+	}, time.Second, time.Millisecond)
+	_, err = bs.wait(context.Background(), "stampede", 3, 0)
+	assert.EqualError(t, err, "barrier 'stampede' is already waiting for 2 VUs, not 3")
+	cancel()
+}
+
+func TestBarrierJS(t *testing.T) {
+	t.Parallel()
+
+	root := New()
+	samples := make(chan metrics.SampleContainer, 10)
+	newVU := func() *modulestest.Runtime {
+		runtime := modulestest.NewRuntime(t)
+		m, ok := root.NewModuleInstance(runtime.VU).(*ModuleInstance)
+		require.True(t, ok)
+		require.NoError(t, runtime.VU.Runtime().Set("exec", m.Exports().Default))
+		runtime.MoveToVUContext(&lib.State{
+			Tags:           lib.NewVUStateTags(metrics.NewRegistry().RootTagSet()),
+			Samples:        samples,
+			BuiltinMetrics: runtime.BuiltinMetrics,
+		})
+		return runtime
+	}
+
+	_, err := newVU().VU.Runtime().RunString(`exec.barrier("herd", 0)`)
+	require.ErrorContains(t, err, "the number of VUs for barrier 'herd' must be at least 1")
+
+	results := make(chan bool, 2)
+	for i := 0; i < 2; i++ {
+		runtime := newVU()
+		go func() {
+			v, err := runtime.VU.Runtime().RunString(`exec.barrier("herd", 2, { timeout: "5s" })`)
+			assert.NoError(t, err)
+			results <- v.ToBoolean()
+		}()
+	}
+	assert.True(t, <-results)
+	assert.True(t, <-results)
+
+	v, err := newVU().VU.Runtime().RunString(`exec.barrier("herd", 2, { timeout: 10 })`)
+	require.NoError(t, err)
+	assert.False(t, v.ToBoolean())
+
+	close(samples)
+	count := 0
+	for sc := range samples {
+		for _, s := range sc.GetSamples() {
+			assert.Equal(t, metrics.BarrierWaitDurationName, s.Metric.Name)
+			tag, _ := s.Tags.Get("barrier")
+			assert.Equal(t, "herd", tag)
+			count++
+		}
+	}
+	assert.Equal(t, 3, count)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/dop251/goja"
 
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 	"go.k6.io/k6/lib"
@@ -18,12 +19,17 @@ import (
 type (
 	// RootModule is the global module instance that will create module
 	// instances for each VU.
-	RootModule struct{}
+	RootModule struct {
+		barriers *barriers
+		queues   *queues
+	}
 
 	// ModuleInstance represents an instance of the execution module.
 	ModuleInstance struct {
-		vu  modules.VU
-		obj *goja.Object
+		vu       modules.VU
+		obj      *goja.Object
+		barriers *barriers
+		queues   *queues
 	}
 )
 
@@ -34,13 +40,13 @@ var (
 
 // New returns a pointer to a new RootModule instance.
 func New() *RootModule {
-	return &RootModule{}
+	return &RootModule{barriers: newBarriers(), queues: newQueues()}
 }
 
 // NewModuleInstance implements the modules.Module interface to return
 // a new instance for each VU.
-func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
-	mi := &ModuleInstance{vu: vu}
+func (rm *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	mi := &ModuleInstance{vu: vu, barriers: rm.barriers, queues: rm.queues}
 	rt := vu.Runtime()
 	o := rt.NewObject()
 	defProp := func(name string, newInfo func() (*goja.Object, error)) {
@@ -59,6 +65,21 @@ func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	defProp("scenario", mi.newScenarioInfo)
 	defProp("test", mi.newTestInfo)
 	defProp("vu", mi.newVUInfo)
+	defProp("iteration", mi.newIterationInfo)
+	defProp("segment", mi.newSegmentInfo)
+	if err := o.Set("barrier", mi.barrier); err != nil {
+		common.Throw(rt, err)
+	}
+	if err := o.Set("queue", mi.queue); err != nil {
+		common.Throw(rt, err)
+	}
+	store, err := mi.newStore()
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if err := o.Set("store", store); err != nil {
+		common.Throw(rt, err)
+	}
 
 	mi.obj = o
 
@@ -107,6 +128,28 @@ func (mi *ModuleInstance) newScenarioInfo() (*goja.Object, error) {
 			p, _ := getScenarioState().ProgressFn()
 			return p
 		},
+		"eta": func() interface{} {
+			// The remaining time in milliseconds, extrapolated from the
+			// progress so far, or null before there is any.
+			ss := getScenarioState()
+			p, _ := ss.ProgressFn()
+			if p <= 0 {
+				return nil
+			}
+			if p >= 1 {
+				return 0.0
+			}
+			elapsed := float64(time.Since(ss.StartTime)) / float64(time.Millisecond)
+			return elapsed * (1 - p) / p
+		},
+		"stage": func() interface{} {
+			ss := getScenarioState()
+			if ss.StageFn == nil {
+				return nil
+			}
+			index, target := ss.StageFn()
+			return map[string]interface{}{"index": index, "target": target}
+		},
 		"iterationInInstance": func() interface{} {
 			if vuState.GetScenarioLocalVUIter == nil {
 				common.Throw(rt, errRunInInitContext)
@@ -166,13 +209,7 @@ func (mi *ModuleInstance) newTestInfo() (*goja.Object, error) {
 	ti := map[string]func() interface{}{
 		// stop the test run
 		"abort": func() interface{} {
-			return func(msg goja.Value) {
-				reason := errext.AbortTest
-				if msg != nil && !goja.IsUndefined(msg) {
-					reason = fmt.Sprintf("%s: %s", reason, msg.String())
-				}
-				rt.Interrupt(&errext.InterruptError{Reason: reason})
-			}
+			return mi.abort
 		},
 		"options": func() interface{} {
 			if optionsObject == nil {
@@ -184,11 +221,60 @@ func (mi *ModuleInstance) newTestInfo() (*goja.Object, error) {
 			}
 			return optionsObject
 		},
+		"parameters": func() interface{} {
+			es := lib.GetExecutionState(mi.vu.Context())
+			if es == nil {
+				common.Throw(rt, errors.New("getting the test parameters in the init context is not supported"))
+			}
+			params, err := parametersAsObject(rt, es.LiveConfig.GetParameters())
+			if err != nil {
+				common.Throw(rt, err)
+			}
+			return params
+		},
 	}
 
 	return newInfoObj(rt, ti)
 }
 
+// abort stops the test run, either right away or, with the graceful option,
+// once the running iterations have finished. The argument is either the
+// reason of the abort or an object like `{reason, exitCode, graceful}`.
+func (mi *ModuleInstance) abort(arg goja.Value) {
+	rt := mi.vu.Runtime()
+	interruptErr := &errext.InterruptError{Reason: errext.AbortTest}
+
+	var msg goja.Value
+	if obj, ok := arg.(*goja.Object); ok && obj.ClassName() == "Object" {
+		msg = obj.Get("reason")
+		if code := obj.Get("exitCode"); code != nil && !goja.IsUndefined(code) {
+			exitCode := code.ToInteger()
+			if exitCode < 1 || exitCode > 255 {
+				common.Throw(rt, fmt.Errorf("the exitCode of test.abort() must be between 1 and 255, got %d", exitCode))
+			}
+			interruptErr.Code = exitcodes.ExitCode(exitCode)
+		}
+		if graceful := obj.Get("graceful"); graceful != nil {
+			interruptErr.Graceful = graceful.ToBoolean()
+		}
+	} else {
+		msg = arg
+	}
+	if msg != nil && !goja.IsUndefined(msg) {
+		interruptErr.Reason = fmt.Sprintf("%s: %s", interruptErr.Reason, msg.String())
+	}
+
+	// the running iteration continues when the test is aborted gracefully,
+	// there's nothing to wait for in the init context though
+	if interruptErr.Graceful {
+		if es := lib.GetExecutionState(mi.vu.Context()); es != nil {
+			es.AbortGracefully(interruptErr)
+			return
+		}
+	}
+	rt.Interrupt(interruptErr)
+}
+
 // newVUInfo returns a goja.Object with property accessors to retrieve
 // information about the currently executing VU.
 func (mi *ModuleInstance) newVUInfo() (*goja.Object, error) {
@@ -249,6 +335,33 @@ func (mi *ModuleInstance) newVUInfo() (*goja.Object, error) {
 	return o, err
 }
 
+// newIterationInfo returns a goja.Object with property accessors to retrieve
+// information about the currently running iteration.
+func (mi *ModuleInstance) newIterationInfo() (*goja.Object, error) {
+	vuState := mi.vu.State()
+	if vuState == nil {
+		return nil, errors.New("getting iteration information in the init context is not supported")
+	}
+	rt := mi.vu.Runtime()
+
+	return newInfoObj(rt, map[string]func() interface{}{
+		"context": func() interface{} {
+			ic := vuState.IterationContext
+			if ic == nil {
+				common.Throw(rt, errors.New("getting the iteration context outside of an iteration is not supported"))
+			}
+			o, err := newInfoObj(rt, map[string]func() interface{}{
+				"correlationId": func() interface{} { return ic.CorrelationID },
+				"startTime":     func() interface{} { return ic.StartTime.UnixNano() / int64(time.Millisecond) },
+			})
+			if err != nil {
+				common.Throw(rt, err)
+			}
+			return o
+		},
+	})
+}
+
 func newInfoObj(rt *goja.Runtime, props map[string]func() interface{}) (*goja.Object, error) {
 	o := rt.NewObject()
 
@@ -325,6 +438,32 @@ func optionsAsObject(rt *goja.Runtime, options lib.Options) (*goja.Object, error
 	return obj, nil
 }
 
+// parametersAsObject returns a frozen goja.Object with the current values of
+// the JSON-encoded test parameters, that can be changed during the test run.
+func parametersAsObject(rt *goja.Runtime, parameters map[string][]byte) (*goja.Object, error) {
+	raw := make(map[string]json.RawMessage, len(parameters))
+	for k, data := range parameters {
+		raw[k] = data
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the test parameters as json: %w", err)
+	}
+
+	// Using the native JS parser, like for the options, so the object can be
+	// deeply frozen.
+	jsonParse, _ := goja.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("parse"))
+	parsed, err := jsonParse(goja.Undefined(), rt.ToValue(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	obj := parsed.ToObject(rt)
+	if freezeErr := common.FreezeObject(rt, obj); freezeErr != nil {
+		return nil, freezeErr
+	}
+	return obj, nil
+}
+
 type tagsDynamicObject struct {
 	runtime *goja.Runtime
 	state   *lib.State
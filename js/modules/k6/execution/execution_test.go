@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modulestest"
 	"go.k6.io/k6/lib"
@@ -218,12 +219,60 @@ func TestAbortTest(t *testing.T) { //nolint:tparallel
 	t.Run("custom reason", func(t *testing.T) { //nolint:paralleltest
 		prove(t, `exec.test.abort("mayday")`, fmt.Sprintf("%s: mayday", errext.AbortTest))
 	})
+	t.Run("custom exit code", func(t *testing.T) { //nolint:paralleltest
+		_, err := rt.RunString(`exec.test.abort({reason: "mayday", exitCode: 42})`)
+		var x *goja.InterruptedError
+		require.ErrorAs(t, err, &x)
+		v, ok := x.Value().(*errext.InterruptError)
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("%s: mayday", errext.AbortTest), v.Reason)
+		assert.Equal(t, exitcodes.ExitCode(42), v.ExitCode())
+		assert.False(t, v.Graceful)
+	})
+	t.Run("invalid exit code", func(t *testing.T) { //nolint:paralleltest
+		_, err := rt.RunString(`exec.test.abort({exitCode: 256})`)
+		assert.ErrorContains(t, err, "the exitCode of test.abort() must be between 1 and 255, got 256")
+	})
+}
+
+func TestAbortTestGracefully(t *testing.T) {
+	t.Parallel()
+
+	rt := modulestest.NewRuntime(t)
+	m, ok := New().NewModuleInstance(rt.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.VU.Runtime().Set("exec", m.Exports().Default))
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(nil, et, 0, 0)
+	rt.VU.CtxField = lib.WithExecutionState(rt.VU.CtxField, es)
+	rt.VU.StateField = &lib.State{}
+
+	val, err := rt.VU.Runtime().RunString(`
+		exec.test.abort({reason: "mayday", exitCode: 42, graceful: true});
+		exec.test.abort({reason: "ignored", graceful: true});
+		"continued";
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "continued", val.String())
+
+	select {
+	case <-es.GracefulAbortNotify():
+	default:
+		t.Fatal("the test wasn't aborted")
+	}
+	var interruptErr *errext.InterruptError
+	require.ErrorAs(t, es.GetGracefulAbortReason(), &interruptErr)
+	assert.Equal(t, fmt.Sprintf("%s: mayday", errext.AbortTest), interruptErr.Reason)
+	assert.Equal(t, exitcodes.ExitCode(42), interruptErr.ExitCode())
+	assert.True(t, interruptErr.Graceful)
 }
 
 func TestOptionsTestFull(t *testing.T) {
 	t.Parallel()
 
-	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
+	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","dependsOn":null,"startWhen":null,"abortOnErrorRate":null,"discardResponseBodies":null,"vuBandwidth":null,"bandwidth":null,"localIPs":null,"proxy":null,"http2":null,"tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","correlationIdHeader":"X-Request-ID","tracePropagator":"w3c","traceSampling":0.5,"batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCurves":null,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"tlsSessionResumption":null,"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"compositeThresholds":{"error_ratio":["errors.count / http_reqs.count < 0.01"]},"slos":{"checkout":{"metric":"http_req_duration{scenario:checkout}","target":"300ms","tolerating":"0s","errorBudget":0.01,"windows":["5m0s","1h0m0s"]}},"abortOnErrorRate":{"rate":0.1,"window":"30s","minRequests":0},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTrendStatsPerMetric":{"http_req_duration":["p(99.9)","tmean(10)"]},"summaryBreakdown":["scenario","group"],"summaryTimeSeries":null,"summaryTimeUnit":"ms","trendSignificantDigits":4,"maxTimeSeries":1000,"maxTimeSeriesAction":"overflow","maxMemory":null,"generatorMetrics":null,"warmVUs":null,"systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"captureFailures":null,"captureFailuresLimit":null,"captureFailuresBodySize":null,"faults":null,"vuBandwidth":null,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
 
 	var (
 		rt    = goja.New()
@@ -275,6 +324,9 @@ func TestOptionsTestFull(t *testing.T) {
 				RPS:                   null.IntFrom(100),
 				MaxRedirects:          null.IntFrom(3),
 				UserAgent:             null.StringFrom("k6-user-agent"),
+				CorrelationIDHeader:   null.StringFrom("X-Request-ID"),
+				TracePropagator:       null.StringFrom("w3c"),
+				TraceSampling:         null.FloatFrom(0.5),
 				Batch:                 null.IntFrom(15),
 				BatchPerHost:          null.IntFrom(5),
 				SetupTimeout:          types.NullDurationFrom(1 * time.Minute),
@@ -325,6 +377,22 @@ func TestOptionsTestFull(t *testing.T) {
 						},
 					},
 				},
+				CompositeThresholds: map[string]metrics.CompositeThresholds{
+					"error_ratio": {
+						Thresholds: []*metrics.CompositeThreshold{
+							{Source: "errors.count / http_reqs.count < 0.01"},
+						},
+					},
+				},
+				SLOs: map[string]metrics.SLO{
+					"checkout": {
+						Metric:      "http_req_duration{scenario:checkout}",
+						Target:      types.Duration(300 * time.Millisecond),
+						ErrorBudget: 0.01,
+						Windows:     []types.Duration{types.Duration(5 * time.Minute), types.Duration(time.Hour)},
+					},
+				},
+				AbortOnErrorRate: &metrics.ErrorRateAbort{Rate: 0.1, Window: types.Duration(30 * time.Second)},
 				BlockedHostnames: func() types.NullHostnameTrie {
 					bh, err := types.NewNullHostnameTrie([]string{"test.k6.io", "*.example.com"})
 					require.NoError(t, err)
@@ -344,7 +412,14 @@ func TestOptionsTestFull(t *testing.T) {
 					"ext-one": json.RawMessage(`{"rawkey":"rawvalue"}`),
 				},
 				SummaryTrendStats: []string{"avg", "min", "max"},
-				SummaryTimeUnit:   null.StringFrom("ms"),
+				SummaryTrendStatsPerMetric: map[string][]string{
+					"http_req_duration": {"p(99.9)", "tmean(10)"},
+				},
+				SummaryBreakdown:       []string{"scenario", "group"},
+				SummaryTimeUnit:        null.StringFrom("ms"),
+				TrendSignificantDigits: null.IntFrom(4),
+				MaxTimeSeries:          null.IntFrom(1000),
+				MaxTimeSeriesAction:    null.StringFrom("overflow"),
 				SystemTags: func() *metrics.SystemTagSet {
 					sysm := metrics.SystemTagSet(metrics.TagIter | metrics.TagVU)
 					return &sysm
@@ -416,6 +491,34 @@ func TestOptionsTestSetPropertyDenied(t *testing.T) {
 	assert.Equal(t, true, rt.ToValue(paused).ToBoolean())
 }
 
+func TestTestParameters(t *testing.T) {
+	t.Parallel()
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(nil, et, 0, 0)
+	es.LiveConfig.UpdateParameters(map[string][]byte{"rate": []byte(`5`), "flags": []byte(`{"beta":true}`)}, nil)
+
+	rt := setupTagsExecEnv(t)
+	rt.VU.CtxField = lib.WithExecutionState(rt.VU.CtxField, es)
+	v, err := rt.VU.Runtime().RunString(`
+		const params = exec.test.parameters;
+		params.rate = 10;
+		params.rate + " " + params.flags.beta + " " + params.missing;
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "5 true undefined", v.String())
+
+	es.LiveConfig.UpdateParameters(map[string][]byte{"rate": []byte(`7`)}, []string{"flags"})
+	v, err = rt.VU.Runtime().RunString(`exec.test.parameters.rate + " " + exec.test.parameters.flags`)
+	require.NoError(t, err)
+	assert.Equal(t, "7 undefined", v.String())
+
+	rt = setupTagsExecEnv(t)
+	_, err = rt.VU.Runtime().RunString(`exec.test.parameters`)
+	require.ErrorContains(t, err, "getting the test parameters in the init context is not supported")
+}
+
 func TestScenarioNoAvailableInInitContext(t *testing.T) {
 	t.Parallel()
 
@@ -434,7 +537,9 @@ func TestScenarioNoAvailableInInitContext(t *testing.T) {
 	require.True(t, ok)
 	require.NoError(t, rt.Set("exec", m.Exports().Default))
 
-	scenarioExportedProps := []string{"name", "executor", "startTime", "progress", "iterationInInstance", "iterationInTest"}
+	scenarioExportedProps := []string{
+		"name", "executor", "startTime", "progress", "eta", "stage", "iterationInInstance", "iterationInTest",
+	}
 
 	for _, code := range scenarioExportedProps {
 		prop := fmt.Sprintf("exec.scenario.%s", code)
@@ -444,6 +549,39 @@ func TestScenarioNoAvailableInInitContext(t *testing.T) {
 	}
 }
 
+func TestScenarioProgress(t *testing.T) {
+	t.Parallel()
+
+	tenv := setupTagsExecEnv(t)
+	tenv.MoveToVUContext(&lib.State{})
+	progress := 0.0
+	ss := &lib.ScenarioState{
+		Name:       "ramp",
+		Executor:   "ramping-vus",
+		StartTime:  time.Now().Add(-10 * time.Second),
+		ProgressFn: func() (float64, []string) { return progress, nil },
+	}
+	tenv.VU.CtxField = lib.WithScenarioState(tenv.VU.CtxField, ss)
+
+	res, err := tenv.VU.Runtime().RunString(`exec.scenario.eta === null && exec.scenario.stage === null`)
+	require.NoError(t, err)
+	assert.True(t, res.ToBoolean())
+
+	progress = 0.25
+	ss.StageFn = func() (int, int64) { return 1, 50 }
+	res, err = tenv.VU.Runtime().RunString(`
+		const stage = exec.scenario.stage;
+		[Math.round(exec.scenario.eta / 1000), stage.index, stage.target].join(" ")
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "30 1 50", res.String())
+
+	progress = 1
+	res, err = tenv.VU.Runtime().RunString(`exec.scenario.eta`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.ToInteger())
+}
+
 func TestVUDefaultDetails(t *testing.T) {
 	t.Parallel()
 
@@ -472,6 +610,30 @@ func TestVUDefaultDetails(t *testing.T) {
 	}
 }
 
+func TestIterationContext(t *testing.T) {
+	t.Parallel()
+
+	tenv := setupTagsExecEnv(t)
+	_, err := tenv.VU.Runtime().RunString(`exec.iteration`)
+	require.ErrorContains(t, err, "getting iteration information in the init context is not supported")
+
+	state := &lib.State{}
+	tenv.MoveToVUContext(state)
+	_, err = tenv.VU.Runtime().RunString(`exec.iteration.context`)
+	require.ErrorContains(t, err, "outside of an iteration is not supported")
+
+	state.IterationContext = &lib.IterationContext{
+		CorrelationID: "0af7651916cd43dd8448eb211c80319c",
+		StartTime:     time.UnixMilli(1700000000000),
+	}
+	res, err := tenv.VU.Runtime().RunString(`
+		const ctx = exec.iteration.context;
+		ctx.correlationId + " " + ctx.startTime
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c 1700000000000", res.String())
+}
+
 func TestTagsDynamicObjectGet(t *testing.T) {
 	t.Parallel()
 	rt := goja.New()
@@ -501,3 +663,87 @@ func TestTagsDynamicObjectSet(t *testing.T) {
 	require.NotNil(t, val)
 	assert.Equal(t, val.String(), "v1")
 }
+
+func TestSegment(t *testing.T) {
+	t.Parallel()
+
+	seq, err := lib.NewExecutionSegmentSequenceFromString("0,1/3,1")
+	require.NoError(t, err)
+
+	var allIndexes []int64
+	for i, segmentStr := range []string{"0:1/3", "1/3:1"} {
+		segment, segErr := lib.NewExecutionSegmentFromString(segmentStr)
+		require.NoError(t, segErr)
+		et, segErr := lib.NewExecutionTuple(segment, &seq)
+		require.NoError(t, segErr)
+
+		rt := setupTagsExecEnv(t)
+		rt.VU.CtxField = lib.WithExecutionState(rt.VU.CtxField, lib.NewExecutionState(nil, et, 0, 0))
+		v, segErr := rt.VU.Runtime().RunString(`
+			const s = exec.segment;
+			JSON.stringify({
+				index: s.index,
+				count: s.count,
+				start: s.start,
+				end: s.end,
+				scale: s.scale(10),
+				iota: s.iota(10),
+				slice: s.slice(['a', 'b', 'c', 'd', 'e', 'f']),
+			});
+		`)
+		require.NoError(t, segErr)
+
+		var result struct {
+			Index, Count int
+			Start, End   float64
+			Scale        int64
+			Iota         []int64
+			Slice        []string
+		}
+		require.NoError(t, json.Unmarshal([]byte(v.String()), &result))
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, 2, result.Count)
+		assert.Equal(t, segment.FloatStart(), result.Start)
+		assert.Equal(t, segment.FloatEnd(), result.End)
+		assert.Equal(t, et.ScaleInt64(10), result.Scale)
+		assert.Len(t, result.Iota, int(result.Scale))
+		assert.Len(t, result.Slice, int(et.ScaleInt64(6)))
+		for j, index := range result.Iota {
+			if index < 6 {
+				assert.Equal(t, string(rune('a'+index)), result.Slice[j])
+			}
+		}
+		allIndexes = append(allIndexes, result.Iota...)
+
+		_, segErr = rt.VU.Runtime().RunString(`exec.segment.iota(-1)`)
+		require.ErrorContains(t, segErr, "the total must be a non-negative number")
+		_, segErr = rt.VU.Runtime().RunString(`exec.segment.slice()`)
+		require.ErrorContains(t, segErr, "an array or a SharedArray is required")
+		_, segErr = rt.VU.Runtime().RunString(`exec.segment.slice(['a', 'b', 'c'])[0] = 'd'`)
+		require.ErrorContains(t, segErr, "the segment slice is read-only")
+	}
+	assert.ElementsMatch(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, allIndexes)
+
+	rt := setupTagsExecEnv(t)
+	_, err = rt.VU.Runtime().RunString(`exec.segment`)
+	require.ErrorContains(t, err, "getting the execution segment in the init context is not supported")
+}
+
+func TestSegmentView(t *testing.T) {
+	t.Parallel()
+
+	seq, err := lib.NewExecutionSegmentSequenceFromString("0,1/4,1/3,2/5,1")
+	require.NoError(t, err)
+	for _, segment := range seq {
+		et, err := lib.NewExecutionTuple(segment, &seq)
+		require.NoError(t, err)
+		for _, total := range []int64{0, 1, 7, 60, 1001} {
+			indexes := segmentIndexes(et, total)
+			sv := newSegmentView(goja.New(), et, nil, total)
+			require.Equal(t, len(indexes), sv.Len(), "segment %s, total %d", segment, total)
+			for i, index := range indexes {
+				assert.Equal(t, index, sv.backingIndex(i), "segment %s, total %d, index %d", segment, total, i)
+			}
+		}
+	}
+}
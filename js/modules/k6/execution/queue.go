@@ -0,0 +1,203 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const defaultQueueCapacity = 1000
+
+// queue is a bounded FIFO queue of JSON-encoded items, that VUs from
+// different scenarios can use to pass work to each other. Pushing to a full
+// queue blocks, so slow consumers apply backpressure on the producers.
+type queue struct {
+	items chan []byte
+}
+
+// queues contains all of the queues in the instance, shared between all of
+// the VUs.
+type queues struct {
+	mx     sync.Mutex
+	queues map[string]*queue
+}
+
+func newQueues() *queues {
+	return &queues{queues: make(map[string]*queue)}
+}
+
+// get returns the queue with the given name, creating it if necessary. A
+// capacity of 0 means that either the capacity of the existing queue or the
+// default one should be used.
+func (qs *queues) get(name string, capacity int64) (*queue, error) {
+	qs.mx.Lock()
+	defer qs.mx.Unlock()
+
+	q, ok := qs.queues[name]
+	if !ok {
+		if capacity == 0 {
+			capacity = defaultQueueCapacity
+		}
+		q = &queue{items: make(chan []byte, capacity)}
+		qs.queues[name] = q
+	} else if capacity != 0 && int64(cap(q.items)) != capacity {
+		return nil, fmt.Errorf("queue '%s' already exists with a capacity of %d, not %d", name, cap(q.items), capacity)
+	}
+	return q, nil
+}
+
+// push adds the item to the queue, waiting for up to timeout (if it's more
+// than 0) for there to be free space. It returns whether the item was added.
+func (q *queue) push(ctx context.Context, item []byte, timeout time.Duration) bool {
+	select {
+	case q.items <- item:
+		return true
+	default:
+	}
+	timeoutCh, stop := getTimeoutChannel(timeout)
+	defer stop()
+	select {
+	case q.items <- item:
+		return true
+	case <-timeoutCh:
+	case <-ctx.Done():
+	}
+	return false
+}
+
+// pop removes and returns the oldest item in the queue, waiting for up to
+// timeout (if it's more than 0) for one to be pushed if the queue is empty.
+func (q *queue) pop(ctx context.Context, timeout time.Duration) ([]byte, bool) {
+	select {
+	case item := <-q.items:
+		return item, true
+	default:
+	}
+	timeoutCh, stop := getTimeoutChannel(timeout)
+	defer stop()
+	select {
+	case item := <-q.items:
+		return item, true
+	case <-timeoutCh:
+	case <-ctx.Done():
+	}
+	return nil, false
+}
+
+// getTimeoutChannel returns a channel that is closed after the timeout or a
+// nil one, which blocks forever, if the timeout isn't more than 0.
+func getTimeoutChannel(timeout time.Duration) (<-chan time.Time, func()) {
+	if timeout <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(timeout)
+	return timer.C, func() { timer.Stop() }
+}
+
+// parseTimeout returns the timeout option from the given JS object, if any.
+func parseTimeout(rt *goja.Runtime, opts goja.Value) (time.Duration, error) {
+	if common.IsNullish(opts) {
+		return 0, nil
+	}
+	t := opts.ToObject(rt).Get("timeout")
+	if common.IsNullish(t) {
+		return 0, nil
+	}
+	return types.GetDurationValue(t.Export())
+}
+
+// queue is the JS function that returns an object for pushing items to and
+// popping items from the queue with the given name, that is shared between
+// all of the VUs in the instance.
+func (mi *ModuleInstance) queue(name string, opts goja.Value) *goja.Object {
+	rt := mi.vu.Runtime()
+	if name == "" {
+		common.Throw(rt, errors.New("the queue name can't be empty"))
+	}
+	var capacity int64
+	if !common.IsNullish(opts) {
+		if c := opts.ToObject(rt).Get("capacity"); !common.IsNullish(c) {
+			if capacity = c.ToInteger(); capacity < 1 {
+				common.Throw(rt, fmt.Errorf("the capacity of queue '%s' must be at least 1", name))
+			}
+		}
+	}
+	q, err := mi.queues.get(name, capacity)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	// emitLength pushes the current length of the queue as a metric sample
+	// and it can only be called in the VU context.
+	emitLength := func() {
+		state := mi.vu.State()
+		ctm := state.Tags.GetCurrentValues()
+		metrics.PushIfNotDone(mi.vu.Context(), state.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: state.BuiltinMetrics.QueueLength,
+				Tags:   ctm.Tags.With("queue", name),
+			},
+			Time:     time.Now(),
+			Value:    float64(len(q.items)),
+			Metadata: ctm.Metadata,
+		})
+	}
+	checkVUContext := func(method string) {
+		if mi.vu.State() == nil {
+			common.Throw(rt, fmt.Errorf("using %s() of queue '%s' in the init context is not supported", method, name))
+		}
+	}
+
+	o := rt.NewObject()
+	methods := map[string]interface{}{
+		"push": func(item goja.Value, opts goja.Value) bool {
+			checkVUContext("push")
+			timeout, err := parseTimeout(rt, opts)
+			if err != nil {
+				common.Throw(rt, fmt.Errorf("invalid timeout for queue '%s': %w", name, err))
+			}
+			data, err := json.Marshal(item.Export())
+			if err != nil {
+				common.Throw(rt, fmt.Errorf("the item can't be pushed to queue '%s': %w", name, err))
+			}
+			pushed := q.push(mi.vu.Context(), data, timeout)
+			emitLength()
+			return pushed
+		},
+		"pop": func(opts goja.Value) goja.Value {
+			checkVUContext("pop")
+			timeout, err := parseTimeout(rt, opts)
+			if err != nil {
+				common.Throw(rt, fmt.Errorf("invalid timeout for queue '%s': %w", name, err))
+			}
+			data, ok := q.pop(mi.vu.Context(), timeout)
+			emitLength()
+			if !ok {
+				return goja.Undefined()
+			}
+			var item interface{}
+			if err := json.Unmarshal(data, &item); err != nil {
+				common.Throw(rt, err)
+			}
+			return rt.ToValue(item)
+		},
+		"size": func() int {
+			return len(q.items)
+		},
+	}
+	for method, fn := range methods {
+		if err := o.Set(method, fn); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return o
+}
@@ -0,0 +1,62 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	root := New()
+	samples := make(chan metrics.SampleContainer, 100)
+	newVU := func() *modulestest.Runtime {
+		runtime := modulestest.NewRuntime(t)
+		m, ok := root.NewModuleInstance(runtime.VU).(*ModuleInstance)
+		require.True(t, ok)
+		require.NoError(t, runtime.VU.Runtime().Set("exec", m.Exports().Default))
+		runtime.MoveToVUContext(&lib.State{
+			Tags:           lib.NewVUStateTags(metrics.NewRegistry().RootTagSet()),
+			Samples:        samples,
+			BuiltinMetrics: runtime.BuiltinMetrics,
+		})
+		return runtime
+	}
+
+	producer := newVU()
+	v, err := producer.VU.Runtime().RunString(`
+		const q = exec.queue("orders", { capacity: 2 });
+		[q.push({ id: 1 }), q.push({ id: 2 }), q.push({ id: 3 }, { timeout: 10 }), q.size()].join(",");
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "true,true,false,2", v.String())
+
+	_, err = producer.VU.Runtime().RunString(`exec.queue("orders", { capacity: 3 })`)
+	require.ErrorContains(t, err, "queue 'orders' already exists with a capacity of 2, not 3")
+
+	consumer := newVU()
+	v, err = consumer.VU.Runtime().RunString(`
+		const q = exec.queue("orders");
+		const ids = [q.pop().id, q.pop().id];
+		ids.push(q.pop({ timeout: "10ms" }));
+		ids.join(",");
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "1,2,", v.String())
+
+	close(samples)
+	var lengths []float64
+	for sc := range samples {
+		for _, s := range sc.GetSamples() {
+			assert.Equal(t, metrics.QueueLengthName, s.Metric.Name)
+			lengths = append(lengths, s.Value)
+		}
+	}
+	assert.Equal(t, []float64{1, 2, 2, 1, 0, 0}, lengths)
+}
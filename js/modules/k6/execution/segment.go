@@ -0,0 +1,151 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
+)
+
+// newSegmentInfo returns a goja.Object with information about the execution
+// segment of the current instance and with helpers for partitioning data
+// between the instances, so each of them uses a disjoint part of it.
+func (mi *ModuleInstance) newSegmentInfo() (*goja.Object, error) {
+	es := lib.GetExecutionState(mi.vu.Context())
+	if es == nil {
+		return nil, errors.New("getting the execution segment in the init context is not supported")
+	}
+	rt := mi.vu.Runtime()
+	et := es.ExecutionTuple
+
+	si := map[string]func() interface{}{
+		"index": func() interface{} { return et.SegmentIndex },
+		"count": func() interface{} { return len(et.Sequence.ExecutionSegmentSequence) },
+		"start": func() interface{} { return et.Segment.FloatStart() },
+		"end":   func() interface{} { return et.Segment.FloatEnd() },
+	}
+	o, err := newInfoObj(rt, si)
+	if err != nil {
+		return nil, err
+	}
+
+	getLength := func(v goja.Value) int64 {
+		if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+			common.Throw(rt, errors.New("an array or a SharedArray is required"))
+		}
+		return v.ToObject(rt).Get("length").ToInteger()
+	}
+	methods := map[string]interface{}{
+		// scale returns how many of the given number of items belong to
+		// the current instance.
+		"scale": func(total int64) int64 {
+			if total < 0 {
+				common.Throw(rt, fmt.Errorf("the total must be a non-negative number, but it's %d", total))
+			}
+			return et.ScaleInt64(total)
+		},
+		// iota returns the indexes in [0, total) that belong to the current
+		// instance, in ascending order.
+		"iota": func(total int64) []int64 {
+			if total < 0 {
+				common.Throw(rt, fmt.Errorf("the total must be a non-negative number, but it's %d", total))
+			}
+			return segmentIndexes(et, total)
+		},
+		// slice returns a read-only view of the elements of the given array
+		// (or SharedArray) at the indexes that belong to the current
+		// instance. Nothing is copied, the elements are only read from the
+		// given array when they are accessed.
+		"slice": func(v goja.Value) goja.Value {
+			length := getLength(v)
+			return rt.NewDynamicArray(newSegmentView(rt, et, v.ToObject(rt), length))
+		},
+	}
+	for name, method := range methods {
+		if err := o.Set(name, method); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// segmentView is a read-only array that maps its indexes onto the indexes of
+// the backing array that are in the execution segment, using the same
+// striping as segmentIndexes, but without going through all of them.
+type segmentView struct {
+	rt      *goja.Runtime
+	backing *goja.Object
+	length  int
+
+	// start and lcd are the striped offsets of the execution tuple, and
+	// cumulative has the sums of its offsets, so the backing index of any
+	// element can be calculated directly.
+	start, lcd int64
+	cumulative []int64
+}
+
+var _ goja.DynamicArray = &segmentView{}
+
+func newSegmentView(rt *goja.Runtime, et *lib.ExecutionTuple, backing *goja.Object, total int64) *segmentView {
+	start, offsets, lcd := et.GetStripedOffsets()
+	sv := &segmentView{rt: rt, backing: backing, start: start, lcd: lcd}
+	if len(offsets) == 0 {
+		return sv // an empty segment
+	}
+	sv.length = int(et.ScaleInt64(total))
+	sv.cumulative = make([]int64, len(offsets))
+	for i := 1; i < len(offsets); i++ {
+		sv.cumulative[i] = sv.cumulative[i-1] + offsets[i-1]
+	}
+	return sv
+}
+
+// backingIndex returns the index in the backing array of the element with
+// the given index in the view. The offsets repeat every lcd indexes.
+func (sv *segmentView) backingIndex(index int) int64 {
+	cycles, rest := int64(index)/int64(len(sv.cumulative)), index%len(sv.cumulative)
+	return cycles*sv.lcd + sv.start + sv.cumulative[rest]
+}
+
+func (sv *segmentView) Len() int {
+	return sv.length
+}
+
+func (sv *segmentView) Get(index int) goja.Value {
+	if index < 0 || index >= sv.length {
+		return goja.Undefined()
+	}
+	return sv.backing.Get(strconv.FormatInt(sv.backingIndex(index), 10))
+}
+
+func (sv *segmentView) Set(_ int, _ goja.Value) bool {
+	panic(sv.rt.NewTypeError("the segment slice is read-only"))
+}
+
+func (sv *segmentView) SetLen(_ int) bool {
+	panic(sv.rt.NewTypeError("the segment slice is read-only"))
+}
+
+// segmentIndexes returns the indexes in [0, total) that are in the execution
+// segment of the given tuple, with the same striping that is used for
+// partitioning the VUs and the iterations between the instances.
+func segmentIndexes(et *lib.ExecutionTuple, total int64) []int64 {
+	if _, offsets, _ := et.GetStripedOffsets(); len(offsets) == 0 {
+		return []int64{} // an empty segment
+	}
+
+	indexes := make([]int64, 0, et.ScaleInt64(total))
+	segmentedIndex := lib.NewSegmentedIndex(et)
+	for {
+		_, unscaled := segmentedIndex.Next()
+		if unscaled > total {
+			return indexes
+		}
+		indexes = append(indexes, unscaled-1) // the unscaled values start from 1
+	}
+}
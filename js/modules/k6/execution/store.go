@@ -0,0 +1,91 @@
+package execution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
+)
+
+// newStore returns a goja.Object with the methods of the key-value store,
+// that is shared between all VUs and scenarios of the test run.
+func (mi *ModuleInstance) newStore() (*goja.Object, error) {
+	rt := mi.vu.Runtime()
+	getStore := func() lib.SharedStore {
+		es := lib.GetExecutionState(mi.vu.Context())
+		if es == nil {
+			common.Throw(rt, errors.New("using the shared store in the init context is not supported"))
+		}
+		return es.SharedStore
+	}
+	marshal := func(key string, v goja.Value) []byte {
+		value, err := json.Marshal(v.Export())
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("the value for key '%s' can't be stored: %w", key, err))
+		}
+		return value
+	}
+
+	o := rt.NewObject()
+	methods := map[string]interface{}{
+		"get": func(key string) goja.Value {
+			value, ok, err := getStore().Get(key)
+			if err != nil {
+				common.Throw(rt, err)
+			}
+			if !ok {
+				return goja.Undefined()
+			}
+			var v interface{}
+			if err := json.Unmarshal(value, &v); err != nil {
+				common.Throw(rt, err)
+			}
+			return rt.ToValue(v)
+		},
+		"set": func(key string, v goja.Value) {
+			if err := getStore().Set(key, marshal(key, v)); err != nil {
+				common.Throw(rt, err)
+			}
+		},
+		"delete": func(key string) {
+			if err := getStore().Delete(key); err != nil {
+				common.Throw(rt, err)
+			}
+		},
+		"incr": func(key string, delta goja.Value) int64 {
+			d := int64(1)
+			if !common.IsNullish(delta) {
+				d = delta.ToInteger()
+			}
+			result, err := getStore().Incr(key, d)
+			if err != nil {
+				common.Throw(rt, err)
+			}
+			return result
+		},
+		// compareAndSwap sets the new value only if the current one is equal
+		// to the expected one. An undefined expected value means that the key
+		// shouldn't exist.
+		"compareAndSwap": func(key string, expected, v goja.Value) bool {
+			var oldValue []byte
+			if expected != nil && !goja.IsUndefined(expected) {
+				oldValue = marshal(key, expected)
+			}
+			swapped, err := getStore().CompareAndSwap(key, oldValue, marshal(key, v))
+			if err != nil {
+				common.Throw(rt, err)
+			}
+			return swapped
+		},
+	}
+	for name, method := range methods {
+		if err := o.Set(name, method); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,55 @@
+package execution
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+)
+
+func TestSharedStore(t *testing.T) {
+	t.Parallel()
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(nil, et, 0, 0)
+	newVU := func() *modulestest.Runtime {
+		runtime := modulestest.NewRuntime(t)
+		m, ok := New().NewModuleInstance(runtime.VU).(*ModuleInstance)
+		require.True(t, ok)
+		require.NoError(t, runtime.VU.Runtime().Set("exec", m.Exports().Default))
+		runtime.VU.CtxField = lib.WithExecutionState(runtime.VU.CtxField, es)
+		runtime.MoveToVUContext(&lib.State{})
+		return runtime
+	}
+
+	_, err = newVU().VU.Runtime().RunString(`
+		exec.store.set("token", { value: "abc", scopes: ["read"] });
+		exec.store.incr("id");
+		exec.store.incr("id", 10);
+	`)
+	require.NoError(t, err)
+
+	v, err := newVU().VU.Runtime().RunString(`
+		if (exec.store.compareAndSwap("lock", undefined, true) !== true) throw "lock not acquired";
+		if (exec.store.compareAndSwap("lock", undefined, true) !== false) throw "lock acquired twice";
+		if (exec.store.get("missing") !== undefined) throw "unexpected value";
+		exec.store.delete("lock");
+		const token = exec.store.get("token");
+		token.value + " " + token.scopes[0] + " " + exec.store.get("id") + " " + exec.store.get("lock");
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "abc read 11 undefined", v.String())
+
+	runtime := modulestest.NewRuntime(t)
+	m, ok := New().NewModuleInstance(runtime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, runtime.VU.Runtime().Set("exec", m.Exports().Default))
+	runtime.VU.CtxField = context.Background()
+	_, err = runtime.VU.Runtime().RunString(`exec.store.get("token")`)
+	require.ErrorContains(t, err, "using the shared store in the init context is not supported")
+}
@@ -0,0 +1,199 @@
+// Package expect provides the k6/experimental/expect module, an assertion
+// library for the checks, like `expect(res.status).toBe(200)`, whose failures
+// are explained by structured messages.
+package expect
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/metrics"
+)
+
+// ErrExpectInInitContext is returned when the assertions are used in the init context.
+var ErrExpectInInitContext = common.NewInitContextError("Using expect() in the init context is not supported")
+
+// CheckFailuresSource is the source of the log entries of the failed
+// assertions, and FailureMetadata the metadata of the checks samples with
+// their message.
+const (
+	CheckFailuresSource = "check_failures"
+	FailureMetadata     = "check_failure"
+)
+
+// maxNameValueLength is the length of the expected values over which they are
+// truncated in the names of the checks.
+const maxNameValueLength = 50
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the expect module for a single VU.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new RootModule instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface to return
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports returns the exports of the expect module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"expect": mi.expect,
+		},
+	}
+}
+
+// expectation is the received value of the assertions, with the optional name
+// of their checks.
+type expectation struct {
+	mi       *ModuleInstance
+	received goja.Value
+	name     string
+	negated  bool
+}
+
+// expect returns the matchers of the received value, each of them emits a
+// check which passes if the received value matches.
+func (mi *ModuleInstance) expect(received goja.Value, name string) *goja.Object {
+	e := &expectation{mi: mi, received: received, name: name}
+	obj := e.matchers()
+	negated := &expectation{mi: mi, received: received, name: name, negated: true}
+	if err := obj.Set("not", negated.matchers()); err != nil {
+		common.Throw(mi.vu.Runtime(), err)
+	}
+	return obj
+}
+
+func (e *expectation) matchers() *goja.Object {
+	rt := e.mi.vu.Runtime()
+	obj := rt.NewObject()
+	methods := map[string]any{
+		"toBe":                   e.toBe,
+		"toEqual":                e.toEqual,
+		"toMatchObject":          e.toMatchObject,
+		"toContain":              e.toContain,
+		"toMatch":                e.toMatch,
+		"toBeTruthy":             e.toBeTruthy,
+		"toBeFalsy":              e.toBeFalsy,
+		"toBeGreaterThan":        e.toBeGreaterThan,
+		"toBeGreaterThanOrEqual": e.toBeGreaterThanOrEqual,
+		"toBeLessThan":           e.toBeLessThan,
+		"toBeLessThanOrEqual":    e.toBeLessThanOrEqual,
+	}
+	for name, method := range methods {
+		if err := obj.Set(name, method); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return obj
+}
+
+// assertion is the outcome of a matcher.
+type assertion struct {
+	matcher  string
+	expected goja.Value
+	pass     bool
+	// description is how the received value should have been, e.g. "to be 200"
+	description string
+}
+
+// report emits the check of the assertion and, if it failed, logs why.
+func (e *expectation) report(a assertion) (bool, error) {
+	state := e.mi.vu.State()
+	if state == nil {
+		return false, ErrExpectInInitContext
+	}
+
+	matcher, description := a.matcher, a.description
+	pass := a.pass
+	if e.negated {
+		matcher, description, pass = "not."+matcher, "not "+description, !pass
+	}
+
+	name := e.name
+	if name == "" {
+		name = matcher + "(" + truncate(formatValue(a.expected), maxNameValueLength) + ")"
+	}
+	check, err := state.Group.Check(name)
+	if err != nil {
+		return false, err
+	}
+
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	if state.Options.SystemTags.Has(metrics.TagCheck) {
+		tagsAndMeta.SetTag("check", check.Name)
+	}
+
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: state.BuiltinMetrics.Checks},
+		Time:       time.Now(),
+		Value:      1,
+	}
+	if pass {
+		atomic.AddInt64(&check.Passes, 1)
+	} else {
+		atomic.AddInt64(&check.Fails, 1)
+		sample.Value = 0
+
+		received := formatValue(e.received)
+		message := fmt.Sprintf("expected %s %s", received, description)
+		tagsAndMeta.SetMetadata(FailureMetadata, message)
+
+		fields := logrus.Fields{"source": CheckFailuresSource, "check": check.Name, "matcher": matcher, "received": received}
+		if a.expected != nil {
+			fields["expected"] = formatValue(a.expected)
+		}
+		state.Logger.WithFields(fields).Warn(message)
+	}
+	sample.Tags, sample.Metadata = tagsAndMeta.Tags, tagsAndMeta.Metadata
+	metrics.PushIfNotDone(e.mi.vu.Context(), state.Samples, sample)
+
+	return pass, nil
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length]) + "…"
+}
+
+// formatValue returns the JSON representation of a value, or its string
+// representation for the values which don't have one, like functions.
+func formatValue(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if _, isFunc := goja.AssertFunction(v); !isFunc {
+		if data, err := jsonMarshal(v.Export()); err == nil {
+			return data
+		}
+	}
+	return strings.TrimSpace(v.String())
+}
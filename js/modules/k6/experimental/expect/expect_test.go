@@ -0,0 +1,157 @@
+package expect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+type testCase struct {
+	runtime *modulestest.Runtime
+	samples chan metrics.SampleContainer
+	hook    *testutils.SimpleLogrusHook
+	group   *lib.Group
+}
+
+func setupExpectTest(t *testing.T) *testCase {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.VU.RuntimeField.Set("expect", New().NewModuleInstance(runtime.VU).Exports().Named["expect"]))
+
+	registry := metrics.NewRegistry()
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	samples := make(chan metrics.SampleContainer, 1000)
+	logger, hook := testutils.NewLoggerWithHook(t)
+	runtime.MoveToVUContext(&lib.State{
+		Group:          root,
+		Options:        lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		Samples:        samples,
+		Tags:           lib.NewVUStateTags(registry.RootTagSet().With("group", root.Path)),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Logger:         logger,
+	})
+	return &testCase{runtime: runtime, samples: samples, hook: hook, group: root}
+}
+
+func TestExpectMatchers(t *testing.T) {
+	t.Parallel()
+
+	passing := []string{
+		`expect(200).toBe(200)`,
+		`expect("a").not.toBe("b")`,
+		`expect({ a: [1, 2.5, { b: "c" }] }).toEqual({ a: [1, 2.5, { b: "c" }] })`,
+		`expect({ a: 1, b: { c: 2, d: 3 }, e: [1] }).toMatchObject({ b: { c: 2 }, e: [1] })`,
+		`expect({ a: 1 }).not.toMatchObject({ a: 1, b: 2 })`,
+		`expect("hello world").toContain("wor")`,
+		`expect([1, { a: 2 }]).toContain({ a: 2 })`,
+		`expect([1, 2]).not.toContain(3)`,
+		`expect("status: 404").toMatch(/\d{3}$/)`,
+		`expect("abc").toMatch("^a")`,
+		`expect(1).toBeTruthy()`,
+		`expect("").toBeFalsy()`,
+		`expect(3).toBeGreaterThan(2)`,
+		`expect(3).toBeGreaterThanOrEqual(3)`,
+		`expect(2.5).toBeLessThan(3)`,
+		`expect(3).toBeLessThanOrEqual(3)`,
+		`expect("3").not.toBeGreaterThan(2)`,
+		`expect(NaN).not.toBeLessThan(2)`,
+		`expect(undefined).toBe()`,
+	}
+	for _, code := range passing {
+		code := code
+		t.Run(code, func(t *testing.T) {
+			t.Parallel()
+
+			tc := setupExpectTest(t)
+			val, err := tc.runtime.VU.Runtime().RunString(code)
+			require.NoError(t, err)
+			assert.True(t, val.ToBoolean())
+
+			samples := metrics.GetBufferedSamples(tc.samples)
+			require.Len(t, samples, 1)
+			sample := samples[0].GetSamples()[0]
+			assert.Equal(t, 1.0, sample.Value)
+			assert.Empty(t, sample.Metadata)
+			assert.Empty(t, tc.hook.Drain())
+		})
+	}
+}
+
+func TestExpectFailure(t *testing.T) {
+	t.Parallel()
+
+	tc := setupExpectTest(t)
+	val, err := tc.runtime.VU.Runtime().RunString(`
+		expect({ status: 404, body: "<html>" }).toMatchObject({ status: 200 });
+	`)
+	require.NoError(t, err)
+	assert.False(t, val.ToBoolean())
+
+	samples := metrics.GetBufferedSamples(tc.samples)
+	require.Len(t, samples, 1)
+	sample := samples[0].GetSamples()[0]
+	assert.Equal(t, 0.0, sample.Value)
+	assert.Equal(t, map[string]string{"group": "", "check": `toMatchObject({"status":200})`}, sample.Tags.Map())
+	message := `expected {"body":"<html>","status":404} to match the object {"status":200}`
+	assert.Equal(t, map[string]string{FailureMetadata: message}, sample.Metadata)
+	assert.EqualValues(t, 1, tc.group.Checks[`toMatchObject({"status":200})`].Fails)
+
+	entries := tc.hook.Drain()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logrus.WarnLevel, entries[0].Level)
+	assert.Equal(t, message, entries[0].Message)
+	assert.Equal(t, logrus.Fields{
+		"source":   CheckFailuresSource,
+		"check":    `toMatchObject({"status":200})`,
+		"matcher":  "toMatchObject",
+		"expected": `{"status":200}`,
+		"received": `{"body":"<html>","status":404}`,
+	}, entries[0].Data)
+}
+
+func TestExpectNamedAndNegated(t *testing.T) {
+	t.Parallel()
+
+	tc := setupExpectTest(t)
+	_, err := tc.runtime.VU.Runtime().RunString(`
+		expect(500, "status isn't 500").not.toBe(500);
+		expect("x".repeat(100)).not.toBe("x".repeat(100));
+	`)
+	require.NoError(t, err)
+
+	samples := metrics.GetBufferedSamples(tc.samples)
+	require.Len(t, samples, 2)
+	check, _ := samples[0].GetSamples()[0].Tags.Get("check")
+	assert.Equal(t, "status isn't 500", check)
+	assert.Equal(t, "expected 500 not to be 500", samples[0].GetSamples()[0].Metadata[FailureMetadata])
+
+	check, _ = samples[1].GetSamples()[0].Tags.Get("check")
+	assert.Equal(t, `not.toBe("`+strings.Repeat("x", 49)+"…)", check)
+
+	entries := tc.hook.Drain()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "not.toBe", entries[0].Data["matcher"])
+}
+
+func TestExpectErrors(t *testing.T) {
+	t.Parallel()
+
+	tc := setupExpectTest(t)
+	_, err := tc.runtime.VU.Runtime().RunString(`expect("a").toMatch("(")`)
+	assert.ErrorContains(t, err, "invalid pattern of toMatch()")
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.VU.RuntimeField.Set("expect", New().NewModuleInstance(runtime.VU).Exports().Named["expect"]))
+	_, err = runtime.VU.Runtime().RunString(`expect(1).toBe(1)`)
+	assert.ErrorContains(t, err, "Using expect() in the init context is not supported")
+}
@@ -0,0 +1,207 @@
+package expect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+func (e *expectation) toBe(expected goja.Value) (bool, error) {
+	expected = orUndefined(expected)
+	return e.report(assertion{
+		matcher:     "toBe",
+		expected:    expected,
+		pass:        e.received.SameAs(expected),
+		description: "to be " + formatValue(expected),
+	})
+}
+
+func (e *expectation) toEqual(expected goja.Value) (bool, error) {
+	expected = orUndefined(expected)
+	return e.report(assertion{
+		matcher:     "toEqual",
+		expected:    expected,
+		pass:        reflect.DeepEqual(normalize(e.received), normalize(expected)),
+		description: "to equal " + formatValue(expected),
+	})
+}
+
+func (e *expectation) toMatchObject(expected goja.Value) (bool, error) {
+	expected = orUndefined(expected)
+	return e.report(assertion{
+		matcher:     "toMatchObject",
+		expected:    expected,
+		pass:        matchObject(normalize(e.received), normalize(expected)),
+		description: "to match the object " + formatValue(expected),
+	})
+}
+
+func (e *expectation) toContain(item goja.Value) (bool, error) {
+	item = orUndefined(item)
+	var pass bool
+	switch received := normalize(e.received).(type) {
+	case string:
+		pass = strings.Contains(received, item.String())
+	case []any:
+		expected := normalize(item)
+		for _, element := range received {
+			if reflect.DeepEqual(element, expected) {
+				pass = true
+				break
+			}
+		}
+	}
+	return e.report(assertion{
+		matcher:     "toContain",
+		expected:    item,
+		pass:        pass,
+		description: "to contain " + formatValue(item),
+	})
+}
+
+// toMatch checks a string with either a regular expression or the source of one.
+func (e *expectation) toMatch(pattern goja.Value) (bool, error) {
+	pattern = orUndefined(pattern)
+	source := pattern.String()
+	if obj, ok := pattern.(*goja.Object); ok && obj.ClassName() == "RegExp" {
+		source = obj.Get("source").String()
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return false, fmt.Errorf("invalid pattern of toMatch(): %w", err)
+	}
+	_, isString := e.received.Export().(string)
+	return e.report(assertion{
+		matcher:     "toMatch",
+		expected:    pattern,
+		pass:        isString && re.MatchString(e.received.String()),
+		description: "to match /" + source + "/",
+	})
+}
+
+func (e *expectation) toBeTruthy() (bool, error) {
+	return e.report(assertion{matcher: "toBeTruthy", pass: e.received.ToBoolean(), description: "to be truthy"})
+}
+
+func (e *expectation) toBeFalsy() (bool, error) {
+	return e.report(assertion{matcher: "toBeFalsy", pass: !e.received.ToBoolean(), description: "to be falsy"})
+}
+
+func (e *expectation) toBeGreaterThan(expected goja.Value) (bool, error) {
+	return e.compare("toBeGreaterThan", "to be greater than", expected, func(r, e float64) bool { return r > e })
+}
+
+func (e *expectation) toBeGreaterThanOrEqual(expected goja.Value) (bool, error) {
+	return e.compare("toBeGreaterThanOrEqual", "to be greater than or equal to", expected,
+		func(r, e float64) bool { return r >= e })
+}
+
+func (e *expectation) toBeLessThan(expected goja.Value) (bool, error) {
+	return e.compare("toBeLessThan", "to be less than", expected, func(r, e float64) bool { return r < e })
+}
+
+func (e *expectation) toBeLessThanOrEqual(expected goja.Value) (bool, error) {
+	return e.compare("toBeLessThanOrEqual", "to be less than or equal to", expected,
+		func(r, e float64) bool { return r <= e })
+}
+
+// compare checks numbers, the received value never matches if it isn't one.
+func (e *expectation) compare(
+	matcher, description string, expected goja.Value, cmp func(received, expected float64) bool,
+) (bool, error) {
+	expected = orUndefined(expected)
+	received, ok := toNumber(e.received)
+	pass := ok && cmp(received, expected.ToFloat())
+	return e.report(assertion{
+		matcher:     matcher,
+		expected:    expected,
+		pass:        pass,
+		description: description + " " + formatValue(expected),
+	})
+}
+
+// orUndefined returns undefined for the missing arguments of the matchers.
+func orUndefined(v goja.Value) goja.Value {
+	if v == nil {
+		return goja.Undefined()
+	}
+	return v
+}
+
+func toNumber(v goja.Value) (float64, bool) {
+	switch n := v.Export().(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, !math.IsNaN(n)
+	default:
+		return 0, false
+	}
+}
+
+// normalize returns the JSON representation of the exported value, so the
+// integers and the floats of JavaScript numbers can be compared.
+func normalize(v goja.Value) any {
+	if v == nil || goja.IsUndefined(v) {
+		return nil
+	}
+	exported := v.Export()
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return exported
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return exported
+	}
+	return normalized
+}
+
+// matchObject checks that the received value has all of the properties of the
+// expected one, recursively; the arrays must have the same length.
+func matchObject(received, expected any) bool {
+	switch expected := expected.(type) {
+	case map[string]any:
+		receivedMap, ok := received.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range expected {
+			receivedValue, ok := receivedMap[key]
+			if !ok || !matchObject(receivedValue, value) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		receivedSlice, ok := received.([]any)
+		if !ok || len(receivedSlice) != len(expected) {
+			return false
+		}
+		for i := range expected {
+			if !matchObject(receivedSlice[i], expected[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(received, expected)
+	}
+}
+
+// jsonMarshal is json.Marshal without the escaping of the HTML characters.
+func jsonMarshal(v any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,509 @@
+// The web classes of the k6/experimental/fetch module. This returns the
+// function, which creates them for a VU with the native functions of the
+// module: request(), which makes the HTTP requests, and encode() and decode(),
+// which convert between strings and UTF-8, and with the AbortController and
+// AbortSignal classes shared by all the modules.
+(function (native) {
+	"use strict";
+
+	const { AbortController, AbortSignal } = native;
+
+	// the size of the chunks of the streamed bodies
+	const CHUNK_SIZE = 64 * 1024;
+	const NULL_BODY_STATUSES = [101, 204, 205, 304];
+	const REDIRECT_STATUSES = [301, 302, 303, 307, 308];
+
+	const HEADERS = Symbol("headers");
+	const STREAM = Symbol("stream");
+	const BODY = Symbol("body");
+	const RESPONSE = Symbol("response");
+
+	function toArrayBuffer(view) {
+		return view.buffer.slice(view.byteOffset, view.byteOffset + view.byteLength);
+	}
+
+	function concat(chunks) {
+		let length = 0;
+		for (const chunk of chunks) {
+			length += chunk.byteLength;
+		}
+		const result = new Uint8Array(length);
+		let offset = 0;
+		for (const chunk of chunks) {
+			result.set(chunk, offset);
+			offset += chunk.byteLength;
+		}
+		return result.buffer;
+	}
+
+	function normalizeName(name) {
+		name = String(name);
+		if (!/^[!#$%&'*+\-.^_`|~0-9A-Za-z]+$/.test(name)) {
+			throw new TypeError(`invalid header name "${name}"`);
+		}
+		return name.toLowerCase();
+	}
+
+	function normalizeValue(value) {
+		return String(value).replace(/^[\t\n\r ]+|[\t\n\r ]+$/g, "");
+	}
+
+	class Headers {
+		constructor(init) {
+			this[HEADERS] = new Map();
+			if (init instanceof Headers) {
+				init.forEach((value, name) => this.append(name, value));
+			} else if (init != null && typeof init[Symbol.iterator] === "function") {
+				for (const pair of init) {
+					if (pair.length !== 2) {
+						throw new TypeError("the header pairs should have a name and a value");
+					}
+					this.append(pair[0], pair[1]);
+				}
+			} else if (init != null) {
+				for (const name of Object.keys(init)) {
+					this.append(name, init[name]);
+				}
+			}
+		}
+
+		append(name, value) {
+			name = normalizeName(name);
+			const values = this[HEADERS].get(name);
+			if (values) {
+				values.push(normalizeValue(value));
+			} else {
+				this[HEADERS].set(name, [normalizeValue(value)]);
+			}
+		}
+
+		delete(name) {
+			this[HEADERS].delete(normalizeName(name));
+		}
+
+		get(name) {
+			const values = this[HEADERS].get(normalizeName(name));
+			return values ? values.join(", ") : null;
+		}
+
+		has(name) {
+			return this[HEADERS].has(normalizeName(name));
+		}
+
+		set(name, value) {
+			this[HEADERS].set(normalizeName(name), [normalizeValue(value)]);
+		}
+
+		forEach(callback, thisArg) {
+			for (const [name, value] of this.entries()) {
+				callback.call(thisArg, value, name, this);
+			}
+		}
+
+		entries() {
+			const names = Array.from(this[HEADERS].keys()).sort();
+			return names.map((name) => [name, this.get(name)])[Symbol.iterator]();
+		}
+
+		keys() {
+			return Array.from(this.entries(), (entry) => entry[0])[Symbol.iterator]();
+		}
+
+		values() {
+			return Array.from(this.entries(), (entry) => entry[1])[Symbol.iterator]();
+		}
+
+		[Symbol.iterator]() {
+			return this.entries();
+		}
+	}
+
+	class ReadableStream {
+		constructor(source = {}) {
+			const state = {
+				source: source,
+				queue: [],
+				closed: false,
+				error: undefined,
+				locked: false,
+				waiting: [],
+			};
+			const wakeup = () => {
+				const waiting = state.waiting;
+				state.waiting = [];
+				waiting.forEach((resolve) => resolve());
+			};
+			state.controller = {
+				enqueue(chunk) {
+					state.queue.push(chunk);
+					wakeup();
+				},
+				close() {
+					state.closed = true;
+					wakeup();
+				},
+				error(err) {
+					state.error = err;
+					wakeup();
+				},
+			};
+			this[STREAM] = state;
+			if (typeof source.start === "function") {
+				source.start(state.controller);
+			}
+		}
+
+		get locked() {
+			return this[STREAM].locked;
+		}
+
+		getReader() {
+			const state = this[STREAM];
+			if (state.locked) {
+				throw new TypeError("the stream is already locked to a reader");
+			}
+			state.locked = true;
+			return new ReadableStreamDefaultReader(state);
+		}
+
+		cancel(reason) {
+			const state = this[STREAM];
+			state.queue = [];
+			state.closed = true;
+			if (typeof state.source.cancel === "function") {
+				return Promise.resolve(state.source.cancel(reason));
+			}
+			return Promise.resolve();
+		}
+
+		[Symbol.asyncIterator]() {
+			const reader = this.getReader();
+			return {
+				next: () => reader.read(),
+				return: () => {
+					reader.releaseLock();
+					return Promise.resolve({ value: undefined, done: true });
+				},
+			};
+		}
+	}
+
+	class ReadableStreamDefaultReader {
+		constructor(state) {
+			this[STREAM] = state;
+		}
+
+		async read() {
+			const state = this[STREAM];
+			if (!state) {
+				throw new TypeError("the reader was released");
+			}
+			for (;;) {
+				if (state.queue.length > 0) {
+					return { value: state.queue.shift(), done: false };
+				}
+				if (state.error !== undefined) {
+					throw state.error;
+				}
+				if (state.closed) {
+					return { value: undefined, done: true };
+				}
+				const enqueued = new Promise((resolve) => state.waiting.push(resolve));
+				if (typeof state.source.pull === "function") {
+					await state.source.pull(state.controller);
+					if (state.queue.length > 0 || state.closed || state.error !== undefined) {
+						continue;
+					}
+				}
+				await enqueued;
+			}
+		}
+
+		releaseLock() {
+			if (this[STREAM]) {
+				this[STREAM].locked = false;
+				this[STREAM] = undefined;
+			}
+		}
+
+		cancel(reason) {
+			const state = this[STREAM];
+			state.queue = [];
+			state.closed = true;
+			return Promise.resolve(typeof state.source.cancel === "function" ? state.source.cancel(reason) : undefined);
+		}
+	}
+
+	// extractBody returns the body and its default content type for the body
+	// of a request or a response, which is either an ArrayBuffer or a stream.
+	function extractBody(body) {
+		if (body == null) {
+			return { body: null, type: null };
+		}
+		if (body instanceof ReadableStream) {
+			return { body: body, type: null };
+		}
+		if (body instanceof ArrayBuffer) {
+			return { body: body.slice(0), type: null };
+		}
+		if (ArrayBuffer.isView(body)) {
+			return { body: toArrayBuffer(body), type: null };
+		}
+		if (body.constructor && body.constructor.name === "URLSearchParams") {
+			return {
+				body: native.encode(body.toString()),
+				type: "application/x-www-form-urlencoded;charset=UTF-8",
+			};
+		}
+		return { body: native.encode(String(body)), type: "text/plain;charset=UTF-8" };
+	}
+
+	// Body implements the methods of the requests and the responses for
+	// reading their bodies, which can be read only once.
+	class Body {
+		constructor(body, headers) {
+			const extracted = extractBody(body);
+			this[BODY] = { source: extracted.body, stream: null, used: false };
+			if (extracted.type !== null && !headers.has("content-type")) {
+				headers.set("content-type", extracted.type);
+			}
+		}
+
+		get body() {
+			const body = this[BODY];
+			if (body.source === null) {
+				return null;
+			}
+			if (body.stream === null) {
+				if (body.source instanceof ReadableStream) {
+					body.stream = body.source;
+				} else {
+					const data = body.source;
+					body.stream = new ReadableStream({
+						start(controller) {
+							for (let offset = 0; offset < data.byteLength; offset += CHUNK_SIZE) {
+								controller.enqueue(new Uint8Array(data.slice(offset, offset + CHUNK_SIZE)));
+							}
+							controller.close();
+						},
+					});
+				}
+			}
+			return body.stream;
+		}
+
+		get bodyUsed() {
+			const body = this[BODY];
+			return body.used || (body.stream !== null && body.stream.locked);
+		}
+
+		async arrayBuffer() {
+			if (this.bodyUsed) {
+				throw new TypeError("the body was already read");
+			}
+			const body = this[BODY];
+			body.used = true;
+			if (body.source === null) {
+				return new ArrayBuffer(0);
+			}
+			if (body.stream === null && body.source instanceof ArrayBuffer) {
+				return body.source;
+			}
+			const reader = this.body.getReader();
+			const chunks = [];
+			for (;;) {
+				const { value, done } = await reader.read();
+				if (done) {
+					break;
+				}
+				chunks.push(typeof value === "string" ? new Uint8Array(native.encode(value)) : new Uint8Array(value));
+			}
+			return concat(chunks);
+		}
+
+		async bytes() {
+			return new Uint8Array(await this.arrayBuffer());
+		}
+
+		async text() {
+			return native.decode(await this.arrayBuffer());
+		}
+
+		async json() {
+			return JSON.parse(await this.text());
+		}
+
+		async blob() {
+			throw new TypeError("Blob bodies aren't supported");
+		}
+
+		async formData() {
+			throw new TypeError("FormData bodies aren't supported");
+		}
+	}
+
+	// cloneBody returns the body for the clone of a request or a response. The
+	// streamed bodies are read completely, so they can be read by both.
+	function cloneBody(original) {
+		const body = original[BODY];
+		if (original.bodyUsed) {
+			throw new TypeError("the body was already read");
+		}
+		if (body.source instanceof ReadableStream) {
+			const chunks = [];
+			const reader = body.source.getReader();
+			const state = reader[STREAM];
+			chunks.push(...state.queue);
+			if (!state.closed) {
+				throw new TypeError("only the bodies, which were completely received, can be cloned");
+			}
+			reader.releaseLock();
+			body.source = concat(chunks.map((chunk) => new Uint8Array(chunk)));
+			body.stream = null;
+		}
+		return body.source;
+	}
+
+	class Request extends Body {
+		constructor(input, init = {}) {
+			const base = input instanceof Request ? input : null;
+			const headers = new Headers(init.headers !== undefined ? init.headers : base ? base.headers : undefined);
+			const method = String(init.method !== undefined ? init.method : base ? base.method : "GET");
+			const normalizedMethod = ["DELETE", "GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH"].includes(
+				method.toUpperCase(),
+			)
+				? method.toUpperCase()
+				: method;
+
+			let body = init.body;
+			if (body === undefined && base !== null) {
+				body = cloneBody(base);
+			}
+			if (body != null && (normalizedMethod === "GET" || normalizedMethod === "HEAD")) {
+				throw new TypeError(`the ${normalizedMethod} requests can't have a body`);
+			}
+			super(body, headers);
+
+			this.url = base ? base.url : String(input);
+			this.method = normalizedMethod;
+			this.headers = headers;
+			this.redirect = init.redirect !== undefined ? String(init.redirect) : base ? base.redirect : "follow";
+			if (!["follow", "manual", "error"].includes(this.redirect)) {
+				throw new TypeError(`invalid redirect mode "${this.redirect}"`);
+			}
+			this.signal = init.signal !== undefined ? init.signal : base ? base.signal : new AbortController().signal;
+			this.credentials = init.credentials !== undefined ? init.credentials : "same-origin";
+			this.mode = init.mode !== undefined ? init.mode : "cors";
+			this.cache = init.cache !== undefined ? init.cache : "default";
+		}
+
+		clone() {
+			return new Request(this);
+		}
+	}
+
+	class Response extends Body {
+		constructor(body = null, init = {}) {
+			const status = init.status !== undefined ? Number(init.status) : 200;
+			if (!Number.isInteger(status) || status < 200 || status > 599) {
+				throw new RangeError(`invalid status ${init.status}`);
+			}
+			if (body != null && NULL_BODY_STATUSES.includes(status)) {
+				throw new TypeError(`the responses with status ${status} can't have a body`);
+			}
+			const headers = new Headers(init.headers);
+			super(body, headers);
+
+			this.status = status;
+			this.statusText = init.statusText !== undefined ? String(init.statusText) : "";
+			this.headers = headers;
+			this[RESPONSE] = { type: "default", url: "", redirected: false };
+		}
+
+		get ok() {
+			return this.status >= 200 && this.status < 300;
+		}
+
+		get type() {
+			return this[RESPONSE].type;
+		}
+
+		get url() {
+			return this[RESPONSE].url;
+		}
+
+		get redirected() {
+			return this[RESPONSE].redirected;
+		}
+
+		clone() {
+			const clone = new Response(cloneBody(this), this);
+			clone[RESPONSE] = Object.assign({}, this[RESPONSE]);
+			return clone;
+		}
+
+		static json(data, init = {}) {
+			const headers = new Headers(init.headers);
+			if (!headers.has("content-type")) {
+				headers.set("content-type", "application/json");
+			}
+			return new Response(JSON.stringify(data), Object.assign({}, init, { headers: headers }));
+		}
+
+		static error() {
+			const response = new Response(null, { status: 200 });
+			response.status = 0;
+			response[RESPONSE].type = "error";
+			return response;
+		}
+
+		static redirect(url, status = 302) {
+			if (!REDIRECT_STATUSES.includes(status)) {
+				throw new RangeError(`invalid redirect status ${status}`);
+			}
+			return new Response(null, { status: status, headers: { location: String(url) } });
+		}
+	}
+
+	async function fetch(input, init) {
+		const request = new Request(input, init);
+		const signal = request.signal;
+		if (signal && signal.aborted) {
+			throw signal.reason;
+		}
+
+		const body = request[BODY].source === null ? null : await request.arrayBuffer();
+		let result;
+		try {
+			result = await native.request(
+				request.method, request.url, Array.from(request.headers), body, request.redirect, signal);
+		} catch (err) {
+			if (signal && signal.aborted) {
+				throw signal.reason;
+			}
+			throw new TypeError(`fetch failed: ${err && err.message !== undefined ? err.message : err}`);
+		}
+
+		if (request.redirect === "error" && REDIRECT_STATUSES.includes(result.status)) {
+			throw new TypeError(`fetch failed: unexpected redirect to ${request.url}`);
+		}
+		const hasBody = request.method !== "HEAD" && !NULL_BODY_STATUSES.includes(result.status);
+		const response = new Response(hasBody ? result.body : null, {
+			status: result.status,
+			statusText: result.statusText,
+			headers: result.headers,
+		});
+		response[RESPONSE] = { type: "basic", url: result.url, redirected: result.redirected };
+		return response;
+	}
+
+	return {
+		fetch: fetch,
+		Headers: Headers,
+		Request: Request,
+		Response: Response,
+		AbortController: AbortController,
+		AbortSignal: AbortSignal,
+		ReadableStream: ReadableStream,
+	};
+});
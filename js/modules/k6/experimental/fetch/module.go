@@ -0,0 +1,241 @@
+// Package fetch provides the k6/experimental/fetch module, which implements
+// the fetch() function of the web, with its standard Headers, Request,
+// Response, AbortController and ReadableStream classes, on top of the HTTP
+// transport of k6, so its requests emit the same metrics as the k6/http ones.
+//
+// Importing the module also defines them as globals, if they aren't already
+// defined, so the libraries written for the browsers can be used unmodified.
+package fetch
+
+import (
+	"bytes"
+	_ "embed" // this is used to embed the contents of fetch.js
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/js/abort"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// fetchCode implements the web classes of the module in JS, with the native
+// functions of the ModuleInstance.
+//
+//go:embed fetch.js
+var fetchCode string
+
+// ErrFetchForbiddenInInitContext is used when fetch() was called in the init context.
+var ErrFetchForbiddenInInitContext = common.NewInitContextError("Using fetch in the init context is not supported")
+
+// exportedNames are the names of the exports of the module, which are also
+// defined as globals.
+var exportedNames = []string{ //nolint:gochecknoglobals
+	"fetch", "Headers", "Request", "Response", "AbortController", "AbortSignal", "ReadableStream",
+}
+
+type (
+	// RootModule is the global module instance that will create instances of
+	// the module for each VU.
+	RootModule struct {
+		program *goja.Program
+	}
+
+	// ModuleInstance represents an instance of the fetch module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		exports map[string]any
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new RootModule instance.
+func New() *RootModule {
+	return &RootModule{
+		program: goja.MustCompile("k6/experimental/fetch/fetch.js", fetchCode, true),
+	}
+}
+
+// NewModuleInstance implements the modules.Module interface to return
+// a new instance for each VU.
+func (rm *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	rt := vu.Runtime()
+	mi := &ModuleInstance{vu: vu, exports: make(map[string]any, len(exportedNames))}
+
+	factory, err := rt.RunProgram(rm.program)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	newExports, _ := goja.AssertFunction(factory)
+	classes := abort.ClassesFor(vu)
+	native := rt.NewObject()
+	for name, fn := range map[string]any{
+		"request":         mi.request,
+		"encode":          mi.encode,
+		"decode":          mi.decode,
+		"AbortController": classes.AbortController,
+		"AbortSignal":     classes.AbortSignal,
+	} {
+		if err = native.Set(name, fn); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	exportsV, err := newExports(goja.Undefined(), native)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	exports := exportsV.ToObject(rt)
+	global := rt.GlobalObject()
+	for _, name := range exportedNames {
+		value := exports.Get(name)
+		mi.exports[name] = value
+		if common.IsNullish(global.Get(name)) {
+			if err = global.Set(name, value); err != nil {
+				common.Throw(rt, err)
+			}
+		}
+	}
+	return mi
+}
+
+// Exports returns the exports of the fetch module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{Named: mi.exports}
+}
+
+// request makes an HTTP request off the event loop and returns a promise,
+// which is resolved with the status, headers and body of the response or
+// rejected with the error of the request. The request is canceled when the
+// signal is aborted.
+func (mi *ModuleInstance) request(
+	method, rawURL string, headers [][]string, body goja.Value, redirect string, signalValue goja.Value,
+) (*goja.Promise, error) {
+	state := mi.vu.State()
+	if state == nil {
+		return nil, ErrFetchForbiddenInInitContext
+	}
+	rt := mi.vu.Runtime()
+	signal, err := abort.FromValue(mi.vu, signalValue)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := httpext.ToURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	preq := &httpext.ParsedHTTPRequest{
+		URL: &u,
+		Req: &http.Request{
+			Method: method,
+			URL:    u.GetURL(),
+			Header: make(http.Header),
+		},
+		Timeout:          60 * time.Second,
+		Throw:            true,
+		Redirects:        state.Options.MaxRedirects,
+		Cookies:          make(map[string]*httpext.HTTPRequestCookie),
+		ResponseCallback: func(status int) bool { return status >= 200 && status < 400 },
+		ResponseType:     httpext.ResponseTypeBinary,
+		ActiveJar:        state.CookieJar,
+		TagsAndMeta:      state.Tags.GetCurrentValues(),
+	}
+	if redirect != "follow" {
+		preq.Redirects = null.IntFrom(0)
+	}
+	var scenario string
+	if ss := lib.GetScenarioState(mi.vu.Context()); ss != nil {
+		scenario = ss.Name
+	}
+	if state.Options.DiscardResponseBodiesFor(scenario) {
+		preq.ResponseType = httpext.ResponseTypeNone
+	}
+
+	preq.Req.Header.Set("User-Agent", state.Options.UserAgent.String)
+	for _, header := range headers {
+		if len(header) != 2 {
+			return nil, fmt.Errorf("invalid header %q", header)
+		}
+		if strings.EqualFold(header[0], "host") {
+			preq.Req.Host = header[1]
+		}
+		preq.Req.Header.Set(header[0], header[1])
+	}
+	if !common.IsNullish(body) {
+		data, err := common.ToBytes(body.Export())
+		if err != nil {
+			return nil, err
+		}
+		preq.Body = bytes.NewBuffer(append([]byte(nil), data...))
+	}
+	if preq.ActiveJar != nil {
+		httpext.SetRequestCookies(preq.Req, preq.ActiveJar, preq.Cookies)
+	}
+
+	ctx, cancel := signal.Context(mi.vu.Context())
+	p, resolve, reject := rt.NewPromise()
+	callback := mi.vu.RegisterCallback()
+	go func() {
+		defer cancel()
+		resp, err := httpext.MakeRequest(ctx, state, preq)
+		callback(func() error {
+			if err != nil {
+				reject(err)
+				return nil //nolint:nilerr // we want to reject the promise in this case
+			}
+			resolve(mi.responseResult(resp, preq.Req.URL.String()))
+			return nil
+		})
+	}()
+
+	return p, nil
+}
+
+// responseResult returns the parts of the response, from which fetch()
+// creates the Response object.
+func (mi *ModuleInstance) responseResult(resp *httpext.Response, requestURL string) map[string]any {
+	rt := mi.vu.Runtime()
+
+	headers := make([][]string, 0, len(resp.Headers))
+	for name, value := range resp.Headers {
+		headers = append(headers, []string{name, value})
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i][0] < headers[j][0] })
+
+	data, _ := resp.Body.([]byte)
+	return map[string]any{
+		"url":        resp.URL,
+		"redirected": resp.URL != requestURL,
+		"status":     resp.Status,
+		"statusText": strings.TrimSpace(strings.TrimPrefix(resp.StatusText, fmt.Sprint(resp.Status))),
+		"headers":    headers,
+		"body":       rt.NewArrayBuffer(data),
+	}
+}
+
+// encode returns the UTF-8 encoding of the string.
+func (mi *ModuleInstance) encode(s string) goja.ArrayBuffer {
+	return mi.vu.Runtime().NewArrayBuffer([]byte(s))
+}
+
+// decode returns the string with the UTF-8 encoding in the buffer, in which
+// the invalid sequences are replaced, as with the TextDecoder of the web.
+func (mi *ModuleInstance) decode(buffer goja.Value) (string, error) {
+	data, err := common.ToBytes(buffer.Export())
+	if err != nil {
+		return "", err
+	}
+	return strings.ToValidUTF8(string(data), "\uFFFD"), nil
+}
@@ -0,0 +1,248 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/js/compiler"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/httpmultibin"
+	"go.k6.io/k6/metrics"
+)
+
+type fetchTestCase struct {
+	tb      *httpmultibin.HTTPMultiBin
+	runtime *modulestest.Runtime
+	samples chan metrics.SampleContainer
+}
+
+func newTestCase(t testing.TB) *fetchTestCase {
+	t.Helper()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	runtime := modulestest.NewRuntime(t)
+
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/fetch": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`require("k6/experimental/fetch");`)
+	require.NoError(t, err)
+
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	registry := metrics.NewRegistry()
+	samples := make(chan metrics.SampleContainer, 1000)
+	runtime.MoveToVUContext(&lib.State{
+		Options: lib.Options{
+			MaxRedirects: null.IntFrom(10),
+			UserAgent:    null.StringFrom("TestUserAgent"),
+			SystemTags:   &metrics.DefaultSystemTagSet,
+		},
+		Logger:         logrus.New(),
+		Group:          root,
+		TLSConfig:      tb.TLSClientConfig,
+		Transport:      tb.HTTPTransport,
+		BufferPool:     lib.NewBufferPool(),
+		Samples:        samples,
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+	})
+	return &fetchTestCase{tb: tb, runtime: runtime, samples: samples}
+}
+
+func (ts *fetchTestCase) run(t *testing.T, code string) {
+	t.Helper()
+	_, err := ts.runtime.RunOnEventLoop(ts.tb.Replacer.Replace(`(async () => {` + code + `})()`))
+	require.NoError(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			const res = await fetch("HTTPBIN_URL/get", { headers: { "X-Test": "value" } });
+			if (!res.ok || res.status !== 200 || res.statusText !== "OK") {
+				throw new Error("unexpected status " + res.status + " " + res.statusText);
+			}
+			if (!res.headers.get("content-type").startsWith("application/json")) {
+				throw new Error("unexpected content type " + res.headers.get("content-type"));
+			}
+			const body = await res.json();
+			if (body.headers["X-Test"][0] !== "value" || body.headers["User-Agent"][0] !== "TestUserAgent") {
+				throw new Error("unexpected headers " + JSON.stringify(body.headers));
+			}
+			if (!res.bodyUsed) {
+				throw new Error("the body should be used");
+			}
+			try {
+				await res.text();
+				throw new Error("the body shouldn't be readable twice");
+			} catch (e) {
+				if (!(e instanceof TypeError)) throw e;
+			}
+		`)
+
+		var found bool
+		for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name == metrics.HTTPReqsName {
+					url, _ := s.Tags.Get("url")
+					found = found || url == ts.tb.Replacer.Replace("HTTPBIN_URL/get")
+				}
+			}
+		}
+		assert.True(t, found, "the request should emit the http metrics")
+	})
+
+	t.Run("post", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			const req = new Request("HTTPBIN_URL/post", {
+				method: "post",
+				headers: { "Content-Type": "application/json" },
+				body: JSON.stringify({ hello: "world" }),
+			});
+			const res = await fetch(req.clone());
+			const body = await res.json();
+			if (body.json.hello !== "world") {
+				throw new Error("unexpected body " + JSON.stringify(body));
+			}
+			if ((await req.text()) !== '{"hello":"world"}') {
+				throw new Error("the original request should keep its body");
+			}
+		`)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			const res = await fetch("HTTPBIN_URL/bytes/100000");
+			const reader = res.body.getReader();
+			let chunks = 0, length = 0;
+			for (;;) {
+				const { value, done } = await reader.read();
+				if (done) break;
+				chunks++;
+				length += value.byteLength;
+			}
+			if (chunks !== 2 || length !== 100000) {
+				throw new Error("unexpected " + chunks + " chunks with " + length + " bytes");
+			}
+
+			const stream = new ReadableStream({
+				start(controller) {
+					controller.enqueue(new Uint8Array([104, 105]));
+					controller.close();
+				},
+			});
+			const text = await new Response(stream).text();
+			if (text !== "hi") {
+				throw new Error("unexpected text " + text);
+			}
+		`)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			const controller = new AbortController();
+			const pending = fetch("HTTPBIN_URL/delay/10", { signal: controller.signal });
+			controller.abort();
+			try {
+				await pending;
+				throw new Error("the request should be aborted");
+			} catch (e) {
+				if (e.name !== "AbortError") throw e;
+			}
+
+			try {
+				await fetch("HTTPBIN_URL/get", { signal: AbortSignal.abort("reason") });
+				throw new Error("the request shouldn't be made");
+			} catch (e) {
+				if (e !== "reason") throw e;
+			}
+		`)
+	})
+
+	t.Run("redirects", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			let res = await fetch("HTTPBIN_URL/redirect/1");
+			if (res.status !== 200 || !res.redirected || res.url !== "HTTPBIN_URL/get") {
+				throw new Error("unexpected followed redirect " + res.status + " " + res.url);
+			}
+			res = await fetch("HTTPBIN_URL/redirect/1", { redirect: "manual" });
+			if (res.status !== 302 || res.redirected) {
+				throw new Error("unexpected manual redirect " + res.status);
+			}
+			try {
+				await fetch("HTTPBIN_URL/redirect/1", { redirect: "error" });
+				throw new Error("the redirect should fail");
+			} catch (e) {
+				if (!(e instanceof TypeError)) throw e;
+			}
+		`)
+	})
+
+	t.Run("network errors", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			try {
+				await fetch("http://127.0.0.1:1/");
+				throw new Error("the request should fail");
+			} catch (e) {
+				if (!(e instanceof TypeError) || !e.message.startsWith("fetch failed")) throw e;
+			}
+		`)
+	})
+}
+
+func TestWebClasses(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	ts.run(t, `
+		const headers = new Headers([["B", "2"], ["a", " 1 "]]);
+		headers.append("b", "3");
+		if (JSON.stringify(Array.from(headers)) !== '[["a","1"],["b","2, 3"]]') {
+			throw new Error("unexpected headers " + JSON.stringify(Array.from(headers)));
+		}
+		headers.delete("A");
+		if (headers.has("a") || headers.get("B") !== "2, 3") {
+			throw new Error("unexpected deleted headers");
+		}
+
+		const res = Response.json({ a: 1 }, { status: 201 });
+		if (res.status !== 201 || res.headers.get("content-type") !== "application/json") {
+			throw new Error("unexpected response " + res.status);
+		}
+		if ((await res.clone().json()).a !== 1 || (await res.text()) !== '{"a":1}') {
+			throw new Error("unexpected cloned body");
+		}
+		if (Response.redirect("/x", 301).headers.get("location") !== "/x" || Response.error().type !== "error") {
+			throw new Error("unexpected static responses");
+		}
+		try {
+			new Request("HTTPBIN_URL/get", { method: "GET", body: "x" });
+			throw new Error("the GET requests shouldn't have a body");
+		} catch (e) {
+			if (!(e instanceof TypeError)) throw e;
+		}
+
+		const signal = AbortSignal.any([new AbortController().signal, AbortSignal.abort("any")]);
+		if (!signal.aborted || signal.reason !== "any") {
+			throw new Error("unexpected signal");
+		}
+	`)
+}
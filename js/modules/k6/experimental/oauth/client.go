@@ -0,0 +1,246 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// tokenRequestTimeout is the timeout of the requests to the token endpoint.
+const tokenRequestTimeout = 60 * time.Second
+
+// ErrTokenInInitContext is returned when a token is requested in the init
+// context, where the VUs can't make requests.
+var ErrTokenInInitContext = errors.New("tokens can't be requested in the init context")
+
+// Client gets the access tokens of a VU from the token endpoint, caches them
+// and refreshes them before they expire.
+type Client struct {
+	vu   modules.VU
+	opts options
+
+	token *token
+}
+
+// token is an access token, as returned by the token endpoint.
+type token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+
+	// when the token has to be refreshed, zero if it doesn't expire
+	refreshAt time.Time
+}
+
+// tokenError is the error response of the token endpoint, see RFC 6749 5.2.
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token returns the access token of the VU, which is requested from the token
+// endpoint the first time and then once it's about to expire.
+func (c *Client) Token() string {
+	tok, err := c.getToken()
+	if err != nil {
+		common.Throw(c.vu.Runtime(), err)
+	}
+	return tok.AccessToken
+}
+
+// Authorize is a k6/http request hook that sets the Authorization header of
+// the requests to the access token of the VU, unless they already have one.
+func (c *Client) Authorize(req goja.Value) {
+	rt := c.vu.Runtime()
+	if common.IsNullish(req) {
+		return
+	}
+	obj := req.ToObject(rt)
+	u, err := url.Parse(obj.Get("url").String())
+	if err != nil || !c.authorizes(u) {
+		return
+	}
+
+	headers := obj.Get("headers").ToObject(rt)
+	for _, key := range headers.Keys() {
+		if strings.EqualFold(key, "authorization") {
+			return
+		}
+	}
+	tok, err := c.getToken()
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if err = headers.Set("Authorization", tok.authorization()); err != nil {
+		common.Throw(rt, err)
+	}
+}
+
+// InstrumentHTTP adds Authorize to the request hooks of k6/http, so all the
+// requests of the VU get its access token.
+func (c *Client) InstrumentHTTP() {
+	rt := c.vu.Runtime()
+	http, err := rt.RunString("require('k6/http')")
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	addRequestHook, ok := goja.AssertFunction(http.ToObject(rt).Get("addRequestHook"))
+	if !ok {
+		common.Throw(rt, errors.New("k6/http has no addRequestHook function"))
+	}
+	if _, err = addRequestHook(goja.Undefined(), rt.ToValue(c.Authorize)); err != nil {
+		common.Throw(rt, err)
+	}
+}
+
+// authorizes returns whether the requests to the URL get the access token,
+// which is never the case for the token endpoint itself.
+func (c *Client) authorizes(u *url.URL) bool {
+	tokenURL := c.opts.tokenURL
+	if u.Host == tokenURL.Host && u.Path == tokenURL.Path {
+		return false
+	}
+	if c.opts.hosts == nil {
+		return true
+	}
+	_, ok := c.opts.hosts[u.Host]
+	return ok
+}
+
+// getToken returns the cached token, unless it has to be refreshed.
+func (c *Client) getToken() (*token, error) {
+	if c.token != nil && (c.token.refreshAt.IsZero() || time.Now().Before(c.token.refreshAt)) {
+		return c.token, nil
+	}
+
+	var (
+		tok *token
+		err error
+	)
+	if c.token != nil && c.token.RefreshToken != "" {
+		tok, err = c.requestToken(url.Values{
+			"grant_type":    {refreshTokenGrant},
+			"refresh_token": {c.token.RefreshToken},
+		})
+		if err == nil && tok.RefreshToken == "" {
+			// the refresh token can be used again, unless a new one is issued
+			tok.RefreshToken = c.token.RefreshToken
+		}
+	}
+	if tok == nil {
+		// the refresh token may have been revoked, so the grant is used again
+		if tok, err = c.requestToken(c.grantParams()); err != nil {
+			return nil, err
+		}
+	}
+	c.token = tok
+	return tok, nil
+}
+
+// grantParams returns the parameters of the token requests of the grant.
+func (c *Client) grantParams() url.Values {
+	params := url.Values{"grant_type": {c.opts.grantType}}
+	if c.opts.grantType == passwordGrant {
+		params.Set("username", c.opts.username)
+		params.Set("password", c.opts.password)
+	}
+	if c.opts.scope != "" {
+		params.Set("scope", c.opts.scope)
+	}
+	if c.opts.audience != "" {
+		params.Set("audience", c.opts.audience)
+	}
+	return params
+}
+
+// requestToken requests a token from the token endpoint. The request is made
+// like the ones of k6/http, so its metrics are emitted with the tags of the VU.
+func (c *Client) requestToken(params url.Values) (*token, error) {
+	state := c.vu.State()
+	if state == nil {
+		return nil, ErrTokenInInitContext
+	}
+
+	if c.opts.clientCredentialsInBody {
+		params.Set("client_id", c.opts.clientID)
+		if c.opts.clientSecret != "" {
+			params.Set("client_secret", c.opts.clientSecret)
+		}
+	}
+	u, err := httpext.NewURL(c.opts.tokenURL.String(), c.opts.tokenURL.String())
+	if err != nil {
+		return nil, err
+	}
+	preq := &httpext.ParsedHTTPRequest{
+		URL: &u,
+		Req: &http.Request{
+			Method: http.MethodPost,
+			URL:    u.GetURL(),
+			Header: make(http.Header),
+		},
+		Body:             bytes.NewBufferString(params.Encode()),
+		Timeout:          tokenRequestTimeout,
+		Throw:            true,
+		Redirects:        state.Options.MaxRedirects,
+		ResponseCallback: func(status int) bool { return status >= 200 && status < 300 },
+		ResponseType:     httpext.ResponseTypeBinary,
+		TagsAndMeta:      state.Tags.GetCurrentValues(),
+	}
+	preq.Req.Header.Set("User-Agent", state.Options.UserAgent.String)
+	preq.Req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	preq.Req.Header.Set("Accept", "application/json")
+	if !c.opts.clientCredentialsInBody {
+		preq.Req.SetBasicAuth(url.QueryEscape(c.opts.clientID), url.QueryEscape(c.opts.clientSecret))
+	}
+
+	requested := time.Now()
+	resp, err := httpext.MakeRequest(c.vu.Context(), state, preq)
+	if err != nil {
+		return nil, fmt.Errorf("the token request failed: %w", err)
+	}
+	body, _ := resp.Body.([]byte)
+	if resp.Status < 200 || resp.Status >= 300 {
+		var tokErr tokenError
+		if json.Unmarshal(body, &tokErr) == nil && tokErr.Error != "" {
+			if tokErr.ErrorDescription != "" {
+				return nil, fmt.Errorf("the token request failed with status %d: %s: %s",
+					resp.Status, tokErr.Error, tokErr.ErrorDescription)
+			}
+			return nil, fmt.Errorf("the token request failed with status %d: %s", resp.Status, tokErr.Error)
+		}
+		return nil, fmt.Errorf("the token request failed with status %d", resp.Status)
+	}
+
+	tok := &token{}
+	if err = json.Unmarshal(body, tok); err != nil {
+		return nil, fmt.Errorf("the token response is invalid: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New("the token response has no access_token")
+	}
+	if tok.ExpiresIn > 0 {
+		// the expiry is counted from the request, as the response may have taken a while
+		tok.refreshAt = requested.Add(time.Duration(tok.ExpiresIn)*time.Second - c.opts.refreshBefore)
+	}
+	return tok, nil
+}
+
+// authorization returns the value of the Authorization header of the token.
+func (tok *token) authorization() string {
+	tokenType := tok.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + tok.AccessToken
+}
@@ -0,0 +1,65 @@
+// Package oauth implements the k6/experimental/oauth module, which gets and
+// caches the OAuth2 access tokens of the VUs, refreshes them before they
+// expire, and adds them to the k6/http requests through the request hooks.
+package oauth
+
+import (
+	"errors"
+
+	"github.com/dop251/goja"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]interface{}{
+			"Client": mi.newClient,
+		},
+	}
+}
+
+// newClient is the JS constructor of the oauth.Client, which expects the
+// options of the token endpoint and of the grant as its argument.
+func (mi *ModuleInstance) newClient(cc goja.ConstructorCall) *goja.Object {
+	rt := mi.vu.Runtime()
+
+	if len(cc.Arguments) < 1 || common.IsNullish(cc.Arguments[0]) {
+		common.Throw(rt, errors.New("the Client constructor expects an options object as its argument"))
+	}
+	opts, err := newOptions(rt, cc.Arguments[0])
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return rt.ToValue(&Client{vu: mi.vu, opts: opts}).ToObject(rt)
+}
@@ -0,0 +1,197 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/js/compiler"
+	k6http "go.k6.io/k6/js/modules/k6/http"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/httpmultibin"
+	"go.k6.io/k6/metrics"
+)
+
+type oauthTestCase struct {
+	tb      *httpmultibin.HTTPMultiBin
+	runtime *modulestest.Runtime
+
+	mx     sync.Mutex
+	grants []string
+}
+
+// newTestCase returns a test case whose httpbin has a token endpoint at
+// /oauth/token, issuing tokens expiring after the expires_in query parameter.
+func newTestCase(t testing.TB) *oauthTestCase {
+	t.Helper()
+	ts := &oauthTestCase{tb: httpmultibin.NewHTTPMultiBin(t)}
+	ts.tb.Mux.HandleFunc("/oauth/token", ts.tokenHandler)
+
+	ts.runtime = modulestest.NewRuntime(t)
+	err := ts.runtime.SetupModuleSystem(map[string]any{
+		"k6/experimental/oauth": New(),
+		"k6/http":               k6http.New(),
+	}, nil, compiler.New(ts.runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+	_, err = ts.runtime.VU.Runtime().RunString(`
+		var oauth = require("k6/experimental/oauth");
+		var http = require("k6/http");
+	`)
+	require.NoError(t, err)
+
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	registry := metrics.NewRegistry()
+	ts.runtime.MoveToVUContext(&lib.State{
+		Options: lib.Options{
+			MaxRedirects: null.IntFrom(10),
+			UserAgent:    null.StringFrom("TestUserAgent"),
+			SystemTags:   &metrics.DefaultSystemTagSet,
+		},
+		Logger:         logrus.New(),
+		Group:          root,
+		TLSConfig:      ts.tb.TLSClientConfig,
+		Transport:      ts.tb.HTTPTransport,
+		BufferPool:     lib.NewBufferPool(),
+		Samples:        make(chan metrics.SampleContainer, 1000),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+	})
+	return ts
+}
+
+func (ts *oauthTestCase) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	grant := r.PostForm.Get("grant_type")
+	if clientID != "id" || clientSecret != "secret" ||
+		(grant == "password" && r.PostForm.Get("password") != "pass") {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad credentials"}`))
+		return
+	}
+
+	ts.mx.Lock()
+	ts.grants = append(ts.grants, grant+" "+r.PostForm.Get("scope")+r.PostForm.Get("refresh_token"))
+	n := len(ts.grants)
+	ts.mx.Unlock()
+
+	resp := map[string]any{"access_token": fmt.Sprintf("token-%d", n), "token_type": "bearer"}
+	if expiresIn := r.URL.Query().Get("expires_in"); expiresIn != "" {
+		resp["expires_in"] = json.Number(expiresIn)
+		resp["refresh_token"] = fmt.Sprintf("refresh-%d", n)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (ts *oauthTestCase) run(t *testing.T, code string) {
+	t.Helper()
+	_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(code))
+	require.NoError(t, err)
+}
+
+func (ts *oauthTestCase) requestedGrants() []string {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+	return append([]string(nil), ts.grants...)
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ClientCredentials", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			var client = new oauth.Client({
+				tokenURL: "HTTPBIN_URL/oauth/token", clientId: "id", clientSecret: "secret", scope: "read",
+			});
+			if (client.token() !== "token-1" || client.token() !== "token-1") {
+				throw new Error("the token wasn't cached");
+			}
+		`)
+		assert.Equal(t, []string{"client_credentials read"}, ts.requestedGrants())
+	})
+
+	t.Run("Password", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			var client = new oauth.Client({
+				tokenURL: "HTTPBIN_URL/oauth/token", grantType: "password", authMethod: "body",
+				clientId: "id", clientSecret: "secret", username: "user", password: "pass",
+			});
+			if (client.token() !== "token-1") {
+				throw new Error("unexpected token");
+			}
+		`)
+		assert.Equal(t, []string{"password "}, ts.requestedGrants())
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			var client = new oauth.Client({
+				tokenURL: "HTTPBIN_URL/oauth/token?expires_in=10", clientId: "id", clientSecret: "secret",
+				refreshBefore: "10s",
+			});
+			var tokens = [client.token(), client.token(), client.token()];
+			if (tokens.join(",") !== "token-1,token-2,token-3") {
+				throw new Error("the token wasn't refreshed: " + tokens);
+			}
+		`)
+		assert.Equal(t, []string{"client_credentials ", "refresh_token refresh-1", "refresh_token refresh-2"},
+			ts.requestedGrants())
+	})
+
+	t.Run("InstrumentHTTP", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.run(t, `
+			var client = new oauth.Client({
+				tokenURL: "HTTPBIN_URL/oauth/token", clientId: "id", clientSecret: "secret",
+			});
+			client.instrumentHTTP();
+			var res = http.get("HTTPBIN_URL/headers");
+			if (res.json().headers["Authorization"][0] !== "Bearer token-1") {
+				throw new Error("unexpected headers " + JSON.stringify(res.json().headers));
+			}
+			res = http.get("HTTPBIN_URL/headers", {headers: {"Authorization": "Basic foo"}});
+			if (res.json().headers["Authorization"][0] !== "Basic foo") {
+				throw new Error("the header was overwritten " + JSON.stringify(res.json().headers));
+			}
+		`)
+		assert.Equal(t, []string{"client_credentials "}, ts.requestedGrants())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		rt := ts.runtime.VU.Runtime()
+		_, err := rt.RunString(`new oauth.Client({clientId: "id"})`)
+		require.ErrorContains(t, err, "the tokenURL option is required")
+		_, err = rt.RunString(`new oauth.Client({tokenURL: "https://idp/token", grantType: "implicit"})`)
+		require.ErrorContains(t, err, "unsupported grantType 'implicit'")
+
+		_, err = rt.RunString(ts.tb.Replacer.Replace(`
+			new oauth.Client({tokenURL: "HTTPBIN_URL/oauth/token", clientId: "id", clientSecret: "wrong"}).token();
+		`))
+		require.ErrorContains(t, err, "the token request failed with status 401: invalid_client: bad credentials")
+	})
+}
@@ -0,0 +1,122 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dop251/goja"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/types"
+)
+
+// The supported grants.
+const (
+	clientCredentialsGrant = "client_credentials"
+	passwordGrant          = "password"
+	refreshTokenGrant      = "refresh_token"
+)
+
+// defaultRefreshBefore is how long before their expiry the tokens are
+// refreshed by default.
+const defaultRefreshBefore = 30 * time.Second
+
+// options are the options of an oauth.Client.
+type options struct {
+	// The URL of the token endpoint of the authorization server.
+	tokenURL *url.URL
+	// Either client_credentials, the default, or password.
+	grantType string
+
+	clientID, clientSecret string
+	// Send the client credentials in the body of the token requests,
+	// instead of in their basic authorization header.
+	clientCredentialsInBody bool
+
+	// The credentials of the resource owner, for the password grant.
+	username, password string
+
+	scope, audience string
+
+	// How long before its expiry a token is refreshed.
+	refreshBefore time.Duration
+	// The hosts whose requests get the token, all if empty.
+	hosts map[string]struct{}
+}
+
+// newOptions parses the options object of the Client constructor, like
+// `{tokenURL: "https://idp/token", clientId: "id", clientSecret: "secret"}`.
+func newOptions(rt *goja.Runtime, v goja.Value) (options, error) {
+	obj := v.ToObject(rt)
+	str := func(key string) string {
+		if value := obj.Get(key); !common.IsNullish(value) {
+			return value.String()
+		}
+		return ""
+	}
+
+	opts := options{
+		grantType:     clientCredentialsGrant,
+		clientID:      str("clientId"),
+		clientSecret:  str("clientSecret"),
+		username:      str("username"),
+		password:      str("password"),
+		scope:         str("scope"),
+		audience:      str("audience"),
+		refreshBefore: defaultRefreshBefore,
+	}
+
+	tokenURL := str("tokenURL")
+	if tokenURL == "" {
+		return opts, errors.New("the tokenURL option is required")
+	}
+	u, err := url.Parse(tokenURL)
+	if err != nil || u.Host == "" {
+		return opts, fmt.Errorf("invalid tokenURL '%s'", tokenURL)
+	}
+	opts.tokenURL = u
+
+	if grantType := str("grantType"); grantType != "" {
+		opts.grantType = grantType
+	}
+	switch opts.grantType {
+	case clientCredentialsGrant:
+		if opts.clientID == "" {
+			return opts, errors.New("the client_credentials grant needs the clientId option")
+		}
+	case passwordGrant:
+		if opts.username == "" {
+			return opts, errors.New("the password grant needs the username option")
+		}
+	default:
+		return opts, fmt.Errorf("unsupported grantType '%s', it has to be %s or %s",
+			opts.grantType, clientCredentialsGrant, passwordGrant)
+	}
+
+	switch authMethod := str("authMethod"); authMethod {
+	case "", "basic":
+	case "body":
+		opts.clientCredentialsInBody = true
+	default:
+		return opts, fmt.Errorf("unsupported authMethod '%s', it has to be basic or body", authMethod)
+	}
+
+	if value := obj.Get("refreshBefore"); !common.IsNullish(value) {
+		if opts.refreshBefore, err = types.GetDurationValue(value.Export()); err != nil {
+			return opts, fmt.Errorf("invalid refreshBefore: %w", err)
+		}
+	}
+
+	if value := obj.Get("hosts"); !common.IsNullish(value) {
+		var hosts []string
+		if err = rt.ExportTo(value, &hosts); err != nil {
+			return opts, fmt.Errorf("the hosts option has to be an array of hosts: %w", err)
+		}
+		opts.hosts = make(map[string]struct{}, len(hosts))
+		for _, host := range hosts {
+			opts.hosts[host] = struct{}{}
+		}
+	}
+	return opts, nil
+}
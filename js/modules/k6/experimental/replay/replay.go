@@ -0,0 +1,113 @@
+// Package replay provides the k6/experimental/replay module, which gives the
+// iterations of a traffic-replay scenario access to the request they replay.
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of
+	// the module for each VU. It caches the parsed requests of every scenario,
+	// so the recorded traffic is parsed only once.
+	RootModule struct {
+		mx       sync.Mutex
+		requests map[string][]executor.ReplayRequest
+	}
+
+	// ModuleInstance represents an instance of the replay module for a single VU.
+	ModuleInstance struct {
+		vu   modules.VU
+		root *RootModule
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new RootModule instance.
+func New() *RootModule {
+	return &RootModule{requests: make(map[string][]executor.ReplayRequest)}
+}
+
+// NewModuleInstance implements the modules.Module interface to return
+// a new instance for each VU.
+func (rm *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu, root: rm}
+}
+
+// Exports returns the exports of the replay module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"request": mi.request,
+		},
+	}
+}
+
+// request returns the recorded request that the current iteration replays.
+func (mi *ModuleInstance) request() map[string]any {
+	req, err := mi.currentRequest()
+	if err != nil {
+		common.Throw(mi.vu.Runtime(), err)
+	}
+
+	return map[string]any{
+		"method":  req.Method,
+		"url":     req.URL,
+		"headers": req.Headers,
+		"body":    req.Body,
+		"offset":  req.Offset.Milliseconds(),
+	}
+}
+
+func (mi *ModuleInstance) currentRequest() (executor.ReplayRequest, error) {
+	vuState := mi.vu.State()
+	if vuState == nil {
+		return executor.ReplayRequest{}, errors.New("getting the replayed request in the init context is not supported")
+	}
+	scenario := lib.GetScenarioState(mi.vu.Context())
+	if scenario == nil || vuState.GetScenarioGlobalVUIter == nil {
+		return executor.ReplayRequest{}, errors.New("the replayed request can only be retrieved during an iteration")
+	}
+
+	requests, err := mi.root.getRequests(vuState.Options.Scenarios, scenario.Name)
+	if err != nil {
+		return executor.ReplayRequest{}, err
+	}
+	iter := vuState.GetScenarioGlobalVUIter()
+	if iter >= uint64(len(requests)) {
+		return executor.ReplayRequest{}, fmt.Errorf("there is no recorded request for iteration %d of scenario '%s'",
+			iter, scenario.Name)
+	}
+	return requests[iter], nil
+}
+
+func (rm *RootModule) getRequests(scenarios lib.ScenarioConfigs, name string) ([]executor.ReplayRequest, error) {
+	rm.mx.Lock()
+	defer rm.mx.Unlock()
+
+	if requests, ok := rm.requests[name]; ok {
+		return requests, nil
+	}
+
+	config, ok := scenarios[name].(*executor.TrafficReplayConfig)
+	if !ok {
+		return nil, fmt.Errorf("scenario '%s' doesn't use the traffic-replay executor", name)
+	}
+	requests, err := config.GetRequests()
+	if err != nil {
+		return nil, err
+	}
+	rm.requests[name] = requests
+	return requests, nil
+}
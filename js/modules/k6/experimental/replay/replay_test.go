@@ -0,0 +1,50 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+)
+
+func setupReplayTest(t *testing.T, iter uint64) *modulestest.Runtime {
+	t.Helper()
+
+	config := executor.NewTrafficReplayConfig("replay")
+	config.Format = null.StringFrom(executor.ReplayFormatCommon)
+	config.BaseURL = null.StringFrom("https://test.k6.io")
+	config.Source = null.StringFrom(
+		`127.0.0.1 - - [01/Jan/2023:10:00:00 +0000] "GET / HTTP/1.1" 200 2326` + "\n" +
+			`127.0.0.1 - - [01/Jan/2023:10:00:01 +0000] "GET /news.php HTTP/1.1" 200 512`,
+	)
+
+	runtime := modulestest.NewRuntime(t)
+	runtime.VU.CtxField = lib.WithScenarioState(runtime.VU.CtxField, &lib.ScenarioState{Name: "replay"})
+	require.NoError(t, runtime.VU.RuntimeField.Set("replay", New().NewModuleInstance(runtime.VU).Exports().Named))
+	runtime.MoveToVUContext(&lib.State{
+		Options:                 lib.Options{Scenarios: lib.ScenarioConfigs{"replay": config}},
+		GetScenarioGlobalVUIter: func() uint64 { return iter },
+	})
+	return runtime
+}
+
+func TestReplayRequest(t *testing.T) {
+	t.Parallel()
+
+	runtime := setupReplayTest(t, 1)
+	val, err := runtime.VU.Runtime().RunString(`
+		const req = replay.request();
+		req.method + " " + req.url + " " + req.offset;
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "GET https://test.k6.io/news.php 1000", val.String())
+
+	runtime = setupReplayTest(t, 2)
+	_, err = runtime.VU.Runtime().RunString(`replay.request()`)
+	require.ErrorContains(t, err, "there is no recorded request for iteration 2 of scenario 'replay'")
+}
@@ -0,0 +1,114 @@
+// Package workers provides the k6/experimental/workers module, which lets a
+// VU offload CPU-bound work to a Worker, running its own script in a separate
+// JS runtime in the background, and exchange messages with it.
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/fsext"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of
+	// the module for each VU. It caches the compiled worker scripts, so every
+	// script is compiled only once for all of the VUs.
+	RootModule struct {
+		mx       sync.Mutex
+		programs map[string]*goja.Program
+	}
+
+	// ModuleInstance represents an instance of the workers module for a single VU.
+	ModuleInstance struct {
+		vu   modules.VU
+		root *RootModule
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new RootModule instance.
+func New() *RootModule {
+	return &RootModule{programs: make(map[string]*goja.Program)}
+}
+
+// NewModuleInstance implements the modules.Module interface to return
+// a new instance for each VU.
+func (rm *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu, root: rm}
+}
+
+// Exports returns the exports of the workers module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"Worker": mi.newWorker,
+		},
+	}
+}
+
+// newWorker is the constructor of the Worker objects, which starts a worker
+// running the script with the given path, relative to the entrypoint script.
+func (mi *ModuleInstance) newWorker(call goja.ConstructorCall) *goja.Object {
+	rt := mi.vu.Runtime()
+	if mi.vu.State() != nil {
+		common.Throw(rt, errors.New("new Worker() can only be used in the init context"))
+	}
+	if common.IsNullish(call.Argument(0)) || call.Argument(0).String() == "" {
+		common.Throw(rt, errors.New("new Worker() needs the path of the worker script"))
+	}
+
+	program, err := mi.root.compile(mi.vu.InitEnv(), call.Argument(0).String())
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	w := newWorker(mi.vu, call.This, program)
+	for name, value := range map[string]any{
+		"postMessage": w.postMessage,
+		"terminate":   w.terminate,
+		"onmessage":   goja.Null(),
+		"onerror":     goja.Null(),
+	} {
+		if err = call.This.Set(name, value); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	w.start()
+	return nil
+}
+
+// compile returns the compiled worker script with the given path.
+func (rm *RootModule) compile(initEnv *common.InitEnvironment, path string) (*goja.Program, error) {
+	path = fsext.Abs(initEnv.CWD.Path, path)
+
+	rm.mx.Lock()
+	defer rm.mx.Unlock()
+	if program, ok := rm.programs[path]; ok {
+		return program, nil
+	}
+
+	fs, ok := initEnv.FileSystems["file"]
+	if !ok {
+		return nil, errors.New("new Worker() can't access the file system")
+	}
+	data, err := fsext.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the worker script %q: %w", path, err)
+	}
+	program, err := goja.Compile(path, string(data), false)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compile the worker script %q: %w", path, err)
+	}
+	rm.programs[path] = program
+	return program, nil
+}
@@ -0,0 +1,136 @@
+package workers
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/compiler"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const testWorkerScript = `
+	let calls = 0;
+	self.onmessage = (e) => {
+		calls++;
+		while (e.data.loop) {}
+		if (e.data.fail) {
+			throw new Error("failed in the worker");
+		}
+		postMessage({ sum: e.data.a + e.data.b, calls: calls });
+	};
+	postMessage("ready");
+`
+
+func newConfiguredRuntime(t testing.TB) *modulestest.Runtime {
+	t.Helper()
+	runtime := modulestest.NewRuntime(t)
+
+	fs := fsext.NewMemMapFs()
+	require.NoError(t, fsext.WriteFile(fs, "/dir/worker.js", []byte(testWorkerScript), 0o644))
+	runtime.VU.InitEnvField.FileSystems = map[string]fsext.Fs{"file": fs}
+	runtime.VU.InitEnvField.CWD = &url.URL{Scheme: "file", Path: "/dir"}
+
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/workers": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+
+	_, err = runtime.RunOnEventLoop(`
+		const { Worker } = require("k6/experimental/workers");
+		var messages = [];
+		var errors = [];
+		var worker = new Worker("./worker.js");
+		worker.onmessage = (e) => messages.push(e.data);
+	`)
+	require.NoError(t, err)
+	return runtime
+}
+
+func TestWorker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("messages", func(t *testing.T) {
+		t.Parallel()
+		runtime := newConfiguredRuntime(t)
+		runtime.MoveToVUContext(&lib.State{})
+
+		v, err := runtime.RunOnEventLoop(`
+			worker.postMessage({ a: 1, b: 2 });
+			worker.postMessage({ a: 40, b: 2 });
+			messages;
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{
+			"ready",
+			map[string]any{"sum": int64(3), "calls": int64(1)},
+			map[string]any{"sum": int64(42), "calls": int64(2)},
+		}, v.Export())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		runtime := newConfiguredRuntime(t)
+		runtime.MoveToVUContext(&lib.State{})
+
+		_, err := runtime.RunOnEventLoop(`worker.postMessage({ fail: true });`)
+		require.ErrorContains(t, err, "uncaught error in the worker: Error: failed in the worker")
+
+		v, err := runtime.RunOnEventLoop(`
+			worker.onerror = (e) => errors.push(e.message);
+			worker.postMessage({ fail: true });
+			errors;
+		`)
+		require.NoError(t, err)
+		assert.Len(t, v.Export(), 1)
+		assert.Contains(t, v.Export().([]any)[0], "failed in the worker")
+	})
+
+	t.Run("terminate", func(t *testing.T) {
+		t.Parallel()
+		runtime := newConfiguredRuntime(t)
+		runtime.MoveToVUContext(&lib.State{})
+
+		v, err := runtime.RunOnEventLoop(`
+			worker.terminate();
+			worker.postMessage({ a: 1, b: 2 });
+			messages.length;
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), v.Export())
+	})
+
+	t.Run("interrupted with the VU", func(t *testing.T) {
+		t.Parallel()
+		runtime := newConfiguredRuntime(t)
+		runtime.MoveToVUContext(&lib.State{})
+
+		ctx, cancel := context.WithCancel(runtime.VU.Context())
+		runtime.VU.CtxField = ctx
+		time.AfterFunc(100*time.Millisecond, cancel)
+		_, err := runtime.RunOnEventLoop(`worker.postMessage({ loop: true });`)
+		require.NoError(t, err)
+
+		runtime.VU.CtxField = context.Background()
+		v, err := runtime.RunOnEventLoop(`
+			worker.postMessage({ a: 1, b: 2 });
+			messages;
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{"ready", map[string]any{"sum": int64(3), "calls": int64(2)}}, v.Export())
+	})
+
+	t.Run("only in the init context", func(t *testing.T) {
+		t.Parallel()
+		runtime := newConfiguredRuntime(t)
+		runtime.MoveToVUContext(&lib.State{})
+
+		_, err := runtime.RunOnEventLoop(`new Worker("./worker.js");`)
+		require.ErrorContains(t, err, "new Worker() can only be used in the init context")
+	})
+}
@@ -0,0 +1,320 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+var errWorkerStopped = errors.New("the worker was stopped")
+
+// task is a unit of work of a worker, i.e. running its script or handling a
+// message from the VU. Its done callback is registered in the event loop of
+// the VU, so the iteration waits for the worker to finish the task and its
+// posted messages are delivered to the VU. The task is interrupted if the
+// context of the VU at the time it was queued, e.g. of the iteration, is done.
+type task struct {
+	ctx  context.Context //nolint:containedctx
+	run  func() error
+	done func(func() error)
+}
+
+// worker runs its script in its own JS runtime. Its goroutine is started
+// only when the VU queues tasks, and it exits as soon as they are finished,
+// so no goroutine outlives the iterations, or the init context, of the VU.
+// Only the goroutine of the worker uses its runtime, and only the VU uses the
+// Worker object, so the only state they share is the queue of tasks.
+type worker struct {
+	vu      modules.VU
+	obj     *goja.Object
+	program *goja.Program
+	logger  *logrus.Entry
+
+	// used only by the VU
+	terminated bool
+
+	// used only by the goroutine of the worker
+	rt      *goja.Runtime
+	outbox  []string
+	closing bool
+
+	mx       sync.Mutex
+	queue    []task
+	running  bool
+	stopped  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newWorker(vu modules.VU, obj *goja.Object, program *goja.Program) *worker {
+	return &worker{
+		vu:      vu,
+		obj:     obj,
+		program: program,
+		logger:  vu.InitEnv().Logger.WithField("source", "worker"),
+		stop:    make(chan struct{}),
+	}
+}
+
+// start creates the runtime of the worker and queues running its script.
+func (w *worker) start() {
+	w.rt = w.newRuntime()
+	w.enqueue(func() error {
+		_, err := w.rt.RunProgram(w.program)
+		return err
+	})
+}
+
+func (w *worker) newRuntime() *goja.Runtime {
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+
+	console := rt.NewObject()
+	for name, level := range map[string]logrus.Level{
+		"log":   logrus.InfoLevel,
+		"info":  logrus.InfoLevel,
+		"debug": logrus.DebugLevel,
+		"warn":  logrus.WarnLevel,
+		"error": logrus.ErrorLevel,
+	} {
+		level := level
+		_ = console.Set(name, func(args ...goja.Value) {
+			strs := make([]string, len(args))
+			for i, arg := range args {
+				strs[i] = arg.String()
+			}
+			w.logger.Log(level, strings.Join(strs, " "))
+		})
+	}
+
+	global := rt.GlobalObject()
+	for name, value := range map[string]any{
+		"self":        global,
+		"console":     console,
+		"postMessage": w.workerPostMessage,
+		"close":       func() { w.closing = true },
+	} {
+		_ = global.Set(name, value)
+	}
+	return rt
+}
+
+// enqueue adds a task to the queue of the worker and starts its goroutine,
+// if it isn't already running. It's called by the VU.
+func (w *worker) enqueue(run func() error) {
+	done := w.vu.RegisterCallback()
+
+	w.mx.Lock()
+	if w.stopped {
+		w.mx.Unlock()
+		done(func() error { return nil })
+		return
+	}
+	w.queue = append(w.queue, task{ctx: w.vu.Context(), run: run, done: done})
+	if !w.running {
+		w.running = true
+		go w.loop()
+	}
+	w.mx.Unlock()
+}
+
+// loop runs the queued tasks until there are no more of them.
+func (w *worker) loop() {
+	for {
+		w.mx.Lock()
+		tasks := w.queue
+		w.queue = nil
+		if len(tasks) == 0 {
+			w.running = false
+			w.mx.Unlock()
+			return
+		}
+		w.mx.Unlock()
+
+		for i, t := range tasks {
+			if w.isStopped() {
+				w.drain(tasks[i:])
+				return
+			}
+
+			var err error
+			if t.ctx.Err() == nil {
+				err = w.runTask(t)
+			}
+			outbox := w.outbox
+			w.outbox = nil
+			t.done(func() error { return w.deliver(outbox, err) })
+
+			if w.closing {
+				w.halt()
+			}
+		}
+	}
+}
+
+// runTask runs the task, interrupting it if its context is done or if the
+// worker is stopped in the meantime.
+func (w *worker) runTask(t task) error {
+	finished, interrupted := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(interrupted)
+		select {
+		case <-t.ctx.Done():
+		case <-w.stop:
+		case <-finished:
+			return
+		}
+		w.rt.Interrupt(errWorkerStopped)
+	}()
+
+	err := t.run()
+	close(finished)
+	<-interrupted
+	w.rt.ClearInterrupt()
+	return err
+}
+
+// halt stops the worker, so it doesn't run any more tasks.
+func (w *worker) halt() {
+	w.mx.Lock()
+	w.stopped = true
+	w.mx.Unlock()
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+func (w *worker) isStopped() bool {
+	select {
+	case <-w.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain finishes the given and the queued tasks without running them, so the
+// VU doesn't wait for them.
+func (w *worker) drain(tasks []task) {
+	w.mx.Lock()
+	tasks = append(tasks, w.queue...)
+	w.queue = nil
+	w.running = false
+	w.mx.Unlock()
+
+	for _, t := range tasks {
+		t.done(func() error { return nil })
+	}
+}
+
+// deliver dispatches the messages posted by the worker during a task, and the
+// error of the task, to the Worker object. It's called by the VU.
+func (w *worker) deliver(outbox []string, err error) error {
+	if w.terminated {
+		return nil
+	}
+	rt := w.vu.Runtime()
+	for _, data := range outbox {
+		value, parseErr := parseMessage(rt, data)
+		if parseErr != nil {
+			return parseErr
+		}
+		if dispatchErr := w.dispatch("onmessage", "data", value); dispatchErr != nil {
+			return dispatchErr
+		}
+	}
+
+	var interruptedErr *goja.InterruptedError
+	if err == nil || errors.As(err, &interruptedErr) {
+		return nil
+	}
+	if _, ok := goja.AssertFunction(w.obj.Get("onerror")); ok {
+		return w.dispatch("onerror", "message", rt.ToValue(err.Error()))
+	}
+	return fmt.Errorf("uncaught error in the worker: %w", err)
+}
+
+// dispatch calls the handler of the Worker object with an event, which has
+// the given value as its field.
+func (w *worker) dispatch(handler, field string, value goja.Value) error {
+	fn, ok := goja.AssertFunction(w.obj.Get(handler))
+	if !ok {
+		return nil
+	}
+	event := w.vu.Runtime().NewObject()
+	if err := event.Set(field, value); err != nil {
+		return err
+	}
+	_, err := fn(w.obj, event)
+	return err
+}
+
+// postMessage is the postMessage() method of the Worker object.
+func (w *worker) postMessage(data goja.Value) {
+	if w.terminated {
+		return
+	}
+	rt := w.vu.Runtime()
+	message, err := stringifyMessage(rt, data)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	w.enqueue(func() error {
+		fn, ok := goja.AssertFunction(w.rt.Get("onmessage"))
+		if !ok {
+			return nil
+		}
+		value, err := parseMessage(w.rt, message)
+		if err != nil {
+			return err
+		}
+		event := w.rt.NewObject()
+		if err = event.Set("data", value); err != nil {
+			return err
+		}
+		_, err = fn(w.rt.GlobalObject(), event)
+		return err
+	})
+}
+
+// terminate is the terminate() method of the Worker object, which stops the
+// worker immediately.
+func (w *worker) terminate() {
+	w.terminated = true
+	w.halt()
+}
+
+// workerPostMessage is the postMessage() function of the worker, whose
+// messages are delivered to the VU when the worker finishes its task.
+func (w *worker) workerPostMessage(data goja.Value) {
+	message, err := stringifyMessage(w.rt, data)
+	if err != nil {
+		common.Throw(w.rt, err)
+	}
+	w.outbox = append(w.outbox, message)
+}
+
+// stringifyMessage serializes the message as JSON, so it can be passed
+// between the runtimes of the VU and the worker.
+func stringifyMessage(rt *goja.Runtime, data goja.Value) (string, error) {
+	stringify, _ := goja.AssertFunction(rt.Get("JSON").ToObject(rt).Get("stringify"))
+	v, err := stringify(goja.Undefined(), data)
+	if err != nil {
+		return "", fmt.Errorf("the message can't be serialized: %w", err)
+	}
+	if common.IsNullish(v) {
+		return "null", nil
+	}
+	return v.String(), nil
+}
+
+func parseMessage(rt *goja.Runtime, message string) (goja.Value, error) {
+	parse, _ := goja.AssertFunction(rt.Get("JSON").ToObject(rt).Get("parse"))
+	return parse(goja.Undefined(), rt.ToValue(message))
+}
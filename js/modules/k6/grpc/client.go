@@ -315,10 +315,16 @@ func (c *Client) Invoke(
 		return nil, fmt.Errorf("unable to serialise request object: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(c.vu.Context(), p.Timeout)
+	if p.Signal.Aborted() {
+		panic(p.Signal.Reason())
+	}
+	ctx, cancel := p.Signal.Context(c.vu.Context())
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, p.Timeout)
 	defer cancel()
 
 	p.SetSystemTags(state, c.addr, method)
+	p.SetCorrelationID(state)
 
 	reqmsg := grpcext.Request{
 		MethodDescriptor: methodDesc,
@@ -326,7 +332,11 @@ func (c *Client) Invoke(
 		TagsAndMeta:      &p.TagsAndMeta,
 	}
 
-	return c.conn.Invoke(ctx, method, p.Metadata, reqmsg)
+	resp, err := c.conn.Invoke(ctx, method, p.Metadata, reqmsg)
+	if p.Signal.Aborted() {
+		panic(p.Signal.Reason())
+	}
+	return resp, err
 }
 
 // Close will close the client gRPC connection
@@ -135,6 +135,7 @@ func (mi *ModuleInstance) stream(c goja.ConstructorCall) *goja.Object {
 	}
 
 	p.SetSystemTags(mi.vu.State(), client.addr, methodName)
+	p.SetCorrelationID(mi.vu.State())
 
 	logger := mi.vu.State().Logger.WithField("streamMethod", methodName)
 
@@ -2,6 +2,10 @@ package grpc
 
 import "go.k6.io/k6/metrics"
 
+// metricsNamespace is the namespace of the metrics of the grpc module, which
+// prefixes their names.
+const metricsNamespace = "grpc"
+
 // instanceMetrics contains the metrics for the grpc extension.
 type instanceMetrics struct {
 	Streams                 *metrics.Metric
@@ -11,18 +15,21 @@ type instanceMetrics struct {
 
 // registerMetrics registers and returns the metrics in the provided registry
 func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
-	var err error
+	namespaced, err := registry.Namespace(metricsNamespace)
+	if err != nil {
+		return nil, err
+	}
 	m := &instanceMetrics{}
 
-	if m.Streams, err = registry.NewMetric("grpc_streams", metrics.Counter); err != nil {
+	if m.Streams, err = namespaced.NewMetric("streams", metrics.Counter); err != nil {
 		return nil, err
 	}
 
-	if m.StreamsMessagesSent, err = registry.NewMetric("grpc_streams_msgs_sent", metrics.Counter); err != nil {
+	if m.StreamsMessagesSent, err = namespaced.NewMetric("streams_msgs_sent", metrics.Counter); err != nil {
 		return nil, err
 	}
 
-	if m.StreamsMessagesReceived, err = registry.NewMetric("grpc_streams_msgs_received", metrics.Counter); err != nil {
+	if m.StreamsMessagesReceived, err = namespaced.NewMetric("streams_msgs_received", metrics.Counter); err != nil {
 		return nil, err
 	}
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/dop251/goja"
+	"go.k6.io/k6/js/abort"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 	"go.k6.io/k6/lib"
@@ -21,6 +22,7 @@ type callParams struct {
 	Metadata    metadata.MD
 	TagsAndMeta metrics.TagsAndMeta
 	Timeout     time.Duration
+	Signal      *abort.Signal
 }
 
 // newCallParams constructs the call parameters from the input value.
@@ -58,6 +60,12 @@ func newCallParams(vu modules.VU, input goja.Value) (*callParams, error) {
 			if err != nil {
 				return result, fmt.Errorf("invalid timeout value: %w", err)
 			}
+		case "signal":
+			var err error
+			result.Signal, err = abort.FromValue(vu, params.Get(k))
+			if err != nil {
+				return result, fmt.Errorf("invalid signal param: %w", err)
+			}
 		default:
 			return result, fmt.Errorf("unknown param: %q", k)
 		}
@@ -119,6 +127,16 @@ func (p *callParams) SetSystemTags(state *lib.State, addr string, methodName str
 	}
 }
 
+// SetCorrelationID adds the current iteration's correlation ID to the call
+// metadata, unless the user has set it explicitly.
+func (p *callParams) SetCorrelationID(state *lib.State) {
+	key := state.Options.CorrelationIDHeader.String
+	if key == "" || state.IterationContext == nil || len(p.Metadata.Get(key)) > 0 {
+		return
+	}
+	p.Metadata.Set(key, state.IterationContext.CorrelationID)
+}
+
 // connectParams is the parameters that can be passed to a gRPC connect call.
 type connectParams struct {
 	IsPlaintext           bool
@@ -98,6 +98,27 @@ func TestCallParamsMetadata(t *testing.T) {
 	}
 }
 
+func TestCallParamsCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	state := &lib.State{
+		Options:          lib.Options{CorrelationIDHeader: null.StringFrom("X-Correlation-ID")},
+		IterationContext: &lib.IterationContext{CorrelationID: "0af7651916cd43dd8448eb211c80319c"},
+	}
+
+	testRuntime, params := newParamsTestRuntime(t, `{}`)
+	p, err := newCallParams(testRuntime.VU, params)
+	require.NoError(t, err)
+	p.SetCorrelationID(state)
+	assert.Equal(t, []string{"0af7651916cd43dd8448eb211c80319c"}, p.Metadata.Get("x-correlation-id"))
+
+	testRuntime, params = newParamsTestRuntime(t, `{metadata: {"x-correlation-id": "custom"}}`)
+	p, err = newCallParams(testRuntime.VU, params)
+	require.NoError(t, err)
+	p.SetCorrelationID(state)
+	assert.Equal(t, []string{"custom"}, p.Metadata.Get("x-correlation-id"))
+}
+
 func TestCallParamsTimeOutParse(t *testing.T) {
 	t.Parallel()
 
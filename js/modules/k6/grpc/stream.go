@@ -83,17 +83,25 @@ func (s *stream) beginStream(p *callParams) error {
 		Metadata:         p.Metadata,
 	}
 
-	ctx := s.vu.Context()
-	var cancel context.CancelFunc
+	// the stream is canceled when the signal is aborted, and its error
+	// listeners are called with the cancellation
+	ctx, cancel := p.Signal.Context(s.vu.Context())
 
 	if p.Timeout != time.Duration(0) {
-		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		var timeoutCancel context.CancelFunc
+		ctx, timeoutCancel = context.WithTimeout(ctx, p.Timeout)
+		signalCancel := cancel
+		cancel = func() {
+			timeoutCancel()
+			signalCancel()
+		}
 	}
 
 	s.timeoutCancel = cancel
 
 	stream, err := s.client.conn.NewStream(ctx, *req)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("failed to create a new stream: %w", err)
 	}
 	s.stream = stream
@@ -7,6 +7,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/abort"
 )
 
 func wrapInAsyncLambda(input string) string {
@@ -260,3 +262,62 @@ func TestAsyncRequestErrors(t *testing.T) {
 		assert.Contains(t, promiseRejected.ToString(), expErr)
 	})
 }
+
+func TestRequestSignal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("async", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		require.NoError(t, abort.Install(ts.runtime.VU))
+
+		sr := ts.tb.Replacer.Replace
+		_, err := ts.runtime.RunOnEventLoop(wrapInAsyncLambda(sr(`
+			const controller = new AbortController();
+			const pending = http.asyncRequest("GET", "HTTPBIN_URL/delay/10", null, { signal: controller.signal });
+			controller.abort("aborted");
+			try {
+				await pending;
+				throw new Error("the request should be aborted");
+			} catch (e) {
+				if (e !== "aborted") throw e;
+			}
+
+			try {
+				await http.asyncRequest("GET", "HTTPBIN_URL/get", null, { signal: controller.signal });
+				throw new Error("the request shouldn't be made");
+			} catch (e) {
+				if (e !== "aborted") throw e;
+			}
+		`)))
+		require.NoError(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		require.NoError(t, abort.Install(ts.runtime.VU))
+
+		start := time.Now()
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			try {
+				http.get("HTTPBIN_URL/delay/10", { signal: AbortSignal.timeout(100) });
+				throw new Error("the request should time out");
+			} catch (e) {
+				if (e.name !== "TimeoutError") throw e;
+			}
+		`))
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			http.get("HTTPBIN_URL/get", { signal: {} });
+		`))
+		require.ErrorContains(t, err, "invalid signal param: the signal must be an AbortSignal")
+	})
+}
@@ -0,0 +1,431 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"gopkg.in/yaml.v3"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/jsonschema"
+	"go.k6.io/k6/metrics"
+)
+
+// contractViolationsMetric counts the responses not matching their JSON
+// schema or their OpenAPI operation.
+const contractViolationsMetric = "contract_violations"
+
+// contracts cache the compiled JSON schemas and OpenAPI specs, which are
+// shared by all the VUs, so they're compiled only once.
+type contracts struct {
+	schemas queryCache[*jsonschema.Schema]
+
+	mx    sync.Mutex
+	specs map[string]*openAPISpec
+}
+
+// ContractValidation is the result of the validation of a response against
+// its JSON schema or its OpenAPI operation.
+type ContractValidation struct {
+	Valid  bool     `js:"valid"`
+	Errors []string `js:"errors"`
+	// Operation is the operationId, or the method and the path, of the
+	// OpenAPI operation of the response.
+	Operation string `js:"operation"`
+}
+
+// ValidateSchema validates the JSON body of the response against the JSON
+// schema, like `res.validateSchema({type: "object", required: ["id"]})`. The
+// invalid responses are counted by the contract_violations metric.
+func (res *Response) ValidateSchema(schema goja.Value) *ContractValidation {
+	mi := res.client.moduleInstance
+	rt := mi.vu.Runtime()
+	if common.IsNullish(schema) {
+		common.Throw(rt, fmt.Errorf("validateSchema() expects a JSON schema"))
+	}
+
+	var doc interface{}
+	if str, ok := schema.Export().(string); ok {
+		if err := json.Unmarshal([]byte(str), &doc); err != nil {
+			common.Throw(rt, fmt.Errorf("invalid JSON schema: %w", err))
+		}
+	} else {
+		doc = schema.Export()
+	}
+	// the schemas are cached by their JSON encoding, as the objects of the
+	// scripts aren't the same in the VUs
+	key, err := json.Marshal(doc)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid JSON schema: %w", err))
+	}
+	compiled, err := mi.rootModule.contracts.schemas.get(string(key), func(string) (*jsonschema.Schema, error) {
+		return jsonschema.Compile(doc)
+	})
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	result := &ContractValidation{}
+	body, err := res.parseJSONBody()
+	if err != nil {
+		result.Errors = []string{err.Error()}
+	} else {
+		for _, verr := range compiled.Validate(body) {
+			result.Errors = append(result.Errors, verr.Error())
+		}
+	}
+	mi.reportContract(res, result)
+	return result
+}
+
+// parseJSONBody returns the JSON body of the response, like json(), but with
+// an error for the bodies that aren't JSON.
+func (res *Response) parseJSONBody() (interface{}, error) {
+	if res.cachedJSON != nil {
+		return res.cachedJSON, nil
+	}
+	if res.Body == nil {
+		return nil, fmt.Errorf("the body is null so it can't be validated")
+	}
+	body, err := common.ToBytes(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("the body isn't valid JSON: %w", err)
+	}
+	res.cachedJSON, res.validatedJSON = v, true
+	return v, nil
+}
+
+// reportContract completes the result of a validation and counts it by the
+// contract_violations metric, if it failed.
+func (mi *ModuleInstance) reportContract(res *Response, result *ContractValidation) {
+	result.Valid = len(result.Errors) == 0
+	if result.Errors == nil {
+		result.Errors = []string{}
+	}
+	state := mi.vu.State()
+	if result.Valid || state == nil || mi.contractViolations == nil {
+		return
+	}
+
+	ctm := state.Tags.GetCurrentValues()
+	if res.Request != nil {
+		ctm.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagMethod, res.Request.Method)
+	}
+	ctm.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagStatus, strconv.Itoa(res.Status))
+	if result.Operation != "" {
+		ctm.SetTag("operation", result.Operation)
+	}
+	metrics.PushIfNotDone(mi.vu.Context(), state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: mi.contractViolations, Tags: ctm.Tags},
+		Time:       time.Now(),
+		Metadata:   ctm.Metadata,
+		Value:      1,
+	})
+}
+
+// OpenAPI is an OpenAPI 3 spec validating the responses of its operations.
+type OpenAPI struct {
+	mi   *ModuleInstance
+	spec *openAPISpec
+}
+
+// newOpenAPI parses the OpenAPI spec, in JSON or in YAML, like
+// `new http.OpenAPI(open("./openapi.yaml"))`. The specs are parsed once and
+// shared by the VUs.
+func (mi *ModuleInstance) newOpenAPI(call goja.ConstructorCall) *goja.Object {
+	rt := mi.vu.Runtime()
+	source := call.Argument(0)
+	if common.IsNullish(source) {
+		common.Throw(rt, fmt.Errorf("OpenAPI expects the source of the spec"))
+	}
+	spec, err := mi.rootModule.contracts.spec(source.String())
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return rt.ToValue(&OpenAPI{mi: mi, spec: spec}).ToObject(rt)
+}
+
+// Validate validates the response against the operation of its request: its
+// status has to be documented, like its content type, and its JSON body has
+// to match the schema of the operation. The invalid responses are counted by
+// the contract_violations metric, which is tagged with the operation.
+func (o *OpenAPI) Validate(res *Response) *ContractValidation {
+	if res == nil || res.Response == nil || res.Request == nil {
+		common.Throw(o.mi.vu.Runtime(), fmt.Errorf("validate() expects a response"))
+	}
+	result := &ContractValidation{}
+	op := o.spec.match(res.Request.Method, res.Request.URL)
+	if op == nil {
+		result.Errors = []string{fmt.Sprintf("no operation of the spec matches %s %s", res.Request.Method, res.Request.URL)}
+	} else {
+		result.Operation = op.name
+		result.Errors = op.validate(res)
+	}
+	o.mi.reportContract(res, result)
+	return result
+}
+
+// openAPISpec is a parsed OpenAPI spec, which is safe for concurrent use.
+type openAPISpec struct {
+	operations []*openAPIOperation
+}
+
+type openAPIOperation struct {
+	name   string
+	method string
+	// paths are the templates of the paths of the operation, one per server
+	paths []openAPIPath
+	// responses are the media types of the responses, by status, and their
+	// schemas, if any
+	responses map[string]map[string]*jsonschema.Schema
+}
+
+type openAPIPath struct {
+	segments []*regexp.Regexp
+	literals int
+}
+
+// spec returns the parsed spec of the source.
+func (c *contracts) spec(source string) (*openAPISpec, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if spec, ok := c.specs[source]; ok {
+		return spec, nil
+	}
+	spec, err := parseOpenAPI(source)
+	if err != nil {
+		return nil, err
+	}
+	if c.specs == nil {
+		c.specs = make(map[string]*openAPISpec)
+	}
+	c.specs[source] = spec
+	return spec, nil
+}
+
+//nolint:funlen,cyclop
+func parseOpenAPI(source string) (*openAPISpec, error) {
+	var doc interface{}
+	// the JSON specs are YAML documents too
+	if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	compiler := jsonschema.NewCompiler(doc)
+	root, _ := compiler.Resolve("#")
+	obj, _ := root.(map[string]interface{})
+	if version, _ := obj["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("invalid OpenAPI spec: only the OpenAPI 3 specs are supported")
+	}
+
+	basePaths := []string{""}
+	if servers, ok := obj["servers"].([]interface{}); ok && len(servers) > 0 {
+		basePaths = basePaths[:0]
+		for _, server := range servers {
+			serverObj, _ := server.(map[string]interface{})
+			serverURL, _ := serverObj["url"].(string)
+			// the variables of the servers aren't valid in the URLs
+			u, err := url.Parse(strings.NewReplacer("{", "%7B", "}", "%7D").Replace(serverURL))
+			if err != nil {
+				return nil, fmt.Errorf("invalid OpenAPI spec: invalid server URL '%s': %w", serverURL, err)
+			}
+			basePaths = append(basePaths, strings.TrimSuffix(u.Path, "/"))
+		}
+	}
+
+	var err error
+	spec := &openAPISpec{}
+	paths, _ := obj["paths"].(map[string]interface{})
+	templates := make([]string, 0, len(paths))
+	for template := range paths {
+		templates = append(templates, template)
+	}
+	sort.Strings(templates)
+	for _, template := range templates {
+		pathItem, _ := paths[template].(map[string]interface{})
+		pointer := "#/paths/" + escapePointer(template)
+		for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op := &openAPIOperation{
+				method:    strings.ToUpper(method),
+				responses: make(map[string]map[string]*jsonschema.Schema),
+			}
+			if op.name, _ = operation["operationId"].(string); op.name == "" {
+				op.name = op.method + " " + template
+			}
+			for _, basePath := range basePaths {
+				path, err := compilePathTemplate(basePath + template)
+				if err != nil {
+					return nil, fmt.Errorf("invalid OpenAPI spec: invalid path '%s': %w", template, err)
+				}
+				op.paths = append(op.paths, path)
+			}
+			responses, _ := operation["responses"].(map[string]interface{})
+			for status := range responses {
+				responsePointer := pointer + "/" + method + "/responses/" + escapePointer(status)
+				if op.responses[strings.ToUpper(status)], err = compileResponse(compiler, responsePointer); err != nil {
+					return nil, fmt.Errorf("invalid OpenAPI spec: invalid response %s of %s: %w", status, op.name, err)
+				}
+			}
+			spec.operations = append(spec.operations, op)
+		}
+	}
+	return spec, nil
+}
+
+// compileResponse compiles the schemas of the media types of a response.
+func compileResponse(compiler *jsonschema.Compiler, pointer string) (map[string]*jsonschema.Schema, error) {
+	value, err := compiler.Resolve(pointer)
+	if err != nil {
+		return nil, err
+	}
+	response, _ := value.(map[string]interface{})
+	if ref, ok := response["$ref"].(string); ok {
+		if value, err = compiler.Resolve(ref); err != nil {
+			return nil, err
+		}
+		pointer = ref
+		response, _ = value.(map[string]interface{})
+	}
+
+	content, _ := response["content"].(map[string]interface{})
+	mediaTypes := make(map[string]*jsonschema.Schema, len(content))
+	for mediaType, value := range content {
+		mediaTypes[mediaType] = nil
+		if mediaTypeObj, _ := value.(map[string]interface{}); mediaTypeObj["schema"] == nil {
+			continue
+		}
+		schemaPointer := pointer + "/content/" + escapePointer(mediaType) + "/schema"
+		if mediaTypes[mediaType], err = compiler.Compile(schemaPointer); err != nil {
+			return nil, err
+		}
+	}
+	return mediaTypes, nil
+}
+
+// pathParameterRegexp matches the quoted parameters of the path templates.
+var pathParameterRegexp = regexp.MustCompile(`\\\{[^/]*?\\\}`)
+
+// compilePathTemplate compiles the segments of a path template, like
+// `/pets/{id}`, in which the parameters match any segment.
+func compilePathTemplate(template string) (openAPIPath, error) {
+	var path openAPIPath
+	for _, segment := range strings.Split(strings.Trim(template, "/"), "/") {
+		if !strings.Contains(segment, "{") {
+			path.literals++
+		}
+		expr := pathParameterRegexp.ReplaceAllString(regexp.QuoteMeta(segment), `[^/]+`)
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return path, err
+		}
+		path.segments = append(path.segments, re)
+	}
+	return path, nil
+}
+
+// match returns the operation of the request, preferring the paths with the
+// most literal segments, like `/pets/mine` over `/pets/{id}`.
+func (s *openAPISpec) match(method, rawURL string) *openAPIOperation {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+
+	var best *openAPIOperation
+	bestLiterals := -1
+	for _, op := range s.operations {
+		if op.method != method {
+			continue
+		}
+		for _, path := range op.paths {
+			if path.literals > bestLiterals && path.matches(segments) {
+				best, bestLiterals = op, path.literals
+			}
+		}
+	}
+	return best
+}
+
+func (p openAPIPath) matches(segments []string) bool {
+	if len(p.segments) != len(segments) {
+		return false
+	}
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		if !p.segments[i].MatchString(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// validate returns the violations of the operation by the response.
+func (op *openAPIOperation) validate(res *Response) []string {
+	status := strconv.Itoa(res.Status)
+	mediaTypes, ok := op.responses[status]
+	if !ok {
+		mediaTypes, ok = op.responses[status[:1]+"XX"]
+	}
+	if !ok {
+		mediaTypes, ok = op.responses["DEFAULT"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("the status %d isn't documented", res.Status)}
+	}
+	if len(mediaTypes) == 0 {
+		return nil
+	}
+
+	contentType := res.Headers[http.CanonicalHeaderKey("Content-Type")]
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return []string{fmt.Sprintf("the content type '%s' of the status %d isn't documented", contentType, res.Status)}
+	}
+	schema, ok := mediaTypes[mediaType]
+	if i := strings.Index(mediaType, "/"); !ok && i > 0 {
+		schema, ok = mediaTypes[mediaType[:i]+"/*"]
+	}
+	if !ok {
+		schema, ok = mediaTypes["*/*"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("the content type '%s' of the status %d isn't documented", mediaType, res.Status)}
+	}
+	if schema == nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+
+	body, err := res.parseJSONBody()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var violations []string
+	for _, verr := range schema.Validate(body) {
+		violations = append(violations, verr.Error())
+	}
+	return violations
+}
+
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+const petstoreSpec = `
+openapi: 3.0.3
+info: {title: Petstore, version: 1.0.0}
+servers:
+  - url: HTTPBIN_URL/api
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        200:
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+        4XX:
+          $ref: "#/components/responses/Error"
+  /pets/mine:
+    get:
+      responses:
+        200:
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: "#/components/schemas/Pet"}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+        tag: {type: string, nullable: true}
+  responses:
+    Error:
+      description: an error
+      content:
+        text/plain: {}
+`
+
+func newContractTestCase(t *testing.T) *httpTestCase {
+	t.Helper()
+	ts := newTestCase(t)
+	bodies := map[string]string{
+		"/api/pets/1":    `{"id": 1, "name": "rex", "tag": null}`,
+		"/api/pets/2":    `{"id": "2", "tag": 3}`,
+		"/api/pets/mine": `[{"id": 1, "name": "rex"}]`,
+	}
+	ts.tb.Mux.HandleFunc("/api/pets/", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		switch {
+		case r.URL.Query().Get("status") == "500":
+			w.WriteHeader(http.StatusInternalServerError)
+		case !ok:
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(body))
+		}
+	})
+	return ts
+}
+
+func TestResponseValidateSchema(t *testing.T) {
+	t.Parallel()
+	ts := newContractTestCase(t)
+
+	v, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+		var schema = {type: "object", required: ["id", "name"], properties: {id: {type: "integer"}}};
+		var valid = http.get("HTTPBIN_URL/api/pets/1").validateSchema(schema);
+		if (!valid.valid || valid.errors.length !== 0) {
+			throw new Error("unexpected errors " + JSON.stringify(valid.errors));
+		}
+		var invalid = http.get("HTTPBIN_URL/api/pets/2").validateSchema(JSON.stringify(schema));
+		invalid.valid + ": " + invalid.errors.join(", ");
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, `false: the property "name" is required, /id: expected integer, but got string`, v.String())
+	assert.Equal(t, 1, countContractViolations(t, ts, ""))
+
+	_, err = ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+		http.get("HTTPBIN_URL/api/pets/1").validateSchema({type: 1});
+	`))
+	require.ErrorContains(t, err, "invalid schema at #: type has to be a string or an array of strings")
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+	ts := newContractTestCase(t)
+	rt := ts.runtime.VU.Runtime()
+	require.NoError(t, rt.Set("spec", ts.tb.Replacer.Replace(petstoreSpec)))
+
+	v, err := rt.RunString(ts.tb.Replacer.Replace(`
+		var api = new http.OpenAPI(spec);
+		[
+			"HTTPBIN_URL/api/pets/1",
+			"HTTPBIN_URL/api/pets/mine",
+			"HTTPBIN_URL/api/pets/2",
+			"HTTPBIN_URL/api/pets/3",
+			"HTTPBIN_URL/api/pets/1?status=500",
+			"HTTPBIN_URL/pets/1",
+		].map(function(url) {
+			var result = api.validate(http.get(url));
+			return result.operation + " " + result.valid + " " + result.errors.join(", ");
+		});
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		"getPet true ",
+		"GET /pets/mine true ",
+		`getPet false the property "name" is required, /id: expected integer, but got string, ` +
+			"/tag: expected string, but got integer",
+		"getPet true ",
+		"getPet false the status 500 isn't documented",
+		" false no operation of the spec matches GET " + ts.tb.Replacer.Replace("HTTPBIN_URL/pets/1"),
+	}, v.Export())
+	assert.Equal(t, 2, countContractViolations(t, ts, "getPet"))
+
+	_, err = rt.RunString(`new http.OpenAPI("swagger: '2.0'")`)
+	require.ErrorContains(t, err, "only the OpenAPI 3 specs are supported")
+	_, err = rt.RunString(`new http.OpenAPI("openapi: 3.1.0\npaths: {/a: {get: {responses: {200: {$ref: '#/nope'}}}}}")`)
+	require.ErrorContains(t, err, "invalid response 200 of GET /a: the $ref '#/nope' doesn't exist")
+}
+
+// countContractViolations returns the number of contract violations of the
+// operation, or of all the ones for an empty operation.
+func countContractViolations(t *testing.T, ts *httpTestCase, operation string) int {
+	t.Helper()
+	n := 0
+	for _, container := range metrics.GetBufferedSamples(ts.samples) {
+		for _, sample := range container.GetSamples() {
+			if sample.Metric.Name != contractViolationsMetric {
+				continue
+			}
+			if op, _ := sample.Tags.Get("operation"); operation == "" || op == operation {
+				n += int(sample.Value)
+			}
+		}
+	}
+	return n
+}
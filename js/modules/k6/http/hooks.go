@@ -0,0 +1,127 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dop251/goja"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// AddRequestHook registers a function called with every request of the VU
+// before it's sent, like `{method, url, headers, body}`. The hook can change
+// the url and the headers of the request, e.g. to sign it or to refresh its
+// credentials, while its body is read-only.
+func (c *Client) AddRequestHook(fn goja.Value) {
+	hook, ok := goja.AssertFunction(fn)
+	if !ok {
+		common.Throw(c.moduleInstance.vu.Runtime(), fmt.Errorf("the request hook has to be a function"))
+	}
+	c.requestHooks = append(c.requestHooks, hook)
+}
+
+// AddResponseHook registers a function called with every response of the VU,
+// once its request is done.
+func (c *Client) AddResponseHook(fn goja.Value) {
+	hook, ok := goja.AssertFunction(fn)
+	if !ok {
+		common.Throw(c.moduleInstance.vu.Runtime(), fmt.Errorf("the response hook has to be a function"))
+	}
+	c.responseHooks = append(c.responseHooks, hook)
+}
+
+// runRequestHooks calls the request hooks, in the order they were added, and
+// applies their changes to the request.
+func (c *Client) runRequestHooks(preq *httpext.ParsedHTTPRequest) error {
+	if len(c.requestHooks) == 0 {
+		return nil
+	}
+	rt := c.moduleInstance.vu.Runtime()
+
+	headers := rt.NewObject()
+	for key, values := range preq.Req.Header {
+		if err := headers.Set(key, strings.Join(values, ", ")); err != nil {
+			return err
+		}
+	}
+	var body string
+	if preq.Body != nil {
+		body = preq.Body.String()
+	}
+	req := rt.NewObject()
+	for key, value := range map[string]interface{}{
+		"method":  preq.Req.Method,
+		"url":     preq.URL.URL,
+		"headers": headers,
+		"body":    body,
+	} {
+		if err := req.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	for _, hook := range c.requestHooks {
+		if _, err := hook(goja.Undefined(), req); err != nil {
+			return err
+		}
+	}
+
+	if newURL := req.Get("url").String(); newURL != preq.URL.URL {
+		// the name of a templated URL is kept, so the tags don't change
+		name := newURL
+		if preq.URL.Name != preq.URL.Clean() {
+			name = preq.URL.Name
+		}
+		u, err := httpext.NewURL(newURL, name)
+		if err != nil {
+			return err
+		}
+		preq.URL = &u
+		preq.Req.URL = u.GetURL()
+	}
+
+	newHeaders := req.Get("headers").ToObject(rt)
+	preq.Req.Header = make(map[string][]string, len(newHeaders.Keys()))
+	for _, key := range newHeaders.Keys() {
+		value := newHeaders.Get(key).String()
+		if strings.EqualFold(key, "host") {
+			preq.Req.Host = value
+		}
+		preq.Req.Header.Set(key, value)
+	}
+	return nil
+}
+
+// runResponseHooks calls the response hooks, in the order they were added.
+func (c *Client) runResponseHooks(resp *Response) error {
+	if len(c.responseHooks) == 0 {
+		return nil
+	}
+	res := c.moduleInstance.vu.Runtime().ToValue(resp)
+	for _, hook := range c.responseHooks {
+		if _, err := hook(goja.Undefined(), res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runBatchResponseHooks calls the response hooks with the responses of a batch.
+func (c *Client) runBatchResponseHooks(results interface{}) error {
+	switch results := results.(type) {
+	case []*Response:
+		for _, res := range results {
+			if err := c.runResponseHooks(res); err != nil {
+				return err
+			}
+		}
+	case map[string]*Response:
+		for _, res := range results {
+			if err := c.runResponseHooks(res); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHooks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Request", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		sr := ts.tb.Replacer.Replace
+		v, err := ts.runtime.VU.Runtime().RunString(sr(`
+			var seen = [];
+			http.addRequestHook(function(req) {
+				seen.push(req.method + " " + req.body);
+				req.headers["Authorization"] = "Bearer token";
+				delete req.headers["X-Internal"];
+			});
+			http.addRequestHook(function(req) {
+				req.url = req.url.replace("/wrong", "/post");
+			});
+			var statuses = [];
+			http.addResponseHook(function(res) { statuses.push(res.status); });
+
+			var res = http.post("HTTPBIN_URL/wrong", "payload", {headers: {"X-Internal": "1"}});
+			var json = res.json();
+			if (json.headers["Authorization"] != "Bearer token") {
+				throw new Error("the header wasn't set: " + JSON.stringify(json.headers));
+			}
+			if (json.headers["X-Internal"] !== undefined) {
+				throw new Error("the header wasn't removed: " + JSON.stringify(json.headers));
+			}
+			if (json.url != "HTTPBIN_URL/post") {
+				throw new Error("the url wasn't changed: " + json.url);
+			}
+
+			http.batch([["GET", "HTTPBIN_URL/status/201"], ["GET", "HTTPBIN_URL/status/202"]]);
+			seen.join(",") + " " + statuses.sort().join(",")
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, "POST payload,GET ,GET  200,201,202", v.String())
+	})
+
+	t.Run("Async", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		sr := ts.tb.Replacer.Replace
+		_, err := ts.runtime.RunOnEventLoop(wrapInAsyncLambda(sr(`
+			http.addRequestHook(function(req) { req.headers["X-Signed"] = "yes"; });
+			var hooked;
+			http.addResponseHook(function(res) { hooked = res; });
+			var res = await http.asyncRequest("GET", "HTTPBIN_URL/headers");
+			if (res.json().headers["X-Signed"] != "yes") { throw new Error("the request hook wasn't called"); }
+			if (hooked !== res) { throw new Error("the response hook wasn't called"); }
+		`)))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		sr := ts.tb.Replacer.Replace
+		_, err := ts.runtime.VU.Runtime().RunString(`http.addRequestHook("nope")`)
+		require.ErrorContains(t, err, "the request hook has to be a function")
+
+		_, err = ts.runtime.VU.Runtime().RunString(sr(`
+			http.addResponseHook(function(res) { throw new Error("unexpected status " + res.status); });
+			http.get("HTTPBIN_URL/status/418");
+		`))
+		require.ErrorContains(t, err, "unexpected status 418")
+	})
+}
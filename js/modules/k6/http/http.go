@@ -1,6 +1,7 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/cookiejar"
 
@@ -9,6 +10,7 @@ import (
 	"go.k6.io/k6/js/modules"
 	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/lib/netext/httpext"
+	"go.k6.io/k6/metrics"
 )
 
 // RootModule is the global module object type. It is instantiated once per test
@@ -16,7 +18,10 @@ import (
 //
 // TODO: add sync.Once for all of the deprecation warnings we might want to do
 // for the old k6/http APIs here, so they are shown only once in a test run.
-type RootModule struct{}
+type RootModule struct {
+	queries   queryCaches
+	contracts contracts
+}
 
 // ModuleInstance represents an instance of the HTTP module for every VU.
 type ModuleInstance struct {
@@ -24,6 +29,8 @@ type ModuleInstance struct {
 	rootModule    *RootModule
 	defaultClient *Client
 	exports       *goja.Object
+
+	contractViolations *metrics.Metric
 }
 
 var (
@@ -46,6 +53,16 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	}
 	mi.defineConstants()
 
+	// the instances of the VU context, like the ones of some tests, don't
+	// report the contract violations
+	if initEnv := vu.InitEnv(); initEnv != nil {
+		var err error
+		mi.contractViolations, err = initEnv.Registry.NewMetric(contractViolationsMetric, metrics.Counter)
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("failed to register the HTTP module metrics: %w", err))
+		}
+	}
+
 	mi.defaultClient = &Client{
 		// TODO: configure this from lib.Options and get rid of some of the
 		// things in the VU State struct that should be here. See
@@ -63,6 +80,8 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	mustExport("url", mi.URL)
 	mustExport("CookieJar", mi.newCookieJar)
 	mustExport("cookieJar", mi.getVUCookieJar)
+	mustExport("Session", mi.newSession)
+	mustExport("OpenAPI", mi.newOpenAPI)
 	mustExport("file", mi.file) // TODO: deprecate or refactor?
 
 	// TODO: refactor so the Client actually has better APIs and these are
@@ -89,8 +108,11 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	mustExport("asyncRequest", mi.defaultClient.asyncRequest)
 	mustExport("batch", mi.defaultClient.Batch)
 	mustExport("setResponseCallback", mi.defaultClient.SetResponseCallback)
+	mustExport("addRequestHook", mi.defaultClient.AddRequestHook)
+	mustExport("addResponseHook", mi.defaultClient.AddResponseHook)
 
 	mustExport("expectedStatuses", mi.expectedStatuses) // TODO: refactor?
+	mustExport("jsonChecks", mi.jsonChecks)
 
 	// TODO: actually expose the default client as k6/http.defaultClient when we
 	// have a better HTTP API (e.g. proper Client constructor, an actual Request
@@ -180,4 +202,6 @@ func (mi *ModuleInstance) URL(parts []string, pieces ...string) (httpext.URL, er
 type Client struct {
 	moduleInstance   *ModuleInstance
 	responseCallback func(int) bool
+	requestHooks     []goja.Callable
+	responseHooks    []goja.Callable
 }
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/jmespath"
+	"go.k6.io/k6/lib/jsonpath"
+)
+
+// maxCachedQueries bounds the compiled expressions of a queryCache, as the
+// scripts making them out of dynamic values would grow it forever.
+const maxCachedQueries = 1000
+
+// queryCaches cache the compiled JSONPath and JMESPath expressions, which are
+// shared by all the VUs, so the expressions of the iterations are compiled
+// only once.
+type queryCaches struct {
+	jsonPaths queryCache[*jsonpath.Path]
+	jmesPaths queryCache[*jmespath.Expression]
+}
+
+type queryCache[T any] struct {
+	mx       sync.RWMutex
+	compiled map[string]T
+}
+
+// get returns the compiled expression, compiling it if it isn't cached.
+func (c *queryCache[T]) get(expr string, compile func(string) (T, error)) (T, error) {
+	c.mx.RLock()
+	query, ok := c.compiled[expr]
+	c.mx.RUnlock()
+	if ok {
+		return query, nil
+	}
+
+	query, err := compile(expr)
+	if err != nil {
+		return query, err
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.compiled == nil || len(c.compiled) >= maxCachedQueries {
+		c.compiled = make(map[string]T)
+	}
+	c.compiled[expr] = query
+	return query, nil
+}
+
+// jsonPath returns the values of the document matching the JSONPath
+// expression.
+func (q *queryCaches) jsonPath(doc interface{}, expr string) ([]interface{}, error) {
+	path, err := q.jsonPaths.get(expr, jsonpath.Compile)
+	if err != nil {
+		return nil, err
+	}
+	return path.Find(doc), nil
+}
+
+// jmesPath returns the result of the JMESPath expression for the document.
+func (q *queryCaches) jmesPath(doc interface{}, expr string) (interface{}, error) {
+	e, err := q.jmesPaths.get(expr, jmespath.Compile)
+	if err != nil {
+		return nil, err
+	}
+	return e.Search(doc)
+}
+
+// compile compiles the expression of query(), to report the invalid ones.
+func (q *queryCaches) compile(expr string) error {
+	var err error
+	if isJSONPath(expr) {
+		_, err = q.jsonPaths.get(expr, jsonpath.Compile)
+	} else {
+		_, err = q.jmesPaths.get(expr, jmespath.Compile)
+	}
+	return err
+}
+
+func isJSONPath(expr string) bool {
+	return strings.HasPrefix(strings.TrimSpace(expr), "$")
+}
+
+// query returns the value of the document selected by the expression, which
+// is a JSONPath one if it starts with $ and a JMESPath one otherwise. The
+// value of a JSONPath expression is its only match for the singular paths,
+// like `$.items[0].id`, and the array of its matches for the others.
+func (q *queryCaches) query(doc interface{}, expr string) (interface{}, error) {
+	if !isJSONPath(expr) {
+		return q.jmesPath(doc, expr)
+	}
+	path, err := q.jsonPaths.get(expr, jsonpath.Compile)
+	if err != nil {
+		return nil, err
+	}
+	matches := path.Find(doc)
+	if !path.Singular() {
+		return matches, nil
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return matches[0], nil
+}
+
+// JSONPath returns the array of the values of the JSON body matching the
+// JSONPath expression, like `res.jsonpath("$.data[?(@.id > 3)].name")`.
+func (res *Response) JSONPath(expr string) goja.Value {
+	rt := res.client.moduleInstance.vu.Runtime()
+	matches, err := res.client.moduleInstance.rootModule.queries.jsonPath(res.jsonBody(), expr)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return rt.ToValue(matches)
+}
+
+// JMESPath returns the result of the JMESPath expression for the JSON body,
+// like `res.jmespath("data[?id > `3`].name")`.
+func (res *Response) JMESPath(expr string) goja.Value {
+	rt := res.client.moduleInstance.vu.Runtime()
+	result, err := res.client.moduleInstance.rootModule.queries.jmesPath(res.jsonBody(), expr)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return rt.ToValue(result)
+}
+
+// jsonBody returns the JSON body of the response, parsed once like by json().
+func (res *Response) jsonBody() interface{} {
+	if res.cachedJSON == nil {
+		res.JSON()
+	}
+	return res.cachedJSON
+}
+
+// jsonChecks returns the checks of the JSON bodies of the responses, for
+// check(), like:
+//
+//	check(res, http.jsonChecks({
+//		"$.data[0].id": 1,
+//		"length(data)": (n) => n > 3,
+//	}));
+//
+// The check names are the JSONPath expressions, starting with $, or the
+// JMESPath ones selecting the values. The values are compared with the
+// expected ones, or passed to the functions returning whether they're valid.
+func (mi *ModuleInstance) jsonChecks(checks goja.Value) (*goja.Object, error) {
+	rt := mi.vu.Runtime()
+	if common.IsNullish(checks) {
+		return nil, fmt.Errorf("jsonChecks() expects an object of the expected values")
+	}
+	queries := &mi.rootModule.queries
+
+	obj := checks.ToObject(rt)
+	result := rt.NewObject()
+	for _, expr := range obj.Keys() {
+		expr, expected := expr, obj.Get(expr)
+		// the invalid expressions fail at once, instead of failing the checks
+		if err := queries.compile(expr); err != nil {
+			return nil, err
+		}
+		validate, isFunction := goja.AssertFunction(expected)
+
+		check := func(res *Response) (bool, error) {
+			if res == nil {
+				return false, fmt.Errorf("the %q check expects a response", expr)
+			}
+			value, err := queries.query(res.jsonBody(), expr)
+			if err != nil {
+				return false, err
+			}
+			if isFunction {
+				valid, err := validate(goja.Undefined(), rt.ToValue(value))
+				if err != nil {
+					return false, err
+				}
+				return valid.ToBoolean(), nil
+			}
+			return equalJSON(value, expected.Export()), nil
+		}
+		if err := result.Set(expr, check); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// equalJSON returns whether the values have the same JSON encoding, so the
+// integers of the scripts are equal to the float64 numbers of the bodies.
+func equalJSON(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	return err == nil && string(x) == string(y)
+}
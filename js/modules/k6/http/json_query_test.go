@@ -0,0 +1,84 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slideshow = `{"slideshow": {
+	"author": "Yours Truly", "date": "date of publication", "title": "Sample Slide Show",
+	"slides": [
+		{"title": "Wake up to WonderWidgets!", "type": "all"},
+		{"title": "Overview", "type": "all", "items": ["Why WonderWidgets are great", "Who buys WonderWidgets"]}
+	]
+}}`
+
+func newJSONQueryTestCase(t *testing.T) *httpTestCase {
+	t.Helper()
+	ts := newTestCase(t)
+	ts.tb.Mux.HandleFunc("/slideshow", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(slideshow))
+	})
+	return ts
+}
+
+func TestResponseJSONQueries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Extraction", func(t *testing.T) {
+		t.Parallel()
+		ts := newJSONQueryTestCase(t)
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var res = http.get("HTTPBIN_URL/slideshow");
+			var titles = res.jsonpath("$.slideshow.slides[?(@.items)].title");
+			if (JSON.stringify(titles) !== '["Overview"]') {
+				throw new Error("unexpected titles " + JSON.stringify(titles));
+			}
+			if (res.jsonpath("$..author")[0] !== "Yours Truly" || res.jsonpath("$.missing").length !== 0) {
+				throw new Error("unexpected author");
+			}
+			var summary = res.jmespath("slideshow.{title: title, slides: length(slides)}");
+			if (summary.title !== "Sample Slide Show" || summary.slides !== 2) {
+				throw new Error("unexpected summary " + JSON.stringify(summary));
+			}
+			if (res.jmespath("slideshow.missing") !== null) {
+				throw new Error("unexpected missing value");
+			}
+		`))
+		require.NoError(t, err)
+
+		_, err = ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			http.get("HTTPBIN_URL/slideshow").jsonpath("$.slideshow[");
+		`))
+		require.ErrorContains(t, err, "invalid JSONPath '$.slideshow['")
+		_, err = ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			http.get("HTTPBIN_URL/slideshow").jmespath("abs(slideshow.title)");
+		`))
+		require.ErrorContains(t, err, "abs() has to be a number")
+	})
+
+	t.Run("Checks", func(t *testing.T) {
+		t.Parallel()
+		ts := newJSONQueryTestCase(t)
+		v, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var res = http.get("HTTPBIN_URL/slideshow");
+			var checks = http.jsonChecks({
+				"$.slideshow.author": "Yours Truly",
+				"$.slideshow.slides[*].type": ["all", "all"],
+				"slideshow.slides[1].items | length(@)": 2,
+				"length(slideshow.slides)": function(n) { return n > 5; },
+				"$.slideshow.date": "tomorrow",
+			});
+			Object.keys(checks).map(function(name) { return checks[name](res); }).join(",");
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, "true,true,true,false,false", v.String())
+
+		_, err = ts.runtime.VU.Runtime().RunString(`http.jsonChecks({"slides[": 1})`)
+		require.ErrorContains(t, err, "invalid JMESPath 'slides['")
+	})
+}
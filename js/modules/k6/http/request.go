@@ -15,7 +15,9 @@ import (
 	"github.com/dop251/goja"
 	"gopkg.in/guregu/null.v3"
 
+	"go.k6.io/k6/js/abort"
 	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext/httpext"
 	"go.k6.io/k6/lib/types"
 )
@@ -41,17 +43,32 @@ func (c *Client) Request(method string, url goja.Value, args ...goja.Value) (*Re
 	}
 	body, params := splitRequestArgs(args)
 
-	req, err := c.parseRequest(method, url, body, params)
+	req, signal, err := c.parseRequest(method, url, body, params)
 	if err != nil {
 		return c.handleParseRequestError(err)
 	}
+	if signal.Aborted() {
+		panic(signal.Reason())
+	}
+	if err = c.runRequestHooks(req); err != nil {
+		return nil, err
+	}
 
-	resp, err := httpext.MakeRequest(c.moduleInstance.vu.Context(), state, req)
+	ctx, cancel := signal.Context(c.moduleInstance.vu.Context())
+	defer cancel()
+	resp, err := httpext.MakeRequest(ctx, state, req)
+	if signal.Aborted() {
+		panic(signal.Reason())
+	}
 	if err != nil {
 		return nil, err
 	}
 	c.processResponse(resp, req.ResponseType)
-	return c.responseFromHTTPext(resp), nil
+	res := c.responseFromHTTPext(resp)
+	if err = c.runResponseHooks(res); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 func splitRequestArgs(args []goja.Value) (body interface{}, params goja.Value) {
@@ -90,7 +107,7 @@ func (c *Client) asyncRequest(method string, url goja.Value, args ...goja.Value)
 
 	body, params := splitRequestArgs(args)
 	rt := c.moduleInstance.vu.Runtime()
-	req, err := c.parseRequest(method, url, body, params)
+	req, signal, err := c.parseRequest(method, url, body, params)
 	p, resolve, reject := rt.NewPromise()
 	if err != nil {
 		var resp *Response
@@ -101,18 +118,37 @@ func (c *Client) asyncRequest(method string, url goja.Value, args ...goja.Value)
 		}
 		return p, nil
 	}
+	if signal.Aborted() {
+		reject(signal.Reason())
+		return p, nil
+	}
+	if err = c.runRequestHooks(req); err != nil {
+		reject(err)
+		return p, nil
+	}
 
 	callback := c.moduleInstance.vu.RegisterCallback()
+	ctx, cancel := signal.Context(c.moduleInstance.vu.Context())
 
 	go func() {
-		resp, err := httpext.MakeRequest(c.moduleInstance.vu.Context(), state, req)
+		defer cancel()
+		resp, err := httpext.MakeRequest(ctx, state, req)
 		callback(func() error {
+			if signal.Aborted() {
+				reject(signal.Reason())
+				return nil
+			}
 			if err != nil {
 				reject(err)
 				return nil //nolint:nilerr // we want to reject the promise in this case
 			}
 			c.processResponse(resp, req.ResponseType)
-			resolve(c.responseFromHTTPext(resp))
+			res := c.responseFromHTTPext(resp)
+			if err = c.runResponseHooks(res); err != nil {
+				reject(err)
+				return nil
+			}
+			resolve(res)
 			return nil
 		})
 	}()
@@ -138,11 +174,11 @@ func (c *Client) responseFromHTTPext(resp *httpext.Response) *Response {
 //nolint:gocyclo, cyclop, funlen, gocognit
 func (c *Client) parseRequest(
 	method string, reqURL, body interface{}, params goja.Value,
-) (*httpext.ParsedHTTPRequest, error) {
+) (*httpext.ParsedHTTPRequest, *abort.Signal, error) {
 	rt := c.moduleInstance.vu.Runtime()
 	state := c.moduleInstance.vu.State()
 	if state == nil {
-		return nil, ErrHTTPForbiddenInInitContext
+		return nil, nil, ErrHTTPForbiddenInInitContext
 	}
 
 	if urlJSValue, ok := reqURL.(goja.Value); ok {
@@ -150,8 +186,9 @@ func (c *Client) parseRequest(
 	}
 	u, err := httpext.ToURL(reqURL)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	var signal *abort.Signal
 
 	result := &httpext.ParsedHTTPRequest{
 		URL: &u,
@@ -168,7 +205,11 @@ func (c *Client) parseRequest(
 		TagsAndMeta:      c.moduleInstance.vu.State().Tags.GetCurrentValues(),
 	}
 
-	if state.Options.DiscardResponseBodies.Bool {
+	var scenario string
+	if ss := lib.GetScenarioState(c.moduleInstance.vu.Context()); ss != nil {
+		scenario = ss.Name
+	}
+	if state.Options.DiscardResponseBodiesFor(scenario) {
 		result.ResponseType = httpext.ResponseTypeNone
 	} else {
 		result.ResponseType = httpext.ResponseTypeText
@@ -254,20 +295,20 @@ func (c *Client) parseRequest(
 				newData[k] = v.Export()
 			}
 			if err := handleObjectBody(newData); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		case goja.ArrayBuffer:
 			result.Body = bytes.NewBuffer(data.Bytes())
 		case map[string]interface{}:
 			if err := handleObjectBody(data); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		case string:
 			result.Body = bytes.NewBufferString(data)
 		case []byte:
 			result.Body = bytes.NewBuffer(data)
 		default:
-			return nil, fmt.Errorf("unknown request body type %T", body)
+			return nil, nil, fmt.Errorf("unknown request body type %T", body)
 		}
 	}
 
@@ -349,7 +390,7 @@ func (c *Client) parseRequest(
 					algo = strings.TrimSpace(algo)
 					result.Compressions[index], err = httpext.CompressionTypeString(algo)
 					if err != nil {
-						return nil, fmt.Errorf("unknown compression algorithm %s, supported algorithms are %s",
+						return nil, nil, fmt.Errorf("unknown compression algorithm %s, supported algorithms are %s",
 							algo, httpext.CompressionTypeValues())
 					}
 				}
@@ -357,24 +398,44 @@ func (c *Client) parseRequest(
 				result.Redirects = null.IntFrom(params.Get(k).ToInteger())
 			case "tags":
 				if err := common.ApplyCustomUserTags(rt, &result.TagsAndMeta, params.Get(k)); err != nil {
-					return nil, fmt.Errorf("invalid HTTP request metric tags: %w", err)
+					return nil, nil, fmt.Errorf("invalid HTTP request metric tags: %w", err)
+				}
+			case "metadata":
+				if err := common.ApplyCustomUserMetadataMap(rt, &result.TagsAndMeta, params.Get(k)); err != nil {
+					return nil, nil, fmt.Errorf("invalid HTTP request metric metadata: %w", err)
 				}
 			case "auth":
 				result.Auth = params.Get(k).String()
 			case "timeout":
 				t, err := types.GetDurationValue(params.Get(k).Export())
 				if err != nil {
-					return nil, fmt.Errorf("invalid timeout value: %w", err)
+					return nil, nil, fmt.Errorf("invalid timeout value: %w", err)
 				}
 				result.Timeout = t
+			case "timeouts":
+				timeouts, err := parsePhaseTimeouts(rt, params.Get(k))
+				if err != nil {
+					return nil, nil, err
+				}
+				result.Timeouts = timeouts
 			case "throw":
 				result.Throw = params.Get(k).ToBoolean()
 			case "responseType":
 				responseType, err := httpext.ResponseTypeString(params.Get(k).String())
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				result.ResponseType = responseType
+			case "peekResponseBody":
+				peek := params.Get(k).ToInteger()
+				if peek <= 0 {
+					return nil, nil, fmt.Errorf("the peekResponseBody value should be a positive number of bytes")
+				}
+				result.PeekResponseBody = peek
+			case "tlsAuth":
+				result.TLSOverride.ClientCert = params.Get(k).String()
+			case "serverName":
+				result.TLSOverride.ServerName = params.Get(k).String()
 			case "responseCallback":
 				v := params.Get(k).Export()
 				if v == nil {
@@ -382,17 +443,29 @@ func (c *Client) parseRequest(
 				} else if c, ok := v.(*expectedStatuses); ok {
 					result.ResponseCallback = c.match
 				} else {
-					return nil, fmt.Errorf("unsupported responseCallback")
+					return nil, nil, fmt.Errorf("unsupported responseCallback")
+				}
+			case "signal":
+				var err error
+				signal, err = abort.FromValue(c.moduleInstance.vu, params.Get(k))
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid signal param: %w", err)
 				}
 			}
 		}
 	}
 
+	// The peeked bytes may end in the middle of a character, so they are
+	// always binary, even if the bodies are discarded by default.
+	if result.PeekResponseBody > 0 {
+		result.ResponseType = httpext.ResponseTypeBinary
+	}
+
 	if result.ActiveJar != nil {
 		httpext.SetRequestCookies(result.Req, result.ActiveJar, result.Cookies)
 	}
 
-	return result, nil
+	return result, signal, nil
 }
 
 func (c *Client) prepareBatchArray(requests []interface{}) (
@@ -507,6 +580,9 @@ func (c *Client) Batch(reqsV ...goja.Value) (interface{}, error) {
 			c.processResponse(req.Response, req.ParsedHTTPRequest.ResponseType)
 		}
 	}
+	if hooksErr := c.runBatchResponseHooks(results); hooksErr != nil {
+		return nil, hooksErr
+	}
 	return results, err
 }
 
@@ -564,7 +640,11 @@ func (c *Client) parseBatchRequest(key interface{}, val interface{}) (*httpext.P
 		reqURL = val
 	}
 
-	return c.parseRequest(method, reqURL, body, params)
+	req, _, err := c.parseRequest(method, reqURL, body, params)
+	if err != nil {
+		return nil, err
+	}
+	return req, c.runRequestHooks(req)
 }
 
 func requestContainsFile(data map[string]interface{}) bool {
@@ -576,3 +656,35 @@ func requestContainsFile(data map[string]interface{}) bool {
 	}
 	return false
 }
+
+// parsePhaseTimeouts parses the timeouts param, like
+// `{connect: "1s", tlsHandshake: "1s", responseHeaders: "5s", body: "10s"}`.
+func parsePhaseTimeouts(rt *goja.Runtime, v goja.Value) (httpext.PhaseTimeouts, error) {
+	var timeouts httpext.PhaseTimeouts
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return timeouts, nil
+	}
+	obj := v.ToObject(rt)
+	for _, key := range obj.Keys() {
+		var timeout *time.Duration
+		switch key {
+		case "connect":
+			timeout = &timeouts.Connect
+		case "tlsHandshake":
+			timeout = &timeouts.TLSHandshake
+		case "responseHeaders":
+			timeout = &timeouts.ResponseHeaders
+		case "body":
+			timeout = &timeouts.Body
+		default:
+			return timeouts, fmt.Errorf("unknown timeouts phase '%s', it has to be connect, tlsHandshake, "+
+				"responseHeaders or body", key)
+		}
+		t, err := types.GetDurationValue(obj.Get(key).Export())
+		if err != nil {
+			return timeouts, fmt.Errorf("invalid %s timeout value: %w", key, err)
+		}
+		*timeout = t
+	}
+	return timeouts, nil
+}
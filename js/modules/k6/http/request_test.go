@@ -38,6 +38,7 @@ import (
 
 	"go.k6.io/k6/js/modulestest"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
 	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/lib/testutils"
 	"go.k6.io/k6/lib/testutils/httpmultibin"
@@ -327,6 +328,26 @@ func TestRequest(t *testing.T) {
 
 			assert.Nil(t, ts.hook.LastEntry())
 		})
+		t.Run("Phases", func(t *testing.T) {
+			startTime := time.Now()
+			_, err := rt.RunString(sr(`
+				http.get("HTTPBIN_URL/delay/10", {
+					timeouts: { connect: "1s", responseHeaders: "1s" },
+				})
+			`))
+			endTime := time.Now()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "response headers timeout")
+			assert.WithinDuration(t, startTime.Add(1*time.Second), endTime, 2*time.Second)
+
+			_, err = rt.RunString(sr(`
+				http.get("HTTPBIN_URL/delay/10", {
+					timeouts: { dns: "1s" },
+				})
+			`))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "unknown timeouts phase 'dns'")
+		})
 	})
 	t.Run("UserAgent", func(t *testing.T) {
 		_, err := rt.RunString(sr(`
@@ -1309,6 +1330,117 @@ func TestRequestCancellation(t *testing.T) {
 	assert.Nil(t, ts.hook.LastEntry())
 }
 
+func TestRequestCorrelationID(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	rt := ts.runtime.VU.Runtime()
+	state := ts.runtime.VU.State()
+	sr := ts.tb.Replacer.Replace
+
+	state.Options.CorrelationIDHeader = null.StringFrom("X-Correlation-ID")
+	state.IterationContext = &lib.IterationContext{CorrelationID: "0af7651916cd43dd8448eb211c80319c"}
+
+	_, err := rt.RunString(sr(`
+		var headers = http.get("HTTPBIN_URL/headers").json().headers;
+		if (headers["X-Correlation-Id"] != "0af7651916cd43dd8448eb211c80319c") {
+			throw new Error("incorrect correlation ID: " + headers["X-Correlation-Id"]);
+		}
+		headers = http.get("HTTPBIN_URL/headers", { headers: { "X-Correlation-ID": "custom" } }).json().headers;
+		if (headers["X-Correlation-Id"] != "custom") {
+			throw new Error("the explicit header should take precedence: " + headers["X-Correlation-Id"]);
+		}
+	`))
+	require.NoError(t, err)
+
+	state.Options.CorrelationIDHeader = null.StringFrom("")
+	_, err = rt.RunString(sr(`
+		var headers = http.get("HTTPBIN_URL/headers").json().headers;
+		if (headers["X-Correlation-Id"] !== undefined) {
+			throw new Error("the correlation ID shouldn't be sent");
+		}
+	`))
+	require.NoError(t, err)
+}
+
+func TestRequestTracePropagation(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	rt := ts.runtime.VU.Runtime()
+	state := ts.runtime.VU.State()
+	sr := ts.tb.Replacer.Replace
+
+	state.Options.TracePropagator = null.StringFrom("b3")
+	_, err := rt.RunString(sr(`
+		var headers = http.get("HTTPBIN_URL/headers").json().headers;
+		if (!/^[0-9a-f]{32}-[0-9a-f]{16}-1$/.test(headers["B3"])) {
+			throw new Error("incorrect b3 header: " + headers["B3"]);
+		}
+		headers = http.get("HTTPBIN_URL/headers", { headers: { "traceparent": "custom" } }).json().headers;
+		if (headers["B3"] !== undefined || headers["Traceparent"] != "custom") {
+			throw new Error("the explicit trace context should take precedence");
+		}
+	`))
+	require.NoError(t, err)
+
+	var traceIDs []string
+	for _, sample := range metrics.GetBufferedSamples(ts.samples) {
+		for _, s := range sample.GetSamples() {
+			if s.Metric.Name == metrics.HTTPReqDurationName {
+				traceIDs = append(traceIDs, s.Metadata["trace_id"])
+			}
+		}
+	}
+	require.Len(t, traceIDs, 2)
+	assert.Len(t, traceIDs[0], 32)
+	assert.Empty(t, traceIDs[1])
+
+	state.Options.TraceSampling = null.FloatFrom(0)
+	_, err = rt.RunString(sr(`
+		var headers = http.get("HTTPBIN_URL/headers").json().headers;
+		if (!/-0$/.test(headers["B3"])) {
+			throw new Error("the trace shouldn't be sampled: " + headers["B3"]);
+		}
+	`))
+	require.NoError(t, err)
+	for _, sample := range metrics.GetBufferedSamples(ts.samples) {
+		for _, s := range sample.GetSamples() {
+			assert.NotContains(t, s.Metadata, "trace_id")
+		}
+	}
+}
+
+func TestRequestMetadata(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	rt := ts.runtime.VU.Runtime()
+	state := ts.runtime.VU.State()
+	sr := ts.tb.Replacer.Replace
+
+	state.Options.SystemTags = metrics.ToSystemTagSet([]string{"url", "name", "vu", "full_url"})
+	_, err := rt.RunString(sr(`
+		http.get("HTTPBIN_URL/get?id=42", { tags: { name: "get" }, metadata: { user_id: 7 } });
+	`))
+	require.NoError(t, err)
+
+	var found bool
+	for _, sample := range metrics.GetBufferedSamples(ts.samples) {
+		for _, s := range sample.GetSamples() {
+			if s.Metric.Name != metrics.HTTPReqDurationName {
+				continue
+			}
+			found = true
+			url, _ := s.Tags.Get("url")
+			assert.Equal(t, "get", url)
+			assert.Equal(t, sr("HTTPBIN_URL/get?id=42"), s.Metadata["full_url"])
+			assert.Equal(t, "7", s.Metadata["user_id"])
+		}
+	}
+	assert.True(t, found)
+
+	_, err = rt.RunString(`http.get("HTTPBIN_URL/get", { metadata: { user: {} } })`)
+	assert.ErrorContains(t, err, "invalid HTTP request metric metadata")
+}
+
 func TestRequestArrayBufferBody(t *testing.T) {
 	t.Parallel()
 	ts := newTestCase(t)
@@ -1680,6 +1812,68 @@ func TestResponseTypes(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestResponseBodyPeekAndDiscard(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	tb := ts.tb
+	rt := ts.runtime.VU.Runtime()
+	state := ts.runtime.VU.State()
+
+	binary := make([]byte, 300)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+	tb.Mux.HandleFunc("/get-bin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write(binary)
+		assert.NoError(t, err)
+	}))
+
+	discardedBytes := func() []float64 {
+		var discarded []float64
+		for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name == metrics.HTTPRespDiscardedName {
+					discarded = append(discarded, s.Value)
+				}
+			}
+		}
+		return discarded
+	}
+
+	_, err := rt.RunString(tb.Replacer.Replace(`
+		var peeked = new Uint8Array(http.get("HTTPBIN_URL/get-bin", { peekResponseBody: 10 }).body);
+		if (peeked.length !== 10 || peeked[9] !== 9) {
+			throw new Error("unexpected peeked body " + peeked);
+		}
+		var short = http.get("HTTPBIN_URL/get-bin", { peekResponseBody: 1000 }).body;
+		if (short.byteLength !== 300) {
+			throw new Error("unexpected peeked body length " + short.byteLength);
+		}
+		http.get("HTTPBIN_URL/get-bin", { responseType: "none" });
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{290, 300}, discardedBytes())
+
+	_, err = rt.RunString(tb.Replacer.Replace(`http.get("HTTPBIN_URL/get-bin", { peekResponseBody: 0 });`))
+	require.ErrorContains(t, err, "peekResponseBody value should be a positive number")
+
+	// The scenario overrides the global option
+	state.Options.DiscardResponseBodies = null.BoolFrom(true)
+	scenario := executor.NewConstantVUsConfig("api")
+	scenario.DiscardResponseBodies = null.BoolFrom(false)
+	state.Options.Scenarios = lib.ScenarioConfigs{"api": scenario}
+	ts.runtime.VU.CtxField = lib.WithScenarioState(ts.runtime.VU.CtxField, &lib.ScenarioState{Name: "api"})
+
+	_, err = rt.RunString(tb.Replacer.Replace(`
+		var body = http.get("HTTPBIN_URL/get-bin").body;
+		if (body === null || body.length !== 300) {
+			throw new Error("the body shouldn't be discarded in the scenario");
+		}
+	`))
+	require.NoError(t, err)
+	assert.Empty(t, discardedBytes())
+}
+
 func checkErrorCode(t testing.TB, sample metrics.Sample, code int, msg string) {
 	errorMsg, ok := sample.Tags.Get("error")
 	if msg == "" {
@@ -0,0 +1,232 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"regexp"
+	"strings"
+
+	"github.com/dop251/goja"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// Session makes the requests of a flow of correlated requests, like the ones
+// of a user logging in and browsing a site, which share a base URL, default
+// headers and a cookie jar. The values extracted from the responses are kept
+// in vars and replace the {{name}} placeholders of the following requests.
+type Session struct {
+	client  *Client
+	baseURL string
+	headers map[string]string
+
+	Jar  *CookieJar   `js:"jar"`
+	Vars *goja.Object `js:"vars"`
+}
+
+// sessionOptions are the options of the Session constructor.
+type sessionOptions struct {
+	BaseURL string            `js:"baseURL"`
+	Headers map[string]string `js:"headers"`
+	Jar     *CookieJar        `js:"jar"`
+}
+
+// varPlaceholder matches the {{name}} placeholders of the session variables.
+var varPlaceholder = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// newSession is the JS constructor of the Session, which accepts the base URL,
+// the default headers and the cookie jar of the session as its options. The
+// session gets its own cookie jar, unless one is given.
+func (mi *ModuleInstance) newSession(call goja.ConstructorCall) *goja.Object {
+	rt := mi.vu.Runtime()
+
+	var opts sessionOptions
+	if len(call.Arguments) > 0 && !common.IsNullish(call.Arguments[0]) {
+		if err := rt.ExportTo(call.Arguments[0], &opts); err != nil {
+			common.Throw(rt, fmt.Errorf("invalid Session options: %w", err))
+		}
+	}
+	if opts.BaseURL != "" {
+		u, err := neturl.Parse(opts.BaseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			common.Throw(rt, fmt.Errorf("invalid baseURL '%s', it has to be an absolute URL", opts.BaseURL))
+		}
+	}
+	if opts.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		opts.Jar = &CookieJar{mi, jar}
+	}
+
+	return rt.ToValue(&Session{
+		client:  mi.defaultClient,
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/"),
+		headers: opts.Headers,
+		Jar:     opts.Jar,
+		Vars:    rt.NewObject(),
+	}).ToObject(rt)
+}
+
+// Get makes a GET request of the session.
+func (s *Session) Get(url goja.Value, args ...goja.Value) (*Response, error) {
+	// like http.get(), session.get(url, params) has no body argument
+	return s.Request(http.MethodGet, url, append([]goja.Value{goja.Undefined()}, args...)...)
+}
+
+// Head makes a HEAD request of the session.
+func (s *Session) Head(url goja.Value, args ...goja.Value) (*Response, error) {
+	return s.Request(http.MethodHead, url, append([]goja.Value{goja.Undefined()}, args...)...)
+}
+
+// Post makes a POST request of the session.
+func (s *Session) Post(url goja.Value, args ...goja.Value) (*Response, error) {
+	return s.Request(http.MethodPost, url, args...)
+}
+
+// Put makes a PUT request of the session.
+func (s *Session) Put(url goja.Value, args ...goja.Value) (*Response, error) {
+	return s.Request(http.MethodPut, url, args...)
+}
+
+// Patch makes a PATCH request of the session.
+func (s *Session) Patch(url goja.Value, args ...goja.Value) (*Response, error) {
+	return s.Request(http.MethodPatch, url, args...)
+}
+
+// Del makes a DELETE request of the session.
+func (s *Session) Del(url goja.Value, args ...goja.Value) (*Response, error) {
+	return s.Request(http.MethodDelete, url, args...)
+}
+
+// Options makes an OPTIONS request of the session.
+func (s *Session) Options(url goja.Value, args ...goja.Value) (*Response, error) {
+	return s.Request(http.MethodOptions, url, args...)
+}
+
+// Request makes a request of the session, like http.request() does. The URL is
+// relative to the base URL of the session, its default headers are added to
+// the ones of the params and its cookie jar is used, unless the params have
+// one. The {{name}} placeholders of the URL, the headers and the body are
+// replaced with the session variables.
+func (s *Session) Request(method string, url goja.Value, args ...goja.Value) (*Response, error) {
+	u, err := s.resolveURL(url)
+	if err != nil {
+		return nil, err
+	}
+	var body, params goja.Value = goja.Undefined(), nil
+	if len(args) > 0 {
+		body = s.expandBody(args[0])
+	}
+	if len(args) > 1 {
+		params = args[1]
+	}
+	return s.client.Request(method, u, body, s.params(params))
+}
+
+// resolveURL returns the URL of a request, with the base URL of the session
+// prepended when it's relative. Both the URL and the name of the templated
+// URLs, made with http.url, are resolved.
+func (s *Session) resolveURL(url goja.Value) (goja.Value, error) {
+	rt := s.client.moduleInstance.vu.Runtime()
+	if u, ok := url.Export().(httpext.URL); ok {
+		nu, err := httpext.NewURL(s.join(s.expand(u.URL)), s.join(u.Name))
+		if err != nil {
+			return nil, err
+		}
+		return rt.ToValue(nu), nil
+	}
+	if common.IsNullish(url) {
+		return url, nil
+	}
+	return rt.ToValue(s.join(s.expand(url.String()))), nil
+}
+
+// join prepends the base URL of the session to a relative URL.
+func (s *Session) join(url string) string {
+	if s.baseURL == "" || strings.Contains(url, "://") {
+		return url
+	}
+	return s.baseURL + "/" + strings.TrimPrefix(url, "/")
+}
+
+// params returns the params of a request, with the default headers and the
+// cookie jar of the session.
+func (s *Session) params(params goja.Value) goja.Value {
+	rt := s.client.moduleInstance.vu.Runtime()
+	result := rt.NewObject()
+	headers := rt.NewObject()
+	for key, value := range s.headers {
+		mustSet(rt, headers, key, s.expand(value))
+	}
+
+	if !common.IsNullish(params) {
+		obj := params.ToObject(rt)
+		for _, key := range obj.Keys() {
+			if key != "headers" {
+				mustSet(rt, result, key, obj.Get(key))
+				continue
+			}
+			if h := obj.Get(key); !common.IsNullish(h) {
+				hobj := h.ToObject(rt)
+				for _, name := range hobj.Keys() {
+					mustSet(rt, headers, name, s.expandValue(hobj.Get(name)))
+				}
+			}
+		}
+	}
+	mustSet(rt, result, "headers", headers)
+	if common.IsNullish(result.Get("jar")) {
+		mustSet(rt, result, "jar", s.Jar)
+	}
+	return result
+}
+
+// expandBody replaces the placeholders of a string body, or of the string
+// values of a form body.
+func (s *Session) expandBody(body goja.Value) goja.Value {
+	rt := s.client.moduleInstance.vu.Runtime()
+	if common.IsNullish(body) {
+		return body
+	}
+	if _, ok := body.Export().(map[string]interface{}); !ok {
+		return s.expandValue(body)
+	}
+	obj := body.ToObject(rt)
+	form := rt.NewObject()
+	for _, key := range obj.Keys() {
+		mustSet(rt, form, key, s.expandValue(obj.Get(key)))
+	}
+	return form
+}
+
+func (s *Session) expandValue(v goja.Value) goja.Value {
+	if str, ok := v.Export().(string); ok {
+		return s.client.moduleInstance.vu.Runtime().ToValue(s.expand(str))
+	}
+	return v
+}
+
+// expand replaces the {{name}} placeholders with the session variables, and
+// leaves the ones of the undefined variables as they are.
+func (s *Session) expand(str string) string {
+	if !strings.Contains(str, "{{") {
+		return str
+	}
+	return varPlaceholder.ReplaceAllStringFunc(str, func(placeholder string) string {
+		name := varPlaceholder.FindStringSubmatch(placeholder)[1]
+		if v := s.Vars.Get(name); !common.IsNullish(v) {
+			return v.String()
+		}
+		return placeholder
+	})
+}
+
+func mustSet(rt *goja.Runtime, obj *goja.Object, key string, value interface{}) {
+	if err := obj.Set(key, value); err != nil {
+		common.Throw(rt, err)
+	}
+}
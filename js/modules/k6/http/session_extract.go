@@ -0,0 +1,287 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+)
+
+// extractOptions are the options of session.extract(), which have exactly one
+// of the css, xpath, regex, jsonpath and jmespath selectors.
+type extractOptions struct {
+	// The session variable the value is stored in, if any.
+	Name string `js:"name"`
+
+	CSS      string `js:"css"`
+	XPath    string `js:"xpath"`
+	Regex    string `js:"regex"`
+	JSONPath string `js:"jsonpath"`
+	JMESPath string `js:"jmespath"`
+
+	// The attribute of the elements selected with css, instead of their text.
+	Attribute string `js:"attribute"`
+	// Extract all the matches as an array, instead of the first one.
+	All bool `js:"all"`
+}
+
+// Extract returns a value of the body of a response, selected with a CSS
+// selector, an XPath expression, a regular expression, a JSONPath expression
+// or a JMESPath one, like
+// `session.extract(res, {name: "csrf", css: "input[name=csrf]", attribute: "value"})`.
+// The value is stored in the session variable of the name, if it's given.
+// Nothing matching returns undefined, or an empty array with the all option.
+func (s *Session) Extract(res *Response, opts goja.Value) goja.Value {
+	rt := s.client.moduleInstance.vu.Runtime()
+	if res == nil || res.Body == nil {
+		common.Throw(rt, errors.New("the response has no body to extract the value from"))
+	}
+	var o extractOptions
+	if common.IsNullish(opts) {
+		common.Throw(rt, errors.New("session.extract() expects the selector options as its second argument"))
+	}
+	if err := rt.ExportTo(opts, &o); err != nil {
+		common.Throw(rt, fmt.Errorf("invalid extract options: %w", err))
+	}
+	body, err := common.ToString(res.Body)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	matches, err := o.extract(body, &s.client.moduleInstance.rootModule.queries)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	var value goja.Value
+	switch {
+	case o.All:
+		value = rt.ToValue(matches)
+	case len(matches) > 0:
+		value = rt.ToValue(matches[0])
+	default:
+		return goja.Undefined()
+	}
+	if o.Name != "" {
+		mustSet(rt, s.Vars, o.Name, value)
+	}
+	return value
+}
+
+// extract returns the matches of the selector in the body.
+func (o extractOptions) extract(body string, queries *queryCaches) ([]interface{}, error) {
+	selectors := 0
+	for _, selector := range []string{o.CSS, o.XPath, o.Regex, o.JSONPath, o.JMESPath} {
+		if selector != "" {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return nil, errors.New("exactly one of the css, xpath, regex, jsonpath and jmespath selectors has to be given")
+	}
+
+	switch {
+	case o.Regex != "":
+		return extractRegex(body, o.Regex)
+	case o.JSONPath != "" || o.JMESPath != "":
+		return extractJSON(body, o.JSONPath, o.JMESPath, queries)
+	case o.XPath != "":
+		css, attribute, err := xpathToCSS(o.XPath)
+		if err != nil {
+			return nil, err
+		}
+		return extractCSS(body, css, attribute)
+	default:
+		return extractCSS(body, o.CSS, o.Attribute)
+	}
+}
+
+// extractRegex returns the first group of the matches of the regular
+// expression, or the whole matches if it has no groups.
+func extractRegex(body, expr string) ([]interface{}, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex '%s': %w", expr, err)
+	}
+	group := 0
+	if re.NumSubexp() > 0 {
+		group = 1
+	}
+	matches := []interface{}{}
+	for _, match := range re.FindAllStringSubmatch(body, -1) {
+		matches = append(matches, match[group])
+	}
+	return matches, nil
+}
+
+// extractJSON returns the matches of the JSONPath expression, or the result
+// of the JMESPath one, in the JSON body. The arrays returned by the JMESPath
+// expressions are all their matches.
+func extractJSON(body, jsonPath, jmesPath string, queries *queryCaches) ([]interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, fmt.Errorf("the body isn't valid JSON: %w", err)
+	}
+	if jsonPath != "" {
+		return queries.jsonPath(doc, jsonPath)
+	}
+	result, err := queries.jmesPath(doc, jmesPath)
+	if err != nil {
+		return nil, err
+	}
+	switch result := result.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case []interface{}:
+		return result, nil
+	default:
+		return []interface{}{result}, nil
+	}
+}
+
+// extractCSS returns the text, or the attribute, of the elements selected
+// with the CSS selector.
+func extractCSS(body, selector, attribute string) ([]interface{}, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	matches := []interface{}{}
+	doc.Find(selector).Each(func(_ int, sel *goquery.Selection) {
+		if attribute == "" {
+			matches = append(matches, sel.Text())
+		} else if value, ok := sel.Attr(attribute); ok {
+			matches = append(matches, value)
+		}
+	})
+	return matches, nil
+}
+
+// xpathStepRe matches the node test and the predicates of an XPath step.
+var xpathStepRe = regexp.MustCompile(`^([\w-]+|\*)((?:\[[^\]]+\])*)$`)
+
+// xpathPredicateRe matches the supported XPath predicates: [@a], [@a='v'],
+// [contains(@a,'v')] and [n].
+var xpathPredicateRe = regexp.MustCompile(
+	`\[\s*(?:@([\w-]+)\s*(?:=\s*(?:'([^']*)'|"([^"]*)"))?|contains\(\s*@([\w-]+)\s*,\s*(?:'([^']*)'|"([^"]*)")\s*\)|(\d+))\s*\]`)
+
+// xpathToCSS translates the XPath expressions of the common subset used for
+// correlation, made of the child and descendant steps with node tests and
+// simple predicates, optionally ending with /@attribute or /text(), into CSS
+// selectors.
+func xpathToCSS(expr string) (css, attribute string, err error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("unsupported xpath '%s': %s", expr, reason)
+	}
+	rest := strings.TrimSpace(expr)
+	var selector strings.Builder
+	for first := true; rest != ""; first = false {
+		combinator := " "
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "/"):
+			rest = rest[1:]
+			combinator = " > "
+		case !first:
+			return "", "", invalid("the steps have to be separated by / or //")
+		}
+
+		end := strings.IndexByte(rest, '/')
+		for end >= 0 && strings.Count(rest[:end], "[") != strings.Count(rest[:end], "]") {
+			// the slash is in a predicate
+			next := strings.IndexByte(rest[end+1:], '/')
+			if next < 0 {
+				end = -1
+			} else {
+				end += next + 1
+			}
+		}
+		step := rest
+		if end >= 0 {
+			step, rest = rest[:end], rest[end:]
+		} else {
+			rest = ""
+		}
+
+		if strings.HasPrefix(step, "@") || step == "text()" {
+			if rest != "" || first {
+				return "", "", invalid("only the last step can select an attribute or the text")
+			}
+			if step != "text()" {
+				attribute = step[1:]
+			}
+			break
+		}
+
+		match := xpathStepRe.FindStringSubmatch(step)
+		if match == nil {
+			return "", "", invalid(fmt.Sprintf("the step '%s' isn't supported", step))
+		}
+		if !first {
+			selector.WriteString(combinator)
+		}
+		selector.WriteString(match[1])
+		if first && combinator == " > " {
+			selector.WriteString(":root")
+		}
+		if err := writeXPathPredicates(&selector, match[1], match[2]); err != nil {
+			return "", "", invalid(err.Error())
+		}
+	}
+	if selector.Len() == 0 {
+		return "", "", invalid("it has no steps")
+	}
+	return selector.String(), attribute, nil
+}
+
+func writeXPathPredicates(selector *strings.Builder, nodeTest, predicates string) error {
+	for predicates != "" {
+		loc := xpathPredicateRe.FindStringSubmatchIndex(predicates)
+		if loc == nil || loc[0] != 0 {
+			return fmt.Errorf("the predicates '%s' aren't supported", predicates)
+		}
+		group := func(i int) (string, bool) {
+			if loc[2*i] < 0 {
+				return "", false
+			}
+			return predicates[loc[2*i]:loc[2*i+1]], true
+		}
+
+		switch attr, ok := group(1); {
+		case ok:
+			value, quoted := group(2)
+			if !quoted {
+				value, quoted = group(3)
+			}
+			if quoted {
+				fmt.Fprintf(selector, "[%s=%s]", attr, strconv.Quote(value))
+			} else {
+				fmt.Fprintf(selector, "[%s]", attr)
+			}
+		default:
+			if attr, ok := group(4); ok {
+				value, quoted := group(5)
+				if !quoted {
+					value, _ = group(6)
+				}
+				fmt.Fprintf(selector, "[%s*=%s]", attr, strconv.Quote(value))
+				break
+			}
+			position, _ := group(7)
+			if nodeTest == "*" {
+				fmt.Fprintf(selector, ":nth-child(%s)", position)
+			} else {
+				fmt.Fprintf(selector, ":nth-of-type(%s)", position)
+			}
+		}
+		predicates = predicates[loc[1]:]
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Flow", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.tb.Mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = fmt.Fprint(w, `<html><body><form action="/post">
+				<input type="hidden" name="csrf" value="token-123"><a href="/next?id=42">next</a>
+			</form></body></html>`)
+		})
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var session = new http.Session({baseURL: "HTTPBIN_URL/", headers: {"X-Client": "k6 {{client}}"}});
+			session.vars.client = "migration";
+
+			var res = session.get("/login");
+			var csrf = session.extract(res, {name: "csrf", css: "input[name=csrf]", attribute: "value"});
+			if (csrf !== "token-123") { throw new Error("unexpected csrf " + csrf); }
+			session.extract(res, {name: "id", xpath: "//form/a[contains(@href,'next')]/@href"});
+			session.extract(res, {name: "id", regex: "id=(\\d+)", });
+			if (session.vars.id !== "42") { throw new Error("unexpected id " + session.vars.id); }
+
+			res = session.post("post?id={{id}}", {csrf: "{{csrf}}"}, {headers: {"X-Id": "{{id}}"}});
+			var form = session.extract(res, {jsonpath: "$.form.csrf[0]"});
+			if (form !== "token-123") { throw new Error("unexpected form " + JSON.stringify(form)); }
+			var headers = res.json().headers;
+			if (headers["X-Client"][0] !== "k6 migration" || headers["X-Id"][0] !== "42") {
+				throw new Error("unexpected headers " + JSON.stringify(headers));
+			}
+			if (session.extract(res, {jmespath: "args.id[0]"}) !== "42") {
+				throw new Error("unexpected url " + res.url);
+			}
+
+			session.get("cookies/set?session=abc");
+			if (session.jar.cookiesForURL("HTTPBIN_URL/cookies").session[0] !== "abc") {
+				throw new Error("the session cookie jar wasn't used");
+			}
+			if (new http.Session().jar.cookiesForURL("HTTPBIN_URL/cookies").session !== undefined) {
+				throw new Error("the cookie of the session leaked into another session");
+			}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		rt := ts.runtime.VU.Runtime()
+		_, err := rt.RunString(`new http.Session({baseURL: "/relative"})`)
+		require.ErrorContains(t, err, "invalid baseURL '/relative'")
+
+		_, err = rt.RunString(ts.tb.Replacer.Replace(`
+			var session = new http.Session({baseURL: "HTTPBIN_URL"});
+			session.extract(session.get("/html"), {css: "h1", regex: "h1"});
+		`))
+		require.ErrorContains(t, err, "exactly one of the css, xpath, regex, jsonpath and jmespath selectors has to be given")
+	})
+}
+
+func TestSessionExtract(t *testing.T) {
+	t.Parallel()
+
+	body := `<html><body>
+		<div id="a"><span class="x">one</span><span class="y">two</span></div>
+		<div><span class="x">three</span></div>
+	</body></html>`
+	testCases := []struct {
+		opts     extractOptions
+		expected []interface{}
+	}{
+		{extractOptions{CSS: "span.x"}, []interface{}{"one", "three"}},
+		{extractOptions{CSS: "div", Attribute: "id"}, []interface{}{"a"}},
+		{extractOptions{XPath: "//div[@id='a']/span[2]/text()"}, []interface{}{"two"}},
+		{extractOptions{XPath: "/html/body/div/span[@class=\"x\"]"}, []interface{}{"one", "three"}},
+		{extractOptions{XPath: "//*[contains(@class,'y')]/@class"}, []interface{}{"y"}},
+		{extractOptions{Regex: `class="(\w)"`}, []interface{}{"x", "y", "x"}},
+		{extractOptions{Regex: `\bt\w+`}, []interface{}{"two", "three"}},
+		{extractOptions{CSS: "p"}, []interface{}{}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%+v", tc.opts), func(t *testing.T) {
+			t.Parallel()
+			matches, err := tc.opts.extract(body, &queryCaches{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, matches)
+		})
+	}
+
+	items := `{"items": [{"id": 1}, {"id": 2}]}`
+	matches, err := extractOptions{JSONPath: "$.items[*].id"}.extract(items, &queryCaches{})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1.0, 2.0}, matches)
+	matches, err = extractOptions{JMESPath: "items[?id > `1`].id | [0]"}.extract(items, &queryCaches{})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{2.0}, matches)
+
+	_, err = extractOptions{XPath: "//div[last()]"}.extract(body, &queryCaches{})
+	assert.ErrorContains(t, err, "unsupported xpath")
+}
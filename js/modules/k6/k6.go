@@ -10,8 +10,12 @@ import (
 
 	"github.com/dop251/goja"
 
+	"go.k6.io/k6/js/abort"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
+	httpModule "go.k6.io/k6/js/modules/k6/http"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/httpext"
 	"go.k6.io/k6/metrics"
 )
 
@@ -61,7 +65,7 @@ func (mi *K6) Exports() modules.Exports {
 			"fail":       mi.Fail,
 			"group":      mi.Group,
 			"randomSeed": mi.RandomSeed,
-			"sleep":      mi.Sleep,
+			"sleep":      mi.sleepExport(),
 		},
 	}
 }
@@ -71,15 +75,37 @@ func (*K6) Fail(msg string) (goja.Value, error) {
 	return goja.Undefined(), errors.New(msg)
 }
 
-// Sleep waits the provided seconds before continuing the execution.
-func (mi *K6) Sleep(secs float64) {
+// Sleep waits the provided seconds before continuing the execution. If the
+// signal param is aborted, e.g. by AbortSignal.timeout(), the sleep is cut
+// short by throwing its reason.
+func (mi *K6) Sleep(secs float64, params goja.Value) error {
+	return mi.sleep(time.Duration(secs*float64(time.Second)), params)
+}
+
+func (mi *K6) sleep(d time.Duration, params goja.Value) error {
+	var signal *abort.Signal
+	if !common.IsNullish(params) {
+		var err error
+		signal, err = abort.FromValue(mi.vu, params.ToObject(mi.vu.Runtime()).Get("signal"))
+		if err != nil {
+			return fmt.Errorf("invalid sleep() signal: %w", err)
+		}
+	}
+	if signal.Aborted() {
+		panic(signal.Reason())
+	}
+
 	ctx := mi.vu.Context()
-	timer := time.NewTimer(time.Duration(secs * float64(time.Second)))
+	timer := time.NewTimer(d)
 	select {
 	case <-timer.C:
 	case <-ctx.Done():
 		timer.Stop()
+	case <-signal.Done():
+		timer.Stop()
+		panic(signal.Reason())
 	}
+	return nil
 }
 
 // RandomSeed sets the seed to the random generator used for this VU.
@@ -203,6 +229,7 @@ func (mi *K6) Check(arg0, checks goja.Value, extras ...goja.Value) (bool, error)
 		if !booleanVal {
 			// A single failure makes the return value false.
 			succ = false
+			captureFailure(state, check.Name, arg0)
 		}
 
 		// Emit! (But only if we have a valid context.)
@@ -235,3 +262,19 @@ func (mi *K6) Check(arg0, checks goja.Value, extras ...goja.Value) (bool, error)
 
 	return succ, nil
 }
+
+// captureFailure captures the request and the response of a failed check of
+// an HTTP response, if the captureFailures option is set.
+func captureFailure(state *lib.State, check string, value goja.Value) {
+	if state.FailureCapture == nil || value == nil {
+		return
+	}
+	res, ok := value.Export().(*httpModule.Response)
+	if !ok || res.Response == nil {
+		return
+	}
+	req, captured := httpext.CaptureFailure(res.Response)
+	if err := state.FailureCapture.CaptureCheck(check, req, captured); err != nil {
+		state.Logger.WithError(err).Warn("Couldn't capture the failed check")
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"go.k6.io/k6/js/abort"
 	"go.k6.io/k6/js/modulestest"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
 	"go.k6.io/k6/metrics"
 )
 
@@ -63,6 +65,111 @@ func TestSleep(t *testing.T) {
 		assert.True(t, d > 500*time.Millisecond, "did not sleep long enough")
 		assert.True(t, d < 2*time.Second, "slept for too long!!")
 	})
+
+	t.Run("Signal", func(t *testing.T) {
+		t.Parallel()
+
+		tc := testCaseRuntime(t)
+		require.NoError(t, abort.Install(tc.testRuntime.VU))
+
+		startTime := time.Now()
+		_, err := tc.testRuntime.RunOnEventLoop(`
+			try {
+				k6.sleep(10, { signal: AbortSignal.timeout(100) });
+				throw new Error("the sleep should be aborted");
+			} catch (e) {
+				if (e.name !== "TimeoutError") throw e;
+			}
+			try {
+				k6.sleep(10, { signal: AbortSignal.abort("aborted") });
+				throw new Error("the sleep shouldn't start");
+			} catch (e) {
+				if (e !== "aborted") throw e;
+			}
+		`)
+		require.NoError(t, err)
+		assert.Less(t, time.Since(startTime), 2*time.Second)
+	})
+}
+
+func TestSleepDistributions(t *testing.T) {
+	t.Parallel()
+
+	tc := testCaseRuntime(t)
+	startTime := time.Now()
+	_, err := tc.testRuntime.RunOnEventLoop(`
+		k6.randomSeed(12345);
+		var slept = [
+			k6.sleep.normal(0.1, 0.05, { min: 0.05, max: 0.15 }),
+			k6.sleep.exponential(20, { max: 0.2 }),
+			k6.sleep.uniform(0.05, 0.1),
+		];
+		if (slept[0] < 0.05 || slept[0] > 0.15) throw new Error("wrong normal sleep: " + slept[0]);
+		if (slept[1] < 0 || slept[1] > 0.2) throw new Error("wrong exponential sleep: " + slept[1]);
+		if (slept[2] < 0.05 || slept[2] > 0.1) throw new Error("wrong uniform sleep: " + slept[2]);
+
+		k6.randomSeed(12345);
+		var again = k6.sleep.normal(0.1, 0.05, { min: 0.05, max: 0.15 });
+		if (again !== slept[0]) throw new Error("randomSeed should repeat the sleeps: " + again);
+	`)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(startTime), 150*time.Millisecond)
+
+	for _, script := range []string{
+		`k6.sleep.normal(1, -1)`,
+		`k6.sleep.exponential(0)`,
+		`k6.sleep.uniform(2, 1)`,
+	} {
+		_, err := tc.testRuntime.RunOnEventLoop(script)
+		assert.Error(t, err, script)
+	}
+}
+
+func TestSleepPacing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Iteration", func(t *testing.T) {
+		t.Parallel()
+
+		tc := testCaseRuntime(t)
+		state := tc.testRuntime.VU.StateField
+		state.IterationContext = &lib.IterationContext{StartTime: time.Now().Add(-200 * time.Millisecond)}
+		state.Logger = testutils.NewLogger(t)
+
+		startTime := time.Now()
+		_, err := tc.testRuntime.RunOnEventLoop(`
+			var slept = k6.sleep.pacing(0.5);
+			if (slept <= 0 || slept > 0.3) throw new Error("wrong pacing sleep: " + slept);
+			if (k6.sleep.pacing(0.1) !== 0) throw new Error("the iteration is already over its target");
+		`)
+		require.NoError(t, err)
+		elapsed := time.Since(startTime)
+		assert.GreaterOrEqual(t, elapsed, 250*time.Millisecond)
+		assert.Less(t, elapsed, time.Second)
+	})
+
+	t.Run("ArrivalRate", func(t *testing.T) {
+		t.Parallel()
+
+		tc := testCaseRuntime(t)
+		tc.testRuntime.VU.StateField.IterationContext = lib.NewIterationContext()
+		tc.testRuntime.VU.CtxField = lib.WithScenarioState(tc.testRuntime.VU.CtxField, &lib.ScenarioState{
+			Name: "default", Executor: "constant-arrival-rate",
+		})
+
+		_, err := tc.testRuntime.RunOnEventLoop(`
+			if (k6.sleep.pacing(10) !== 0) throw new Error("arrival-rate iterations are already paced");
+		`)
+		require.NoError(t, err)
+	})
+
+	t.Run("NoIteration", func(t *testing.T) {
+		t.Parallel()
+
+		tc := testCaseRuntime(t)
+		_, err := tc.testRuntime.RunOnEventLoop(`k6.sleep.pacing(1)`)
+		assert.ErrorContains(t, err, "sleep.pacing() can only be used in an iteration")
+	})
 }
 
 func TestRandSeed(t *testing.T) {
@@ -18,6 +18,10 @@ import (
 type Metric struct {
 	metric *metrics.Metric
 	vu     modules.VU
+
+	// tagSchema are the allowed values of the tags of add(), by name, a nil
+	// slice allowing any value, if the metric declares its tags
+	tagSchema map[string][]string
 }
 
 // ErrMetricsAddInInitContext is error returned when adding to metric is done in the init context
@@ -29,21 +33,35 @@ func (mi *ModuleInstance) newMetric(call goja.ConstructorCall, t metrics.MetricT
 		return nil, errors.New("metrics must be declared in the init context")
 	}
 	rt := mi.vu.Runtime()
-	c, _ := goja.AssertFunction(rt.ToValue(func(name string, isTime ...bool) (*goja.Object, error) {
+	c, _ := goja.AssertFunction(rt.ToValue(func(name string, opts goja.Value) (*goja.Object, error) {
+		options, err := parseMetricOptions(rt, opts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid options of the metric '%s': %w", name, err)
+		}
 		valueType := metrics.Default
-		if len(isTime) > 0 && isTime[0] {
+		if options.isTime {
 			valueType = metrics.Time
 		}
 		m, err := initEnv.Registry.NewMetric(name, t, valueType)
 		if err != nil {
 			return nil, err
 		}
-		metric := &Metric{metric: m, vu: mi.vu}
+		if options.description != "" {
+			if err = m.Describe(options.description); err != nil {
+				return nil, err
+			}
+		}
+		metric := &Metric{metric: m, vu: mi.vu, tagSchema: options.tags}
 		o := rt.NewObject()
 		err = o.DefineDataProperty("name", rt.ToValue(name), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
 		if err != nil {
 			return nil, err
 		}
+		err = o.DefineDataProperty("description", rt.ToValue(m.Description),
+			goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+		if err != nil {
+			return nil, err
+		}
 		if err = o.Set("add", rt.ToValue(metric.add)); err != nil {
 			return nil, err
 		}
@@ -57,6 +75,69 @@ func (mi *ModuleInstance) newMetric(call goja.ConstructorCall, t metrics.MetricT
 	return v.ToObject(rt), nil
 }
 
+type metricOptions struct {
+	isTime      bool
+	description string
+	tags        map[string][]string
+}
+
+// parseMetricOptions parses the second argument of the constructors, which is
+// either the isTime boolean or an object like
+// `{isTime: true, description: "...", tags: {status: ["ok", "failed"], region: true}}`.
+func parseMetricOptions(rt *goja.Runtime, opts goja.Value) (metricOptions, error) {
+	var options metricOptions
+	if common.IsNullish(opts) {
+		return options, nil
+	}
+	if _, isObject := opts.(*goja.Object); !isObject {
+		options.isTime = opts.ToBoolean()
+		return options, nil
+	}
+
+	obj := opts.ToObject(rt)
+	for _, key := range obj.Keys() {
+		value := obj.Get(key)
+		switch key {
+		case "isTime":
+			options.isTime = value.ToBoolean()
+		case "description":
+			options.description = value.String()
+		case "tags":
+			tags, err := parseTagSchema(rt, value)
+			if err != nil {
+				return options, err
+			}
+			options.tags = tags
+		default:
+			return options, fmt.Errorf("unknown option '%s'", key)
+		}
+	}
+	return options, nil
+}
+
+// parseTagSchema parses the declared tags of a metric, the values of which
+// are the arrays of their allowed values, or true for any value.
+func parseTagSchema(rt *goja.Runtime, v goja.Value) (map[string][]string, error) {
+	if common.IsNullish(v) {
+		return nil, nil
+	}
+	obj := v.ToObject(rt)
+	schema := make(map[string][]string)
+	for _, name := range obj.Keys() {
+		allowed := obj.Get(name)
+		if anyValue, ok := allowed.Export().(bool); ok && anyValue {
+			schema[name] = nil
+			continue
+		}
+		var values []string
+		if err := rt.ExportTo(allowed, &values); err != nil || len(values) == 0 {
+			return nil, fmt.Errorf("the tag '%s' has to be true or an array of its allowed values", name)
+		}
+		schema[name] = values
+	}
+	return schema, nil
+}
+
 const warnMessageValueMaxSize = 100
 
 func limitValue(v string) string {
@@ -72,7 +153,8 @@ func limitValue(v string) string {
 	}, string(omitMsg))
 }
 
-func (m Metric) add(v goja.Value, addTags goja.Value) (bool, error) {
+//nolint:cyclop,funlen
+func (m Metric) add(v goja.Value, addTags goja.Value, addMetadata goja.Value, at goja.Value) (bool, error) {
 	state := m.vu.State()
 	if state == nil {
 		return false, ErrMetricsAddInInitContext
@@ -108,17 +190,36 @@ func (m Metric) add(v goja.Value, addTags goja.Value) (bool, error) {
 		return raiseNan()
 	}
 
+	sampleTime := time.Now()
+	if !common.IsNullish(at) {
+		if m.metric.Type != metrics.Gauge {
+			return raiseErr(fmt.Errorf("only the gauges accept the time of their values, unlike the metric '%s'",
+				m.metric.Name))
+		}
+		var ok bool
+		if sampleTime, ok = parseTime(at); !ok {
+			return raiseErr(fmt.Errorf("'%s' is an invalid time for metric '%s', "+
+				"a Date or a number of milliseconds since the epoch is expected", limitValue(at.String()), m.metric.Name))
+		}
+	}
+	if err := m.checkTags(addTags); err != nil {
+		return raiseErr(err)
+	}
+
 	ctm := state.Tags.GetCurrentValues()
 	if err := common.ApplyCustomUserTags(m.vu.Runtime(), &ctm, addTags); err != nil {
 		return false, fmt.Errorf("cannot add tags for the '%s' custom metric: %w", m.metric.Name, err)
 	}
+	if err := common.ApplyCustomUserMetadataMap(m.vu.Runtime(), &ctm, addMetadata); err != nil {
+		return false, fmt.Errorf("cannot add metadata for the '%s' custom metric: %w", m.metric.Name, err)
+	}
 
 	sample := metrics.Sample{
 		TimeSeries: metrics.TimeSeries{
 			Metric: m.metric,
 			Tags:   ctm.Tags,
 		},
-		Time:     time.Now(),
+		Time:     sampleTime,
 		Metadata: ctm.Metadata,
 		Value:    vfloat,
 	}
@@ -126,6 +227,47 @@ func (m Metric) add(v goja.Value, addTags goja.Value) (bool, error) {
 	return true, nil
 }
 
+// checkTags returns an error if the tags of add() aren't the declared ones of
+// the metric or don't have one of their allowed values.
+func (m Metric) checkTags(addTags goja.Value) error {
+	if m.tagSchema == nil || common.IsNullish(addTags) {
+		return nil
+	}
+	obj := addTags.ToObject(m.vu.Runtime())
+	for _, name := range obj.Keys() {
+		allowed, ok := m.tagSchema[name]
+		if !ok {
+			return fmt.Errorf("the tag '%s' isn't declared by the metric '%s'", name, m.metric.Name)
+		}
+		if allowed == nil {
+			continue
+		}
+		value := obj.Get(name).String()
+		valid := false
+		for _, a := range allowed {
+			valid = valid || a == value
+		}
+		if !valid {
+			return fmt.Errorf("'%s' isn't an allowed value of the tag '%s' of the metric '%s', expected one of '%s'",
+				limitValue(value), name, m.metric.Name, strings.Join(allowed, "', '"))
+		}
+	}
+	return nil
+}
+
+// parseTime returns the time of a Date or of a number of milliseconds since
+// the epoch.
+func parseTime(v goja.Value) (time.Time, bool) {
+	if t, ok := v.Export().(time.Time); ok {
+		return t, true
+	}
+	ms := v.ToFloat()
+	if math.IsNaN(ms) || math.IsInf(ms, 0) || ms < 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(ms*float64(time.Millisecond))), true
+}
+
 type (
 	// RootModule is the root metrics module
 	RootModule struct{}
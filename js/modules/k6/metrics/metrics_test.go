@@ -10,6 +10,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modulestest"
@@ -35,6 +36,7 @@ type addTest struct {
 	valueType    metrics.ValueType
 	js           string
 	expectedTags map[string]string
+	expectedMeta map[string]string
 }
 
 func (a addTest) run(t *testing.T) {
@@ -60,6 +62,7 @@ func (a addTest) run(t *testing.T) {
 		assert.NotZero(t, sample.Time)
 		assert.Equal(t, a.val.Float, sample.Value)
 		assert.Equal(t, a.expectedTags, sample.Tags.Map())
+		assert.Equal(t, a.expectedMeta, sample.Metadata)
 		assert.Equal(t, "my_metric", sample.Metric.Name)
 		assert.Equal(t, a.mtyp, sample.Metric.Type)
 		assert.Equal(t, a.valueType, sample.Metric.Contains)
@@ -150,12 +153,20 @@ func TestMetrics(t *testing.T) {
 							t.Run(fmt.Sprintf("%s/isThrow=%v/Simple", name, isThrow), func(t *testing.T) {
 								test.js = fmt.Sprintf(`m.add(%v)`, val.JS)
 								test.expectedTags = map[string]string{"key": "value"}
+								test.expectedMeta = nil
 								test.run(t)
 							})
 							if !val.noTags {
 								t.Run(fmt.Sprintf("%s/isThrow=%v/Tags", name, isThrow), func(t *testing.T) {
 									test.js = fmt.Sprintf(`m.add(%v, {a:1})`, val.JS)
 									test.expectedTags = map[string]string{"key": "value", "a": "1"}
+									test.expectedMeta = nil
+									test.run(t)
+								})
+								t.Run(fmt.Sprintf("%s/isThrow=%v/Metadata", name, isThrow), func(t *testing.T) {
+									test.js = fmt.Sprintf(`m.add(%v, {a:1}, {trace_id: "abc", vu: 3})`, val.JS)
+									test.expectedTags = map[string]string{"key": "value", "a": "1"}
+									test.expectedMeta = map[string]string{"trace_id": "abc", "vu": "3"}
 									test.run(t)
 								})
 							}
@@ -235,3 +246,93 @@ func TestMetricDuplicates(t *testing.T) {
 
 	require.True(t, v.ToBoolean())
 }
+
+func TestMetricOptions(t *testing.T) {
+	t.Parallel()
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+
+	registry := metrics.NewRegistry()
+	mii := &modulestest.VU{
+		RuntimeField: rt,
+		InitEnvField: &common.InitEnvironment{TestPreInitState: &lib.TestPreInitState{Registry: registry}},
+		CtxField:     context.Background(),
+	}
+	m, ok := New().NewModuleInstance(mii).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.Set("metrics", m.Exports().Named))
+	v, err := rt.RunString(`
+		var orders = new metrics.Counter("orders", {
+			description: "The number of orders.",
+			tags: {status: ["ok", "failed"], region: true},
+		});
+		var queue = new metrics.Gauge("queue_depth", {description: "The depth of the queue."});
+		var latency = new metrics.Trend("latency", {isTime: true});
+		orders.description;
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "The number of orders.", v.String())
+	assert.Equal(t, "The number of orders.", registry.Get("orders").Description)
+	assert.Equal(t, metrics.Time, registry.Get("latency").Contains)
+
+	for js, msg := range map[string]string{
+		`new metrics.Counter("orders", {description: "Other."})`: "metric 'orders' already exists " +
+			"but with the description 'The number of orders.'",
+		`new metrics.Counter("other", {tags: {status: false}})`: "the tag 'status' has to be true " +
+			"or an array of its allowed values",
+		`new metrics.Counter("other", {unit: "ms"})`: "invalid options of the metric 'other': " +
+			"unknown option 'unit'",
+		`new metrics.Counter("orders", {description: "The number of orders."})`: "",
+	} {
+		_, err := rt.RunString(js)
+		if msg == "" {
+			assert.NoError(t, err, js)
+		} else {
+			assert.ErrorContains(t, err, msg, js)
+		}
+	}
+
+	samples := make(chan metrics.SampleContainer, 10)
+	logger := logrus.New()
+	logger.Out = io.Discard
+	mii.InitEnvField = nil
+	mii.StateField = &lib.State{
+		Options: lib.Options{Throw: null.BoolFrom(true)},
+		Samples: samples,
+		Tags:    lib.NewVUStateTags(registry.RootTagSet()),
+		Logger:  logger,
+	}
+
+	_, err = rt.RunString(`
+		orders.add(1, {status: "ok", region: "eu"});
+		queue.add(3, {}, {}, new Date(1700000000000));
+		queue.add(4, null, null, 1700000001000);
+	`)
+	require.NoError(t, err)
+	bufSamples := metrics.GetBufferedSamples(samples)
+	require.Len(t, bufSamples, 3)
+	order, ok := bufSamples[0].(metrics.Sample)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"status": "ok", "region": "eu"}, order.Tags.Map())
+	for i, expected := range []int64{1700000000000, 1700000001000} {
+		sample, ok := bufSamples[i+1].(metrics.Sample)
+		require.True(t, ok)
+		assert.Equal(t, expected, sample.Time.UnixMilli())
+	}
+
+	for js, msg := range map[string]string{
+		`orders.add(1, {status: "pending"})`: "'pending' isn't an allowed value of the tag 'status' of the metric 'orders'," +
+			" expected one of 'ok', 'failed'",
+		`orders.add(1, {method: "GET"})`:     "the tag 'method' isn't declared by the metric 'orders'",
+		`orders.add(1, {}, {}, Date.now())`:  "only the gauges accept the time of their values, unlike the metric 'orders'",
+		`queue.add(1, {}, {}, "yesterday")`:  "'yesterday' is an invalid time for metric 'queue_depth'",
+		`latency.add(1, {anything: "goes"})`: "",
+	} {
+		_, err := rt.RunString(js)
+		if msg == "" {
+			assert.NoError(t, err, js)
+		} else {
+			assert.ErrorContains(t, err, msg, js)
+		}
+	}
+}
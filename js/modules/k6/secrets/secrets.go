@@ -0,0 +1,80 @@
+// Package secrets provides the k6/secrets module, which gives the scripts the
+// secrets of the --secret-source flags, e.g. `await secrets.get("API_TOKEN")`.
+package secrets
+
+import (
+	"errors"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/js/promises"
+	"go.k6.io/k6/secretsource"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the secrets module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		secrets *secretsource.Manager
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new RootModule instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface to return
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	mi := &ModuleInstance{vu: vu}
+	if initEnv := vu.InitEnv(); initEnv != nil && initEnv.TestPreInitState != nil {
+		mi.secrets = initEnv.TestPreInitState.Secrets
+	}
+	return mi
+}
+
+// Exports returns the exports of the secrets module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"get": mi.get,
+		},
+	}
+}
+
+// get returns a promise of the secret with the key, from the first of the
+// secret sources which has it.
+func (mi *ModuleInstance) get(key goja.Value) *goja.Promise {
+	promise, resolve, reject := promises.New(mi.vu)
+	if common.IsNullish(key) || key.String() == "" {
+		reject(errors.New("get() needs the key of the secret"))
+		return promise
+	}
+	if mi.secrets == nil {
+		reject(errors.New("there are no secret sources, they're set with --secret-source"))
+		return promise
+	}
+
+	k := key.String()
+	go func() {
+		value, err := mi.secrets.Get(k)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(value)
+	}()
+	return promise
+}
@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/secretsource"
+)
+
+type mapSource map[string]string
+
+func (mapSource) Description() string { return "map" }
+
+func (ms mapSource) Get(key string) (string, error) {
+	value, ok := ms[key]
+	if !ok {
+		return "", secretsource.ErrNotFound
+	}
+	return value, nil
+}
+
+func newSecretsRuntime(t *testing.T, secrets *secretsource.Manager) *modulestest.Runtime {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	runtime.VU.InitEnvField.TestPreInitState.Secrets = secrets
+	m := New().NewModuleInstance(runtime.VU)
+	require.NoError(t, runtime.VU.RuntimeField.Set("secrets", m.Exports().Named))
+	return runtime
+}
+
+func TestSecretsGet(t *testing.T) {
+	t.Parallel()
+
+	manager := secretsource.NewManager([]secretsource.Source{
+		mapSource{"token": "first"},
+		mapSource{"token": "second", "password": "hunter2"},
+	})
+	runtime := newSecretsRuntime(t, manager)
+	_, err := runtime.RunOnEventLoop(`
+		(async () => {
+			const token = await secrets.get("token");
+			const password = await secrets.get("password");
+			if (token !== "first" || password !== "hunter2") {
+				throw new Error("unexpected secrets " + token + " " + password);
+			}
+		})()
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "*** "+secretsource.RedactedValue, manager.Redact("*** hunter2"))
+}
+
+func TestSecretsGetErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		secrets *secretsource.Manager
+		code    string
+		err     string
+	}{
+		"missing": {
+			secrets: secretsource.NewManager([]secretsource.Source{mapSource{}}),
+			code:    `secrets.get("missing")`,
+			err:     "secret not found: 'missing' isn't in any of the secret sources",
+		},
+		"no key": {
+			secrets: secretsource.NewManager([]secretsource.Source{mapSource{}}),
+			code:    `secrets.get()`,
+			err:     "get() needs the key of the secret",
+		},
+		"no sources": {
+			code: `secrets.get("token")`,
+			err:  "there are no secret sources, they're set with --secret-source",
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			runtime := newSecretsRuntime(t, tc.secrets)
+			_, err := runtime.RunOnEventLoop(`(async () => { await ` + tc.code + ` })()`)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
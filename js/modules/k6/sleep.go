@@ -0,0 +1,128 @@
+package k6
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
+)
+
+// sleepExport returns the sleep() function, with the think time helpers
+// drawing the durations from statistical distributions as its properties.
+func (mi *K6) sleepExport() goja.Value {
+	rt := mi.vu.Runtime()
+	sleep := rt.ToValue(mi.Sleep).ToObject(rt)
+
+	mustExport := func(name string, value interface{}) {
+		if err := sleep.Set(name, value); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	mustExport("normal", mi.SleepNormal)
+	mustExport("exponential", mi.SleepExponential)
+	mustExport("uniform", mi.SleepUniform)
+	mustExport("pacing", mi.Pacing)
+	return sleep
+}
+
+// SleepNormal sleeps for a duration drawn from the normal distribution of the
+// mean and the standard deviation, in seconds. The negative draws are
+// truncated to zero, and the params can bound the duration with min and max.
+func (mi *K6) SleepNormal(mean, stddev float64, params goja.Value) (float64, error) {
+	if stddev < 0 {
+		return 0, fmt.Errorf("the standard deviation of sleep.normal() can't be negative, got %g", stddev)
+	}
+	// Box-Muller transform of two uniform draws, 1-u is in (0, 1]
+	u1, u2 := 1-mi.random(), mi.random()
+	secs := mean + stddev*math.Sqrt(-2*math.Log(u1))*math.Cos(2*math.Pi*u2)
+	return mi.sleepBounded(secs, params)
+}
+
+// SleepExponential sleeps for a duration drawn from the exponential
+// distribution of the rate, i.e. 1/rate seconds on average like the time
+// between the events of a Poisson process.
+func (mi *K6) SleepExponential(rate float64, params goja.Value) (float64, error) {
+	if rate <= 0 {
+		return 0, fmt.Errorf("the rate of sleep.exponential() must be positive, got %g", rate)
+	}
+	secs := -math.Log(1-mi.random()) / rate
+	return mi.sleepBounded(secs, params)
+}
+
+// SleepUniform sleeps for a duration drawn uniformly between min and max
+// seconds.
+func (mi *K6) SleepUniform(minSecs, maxSecs float64, params goja.Value) (float64, error) {
+	if minSecs < 0 || maxSecs < minSecs {
+		return 0, fmt.Errorf("sleep.uniform() needs a max of at least its non-negative min, got %g and %g",
+			minSecs, maxSecs)
+	}
+	return mi.sleepBounded(minSecs+mi.random()*(maxSecs-minSecs), params)
+}
+
+// Pacing sleeps until the current iteration has lasted the target seconds,
+// so the iterations of the VU start at a steady pace whatever the response
+// times. The iterations of the arrival-rate executors are already started at
+// the configured rate, so it doesn't sleep for them.
+func (mi *K6) Pacing(target float64, params goja.Value) (float64, error) {
+	state := mi.vu.State()
+	if state == nil || state.IterationContext == nil {
+		return 0, errors.New("sleep.pacing() can only be used in an iteration")
+	}
+	if target < 0 {
+		return 0, fmt.Errorf("the target duration of sleep.pacing() can't be negative, got %g", target)
+	}
+	if mi.isArrivalRate() {
+		return 0, nil
+	}
+
+	remaining := time.Duration(target*float64(time.Second)) - time.Since(state.IterationContext.StartTime)
+	if remaining <= 0 {
+		state.Logger.Debugf("The iteration lasted more than the %gs of sleep.pacing()", target)
+		return 0, nil
+	}
+	return remaining.Seconds(), mi.sleep(remaining, params)
+}
+
+// sleepBounded sleeps for the seconds, bounded by the min and the max of the
+// params and by zero, and returns the seconds it slept for.
+func (mi *K6) sleepBounded(secs float64, params goja.Value) (float64, error) {
+	if !common.IsNullish(params) {
+		obj := params.ToObject(mi.vu.Runtime())
+		if v := obj.Get("min"); !common.IsNullish(v) {
+			secs = math.Max(secs, v.ToFloat())
+		}
+		if v := obj.Get("max"); !common.IsNullish(v) {
+			secs = math.Min(secs, v.ToFloat())
+		}
+	}
+	secs = math.Max(secs, 0)
+	return secs, mi.sleep(time.Duration(secs*float64(time.Second)), params)
+}
+
+// random returns a number in [0, 1) from Math.random(), so the durations are
+// reproducible with randomSeed().
+func (mi *K6) random() float64 {
+	rt := mi.vu.Runtime()
+	random, ok := goja.AssertFunction(rt.GlobalObject().Get("Math").ToObject(rt).Get("random"))
+	if !ok {
+		common.Throw(rt, errors.New("Math.random() isn't a function"))
+	}
+	v, err := random(goja.Undefined())
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return v.ToFloat()
+}
+
+// isArrivalRate returns true when the current scenario has an arrival-rate
+// executor, which starts the iterations at a rate.
+func (mi *K6) isArrivalRate() bool {
+	ss := lib.GetScenarioState(mi.vu.Context())
+	return ss != nil && strings.HasSuffix(ss.Executor, "arrival-rate")
+}
@@ -0,0 +1,322 @@
+// Package timers implements setTimeout, setInterval and their clear functions,
+// following the timers section of the HTML specification.
+//
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timers
+package timers
+
+import (
+	"math"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/js/modules"
+)
+
+const (
+	setTimeoutName  = "setTimeout"
+	setIntervalName = "setInterval"
+
+	// maxNestingLevel is the nesting level of the timers after which their
+	// timeout is clamped to minNestedTimeout, as it's in the browsers.
+	maxNestingLevel  = 5
+	minNestedTimeout = 4
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// Timers represents an instance of the timers module for a single VU.
+	Timers struct {
+		vu modules.VU
+
+		lastID int64
+		// timers are the active timers by their IDs
+		timers map[int64]*timer
+		// queue are the active timers in the order they will run
+		queue []*timer
+		// running is the timer whose callback is currently running
+		running *timer
+		// wakeup is the pending wakeup of the event loop for the first timer
+		wakeup *wakeup
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &Timers{}
+)
+
+// New returns a pointer to a new RootModule instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface to return
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &Timers{
+		vu:     vu,
+		timers: make(map[int64]*timer),
+	}
+}
+
+// Exports returns the exports of the timers module.
+func (e *Timers) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]interface{}{
+			"setTimeout":    e.setTimeout,
+			"clearTimeout":  e.clearTimeout,
+			"setInterval":   e.setInterval,
+			"clearInterval": e.clearInterval,
+		},
+	}
+}
+
+// timer keeps the internals of a single timer.
+type timer struct {
+	id       int64
+	name     string
+	handler  goja.Value
+	args     []goja.Value
+	repeat   bool
+	timeout  int64
+	nesting  int
+	nextTime time.Time
+}
+
+// wakeup is a wakeup of the event loop, at the time of the first timer. Its
+// goroutine holds a registered callback, so the event loop doesn't finish
+// while there are active timers.
+type wakeup struct {
+	at   time.Time
+	stop chan struct{}
+}
+
+func (e *Timers) setTimeout(handler goja.Value, timeout goja.Value, args ...goja.Value) int64 {
+	return e.timerInitialization(setTimeoutName, handler, timeout, args, false)
+}
+
+func (e *Timers) setInterval(handler goja.Value, timeout goja.Value, args ...goja.Value) int64 {
+	return e.timerInitialization(setIntervalName, handler, timeout, args, true)
+}
+
+// clearTimeout removes the timer with the id, which can be a timeout or an
+// interval, as in the specification.
+func (e *Timers) clearTimeout(id goja.Value) {
+	t, ok := e.timers[toLong(id)]
+	if !ok {
+		return
+	}
+	delete(e.timers, t.id)
+	e.remove(t)
+	e.schedule()
+}
+
+func (e *Timers) clearInterval(id goja.Value) {
+	e.clearTimeout(id)
+}
+
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-initialisation-steps
+func (e *Timers) timerInitialization(
+	name string, handler goja.Value, timeout goja.Value, args []goja.Value, repeat bool,
+) int64 {
+	e.lastID++
+	t := &timer{
+		id:       e.lastID,
+		name:     name,
+		handler:  handler,
+		args:     args,
+		repeat:   repeat,
+		nextTime: time.Now(),
+	}
+	if e.running != nil {
+		t.nesting = e.running.nesting
+	}
+	e.timers[t.id] = t
+	e.add(t, toLong(timeout))
+	e.schedule()
+	return t.id
+}
+
+// add queues the timer to run after the timeout from its previous time, which
+// is the time of its creation or, for the intervals, the time their previous
+// run was scheduled at. So the intervals don't drift by the time it takes to
+// run their callbacks.
+func (e *Timers) add(t *timer, timeout int64) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	if t.nesting > maxNestingLevel && timeout < minNestedTimeout {
+		timeout = minNestedTimeout
+	}
+	t.timeout = timeout
+	t.nesting++
+
+	t.nextTime = t.nextTime.Add(time.Duration(timeout) * time.Millisecond)
+	if now := time.Now(); t.nextTime.Before(now) {
+		t.nextTime = now
+	}
+
+	// the timers with the same time run in the order they were added
+	i := len(e.queue)
+	for i > 0 && e.queue[i-1].nextTime.After(t.nextTime) {
+		i--
+	}
+	e.queue = append(e.queue, nil)
+	copy(e.queue[i+1:], e.queue[i:])
+	e.queue[i] = t
+}
+
+func (e *Timers) remove(t *timer) {
+	for i, queued := range e.queue {
+		if queued == t {
+			e.queue = append(e.queue[:i], e.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// schedule makes sure the event loop is woken up at the time of the first
+// timer, or released if there are no more timers.
+func (e *Timers) schedule() {
+	if len(e.queue) == 0 {
+		e.stopWakeup()
+		return
+	}
+	at := e.queue[0].nextTime
+	if e.wakeup != nil && e.wakeup.at.Equal(at) {
+		return
+	}
+	e.stopWakeup()
+
+	w := &wakeup{at: at, stop: make(chan struct{})}
+	e.wakeup = w
+	enqueueCallback := e.vu.RegisterCallback()
+	ctx := e.vu.Context()
+	go func() {
+		t := time.NewTimer(time.Until(w.at))
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+			enqueueCallback(func() error { return e.runTimers(w) })
+		case <-w.stop:
+			enqueueCallback(func() error { return nil })
+		case <-ctx.Done():
+			enqueueCallback(func() error {
+				e.interrupt(w)
+				return nil
+			})
+		}
+	}()
+}
+
+func (e *Timers) stopWakeup() {
+	if e.wakeup != nil {
+		close(e.wakeup.stop)
+		e.wakeup = nil
+	}
+}
+
+// runTimers runs the callbacks of the timers whose time has come. If one of
+// them fails, the rest of the timers are removed, as the error ends the
+// iteration.
+func (e *Timers) runTimers(w *wakeup) error {
+	if w != e.wakeup {
+		return nil // the wakeup was replaced while it was being queued
+	}
+	e.wakeup = nil
+
+	now := time.Now()
+	for len(e.queue) > 0 && !e.queue[0].nextTime.After(now) {
+		t := e.queue[0]
+		e.queue = e.queue[1:]
+
+		if err := e.run(t); err != nil {
+			e.reset()
+			return err
+		}
+	}
+	e.schedule()
+	return nil
+}
+
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-initialisation-steps
+// (the task of step 8)
+func (e *Timers) run(t *timer) error {
+	if e.timers[t.id] != t {
+		return nil
+	}
+
+	e.running = t
+	err := e.call(t)
+	e.running = nil
+
+	if e.timers[t.id] != t {
+		return err // it was cleared by its callback
+	}
+	if err == nil && t.repeat {
+		e.add(t, t.timeout)
+	} else {
+		delete(e.timers, t.id)
+	}
+	return err
+}
+
+func (e *Timers) call(t *timer) error {
+	rt := e.vu.Runtime()
+	if fn, ok := goja.AssertFunction(t.handler); ok {
+		_, err := fn(rt.GlobalObject(), t.args...)
+		return err
+	}
+	// as in the browsers, a handler which isn't a function is evaluated as code
+	_, err := rt.RunString(t.handler.String())
+	return err
+}
+
+// interrupt removes the timers when the context of the VU is done, e.g. the
+// iteration was interrupted, and warns that they won't run.
+func (e *Timers) interrupt(w *wakeup) {
+	if w != e.wakeup {
+		return
+	}
+	logger := e.logger()
+	for _, t := range e.queue {
+		logger.Warnf("%s %d was stopped because the VU iteration was interrupted", t.name, t.id)
+	}
+	e.wakeup = nil
+	e.reset()
+}
+
+func (e *Timers) reset() {
+	e.stopWakeup()
+	e.timers = make(map[int64]*timer)
+	e.queue = nil
+	e.running = nil
+}
+
+// logger returns the logger of the VU state or, in the init context, the one
+// of the init environment.
+func (e *Timers) logger() logrus.FieldLogger {
+	if state := e.vu.State(); state != nil {
+		return state.Logger
+	}
+	return e.vu.InitEnv().Logger
+}
+
+// toLong converts the value as the long type of WebIDL, which is how the
+// specification receives the timeouts and the IDs.
+func toLong(v goja.Value) int64 {
+	if v == nil {
+		return 0
+	}
+	f := v.ToFloat()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int64(int32(uint32(int64(math.Mod(math.Trunc(f), 1<<32)))))
+}
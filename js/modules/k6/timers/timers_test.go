@@ -0,0 +1,160 @@
+package timers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/compiler"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+)
+
+func newRuntime(t testing.TB) *modulestest.Runtime {
+	t.Helper()
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/timers": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+
+	_, err = runtime.VU.Runtime().RunString(`
+		var { setTimeout, clearTimeout, setInterval, clearInterval } = require("k6/timers");
+	`)
+	require.NoError(t, err)
+	return runtime
+}
+
+func TestSetTimeout(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	_, err := runtime.RunOnEventLoop(`
+		var log = [];
+		setTimeout((a, b) => log.push("second " + a + b), 10, "x", "y");
+		setTimeout(() => log.push("first"));
+		setTimeout(() => log.push("first again"), -5);
+		setTimeout("log.push('string handler')", 20);
+		Promise.resolve().then(() => log.push("microtask"));
+		log.push("sync");
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		"sync", "microtask", "first", "first again", "second xy", "string handler",
+	}, runtime.VU.Runtime().Get("log").Export())
+}
+
+func TestClearTimeout(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	_, err := runtime.RunOnEventLoop(`
+		var log = [];
+		const id = setTimeout(() => log.push("cleared"), 5);
+		const other = setInterval(() => log.push("cleared interval"), 5);
+		setTimeout(() => log.push("kept"), 10);
+		clearTimeout(id);
+		clearTimeout(other); // timeouts and intervals share their IDs
+		clearTimeout(12345);
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"kept"}, runtime.VU.Runtime().Get("log").Export())
+}
+
+func TestSharedBetweenNames(t *testing.T) {
+	t.Parallel()
+	runtime := modulestest.NewRuntime(t)
+	timers := New()
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/timers": timers, "k6/experimental/timers": timers},
+		nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+
+	_, err = runtime.RunOnEventLoop(`
+		var log = [];
+		const id = require("k6/experimental/timers").setTimeout(() => log.push("cleared"), 5);
+		require("k6/timers").setTimeout(() => log.push("kept"), 10);
+		require("k6/timers").clearTimeout(id);
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"kept"}, runtime.VU.Runtime().Get("log").Export())
+}
+
+func TestSetInterval(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	start := time.Now()
+	_, err := runtime.RunOnEventLoop(`
+		var calls = 0;
+		const id = setInterval(() => {
+			calls++;
+			if (calls === 10) {
+				clearInterval(id);
+			}
+			const end = Date.now() + 15;
+			while (Date.now() < end) {} // the intervals shouldn't drift by the time of the callback
+		}, 20);
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), runtime.VU.Runtime().Get("calls").ToInteger())
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestNestedTimeoutClamping(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	start := time.Now()
+	_, err := runtime.RunOnEventLoop(`
+		var depth = 0;
+		function nest() {
+			if (++depth < 50) {
+				setTimeout(nest, 0);
+			}
+		}
+		setTimeout(nest, 0);
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), runtime.VU.Runtime().Get("depth").ToInteger())
+	// the first 5 levels are exempt, the rest are clamped to 4ms
+	assert.GreaterOrEqual(t, time.Since(start), 45*4*time.Millisecond)
+}
+
+func TestTimerErrors(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	_, err := runtime.RunOnEventLoop(`
+		var calls = 0;
+		setTimeout(() => { throw new Error("oops"); }, 1);
+		setTimeout(() => calls++, 5);
+	`)
+	require.ErrorContains(t, err, "oops")
+
+	// the timers of the failed iteration don't run in the next one
+	_, err = runtime.RunOnEventLoop(`setTimeout(() => {}, 20);`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), runtime.VU.Runtime().Get("calls").ToInteger())
+}
+
+func TestTimersInterrupted(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+	logger, hook := testutils.NewLoggerWithHook(t)
+	runtime.MoveToVUContext(&lib.State{Logger: logger})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	runtime.VU.CtxField = ctx
+
+	_, err := runtime.RunOnEventLoop(`setInterval(() => {}, 10);`)
+	require.NoError(t, err)
+
+	entries := hook.Drain()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "setInterval 1 was stopped because the VU iteration was interrupted", entries[0].Message)
+}
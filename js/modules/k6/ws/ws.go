@@ -19,6 +19,7 @@ import (
 	"github.com/dop251/goja"
 	"github.com/gorilla/websocket"
 
+	"go.k6.io/k6/js/abort"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 	httpModule "go.k6.io/k6/js/modules/k6/http"
@@ -105,6 +106,7 @@ type wsConnectArgs struct {
 	enableCompression bool
 	cookieJar         *cookiejar.Jar
 	tagsAndMeta       *metrics.TagsAndMeta
+	signal            *abort.Signal
 }
 
 const writeWait = 10 * time.Second
@@ -125,16 +127,25 @@ func (mi *WS) Connect(url string, args ...goja.Value) (*HTTPResponse, error) {
 		return nil, ErrWSInInitContext
 	}
 
-	parsedArgs, err := parseConnectArgs(state, rt, args...)
+	parsedArgs, err := parseConnectArgs(mi.vu, args...)
 	if err != nil {
 		return nil, err
 	}
+	signal := parsedArgs.signal
+	if signal.Aborted() {
+		panic(signal.Reason())
+	}
 
 	parsedArgs.tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagURL, url)
 
-	socket, httpResponse, connEndHook, err := mi.dial(ctx, state, rt, url, parsedArgs)
+	dialCtx, dialCancel := signal.Context(ctx)
+	defer dialCancel()
+	socket, httpResponse, connEndHook, err := mi.dial(dialCtx, state, rt, url, parsedArgs)
 	defer connEndHook()
 	if err != nil {
+		if signal.Aborted() {
+			panic(signal.Reason())
+		}
 		// Pass the error to the user script before exiting immediately
 		socket.handleEvent("error", rt.ToValue(err))
 		if state.Options.Throw.Bool {
@@ -236,6 +247,10 @@ func (mi *WS) Connect(url string, args ...goja.Value) (*HTTPResponse, error) {
 			// socket events will not be forwarded to the VU
 			_ = socket.closeConnection(websocket.CloseGoingAway)
 
+		case <-signal.Done():
+			// the connection was aborted, by the script or a timeout
+			_ = socket.closeConnection(websocket.CloseGoingAway)
+
 		case <-socket.done:
 			// This is the final exit point normally triggered by closeConnection
 			return wsResponse, nil
@@ -622,7 +637,8 @@ func wrapHTTPResponse(httpResponse *http.Response) (*HTTPResponse, error) {
 }
 
 //nolint:gocognit
-func parseConnectArgs(state *lib.State, rt *goja.Runtime, args ...goja.Value) (*wsConnectArgs, error) {
+func parseConnectArgs(vu modules.VU, args ...goja.Value) (*wsConnectArgs, error) {
+	state, rt := vu.State(), vu.Runtime()
 	// The params argument is optional
 	var callableV, paramsV goja.Value
 	switch len(args) {
@@ -699,6 +715,12 @@ func parseConnectArgs(state *lib.State, rt *goja.Runtime, args ...goja.Value) (*
 			}
 
 			parsedArgs.enableCompression = true
+		case "signal":
+			signal, err := abort.FromValue(vu, params.Get(k))
+			if err != nil {
+				return nil, fmt.Errorf("invalid ws.connect() signal: %w", err)
+			}
+			parsedArgs.signal = signal
 		}
 	}
 
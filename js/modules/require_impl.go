@@ -2,6 +2,7 @@ package modules
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 
@@ -9,6 +10,9 @@ import (
 	"go.k6.io/k6/loader"
 )
 
+const initContextOnlyMsg = `the "%s" function is only available in the init stage ` +
+	`(i.e. the global scope), see https://grafana.com/docs/k6/latest/using-k6/test-lifecycle/ for more information`
+
 // LegacyRequireImpl is a legacy implementation of `require()` that is not compatible with
 // CommonJS as it loads modules relative to the currently required file,
 // instead of relative to the file the `require()` is written in.
@@ -19,13 +23,17 @@ type LegacyRequireImpl struct {
 	currentlyRequiredModule *url.URL
 }
 
-// NewLegacyRequireImpl creates a new LegacyRequireImpl
+// NewLegacyRequireImpl creates a new LegacyRequireImpl.
+// The module system uses it for recording the currently required module, when
+// the modules are required relative to the files they are in.
 func NewLegacyRequireImpl(vu VU, ms *ModuleSystem, pwd url.URL) *LegacyRequireImpl {
-	return &LegacyRequireImpl{
+	r := &LegacyRequireImpl{
 		vu:                      vu,
 		modules:                 ms,
 		currentlyRequiredModule: &pwd,
 	}
+	ms.requireImpl = r
+	return r
 }
 
 // Require is the actual call that implements require
@@ -48,7 +56,7 @@ func (r *LegacyRequireImpl) Require(specifier string) (*goja.Object, error) {
 		// In theory we can give that downwards, but this makes the code more tightly coupled
 		// plus as explained above this will be removed in the future so the code reflects more
 		// closely what will be needed then
-		fileURL, err := loader.Resolve(r.currentlyRequiredModule, specifier)
+		fileURL, err := r.modules.resolver.resolveSpecifier(r.currentlyRequiredModule, specifier)
 		if err != nil {
 			return nil, err
 		}
@@ -67,3 +75,58 @@ func (r *LegacyRequireImpl) Require(specifier string) (*goja.Object, error) {
 func (r *LegacyRequireImpl) CurrentlyRequiredModule() url.URL {
 	return *r.currentlyRequiredModule
 }
+
+// scopedRequire returns the `require` function that is given to the module
+// with the given URL, which resolves the specifiers relative to the module
+// itself. Its `import` method is used for the dynamic imports in the module.
+func (ms *ModuleSystem) scopedRequire(moduleURL *url.URL) goja.Value {
+	rt := ms.vu.Runtime()
+	base := loader.Dir(moduleURL)
+	require := rt.ToValue(func(specifier string) (*goja.Object, error) {
+		if ms.vu.State() != nil {
+			return nil, fmt.Errorf(initContextOnlyMsg, "require")
+		}
+		instance, err := ms.requireFrom(base, specifier)
+		if err != nil {
+			return nil, err
+		}
+		return instance.exports(), nil
+	}).ToObject(rt)
+
+	dynamicImport := func(specifier string) (goja.Value, error) {
+		if ms.vu.State() != nil {
+			return nil, fmt.Errorf(initContextOnlyMsg, "import()")
+		}
+		instance, err := ms.requireFrom(base, specifier)
+		if err != nil {
+			return nil, err
+		}
+		p := pendingPromise(instance)
+		if p == nil {
+			return namespaceObject(rt, instance.exports()), nil
+		}
+		promise, resolve, reject := rt.NewPromise()
+		ms.then([]*goja.Promise{p}, func() { resolve(namespaceObject(rt, instance.exports())) }, reject)
+		return rt.ToValue(promise), nil
+	}
+	_ = require.Set("import", dynamicImport) // it can't fail, as require is a new function
+	return require
+}
+
+// namespaceObject returns the object that a dynamic import is resolved with
+// for the given exports. The same as in the static imports, the exports of a
+// CommonJS module are its default export.
+func namespaceObject(rt *goja.Runtime, exports *goja.Object) goja.Value {
+	if exports == nil {
+		return rt.ToValue(map[string]interface{}{"default": goja.Undefined()})
+	}
+	if esModule := exports.Get("__esModule"); esModule != nil && esModule.ToBoolean() {
+		return exports
+	}
+	namespace := rt.NewObject()
+	for _, key := range exports.Keys() {
+		_ = namespace.Set(key, exports.Get(key))
+	}
+	_ = namespace.Set("default", exports)
+	return namespace
+}
@@ -1,12 +1,16 @@
 package modules
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
+	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/dop251/goja"
 	"go.k6.io/k6/js/compiler"
+	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/loader"
 )
 
@@ -21,17 +25,37 @@ type moduleInstance interface {
 	execute() error
 	exports() *goja.Object
 }
+
+// moduleCacheElement is a module in the cache of the resolver, which is
+// loaded and compiled only once, by the first VU requiring it.
 type moduleCacheElement struct {
-	mod module
-	err error
+	once sync.Once
+	mod  module
+	err  error
 }
 
-// ModuleResolver knows how to get base Module that can be initialized
+// ModuleResolver knows how to get base Module that can be initialized. It's
+// shared by all the VUs of a test run, so the modules are loaded and compiled
+// only once, and the VUs only evaluate their compiled programs.
 type ModuleResolver struct {
-	cache     map[string]moduleCacheElement
+	// cacheMx guards the cache and goWrappers, the VUs can resolve modules
+	// concurrently, e.g. the ones required conditionally while they are
+	// initialized. It's held only to look up and to add the elements of the
+	// cache, the modules are loaded and compiled by their elements.
+	cacheMx   sync.Mutex
+	cache     map[string]*moduleCacheElement
 	goModules map[string]interface{}
 	loadCJS   FileLoader
 	compiler  *compiler.Compiler
+	// resolveURL is loader.Resolve, unless the node_modules are used
+	resolveURL func(pwd *url.URL, moduleSpecifier string) (*url.URL, error)
+	// goWrappers has the wrappers of the Go modules by their values, so a
+	// module registered with several names, e.g. with an experimental one
+	// too, has a single instance per VU, whichever name it's imported with
+	goWrappers map[Module]*goModule
+
+	asyncMx    sync.Mutex
+	asyncCache map[*cjsModule]bool
 }
 
 // NewModuleResolver returns a new module resolution instance that will resolve.
@@ -39,15 +63,24 @@ type ModuleResolver struct {
 // loadCJS is used to load commonjs files
 func NewModuleResolver(goModules map[string]interface{}, loadCJS FileLoader, c *compiler.Compiler) *ModuleResolver {
 	return &ModuleResolver{
-		goModules: goModules,
-		cache:     make(map[string]moduleCacheElement),
-		loadCJS:   loadCJS,
-		compiler:  c,
+		goModules:  goModules,
+		cache:      make(map[string]*moduleCacheElement),
+		goWrappers: make(map[Module]*goModule),
+		loadCJS:    loadCJS,
+		compiler:   c,
+		resolveURL: loader.Resolve,
+		asyncCache: make(map[*cjsModule]bool),
 	}
 }
 
+// UseNodeModules makes the resolver resolve the bare specifiers of the modules
+// (e.g. "lodash") from the node_modules directories in the given filesystem.
+func (mr *ModuleResolver) UseNodeModules(fs fsext.Fs) {
+	mr.resolveURL = loader.NewNodeModulesResolver(fs).Resolve
+}
+
 func (mr *ModuleResolver) resolveSpecifier(basePWD *url.URL, arg string) (*url.URL, error) {
-	specifier, err := loader.Resolve(basePWD, arg)
+	specifier, err := mr.resolveURL(basePWD, arg)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +93,17 @@ func (mr *ModuleResolver) requireModule(name string) (module, error) {
 		return nil, fmt.Errorf("unknown module: %s", name)
 	}
 	if m, ok := mod.(Module); ok {
-		return &goModule{Module: m}, nil
+		if !reflect.TypeOf(m).Comparable() {
+			return &goModule{Module: m}, nil
+		}
+		mr.cacheMx.Lock()
+		defer mr.cacheMx.Unlock()
+		if wrapper, ok := mr.goWrappers[m]; ok {
+			return wrapper, nil
+		}
+		wrapper := &goModule{Module: m}
+		mr.goWrappers[m] = wrapper
+		return wrapper, nil
 	}
 
 	return &baseGoModule{mod: mod}, nil
@@ -72,52 +115,95 @@ func (mr *ModuleResolver) resolveLoaded(basePWD *url.URL, arg string, data []byt
 		return nil, err
 	}
 	// try cache with the final specifier
-	if cached, ok := mr.cache[specifier.String()]; ok {
-		return cached.mod, cached.err
-	}
-
-	mod, err := cjsModuleFromString(specifier, data, mr.compiler)
-	mr.cache[specifier.String()] = moduleCacheElement{mod: mod, err: err}
-	return mod, err
+	return mr.cached(specifier.String(), func() (module, error) {
+		return cjsModuleFromString(specifier, data, mr.compiler)
+	})
 }
 
 func (mr *ModuleResolver) resolve(basePWD *url.URL, arg string) (module, error) {
-	if cached, ok := mr.cache[arg]; ok {
-		return cached.mod, cached.err
-	}
 	switch {
 	case arg == "k6", strings.HasPrefix(arg, "k6/"):
 		// Builtin or external modules ("k6", "k6/*", or "k6/x/*") are handled
 		// specially, as they don't exist on the filesystem.
-		mod, err := mr.requireModule(arg)
-		mr.cache[arg] = moduleCacheElement{mod: mod, err: err}
-		return mod, err
+		return mr.cached(arg, func() (module, error) {
+			return mr.requireModule(arg)
+		})
 	default:
 		specifier, err := mr.resolveSpecifier(basePWD, arg)
 		if err != nil {
 			return nil, err
 		}
-		// try cache with the final specifier
-		if cached, ok := mr.cache[specifier.String()]; ok {
-			return cached.mod, cached.err
-		}
+		// try cache with the final specifier, or fall back to loading
+		return mr.cached(specifier.String(), func() (module, error) {
+			data, err := mr.loadCJS(specifier, arg)
+			if err != nil {
+				return nil, err
+			}
+			return cjsModuleFromString(specifier, data, mr.compiler)
+		})
+	}
+}
+
+// cached returns the module with the given key from the cache. If it isn't
+// there yet, it's added and loaded with the load function. The VUs requiring
+// it at the same time wait for it instead of loading it too, but the other
+// modules can be resolved in the meantime.
+func (mr *ModuleResolver) cached(key string, load func() (module, error)) (module, error) {
+	mr.cacheMx.Lock()
+	element, ok := mr.cache[key]
+	if !ok {
+		element = &moduleCacheElement{}
+		mr.cache[key] = element
+	}
+	mr.cacheMx.Unlock()
+
+	element.once.Do(func() {
+		element.mod, element.err = load()
+	})
+	return element.mod, element.err
+}
+
+// isAsync returns whether the given module, or any of the modules that it
+// imports statically, uses top-level await. Such modules are evaluated only
+// after all of their imports are, as they would be as ES modules.
+func (mr *ModuleResolver) isAsync(mod *cjsModule) bool {
+	mr.asyncMx.Lock()
+	defer mr.asyncMx.Unlock()
+	return mr.hasTopLevelAwait(mod, make(map[*cjsModule]struct{}))
+}
+
+func (mr *ModuleResolver) hasTopLevelAwait(mod *cjsModule, visited map[*cjsModule]struct{}) bool {
+	if async, ok := mr.asyncCache[mod]; ok {
+		return async
+	}
+	if _, ok := visited[mod]; ok {
+		return false // a cycle, which is checked by the module that started it
+	}
+	visited[mod] = struct{}{}
 
-		// Fall back to loading
-		data, err := mr.loadCJS(specifier, arg)
+	async := mod.info.Async
+	base := loader.Dir(mod.url)
+	for _, specifier := range mod.info.Imports {
+		if async {
+			break
+		}
+		dep, err := mr.resolve(base, specifier)
 		if err != nil {
-			mr.cache[specifier.String()] = moduleCacheElement{err: err}
-			return nil, err
+			continue // the error is returned when the module is actually required
+		}
+		if cjs, ok := dep.(*cjsModule); ok {
+			async = mr.hasTopLevelAwait(cjs, visited)
 		}
-		mod, err := cjsModuleFromString(specifier, data, mr.compiler)
-		mr.cache[specifier.String()] = moduleCacheElement{mod: mod, err: err}
-
-		return mod, err
 	}
+	mr.asyncCache[mod] = async
+	return async
 }
 
 // Imported returns the list of imported and resolved modules.
 // Each string represents the path as used for importing.
 func (mr *ModuleResolver) Imported() []string {
+	mr.cacheMx.Lock()
+	defer mr.cacheMx.Unlock()
 	if len(mr.cache) < 1 {
 		return nil
 	}
@@ -133,6 +219,7 @@ type ModuleSystem struct {
 	vu            VU
 	instanceCache map[module]moduleInstance
 	resolver      *ModuleResolver
+	requireImpl   *LegacyRequireImpl
 }
 
 // NewModuleSystem returns a new ModuleSystem for the provide VU using the provided resoluter
@@ -146,26 +233,179 @@ func NewModuleSystem(resolver *ModuleResolver, vu VU) *ModuleSystem {
 
 // Require is called when a module/file needs to be loaded by a script
 func (ms *ModuleSystem) Require(pwd *url.URL, arg string) (*goja.Object, error) {
+	instance, err := ms.require(pwd, arg)
+	if err != nil {
+		return nil, err
+	}
+	return instance.exports(), nil
+}
+
+func (ms *ModuleSystem) require(pwd *url.URL, arg string) (moduleInstance, error) {
 	mod, err := ms.resolver.resolve(pwd, arg)
 	if err != nil {
 		return nil, err
 	}
 	if instance, ok := ms.instanceCache[mod]; ok {
-		return instance.exports(), nil
+		return instance, nil
 	}
 
 	instance := mod.instantiate(ms.vu)
 	ms.instanceCache[mod] = instance
+	if cjs, ok := instance.(*cjsModuleInstance); ok {
+		cjs.require = ms.scopedRequire(cjs.mod.url)
+		if ms.resolver.isAsync(cjs.mod) {
+			if err = ms.evaluateAsync(cjs); err != nil {
+				return nil, err
+			}
+			return instance, nil
+		}
+	}
 	if err = instance.execute(); err != nil {
 		return nil, err
 	}
 
-	return instance.exports(), nil
+	return instance, nil
+}
+
+// requireFrom requires the module with the given specifier relative to the
+// base, which is the directory of the module that imports it. The same as
+// with LegacyRequireImpl, the required module is recorded as the currently
+// required one while it's evaluated, for the `open` calls in it.
+func (ms *ModuleSystem) requireFrom(base *url.URL, specifier string) (moduleInstance, error) {
+	if specifier == "" {
+		return nil, errors.New("require() can't be used with an empty specifier")
+	}
+	if specifier == "k6" || strings.HasPrefix(specifier, "k6/") {
+		return ms.require(base, specifier)
+	}
+	fileURL, err := ms.resolver.resolveSpecifier(base, specifier)
+	if err != nil {
+		return nil, err
+	}
+	var instance moduleInstance
+	ms.withCurrentModule(loader.Dir(fileURL), func() {
+		instance, err = ms.require(base, specifier)
+	})
+	return instance, err
+}
+
+func (ms *ModuleSystem) withCurrentModule(pwd *url.URL, fn func()) {
+	if ms.requireImpl == nil {
+		fn()
+		return
+	}
+	previous := ms.requireImpl.currentlyRequiredModule
+	ms.requireImpl.currentlyRequiredModule = pwd
+	defer func() {
+		ms.requireImpl.currentlyRequiredModule = previous
+	}()
+	fn()
+}
+
+// evaluateAsync evaluates the module, which uses top-level await or imports a
+// module that does, after all of its static imports are evaluated. The module
+// instance keeps the promise for its evaluation, until it's fulfilled.
+func (ms *ModuleSystem) evaluateAsync(c *cjsModuleInstance) error {
+	if err := c.prepare(); err != nil {
+		return err
+	}
+
+	base := loader.Dir(c.mod.url)
+	var pending []*goja.Promise
+	for _, specifier := range c.mod.info.Imports {
+		dep, err := ms.requireFrom(base, specifier)
+		if err != nil {
+			return err
+		}
+		if p := pendingPromise(dep); p != nil {
+			pending = append(pending, p)
+		}
+	}
+
+	rt := ms.vu.Runtime()
+	promise, resolve, reject := rt.NewPromise()
+	evaluate := func() error {
+		var result goja.Value
+		var err error
+		ms.withCurrentModule(base, func() {
+			result, err = c.evaluate()
+		})
+		if err != nil {
+			return err
+		}
+		p, ok := result.Export().(*goja.Promise)
+		if !ok {
+			resolve(c.exports())
+			return nil
+		}
+		c.promise = promise
+		ms.then([]*goja.Promise{p}, func() { resolve(c.exports()) }, reject)
+		return nil
+	}
+	if len(pending) == 0 {
+		return evaluate() // there is nothing to wait for, so it's evaluated right away
+	}
+
+	c.promise = promise
+	ms.then(pending, func() {
+		if err := evaluate(); err != nil {
+			reject(rejectionReason(rt, err))
+		}
+	}, reject)
+	return nil
+}
+
+// then calls onFulfilled when all of the given promises are fulfilled, or
+// onRejected with the reason of the first one of them that is rejected.
+func (ms *ModuleSystem) then(promises []*goja.Promise, onFulfilled func(), onRejected func(interface{})) {
+	rt := ms.vu.Runtime()
+	remaining := len(promises)
+	rejected := false
+	for _, p := range promises {
+		thenFn, _ := goja.AssertFunction(rt.ToValue(p).ToObject(rt).Get("then"))
+		fulfilledCb := func() {
+			remaining--
+			if remaining == 0 && !rejected {
+				onFulfilled()
+			}
+		}
+		rejectedCb := func(reason goja.Value) {
+			if !rejected {
+				rejected = true
+				onRejected(reason)
+			}
+		}
+		if _, err := thenFn(rt.ToValue(p), rt.ToValue(fulfilledCb), rt.ToValue(rejectedCb)); err != nil {
+			rejectedCb(rejectionReason(rt, err))
+		}
+	}
+}
+
+// pendingPromise returns the promise for the evaluation of the module
+// instance, if it isn't fulfilled yet.
+func pendingPromise(instance moduleInstance) *goja.Promise {
+	c, ok := instance.(*cjsModuleInstance)
+	if !ok || c.promise == nil || c.promise.State() == goja.PromiseStateFulfilled {
+		return nil
+	}
+	return c.promise
+}
+
+// rejectionReason returns the value that a promise should be rejected with
+// for the given error.
+func rejectionReason(rt *goja.Runtime, err error) goja.Value {
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		return exception.Value()
+	}
+	return rt.NewGoError(err)
 }
 
 // RunSourceData runs the provided sourceData and adds it to the cache.
 // If a module with the same specifier as the source is already cached
 // it will be used instead of reevaluating the source from the provided SourceData.
+// If the module uses top-level await, a promise for its exports is returned,
+// which is settled when the event loop is done.
 //
 // TODO: this API will likely change as native ESM support will likely not let us have the exports
 // as one big goja.Value that we can manipulate
@@ -175,5 +415,12 @@ func (ms *ModuleSystem) RunSourceData(source *loader.SourceData) (goja.Value, er
 	if _, err := ms.resolver.resolveLoaded(pwd, specifier, source.Data); err != nil {
 		return nil, err // TODO wrap as this should never happen
 	}
-	return ms.Require(pwd, specifier)
+	instance, err := ms.require(pwd, specifier)
+	if err != nil {
+		return nil, err
+	}
+	if p := pendingPromise(instance); p != nil {
+		return ms.vu.Runtime().ToValue(p), nil
+	}
+	return instance.exports(), nil
 }
@@ -0,0 +1,55 @@
+package modules
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/compiler"
+	"go.k6.io/k6/lib/testutils"
+)
+
+func TestModuleResolverConcurrentLoads(t *testing.T) {
+	t.Parallel()
+
+	unblock := make(chan struct{})
+	var loads int64
+	loadCJS := func(specifier *url.URL, _ string) ([]byte, error) {
+		atomic.AddInt64(&loads, 1)
+		if specifier.Path == "/slow.js" {
+			<-unblock
+		}
+		return []byte(`exports.value = 1;`), nil
+	}
+	mr := NewModuleResolver(nil, loadCJS, compiler.New(testutils.NewLogger(t)))
+	base := &url.URL{Scheme: "file", Path: "/"}
+
+	var wg sync.WaitGroup
+	slow := make([]module, 5)
+	for i := range slow {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mod, err := mr.resolve(base, "./slow.js")
+			assert.NoError(t, err)
+			slow[i] = mod
+		}(i)
+	}
+
+	// the other modules are resolved while the slow one is still loading
+	fast, err := mr.resolve(base, "./fast.js")
+	require.NoError(t, err)
+	require.NotNil(t, fast)
+
+	close(unblock)
+	wg.Wait()
+	for _, mod := range slow {
+		assert.Same(t, slow[0], mod)
+	}
+	assert.Equal(t, int64(2), atomic.LoadInt64(&loads))
+	assert.ElementsMatch(t, []string{"file:///slow.js", "file:///fast.js"}, mr.Imported())
+}
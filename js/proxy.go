@@ -0,0 +1,51 @@
+package js
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/types"
+)
+
+// scenarioProxies are the proxies of the scenarios, created on their first use.
+type scenarioProxies struct {
+	mx         sync.Mutex
+	byScenario map[string]*netext.Proxy
+}
+
+func (sp *scenarioProxies) get(scenario string, settings types.Proxy) (*netext.Proxy, error) {
+	sp.mx.Lock()
+	defer sp.mx.Unlock()
+	if proxy, ok := sp.byScenario[scenario]; ok {
+		return proxy, nil
+	}
+	proxy, err := netext.NewProxy(settings)
+	if err != nil {
+		return nil, err
+	}
+	if sp.byScenario == nil {
+		sp.byScenario = make(map[string]*netext.Proxy)
+	}
+	sp.byScenario[scenario] = proxy
+	return proxy, nil
+}
+
+// proxyFor returns the function choosing the proxy of the requests of a VU,
+// whose own proxies are vuProxies. The requests of a scenario with a proxy go
+// through it, the others through the proxy of the environment variables.
+func (r *Runner) proxyFor(vuProxies *scenarioProxies) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		scenario := scenarioName(req.Context())
+		config, ok := r.Bundle.Options.Scenarios[scenario]
+		if !ok || config.GetProxy() == nil {
+			return http.ProxyFromEnvironment(req)
+		}
+		proxy, err := vuProxies.get(scenario, *config.GetProxy())
+		if err != nil {
+			return nil, err
+		}
+		return proxy.For(req)
+	}
+}
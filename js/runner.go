@@ -18,7 +18,6 @@ import (
 
 	"github.com/dop251/goja"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/net/http2"
 	"golang.org/x/time/rate"
 
 	"go.k6.io/k6/errext"
@@ -30,6 +29,7 @@ import (
 	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/netext/faults"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/loader"
 	"go.k6.io/k6/metrics"
@@ -44,6 +44,10 @@ var _ lib.Runner = &Runner{}
 //nolint:gochecknoglobals
 var nameToCertWarning sync.Once
 
+// eventLoopLagInterval is how often each VU emits the longest lag of its event
+// loop, when the health metrics of the load generator are enabled.
+const eventLoopLagInterval = time.Second
+
 // Runner implements [lib.Runner] and is used to run js tests
 type Runner struct {
 	Bundle       *Bundle
@@ -57,9 +61,16 @@ type Runner struct {
 	RPSLimit       *rate.Limiter
 	RunTags        *metrics.TagSet
 
-	console    *console
-	setupData  []byte
-	BufferPool *lib.BufferPool
+	console        *console
+	failureCapture *lib.FailureCapture
+	setupData      []byte
+	BufferPool     *lib.BufferPool
+
+	scenarioSetupDataMx sync.RWMutex
+	scenarioSetupData   map[string][]byte
+
+	// the limiters of the bandwidth of all VUs of each scenario together
+	scenarioBandwidth bandwidthLimiters
 }
 
 // New returns a new Runner for the provided source
@@ -147,18 +158,14 @@ func (r *Runner) newVU(
 		tlsVersions = *r.Bundle.Options.TLSVersion
 	}
 
-	tlsAuth := r.Bundle.Options.TLSAuth
-	certs := make([]tls.Certificate, len(tlsAuth))
-	nameToCert := make(map[string]*tls.Certificate)
-	for i, auth := range tlsAuth {
-		cert, errC := auth.Certificate()
-		if errC != nil {
-			return nil, errC
-		}
-		certs[i] = *cert
-		for _, name := range auth.Domains {
-			nameToCert[name] = cert
-		}
+	var ipIndex uint64
+	if idLocal > 0 {
+		ipIndex = idLocal - 1
+	}
+
+	certs, nameToCert, namedCerts, err := vuCertificates(r.Bundle.Options.TLSAuth, ipIndex)
+	if err != nil {
+		return nil, err
 	}
 
 	dialer := &netext.Dialer{
@@ -168,13 +175,11 @@ func (r *Runner) newVU(
 		BlockedHostnames: r.Bundle.Options.BlockedHostnames.Trie,
 		Hosts:            r.Bundle.Options.Hosts.Trie,
 	}
+	dialer.BandwidthLimiters = r.bandwidthLimitersFor(&bandwidthLimiters{})
 	if r.Bundle.Options.LocalIPs.Valid {
-		var ipIndex uint64
-		if idLocal > 0 {
-			ipIndex = idLocal - 1
-		}
 		dialer.Dialer.LocalAddr = &net.TCPAddr{IP: r.Bundle.Options.LocalIPs.Pool.GetIP(ipIndex)}
 	}
+	dialer.LocalIP = r.scenarioLocalIPFor(ipIndex)
 
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: r.Bundle.Options.InsecureSkipTLSVerify.Bool, //nolint:gosec
@@ -185,6 +190,12 @@ func (r *Runner) newVU(
 		Renegotiation:      tls.RenegotiateFreelyAsClient,
 		KeyLogWriter:       r.preInitState.KeyLogger,
 	}
+	if r.Bundle.Options.TLSCurves != nil {
+		tlsConfig.CurvePreferences = *r.Bundle.Options.TLSCurves
+	}
+	if r.Bundle.Options.TLSSessionResumption.Bool {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
 	// Follow NameToCertificate in https://pkg.go.dev/crypto/tls@go1.17.6#Config, leave this field nil
 	// when it is empty
 	if len(nameToCert) > 0 {
@@ -198,20 +209,54 @@ func (r *Runner) newVU(
 		//nolint:staticcheck // ignore SA1019 we can deprecate it but we have to continue to support the previous code.
 		tlsConfig.NameToCertificate = nameToCert
 	}
-	transport := &http.Transport{
-		Proxy:               http.ProxyFromEnvironment,
-		TLSClientConfig:     tlsConfig,
-		DialContext:         dialer.DialContext,
-		DisableCompression:  true,
-		DisableKeepAlives:   r.Bundle.Options.NoConnectionReuse.Bool,
-		MaxIdleConns:        int(r.Bundle.Options.Batch.Int64),
-		MaxIdleConnsPerHost: int(r.Bundle.Options.BatchPerHost.Int64),
-	}
+	proxy := r.proxyFor(&scenarioProxies{})
+	http2Errors := &http2ErrorCounter{
+		ctx:            ctx,
+		samples:        samplesOut,
+		builtinMetrics: r.preInitState.BuiltinMetrics,
+	}
+	newTransport := func(tlsConfig *tls.Config, http2Settings *types.HTTP2) *http.Transport {
+		transport := &http.Transport{
+			Proxy:               proxy,
+			TLSClientConfig:     tlsConfig,
+			DialContext:         dialer.DialContext,
+			DisableCompression:  true,
+			DisableKeepAlives:   r.Bundle.Options.NoConnectionReuse.Bool,
+			MaxIdleConns:        int(r.Bundle.Options.Batch.Int64),
+			MaxIdleConnsPerHost: int(r.Bundle.Options.BatchPerHost.Int64),
+		}
 
-	if r.forceHTTP1() {
-		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper) // send over h1 protocol
-	} else {
-		_ = http2.ConfigureTransport(transport) // send over h2 protocol
+		if r.forceHTTP1() {
+			transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper) // send over h1 protocol
+		} else {
+			configureHTTP2(transport, http2Settings, http2Errors.count) // send over h2 protocol
+		}
+		return transport
+	}
+	// The requests overriding the client certificate or the server name get
+	// transports of their own, made like the one of the VU.
+	newTLSTransport := func(http2Settings *types.HTTP2) *tlsOverrideTransport {
+		return &tlsOverrideTransport{
+			base:      newTransport(tlsConfig, http2Settings),
+			tlsConfig: tlsConfig,
+			certs:     namedCerts,
+			newTransport: func(tlsConfig *tls.Config) *http.Transport {
+				return newTransport(tlsConfig, http2Settings)
+			},
+		}
+	}
+	tlsTransport := newTLSTransport(nil)
+	// So do the requests of the scenarios with HTTP/2 settings.
+	scenariosTransport := &http2Transport{
+		base:      tlsTransport,
+		scenarios: r.Bundle.Options.Scenarios,
+		newTransport: func(http2Settings *types.HTTP2) *scenarioHTTP2Transport {
+			transport := &scenarioHTTP2Transport{tls: newTLSTransport(http2Settings), settings: *http2Settings}
+			if http2Settings.H2C && !r.forceHTTP1() {
+				transport.h2c = newH2CTransport(dialer.DialContext, http2Settings, http2Errors.count)
+			}
+			return transport
+		},
 	}
 
 	cookieJar, err := cookiejar.New(nil)
@@ -220,25 +265,35 @@ func (r *Runner) newVU(
 	}
 
 	vu := &VU{
-		ID:             idLocal,
-		IDGlobal:       idGlobal,
-		iteration:      int64(-1),
-		BundleInstance: *bi,
-		Runner:         r,
-		Transport:      transport,
-		Dialer:         dialer,
-		CookieJar:      cookieJar,
-		TLSConfig:      tlsConfig,
-		Console:        r.console,
-		BufferPool:     r.BufferPool,
-		Samples:        samplesOut,
-		scenarioIter:   make(map[string]uint64),
+		ID:                idLocal,
+		IDGlobal:          idGlobal,
+		iteration:         int64(-1),
+		BundleInstance:    *bi,
+		Runner:            r,
+		Transport:         tlsTransport.base,
+		tlsTransport:      tlsTransport,
+		http2Transport:    scenariosTransport,
+		Dialer:            dialer,
+		CookieJar:         cookieJar,
+		TLSConfig:         tlsConfig,
+		Console:           r.console,
+		BufferPool:        r.BufferPool,
+		Samples:           samplesOut,
+		scenarioIter:      make(map[string]uint64),
+		scenarioSetupData: make(map[string]goja.Value),
+	}
+
+	// The faults are injected in the requests of the VU, like if the network
+	// was degraded, while the connections are still managed by vu.Transport.
+	var vuTransport http.RoundTripper = vu.http2Transport
+	if len(r.Bundle.Options.Faults) > 0 {
+		vuTransport = faults.NewTransport(vu.http2Transport, r.Bundle.Options.Faults, scenarioName)
 	}
 
 	vu.state = &lib.State{
 		Logger:         vu.Runner.preInitState.Logger,
 		Options:        vu.Runner.Bundle.Options,
-		Transport:      vu.Transport,
+		Transport:      vuTransport,
 		Dialer:         vu.Dialer,
 		TLSConfig:      vu.TLSConfig,
 		CookieJar:      cookieJar,
@@ -251,8 +306,10 @@ func (r *Runner) newVU(
 		Group:          r.defaultGroup,
 		BuiltinMetrics: r.preInitState.BuiltinMetrics,
 		TracerProvider: r.preInitState.TracerProvider,
+		FailureCapture: r.failureCapture,
 	}
 	vu.moduleVUImpl.state = vu.state
+	http2Errors.tags = vu.state.Tags
 	_ = vu.Runtime.Set("console", vu.Console)
 
 	// This is here mostly so if someone tries they get a nice message
@@ -265,6 +322,14 @@ func (r *Runner) newVU(
 	return vu, nil
 }
 
+// scenarioName returns the name of the scenario of the context, if any.
+func scenarioName(ctx context.Context) string {
+	if ss := lib.GetScenarioState(ctx); ss != nil {
+		return ss.Name
+	}
+	return ""
+}
+
 // forceHTTP1 checks if force http1 env variable has been set in order to force requests to be sent over h1
 // TODO: This feature is temporary until #936 is resolved
 func (r *Runner) forceHTTP1() bool {
@@ -295,25 +360,44 @@ func (r *Runner) Setup(ctx context.Context, out chan<- metrics.SampleContainer)
 	}
 	r.preInitState.Logger.Debugf("Running %s()...", consts.SetupFn)
 
-	setupCtx, setupCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.SetupFn))
-	defer setupCancel()
-
-	v, err := r.runPart(setupCtx, out, consts.SetupFn, nil)
+	v, err := r.runPart(ctx, out, consts.SetupFn, r.getTimeoutFor(consts.SetupFn), nil)
 	if err != nil {
 		return err
 	}
 	// r.setupData = nil is special it means undefined from this moment forward
+	r.setupData, err = marshalSetupData(consts.SetupFn, v)
+	return err
+}
+
+// marshalSetupData returns the JSON representation of the value returned by a
+// setup function, or nil if it returned undefined.
+func marshalSetupData(fnName string, v goja.Value) ([]byte, error) {
 	if goja.IsUndefined(v) {
-		r.setupData = nil
-		return nil
+		return nil, nil
 	}
 
-	r.setupData, err = json.Marshal(v.Export())
+	data, err := json.Marshal(v.Export())
 	if err != nil {
-		return fmt.Errorf("error marshaling setup() data to JSON: %w", err)
+		return nil, fmt.Errorf("error marshaling %s() data to JSON: %w", fnName, err)
 	}
 	var tmp interface{}
-	return json.Unmarshal(r.setupData, &tmp)
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// unmarshalSetupData is the inverse of marshalSetupData, it returns undefined
+// for nil data.
+func unmarshalSetupData(fnName string, data []byte) (interface{}, error) {
+	if data == nil {
+		return goja.Undefined(), nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("error unmarshaling setup data for %s() from JSON: %w", fnName, err)
+	}
+	return v, nil
 }
 
 // GetSetupData returns the setup data as json if Setup() was specified and executed, nil otherwise
@@ -336,21 +420,108 @@ func (r *Runner) Teardown(ctx context.Context, out chan<- metrics.SampleContaine
 	}
 	r.preInitState.Logger.Debugf("Running %s()...", consts.TeardownFn)
 
-	teardownCtx, teardownCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.TeardownFn))
-	defer teardownCancel()
+	data, err := unmarshalSetupData(consts.TeardownFn, r.setupData)
+	if err != nil {
+		return err
+	}
+	_, err = r.runPart(ctx, out, consts.TeardownFn, r.getTimeoutFor(consts.TeardownFn), data)
+	return err
+}
+
+// ScenarioSetup runs the setup function of the given scenario, if it has one.
+// It receives the global setup data and its result is passed to the
+// iterations and the teardown function of the scenario.
+func (r *Runner) ScenarioSetup(
+	ctx context.Context, scenario string, out chan<- metrics.SampleContainer,
+) ([]byte, error) {
+	lifecycle := r.getScenarioLifecycle(scenario)
+	if lifecycle.Setup == "" {
+		return nil, nil
+	}
+	r.preInitState.Logger.Debugf("Running %s() for scenario %s...", lifecycle.Setup, scenario)
 
-	var data interface{}
-	if r.setupData != nil {
-		if err := json.Unmarshal(r.setupData, &data); err != nil {
-			return fmt.Errorf("error unmarshaling setup data for teardown() from JSON: %w", err)
-		}
-	} else {
-		data = goja.Undefined()
+	arg, err := unmarshalSetupData(lifecycle.Setup, r.setupData)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := lifecycle.SetupTimeout
+	if timeout == 0 {
+		timeout = r.getTimeoutFor(consts.SetupFn)
+	}
+	v, err := r.runPart(ctx, out, lifecycle.Setup, timeout, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := marshalSetupData(lifecycle.Setup, v)
+	if err != nil {
+		return nil, err
+	}
+	r.SetScenarioSetupData(scenario, data)
+	return data, nil
+}
+
+// SetScenarioSetupData saves the externally supplied setup data of a scenario
+// as json in the runner, so it can be used by the VUs running that scenario.
+func (r *Runner) SetScenarioSetupData(scenario string, data []byte) {
+	r.scenarioSetupDataMx.Lock()
+	defer r.scenarioSetupDataMx.Unlock()
+
+	if r.scenarioSetupData == nil {
+		r.scenarioSetupData = make(map[string][]byte)
+	}
+	r.scenarioSetupData[scenario] = data
+}
+
+// getScenarioSetupData returns the setup data of the given scenario and
+// whether it actually had any, i.e. if its setup function was executed.
+func (r *Runner) getScenarioSetupData(scenario string) ([]byte, bool) {
+	r.scenarioSetupDataMx.RLock()
+	defer r.scenarioSetupDataMx.RUnlock()
+
+	data, ok := r.scenarioSetupData[scenario]
+	return data, ok
+}
+
+// getSetupDataFor returns the setup data that should be used by the given
+// scenario - its own, if it had a setup function, or the global one otherwise.
+func (r *Runner) getSetupDataFor(scenario string) []byte {
+	if data, ok := r.getScenarioSetupData(scenario); ok {
+		return data
 	}
-	_, err := r.runPart(teardownCtx, out, consts.TeardownFn, data)
+	return r.setupData
+}
+
+// ScenarioTeardown runs the teardown function of the given scenario, if it has one.
+func (r *Runner) ScenarioTeardown(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error {
+	lifecycle := r.getScenarioLifecycle(scenario)
+	if lifecycle.Teardown == "" {
+		return nil
+	}
+	r.preInitState.Logger.Debugf("Running %s() for scenario %s...", lifecycle.Teardown, scenario)
+
+	data, err := unmarshalSetupData(lifecycle.Teardown, r.getSetupDataFor(scenario))
+	if err != nil {
+		return err
+	}
+
+	timeout := lifecycle.TeardownTimeout
+	if timeout == 0 {
+		timeout = r.getTimeoutFor(consts.TeardownFn)
+	}
+	_, err = r.runPart(ctx, out, lifecycle.Teardown, timeout, data)
 	return err
 }
 
+func (r *Runner) getScenarioLifecycle(scenario string) lib.ScenarioLifecycle {
+	conf, ok := r.Bundle.Options.Scenarios[scenario]
+	if !ok {
+		return lib.ScenarioLifecycle{}
+	}
+	return conf.GetLifecycle()
+}
+
 // GetDefaultGroup returns the default (root) Group.
 func (r *Runner) GetDefaultGroup() *lib.Group {
 	return r.defaultGroup
@@ -380,7 +551,8 @@ func (r *Runner) HandleSummary(ctx context.Context, summary *lib.Summary) (map[s
 		}
 	}()
 
-	summaryCtx, cancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.HandleSummaryFn))
+	summaryTimeout := r.getTimeoutFor(consts.HandleSummaryFn)
+	summaryCtx, cancel := context.WithTimeout(ctx, summaryTimeout)
 	defer cancel()
 
 	vu, err := r.newVU(summaryCtx, 0, 0, out)
@@ -402,10 +574,18 @@ func (r *Runner) HandleSummary(ctx context.Context, summary *lib.Summary) (map[s
 			return nil, fmt.Errorf("exported identifier %s must be a function", consts.HandleSummaryFn)
 		}
 
-		callbackResult, _, _, err = vu.runFn(summaryCtx, false, handleSummaryFn, nil, vu.Runtime.ToValue(summaryDataForJS))
+		// the summary wrapper runs on the same event loop after the callback, so any
+		// timers or other async work left by a failed callback are stopped first
+		callbackCtx, callbackCancel := context.WithCancel(summaryCtx)
+		vu.moduleVUImpl.ctx = callbackCtx
+		callbackResult, _, _, err = vu.runFn(
+			callbackCtx, false, handleSummaryFn, callbackCancel, vu.Runtime.ToValue(summaryDataForJS))
+		vu.moduleVUImpl.ctx = summaryCtx
 		if err != nil {
 			errText, fields := errext.Format(err)
 			r.preInitState.Logger.WithFields(fields).Error(errText)
+			// the result of a rejected promise is its reason, not a summary
+			callbackResult = goja.Undefined()
 		}
 	}
 
@@ -419,14 +599,17 @@ func (r *Runner) HandleSummary(ctx context.Context, summary *lib.Summary) (map[s
 		return nil, fmt.Errorf("unexpected error did not get a callable summary wrapper")
 	}
 
+	// the other targets of the summary export are reports, generated outside
+	jsonSummaryPaths, _ := r.Bundle.preInitState.RuntimeOptions.SummaryExportTargets()
 	wrapperArgs := []goja.Value{
 		callbackResult,
-		vu.Runtime.ToValue(r.Bundle.preInitState.RuntimeOptions.SummaryExport.String),
+		vu.Runtime.ToValue(jsonSummaryPaths),
 		vu.Runtime.ToValue(summaryDataForJS),
 	}
 	rawResult, _, _, err := vu.runFn(summaryCtx, false, handleSummaryWrapper, nil, wrapperArgs...)
 
-	if deadlineError := r.checkDeadline(summaryCtx, consts.HandleSummaryFn, rawResult, err); deadlineError != nil {
+	deadlineError := checkDeadline(summaryCtx, consts.HandleSummaryFn, summaryTimeout, rawResult, err)
+	if deadlineError != nil {
 		return nil, deadlineError
 	}
 
@@ -436,7 +619,7 @@ func (r *Runner) HandleSummary(ctx context.Context, summary *lib.Summary) (map[s
 	return getSummaryResult(rawResult)
 }
 
-func (r *Runner) checkDeadline(ctx context.Context, name string, result goja.Value, err error) error {
+func checkDeadline(ctx context.Context, name string, timeout time.Duration, result goja.Value, err error) error {
 	if deadline, ok := ctx.Deadline(); !(ok && time.Now().After(deadline)) {
 		return nil
 	}
@@ -449,7 +632,7 @@ func (r *Runner) checkDeadline(ctx context.Context, name string, result goja.Val
 		return err
 	}
 	// otherwise we have timeouted
-	return newTimeoutError(name, r.getTimeoutFor(name))
+	return newTimeoutError(name, timeout)
 }
 
 // SetOptions sets the test Options to the provided data and makes necessary changes to the Runner.
@@ -479,6 +662,11 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 		r.console = c
 	}
 
+	r.failureCapture = nil
+	if opts.CaptureFailures.String != "" {
+		r.failureCapture = lib.NewFailureCapture(opts.CaptureFailures.String, opts)
+	}
+
 	// FIXME: Resolver probably shouldn't be reset here...
 	// It's done because the js.Runner is created before the full
 	// configuration has been processed, at which point we don't have
@@ -541,14 +729,16 @@ func parseTTL(ttlS string) (time.Duration, error) {
 }
 
 // Runs an exported function in its own temporary VU, optionally with an argument. Execution is
-// interrupted if the context expires. No error is returned if the part does not exist.
+// interrupted if the context expires or the timeout is reached. No error is returned if the part
+// does not exist.
 func (r *Runner) runPart(
 	parentCtx context.Context,
 	out chan<- metrics.SampleContainer,
 	name string,
+	timeout time.Duration,
 	arg interface{},
 ) (goja.Value, error) {
-	ctx, cancel := context.WithCancel(parentCtx)
+	ctx, cancel := context.WithTimeout(parentCtx, timeout)
 	defer cancel()
 
 	vu, err := r.newVU(ctx, 0, 0, out)
@@ -579,7 +769,7 @@ func (r *Runner) runPart(
 	vu.state.Group = group
 	v, _, _, err := vu.runFn(ctx, false, fn, nil, vu.Runtime.ToValue(arg))
 
-	if deadlineError := r.checkDeadline(ctx, name, v, err); deadlineError != nil {
+	if deadlineError := checkDeadline(ctx, name, timeout, v, err); deadlineError != nil {
 		return nil, deadlineError
 	}
 
@@ -631,22 +821,44 @@ type VU struct {
 	IDGlobal  uint64 // global across all instances
 	iteration int64
 
+	// sends the requests overriding their TLS settings through transports
+	// of their own, and the others through Transport
+	tlsTransport *tlsOverrideTransport
+	// sends the requests of the scenarios with HTTP/2 settings through
+	// transports of their own, and the others through tlsTransport
+	http2Transport *http2Transport
+
 	Console    *console
 	BufferPool *lib.BufferPool
 
 	Samples chan<- metrics.SampleContainer
 
 	setupData goja.Value
+	// unmarshalled setup data of the scenarios with their own setup function
+	scenarioSetupData map[string]goja.Value
+
+	// hasRun is set on the first iteration of the VU, when vuSetup() is called
+	// and its result is saved in vuData so it can be passed to iterations, or
+	// its error in vuSetupErr so all the iterations of the VU fail with it
+	hasRun     bool
+	vuData     goja.Value
+	vuSetupErr error
 
 	state *lib.State
 	// count of iterations executed by this VU in each scenario
 	scenarioIter map[string]uint64
+
+	// the longest lag of the event loop in the iterations since the last
+	// event_loop_lag sample, and the time of that sample
+	eventLoopMaxLag     time.Duration
+	eventLoopLagEmitted time.Time
 }
 
 // Verify that interfaces are implemented
 var (
-	_ lib.ActiveVU      = &ActiveVU{}
-	_ lib.InitializedVU = &VU{}
+	_ lib.ActiveVU       = &ActiveVU{}
+	_ lib.InitializedVU  = &VU{}
+	_ lib.TeardownableVU = &VU{}
 )
 
 // ActiveVU holds a VU and its activation parameters
@@ -658,6 +870,10 @@ type ActiveVU struct {
 	scenarioName              string
 	getNextIterationCounters  func() (uint64, uint64)
 	scIterLocal, scIterGlobal uint64
+
+	// the live tags that were last applied to the VU tags and their version
+	liveTags        map[string]string
+	liveTagsVersion uint64
 }
 
 // GetID returns the unique VU ID.
@@ -744,6 +960,38 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	return avu
 }
 
+// applyLiveTags updates the VU tags with the live tags of the test run, if
+// they were changed since the last iteration of the VU. Tags that were removed
+// from the live ones are restored to their values for the scenario, if any.
+func (u *ActiveVU) applyLiveTags() {
+	es := lib.GetExecutionState(u.RunContext)
+	if es == nil || es.LiveConfig == nil {
+		return
+	}
+	tags, version := es.LiveConfig.GetTags()
+	if version == u.liveTagsVersion {
+		return
+	}
+
+	scenarioTags := u.Runner.RunTags.WithTagsFromMap(u.VUActivationParams.Tags)
+	u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+		for k := range u.liveTags {
+			if _, ok := tags[k]; ok {
+				continue
+			}
+			if v, ok := scenarioTags.Get(k); ok {
+				tagsAndMeta.SetTag(k, v)
+			} else {
+				tagsAndMeta.DeleteTag(k)
+			}
+		}
+		for k, v := range tags {
+			tagsAndMeta.SetTag(k, v)
+		}
+	})
+	u.liveTags, u.liveTagsVersion = tags, version
+}
+
 // RunOnce runs the configured Exec function once.
 func (u *ActiveVU) RunOnce() error {
 	select {
@@ -756,18 +1004,9 @@ func (u *ActiveVU) RunOnce() error {
 		<-u.busy // unlock deactivation again
 	}()
 
-	// Unmarshall the setupData only the first time for each VU so that VUs are isolated but we
-	// still don't use too much CPU in the middle test
-	if u.setupData == nil {
-		if u.Runner.setupData != nil {
-			var data interface{}
-			if err := json.Unmarshal(u.Runner.setupData, &data); err != nil {
-				return fmt.Errorf("error unmarshaling setup data for the iteration from JSON: %w", err)
-			}
-			u.setupData = u.Runtime.ToValue(data)
-		} else {
-			u.setupData = goja.Undefined()
-		}
+	setupData, err := u.getSetupData()
+	if err != nil {
+		return err
 	}
 
 	fn := u.getCallableExport(u.Exec)
@@ -776,6 +1015,18 @@ func (u *ActiveVU) RunOnce() error {
 		panic(fmt.Sprintf("function '%s' not found in exports", u.Exec))
 	}
 
+	if !u.hasRun {
+		u.hasRun = true
+		u.vuSetupErr = u.runVUSetup()
+	}
+	if u.vuSetupErr != nil {
+		return u.vuSetupErr
+	}
+	args := []goja.Value{setupData}
+	if u.Runner.IsExecutable(consts.VUSetupFn) {
+		args = append(args, u.vuData)
+	}
+
 	u.incrIteration()
 	if err := u.Runtime.Set("__ITER", u.iteration); err != nil {
 		panic(fmt.Errorf("error setting __ITER in goja runtime: %w", err))
@@ -793,8 +1044,10 @@ func (u *ActiveVU) RunOnce() error {
 
 	u.emitAndWaitEvent(&event.Event{Type: event.IterStart, Data: eventIterData})
 
+	u.applyLiveTags()
+
 	// Call the exported function.
-	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, u.setupData)
+	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, args...)
 	if err != nil {
 		var x *goja.InterruptedError
 		if errors.As(err, &x) {
@@ -823,6 +1076,104 @@ func (u *ActiveVU) RunOnce() error {
 	return err
 }
 
+// runVUSetup calls the exported vuSetup() function, if there is one, and saves
+// its result, so it can be passed to all iterations and to vuTeardown(). It's
+// executed with the setupTimeout.
+func (u *ActiveVU) runVUSetup() error {
+	u.vuData = goja.Undefined()
+	fn := u.getCallableExport(consts.VUSetupFn)
+	if fn == nil {
+		return nil
+	}
+
+	timeout := u.Runner.getTimeoutFor(consts.SetupFn)
+	ctx, cancel := context.WithTimeout(u.RunContext, timeout)
+	defer cancel()
+	u.moduleVUImpl.ctx = ctx
+
+	// the runtime is only interrupted if vuSetup() doesn't return in time,
+	// since the VU runs its iterations with it afterwards
+	done, interrupted := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(interrupted)
+		select {
+		case <-ctx.Done():
+			u.Runtime.Interrupt(context.Canceled)
+		case <-done:
+		}
+	}()
+	v, _, _, err := u.runFn(ctx, false, fn, nil)
+	close(done)
+	<-interrupted
+
+	if deadlineError := checkDeadline(ctx, consts.VUSetupFn, timeout, v, err); deadlineError != nil {
+		return deadlineError
+	}
+	if err != nil {
+		return err
+	}
+	u.vuData = v
+	return nil
+}
+
+// Teardown calls the exported vuTeardown() function with the result of
+// vuSetup(), if the VU was actually used in the test run. It's executed with
+// the teardownTimeout, with a new context, since the activation one is done.
+func (u *VU) Teardown(ctx context.Context) error {
+	fn := u.getCallableExport(consts.VUTeardownFn)
+	if !u.hasRun || u.vuSetupErr != nil || fn == nil {
+		return nil
+	}
+
+	timeout := u.Runner.getTimeoutFor(consts.TeardownFn)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	u.Runtime.ClearInterrupt()
+	go func() {
+		<-ctx.Done()
+		u.Runtime.Interrupt(context.Canceled)
+	}()
+	u.moduleVUImpl.ctx = ctx
+
+	v, _, _, err := u.runFn(ctx, false, fn, nil, u.vuData)
+	if deadlineError := checkDeadline(ctx, consts.VUTeardownFn, timeout, v, err); deadlineError != nil {
+		return deadlineError
+	}
+	return err
+}
+
+// getSetupData unmarshalls the setupData only the first time for each VU (and
+// each scenario with its own setup function) so that VUs are isolated but we
+// still don't use too much CPU in the middle test.
+func (u *ActiveVU) getSetupData() (goja.Value, error) {
+	rawData, hasOwn := u.Runner.getScenarioSetupData(u.scenarioName)
+	if !hasOwn {
+		if u.setupData != nil {
+			return u.setupData, nil
+		}
+		rawData = u.Runner.setupData
+	} else if data, ok := u.scenarioSetupData[u.scenarioName]; ok {
+		return data, nil
+	}
+
+	data := goja.Undefined()
+	if rawData != nil {
+		var v interface{}
+		if err := json.Unmarshal(rawData, &v); err != nil {
+			return nil, fmt.Errorf("error unmarshaling setup data for the iteration from JSON: %w", err)
+		}
+		data = u.Runtime.ToValue(v)
+	}
+
+	if hasOwn {
+		u.scenarioSetupData[u.scenarioName] = data
+	} else {
+		u.setupData = data
+	}
+	return data, nil
+}
+
 func (u *ActiveVU) emitAndWaitEvent(evt *event.Event) {
 	waitDone := u.moduleVUImpl.events.local.Emit(evt)
 	waitCtx, waitCancel := context.WithTimeout(u.RunContext, 30*time.Minute)
@@ -856,6 +1207,11 @@ func (u *VU) runFn(
 		})
 	}
 
+	u.state.IterationContext = lib.NewIterationContext()
+	u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+		tagsAndMeta.SetMetadata("correlation_id", u.state.IterationContext.CorrelationID)
+	})
+
 	startTime := time.Now()
 
 	if u.moduleVUImpl.eventLoop == nil {
@@ -886,18 +1242,52 @@ func (u *VU) runFn(
 	}
 
 	if u.Runner.Bundle.Options.NoVUConnectionReuse.Bool {
-		u.Transport.CloseIdleConnections()
+		u.http2Transport.CloseIdleConnections()
 	}
 
-	u.state.Samples <- u.Dialer.GetTrail(
+	ctm := u.state.Tags.GetCurrentValues()
+	trail := u.Dialer.GetTrail(
 		startTime, endTime, isFullIteration,
-		isDefault, u.state.Tags.GetCurrentValues(), u.Runner.preInitState.BuiltinMetrics)
+		isDefault, ctm, u.Runner.preInitState.BuiltinMetrics)
+	if isFullIteration && isDefault && u.Runner.Bundle.Options.GeneratorMetrics.Bool {
+		if sample, ok := u.eventLoopLagSample(endTime, ctm); ok {
+			trail.Samples = append(trail.Samples, sample)
+		}
+	}
+	u.state.Samples <- trail
+
+	u.state.IterationContext = nil
+	u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+		tagsAndMeta.DeleteMetadata("correlation_id")
+	})
 
 	v = unPromisify(v)
 
 	return v, isFullIteration, endTime.Sub(startTime), err
 }
 
+// eventLoopLagSample keeps track of the longest lag of the event loop of the
+// VU in its iterations and returns it as a sample every eventLoopLagInterval.
+func (u *VU) eventLoopLagSample(now time.Time, ctm metrics.TagsAndMeta) (metrics.Sample, bool) {
+	if lag := u.moduleVUImpl.eventLoop.MaxLag(); lag > u.eventLoopMaxLag {
+		u.eventLoopMaxLag = lag
+	}
+	if now.Sub(u.eventLoopLagEmitted) < eventLoopLagInterval {
+		return metrics.Sample{}, false
+	}
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: u.Runner.preInitState.BuiltinMetrics.EventLoopLag,
+			Tags:   ctm.Tags,
+		},
+		Time:     now,
+		Metadata: ctm.Metadata,
+		Value:    metrics.D(u.eventLoopMaxLag),
+	}
+	u.eventLoopMaxLag, u.eventLoopLagEmitted = 0, now
+	return sample, true
+}
+
 func (u *ActiveVU) incrIteration() {
 	u.iteration++
 	u.state.Iteration = u.iteration
@@ -493,6 +493,131 @@ func TestSetupDataNoSetup(t *testing.T) {
 	};`)
 }
 
+func TestVUSetupTeardown(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	exports.options = { setupTimeout: "1s", teardownTimeout: "1s" };
+	var vuSetupCalls = 0;
+	var iterations = 0;
+	exports.setup = function() {
+		return 42;
+	}
+	exports.vuSetup = function() {
+		vuSetupCalls++;
+		return { vu: __VU, calls: vuSetupCalls };
+	}
+	exports.default = function(data, vuData) {
+		if (data != 42) {
+			throw new Error("default: wrong data: " + JSON.stringify(data));
+		}
+		if (vuData.vu != __VU || vuData.calls != 1 || vuSetupCalls != 1) {
+			throw new Error("default: wrong vu data: " + JSON.stringify(vuData));
+		}
+		iterations++;
+	};
+	exports.vuTeardown = function(vuData) {
+		if (vuData.vu != __VU || iterations != 2) {
+			throw new Error("vuTeardown: wrong vu data: " + JSON.stringify(vuData));
+		}
+		throw new Error("vuTeardown was called");
+	};`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+	go func() {
+		for range samples { //nolint:revive
+		}
+	}()
+	defer close(samples)
+
+	require.NoError(t, r.Setup(ctx, samples))
+	vu, err := r.newVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+
+	// an unused VU shouldn't run vuTeardown()
+	require.NoError(t, vu.Teardown(ctx))
+
+	activeCtx, activeCancel := context.WithCancel(ctx)
+	deactivated := make(chan struct{})
+	activeVU := vu.Activate(&lib.VUActivationParams{
+		RunContext:         activeCtx,
+		DeactivateCallback: func(lib.InitializedVU) { close(deactivated) },
+	})
+	require.NoError(t, activeVU.RunOnce())
+	require.NoError(t, activeVU.RunOnce())
+	activeCancel()
+	<-deactivated
+
+	err = vu.Teardown(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vuTeardown was called")
+}
+
+func TestVUSetupFailure(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		vuSetup, expErr string
+	}{
+		"error":   {vuSetup: `throw new Error("vuSetup failed");`, expErr: "vuSetup failed"},
+		"timeout": {vuSetup: `while (true) {}`, expErr: "vuSetup() execution timed out after 1 seconds"},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			r, err := getSimpleRunner(t, "/script.js", `
+			exports.options = { setupTimeout: "1s" };
+			var vuSetupCalls = 0;
+			exports.vuSetup = function() {
+				vuSetupCalls++;
+				if (vuSetupCalls > 1) {
+					throw new Error("vuSetup was called again");
+				}
+				`+tc.vuSetup+`
+			}
+			exports.default = function() {
+				throw new Error("an iteration was run");
+			};
+			exports.vuTeardown = function() {
+				throw new Error("vuTeardown was called");
+			};`)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			samples := make(chan metrics.SampleContainer, 100)
+			go func() {
+				for range samples { //nolint:revive
+				}
+			}()
+			defer close(samples)
+
+			vu, err := r.newVU(ctx, 1, 1, samples)
+			require.NoError(t, err)
+			activeCtx, activeCancel := context.WithCancel(ctx)
+			deactivated := make(chan struct{})
+			activeVU := vu.Activate(&lib.VUActivationParams{
+				RunContext:         activeCtx,
+				DeactivateCallback: func(lib.InitializedVU) { close(deactivated) },
+			})
+
+			// all the iterations of the VU fail with the error of vuSetup()
+			for i := 0; i < 3; i++ {
+				err = activeVU.RunOnce()
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expErr)
+			}
+			activeCancel()
+			<-deactivated
+
+			require.NoError(t, vu.Teardown(ctx))
+		})
+	}
+}
+
 func TestConsoleInInitContext(t *testing.T) {
 	t.Parallel()
 	r1, err := getSimpleRunner(t, "/script.js", `
@@ -556,6 +681,28 @@ func TestSetupDataPromise(t *testing.T) {
 	};`)
 }
 
+func TestSetupDataTimers(t *testing.T) {
+	t.Parallel()
+	testSetupDataHelper(t, `
+	const { setTimeout } = require("k6/timers");
+	exports.options = { setupTimeout: "1s", teardownTimeout: "1s" };
+	exports.setup = function() {
+		return new Promise((resolve) => setTimeout(() => resolve({"data": "correct"}), 10));
+	}
+	exports.default = function(data) {
+		if (data.data !== "correct") {
+			throw new Error("default: wrong data: " + JSON.stringify(data))
+		}
+	};
+
+	exports.teardown = async function(data) {
+		await new Promise((resolve) => setTimeout(resolve, 10));
+		if (data.data !== "correct") {
+			throw new Error("teardown: wrong data: " + JSON.stringify(data))
+		}
+	};`)
+}
+
 func TestRunnerIntegrationImports(t *testing.T) {
 	t.Parallel()
 	t.Run("Modules", func(t *testing.T) {
@@ -673,7 +820,9 @@ func TestVURunContext(t *testing.T) {
 				assert.Equal(t, null.BoolFrom(true), state.Options.Throw)
 				assert.NotNil(t, state.Logger)
 				assert.Equal(t, r.GetDefaultGroup(), state.Group)
-				assert.Equal(t, vu.Transport, state.Transport)
+				assert.Equal(t, vu.http2Transport, state.Transport)
+				assert.Equal(t, vu.tlsTransport, vu.http2Transport.base)
+				assert.Equal(t, vu.Transport, vu.tlsTransport.base)
 			}))
 
 			activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx})
@@ -842,6 +991,57 @@ func TestVUIntegrationGroups(t *testing.T) {
 	}
 }
 
+func TestVULiveTags(t *testing.T) {
+	t.Parallel()
+
+	r, err := getSimpleRunner(t, "/script.js", `
+		var Counter = require("k6/metrics").Counter;
+		var myMetric = new Counter("my_metric");
+		exports.default = function() { myMetric.add(1); }
+	`)
+	require.NoError(t, err)
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(nil, et, 0, 0)
+	ctx, cancel := context.WithCancel(lib.WithExecutionState(context.Background(), es))
+	defer cancel()
+
+	samples := make(chan metrics.SampleContainer, 100)
+	vu, err := r.newVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	activeVU := vu.Activate(&lib.VUActivationParams{
+		RunContext: ctx,
+		Tags:       map[string]string{"flag": "scenario"},
+	})
+
+	getTags := func() map[string]string {
+		require.NoError(t, activeVU.RunOnce())
+		for _, sc := range metrics.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name == "my_metric" {
+					return s.Tags.Map()
+				}
+			}
+		}
+		require.Fail(t, "no my_metric sample")
+		return nil
+	}
+
+	tags := getTags()
+	assert.Equal(t, "scenario", tags["flag"])
+
+	es.LiveConfig.UpdateTags(map[string]string{"flag": "live", "extra": "yes"}, nil)
+	tags = getTags()
+	assert.Equal(t, "live", tags["flag"])
+	assert.Equal(t, "yes", tags["extra"])
+
+	es.LiveConfig.UpdateTags(nil, []string{"flag", "extra"})
+	tags = getTags()
+	assert.Equal(t, "scenario", tags["flag"])
+	assert.NotContains(t, tags, "extra")
+}
+
 func TestVUIntegrationMetrics(t *testing.T) {
 	t.Parallel()
 	testdata := make(map[string]*Runner, 2)
@@ -911,6 +1111,84 @@ func TestVUIntegrationMetrics(t *testing.T) {
 	}
 }
 
+func TestVUEventLoopLagMetric(t *testing.T) {
+	t.Parallel()
+
+	r, err := getSimpleRunner(t, "/script.js", `
+		exports.options = { generatorMetrics: true };
+		exports.default = function() {};
+	`)
+	require.NoError(t, err)
+
+	samples := make(chan metrics.SampleContainer, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vu, err := r.newVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx})
+
+	countLagSamples := func() int {
+		count := 0
+		for _, sampleC := range metrics.GetBufferedSamples(samples) {
+			for _, s := range sampleC.GetSamples() {
+				if s.Metric == r.preInitState.BuiltinMetrics.EventLoopLag {
+					count++
+				}
+			}
+		}
+		return count
+	}
+
+	// the lag is emitted after the first iteration, and then at most once
+	// every eventLoopLagInterval, not after every iteration
+	for i := 0; i < 10; i++ {
+		require.NoError(t, activeVU.RunOnce())
+	}
+	assert.Equal(t, 1, countLagSamples())
+
+	vu.eventLoopLagEmitted = time.Now().Add(-eventLoopLagInterval)
+	require.NoError(t, activeVU.RunOnce())
+	assert.Equal(t, 1, countLagSamples())
+}
+
+func TestVUIntegrationCorrelationID(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+		var exec = require("k6/execution");
+		var Counter = require("k6/metrics").Counter;
+		var ids = new Counter("ids");
+		exports.default = function() {
+			ids.add(1, { id: exec.iteration.context.correlationId });
+		}
+	`)
+	require.NoError(t, err)
+
+	samples := make(chan metrics.SampleContainer, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vu, err := r.newVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx})
+	require.NoError(t, activeVU.RunOnce())
+	require.NoError(t, activeVU.RunOnce())
+
+	var ids []string
+	for _, sampleC := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sampleC.GetSamples() {
+			if s.Metric.Name != "ids" {
+				continue
+			}
+			id, _ := s.Tags.Get("id")
+			assert.Len(t, id, 32)
+			assert.Equal(t, id, s.Metadata["correlation_id"])
+			ids = append(ids, id)
+		}
+	}
+	require.Len(t, ids, 2)
+	assert.NotEqual(t, ids[0], ids[1])
+}
+
 func generateTLSCertificate(t *testing.T, host string, notBefore time.Time, validFor time.Duration) ([]byte, []byte) {
 	return generateTLSCertificateWithCA(t, host, notBefore, validFor, nil, nil)
 }
@@ -1833,6 +2111,119 @@ func TestVUIntegrationClientCerts(t *testing.T) {
 	}
 }
 
+func TestVUIntegrationClientCertsOverride(t *testing.T) {
+	t.Parallel()
+
+	caCertPem, caKeyPem := generateTLSCertificate(t, "127.0.0.1", time.Now(), time.Hour)
+	caCertBlock, _ := pem.Decode(caCertPem)
+	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
+	require.NoError(t, err)
+	caKeyBlock, _ := pem.Decode(caKeyPem)
+	caKeyAny, err := x509.ParsePKCS8PrivateKey(caKeyBlock.Bytes)
+	require.NoError(t, err)
+	caKey, ok := caKeyAny.(*rsa.PrivateKey)
+	require.True(t, ok)
+
+	srvCertPem, srvKeyPem := generateTLSCertificateWithCA(t, "127.0.0.1", time.Now(), time.Hour, caCert, caKey)
+	serverCert, err := tls.X509KeyPair(append(srvCertPem, caCertPem...), srvKeyPem)
+	require.NoError(t, err)
+	clientCAPool := x509.NewCertPool()
+	require.True(t, clientCAPool.AppendCertsFromPEM(caCertPem))
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAPool,
+	})
+	require.NoError(t, err)
+	srv := &http.Server{ //nolint:gosec
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = fmt.Fprintf(w, "%s|%s", req.TLS.PeerCertificates[0].DNSNames[0], req.TLS.ServerName)
+		}),
+		ErrorLog: stdlog.New(io.Discard, "", 0),
+	}
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	r, err := getSimpleRunner(t, "/script.js", fmt.Sprintf(`
+		var http = require("k6/http");
+		exports.default = function() {
+			var url = "https://%s/";
+			var expect = function(res, body) {
+				if (res.body !== body) { throw new Error("expected " + body + ", got " + res.body); }
+			};
+			// the second VU presents the second certificate of the pool
+			expect(http.get(url), "client-b|");
+			expect(http.get(url, { tlsAuth: "a" }), "client-a|");
+			expect(http.get(url, { serverName: "tenant.example" }), "client-b|tenant.example");
+			expect(http.get(url), "client-b|");
+		}`, listener.Addr().String()))
+	require.NoError(t, err)
+
+	tlsAuth := make([]*lib.TLSAuth, 0, 2)
+	for _, name := range []string{"a", "b"} {
+		certPem, keyPem := generateTLSCertificateWithCA(t, "client-"+name, time.Now(), time.Hour, caCert, caKey)
+		tlsAuth = append(tlsAuth, &lib.TLSAuth{TLSAuthFields: lib.TLSAuthFields{
+			Cert: string(certPem), Key: string(keyPem), Name: name, Pool: "clients",
+		}})
+	}
+	require.NoError(t, r.SetOptions(lib.Options{
+		Throw:                 null.BoolFrom(true),
+		InsecureSkipTLSVerify: null.BoolFrom(true),
+		TLSAuth:               tlsAuth,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	initVU, err := r.NewVU(ctx, 2, 2, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+	vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+	require.NoError(t, vu.RunOnce())
+}
+
+func TestVUIntegrationTLSCurves(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "ok")
+	}))
+	srv.TLS = &tls.Config{CurvePreferences: []tls.CurveID{tls.CurveP384}} //nolint:gosec
+	srv.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	for _, tc := range []struct {
+		curves lib.TLSCurves
+		errMsg string
+	}{
+		{curves: lib.TLSCurves{tls.CurveP384}},
+		{curves: lib.TLSCurves{tls.X25519, tls.CurveP384}},
+		{curves: lib.TLSCurves{tls.CurveP256}, errMsg: "handshake failure"},
+	} {
+		r, err := getSimpleRunner(t, "/script.js", fmt.Sprintf(`
+			var http = require("k6/http");
+			exports.default = function() { http.get("%s"); }`, srv.URL))
+		require.NoError(t, err)
+		curves := tc.curves
+		require.NoError(t, r.SetOptions(lib.Options{
+			Throw:                 null.BoolFrom(true),
+			InsecureSkipTLSVerify: null.BoolFrom(true),
+			TLSCurves:             &curves,
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+		require.NoError(t, err)
+		err = initVU.Activate(&lib.VUActivationParams{RunContext: ctx}).RunOnce()
+		cancel()
+		if tc.errMsg == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorContains(t, err, tc.errMsg)
+		}
+	}
+}
+
 func TestHTTPRequestInInitContext(t *testing.T) {
 	t.Parallel()
 	tb := httpmultibin.NewHTTPMultiBin(t)
@@ -59,7 +59,7 @@
         return JSON.stringify(results, null, 4);
     };
 
-    return function (summaryCallbackResult, jsonSummaryPath, data) {
+    return function (summaryCallbackResult, jsonSummaryPaths, data) {
         var result = summaryCallbackResult;
         if (!result) {
             var enableColors = (!data.options.noColor && data.state.isStdOutTTY);
@@ -71,8 +71,11 @@
         // TODO: ensure we're returning a map of strings or null/undefined...
         // and if not, log an error and generate the default summary?
 
-        if (jsonSummaryPath != '') {
-            result[jsonSummaryPath] = oldJSONSummary(data);
+        if (jsonSummaryPaths && jsonSummaryPaths.length > 0) {
+            var jsonSummary = oldJSONSummary(data);
+            for (var i = 0; i < jsonSummaryPaths.length; i++) {
+                result[jsonSummaryPaths[i]] = jsonSummary;
+            }
         }
 
         return result;
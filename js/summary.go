@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/dop251/goja"
@@ -23,13 +24,21 @@ var summaryWrapperLambdaCode string
 
 // TODO: figure out something saner... refactor the sinks and how we deal with
 // metrics in general... so much pain and misery... :sob:
-func metricValueGetter(summaryTrendStats []string) func(metrics.Sink, time.Duration) map[string]float64 {
+func metricValueGetter(
+	summaryTrendStats []string, perMetric map[string][]string,
+) func(string, metrics.Sink, time.Duration) map[string]float64 {
 	trendResolvers, err := metrics.GetResolversForTrendColumns(summaryTrendStats)
 	if err != nil {
 		panic(err.Error()) // this should have been validated already
 	}
+	perMetricResolvers := make(map[string]map[string]func(s *metrics.TrendSink) float64, len(perMetric))
+	for name, stats := range perMetric {
+		if perMetricResolvers[name], err = metrics.GetResolversForTrendColumns(stats); err != nil {
+			panic(err.Error()) // this should have been validated already
+		}
+	}
 
-	return func(sink metrics.Sink, t time.Duration) (result map[string]float64) {
+	return func(name string, sink metrics.Sink, t time.Duration) (result map[string]float64) {
 		switch sink := sink.(type) {
 		case *metrics.CounterSink:
 			result = sink.Format(t)
@@ -51,6 +60,15 @@ func metricValueGetter(summaryTrendStats []string) func(metrics.Sink, time.Durat
 			for _, col := range summaryTrendStats {
 				result[col] = trendResolvers[col](sink)
 			}
+			// the stats of a metric also apply to its submetrics
+			resolvers, ok := perMetricResolvers[name]
+			if !ok {
+				parent, _, _ := strings.Cut(name, "{")
+				resolvers = perMetricResolvers[parent]
+			}
+			for col, resolve := range resolvers {
+				result[col] = resolve(sink)
+			}
 		}
 
 		return result
@@ -64,9 +82,10 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 	m["root_group"] = exportGroup(data.RootGroup)
 	m["options"] = map[string]interface{}{
 		// TODO: improve when we can easily export all option values, including defaults?
-		"summaryTrendStats": options.SummaryTrendStats,
-		"summaryTimeUnit":   options.SummaryTimeUnit.String,
-		"noColor":           data.NoColor, // TODO: move to the (runtime) options
+		"summaryTrendStats":          options.SummaryTrendStats,
+		"summaryTrendStatsPerMetric": options.SummaryTrendStatsPerMetric,
+		"summaryTimeUnit":            options.SummaryTimeUnit.String,
+		"noColor":                    data.NoColor, // TODO: move to the (runtime) options
 	}
 	m["state"] = map[string]interface{}{
 		"isStdOutTTY":       data.UIState.IsStdOutTTY,
@@ -74,14 +93,14 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 		"testRunDurationMs": float64(data.TestRunDuration) / float64(time.Millisecond),
 	}
 
-	getMetricValues := metricValueGetter(options.SummaryTrendStats)
+	getMetricValues := metricValueGetter(options.SummaryTrendStats, options.SummaryTrendStatsPerMetric)
 
 	metricsData := make(map[string]interface{})
 	for name, m := range data.Metrics {
 		metricData := map[string]interface{}{
 			"type":     m.Type.String(),
 			"contains": m.Contains.String(),
-			"values":   getMetricValues(m.Sink, data.TestRunDuration),
+			"values":   getMetricValues(name, m.Sink, data.TestRunDuration),
 		}
 
 		if len(m.Thresholds.Thresholds) > 0 {
@@ -97,6 +116,36 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 	}
 	m["metrics"] = metricsData
 
+	if len(data.CompositeThresholds) > 0 {
+		compositeData := make(map[string]interface{})
+		for name, cts := range data.CompositeThresholds {
+			thresholds := make(map[string]interface{})
+			for _, threshold := range cts.Thresholds {
+				thresholds[threshold.Source] = map[string]interface{}{
+					"ok": !threshold.LastFailed,
+				}
+			}
+			compositeData[name] = thresholds
+		}
+		m["composite_thresholds"] = compositeData
+	}
+
+	if data.Cardinality != nil {
+		m["cardinality"] = exportCardinality(data.Cardinality)
+	}
+	if data.Baseline != nil {
+		m["baseline"] = exportBaseline(data.Baseline)
+	}
+	if data.TimeSeries != nil {
+		m["time_series"] = exportTimeSeries(data.TimeSeries)
+	}
+	if data.Abort != nil {
+		m["abort"] = map[string]interface{}{
+			"reason":    data.Abort.Reason,
+			"exit_code": data.Abort.ExitCode,
+		}
+	}
+
 	var setupDataI interface{}
 	if setupData != nil {
 		if err := json.Unmarshal(setupData, &setupDataI); err != nil {
@@ -112,6 +161,95 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 	return m
 }
 
+func exportCardinality(report *metrics.CardinalityReport) map[string]interface{} {
+	exportOffenders := func(offenders []metrics.CardinalityOffender) []map[string]interface{} {
+		result := make([]map[string]interface{}, len(offenders))
+		for i, offender := range offenders {
+			result[i] = map[string]interface{}{"name": offender.Name, "series": offender.Series}
+		}
+		return result
+	}
+	return map[string]interface{}{
+		"limit":            report.Limit,
+		"action":           report.Action,
+		"series":           report.Series,
+		"rejected_samples": report.RejectedSamples,
+		"top_metrics":      exportOffenders(report.TopMetrics),
+		"top_tags":         exportOffenders(report.TopTags),
+	}
+}
+
+// exportTimeSeries exports the series of each metric with their points, the
+// times and the interval are in milliseconds.
+func exportTimeSeries(buckets *metrics.TimeBuckets) map[string]interface{} {
+	config := buckets.Config()
+	interval := time.Duration(config.Interval)
+	allSeries := buckets.Series()
+	trendStats := make(map[string][]string)
+	for _, series := range allSeries {
+		if series.Metric.Type == metrics.Trend {
+			trendStats[series.Metric.Name] = config.Metrics[series.Metric.Name]
+		}
+	}
+	getMetricValues := metricValueGetter(nil, trendStats)
+
+	metricsData := make(map[string][]map[string]interface{}, len(config.Metrics))
+	for name := range config.Metrics {
+		metricsData[name] = []map[string]interface{}{}
+	}
+	for _, series := range allSeries {
+		name := series.Metric.Name
+		stats := config.Metrics[name]
+		points := make([]map[string]interface{}, len(series.Buckets))
+		for i, bucket := range series.Buckets {
+			values := getMetricValues(name, bucket.Sink, interval)
+			if series.Metric.Type != metrics.Trend && len(stats) > 0 {
+				filtered := make(map[string]float64, len(stats))
+				for _, stat := range stats {
+					filtered[stat] = values[stat]
+				}
+				values = filtered
+			}
+			points[i] = map[string]interface{}{
+				"time":   float64(bucket.Time.UnixNano()) / float64(time.Millisecond),
+				"values": values,
+			}
+		}
+		metricsData[name] = append(metricsData[name], map[string]interface{}{
+			"tags":   series.Tags,
+			"points": points,
+		})
+	}
+	return map[string]interface{}{
+		"interval":  float64(interval) / float64(time.Millisecond),
+		"breakdown": config.Breakdown,
+		"metrics":   metricsData,
+	}
+}
+
+func exportBaseline(report *metrics.BaselineReport) map[string]interface{} {
+	deltas := make([]map[string]interface{}, len(report.Deltas))
+	for i, delta := range report.Deltas {
+		deltas[i] = map[string]interface{}{
+			"tolerance": delta.Tolerance.Source,
+			"metric":    delta.Tolerance.Metric,
+			"stat":      delta.Tolerance.Stat,
+			"percent":   delta.Tolerance.Percent,
+			"type":      delta.Type.String(),
+			"contains":  delta.Contains.String(),
+			"baseline":  delta.Baseline,
+			"current":   delta.Current,
+			"change":    delta.Change,
+			"ok":        !delta.Regressed,
+		}
+	}
+	return map[string]interface{}{
+		"path":    report.Path,
+		"deltas":  deltas,
+		"missing": report.Missing,
+	}
+}
+
 func exportGroup(group *lib.Group) map[string]interface{} {
 	subGroups := make([]map[string]interface{}, len(group.OrderedGroups))
 	for i, subGroup := range group.OrderedGroups {
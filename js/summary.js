@@ -26,6 +26,7 @@ var defaultOptions = {
   enableColors: true,
   summaryTimeUnit: null,
   summaryTrendStats: null,
+  summaryTrendStatsPerMetric: null,
 }
 
 // strWidth tries to return the actual width the string will take up on the
@@ -241,6 +242,16 @@ function nonTrendMetricValueForSum(metric, timeUnit) {
   }
 }
 
+// extraTrendStatsForMetric returns the stats of the metric, or of its parent
+// for a submetric, which aren't already in the columns of all the trends.
+function extraTrendStatsForMetric(options, name) {
+  var perMetric = options.summaryTrendStatsPerMetric || {}
+  var stats = perMetric[name] || perMetric[name.split('{', 1)[0]] || []
+  return stats.filter(function (stat) {
+    return options.summaryTrendStats.indexOf(stat) < 0
+  })
+}
+
 function summarizeMetrics(options, data, decorate) {
   var indent = options.indent + '  '
   var result = []
@@ -254,6 +265,7 @@ function summarizeMetrics(options, data, decorate) {
   var nonTrendExtraMaxLens = [0, 0]
 
   var trendCols = {}
+  var trendExtras = {}
   var numTrendColumns = options.summaryTrendStats.length
   var trendColMaxLens = new Array(numTrendColumns).fill(0)
   forEach(data.metrics, function (name, metric) {
@@ -282,6 +294,15 @@ function summarizeMetrics(options, data, decorate) {
         cols[i] = value
       }
       trendCols[name] = cols
+      trendExtras[name] = extraTrendStatsForMetric(options, name).map(function (stat) {
+        var value = metric.values[stat]
+        if (stat === 'count') {
+          value = value.toString()
+        } else {
+          value = humanizeValue(value, metric, options.summaryTimeUnit)
+        }
+        return stat + '=' + decorate(value, palette.cyan)
+      })
       return
     }
     var values = nonTrendMetricValueForSum(metric, options.summaryTimeUnit)
@@ -299,6 +320,13 @@ function summarizeMetrics(options, data, decorate) {
     }
   })
 
+  var compositeNames = Object.keys(data.composite_thresholds || {}).sort()
+  compositeNames.forEach(function (name) {
+    if (strWidth(name) > nameLenMax) {
+      nameLenMax = strWidth(name)
+    }
+  })
+
   // sort all metrics but keep sub metrics grouped with their parent metrics
   names.sort(function (metric1, metric2) {
     var parent1 = metric1.split('{', 1)[0]
@@ -323,7 +351,7 @@ function summarizeMetrics(options, data, decorate) {
           decorate(cols[i], palette.cyan) +
           ' '.repeat(trendColMaxLens[i] - strWidth(cols[i]))
       }
-      return tmpCols.join(' ')
+      return tmpCols.concat(trendExtras[name]).join(' ')
     }
 
     var value = nonTrendValues[name]
@@ -379,9 +407,119 @@ function summarizeMetrics(options, data, decorate) {
     result.push(indent + fmtIndent + markColor(mark) + ' ' + fmtName + ' ' + getData(name))
   }
 
+  // the composite thresholds are listed after the metrics, one line for each
+  // of their expressions, as they don't belong to a single metric
+  compositeNames.forEach(function (name) {
+    var fmtName = name + decorate('.'.repeat(nameLenMax - strWidth(name) + 3) + ':', palette.faint)
+    forEach(data.composite_thresholds[name], function (source, threshold) {
+      var mark = threshold.ok ? decorate(succMark, palette.green) : decorate(failMark, palette.red)
+      result.push(indent + mark + ' ' + fmtName + ' ' + decorate(source, palette.cyan))
+    })
+  })
+
+  return result
+}
+
+function summarizeCardinality(indent, data, decorate) {
+  var cardinality = data.cardinality
+  if (!cardinality) {
+    return []
+  }
+  var formatOffenders = function (offenders) {
+    return offenders
+      .map(function (offender) {
+        return offender.name + '=' + decorate(offender.series, palette.cyan)
+      })
+      .join(' ')
+  }
+
+  var result = [
+    '',
+    indent +
+      decorate(
+        failMark +
+          ' more than ' +
+          cardinality.limit +
+          ' unique time series, the new ones were handled with the ' +
+          cardinality.action +
+          ' action',
+        palette.red
+      ),
+    indent + '  time series........: ' + decorate(cardinality.series, palette.cyan),
+  ]
+  if (cardinality.rejected_samples > 0) {
+    result.push(
+      indent + '  rejected samples...: ' + decorate(cardinality.rejected_samples, palette.cyan)
+    )
+  }
+  result.push(indent + '  top metrics........: ' + formatOffenders(cardinality.top_metrics))
+  result.push(indent + '  top tags (values)..: ' + formatOffenders(cardinality.top_tags))
+  return result
+}
+
+function summarizeBaseline(indent, options, data, decorate) {
+  var baseline = data.baseline
+  if (!baseline || baseline.deltas.length === 0) {
+    return []
+  }
+  var formatChange = function (change) {
+    if (!isFinite(change)) {
+      return change > 0 ? '+∞' : '-∞'
+    }
+    return (change > 0 ? '+' : '') + toFixedNoTrailingZeros(change, 2) + '%'
+  }
+
+  var nameLenMax = 0
+  baseline.deltas.forEach(function (delta) {
+    nameLenMax = Math.max(nameLenMax, strWidth(delta.metric + '.' + delta.stat))
+  })
+
+  var result = ['', indent + 'compared with the baseline ' + baseline.path + ':']
+  baseline.deltas.forEach(function (delta) {
+    var metric = { type: delta.type, contains: delta.contains }
+    var name = delta.metric + '.' + delta.stat
+    var mark = delta.ok ? decorate(succMark, palette.green) : decorate(failMark, palette.red)
+    result.push(
+      indent +
+        '  ' +
+        mark +
+        ' ' +
+        name +
+        decorate('.'.repeat(nameLenMax - strWidth(name) + 3) + ':', palette.faint) +
+        ' ' +
+        humanizeValue(delta.baseline, metric, options.summaryTimeUnit) +
+        ' → ' +
+        decorate(humanizeValue(delta.current, metric, options.summaryTimeUnit), palette.cyan) +
+        ' ' +
+        decorate(
+          '(' +
+            formatChange(delta.change) +
+            ', tolerance ' +
+            (delta.percent > 0 ? '+' : '') +
+            delta.percent +
+            '%)',
+          delta.ok ? palette.faint : palette.red
+        )
+    )
+  })
   return result
 }
 
+function summarizeAbort(indent, data, decorate) {
+  var abort = data.abort
+  if (!abort) {
+    return []
+  }
+  return [
+    '',
+    indent +
+      decorate(
+        failMark + ' ' + abort.reason + ', gracefully (exit code ' + abort.exit_code + ')',
+        palette.red
+      ),
+  ]
+}
+
 function generateTextSummary(data, options) {
   var mergedOpts = Object.assign({}, defaultOptions, data.options, options)
   var lines = []
@@ -407,6 +545,18 @@ function generateTextSummary(data, options) {
 
   Array.prototype.push.apply(lines, summarizeMetrics(mergedOpts, data, decorate))
 
+  Array.prototype.push.apply(
+    lines,
+    summarizeCardinality(mergedOpts.indent + '    ', data, decorate)
+  )
+
+  Array.prototype.push.apply(
+    lines,
+    summarizeBaseline(mergedOpts.indent + '    ', mergedOpts, data, decorate)
+  )
+
+  Array.prototype.push.apply(lines, summarizeAbort(mergedOpts.indent + '    ', data, decorate))
+
   return lines.join('\n')
 }
 
@@ -15,6 +15,7 @@ import (
 
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -133,6 +134,233 @@ func TestTextSummaryWithSubMetrics(t *testing.T) {
 	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
 }
 
+func TestTextSummaryWithCardinality(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	reqs.Sink.Add(metrics.Sample{Value: 5})
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{reqs.Name: reqs},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+		Cardinality: &metrics.CardinalityReport{
+			Limit:           3,
+			Action:          metrics.CardinalityDrop,
+			Series:          3,
+			LimitReached:    true,
+			RejectedSamples: 2,
+			TopMetrics:      []metrics.CardinalityOffender{{Name: "http_reqs", Series: 3}},
+			TopTags:         []metrics.CardinalityOffender{{Name: "url", Series: 3}, {Name: "method", Series: 1}},
+		},
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		"exports.default = function() {/* we don't run this, metrics are mocked */};",
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+
+	expected := "     http_reqs...: 5 5/s\n\n" +
+		"     ✗ more than 3 unique time series, the new ones were handled with the drop action\n" +
+		"       time series........: 3\n" +
+		"       rejected samples...: 2\n" +
+		"       top metrics........: http_reqs=3\n" +
+		"       top tags (values)..: url=3 method=1\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+}
+
+func TestTextSummaryWithStatsPerMetric(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	duration, err := registry.NewMetric("my_trend", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	for _, v := range []float64{10, 20, 30, 40, 1000} {
+		duration.Sink.Add(metrics.Sample{Value: v})
+	}
+	other, err := registry.NewMetric("other_trend", metrics.Trend)
+	require.NoError(t, err)
+	other.Sink.Add(metrics.Sample{Value: 1})
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{duration.Name: duration, other.Name: other},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		`
+		exports.options = {
+			summaryTrendStats: ["avg", "max"],
+			summaryTrendStatsPerMetric: {my_trend: ["max", "p(99.9)", "tmean(20)", "stddev", "iqr"]},
+		};
+		exports.default = function() {/* we don't run this, metrics are mocked */};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+	expected := "     my_trend......: avg=220ms max=1s p(99.9)=996.16ms tmean(20)=30ms stddev=390.12ms iqr=20ms\n" +
+		"     other_trend...: avg=1     max=1 \n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+
+	data := summarizeMetricsToObject(summary, runner.GetOptions(), nil)
+	values := data["metrics"].(map[string]interface{})["my_trend"].(map[string]interface{})["values"]
+	expectedValues := map[string]float64{
+		"avg": 220, "max": 1000, "p(99.9)": 996.16, "tmean(20)": 30, "stddev": 390.128, "iqr": 20,
+	}
+	require.Len(t, values, len(expectedValues))
+	for stat, value := range expectedValues {
+		assert.InDelta(t, value, values.(map[string]float64)[stat], 0.001, stat)
+	}
+}
+
+func TestTextSummaryWithBaseline(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	reqs.Sink.Add(metrics.Sample{Value: 5})
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{reqs.Name: reqs},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+		Baseline: &metrics.BaselineReport{
+			Path: "previous.json",
+			Deltas: []metrics.BaselineDelta{
+				{
+					Tolerance: metrics.BaselineTolerance{
+						Source: "http_req_duration.p(95)=10%", Metric: "http_req_duration", Stat: "p(95)", Percent: 10,
+					},
+					Type: metrics.Trend, Contains: metrics.Time, Baseline: 100, Current: 125, Change: 25, Regressed: true,
+				},
+				{
+					Tolerance: metrics.BaselineTolerance{
+						Source: "checks.rate=-5%", Metric: "checks", Stat: "rate", Percent: -5,
+					},
+					Type: metrics.Rate, Contains: metrics.Default, Baseline: 0.5, Current: 0.49, Change: -2,
+				},
+			},
+		},
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		"exports.default = function() {/* we don't run this, metrics are mocked */};",
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+
+	expected := "     http_reqs...: 5 5/s\n\n" +
+		"     compared with the baseline previous.json:\n" +
+		"       ✗ http_req_duration.p(95)...: 100ms → 125ms (+25%, tolerance +10%)\n" +
+		"       ✓ checks.rate...............: 50.00% → 49.00% (-2%, tolerance -5%)\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+}
+
+func TestTextSummaryWithCompositeThresholds(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	reqs.Sink.Add(metrics.Sample{Value: 5})
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{reqs.Name: reqs},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+		CompositeThresholds: map[string]*metrics.CompositeThresholds{
+			"error_ratio": {Thresholds: []*metrics.CompositeThreshold{
+				{Source: "errors.count / http_reqs.count < 0.01", LastFailed: true},
+			}},
+			"ok": {Thresholds: []*metrics.CompositeThreshold{
+				{Source: "http_reqs.count > 1"},
+			}},
+		},
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		"exports.default = function() {/* we don't run this, metrics are mocked */};",
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+
+	expected := "     http_reqs.....: 5 5/s\n" +
+		"   ✗ error_ratio...: errors.count / http_reqs.count < 0.01\n" +
+		"   ✓ ok............: http_reqs.count > 1\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+
+	data := summarizeMetricsToObject(summary, runner.GetOptions(), nil)
+	assert.Equal(t, map[string]interface{}{
+		"error_ratio": map[string]interface{}{
+			"errors.count / http_reqs.count < 0.01": map[string]interface{}{"ok": false},
+		},
+		"ok": map[string]interface{}{
+			"http_reqs.count > 1": map[string]interface{}{"ok": true},
+		},
+	}, data["composite_thresholds"])
+}
+
+func TestTextSummaryWithAbort(t *testing.T) {
+	t.Parallel()
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+		Abort:           &lib.SummaryAbort{Reason: "test aborted: mayday", ExitCode: 42},
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		"exports.default = function() {/* we don't run this, metrics are mocked */};",
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+	assert.Equal(t, "\n\n     ✗ test aborted: mayday, gracefully (exit code 42)\n\n", string(summaryOut))
+
+	data := summarizeMetricsToObject(summary, runner.GetOptions(), nil)
+	assert.Equal(t, map[string]interface{}{"reason": "test aborted: mayday", "exit_code": 42}, data["abort"])
+}
+
 func createTestMetrics(t *testing.T) (map[string]*metrics.Metric, *lib.Group) {
 	registry := metrics.NewRegistry()
 	testMetrics := make(map[string]*metrics.Metric)
@@ -371,6 +599,7 @@ const expectedHandleSummaryRawData = `
             "p(99)",
             "count"
         ],
+        "summaryTrendStatsPerMetric": {},
         "summaryTimeUnit": "",
         "noColor": false
     },
@@ -488,7 +717,8 @@ const expectedHandleSummaryDataWithSetup = `
             "p(99)",
             "count"
             ],
-            "summaryTimeUnit": "",
+            "summaryTrendStatsPerMetric": {},
+        "summaryTimeUnit": "",
             "noColor": false
         },
         "state": {
@@ -619,6 +849,65 @@ func TestRawHandleSummaryDataWithSetupData(t *testing.T) {
 	assert.JSONEq(t, expectedHandleSummaryDataWithSetup, string(dataWithSetup))
 }
 
+func TestRawHandleSummaryTimeSeries(t *testing.T) {
+	t.Parallel()
+	runner, err := getSimpleRunner(
+		t, "/script.js",
+		`
+		exports.default = function() { /* we don't run this, metrics are mocked */ };
+		exports.handleSummary = function(data) {
+			return {'timeSeries.json': JSON.stringify(data.time_series)};
+		};
+		`,
+	)
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	duration, err := registry.NewMetric("duration", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	reqs, err := registry.NewMetric("reqs", metrics.Counter)
+	require.NoError(t, err)
+	buckets := metrics.NewTimeBuckets(metrics.SummaryTimeSeries{
+		Interval:  types.Duration(10 * time.Second),
+		Metrics:   map[string][]string{"duration": {"p(95)", "max"}, "reqs": {"rate"}},
+		Breakdown: []string{"scenario"},
+	})
+	start := time.Unix(100, 0)
+	for i, scenario := range []string{"a", "a", "b", "a"} {
+		tags := registry.RootTagSet().With("scenario", scenario)
+		at := start.Add(time.Duration(i) * 5 * time.Second)
+		buckets.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: duration, Tags: tags}, Time: at, Value: float64(i)})
+		buckets.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: reqs, Tags: tags}, Time: at, Value: 5})
+	}
+
+	summary := createTestSummary(t)
+	summary.TimeSeries = buckets
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	timeSeries, err := io.ReadAll(result["timeSeries.json"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"interval": 10000,
+		"breakdown": ["scenario"],
+		"metrics": {
+			"duration": [
+				{"tags": {"scenario": "a"}, "points": [
+					{"time": 100000, "values": {"p(95)": 0.95, "max": 1}},
+					{"time": 110000, "values": {"p(95)": 3, "max": 3}}
+				]},
+				{"tags": {"scenario": "b"}, "points": [{"time": 110000, "values": {"p(95)": 2, "max": 2}}]}
+			],
+			"reqs": [
+				{"tags": {"scenario": "a"}, "points": [
+					{"time": 100000, "values": {"rate": 1}},
+					{"time": 110000, "values": {"rate": 0.5}}
+				]},
+				{"tags": {"scenario": "b"}, "points": [{"time": 110000, "values": {"rate": 0.5}}]}
+			]
+		}
+	}`, string(timeSeries))
+}
+
 func TestRawHandleSummaryPromise(t *testing.T) {
 	t.Parallel()
 	runner, err := getSimpleRunner(
@@ -642,6 +931,32 @@ func TestRawHandleSummaryPromise(t *testing.T) {
 	assert.JSONEq(t, expectedHandleSummaryDataWithSetup, string(dataWithSetup))
 }
 
+func TestRawHandleSummaryTimers(t *testing.T) {
+	t.Parallel()
+	runner, err := getSimpleRunner(
+		t, "/script.js",
+		`
+		const { setTimeout, setInterval } = require("k6/timers");
+		exports.options = {summaryTrendStats: ["avg", "min", "med", "max", "p(90)", "p(95)", "p(99)", "count"]};
+		exports.default = function() { /* we don't run this, metrics are mocked */ };
+		exports.handleSummary = function(data) {
+			setInterval(() => {}, 10); // it's left by the failed callback
+			return new Promise((_, reject) => setTimeout(() => reject(new Error("intentional error")), 10));
+		};
+		`,
+	)
+	require.NoError(t, err)
+
+	summary := createTestSummary(t)
+	start := time.Now()
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	require.Len(t, result, 1)
+	require.NotNil(t, result["stdout"])
+}
+
 func TestWrongSummaryHandlerExportTypes(t *testing.T) {
 	t.Parallel()
 	testCases := []string{"{}", `"foo"`, "null", "undefined", "123"}
@@ -37,9 +37,9 @@ func (t timeoutError) Hint() string {
 	hint := ""
 
 	switch t.place {
-	case consts.SetupFn:
+	case consts.SetupFn, consts.VUSetupFn:
 		hint = "You can increase the time limit via the setupTimeout option"
-	case consts.TeardownFn:
+	case consts.TeardownFn, consts.VUTeardownFn:
 		hint = "You can increase the time limit via the teardownTimeout option"
 	}
 	return hint
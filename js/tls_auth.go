@@ -0,0 +1,117 @@
+package js
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
+)
+
+// vuCertificates returns the client certificates presented by the VU with the
+// given index: the tlsAuth entries without a pool and one entry of each pool,
+// round-robin by VU. All the named entries are returned by name too, for the
+// requests choosing them.
+func vuCertificates(tlsAuth []*lib.TLSAuth, vuIndex uint64) (
+	certs []tls.Certificate, nameToCert map[string]*tls.Certificate, byName map[string]*tls.Certificate, err error,
+) {
+	pools := make(map[string][]*lib.TLSAuth)
+	for _, auth := range tlsAuth {
+		if auth.Pool != "" {
+			pools[auth.Pool] = append(pools[auth.Pool], auth)
+		}
+	}
+
+	nameToCert = make(map[string]*tls.Certificate)
+	byName = make(map[string]*tls.Certificate)
+	for _, auth := range tlsAuth {
+		cert, err := auth.Certificate()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if auth.Name != "" {
+			byName[auth.Name] = cert
+		}
+		if pool := pools[auth.Pool]; auth.Pool != "" && pool[vuIndex%uint64(len(pool))] != auth {
+			continue
+		}
+		certs = append(certs, *cert)
+		for _, name := range auth.Domains {
+			nameToCert[name] = cert
+		}
+	}
+	return certs, nameToCert, byName, nil
+}
+
+// tlsOverrideTransport sends the requests overriding the client certificate or
+// the server name of their TLS connections through transports of their own,
+// so their connections aren't reused by the other requests.
+type tlsOverrideTransport struct {
+	base         *http.Transport
+	tlsConfig    *tls.Config
+	certs        map[string]*tls.Certificate
+	newTransport func(*tls.Config) *http.Transport
+
+	mx         sync.Mutex
+	transports map[netext.TLSOverride]*http.Transport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tlsOverrideTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	override := netext.GetTLSOverride(req.Context())
+	if override == (netext.TLSOverride{}) {
+		return t.base.RoundTrip(req)
+	}
+	transport, err := t.transportFor(override)
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+func (t *tlsOverrideTransport) transportFor(override netext.TLSOverride) (*http.Transport, error) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if transport, ok := t.transports[override]; ok {
+		return transport, nil
+	}
+
+	tlsConfig := t.tlsConfig.Clone()
+	if override.ClientCert != "" {
+		cert, ok := t.certs[override.ClientCert]
+		if !ok {
+			return nil, fmt.Errorf("there isn't a tlsAuth certificate with the name '%s'", override.ClientCert)
+		}
+		// The chosen certificate is presented even if it isn't issued by one
+		// of the authorities the server asks for.
+		tlsConfig.Certificates = nil
+		tlsConfig.NameToCertificate = nil //nolint:staticcheck // it's set by the tlsAuth domains
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+	}
+	if override.ServerName != "" {
+		// The server name is verified against the certificate of the server
+		// too, instead of the host of the URL.
+		tlsConfig.ServerName = override.ServerName
+	}
+
+	transport := t.newTransport(tlsConfig)
+	if t.transports == nil {
+		t.transports = make(map[netext.TLSOverride]*http.Transport)
+	}
+	t.transports[override] = transport
+	return transport, nil
+}
+
+// CloseIdleConnections closes the idle connections of all the transports.
+func (t *tlsOverrideTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	for _, transport := range t.transports {
+		transport.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,83 @@
+package js
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/metrics"
+)
+
+func TestVUCertificates(t *testing.T) {
+	t.Parallel()
+
+	newTLSAuth := func(name, pool string) *lib.TLSAuth {
+		certPem, keyPem := generateTLSCertificate(t, name, time.Now(), time.Hour)
+		return &lib.TLSAuth{TLSAuthFields: lib.TLSAuthFields{
+			Cert: string(certPem), Key: string(keyPem), Name: name, Pool: pool, Domains: []string{name + ".example"},
+		}}
+	}
+	tlsAuth := []*lib.TLSAuth{newTLSAuth("static", ""), newTLSAuth("a", "p"), newTLSAuth("b", "p"), newTLSAuth("c", "p")}
+
+	for vuIndex, pooled := range []string{"a", "b", "c", "a"} {
+		certs, nameToCert, byName, err := vuCertificates(tlsAuth, uint64(vuIndex))
+		require.NoError(t, err)
+		require.Len(t, certs, 2)
+		assert.Len(t, byName, 4, "all the named certificates can be chosen by the requests")
+		assert.Contains(t, nameToCert, "static.example")
+		assert.Contains(t, nameToCert, pooled+".example")
+		assert.Len(t, nameToCert, 2)
+	}
+}
+
+func TestTLSOverrideTransport(t *testing.T) {
+	t.Parallel()
+
+	tr := &tlsOverrideTransport{
+		tlsConfig: &tls.Config{}, //nolint:gosec
+		certs:     map[string]*tls.Certificate{"a": {}},
+		newTransport: func(tlsConfig *tls.Config) *http.Transport {
+			return &http.Transport{TLSClientConfig: tlsConfig}
+		},
+	}
+
+	_, err := tr.transportFor(netext.TLSOverride{ClientCert: "missing"})
+	assert.ErrorContains(t, err, "there isn't a tlsAuth certificate with the name 'missing'")
+
+	override := netext.TLSOverride{ClientCert: "a", ServerName: "tenant.example"}
+	transport, err := tr.transportFor(override)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant.example", transport.TLSClientConfig.ServerName)
+	cert, err := transport.TLSClientConfig.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Same(t, tr.certs["a"], cert)
+	assert.Empty(t, tr.tlsConfig.ServerName, "the config of the VU shouldn't change")
+
+	again, err := tr.transportFor(override)
+	require.NoError(t, err)
+	assert.Same(t, transport, again, "the transports should be reused")
+}
+
+func TestVUTLSSessionResumption(t *testing.T) {
+	t.Parallel()
+
+	for _, resumption := range []bool{false, true} {
+		r, err := getSimpleRunner(t, "/script.js", `exports.default = function() {}`)
+		require.NoError(t, err)
+		require.NoError(t, r.SetOptions(lib.Options{TLSSessionResumption: null.BoolFrom(resumption)}))
+
+		initVU, err := r.NewVU(context.Background(), 1, 1, make(chan metrics.SampleContainer, 100))
+		require.NoError(t, err)
+		vu, ok := initVU.(*VU)
+		require.True(t, ok)
+		assert.Equal(t, resumption, vu.TLSConfig.ClientSessionCache != nil)
+	}
+}
@@ -0,0 +1,404 @@
+package js
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+// wasmCompilationCache is shared by the wazero runtimes of all VUs, so every
+// WebAssembly module is compiled only once, even though each instance has its
+// own runtime.
+var wasmCompilationCache = wazero.NewCompilationCache() //nolint:gochecknoglobals
+
+// webAssembly implements the WebAssembly global object, with the subset of the
+// JS API for compiling and instantiating WebAssembly modules, which can import
+// JS functions and export functions and memories.
+type webAssembly struct {
+	vu modules.VU
+
+	moduleCtor   *goja.Object
+	instanceCtor *goja.Object
+	memoryCtor   *goja.Object
+	// moduleSymbol is the key of the compiled module in the Module objects
+	moduleSymbol *goja.Symbol
+}
+
+// wasmModule is a validated WebAssembly module with the definitions of its
+// imports and exports.
+type wasmModule struct {
+	binary    []byte
+	imports   []api.FunctionDefinition
+	functions map[string]api.FunctionDefinition
+	memories  map[string]api.MemoryDefinition
+}
+
+func newWebAssembly(vu modules.VU) (*goja.Object, error) {
+	rt := vu.Runtime()
+	w := &webAssembly{vu: vu, moduleSymbol: goja.NewSymbol("WebAssembly.Module")}
+	w.moduleCtor = rt.ToValue(w.newModule).ToObject(rt)
+	w.instanceCtor = rt.ToValue(w.newInstance).ToObject(rt)
+	w.memoryCtor = rt.ToValue(func(goja.ConstructorCall) *goja.Object {
+		panic(rt.NewTypeError("WebAssembly.Memory can only be exported by the instances"))
+	}).ToObject(rt)
+
+	obj := rt.NewObject()
+	for name, value := range map[string]interface{}{
+		"Module":      w.moduleCtor,
+		"Instance":    w.instanceCtor,
+		"Memory":      w.memoryCtor,
+		"validate":    w.validate,
+		"compile":     w.compile,
+		"instantiate": w.instantiate,
+	} {
+		if err := obj.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// newRuntime returns a new wazero runtime, which is closed when the wasm code
+// is interrupted, e.g. at the end of the iteration. Its compiled code is
+// released when it's garbage collected, so it doesn't need to be closed.
+func (w *webAssembly) newRuntime() wazero.Runtime {
+	config := wazero.NewRuntimeConfig().
+		WithCompilationCache(wasmCompilationCache).
+		WithCloseOnContextDone(true)
+	return wazero.NewRuntimeWithConfig(context.Background(), config)
+}
+
+// compileModule validates and compiles the given WebAssembly binary.
+func (w *webAssembly) compileModule(source goja.Value) (*wasmModule, error) {
+	data, err := common.ToBytes(source.Export())
+	if err != nil {
+		return nil, fmt.Errorf("the WebAssembly module should be an ArrayBuffer or a typed array: %w", err)
+	}
+	// the source can be changed by the script, so it needs to be copied
+	binary := make([]byte, len(data))
+	copy(binary, data)
+
+	ctx := context.Background()
+	r := w.newRuntime()
+	defer func() { _ = r.Close(ctx) }()
+
+	compiled, err := r.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebAssembly module: %w", err)
+	}
+	if len(compiled.ImportedMemories()) > 0 {
+		return nil, errors.New("the WebAssembly modules can't import memories, only functions")
+	}
+	return &wasmModule{
+		binary:    binary,
+		imports:   compiled.ImportedFunctions(),
+		functions: compiled.ExportedFunctions(),
+		memories:  compiled.ExportedMemories(),
+	}, nil
+}
+
+func (w *webAssembly) validate(source goja.Value) bool {
+	_, err := w.compileModule(source)
+	return err == nil
+}
+
+func (w *webAssembly) compile(source goja.Value) *goja.Promise {
+	rt := w.vu.Runtime()
+	p, resolve, reject := rt.NewPromise()
+	if m, err := w.compileModule(source); err != nil {
+		reject(rt.NewGoError(err))
+	} else {
+		resolve(w.wrapModule(m, nil))
+	}
+	return p
+}
+
+// instantiate instantiates either a WebAssembly binary, in which case the
+// promise is resolved with both the module and the instance, or a module, in
+// which case it's resolved only with the instance.
+func (w *webAssembly) instantiate(source goja.Value, importObject goja.Value) *goja.Promise {
+	rt := w.vu.Runtime()
+	p, resolve, reject := rt.NewPromise()
+
+	if m, ok := w.unwrapModule(source); ok {
+		instance, err := w.instantiateModule(m, importObject, nil)
+		if err != nil {
+			reject(rt.NewGoError(err))
+		} else {
+			resolve(instance)
+		}
+		return p
+	}
+
+	m, err := w.compileModule(source)
+	if err != nil {
+		reject(rt.NewGoError(err))
+		return p
+	}
+	instance, err := w.instantiateModule(m, importObject, nil)
+	if err != nil {
+		reject(rt.NewGoError(err))
+		return p
+	}
+	result := rt.NewObject()
+	_ = result.Set("module", w.wrapModule(m, nil))
+	_ = result.Set("instance", instance)
+	resolve(result)
+	return p
+}
+
+func (w *webAssembly) newModule(call goja.ConstructorCall) *goja.Object {
+	rt := w.vu.Runtime()
+	m, err := w.compileModule(call.Argument(0))
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return w.wrapModule(m, call.This)
+}
+
+func (w *webAssembly) newInstance(call goja.ConstructorCall) *goja.Object {
+	rt := w.vu.Runtime()
+	m, ok := w.unwrapModule(call.Argument(0))
+	if !ok {
+		panic(rt.NewTypeError("the first argument of WebAssembly.Instance should be a WebAssembly.Module"))
+	}
+	instance, err := w.instantiateModule(m, call.Argument(1), call.This)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return instance
+}
+
+// wrapModule returns the WebAssembly.Module object for m, which is either
+// obj, when it's created by the constructor, or a new object.
+func (w *webAssembly) wrapModule(m *wasmModule, obj *goja.Object) *goja.Object {
+	rt := w.vu.Runtime()
+	if obj == nil {
+		obj = rt.CreateObject(w.moduleCtor.Get("prototype").ToObject(rt))
+	}
+	if err := obj.SetSymbol(w.moduleSymbol, rt.ToValue(m)); err != nil {
+		common.Throw(rt, err)
+	}
+	return obj
+}
+
+func (w *webAssembly) unwrapModule(v goja.Value) (*wasmModule, bool) {
+	obj, ok := v.(*goja.Object)
+	if !ok || obj == nil {
+		return nil, false
+	}
+	v = obj.GetSymbol(w.moduleSymbol)
+	if v == nil {
+		return nil, false
+	}
+	m, ok := v.Export().(*wasmModule)
+	return m, ok
+}
+
+// instantiateModule instantiates m in its own wazero runtime, with the
+// imported functions from importObject, and returns the WebAssembly.Instance
+// object with its exports.
+func (w *webAssembly) instantiateModule(
+	m *wasmModule, importObject goja.Value, obj *goja.Object,
+) (*goja.Object, error) {
+	rt := w.vu.Runtime()
+	ctx := w.vu.Context()
+	r := w.newRuntime()
+
+	compiled, err := r.CompileModule(ctx, m.binary)
+	if err != nil {
+		return nil, err
+	}
+
+	builders := make(map[string]wazero.HostModuleBuilder)
+	var moduleNames []string
+	for _, def := range m.imports {
+		moduleName, name, _ := def.Import()
+		fn, err := w.importedFunction(importObject, moduleName, name)
+		if err != nil {
+			return nil, err
+		}
+		builder, ok := builders[moduleName]
+		if !ok {
+			builder = r.NewHostModuleBuilder(moduleName)
+			builders[moduleName] = builder
+			moduleNames = append(moduleNames, moduleName)
+		}
+		builder.NewFunctionBuilder().
+			WithGoFunction(w.hostFunction(fn, def), def.ParamTypes(), def.ResultTypes()).
+			Export(name)
+	}
+	for _, moduleName := range moduleNames {
+		if _, err = builders[moduleName].Instantiate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// as in the browsers, only the start function of the module is called
+	mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithStartFunctions())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't instantiate the WebAssembly module: %w", err)
+	}
+
+	exports := rt.NewObject()
+	for name, def := range m.functions {
+		if err = exports.Set(name, w.exportedFunction(mod.ExportedFunction(name), def)); err != nil {
+			return nil, err
+		}
+	}
+	for name := range m.memories {
+		if err = exports.Set(name, w.exportedMemory(mod.ExportedMemory(name))); err != nil {
+			return nil, err
+		}
+	}
+
+	if obj == nil {
+		obj = rt.CreateObject(w.instanceCtor.Get("prototype").ToObject(rt))
+	}
+	if err = obj.Set("exports", exports); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (w *webAssembly) importedFunction(importObject goja.Value, moduleName, name string) (goja.Callable, error) {
+	rt := w.vu.Runtime()
+	if common.IsNullish(importObject) {
+		return nil, fmt.Errorf("the WebAssembly module imports '%s.%s', but there's no import object", moduleName, name)
+	}
+	imports := importObject.ToObject(rt).Get(moduleName)
+	if common.IsNullish(imports) {
+		return nil, fmt.Errorf("the WebAssembly module imports the module '%s', which isn't in the import object",
+			moduleName)
+	}
+	fn, ok := goja.AssertFunction(imports.ToObject(rt).Get(name))
+	if !ok {
+		return nil, fmt.Errorf("the WebAssembly module imports '%s.%s', which isn't a function", moduleName, name)
+	}
+	return fn, nil
+}
+
+// hostFunction returns the wazero function, which calls the imported JS
+// function. Its exceptions are panics, so they're returned by the wasm call.
+func (w *webAssembly) hostFunction(fn goja.Callable, def api.FunctionDefinition) api.GoFunc {
+	rt := w.vu.Runtime()
+	paramTypes, resultTypes := def.ParamTypes(), def.ResultTypes()
+	return func(_ context.Context, stack []uint64) {
+		args := make([]goja.Value, len(paramTypes))
+		for i, t := range paramTypes {
+			args[i] = rt.ToValue(decodeWasmValue(t, stack[i]))
+		}
+		result, err := fn(goja.Undefined(), args...)
+		if err != nil {
+			panic(err)
+		}
+		switch len(resultTypes) {
+		case 0:
+		case 1:
+			stack[0] = encodeWasmValue(resultTypes[0], result.ToFloat())
+		default:
+			var results []goja.Value
+			if err = rt.ExportTo(result, &results); err != nil || len(results) != len(resultTypes) {
+				panic(fmt.Errorf("the imported function '%s' should return an array of %d values",
+					def.DebugName(), len(resultTypes)))
+			}
+			for i, t := range resultTypes {
+				stack[i] = encodeWasmValue(t, results[i].ToFloat())
+			}
+		}
+	}
+}
+
+// exportedFunction returns the JS function, which calls the exported wasm
+// function with the context of the VU, so it's interrupted with it.
+func (w *webAssembly) exportedFunction(fn api.Function, def api.FunctionDefinition) func(goja.FunctionCall) goja.Value {
+	rt := w.vu.Runtime()
+	paramTypes, resultTypes := def.ParamTypes(), def.ResultTypes()
+	return func(call goja.FunctionCall) goja.Value {
+		params := make([]uint64, len(paramTypes))
+		for i, t := range paramTypes {
+			params[i] = encodeWasmValue(t, call.Argument(i).ToFloat())
+		}
+		results, err := fn.Call(w.vu.Context(), params...)
+		if err != nil {
+			var exception *goja.Exception
+			if errors.As(err, &exception) {
+				panic(exception)
+			}
+			common.Throw(rt, err)
+		}
+		switch len(resultTypes) {
+		case 0:
+			return goja.Undefined()
+		case 1:
+			return rt.ToValue(decodeWasmValue(resultTypes[0], results[0]))
+		default:
+			values := make([]interface{}, len(resultTypes))
+			for i, t := range resultTypes {
+				values[i] = decodeWasmValue(t, results[i])
+			}
+			return rt.ToValue(values)
+		}
+	}
+}
+
+// exportedMemory returns the WebAssembly.Memory object for the exported
+// memory, whose buffer shares the memory with the wasm code.
+func (w *webAssembly) exportedMemory(memory api.Memory) *goja.Object {
+	rt := w.vu.Runtime()
+	obj := rt.CreateObject(w.memoryCtor.Get("prototype").ToObject(rt))
+	buffer := func() goja.Value {
+		data, _ := memory.Read(0, memory.Size())
+		return rt.ToValue(rt.NewArrayBuffer(data))
+	}
+	grow := func(deltaPages uint32) uint32 {
+		previousPages, ok := memory.Grow(deltaPages)
+		if !ok {
+			panic(rt.NewGoError(fmt.Errorf("couldn't grow the memory by %d pages", deltaPages)))
+		}
+		return previousPages
+	}
+	err := obj.DefineAccessorProperty("buffer", rt.ToValue(buffer), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if err = obj.Set("grow", grow); err != nil {
+		common.Throw(rt, err)
+	}
+	return obj
+}
+
+// encodeWasmValue encodes the JS number v as a wasm value of the type t. The
+// 64-bit integers are numbers, since goja doesn't support BigInt.
+func encodeWasmValue(t api.ValueType, v float64) uint64 {
+	switch t {
+	case api.ValueTypeI32:
+		return api.EncodeI32(int32(int64(v)))
+	case api.ValueTypeI64:
+		return api.EncodeI64(int64(v))
+	case api.ValueTypeF32:
+		return api.EncodeF32(float32(v))
+	default:
+		return api.EncodeF64(v)
+	}
+}
+
+func decodeWasmValue(t api.ValueType, v uint64) interface{} {
+	switch t {
+	case api.ValueTypeI32:
+		return api.DecodeI32(v)
+	case api.ValueTypeI64:
+		return int64(v)
+	case api.ValueTypeF32:
+		return api.DecodeF32(v)
+	default:
+		return api.DecodeF64(v)
+	}
+}
@@ -0,0 +1,124 @@
+package js
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/consts"
+)
+
+// testWasmModule is a WebAssembly module, which imports env.log(i32), and
+// exports add(i32, i32) i32, callLog(i32), which calls env.log, and memory.
+const testWasmModule = `new Uint8Array([
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x0b, 0x02, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f, 0x60, 0x01, 0x7f, 0x00,
+	0x02, 0x0b, 0x01, 0x03, 0x65, 0x6e, 0x76, 0x03, 0x6c, 0x6f, 0x67, 0x00, 0x01,
+	0x03, 0x03, 0x02, 0x00, 0x01,
+	0x05, 0x03, 0x01, 0x00, 0x01,
+	0x07, 0x1a, 0x03,
+	0x03, 0x61, 0x64, 0x64, 0x00, 0x01,
+	0x07, 0x63, 0x61, 0x6c, 0x6c, 0x4c, 0x6f, 0x67, 0x00, 0x02,
+	0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00,
+	0x0a, 0x10, 0x02,
+	0x07, 0x00, 0x20, 0x00, 0x20, 0x01, 0x6a, 0x0b,
+	0x06, 0x00, 0x20, 0x00, 0x10, 0x00, 0x0b,
+])`
+
+func TestWebAssembly(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name, script string
+		expected     interface{}
+	}{
+		{
+			name: "validate",
+			script: `
+				const result = [WebAssembly.validate(wasm), WebAssembly.validate(new Uint8Array([1, 2, 3]))];
+				export default function() { return result; }
+			`,
+			expected: []interface{}{true, false},
+		},
+		{
+			name: "instantiate",
+			script: `
+				let logged = [];
+				let result;
+				WebAssembly.instantiate(wasm, { env: { log: (v) => logged.push(v) } }).then((r) => { result = r; });
+				export default function() {
+					const { add, callLog } = result.instance.exports;
+					callLog(add(40, 2));
+					return [
+						result.module instanceof WebAssembly.Module,
+						result.instance instanceof WebAssembly.Instance,
+						logged[0],
+					];
+				}
+			`,
+			expected: []interface{}{true, true, int64(42)},
+		},
+		{
+			name: "instantiate module",
+			script: `
+				const module = new WebAssembly.Module(wasm);
+				const instance = new WebAssembly.Instance(module, { env: { log: () => {} } });
+				let other;
+				WebAssembly.instantiate(module, { env: { log: () => {} } }).then((i) => { other = i; });
+				export default function() {
+					new Uint8Array(instance.exports.memory.buffer)[0] = 7;
+					return [
+						instance.exports.add(1, 2),
+						new Uint8Array(instance.exports.memory.buffer)[0],
+						new Uint8Array(other.exports.memory.buffer)[0],
+						instance.exports.memory.buffer.byteLength,
+					];
+				}
+			`,
+			expected: []interface{}{int64(3), int64(7), int64(0), int64(65536)},
+		},
+		{
+			name: "exceptions of the imports",
+			script: `
+				const instance = new WebAssembly.Instance(new WebAssembly.Module(wasm), {
+					env: { log: () => { throw new Error("from the import"); } },
+				});
+				export default function() {
+					try {
+						instance.exports.callLog(1);
+					} catch (e) {
+						return e.message;
+					}
+				}
+			`,
+			expected: "from the import",
+		},
+		{
+			name: "missing imports",
+			script: `
+				let error;
+				WebAssembly.instantiate(wasm, { env: {} }).catch((e) => { error = e; });
+				export default function() { return error.toString(); }
+			`,
+			expected: "GoError: the WebAssembly module imports 'env.log', which isn't a function",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := getSimpleBundle(t, "/script.js", "const wasm = "+testWasmModule+";\n"+tc.script)
+			require.NoError(t, err)
+
+			bi, err := b.Instantiate(context.Background(), 0)
+			require.NoError(t, err)
+			v, err := bi.getCallableExport(consts.DefaultFn)(goja.Undefined())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, v.Export())
+		})
+	}
+}
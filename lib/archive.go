@@ -13,7 +13,6 @@ import (
 	"regexp"
 	"sort"
 	"strings"
-	"time"
 
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/loader"
@@ -66,6 +65,7 @@ type Archive struct {
 	Env map[string]string `json:"env"`
 
 	CompatibilityMode string `json:"compatibilityMode"`
+	ModuleResolution  string `json:"moduleResolution,omitempty"`
 
 	K6Version string `json:"k6version"`
 	Goos      string `json:"goos"`
@@ -218,10 +218,14 @@ func getURLtoString(u *url.URL) string {
 // The format should be treated as opaque; currently it is simply a TAR rollup, but this may
 // change. If it does change, ReadArchive must be able to handle all previous formats as well as
 // the current one.
+//
+// The archives are reproducible: the same archive is always written byte for byte the same, so
+// all of the entries have archiveModTime as their modification time. The last entry is the
+// manifest with the checksums of all of the other files, see VerifyArchive.
 func (arc *Archive) Write(out io.Writer) error {
 	w := tar.NewWriter(out)
+	manifest := &ArchiveManifest{}
 
-	now := time.Now()
 	metaArc := *arc
 	normalizeAndAnonymizeURL(metaArc.FilenameURL)
 	normalizeAndAnonymizeURL(metaArc.PwdURL)
@@ -236,25 +240,10 @@ func (arc *Archive) Write(out io.Writer) error {
 	if err != nil {
 		return err
 	}
-	_ = w.WriteHeader(&tar.Header{
-		Name:     "metadata.json",
-		Mode:     0o644,
-		Size:     int64(len(metadata)),
-		ModTime:  now,
-		Typeflag: tar.TypeReg,
-	})
-	if _, err = w.Write(metadata); err != nil {
+	if err = writeArchiveFile(w, manifest, "metadata.json", metadata); err != nil {
 		return err
 	}
-
-	_ = w.WriteHeader(&tar.Header{
-		Name:     "data",
-		Mode:     0o644,
-		Size:     int64(len(arc.Data)),
-		ModTime:  now,
-		Typeflag: tar.TypeReg,
-	})
-	if _, err = w.Write(arc.Data); err != nil {
+	if err = writeArchiveFile(w, manifest, "data", arc.Data); err != nil {
 		return err
 	}
 	for _, name := range [...]string{"file", "https"} {
@@ -275,7 +264,6 @@ func (arc *Archive) Write(out io.Writer) error {
 		//   anonymize paths before stuffing them in a shareable archive.
 		foundDirs := make(map[string]bool)
 		paths := make([]string, 0, 10)
-		infos := make(map[string]fs.FileInfo) // ... fix this ?
 		files := make(map[string][]byte)
 
 		walkFunc := filepath.WalkFunc(func(filePath string, info fs.FileInfo, err error) error {
@@ -284,7 +272,6 @@ func (arc *Archive) Write(out io.Writer) error {
 			}
 			normalizedPath := NormalizeAndAnonymizePath(filePath)
 
-			infos[normalizedPath] = info
 			if info.IsDir() {
 				foundDirs[normalizedPath] = true
 				return nil
@@ -310,12 +297,10 @@ func (arc *Archive) Write(out io.Writer) error {
 
 		for _, dirPath := range dirs {
 			_ = w.WriteHeader(&tar.Header{
-				Name:       path.Clean(path.Join(name, dirPath)),
-				Mode:       0o755, // MemMapFs is buggy
-				AccessTime: now,   // MemMapFs is buggy
-				ChangeTime: now,   // MemMapFs is buggy
-				ModTime:    now,   // MemMapFs is buggy
-				Typeflag:   tar.TypeDir,
+				Name:     path.Clean(path.Join(name, dirPath)),
+				Mode:     0o755, // MemMapFs is buggy
+				ModTime:  archiveModTime,
+				Typeflag: tar.TypeDir,
 			})
 		}
 
@@ -327,22 +312,12 @@ func (arc *Archive) Write(out io.Writer) error {
 				err = w.WriteHeader(&tar.Header{
 					Name:     fullFilePath,
 					Size:     0,
+					ModTime:  archiveModTime,
 					Typeflag: tar.TypeLink,
 					Linkname: "data",
 				})
 			} else {
-				err = w.WriteHeader(&tar.Header{
-					Name:       fullFilePath,
-					Mode:       0o644, // MemMapFs is buggy
-					Size:       int64(len(files[filePath])),
-					AccessTime: infos[filePath].ModTime(),
-					ChangeTime: infos[filePath].ModTime(),
-					ModTime:    infos[filePath].ModTime(),
-					Typeflag:   tar.TypeReg,
-				})
-				if err == nil {
-					_, err = w.Write(files[filePath])
-				}
+				err = writeArchiveFile(w, manifest, fullFilePath, files[filePath])
 			}
 			if err != nil {
 				return err
@@ -354,9 +329,39 @@ func (arc *Archive) Write(out io.Writer) error {
 		return fmt.Errorf("archive creation failed because the main script wasn't present in the cached filesystem")
 	}
 
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = writeArchiveFile(w, nil, ArchiveManifestName, append(manifestData, '\n')); err != nil {
+		return err
+	}
+
 	return w.Close()
 }
 
+// writeArchiveFile writes a regular file to the archive and, unless the
+// manifest is nil, adds its checksum to it.
+func writeArchiveFile(w *tar.Writer, manifest *ArchiveManifest, name string, data []byte) error {
+	err := w.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0o644, // MemMapFs is buggy
+		Size:     int64(len(data)),
+		ModTime:  archiveModTime,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	if manifest != nil {
+		manifest.Files = append(manifest.Files, ArchiveManifestFile{Path: name, SHA256: sha256Hex(data)})
+	}
+	return nil
+}
+
 func (arc *Archive) json() ([]byte, error) {
 	buffer := &bytes.Buffer{}
 	encoder := json.NewEncoder(buffer)
@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encryptedArchiveMagic is the header of the encrypted archives, it's followed
+// by the salt of the key, the nonce, and the archive sealed with AES-256-GCM.
+const encryptedArchiveMagic = "k6-encrypted-archive/v1\n"
+
+// The key of the encrypted archives is derived from their secret with
+// PBKDF2-SHA256 and a random salt.
+const (
+	archiveSaltSize      = 16
+	archiveKeyIterations = 600000
+	archiveKeySize       = 32
+)
+
+// ErrArchiveDecryption is returned when an archive can't be decrypted, either
+// because the secret is wrong or because the archive was changed.
+var ErrArchiveDecryption = errors.New("couldn't decrypt the archive, the passphrase or the key file is wrong")
+
+// IsEncryptedArchive returns whether the data is an archive encrypted with
+// EncryptArchive.
+func IsEncryptedArchive(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedArchiveMagic))
+}
+
+// EncryptArchive encrypts an archive written by Archive.Write with the
+// secret, a passphrase or the content of a key file.
+func EncryptArchive(data, secret []byte) ([]byte, error) {
+	salt := make([]byte, archiveSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newArchiveAEAD(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(encryptedArchiveMagic)+len(salt)+len(nonce)+len(data)+aead.Overhead())
+	out = append(out, encryptedArchiveMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, []byte(encryptedArchiveMagic)), nil
+}
+
+// DecryptArchive decrypts an archive encrypted with EncryptArchive.
+func DecryptArchive(data, secret []byte) ([]byte, error) {
+	if !IsEncryptedArchive(data) {
+		return nil, errors.New("the archive isn't encrypted")
+	}
+	data = data[len(encryptedArchiveMagic):]
+	if len(data) < archiveSaltSize {
+		return nil, ErrArchiveDecryption
+	}
+	aead, err := newArchiveAEAD(secret, data[:archiveSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	data = data[archiveSaltSize:]
+	if len(data) < aead.NonceSize() {
+		return nil, ErrArchiveDecryption
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(encryptedArchiveMagic))
+	if err != nil {
+		return nil, ErrArchiveDecryption
+	}
+	return plain, nil
+}
+
+func newArchiveAEAD(secret, salt []byte) (cipher.AEAD, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("the passphrase or the key file of the archive encryption can't be empty")
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(secret, salt, archiveKeyIterations, archiveKeySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
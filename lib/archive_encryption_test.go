@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveEncryption(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("the archive")
+	encrypted, err := EncryptArchive(data, []byte("secret"))
+	require.NoError(t, err)
+	assert.True(t, IsEncryptedArchive(encrypted))
+	assert.False(t, IsEncryptedArchive(data))
+	assert.NotContains(t, string(encrypted), "the archive")
+
+	decrypted, err := DecryptArchive(encrypted, []byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+
+	_, err = DecryptArchive(encrypted, []byte("wrong"))
+	assert.ErrorIs(t, err, ErrArchiveDecryption)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = DecryptArchive(tampered, []byte("secret"))
+	assert.ErrorIs(t, err, ErrArchiveDecryption)
+
+	_, err = DecryptArchive(encrypted[:len(encryptedArchiveMagic)+4], []byte("secret"))
+	assert.ErrorIs(t, err, ErrArchiveDecryption)
+
+	_, err = DecryptArchive(data, []byte("secret"))
+	assert.EqualError(t, err, "the archive isn't encrypted")
+
+	_, err = EncryptArchive(data, nil)
+	assert.EqualError(t, err, "the passphrase or the key file of the archive encryption can't be empty")
+}
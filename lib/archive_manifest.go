@@ -0,0 +1,99 @@
+package lib
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveManifestName is the name of the manifest entry of the archives.
+const ArchiveManifestName = "manifest.json"
+
+// archiveModTime is the modification time of all of the entries of the
+// archives, so writing the same archive twice gives the same bytes.
+var archiveModTime = time.Unix(0, 0).UTC() //nolint:gochecknoglobals
+
+// ArchiveManifest is the list of all of the files of an archive, with their
+// SHA-256 checksums, in the order they were written.
+type ArchiveManifest struct {
+	Files []ArchiveManifestFile `json:"files"`
+}
+
+// ArchiveManifestFile is a file of an ArchiveManifest.
+type ArchiveManifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// VerifyArchive reads an archive created by Archive.Write and checks that its
+// files are exactly the ones of its manifest, with the same checksums. It
+// returns the manifest, or an error listing all of the differences.
+func VerifyArchive(in io.Reader) (*ArchiveManifest, error) {
+	r := tar.NewReader(in)
+	var manifest *ArchiveManifest
+	checksums := make(map[string]string)
+	for {
+		hdr, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == ArchiveManifestName {
+			manifest = &ArchiveManifest{}
+			if err = json.Unmarshal(data, manifest); err != nil {
+				return nil, fmt.Errorf("couldn't parse the manifest of the archive: %w", err)
+			}
+			continue
+		}
+		checksums[hdr.Name] = sha256Hex(data)
+	}
+	if manifest == nil {
+		return nil, errors.New("the archive doesn't have a manifest, it was created by an older version of k6")
+	}
+
+	var problems []string
+	for _, file := range manifest.Files {
+		checksum, ok := checksums[file.Path]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("'%s' is missing", file.Path))
+		case checksum != file.SHA256:
+			problems = append(problems, fmt.Sprintf("'%s' has the checksum %s instead of %s", file.Path, checksum, file.SHA256))
+		}
+		delete(checksums, file.Path)
+	}
+	extra := make([]string, 0, len(checksums))
+	for name := range checksums {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		problems = append(problems, fmt.Sprintf("'%s' isn't in the manifest", name))
+	}
+	if len(problems) > 0 {
+		return manifest, fmt.Errorf("the archive doesn't match its manifest:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+	return manifest, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
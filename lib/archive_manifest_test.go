@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func writeTestArchive(t *testing.T) []byte {
+	t.Helper()
+
+	arc := &Archive{
+		Type:        "js",
+		K6Version:   consts.Version,
+		FilenameURL: &url.URL{Scheme: "file", Path: "/path/to/a.js"},
+		Data:        []byte(`// a contents`),
+		PwdURL:      &url.URL{Scheme: "file", Path: "/path/to"},
+		Filesystems: map[string]fsext.Fs{
+			"file": makeMemMapFs(t, map[string][]byte{
+				"/path/to/a.js":     []byte(`// a contents`),
+				"/path/to/data.csv": []byte(`a,b`),
+			}),
+		},
+	}
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, arc.Write(buf))
+	return buf.Bytes()
+}
+
+// rewriteTestArchive copies the regular files of an archive, changed by the
+// function, which drops them when it returns nil.
+func rewriteTestArchive(t *testing.T, data []byte, change func(name string, data []byte) []byte) []byte {
+	t.Helper()
+
+	r := tar.NewReader(bytes.NewReader(data))
+	buf := bytes.NewBuffer(nil)
+	w := tar.NewWriter(buf)
+	for {
+		hdr, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		if hdr.Typeflag == tar.TypeReg {
+			if content = change(hdr.Name, content); content == nil {
+				continue
+			}
+			hdr.Size = int64(len(content))
+		}
+		require.NoError(t, w.WriteHeader(hdr))
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestVerifyArchive(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		manifest, err := VerifyArchive(bytes.NewReader(writeTestArchive(t)))
+		require.NoError(t, err)
+		paths := make([]string, len(manifest.Files))
+		for i, file := range manifest.Files {
+			paths[i] = file.Path
+		}
+		assert.Equal(t, []string{"metadata.json", "data", "file/path/to/data.csv"}, paths)
+		assert.Equal(t, sha256Hex([]byte(`a,b`)), manifest.Files[2].SHA256)
+	})
+
+	t.Run("readable", func(t *testing.T) {
+		t.Parallel()
+
+		arc, err := ReadArchive(bytes.NewReader(writeTestArchive(t)))
+		require.NoError(t, err)
+		data, err := fsext.ReadFile(arc.Filesystems["file"], "/path/to/data.csv")
+		require.NoError(t, err)
+		assert.Equal(t, "a,b", string(data))
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		t.Parallel()
+
+		data := rewriteTestArchive(t, writeTestArchive(t), func(name string, data []byte) []byte {
+			switch name {
+			case "file/path/to/data.csv":
+				return []byte(`a,c`)
+			case "data":
+				return nil
+			}
+			return data
+		})
+		_, err := VerifyArchive(bytes.NewReader(data))
+		require.Error(t, err)
+		assert.Equal(t, "the archive doesn't match its manifest:\n"+
+			"\t'data' is missing\n"+
+			"\t'file/path/to/data.csv' has the checksum "+sha256Hex([]byte(`a,c`))+" instead of "+sha256Hex([]byte(`a,b`)),
+			err.Error())
+	})
+
+	t.Run("extra file", func(t *testing.T) {
+		t.Parallel()
+
+		data := rewriteTestArchive(t, writeTestArchive(t), func(name string, data []byte) []byte {
+			if name == ArchiveManifestName {
+				manifest := &ArchiveManifest{}
+				require.NoError(t, json.Unmarshal(data, manifest))
+				manifest.Files = manifest.Files[:2]
+				data, err := json.Marshal(manifest)
+				require.NoError(t, err)
+				return data
+			}
+			return data
+		})
+		_, err := VerifyArchive(bytes.NewReader(data))
+		assert.EqualError(t, err, "the archive doesn't match its manifest:\n\t'file/path/to/data.csv' isn't in the manifest")
+	})
+
+	t.Run("no manifest", func(t *testing.T) {
+		t.Parallel()
+
+		data := rewriteTestArchive(t, writeTestArchive(t), func(name string, data []byte) []byte {
+			if name == ArchiveManifestName {
+				return nil
+			}
+			return data
+		})
+		_, err := VerifyArchive(bytes.NewReader(data))
+		assert.EqualError(t, err, "the archive doesn't have a manifest, it was created by an older version of k6")
+	})
+}
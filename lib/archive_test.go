@@ -1,14 +1,18 @@
 package lib
 
 import (
+	"archive/tar"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"net/url"
 	"path"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -387,3 +391,51 @@ func TestStdinArchive(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, string(data), "test")
 }
+
+func TestArchiveReproducible(t *testing.T) {
+	t.Parallel()
+
+	write := func(modTime time.Time) []byte {
+		filesystem := makeMemMapFs(t, map[string][]byte{
+			"/path/to/a.js":     []byte(`// a contents`),
+			"/path/to/b.js":     []byte(`// b contents`),
+			"/path/to/file.txt": []byte(`hi!`),
+		})
+		for _, name := range []string{"/path/to/a.js", "/path/to/b.js", "/path/to/file.txt"} {
+			require.NoError(t, filesystem.Chtimes(name, modTime, modTime))
+		}
+		arc := &Archive{
+			Type:        "js",
+			K6Version:   consts.Version,
+			Options:     Options{VUs: null.IntFrom(10)},
+			Env:         map[string]string{"B": "2", "A": "1", "C": "3"},
+			FilenameURL: &url.URL{Scheme: "file", Path: "/path/to/a.js"},
+			Data:        []byte(`// a contents`),
+			PwdURL:      &url.URL{Scheme: "file", Path: "/path/to"},
+			Filesystems: map[string]fsext.Fs{"file": filesystem},
+		}
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, arc.Write(buf))
+		return buf.Bytes()
+	}
+
+	first := write(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	second := write(time.Now())
+	assert.Equal(t, first, second)
+
+	r := tar.NewReader(bytes.NewReader(first))
+	var names []string
+	for {
+		hdr, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+		assert.True(t, archiveModTime.Equal(hdr.ModTime), hdr.Name)
+	}
+	assert.Equal(t, []string{
+		"metadata.json", "data", "file", "file/path", "file/path/to",
+		"file/path/to/a.js", "file/path/to/b.js", "file/path/to/file.txt", ArchiveManifestName,
+	}, names)
+}
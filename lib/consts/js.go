@@ -6,5 +6,7 @@ const (
 	Options         = "options"
 	SetupFn         = "setup"
 	TeardownFn      = "teardown"
+	VUSetupFn       = "vuSetup"
+	VUTeardownFn    = "vuTeardown"
 	HandleSummaryFn = "handleSummary"
 )
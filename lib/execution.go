@@ -80,6 +80,15 @@ type ExecutionState struct {
 
 	ExecutionTuple *ExecutionTuple // TODO Rename, possibly move
 
+	// The key-value store that is shared between all VUs and scenarios. By
+	// default it's in-memory, but the execution controller can replace it
+	// with one that is shared between all instances of a distributed test.
+	SharedStore SharedStore
+
+	// The tags and test parameters that can be changed while the test is
+	// running, e.g. through the REST API.
+	LiveConfig *LiveConfig
+
 	// vus is the shared channel buffer that contains all of the VUs that have
 	// been initialized and aren't currently being used by a executor.
 	//
@@ -130,6 +139,10 @@ type ExecutionState struct {
 	// no more unplanned VUs can be initialized.
 	uninitializedUnplannedVUs *int64
 
+	// Number of the unplanned VUs being warmed up in the background, which
+	// will be added to the vus buffer once they are initialized.
+	warmingVUs *int64
+
 	// Injected when the execution scheduler's Init function is called, used for
 	// initializing unplanned VUs.
 	initVUFunc InitVUFunc
@@ -152,6 +165,10 @@ type ExecutionState struct {
 	// API, etc.
 	interruptedIterationsCount *uint64
 
+	// The total number of full iterations which ended with an error, like an
+	// exception thrown by the script.
+	failedIterationsCount *uint64
+
 	// A machine-readable indicator in which the current state of the test
 	// execution is currently stored. Useful for the REST API and external
 	// observability of the k6 test run progress.
@@ -196,6 +213,18 @@ type ExecutionState struct {
 	pauseStateLock      sync.RWMutex
 	totalPausedDuration time.Duration // only modified behind the lock
 	resumeNotify        chan struct{}
+
+	// The number of iterations that are currently being executed by the VUs,
+	// so a graceful abort of the test can stop the executors as soon as the
+	// last one of them has finished.
+	runningIterations *int64
+
+	// When a script calls test.abort() with the graceful option, the reason
+	// is saved and gracefulAbortNotify is closed, so the executors stop
+	// starting new iterations. Both are only modified once.
+	gracefulAbortOnce   sync.Once
+	gracefulAbortReason error
+	gracefulAbortNotify chan struct{}
 }
 
 // NewExecutionState initializes all of the pointers in the ExecutionState
@@ -213,6 +242,8 @@ func NewExecutionState(
 	return &ExecutionState{
 		Test:           testRunState,
 		ExecutionTuple: et,
+		SharedStore:    NewMemorySharedStore(),
+		LiveConfig:     NewLiveConfig(),
 
 		vus: make(chan InitializedVU, maxPossibleVUs),
 
@@ -221,8 +252,10 @@ func NewExecutionState(
 		vuIDSegIndex:               segIdx,
 		initializedVUs:             new(int64),
 		uninitializedUnplannedVUs:  &maxUnplannedUninitializedVUs,
+		warmingVUs:                 new(int64),
 		activeVUs:                  new(int64),
 		fullIterationsCount:        new(uint64),
+		failedIterationsCount:      new(uint64),
 		interruptedIterationsCount: new(uint64),
 		startTime:                  new(int64),
 		endTime:                    new(int64),
@@ -230,6 +263,8 @@ func NewExecutionState(
 		pauseStateLock:             sync.RWMutex{},
 		totalPausedDuration:        0, // Accessed only behind the pauseStateLock
 		resumeNotify:               resumeNotify,
+		runningIterations:          new(int64),
+		gracefulAbortNotify:        make(chan struct{}),
 	}
 }
 
@@ -293,6 +328,22 @@ func (es *ExecutionState) AddFullIterations(count uint64) uint64 {
 	return atomic.AddUint64(es.fullIterationsCount, count)
 }
 
+// GetFailedIterationCount returns the total of full iterations which ended
+// with an error so far.
+//
+// IMPORTANT: for UI/information purposes only, don't use for synchronization.
+func (es *ExecutionState) GetFailedIterationCount() uint64 {
+	return atomic.LoadUint64(es.failedIterationsCount)
+}
+
+// AddFailedIterations increments the number of the full iterations which ended
+// with an error by the provided amount.
+//
+// IMPORTANT: for UI/information purposes only, don't use for synchronization.
+func (es *ExecutionState) AddFailedIterations(count uint64) uint64 {
+	return atomic.AddUint64(es.failedIterationsCount, count)
+}
+
 // GetPartialIterationCount returns the total of partial (i.e interrupted)
 // iterations that have been completed so far.
 //
@@ -309,6 +360,49 @@ func (es *ExecutionState) AddInterruptedIterations(count uint64) uint64 {
 	return atomic.AddUint64(es.interruptedIterationsCount, count)
 }
 
+// ModRunningIterationsCount changes the number of the iterations which are
+// currently being executed by the supplied amount and returns the new value.
+func (es *ExecutionState) ModRunningIterationsCount(mod int64) int64 {
+	return atomic.AddInt64(es.runningIterations, mod)
+}
+
+// GetRunningIterationsCount returns the number of the iterations which are
+// currently being executed.
+func (es *ExecutionState) GetRunningIterationsCount() int64 {
+	return atomic.LoadInt64(es.runningIterations)
+}
+
+// AbortGracefully marks the test as gracefully aborted for the supplied
+// reason: no new iterations are started, while the running ones have the
+// gracefulStop of their scenarios to finish. It returns false if the test had
+// already been aborted gracefully, in which case the reason isn't changed.
+func (es *ExecutionState) AbortGracefully(reason error) bool {
+	aborted := false
+	es.gracefulAbortOnce.Do(func() {
+		es.gracefulAbortReason = reason
+		close(es.gracefulAbortNotify)
+		aborted = true
+	})
+	return aborted
+}
+
+// GracefulAbortNotify returns a channel which is closed when the test is
+// aborted gracefully.
+func (es *ExecutionState) GracefulAbortNotify() <-chan struct{} {
+	return es.gracefulAbortNotify
+}
+
+// GetGracefulAbortReason returns the reason the test was aborted gracefully
+// for, or nil if it wasn't.
+func (es *ExecutionState) GetGracefulAbortReason() error {
+	select {
+	case <-es.gracefulAbortNotify:
+		return es.gracefulAbortReason
+	default:
+		return nil
+	}
+}
+
 // SetExecutionStatus changes the current execution status to the supplied value
 // and returns the current value.
 func (es *ExecutionState) SetExecutionStatus(newStatus ExecutionStatus) (oldStatus ExecutionStatus) {
@@ -509,8 +603,11 @@ func (es *ExecutionState) SetInitVUFunc(initVUFunc InitVUFunc) {
 func (es *ExecutionState) GetUnplannedVU(ctx context.Context, logger *logrus.Entry) (InitializedVU, error) {
 	remVUs := atomic.AddInt64(es.uninitializedUnplannedVUs, -1)
 	if remVUs < 0 {
-		logger.Debug("Reusing a previously initialized unplanned VU")
 		atomic.AddInt64(es.uninitializedUnplannedVUs, 1)
+		if atomic.LoadInt64(es.warmingVUs) > 0 {
+			return es.getWarmedUpVU(ctx, logger)
+		}
+		logger.Debug("Reusing a previously initialized unplanned VU")
 		return es.GetPlannedVU(logger, false)
 	}
 
@@ -518,6 +615,74 @@ func (es *ExecutionState) GetUnplannedVU(ctx context.Context, logger *logrus.Ent
 	return es.InitializeNewVU(ctx, logger)
 }
 
+// WarmUnplannedVUs initializes up to count of the unplanned VUs in the
+// background, with the given concurrency, and adds them to the buffer as soon
+// as they are ready. So the executors needing them get them from the buffer
+// with GetUnplannedVU(), instead of waiting for their initialization in the
+// middle of the test run. It returns the number of the VUs it initializes,
+// and a function waiting for them.
+//
+// Only the executors with preAllocatedVUs and maxVUs, i.e. the arrival-rate
+// and the traffic-replay ones, have unplanned VUs. The VUs that
+// externally-controlled initializes when it's scaled over its vus bypass
+// them, with InitializeNewVU(), so they aren't warmed up.
+func (es *ExecutionState) WarmUnplannedVUs(
+	ctx context.Context, logger *logrus.Entry, count int64, concurrency int,
+) (warming int64, wait func()) {
+	for warming < count {
+		if atomic.AddInt64(es.uninitializedUnplannedVUs, -1) < 0 {
+			atomic.AddInt64(es.uninitializedUnplannedVUs, 1)
+			break
+		}
+		warming++
+	}
+
+	atomic.AddInt64(es.warmingVUs, warming)
+
+	wg := &sync.WaitGroup{}
+	limiter := make(chan struct{}, concurrency)
+	for i := int64(0); i < warming; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+			defer atomic.AddInt64(es.warmingVUs, -1)
+
+			vu, err := es.InitializeNewVU(ctx, logger)
+			if err != nil {
+				// the VU can still be initialized when it's needed
+				atomic.AddInt64(es.uninitializedUnplannedVUs, 1)
+				logger.WithError(err).Warn("Couldn't warm up an unplanned VU")
+				return
+			}
+			es.vus <- vu
+		}()
+	}
+	return warming, wg.Wait
+}
+
+// getWarmedUpVU waits for a VU from the buffer while the unplanned VUs are
+// warmed up. If they are all done without one being available, it falls back
+// to GetUnplannedVU(), since the failed ones can be initialized again.
+func (es *ExecutionState) getWarmedUpVU(ctx context.Context, logger *logrus.Entry) (InitializedVU, error) {
+	logger.Debug("Waiting for a warmed up unplanned VU")
+	ticker := time.NewTicker(MaxTimeToWaitForPlannedVU)
+	defer ticker.Stop()
+	for {
+		select {
+		case vu := <-es.vus:
+			return vu, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+			if atomic.LoadInt64(es.warmingVUs) == 0 {
+				return es.GetUnplannedVU(ctx, logger)
+			}
+		}
+	}
+}
+
 // InitializeNewVU creates and returns a brand new VU, updating the relevant
 // tracking counters.
 func (es *ExecutionState) InitializeNewVU(ctx context.Context, logger *logrus.Entry) (InitializedVU, error) {
@@ -539,6 +704,21 @@ func (es *ExecutionState) AddInitializedVU(vu InitializedVU) {
 	es.ModInitializedVUsCount(+1)
 }
 
+// DrainVUs removes all of the VUs that are currently in the buffer and returns
+// them. It is used at the end of the test run, once all executors are done and
+// have returned their VUs, so that the VUs can be torn down.
+func (es *ExecutionState) DrainVUs() []InitializedVU {
+	var vus []InitializedVU
+	for {
+		select {
+		case vu := <-es.vus:
+			vus = append(vus, vu)
+		default:
+			return vus
+		}
+	}
+}
+
 // ReturnVU is a helper function that puts VUs back into the buffer and
 // decreases the active VUs counter.
 func (es *ExecutionState) ReturnVU(vu InitializedVU, wasActive bool) {
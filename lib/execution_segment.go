@@ -161,6 +161,26 @@ func (es *ExecutionSegment) FloatLength() float64 {
 	return res
 }
 
+// FloatStart is a helper method for getting the start of the execution
+// segment as a float number.
+func (es *ExecutionSegment) FloatStart() float64 {
+	if es == nil {
+		return 0.0
+	}
+	res, _ := es.from.Float64()
+	return res
+}
+
+// FloatEnd is a helper method for getting the end of the execution segment as
+// a float number.
+func (es *ExecutionSegment) FloatEnd() float64 {
+	if es == nil {
+		return 1.0
+	}
+	res, _ := es.to.Float64()
+	return res
+}
+
 // Split evenly divides the execution segment into the specified number of
 // equal consecutive execution sub-segments.
 func (es *ExecutionSegment) Split(numParts int64) ([]*ExecutionSegment, error) {
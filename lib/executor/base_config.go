@@ -11,6 +11,7 @@ import (
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
 )
 
 // DefaultGracefulStopValue is the graceful top value for all executors, unless
@@ -33,6 +34,44 @@ type BaseConfig struct {
 	Tags         map[string]string    `json:"tags"`
 	Options      *lib.ScenarioOptions `json:"options,omitempty"`
 
+	// Optional functions that are run once before and once after the
+	// scenario, with their own timeouts, also externally validated; they
+	// are omitted from the JSON when they aren't set
+	Setup           string         `json:"setup,omitempty"`
+	Teardown        string         `json:"teardown,omitempty"`
+	SetupTimeout    types.Duration `json:"setupTimeout,omitempty"`
+	TeardownTimeout types.Duration `json:"teardownTimeout,omitempty"`
+
+	// Other scenarios that have to finish, or with a ":setup" suffix only
+	// complete their setup, and an optional metric condition that has to be
+	// met before the scenario starts, after its startTime
+	DependsOn []string             `json:"dependsOn"`
+	StartWhen *lib.MetricCondition `json:"startWhen"`
+
+	// An optional condition on the error rate of the requests of the scenario
+	// which aborts the whole test when it's met
+	AbortOnErrorRate *metrics.ErrorRateAbort `json:"abortOnErrorRate"`
+
+	// Overrides the global discardResponseBodies option for the requests
+	// made by the scenario
+	DiscardResponseBodies null.Bool `json:"discardResponseBodies"`
+
+	// Caps on the network bandwidth of each VU of the scenario, overriding
+	// the global vuBandwidth option, and of all of them together
+	VUBandwidth *types.Bandwidth `json:"vuBandwidth"`
+	Bandwidth   *types.Bandwidth `json:"bandwidth"`
+
+	// The local IPs the connections of the VUs of the scenario are bound to,
+	// round-robin by VU, overriding the global --local-ips option
+	LocalIPs *types.NullIPPool `json:"localIPs"`
+
+	// The proxy of the HTTP requests of the scenario, instead of the one of
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy *types.Proxy `json:"proxy"`
+
+	// The settings of the HTTP/2 connections of the scenario
+	HTTP2 *types.HTTP2 `json:"http2"`
+
 	// TODO: future extensions like distribution, others?
 }
 
@@ -58,6 +97,51 @@ func (bc BaseConfig) Validate() (errors []error) {
 	if bc.Exec.Valid && bc.Exec.String == "" {
 		errors = append(errors, fmt.Errorf("exec value cannot be empty"))
 	}
+	if bc.SetupTimeout < 0 {
+		errors = append(errors, fmt.Errorf("the setupTimeout can't be negative"))
+	}
+	if bc.TeardownTimeout < 0 {
+		errors = append(errors, fmt.Errorf("the teardownTimeout can't be negative"))
+	}
+	for _, dep := range bc.DependsOn {
+		name, onlySetup := parseScenarioDependency(dep)
+		switch {
+		case name == "" || strings.Contains(name, ":") && !onlySetup:
+			errors = append(errors, fmt.Errorf("invalid dependsOn value '%s'", dep))
+		case name == bc.Name:
+			errors = append(errors, fmt.Errorf("the scenario can't depend on itself"))
+		}
+	}
+	if bc.StartWhen != nil {
+		if err := validateMetricCondition(*bc.StartWhen); err != nil {
+			errors = append(errors, fmt.Errorf("invalid startWhen value: %w", err))
+		}
+	}
+	if bc.AbortOnErrorRate != nil {
+		if err := bc.AbortOnErrorRate.Validate(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if bc.VUBandwidth != nil {
+		if err := bc.VUBandwidth.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid vuBandwidth: %w", err))
+		}
+	}
+	if bc.Bandwidth != nil {
+		if err := bc.Bandwidth.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid bandwidth: %w", err))
+		}
+	}
+	if bc.Proxy != nil {
+		if err := bc.Proxy.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid proxy: %w", err))
+		}
+	}
+	if bc.HTTP2 != nil {
+		if err := bc.HTTP2.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid http2: %w", err))
+		}
+	}
 	if bc.Type == "" {
 		errors = append(errors, fmt.Errorf("missing or empty type field"))
 	}
@@ -112,6 +196,100 @@ func (bc BaseConfig) GetExec() string {
 	return exec
 }
 
+// GetLifecycle returns the configured scenario-level setup and teardown
+// functions, if any. Unset timeouts are returned as 0, which means that the
+// global setupTimeout and teardownTimeout options should be used.
+func (bc BaseConfig) GetLifecycle() lib.ScenarioLifecycle {
+	return lib.ScenarioLifecycle{
+		Setup:           bc.Setup,
+		Teardown:        bc.Teardown,
+		SetupTimeout:    time.Duration(bc.SetupTimeout),
+		TeardownTimeout: time.Duration(bc.TeardownTimeout),
+	}
+}
+
+// GetStartConditions returns the scenarios this one depends on and the
+// optional metric condition for its start.
+func (bc BaseConfig) GetStartConditions() lib.ScenarioStartConditions {
+	conditions := lib.ScenarioStartConditions{StartWhen: bc.StartWhen}
+	for _, dep := range bc.DependsOn {
+		name, onlySetup := parseScenarioDependency(dep)
+		conditions.DependsOn = append(conditions.DependsOn, lib.ScenarioDependency{
+			Scenario:  name,
+			OnlySetup: onlySetup,
+		})
+	}
+	return conditions
+}
+
+// GetAbortOnErrorRate returns the condition on the error rate of the requests
+// of the scenario to abort the test, if any.
+func (bc BaseConfig) GetAbortOnErrorRate() *metrics.ErrorRateAbort {
+	return bc.AbortOnErrorRate
+}
+
+// GetDiscardResponseBodies returns if the response bodies of the requests of
+// the scenario are discarded by default. It isn't valid when the scenario
+// doesn't override the global option.
+func (bc BaseConfig) GetDiscardResponseBodies() null.Bool {
+	return bc.DiscardResponseBodies
+}
+
+// GetVUBandwidth returns the caps on the bandwidth of each VU of the scenario,
+// or nil if the global ones apply.
+func (bc BaseConfig) GetVUBandwidth() *types.Bandwidth {
+	return bc.VUBandwidth
+}
+
+// GetBandwidth returns the caps on the bandwidth of all VUs of the scenario
+// together, if any.
+func (bc BaseConfig) GetBandwidth() *types.Bandwidth {
+	return bc.Bandwidth
+}
+
+// GetLocalIPs returns the local IPs the connections of the VUs of the scenario
+// are bound to, or nil if the global ones apply.
+func (bc BaseConfig) GetLocalIPs() *types.NullIPPool {
+	return bc.LocalIPs
+}
+
+// GetProxy returns the proxy of the HTTP requests of the scenario, or nil if
+// the one of the environment variables applies.
+func (bc BaseConfig) GetProxy() *types.Proxy {
+	return bc.Proxy
+}
+
+// GetHTTP2 returns the settings of the HTTP/2 connections of the scenario, or
+// nil if the defaults apply.
+func (bc BaseConfig) GetHTTP2() *types.HTTP2 {
+	return bc.HTTP2
+}
+
+// parseScenarioDependency parses a dependsOn value, which is either the name of
+// a scenario or the name followed by ":setup".
+func parseScenarioDependency(dep string) (name string, onlySetup bool) {
+	if strings.HasSuffix(dep, ":setup") {
+		return strings.TrimSuffix(dep, ":setup"), true
+	}
+	return dep, false
+}
+
+// validateMetricCondition checks that the metric name and the condition
+// expression of a startWhen value are valid.
+func validateMetricCondition(mc lib.MetricCondition) error {
+	if _, _, err := metrics.ParseMetricName(mc.Metric); err != nil {
+		return err
+	}
+	thresholds := metrics.NewThresholds([]string{mc.Condition})
+	if err := thresholds.Parse(); err != nil {
+		return err
+	}
+	if thresholds.IsWindowed() {
+		return fmt.Errorf("the condition '%s' can't be evaluated over windows", mc.Condition)
+	}
+	return nil
+}
+
 // GetScenarioOptions returns the options specific to a scenario.
 func (bc BaseConfig) GetScenarioOptions() *lib.ScenarioOptions {
 	return bc.Options
@@ -133,9 +311,24 @@ func (bc BaseConfig) getBaseInfo(facts ...string) string {
 	if bc.Exec.Valid {
 		facts = append(facts, fmt.Sprintf("exec: %s", bc.Exec.String))
 	}
+	if bc.Setup != "" {
+		facts = append(facts, fmt.Sprintf("setup: %s", bc.Setup))
+	}
+	if bc.Teardown != "" {
+		facts = append(facts, fmt.Sprintf("teardown: %s", bc.Teardown))
+	}
 	if bc.StartTime.Duration > 0 {
 		facts = append(facts, fmt.Sprintf("startTime: %s", bc.StartTime.Duration))
 	}
+	if len(bc.DependsOn) > 0 {
+		facts = append(facts, fmt.Sprintf("dependsOn: %s", strings.Join(bc.DependsOn, ", ")))
+	}
+	if bc.StartWhen != nil {
+		facts = append(facts, fmt.Sprintf("startWhen: %s %s", bc.StartWhen.Metric, bc.StartWhen.Condition))
+	}
+	if bc.AbortOnErrorRate != nil {
+		facts = append(facts, fmt.Sprintf("abortOnErrorRate: %s", bc.AbortOnErrorRate))
+	}
 	if bc.GracefulStop.Duration > 0 {
 		facts = append(facts, fmt.Sprintf("gracefulStop: %s", bc.GracefulStop.Duration))
 	}
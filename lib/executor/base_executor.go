@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"sync"
 
@@ -23,6 +24,11 @@ type BaseExecutor struct {
 	iterSegIndex   *lib.SegmentedIndex
 	logger         *logrus.Entry
 	progress       *pb.ProgressBar
+
+	// resumeNotify is nil, unless the scenario was paused on its own, in which
+	// case it will be closed when the scenario is resumed
+	pauseLock    *sync.RWMutex
+	resumeNotify chan struct{}
 }
 
 // NewBaseExecutor returns an initialized BaseExecutor
@@ -34,6 +40,7 @@ func NewBaseExecutor(config lib.ExecutorConfig, es *lib.ExecutionState, logger *
 		logger:         logger,
 		iterSegIndexMx: new(sync.Mutex),
 		iterSegIndex:   segIdx,
+		pauseLock:      new(sync.RWMutex),
 		progress: pb.New(
 			pb.WithLeft(config.GetName),
 			pb.WithLogger(logger),
@@ -70,6 +77,54 @@ func (bs *BaseExecutor) GetProgress() *pb.ProgressBar {
 	return bs.progress
 }
 
+// SetScenarioPaused pauses or resumes only the scenario of the executor, while
+// the rest of the test keeps running. While the scenario is paused, its VUs
+// finish their current iterations, but they don't start new ones. The
+// executor's own timeline isn't paused, e.g. the stages continue to progress.
+func (bs *BaseExecutor) SetScenarioPaused(paused bool) error {
+	bs.pauseLock.Lock()
+	defer bs.pauseLock.Unlock()
+
+	if paused {
+		if bs.resumeNotify != nil {
+			return fmt.Errorf("scenario '%s' is already paused", bs.config.GetName())
+		}
+		bs.resumeNotify = make(chan struct{})
+		return nil
+	}
+	if bs.resumeNotify == nil {
+		return fmt.Errorf("scenario '%s' isn't paused", bs.config.GetName())
+	}
+	close(bs.resumeNotify)
+	bs.resumeNotify = nil
+	return nil
+}
+
+// IsScenarioPaused returns whether the scenario of the executor was paused on
+// its own.
+func (bs *BaseExecutor) IsScenarioPaused() bool {
+	bs.pauseLock.RLock()
+	defer bs.pauseLock.RUnlock()
+	return bs.resumeNotify != nil
+}
+
+// waitForScenarioResume blocks while the scenario of the executor is paused.
+// It returns false if the context was done before the scenario was resumed.
+func (bs *BaseExecutor) waitForScenarioResume(ctx context.Context) bool {
+	bs.pauseLock.RLock()
+	resumeNotify := bs.resumeNotify
+	bs.pauseLock.RUnlock()
+	if resumeNotify == nil {
+		return true
+	}
+	select {
+	case <-resumeNotify:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // getMetricTags returns a tag set that can be used to emit metrics by the
 // executor. The VU ID is optional.
 func (bs *BaseExecutor) getMetricTags(vuID *uint64) *metrics.TagSet {
@@ -168,7 +168,7 @@ func (clv ConstantVUs) Run(parentCtx context.Context, out chan<- metrics.SampleC
 	defer activeVUs.Wait()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(clv.executionState, clv.logger)
+	runIteration := getIterationRunner(clv.BaseExecutor)
 
 	returnVU := func(u lib.InitializedVU) {
 		clv.executionState.ReturnVU(u, true)
@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -52,3 +53,35 @@ func TestConstantVUsRun(t *testing.T) {
 	})
 	assert.Equal(t, uint64(50), totalIters)
 }
+
+func TestConstantVUsScenarioPause(t *testing.T) {
+	t.Parallel()
+	var iterations int64
+
+	runner := simpleRunner(func(ctx context.Context, _ *lib.State) error {
+		atomic.AddInt64(&iterations, 1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestConstantVUsConfig())
+	defer test.cancel()
+
+	pausable, ok := test.executor.(lib.ScenarioPausableExecutor)
+	require.True(t, ok)
+	require.NoError(t, pausable.SetScenarioPaused(true))
+	assert.True(t, pausable.IsScenarioPaused())
+	assert.ErrorContains(t, pausable.SetScenarioPaused(true), "is already paused")
+
+	errCh := make(chan error)
+	go func() { errCh <- test.executor.Run(test.ctx, nil) }()
+
+	time.Sleep(500 * time.Millisecond)
+	assert.Zero(t, atomic.LoadInt64(&iterations))
+	require.NoError(t, pausable.SetScenarioPaused(false))
+	assert.False(t, pausable.IsScenarioPaused())
+	assert.ErrorContains(t, pausable.SetScenarioPaused(false), "isn't paused")
+
+	require.NoError(t, <-errCh)
+	assert.NotZero(t, atomic.LoadInt64(&iterations))
+}
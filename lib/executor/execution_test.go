@@ -169,6 +169,48 @@ func TestExecutionStateGettingVUs(t *testing.T) {
 	}
 }
 
+func TestExecutionStateWarmUnplannedVUs(t *testing.T) {
+	t.Parallel()
+	logEntry := testutils.NewLogger(t).WithField("test", t.Name())
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(nil, et, 2, 5)
+	release := make(chan struct{})
+	es.SetInitVUFunc(func(_ context.Context, _ *logrus.Entry) (lib.InitializedVU, error) {
+		<-release
+		return &minirunner.VU{}, nil
+	})
+
+	// only the 3 unplanned VUs can be warmed up
+	warming, wait := es.WarmUnplannedVUs(context.Background(), logEntry, 10, 2)
+	require.EqualValues(t, 3, warming)
+
+	// the unplanned VUs are taken from the warm ones, once they are ready
+	got := make(chan lib.InitializedVU)
+	go func() {
+		vu, err := es.GetUnplannedVU(context.Background(), logEntry)
+		assert.NoError(t, err)
+		got <- vu
+	}()
+	select {
+	case <-got:
+		t.Fatal("got a VU before it was warmed up")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	require.NotNil(t, <-got)
+	wait()
+	require.EqualValues(t, 3, es.GetInitializedVUsCount())
+
+	for i := 0; i < 2; i++ {
+		vu, err := es.GetUnplannedVU(context.Background(), logEntry)
+		require.NoError(t, err)
+		require.NotNil(t, vu)
+	}
+	require.EqualValues(t, 3, es.GetInitializedVUsCount())
+}
+
 func TestMarkStartedPanicsOnSecondRun(t *testing.T) {
 	t.Parallel()
 	et, err := lib.NewExecutionTuple(nil, nil)
@@ -419,6 +419,40 @@ var configMapTestCases = []configMapTestCase{
 	},
 	// only the "browser" scenario option is supported
 	{`{"ui": {"executor": "shared-iterations", "iterations": 22, "vus": 12, "maxDuration": "100s", "options": {"unsupported": {}}}}`, exp{parseError: true}},
+
+	// start conditions
+	{
+		`{"a": {"executor": "shared-iterations"},
+		"b": {"executor": "shared-iterations", "dependsOn": ["a"]},
+		"c": {"executor": "shared-iterations", "dependsOn": ["a:setup", "b"], "startWhen": {"metric": "http_reqs{status:200}", "condition": "count>100"}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Equal(t, lib.ScenarioStartConditions{
+				DependsOn: []lib.ScenarioDependency{{Scenario: "a", OnlySetup: true}, {Scenario: "b"}},
+				StartWhen: &lib.MetricCondition{Metric: "http_reqs{status:200}", Condition: "count>100"},
+			}, cm["c"].GetStartConditions())
+			assert.Empty(t, cm["a"].GetStartConditions().DependsOn)
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t,
+				"1 iterations shared among 1 VUs (maxDuration: 10m0s, dependsOn: a:setup, b, "+
+					"startWhen: http_reqs{status:200} count>100, gracefulStop: 30s)",
+				cm["c"].GetDescription(et))
+		}},
+	},
+	{`{"a": {"executor": "shared-iterations", "dependsOn": ["b"]}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "dependsOn": ["a"]}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "dependsOn": [""]}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "dependsOn": ["b:teardown"]}, "b": {"executor": "shared-iterations"}}`, exp{validationError: true}},
+	{
+		`{"a": {"executor": "shared-iterations", "dependsOn": ["c"]},
+		"b": {"executor": "shared-iterations", "dependsOn": ["a:setup"]},
+		"c": {"executor": "shared-iterations", "dependsOn": ["b"]}}`,
+		exp{validationError: true},
+	},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"metric": "http_reqs", "condition": "count>"}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"metric": "http_reqs{", "condition": "count>1"}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"metric": "http_reqs", "condition": "count>1 over 1m"}}}`, exp{validationError: true}},
 }
 
 func TestConfigMapParsingAndValidation(t *testing.T) {
@@ -523,7 +523,7 @@ func (mex *ExternallyControlled) Run(parentCtx context.Context, out chan<- metri
 		currentlyPaused: false,
 		activeVUsCount:  new(int64),
 		maxVUs:          new(int64),
-		runIteration:    getIterationRunner(mex.executionState, mex.logger),
+		runIteration:    getIterationRunner(mex.BaseExecutor),
 	}
 	ss.ProgressFn = runState.progressFn
 
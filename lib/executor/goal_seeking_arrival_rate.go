@@ -0,0 +1,518 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/ui/pb"
+)
+
+const goalSeekingArrivalRateType = "goal-seeking-arrival-rate"
+
+// GoalSeekingCapacityMetricName is the name of the gauge metric with the
+// highest arrival rate, in iterations per second, that met the SLO.
+const GoalSeekingCapacityMetricName = "goal_seeking_capacity"
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		goalSeekingArrivalRateType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewGoalSeekingArrivalRateConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// GoalSeekingArrivalRateConfig stores the config for the goal-seeking
+// arrival-rate executor. It runs a number of steps with a constant arrival
+// rate each, and uses binary search between minRate and maxRate to find the
+// highest rate at which the iterations still meet the SLO.
+type GoalSeekingArrivalRateConfig struct {
+	BaseConfig
+	TimeUnit types.NullDuration `json:"timeUnit"`
+	MinRate  null.Int           `json:"minRate"`
+	MaxRate  null.Int           `json:"maxRate"`
+
+	// Every step runs for StepDuration and its results decide the rate of
+	// the next one.
+	StepDuration types.NullDuration `json:"stepDuration"`
+	Steps        null.Int           `json:"steps"`
+
+	// The SLO is met when the p95 of the iteration durations isn't more than
+	// MaxP95Duration and the ratio of failed and dropped iterations isn't
+	// more than MaxErrorRate. At least one of them has to be specified.
+	MaxP95Duration types.NullDuration `json:"maxP95Duration"`
+	MaxErrorRate   null.Float         `json:"maxErrorRate"`
+
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewGoalSeekingArrivalRateConfig returns a GoalSeekingArrivalRateConfig with default values
+func NewGoalSeekingArrivalRateConfig(name string) *GoalSeekingArrivalRateConfig {
+	return &GoalSeekingArrivalRateConfig{
+		BaseConfig: NewBaseConfig(name, goalSeekingArrivalRateType),
+		TimeUnit:   types.NewNullDuration(1*time.Second, false),
+		MinRate:    null.NewInt(0, false),
+		Steps:      null.NewInt(5, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &GoalSeekingArrivalRateConfig{}
+
+// getDuration returns the total duration of all of the steps.
+func (gsc GoalSeekingArrivalRateConfig) getDuration() time.Duration {
+	return gsc.StepDuration.TimeDuration() * time.Duration(gsc.Steps.Int64)
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (gsc GoalSeekingArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) string {
+	preAllocatedVUs, maxVUs := et.ScaleInt64(gsc.PreAllocatedVUs.Int64), et.ScaleInt64(gsc.MaxVUs.Int64)
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", preAllocatedVUs)
+	if maxVUs > preAllocatedVUs {
+		maxVUsRange += fmt.Sprintf("-%d", maxVUs)
+	}
+
+	timeUnit := gsc.TimeUnit.TimeDuration()
+	minRate := float64(gsc.MinRate.Int64) / timeUnit.Seconds()
+	maxRate := float64(gsc.MaxRate.Int64) / timeUnit.Seconds()
+	return fmt.Sprintf("Searching for the max rate between %.2f and %.2f iterations/s in %d steps of %s%s",
+		minRate, maxRate, gsc.Steps.Int64, gsc.StepDuration.TimeDuration(), gsc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (gsc *GoalSeekingArrivalRateConfig) Validate() []error {
+	errors := gsc.BaseConfig.Validate()
+	if gsc.TimeUnit.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
+	}
+
+	if !gsc.MaxRate.Valid {
+		errors = append(errors, fmt.Errorf("the maxRate isn't specified"))
+	} else if gsc.MaxRate.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the maxRate must be more than 0"))
+	}
+	if gsc.MinRate.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the minRate can't be negative"))
+	} else if gsc.MaxRate.Valid && gsc.MinRate.Int64 >= gsc.MaxRate.Int64 {
+		errors = append(errors, fmt.Errorf("the minRate must be less than the maxRate"))
+	}
+
+	if !gsc.StepDuration.Valid {
+		errors = append(errors, fmt.Errorf("the stepDuration isn't specified"))
+	} else if gsc.StepDuration.TimeDuration() < minDuration {
+		errors = append(errors, fmt.Errorf(
+			"the stepDuration must be at least %s, but is %s", minDuration, gsc.StepDuration,
+		))
+	}
+	if gsc.Steps.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the number of steps must be more than 0"))
+	}
+
+	if !gsc.MaxP95Duration.Valid && !gsc.MaxErrorRate.Valid {
+		errors = append(errors, fmt.Errorf("at least one of maxP95Duration and maxErrorRate must be specified"))
+	}
+	if gsc.MaxP95Duration.Valid && gsc.MaxP95Duration.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the maxP95Duration must be more than 0"))
+	}
+	if gsc.MaxErrorRate.Valid && (gsc.MaxErrorRate.Float64 < 0 || gsc.MaxErrorRate.Float64 >= 1) {
+		errors = append(errors, fmt.Errorf("the maxErrorRate must be at least 0 and less than 1"))
+	}
+
+	if !gsc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if gsc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !gsc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		gsc.MaxVUs.Int64 = gsc.PreAllocatedVUs.Int64
+	} else if gsc.MaxVUs.Int64 < gsc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop.
+func (gsc GoalSeekingArrivalRateConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(et.ScaleInt64(gsc.PreAllocatedVUs.Int64)),
+			MaxUnplannedVUs: uint64(et.ScaleInt64(gsc.MaxVUs.Int64) - et.ScaleInt64(gsc.PreAllocatedVUs.Int64)),
+		}, {
+			TimeOffset:      gsc.getDuration() + gsc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new GoalSeekingArrivalRate executor
+func (gsc GoalSeekingArrivalRateConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	return &GoalSeekingArrivalRate{
+		BaseExecutor: NewBaseExecutor(&gsc, es, logger),
+		config:       gsc,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (gsc GoalSeekingArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return et.ScaleInt64(gsc.MaxVUs.Int64) > 0
+}
+
+// goalSeekingStep collects the results of the iterations started during one
+// step of the goal-seeking executor.
+type goalSeekingStep struct {
+	mx        sync.Mutex
+	nextID    uint64
+	running   map[uint64]time.Time
+	durations []time.Duration
+	failed    uint64
+	dropped   uint64
+}
+
+func newGoalSeekingStep() *goalSeekingStep {
+	return &goalSeekingStep{running: make(map[uint64]time.Time)}
+}
+
+func (s *goalSeekingStep) begin() uint64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.nextID++
+	s.running[s.nextID] = time.Now()
+	return s.nextID
+}
+
+func (s *goalSeekingStep) end(id uint64, finished bool, err error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	startTime := s.running[id]
+	delete(s.running, id)
+	if !finished {
+		return
+	}
+	s.durations = append(s.durations, time.Since(startTime))
+	if err != nil {
+		s.failed++
+	}
+}
+
+func (s *goalSeekingStep) drop() {
+	s.mx.Lock()
+	s.dropped++
+	s.mx.Unlock()
+}
+
+// evaluate returns the p95 of the iteration durations and the ratio of failed
+// and dropped iterations. Iterations that are still running are counted with
+// their duration so far, since they're usually the slowest ones.
+func (s *goalSeekingStep) evaluate() (p95 time.Duration, errorRate float64, total uint64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	durations := make([]time.Duration, 0, len(s.durations)+len(s.running))
+	durations = append(durations, s.durations...)
+	for _, startTime := range s.running {
+		durations = append(durations, time.Since(startTime))
+	}
+	total = uint64(len(durations)) + s.dropped
+	if total == 0 {
+		return 0, 0, 0
+	}
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		p95 = durations[int(math.Ceil(0.95*float64(len(durations))))-1]
+	}
+	return p95, float64(s.failed+s.dropped) / float64(total), total
+}
+
+// GoalSeekingArrivalRate runs a binary search for the highest arrival rate
+// that meets the configured SLO.
+type GoalSeekingArrivalRate struct {
+	*BaseExecutor
+	config         GoalSeekingArrivalRateConfig
+	et             *lib.ExecutionTuple
+	capacityMetric *metrics.Metric
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &GoalSeekingArrivalRate{}
+
+// Init values needed for the execution
+func (gs *GoalSeekingArrivalRate) Init(_ context.Context) error {
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := gs.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(gs.config.MaxVUs.Int64)
+	if err != nil {
+		return err
+	}
+	gs.et = et
+	gs.iterSegIndex = lib.NewSegmentedIndex(et)
+
+	gs.capacityMetric, err = gs.executionState.Test.Registry.NewMetric(GoalSeekingCapacityMetricName, metrics.Gauge)
+	return err
+}
+
+// meetsSLO checks the results of a step against the configured SLO.
+func (gs GoalSeekingArrivalRate) meetsSLO(step *goalSeekingStep) bool {
+	p95, errorRate, total := step.evaluate()
+	if total == 0 {
+		return false
+	}
+	if gs.config.MaxP95Duration.Valid && p95 > gs.config.MaxP95Duration.TimeDuration() {
+		return false
+	}
+	if gs.config.MaxErrorRate.Valid && errorRate > gs.config.MaxErrorRate.Float64 {
+		return false
+	}
+	return true
+}
+
+// Run executes the steps of the search, each one with a constant arrival rate
+// in the middle of the range that is still searched. When it's done, the
+// highest rate that met the SLO is emitted as the goal_seeking_capacity metric.
+//
+// TODO: every instance decides on its own, based on the results of its part
+// of the iterations, so the rates of the instances in a distributed test can
+// diverge.
+//
+//nolint:funlen,gocognit
+func (gs GoalSeekingArrivalRate) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	gracefulStop := gs.config.GetGracefulStop()
+	duration := gs.config.getDuration()
+	stepDuration := gs.config.StepDuration.TimeDuration()
+	timeUnit := gs.config.TimeUnit.TimeDuration()
+	preAllocatedVUs := gs.et.ScaleInt64(gs.config.PreAllocatedVUs.Int64)
+	maxVUs := gs.et.ScaleInt64(gs.config.MaxVUs.Int64)
+
+	gs.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"steps": gs.config.Steps.Int64, "type": gs.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	vusPool := newActiveVUPool(gs.executionState)
+	defer func() {
+		<-returnedVUs
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount := uint64(0)
+
+	// the current rate, in iterations per second, stored as float64 bits
+	currentRate := uint64(0)
+	var (
+		stepMx      sync.RWMutex
+		currentStep = newGoalSeekingStep()
+	)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", vusPool.Running(), atomic.LoadUint64(&activeVUsCount))
+		progIters := fmt.Sprintf("%.2f iters/s", math.Float64frombits(atomic.LoadUint64(&currentRate)))
+		right := []string{progVUs, duration.String(), progIters}
+
+		if spent > duration {
+			return 1, right
+		}
+		right[1] = fmt.Sprintf("%s/%s", pb.GetFixedLengthDuration(spent, duration), duration)
+		return math.Min(1, float64(spent)/float64(duration)), right
+	}
+	gs.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       gs.config.Name,
+		Executor:   gs.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &gs, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		gs.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationWithError := getIterationRunnerWithError(gs.BaseExecutor)
+	runIteration := func(ctx context.Context, vu lib.ActiveVU) bool {
+		stepMx.RLock()
+		step := currentStep
+		stepMx.RUnlock()
+
+		id := step.begin()
+		finished, err := runIterationWithError(ctx, vu)
+		step.end(id, finished, err)
+		return finished
+	}
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		activeVU := initVU.Activate(getVUActivationParams(
+			maxDurationCtx, gs.config.BaseConfig, returnVU,
+			gs.nextIterationCounters,
+		))
+		atomic.AddUint64(&activeVUsCount, 1)
+		vusPool.AddVU(maxDurationCtx, activeVU, runIteration)
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			gs.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := gs.executionState.GetUnplannedVU(maxDurationCtx, gs.logger)
+			if err != nil {
+				gs.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				gs.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := gs.executionState.GetPlannedVU(gs.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	start, offsets, _ := gs.et.GetStripedOffsets()
+	timer := time.NewTimer(time.Hour * 24)
+	droppedIterationMetric := gs.executionState.Test.BuiltinMetrics.DroppedIterations
+	shownWarning := false
+	metricTags := gs.getMetricTags(nil)
+
+	// runStep starts the iterations of a single step, for which the planned
+	// start times of the global iterations are based on the unscaled rate,
+	// the same way as in the constant-arrival-rate executor.
+	runStep := func(stepStart time.Time, rate float64, step *goalSeekingStep) bool {
+		period := float64(timeUnit) / rate
+		stepEnd := stepStart.Add(stepDuration)
+		for li, gi := 0, start; ; li, gi = li+1, gi+offsets[li%len(offsets)] {
+			iterStart := stepStart.Add(time.Duration(float64(gi) * period))
+			if !iterStart.Before(stepEnd) {
+				timer.Reset(time.Until(stepEnd))
+			} else {
+				timer.Reset(time.Until(iterStart))
+			}
+			select {
+			case <-timer.C:
+				if !iterStart.Before(stepEnd) {
+					return true
+				}
+				if vusPool.TryRunIteration() {
+					continue
+				}
+
+				step.drop()
+				metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+					TimeSeries: metrics.TimeSeries{
+						Metric: droppedIterationMetric,
+						Tags:   metricTags,
+					},
+					Time:  time.Now(),
+					Value: 1,
+				})
+
+				if remainingUnplannedVUs == 0 {
+					if !shownWarning {
+						gs.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+						shownWarning = true
+					}
+					continue
+				}
+
+				select {
+				case makeUnplannedVUCh <- struct{}{}:
+					remainingUnplannedVUs--
+				default: // we're already allocating a new VU
+				}
+
+			case <-maxDurationCtx.Done():
+				// the end of the last step is the end of the regular
+				// duration, so regDurationCtx can't be used here
+				return false
+			}
+		}
+	}
+
+	low, high := float64(gs.config.MinRate.Int64), float64(gs.config.MaxRate.Int64)
+	capacity := 0.0
+	for i := int64(0); i < gs.config.Steps.Int64; i++ {
+		rate := (low + high) / 2
+		atomic.StoreUint64(&currentRate, math.Float64bits(rate/timeUnit.Seconds()))
+
+		step := newGoalSeekingStep()
+		stepMx.Lock()
+		currentStep = step
+		stepMx.Unlock()
+
+		if !runStep(startTime.Add(stepDuration*time.Duration(i)), rate, step) {
+			break
+		}
+
+		p95, errorRate, total := step.evaluate()
+		meetsSLO := gs.meetsSLO(step)
+		gs.logger.WithFields(logrus.Fields{
+			"rate": rate / timeUnit.Seconds(), "p95": p95, "errorRate": errorRate,
+			"iterations": total, "meetsSLO": meetsSLO,
+		}).Debug("Goal-seeking step finished")
+		if meetsSLO {
+			capacity = rate
+			low = rate
+		} else {
+			high = rate
+		}
+	}
+
+	capacityPerSec := capacity / timeUnit.Seconds()
+	gs.logger.WithField("capacity", capacityPerSec).Infof(
+		"the highest rate that met the SLO is %.2f iterations/s", capacityPerSec)
+	metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: gs.capacityMetric,
+			Tags:   metricTags,
+		},
+		Time:  time.Now(),
+		Value: capacityPerSec,
+	})
+	return nil
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestGoalSeekingArrivalRateConfig() *GoalSeekingArrivalRateConfig {
+	config := NewGoalSeekingArrivalRateConfig("test")
+	config.GracefulStop = types.NullDurationFrom(1 * time.Second)
+	config.MaxRate = null.IntFrom(400)
+	config.StepDuration = types.NullDurationFrom(1 * time.Second)
+	config.Steps = null.IntFrom(3)
+	config.MaxErrorRate = null.FloatFrom(0.1)
+	config.PreAllocatedVUs = null.IntFrom(4)
+	return config
+}
+
+func TestGoalSeekingArrivalRateValidation(t *testing.T) {
+	t.Parallel()
+
+	config := getTestGoalSeekingArrivalRateConfig()
+	require.Empty(t, config.Validate())
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Searching for the max rate between 0.00 and 400.00 iterations/s in 3 steps of 1s "+
+		"(maxVUs: 4, gracefulStop: 1s)", config.GetDescription(et))
+
+	config.MinRate = null.IntFrom(400)
+	config.MaxErrorRate = null.Float{}
+	config.Steps = null.IntFrom(0)
+	errs := config.Validate()
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "the minRate must be less than the maxRate")
+	assert.EqualError(t, errs[1], "the number of steps must be more than 0")
+	assert.EqualError(t, errs[2], "at least one of maxP95Duration and maxErrorRate must be specified")
+}
+
+func TestGoalSeekingStepEvaluate(t *testing.T) {
+	t.Parallel()
+
+	step := newGoalSeekingStep()
+	for i := 0; i < 18; i++ {
+		step.end(step.begin(), true, nil)
+	}
+	step.end(step.begin(), true, errors.New("oops"))
+	step.end(step.begin(), false, nil) // interrupted iterations are ignored
+	step.drop()
+	step.begin()
+	step.begin()
+	time.Sleep(50 * time.Millisecond)
+
+	p95, errorRate, total := step.evaluate()
+	assert.Equal(t, uint64(22), total)
+	assert.InDelta(t, 2.0/22, errorRate, 0.0001)
+	assert.GreaterOrEqual(t, p95, 50*time.Millisecond)
+}
+
+func TestGoalSeekingArrivalRateRun(t *testing.T) {
+	t.Parallel()
+
+	config := getTestGoalSeekingArrivalRateConfig()
+	require.Empty(t, config.Validate())
+
+	runner := simpleRunner(func(ctx context.Context, _ *lib.State) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 10000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	close(engineOut)
+
+	var capacity []float64
+	for sc := range engineOut {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Name == GoalSeekingCapacityMetricName {
+				capacity = append(capacity, s.Value)
+			}
+		}
+	}
+	// 4 VUs with 50ms iterations can't do 200 or 100 iterations/s, only 50
+	assert.Equal(t, []float64{50}, capacity)
+}
@@ -7,8 +7,6 @@ import (
 	"math/big"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
 	"go.k6.io/k6/errext"
 	"go.k6.io/k6/execution"
 	"go.k6.io/k6/lib"
@@ -23,6 +21,24 @@ func sumStagesDuration(stages []Stage) (result time.Duration) {
 	return
 }
 
+// getCurrentStage returns the index of the stage running after the given time
+// since the start of the stages, the last one once all of them ended, and its
+// target.
+func getCurrentStage(stages []Stage, elapsed time.Duration) (int, int64) {
+	if len(stages) == 0 {
+		return 0, 0
+	}
+	var end time.Duration
+	for i, stage := range stages {
+		end += stage.Duration.TimeDuration()
+		if elapsed < end {
+			return i, stage.Target.Int64
+		}
+	}
+	last := len(stages) - 1
+	return last, stages[last].Target.Int64
+}
+
 func getStagesUnscaledMaxTarget(unscaledStartValue int64, stages []Stage) int64 {
 	max := unscaledStartValue
 	for _, s := range stages {
@@ -71,14 +87,39 @@ func handleInterrupt(ctx context.Context, err error) bool {
 
 // getIterationRunner is a helper function that returns an iteration executor
 // closure. It takes care of updating the execution state statistics and
-// warning messages. And returns whether a full iteration was finished or not
+// warning messages. It also waits before starting new iterations while the
+// executor's scenario is paused. And returns whether a full iteration was
+// finished or not
 //
 // TODO: emit the end-of-test iteration metrics here (https://github.com/k6io/k6/issues/1250)
-func getIterationRunner(
-	executionState *lib.ExecutionState, logger *logrus.Entry,
-) func(context.Context, lib.ActiveVU) bool {
+func getIterationRunner(bs *BaseExecutor) func(context.Context, lib.ActiveVU) bool {
+	runIteration := getIterationRunnerWithError(bs)
 	return func(ctx context.Context, vu lib.ActiveVU) bool {
+		finished, _ := runIteration(ctx, vu)
+		return finished
+	}
+}
+
+// getIterationRunnerWithError is the same as getIterationRunner, but the
+// returned closure also returns the error of fully finished iterations, for
+// executors that need to know whether they were successful.
+func getIterationRunnerWithError(bs *BaseExecutor) func(context.Context, lib.ActiveVU) (bool, error) {
+	executionState, logger := bs.executionState, bs.logger
+	return func(ctx context.Context, vu lib.ActiveVU) (bool, error) {
+		if !bs.waitForScenarioResume(ctx) {
+			return false, nil
+		}
+		select {
+		case <-executionState.GracefulAbortNotify():
+			// No new iterations after a graceful abort, the executor is
+			// stopped once the running ones have finished
+			<-ctx.Done()
+			return false, nil
+		default:
+		}
+		executionState.ModRunningIterationsCount(1)
 		err := vu.RunOnce()
+		executionState.ModRunningIterationsCount(-1)
 
 		// TODO: track (non-ramp-down) errors from script iterations as a metric,
 		// and have a default threshold that will abort the script when the error
@@ -88,14 +129,15 @@ func getIterationRunner(
 		case <-ctx.Done():
 			// Don't log errors or emit iterations metrics from cancelled iterations
 			executionState.AddInterruptedIterations(1)
-			return false
+			return false, nil
 		default:
 			if err != nil {
 				if handleInterrupt(ctx, err) {
 					executionState.AddInterruptedIterations(1)
-					return false
+					return false, nil
 				}
 
+				executionState.AddFailedIterations(1)
 				var exception errext.Exception
 				if errors.As(err, &exception) {
 					// TODO don't count this as a full iteration?
@@ -108,7 +150,7 @@ func getIterationRunner(
 
 			// TODO: move emission of end-of-iteration metrics here?
 			executionState.AddFullIterations(1)
-			return true
+			return true, err
 		}
 	}
 }
@@ -378,6 +378,9 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		Executor:   varr.config.Type,
 		StartTime:  startTime,
 		ProgressFn: progressFn,
+		StageFn: func() (int, int64) {
+			return getCurrentStage(varr.config.Stages, time.Since(startTime))
+		},
 	})
 	go func() {
 		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &varr, progressFn)
@@ -393,7 +396,7 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		activeVUsWg.Done()
 	}
 
-	runIterationBasic := getIterationRunner(varr.executionState, varr.logger)
+	runIterationBasic := getIterationRunner(varr.BaseExecutor)
 
 	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
 		activeVUsWg.Add(1)
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -450,6 +451,7 @@ func (vlvc RampingVUsConfig) NewExecutor(es *lib.ExecutionState, logger *logrus.
 	return &RampingVUs{
 		BaseExecutor: NewBaseExecutor(vlvc, es, logger),
 		config:       vlvc,
+		stages:       vlvc.Stages,
 	}, nil
 }
 
@@ -460,16 +462,24 @@ func (vlvc RampingVUsConfig) HasWork(et *lib.ExecutionTuple) bool {
 
 // RampingVUs handles the old "stages" execution configuration - it loops
 // iterations with a variable number of VUs for the sum of all of the specified
-// stages' duration.
+// stages' duration. The remaining stages can be changed while it's running.
 type RampingVUs struct {
 	*BaseExecutor
 	config RampingVUsConfig
 
+	// stepsLock guards the current stages and steps, which can be changed with
+	// UpdateConfig() while the executor is running
+	stepsLock               sync.RWMutex
+	stages                  []Stage
 	rawSteps, gracefulSteps []lib.ExecutionStep
+	runState                *rampingVUsRunState
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &RampingVUs{}
+// Make sure we implement the lib.Executor and lib.LiveUpdatableExecutor interfaces.
+var (
+	_ lib.Executor              = &RampingVUs{}
+	_ lib.LiveUpdatableExecutor = &RampingVUs{}
+)
 
 // Init initializes the rampingVUs executor by precalculating the raw
 // and graceful steps.
@@ -483,6 +493,90 @@ func (vlv *RampingVUs) Init(_ context.Context) error {
 	return nil
 }
 
+// GetCurrentStages returns the stages of the executor, including any changes
+// that were made to them with UpdateConfig().
+func (vlv *RampingVUs) GetCurrentStages() []Stage {
+	vlv.stepsLock.RLock()
+	defer vlv.stepsLock.RUnlock()
+	return append([]Stage{}, vlv.stages...)
+}
+
+// UpdateConfig replaces the remaining stages of the running executor with the
+// supplied []Stage, which start from the current moment and the current number
+// of VUs, so it can be used both to modify and to append stages. The new stages
+// can't need more VUs than were initialized for the scenario at the start.
+//
+// Keep in mind that the execution plan of the whole test isn't updated, so if
+// the scenario is extended, it may not be able to reuse VUs with the scenarios
+// that were planned to start after it.
+func (vlv *RampingVUs) UpdateConfig(_ context.Context, newConf interface{}) error {
+	newStages, ok := newConf.([]Stage)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	if errs := validateStages(newStages); len(errs) != 0 {
+		return fmt.Errorf("invalid stages supplied: %s", lib.ConcatErrors(errs, ", "))
+	}
+
+	vlv.stepsLock.Lock()
+	defer vlv.stepsLock.Unlock()
+
+	rs := vlv.runState
+	if rs == nil {
+		return fmt.Errorf("the stages of scenario '%s' can be changed only while it's running", vlv.config.Name)
+	}
+	if rs.stepsFinished {
+		return fmt.Errorf("scenario '%s' has already finished its stages", vlv.config.Name)
+	}
+
+	config := vlv.config
+	config.Stages = append(getStagesUntil(config.StartVUs.Int64, vlv.stages, time.Since(rs.started)), newStages...)
+	et := vlv.executionState.ExecutionTuple
+	gracefulSteps := config.GetExecutionRequirements(et)
+	if neededVUs := lib.GetMaxPlannedVUs(gracefulSteps); neededVUs > rs.maxVUs {
+		return fmt.Errorf("the new stages need %d VUs, but only %d were initialized for scenario '%s'",
+			neededVUs, rs.maxVUs, vlv.config.Name)
+	}
+
+	vlv.stages = config.Stages
+	vlv.rawSteps = config.getRawExecutionSteps(et, true)
+	vlv.gracefulSteps = gracefulSteps
+	select {
+	case rs.stepsUpdated <- struct{}{}:
+	default: // there is already a pending update notification
+	}
+	return nil
+}
+
+// getSteps returns the current raw and graceful steps of the executor.
+func (vlv *RampingVUs) getSteps() (rawSteps, gracefulSteps []lib.ExecutionStep) {
+	vlv.stepsLock.RLock()
+	defer vlv.stepsLock.RUnlock()
+	return vlv.rawSteps, vlv.gracefulSteps
+}
+
+// getStagesUntil returns the stages that have been completed until the given
+// offset, with the one that was in progress at that time cut short at it, with
+// a target equal to the number of VUs it had reached.
+func getStagesUntil(startVUs int64, stages []Stage, offset time.Duration) []Stage {
+	var result []Stage
+	from, stageStart := startVUs, time.Duration(0)
+	for _, stage := range stages {
+		duration := stage.Duration.TimeDuration()
+		if stageStart+duration > offset {
+			elapsed := offset - stageStart
+			target := from + (stage.Target.Int64-from)*int64(elapsed)/int64(duration)
+			return append(result, Stage{
+				Duration: types.NullDurationFrom(elapsed),
+				Target:   null.IntFrom(target),
+			})
+		}
+		result = append(result, stage)
+		from, stageStart = stage.Target.Int64, stageStart+duration
+	}
+	return result
+}
+
 // Run constantly loops through as many iterations as possible on a variable
 // number of VUs for the specified stages.
 func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer) error {
@@ -495,9 +589,11 @@ func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer
 		return fmt.Errorf("%s expected graceful end offset at %s to be final", vlv.config.GetName(), maxDuration)
 	}
 	waitOnProgressChannel := make(chan struct{})
-	startTime, maxDurationCtx, regularDurationCtx, cancel := getDurationContexts(
-		ctx, regularDuration, maxDuration-regularDuration,
-	)
+	// The durations can change with UpdateConfig(), so instead of deadlines,
+	// the contexts are cancelled when the respective execution steps are over.
+	startTime := time.Now()
+	maxDurationCtx, cancel := context.WithCancel(ctx)
+	regularDurationCtx, regularDurationCancel := context.WithCancel(maxDurationCtx)
 	defer func() {
 		cancel()
 		<-waitOnProgressChannel
@@ -519,15 +615,22 @@ func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer
 		maxVUs:         maxVUs,
 		activeVUsCount: new(int64),
 		started:        startTime,
-		runIteration:   getIterationRunner(vlv.executionState, vlv.logger),
+		stepsUpdated:   make(chan struct{}, 1),
+		runIteration:   getIterationRunner(vlv.BaseExecutor),
 	}
+	vlv.stepsLock.Lock()
+	vlv.runState = runState
+	vlv.stepsLock.Unlock()
 
-	progressFn := runState.makeProgressFn(regularDuration)
+	progressFn := runState.makeProgressFn()
 	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
 		Name:       vlv.config.Name,
 		Executor:   vlv.config.Type,
 		StartTime:  runState.started,
 		ProgressFn: progressFn,
+		StageFn: func() (int, int64) {
+			return getCurrentStage(vlv.GetCurrentStages(), time.Since(runState.started))
+		},
 	})
 	vlv.progress.Modify(pb.WithProgress(progressFn))
 	go func() {
@@ -543,16 +646,20 @@ func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer
 		handleNewMaxAllowedVUs = runState.maxAllowedVUsHandlerStrategy()
 		handleNewScheduledVUs  = runState.scheduledVUsHandlerStrategy()
 	)
-	handledGracefulSteps := runState.iterateSteps(
+	remainingGracefulSteps := runState.iterateSteps(
 		ctx,
 		handleNewMaxAllowedVUs,
 		handleNewScheduledVUs,
 	)
-	go runState.runRemainingGracefulSteps(
-		ctx,
-		handleNewMaxAllowedVUs,
-		handledGracefulSteps,
-	)
+	regularDurationCancel()
+	go func() {
+		runState.runRemainingGracefulSteps(
+			ctx,
+			handleNewMaxAllowedVUs,
+			remainingGracefulSteps,
+		)
+		cancel()
+	}()
 	return nil
 }
 
@@ -567,14 +674,19 @@ type rampingVUsRunState struct {
 	started        time.Time
 	wg             sync.WaitGroup
 
+	stepsUpdated  chan struct{} // notifies about new steps from UpdateConfig()
+	stepsFinished bool          // guarded by the executor's stepsLock
+
 	runIteration func(context.Context, lib.ActiveVU) bool // a helper closure function that runs a single iteration
 }
 
-func (rs *rampingVUsRunState) makeProgressFn(regular time.Duration) (progressFn func() (float64, []string)) {
+func (rs *rampingVUsRunState) makeProgressFn() (progressFn func() (float64, []string)) {
 	vusFmt := pb.GetFixedLengthIntFormat(int64(rs.maxVUs))
-	regularDuration := pb.GetFixedLengthDuration(regular, regular)
 
 	return func() (float64, []string) {
+		rawSteps, _ := rs.executor.getSteps()
+		regular, _ := lib.GetEndOffset(rawSteps)
+		regularDuration := pb.GetFixedLengthDuration(regular, regular)
 		spent := time.Since(rs.started)
 		cur := atomic.LoadInt64(rs.activeVUsCount)
 		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", cur, rs.maxVUs)
@@ -615,30 +727,82 @@ func (rs *rampingVUsRunState) runLoopsIfPossible(ctx context.Context, cancel fun
 
 // iterateSteps iterates over rawSteps and gracefulSteps in order according to
 // their TimeOffsets, prioritizing rawSteps. It stops iterating once rawSteps
-// are over. And it returns the number of handled gracefulSteps.
+// are over. And it returns the gracefulSteps that weren't handled yet.
+//
+// If the steps are changed with UpdateConfig() in the meantime, the VUs are
+// immediately adjusted to the new steps for the current moment and the
+// iteration continues with the new steps after it.
 func (rs *rampingVUsRunState) iterateSteps(
 	ctx context.Context,
 	handleNewMaxAllowedVUs, handleNewScheduledVUs func(lib.ExecutionStep),
-) (handledGracefulSteps int) {
-	wait := waiter(ctx, rs.started)
+) (remainingGracefulSteps []lib.ExecutionStep) {
+	wait := updatableWaiter(ctx, rs.started, rs.stepsUpdated)
+	rawSteps, gracefulSteps := rs.executor.getSteps()
 	i, j := 0, 0
-	for i != len(rs.executor.rawSteps) {
-		r, g := rs.executor.rawSteps[i], rs.executor.gracefulSteps[j]
-		if g.TimeOffset < r.TimeOffset {
-			if wait(g.TimeOffset) {
-				break
+	applyUpdatedSteps := func() {
+		rawSteps, gracefulSteps = rs.executor.getSteps()
+		now := time.Since(rs.started)
+		if i = countStepsUntil(rawSteps, now); i > 0 {
+			handleNewScheduledVUs(rawSteps[i-1])
+		}
+		if j = countStepsUntil(gracefulSteps, now); j > 0 {
+			handleNewMaxAllowedVUs(gracefulSteps[j-1])
+		}
+	}
+	for {
+		if i == len(rawSteps) {
+			if !rs.finishSteps() {
+				return gracefulSteps[j:]
 			}
-			handleNewMaxAllowedVUs(g)
-			j++
-		} else {
-			if wait(r.TimeOffset) {
-				break
+			applyUpdatedSteps() // there was a last-moment update
+			continue
+		}
+		r, g := rawSteps[i], gracefulSteps[j]
+		isRaw := g.TimeOffset >= r.TimeOffset
+		offset := r.TimeOffset
+		if !isRaw {
+			offset = g.TimeOffset
+		}
+
+		switch wait(offset) {
+		case waitDone:
+			return nil
+		case waitUpdated:
+			applyUpdatedSteps()
+		case waitFinished:
+			if isRaw {
+				handleNewScheduledVUs(r)
+				i++
+			} else {
+				handleNewMaxAllowedVUs(g)
+				j++
 			}
-			handleNewScheduledVUs(r)
-			i++
 		}
 	}
-	return j
+}
+
+// finishSteps marks the raw steps as finished, so they can't be updated
+// anymore, unless there is a pending update, in which case it returns true.
+func (rs *rampingVUsRunState) finishSteps() bool {
+	rs.executor.stepsLock.Lock()
+	defer rs.executor.stepsLock.Unlock()
+	select {
+	case <-rs.stepsUpdated:
+		return true
+	default:
+		rs.stepsFinished = true
+		return false
+	}
+}
+
+// countStepsUntil returns the number of steps with a time offset that isn't
+// after the given one.
+func countStepsUntil(steps []lib.ExecutionStep, offset time.Duration) int {
+	i := 0
+	for i < len(steps) && steps[i].TimeOffset <= offset {
+		i++
+	}
+	return i
 }
 
 // runRemainingGracefulSteps runs the remaining gracefulSteps concurrently
@@ -651,10 +815,10 @@ func (rs *rampingVUsRunState) iterateSteps(
 func (rs *rampingVUsRunState) runRemainingGracefulSteps(
 	ctx context.Context,
 	handleNewMaxAllowedVUs func(lib.ExecutionStep),
-	handledGracefulSteps int,
+	remainingGracefulSteps []lib.ExecutionStep,
 ) {
 	wait := waiter(ctx, rs.started)
-	for _, s := range rs.executor.gracefulSteps[handledGracefulSteps:] {
+	for _, s := range remainingGracefulSteps {
 		if wait(s.TimeOffset) {
 			return
 		}
@@ -707,3 +871,37 @@ func waiter(ctx context.Context, start time.Time) func(offset time.Duration) boo
 		return false
 	}
 }
+
+type waitResult int
+
+const (
+	waitFinished waitResult = iota
+	waitUpdated
+	waitDone
+)
+
+// updatableWaiter is like waiter, but the returned function also stops waiting
+// early when a notification is received on the updated channel.
+func updatableWaiter(
+	ctx context.Context, start time.Time, updated <-chan struct{},
+) func(offset time.Duration) waitResult {
+	timer := time.NewTimer(time.Hour * 24)
+	return func(offset time.Duration) waitResult {
+		diff := offset - time.Since(start)
+		if diff <= 0 {
+			diff = 0
+		}
+		timer.Reset(diff)
+		select {
+		case <-ctx.Done():
+			return waitDone
+		case <-updated:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			return waitUpdated
+		case <-timer.C:
+			return waitFinished
+		}
+	}
+}
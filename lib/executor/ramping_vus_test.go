@@ -1150,3 +1150,122 @@ func TestSumRandomSegmentSequenceMatchesNoSegment(t *testing.T) {
 		})
 	}
 }
+
+func TestRampingVUsUpdateConfig(t *testing.T) {
+	t.Parallel()
+
+	config := RampingVUsConfig{
+		BaseConfig:       BaseConfig{GracefulStop: types.NullDurationFrom(0)},
+		GracefulRampDown: types.NullDurationFrom(0),
+		StartVUs:         null.IntFrom(4),
+		Stages: []Stage{
+			{Duration: types.NullDurationFrom(1 * time.Second), Target: null.IntFrom(4)},
+			{Duration: types.NullDurationFrom(0), Target: null.IntFrom(2)},
+			{Duration: types.NullDurationFrom(2 * time.Second), Target: null.IntFrom(2)},
+		},
+	}
+
+	runner := simpleRunner(func(ctx context.Context, _ *lib.State) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	rampingVUs, ok := test.executor.(*RampingVUs)
+	require.True(t, ok)
+	newStages := []Stage{
+		{Duration: types.NullDurationFrom(0), Target: null.IntFrom(3)},
+		{Duration: types.NullDurationFrom(1 * time.Second), Target: null.IntFrom(3)},
+	}
+	err := rampingVUs.UpdateConfig(test.ctx, newStages)
+	assert.ErrorContains(t, err, "can be changed only while it's running")
+
+	startTime := time.Now()
+	errCh := make(chan error)
+	go func() { errCh <- test.executor.Run(test.ctx, nil) }()
+
+	time.Sleep(500 * time.Millisecond)
+	err = rampingVUs.UpdateConfig(test.ctx, []Stage{
+		{Duration: types.NullDurationFrom(1 * time.Second), Target: null.IntFrom(10)},
+	})
+	assert.ErrorContains(t, err, "the new stages need 10 VUs, but only 4 were initialized")
+	assert.ErrorContains(t, rampingVUs.UpdateConfig(test.ctx, []Stage{}), "at least one stage")
+	require.NoError(t, rampingVUs.UpdateConfig(test.ctx, newStages))
+
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, int64(3), test.state.GetCurrentlyActiveVUsCount())
+
+	require.NoError(t, <-errCh)
+	runTime := time.Since(startTime)
+	assert.True(t, runTime < 2*time.Second, "the updated stages weren't used, took %s", runTime)
+	assert.Equal(t, int64(0), test.state.GetCurrentlyActiveVUsCount())
+
+	stages := rampingVUs.GetCurrentStages()
+	require.Len(t, stages, 3)
+	assert.Equal(t, newStages, stages[1:])
+	assert.Equal(t, null.IntFrom(4), stages[0].Target)
+
+	err = rampingVUs.UpdateConfig(test.ctx, newStages)
+	assert.ErrorContains(t, err, "has already finished its stages")
+}
+
+func TestGetStagesUntil(t *testing.T) {
+	t.Parallel()
+
+	stages := []Stage{
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(10)},
+		{Duration: types.NullDurationFrom(0), Target: null.IntFrom(20)},
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(0)},
+	}
+	testCases := []struct {
+		offset   time.Duration
+		expected []Stage
+	}{
+		{offset: 0, expected: []Stage{
+			{Duration: types.NullDurationFrom(0), Target: null.IntFrom(0)},
+		}},
+		{offset: 5 * time.Second, expected: []Stage{
+			{Duration: types.NullDurationFrom(5 * time.Second), Target: null.IntFrom(5)},
+		}},
+		{offset: 10 * time.Second, expected: []Stage{
+			stages[0], stages[1],
+			{Duration: types.NullDurationFrom(0), Target: null.IntFrom(20)},
+		}},
+		{offset: 12500 * time.Millisecond, expected: []Stage{
+			stages[0], stages[1],
+			{Duration: types.NullDurationFrom(2500 * time.Millisecond), Target: null.IntFrom(15)},
+		}},
+		{offset: 30 * time.Second, expected: stages},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, getStagesUntil(0, stages, tc.offset), tc.offset)
+	}
+}
+
+func TestGetCurrentStage(t *testing.T) {
+	t.Parallel()
+
+	stages := []Stage{
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(10)},
+		{Duration: types.NullDurationFrom(0), Target: null.IntFrom(20)},
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(0)},
+	}
+	testCases := []struct {
+		elapsed time.Duration
+		index   int
+		target  int64
+	}{
+		{elapsed: 0, index: 0, target: 10},
+		{elapsed: 9 * time.Second, index: 0, target: 10},
+		// the stages without a duration are skipped
+		{elapsed: 10 * time.Second, index: 2, target: 0},
+		{elapsed: 30 * time.Second, index: 2, target: 0},
+	}
+	for _, tc := range testCases {
+		index, target := getCurrentStage(stages, tc.elapsed)
+		assert.Equal(t, tc.index, index, tc.elapsed)
+		assert.Equal(t, tc.target, target, tc.elapsed)
+	}
+}
@@ -0,0 +1,340 @@
+package executor
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+const shapedArrivalRateType = "shaped-arrival-rate"
+
+// The supported types of rate shapes
+const (
+	RateShapeSine   = "sine"
+	RateShapeSpikes = "spikes"
+	RateShapePoints = "points"
+	RateShapeCSV    = "csv"
+)
+
+// shapedRatePrecision is the multiplier used to convert the fractional rates of
+// the shape to the integer targets of the underlying ramping-arrival-rate
+// stages. The time unit is multiplied by the same value, so the actual rate
+// doesn't change.
+const shapedRatePrecision = 1000
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		shapedArrivalRateType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewShapedArrivalRateConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// RatePoint is a single point of a piecewise linear arrival rate curve.
+type RatePoint struct {
+	Time types.NullDuration `json:"time"`
+	Rate null.Float         `json:"rate"`
+}
+
+// RateShape describes how the arrival rate changes over time. Arbitrary curves
+// can be specified with points, which can also be generated by a JS function
+// in the init context of the script, or with CSV data with `time,rate` rows,
+// e.g. historical production RPS data loaded with open(). Like all other
+// durations in k6, the times can be duration strings or milliseconds.
+type RateShape struct {
+	Type string `json:"type"`
+
+	// Used by the sine shape, which oscillates between base-amplitude and
+	// base+amplitude, and by the spikes shape, which stays at the base rate
+	// and jumps to the peak rate for the given width every period.
+	Base      null.Float         `json:"base"`
+	Amplitude null.Float         `json:"amplitude"`
+	Peak      null.Float         `json:"peak"`
+	Period    types.NullDuration `json:"period"`
+	Width     types.NullDuration `json:"width"`
+
+	// Used by the points and csv shapes, the rate between two points changes
+	// linearly and before the first point it's equal to its rate.
+	Points []RatePoint `json:"points"`
+	CSV    null.String `json:"csv"`
+}
+
+// ShapedArrivalRateConfig stores the config for the shaped arrival-rate
+// executor, a ramping arrival-rate executor where the rate follows an
+// arbitrary curve instead of linear stages.
+type ShapedArrivalRateConfig struct {
+	BaseConfig
+	TimeUnit types.NullDuration `json:"timeUnit"`
+	Shape    RateShape          `json:"shape"`
+
+	// Duration is required for the periodic shapes and the resolution
+	// specifies how often the sine curve is sampled.
+	Duration   types.NullDuration `json:"duration"`
+	Resolution types.NullDuration `json:"resolution"`
+
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewShapedArrivalRateConfig returns a ShapedArrivalRateConfig with default values
+func NewShapedArrivalRateConfig(name string) *ShapedArrivalRateConfig {
+	return &ShapedArrivalRateConfig{
+		BaseConfig: NewBaseConfig(name, shapedArrivalRateType),
+		TimeUnit:   types.NewNullDuration(1*time.Second, false),
+		Resolution: types.NewNullDuration(1*time.Second, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &ShapedArrivalRateConfig{}
+
+// Validate makes sure all options are configured and valid
+func (sarc *ShapedArrivalRateConfig) Validate() []error {
+	errors := sarc.BaseConfig.Validate()
+
+	if sarc.TimeUnit.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
+	}
+
+	if !sarc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if sarc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !sarc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		sarc.MaxVUs.Int64 = sarc.PreAllocatedVUs.Int64
+	} else if sarc.MaxVUs.Int64 < sarc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	if _, err := sarc.getPoints(); err != nil {
+		errors = append(errors, err)
+	}
+
+	return errors
+}
+
+// getPoints converts the configured shape to the points of a piecewise linear
+// curve, where multiple points with the same time denote abrupt rate changes.
+//
+//nolint:funlen,cyclop
+func (sarc ShapedArrivalRateConfig) getPoints() ([]RatePoint, error) {
+	shape := sarc.Shape
+	duration := sarc.Duration.TimeDuration()
+	periodic := shape.Type == RateShapeSine || shape.Type == RateShapeSpikes
+	if periodic {
+		if !sarc.Duration.Valid || duration <= 0 {
+			return nil, fmt.Errorf("the duration must be more than 0 for the %s shape", shape.Type)
+		}
+		if shape.Period.TimeDuration() <= 0 {
+			return nil, fmt.Errorf("the period must be more than 0 for the %s shape", shape.Type)
+		}
+		if !shape.Base.Valid || shape.Base.Float64 < 0 {
+			return nil, fmt.Errorf("the base rate must be specified and can't be negative")
+		}
+	} else if sarc.Duration.Valid {
+		return nil, fmt.Errorf("the duration can't be specified for the %s shape", shape.Type)
+	}
+
+	switch shape.Type {
+	case RateShapeSine:
+		if shape.Amplitude.Float64 < 0 || shape.Amplitude.Float64 > shape.Base.Float64 {
+			return nil, fmt.Errorf("the amplitude must be between 0 and the base rate")
+		}
+		if sarc.Resolution.TimeDuration() <= 0 {
+			return nil, fmt.Errorf("the resolution must be more than 0")
+		}
+		return getSinePoints(shape, duration, sarc.Resolution.TimeDuration()), nil
+	case RateShapeSpikes:
+		if !shape.Peak.Valid || shape.Peak.Float64 < 0 {
+			return nil, fmt.Errorf("the peak rate must be specified and can't be negative")
+		}
+		if shape.Width.TimeDuration() <= 0 || shape.Width.TimeDuration() > shape.Period.TimeDuration() {
+			return nil, fmt.Errorf("the width must be more than 0 and not longer than the period")
+		}
+		return getSpikesPoints(shape, duration), nil
+	case RateShapePoints:
+		return validatePoints(shape.Points)
+	case RateShapeCSV:
+		if !shape.CSV.Valid {
+			return nil, fmt.Errorf("the csv data must be specified for the csv shape")
+		}
+		points, err := parseRatePointsCSV(shape.CSV.String)
+		if err != nil {
+			return nil, err
+		}
+		return validatePoints(points)
+	case "":
+		return nil, fmt.Errorf("the shape type must be specified")
+	default:
+		return nil, fmt.Errorf("unknown shape type '%s'", shape.Type)
+	}
+}
+
+func getSinePoints(shape RateShape, duration, resolution time.Duration) []RatePoint {
+	period := shape.Period.TimeDuration().Seconds()
+	rateAt := func(t time.Duration) float64 {
+		return shape.Base.Float64 + shape.Amplitude.Float64*math.Sin(2*math.Pi*t.Seconds()/period)
+	}
+
+	points := make([]RatePoint, 0, duration/resolution+2)
+	for t := time.Duration(0); t < duration; t += resolution {
+		points = append(points, RatePoint{Time: types.NullDurationFrom(t), Rate: null.FloatFrom(rateAt(t))})
+	}
+	return append(points, RatePoint{Time: types.NullDurationFrom(duration), Rate: null.FloatFrom(rateAt(duration))})
+}
+
+func getSpikesPoints(shape RateShape, duration time.Duration) []RatePoint {
+	period, width := shape.Period.TimeDuration(), shape.Width.TimeDuration()
+	base, peak := shape.Base, shape.Peak
+
+	var points []RatePoint
+	add := func(t time.Duration, rate null.Float) {
+		points = append(points, RatePoint{Time: types.NullDurationFrom(t), Rate: rate})
+	}
+	for start := time.Duration(0); start < duration; start += period {
+		spikeEnd := start + width
+		if spikeEnd > duration {
+			spikeEnd = duration
+		}
+		add(start, peak)
+		add(spikeEnd, peak)
+		add(spikeEnd, base)
+	}
+	add(duration, base)
+	return points
+}
+
+func validatePoints(points []RatePoint) ([]RatePoint, error) {
+	if len(points) == 0 {
+		return nil, errors.New("at least one rate point has to be specified")
+	}
+	var prev time.Duration
+	for i, p := range points {
+		pointNum := i + 1
+		if !p.Time.Valid || p.Time.TimeDuration() < 0 {
+			return nil, fmt.Errorf("the time for rate point %d must be specified and can't be negative", pointNum)
+		}
+		if !p.Rate.Valid || p.Rate.Float64 < 0 {
+			return nil, fmt.Errorf("the rate for rate point %d must be specified and can't be negative", pointNum)
+		}
+		if p.Time.TimeDuration() < prev {
+			return nil, fmt.Errorf("the time for rate point %d can't be before the previous one", pointNum)
+		}
+		prev = p.Time.TimeDuration()
+	}
+	return points, nil
+}
+
+// parseRatePointsCSV parses CSV data with `time,rate` rows, where the time can
+// be a duration string like `1m30s` or a number of milliseconds. A header row
+// is allowed and skipped.
+func parseRatePointsCSV(data string) ([]RatePoint, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+
+	var points []RatePoint
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate csv data: %w", err)
+		}
+
+		var t types.Duration
+		timeErr := t.UnmarshalText([]byte(record[0]))
+		rate, rateErr := strconv.ParseFloat(record[1], 64)
+		if timeErr != nil || rateErr != nil {
+			if row == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("invalid rate csv data on row %d: %q", row, strings.Join(record, ","))
+		}
+		points = append(points, RatePoint{Time: types.NullDurationFrom(time.Duration(t)), Rate: null.FloatFrom(rate)})
+	}
+	return points, nil
+}
+
+// getRampingConfig returns the ramping arrival-rate config with the stages
+// that correspond to the configured shape. The config should be validated.
+func (sarc ShapedArrivalRateConfig) getRampingConfig() RampingArrivalRateConfig {
+	points, _ := sarc.getPoints()
+	toTarget := func(rate null.Float) null.Int {
+		return null.IntFrom(int64(math.Round(rate.Float64 * shapedRatePrecision)))
+	}
+
+	var startRate null.Int
+	var stages []Stage
+	if len(points) > 0 {
+		startRate = toTarget(points[0].Rate)
+		prev := time.Duration(0)
+		for _, p := range points {
+			stages = append(stages, Stage{
+				Duration: types.NullDurationFrom(p.Time.TimeDuration() - prev),
+				Target:   toTarget(p.Rate),
+			})
+			prev = p.Time.TimeDuration()
+		}
+	}
+
+	return RampingArrivalRateConfig{
+		BaseConfig:      sarc.BaseConfig,
+		StartRate:       startRate,
+		TimeUnit:        types.NullDurationFrom(sarc.TimeUnit.TimeDuration() * shapedRatePrecision),
+		Stages:          stages,
+		PreAllocatedVUs: sarc.PreAllocatedVUs,
+		MaxVUs:          sarc.MaxVUs,
+	}
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (sarc ShapedArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) string {
+	rc := sarc.getRampingConfig()
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", et.ScaleInt64(sarc.PreAllocatedVUs.Int64))
+	if sarc.MaxVUs.Int64 > sarc.PreAllocatedVUs.Int64 {
+		maxVUsRange += fmt.Sprintf("-%d", et.ScaleInt64(sarc.MaxVUs.Int64))
+	}
+	maxUnscaledRate := getStagesUnscaledMaxTarget(rc.StartRate.Int64, rc.Stages)
+	maxArrRatePerSec, _ := getArrivalRatePerSec(
+		getScaledArrivalRate(et.Segment, maxUnscaledRate, rc.TimeUnit.TimeDuration()),
+	).Float64()
+
+	return fmt.Sprintf("Up to %.2f iterations/s for %s following a %s shape%s",
+		maxArrRatePerSec, sumStagesDuration(rc.Stages), sarc.Shape.Type, sarc.getBaseInfo(maxVUsRange))
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration, the same way the ramping arrival-rate does.
+func (sarc ShapedArrivalRateConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return sarc.getRampingConfig().GetExecutionRequirements(et)
+}
+
+// NewExecutor creates a new RampingArrivalRate executor with the stages
+// derived from the configured shape.
+func (sarc ShapedArrivalRateConfig) NewExecutor(es *lib.ExecutionState, logger *logrus.Entry) (lib.Executor, error) {
+	return sarc.getRampingConfig().NewExecutor(es, logger)
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (sarc ShapedArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return et.ScaleInt64(sarc.MaxVUs.Int64) > 0
+}
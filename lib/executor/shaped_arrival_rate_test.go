@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestShapedArrivalRateConfig(shape RateShape) *ShapedArrivalRateConfig {
+	config := NewShapedArrivalRateConfig("test")
+	config.GracefulStop = types.NullDurationFrom(1 * time.Second)
+	config.Shape = shape
+	config.PreAllocatedVUs = null.IntFrom(10)
+	config.MaxVUs = null.IntFrom(20)
+	return config
+}
+
+func TestShapedArrivalRateValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		shape    RateShape
+		duration types.NullDuration
+		err      string
+	}{
+		{name: "no type", err: "the shape type must be specified"},
+		{name: "unknown type", shape: RateShape{Type: "square"}, err: "unknown shape type 'square'"},
+		{
+			name:  "sine without duration",
+			shape: RateShape{Type: RateShapeSine, Base: null.FloatFrom(10), Period: types.NullDurationFrom(time.Second)},
+			err:   "the duration must be more than 0 for the sine shape",
+		},
+		{
+			name: "sine big amplitude",
+			shape: RateShape{
+				Type: RateShapeSine, Base: null.FloatFrom(10), Amplitude: null.FloatFrom(11),
+				Period: types.NullDurationFrom(time.Second),
+			},
+			duration: types.NullDurationFrom(time.Minute),
+			err:      "the amplitude must be between 0 and the base rate",
+		},
+		{
+			name: "spikes too wide",
+			shape: RateShape{
+				Type: RateShapeSpikes, Base: null.FloatFrom(1), Peak: null.FloatFrom(10),
+				Period: types.NullDurationFrom(time.Second), Width: types.NullDurationFrom(2 * time.Second),
+			},
+			duration: types.NullDurationFrom(time.Minute),
+			err:      "the width must be more than 0 and not longer than the period",
+		},
+		{
+			name: "points with duration",
+			shape: RateShape{Type: RateShapePoints, Points: []RatePoint{
+				{Time: types.NullDurationFrom(0), Rate: null.FloatFrom(1)},
+			}},
+			duration: types.NullDurationFrom(time.Minute),
+			err:      "the duration can't be specified for the points shape",
+		},
+		{
+			name: "unordered points",
+			shape: RateShape{Type: RateShapePoints, Points: []RatePoint{
+				{Time: types.NullDurationFrom(time.Second), Rate: null.FloatFrom(1)},
+				{Time: types.NullDurationFrom(0), Rate: null.FloatFrom(1)},
+			}},
+			err: "the time for rate point 2 can't be before the previous one",
+		},
+		{
+			name:  "bad csv",
+			shape: RateShape{Type: RateShapeCSV, CSV: null.StringFrom("time,rate\n1s,5\n2s,foo\n")},
+			err:   `invalid rate csv data on row 3: "2s,foo"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			config := getTestShapedArrivalRateConfig(tc.shape)
+			config.Duration = tc.duration
+			errs := config.Validate()
+			require.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], tc.err)
+		})
+	}
+}
+
+func TestShapedArrivalRateStages(t *testing.T) {
+	t.Parallel()
+
+	t.Run("spikes", func(t *testing.T) {
+		t.Parallel()
+		config := getTestShapedArrivalRateConfig(RateShape{
+			Type: RateShapeSpikes, Base: null.FloatFrom(1), Peak: null.FloatFrom(10.5),
+			Period: types.NullDurationFrom(10 * time.Second), Width: types.NullDurationFrom(2 * time.Second),
+		})
+		config.Duration = types.NullDurationFrom(15 * time.Second)
+		require.Empty(t, config.Validate())
+
+		rc := config.getRampingConfig()
+		assert.Equal(t, types.NullDurationFrom(1000*time.Second), rc.TimeUnit)
+		assert.Equal(t, null.IntFrom(10500), rc.StartRate)
+		stage := func(d time.Duration, target int64) Stage {
+			return Stage{Duration: types.NullDurationFrom(d), Target: null.IntFrom(target)}
+		}
+		assert.Equal(t, []Stage{
+			stage(0, 10500), stage(2*time.Second, 10500), stage(0, 1000),
+			stage(8*time.Second, 10500), stage(2*time.Second, 10500), stage(0, 1000),
+			stage(3*time.Second, 1000),
+		}, rc.Stages)
+		assert.Equal(t, 15*time.Second, sumStagesDuration(rc.Stages))
+	})
+
+	t.Run("sine", func(t *testing.T) {
+		t.Parallel()
+		config := getTestShapedArrivalRateConfig(RateShape{
+			Type: RateShapeSine, Base: null.FloatFrom(10), Amplitude: null.FloatFrom(5),
+			Period: types.NullDurationFrom(4 * time.Second),
+		})
+		config.Duration = types.NullDurationFrom(4 * time.Second)
+		require.Empty(t, config.Validate())
+
+		rc := config.getRampingConfig()
+		targets := make([]int64, 0, len(rc.Stages))
+		for _, s := range rc.Stages {
+			targets = append(targets, s.Target.Int64)
+		}
+		assert.Equal(t, []int64{10000, 15000, 10000, 5000, 10000}, targets)
+
+		et, err := lib.NewExecutionTuple(nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Up to 15.00 iterations/s for 4s following a sine shape (maxVUs: 10-20, gracefulStop: 1s)",
+			config.GetDescription(et))
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+		config := getTestShapedArrivalRateConfig(RateShape{
+			Type: RateShapeCSV, CSV: null.StringFrom("time,rate\n1s,5\n1m,0.25\n90000,2\n"),
+		})
+		require.Empty(t, config.Validate())
+
+		rc := config.getRampingConfig()
+		assert.Equal(t, null.IntFrom(5000), rc.StartRate)
+		assert.Equal(t, []Stage{
+			{Duration: types.NullDurationFrom(time.Second), Target: null.IntFrom(5000)},
+			{Duration: types.NullDurationFrom(59 * time.Second), Target: null.IntFrom(250)},
+			{Duration: types.NullDurationFrom(30 * time.Second), Target: null.IntFrom(2000)},
+		}, rc.Stages)
+	})
+}
+
+func TestShapedArrivalRateRun(t *testing.T) {
+	t.Parallel()
+
+	config := getTestShapedArrivalRateConfig(RateShape{Type: RateShapePoints, Points: []RatePoint{
+		{Time: types.NullDurationFrom(0), Rate: null.FloatFrom(20)},
+		{Time: types.NullDurationFrom(time.Second), Rate: null.FloatFrom(20)},
+		{Time: types.NullDurationFrom(time.Second), Rate: null.FloatFrom(0)},
+		{Time: types.NullDurationFrom(2 * time.Second), Rate: null.FloatFrom(0)},
+	}})
+	require.Empty(t, config.Validate())
+
+	var count int64
+	runner := simpleRunner(func(ctx context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.InDelta(t, 20, atomic.LoadInt64(&count), 1)
+	assert.Empty(t, test.logHook.Drain())
+}
@@ -229,7 +229,7 @@ func (si SharedIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 	}()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(si.executionState, si.logger)
+	runIteration := getIterationRunner(si.BaseExecutor)
 
 	returnVU := func(u lib.InitializedVU) {
 		si.executionState.ReturnVU(u, true)
@@ -0,0 +1,337 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/ui/pb"
+)
+
+const trafficReplayType = "traffic-replay"
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		trafficReplayType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewTrafficReplayConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// TrafficReplayConfig stores the config for the traffic-replay executor, which
+// starts one iteration for every recorded request, with the original timing
+// between them. The source is the content of a HAR file or an access log, e.g.
+// loaded with open(), and the request for the current iteration is available
+// in the script through the k6/experimental/replay module.
+type TrafficReplayConfig struct {
+	BaseConfig
+	Source  null.String `json:"source"`
+	Format  null.String `json:"format"`
+	BaseURL null.String `json:"baseURL"`
+
+	// Speed multiplies the original rate of requests, i.e. 2 replays the
+	// recorded traffic twice as fast and 0.5 - twice as slow.
+	Speed null.Float `json:"speed"`
+
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewTrafficReplayConfig returns a TrafficReplayConfig with default values
+func NewTrafficReplayConfig(name string) *TrafficReplayConfig {
+	return &TrafficReplayConfig{
+		BaseConfig: NewBaseConfig(name, trafficReplayType),
+		Format:     null.NewString(ReplayFormatHAR, false),
+		Speed:      null.NewFloat(1, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &TrafficReplayConfig{}
+
+// GetRequests parses and returns the requests that will be replayed.
+func (trc TrafficReplayConfig) GetRequests() ([]ReplayRequest, error) {
+	return ParseReplayRequests(trc.Format.String, trc.Source.String, trc.BaseURL.String)
+}
+
+// getDuration returns how long it will take to start all of the requests.
+func (trc TrafficReplayConfig) getDuration(requests []ReplayRequest) time.Duration {
+	if len(requests) == 0 || trc.Speed.Float64 <= 0 {
+		return 0
+	}
+	return time.Duration(float64(requests[len(requests)-1].Offset) / trc.Speed.Float64)
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (trc TrafficReplayConfig) GetDescription(et *lib.ExecutionTuple) string {
+	preAllocatedVUs, maxVUs := et.ScaleInt64(trc.PreAllocatedVUs.Int64), et.ScaleInt64(trc.MaxVUs.Int64)
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", preAllocatedVUs)
+	if maxVUs > preAllocatedVUs {
+		maxVUsRange += fmt.Sprintf("-%d", maxVUs)
+	}
+
+	requests, _ := trc.GetRequests()
+	return fmt.Sprintf("%d %s requests replayed over %s at %.2fx speed%s",
+		len(requests), trc.Format.String, trc.getDuration(requests), trc.Speed.Float64,
+		trc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (trc *TrafficReplayConfig) Validate() []error {
+	errors := trc.BaseConfig.Validate()
+
+	if !trc.Source.Valid || trc.Source.String == "" {
+		errors = append(errors, fmt.Errorf("the replay source isn't specified"))
+	} else if _, err := trc.GetRequests(); err != nil {
+		errors = append(errors, err)
+	}
+
+	if trc.Speed.Float64 <= 0 {
+		errors = append(errors, fmt.Errorf("the speed must be more than 0"))
+	}
+
+	if !trc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if trc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !trc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		trc.MaxVUs.Int64 = trc.PreAllocatedVUs.Int64
+	} else if trc.MaxVUs.Int64 < trc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop.
+func (trc TrafficReplayConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	requests, _ := trc.GetRequests()
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(et.ScaleInt64(trc.PreAllocatedVUs.Int64)),
+			MaxUnplannedVUs: uint64(et.ScaleInt64(trc.MaxVUs.Int64) - et.ScaleInt64(trc.PreAllocatedVUs.Int64)),
+		}, {
+			TimeOffset:      trc.getDuration(requests) + trc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new TrafficReplay executor
+func (trc TrafficReplayConfig) NewExecutor(es *lib.ExecutionState, logger *logrus.Entry) (lib.Executor, error) {
+	requests, err := trc.GetRequests()
+	if err != nil {
+		return nil, err
+	}
+	return &TrafficReplay{
+		BaseExecutor: NewBaseExecutor(&trc, es, logger),
+		config:       trc,
+		requests:     requests,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (trc TrafficReplayConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return et.ScaleInt64(trc.MaxVUs.Int64) > 0
+}
+
+// TrafficReplay starts an iteration for every recorded request, at the
+// (scaled) time it was originally made.
+type TrafficReplay struct {
+	*BaseExecutor
+	config   TrafficReplayConfig
+	requests []ReplayRequest
+	et       *lib.ExecutionTuple
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &TrafficReplay{}
+
+// Init values needed for the execution
+func (tr *TrafficReplay) Init(_ context.Context) error {
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := tr.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(tr.config.MaxVUs.Int64)
+	tr.et = et
+	tr.iterSegIndex = lib.NewSegmentedIndex(et)
+
+	return err
+}
+
+// Run replays the recorded requests. Like the arrival-rate executors, if there
+// isn't a free VU when a request should be started, it's dropped.
+//
+//nolint:funlen
+func (tr TrafficReplay) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	gracefulStop := tr.config.GetGracefulStop()
+	duration := tr.config.getDuration(tr.requests)
+	speed := tr.config.Speed.Float64
+	preAllocatedVUs := tr.et.ScaleInt64(tr.config.PreAllocatedVUs.Int64)
+	maxVUs := tr.et.ScaleInt64(tr.config.MaxVUs.Int64)
+
+	tr.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"requests": len(tr.requests), "speed": speed, "type": tr.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	vusPool := newActiveVUPool(tr.executionState)
+	defer func() {
+		<-returnedVUs
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount := uint64(0)
+	startedRequests := uint64(0)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	reqsFmt := pb.GetFixedLengthIntFormat(int64(len(tr.requests)))
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", vusPool.Running(), atomic.LoadUint64(&activeVUsCount))
+		progReqs := fmt.Sprintf(reqsFmt+"/"+reqsFmt+" requests",
+			atomic.LoadUint64(&startedRequests), len(tr.requests))
+		right := []string{progVUs, duration.String(), progReqs}
+
+		if spent > duration {
+			return 1, right
+		}
+		right[1] = fmt.Sprintf("%s/%s", pb.GetFixedLengthDuration(spent, duration), duration)
+		if duration == 0 {
+			return 1, right
+		}
+		return math.Min(1, float64(spent)/float64(duration)), right
+	}
+	tr.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       tr.config.Name,
+		Executor:   tr.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &tr, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		tr.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := getIterationRunner(tr.BaseExecutor)
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		activeVU := initVU.Activate(getVUActivationParams(
+			maxDurationCtx, tr.config.BaseConfig, returnVU,
+			tr.nextIterationCounters,
+		))
+		atomic.AddUint64(&activeVUsCount, 1)
+		vusPool.AddVU(maxDurationCtx, activeVU, runIterationBasic)
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			tr.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := tr.executionState.GetUnplannedVU(maxDurationCtx, tr.logger)
+			if err != nil {
+				tr.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				tr.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := tr.executionState.GetPlannedVU(tr.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	// The global iteration numbers of this instance are the indexes of the
+	// requests it replays, which is how the replay JS module finds them.
+	start, offsets, _ := tr.et.GetStripedOffsets()
+	timer := time.NewTimer(time.Hour * 24)
+	droppedIterationMetric := tr.executionState.Test.BuiltinMetrics.DroppedIterations
+	shownWarning := false
+	metricTags := tr.getMetricTags(nil)
+	for li, gi := 0, start; gi < int64(len(tr.requests)); li, gi = li+1, gi+offsets[li%len(offsets)] {
+		t := time.Duration(float64(tr.requests[gi].Offset)/speed) - time.Since(startTime)
+		timer.Reset(t)
+		select {
+		case <-timer.C:
+			atomic.AddUint64(&startedRequests, 1)
+			if vusPool.TryRunIteration() {
+				continue
+			}
+
+			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: droppedIterationMetric,
+					Tags:   metricTags,
+				},
+				Time:  time.Now(),
+				Value: 1,
+			})
+
+			if remainingUnplannedVUs == 0 {
+				if !shownWarning {
+					tr.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+					shownWarning = true
+				}
+				continue
+			}
+
+			select {
+			case makeUnplannedVUCh <- struct{}{}:
+				remainingUnplannedVUs--
+			default: // we're already allocating a new VU
+			}
+
+		case <-maxDurationCtx.Done():
+			// the regular duration ends when the last request should be
+			// started, so it can't be used here, since timers could be late
+			return nil
+		}
+	}
+
+	<-regDurationCtx.Done()
+	return nil
+}
@@ -0,0 +1,162 @@
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReplayRequest is a single recorded request that should be replayed by the
+// traffic-replay executor, at the given offset from the first recorded one.
+type ReplayRequest struct {
+	Offset  time.Duration     `json:"offset"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// The supported formats of the traffic-replay source
+const (
+	ReplayFormatHAR      = "har"
+	ReplayFormatCommon   = "common"
+	ReplayFormatCombined = "combined"
+)
+
+// accessLogRegex matches the Common Log Format and, optionally, the extra
+// referer and user agent fields of the Combined Log Format.
+var accessLogRegex = regexp.MustCompile(
+	`^(\S+) \S+ \S+ \[([^\]]+)\] "(\S+) (\S+)(?: [^"]*)?" \S+ \S+(?: "([^"]*)" "([^"]*)")?`,
+)
+
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// harLog contains the subset of the HAR 1.2 format that is needed for replay.
+type harLog struct {
+	Log struct {
+		Entries []struct {
+			StartedDateTime time.Time `json:"startedDateTime"`
+			Request         struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// ParseReplayRequests parses the recorded traffic in the given format and
+// returns the requests sorted by their offset from the first one. For access
+// logs, which contain only the request paths, the baseURL is prepended.
+func ParseReplayRequests(format, source, baseURL string) ([]ReplayRequest, error) {
+	var (
+		requests []ReplayRequest
+		times    []time.Time
+		err      error
+	)
+	switch format {
+	case ReplayFormatHAR:
+		requests, times, err = parseHARRequests(source)
+	case ReplayFormatCommon, ReplayFormatCombined:
+		requests, times, err = parseAccessLogRequests(source, baseURL)
+	default:
+		return nil, fmt.Errorf("unknown replay format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no requests found in the %s replay source", format)
+	}
+
+	first := times[0]
+	for _, t := range times {
+		if t.Before(first) {
+			first = t
+		}
+	}
+	for i := range requests {
+		requests[i].Offset = times[i].Sub(first)
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].Offset < requests[j].Offset
+	})
+	return requests, nil
+}
+
+func parseHARRequests(source string) ([]ReplayRequest, []time.Time, error) {
+	var har harLog
+	if err := json.Unmarshal([]byte(source), &har); err != nil {
+		return nil, nil, fmt.Errorf("invalid HAR replay source: %w", err)
+	}
+
+	requests := make([]ReplayRequest, 0, len(har.Log.Entries))
+	times := make([]time.Time, 0, len(har.Log.Entries))
+	for _, e := range har.Log.Entries {
+		req := ReplayRequest{
+			Method:  e.Request.Method,
+			URL:     e.Request.URL,
+			Headers: make(map[string]string, len(e.Request.Headers)),
+		}
+		for _, h := range e.Request.Headers {
+			// HTTP/2 pseudo-headers can't be sent as normal ones
+			if !strings.HasPrefix(h.Name, ":") {
+				req.Headers[h.Name] = h.Value
+			}
+		}
+		if e.Request.PostData != nil {
+			req.Body = e.Request.PostData.Text
+		}
+		requests = append(requests, req)
+		times = append(times, e.StartedDateTime)
+	}
+	return requests, times, nil
+}
+
+func parseAccessLogRequests(source, baseURL string) ([]ReplayRequest, []time.Time, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	var (
+		requests []ReplayRequest
+		times    []time.Time
+	)
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		match := accessLogRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil, nil, fmt.Errorf("invalid access log line %d: %q", lineNum, line)
+		}
+		t, err := time.Parse(accessLogTimeLayout, match[2])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time on access log line %d: %w", lineNum, err)
+		}
+
+		req := ReplayRequest{Method: match[3], URL: baseURL + match[4], Headers: make(map[string]string)}
+		if referer := match[5]; referer != "" && referer != "-" {
+			req.Headers["Referer"] = referer
+		}
+		if userAgent := match[6]; userAgent != "" && userAgent != "-" {
+			req.Headers["User-Agent"] = userAgent
+		}
+		requests = append(requests, req)
+		times = append(times, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading the access log: %w", err)
+	}
+	return requests, times, nil
+}
@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+const testHARSource = `{"log": {"entries": [
+	{"startedDateTime": "2023-01-01T10:00:00.300Z", "request": {
+		"method": "POST", "url": "https://test.k6.io/login",
+		"headers": [{"name": ":authority", "value": "test.k6.io"}, {"name": "Content-Type", "value": "text/plain"}],
+		"postData": {"text": "user=admin"}
+	}},
+	{"startedDateTime": "2023-01-01T10:00:00.000Z", "request": {
+		"method": "GET", "url": "https://test.k6.io/", "headers": []
+	}},
+	{"startedDateTime": "2023-01-01T10:00:00.500Z", "request": {
+		"method": "GET", "url": "https://test.k6.io/news.php", "headers": []
+	}}
+]}}`
+
+func getTestTrafficReplayConfig() *TrafficReplayConfig {
+	config := NewTrafficReplayConfig("test")
+	config.Source = null.StringFrom(testHARSource)
+	config.PreAllocatedVUs = null.IntFrom(2)
+	config.MaxVUs = null.IntFrom(3)
+	return config
+}
+
+func TestParseReplayRequests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("har", func(t *testing.T) {
+		t.Parallel()
+		requests, err := ParseReplayRequests(ReplayFormatHAR, testHARSource, "")
+		require.NoError(t, err)
+		assert.Equal(t, []ReplayRequest{
+			{Method: "GET", URL: "https://test.k6.io/", Headers: map[string]string{}},
+			{
+				Offset: 300 * time.Millisecond, Method: "POST", URL: "https://test.k6.io/login",
+				Headers: map[string]string{"Content-Type": "text/plain"}, Body: "user=admin",
+			},
+			{Offset: 500 * time.Millisecond, Method: "GET", URL: "https://test.k6.io/news.php", Headers: map[string]string{}},
+		}, requests)
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		t.Parallel()
+		source := `127.0.0.1 - - [01/Jan/2023:10:00:02 +0000] "GET /contacts.php HTTP/1.1" 200 512 "-" "curl/7.68.0"
+127.0.0.1 - frank [01/Jan/2023:10:00:00 +0000] "GET /?a=b HTTP/1.1" 200 2326 "https://test.k6.io/" "-"
+`
+		requests, err := ParseReplayRequests(ReplayFormatCombined, source, "https://test.k6.io/")
+		require.NoError(t, err)
+		assert.Equal(t, []ReplayRequest{
+			{Method: "GET", URL: "https://test.k6.io/?a=b", Headers: map[string]string{"Referer": "https://test.k6.io/"}},
+			{
+				Offset: 2 * time.Second, Method: "GET", URL: "https://test.k6.io/contacts.php",
+				Headers: map[string]string{"User-Agent": "curl/7.68.0"},
+			},
+		}, requests)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseReplayRequests("pcap", "", "")
+		assert.EqualError(t, err, "unknown replay format 'pcap'")
+		_, err = ParseReplayRequests(ReplayFormatHAR, `{"log": {"entries": []}}`, "")
+		assert.EqualError(t, err, "no requests found in the har replay source")
+		_, err = ParseReplayRequests(ReplayFormatCommon, "\nfoo bar\n", "")
+		assert.EqualError(t, err, `invalid access log line 2: "foo bar"`)
+	})
+}
+
+func TestTrafficReplayConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	config := getTestTrafficReplayConfig()
+	require.Empty(t, config.Validate())
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "3 har requests replayed over 500ms at 1.00x speed (maxVUs: 2-3, gracefulStop: 30s)",
+		config.GetDescription(et))
+
+	config.Speed = null.FloatFrom(0)
+	config.Source = null.StringFrom("")
+	config.PreAllocatedVUs = null.IntFrom(-1)
+	errs := config.Validate()
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "the replay source isn't specified")
+	assert.EqualError(t, errs[1], "the speed must be more than 0")
+	assert.EqualError(t, errs[2], "the number of preAllocatedVUs can't be negative")
+}
+
+func TestTrafficReplayRun(t *testing.T) {
+	t.Parallel()
+
+	config := getTestTrafficReplayConfig()
+	config.Speed = null.FloatFrom(2)
+	require.Empty(t, config.Validate())
+
+	var (
+		mx      sync.Mutex
+		offsets []time.Duration
+	)
+	startTime := time.Now()
+	runner := simpleRunner(func(ctx context.Context, state *lib.State) error {
+		mx.Lock()
+		defer mx.Unlock()
+		assert.Equal(t, uint64(len(offsets)), state.GetScenarioGlobalVUIter())
+		offsets = append(offsets, time.Since(startTime))
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	require.Len(t, offsets, 3)
+	assert.InDelta(t, 150*time.Millisecond, offsets[1], float64(100*time.Millisecond))
+	assert.InDelta(t, 250*time.Millisecond, offsets[2], float64(100*time.Millisecond))
+	assert.Empty(t, test.logHook.Drain())
+}
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
 
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/ui/pb"
 )
@@ -78,6 +80,39 @@ type ExecutorConfig interface {
 	GetExec() string
 	GetTags() map[string]string
 
+	// Returns the optional scenario-level setup and teardown functions, which
+	// are run once before the scenario starts and once after it's done.
+	GetLifecycle() ScenarioLifecycle
+
+	// Returns what the scenario has to wait for, after its startTime, before
+	// it can actually start.
+	GetStartConditions() ScenarioStartConditions
+
+	// Returns the condition on the error rate of the requests of the
+	// scenario to abort the test, or nil if there isn't one.
+	GetAbortOnErrorRate() *metrics.ErrorRateAbort
+
+	// Returns the scenario override of the discardResponseBodies option, not
+	// valid if there isn't one.
+	GetDiscardResponseBodies() null.Bool
+
+	// Return the caps on the bandwidth of each VU of the scenario, nil if the
+	// global ones apply, and of all of its VUs together, nil if there aren't.
+	GetVUBandwidth() *types.Bandwidth
+	GetBandwidth() *types.Bandwidth
+
+	// Returns the local IPs the connections of the VUs of the scenario are
+	// bound to, nil if the global ones apply.
+	GetLocalIPs() *types.NullIPPool
+
+	// Returns the proxy of the HTTP requests of the scenario, nil if the one
+	// of the environment variables applies.
+	GetProxy() *types.Proxy
+
+	// Returns the settings of the HTTP/2 connections of the scenario, nil if
+	// the defaults apply.
+	GetHTTP2() *types.HTTP2
+
 	// Calculates the VU requirements in different stages of the executor's
 	// execution, including any extensions caused by waiting for iterations to
 	// finish with graceful stops or ramp-downs.
@@ -99,12 +134,47 @@ type ScenarioOptions struct {
 	Browser map[string]any `json:"browser"`
 }
 
+// ScenarioLifecycle contains the names of the exported functions that should be
+// run once before and once after a specific scenario, together with their
+// timeouts. Empty names mean that the scenario doesn't have such functions and
+// zero timeouts mean that the global setupTimeout and teardownTimeout options
+// should be used instead.
+type ScenarioLifecycle struct {
+	Setup, Teardown               string
+	SetupTimeout, TeardownTimeout time.Duration
+}
+
+// ScenarioDependency is another scenario that has to either finish, or only
+// complete its setup and start running, before a scenario can start.
+type ScenarioDependency struct {
+	Scenario  string
+	OnlySetup bool
+}
+
+// MetricCondition is a condition on the aggregated values of a metric (or a
+// sub-metric), in the same format as the thresholds, e.g. "count>100".
+type MetricCondition struct {
+	Metric    string `json:"metric"`
+	Condition string `json:"condition"`
+}
+
+// ScenarioStartConditions contains the conditions that have to be met, after
+// the startTime of a scenario, before it can start. A nil StartWhen means that
+// the scenario doesn't depend on any metric values.
+type ScenarioStartConditions struct {
+	DependsOn []ScenarioDependency
+	StartWhen *MetricCondition
+}
+
 // ScenarioState holds runtime scenario information returned by the k6/execution
 // JS module.
 type ScenarioState struct {
 	Name, Executor string
 	StartTime      time.Time
 	ProgressFn     func() (float64, []string)
+	// Returns the index of the current stage and its target, for the
+	// executors with stages, nil for the others.
+	StageFn func() (index int, target int64)
 }
 
 // InitVUFunc is just a shorthand so we don't have to type the function
@@ -128,9 +198,17 @@ type PausableExecutor interface {
 	SetPaused(bool) error
 }
 
+// ScenarioPausableExecutor should be implemented by the executors whose
+// scenario can be paused and resumed on its own, while the other scenarios of
+// the test keep running. All of the built-in executors implement it.
+type ScenarioPausableExecutor interface {
+	SetScenarioPaused(bool) error
+	IsScenarioPaused() bool
+}
+
 // LiveUpdatableExecutor should be implemented for the executors whose
 // configuration can be modified in the middle of the test execution. Currently,
-// only the manual execution executor implements it.
+// only the manual execution and the ramping-vus executors implement it.
 type LiveUpdatableExecutor interface {
 	UpdateConfig(ctx context.Context, newConfig interface{}) error
 }
@@ -202,10 +280,64 @@ func (scs ScenarioConfigs) Validate() (errors []error) {
 			errors = append(errors,
 				fmt.Errorf("scenario %s has configuration errors: %s", name, ConcatErrors(execErr, ", ")))
 		}
+		for _, dep := range exec.GetStartConditions().DependsOn {
+			if _, ok := scs[dep.Scenario]; !ok {
+				errors = append(errors, fmt.Errorf("scenario %s depends on the non-existent scenario %s", name, dep.Scenario))
+			}
+		}
+	}
+	if cycle := scs.findDependencyCycle(); cycle != nil {
+		errors = append(errors, fmt.Errorf("the scenario dependencies have a cycle: %s", strings.Join(cycle, " -> ")))
 	}
 	return errors
 }
 
+// findDependencyCycle returns the names of the scenarios in the first found
+// dependency cycle, in which the first and the last one are the same, or nil
+// if there aren't any cycles.
+func (scs ScenarioConfigs) findDependencyCycle() []string {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(scs))
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		}
+		conf, ok := scs[name]
+		if !ok {
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range conf.GetStartConditions().DependsOn {
+			if cycle := visit(dep.Scenario); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, conf := range scs.GetSortedConfigs() { // for a predictable result
+		if cycle := visit(conf.GetName()); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
 // GetSortedConfigs returns a slice with the executor configurations,
 // sorted in a consistent and predictable manner. It is useful when we want or
 // have to avoid using maps with string keys (and tons of string lookups in
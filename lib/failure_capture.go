@@ -0,0 +1,129 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The defaults of the captureFailuresLimit and the captureFailuresBodySize options.
+const (
+	DefaultCaptureFailuresLimit    = 5
+	DefaultCaptureFailuresBodySize = 1024
+)
+
+// CapturedRequest is a captured HTTP request.
+type CapturedRequest struct {
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+}
+
+// CapturedResponse is a captured HTTP response.
+type CapturedResponse struct {
+	Status        int               `json:"status"`
+	Headers       map[string]string `json:"headers"`
+	Body          string            `json:"body"`
+	BodyTruncated bool              `json:"bodyTruncated,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// CapturedFailure is a line of the artifact file of the captured failures, the
+// request and the response of either a failed check or a non-2xx status, as
+// told by its reason.
+type CapturedFailure struct {
+	Time     time.Time        `json:"time"`
+	Reason   string           `json:"reason"`
+	Check    string           `json:"check,omitempty"`
+	Request  CapturedRequest  `json:"request"`
+	Response CapturedResponse `json:"response"`
+}
+
+// FailureCapture writes the first requests and responses of each failing
+// check and of each non-2xx status to an artifact file, as JSON lines.
+//
+// The file is only created with the first captured failure, and it's shared
+// by all of the VUs.
+type FailureCapture struct {
+	path            string
+	limit, bodySize int
+
+	mu      sync.Mutex
+	counts  map[string]int
+	file    *os.File
+	openErr error
+}
+
+// NewFailureCapture returns a FailureCapture writing to the file at path with
+// the limit and the body size of the options, or their defaults.
+func NewFailureCapture(path string, opts Options) *FailureCapture {
+	fc := &FailureCapture{
+		path:     path,
+		limit:    DefaultCaptureFailuresLimit,
+		bodySize: DefaultCaptureFailuresBodySize,
+		counts:   make(map[string]int),
+	}
+	if opts.CaptureFailuresLimit.Valid {
+		fc.limit = int(opts.CaptureFailuresLimit.Int64)
+	}
+	if opts.CaptureFailuresBodySize.Valid {
+		fc.bodySize = int(opts.CaptureFailuresBodySize.Int64)
+	}
+	return fc
+}
+
+// CaptureCheck captures the request and the response of a failed check, if
+// the check hasn't already been captured as many times as the limit.
+func (fc *FailureCapture) CaptureCheck(check string, req CapturedRequest, res CapturedResponse) error {
+	return fc.capture("check:"+check, CapturedFailure{Reason: "check", Check: check, Request: req, Response: res})
+}
+
+// CaptureStatus captures the request and the response of a non-2xx status, if
+// the status hasn't already been captured as many times as the limit.
+func (fc *FailureCapture) CaptureStatus(req CapturedRequest, res CapturedResponse) error {
+	key := "status:" + strconv.Itoa(res.Status)
+	return fc.capture(key, CapturedFailure{Reason: "status", Request: req, Response: res})
+}
+
+func (fc *FailureCapture) capture(key string, failure CapturedFailure) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.counts[key] >= fc.limit {
+		return nil
+	}
+	fc.counts[key]++
+
+	if fc.file == nil && fc.openErr == nil {
+		//nolint:gosec,forbidigo // see https://github.com/grafana/k6/issues/2565
+		fc.file, fc.openErr = os.OpenFile(fc.path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0o644)
+		if fc.openErr != nil {
+			return fmt.Errorf("couldn't create the file of the captured failures: %w", fc.openErr)
+		}
+	}
+	if fc.openErr != nil {
+		return nil // the error has already been returned once
+	}
+
+	failure.Time = time.Now()
+	failure.Request.Body, failure.Request.BodyTruncated = fc.truncate(failure.Request.Body)
+	failure.Response.Body, failure.Response.BodyTruncated = fc.truncate(failure.Response.Body)
+	data, err := json.Marshal(failure)
+	if err != nil {
+		return err
+	}
+	_, err = fc.file.Write(append(data, '\n'))
+	return err
+}
+
+func (fc *FailureCapture) truncate(body string) (string, bool) {
+	if len(body) <= fc.bodySize {
+		return body, false
+	}
+	return body[:fc.bodySize], true
+}
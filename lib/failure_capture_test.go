@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestFailureCapture(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "failures.jsonl")
+	fc := NewFailureCapture(path, Options{CaptureFailuresLimit: null.IntFrom(2), CaptureFailuresBodySize: null.IntFrom(4)})
+
+	_, err := os.Stat(path) //nolint:forbidigo
+	require.ErrorIs(t, err, os.ErrNotExist, "the file should only be created with the first failure")
+
+	req := CapturedRequest{Method: "GET", URL: "http://example.com", Body: "abc"}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, fc.CaptureStatus(req, CapturedResponse{Status: 500, Body: "internal error"}))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, fc.CaptureCheck("is ok", req, CapturedResponse{Status: 404}))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, fc.CaptureStatus(req, CapturedResponse{Status: 502}))
+
+	data, err := os.ReadFile(path) //nolint:forbidigo
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 5)
+
+	reasons := map[string]int{}
+	for _, line := range lines {
+		var failure CapturedFailure
+		require.NoError(t, json.Unmarshal([]byte(line), &failure))
+		reasons[failure.Reason+failure.Check]++
+		assert.Equal(t, "abc", failure.Request.Body)
+		assert.False(t, failure.Request.BodyTruncated)
+		if failure.Response.Status == 500 {
+			assert.Equal(t, "inte", failure.Response.Body)
+			assert.True(t, failure.Response.BodyTruncated)
+		}
+	}
+	assert.Equal(t, map[string]int{"status": 3, "checkis ok": 2}, reasons)
+}
+
+func TestFailureCaptureFileError(t *testing.T) {
+	t.Parallel()
+
+	fc := NewFailureCapture(filepath.Join(t.TempDir(), "missing", "failures.jsonl"), Options{})
+	assert.ErrorContains(t, fc.CaptureStatus(CapturedRequest{}, CapturedResponse{}),
+		"couldn't create the file of the captured failures")
+	assert.NoError(t, fc.CaptureStatus(CapturedRequest{}, CapturedResponse{}), "the error should only be returned once")
+}
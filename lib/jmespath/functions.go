@@ -0,0 +1,410 @@
+package jmespath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// arities are the minimum and maximum numbers of arguments of the functions,
+// a negative maximum meaning that they're variadic.
+var arities = map[string][2]int{ //nolint:gochecknoglobals
+	"abs": {1, 1}, "avg": {1, 1}, "ceil": {1, 1}, "contains": {2, 2}, "ends_with": {2, 2},
+	"floor": {1, 1}, "join": {2, 2}, "keys": {1, 1}, "length": {1, 1}, "map": {2, 2},
+	"max": {1, 1}, "max_by": {2, 2}, "merge": {1, -1}, "min": {1, 1}, "min_by": {2, 2},
+	"not_null": {1, -1}, "reverse": {1, 1}, "sort": {1, 1}, "sort_by": {2, 2},
+	"starts_with": {2, 2}, "sum": {1, 1}, "to_array": {1, 1}, "to_number": {1, 1},
+	"to_string": {1, 1}, "type": {1, 1}, "values": {1, 1},
+}
+
+// checkArity returns an error if the function doesn't exist or doesn't accept
+// the number of arguments.
+func checkArity(name string, n int) error {
+	arity, ok := arities[name]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown function %s()", name)
+	case n < arity[0] || (arity[1] >= 0 && n > arity[1]):
+		return fmt.Errorf("invalid arity: %s() expects %s, but got %d", name, describeArity(arity), n)
+	default:
+		return nil
+	}
+}
+
+func describeArity(arity [2]int) string {
+	switch {
+	case arity[1] < 0:
+		return fmt.Sprintf("at least %d arguments", arity[0])
+	case arity[0] == 1:
+		return "1 argument"
+	default:
+		return fmt.Sprintf("%d arguments", arity[0])
+	}
+}
+
+// typeOf returns the JMESPath type of the value.
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case expref:
+		return "expref"
+	default:
+		return "unknown"
+	}
+}
+
+func invalidType(name string, i int, expected string, value interface{}) error {
+	return fmt.Errorf("invalid type: the argument %d of %s() has to be %s, but it's %s",
+		i+1, name, expected, typeOf(value))
+}
+
+// callFunction calls the built-in function with the evaluated arguments.
+//
+//nolint:funlen,cyclop,gocognit
+func callFunction(name string, args []interface{}) (interface{}, error) {
+	number := func(i int) (float64, error) {
+		n, ok := args[i].(float64)
+		if !ok {
+			return 0, invalidType(name, i, "a number", args[i])
+		}
+		return n, nil
+	}
+	str := func(i int) (string, error) {
+		s, ok := args[i].(string)
+		if !ok {
+			return "", invalidType(name, i, "a string", args[i])
+		}
+		return s, nil
+	}
+	array := func(i int) ([]interface{}, error) {
+		arr, ok := args[i].([]interface{})
+		if !ok {
+			return nil, invalidType(name, i, "an array", args[i])
+		}
+		return arr, nil
+	}
+	object := func(i int) (map[string]interface{}, error) {
+		obj, ok := args[i].(map[string]interface{})
+		if !ok {
+			return nil, invalidType(name, i, "an object", args[i])
+		}
+		return obj, nil
+	}
+	exprefArg := func(i int) (*node, error) {
+		ref, ok := args[i].(expref)
+		if !ok {
+			return nil, invalidType(name, i, "an expression reference", args[i])
+		}
+		return ref.node, nil
+	}
+	numbers := func(i int) ([]float64, error) {
+		arr, err := array(i)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]float64, len(arr))
+		for j, element := range arr {
+			n, ok := element.(float64)
+			if !ok {
+				return nil, invalidType(name, i, "an array of numbers", args[i])
+			}
+			result[j] = n
+		}
+		return result, nil
+	}
+
+	switch name {
+	case "abs", "ceil", "floor":
+		n, err := number(0)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]func(float64) float64{"abs": math.Abs, "ceil": math.Ceil, "floor": math.Floor}[name](n), nil
+	case "avg", "sum":
+		ns, err := numbers(0)
+		if err != nil {
+			return nil, err
+		}
+		var sum float64
+		for _, n := range ns {
+			sum += n
+		}
+		if name == "sum" {
+			return sum, nil
+		}
+		if len(ns) == 0 {
+			return nil, nil
+		}
+		return sum / float64(len(ns)), nil
+	case "contains":
+		switch subject := args[0].(type) {
+		case string:
+			search, ok := args[1].(string)
+			return ok && strings.Contains(subject, search), nil
+		case []interface{}:
+			for _, element := range subject {
+				if compare(tEQ, element, args[1]) == true {
+					return true, nil
+				}
+			}
+			return false, nil
+		default:
+			return nil, invalidType(name, 0, "an array or a string", args[0])
+		}
+	case "starts_with", "ends_with":
+		subject, err := str(0)
+		if err != nil {
+			return nil, err
+		}
+		affix, err := str(1)
+		if err != nil {
+			return nil, err
+		}
+		if name == "starts_with" {
+			return strings.HasPrefix(subject, affix), nil
+		}
+		return strings.HasSuffix(subject, affix), nil
+	case "join":
+		glue, err := str(0)
+		if err != nil {
+			return nil, err
+		}
+		arr, err := array(1)
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, len(arr))
+		for i, element := range arr {
+			s, ok := element.(string)
+			if !ok {
+				return nil, invalidType(name, 1, "an array of strings", args[1])
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, glue), nil
+	case "keys":
+		obj, err := object(0)
+		if err != nil {
+			return nil, err
+		}
+		result := []interface{}{}
+		for _, key := range keys(obj) {
+			result = append(result, key)
+		}
+		return result, nil
+	case "values":
+		obj, err := object(0)
+		if err != nil {
+			return nil, err
+		}
+		return values(obj), nil
+	case "length":
+		switch subject := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(subject)), nil
+		case []interface{}:
+			return float64(len(subject)), nil
+		case map[string]interface{}:
+			return float64(len(subject)), nil
+		default:
+			return nil, invalidType(name, 0, "a string, an array or an object", args[0])
+		}
+	case "map":
+		fn, err := exprefArg(0)
+		if err != nil {
+			return nil, err
+		}
+		arr, err := array(1)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]interface{}, len(arr))
+		for i, element := range arr {
+			if result[i], err = fn.search(element); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case "max", "min":
+		arr, err := array(0)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := sortKeys(name, 0, arr)
+		if err != nil || len(arr) == 0 {
+			return nil, err
+		}
+		return arr[extreme(name == "max", keys)], nil
+	case "max_by", "min_by":
+		arr, err := array(0)
+		if err != nil {
+			return nil, err
+		}
+		fn, err := exprefArg(1)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := evalSortKeys(name, arr, fn)
+		if err != nil || len(arr) == 0 {
+			return nil, err
+		}
+		return arr[extreme(name == "max_by", keys)], nil
+	case "sort", "sort_by":
+		arr, err := array(0)
+		if err != nil {
+			return nil, err
+		}
+		var keys []interface{}
+		if name == "sort" {
+			keys, err = sortKeys(name, 0, arr)
+		} else {
+			var fn *node
+			if fn, err = exprefArg(1); err == nil {
+				keys, err = evalSortKeys(name, arr, fn)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		indexes := make([]int, len(arr))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(i, j int) bool { return lessKey(keys[indexes[i]], keys[indexes[j]]) })
+		result := make([]interface{}, len(arr))
+		for i, index := range indexes {
+			result[i] = arr[index]
+		}
+		return result, nil
+	case "merge":
+		result := map[string]interface{}{}
+		for i := range args {
+			obj, err := object(i)
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range obj {
+				result[key] = value
+			}
+		}
+		return result, nil
+	case "not_null":
+		for _, arg := range args {
+			if arg != nil {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	case "reverse":
+		switch subject := args[0].(type) {
+		case string:
+			runes := []rune(subject)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return string(runes), nil
+		case []interface{}:
+			result := make([]interface{}, len(subject))
+			for i, element := range subject {
+				result[len(subject)-1-i] = element
+			}
+			return result, nil
+		default:
+			return nil, invalidType(name, 0, "an array or a string", args[0])
+		}
+	case "to_array":
+		if arr, ok := args[0].([]interface{}); ok {
+			return arr, nil
+		}
+		return []interface{}{args[0]}, nil
+	case "to_string":
+		if s, ok := args[0].(string); ok {
+			return s, nil
+		}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(args[0]); err != nil {
+			return nil, err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	case "to_number":
+		switch subject := args[0].(type) {
+		case float64:
+			return subject, nil
+		case string:
+			n, err := strconv.ParseFloat(subject, 64)
+			if err != nil {
+				return nil, nil //nolint:nilerr // the strings that aren't numbers are null
+			}
+			return n, nil
+		default:
+			return nil, nil
+		}
+	default: // type
+		return typeOf(args[0]), nil
+	}
+}
+
+// sortKeys checks that the elements are all numbers or all strings, which
+// are the values that can be sorted.
+func sortKeys(name string, i int, arr []interface{}) ([]interface{}, error) {
+	for _, element := range arr {
+		if typeOf(element) != typeOf(arr[0]) || (typeOf(element) != "number" && typeOf(element) != "string") {
+			return nil, fmt.Errorf("invalid type: the argument %d of %s() has to be "+
+				"an array of numbers or an array of strings", i+1, name)
+		}
+	}
+	return arr, nil
+}
+
+// evalSortKeys evaluates the expression reference for the elements.
+func evalSortKeys(name string, arr []interface{}, fn *node) ([]interface{}, error) {
+	keys := make([]interface{}, len(arr))
+	for i, element := range arr {
+		key, err := fn.search(element)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	if _, err := sortKeys(name, 1, keys); err != nil {
+		return nil, fmt.Errorf("invalid type: the expression of %s() has to return "+
+			"only numbers or only strings", name)
+	}
+	return keys, nil
+}
+
+func lessKey(a, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		return x < b.(float64) //nolint:forcetypeassert // checked by sortKeys
+	}
+	return a.(string) < b.(string) //nolint:forcetypeassert // checked by sortKeys
+}
+
+// extreme returns the index of the greatest or the least key.
+func extreme(greatest bool, keys []interface{}) int {
+	index := 0
+	for i := 1; i < len(keys); i++ {
+		if lessKey(keys[index], keys[i]) == greatest {
+			index = i
+		}
+	}
+	return index
+}
@@ -0,0 +1,307 @@
+package jmespath
+
+import (
+	"reflect"
+	"sort"
+)
+
+// expref is the value of an expression reference, like `&name`, which the
+// functions like sort_by() evaluate for each element.
+type expref struct {
+	node *node
+}
+
+// search evaluates the node with the value as the current node.
+//
+//nolint:funlen,cyclop,gocognit
+func (n *node) search(value interface{}) (interface{}, error) {
+	switch n.typ {
+	case nField:
+		if obj, ok := value.(map[string]interface{}); ok {
+			return obj[n.name], nil
+		}
+		return nil, nil
+	case nSubexpression, nIndexExpression:
+		result := value
+		for _, child := range n.children {
+			var err error
+			if result, err = child.search(result); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case nIndex:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		i := n.index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil, nil
+		}
+		return arr[i], nil
+	case nSlice:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		return slice(arr, n.slice), nil
+	case nIdentity:
+		return value, nil
+	case nLiteral:
+		return n.literal, nil
+	case nComparator:
+		left, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		right, err := n.children[1].search(value)
+		if err != nil {
+			return nil, err
+		}
+		return compare(n.comparator, left, right), nil
+	case nProjection:
+		base, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		arr, ok := base.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		return project(arr, n.children[1], nil)
+	case nValueProjection:
+		base, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := base.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		return project(values(obj), n.children[1], nil)
+	case nFilterProjection:
+		base, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		arr, ok := base.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		return project(arr, n.children[1], n.children[2])
+	case nFlatten:
+		base, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		arr, ok := base.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		flattened := []interface{}{}
+		for _, element := range arr {
+			if inner, ok := element.([]interface{}); ok {
+				flattened = append(flattened, inner...)
+			} else {
+				flattened = append(flattened, element)
+			}
+		}
+		return flattened, nil
+	case nMultiSelectList:
+		if value == nil {
+			return nil, nil
+		}
+		list := make([]interface{}, len(n.children))
+		for i, child := range n.children {
+			var err error
+			if list[i], err = child.search(value); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case nMultiSelectHash:
+		if value == nil {
+			return nil, nil
+		}
+		hash := make(map[string]interface{}, len(n.children))
+		for i, child := range n.children {
+			v, err := child.search(value)
+			if err != nil {
+				return nil, err
+			}
+			hash[n.keys[i]] = v
+		}
+		return hash, nil
+	case nOr, nAnd:
+		left, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		if isTruthy(left) == (n.typ == nOr) {
+			return left, nil
+		}
+		return n.children[1].search(value)
+	case nNot:
+		v, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		return !isTruthy(v), nil
+	case nPipe:
+		left, err := n.children[0].search(value)
+		if err != nil {
+			return nil, err
+		}
+		return n.children[1].search(left)
+	case nFunction:
+		args := make([]interface{}, len(n.children))
+		for i, child := range n.children {
+			var err error
+			if args[i], err = child.search(value); err != nil {
+				return nil, err
+			}
+		}
+		return callFunction(n.name, args)
+	default: // nExpref
+		return expref{node: n.children[0]}, nil
+	}
+}
+
+// project evaluates the right hand side of a projection for each element,
+// or for the ones matching the condition of a filter projection, and collects
+// the results that aren't null.
+func project(elements []interface{}, rhs, condition *node) (interface{}, error) {
+	results := []interface{}{}
+	for _, element := range elements {
+		if condition != nil {
+			matches, err := condition.search(element)
+			if err != nil {
+				return nil, err
+			}
+			if !isTruthy(matches) {
+				continue
+			}
+		}
+		result, err := rhs.search(element)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// slice returns the elements of the slice, with the semantics of the slices
+// of Python.
+func slice(arr []interface{}, parts [3]*int) []interface{} {
+	length := len(arr)
+	step := 1
+	if parts[2] != nil {
+		step = *parts[2]
+	}
+	adjust := func(endpoint *int, defaultValue int) int {
+		if endpoint == nil {
+			return defaultValue
+		}
+		v := *endpoint
+		switch {
+		case v < 0:
+			v += length
+			if v < 0 {
+				if step < 0 {
+					return -1
+				}
+				return 0
+			}
+		case v >= length:
+			if step < 0 {
+				return length - 1
+			}
+			return length
+		}
+		return v
+	}
+
+	result := []interface{}{}
+	if step > 0 {
+		for i := adjust(parts[0], 0); i < adjust(parts[1], length); i += step {
+			result = append(result, arr[i])
+		}
+		return result
+	}
+	for i := adjust(parts[0], length-1); i > adjust(parts[1], -1); i += step {
+		result = append(result, arr[i])
+	}
+	return result
+}
+
+// compare compares the values with the comparator. All the values can be
+// equal, but only the numbers are ordered.
+func compare(comparator tokenType, left, right interface{}) interface{} {
+	switch comparator {
+	case tEQ:
+		return reflect.DeepEqual(left, right)
+	case tNE:
+		return !reflect.DeepEqual(left, right)
+	}
+
+	x, ok := left.(float64)
+	y, ok2 := right.(float64)
+	if !ok || !ok2 {
+		return nil
+	}
+	switch comparator {
+	case tLT:
+		return x < y
+	case tLTE:
+		return x <= y
+	case tGT:
+		return x > y
+	default: // tGTE
+		return x >= y
+	}
+}
+
+// isTruthy returns whether the value is true, which is the case of all of
+// them but null, false and the empty strings, arrays and objects.
+func isTruthy(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case string:
+		return value != ""
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	default:
+		return true
+	}
+}
+
+// keys returns the keys of the object, in order.
+func keys(obj map[string]interface{}) []string {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// values returns the values of the object, in the order of their keys.
+func values(obj map[string]interface{}) []interface{} {
+	result := make([]interface{}, 0, len(obj))
+	for _, name := range keys(obj) {
+		result = append(result, obj[name])
+	}
+	return result
+}
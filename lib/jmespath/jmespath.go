@@ -0,0 +1,48 @@
+// Package jmespath implements the JMESPath query language, which searches
+// and transforms JSON documents, like `locations[?state == 'WA'].name | sort(@)`.
+//
+// The documents are the values decoded by encoding/json into interface{}, so
+// the numbers are float64. The members of the objects are visited in the
+// order of their names, as the one of the document isn't known.
+package jmespath
+
+// Expression is a compiled JMESPath expression, which is safe for concurrent
+// use.
+type Expression struct {
+	expr string
+	root *node
+}
+
+// Compile parses a JMESPath expression.
+func Compile(expr string) (*Expression, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{expr: expr, tokens: tokens}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{expr: expr, root: root}, nil
+}
+
+// MustCompile is like Compile, but it panics if the expression is invalid.
+func MustCompile(expr string) *Expression {
+	e, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.expr
+}
+
+// Search evaluates the expression against the document. The errors are the
+// ones of the functions called with invalid arguments.
+func (e *Expression) Search(doc interface{}) (interface{}, error) {
+	return e.root.search(doc)
+}
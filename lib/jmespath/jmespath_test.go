@@ -0,0 +1,139 @@
+package jmespath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const doc = `{
+	"locations": [
+		{"name": "Seattle", "state": "WA", "population": 737015},
+		{"name": "New York", "state": "NY", "population": 8804190},
+		{"name": "Bellevue", "state": "WA", "population": 151854},
+		{"name": "Olympia", "state": "WA", "population": 55605}
+	],
+	"reservations": [
+		{"instances": [{"id": "a", "state": "running"}, {"id": "b", "state": "stopped"}]},
+		{"instances": [{"id": "c", "state": "running"}]}
+	],
+	"ops": {"functionA": {"numArgs": 2}, "functionB": {"numArgs": 3}, "functionC": {"variadic": true}},
+	"nested": [[0, 1], 2, [3, [4]]],
+	"a.b": "quoted",
+	"empty": [],
+	"text": "k6 load testing"
+}`
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	var data interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &data))
+
+	testCases := []struct {
+		expr     string
+		expected string
+	}{
+		{`locations[0].name`, `"Seattle"`},
+		{`locations[-1].name`, `"Olympia"`},
+		{`locations[?state == 'WA'].name | sort(@) | {WashingtonCities: join(', ', @)}`,
+			`{"WashingtonCities": "Bellevue, Olympia, Seattle"}`},
+		{`locations[*].state`, `["WA","NY","WA","WA"]`},
+		{`locations[:2].name`, `["Seattle","New York"]`},
+		{`locations[::-2].name`, `["Olympia","New York"]`},
+		{`locations[?population > ` + "`500000`" + `].name`, `["Seattle","New York"]`},
+		{`locations[?state == 'WA' && population < ` + "`100000`" + `].name`, `["Olympia"]`},
+		{`locations[?!(state == 'WA')].name`, `["New York"]`},
+		{`reservations[*].instances[*].id`, `[["a","b"],["c"]]`},
+		{`reservations[].instances[].id`, `["a","b","c"]`},
+		{`reservations[].instances[?state == 'running'].id[]`, `["a","c"]`},
+		{`nested[]`, `[0,1,2,3,[4]]`},
+		{`nested[][]`, `[0,1,2,3,4]`},
+		{`ops.*.numArgs`, `[2,3]`},
+		{`ops.functionA.[numArgs, missing]`, `[2,null]`},
+		{`locations[0].{city: name, pop: population}`, `{"city":"Seattle","pop":737015}`},
+		{`"a.b"`, `"quoted"`},
+		{`missing.field`, `null`},
+		{`missing || 'default'`, `"default"`},
+		{`empty || text`, `"k6 load testing"`},
+		{`text && empty`, `[]`},
+		{`length(locations)`, `4`},
+		{`max_by(locations, &population).name`, `"New York"`},
+		{`min_by(locations, &population).name`, `"Olympia"`},
+		{`sort_by(locations, &name)[*].name`, `["Bellevue","New York","Olympia","Seattle"]`},
+		{`map(&population, locations[?state == 'NY'])`, `[8804190]`},
+		{`sum(locations[*].population)`, `9748664`},
+		{`avg([` + "`1`, `2`" + `])`, `1.5`},
+		{`max(locations[*].name)`, `"Seattle"`},
+		{`contains(locations[*].state, 'NY')`, `true`},
+		{`contains(text, 'load')`, `true`},
+		{`starts_with(text, 'k6') && ends_with(text, 'ing')`, `true`},
+		{`keys(ops)`, `["functionA","functionB","functionC"]`},
+		{`values(ops)[*].numArgs`, `[2,3]`},
+		{`merge(ops.functionA, ops.functionC)`, `{"numArgs":2,"variadic":true}`},
+		{`not_null(missing, ops.functionB.numArgs)`, `3`},
+		{`reverse(text)`, `"gnitset daol 6k"`},
+		{`to_string(ops.functionA)`, `"{\"numArgs\":2}"`},
+		{`to_number('1.5')`, `1.5`},
+		{`to_array(text)`, `["k6 load testing"]`},
+		{`type(ops) == 'object'`, `true`},
+		{`abs(` + "`-3`" + `) == ceil(` + "`2.5`" + `)`, `true`},
+		{"`[1, {\"a\": \"b\"}]`", `[1,{"a":"b"}]`},
+		{`@.text`, `"k6 load testing"`},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			expr, err := Compile(tc.expr)
+			require.NoError(t, err)
+			result, err := expr.Search(data)
+			require.NoError(t, err)
+			actual, err := json.Marshal(result)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	compileErrors := map[string]string{
+		`foo.`:            "unexpected end of the expression",
+		`foo[`:            "unexpected end of the expression",
+		`foo]`:            "unexpected token ']' at position 3",
+		`foo = bar`:       "unexpected character '='",
+		`unknown(@)`:      "unknown function unknown()",
+		`length(@, @)`:    "invalid arity: length() expects 1 argument, but got 2",
+		`foo[::0]`:        "the step of a slice can't be 0",
+		"`\"bad`":         "invalid JSON literal",
+		`'unterminated`:   "unterminated '",
+		`"a"(@)`:          "quoted identifiers can't be function names",
+		`{a: b, c}`:       "unexpected token '}'",
+		`not_null()`:      "not_null() expects at least 1 arguments, but got 0",
+		`[a, b] | [0] |`:  "unexpected end of the expression",
+		`foo.[?bar]`:      "unexpected token '[?'",
+		`foo.bar.baz -1`:  "unexpected token '-1'",
+		`sort_by(@, name`: "unexpected end of the expression",
+	}
+	for expr, msg := range compileErrors {
+		_, err := Compile(expr)
+		assert.ErrorContains(t, err, msg, expr)
+	}
+
+	var data interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &data))
+	searchErrors := map[string]string{
+		`abs(text)`:               "the argument 1 of abs() has to be a number, but it's string",
+		`sort(locations)`:         "the argument 1 of sort() has to be an array of numbers or an array of strings",
+		`sort_by(locations, &id)`: "the expression of sort_by() has to return only numbers or only strings",
+		`join(', ', nested)`:      "the argument 2 of join() has to be an array of strings",
+	}
+	for expr, msg := range searchErrors {
+		_, err := MustCompile(expr).Search(data)
+		assert.ErrorContains(t, err, msg, expr)
+	}
+}
@@ -0,0 +1,185 @@
+package jmespath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	tEOF tokenType = iota
+	tUnquotedIdentifier
+	tQuotedIdentifier
+	tNumber
+	tLiteral
+	tDot
+	tStar
+	tLbracket
+	tRbracket
+	tFilter
+	tFlatten
+	tLbrace
+	tRbrace
+	tLparen
+	tRparen
+	tComma
+	tColon
+	tPipe
+	tOr
+	tAnd
+	tNot
+	tCurrent
+	tExpref
+	tEQ
+	tNE
+	tLT
+	tLTE
+	tGT
+	tGTE
+)
+
+// token is a token of an expression. The identifiers have their value, the
+// numbers their number and the literals, including the raw strings, their
+// decoded JSON value.
+type token struct {
+	typ     tokenType
+	value   string
+	number  int
+	literal interface{}
+	// the position of the token in the expression, and of its end
+	pos, end int
+}
+
+// lexer splits the expressions into tokens.
+type lexer struct {
+	expr string
+	pos  int
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JMESPath '%s': %s at position %d", l.expr, fmt.Sprintf(format, args...), l.pos)
+}
+
+// tokenize returns the tokens of the expression, ending with tEOF.
+func tokenize(expr string) ([]token, error) {
+	l := &lexer{expr: expr}
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tok.end = l.pos
+		tokens = append(tokens, tok)
+		if tok.typ == tEOF {
+			return tokens, nil
+		}
+	}
+}
+
+//nolint:funlen,cyclop
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.expr) && strings.IndexByte(" \t\n\r", l.expr[l.pos]) >= 0 {
+		l.pos++
+	}
+	start := l.pos
+	tok := func(typ tokenType, size int) (token, error) {
+		l.pos += size
+		return token{typ: typ, value: l.expr[start:l.pos], pos: start}, nil
+	}
+	if l.pos >= len(l.expr) {
+		return token{typ: tEOF, pos: start}, nil
+	}
+
+	rest := l.expr[l.pos:]
+	switch c := rest[0]; {
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		size := 1
+		for size < len(rest) && isIdentifierByte(rest[size]) {
+			size++
+		}
+		return tok(tUnquotedIdentifier, size)
+	case c == '-' || (c >= '0' && c <= '9'):
+		size := 1
+		for size < len(rest) && rest[size] >= '0' && rest[size] <= '9' {
+			size++
+		}
+		n, err := strconv.Atoi(rest[:size])
+		if err != nil {
+			return token{}, l.errorf("invalid number '%s'", rest[:size])
+		}
+		l.pos += size
+		return token{typ: tNumber, value: rest[:size], number: n, pos: start}, nil
+	case c == '"':
+		lexeme, err := l.delimited('"')
+		if err != nil {
+			return token{}, err
+		}
+		var name string
+		if err := json.Unmarshal([]byte(`"`+lexeme+`"`), &name); err != nil {
+			return token{}, l.errorf("invalid quoted identifier %q", lexeme)
+		}
+		return token{typ: tQuotedIdentifier, value: name, pos: start}, nil
+	case c == '\'':
+		lexeme, err := l.delimited('\'')
+		if err != nil {
+			return token{}, err
+		}
+		return token{typ: tLiteral, literal: strings.ReplaceAll(lexeme, `\'`, `'`), pos: start}, nil
+	case c == '`':
+		lexeme, err := l.delimited('`')
+		if err != nil {
+			return token{}, err
+		}
+		lexeme = strings.ReplaceAll(lexeme, "\\`", "`")
+		var value interface{}
+		if err := json.Unmarshal([]byte(lexeme), &value); err != nil {
+			// the deprecated literals of unquoted strings, like `foo`
+			if err := json.Unmarshal([]byte(`"`+strings.TrimLeft(lexeme, " \t\n\r")+`"`), &value); err != nil {
+				return token{}, l.errorf("invalid JSON literal `%s`", lexeme)
+			}
+		}
+		return token{typ: tLiteral, literal: value, pos: start}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op.lexeme) {
+			return tok(op.typ, len(op.lexeme))
+		}
+	}
+	return token{}, l.errorf("unexpected character '%c'", rest[0])
+}
+
+// operators are the operator tokens, with the longer ones first.
+var operators = []struct { //nolint:gochecknoglobals
+	lexeme string
+	typ    tokenType
+}{
+	{"[?", tFilter}, {"[]", tFlatten}, {"||", tOr}, {"&&", tAnd}, {"==", tEQ}, {"!=", tNE},
+	{"<=", tLTE}, {">=", tGTE}, {"<", tLT}, {">", tGT}, {"!", tNot}, {"&", tExpref}, {"|", tPipe},
+	{".", tDot}, {"*", tStar}, {"[", tLbracket}, {"]", tRbracket}, {"{", tLbrace}, {"}", tRbrace},
+	{"(", tLparen}, {")", tRparen}, {",", tComma}, {":", tColon}, {"@", tCurrent},
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// delimited returns the lexeme between the delimiters, skipping the escaped
+// ones, and moves past the closing one.
+func (l *lexer) delimited(delimiter byte) (string, error) {
+	start := l.pos + 1
+	for i := start; i < len(l.expr); i++ {
+		switch l.expr[i] {
+		case '\\':
+			i++
+		case delimiter:
+			l.pos = i + 1
+			return l.expr[start:i], nil
+		}
+	}
+	return "", l.errorf("unterminated %c", delimiter)
+}
@@ -0,0 +1,452 @@
+package jmespath
+
+import (
+	"fmt"
+)
+
+type nodeType int
+
+const (
+	nField nodeType = iota
+	nSubexpression
+	nIndexExpression
+	nIndex
+	nSlice
+	nIdentity
+	nLiteral
+	nComparator
+	nProjection
+	nValueProjection
+	nFilterProjection
+	nFlatten
+	nMultiSelectList
+	nMultiSelectHash
+	nOr
+	nAnd
+	nNot
+	nPipe
+	nFunction
+	nExpref
+)
+
+// node is a node of the abstract syntax tree of an expression.
+type node struct {
+	typ nodeType
+	// the name of the fields and of the functions
+	name string
+	// the value of the literals
+	literal interface{}
+	// the index of nIndex
+	index int
+	// the start, stop and step of nSlice
+	slice [3]*int
+	// the comparator of nComparator
+	comparator tokenType
+	// the keys of nMultiSelectHash, matching its children
+	keys     []string
+	children []*node
+}
+
+// bindingPowers are the binding powers of the tokens, of which the ones below
+// projectionStop end the right hand side of the projections.
+var bindingPowers = map[tokenType]int{ //nolint:gochecknoglobals
+	tPipe: 1, tOr: 2, tAnd: 3, tEQ: 5, tNE: 5, tLT: 5, tLTE: 5, tGT: 5, tGTE: 5,
+	tFlatten: 9, tStar: 20, tFilter: 21, tDot: 40, tNot: 45, tLbrace: 50, tLbracket: 55, tLparen: 60,
+}
+
+const projectionStop = 10
+
+// parser is a Pratt parser of the expressions, following the one of the
+// reference implementation.
+type parser struct {
+	expr   string
+	tokens []token
+	i      int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JMESPath '%s': %s at position %d",
+		p.expr, fmt.Sprintf(format, args...), p.tokens[p.i].pos)
+}
+
+func (p *parser) current() tokenType {
+	return p.tokens[p.i].typ
+}
+
+func (p *parser) lookahead(n int) tokenType {
+	if p.i+n < len(p.tokens) {
+		return p.tokens[p.i+n].typ
+	}
+	return tEOF
+}
+
+func (p *parser) advance() {
+	if p.i < len(p.tokens)-1 {
+		p.i++
+	}
+}
+
+func (p *parser) match(typ tokenType) error {
+	if p.current() != typ {
+		return p.unexpected()
+	}
+	p.advance()
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	return p.unexpectedToken(p.tokens[p.i])
+}
+
+func (p *parser) unexpectedToken(tok token) error {
+	if tok.typ == tEOF {
+		return fmt.Errorf("invalid JMESPath '%s': unexpected end of the expression", p.expr)
+	}
+	return fmt.Errorf("invalid JMESPath '%s': unexpected token '%s' at position %d",
+		p.expr, p.expr[tok.pos:tok.end], tok.pos)
+}
+
+// parse parses the whole expression.
+func (p *parser) parse() (*node, error) {
+	n, err := p.expression(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.current() != tEOF {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+func (p *parser) expression(bindingPower int) (*node, error) {
+	tok := p.tokens[p.i]
+	p.advance()
+	left, err := p.nud(tok)
+	if err != nil {
+		return nil, err
+	}
+	for bindingPower < bindingPowers[p.current()] {
+		tok := p.tokens[p.i]
+		p.advance()
+		if left, err = p.led(tok, left); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+// nud parses the expressions starting with the token.
+//
+//nolint:funlen,cyclop
+func (p *parser) nud(tok token) (*node, error) {
+	identity := &node{typ: nIdentity}
+	switch tok.typ {
+	case tLiteral:
+		return &node{typ: nLiteral, literal: tok.literal}, nil
+	case tUnquotedIdentifier:
+		return &node{typ: nField, name: tok.value}, nil
+	case tQuotedIdentifier:
+		if p.current() == tLparen {
+			return nil, p.errorf("quoted identifiers can't be function names")
+		}
+		return &node{typ: nField, name: tok.value}, nil
+	case tStar:
+		right := identity
+		if p.current() != tRbracket {
+			var err error
+			if right, err = p.projectionRHS(bindingPowers[tStar]); err != nil {
+				return nil, err
+			}
+		}
+		return &node{typ: nValueProjection, children: []*node{identity, right}}, nil
+	case tFilter:
+		return p.led(tok, identity)
+	case tLbrace:
+		return p.multiSelectHash()
+	case tLparen:
+		n, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		return n, p.match(tRparen)
+	case tFlatten:
+		right, err := p.projectionRHS(bindingPowers[tFlatten])
+		if err != nil {
+			return nil, err
+		}
+		flatten := &node{typ: nFlatten, children: []*node{identity}}
+		return &node{typ: nProjection, children: []*node{flatten, right}}, nil
+	case tNot:
+		n, err := p.expression(bindingPowers[tNot])
+		if err != nil {
+			return nil, err
+		}
+		return &node{typ: nNot, children: []*node{n}}, nil
+	case tLbracket:
+		switch {
+		case p.current() == tNumber || p.current() == tColon:
+			right, err := p.indexExpression()
+			if err != nil {
+				return nil, err
+			}
+			return p.projectIfSlice(identity, right)
+		case p.current() == tStar && p.lookahead(1) == tRbracket:
+			p.advance()
+			p.advance()
+			right, err := p.projectionRHS(bindingPowers[tStar])
+			if err != nil {
+				return nil, err
+			}
+			return &node{typ: nProjection, children: []*node{identity, right}}, nil
+		default:
+			return p.multiSelectList()
+		}
+	case tCurrent:
+		return identity, nil
+	case tExpref:
+		n, err := p.expression(bindingPowers[tExpref])
+		if err != nil {
+			return nil, err
+		}
+		return &node{typ: nExpref, children: []*node{n}}, nil
+	default:
+		return nil, p.unexpectedToken(tok)
+	}
+}
+
+// led parses the expressions where the token follows the left expression.
+//
+//nolint:funlen,cyclop
+func (p *parser) led(tok token, left *node) (*node, error) {
+	switch tok.typ {
+	case tDot:
+		if p.current() == tStar {
+			p.advance()
+			right, err := p.projectionRHS(bindingPowers[tDot])
+			if err != nil {
+				return nil, err
+			}
+			return &node{typ: nValueProjection, children: []*node{left, right}}, nil
+		}
+		right, err := p.dotRHS(bindingPowers[tDot])
+		if err != nil {
+			return nil, err
+		}
+		if left.typ == nSubexpression {
+			left.children = append(left.children, right)
+			return left, nil
+		}
+		return &node{typ: nSubexpression, children: []*node{left, right}}, nil
+	case tPipe, tOr, tAnd:
+		right, err := p.expression(bindingPowers[tok.typ])
+		if err != nil {
+			return nil, err
+		}
+		typ := map[tokenType]nodeType{tPipe: nPipe, tOr: nOr, tAnd: nAnd}[tok.typ]
+		return &node{typ: typ, children: []*node{left, right}}, nil
+	case tLparen:
+		if left.typ != nField {
+			return nil, p.errorf("invalid function name")
+		}
+		fn := &node{typ: nFunction, name: left.name}
+		for p.current() != tRparen {
+			arg, err := p.expression(0)
+			if err != nil {
+				return nil, err
+			}
+			if p.current() == tComma {
+				p.advance()
+			} else if p.current() != tRparen {
+				return nil, p.unexpected()
+			}
+			fn.children = append(fn.children, arg)
+		}
+		p.advance()
+		if err := checkArity(fn.name, len(fn.children)); err != nil {
+			return nil, fmt.Errorf("invalid JMESPath '%s': %w", p.expr, err)
+		}
+		return fn, nil
+	case tFilter:
+		condition, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.match(tRbracket); err != nil {
+			return nil, err
+		}
+		right := &node{typ: nIdentity}
+		if p.current() != tFlatten {
+			if right, err = p.projectionRHS(bindingPowers[tFilter]); err != nil {
+				return nil, err
+			}
+		}
+		return &node{typ: nFilterProjection, children: []*node{left, right, condition}}, nil
+	case tEQ, tNE, tLT, tLTE, tGT, tGTE:
+		right, err := p.expression(bindingPowers[tok.typ])
+		if err != nil {
+			return nil, err
+		}
+		return &node{typ: nComparator, comparator: tok.typ, children: []*node{left, right}}, nil
+	case tFlatten:
+		right, err := p.projectionRHS(bindingPowers[tFlatten])
+		if err != nil {
+			return nil, err
+		}
+		flatten := &node{typ: nFlatten, children: []*node{left}}
+		return &node{typ: nProjection, children: []*node{flatten, right}}, nil
+	case tLbracket:
+		if p.current() == tNumber || p.current() == tColon {
+			right, err := p.indexExpression()
+			if err != nil {
+				return nil, err
+			}
+			if left.typ == nIndexExpression {
+				left.children = append(left.children, right)
+				return left, nil
+			}
+			return p.projectIfSlice(left, right)
+		}
+		if err := p.match(tStar); err != nil {
+			return nil, err
+		}
+		if err := p.match(tRbracket); err != nil {
+			return nil, err
+		}
+		right, err := p.projectionRHS(bindingPowers[tStar])
+		if err != nil {
+			return nil, err
+		}
+		return &node{typ: nProjection, children: []*node{left, right}}, nil
+	default:
+		return nil, p.unexpectedToken(tok)
+	}
+}
+
+// indexExpression parses an index, like `[0]`, or a slice, like `[1:3]`,
+// following the opening bracket.
+func (p *parser) indexExpression() (*node, error) {
+	if p.current() == tColon || p.lookahead(1) == tColon {
+		return p.sliceExpression()
+	}
+	n := &node{typ: nIndex, index: p.tokens[p.i].number}
+	p.advance()
+	return n, p.match(tRbracket)
+}
+
+func (p *parser) sliceExpression() (*node, error) {
+	n := &node{typ: nSlice}
+	part := 0
+	for p.current() != tRbracket && part < 3 {
+		switch p.current() {
+		case tColon:
+			part++
+			if part == 3 {
+				return nil, p.unexpected()
+			}
+		case tNumber:
+			number := p.tokens[p.i].number
+			n.slice[part] = &number
+		default:
+			return nil, p.unexpected()
+		}
+		p.advance()
+	}
+	if n.slice[2] != nil && *n.slice[2] == 0 {
+		return nil, p.errorf("the step of a slice can't be 0")
+	}
+	return n, p.match(tRbracket)
+}
+
+func (p *parser) projectIfSlice(left, right *node) (*node, error) {
+	index := &node{typ: nIndexExpression, children: []*node{left, right}}
+	if right.typ != nSlice {
+		return index, nil
+	}
+	rhs, err := p.projectionRHS(bindingPowers[tStar])
+	if err != nil {
+		return nil, err
+	}
+	return &node{typ: nProjection, children: []*node{index, rhs}}, nil
+}
+
+// multiSelectList parses a list like `[a, b]`, following the opening bracket.
+func (p *parser) multiSelectList() (*node, error) {
+	n := &node{typ: nMultiSelectList}
+	for {
+		expr, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, expr)
+		if p.current() == tRbracket {
+			p.advance()
+			return n, nil
+		}
+		if err = p.match(tComma); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// multiSelectHash parses a hash like `{a: b, c: d}`, following the opening
+// brace.
+func (p *parser) multiSelectHash() (*node, error) {
+	n := &node{typ: nMultiSelectHash}
+	for {
+		key := p.tokens[p.i]
+		if key.typ != tQuotedIdentifier && key.typ != tUnquotedIdentifier {
+			return nil, p.unexpected()
+		}
+		p.advance()
+		if err := p.match(tColon); err != nil {
+			return nil, err
+		}
+		value, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		n.keys = append(n.keys, key.value)
+		n.children = append(n.children, value)
+
+		switch p.current() {
+		case tComma:
+			p.advance()
+		case tRbrace:
+			p.advance()
+			return n, nil
+		default:
+			return nil, p.unexpected()
+		}
+	}
+}
+
+// projectionRHS parses the expression applied to the elements of a projection.
+func (p *parser) projectionRHS(bindingPower int) (*node, error) {
+	switch current := p.current(); {
+	case bindingPowers[current] < projectionStop:
+		return &node{typ: nIdentity}, nil
+	case current == tLbracket, current == tFilter:
+		return p.expression(bindingPower)
+	case current == tDot:
+		p.advance()
+		return p.dotRHS(bindingPower)
+	default:
+		return nil, p.unexpected()
+	}
+}
+
+// dotRHS parses the expression following a dot.
+func (p *parser) dotRHS(bindingPower int) (*node, error) {
+	switch p.current() {
+	case tQuotedIdentifier, tUnquotedIdentifier, tStar:
+		return p.expression(bindingPower)
+	case tLbracket:
+		p.advance()
+		return p.multiSelectList()
+	case tLbrace:
+		p.advance()
+		return p.multiSelectHash()
+	default:
+		return nil, p.unexpected()
+	}
+}
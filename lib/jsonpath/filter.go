@@ -0,0 +1,220 @@
+package jsonpath
+
+import (
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+)
+
+// filterExpr is an expression of a filter selector.
+type filterExpr interface {
+	// eval returns the value of the expression for the current node, and
+	// whether it has one, which isn't the case of the queries that select
+	// nothing.
+	eval(current, root interface{}) (interface{}, bool)
+}
+
+// test returns whether a filter expression is true for the current node. The
+// queries are existence tests, so `[?(@.isbn)]` selects the nodes with an
+// isbn, whatever its value.
+func test(expr filterExpr, current, root interface{}) bool {
+	value, ok := expr.eval(current, root)
+	if _, isQuery := expr.(queryExpr); isQuery || !ok {
+		return ok
+	}
+	b, isBool := value.(bool)
+	return (isBool && b) || (!isBool && value != nil)
+}
+
+// queryExpr is a path relative to the current node, like `@.price`, or to the
+// root of the document, like `$.limit`. Its value is the node it selects, or
+// the array of the nodes when it selects several ones.
+type queryExpr struct {
+	relative bool
+	path     *Path
+}
+
+func (e queryExpr) eval(current, root interface{}) (interface{}, bool) {
+	start := root
+	if e.relative {
+		start = current
+	}
+	nodes := e.path.find(start, root)
+	switch len(nodes) {
+	case 0:
+		return nil, false
+	case 1:
+		return nodes[0], true
+	default:
+		return nodes, true
+	}
+}
+
+// literalExpr is a string, number, boolean or null literal.
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(_, _ interface{}) (interface{}, bool) {
+	return e.value, true
+}
+
+type notExpr struct {
+	expr filterExpr
+}
+
+func (e notExpr) eval(current, root interface{}) (interface{}, bool) {
+	return !test(e.expr, current, root), true
+}
+
+type andExpr struct {
+	left, right filterExpr
+}
+
+func (e andExpr) eval(current, root interface{}) (interface{}, bool) {
+	return test(e.left, current, root) && test(e.right, current, root), true
+}
+
+type orExpr struct {
+	left, right filterExpr
+}
+
+func (e orExpr) eval(current, root interface{}) (interface{}, bool) {
+	return test(e.left, current, root) || test(e.right, current, root), true
+}
+
+// compareExpr compares two values. The missing values are only equal to each
+// other, and only the numbers and the strings are ordered.
+type compareExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e compareExpr) eval(current, root interface{}) (interface{}, bool) {
+	left, leftOK := e.left.eval(current, root)
+	right, rightOK := e.right.eval(current, root)
+	if !leftOK || !rightOK {
+		bothMissing := leftOK == rightOK
+		switch e.op {
+		case "==", "<=", ">=":
+			return bothMissing, true
+		case "!=":
+			return !bothMissing, true
+		default:
+			return false, true
+		}
+	}
+
+	switch e.op {
+	case "==":
+		return equal(left, right), true
+	case "!=":
+		return !equal(left, right), true
+	case "<":
+		return less(left, right), true
+	case "<=":
+		return less(left, right) || equal(left, right), true
+	case ">":
+		return less(right, left), true
+	default: // ">="
+		return less(right, left) || equal(left, right), true
+	}
+}
+
+func equal(a, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		y, ok := b.(float64)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func less(a, b interface{}) bool {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		return ok && x < y
+	case string:
+		y, ok := b.(string)
+		return ok && x < y
+	default:
+		return false
+	}
+}
+
+// regexExpr matches a string with a regular expression, like
+// `@.name =~ /^k6/i`.
+type regexExpr struct {
+	expr filterExpr
+	re   *regexp.Regexp
+}
+
+func (e regexExpr) eval(current, root interface{}) (interface{}, bool) {
+	value, ok := e.expr.eval(current, root)
+	s, isString := value.(string)
+	return ok && isString && e.re.MatchString(s), true
+}
+
+// funcExpr is a call of one of the functions of RFC 9535: length(), count(),
+// match() and search().
+type funcExpr struct {
+	name string
+	args []filterExpr
+	// the regular expression of match() and search(), when it's a literal
+	re *regexp.Regexp
+}
+
+func (e funcExpr) eval(current, root interface{}) (interface{}, bool) {
+	switch e.name {
+	case "count":
+		query, _ := e.args[0].(queryExpr)
+		start := root
+		if query.relative {
+			start = current
+		}
+		return float64(len(query.path.find(start, root))), true
+	case "length":
+		value, ok := e.args[0].eval(current, root)
+		if !ok {
+			return nil, false
+		}
+		switch value := value.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(value)), true
+		case []interface{}:
+			return float64(len(value)), true
+		case map[string]interface{}:
+			return float64(len(value)), true
+		default:
+			return nil, false
+		}
+	default: // match and search
+		value, ok := e.args[0].eval(current, root)
+		s, isString := value.(string)
+		if !ok || !isString {
+			return false, true
+		}
+		re := e.re
+		if re == nil {
+			pattern, _ := e.args[1].eval(current, root)
+			p, isString := pattern.(string)
+			if !isString {
+				return false, true
+			}
+			var err error
+			if re, err = compileFuncRegexp(e.name, p); err != nil {
+				return false, true
+			}
+		}
+		return re.MatchString(s), true
+	}
+}
+
+// compileFuncRegexp compiles the regular expression of match(), which has to
+// match the whole string, or of search(), which can match a part of it.
+func compileFuncRegexp(name, pattern string) (*regexp.Regexp, error) {
+	if name == "match" {
+		pattern = `^(?:` + pattern + `)$`
+	}
+	return regexp.Compile(pattern)
+}
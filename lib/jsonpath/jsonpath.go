@@ -0,0 +1,229 @@
+// Package jsonpath implements the JSONPath expressions of RFC 9535, which
+// select the values of JSON documents, like `$.store.book[?(@.price < 10)].title`.
+//
+// The documents are the values decoded by encoding/json into interface{}, so
+// the numbers are float64. The members of the objects are visited in the
+// order of their names, as the one of the document isn't known.
+package jsonpath
+
+import (
+	"sort"
+)
+
+// Path is a compiled JSONPath expression, which is safe for concurrent use.
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+// segment is a step of a path, which selects the children, or with the
+// descendant segments, the descendants of the nodes.
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+// selector selects children of a node.
+type selector interface {
+	// selectFrom appends the children of the node it selects to the nodes.
+	selectFrom(nodes []interface{}, node, root interface{}) []interface{}
+}
+
+// Compile parses a JSONPath expression.
+func Compile(expr string) (*Path, error) {
+	p := &parser{expr: expr}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// MustCompile is like Compile, but it panics if the expression is invalid.
+func MustCompile(expr string) *Path {
+	path, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// String returns the expression of the path.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Singular returns whether the path selects at most one value, which is the
+// case of the paths made only of names and indexes, like `$.items[0].id`.
+func (p *Path) Singular() bool {
+	for _, seg := range p.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the values of the document selected by the path, in the order
+// they were selected.
+func (p *Path) Find(doc interface{}) []interface{} {
+	return p.find(doc, doc)
+}
+
+// find applies the segments of the path to the start node, which is the root
+// of the document for the absolute paths and the current node of a filter for
+// the relative ones.
+func (p *Path) find(start, root interface{}) []interface{} {
+	nodes := []interface{}{start}
+	for _, seg := range p.segments {
+		next := []interface{}{}
+		for _, node := range nodes {
+			if !seg.descendant {
+				for _, sel := range seg.selectors {
+					next = sel.selectFrom(next, node, root)
+				}
+				continue
+			}
+			walk(node, func(n interface{}) {
+				for _, sel := range seg.selectors {
+					next = sel.selectFrom(next, n, root)
+				}
+			})
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// walk calls fn with the node and all its descendants, in document order.
+func walk(node interface{}, fn func(interface{})) {
+	fn(node)
+	for _, child := range children(node) {
+		walk(child, fn)
+	}
+}
+
+// children returns the elements of an array, or the values of the members of
+// an object ordered by their names.
+func children(node interface{}) []interface{} {
+	switch node := node.(type) {
+	case []interface{}:
+		return node
+	case map[string]interface{}:
+		names := make([]string, 0, len(node))
+		for name := range node {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]interface{}, len(names))
+		for i, name := range names {
+			values[i] = node[name]
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// nameSelector selects the member of an object with the name, like `.name`
+// and `['name']`.
+type nameSelector string
+
+func (s nameSelector) selectFrom(nodes []interface{}, node, _ interface{}) []interface{} {
+	if obj, ok := node.(map[string]interface{}); ok {
+		if value, ok := obj[string(s)]; ok {
+			nodes = append(nodes, value)
+		}
+	}
+	return nodes
+}
+
+// wildcardSelector selects all the children of a node, like `.*` and `[*]`.
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(nodes []interface{}, node, _ interface{}) []interface{} {
+	return append(nodes, children(node)...)
+}
+
+// indexSelector selects the element of an array at the index, which counts
+// from its end when it's negative, like `[0]` and `[-1]`.
+type indexSelector int
+
+func (s indexSelector) selectFrom(nodes []interface{}, node, _ interface{}) []interface{} {
+	arr, ok := node.([]interface{})
+	if !ok {
+		return nodes
+	}
+	i := int(s)
+	if i < 0 {
+		i += len(arr)
+	}
+	if i >= 0 && i < len(arr) {
+		nodes = append(nodes, arr[i])
+	}
+	return nodes
+}
+
+// sliceSelector selects the elements of an array between the start and the
+// end, like `[1:3]` and `[::-1]`.
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(nodes []interface{}, node, _ interface{}) []interface{} {
+	arr, ok := node.([]interface{})
+	if !ok || s.step == 0 {
+		return nodes
+	}
+	n := len(arr)
+	bound := func(i *int, defaultValue, lower, upper int) int {
+		if i == nil {
+			return defaultValue
+		}
+		v := *i
+		if v < 0 {
+			v += n
+		}
+		if v < lower {
+			return lower
+		}
+		if v > upper {
+			return upper
+		}
+		return v
+	}
+
+	if s.step > 0 {
+		lower, upper := bound(s.start, 0, 0, n), bound(s.end, n, 0, n)
+		for i := lower; i < upper; i += s.step {
+			nodes = append(nodes, arr[i])
+		}
+		return nodes
+	}
+	upper, lower := bound(s.start, n-1, -1, n-1), bound(s.end, -1, -1, n-1)
+	for i := upper; i > lower; i += s.step {
+		nodes = append(nodes, arr[i])
+	}
+	return nodes
+}
+
+// filterSelector selects the children of a node for which the expression is
+// true, like `[?(@.price < 10)]`.
+type filterSelector struct {
+	expr filterExpr
+}
+
+func (s filterSelector) selectFrom(nodes []interface{}, node, root interface{}) []interface{} {
+	for _, child := range children(node) {
+		if test(s.expr, child, root) {
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,104 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const store = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 399}
+	},
+	"limit": 10,
+	"o": {"j j": {"k.k": 3}, "a": [1, 2, 3, 4, 5]}
+}`
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(store), &doc))
+
+	testCases := []struct {
+		expr     string
+		expected string
+	}{
+		{`$`, `[` + store + `]`},
+		{`$.store.book[*].author`, `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{`$..author`, `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{`$.store.*.color`, `["red"]`},
+		{`$..book[2].title`, `["Moby Dick"]`},
+		{`$..book[-1].title`, `["The Lord of the Rings"]`},
+		{`$..book[0,1].title`, `["Sayings of the Century","Sword of Honour"]`},
+		{`$..book[:2].price`, `[8.95,12.99]`},
+		{`$.o.a[1:4:2]`, `[2,4]`},
+		{`$.o.a[::-2]`, `[5,3,1]`},
+		{`$.o.a[-2:]`, `[4,5]`},
+		{`$.o.a[0:0]`, `[]`},
+		{`$.o['j j']["k.k"]`, `[3]`},
+		{`$['store']['bicycle', 'missing']['color']`, `["red"]`},
+		{`$..book[?(@.isbn)].title`, `["Moby Dick","The Lord of the Rings"]`},
+		{`$..book[?(!@.isbn)].price`, `[8.95,12.99]`},
+		{`$..book[?(@.price < 10)].title`, `["Sayings of the Century","Moby Dick"]`},
+		{`$..book[?@.price > $.limit && @.category == 'fiction'].title`, `["Sword of Honour","The Lord of the Rings"]`},
+		{`$..book[?(@.price == 8.95 || @.author == "Herman Melville")].price`, `[8.95,8.99]`},
+		{`$..book[?(@.isbn != '0-553-21311-3')].price`, `[8.95,12.99,22.99]`},
+		{`$..book[?(@.author =~ /^j\. r/i)].price`, `[22.99]`},
+		{`$..book[?(match(@.author, 'Nig.*'))].price`, `[8.95]`},
+		{`$..book[?(search(@.title, "of the"))].price`, `[8.95,22.99]`},
+		{`$..book[?(length(@.title) <= 9)].title`, `["Moby Dick"]`},
+		{`$.store[?(count(@.*) == 2)].color`, `["red"]`},
+		{`$..[?(@.color)].price`, `[399]`},
+		{`$.missing`, `[]`},
+		{`$.store.book.title`, `[]`},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			path, err := Compile(tc.expr)
+			require.NoError(t, err)
+			actual, err := json.Marshal(path.Find(doc))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestSingular(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, MustCompile(`$.a[0]['b']`).Singular())
+	assert.True(t, MustCompile(`$`).Singular())
+	for _, expr := range []string{`$.a[*]`, `$..a`, `$.a[0,1]`, `$.a[1:]`, `$.a[?(@.b)]`} {
+		assert.False(t, MustCompile(expr).Singular(), expr)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		`store`:                "the expression has to start with $",
+		`$.`:                   "expected a name or * after the dot",
+		`$[1`:                  "expected , or ]",
+		`$['a`:                 "unterminated string",
+		`$[?(@.a == 1]`:        "expected )",
+		`$[?(foo(@.a))]`:       "unknown function 'foo'",
+		`$[?(length(@.a, 1))]`: "length() expects 1 arguments",
+		`$.a b`:                "unexpected 'b'",
+	}
+	for expr, msg := range testCases {
+		_, err := Compile(expr)
+		assert.ErrorContains(t, err, msg, expr)
+	}
+}
@@ -0,0 +1,508 @@
+package jsonpath
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// parser parses the expressions with a recursive descent.
+type parser struct {
+	expr string
+	pos  int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSONPath '%s': %s at position %d", p.expr, fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.expr) {
+		return p.expr[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.expr[p.pos:], prefix)
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.expr) && strings.IndexByte(" \t\n\r", p.expr[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// parsePath parses a whole expression, which starts with the root $.
+func (p *parser) parsePath() (*Path, error) {
+	p.skipSpaces()
+	if p.peek() != '$' {
+		return nil, p.errorf("the expression has to start with $")
+	}
+	p.pos++
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.expr) {
+		return nil, p.errorf("unexpected '%c'", p.peek())
+	}
+	return &Path{expr: p.expr, segments: segments}, nil
+}
+
+// parseSegments parses the segments following $ or @, like `.a[0]..b`.
+func (p *parser) parseSegments() ([]segment, error) {
+	var segments []segment
+	for {
+		var seg segment
+		switch {
+		case p.hasPrefix(".."):
+			p.pos += 2
+			seg.descendant = true
+			if p.peek() == '[' {
+				break
+			}
+			fallthrough
+		case p.peek() == '.':
+			if !seg.descendant {
+				p.pos++
+			}
+			if p.peek() == '*' {
+				p.pos++
+				seg.selectors = []selector{wildcardSelector{}}
+			} else if name := p.parseName(); name != "" {
+				seg.selectors = []selector{nameSelector(name)}
+			} else {
+				return nil, p.errorf("expected a name or * after the dot")
+			}
+		case p.peek() == '[':
+		default:
+			return segments, nil
+		}
+
+		if seg.selectors == nil {
+			selectors, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = selectors
+		}
+		segments = append(segments, seg)
+	}
+}
+
+// parseName parses the name of a member following a dot.
+func (p *parser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.expr) {
+		r, size := utf8.DecodeRuneInString(p.expr[p.pos:])
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && r < utf8.RuneSelf {
+			break
+		}
+		p.pos += size
+	}
+	return p.expr[start:p.pos]
+}
+
+// parseBracket parses the comma-separated selectors between brackets, like
+// `['a','b']`, `[0,-1]`, `[1:3]`, `[*]` and `[?(@.a > 1)]`.
+func (p *parser) parseBracket() ([]selector, error) {
+	p.pos++ // [
+	var selectors []selector
+	for {
+		p.skipSpaces()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return selectors, nil
+		default:
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return nameSelector(name), nil
+	case c == '?':
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return filterSelector{expr: expr}, nil
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	default:
+		return nil, p.errorf("unexpected selector")
+	}
+}
+
+// parseIndexOrSlice parses an index, like `-1`, or a slice, like `1:5:2`.
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	var (
+		numbers [3]*int
+		parts   int
+	)
+	for parts < 3 {
+		p.skipSpaces()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			start := p.pos
+			p.pos++
+			for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+				p.pos++
+			}
+			n, err := strconv.Atoi(p.expr[start:p.pos])
+			if err != nil {
+				return nil, p.errorf("invalid integer '%s'", p.expr[start:p.pos])
+			}
+			numbers[parts] = &n
+		}
+		p.skipSpaces()
+		parts++
+		if p.peek() != ':' {
+			break
+		}
+		p.pos++
+	}
+
+	if parts == 1 {
+		if numbers[0] == nil {
+			return nil, p.errorf("expected an index")
+		}
+		return indexSelector(*numbers[0]), nil
+	}
+	sel := sliceSelector{start: numbers[0], end: numbers[1], step: 1}
+	if numbers[2] != nil {
+		sel.step = *numbers[2]
+	}
+	return sel, nil
+}
+
+// parseString parses a single or double quoted string, with the escapes of
+// JSON.
+func (p *parser) parseString() (string, error) {
+	quote := p.expr[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.expr) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.expr[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func (p *parser) parseEscape() (rune, error) {
+	if p.pos >= len(p.expr) {
+		return 0, p.errorf("unterminated escape")
+	}
+	c := p.expr[p.pos]
+	p.pos++
+	switch c {
+	case '\\', '/', '\'', '"':
+		return rune(c), nil
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case 'u':
+		r, err := p.parseHex()
+		if err != nil {
+			return 0, err
+		}
+		if utf16.IsSurrogate(r) && p.hasPrefix(`\u`) {
+			p.pos += 2
+			low, err := p.parseHex()
+			if err != nil {
+				return 0, err
+			}
+			r = utf16.DecodeRune(r, low)
+		}
+		return r, nil
+	default:
+		return 0, p.errorf("invalid escape '\\%c'", c)
+	}
+}
+
+func (p *parser) parseHex() (rune, error) {
+	if p.pos+4 > len(p.expr) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	n, err := strconv.ParseUint(p.expr[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	p.pos += 4
+	return rune(n), nil
+}
+
+// parseOr parses a filter expression, made of the logical operators ||, &&
+// and !, of the comparisons and of parentheses.
+func (p *parser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.hasPrefix("||"); p.skipSpaces() {
+		p.pos += 2
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (filterExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.hasPrefix("&&"); p.skipSpaces() {
+		p.pos += 2
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (filterExpr, error) {
+	p.skipSpaces()
+	if p.peek() == '!' && !p.hasPrefix("!=") {
+		p.pos++
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOperators = []string{"==", "!=", "<=", ">=", "=~", "<", ">"} //nolint:gochecknoglobals
+
+func (p *parser) parseComparison() (filterExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	op := ""
+	for _, candidate := range comparisonOperators {
+		if p.hasPrefix(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return left, nil
+	}
+	p.pos += len(op)
+
+	if op == "=~" {
+		re, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		return regexExpr{expr: left, re: re}, nil
+	}
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return compareExpr{op: op, left: left, right: right}, nil
+}
+
+// parseRegex parses the regular expression of =~, which is either a literal,
+// like `/^k6/i`, or a string.
+func (p *parser) parseRegex() (*regexp.Regexp, error) {
+	p.skipSpaces()
+	var pattern string
+	switch p.peek() {
+	case '/':
+		p.pos++
+		end := p.pos
+		for end < len(p.expr) && p.expr[end] != '/' {
+			if p.expr[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.expr) {
+			return nil, p.errorf("unterminated regular expression")
+		}
+		pattern = p.expr[p.pos:end]
+		p.pos = end + 1
+		if p.peek() == 'i' {
+			p.pos++
+			pattern = "(?i)" + pattern
+		}
+	case '\'', '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		pattern = s
+	default:
+		return nil, p.errorf("expected a regular expression")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, p.errorf("invalid regular expression: %s", err)
+	}
+	return re, nil
+}
+
+func (p *parser) parsePrimary() (filterExpr, error) {
+	p.skipSpaces()
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return expr, nil
+	case c == '@' || c == '$':
+		start := p.pos
+		p.pos++
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		return queryExpr{relative: c == '@', path: &Path{expr: p.expr[start:p.pos], segments: segments}}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{value: s}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.expr) && strings.IndexByte("+-.0123456789eE", p.expr[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.expr[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%s'", p.expr[start:p.pos])
+		}
+		return literalExpr{value: n}, nil
+	default:
+		name := p.parseName()
+		switch name {
+		case "true":
+			return literalExpr{value: true}, nil
+		case "false":
+			return literalExpr{value: false}, nil
+		case "null":
+			return literalExpr{value: nil}, nil
+		case "":
+			return nil, p.errorf("expected an expression")
+		default:
+			return p.parseFunction(name)
+		}
+	}
+}
+
+// parseFunction parses the arguments of a function call.
+func (p *parser) parseFunction(name string) (filterExpr, error) {
+	arity := map[string]int{"length": 1, "count": 1, "match": 2, "search": 2}[name]
+	if arity == 0 {
+		return nil, p.errorf("unknown function '%s'", name)
+	}
+	p.skipSpaces()
+	if p.peek() != '(' {
+		return nil, p.errorf("expected ( after %s", name)
+	}
+	p.pos++
+
+	fn := funcExpr{name: name}
+	for p.skipSpaces(); p.peek() != ')'; p.skipSpaces() {
+		if len(fn.args) > 0 {
+			if p.peek() != ',' {
+				return nil, p.errorf("expected , or )")
+			}
+			p.pos++
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		fn.args = append(fn.args, arg)
+	}
+	p.pos++
+	if len(fn.args) != arity {
+		return nil, p.errorf("%s() expects %d arguments", name, arity)
+	}
+
+	switch name {
+	case "count":
+		if _, ok := fn.args[0].(queryExpr); !ok {
+			return nil, p.errorf("count() expects a query")
+		}
+	case "match", "search":
+		if pattern, ok := fn.args[1].(literalExpr); ok {
+			s, isString := pattern.value.(string)
+			if !isString {
+				return nil, p.errorf("%s() expects a string pattern", name)
+			}
+			re, err := compileFuncRegexp(name, s)
+			if err != nil {
+				return nil, p.errorf("invalid regular expression: %s", err)
+			}
+			fn.re = re
+		}
+	}
+	return fn, nil
+}
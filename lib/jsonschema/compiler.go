@@ -0,0 +1,364 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Compiler compiles the schemas of a document, like the ones of the
+// operations of an OpenAPI spec, which share the schemas of their $ref. It
+// isn't safe for concurrent use, unlike the schemas it compiles.
+type Compiler struct {
+	doc     interface{}
+	schemas map[string]*Schema
+}
+
+// NewCompiler returns a compiler of the schemas of the document. The numbers
+// of the document can be of any type and the keys of its maps can be of any
+// type that formats as a string, like the ones decoded from YAML.
+func NewCompiler(doc interface{}) *Compiler {
+	return &Compiler{doc: normalize(doc), schemas: make(map[string]*Schema)}
+}
+
+// Compile compiles the schema at the JSON pointer of the document, like
+// `#/components/schemas/User` or `#` for the document itself.
+func (c *Compiler) Compile(ref string) (*Schema, error) {
+	if s, ok := c.schemas[ref]; ok {
+		return s, nil
+	}
+	value, err := resolve(c.doc, ref)
+	if err != nil {
+		return nil, err
+	}
+	// the schema is cached before being compiled, for the recursive ones
+	s := &Schema{}
+	c.schemas[ref] = s
+	if err := c.compileInto(s, value, ref); err != nil {
+		delete(c.schemas, ref)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Resolve returns the value at the JSON pointer of the normalized document,
+// like `#/paths` for the paths of an OpenAPI spec.
+func (c *Compiler) Resolve(ref string) (interface{}, error) {
+	return resolve(c.doc, ref)
+}
+
+func (c *Compiler) compile(value interface{}, location string) (*Schema, error) {
+	s := &Schema{}
+	if err := c.compileInto(s, value, location); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//nolint:funlen,gocognit,cyclop
+func (c *Compiler) compileInto(s *Schema, value interface{}, location string) error {
+	s.location = location
+	if b, ok := value.(bool); ok {
+		s.always = &b
+		return nil
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid schema at %s: expected an object or a boolean, but got %s", location, typeOf(value))
+	}
+
+	var err error
+	invalid := func(keyword, expected string) error {
+		return fmt.Errorf("invalid schema at %s: %s has to be %s", location, keyword, expected)
+	}
+	number := func(keyword string) *float64 {
+		if err != nil {
+			return nil
+		}
+		v, ok := obj[keyword]
+		if !ok {
+			return nil
+		}
+		n, ok := v.(float64)
+		if !ok {
+			err = invalid(keyword, "a number")
+			return nil
+		}
+		return &n
+	}
+	subschema := func(keyword string) *Schema {
+		if err != nil {
+			return nil
+		}
+		v, ok := obj[keyword]
+		if !ok {
+			return nil
+		}
+		var sub *Schema
+		sub, err = c.compile(v, location+"/"+escapePointer(keyword))
+		return sub
+	}
+	subschemas := func(keyword string) []*Schema {
+		if err != nil {
+			return nil
+		}
+		v, ok := obj[keyword]
+		if !ok {
+			return nil
+		}
+		arr, ok := v.([]interface{})
+		if !ok {
+			err = invalid(keyword, "an array of schemas")
+			return nil
+		}
+		result := make([]*Schema, len(arr))
+		for i, item := range arr {
+			if result[i], err = c.compile(item, location+"/"+keyword+"/"+strconv.Itoa(i)); err != nil {
+				return nil
+			}
+		}
+		return result
+	}
+	pattern := func(keyword string, expr interface{}) *regexp.Regexp {
+		if err != nil {
+			return nil
+		}
+		str, ok := expr.(string)
+		if !ok {
+			err = invalid(keyword, "a string")
+			return nil
+		}
+		var re *regexp.Regexp
+		if re, err = regexp.Compile(str); err != nil {
+			err = fmt.Errorf("invalid schema at %s: invalid %s %q: %w", location, keyword, str, err)
+		}
+		return re
+	}
+
+	if ref, ok := obj["$ref"]; ok {
+		str, isString := ref.(string)
+		if !isString {
+			return invalid("$ref", "a string")
+		}
+		if s.ref, err = c.Compile(str); err != nil {
+			return fmt.Errorf("invalid schema at %s: %w", location, err)
+		}
+	}
+
+	switch t := obj["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			str, ok := item.(string)
+			if !ok {
+				return invalid("type", "a string or an array of strings")
+			}
+			s.types = append(s.types, str)
+		}
+	default:
+		return invalid("type", "a string or an array of strings")
+	}
+	s.nullable, _ = obj["nullable"].(bool)
+	if enum, ok := obj["enum"]; ok {
+		if s.enum, ok = enum.([]interface{}); !ok {
+			return invalid("enum", "an array")
+		}
+	}
+	if constant, ok := obj["const"]; ok {
+		s.constant = []interface{}{constant}
+	}
+
+	s.minimum, s.maximum, s.multipleOf = number("minimum"), number("maximum"), number("multipleOf")
+	// the exclusive bounds of the draft 4 are booleans making the others exclusive
+	switch exclusive := obj["exclusiveMinimum"].(type) {
+	case bool:
+		if exclusive {
+			s.minimum, s.exclusiveMinimum = nil, s.minimum
+		}
+	default:
+		s.exclusiveMinimum = number("exclusiveMinimum")
+	}
+	switch exclusive := obj["exclusiveMaximum"].(type) {
+	case bool:
+		if exclusive {
+			s.maximum, s.exclusiveMaximum = nil, s.maximum
+		}
+	default:
+		s.exclusiveMaximum = number("exclusiveMaximum")
+	}
+
+	s.minLength, s.maxLength = number("minLength"), number("maxLength")
+	if expr, ok := obj["pattern"]; ok {
+		s.pattern = pattern("pattern", expr)
+	}
+	if format, ok := obj["format"]; ok {
+		if s.format, ok = format.(string); !ok {
+			return invalid("format", "a string")
+		}
+	}
+
+	// the items arrays of the drafts before 2020-12 are the prefixItems ones
+	if _, ok := obj["items"].([]interface{}); ok {
+		s.prefixItems, s.items = subschemas("items"), subschema("additionalItems")
+	} else {
+		s.prefixItems, s.items = subschemas("prefixItems"), subschema("items")
+	}
+	s.minItems, s.maxItems = number("minItems"), number("maxItems")
+	s.uniqueItems, _ = obj["uniqueItems"].(bool)
+	s.contains = subschema("contains")
+
+	if properties, ok := obj["properties"].(map[string]interface{}); ok && err == nil {
+		s.properties = make(map[string]*Schema, len(properties))
+		for name, property := range properties {
+			propertyLocation := location + "/properties/" + escapePointer(name)
+			if s.properties[name], err = c.compile(property, propertyLocation); err != nil {
+				return err
+			}
+		}
+	}
+	if properties, ok := obj["patternProperties"].(map[string]interface{}); ok && err == nil {
+		exprs := make([]string, 0, len(properties))
+		for expr := range properties {
+			exprs = append(exprs, expr)
+		}
+		sort.Strings(exprs)
+		for _, expr := range exprs {
+			pp := patternSchema{pattern: pattern("patternProperties", expr)}
+			if err != nil {
+				return err
+			}
+			propertyLocation := location + "/patternProperties/" + escapePointer(expr)
+			if pp.schema, err = c.compile(properties[expr], propertyLocation); err != nil {
+				return err
+			}
+			s.patternProperties = append(s.patternProperties, pp)
+		}
+	}
+	s.additionalProperties, s.propertyNames = subschema("additionalProperties"), subschema("propertyNames")
+	if required, ok := obj["required"]; ok && err == nil {
+		arr, ok := required.([]interface{})
+		if !ok {
+			return invalid("required", "an array of strings")
+		}
+		for _, item := range arr {
+			name, ok := item.(string)
+			if !ok {
+				return invalid("required", "an array of strings")
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	s.minProperties, s.maxProperties = number("minProperties"), number("maxProperties")
+
+	s.allOf, s.anyOf, s.oneOf = subschemas("allOf"), subschemas("anyOf"), subschemas("oneOf")
+	s.not = subschema("not")
+	s.ifSchema, s.thenSchema, s.elseSchema = subschema("if"), subschema("then"), subschema("else")
+	return err
+}
+
+// resolve returns the value at the JSON pointer of the document.
+func resolve(doc interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref '%s', only the JSON pointers of the document are supported", ref)
+	}
+	pointer, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref '%s': %w", ref, err)
+	}
+	if pointer == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("unsupported $ref '%s', only the JSON pointers of the document are supported", ref)
+	}
+
+	value := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("the $ref '%s' doesn't exist", ref)
+			}
+			value = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("the $ref '%s' doesn't exist", ref)
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Errorf("the $ref '%s' doesn't exist", ref)
+		}
+	}
+	return value, nil
+}
+
+// normalize returns the value with float64 numbers and string keys, like the
+// ones decoded by encoding/json.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = normalize(item)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+		return result
+	case nil, bool, float64, string:
+		return v
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() { //nolint:exhaustive
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		result := make([]interface{}, rv.Len())
+		for i := range result {
+			result[i] = normalize(rv.Index(i).Interface())
+		}
+		return result
+	case reflect.Map:
+		result := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			result[fmt.Sprint(iter.Key().Interface())] = normalize(iter.Value().Interface())
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// encode returns the JSON encoding of the value, for the error messages.
+func encode(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
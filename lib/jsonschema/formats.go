@@ -0,0 +1,72 @@
+package jsonschema
+
+import (
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	dateRegexp     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	timeRegexp     = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?([zZ]|[+-]\d{2}:\d{2})$`)
+	emailRegexp    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameRegexp = regexp.MustCompile(
+		`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+)
+
+// validStringFormat returns whether the string has the format, the unknown
+// formats being always valid.
+func validStringFormat(format, str string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, strings.ToUpper(str))
+		return err == nil
+	case "date":
+		if !dateRegexp.MatchString(str) {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", str)
+		return err == nil
+	case "time":
+		return timeRegexp.MatchString(str)
+	case "email":
+		return emailRegexp.MatchString(str)
+	case "uuid":
+		return uuidRegexp.MatchString(str)
+	case "uri":
+		u, err := url.Parse(str)
+		return err == nil && u.IsAbs()
+	case "uri-reference":
+		_, err := url.Parse(str)
+		return err == nil
+	case "ipv4":
+		ip := net.ParseIP(str)
+		return ip != nil && ip.To4() != nil && !strings.Contains(str, ":")
+	case "ipv6":
+		return net.ParseIP(str) != nil && strings.Contains(str, ":")
+	case "hostname":
+		return len(str) <= 253 && hostnameRegexp.MatchString(str)
+	case "regex":
+		_, err := regexp.Compile(str)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+// validNumberFormat returns whether the number has the format, like the
+// int32 and int64 ones of OpenAPI.
+func validNumberFormat(format string, n float64) bool {
+	switch format {
+	case "int32":
+		return n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32
+	case "int64":
+		return n == math.Trunc(n) && n >= math.MinInt64 && n <= math.MaxInt64
+	default:
+		return true
+	}
+}
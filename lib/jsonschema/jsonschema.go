@@ -0,0 +1,387 @@
+// Package jsonschema validates JSON documents against JSON schemas, like the
+// ones of the responses of the OpenAPI specs.
+//
+// It implements the validation keywords of the drafts 4 to 2020-12, and the
+// nullable one of OpenAPI 3.0, but not the annotations and the dynamic
+// references. The $ref have to be JSON pointers in the document of the
+// schema, like `#/components/schemas/User`, and the unknown formats are valid.
+//
+// The documents are the values decoded by encoding/json into interface{}, so
+// the numbers are float64.
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is a compiled JSON schema, which is safe for concurrent use.
+type Schema struct {
+	location string
+
+	// always is the result of the boolean schemas, true and false
+	always *bool
+	ref    *Schema
+
+	types    []string
+	nullable bool
+	enum     []interface{}
+	constant []interface{} // the const value, if any
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	multipleOf                         *float64
+
+	minLength, maxLength *float64
+	pattern              *regexp.Regexp
+	format               string
+
+	prefixItems          []*Schema
+	items                *Schema
+	minItems, maxItems   *float64
+	uniqueItems          bool
+	contains             *Schema
+	properties           map[string]*Schema
+	patternProperties    []patternSchema
+	additionalProperties *Schema
+	propertyNames        *Schema
+	required             []string
+	minProperties        *float64
+	maxProperties        *float64
+
+	allOf, anyOf, oneOf []*Schema
+	not                 *Schema
+	ifSchema            *Schema
+	thenSchema          *Schema
+	elseSchema          *Schema
+}
+
+type patternSchema struct {
+	pattern *regexp.Regexp
+	schema  *Schema
+}
+
+// ValidationError is a value of a document not matching its schema.
+type ValidationError struct {
+	// Path is the JSON pointer of the value in the document, like
+	// `/items/0/id`, which is empty for the document itself.
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Compile compiles the JSON schema.
+func Compile(schema interface{}) (*Schema, error) {
+	return NewCompiler(schema).Compile("#")
+}
+
+// MustCompile is like Compile, but it panics if the schema is invalid.
+func MustCompile(schema interface{}) *Schema {
+	s, err := Compile(schema)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the JSON pointer of the schema in its document.
+func (s *Schema) String() string {
+	return s.location
+}
+
+// Validate returns the values of the document not matching the schema, which
+// is valid if there aren't any.
+func (s *Schema) Validate(doc interface{}) []*ValidationError {
+	return s.validate(doc, "")
+}
+
+//nolint:funlen,gocognit,cyclop
+func (s *Schema) validate(value interface{}, path string) []*ValidationError {
+	if s.always != nil {
+		if *s.always {
+			return nil
+		}
+		return []*ValidationError{{Path: path, Message: "no value is allowed"}}
+	}
+	if value == nil && s.nullable {
+		return nil
+	}
+
+	var errs []*ValidationError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.ref != nil {
+		errs = append(errs, s.ref.validate(value, path)...)
+	}
+	if len(s.types) > 0 && !s.hasType(value) {
+		fail("expected %s, but got %s", strings.Join(s.types, " or "), typeOf(value))
+	}
+	if len(s.enum) > 0 && !contains(s.enum, value) {
+		fail("%s isn't one of %s", encode(value), encode(s.enum))
+	}
+	if len(s.constant) > 0 && !equal(s.constant[0], value) {
+		fail("expected %s, but got %s", encode(s.constant[0]), encode(value))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		errs = append(errs, s.validateNumber(v, path)...)
+	case string:
+		errs = append(errs, s.validateString(v, path)...)
+	case []interface{}:
+		errs = append(errs, s.validateArray(v, path)...)
+	case map[string]interface{}:
+		errs = append(errs, s.validateObject(v, path)...)
+	}
+
+	for _, sub := range s.allOf {
+		errs = append(errs, sub.validate(value, path)...)
+	}
+	if len(s.anyOf) > 0 && s.countMatches(s.anyOf, value, path) == 0 {
+		fail("the value doesn't match any of the anyOf schemas")
+	}
+	if len(s.oneOf) > 0 {
+		if n := s.countMatches(s.oneOf, value, path); n != 1 {
+			fail("the value matches %d of the oneOf schemas instead of 1", n)
+		}
+	}
+	if s.not != nil && len(s.not.validate(value, path)) == 0 {
+		fail("the value matches the schema it mustn't match")
+	}
+	if s.ifSchema != nil {
+		if len(s.ifSchema.validate(value, path)) == 0 {
+			if s.thenSchema != nil {
+				errs = append(errs, s.thenSchema.validate(value, path)...)
+			}
+		} else if s.elseSchema != nil {
+			errs = append(errs, s.elseSchema.validate(value, path)...)
+		}
+	}
+	return errs
+}
+
+func (s *Schema) countMatches(schemas []*Schema, value interface{}, path string) int {
+	n := 0
+	for _, sub := range schemas {
+		if len(sub.validate(value, path)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Schema) hasType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, t := range s.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) validateNumber(n float64, path string) []*ValidationError {
+	var errs []*ValidationError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.minimum != nil && n < *s.minimum {
+		fail("%v is less than the minimum %v", n, *s.minimum)
+	}
+	if s.maximum != nil && n > *s.maximum {
+		fail("%v is greater than the maximum %v", n, *s.maximum)
+	}
+	if s.exclusiveMinimum != nil && n <= *s.exclusiveMinimum {
+		fail("%v has to be greater than %v", n, *s.exclusiveMinimum)
+	}
+	if s.exclusiveMaximum != nil && n >= *s.exclusiveMaximum {
+		fail("%v has to be less than %v", n, *s.exclusiveMaximum)
+	}
+	if s.multipleOf != nil {
+		if q := n / *s.multipleOf; math.IsInf(q, 0) || q != math.Trunc(q) {
+			fail("%v isn't a multiple of %v", n, *s.multipleOf)
+		}
+	}
+	if s.format != "" && !validNumberFormat(s.format, n) {
+		fail("%v isn't a valid %s", n, s.format)
+	}
+	return errs
+}
+
+func (s *Schema) validateString(str string, path string) []*ValidationError {
+	var errs []*ValidationError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	length := float64(len([]rune(str)))
+	if s.minLength != nil && length < *s.minLength {
+		fail("the length %v is less than the minimum %v", length, *s.minLength)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		fail("the length %v is greater than the maximum %v", length, *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		fail("%q doesn't match the pattern %q", str, s.pattern.String())
+	}
+	if s.format != "" && !validStringFormat(s.format, str) {
+		fail("%q isn't a valid %s", str, s.format)
+	}
+	return errs
+}
+
+//nolint:cyclop
+func (s *Schema) validateArray(arr []interface{}, path string) []*ValidationError {
+	var errs []*ValidationError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	n := float64(len(arr))
+	if s.minItems != nil && n < *s.minItems {
+		fail("the array has %v items, less than the minimum %v", n, *s.minItems)
+	}
+	if s.maxItems != nil && n > *s.maxItems {
+		fail("the array has %v items, more than the maximum %v", n, *s.maxItems)
+	}
+	if s.uniqueItems {
+	unique:
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if equal(arr[i], arr[j]) {
+					fail("the items %d and %d are equal", i, j)
+					break unique
+				}
+			}
+		}
+	}
+	for i, item := range arr {
+		itemPath := path + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(s.prefixItems):
+			errs = append(errs, s.prefixItems[i].validate(item, itemPath)...)
+		case s.items != nil:
+			errs = append(errs, s.items.validate(item, itemPath)...)
+		}
+	}
+	if s.contains != nil {
+		found := false
+		for i, item := range arr {
+			if len(s.contains.validate(item, path+"/"+strconv.Itoa(i))) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("no item matches the contains schema")
+		}
+	}
+	return errs
+}
+
+//nolint:cyclop
+func (s *Schema) validateObject(obj map[string]interface{}, path string) []*ValidationError {
+	var errs []*ValidationError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	n := float64(len(obj))
+	if s.minProperties != nil && n < *s.minProperties {
+		fail("the object has %v properties, less than the minimum %v", n, *s.minProperties)
+	}
+	if s.maxProperties != nil && n > *s.maxProperties {
+		fail("the object has %v properties, more than the maximum %v", n, *s.maxProperties)
+	}
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			fail("the property %q is required", name)
+		}
+	}
+
+	// the properties are validated in the order of their names, so the errors
+	// are always in the same order
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, propertyPath := obj[name], path+"/"+escapePointer(name)
+		if s.propertyNames != nil {
+			for _, err := range s.propertyNames.validate(name, propertyPath) {
+				fail("invalid property name %q: %s", name, err.Message)
+			}
+		}
+		matched := false
+		if sub, ok := s.properties[name]; ok {
+			matched = true
+			errs = append(errs, sub.validate(value, propertyPath)...)
+		}
+		for _, pp := range s.patternProperties {
+			if pp.pattern.MatchString(name) {
+				matched = true
+				errs = append(errs, pp.schema.validate(value, propertyPath)...)
+			}
+		}
+		if matched || s.additionalProperties == nil {
+			continue
+		}
+		if a := s.additionalProperties.always; a != nil && !*a {
+			fail("the property %q isn't allowed", name)
+			continue
+		}
+		errs = append(errs, s.additionalProperties.validate(value, propertyPath)...)
+	}
+	return errs
+}
+
+// typeOf returns the JSON schema type of the value.
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
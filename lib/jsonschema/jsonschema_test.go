@@ -0,0 +1,191 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `{
+	"$defs": {
+		"user": {
+			"type": "object",
+			"required": ["id", "name"],
+			"properties": {
+				"id": {"type": "integer", "minimum": 1},
+				"name": {"type": "string", "minLength": 1, "maxLength": 10},
+				"email": {"type": "string", "format": "email"},
+				"role": {"enum": ["admin", "user"]},
+				"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+				"manager": {"$ref": "#/$defs/user"},
+				"nickname": {"type": "string", "nullable": true}
+			},
+			"additionalProperties": false
+		}
+	},
+	"type": "array",
+	"items": {"$ref": "#/$defs/user"},
+	"minItems": 1
+}`
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(userSchema), &doc))
+	schema, err := Compile(doc)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"valid", `[{"id": 1, "name": "alice", "email": "alice@example.com", "role": "admin",
+			"tags": ["a", "b"], "nickname": null, "manager": {"id": 2, "name": "bob"}}]`, nil},
+		{"empty", `[]`, []string{"the array has 0 items, less than the minimum 1"}},
+		{"type", `{}`, []string{"expected array, but got object"}},
+		{"required", `[{"id": 1}]`, []string{`/0: the property "name" is required`}},
+		{"nested", `[{"id": 1.5, "name": "alice", "manager": {"id": 0, "name": ""}}]`, []string{
+			"/0/id: expected integer, but got number",
+			"/0/manager/id: 0 is less than the minimum 1",
+			"/0/manager/name: the length 0 is less than the minimum 1",
+		}},
+		{"strings", `[{"id": 1, "name": "a very long name", "email": "alice", "role": "guest"}]`, []string{
+			`/0/email: "alice" isn't a valid email`,
+			`/0/name: the length 16 is greater than the maximum 10`,
+			`/0/role: "guest" isn't one of ["admin","user"]`,
+		}},
+		{"arrays", `[{"id": 1, "name": "alice", "tags": ["a", "a", 1, "b"]}]`, []string{
+			"/0/tags: the array has 4 items, more than the maximum 3",
+			"/0/tags: the items 0 and 1 are equal",
+			"/0/tags/2: expected string, but got integer",
+		}},
+		{"additional", `[{"id": 1, "name": "alice", "age": 3}]`, []string{`/0: the property "age" isn't allowed`}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var value interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.value), &value))
+			var messages []string
+			for _, err := range schema.Validate(value) {
+				messages = append(messages, err.Error())
+			}
+			assert.ElementsMatch(t, tc.expected, messages)
+		})
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		schema  string
+		valid   []string
+		invalid []string
+	}{
+		{`{"type": ["string", "null"]}`, []string{`"a"`, `null`}, []string{`1`}},
+		{`{"const": {"a": [1]}}`, []string{`{"a": [1]}`}, []string{`{"a": [2]}`}},
+		{`{"exclusiveMinimum": 1, "exclusiveMaximum": 3}`, []string{`2`, `"a"`}, []string{`1`, `3`}},
+		{`{"minimum": 1, "exclusiveMinimum": true}`, []string{`1.5`}, []string{`1`}},
+		{`{"multipleOf": 0.5}`, []string{`1.5`}, []string{`1.2`}},
+		{`{"pattern": "^a+$"}`, []string{`"aa"`, `1`}, []string{`"ab"`}},
+		{`{"format": "date-time"}`, []string{`"2023-01-02T03:04:05Z"`}, []string{`"2023-01-02"`}},
+		{`{"format": "uuid"}`, []string{`"0f8fad5b-d9cb-469f-a165-70867728950e"`}, []string{`"0f8fad5b"`}},
+		{`{"format": "int32"}`, []string{`2147483647`}, []string{`2147483648`}},
+		{`{"prefixItems": [{"type": "integer"}], "items": false}`, []string{`[1]`}, []string{`["a"]`, `[1, 2]`}},
+		{`{"items": [{"type": "integer"}], "additionalItems": {"type": "string"}}`, []string{`[1, "a"]`}, []string{`[1, 2]`}},
+		{`{"contains": {"const": 1}}`, []string{`[0, 1]`}, []string{`[0]`}},
+		{`{"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": {"type": "integer"}}`,
+			[]string{`{"x-a": "a", "b": 1}`}, []string{`{"x-a": 1}`, `{"b": "a"}`}},
+		{`{"propertyNames": {"maxLength": 2}, "minProperties": 1}`, []string{`{"ab": 1}`}, []string{`{}`, `{"abc": 1}`}},
+		{`{"anyOf": [{"type": "string"}, {"minimum": 2}]}`, []string{`"a"`, `3`}, []string{`1`}},
+		{`{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`, []string{`1`, `2.5`}, []string{`3`}},
+		{`{"allOf": [{"minimum": 1}, {"maximum": 2}]}`, []string{`1`}, []string{`3`}},
+		{`{"not": {"type": "null"}}`, []string{`1`}, []string{`null`}},
+		{`{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`,
+			[]string{`20`, `4`}, []string{`15`, `7`}},
+		{`true`, []string{`null`}, nil},
+		{`false`, nil, []string{`null`}},
+	}
+	for _, tc := range testCases {
+		var doc interface{}
+		require.NoError(t, json.Unmarshal([]byte(tc.schema), &doc))
+		schema, err := Compile(doc)
+		require.NoError(t, err, tc.schema)
+		for _, value := range tc.valid {
+			var v interface{}
+			require.NoError(t, json.Unmarshal([]byte(value), &v))
+			assert.Empty(t, schema.Validate(v), "%s should be valid for %s", value, tc.schema)
+		}
+		for _, value := range tc.invalid {
+			var v interface{}
+			require.NoError(t, json.Unmarshal([]byte(value), &v))
+			assert.NotEmpty(t, schema.Validate(v), "%s should be invalid for %s", value, tc.schema)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Normalize", func(t *testing.T) {
+		t.Parallel()
+		// the values of the scripts and of the YAML documents
+		schema, err := Compile(map[string]interface{}{
+			"properties": map[interface{}]interface{}{
+				"id": map[string]interface{}{"maximum": int64(3), "enum": []interface{}{int64(1), int64(2)}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, schema.Validate(map[string]interface{}{"id": 1.0}))
+		assert.Len(t, schema.Validate(map[string]interface{}{"id": 4.0}), 2)
+	})
+
+	t.Run("Refs", func(t *testing.T) {
+		t.Parallel()
+		var doc interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"components": {"schemas": {
+				"Pet": {"type": "object", "properties": {"name": {"type": "string"}}},
+				"a/b": {"type": "integer"}
+			}},
+			"paths": {"/pets": {"get": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}}
+		}`), &doc))
+		c := NewCompiler(doc)
+		pets, err := c.Compile("#/paths/~1pets/get/schema")
+		require.NoError(t, err)
+		assert.Equal(t, "#/paths/~1pets/get/schema", pets.String())
+		assert.Len(t, pets.Validate([]interface{}{map[string]interface{}{"name": 1.0}}), 1)
+		pet, err := c.Compile("#/components/schemas/Pet")
+		require.NoError(t, err)
+		assert.Same(t, pet, pets.items.ref)
+		_, err = c.Compile("#/components/schemas/a~1b")
+		require.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		testCases := map[string]string{
+			`{"type": 1}`:                   "invalid schema at #: type has to be a string or an array of strings",
+			`{"minimum": "1"}`:              "invalid schema at #: minimum has to be a number",
+			`{"pattern": "("}`:              "invalid schema at #: invalid pattern \"(\"",
+			`{"properties": {"a": 1}}`:      "invalid schema at #/properties/a: expected an object or a boolean",
+			`{"items": {"$ref": "#/nope"}}`: "invalid schema at #/items: the $ref '#/nope' doesn't exist",
+			`{"$ref": "other.json#/a"}`:     "unsupported $ref 'other.json#/a'",
+			`{"allOf": {"type": "string"}}`: "invalid schema at #: allOf has to be an array of schemas",
+			`{"required": [1]}`:             "invalid schema at #: required has to be an array of strings",
+		}
+		for schema, msg := range testCases {
+			var doc interface{}
+			require.NoError(t, json.Unmarshal([]byte(schema), &doc))
+			_, err := Compile(doc)
+			assert.ErrorContains(t, err, msg, schema)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+// Package limits checks the limits of the system on the open files and on the
+// ephemeral ports against the connections the VUs of a test run need, since
+// running out of them fails the requests in the middle of the test run with
+// errors like "too many open files".
+package limits
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// filesPerVU is the estimate of the files a VU keeps open, for its
+	// connection and the one replacing it when it's renewed.
+	filesPerVU = 2
+	// baseFiles is the estimate of the files k6 keeps open itself, like the
+	// scripts, the outputs and the logs.
+	baseFiles = 64
+)
+
+// portRangePath is where Linux has the range of the ephemeral ports.
+const portRangePath = "/proc/sys/net/ipv4/ip_local_port_range"
+
+// OpenFiles is the limit of the open files of the k6 process, RLIMIT_NOFILE.
+type OpenFiles struct {
+	Soft uint64 `json:"soft"`
+	Hard uint64 `json:"hard"`
+	// Raised is true when the soft limit was raised for the test run.
+	Raised bool `json:"raised,omitempty"`
+}
+
+// PortRange is the range of the local ports of the outgoing connections.
+type PortRange struct {
+	First uint64 `json:"first"`
+	Last  uint64 `json:"last"`
+}
+
+// Size returns the number of the ports in the range.
+func (pr PortRange) Size() uint64 {
+	if pr.Last < pr.First {
+		return 0
+	}
+	return pr.Last - pr.First + 1
+}
+
+// Report is the result of the checks of the limits for the VUs of a test run.
+type Report struct {
+	MaxVUs      uint64 `json:"maxVUs"`
+	NeededFiles uint64 `json:"neededFiles"`
+	// OpenFiles and EphemeralPorts are nil when the system doesn't have them,
+	// or they couldn't be read.
+	OpenFiles      *OpenFiles `json:"openFiles,omitempty"`
+	EphemeralPorts *PortRange `json:"ephemeralPorts,omitempty"`
+	Warnings       []string   `json:"warnings,omitempty"`
+}
+
+// Check checks the limits for maxVUs VUs. When raise is true and the soft
+// limit of the open files is too low, it's raised for the k6 process as much
+// as the hard limit permits.
+func Check(maxVUs uint64, raise bool) Report {
+	r := Report{MaxVUs: maxVUs, NeededFiles: baseFiles + maxVUs*filesPerVU}
+
+	if soft, hard, ok := getOpenFilesLimit(); ok {
+		r.OpenFiles = &OpenFiles{Soft: soft, Hard: hard}
+		if raise && soft < r.NeededFiles && soft < hard {
+			target := r.NeededFiles
+			if hard < target {
+				target = hard
+			}
+			if err := setOpenFilesLimit(target); err == nil {
+				r.OpenFiles.Soft, r.OpenFiles.Raised = target, true
+			}
+		}
+	}
+	if data, err := os.ReadFile(portRangePath); err == nil {
+		r.EphemeralPorts = parsePortRange(string(data))
+	}
+
+	r.Warnings = r.warnings()
+	return r
+}
+
+// warnings returns the limits which are too low for the VUs.
+func (r Report) warnings() []string {
+	var warnings []string
+	if r.OpenFiles != nil && r.OpenFiles.Soft < r.NeededFiles {
+		hint := fmt.Sprintf("raise it with 'ulimit -n %d'", r.NeededFiles)
+		if r.OpenFiles.Hard < r.NeededFiles {
+			hint = fmt.Sprintf("its hard limit is %d, raise it in /etc/security/limits.conf or with "+
+				"'ulimit -Hn %d' as root", r.OpenFiles.Hard, r.NeededFiles)
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"the limit of the open files is %d, less than the %d the %d VUs may need and the requests "+
+				"may fail with 'too many open files'; %s",
+			r.OpenFiles.Soft, r.NeededFiles, r.MaxVUs, hint,
+		))
+	}
+	if r.EphemeralPorts != nil && r.EphemeralPorts.Size() < r.MaxVUs {
+		warnings = append(warnings, fmt.Sprintf(
+			"the %d ephemeral ports (%d-%d) are less than the connections of the %d VUs to the same host "+
+				"may need; widen them with 'sysctl -w net.ipv4.ip_local_port_range=\"1024 65535\"'",
+			r.EphemeralPorts.Size(), r.EphemeralPorts.First, r.EphemeralPorts.Last, r.MaxVUs,
+		))
+	}
+	return warnings
+}
+
+// parsePortRange parses the content of ip_local_port_range, the first and the
+// last port separated by whitespace.
+func parsePortRange(s string) *PortRange {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return nil
+	}
+	first, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return nil
+	}
+	last, err := strconv.ParseUint(fields[1], 10, 16)
+	if err != nil {
+		return nil
+	}
+	return &PortRange{First: first, Last: last}
+}
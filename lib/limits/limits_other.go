@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package limits
+
+import "errors"
+
+// The open files aren't limited by an rlimit on Windows, and the limit isn't
+// read on the other systems.
+func getOpenFilesLimit() (soft, hard uint64, ok bool) {
+	return 0, 0, false
+}
+
+func setOpenFilesLimit(uint64) error {
+	return errors.New("the limit of the open files isn't supported on this system")
+}
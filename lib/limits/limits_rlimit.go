@@ -0,0 +1,23 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package limits
+
+import "syscall"
+
+func getOpenFilesLimit() (soft, hard uint64, ok bool) {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, 0, false
+	}
+	return rl.Cur, rl.Max, true
+}
+
+func setOpenFilesLimit(soft uint64) error {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return err
+	}
+	rl.Cur = soft
+	return syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rl)
+}
@@ -0,0 +1,51 @@
+package limits
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePortRange(t *testing.T) {
+	t.Parallel()
+
+	pr := parsePortRange("32768\t60999\n")
+	require.NotNil(t, pr)
+	assert.Equal(t, PortRange{First: 32768, Last: 60999}, *pr)
+	assert.Equal(t, uint64(28232), pr.Size())
+
+	for _, s := range []string{"", "32768", "32768 65536", "a b"} {
+		assert.Nil(t, parsePortRange(s), s)
+	}
+}
+
+func TestReportWarnings(t *testing.T) {
+	t.Parallel()
+
+	r := Report{
+		MaxVUs: 1000, NeededFiles: 2064,
+		OpenFiles:      &OpenFiles{Soft: 1024, Hard: 4096},
+		EphemeralPorts: &PortRange{First: 60000, Last: 60499},
+	}
+	warnings := r.warnings()
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "the limit of the open files is 1024, less than the 2064 the 1000 VUs may need")
+	assert.Contains(t, warnings[0], "'ulimit -n 2064'")
+	assert.Contains(t, warnings[1], "the 500 ephemeral ports (60000-60499)")
+
+	r.OpenFiles.Hard = 1024
+	assert.Contains(t, r.warnings()[0], "its hard limit is 1024")
+
+	r.OpenFiles.Soft, r.EphemeralPorts.Last = 4096, 65535
+	assert.Empty(t, r.warnings())
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	r := Check(10, false)
+	assert.Equal(t, uint64(10), r.MaxVUs)
+	assert.Equal(t, uint64(84), r.NeededFiles)
+	assert.Equal(t, r.warnings(), r.Warnings)
+}
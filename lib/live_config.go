@@ -0,0 +1,74 @@
+package lib
+
+import (
+	"sync"
+)
+
+// LiveConfig contains the parts of the test configuration that can be changed
+// while the test is running, for example through the REST API. The changed
+// tags are applied by the VUs at the start of their next iteration and the
+// test parameters are JSON-encoded values that the scripts can read.
+type LiveConfig struct {
+	mx          sync.RWMutex
+	tags        map[string]string
+	tagsVersion uint64
+	parameters  map[string][]byte
+}
+
+// NewLiveConfig returns a new LiveConfig without any tags or parameters.
+func NewLiveConfig() *LiveConfig {
+	return &LiveConfig{
+		tags:       make(map[string]string),
+		parameters: make(map[string][]byte),
+	}
+}
+
+// GetTags returns a copy of the current live tags and their version, which
+// is increased every time they are changed, so the VUs can cheaply check
+// whether they need to update their own tags.
+func (lc *LiveConfig) GetTags() (map[string]string, uint64) {
+	lc.mx.RLock()
+	defer lc.mx.RUnlock()
+	tags := make(map[string]string, len(lc.tags))
+	for k, v := range lc.tags {
+		tags[k] = v
+	}
+	return tags, lc.tagsVersion
+}
+
+// UpdateTags sets the given tags and deletes the ones in the deleted slice.
+func (lc *LiveConfig) UpdateTags(set map[string]string, deleted []string) {
+	lc.mx.Lock()
+	defer lc.mx.Unlock()
+	for k, v := range set {
+		lc.tags[k] = v
+	}
+	for _, k := range deleted {
+		delete(lc.tags, k)
+	}
+	lc.tagsVersion++
+}
+
+// GetParameters returns a copy of the current JSON-encoded test parameters.
+func (lc *LiveConfig) GetParameters() map[string][]byte {
+	lc.mx.RLock()
+	defer lc.mx.RUnlock()
+	parameters := make(map[string][]byte, len(lc.parameters))
+	for k, v := range lc.parameters {
+		parameters[k] = v
+	}
+	return parameters
+}
+
+// UpdateParameters sets the given JSON-encoded test parameters and deletes
+// the ones in the deleted slice.
+func (lc *LiveConfig) UpdateParameters(set map[string][]byte, deleted []string) {
+	lc.mx.Lock()
+	defer lc.mx.Unlock()
+	for k, v := range set {
+		lc.parameters[k] = v
+	}
+	for _, k := range deleted {
+		delete(lc.parameters, k)
+	}
+}
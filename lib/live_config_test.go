@@ -0,0 +1,31 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveConfig(t *testing.T) {
+	t.Parallel()
+
+	lc := NewLiveConfig()
+	tags, version := lc.GetTags()
+	assert.Empty(t, tags)
+	assert.Equal(t, uint64(0), version)
+
+	lc.UpdateTags(map[string]string{"flag": "a", "env": "staging"}, nil)
+	tags, version = lc.GetTags()
+	assert.Equal(t, map[string]string{"flag": "a", "env": "staging"}, tags)
+	assert.Equal(t, uint64(1), version)
+
+	tags["flag"] = "changed"
+	lc.UpdateTags(map[string]string{"flag": "b"}, []string{"env"})
+	tags, version = lc.GetTags()
+	assert.Equal(t, map[string]string{"flag": "b"}, tags)
+	assert.Equal(t, uint64(2), version)
+
+	lc.UpdateParameters(map[string][]byte{"rate": []byte(`5`), "mode": []byte(`"fast"`)}, nil)
+	lc.UpdateParameters(nil, []string{"mode", "missing"})
+	assert.Equal(t, map[string][]byte{"rate": []byte(`5`)}, lc.GetParameters())
+}
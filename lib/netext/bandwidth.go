@@ -0,0 +1,97 @@
+package netext
+
+import (
+	"context"
+	"net"
+
+	"golang.org/x/time/rate"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// BandwidthLimiter is a pair of token buckets capping the egress and the
+// ingress traffic of the connections it's shared by.
+type BandwidthLimiter struct {
+	egress, ingress *rate.Limiter
+}
+
+// NewBandwidthLimiter returns a limiter with the caps of the given bandwidth.
+func NewBandwidthLimiter(bandwidth types.Bandwidth) *BandwidthLimiter {
+	return &BandwidthLimiter{
+		egress:  newBandwidthBucket(bandwidth.Egress),
+		ingress: newBandwidthBucket(bandwidth.Ingress),
+	}
+}
+
+// newBandwidthBucket returns a token bucket of bytesPerSecond, with a burst of
+// a tenth of a second so the traffic is smooth, or nil when it isn't capped.
+func newBandwidthBucket(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	burst := int(bytesPerSecond / 10)
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// throttledConn is a connection whose reads and writes wait for the tokens of
+// all of its limiters.
+type throttledConn struct {
+	net.Conn
+	limiters []*BandwidthLimiter
+}
+
+// maxChunk returns the largest number of bytes that can be read or written at
+// once without going over the burst of any of the buckets.
+func (c *throttledConn) maxChunk(n int, bucket func(*BandwidthLimiter) *rate.Limiter) int {
+	for _, l := range c.limiters {
+		if b := bucket(l); b != nil && b.Burst() < n {
+			n = b.Burst()
+		}
+	}
+	return n
+}
+
+func (c *throttledConn) wait(n int, bucket func(*BandwidthLimiter) *rate.Limiter) {
+	for _, l := range c.limiters {
+		if b := bucket(l); b != nil {
+			// it can only fail for more tokens than the burst, which maxChunk prevents
+			_ = b.WaitN(context.Background(), n)
+		}
+	}
+}
+
+func ingressBucket(l *BandwidthLimiter) *rate.Limiter { return l.ingress }
+
+func egressBucket(l *BandwidthLimiter) *rate.Limiter { return l.egress }
+
+// Read reads at most a burst of the ingress buckets and then waits for the
+// tokens of the bytes it read.
+func (c *throttledConn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return c.Conn.Read(b)
+	}
+	n, err := c.Conn.Read(b[:c.maxChunk(len(b), ingressBucket)])
+	if n > 0 {
+		c.wait(n, ingressBucket)
+	}
+	return n, err
+}
+
+// Write writes the bytes in chunks of at most a burst of the egress buckets,
+// waiting for the tokens of each chunk before it's written.
+func (c *throttledConn) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		chunk := c.maxChunk(len(b)-written, egressBucket)
+		c.wait(chunk, egressBucket)
+		n, err := c.Conn.Write(b[written : written+chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
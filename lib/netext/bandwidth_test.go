@@ -0,0 +1,74 @@
+package netext
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestThrottledConn(t *testing.T) {
+	t.Parallel()
+
+	// the burst is a tenth of a second, so the first 1000 bytes go through
+	// immediately and the other 2000 take about 200ms
+	data := make([]byte, 3000)
+
+	t.Run("egress", func(t *testing.T) {
+		t.Parallel()
+		client, server := net.Pipe()
+		defer func() { _ = client.Close() }()
+		go func() { _, _ = io.Copy(io.Discard, server) }()
+
+		conn := &throttledConn{Conn: client, limiters: []*BandwidthLimiter{NewBandwidthLimiter(types.Bandwidth{Egress: 10000})}}
+		start := time.Now()
+		n, err := conn.Write(data)
+		require.NoError(t, err)
+		assert.Equal(t, len(data), n)
+		assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	})
+
+	t.Run("ingress", func(t *testing.T) {
+		t.Parallel()
+		client, server := net.Pipe()
+		defer func() { _ = client.Close() }()
+		go func() {
+			_, _ = server.Write(data)
+			_ = server.Close()
+		}()
+
+		conn := &throttledConn{Conn: client, limiters: []*BandwidthLimiter{NewBandwidthLimiter(types.Bandwidth{Ingress: 10000})}}
+		start := time.Now()
+		read, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		assert.Len(t, read, len(data))
+		assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	})
+
+	t.Run("uncapped", func(t *testing.T) {
+		t.Parallel()
+		client, server := net.Pipe()
+		defer func() { _ = client.Close() }()
+		go func() { _, _ = io.Copy(io.Discard, server) }()
+
+		conn := &throttledConn{Conn: client, limiters: []*BandwidthLimiter{NewBandwidthLimiter(types.Bandwidth{Ingress: 1})}}
+		start := time.Now()
+		_, err := conn.Write(data)
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+}
+
+func TestBandwidthValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, types.Bandwidth{Egress: 1}.Validate())
+	assert.NoError(t, types.Bandwidth{Ingress: 1}.Validate())
+	assert.ErrorContains(t, types.Bandwidth{}.Validate(), "needs an egress or an ingress cap")
+	assert.ErrorContains(t, types.Bandwidth{Egress: -1, Ingress: 1}.Validate(), "can't be negative")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -23,6 +24,13 @@ type Dialer struct {
 	BlockedHostnames *types.HostnameTrie
 	Hosts            *types.Hosts
 
+	// BandwidthLimiters returns the limiters capping the bandwidth of the
+	// connections dialed with the given context, if any.
+	BandwidthLimiters func(ctx context.Context) []*BandwidthLimiter
+	// LocalIP returns the local IP the connections dialed with the given
+	// context are bound to, overriding the LocalAddr of the net.Dialer, or nil.
+	LocalIP func(ctx context.Context) net.IP
+
 	BytesRead    int64
 	BytesWritten int64
 }
@@ -61,14 +69,33 @@ func (d *Dialer) DialContext(ctx context.Context, proto, addr string) (net.Conn,
 	if err != nil {
 		return nil, err
 	}
-	conn, err := d.Dialer.DialContext(ctx, proto, dialAddr)
+	dialer := d.Dialer
+	if d.LocalIP != nil {
+		if ip := d.LocalIP(ctx); ip != nil {
+			dialer.LocalAddr = localAddr(proto, ip)
+		}
+	}
+	conn, err := dialer.DialContext(ctx, proto, dialAddr)
 	if err != nil {
 		return nil, err
 	}
+	if d.BandwidthLimiters != nil {
+		if limiters := d.BandwidthLimiters(ctx); len(limiters) > 0 {
+			conn = &throttledConn{Conn: conn, limiters: limiters}
+		}
+	}
 	conn = &Conn{conn, &d.BytesRead, &d.BytesWritten}
 	return conn, err
 }
 
+// localAddr returns the local address with the given IP for the protocol.
+func localAddr(proto string, ip net.IP) net.Addr {
+	if strings.HasPrefix(proto, "udp") {
+		return &net.UDPAddr{IP: ip}
+	}
+	return &net.TCPAddr{IP: ip}
+}
+
 // GetTrail creates a new NetTrail instance with the Dialer
 // sent and received data metrics and the supplied times and tags.
 // TODO: Refactor this according to
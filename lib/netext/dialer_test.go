@@ -1,7 +1,9 @@
 package netext
 
 import (
+	"context"
 	"net"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -148,3 +150,23 @@ func newResolver() *mockresolver.MockResolver {
 		},
 	)
 }
+
+func TestDialerLocalIP(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" {
+		t.Skip("only Linux has the whole 127.0.0.0/8 on the loopback interface by default")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	dialer.LocalIP = func(context.Context) net.IP { return net.ParseIP("127.0.0.2") }
+	conn, err := dialer.DialContext(context.Background(), "tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	localAddr, ok := conn.LocalAddr().(*net.TCPAddr)
+	require.True(t, ok)
+	require.Equal(t, "127.0.0.2", localAddr.IP.String())
+}
@@ -0,0 +1,199 @@
+// Package faults injects faults in the HTTP requests of the VUs, like delays,
+// connection resets, DNS failures and bandwidth caps, to test the resiliency
+// of the clients under degraded network conditions without external proxies.
+package faults
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// The types of the faults.
+const (
+	// Delay delays the requests by a random duration between Min and Max.
+	Delay = "delay"
+	// Reset fails the requests with a connection reset by the peer.
+	Reset = "reset"
+	// DNS fails the requests with a DNS resolution failure of their host.
+	DNS = "dns"
+	// Bandwidth caps the transfer of the bodies of the requests and of the
+	// responses to BytesPerSecond.
+	Bandwidth = "bandwidth"
+)
+
+// Fault is a fault injected in a percentage of the requests, like
+// `{"type": "delay", "percentage": 10, "min": "100ms", "max": "1s"}`.
+type Fault struct {
+	Type string `json:"type"`
+	// Percentage of the requests the fault is injected in, over 0 and up to 100.
+	Percentage float64 `json:"percentage"`
+	// Scenarios are the names of the scenarios whose requests get the fault,
+	// all of them when empty.
+	Scenarios []string `json:"scenarios,omitempty"`
+
+	// Min and Max are the bounds of the duration of the delays.
+	Min types.Duration `json:"min,omitempty"`
+	Max types.Duration `json:"max,omitempty"`
+
+	// BytesPerSecond is the bandwidth cap.
+	BytesPerSecond int64 `json:"bytesPerSecond,omitempty"`
+}
+
+// Validate checks that the type of the fault is known and that its
+// parameters make sense.
+func (f Fault) Validate() error {
+	if f.Percentage <= 0 || f.Percentage > 100 {
+		return fmt.Errorf("the percentage of the %s fault must be over 0 and up to 100, got %g", f.Type, f.Percentage)
+	}
+	switch f.Type {
+	case Delay:
+		if f.Min < 0 || f.Max < f.Min {
+			return fmt.Errorf("the delay fault needs a max of at least its min, got %s and %s", f.Min, f.Max)
+		}
+	case Reset, DNS:
+	case Bandwidth:
+		if f.BytesPerSecond <= 0 {
+			return fmt.Errorf("the bandwidth fault needs a positive bytesPerSecond, got %d", f.BytesPerSecond)
+		}
+	default:
+		return fmt.Errorf("unknown fault type '%s', it must be one of %s, %s, %s or %s", f.Type, Delay, Reset, DNS, Bandwidth)
+	}
+	return nil
+}
+
+func (f Fault) appliesTo(scenario string) bool {
+	if len(f.Scenarios) == 0 {
+		return true
+	}
+	for _, name := range f.Scenarios {
+		if name == scenario {
+			return true
+		}
+	}
+	return false
+}
+
+// Transport is an http.RoundTripper injecting the faults in the requests.
+type Transport struct {
+	transport  http.RoundTripper
+	faults     []Fault
+	scenarioOf func(context.Context) string
+	// random returns a number in [0, 1), it's replaced in the tests
+	random func() float64
+}
+
+var _ http.RoundTripper = &Transport{}
+
+// NewTransport returns a transport injecting the faults in the requests made
+// with the transport. The scenario of the requests is given by scenarioOf.
+func NewTransport(transport http.RoundTripper, faults []Fault, scenarioOf func(context.Context) string) *Transport {
+	return &Transport{
+		transport:  transport,
+		faults:     faults,
+		scenarioOf: scenarioOf,
+		random:     rand.Float64, //nolint:gosec
+	}
+}
+
+// RoundTrip injects the faults drawn for the request, and makes it unless one
+// of them fails it.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	scenario := t.scenarioOf(ctx)
+
+	var bytesPerSecond int64
+	for _, fault := range t.faults {
+		if !fault.appliesTo(scenario) || t.random()*100 >= fault.Percentage {
+			continue
+		}
+		switch fault.Type {
+		case Delay:
+			delay := time.Duration(fault.Min) + time.Duration(t.random()*float64(fault.Max-fault.Min))
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		case Reset:
+			closeBody(req)
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+		case DNS:
+			closeBody(req)
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{
+				Err: "no such host", Name: req.URL.Hostname(), IsNotFound: true,
+			}}
+		case Bandwidth:
+			if bytesPerSecond == 0 || fault.BytesPerSecond < bytesPerSecond {
+				bytesPerSecond = fault.BytesPerSecond
+			}
+		}
+	}
+
+	if bytesPerSecond == 0 {
+		return t.transport.RoundTrip(req)
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(ctx)
+		req.Body = newThrottledBody(ctx, req.Body, bytesPerSecond)
+	}
+	res, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = newThrottledBody(ctx, res.Body, bytesPerSecond)
+	return res, nil
+}
+
+// closeBody closes the body of the request which isn't sent, as the
+// transports have to.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// throttledBody is a body read at most at bytesPerSecond.
+type throttledBody struct {
+	io.ReadCloser
+	ctx            context.Context //nolint:containedctx
+	bytesPerSecond int64
+	started        time.Time
+	read           int64
+}
+
+func newThrottledBody(ctx context.Context, body io.ReadCloser, bytesPerSecond int64) *throttledBody {
+	return &throttledBody{ReadCloser: body, ctx: ctx, bytesPerSecond: bytesPerSecond, started: time.Now()}
+}
+
+// Read reads at most the bytes of a tenth of a second at a time, and waits
+// until the body has been read for long enough for the bytes read so far.
+func (tb *throttledBody) Read(p []byte) (int, error) {
+	if chunk := tb.bytesPerSecond / 10; chunk > 0 && int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := tb.ReadCloser.Read(p)
+	tb.read += int64(n)
+
+	wait := time.Duration(tb.read*int64(time.Second)/tb.bytesPerSecond) - time.Since(tb.started)
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-tb.ctx.Done():
+			return n, tb.ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return n, err
+}
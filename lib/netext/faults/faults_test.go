@@ -0,0 +1,125 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/types"
+)
+
+type scenarioKey struct{}
+
+func scenarioOf(ctx context.Context) string {
+	name, _ := ctx.Value(scenarioKey{}).(string)
+	return name
+}
+
+func newTestRequest(t *testing.T, url, scenario string, body io.Reader) *http.Request {
+	t.Helper()
+	ctx := context.WithValue(context.Background(), scenarioKey{}, scenario)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	require.NoError(t, err)
+	return req
+}
+
+func TestTransportFailures(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	transport := NewTransport(srv.Client().Transport, []Fault{
+		{Type: DNS, Percentage: 100, Scenarios: []string{"dns"}},
+		{Type: Reset, Percentage: 50, Scenarios: []string{"reset"}},
+	}, scenarioOf)
+
+	_, err := transport.RoundTrip(newTestRequest(t, srv.URL, "dns", nil))
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsNotFound)
+	assert.Equal(t, "127.0.0.1", dnsErr.Name)
+
+	// the reset is injected only in the requests whose draw is under 50%
+	transport.random = func() float64 { return 0.4 }
+	_, err = transport.RoundTrip(newTestRequest(t, srv.URL, "reset", nil))
+	assert.True(t, errors.Is(err, syscall.ECONNRESET))
+
+	transport.random = func() float64 { return 0.6 }
+	for _, scenario := range []string{"reset", "other"} {
+		res, err := transport.RoundTrip(newTestRequest(t, srv.URL, scenario, nil))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+}
+
+func TestTransportDelayAndBandwidth(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer srv.Close()
+
+	transport := NewTransport(srv.Client().Transport, []Fault{
+		{Type: Delay, Percentage: 100, Min: types.Duration(100 * time.Millisecond), Max: types.Duration(200 * time.Millisecond)},
+		{Type: Bandwidth, Percentage: 100, BytesPerSecond: 1000},
+	}, scenarioOf)
+	transport.random = func() float64 { return 0.5 }
+
+	start := time.Now()
+	res, err := transport.RoundTrip(newTestRequest(t, srv.URL, "", strings.NewReader(strings.Repeat("a", 200))))
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Len(t, body, 200)
+	// 150ms of delay, and 200ms for each of the bodies
+	assert.GreaterOrEqual(t, time.Since(start), 550*time.Millisecond)
+}
+
+func TestFaultValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		fault Fault
+		err   string
+	}{
+		"delay":              {fault: Fault{Type: Delay, Percentage: 10, Max: types.Duration(time.Second)}},
+		"reset":              {fault: Fault{Type: Reset, Percentage: 100}},
+		"bandwidth":          {fault: Fault{Type: Bandwidth, Percentage: 1, BytesPerSecond: 1024}},
+		"unknown type":       {fault: Fault{Type: "loss", Percentage: 10}, err: "unknown fault type 'loss'"},
+		"no percentage":      {fault: Fault{Type: DNS}, err: "must be over 0 and up to 100, got 0"},
+		"too big percentage": {fault: Fault{Type: DNS, Percentage: 101}, err: "must be over 0 and up to 100, got 101"},
+		"max under min": {
+			fault: Fault{Type: Delay, Percentage: 10, Min: types.Duration(time.Second)},
+			err:   "needs a max of at least its min",
+		},
+		"no bytesPerSecond": {fault: Fault{Type: Bandwidth, Percentage: 10}, err: "needs a positive bytesPerSecond"},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.fault.Validate()
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
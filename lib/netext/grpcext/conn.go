@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -238,7 +237,7 @@ func (h statsHandler) HandleRPC(ctx context.Context, stat grpcstats.RPCStats) {
 		}
 	case *grpcstats.End:
 		if state.Options.SystemTags.Has(metrics.TagStatus) {
-			stateRPC.tagsAndMeta.SetSystemTagOrMeta(metrics.TagStatus, strconv.Itoa(int(status.Code(s.Error))))
+			stateRPC.tagsAndMeta.SetSystemTagOrMeta(metrics.TagStatus, metrics.IntTagValue(int(status.Code(s.Error))))
 		}
 
 		metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
@@ -115,21 +115,26 @@ func wrapDecompressionError(err error) error {
 	return err
 }
 
+// readResponseBody reads the whole body of the response and returns it in the
+// given type. When peek is positive, only its first peek bytes are returned.
+// It also returns the number of the bytes which were read but not returned,
+// the encoded ones when the body is discarded and the decoded ones otherwise.
 func readResponseBody(
 	state *lib.State,
 	respType ResponseType,
+	peek int64,
 	resp *http.Response,
 	respErr error,
-) (interface{}, error) {
+) (result interface{}, discarded int64, _ error) {
 	if resp == nil || respErr != nil {
-		return nil, respErr
+		return nil, 0, respErr
 	}
 
 	if respType == ResponseTypeNone {
-		_, err := io.Copy(io.Discard, resp.Body)
+		discarded, err := io.Copy(io.Discard, resp.Body)
 		_ = resp.Body.Close()
 
-		return nil, err
+		return nil, discarded, err
 	}
 
 	rc := &readCloser{resp.Body}
@@ -144,7 +149,7 @@ func readResponseBody(
 		// for all three of this status code there is always no content
 		// https://www.rfc-editor.org/rfc/rfc9110.html#section-6.4.1-8
 		// this also prevents trying to read
-		return nil, nil //nolint:nilnil
+		return nil, 0, nil
 	}
 	contentEncodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
 	// Transparently decompress the body if it's has a content-encoding we
@@ -154,7 +159,7 @@ func readResponseBody(
 		if compression, err := CompressionTypeString(contentEncoding); err == nil {
 			decoder, err := pickDecoder(compression, rc)
 			if err != nil {
-				return nil, newDecompressionError(err)
+				return nil, 0, newDecompressionError(err)
 			}
 
 			rc = &readCloser{decoder}
@@ -163,7 +168,17 @@ func readResponseBody(
 
 	buf := state.BufferPool.Get()
 	defer state.BufferPool.Put(buf)
-	_, err := io.Copy(buf, rc.Reader)
+	var err error
+	if peek > 0 {
+		if _, err = io.CopyN(buf, rc.Reader, peek); errors.Is(err, io.EOF) {
+			err = nil // the body is shorter than the peek
+		}
+		if err == nil {
+			discarded, err = io.Copy(io.Discard, rc.Reader)
+		}
+	} else {
+		_, err = io.Copy(buf, rc.Reader)
+	}
 	if err != nil {
 		respErr = wrapDecompressionError(err)
 	}
@@ -173,7 +188,6 @@ func readResponseBody(
 		respErr = wrapDecompressionError(err)
 	}
 
-	var result interface{}
 	// Binary or string
 	switch respType {
 	case ResponseTypeText:
@@ -190,7 +204,7 @@ func readResponseBody(
 		respErr = fmt.Errorf("unknown responseType %s", respType)
 	}
 
-	return result, respErr
+	return result, discarded, respErr
 }
 
 func pickDecoder(compression CompressionType, rc *readCloser) (io.Reader, error) {
@@ -28,6 +28,11 @@ const (
 	defaultNetNonTCPErrorCode errCode = 1010
 	invalidURLErrorCode       errCode = 1020
 	requestTimeoutErrorCode   errCode = 1050
+	// the timeouts of the phases of the requests
+	connectTimeoutErrorCode         errCode = 1051
+	tlsHandshakeTimeoutErrorCode    errCode = 1052
+	responseHeadersTimeoutErrorCode errCode = 1053
+	bodyTimeoutErrorCode            errCode = 1054
 	// DNS errors
 	defaultDNSErrorCode      errCode = 1100
 	dnsNoSuchHostErrorCode   errCode = 1101
@@ -85,6 +90,13 @@ const (
 	invalidURLErrorCodeMsg      = "invalid URL"
 )
 
+var phaseTimeoutErrorCodeMsgs = map[errCode]string{ //nolint:gochecknoglobals
+	connectTimeoutErrorCode:         "connect timeout",
+	tlsHandshakeTimeoutErrorCode:    "tls handshake timeout",
+	responseHeadersTimeoutErrorCode: "response headers timeout",
+	bodyTimeoutErrorCode:            "response body timeout",
+}
+
 func http2ErrCodeOffset(code http2.ErrCode) errCode {
 	if code > http2.ErrCodeHTTP11Required {
 		return 0
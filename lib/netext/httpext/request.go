@@ -18,6 +18,8 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/trace"
 	"go.k6.io/k6/metrics"
 )
 
@@ -42,9 +44,12 @@ type ParsedHTTPRequest struct {
 	Body             *bytes.Buffer
 	Req              *http.Request
 	Timeout          time.Duration
+	Timeouts         PhaseTimeouts
 	Auth             string
 	Throw            bool
 	ResponseType     ResponseType
+	PeekResponseBody int64 // keep only the first bytes of the body, if positive
+	TLSOverride      netext.TLSOverride
 	ResponseCallback func(int) bool
 	Compressions     []CompressionType
 	Redirects        null.Int
@@ -96,13 +101,14 @@ func updateK6Response(k6Response *Response, finishedReq *finishedRequest) {
 		k6Response.RemotePort = remotePort
 	}
 	k6Response.Timings = ResponseTimings{
-		Duration:       metrics.D(trail.Duration),
-		Blocked:        metrics.D(trail.Blocked),
-		Connecting:     metrics.D(trail.Connecting),
-		TLSHandshaking: metrics.D(trail.TLSHandshaking),
-		Sending:        metrics.D(trail.Sending),
-		Waiting:        metrics.D(trail.Waiting),
-		Receiving:      metrics.D(trail.Receiving),
+		Duration:        metrics.D(trail.Duration),
+		Blocked:         metrics.D(trail.Blocked),
+		Connecting:      metrics.D(trail.Connecting),
+		ProxyConnecting: metrics.D(trail.ProxyConnecting),
+		TLSHandshaking:  metrics.D(trail.TLSHandshaking),
+		Sending:         metrics.D(trail.Sending),
+		Waiting:         metrics.D(trail.Waiting),
+		Receiving:       metrics.D(trail.Receiving),
 	}
 }
 
@@ -167,6 +173,26 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		}
 	}
 
+	// Send the iteration's correlation ID, unless the user has set the header explicitly.
+	if header := state.Options.CorrelationIDHeader.String; header != "" && state.IterationContext != nil &&
+		preq.Req.Header.Get(header) == "" {
+		preq.Req.Header.Set(header, state.IterationContext.CorrelationID)
+	}
+
+	// Propagate a new trace context, unless one was already set, e.g. by the user or the tracing module.
+	// The trace and span IDs of the sampled ones are added as metadata, so the samples can be used
+	// as exemplars and the trace outputs can emit the client spans of the propagated traces.
+	if propagator := state.Options.TracePropagator.String; propagator != "" && !trace.HasContext(preq.Req.Header) {
+		sampling := 1.0
+		if state.Options.TraceSampling.Valid {
+			sampling = state.Options.TraceSampling.Float64
+		}
+		if traceID, spanID, sampled := trace.Propagate(preq.Req.Header, propagator, sampling); sampled {
+			preq.TagsAndMeta.SetMetadata("trace_id", traceID)
+			preq.TagsAndMeta.SetMetadata("span_id", spanID)
+		}
+	}
+
 	// Only set the name system tag if the user didn't explicitly set it beforehand,
 	// and the Name was generated from a tagged template string (via http.url).
 	if _, ok := preq.TagsAndMeta.Tags.Get(metrics.TagName.String()); !ok &&
@@ -261,6 +287,13 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 
 	reqCtx, cancelFunc := context.WithTimeout(ctx, preq.Timeout)
 	defer cancelFunc()
+	if preq.Timeouts != (PhaseTimeouts{}) {
+		tracerTransport.deadlines = newPhaseDeadlines(preq.Timeouts, cancelFunc)
+		defer tracerTransport.deadlines.stop()
+	}
+	if preq.TLSOverride != (netext.TLSOverride{}) {
+		reqCtx = netext.WithTLSOverride(reqCtx, preq.TLSOverride)
+	}
 	mreq := preq.Req.WithContext(reqCtx)
 	res, resErr := client.Do(mreq)
 
@@ -273,9 +306,14 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		return nil, fmt.Errorf("unsupported response status: %s", res.Status)
 	}
 
+	var discardedBytes int64
 	if resErr == nil {
-		resp.Body, resErr = readResponseBody(state, preq.ResponseType, res, resErr)
-		if resErr != nil && errors.Is(resErr, context.DeadlineExceeded) {
+		tracerTransport.deadlines.startBody()
+		resp.Body, discardedBytes, resErr = readResponseBody(state, preq.ResponseType, preq.PeekResponseBody, res, resErr)
+		tracerTransport.deadlines.stop()
+		if resErr != nil && tracerTransport.deadlines.timedOut() {
+			resErr = tracerTransport.deadlines.wrap(resErr)
+		} else if resErr != nil && errors.Is(resErr, context.DeadlineExceeded) {
 			// TODO This can be more specific that the timeout happened in the middle of the reading of the body
 			resErr = NewK6Error(requestTimeoutErrorCode, requestTimeoutErrorCodeMsg, resErr)
 		}
@@ -283,6 +321,18 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 	finishedReq := tracerTransport.processLastSavedRequest(wrapDecompressionError(resErr))
 	if finishedReq != nil {
 		updateK6Response(resp, finishedReq)
+		if discardedBytes > 0 {
+			// the bodies aren't kept, but their sizes are still accounted for
+			metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: state.BuiltinMetrics.HTTPRespDiscarded,
+					Tags:   finishedReq.trail.Tags,
+				},
+				Time:     finishedReq.trail.EndTime,
+				Metadata: finishedReq.trail.Metadata,
+				Value:    float64(discardedBytes),
+			})
+		}
 	}
 
 	if resErr == nil {
@@ -322,6 +372,12 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		}
 	}
 
+	if state.FailureCapture != nil && (resp.Status < 200 || resp.Status > 299) {
+		if err := state.FailureCapture.CaptureStatus(CaptureFailure(resp)); err != nil {
+			state.Logger.WithError(err).Warn("Couldn't capture the failed request")
+		}
+	}
+
 	if resErr != nil {
 		if preq.Throw { // if we are going to throw, we shouldn't log it
 			return nil, resErr
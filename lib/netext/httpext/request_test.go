@@ -290,6 +290,117 @@ func TestMakeRequestTimeoutInTheMiddle(t *testing.T) {
 	}
 }
 
+func TestMakeRequestPhaseTimeouts(t *testing.T) {
+	t.Parallel()
+
+	slowHeaders := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(slowHeaders.Close)
+	slowBody := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("Content-Length", "100000")
+		w.WriteHeader(http.StatusOK)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		time.Sleep(200 * time.Millisecond)
+	}))
+	t.Cleanup(slowBody.Close)
+	// a server accepting the connections without ever doing the TLS handshake
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = silent.Close() })
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+
+	testCases := []struct {
+		name      string
+		url       string
+		timeouts  PhaseTimeouts
+		errorCode string
+		errorMsg  string
+	}{
+		{
+			name:      "TLSHandshake",
+			url:       "https://" + silent.Addr().String(),
+			timeouts:  PhaseTimeouts{TLSHandshake: 50 * time.Millisecond},
+			errorCode: "1052",
+			errorMsg:  "tls handshake timeout",
+		},
+		{
+			name:      "ResponseHeaders",
+			url:       slowHeaders.URL,
+			timeouts:  PhaseTimeouts{ResponseHeaders: 50 * time.Millisecond},
+			errorCode: "1053",
+			errorMsg:  "response headers timeout",
+		},
+		{
+			name:      "Body",
+			url:       slowBody.URL,
+			timeouts:  PhaseTimeouts{ResponseHeaders: time.Second, Body: 50 * time.Millisecond},
+			errorCode: "1054",
+			errorMsg:  "response body timeout",
+		},
+		{
+			name:     "InTime",
+			url:      slowHeaders.URL,
+			timeouts: PhaseTimeouts{Connect: time.Second, ResponseHeaders: time.Second, Body: time.Second},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			samples := make(chan metrics.SampleContainer, 10)
+			registry := metrics.NewRegistry()
+			state := &lib.State{
+				Options: lib.Options{
+					SystemTags: &metrics.DefaultSystemTagSet,
+				},
+				Transport:      http.DefaultTransport,
+				Samples:        samples,
+				Logger:         logrus.New(),
+				BufferPool:     lib.NewBufferPool(),
+				BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+				Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+			}
+			req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+			preq := &ParsedHTTPRequest{
+				Req:         req,
+				URL:         &URL{u: req.URL, URL: tc.url},
+				Body:        new(bytes.Buffer),
+				Timeout:     10 * time.Second,
+				Timeouts:    tc.timeouts,
+				Throw:       true,
+				TagsAndMeta: state.Tags.GetCurrentValues(),
+			}
+
+			start := time.Now()
+			_, err := MakeRequest(context.Background(), state, preq)
+			assert.Less(t, time.Since(start), 5*time.Second)
+			if tc.errorCode == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tc.errorMsg)
+			require.Len(t, samples, 1)
+			for _, s := range (<-samples).GetSamples() {
+				errorCode, _ := s.Tags.Get("error_code")
+				assert.Equal(t, tc.errorCode, errorCode)
+			}
+		})
+	}
+}
+
 func BenchmarkWrapDecompressionError(b *testing.B) {
 	err := errors.New("error")
 	b.ResetTimer()
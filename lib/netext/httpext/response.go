@@ -3,6 +3,7 @@ package httpext
 import (
 	"crypto/tls"
 
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext"
 )
 
@@ -33,14 +34,15 @@ const (
 
 // ResponseTimings is a struct to put all timings for a given HTTP response/request
 type ResponseTimings struct {
-	Duration       float64 `json:"duration"`
-	Blocked        float64 `json:"blocked"`
-	LookingUp      float64 `json:"looking_up"`
-	Connecting     float64 `json:"connecting"`
-	TLSHandshaking float64 `json:"tls_handshaking"`
-	Sending        float64 `json:"sending"`
-	Waiting        float64 `json:"waiting"`
-	Receiving      float64 `json:"receiving"`
+	Duration        float64 `json:"duration"`
+	Blocked         float64 `json:"blocked"`
+	LookingUp       float64 `json:"looking_up"`
+	Connecting      float64 `json:"connecting"`
+	ProxyConnecting float64 `json:"proxy_connecting"`
+	TLSHandshaking  float64 `json:"tls_handshaking"`
+	Sending         float64 `json:"sending"`
+	Waiting         float64 `json:"waiting"`
+	Receiving       float64 `json:"receiving"`
 }
 
 // HTTPCookie is a representation of an http cookies used in the Response object
@@ -84,3 +86,26 @@ func (res *Response) setTLSInfo(tlsState *tls.ConnectionState) {
 	res.TLSCipherSuite = tlsInfo.CipherSuite
 	res.OCSP = oscp
 }
+
+// CaptureFailure returns the request and the response of res for the capture
+// of the failures. It isn't a method, so it isn't exposed to the scripts.
+func CaptureFailure(res *Response) (lib.CapturedRequest, lib.CapturedResponse) {
+	var req lib.CapturedRequest
+	if res.Request != nil {
+		req = lib.CapturedRequest{
+			Method:  res.Request.Method,
+			URL:     res.Request.URL,
+			Headers: res.Request.Headers,
+			Body:    res.Request.Body,
+		}
+	}
+
+	captured := lib.CapturedResponse{Status: res.Status, Headers: res.Headers, Error: res.Error}
+	switch body := res.Body.(type) {
+	case string:
+		captured.Body = body
+	case []byte:
+		captured.Body = string(body)
+	}
+	return req, captured
+}
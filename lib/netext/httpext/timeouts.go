@@ -0,0 +1,132 @@
+package httpext
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// PhaseTimeouts are the timeouts of the phases of a request, on top of its
+// total timeout. A zero timeout leaves its phase bounded only by the total
+// one.
+type PhaseTimeouts struct {
+	// Establishing the TCP connections.
+	Connect time.Duration
+	// The TLS handshakes.
+	TLSHandshake time.Duration
+	// From the request being written to the first byte of the response.
+	ResponseHeaders time.Duration
+	// Reading the body of the response.
+	Body time.Duration
+}
+
+// phaseDeadlines cancels a request when one of its phases takes longer than
+// its timeout, and remembers which one it was, so the error can say so. The
+// methods are no-ops on a nil phaseDeadlines.
+type phaseDeadlines struct {
+	timeouts PhaseTimeouts
+	cancel   context.CancelFunc
+
+	mx         sync.Mutex
+	timer      *time.Timer
+	generation uint64
+	expired    errCode
+}
+
+func newPhaseDeadlines(timeouts PhaseTimeouts, cancel context.CancelFunc) *phaseDeadlines {
+	return &phaseDeadlines{timeouts: timeouts, cancel: cancel}
+}
+
+// start starts the timeout of a phase, stopping the one of the previous phase.
+func (pd *phaseDeadlines) start(timeout time.Duration, code errCode) {
+	if pd == nil {
+		return
+	}
+	pd.mx.Lock()
+	defer pd.mx.Unlock()
+	pd.stopLocked()
+	if timeout <= 0 {
+		return
+	}
+	generation := pd.generation
+	pd.timer = time.AfterFunc(timeout, func() {
+		pd.mx.Lock()
+		// the phase may have ended while the timer was firing
+		current := generation == pd.generation && pd.expired == 0
+		if current {
+			pd.expired = code
+		}
+		pd.mx.Unlock()
+		if current {
+			pd.cancel()
+		}
+	})
+}
+
+// stop stops the timeout of the current phase.
+func (pd *phaseDeadlines) stop() {
+	if pd == nil {
+		return
+	}
+	pd.mx.Lock()
+	defer pd.mx.Unlock()
+	pd.stopLocked()
+}
+
+func (pd *phaseDeadlines) stopLocked() {
+	pd.generation++
+	if pd.timer != nil {
+		pd.timer.Stop()
+		pd.timer = nil
+	}
+}
+
+// startBody starts the timeout of reading the body of the response.
+func (pd *phaseDeadlines) startBody() {
+	if pd == nil {
+		return
+	}
+	pd.start(pd.timeouts.Body, bodyTimeoutErrorCode)
+}
+
+// trace returns the hooks timing the phases of the round trips.
+func (pd *phaseDeadlines) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		ConnectStart: func(string, string) { pd.start(pd.timeouts.Connect, connectTimeoutErrorCode) },
+		ConnectDone:  func(string, string, error) { pd.stop() },
+		TLSHandshakeStart: func() {
+			pd.start(pd.timeouts.TLSHandshake, tlsHandshakeTimeoutErrorCode)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) { pd.stop() },
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			pd.start(pd.timeouts.ResponseHeaders, responseHeadersTimeoutErrorCode)
+		},
+		GotFirstResponseByte: pd.stop,
+	}
+}
+
+// timedOut returns whether one of the phases timed out.
+func (pd *phaseDeadlines) timedOut() bool {
+	return pd.expiredCode() != 0
+}
+
+func (pd *phaseDeadlines) expiredCode() errCode {
+	if pd == nil {
+		return 0
+	}
+	pd.mx.Lock()
+	defer pd.mx.Unlock()
+	return pd.expired
+}
+
+// wrap returns the error of the request, annotated with the phase that timed
+// out, if any.
+func (pd *phaseDeadlines) wrap(err error) error {
+	expired := pd.expiredCode()
+	if err == nil || expired == 0 {
+		return err
+	}
+	return NewK6Error(expired, phaseTimeoutErrorCodeMsgs[expired], err)
+}
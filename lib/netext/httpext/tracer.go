@@ -22,16 +22,23 @@ type Trail struct {
 	// Total request duration, excluding DNS lookup and connect time.
 	Duration time.Duration
 
-	Blocked        time.Duration // Waiting to acquire a connection.
-	Connecting     time.Duration // Connecting to remote host.
-	TLSHandshaking time.Duration // Executing TLS handshake.
-	Sending        time.Duration // Writing request.
-	Waiting        time.Duration // Waiting for first byte.
-	Receiving      time.Duration // Receiving response.
+	Blocked         time.Duration // Waiting to acquire a connection.
+	Connecting      time.Duration // Connecting to remote host.
+	ProxyConnecting time.Duration // Establishing the tunnel through a proxy.
+	TLSHandshaking  time.Duration // Executing TLS handshake.
+	Sending         time.Duration // Writing request.
+	Waiting         time.Duration // Waiting for first byte.
+	Receiving       time.Duration // Receiving response.
 
 	// Detailed connection information.
 	ConnReused     bool
 	ConnRemoteAddr net.Addr
+	// Proxied is true when the connection was to a proxy.
+	Proxied bool
+	// TLSHandshake is true when a TLS handshake was made for the request,
+	// and TLSResumed when it resumed the session of a previous connection.
+	TLSHandshake bool
+	TLSResumed   bool
 
 	Failed null.Bool
 	// Populated by SaveSamples()
@@ -44,7 +51,9 @@ type Trail struct {
 func (tr *Trail) SaveSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metrics.TagsAndMeta) {
 	tr.Tags = ctm.Tags
 	tr.Metadata = ctm.Metadata
-	tr.Samples = make([]metrics.Sample, 0, 9) // this is with 1 more for a possible HTTPReqFailed
+	// this is with 3 more for a possible HTTPReqProxyConnecting, the kind of
+	// the TLS handshake and HTTPReqFailed
+	tr.Samples = make([]metrics.Sample, 0, 11)
 	tr.Samples = append(tr.Samples, []metrics.Sample{
 		{
 			TimeSeries: metrics.TimeSeries{
@@ -119,6 +128,32 @@ func (tr *Trail) SaveSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metric
 			Value:    metrics.D(tr.Receiving),
 		},
 	}...)
+	if tr.Proxied {
+		tr.Samples = append(tr.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.HTTPReqProxyConnecting,
+				Tags:   ctm.Tags,
+			},
+			Time:     tr.EndTime,
+			Metadata: ctm.Metadata,
+			Value:    metrics.D(tr.ProxyConnecting),
+		})
+	}
+	if tr.TLSHandshake {
+		handshakeMetric := builtinMetrics.HTTPReqTLSFullHandshaking
+		if tr.TLSResumed {
+			handshakeMetric = builtinMetrics.HTTPReqTLSResumedHandshaking
+		}
+		tr.Samples = append(tr.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: handshakeMetric,
+				Tags:   ctm.Tags,
+			},
+			Time:     tr.EndTime,
+			Metadata: ctm.Metadata,
+			Value:    metrics.D(tr.TLSHandshaking),
+		})
+	}
 }
 
 // GetSamples implements the metrics.SampleContainer interface.
@@ -156,6 +191,15 @@ type Tracer struct {
 
 	connReused     bool
 	connRemoteAddr net.Addr
+
+	// set by the first successful TLS handshake, and if it was resumed
+	tlsHandshake int32
+	tlsResumed   int32
+
+	// target is the "host:port" of the request, a different one in GetConn
+	// is of a proxy
+	target  string
+	proxied bool
 }
 
 // Trace returns a premade ClientTrace that calls all of the Tracer's hooks.
@@ -184,8 +228,9 @@ func now() int64 {
 // Keep in mind that GetConn won't be called if a connection
 // is reused though, for example when there's a redirect.
 // If it's called, it will be called before all other hooks.
-func (t *Tracer) GetConn(_ string) {
+func (t *Tracer) GetConn(hostPort string) {
 	t.getConn = now()
+	t.proxied = t.target != "" && hostPort != t.target
 }
 
 // ConnectStart is called when a new connection's Dial begins.
@@ -239,9 +284,12 @@ func (t *Tracer) TLSHandshakeStart() {
 // it will be called after TLSHandshakeStart() and before GotConn().
 // If the request was cancelled, this could be called after the
 // RoundTrip() method has returned.
-func (t *Tracer) TLSHandshakeDone(_ tls.ConnectionState, err error) {
+func (t *Tracer) TLSHandshakeDone(state tls.ConnectionState, err error) {
 	if err == nil {
 		atomic.CompareAndSwapInt64(&t.tlsHandshakeDone, 0, now())
+		if atomic.CompareAndSwapInt32(&t.tlsHandshake, 0, 1) && state.DidResume {
+			atomic.StoreInt32(&t.tlsResumed, 1)
+		}
 	}
 	// if there is an error it will be returned by the http call
 }
@@ -318,6 +366,9 @@ func (t *Tracer) Done() *Trail {
 	trail := Trail{
 		ConnReused:     t.connReused,
 		ConnRemoteAddr: t.connRemoteAddr,
+		Proxied:        t.proxied,
+		TLSHandshake:   !t.connReused && atomic.LoadInt32(&t.tlsHandshake) == 1,
+		TLSResumed:     atomic.LoadInt32(&t.tlsResumed) == 1,
 	}
 
 	if t.gotConn != 0 && t.getConn != 0 && t.gotConn > t.getConn {
@@ -343,6 +394,18 @@ func (t *Tracer) Done() *Trail {
 	if tlsHandshakeDone != 0 && tlsHandshakeStart != 0 {
 		trail.TLSHandshaking = time.Duration(tlsHandshakeDone - tlsHandshakeStart)
 	}
+	if t.proxied && connectDone != 0 {
+		// The tunnel through the proxy is established between the connection
+		// to the proxy and the TLS handshake with the target, or the connection
+		// being ready for the plain HTTP requests
+		tunnelDone := tlsHandshakeStart
+		if tunnelDone == 0 {
+			tunnelDone = gotConn
+		}
+		if tunnelDone > connectDone {
+			trail.ProxyConnecting = time.Duration(tunnelDone - connectDone)
+		}
+	}
 	if wroteRequest != 0 {
 		switch {
 		case tlsHandshakeDone != 0:
@@ -351,7 +414,7 @@ func (t *Tracer) Done() *Trail {
 			trail.Sending = time.Duration(wroteRequest - tlsHandshakeDone)
 		case connectDone != 0:
 			// Otherwise, use the end of the normal connection
-			trail.Sending = time.Duration(wroteRequest - connectDone)
+			trail.Sending = time.Duration(wroteRequest-connectDone) - trail.ProxyConnecting
 		default:
 			// Finally, this handles the strange HTTP/2 case where the GotConn() hook
 			// gets called first, but with Reused=false
@@ -377,7 +440,7 @@ func (t *Tracer) Done() *Trail {
 
 	// Calculate total times using adjusted values.
 	trail.EndTime = done
-	trail.ConnDuration = trail.Connecting + trail.TLSHandshaking
+	trail.ConnDuration = trail.Connecting + trail.ProxyConnecting + trail.TLSHandshaking
 	trail.Duration = trail.Sending + trail.Waiting + trail.Receiving
 
 	return &trail
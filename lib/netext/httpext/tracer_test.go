@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/http/httptrace"
+	"net/url"
 	"runtime"
 	"strings"
 	"testing"
@@ -145,7 +146,11 @@ func TestTracer(t *testing.T) { //nolint:tparallel
 
 			assert.Equal(t, strings.TrimPrefix(srv.URL, "https://"), trail.ConnRemoteAddr.String())
 
-			assert.Len(t, samples, 8)
+			if isReuse {
+				assert.Len(t, samples, 8)
+			} else {
+				assert.Len(t, samples, 9, "a new connection has the sample of its full TLS handshake")
+			}
 			seenMetrics := map[*metrics.Metric]bool{}
 			for i, s := range samples {
 				assert.NotContains(t, seenMetrics, s.Metric)
@@ -164,7 +169,7 @@ func TestTracer(t *testing.T) { //nolint:tparallel
 						break
 					}
 					fallthrough
-				case builtinMetrics.HTTPReqDuration, builtinMetrics.HTTPReqBlocked, builtinMetrics.HTTPReqSending, builtinMetrics.HTTPReqWaiting, builtinMetrics.HTTPReqReceiving:
+				case builtinMetrics.HTTPReqDuration, builtinMetrics.HTTPReqBlocked, builtinMetrics.HTTPReqSending, builtinMetrics.HTTPReqWaiting, builtinMetrics.HTTPReqReceiving, builtinMetrics.HTTPReqTLSFullHandshaking:
 					assert.True(t, s.Value > 0.0, "%s is <= 0", s.Metric.Name)
 				default:
 					t.Errorf("unexpected metric: %s", s.Metric.Name)
@@ -290,3 +295,121 @@ func TestCancelledRequest(t *testing.T) {
 		}
 	})
 }
+
+func TestTracerProxy(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewTLSServer(httpbin.New().Handler())
+	defer srv.Close()
+
+	const tunnelDelay = 50 * time.Millisecond
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer func() { _ = target.Close() }()
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		conn, _, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		time.Sleep(tunnelDelay)
+		if _, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+			return
+		}
+		go func() { _, _ = io.Copy(target, conn) }()
+		_, _ = io.Copy(conn, target)
+	}))
+	defer proxy.Close()
+
+	transport, ok := srv.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	transport.Proxy = func(*http.Request) (*url.URL, error) { return url.Parse(proxy.URL) }
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/get", nil)
+	require.NoError(t, err)
+	tracer := &Tracer{target: canonicalAddr(req.URL)}
+	res, err := transport.RoundTrip(req.WithContext(httptrace.WithClientTrace(context.Background(), tracer.Trace())))
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	trail := tracer.Done()
+	assert.True(t, trail.Proxied)
+	assert.GreaterOrEqual(t, trail.ProxyConnecting, tunnelDelay)
+	assert.Less(t, trail.TLSHandshaking, tunnelDelay, "the tunnel shouldn't be a part of the TLS handshake")
+	assert.Equal(t, trail.Connecting+trail.ProxyConnecting+trail.TLSHandshaking, trail.ConnDuration)
+
+	trail.SaveSamples(builtinMetrics, &metrics.TagsAndMeta{Tags: registry.RootTagSet()})
+	samples := trail.GetSamples()
+	proxySample := samples[len(samples)-2] // the last one is of the TLS handshake
+	assert.Equal(t, builtinMetrics.HTTPReqProxyConnecting, proxySample.Metric)
+	assert.Equal(t, metrics.D(trail.ProxyConnecting), proxySample.Value)
+}
+
+func TestCanonicalAddr(t *testing.T) {
+	t.Parallel()
+	for rawURL, expected := range map[string]string{
+		"http://example.com/path":    "example.com:80",
+		"https://Example.com/":       "Example.com:443",
+		"http://example.com:8080/":   "example.com:8080",
+		"https://[::1]/":             "[::1]:443",
+		"https://bücher.example/":    "xn--bcher-kva.example:443",
+		"wss://example.com/socket":   "example.com:443",
+		"http://127.0.0.1:6565/path": "127.0.0.1:6565",
+	} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		assert.Equal(t, expected, canonicalAddr(u), rawURL)
+	}
+}
+
+func TestTracerTLSResumption(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewTLSServer(httpbin.New().Handler())
+	defer srv.Close()
+
+	transport, ok := srv.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	transport.DisableKeepAlives = true
+	transport.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+
+	for _, expected := range []*metrics.Metric{
+		builtinMetrics.HTTPReqTLSFullHandshaking,
+		builtinMetrics.HTTPReqTLSResumedHandshaking,
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/get", nil)
+		require.NoError(t, err)
+		tracer := &Tracer{}
+		res, err := transport.RoundTrip(req.WithContext(httptrace.WithClientTrace(context.Background(), tracer.Trace())))
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		trail := tracer.Done()
+		assert.True(t, trail.TLSHandshake)
+		assert.Equal(t, expected == builtinMetrics.HTTPReqTLSResumedHandshaking, trail.TLSResumed)
+		trail.SaveSamples(builtinMetrics, &metrics.TagsAndMeta{Tags: registry.RootTagSet()})
+		samples := trail.GetSamples()
+		handshakeSample := samples[len(samples)-1]
+		assert.Equal(t, expected, handshakeSample.Metric)
+		assert.Equal(t, metrics.D(trail.TLSHandshaking), handshakeSample.Value)
+	}
+}
@@ -7,8 +7,12 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptrace"
+	"net/url"
 	"strconv"
 	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
 
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext"
@@ -22,6 +26,7 @@ type transport struct {
 	state            *lib.State
 	tagsAndMeta      *metrics.TagsAndMeta
 	responseCallback func(int) bool
+	deadlines        *phaseDeadlines // nil without phase timeouts
 
 	lastRequest     *unfinishedRequest
 	lastRequestLock *sync.Mutex
@@ -102,18 +107,20 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagURL, nameTagValue)
 	}
 
+	// The full URL is metadata, so it's kept even when the indexed tags are grouped by name
+	tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagFullURL, cleanURL)
 	tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagMethod, unfReq.request.Method)
 
 	if unfReq.err != nil {
 		result.errorCode, result.errorMsg = errorCodeForError(unfReq.err)
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagError, result.errorMsg)
-		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, strconv.Itoa(int(result.errorCode)))
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, metrics.IntTagValue(int(result.errorCode)))
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagStatus, "0")
 	} else {
-		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagStatus, strconv.Itoa(unfReq.response.StatusCode))
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagStatus, metrics.IntTagValue(unfReq.response.StatusCode))
 		if unfReq.response.StatusCode >= 400 {
 			result.errorCode = errCode(1000 + unfReq.response.StatusCode)
-			tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, strconv.Itoa(int(result.errorCode)))
+			tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, metrics.IntTagValue(int(result.errorCode)))
 		}
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagProto, unfReq.response.Proto)
 
@@ -202,8 +209,12 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	t.processLastSavedRequest(nil)
 
 	ctx := req.Context()
-	tracer := &Tracer{}
-	reqWithTracer := req.WithContext(httptrace.WithClientTrace(ctx, tracer.Trace()))
+	tracer := &Tracer{target: canonicalAddr(req.URL)}
+	traceCtx := ctx
+	if t.deadlines != nil {
+		traceCtx = httptrace.WithClientTrace(traceCtx, t.deadlines.trace())
+	}
+	reqWithTracer := req.WithContext(httptrace.WithClientTrace(traceCtx, tracer.Trace()))
 	resp, err := t.state.Transport.RoundTrip(reqWithTracer)
 
 	var netError net.Error
@@ -215,6 +226,7 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 			err = NewK6Error(requestTimeoutErrorCode, requestTimeoutErrorCodeMsg, netError)
 		}
 	}
+	err = t.deadlines.wrap(err)
 
 	t.saveCurrentRequest(&unfinishedRequest{
 		ctx:      ctx,
@@ -226,3 +238,33 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	return resp, err
 }
+
+// canonicalAddr returns the "host:port" of the URL the way net/http passes it
+// to the GetConn hook of httptrace, when the connection isn't to a proxy.
+func canonicalAddr(u *url.URL) string {
+	host := u.Hostname()
+	if !isASCII(host) {
+		if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+			host = ascii
+		}
+	}
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https", "wss":
+			port = "443"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
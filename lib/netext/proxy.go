@@ -0,0 +1,185 @@
+package netext
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// Proxy chooses the proxy of the requests, for http.Transport.Proxy.
+type Proxy struct {
+	url *url.URL
+	pac *pacScript
+}
+
+// NewProxy returns the Proxy of the given settings, which have to be valid.
+func NewProxy(settings types.Proxy) (*Proxy, error) {
+	if settings.PAC != "" {
+		pac, err := newPACScript(settings.PAC)
+		if err != nil {
+			return nil, err
+		}
+		return &Proxy{pac: pac}, nil
+	}
+	u, err := url.Parse(settings.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	return &Proxy{url: u}, nil
+}
+
+// For returns the URL of the proxy of the request, or nil if it's direct.
+func (p *Proxy) For(req *http.Request) (*url.URL, error) {
+	if p.pac == nil {
+		return p.url, nil
+	}
+	result, err := p.pac.findProxy(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	return parsePACResult(result)
+}
+
+// pacScript is a proxy auto-config script. Its runtime isn't safe for
+// concurrent use, so the calls of FindProxyForURL are serialized.
+type pacScript struct {
+	mx   sync.Mutex
+	rt   *goja.Runtime
+	find goja.Callable
+}
+
+func newPACScript(src string) (*pacScript, error) {
+	rt := goja.New()
+	for name, fn := range pacFunctions {
+		if err := rt.Set(name, fn); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := rt.RunString(src); err != nil {
+		return nil, fmt.Errorf("couldn't evaluate the PAC script: %w", err)
+	}
+	find, ok := goja.AssertFunction(rt.Get("FindProxyForURL"))
+	if !ok {
+		return nil, fmt.Errorf("the PAC script doesn't define the FindProxyForURL function")
+	}
+	return &pacScript{rt: rt, find: find}, nil
+}
+
+// findProxy returns the result of FindProxyForURL for the URL.
+func (ps *pacScript) findProxy(u *url.URL) (string, error) {
+	ps.mx.Lock()
+	defer ps.mx.Unlock()
+
+	v, err := ps.find(goja.Undefined(), ps.rt.ToValue(u.String()), ps.rt.ToValue(u.Hostname()))
+	if err != nil {
+		return "", fmt.Errorf("the FindProxyForURL function of the PAC script failed: %w", err)
+	}
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return "", nil
+	}
+	return v.String(), nil
+}
+
+// parsePACResult returns the proxy of the first entry of a FindProxyForURL
+// result, like "PROXY 10.0.0.1:3128; DIRECT", or nil if it's DIRECT. The
+// fallback entries aren't tried when the first proxy fails.
+func parsePACResult(result string) (*url.URL, error) {
+	entry := strings.TrimSpace(strings.SplitN(result, ";", 2)[0])
+	fields := strings.Fields(entry)
+	if len(fields) == 0 || strings.EqualFold(fields[0], "DIRECT") {
+		return nil, nil //nolint:nilnil // a direct connection doesn't have a proxy
+	}
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid PAC result '%s'", entry)
+	}
+	var scheme string
+	switch strings.ToUpper(fields[0]) {
+	case "PROXY", "HTTP":
+		scheme = "http"
+	case "HTTPS":
+		scheme = "https"
+	case "SOCKS", "SOCKS5":
+		scheme = "socks5"
+	default:
+		return nil, fmt.Errorf("unsupported proxy type '%s' in the PAC result '%s'", fields[0], entry)
+	}
+	return &url.URL{Scheme: scheme, Host: fields[1]}, nil
+}
+
+// pacFunctions are the predefined functions of the PAC scripts, except for the
+// time based ones. The names are resolved by the system resolver, not the one
+// of k6.
+var pacFunctions = map[string]interface{}{
+	"isPlainHostName": func(host string) bool {
+		return !strings.Contains(host, ".")
+	},
+	"dnsDomainIs": func(host, domain string) bool {
+		return strings.HasSuffix(strings.ToLower(host), strings.ToLower(domain))
+	},
+	"localHostOrDomainIs": func(host, hostdom string) bool {
+		return strings.EqualFold(host, hostdom) ||
+			(!strings.Contains(host, ".") && strings.HasPrefix(strings.ToLower(hostdom), strings.ToLower(host)+"."))
+	},
+	"isResolvable": func(host string) bool {
+		return pacResolve(host) != nil
+	},
+	"dnsResolve": func(host string) interface{} {
+		if ip := pacResolve(host); ip != nil {
+			return ip.String()
+		}
+		return nil
+	},
+	"isInNet": func(host, pattern, mask string) bool {
+		ip, patternIP, maskIP := pacResolve(host), net.ParseIP(pattern).To4(), net.ParseIP(mask).To4()
+		if ip == nil || patternIP == nil || maskIP == nil {
+			return false
+		}
+		return ip.Mask(net.IPMask(maskIP)).Equal(patternIP.Mask(net.IPMask(maskIP)))
+	},
+	"myIpAddress": func() string {
+		addrs, err := net.InterfaceAddrs()
+		if err == nil {
+			for _, addr := range addrs {
+				if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+					return ipNet.IP.String()
+				}
+			}
+		}
+		return "127.0.0.1"
+	},
+	"dnsDomainLevels": func(host string) int {
+		return strings.Count(host, ".")
+	},
+	"shExpMatch": func(str, shexp string) bool {
+		expr := regexp.QuoteMeta(shexp)
+		expr = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(expr)
+		matched, err := regexp.MatchString("^"+expr+"$", str)
+		return err == nil && matched
+	},
+}
+
+// pacResolve returns the IPv4 address of the host, or nil if it can't be
+// resolved.
+func pacResolve(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.To4()
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4
+		}
+	}
+	return nil
+}
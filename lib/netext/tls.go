@@ -2,6 +2,7 @@
 package netext
 
 import (
+	"context"
 	"crypto/tls"
 
 	"golang.org/x/crypto/ocsp"
@@ -31,6 +32,27 @@ const (
 	TLS_1_3                            = "tls1.3"
 )
 
+// TLSOverride overrides the client certificate, by the name of its tlsAuth
+// entry, and the server name (SNI) of the TLS connections of a request.
+type TLSOverride struct {
+	ClientCert string
+	ServerName string
+}
+
+type tlsOverrideKey struct{}
+
+// WithTLSOverride embeds a TLSOverride in ctx.
+func WithTLSOverride(ctx context.Context, o TLSOverride) context.Context {
+	return context.WithValue(ctx, tlsOverrideKey{}, o)
+}
+
+// GetTLSOverride returns the TLSOverride of ctx, the zero one if it doesn't
+// have one.
+func GetTLSOverride(ctx context.Context) TLSOverride {
+	o, _ := ctx.Value(tlsOverrideKey{}).(TLSOverride)
+	return o
+}
+
 // TLSInfo keeps TLS details
 type TLSInfo struct {
 	Version     string
@@ -9,6 +9,8 @@ import (
 	"net"
 	"reflect"
 
+	"go.k6.io/k6/lib/netext/faults"
+	"go.k6.io/k6/lib/trace"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"gopkg.in/guregu/null.v3"
@@ -19,6 +21,10 @@ import (
 // iterations+vus, or stages)
 const DefaultScenarioName = "default"
 
+// DefaultCorrelationIDHeader is the default header in which the iteration's
+// correlation ID is sent with the requests.
+const DefaultCorrelationIDHeader = "X-K6-Correlation-ID"
+
 // DefaultSummaryTrendStats are the default trend columns shown in the test summary output
 //
 //nolint:gochecknoglobals
@@ -71,6 +77,45 @@ func (v *TLSVersions) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// A list of the key exchange curves of the TLS handshakes, in the order of preference.
+// Marshals and unmarshals from a list of names, eg. "X25519MLKEM768".
+type TLSCurves []tls.CurveID
+
+// MarshalJSON will return the JSON representation according to the supported TLS curves
+func (c *TLSCurves) MarshalJSON() ([]byte, error) {
+	curveNames := make([]string, 0, len(*c))
+	for _, id := range *c {
+		curveName, ok := SupportedTLSCurvesToString[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown curve id '%d'", id)
+		}
+		curveNames = append(curveNames, curveName)
+	}
+
+	return json.Marshal(curveNames)
+}
+
+// UnmarshalJSON unmarshals the curves from a list of their names
+func (c *TLSCurves) UnmarshalJSON(data []byte) error {
+	var curveNames []string
+	if err := StrictJSONUnmarshal(data, &curveNames); err != nil {
+		return err
+	}
+
+	curveIDs := make([]tls.CurveID, 0, len(curveNames))
+	for _, name := range curveNames {
+		curveID, ok := SupportedTLSCurves[name]
+		if !ok {
+			return fmt.Errorf("unknown curve '%s'", name)
+		}
+		curveIDs = append(curveIDs, curveID)
+	}
+
+	*c = curveIDs
+
+	return nil
+}
+
 // A list of TLS cipher suites.
 // Marshals and unmarshals from a list of names, eg. "TLS_ECDHE_RSA_WITH_RC4_128_SHA".
 type TLSCipherSuites []uint16
@@ -118,6 +163,11 @@ type TLSAuthFields struct {
 
 	// Domains to present the certificate to. May contain wildcards, eg. "*.example.com".
 	Domains []string `json:"domains"`
+
+	// Name of the certificate, for the requests to present it with their tlsAuth param.
+	Name string `json:"name,omitempty"`
+	// The certificates of a pool are rotated over the VUs, each VU presents only one of them.
+	Pool string `json:"pool,omitempty"`
 }
 
 // Defines a TLS client certificate to present to certain hosts.
@@ -255,6 +305,15 @@ type Options struct {
 	// Default User Agent string for HTTP requests.
 	UserAgent null.String `json:"userAgent" envconfig:"K6_USER_AGENT"`
 
+	// The header in which the iteration's correlation ID is sent with every
+	// HTTP and gRPC request, an empty value disables it.
+	CorrelationIDHeader null.String `json:"correlationIdHeader" envconfig:"K6_CORRELATION_ID_HEADER"`
+
+	// The format in which a new trace context is propagated with every HTTP
+	// request (w3c, b3 or jaeger) and the rate at which those are sampled.
+	TracePropagator null.String `json:"tracePropagator" envconfig:"K6_TRACE_PROPAGATOR"`
+	TraceSampling   null.Float  `json:"traceSampling" envconfig:"K6_TRACE_SAMPLING"`
+
 	// How many batch requests are allowed in parallel, in total and per host?
 	Batch        null.Int `json:"batch" envconfig:"K6_BATCH"`
 	BatchPerHost null.Int `json:"batchPerHost" envconfig:"K6_BATCH_PER_HOST"`
@@ -265,11 +324,16 @@ type Options struct {
 	// Accept invalid or untrusted TLS certificates.
 	InsecureSkipTLSVerify null.Bool `json:"insecureSkipTLSVerify" envconfig:"K6_INSECURE_SKIP_TLS_VERIFY"`
 
-	// Specify TLS versions and cipher suites, and present client certificates.
+	// Specify TLS versions, cipher suites and key exchange curves, and present client certificates.
 	TLSCipherSuites *TLSCipherSuites `json:"tlsCipherSuites" envconfig:"K6_TLS_CIPHER_SUITES"`
+	TLSCurves       *TLSCurves       `json:"tlsCurves" envconfig:"K6_TLS_CURVES"`
 	TLSVersion      *TLSVersions     `json:"tlsVersion" ignored:"true"`
 	TLSAuth         []*TLSAuth       `json:"tlsAuth" envconfig:"K6_TLSAUTH"`
 
+	// Resume the TLS sessions of the previous connections of a VU with session tickets,
+	// instead of making full handshakes for all of its new connections.
+	TLSSessionResumption null.Bool `json:"tlsSessionResumption" envconfig:"K6_TLS_SESSION_RESUMPTION"`
+
 	// Throw warnings (eg. failed HTTP requests) as errors instead of simply logging them.
 	Throw null.Bool `json:"throw" envconfig:"K6_THROW"`
 
@@ -278,6 +342,18 @@ type Options struct {
 	// metric on a nonexistent metric named 'real_metric{tagA:valueA,tagB:valueB}'.
 	Thresholds map[string]metrics.Thresholds `json:"thresholds" envconfig:"K6_THRESHOLDS"`
 
+	// Define thresholds on expressions combining several metrics, by name; these take the
+	// form of 'name=["errors.count / http_reqs.count < 0.01"]'.
+	CompositeThresholds map[string]metrics.CompositeThresholds `json:"compositeThresholds" envconfig:"K6_COMPOSITE_THRESHOLDS"` //nolint:lll
+
+	// Define service level objectives on the latency of trend metrics, by name; the apdex score
+	// and the burn rates of their error budgets are emitted as the slo_apdex and slo_burn_rate
+	// metrics, tagged with the slo name, so thresholds can be defined on them.
+	SLOs map[string]metrics.SLO `json:"slos" ignored:"true"`
+
+	// Abort the test as soon as the rate of the failed requests over a window is too high
+	AbortOnErrorRate *metrics.ErrorRateAbort `json:"abortOnErrorRate" ignored:"true"`
+
 	// Blacklist IP ranges that tests may not contact. Mainly useful in hosted setups.
 	BlacklistIPs []*IPNet `json:"blacklistIPs" envconfig:"K6_BLACKLIST_IPS"`
 
@@ -306,9 +382,44 @@ type Options struct {
 	// Summary trend stats for trend metrics (response times) in CLI output
 	SummaryTrendStats []string `json:"summaryTrendStats" envconfig:"K6_SUMMARY_TREND_STATS"`
 
+	// Additional summary trend stats of some trend metrics, by metric name, the ones of a
+	// metric also apply to its submetrics. Can't be set through env vars.
+	SummaryTrendStatsPerMetric map[string][]string `json:"summaryTrendStatsPerMetric" ignored:"true"`
+
+	// Tags, among scenario and group, by which the metrics are automatically broken down in
+	// submetrics for the end-of-test summary, one for each value of the tags.
+	SummaryBreakdown []string `json:"summaryBreakdown" envconfig:"K6_SUMMARY_BREAKDOWN"`
+
+	// Series of the values of some metrics over fixed time buckets passed to handleSummary,
+	// optionally broken down by tags, so the custom reports can chart them. Can't be set
+	// through env vars.
+	SummaryTimeSeries *metrics.SummaryTimeSeries `json:"summaryTimeSeries" ignored:"true"`
+
 	// Summary time unit for summary metrics (response times) in CLI output
 	SummaryTimeUnit null.String `json:"summaryTimeUnit" envconfig:"K6_SUMMARY_TIME_UNIT"`
 
+	// Precision, in significant digits, of the histograms the trend metrics switch to
+	// when they have too many values to keep all of them
+	TrendSignificantDigits null.Int `json:"trendSignificantDigits" envconfig:"K6_TREND_SIGNIFICANT_DIGITS"`
+
+	// Limit of the unique time series (metric and tags combinations), disabled by default,
+	// and what happens to the samples of the new ones once it's reached
+	MaxTimeSeries       null.Int    `json:"maxTimeSeries" envconfig:"K6_MAX_TIME_SERIES"`
+	MaxTimeSeriesAction null.String `json:"maxTimeSeriesAction" envconfig:"K6_MAX_TIME_SERIES_ACTION"`
+
+	// Memory, in megabytes, above which the test run is stopped before k6 runs out of it; disabled by default
+	MaxMemory null.Int `json:"maxMemory" envconfig:"K6_MAX_MEMORY"`
+
+	// Emit the metrics of the health of the load generator, like its CPU and memory usage,
+	// the lag of the event loops of the VUs and the fill of their sample buffers
+	GeneratorMetrics null.Bool `json:"generatorMetrics" envconfig:"K6_GENERATOR_METRICS"`
+
+	// Unplanned VUs initialized in the background from the start instead of when they are needed.
+	// Only the arrival-rate and traffic-replay executors have unplanned VUs, the ones over their
+	// preAllocatedVUs. The other executors, like ramping-vus, initialize all of their VUs before
+	// the test starts, and the VUs that externally-controlled adds over its vus aren't warmed up.
+	WarmVUs null.Int `json:"warmVUs" envconfig:"K6_WARM_VUS"`
+
 	// Which system tags to include with metrics ("method", "vu" etc.)
 	// Use pointer for identifying whether user provide any tag or not.
 	SystemTags *metrics.SystemTagSet `json:"systemTags" envconfig:"K6_SYSTEM_TAGS"`
@@ -325,6 +436,19 @@ type Options struct {
 	// Discard Http Responses Body
 	DiscardResponseBodies null.Bool `json:"discardResponseBodies" envconfig:"K6_DISCARD_RESPONSE_BODIES"`
 
+	// Write the requests and responses of the failed checks and of the non-2xx statuses to a file
+	CaptureFailures null.String `json:"captureFailures" envconfig:"K6_CAPTURE_FAILURES"`
+
+	// Number of the captured requests per failing check or status, and size of their captured bodies
+	CaptureFailuresLimit    null.Int `json:"captureFailuresLimit" envconfig:"K6_CAPTURE_FAILURES_LIMIT"`
+	CaptureFailuresBodySize null.Int `json:"captureFailuresBodySize" envconfig:"K6_CAPTURE_FAILURES_BODY_SIZE"`
+
+	// Faults injected in a percentage of the HTTP requests, like delays and connection resets
+	Faults []faults.Fault `json:"faults" ignored:"true"`
+
+	// Caps on the network bandwidth of each VU, to emulate constrained clients
+	VUBandwidth *types.Bandwidth `json:"vuBandwidth" ignored:"true"`
+
 	// Redirect console logging to a file
 	ConsoleOutput null.String `json:"-" envconfig:"K6_CONSOLE_OUTPUT"`
 
@@ -410,6 +534,15 @@ func (o Options) Apply(opts Options) Options {
 	if opts.UserAgent.Valid {
 		o.UserAgent = opts.UserAgent
 	}
+	if opts.CorrelationIDHeader.Valid {
+		o.CorrelationIDHeader = opts.CorrelationIDHeader
+	}
+	if opts.TracePropagator.Valid {
+		o.TracePropagator = opts.TracePropagator
+	}
+	if opts.TraceSampling.Valid {
+		o.TraceSampling = opts.TraceSampling
+	}
 	if opts.Batch.Valid {
 		o.Batch = opts.Batch
 	}
@@ -425,18 +558,33 @@ func (o Options) Apply(opts Options) Options {
 	if opts.TLSCipherSuites != nil {
 		o.TLSCipherSuites = opts.TLSCipherSuites
 	}
+	if opts.TLSCurves != nil {
+		o.TLSCurves = opts.TLSCurves
+	}
 	if opts.TLSVersion != nil {
 		o.TLSVersion = opts.TLSVersion
 	}
 	if opts.TLSAuth != nil {
 		o.TLSAuth = opts.TLSAuth
 	}
+	if opts.TLSSessionResumption.Valid {
+		o.TLSSessionResumption = opts.TLSSessionResumption
+	}
 	if opts.Throw.Valid {
 		o.Throw = opts.Throw
 	}
 	if opts.Thresholds != nil {
 		o.Thresholds = opts.Thresholds
 	}
+	if opts.CompositeThresholds != nil {
+		o.CompositeThresholds = opts.CompositeThresholds
+	}
+	if opts.SLOs != nil {
+		o.SLOs = opts.SLOs
+	}
+	if opts.AbortOnErrorRate != nil {
+		o.AbortOnErrorRate = opts.AbortOnErrorRate
+	}
 	if opts.BlacklistIPs != nil {
 		o.BlacklistIPs = opts.BlacklistIPs
 	}
@@ -464,9 +612,36 @@ func (o Options) Apply(opts Options) Options {
 	if opts.SummaryTrendStats != nil {
 		o.SummaryTrendStats = opts.SummaryTrendStats
 	}
+	if opts.SummaryTrendStatsPerMetric != nil {
+		o.SummaryTrendStatsPerMetric = opts.SummaryTrendStatsPerMetric
+	}
+	if opts.SummaryBreakdown != nil {
+		o.SummaryBreakdown = opts.SummaryBreakdown
+	}
+	if opts.SummaryTimeSeries != nil {
+		o.SummaryTimeSeries = opts.SummaryTimeSeries
+	}
 	if opts.SummaryTimeUnit.Valid {
 		o.SummaryTimeUnit = opts.SummaryTimeUnit
 	}
+	if opts.TrendSignificantDigits.Valid {
+		o.TrendSignificantDigits = opts.TrendSignificantDigits
+	}
+	if opts.MaxTimeSeries.Valid {
+		o.MaxTimeSeries = opts.MaxTimeSeries
+	}
+	if opts.MaxTimeSeriesAction.Valid {
+		o.MaxTimeSeriesAction = opts.MaxTimeSeriesAction
+	}
+	if opts.MaxMemory.Valid {
+		o.MaxMemory = opts.MaxMemory
+	}
+	if opts.GeneratorMetrics.Valid {
+		o.GeneratorMetrics = opts.GeneratorMetrics
+	}
+	if opts.WarmVUs.Valid {
+		o.WarmVUs = opts.WarmVUs
+	}
 	if opts.SystemTags != nil {
 		o.SystemTags = opts.SystemTags
 	}
@@ -479,6 +654,21 @@ func (o Options) Apply(opts Options) Options {
 	if opts.DiscardResponseBodies.Valid {
 		o.DiscardResponseBodies = opts.DiscardResponseBodies
 	}
+	if opts.CaptureFailures.Valid {
+		o.CaptureFailures = opts.CaptureFailures
+	}
+	if opts.CaptureFailuresLimit.Valid {
+		o.CaptureFailuresLimit = opts.CaptureFailuresLimit
+	}
+	if opts.CaptureFailuresBodySize.Valid {
+		o.CaptureFailuresBodySize = opts.CaptureFailuresBodySize
+	}
+	if opts.Faults != nil {
+		o.Faults = opts.Faults
+	}
+	if opts.VUBandwidth != nil {
+		o.VUBandwidth = opts.VUBandwidth
+	}
 	if opts.ConsoleOutput.Valid {
 		o.ConsoleOutput = opts.ConsoleOutput
 	}
@@ -498,6 +688,18 @@ func (o Options) Apply(opts Options) Options {
 	return o
 }
 
+// DiscardResponseBodiesFor returns if the response bodies of the requests made
+// by the given scenario are discarded by default, either because of its own
+// discardResponseBodies option or the global one.
+func (o Options) DiscardResponseBodiesFor(scenario string) bool {
+	if sc, ok := o.Scenarios[scenario]; ok {
+		if discard := sc.GetDiscardResponseBodies(); discard.Valid {
+			return discard.Bool
+		}
+	}
+	return o.DiscardResponseBodies.Bool
+}
+
 // Validate checks if all of the specified options make sense
 func (o Options) Validate() []error {
 	// TODO: validate all of the other options... that we should have already been validating...
@@ -517,6 +719,67 @@ func (o Options) Validate() []error {
 					o.ExecutionSegment, o.ExecutionSegmentSequence))
 		}
 	}
+	if o.TracePropagator.String != "" {
+		if err := trace.ValidatePropagation(o.TracePropagator.String, o.TraceSampling.Float64); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if o.TrendSignificantDigits.Valid {
+		if _, err := metrics.NewHistogram(int(o.TrendSignificantDigits.Int64)); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if o.MaxTimeSeries.Valid && o.MaxTimeSeries.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("maxTimeSeries can't be negative, got %d", o.MaxTimeSeries.Int64))
+	}
+	if o.MaxMemory.Valid && o.MaxMemory.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("maxMemory can't be negative, got %d", o.MaxMemory.Int64))
+	}
+	if o.WarmVUs.Valid && o.WarmVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("warmVUs can't be negative, got %d", o.WarmVUs.Int64))
+	}
+	if o.MaxTimeSeriesAction.Valid {
+		if err := metrics.ValidateCardinalityAction(o.MaxTimeSeriesAction.String); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if o.AbortOnErrorRate != nil {
+		if err := o.AbortOnErrorRate.Validate(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if o.CaptureFailuresLimit.Valid && o.CaptureFailuresLimit.Int64 < 1 {
+		errors = append(errors, fmt.Errorf("captureFailuresLimit must be positive, got %d", o.CaptureFailuresLimit.Int64))
+	}
+	if o.CaptureFailuresBodySize.Valid && o.CaptureFailuresBodySize.Int64 < 0 {
+		errors = append(errors,
+			fmt.Errorf("captureFailuresBodySize can't be negative, got %d", o.CaptureFailuresBodySize.Int64))
+	}
+	for _, fault := range o.Faults {
+		if err := fault.Validate(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	tlsAuthNames := make(map[string]bool, len(o.TLSAuth))
+	for _, auth := range o.TLSAuth {
+		if auth == nil || auth.Name == "" {
+			continue
+		}
+		if tlsAuthNames[auth.Name] {
+			errors = append(errors, fmt.Errorf("the tlsAuth name '%s' is used by more than one certificate", auth.Name))
+		}
+		tlsAuthNames[auth.Name] = true
+	}
+	if o.VUBandwidth != nil {
+		if err := o.VUBandwidth.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid vuBandwidth: %w", err))
+		}
+	}
+	for _, tag := range o.SummaryBreakdown {
+		if tag != metrics.TagScenario.String() && tag != metrics.TagGroup.String() {
+			errors = append(errors, fmt.Errorf("the summary can only be broken down by scenario or group, got '%s'", tag))
+		}
+	}
 	return append(errors, o.Scenarios.Validate()...)
 }
 
@@ -87,6 +87,22 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.UserAgent.Valid)
 		assert.Equal(t, "foo", opts.UserAgent.String)
 	})
+	t.Run("CorrelationIDHeader", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{CorrelationIDHeader: null.StringFrom("X-Request-ID")})
+		assert.True(t, opts.CorrelationIDHeader.Valid)
+		assert.Equal(t, "X-Request-ID", opts.CorrelationIDHeader.String)
+	})
+	t.Run("TracePropagation", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{TracePropagator: null.StringFrom("b3"), TraceSampling: null.FloatFrom(0.5)})
+		assert.Equal(t, null.StringFrom("b3"), opts.TracePropagator)
+		assert.Equal(t, null.FloatFrom(0.5), opts.TraceSampling)
+		assert.Empty(t, opts.Validate())
+
+		opts.TracePropagator = null.StringFrom("zipkin")
+		assert.Len(t, opts.Validate(), 1)
+	})
 	t.Run("Batch", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{Batch: null.IntFrom(12345)})
@@ -158,6 +174,26 @@ func TestOptions(t *testing.T) {
 			})
 		})
 	})
+	t.Run("TLSCurves", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{TLSCurves: &TLSCurves{tls.X25519}})
+		assert.Equal(t, &TLSCurves{tls.X25519}, opts.TLSCurves)
+
+		t.Run("JSON", func(t *testing.T) {
+			t.Parallel()
+			var opts Options
+			jsonStr := `{"tlsCurves":["X25519MLKEM768","X25519","CurveP256"]}`
+			require.NoError(t, json.Unmarshal([]byte(jsonStr), &opts))
+			assert.Equal(t, &TLSCurves{0x11ec, tls.X25519, tls.CurveP256}, opts.TLSCurves)
+
+			data, err := json.Marshal(opts.TLSCurves)
+			require.NoError(t, err)
+			assert.Equal(t, `["X25519MLKEM768","X25519","CurveP256"]`, string(data))
+
+			assert.Error(t, json.Unmarshal([]byte(`{"tlsCurves":["foo"]}`), &opts))
+			assert.Error(t, json.Unmarshal([]byte(`{"tlsCurves":[29]}`), &opts))
+		})
+	})
 	t.Run("TLSVersion", func(t *testing.T) {
 		t.Parallel()
 		versions := TLSVersions{Min: tls.VersionSSL30, Max: tls.VersionTLS12}
@@ -297,6 +333,16 @@ func TestOptions(t *testing.T) {
 			jsonStr := `{"tlsAuth":[{"Cert":""}]}`
 			assert.Error(t, json.Unmarshal([]byte(jsonStr), &opts))
 		})
+
+		t.Run("Names", func(t *testing.T) {
+			t.Parallel()
+			named := []*TLSAuth{{TLSAuthFields: tlsAuth[0].TLSAuthFields}, {TLSAuthFields: tlsAuth[1].TLSAuthFields}}
+			named[0].Name, named[1].Name = "first", "second"
+			assert.Empty(t, Options{TLSAuth: named}.Validate())
+
+			named[1].Name = "first"
+			assert.Len(t, Options{TLSAuth: named}.Validate(), 1)
+		})
 	})
 	t.Run("TLSAuth with", func(t *testing.T) {
 		t.Parallel()
@@ -543,6 +589,64 @@ func TestOptions(t *testing.T) {
 		opts := Options{}.Apply(Options{SummaryTrendStats: stats})
 		assert.Equal(t, stats, opts.SummaryTrendStats)
 	})
+	t.Run("TrendSignificantDigits", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{TrendSignificantDigits: null.IntFrom(4)})
+		assert.Equal(t, null.IntFrom(4), opts.TrendSignificantDigits)
+		assert.Empty(t, opts.Validate())
+
+		opts.TrendSignificantDigits = null.IntFrom(6)
+		assert.Len(t, opts.Validate(), 1)
+	})
+	t.Run("SummaryBreakdown", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{SummaryBreakdown: []string{"scenario", "group"}})
+		assert.Equal(t, []string{"scenario", "group"}, opts.SummaryBreakdown)
+		assert.Empty(t, opts.Validate())
+
+		opts.SummaryBreakdown = []string{"status"}
+		assert.Len(t, opts.Validate(), 1)
+	})
+	t.Run("AbortOnErrorRate", func(t *testing.T) {
+		t.Parallel()
+		abort := &metrics.ErrorRateAbort{Rate: 0.1, Window: types.Duration(30 * time.Second)}
+		opts := Options{}.Apply(Options{AbortOnErrorRate: abort})
+		assert.Equal(t, abort, opts.AbortOnErrorRate)
+		assert.Empty(t, opts.Validate())
+
+		opts.AbortOnErrorRate = &metrics.ErrorRateAbort{Rate: 2, Window: types.Duration(30 * time.Second)}
+		assert.Len(t, opts.Validate(), 1)
+	})
+	t.Run("CaptureFailures", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{
+			CaptureFailures:         null.StringFrom("failures.jsonl"),
+			CaptureFailuresLimit:    null.IntFrom(3),
+			CaptureFailuresBodySize: null.IntFrom(0),
+		})
+		assert.Equal(t, null.StringFrom("failures.jsonl"), opts.CaptureFailures)
+		assert.Equal(t, null.IntFrom(3), opts.CaptureFailuresLimit)
+		assert.Equal(t, null.IntFrom(0), opts.CaptureFailuresBodySize)
+		assert.Empty(t, opts.Validate())
+
+		opts.CaptureFailuresLimit = null.IntFrom(0)
+		opts.CaptureFailuresBodySize = null.IntFrom(-1)
+		assert.Len(t, opts.Validate(), 2)
+	})
+	t.Run("MaxTimeSeries", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{
+			MaxTimeSeries:       null.IntFrom(1000),
+			MaxTimeSeriesAction: null.StringFrom("drop"),
+		})
+		assert.Equal(t, null.IntFrom(1000), opts.MaxTimeSeries)
+		assert.Equal(t, null.StringFrom("drop"), opts.MaxTimeSeriesAction)
+		assert.Empty(t, opts.Validate())
+
+		opts.MaxTimeSeries = null.IntFrom(-1)
+		opts.MaxTimeSeriesAction = null.StringFrom("ignore")
+		assert.Len(t, opts.Validate(), 2)
+	})
 	t.Run("RunTags", func(t *testing.T) {
 		t.Parallel()
 		tags := map[string]string{"myTag": "hello"}
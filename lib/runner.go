@@ -28,6 +28,16 @@ type InitializedVU interface {
 	GetID() uint64
 }
 
+// TeardownableVU is implemented by the VUs that need to run some cleanup code
+// once, at the end of the test run, after all of the executors are done. For
+// the JS VUs, that's the exported vuTeardown() function.
+type TeardownableVU interface {
+	InitializedVU
+
+	// Teardown is called only once per VU, the VU can't be used afterwards.
+	Teardown(ctx context.Context) error
+}
+
 // VUActivationParams are supplied by each executor when it retrieves a VU from
 // the buffer pool and activates it for use.
 type VUActivationParams struct {
@@ -73,6 +83,18 @@ type Runner interface {
 	// Runs post-test teardown, if applicable.
 	Teardown(ctx context.Context, out chan<- metrics.SampleContainer) error
 
+	// Runs the setup function of the given scenario, if it has one, and
+	// returns the json representation of its result. The returned data will
+	// be passed to the scenario's iterations and teardown function, instead
+	// of the global setup data.
+	ScenarioSetup(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) ([]byte, error)
+
+	// Saves the externally supplied setup data of a scenario as json in the runner
+	SetScenarioSetupData(scenario string, data []byte)
+
+	// Runs the teardown function of the given scenario, if it has one.
+	ScenarioTeardown(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error
+
 	// Returns the default (root) Group.
 	GetDefaultGroup() *Group
 
@@ -103,4 +125,25 @@ type Summary struct {
 	TestRunDuration time.Duration // TODO: use lib.ExecutionState-based interface instead?
 	NoColor         bool          // TODO: drop this when noColor is part of the (runtime) options
 	UIState         UIState
+	// Cardinality describes the time series of the test if it had more of
+	// them than their limit, it's nil otherwise.
+	Cardinality *metrics.CardinalityReport
+	// Baseline is the comparison of the test run with the baseline, it's nil
+	// if there isn't one.
+	Baseline *metrics.BaselineReport
+	// Abort is why the script aborted the test gracefully, it's nil if it
+	// didn't.
+	Abort *SummaryAbort
+	// TimeSeries are the buckets of the summaryTimeSeries option, it's nil if
+	// it isn't set.
+	TimeSeries *metrics.TimeBuckets
+	// CompositeThresholds are the thresholds on expressions combining several
+	// metrics, by their names.
+	CompositeThresholds map[string]*metrics.CompositeThresholds
+}
+
+// SummaryAbort is the graceful abort of a test by its script.
+type SummaryAbort struct {
+	Reason   string
+	ExitCode int
 }
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"gopkg.in/guregu/null.v3"
@@ -19,6 +20,17 @@ const (
 	CompatibilityModeBase
 )
 
+// ModuleResolution specifies how the bare module specifiers (e.g. "lodash")
+// are resolved
+type ModuleResolution string
+
+const (
+	// ModuleResolutionK6 resolves them only with the remote loaders (e.g. for github.com)
+	ModuleResolutionK6 ModuleResolution = "k6"
+	// ModuleResolutionNode also resolves them from the node_modules directories, as Node.js does
+	ModuleResolutionNode ModuleResolution = "node"
+)
+
 // RuntimeOptions are settings passed onto the goja JS runtime
 type RuntimeOptions struct {
 	TestType null.String `json:"-"`
@@ -34,16 +46,55 @@ type RuntimeOptions struct {
 	// default one, so we can handle `k6 run --compatibility-mode=base es6_extended_archive.tar`
 	CompatibilityMode null.String `json:"compatibilityMode"`
 
+	// Module resolution mode: "k6" (the default) or "node" (also from node_modules)
+	ModuleResolution null.String `json:"moduleResolution"`
+
+	// Whether only the remote modules in the lockfile can be loaded
+	FrozenLockfile null.Bool `json:"-"`
+
 	// Environment variables passed onto the runner
 	Env map[string]string `json:"env"`
 
-	NoThresholds  null.Bool   `json:"noThresholds"`
-	NoSummary     null.Bool   `json:"noSummary"`
+	// The names of the environment variables from the secret sources, whose
+	// values are redacted from the archives
+	SecretEnvVars []string `json:"-"`
+
+	NoThresholds null.Bool `json:"noThresholds"`
+	NoSummary    null.Bool `json:"noSummary"`
+	// A comma-separated list of the targets of the summary, `type=path`, where a
+	// path without a type is a JSON one; see SummaryExportTargets
 	SummaryExport null.String `json:"summaryExport"`
 	KeyWriter     null.String `json:"-"`
 	TracesOutput  null.String `json:"tracesOutput"`
 }
 
+// summaryExportType matches the type of a summary export target.
+var summaryExportType = regexp.MustCompile(`^([a-z]+)=(.*)$`)
+
+// SummaryExportTargets splits the SummaryExport option in the paths of the
+// JSON summaries, the targets without a type or with the json one, and the
+// `type=path` of the other targets, which are end-of-test reports.
+func (o RuntimeOptions) SummaryExportTargets() (jsonPaths []string, reports []string) {
+	for _, target := range strings.Split(o.SummaryExport.String, ",") {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		match := summaryExportType.FindStringSubmatch(target)
+		switch {
+		case match == nil:
+			jsonPaths = append(jsonPaths, target)
+		case match[1] == "json" && match[2] == "":
+			jsonPaths = append(jsonPaths, "summary.json")
+		case match[1] == "json":
+			jsonPaths = append(jsonPaths, match[2])
+		default:
+			reports = append(reports, target)
+		}
+	}
+	return jsonPaths, reports
+}
+
 // ValidateCompatibilityMode checks if the provided val is a valid compatibility mode
 func ValidateCompatibilityMode(val string) (cm CompatibilityMode, err error) {
 	if val == "" {
@@ -59,3 +110,16 @@ func ValidateCompatibilityMode(val string) (cm CompatibilityMode, err error) {
 	}
 	return
 }
+
+// ValidateModuleResolution checks if the provided val is a valid module resolution mode
+func ValidateModuleResolution(val string) (ModuleResolution, error) {
+	switch mr := ModuleResolution(val); mr {
+	case "":
+		return ModuleResolutionK6, nil
+	case ModuleResolutionK6, ModuleResolutionNode:
+		return mr, nil
+	default:
+		return "", fmt.Errorf(`invalid module resolution "%s". Use: "%s", "%s"`,
+			val, ModuleResolutionK6, ModuleResolutionNode)
+	}
+}
@@ -0,0 +1,30 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestSummaryExportTargets(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value     string
+		jsonPaths []string
+		reports   []string
+	}{
+		{"", nil, nil},
+		{"summary.json", []string{"summary.json"}, nil},
+		{"results/run 1.json, json=other.json,json=", []string{"results/run 1.json", "other.json", "summary.json"}, nil},
+		{"json=a.json,junit=junit.xml,markdown=,html=r.html", []string{"a.json"},
+			[]string{"junit=junit.xml", "markdown=", "html=r.html"}},
+		{"template=comment.md,C:\\out\\summary.json", []string{"C:\\out\\summary.json"}, []string{"template=comment.md"}},
+	}
+	for _, tc := range testCases {
+		jsonPaths, reports := RuntimeOptions{SummaryExport: null.StringFrom(tc.value)}.SummaryExportTargets()
+		assert.Equal(t, tc.jsonPaths, jsonPaths, tc.value)
+		assert.Equal(t, tc.reports, reports, tc.value)
+	}
+}
@@ -0,0 +1,99 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// SharedStore is a key-value store that is shared between all VUs and
+// scenarios of a test run. The values are JSON-encoded, so they can be
+// transferred between k6 instances in distributed tests.
+type SharedStore interface {
+	// Get returns the value for the given key and whether it exists.
+	Get(key string) ([]byte, bool, error)
+
+	// Set sets the value for the given key.
+	Set(key string, value []byte) error
+
+	// Delete removes the given key, if it exists.
+	Delete(key string) error
+
+	// Incr atomically adds delta to the integer value of the given key,
+	// which is considered to be 0 if it doesn't exist, and returns the result.
+	Incr(key string, delta int64) (int64, error)
+
+	// CompareAndSwap atomically sets the value of the given key to newValue,
+	// only if its current value is equal to oldValue. A nil oldValue means
+	// that the key shouldn't exist. It returns whether the value was swapped.
+	CompareAndSwap(key string, oldValue, newValue []byte) (bool, error)
+}
+
+// MemorySharedStore is a SharedStore that keeps the values in memory, for
+// tests that have a single k6 instance.
+type MemorySharedStore struct {
+	mx     sync.Mutex
+	values map[string][]byte
+}
+
+var _ SharedStore = &MemorySharedStore{}
+
+// NewMemorySharedStore returns a new empty MemorySharedStore.
+func NewMemorySharedStore() *MemorySharedStore {
+	return &MemorySharedStore{values: make(map[string][]byte)}
+}
+
+// Get returns the value for the given key and whether it exists.
+func (s *MemorySharedStore) Get(key string) ([]byte, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+// Set sets the value for the given key.
+func (s *MemorySharedStore) Set(key string, value []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+// Delete removes the given key, if it exists.
+func (s *MemorySharedStore) Delete(key string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	delete(s.values, key)
+	return nil
+}
+
+// Incr atomically adds delta to the integer value of the given key.
+func (s *MemorySharedStore) Incr(key string, delta int64) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var current int64
+	if value, ok := s.values[key]; ok {
+		if err := json.Unmarshal(value, &current); err != nil {
+			return 0, fmt.Errorf("the value of key '%s' isn't an integer", key)
+		}
+	}
+	current += delta
+	s.values[key] = []byte(fmt.Sprint(current))
+	return current, nil
+}
+
+// CompareAndSwap atomically swaps the value of the given key, if it's equal
+// to oldValue.
+func (s *MemorySharedStore) CompareAndSwap(key string, oldValue, newValue []byte) (bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	current, ok := s.values[key]
+	if oldValue == nil && ok || oldValue != nil && (!ok || !bytes.Equal(current, oldValue)) {
+		return false, nil
+	}
+	s.values[key] = newValue
+	return true, nil
+}
@@ -0,0 +1,46 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySharedStore(t *testing.T) {
+	t.Parallel()
+
+	s := NewMemorySharedStore()
+	_, ok, err := s.Get("token")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	swapped, err := s.CompareAndSwap("token", nil, []byte(`"abc"`))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	swapped, err = s.CompareAndSwap("token", nil, []byte(`"def"`))
+	require.NoError(t, err)
+	assert.False(t, swapped)
+	swapped, err = s.CompareAndSwap("token", []byte(`"abc"`), []byte(`"def"`))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	value, ok, err := s.Get("token")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `"def"`, string(value))
+
+	result, err := s.Incr("id", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result)
+	result, err = s.Incr("id", 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), result)
+	_, err = s.Incr("token", 1)
+	assert.EqualError(t, err, "the value of key 'token' isn't an integer")
+
+	require.NoError(t, s.Delete("token"))
+	_, ok, err = s.Get("token")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -26,7 +26,11 @@ type MiniRunner struct {
 	TeardownFn      func(ctx context.Context, out chan<- metrics.SampleContainer) error
 	HandleSummaryFn func(context.Context, *lib.Summary) (map[string]io.Reader, error)
 
-	SetupData []byte
+	ScenarioSetupFn    func(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) ([]byte, error)
+	ScenarioTeardownFn func(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error
+
+	SetupData         []byte
+	ScenarioSetupData map[string][]byte
 
 	Group        *lib.Group
 	Options      lib.Options
@@ -86,6 +90,37 @@ func (r MiniRunner) Teardown(ctx context.Context, out chan<- metrics.SampleConta
 	return nil
 }
 
+// ScenarioSetup calls the supplied mock scenario setup function, if present.
+func (r *MiniRunner) ScenarioSetup(
+	ctx context.Context, scenario string, out chan<- metrics.SampleContainer,
+) ([]byte, error) {
+	if fn := r.ScenarioSetupFn; fn != nil {
+		data, err := fn(ctx, scenario, out)
+		if err != nil {
+			return nil, err
+		}
+		r.SetScenarioSetupData(scenario, data)
+		return data, nil
+	}
+	return nil, nil
+}
+
+// SetScenarioSetupData saves the externally supplied scenario setup data.
+func (r *MiniRunner) SetScenarioSetupData(scenario string, data []byte) {
+	if r.ScenarioSetupData == nil {
+		r.ScenarioSetupData = make(map[string][]byte)
+	}
+	r.ScenarioSetupData[scenario] = data
+}
+
+// ScenarioTeardown calls the supplied mock scenario teardown function, if present.
+func (r MiniRunner) ScenarioTeardown(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error {
+	if fn := r.ScenarioTeardownFn; fn != nil {
+		return fn(ctx, scenario, out)
+	}
+	return nil
+}
+
 // GetDefaultGroup returns the default group.
 func (r MiniRunner) GetDefaultGroup() *lib.Group {
 	if r.Group == nil {
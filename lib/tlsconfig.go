@@ -88,3 +88,30 @@ var SupportedTLSCipherSuitesToString = map[uint16]string{
 	tls.TLS_AES_256_GCM_SHA384:                  "TLS_AES_256_GCM_SHA384",
 	tls.TLS_CHACHA20_POLY1305_SHA256:            "TLS_CHACHA20_POLY1305_SHA256",
 }
+
+// SupportedTLSCurves is string-to-constant map of the key exchange curves of
+// the TLS handshakes. The post-quantum hybrid ones are only used if the Go
+// version k6 is built with implements them, and ignored otherwise.
+//
+//nolint:gochecknoglobals
+var SupportedTLSCurves = map[string]tls.CurveID{
+	"CurveP256":             tls.CurveP256,
+	"CurveP384":             tls.CurveP384,
+	"CurveP521":             tls.CurveP521,
+	"X25519":                tls.X25519,
+	"X25519Kyber768Draft00": 0x6399,
+	"X25519MLKEM768":        0x11ec,
+}
+
+// SupportedTLSCurvesToString is constant-to-string map of the key exchange
+// curves of the TLS handshakes.
+//
+//nolint:gochecknoglobals
+var SupportedTLSCurvesToString = map[tls.CurveID]string{
+	tls.CurveP256: "CurveP256",
+	tls.CurveP384: "CurveP384",
+	tls.CurveP521: "CurveP521",
+	tls.X25519:    "X25519",
+	0x6399:        "X25519Kyber768Draft00",
+	0x11ec:        "X25519MLKEM768",
+}
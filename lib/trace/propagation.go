@@ -0,0 +1,77 @@
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+)
+
+// The supported trace context propagation formats.
+const (
+	// PropagatorW3C injects the W3C trace context traceparent header.
+	PropagatorW3C = "w3c"
+	// PropagatorB3 injects the single b3 header used by Zipkin.
+	PropagatorB3 = "b3"
+	// PropagatorJaeger injects the uber-trace-id header used by Jaeger.
+	PropagatorJaeger = "jaeger"
+)
+
+// The headers in which each of the supported formats propagates the trace context.
+const (
+	W3CHeader    = "traceparent"
+	B3Header     = "b3"
+	JaegerHeader = "uber-trace-id"
+)
+
+// ValidatePropagation returns an error if the propagator isn't one of the
+// supported formats or if the sampling rate isn't within 0.0 <= n <= 1.0.
+func ValidatePropagation(propagator string, sampling float64) error {
+	switch propagator {
+	case PropagatorW3C, PropagatorB3, PropagatorJaeger:
+	default:
+		return fmt.Errorf("unknown trace propagator %q, it must be one of %s, %s or %s",
+			propagator, PropagatorW3C, PropagatorB3, PropagatorJaeger)
+	}
+	if sampling < 0.0 || sampling > 1.0 {
+		return fmt.Errorf("the trace sampling rate must be between 0.0 and 1.0, got %g", sampling)
+	}
+	return nil
+}
+
+// HasContext returns true if the header already carries a trace context in
+// any of the supported formats, e.g. one set explicitly by the user.
+func HasContext(header http.Header) bool {
+	return header.Get(W3CHeader) != "" || header.Get(B3Header) != "" || header.Get(JaegerHeader) != ""
+}
+
+// Propagate generates a new trace context, takes the sampling decision based
+// on the sampling rate and injects it in the header in the propagator's
+// format. It returns the hex-encoded trace and parent span IDs and whether it
+// was sampled.
+func Propagate(header http.Header, propagator string, sampling float64) (traceID, spanID string, sampled bool) {
+	var ids [24]byte
+	_, _ = rand.Read(ids[:]) // it never returns an error
+	traceID = hex.EncodeToString(ids[:16])
+	spanID = hex.EncodeToString(ids[16:])
+	sampled = sampling >= 1.0 || (sampling > 0.0 && mathrand.Float64() < sampling) //nolint:gosec
+
+	switch propagator {
+	case PropagatorW3C:
+		header.Set(W3CHeader, "00-"+traceID+"-"+spanID+"-"+pick(sampled, "01", "00"))
+	case PropagatorB3:
+		header.Set(B3Header, traceID+"-"+spanID+"-"+pick(sampled, "1", "0"))
+	case PropagatorJaeger:
+		// Jaeger considers a parent span ID of 0 as the root span.
+		header.Set(JaegerHeader, traceID+":"+spanID+":0:"+pick(sampled, "1", "0"))
+	}
+	return traceID, spanID, sampled
+}
+
+func pick(decision bool, lhs, rhs string) string {
+	if decision {
+		return lhs
+	}
+	return rhs
+}
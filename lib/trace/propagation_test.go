@@ -0,0 +1,55 @@
+package trace
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		propagator string
+		sampling   float64
+		header     string
+		expected   string
+		sampled    bool
+	}{
+		{PropagatorW3C, 1, "traceparent", `^00-([0-9a-f]{32})-([0-9a-f]{16})-01$`, true},
+		{PropagatorW3C, 0, "traceparent", `^00-([0-9a-f]{32})-([0-9a-f]{16})-00$`, false},
+		{PropagatorB3, 1, "b3", `^([0-9a-f]{32})-([0-9a-f]{16})-1$`, true},
+		{PropagatorB3, 0, "b3", `^([0-9a-f]{32})-([0-9a-f]{16})-0$`, false},
+		{PropagatorJaeger, 1, "uber-trace-id", `^([0-9a-f]{32}):([0-9a-f]{16}):0:1$`, true},
+		{PropagatorJaeger, 0, "uber-trace-id", `^([0-9a-f]{32}):([0-9a-f]{16}):0:0$`, false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.header, func(t *testing.T) {
+			t.Parallel()
+
+			header := http.Header{}
+			require.False(t, HasContext(header))
+			traceID, spanID, sampled := Propagate(header, tc.propagator, tc.sampling)
+			assert.Equal(t, tc.sampled, sampled)
+			assert.True(t, HasContext(header))
+
+			matches := regexp.MustCompile(tc.expected).FindStringSubmatch(header.Get(tc.header))
+			require.Len(t, matches, 3, header.Get(tc.header))
+			assert.Equal(t, traceID, matches[1])
+			assert.Equal(t, spanID, matches[2])
+		})
+	}
+}
+
+func TestValidatePropagation(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidatePropagation(PropagatorB3, 0.5))
+	assert.ErrorContains(t, ValidatePropagation("zipkin", 1), `unknown trace propagator "zipkin"`)
+	assert.ErrorContains(t, ValidatePropagation(PropagatorW3C, 1.5), "must be between 0.0 and 1.0")
+}
@@ -0,0 +1,23 @@
+package types
+
+import "fmt"
+
+// Bandwidth caps the egress and the ingress network traffic, in bytes per
+// second, like `{"egress": 50000, "ingress": 200000}`. A zero cap means that
+// the direction isn't capped.
+type Bandwidth struct {
+	Egress  int64 `json:"egress,omitempty"`
+	Ingress int64 `json:"ingress,omitempty"`
+}
+
+// Validate checks that the caps aren't negative and that at least one of them
+// is set.
+func (b Bandwidth) Validate() error {
+	if b.Egress < 0 || b.Ingress < 0 {
+		return fmt.Errorf("the bandwidth caps can't be negative, got egress %d and ingress %d", b.Egress, b.Ingress)
+	}
+	if b.Egress == 0 && b.Ingress == 0 {
+		return fmt.Errorf("the bandwidth needs an egress or an ingress cap")
+	}
+	return nil
+}
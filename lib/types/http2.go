@@ -0,0 +1,36 @@
+package types
+
+import "fmt"
+
+// The limits of the HTTP/2 SETTINGS_MAX_FRAME_SIZE, from RFC 9113.
+const (
+	minHTTP2FrameSize = 1 << 14
+	maxHTTP2FrameSize = 1<<24 - 1
+)
+
+// HTTP2 are the settings of the HTTP/2 connections, like
+// `{"maxConcurrentStreams": 10, "h2c": true}`.
+type HTTP2 struct {
+	// At most this many requests to a host are in flight at once, or fewer
+	// if the server allows fewer streams, and the others wait for one of
+	// them to end instead of opening more connections.
+	MaxConcurrentStreams int64 `json:"maxConcurrentStreams,omitempty"`
+	// The largest frame the server may send, advertised as the
+	// SETTINGS_MAX_FRAME_SIZE of the connections.
+	MaxReadFrameSize int64 `json:"maxReadFrameSize,omitempty"`
+	// Send the requests of http:// URLs over cleartext HTTP/2 with prior
+	// knowledge, instead of HTTP/1.1.
+	H2C bool `json:"h2c,omitempty"`
+}
+
+// Validate checks that the settings are within the limits of HTTP/2.
+func (h HTTP2) Validate() error {
+	if h.MaxConcurrentStreams < 0 {
+		return fmt.Errorf("the maxConcurrentStreams can't be negative, got %d", h.MaxConcurrentStreams)
+	}
+	if h.MaxReadFrameSize != 0 && (h.MaxReadFrameSize < minHTTP2FrameSize || h.MaxReadFrameSize > maxHTTP2FrameSize) {
+		return fmt.Errorf("the maxReadFrameSize has to be between %d and %d, got %d",
+			minHTTP2FrameSize, maxHTTP2FrameSize, h.MaxReadFrameSize)
+	}
+	return nil
+}
@@ -2,11 +2,14 @@ package lib
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
+	"encoding/hex"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
@@ -84,6 +87,31 @@ type State struct {
 
 	// Tracing instrumentation.
 	TracerProvider TracerProvider
+
+	// Capture of the failed requests, nil unless the captureFailures option is set.
+	FailureCapture *FailureCapture
+
+	// The context of the currently running iteration, nil outside of one.
+	IterationContext *IterationContext
+}
+
+// IterationContext holds the information about a single iteration that is
+// shared with the systems under test, e.g. the correlation ID which is sent
+// with every request so server-side traces can be joined with k6 metrics.
+type IterationContext struct {
+	CorrelationID string
+	StartTime     time.Time
+}
+
+// NewIterationContext returns a new IterationContext with a random 128-bit
+// correlation ID, encoded the same way as a W3C trace ID.
+func NewIterationContext() *IterationContext {
+	var id [16]byte
+	_, _ = rand.Read(id[:]) // it never returns an error
+	return &IterationContext{
+		CorrelationID: hex.EncodeToString(id[:]),
+		StartTime:     time.Now(),
+	}
 }
 
 // VUStateTags wraps the current VU's tags and ensures a thread-safe way to
@@ -144,25 +144,11 @@ func Load(
 			"originalModuleSpecifier": originalModuleSpecifier,
 		}).Debug("Loading...")
 
-	var pathOnFs string
-	switch {
-	case moduleSpecifier.Opaque != "": // This is loader
-		pathOnFs = filepath.Join(fsext.FilePathSeparator, moduleSpecifier.Opaque)
-	case moduleSpecifier.Scheme == "":
-		pathOnFs = path.Clean(moduleSpecifier.String())
-	default:
-		pathOnFs = path.Clean(moduleSpecifier.String()[len(moduleSpecifier.Scheme)+len(":/"):])
-	}
-	scheme := moduleSpecifier.Scheme
-	if scheme == "" {
-		if moduleSpecifier.Opaque == "" {
-			//nolint:stylecheck
-			return nil, fmt.Errorf(fileSchemeCouldntBeLoadedMsg, originalModuleSpecifier)
-		}
-		scheme = "https"
+	if moduleSpecifier.Scheme == "" && moduleSpecifier.Opaque == "" {
+		//nolint:stylecheck
+		return nil, fmt.Errorf(fileSchemeCouldntBeLoadedMsg, originalModuleSpecifier)
 	}
-
-	pathOnFs, err := url.PathUnescape(filepath.FromSlash(pathOnFs))
+	scheme, pathOnFs, err := filesystemPath(moduleSpecifier)
 	if err != nil {
 		return nil, err
 	}
@@ -180,26 +166,58 @@ func Load(
 		return nil, fmt.Errorf(fileSchemeCouldntBeLoadedMsg, originalModuleSpecifier)
 	}
 
+	result, err := fetchRemoteModule(logger, moduleSpecifier, originalModuleSpecifier)
+	if err != nil {
+		return nil, err
+	}
+	// TODO maybe make an fsext.Fs which makes request directly and than use CacheOnReadFs
+	// on top of as with the `file` scheme fs
+	_ = fsext.WriteFile(filesystems[scheme], pathOnFs, result.Data, 0o644)
+
+	return result, nil
+}
+
+// filesystemPath returns the scheme of the filesystem, in which the module
+// with the given specifier is stored, and its path in it.
+func filesystemPath(moduleSpecifier *url.URL) (scheme string, pathOnFs string, err error) {
+	switch {
+	case moduleSpecifier.Opaque != "": // This is loader
+		pathOnFs = filepath.Join(fsext.FilePathSeparator, moduleSpecifier.Opaque)
+	case moduleSpecifier.Scheme == "":
+		pathOnFs = path.Clean(moduleSpecifier.String())
+	default:
+		pathOnFs = path.Clean(moduleSpecifier.String()[len(moduleSpecifier.Scheme)+len(":/"):])
+	}
+	scheme = moduleSpecifier.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	pathOnFs, err = url.PathUnescape(filepath.FromSlash(pathOnFs))
+	return scheme, pathOnFs, err
+}
+
+// fetchRemoteModule fetches the remote module with the given specifier, which
+// is either an https URL or a reference for one of the loaders.
+func fetchRemoteModule(
+	logger logrus.FieldLogger, moduleSpecifier *url.URL, originalModuleSpecifier string,
+) (*SourceData, error) {
 	finalModuleSpecifierURL := moduleSpecifier
 
 	if moduleSpecifier.Opaque != "" { // This is a loader
+		var err error
 		finalModuleSpecifierURL, err = resolveUsingLoaders(logger, moduleSpecifier.Opaque)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	var result *SourceData
-	result, err = loadRemoteURL(logger, finalModuleSpecifierURL)
+	result, err := loadRemoteURL(logger, finalModuleSpecifierURL)
 	if err != nil {
 		//nolint:stylecheck
 		return nil, fmt.Errorf(httpsSchemeCouldntBeLoadedMsg, originalModuleSpecifier, finalModuleSpecifierURL, err)
 	}
 	result.URL = moduleSpecifier
-	// TODO maybe make an fsext.Fs which makes request directly and than use CacheOnReadFs
-	// on top of as with the `file` scheme fs
-	_ = fsext.WriteFile(filesystems[scheme], pathOnFs, result.Data, 0o644)
-
 	return result, nil
 }
 
@@ -0,0 +1,256 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+const (
+	// LockfileName is the name of the lockfile with the remote modules of a
+	// script, which is in the same directory as the script.
+	LockfileName = "k6.lock"
+	// ModulesCacheDir is the directory, next to the lockfile, in which the
+	// locked remote modules are cached, so they can be loaded offline.
+	ModulesCacheDir = ".k6/modules"
+
+	lockfileVersion   = 1
+	integrityAlgoritm = "sha256-"
+)
+
+// Lockfile pins the remote modules (e.g. from https URLs or github.com) that
+// are imported by a script to their content, so every run uses the same code.
+type Lockfile struct {
+	Version int                     `json:"lockfileVersion"`
+	Modules map[string]LockedModule `json:"modules"`
+}
+
+// LockedModule is a single remote module in the lockfile.
+type LockedModule struct {
+	// Integrity is the hash of the content of the module, in the same format
+	// as the integrity of the subresources in HTML, e.g. "sha256-<base64>".
+	Integrity string `json:"integrity"`
+}
+
+// NewLockfile returns a new empty lockfile.
+func NewLockfile() *Lockfile {
+	return &Lockfile{Version: lockfileVersion, Modules: make(map[string]LockedModule)}
+}
+
+// ReadLockfile reads the lockfile with the given filename.
+func ReadLockfile(fs fsext.Fs, filename string) (*Lockfile, error) {
+	data, err := fsext.ReadFile(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+	lock := NewLockfile()
+	if err = json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("couldn't parse the lockfile '%s': %w", filename, err)
+	}
+	if lock.Version != lockfileVersion {
+		return nil, fmt.Errorf("the lockfile '%s' has an unsupported version %d", filename, lock.Version)
+	}
+	for specifier, module := range lock.Modules {
+		if !strings.HasPrefix(module.Integrity, integrityAlgoritm) {
+			return nil, fmt.Errorf("the module '%s' in the lockfile '%s' has an unsupported integrity '%s'",
+				specifier, filename, module.Integrity)
+		}
+	}
+	return lock, nil
+}
+
+// Write writes the lockfile with the given filename.
+func (l *Lockfile) Write(fs fsext.Fs, filename string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = fs.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	return fsext.WriteFile(fs, filename, append(data, '\n'), 0o644)
+}
+
+// Specifiers returns the sorted specifiers of all of the locked modules.
+func (l *Lockfile) Specifiers() []string {
+	specifiers := make([]string, 0, len(l.Modules))
+	for specifier := range l.Modules {
+		specifiers = append(specifiers, specifier)
+	}
+	sort.Strings(specifiers)
+	return specifiers
+}
+
+// LockModules adds the remote modules with the given specifiers, which were
+// already loaded in the filesystems, to the lockfile and copies them to the
+// cache directory in fs. The other specifiers (e.g. of the local files and the
+// k6 modules) are skipped. It returns the specifiers of the locked modules.
+func (l *Lockfile) LockModules(
+	filesystems map[string]fsext.Fs, fs fsext.Fs, cacheDir string, specifiers []string,
+) ([]string, error) {
+	var locked []string
+	for _, specifier := range specifiers {
+		u, ok := remoteModuleURL(specifier)
+		if !ok {
+			continue
+		}
+		scheme, pathOnFs, err := filesystemPath(u)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fsext.ReadFile(filesystems[scheme], pathOnFs)
+		if err != nil {
+			return nil, fmt.Errorf("the remote module '%s' wasn't loaded: %w", specifier, err)
+		}
+		module := LockedModule{Integrity: integrity(data)}
+		if err = writeCachedModule(fs, cacheDir, module, data); err != nil {
+			return nil, err
+		}
+		l.Modules[u.String()] = module
+		locked = append(locked, u.String())
+	}
+	sort.Strings(locked)
+	return locked, nil
+}
+
+// UseLockfile makes the remote modules in the lockfile be loaded from the
+// cache directory in fs, or fetched and cached if they aren't there, and
+// checked against their integrity. If frozen is set, the remote modules that
+// aren't in the lockfile can't be loaded.
+func UseLockfile(
+	logger logrus.FieldLogger, filesystems map[string]fsext.Fs,
+	lock *Lockfile, fs fsext.Fs, cacheDir string, frozen bool,
+) error {
+	for _, specifier := range lock.Specifiers() {
+		module := lock.Modules[specifier]
+		u, ok := remoteModuleURL(specifier)
+		if !ok {
+			return fmt.Errorf("the module '%s' in the lockfile isn't a remote module", specifier)
+		}
+		scheme, pathOnFs, err := filesystemPath(u)
+		if err != nil {
+			return err
+		}
+
+		data, err := readCachedModule(fs, cacheDir, module)
+		if err != nil {
+			logger.WithField("module", specifier).Debug("The locked module isn't cached, fetching it...")
+			src, fetchErr := fetchRemoteModule(logger, u, specifier)
+			if fetchErr != nil {
+				return fetchErr
+			}
+			data = src.Data
+			if integrity(data) != module.Integrity {
+				return fmt.Errorf("the integrity of the remote module '%s' doesn't match the lockfile, "+
+					"its content has changed since it was locked; if that's expected, update the lockfile "+
+					"by running `k6 deps`", specifier)
+			}
+			if err = writeCachedModule(fs, cacheDir, module, data); err != nil {
+				return err
+			}
+		}
+		if err = fsext.WriteFile(filesystems[scheme], pathOnFs, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	if frozen {
+		filesystems["https"] = &frozenFs{Fs: filesystems["https"]}
+	}
+	return nil
+}
+
+// remoteModuleURL returns the URL of the remote module with the given
+// specifier, as it's resolved for the imports.
+func remoteModuleURL(specifier string) (*url.URL, bool) {
+	u, err := Resolve(&url.URL{Scheme: "https", Path: "/"}, specifier)
+	if err != nil || (u.Scheme != "https" && u.Opaque == "") {
+		return nil, false
+	}
+	return u, true
+}
+
+func integrity(data []byte) string {
+	sum := sha256.Sum256(data)
+	return integrityAlgoritm + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// cachedModulePath returns the path of the module in the cache directory,
+// which is based on its content, so the modules are never overwritten.
+func cachedModulePath(cacheDir string, module LockedModule) (string, error) {
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(module.Integrity, integrityAlgoritm))
+	if err != nil || len(sum) != sha256.Size {
+		return "", fmt.Errorf("invalid integrity '%s'", module.Integrity)
+	}
+	return filepath.Join(cacheDir, hex.EncodeToString(sum)), nil
+}
+
+func readCachedModule(fs fsext.Fs, cacheDir string, module LockedModule) ([]byte, error) {
+	p, err := cachedModulePath(cacheDir, module)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fsext.ReadFile(fs, p)
+	if err != nil {
+		return nil, err
+	}
+	if integrity(data) != module.Integrity {
+		return nil, fmt.Errorf("the cached module '%s' is corrupted", p)
+	}
+	return data, nil
+}
+
+func writeCachedModule(fs fsext.Fs, cacheDir string, module LockedModule, data []byte) error {
+	p, err := cachedModulePath(cacheDir, module)
+	if err != nil {
+		return err
+	}
+	if err = fs.MkdirAll(cacheDir, 0o755); err != nil {
+		return err
+	}
+	return fsext.WriteFile(fs, p, data, 0o644)
+}
+
+// frozenFs is the filesystem for the remote modules with a frozen lockfile,
+// which only has the locked modules and doesn't let any others be fetched.
+type frozenFs struct {
+	fsext.Fs
+}
+
+func (f *frozenFs) Open(name string) (afero.File, error) {
+	file, err := f.Fs.Open(name)
+	return file, f.wrapError(name, err)
+}
+
+func (f *frozenFs) OpenFile(name string, flag int, perm fs.FileMode) (afero.File, error) {
+	file, err := f.Fs.OpenFile(name, flag, perm)
+	return file, f.wrapError(name, err)
+}
+
+func (f *frozenFs) Stat(name string) (fs.FileInfo, error) {
+	fi, err := f.Fs.Stat(name)
+	return fi, f.wrapError(name, err)
+}
+
+// wrapError replaces the errors for the missing modules, so the loader
+// returns them instead of fetching the modules.
+func (f *frozenFs) wrapError(name string, err error) error {
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return fmt.Errorf("the remote module '%s' isn't in the lockfile, which is frozen; "+
+		"run `k6 deps` to add it", strings.TrimPrefix(filepath.ToSlash(name), "/"))
+}
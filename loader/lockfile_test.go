@@ -0,0 +1,121 @@
+package loader_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/lib/testutils/httpmultibin"
+	"go.k6.io/k6/loader"
+)
+
+//nolint:paralleltest // this touch the global http.DefaultTransport
+func TestLockfile(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	sr := tb.Replacer.Replace
+
+	oldHTTPTransport := http.DefaultTransport
+	http.DefaultTransport = tb.HTTPTransport
+	t.Cleanup(func() {
+		http.DefaultTransport = oldHTTPTransport
+	})
+
+	var version, requests int64
+	tb.Mux.HandleFunc("/lib.js", func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		_, err := fmt.Fprintf(w, "export const version = %d;", atomic.LoadInt64(&version))
+		assert.NoError(t, err)
+	})
+	tb.Mux.HandleFunc("/other.js", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := fmt.Fprint(w, "export default 1;")
+		assert.NoError(t, err)
+	})
+
+	libURL := sr("HTTPSBIN_URL/lib.js")
+	load := func(t *testing.T, filesystems map[string]fsext.Fs, specifier string) (*loader.SourceData, error) {
+		t.Helper()
+		u, err := loader.Resolve(&url.URL{Scheme: "file", Path: "/"}, specifier)
+		require.NoError(t, err)
+		return loader.Load(logger, filesystems, u, specifier)
+	}
+
+	fs := fsext.NewMemMapFs()
+	filesystems := loader.CreateFilesystems(fs)
+	_, err := load(t, filesystems, libURL)
+	require.NoError(t, err)
+
+	lock := loader.NewLockfile()
+	locked, err := lock.LockModules(filesystems, fs, "/.k6/modules", []string{libURL, "file:///script.js", "k6/http"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{libURL}, locked)
+	require.NoError(t, lock.Write(fs, "/k6.lock"))
+
+	lock, err = loader.ReadLockfile(fs, "/k6.lock")
+	require.NoError(t, err)
+	assert.Equal(t, []string{libURL}, lock.Specifiers())
+	assert.Equal(t, "sha256-", lock.Modules[libURL].Integrity[:7])
+
+	t.Run("cached", func(t *testing.T) {
+		atomic.StoreInt64(&requests, 0)
+		filesystems := loader.CreateFilesystems(fs)
+		require.NoError(t, loader.UseLockfile(logger, filesystems, lock, fs, "/.k6/modules", false))
+
+		src, err := load(t, filesystems, libURL)
+		require.NoError(t, err)
+		assert.Equal(t, "export const version = 0;", string(src.Data))
+		assert.Equal(t, int64(0), atomic.LoadInt64(&requests))
+	})
+
+	t.Run("not cached", func(t *testing.T) {
+		filesystems := loader.CreateFilesystems(fs)
+		require.NoError(t, loader.UseLockfile(logger, filesystems, lock, fsext.NewMemMapFs(), "/.k6/modules", false))
+
+		src, err := load(t, filesystems, libURL)
+		require.NoError(t, err)
+		assert.Equal(t, "export const version = 0;", string(src.Data))
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		atomic.StoreInt64(&version, 1)
+		t.Cleanup(func() { atomic.StoreInt64(&version, 0) })
+
+		filesystems := loader.CreateFilesystems(fs)
+		err := loader.UseLockfile(logger, filesystems, lock, fsext.NewMemMapFs(), "/.k6/modules", false)
+		require.ErrorContains(t, err, "the integrity of the remote module '"+libURL+"' doesn't match the lockfile")
+	})
+
+	t.Run("frozen", func(t *testing.T) {
+		filesystems := loader.CreateFilesystems(fs)
+		require.NoError(t, loader.UseLockfile(logger, filesystems, lock, fs, "/.k6/modules", true))
+
+		_, err := load(t, filesystems, libURL)
+		require.NoError(t, err)
+		_, err = load(t, filesystems, sr("HTTPSBIN_URL/other.js"))
+		require.ErrorContains(t, err, "isn't in the lockfile, which is frozen")
+	})
+
+	t.Run("not frozen", func(t *testing.T) {
+		filesystems := loader.CreateFilesystems(fs)
+		require.NoError(t, loader.UseLockfile(logger, filesystems, lock, fs, "/.k6/modules", false))
+
+		src, err := load(t, filesystems, sr("HTTPSBIN_URL/other.js"))
+		require.NoError(t, err)
+		assert.Equal(t, "export default 1;", string(src.Data))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require.NoError(t, fsext.WriteFile(fs, "/invalid.lock", []byte(`{"lockfileVersion": 2}`), 0o644))
+		_, err := loader.ReadLockfile(fs, "/invalid.lock")
+		require.ErrorContains(t, err, "unsupported version 2")
+	})
+}
@@ -0,0 +1,250 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+//nolint:gochecknoglobals
+var (
+	// nodeModuleExtensions are tried, in order, for the files without an
+	// extension in a package, as Node.js does for the require() calls.
+	nodeModuleExtensions = []string{".js", ".mjs", ".cjs"}
+	// nodeModuleConditions are the conditions of the "exports" in package.json
+	// that k6 supports, in the order of their priority.
+	nodeModuleConditions = []string{"k6", "import", "module", "default", "require"}
+)
+
+// packageJSON is the part of a package.json file that is used for resolving
+// the files of the package.
+type packageJSON struct {
+	Main    string          `json:"main"`
+	Module  string          `json:"module"`
+	Exports json.RawMessage `json:"exports"`
+}
+
+// NodeModulesResolver resolves the bare module specifiers (e.g. "lodash"),
+// which k6 doesn't support otherwise, from the node_modules directories, the
+// same as Node.js does. The files are read through the given filesystem, so
+// the package.json files and the modules end up in the archives, which can be
+// run without the node_modules directories.
+type NodeModulesResolver struct {
+	fs fsext.Fs
+
+	mx    sync.Mutex
+	cache map[string]*url.URL
+}
+
+// NewNodeModulesResolver returns a new NodeModulesResolver for the given
+// filesystem, which is usually the one for the file scheme.
+func NewNodeModulesResolver(fs fsext.Fs) *NodeModulesResolver {
+	return &NodeModulesResolver{fs: fs, cache: make(map[string]*url.URL)}
+}
+
+// Resolve resolves the given module specifier in the same way as Resolve does,
+// with the exception of the bare specifiers of local modules, which are
+// resolved from the node_modules directory in pwd or in any of its parents.
+func (r *NodeModulesResolver) Resolve(pwd *url.URL, moduleSpecifier string) (*url.URL, error) {
+	u, err := Resolve(pwd, moduleSpecifier)
+	var unresolvableErr unresolvableURLError
+	if err == nil || !errors.As(err, &unresolvableErr) || pwd.Scheme != "file" {
+		return u, err
+	}
+
+	key := pwd.String() + " " + moduleSpecifier
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if cached, ok := r.cache[key]; ok {
+		return cached, nil
+	}
+
+	name, subpath, ok := splitPackageSpecifier(moduleSpecifier)
+	if !ok {
+		return nil, err
+	}
+	for dir := pwd.Path; ; dir = path.Dir(dir) {
+		if path.Base(dir) != "node_modules" {
+			pkgDir := path.Join(dir, "node_modules", name)
+			if resolved, found, pkgErr := r.resolvePackage(pkgDir, subpath); pkgErr != nil {
+				return nil, fmt.Errorf("couldn't resolve %q from %q: %w", moduleSpecifier, pkgDir, pkgErr)
+			} else if found {
+				fileURL := &url.URL{Scheme: "file", Path: resolved}
+				r.cache[key] = fileURL
+				return fileURL, nil
+			}
+		}
+		if dir == "/" || dir == "." || dir == "" {
+			break
+		}
+	}
+	//nolint:stylecheck
+	return nil, fmt.Errorf("%w It also couldn't be found in any node_modules directory.", err)
+}
+
+// resolvePackage returns the path of the file for the subpath (which is "."
+// for the package itself) of the package in the given directory, if it exists.
+func (r *NodeModulesResolver) resolvePackage(pkgDir, subpath string) (string, bool, error) {
+	pkg, hasPackageJSON, err := r.readPackageJSON(pkgDir)
+	if err != nil {
+		return "", false, err
+	}
+	if hasPackageJSON && len(pkg.Exports) > 0 && string(pkg.Exports) != "null" {
+		target, exportsErr := resolvePackageExports(pkg.Exports, subpath)
+		if exportsErr != nil {
+			return "", false, exportsErr
+		}
+		resolved := path.Join(pkgDir, target)
+		if !r.isFile(resolved) {
+			return "", false, fmt.Errorf("the exported file %q doesn't exist", target)
+		}
+		return resolved, true, nil
+	}
+
+	if subpath != "." {
+		resolved, found := r.resolveFile(path.Join(pkgDir, subpath))
+		return resolved, found, nil
+	}
+	if !hasPackageJSON {
+		resolved, found := r.resolveFile(path.Join(pkgDir, "index"))
+		return resolved, found, nil
+	}
+	for _, main := range []string{pkg.Module, pkg.Main} {
+		if main == "" {
+			continue
+		}
+		if resolved, found := r.resolveFile(path.Join(pkgDir, main)); found {
+			return resolved, true, nil
+		}
+	}
+	resolved, found := r.resolveFile(path.Join(pkgDir, "index"))
+	return resolved, found, nil
+}
+
+// resolveFile returns the path of the file for the given path in a package,
+// trying the usual extensions and the index of the directory with that path.
+func (r *NodeModulesResolver) resolveFile(p string) (string, bool) {
+	candidates := []string{p}
+	for _, ext := range nodeModuleExtensions {
+		candidates = append(candidates, p+ext)
+	}
+	for _, ext := range nodeModuleExtensions {
+		candidates = append(candidates, path.Join(p, "index"+ext))
+	}
+	for _, candidate := range candidates {
+		if r.isFile(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func (r *NodeModulesResolver) isFile(p string) bool {
+	fi, err := r.fs.Stat(filepath.FromSlash(p))
+	return err == nil && !fi.IsDir()
+}
+
+func (r *NodeModulesResolver) readPackageJSON(pkgDir string) (packageJSON, bool, error) {
+	var pkg packageJSON
+	p := path.Join(pkgDir, "package.json")
+	if !r.isFile(p) {
+		return pkg, false, nil
+	}
+	data, err := fsext.ReadFile(r.fs, filepath.FromSlash(p))
+	if err != nil {
+		return pkg, false, err
+	}
+	if err = json.Unmarshal(data, &pkg); err != nil {
+		return pkg, false, fmt.Errorf("couldn't parse %q: %w", p, err)
+	}
+	return pkg, true, nil
+}
+
+// splitPackageSpecifier splits the bare specifier into the name of the
+// package, which can be scoped (e.g. "@scope/name"), and the subpath in it.
+func splitPackageSpecifier(moduleSpecifier string) (name, subpath string, ok bool) {
+	parts := strings.SplitN(moduleSpecifier, "/", 3)
+	n := 1
+	if strings.HasPrefix(moduleSpecifier, "@") {
+		if len(parts) < 2 || parts[1] == "" {
+			return "", "", false
+		}
+		n = 2
+	}
+	name = strings.Join(parts[:n], "/")
+	subpath = "."
+	if rest := strings.Join(parts[n:], "/"); rest != "" {
+		subpath = "./" + rest
+	}
+	return name, subpath, parts[0] != "" && !strings.Contains(moduleSpecifier, `\`)
+}
+
+// resolvePackageExports returns the target of the subpath in the "exports"
+// of package.json, which can be a string, an object with the conditions, or
+// an object with the subpaths (which can end with "*" for the patterns).
+func resolvePackageExports(exports json.RawMessage, subpath string) (string, error) {
+	var subpaths map[string]json.RawMessage
+	if err := json.Unmarshal(exports, &subpaths); err == nil && isSubpathsMap(subpaths) {
+		if target, ok := subpaths[subpath]; ok {
+			return resolveExportsTarget(target, "")
+		}
+		for key, target := range subpaths {
+			prefix, suffix, isPattern := strings.Cut(key, "*")
+			if isPattern && strings.HasPrefix(subpath, prefix) && strings.HasSuffix(subpath, suffix) &&
+				len(subpath) >= len(prefix)+len(suffix) {
+				return resolveExportsTarget(target, subpath[len(prefix):len(subpath)-len(suffix)])
+			}
+		}
+		return "", fmt.Errorf("the subpath %q isn't exported by the package", subpath)
+	}
+	if subpath != "." {
+		return "", fmt.Errorf("the subpath %q isn't exported by the package", subpath)
+	}
+	return resolveExportsTarget(exports, "")
+}
+
+func isSubpathsMap(m map[string]json.RawMessage) bool {
+	for key := range m {
+		return strings.HasPrefix(key, ".")
+	}
+	return false
+}
+
+// resolveExportsTarget returns the path for the target in the "exports" of
+// package.json, with the first of the supported conditions that matches.
+func resolveExportsTarget(target json.RawMessage, pattern string) (string, error) {
+	var s string
+	if err := json.Unmarshal(target, &s); err == nil {
+		if !strings.HasPrefix(s, "./") {
+			return "", fmt.Errorf("invalid target %q in the package exports", s)
+		}
+		return strings.ReplaceAll(s, "*", pattern), nil
+	}
+
+	var conditions map[string]json.RawMessage
+	if err := json.Unmarshal(target, &conditions); err == nil {
+		for _, condition := range nodeModuleConditions {
+			if conditional, ok := conditions[condition]; ok {
+				return resolveExportsTarget(conditional, pattern)
+			}
+		}
+		return "", errors.New("none of the conditions of the package exports are supported by k6")
+	}
+
+	var alternatives []json.RawMessage
+	if err := json.Unmarshal(target, &alternatives); err == nil {
+		for _, alternative := range alternatives {
+			if resolved, altErr := resolveExportsTarget(alternative, pattern); altErr == nil {
+				return resolved, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("invalid target %s in the package exports", target)
+}
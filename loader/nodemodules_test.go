@@ -0,0 +1,90 @@
+package loader_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/loader"
+)
+
+func TestNodeModulesResolver(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	files := map[string]string{
+		"/project/node_modules/main-only/package.json":       `{"main": "lib/main"}`,
+		"/project/node_modules/main-only/lib/main.js":        ``,
+		"/project/node_modules/main-only/lib/extra.js":       ``,
+		"/project/node_modules/no-package/index.js":          ``,
+		"/project/node_modules/module/package.json":          `{"main": "main.cjs", "module": "main.mjs"}`,
+		"/project/node_modules/module/main.mjs":              ``,
+		"/project/node_modules/module/main.cjs":              ``,
+		"/project/node_modules/@scope/pkg/package.json":      `{"exports": {".": "./dist/index.js", "./utils/*": "./dist/utils/*.js"}}`,
+		"/project/node_modules/@scope/pkg/dist/index.js":     ``,
+		"/project/node_modules/@scope/pkg/dist/utils/str.js": ``,
+		"/project/node_modules/conditions/package.json":      `{"exports": {"node": "./node.js", "import": "./esm.js", "require": "./cjs.js"}}`,
+		"/project/node_modules/conditions/esm.js":            ``,
+		"/project/sub/node_modules/main-only/package.json":   `{"main": "nested.js"}`,
+		"/project/sub/node_modules/main-only/nested.js":      ``,
+		"/project/sub/script.js":                             ``,
+	}
+	for name, data := range files {
+		require.NoError(t, fsext.WriteFile(fs, name, []byte(data), 0o644))
+	}
+	r := loader.NewNodeModulesResolver(fs)
+	pwd := &url.URL{Scheme: "file", Path: "/project/"}
+
+	testCases := map[string]string{
+		"main-only":            "file:///project/node_modules/main-only/lib/main.js",
+		"main-only/lib/extra":  "file:///project/node_modules/main-only/lib/extra.js",
+		"no-package":           "file:///project/node_modules/no-package/index.js",
+		"module":               "file:///project/node_modules/module/main.mjs",
+		"@scope/pkg":           "file:///project/node_modules/@scope/pkg/dist/index.js",
+		"@scope/pkg/utils/str": "file:///project/node_modules/@scope/pkg/dist/utils/str.js",
+		"conditions":           "file:///project/node_modules/conditions/esm.js",
+		"./sub/script.js":      "file:///project/sub/script.js",
+	}
+	for specifier, expected := range testCases {
+		specifier, expected := specifier, expected
+		t.Run(specifier, func(t *testing.T) {
+			t.Parallel()
+			u, err := r.Resolve(pwd, specifier)
+			require.NoError(t, err)
+			assert.Equal(t, expected, u.String())
+		})
+	}
+
+	t.Run("nested node_modules", func(t *testing.T) {
+		t.Parallel()
+		u, err := r.Resolve(&url.URL{Scheme: "file", Path: "/project/sub/"}, "main-only")
+		require.NoError(t, err)
+		assert.Equal(t, "file:///project/sub/node_modules/main-only/nested.js", u.String())
+
+		u, err = r.Resolve(&url.URL{Scheme: "file", Path: "/project/sub/"}, "no-package")
+		require.NoError(t, err)
+		assert.Equal(t, "file:///project/node_modules/no-package/index.js", u.String())
+	})
+
+	t.Run("not exported", func(t *testing.T) {
+		t.Parallel()
+		_, err := r.Resolve(pwd, "@scope/pkg/dist/index.js")
+		require.ErrorContains(t, err, `the subpath "./dist/index.js" isn't exported by the package`)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := r.Resolve(pwd, "lodash")
+		require.ErrorContains(t, err, `The moduleSpecifier "lodash" couldn't be recognised as something k6 supports.`)
+		require.ErrorContains(t, err, "It also couldn't be found in any node_modules directory.")
+	})
+
+	t.Run("remote modules", func(t *testing.T) {
+		t.Parallel()
+		_, err := r.Resolve(&url.URL{Scheme: "https", Host: "example.com", Path: "/"}, "main-only")
+		require.ErrorContains(t, err, `The moduleSpecifier "main-only" couldn't be recognised as something k6 supports.`)
+	})
+}
@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaselineTolerances are the tolerances used when comparing a test run
+// with a baseline without any explicit ones.
+var DefaultBaselineTolerances = []string{"http_req_duration.p(95)=10%"} //nolint:gochecknoglobals
+
+// BaselineTolerance is how much a statistic of a metric can change compared
+// to the baseline before it's considered a regression, e.g.
+// "http_req_duration.p(95)=10%" for an increase of at most 10% or
+// "checks.rate=-5%" for a decrease of at most 5%.
+type BaselineTolerance struct {
+	Source string
+	Metric string
+	Stat   string
+	// Percent is the allowed change in percent of the baseline value, it is
+	// negative for the statistics which regress when they decrease.
+	Percent float64
+}
+
+// ParseBaselineTolerance parses a tolerance in the metric.stat=percent% format.
+func ParseBaselineTolerance(source string) (BaselineTolerance, error) {
+	tolerance := BaselineTolerance{Source: source}
+
+	lhs, rhs, ok := strings.Cut(source, "=")
+	if !ok {
+		return tolerance, fmt.Errorf("invalid baseline tolerance '%s', it must be in the metric.stat=percent%% format", source)
+	}
+
+	// the values of the parametric statistics can contain dots too, e.g. p(99.9)
+	lhs = strings.TrimSpace(lhs)
+	end := len(lhs)
+	if strings.HasSuffix(lhs, ")") && strings.LastIndex(lhs, "(") > 0 {
+		end = strings.LastIndex(lhs, "(")
+	}
+	dot := strings.LastIndex(lhs[:end], ".")
+	if dot <= 0 || dot == len(lhs)-1 {
+		return tolerance, fmt.Errorf("invalid baseline tolerance '%s', missing the metric or the statistic", source)
+	}
+	tolerance.Metric, tolerance.Stat = lhs[:dot], lhs[dot+1:]
+	stat, err := normalizeBaselineStat(tolerance.Stat)
+	if err != nil {
+		return tolerance, fmt.Errorf("invalid baseline tolerance '%s': %w", source, err)
+	}
+	tolerance.Stat = stat
+
+	rhs = strings.TrimSpace(rhs)
+	if !strings.HasSuffix(rhs, "%") {
+		return tolerance, fmt.Errorf("invalid baseline tolerance '%s', the tolerance must be a percentage", source)
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(rhs, "%"), 64)
+	if err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) {
+		return tolerance, fmt.Errorf("invalid baseline tolerance '%s', malformed percentage", source)
+	}
+	tolerance.Percent = percent
+
+	return tolerance, nil
+}
+
+// normalizeBaselineStat checks the statistic and returns it in the format of
+// the summary, e.g. p(95) for p(95.0).
+func normalizeBaselineStat(stat string) (string, error) {
+	method, value, err := parseThresholdAggregationMethod(stat)
+	if err != nil {
+		return "", fmt.Errorf("invalid statistic '%s'; reason: %w", stat, err)
+	}
+	if method == tokenPercentile {
+		if _, err := parsePercentile(stat); err != nil {
+			return "", err
+		}
+	}
+	expression := thresholdExpression{AggregationMethod: method, AggregationValue: value}
+	return expression.SinkKey(), nil
+}
+
+// Baseline contains the values of the metrics of a previous test run, by
+// metric name and statistic.
+type Baseline map[string]map[string]float64
+
+// ParseBaseline parses the JSON summary of a previous test run, either the one
+// exported with --summary-export or the data passed to handleSummary().
+func ParseBaseline(data []byte) (Baseline, error) {
+	var summary struct {
+		Metrics map[string]json.RawMessage `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("malformed baseline summary: %w", err)
+	}
+	if len(summary.Metrics) == 0 {
+		return nil, errors.New("the baseline summary doesn't have any metrics")
+	}
+
+	baseline := make(Baseline, len(summary.Metrics))
+	for name, raw := range summary.Metrics {
+		var metric struct {
+			Values map[string]float64 `json:"values"`
+		}
+		if err := json.Unmarshal(raw, &metric); err == nil && metric.Values != nil {
+			baseline[name] = metric.Values
+			continue
+		}
+
+		// the --summary-export format has the values directly in the metric,
+		// next to the thresholds
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, fmt.Errorf("malformed values of the metric '%s' in the baseline summary: %w", name, err)
+		}
+		values := make(map[string]float64, len(fields))
+		for stat, rawValue := range fields {
+			var value float64
+			if json.Unmarshal(rawValue, &value) == nil {
+				values[stat] = value
+			}
+		}
+		// the rate is exported as the value for the rate metrics
+		if _, isRate := values["passes"]; isRate {
+			if value, ok := values["value"]; ok {
+				values["rate"] = value
+			}
+		}
+		baseline[name] = values
+	}
+	return baseline, nil
+}
+
+// BaselineDelta is the comparison of a statistic of a metric with its value
+// in the baseline.
+type BaselineDelta struct {
+	Tolerance BaselineTolerance
+	Type      MetricType
+	Contains  ValueType
+	Baseline  float64
+	Current   float64
+	// Change is the change of the statistic in percent of the baseline value.
+	Change    float64
+	Regressed bool
+}
+
+// BaselineReport is the comparison of a test run with a baseline.
+type BaselineReport struct {
+	Path   string
+	Deltas []BaselineDelta
+	// Missing are the tolerances whose statistic is missing either from the
+	// baseline or from the test run, so they couldn't be compared.
+	Missing []string
+}
+
+// Regressions returns the tolerances which have been exceeded.
+func (r *BaselineReport) Regressions() []string {
+	var regressions []string
+	for _, delta := range r.Deltas {
+		if delta.Regressed {
+			regressions = append(regressions, delta.Tolerance.Source)
+		}
+	}
+	return regressions
+}
+
+// CompareBaseline compares the statistics of the tolerances in the metrics of
+// a test run which lasted for the given duration with the baseline.
+func CompareBaseline(
+	baseline Baseline, tolerances []BaselineTolerance, metrics map[string]*Metric, duration time.Duration,
+) *BaselineReport {
+	report := &BaselineReport{}
+	for _, tolerance := range tolerances {
+		baselineValue, ok := baseline[tolerance.Metric][tolerance.Stat]
+		metric, observed := metrics[tolerance.Metric]
+		if !ok || !observed {
+			report.Missing = append(report.Missing, tolerance.Source)
+			continue
+		}
+		var expressions []*thresholdExpression
+		if method, value, err := parseThresholdAggregationMethod(tolerance.Stat); err == nil {
+			expressions = append(expressions, &thresholdExpression{AggregationMethod: method, AggregationValue: value})
+		}
+		values, err := sinkValues(metric.Sink, duration, expressions)
+		currentValue, ok := values[tolerance.Stat]
+		if err != nil || !ok {
+			report.Missing = append(report.Missing, tolerance.Source)
+			continue
+		}
+
+		delta := BaselineDelta{
+			Tolerance: tolerance,
+			Type:      metric.Type,
+			Contains:  metric.Contains,
+			Baseline:  baselineValue,
+			Current:   currentValue,
+		}
+		switch {
+		case baselineValue == currentValue:
+			delta.Change = 0
+		case baselineValue == 0:
+			delta.Change = math.Inf(1)
+			if currentValue < 0 {
+				delta.Change = math.Inf(-1)
+			}
+		default:
+			delta.Change = (currentValue - baselineValue) / math.Abs(baselineValue) * 100
+		}
+		if tolerance.Percent < 0 {
+			delta.Regressed = delta.Change < tolerance.Percent
+		} else {
+			delta.Regressed = delta.Change > tolerance.Percent
+		}
+		report.Deltas = append(report.Deltas, delta)
+	}
+	return report
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBaselineTolerance(t *testing.T) {
+	t.Parallel()
+
+	valid := map[string]BaselineTolerance{
+		"http_req_duration.p(95)=10%": {Metric: "http_req_duration", Stat: "p(95)", Percent: 10},
+		"http_req_duration.p(99.9)=5.5%": {
+			Metric: "http_req_duration", Stat: "p(99.9)", Percent: 5.5,
+		},
+		"http_req_duration.p(90.0) = 1%":        {Metric: "http_req_duration", Stat: "p(90)", Percent: 1},
+		"checks.rate=-5%":                       {Metric: "checks", Stat: "rate", Percent: -5},
+		"http_req_duration{url:a.b.c}.avg=0%":   {Metric: "http_req_duration{url:a.b.c}", Stat: "avg", Percent: 0},
+		"http_req_duration{url:a.b}.p(50)=200%": {Metric: "http_req_duration{url:a.b}", Stat: "p(50)", Percent: 200},
+		"iterations.count=-20%":                 {Metric: "iterations", Stat: "count", Percent: -20},
+		"vus.value=10%":                         {Metric: "vus", Stat: "value", Percent: 10},
+		"iteration_duration.med=10%":            {Metric: "iteration_duration", Stat: "med", Percent: 10},
+		"http_req_connecting.max=10%":           {Metric: "http_req_connecting", Stat: "max", Percent: 10},
+		"http_req_connecting.min=10%":           {Metric: "http_req_connecting", Stat: "min", Percent: 10},
+		"http_req_connecting.tmean(5.0)=10%":    {Metric: "http_req_connecting", Stat: "tmean(5)", Percent: 10},
+	}
+	for source, expected := range valid {
+		tolerance, err := ParseBaselineTolerance(source)
+		require.NoError(t, err, source)
+		expected.Source = source
+		assert.Equal(t, expected, tolerance)
+	}
+
+	invalid := map[string]string{
+		"http_req_duration.p(95)":       "metric.stat=percent% format",
+		"http_req_duration=10%":         "missing the metric or the statistic",
+		".avg=10%":                      "missing the metric or the statistic",
+		"http_req_duration.=10%":        "missing the metric or the statistic",
+		"http_req_duration.foo=10%":     "invalid statistic 'foo'",
+		"http_req_duration.p(101)=10%":  "provide a number between 0 and 100",
+		"http_req_duration.p(95)=10":    "the tolerance must be a percentage",
+		"http_req_duration.p(95)=abc%":  "malformed percentage",
+		"http_req_duration.p(95)=Inf%":  "malformed percentage",
+		"http_req_duration.p(95)=NaN%":  "malformed percentage",
+		"http_req_duration.p(95)=10%%":  "malformed percentage",
+		"http_req_duration.p(95)=-10 %": "malformed percentage",
+	}
+	for source, expectedErr := range invalid {
+		_, err := ParseBaselineTolerance(source)
+		assert.ErrorContains(t, err, expectedErr, source)
+	}
+}
+
+func TestParseBaseline(t *testing.T) {
+	t.Parallel()
+
+	t.Run("HandleSummaryData", func(t *testing.T) {
+		t.Parallel()
+		baseline, err := ParseBaseline([]byte(`{
+			"root_group": {},
+			"metrics": {
+				"http_req_duration": {"type": "trend", "contains": "time", "values": {"avg": 10, "p(95)": 20}},
+				"checks": {"type": "rate", "contains": "default", "values": {"rate": 0.5, "passes": 1, "fails": 1}}
+			}
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, Baseline{
+			"http_req_duration": {"avg": 10, "p(95)": 20},
+			"checks":            {"rate": 0.5, "passes": 1, "fails": 1},
+		}, baseline)
+	})
+
+	t.Run("SummaryExport", func(t *testing.T) {
+		t.Parallel()
+		baseline, err := ParseBaseline([]byte(`{
+			"root_group": {},
+			"metrics": {
+				"http_req_duration": {"avg": 10, "p(95)": 20, "thresholds": {"p(95)<100": false}},
+				"checks": {"value": 0.5, "passes": 1, "fails": 1}
+			}
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, Baseline{
+			"http_req_duration": {"avg": 10, "p(95)": 20},
+			"checks":            {"value": 0.5, "rate": 0.5, "passes": 1, "fails": 1},
+		}, baseline)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseBaseline([]byte(`{"metrics":`))
+		assert.ErrorContains(t, err, "malformed baseline summary")
+		_, err = ParseBaseline([]byte(`{"root_group": {}}`))
+		assert.ErrorContains(t, err, "the baseline summary doesn't have any metrics")
+		_, err = ParseBaseline([]byte(`{"metrics": {"checks": 1}}`))
+		assert.ErrorContains(t, err, "malformed values of the metric 'checks'")
+	})
+}
+
+func TestCompareBaseline(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	duration := registry.MustNewMetric("http_req_duration", Trend, Time)
+	checks := registry.MustNewMetric("checks", Rate)
+	errs := registry.MustNewMetric("errors", Counter)
+	for i := 1; i <= 100; i++ {
+		duration.Sink.Add(Sample{Value: float64(i)})
+	}
+	checks.Sink.Add(Sample{Value: 1})
+	checks.Sink.Add(Sample{Value: 0})
+	errs.Sink.Add(Sample{Value: 3})
+	observed := map[string]*Metric{"http_req_duration": duration, "checks": checks, "errors": errs}
+
+	baseline := Baseline{
+		"http_req_duration": {"avg": 50, "p(95)": 80, "med": 50.5},
+		"checks":            {"rate": 0.6},
+		"errors":            {"count": 0},
+		"vus":               {"value": 10},
+	}
+	var tolerances []BaselineTolerance
+	for _, source := range []string{
+		"http_req_duration.p(95)=10%", "http_req_duration.avg=10%", "http_req_duration.med=0%",
+		"checks.rate=-10%", "checks.rate=-20%", "errors.count=50%",
+		"http_req_duration.p(99)=10%", "vus.value=10%",
+	} {
+		tolerance, err := ParseBaselineTolerance(source)
+		require.NoError(t, err)
+		tolerances = append(tolerances, tolerance)
+	}
+
+	report := CompareBaseline(baseline, tolerances, observed, time.Second)
+	assert.Equal(t, []string{"http_req_duration.p(99)=10%", "vus.value=10%"}, report.Missing)
+	require.Len(t, report.Deltas, 6)
+
+	p95 := report.Deltas[0]
+	assert.Equal(t, Trend, p95.Type)
+	assert.Equal(t, Time, p95.Contains)
+	assert.Equal(t, 80.0, p95.Baseline)
+	assert.InDelta(t, 95.05, p95.Current, 0.01)
+	assert.InDelta(t, 18.81, p95.Change, 0.01)
+	assert.True(t, p95.Regressed)
+
+	avg := report.Deltas[1]
+	assert.InDelta(t, 1, avg.Change, 0.001)
+	assert.False(t, avg.Regressed)
+
+	med := report.Deltas[2]
+	assert.Equal(t, 0.0, med.Change)
+	assert.False(t, med.Regressed)
+
+	assert.InDelta(t, -16.67, report.Deltas[3].Change, 0.01)
+	assert.True(t, report.Deltas[3].Regressed)
+	assert.False(t, report.Deltas[4].Regressed)
+
+	assert.True(t, math.IsInf(report.Deltas[5].Change, 1))
+	assert.True(t, report.Deltas[5].Regressed)
+
+	assert.Equal(t, []string{"http_req_duration.p(95)=10%", "checks.rate=-10%", "errors.count=50%"}, report.Regressions())
+}
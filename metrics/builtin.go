@@ -7,18 +7,39 @@ const (
 	IterationDurationName = "iteration_duration"
 	DroppedIterationsName = "dropped_iterations"
 
+	BarrierWaitDurationName = "barrier_wait_duration"
+	QueueLengthName         = "queue_length"
+
+	EventLoopLagName = "event_loop_lag"
+
+	GeneratorCPUName     = "generator_cpu"
+	GeneratorMemoryName  = "generator_memory"
+	GeneratorGCPauseName = "generator_gc_pause"
+
+	SampleBufferFillName = "sample_buffer_fill"
+	SampleBufferFullName = "sample_buffer_full"
+
+	SLOApdexName    = "slo_apdex"
+	SLOBurnRateName = "slo_burn_rate"
+
 	ChecksName        = "checks"
 	GroupDurationName = "group_duration"
 
-	HTTPReqsName              = "http_reqs"
-	HTTPReqFailedName         = "http_req_failed"
-	HTTPReqDurationName       = "http_req_duration"
-	HTTPReqBlockedName        = "http_req_blocked"
-	HTTPReqConnectingName     = "http_req_connecting"
-	HTTPReqTLSHandshakingName = "http_req_tls_handshaking"
-	HTTPReqSendingName        = "http_req_sending"
-	HTTPReqWaitingName        = "http_req_waiting"
-	HTTPReqReceivingName      = "http_req_receiving"
+	HTTPReqsName                     = "http_reqs"
+	HTTPReqFailedName                = "http_req_failed"
+	HTTPReqDurationName              = "http_req_duration"
+	HTTPReqBlockedName               = "http_req_blocked"
+	HTTPReqConnectingName            = "http_req_connecting"
+	HTTPReqTLSHandshakingName        = "http_req_tls_handshaking"
+	HTTPReqProxyConnectingName       = "http_req_proxy_connecting"
+	HTTPReqTLSFullHandshakingName    = "http_req_tls_full_handshaking"
+	HTTPReqTLSResumedHandshakingName = "http_req_tls_resumed_handshaking"
+	HTTPReqSendingName               = "http_req_sending"
+	HTTPReqWaitingName               = "http_req_waiting"
+	HTTPReqReceivingName             = "http_req_receiving"
+	HTTPRespDiscardedName            = "http_resp_discarded"
+	HTTP2StreamResetsName            = "http2_stream_resets"
+	HTTP2GoAwaysName                 = "http2_goaways"
 
 	WSSessionsName         = "ws_sessions"
 	WSMessagesSentName     = "ws_msgs_sent"
@@ -41,20 +62,51 @@ type BuiltinMetrics struct {
 	IterationDuration *Metric
 	DroppedIterations *Metric
 
+	// Emitted by the k6/execution barriers and queues.
+	BarrierWaitDuration *Metric
+	QueueLength         *Metric
+
+	// The longest time a callback waited in the event loop of the VU in
+	// each iteration.
+	EventLoopLag *Metric
+
+	// Health of the load generator, emitted by the execution scheduler.
+	GeneratorCPU     *Metric
+	GeneratorMemory  *Metric
+	GeneratorGCPause *Metric
+
+	// Backpressure of the samples buffered by the VUs, emitted by the
+	// execution scheduler when the samples are batched.
+	SampleBufferFill *Metric
+	SampleBufferFull *Metric
+
+	// Emitted by the metrics engine for the SLOs in the options.
+	SLOApdex    *Metric
+	SLOBurnRate *Metric
+
 	// Runner-emitted.
 	Checks        *Metric
 	GroupDuration *Metric
 
 	// HTTP-related.
-	HTTPReqs              *Metric
-	HTTPReqFailed         *Metric
-	HTTPReqDuration       *Metric
-	HTTPReqBlocked        *Metric
-	HTTPReqConnecting     *Metric
-	HTTPReqTLSHandshaking *Metric
-	HTTPReqSending        *Metric
-	HTTPReqWaiting        *Metric
-	HTTPReqReceiving      *Metric
+	HTTPReqs                     *Metric
+	HTTPReqFailed                *Metric
+	HTTPReqDuration              *Metric
+	HTTPReqBlocked               *Metric
+	HTTPReqConnecting            *Metric
+	HTTPReqTLSHandshaking        *Metric
+	HTTPReqProxyConnecting       *Metric
+	HTTPReqTLSFullHandshaking    *Metric
+	HTTPReqTLSResumedHandshaking *Metric
+	HTTPReqSending               *Metric
+	HTTPReqWaiting               *Metric
+	HTTPReqReceiving             *Metric
+	// The bytes of the response bodies which were read but not kept.
+	HTTPRespDiscarded *Metric
+	// The RST_STREAM frames, and the GOAWAY frames with an error, received
+	// over the HTTP/2 connections.
+	HTTP2StreamResets *Metric
+	HTTP2GoAways      *Metric
 
 	// Websocket-related
 	WSSessions         *Metric
@@ -81,18 +133,39 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		IterationDuration: registry.MustNewMetric(IterationDurationName, Trend, Time),
 		DroppedIterations: registry.MustNewMetric(DroppedIterationsName, Counter),
 
+		BarrierWaitDuration: registry.MustNewMetric(BarrierWaitDurationName, Trend, Time),
+		QueueLength:         registry.MustNewMetric(QueueLengthName, Gauge),
+
+		EventLoopLag: registry.MustNewMetric(EventLoopLagName, Trend, Time),
+
+		GeneratorCPU:     registry.MustNewMetric(GeneratorCPUName, Gauge),
+		GeneratorMemory:  registry.MustNewMetric(GeneratorMemoryName, Gauge, Data),
+		GeneratorGCPause: registry.MustNewMetric(GeneratorGCPauseName, Trend, Time),
+
+		SampleBufferFill: registry.MustNewMetric(SampleBufferFillName, Gauge),
+		SampleBufferFull: registry.MustNewMetric(SampleBufferFullName, Counter),
+
+		SLOApdex:    registry.MustNewMetric(SLOApdexName, Gauge),
+		SLOBurnRate: registry.MustNewMetric(SLOBurnRateName, Gauge),
+
 		Checks:        registry.MustNewMetric(ChecksName, Rate),
 		GroupDuration: registry.MustNewMetric(GroupDurationName, Trend, Time),
 
-		HTTPReqs:              registry.MustNewMetric(HTTPReqsName, Counter),
-		HTTPReqFailed:         registry.MustNewMetric(HTTPReqFailedName, Rate),
-		HTTPReqDuration:       registry.MustNewMetric(HTTPReqDurationName, Trend, Time),
-		HTTPReqBlocked:        registry.MustNewMetric(HTTPReqBlockedName, Trend, Time),
-		HTTPReqConnecting:     registry.MustNewMetric(HTTPReqConnectingName, Trend, Time),
-		HTTPReqTLSHandshaking: registry.MustNewMetric(HTTPReqTLSHandshakingName, Trend, Time),
-		HTTPReqSending:        registry.MustNewMetric(HTTPReqSendingName, Trend, Time),
-		HTTPReqWaiting:        registry.MustNewMetric(HTTPReqWaitingName, Trend, Time),
-		HTTPReqReceiving:      registry.MustNewMetric(HTTPReqReceivingName, Trend, Time),
+		HTTPReqs:                     registry.MustNewMetric(HTTPReqsName, Counter),
+		HTTPReqFailed:                registry.MustNewMetric(HTTPReqFailedName, Rate),
+		HTTPReqDuration:              registry.MustNewMetric(HTTPReqDurationName, Trend, Time),
+		HTTPReqBlocked:               registry.MustNewMetric(HTTPReqBlockedName, Trend, Time),
+		HTTPReqConnecting:            registry.MustNewMetric(HTTPReqConnectingName, Trend, Time),
+		HTTPReqTLSHandshaking:        registry.MustNewMetric(HTTPReqTLSHandshakingName, Trend, Time),
+		HTTPReqProxyConnecting:       registry.MustNewMetric(HTTPReqProxyConnectingName, Trend, Time),
+		HTTPReqTLSFullHandshaking:    registry.MustNewMetric(HTTPReqTLSFullHandshakingName, Trend, Time),
+		HTTPReqTLSResumedHandshaking: registry.MustNewMetric(HTTPReqTLSResumedHandshakingName, Trend, Time),
+		HTTPReqSending:               registry.MustNewMetric(HTTPReqSendingName, Trend, Time),
+		HTTPReqWaiting:               registry.MustNewMetric(HTTPReqWaitingName, Trend, Time),
+		HTTPReqReceiving:             registry.MustNewMetric(HTTPReqReceivingName, Trend, Time),
+		HTTPRespDiscarded:            registry.MustNewMetric(HTTPRespDiscardedName, Counter, Data),
+		HTTP2StreamResets:            registry.MustNewMetric(HTTP2StreamResetsName, Counter),
+		HTTP2GoAways:                 registry.MustNewMetric(HTTP2GoAwaysName, Counter),
 
 		WSSessions:         registry.MustNewMetric(WSSessionsName, Counter),
 		WSMessagesSent:     registry.MustNewMetric(WSMessagesSentName, Counter),
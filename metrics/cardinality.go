@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The actions of a CardinalityGuard for the samples of the new time series
+// once its limit has been reached.
+const (
+	// CardinalityWarn only logs a warning, all the samples are kept.
+	CardinalityWarn = "warn"
+	// CardinalityDrop drops the samples of the new time series.
+	CardinalityDrop = "drop"
+	// CardinalityOverflow moves the samples of the new time series to a single
+	// time series per metric, tagged with CardinalityOverflowTag.
+	CardinalityOverflow = "overflow"
+)
+
+// CardinalityOverflowTag is the only tag of the time series the samples are
+// moved to by the CardinalityOverflow action.
+const CardinalityOverflowTag = "__cardinality_overflow__"
+
+// cardinalityTopOffenders is how many metrics and tags are reported.
+const cardinalityTopOffenders = 5
+
+// ValidateCardinalityAction checks that the action is one of the known ones.
+func ValidateCardinalityAction(action string) error {
+	switch action {
+	case CardinalityWarn, CardinalityDrop, CardinalityOverflow:
+		return nil
+	default:
+		return fmt.Errorf("invalid time series limit action '%s', it must be one of %s, %s or %s",
+			action, CardinalityWarn, CardinalityDrop, CardinalityOverflow)
+	}
+}
+
+// CardinalityGuard tracks the unique time series, i.e. metric and tags
+// combinations, of the samples and applies its action to the samples of the
+// new ones once there are more than its limit, so that a tag with unbounded
+// values, like a URL with IDs in it, can't exhaust the memory.
+type CardinalityGuard struct {
+	limit    int
+	action   string
+	logger   logrus.FieldLogger
+	overflow *TagSet
+
+	mu              sync.Mutex
+	series          map[TimeSeries]struct{}
+	metricSeries    map[*Metric]int
+	tagValues       map[string]map[string]struct{}
+	limitReached    bool
+	rejectedSamples uint64
+}
+
+// NewCardinalityGuard returns a guard allowing up to limit unique time series.
+func NewCardinalityGuard(
+	registry *Registry, limit int, action string, logger logrus.FieldLogger,
+) (*CardinalityGuard, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("the time series limit must be positive, got %d", limit)
+	}
+	if err := ValidateCardinalityAction(action); err != nil {
+		return nil, err
+	}
+	return &CardinalityGuard{
+		limit:        limit,
+		action:       action,
+		logger:       logger.WithField("component", "cardinality-guard"),
+		overflow:     registry.RootTagSet().With(CardinalityOverflowTag, "true"),
+		series:       make(map[TimeSeries]struct{}),
+		metricSeries: make(map[*Metric]int),
+		tagValues:    make(map[string]map[string]struct{}),
+	}, nil
+}
+
+// Process tracks the time series of the samples and returns them, without the
+// samples of the time series over the limit if they are dropped or moved to
+// the overflow time series. The containers without such samples are returned
+// unchanged.
+func (cg *CardinalityGuard) Process(containers []SampleContainer) []SampleContainer {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+
+	result := containers[:0:0]
+	changed := false
+	for i, sc := range containers {
+		samples := sc.GetSamples()
+		kept := samples[:0:0]
+		containerChanged := false
+		for j, s := range samples {
+			s, status := cg.admit(s)
+			if status == admitted {
+				if containerChanged {
+					kept = append(kept, s)
+				}
+				continue
+			}
+			if !containerChanged {
+				containerChanged = true
+				kept = append(kept, samples[:j]...)
+			}
+			if status == moved {
+				kept = append(kept, s)
+			}
+		}
+		if !containerChanged {
+			if changed {
+				result = append(result, sc)
+			}
+			continue
+		}
+		if !changed {
+			changed = true
+			result = append(result, containers[:i]...)
+		}
+		if len(kept) > 0 {
+			result = append(result, rebuildContainer(sc, kept))
+		}
+	}
+	if !changed {
+		return containers
+	}
+	return result
+}
+
+type admission int
+
+const (
+	admitted admission = iota
+	moved
+	rejected
+)
+
+func (cg *CardinalityGuard) admit(s Sample) (Sample, admission) {
+	if _, ok := cg.series[s.TimeSeries]; ok {
+		return s, admitted
+	}
+	if len(cg.series) >= cg.limit {
+		if !cg.limitReached {
+			cg.limitReached = true
+			cg.logger.Warnf("The test has more than %d unique time series, the new ones are handled with "+
+				"the '%s' action; this is usually caused by tags with unbounded values, like URLs with IDs "+
+				"in them, which can be grouped with the 'name' tag", cg.limit, cg.action)
+		}
+		switch cg.action {
+		case CardinalityDrop:
+			cg.rejectedSamples++
+			return s, rejected
+		case CardinalityOverflow:
+			cg.rejectedSamples++
+			s.TimeSeries = TimeSeries{Metric: s.Metric, Tags: cg.overflow}
+			s.Metadata = nil
+			if _, ok := cg.series[s.TimeSeries]; !ok {
+				cg.series[s.TimeSeries] = struct{}{}
+				cg.metricSeries[s.Metric]++
+			}
+			return s, moved
+		}
+	}
+
+	cg.series[s.TimeSeries] = struct{}{}
+	cg.metricSeries[s.Metric]++
+	if s.Tags == nil {
+		return s, admitted
+	}
+	for key, value := range s.Tags.Map() {
+		values, ok := cg.tagValues[key]
+		if !ok {
+			values = make(map[string]struct{})
+			cg.tagValues[key] = values
+		}
+		values[value] = struct{}{}
+	}
+	return s, admitted
+}
+
+func rebuildContainer(sc SampleContainer, samples []Sample) SampleContainer {
+	if cs, ok := sc.(ConnectedSamples); ok {
+		return ConnectedSamples{Samples: samples, Tags: cs.Tags, Time: cs.Time}
+	}
+	return Samples(samples)
+}
+
+// CardinalityOffender is a metric or a tag with many time series.
+type CardinalityOffender struct {
+	Name string
+	// Series is the number of time series of a metric, or the number of
+	// distinct values of a tag.
+	Series int
+}
+
+// CardinalityReport describes the time series seen by a CardinalityGuard.
+type CardinalityReport struct {
+	Limit        int
+	Action       string
+	Series       int
+	LimitReached bool
+	// RejectedSamples is how many samples have been dropped or moved to the
+	// overflow time series.
+	RejectedSamples uint64
+	TopMetrics      []CardinalityOffender
+	TopTags         []CardinalityOffender
+}
+
+// Report returns the number of time series and the metrics and tags with the
+// most of them.
+func (cg *CardinalityGuard) Report() CardinalityReport {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+
+	metricCounts := make(map[string]int, len(cg.metricSeries))
+	for m, n := range cg.metricSeries {
+		metricCounts[m.Name] = n
+	}
+	tags := make(map[string]int, len(cg.tagValues))
+	for key, values := range cg.tagValues {
+		tags[key] = len(values)
+	}
+	return CardinalityReport{
+		Limit:           cg.limit,
+		Action:          cg.action,
+		Series:          len(cg.series),
+		LimitReached:    cg.limitReached,
+		RejectedSamples: cg.rejectedSamples,
+		TopMetrics:      topOffenders(metricCounts),
+		TopTags:         topOffenders(tags),
+	}
+}
+
+func topOffenders(counts map[string]int) []CardinalityOffender {
+	offenders := make([]CardinalityOffender, 0, len(counts))
+	for name, n := range counts {
+		offenders = append(offenders, CardinalityOffender{Name: name, Series: n})
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].Series != offenders[j].Series {
+			return offenders[i].Series > offenders[j].Series
+		}
+		return offenders[i].Name < offenders[j].Name
+	})
+	if len(offenders) > cardinalityTopOffenders {
+		offenders = offenders[:cardinalityTopOffenders]
+	}
+	return offenders
+}
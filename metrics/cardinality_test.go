@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCardinalityGuard(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	logger := logrus.New()
+	_, err := NewCardinalityGuard(registry, 0, CardinalityWarn, logger)
+	assert.EqualError(t, err, "the time series limit must be positive, got 0")
+	_, err = NewCardinalityGuard(registry, 10, "ignore", logger)
+	assert.EqualError(t, err, "invalid time series limit action 'ignore', it must be one of warn, drop or overflow")
+}
+
+func TestCardinalityGuard(t *testing.T) {
+	t.Parallel()
+
+	newSamples := func(registry *Registry) (Samples, *Metric, *Metric) {
+		reqs := registry.MustNewMetric("http_reqs", Counter)
+		vus := registry.MustNewMetric("vus", Gauge)
+		samples := Samples{{TimeSeries: TimeSeries{Metric: vus, Tags: registry.RootTagSet()}, Time: time.Now()}}
+		for i := 0; i < 5; i++ {
+			tags := registry.RootTagSet().With("method", "GET").With("url", fmt.Sprintf("http://example.com/%d", i))
+			samples = append(samples,
+				Sample{TimeSeries: TimeSeries{Metric: reqs, Tags: tags}, Time: time.Now(), Value: 1},
+				Sample{TimeSeries: TimeSeries{Metric: reqs, Tags: tags}, Time: time.Now(), Value: 1},
+			)
+		}
+		return samples, reqs, vus
+	}
+
+	t.Run("warn", func(t *testing.T) {
+		t.Parallel()
+
+		registry := NewRegistry()
+		logger, hook := test.NewNullLogger()
+		samples, _, _ := newSamples(registry)
+		cg, err := NewCardinalityGuard(registry, 3, CardinalityWarn, logger)
+		require.NoError(t, err)
+
+		containers := []SampleContainer{samples}
+		assert.Equal(t, containers, cg.Process(containers))
+		require.Len(t, hook.AllEntries(), 1)
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "more than 3 unique time series")
+
+		report := cg.Report()
+		assert.True(t, report.LimitReached)
+		assert.Equal(t, 6, report.Series)
+		assert.Equal(t, uint64(0), report.RejectedSamples)
+		assert.Equal(t, []CardinalityOffender{{Name: "http_reqs", Series: 5}, {Name: "vus", Series: 1}}, report.TopMetrics)
+		assert.Equal(t, []CardinalityOffender{{Name: "url", Series: 5}, {Name: "method", Series: 1}}, report.TopTags)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		t.Parallel()
+
+		registry := NewRegistry()
+		samples, _, vus := newSamples(registry)
+		cg, err := NewCardinalityGuard(registry, 3, CardinalityDrop, logrus.New())
+		require.NoError(t, err)
+
+		unchanged := Samples{samples[0]}
+		result := cg.Process([]SampleContainer{unchanged, samples})
+		require.Len(t, result, 2)
+		assert.Equal(t, unchanged, result[0])
+		kept := result[1].GetSamples()
+		require.Len(t, kept, 5)
+		assert.Same(t, vus, kept[0].Metric)
+		assert.Equal(t, "http://example.com/1", kept[4].Tags.Map()["url"])
+
+		report := cg.Report()
+		assert.Equal(t, 3, report.Series)
+		assert.Equal(t, uint64(6), report.RejectedSamples)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		t.Parallel()
+
+		registry := NewRegistry()
+		samples, reqs, _ := newSamples(registry)
+		cg, err := NewCardinalityGuard(registry, 3, CardinalityOverflow, logrus.New())
+		require.NoError(t, err)
+
+		connected := ConnectedSamples{Samples: samples, Tags: registry.RootTagSet(), Time: time.Now()}
+		result := cg.Process([]SampleContainer{connected})
+		require.Len(t, result, 1)
+		processed, ok := result[0].(ConnectedSamples)
+		require.True(t, ok)
+		require.Len(t, processed.Samples, 11)
+		for _, s := range processed.Samples[5:] {
+			assert.Same(t, reqs, s.Metric)
+			assert.Equal(t, map[string]string{CardinalityOverflowTag: "true"}, s.Tags.Map())
+		}
+
+		report := cg.Report()
+		assert.Equal(t, 4, report.Series)
+		assert.Equal(t, uint64(6), report.RejectedSamples)
+		assert.Equal(t, []CardinalityOffender{{Name: "http_reqs", Series: 3}, {Name: "vus", Series: 1}}, report.TopMetrics)
+	})
+}
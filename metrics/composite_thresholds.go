@@ -0,0 +1,440 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/types"
+)
+
+// CompositeThresholds are the thresholds on an arithmetic expression of the
+// aggregated values of several metrics, like the ratio of the errors to the
+// requests: `errors.count / http_reqs.count < 0.01`.
+type CompositeThresholds struct {
+	Thresholds []*CompositeThreshold
+	Abort      bool
+}
+
+// CompositeThreshold is a single threshold of CompositeThresholds.
+type CompositeThreshold struct {
+	// Source is the text based source of the threshold
+	Source string
+	// LastFailed is a marker if the last testing of this threshold failed
+	LastFailed bool
+	// AbortOnFail marks if a given threshold fails that the whole test should be aborted
+	AbortOnFail bool
+	// AbortGracePeriod is a the minimum amount of time a test should be running before a failing
+	// this threshold will abort the test
+	AbortGracePeriod types.NullDuration
+	// parsed is the expression parsed from the Source
+	parsed *compositeExpression
+}
+
+// compositeExpression holds the parsed result of a composite threshold
+// expression, of the form: `arithmetic operator value`. As defined by the
+// following BNF:
+// ```
+// expression -> sum whitespace* operator whitespace* float
+// sum        -> product (("+" | "-") product)*
+// product    -> unary (("*" | "/") unary)*
+// unary      -> "-" unary | primary
+// primary    -> float | operand | "(" sum ")"
+// operand    -> metric_name ("{" submetric "}")? "." aggregation_method
+// ```
+// With the operators and the aggregation methods of the thresholds.
+type compositeExpression struct {
+	Arithmetic *compositeTerm
+	Operator   string
+	Value      float64
+}
+
+// compositeTerm is a node of the arithmetic of a composite expression: a
+// number, an operand or an operation on one or two terms.
+type compositeTerm struct {
+	// Operation is one of + - * / or n for the negation, 0 for the leaves.
+	Operation   byte
+	Left, Right *compositeTerm
+
+	Number  float64
+	Operand *compositeOperand
+}
+
+// compositeOperand is an aggregated value of a metric in a composite expression.
+type compositeOperand struct {
+	// Metric is the name of the metric, or of the submetric.
+	Metric     string
+	Expression thresholdExpression
+}
+
+// Parse parses the thresholds and fills each CompositeThreshold.parsed field
+// with the result.
+func (cts *CompositeThresholds) Parse() error {
+	for _, t := range cts.Thresholds {
+		parsed, err := parseCompositeExpression(t.Source)
+		if err != nil {
+			return err
+		}
+		t.parsed = parsed
+	}
+	return nil
+}
+
+// Validate ensures the metrics of the parsed expressions exist and support
+// their aggregation methods.
+func (cts *CompositeThresholds) Validate(name string, r *Registry) error {
+	for _, t := range cts.Thresholds {
+		for _, operand := range t.parsed.operands() {
+			metricName, _, err := ParseMetricName(operand.Metric)
+			if err != nil {
+				err = fmt.Errorf("%w %q defined on %s; reason: %v", ErrInvalidThreshold, t.Source, name, err)
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+			metric := r.Get(metricName)
+			if metric == nil {
+				err := fmt.Errorf("%w %q defined on %s; reason: no metric name %q found",
+					ErrInvalidThreshold, t.Source, name, metricName)
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+			if !metric.Type.supportsAggregationMethod(operand.Expression.AggregationMethod) {
+				err := fmt.Errorf(
+					"%w %q defined on %s; reason: "+
+						"unsupported aggregation method %s on metric %s of type %s. "+
+						"supported aggregation methods for this metric are: %s",
+					ErrInvalidThreshold, t.Source, name,
+					operand.Expression.AggregationMethod, metricName, metric.Type,
+					strings.Join(metric.Type.supportedAggregationMethods(), ", "),
+				)
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+		}
+	}
+	return nil
+}
+
+// Metrics returns the names of the metrics and submetrics of the parsed
+// expressions.
+func (cts *CompositeThresholds) Metrics() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, t := range cts.Thresholds {
+		if t.parsed == nil {
+			continue
+		}
+		for _, operand := range t.parsed.operands() {
+			if !seen[operand.Metric] {
+				seen[operand.Metric] = true
+				names = append(names, operand.Metric)
+			}
+		}
+	}
+	return names
+}
+
+// Run evaluates the thresholds with the sinks of the given metrics, by name,
+// and returns if any of them fails. The thresholds with values which can't be
+// computed yet, like a division by a counter without samples, are ignored.
+func (cts *CompositeThresholds) Run(metrics map[string]*Metric, duration time.Duration) (bool, error) {
+	succeeded := true
+	for i, t := range cts.Thresholds {
+		lhs, ok, err := t.parsed.Arithmetic.eval(metrics, duration)
+		if err != nil {
+			return false, fmt.Errorf("composite threshold %d run error: %w", i, err)
+		}
+		if !ok || math.IsNaN(lhs) || math.IsInf(lhs, 0) {
+			continue
+		}
+
+		passes, err := applyThresholdOperator(t.Source, lhs, t.parsed.Operator, t.parsed.Value)
+		if err != nil {
+			return false, fmt.Errorf("composite threshold %d run error: %w", i, err)
+		}
+		t.LastFailed = !passes
+		if passes {
+			continue
+		}
+
+		succeeded = false
+		if cts.Abort || !t.AbortOnFail {
+			continue
+		}
+		cts.Abort = !t.AbortGracePeriod.Valid ||
+			t.AbortGracePeriod.Duration < types.Duration(duration)
+	}
+	return succeeded, nil
+}
+
+// UnmarshalJSON is implementation of json.Unmarshaler
+func (cts *CompositeThresholds) UnmarshalJSON(data []byte) error {
+	var configs []thresholdConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return err
+	}
+
+	cts.Thresholds = make([]*CompositeThreshold, len(configs))
+	for i, config := range configs {
+		cts.Thresholds[i] = &CompositeThreshold{
+			Source:           config.Threshold,
+			AbortOnFail:      config.AbortOnFail,
+			AbortGracePeriod: config.AbortGracePeriod,
+		}
+	}
+	cts.Abort = false
+
+	return nil
+}
+
+// MarshalJSON is implementation of json.Marshaler
+func (cts CompositeThresholds) MarshalJSON() ([]byte, error) {
+	configs := make([]thresholdConfig, len(cts.Thresholds))
+	for i, t := range cts.Thresholds {
+		configs[i].Threshold = t.Source
+		configs[i].AbortOnFail = t.AbortOnFail
+		configs[i].AbortGracePeriod = t.AbortGracePeriod
+	}
+
+	return MarshalJSONWithoutHTMLEscape(configs)
+}
+
+var (
+	_ json.Unmarshaler = &CompositeThresholds{}
+	_ json.Marshaler   = &CompositeThresholds{}
+)
+
+func (ce *compositeExpression) operands() []*compositeOperand {
+	var operands []*compositeOperand
+	var walk func(term *compositeTerm)
+	walk = func(term *compositeTerm) {
+		if term == nil {
+			return
+		}
+		if term.Operand != nil {
+			operands = append(operands, term.Operand)
+		}
+		walk(term.Left)
+		walk(term.Right)
+	}
+	walk(ce.Arithmetic)
+	return operands
+}
+
+// eval computes the value of the term, ok is false when some of the values of
+// its operands aren't available yet.
+func (ct *compositeTerm) eval(metrics map[string]*Metric, duration time.Duration) (float64, bool, error) {
+	switch ct.Operation {
+	case 0:
+		if ct.Operand == nil {
+			return ct.Number, true, nil
+		}
+		metric, ok := metrics[ct.Operand.Metric]
+		if !ok {
+			return 0, false, nil
+		}
+		values, err := sinkValues(metric.Sink, duration, []*thresholdExpression{&ct.Operand.Expression})
+		if err != nil {
+			return 0, false, err
+		}
+		value, ok := values[ct.Operand.Expression.SinkKey()]
+		return value, ok, nil
+	case 'n':
+		value, ok, err := ct.Left.eval(metrics, duration)
+		return -value, ok, err
+	}
+
+	left, ok, err := ct.Left.eval(metrics, duration)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	right, ok, err := ct.Right.eval(metrics, duration)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	switch ct.Operation {
+	case '+':
+		return left + right, true, nil
+	case '-':
+		return left - right, true, nil
+	case '*':
+		return left * right, true, nil
+	default:
+		return left / right, true, nil
+	}
+}
+
+// parseCompositeExpression parses a composite threshold expression, like
+// `(errors.count + timeouts.count) / http_reqs.count < 0.01`.
+func parseCompositeExpression(input string) (*compositeExpression, error) {
+	arithmetic, operator, value, err := scanThresholdExpression(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing composite threshold expression %q; reason: %w", input, err)
+	}
+
+	p := &compositeParser{input: arithmetic}
+	term, err := p.parseSum()
+	if err == nil && p.skipSpaces() < len(p.input) {
+		err = fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing composite threshold expression's %q left hand side; "+
+			"reason: %w", input, err)
+	}
+
+	parsedValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing composite threshold expression's %q right hand side; "+
+			"reason: %w", input, err)
+	}
+
+	return &compositeExpression{Arithmetic: term, Operator: operator, Value: parsedValue}, nil
+}
+
+// compositeParser is a recursive descent parser of the arithmetic of
+// composite threshold expressions.
+type compositeParser struct {
+	input string
+	pos   int
+}
+
+func (p *compositeParser) skipSpaces() int {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+	return p.pos
+}
+
+func (p *compositeParser) peek() byte {
+	if p.skipSpaces() >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *compositeParser) parseSum() (*compositeTerm, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &compositeTerm{Operation: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *compositeParser) parseProduct() (*compositeTerm, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &compositeTerm{Operation: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *compositeParser) parseUnary() (*compositeTerm, error) {
+	if p.peek() != '-' {
+		return p.parsePrimary()
+	}
+	p.pos++
+	term, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &compositeTerm{Operation: 'n', Left: term}, nil
+}
+
+func (p *compositeParser) parsePrimary() (*compositeTerm, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		term, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis at position %d", p.pos)
+		}
+		p.pos++
+		return term, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		number, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed number %q", p.input[start:p.pos])
+		}
+		return &compositeTerm{Number: number}, nil
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &compositeTerm{Operand: operand}, nil
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of the expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+	}
+}
+
+func (p *compositeParser) parseOperand() (*compositeOperand, error) {
+	start := p.pos
+	for p.pos < len(p.input) && isMetricNameChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '{' {
+		end := strings.IndexByte(p.input[p.pos:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("missing ending bracket of the submetric at position %d", p.pos)
+		}
+		p.pos += end + 1
+	}
+	metric := p.input[start:p.pos]
+
+	if p.pos >= len(p.input) || p.input[p.pos] != '.' {
+		return nil, fmt.Errorf("missing aggregation method of the metric %q", metric)
+	}
+	p.pos++
+	methodStart := p.pos
+	for p.pos < len(p.input) && isMetricNameChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		end := strings.IndexByte(p.input[p.pos:], ')')
+		if end < 0 {
+			return nil, fmt.Errorf("missing closing parenthesis of the percentile at position %d", p.pos)
+		}
+		p.pos += end + 1
+	}
+	method, value, err := parseThresholdAggregationMethod(p.input[methodStart:p.pos])
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregation method of the metric %q; reason: %w", metric, err)
+	}
+
+	return &compositeOperand{
+		Metric:     metric,
+		Expression: thresholdExpression{AggregationMethod: method, AggregationValue: value},
+	}, nil
+}
+
+func isMetricNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
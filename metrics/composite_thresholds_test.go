@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestParseCompositeExpression(t *testing.T) {
+	t.Parallel()
+
+	expression, err := parseCompositeExpression("(errors.count + timeouts{type:read}.count) / -http_reqs.rate*2 >= 0.5")
+	require.NoError(t, err)
+	assert.Equal(t, tokenGreaterEqual, expression.Operator)
+	assert.Equal(t, 0.5, expression.Value)
+
+	operands := expression.operands()
+	require.Len(t, operands, 3)
+	assert.Equal(t, "errors", operands[0].Metric)
+	assert.Equal(t, "timeouts{type:read}", operands[1].Metric)
+	assert.Equal(t, "http_reqs", operands[2].Metric)
+	assert.Equal(t, tokenRate, operands[2].Expression.AggregationMethod)
+
+	expression, err = parseCompositeExpression("http_req_duration.p(99.9) - 2 * http_req_waiting.avg < 100")
+	require.NoError(t, err)
+	operands = expression.operands()
+	require.Len(t, operands, 2)
+	assert.Equal(t, thresholdExpression{AggregationMethod: tokenPercentile, AggregationValue: null.FloatFrom(99.9)},
+		operands[0].Expression)
+
+	invalid := map[string]string{
+		"errors.count":                    "malformed threshold expression",
+		"errors < 1":                      "missing aggregation method of the metric \"errors\"",
+		"errors.foo < 1":                  "invalid aggregation method of the metric \"errors\"",
+		"(errors.count < 1":               "missing closing parenthesis",
+		"errors.count + < 1":              "unexpected end of the expression",
+		"errors.count errors.count < 1":   "unexpected \"errors.count\"",
+		"errors{a:b.count < 1":            "missing ending bracket of the submetric",
+		"errors.count / reqs.count < abc": "right hand side",
+	}
+	for input, expectedErr := range invalid {
+		_, err := parseCompositeExpression(input)
+		assert.ErrorContains(t, err, expectedErr, input)
+	}
+}
+
+func TestCompositeThresholdsValidate(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.MustNewMetric("errors", Counter)
+	registry.MustNewMetric("http_req_duration", Trend)
+
+	validate := func(source string) error {
+		cts := CompositeThresholds{Thresholds: []*CompositeThreshold{{Source: source}}}
+		require.NoError(t, cts.Parse())
+		return cts.Validate("composite", registry)
+	}
+
+	assert.NoError(t, validate("errors{status:500}.count / http_req_duration.p(95) < 1"))
+	assert.ErrorContains(t, validate("missing.count < 1"), `no metric name "missing" found`)
+	assert.ErrorContains(t, validate("errors.avg < 1"), "unsupported aggregation method avg on metric errors")
+}
+
+func TestCompositeThresholdsRun(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	errs := registry.MustNewMetric("errors", Counter)
+	reqs := registry.MustNewMetric("reqs", Counter)
+	duration := registry.MustNewMetric("duration", Trend)
+	byName := map[string]*Metric{"errors": errs, "reqs": reqs, "duration": duration}
+
+	var cts CompositeThresholds
+	require.NoError(t, json.Unmarshal([]byte(`[
+		"errors.count / reqs.count < 0.1",
+		{"threshold": "duration.p(50) * reqs.rate < 100", "abortOnFail": true, "delayAbortEval": "10s"}
+	]`), &cts))
+	require.NoError(t, cts.Parse())
+	assert.Equal(t, []string{"errors", "reqs", "duration"}, cts.Metrics())
+
+	// the division by zero is ignored
+	passes, err := cts.Run(byName, time.Second)
+	require.NoError(t, err)
+	assert.True(t, passes)
+
+	reqs.Sink.Add(Sample{Value: 20})
+	errs.Sink.Add(Sample{Value: 1})
+	duration.Sink.Add(Sample{Value: 10})
+	passes, err = cts.Run(byName, 2*time.Second)
+	require.NoError(t, err)
+	assert.False(t, passes)
+	assert.False(t, cts.Thresholds[0].LastFailed)
+	assert.True(t, cts.Thresholds[1].LastFailed)
+	assert.False(t, cts.Abort)
+
+	passes, err = cts.Run(byName, 20*time.Second)
+	require.NoError(t, err)
+	assert.True(t, passes)
+
+	errs.Sink.Add(Sample{Value: 10})
+	passes, err = cts.Run(byName, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, passes)
+	assert.True(t, cts.Thresholds[0].LastFailed)
+	assert.False(t, cts.Abort)
+
+	data, err := json.Marshal(cts)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		"errors.count / reqs.count < 0.1",
+		{"threshold": "duration.p(50) * reqs.rate < 100", "abortOnFail": true, "delayAbortEval": "10s"}
+	]`, string(data))
+}
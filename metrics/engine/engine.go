@@ -31,6 +31,28 @@ type MetricsEngine struct {
 	metricsWithThresholds   []*metrics.Metric
 	breachedThresholdsCount uint32
 
+	// Called with the thresholds which are newly crossed while the test runs
+	onThresholdsCrossed func(crossed []string)
+
+	// The thresholds combining several metrics, by name, and these metrics
+	compositeThresholds map[string]*metrics.CompositeThresholds
+	compositeMetrics    map[string]*metrics.Metric
+
+	// The trackers of the SLOs, by the metric or submetric of their latencies
+	sloTrackers map[*metrics.Metric][]*metrics.SLOTracker
+
+	// The trackers of the abortOnErrorRate conditions, by the metric or
+	// submetric of the failed requests
+	errorRateAborts map[*metrics.Metric][]*errorRateAbort
+
+	// The tags the metrics are broken down by in the summary, and the
+	// submetrics already created for their values
+	summaryBreakdown []string
+	breakdowns       map[breakdownKey]struct{}
+
+	// The buckets of the time series passed to handleSummary, if any
+	timeBuckets *metrics.TimeBuckets
+
 	// TODO: completely refactor:
 	//   - make these private, add a method to export the raw data
 	//   - do not use an unnecessary map for the observed metrics
@@ -43,9 +65,14 @@ type MetricsEngine struct {
 // NewMetricsEngine creates a new metrics Engine with the given parameters.
 func NewMetricsEngine(registry *metrics.Registry, logger logrus.FieldLogger) (*MetricsEngine, error) {
 	me := &MetricsEngine{
-		registry:        registry,
-		logger:          logger.WithField("component", "metrics-engine"),
-		ObservedMetrics: make(map[string]*metrics.Metric),
+		registry:            registry,
+		logger:              logger.WithField("component", "metrics-engine"),
+		compositeThresholds: make(map[string]*metrics.CompositeThresholds),
+		compositeMetrics:    make(map[string]*metrics.Metric),
+		sloTrackers:         make(map[*metrics.Metric][]*metrics.SLOTracker),
+		errorRateAborts:     make(map[*metrics.Metric][]*errorRateAbort),
+		breakdowns:          make(map[breakdownKey]struct{}),
+		ObservedMetrics:     make(map[string]*metrics.Metric),
 	}
 
 	return me, nil
@@ -114,10 +141,19 @@ func (me *MetricsEngine) markObserved(metric *metrics.Metric) {
 	}
 }
 
-// InitSubMetricsAndThresholds parses the thresholds from the test Options and
-// initializes both the thresholds themselves, as well as any submetrics that
-// were referenced in them.
+// InitSubMetricsAndThresholds sets the precision of the trend metrics, parses
+// the thresholds from the test Options and initializes both the thresholds
+// themselves, as well as any submetrics that were referenced in them.
 func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLogErrors bool) error {
+	if options.TrendSignificantDigits.Valid {
+		if err := me.registry.SetTrendSignificantDigits(int(options.TrendSignificantDigits.Int64)); err != nil {
+			if !onlyLogErrors {
+				return err
+			}
+			me.logger.WithError(err).Warn("Invalid precision of the trend metrics")
+		}
+	}
+
 	for metricName, thresholds := range options.Thresholds {
 		metric, err := me.getThresholdMetricOrSubmetric(metricName)
 
@@ -144,6 +180,40 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 		}
 	}
 
+	for name, compositeThresholds := range options.CompositeThresholds {
+		if err := me.initCompositeThresholds(name, compositeThresholds); err != nil {
+			if !onlyLogErrors {
+				return err
+			}
+			me.logger.WithError(err).Warnf("Invalid composite thresholds '%s'", name)
+		}
+	}
+
+	me.summaryBreakdown = options.SummaryBreakdown
+
+	if err := me.initTimeBuckets(options); err != nil {
+		if !onlyLogErrors {
+			return err
+		}
+		me.logger.WithError(err).Warn("Invalid summaryTimeSeries")
+	}
+
+	for name, slo := range options.SLOs {
+		if err := me.initSLO(name, slo); err != nil {
+			if !onlyLogErrors {
+				return err
+			}
+			me.logger.WithError(err).Warnf("Invalid SLO '%s'", name)
+		}
+	}
+
+	if err := me.initErrorRateAborts(options); err != nil {
+		if !onlyLogErrors {
+			return err
+		}
+		me.logger.WithError(err).Warn("Invalid abortOnErrorRate")
+	}
+
 	// TODO: refactor out of here when https://github.com/grafana/k6/issues/1321
 	// lands and there is a better way to enable a metric with tag
 	if options.SystemTags.Has(metrics.TagExpectedResponse) {
@@ -156,6 +226,175 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 	return nil
 }
 
+// initCompositeThresholds initializes the metrics and submetrics of the
+// composite thresholds with the given name.
+func (me *MetricsEngine) initCompositeThresholds(name string, compositeThresholds metrics.CompositeThresholds) error {
+	compositeMetrics := make(map[string]*metrics.Metric)
+	for _, metricName := range compositeThresholds.Metrics() {
+		metric, err := me.getThresholdMetricOrSubmetric(metricName)
+		if err != nil {
+			return fmt.Errorf("invalid metric '%s' in the composite thresholds '%s': %w", metricName, name, err)
+		}
+		compositeMetrics[metricName] = metric
+	}
+
+	for metricName, metric := range compositeMetrics {
+		me.compositeMetrics[metricName] = metric
+	}
+	me.compositeThresholds[name] = &compositeThresholds
+	return nil
+}
+
+// initSLO initializes the tracker of the SLO with the given name, and the
+// submetric of its latencies if needed.
+func (me *MetricsEngine) initSLO(name string, slo metrics.SLO) error {
+	metric, err := me.getThresholdMetricOrSubmetric(slo.MetricName())
+	if err != nil {
+		return fmt.Errorf("invalid metric '%s' in the SLO '%s': %w", slo.MetricName(), name, err)
+	}
+	tracker, err := metrics.NewSLOTracker(name, slo, me.registry)
+	if err != nil {
+		return fmt.Errorf("invalid SLO '%s': %w", name, err)
+	}
+	me.sloTrackers[metric] = append(me.sloTrackers[metric], tracker)
+	return nil
+}
+
+// initTimeBuckets initializes the buckets of the summary time series, with
+// the stats of the end-of-test summary for the trends without any.
+func (me *MetricsEngine) initTimeBuckets(options lib.Options) error {
+	if options.SummaryTimeSeries == nil {
+		return nil
+	}
+	config := *options.SummaryTimeSeries
+	if err := config.Validate(me.registry); err != nil {
+		return err
+	}
+	config.Metrics = make(map[string][]string, len(options.SummaryTimeSeries.Metrics))
+	for name, stats := range options.SummaryTimeSeries.Metrics {
+		if len(stats) == 0 && me.registry.Get(name).Type == metrics.Trend {
+			stats = options.SummaryTrendStats
+		}
+		config.Metrics[name] = stats
+	}
+	me.timeBuckets = metrics.NewTimeBuckets(config)
+	return nil
+}
+
+// SummaryTimeSeries returns the buckets of the summary time series, it's nil
+// if they aren't configured.
+func (me *MetricsEngine) SummaryTimeSeries() *metrics.TimeBuckets {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	return me.timeBuckets
+}
+
+// CompositeThresholds returns the thresholds combining several metrics, by
+// their names, with the results of their last evaluation.
+func (me *MetricsEngine) CompositeThresholds() map[string]*metrics.CompositeThresholds {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	return me.compositeThresholds
+}
+
+// errorRateAbort is an abortOnErrorRate condition, either of the whole test or
+// of the requests of a scenario.
+type errorRateAbort struct {
+	scenario  string
+	condition metrics.ErrorRateAbort
+	tracker   *metrics.ErrorRateTracker
+}
+
+// initErrorRateAborts initializes the trackers of the abortOnErrorRate
+// conditions of the test and of its scenarios, with the submetrics of the
+// failed requests of the scenarios.
+func (me *MetricsEngine) initErrorRateAborts(options lib.Options) error {
+	add := func(scenario string, condition metrics.ErrorRateAbort) error {
+		metricName := metrics.HTTPReqFailedName
+		if scenario != "" {
+			metricName += "{scenario:" + scenario + "}"
+		}
+		metric, err := me.getThresholdMetricOrSubmetric(metricName)
+		if err != nil {
+			return err
+		}
+		me.errorRateAborts[metric] = append(me.errorRateAborts[metric], &errorRateAbort{
+			scenario:  scenario,
+			condition: condition,
+			tracker:   metrics.NewErrorRateTracker(condition),
+		})
+		return nil
+	}
+
+	if options.AbortOnErrorRate != nil {
+		if err := add("", *options.AbortOnErrorRate); err != nil {
+			return err
+		}
+	}
+	for name, scenario := range options.Scenarios {
+		if condition := scenario.GetAbortOnErrorRate(); condition != nil {
+			if err := add(name, *condition); err != nil {
+				return fmt.Errorf("invalid abortOnErrorRate of the scenario '%s': %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// exceededErrorRate returns an error for the first abortOnErrorRate condition
+// met at now, if any.
+func (me *MetricsEngine) exceededErrorRate(now time.Time) error {
+	for _, aborts := range me.errorRateAborts {
+		for _, abort := range aborts {
+			rate, exceeded := abort.tracker.Exceeded(now)
+			if !exceeded {
+				continue
+			}
+			requests := "the requests"
+			if abort.scenario != "" {
+				requests = fmt.Sprintf("the requests of the scenario '%s'", abort.scenario)
+			}
+			err := fmt.Errorf(
+				"%.2f%% of %s failed over the last %s, more than the %s of abortOnErrorRate; stopping test prematurely",
+				rate*100, requests, abort.condition.Window, abort.condition,
+			)
+			return errext.WithAbortReasonIfNone(
+				errext.WithExitCodeIfNone(err, exitcodes.ErrorRateExceeded), errext.AbortedByErrorRate,
+			)
+		}
+	}
+	return nil
+}
+
+type breakdownKey struct {
+	metric     *metrics.Metric
+	tag, value string
+}
+
+// breakDown creates the submetrics of the metric of the sample for its values
+// of the tags the summary is broken down by, if they don't exist yet.
+func (me *MetricsEngine) breakDown(sample metrics.Sample) {
+	for _, tag := range me.summaryBreakdown {
+		value, ok := sample.Tags.Get(tag)
+		if !ok || value == "" { // e.g. the root group
+			continue
+		}
+		key := breakdownKey{metric: sample.Metric, tag: tag, value: value}
+		if _, ok := me.breakdowns[key]; ok {
+			continue
+		}
+		me.breakdowns[key] = struct{}{}
+		sample.Metric.AddTagSubmetric(tag, value)
+	}
+}
+
+// OnThresholdsCrossed sets the function called with the names of the metrics
+// whose thresholds are crossed while the test runs, each time they become
+// crossed. It has to be called before StartThresholdCalculations().
+func (me *MetricsEngine) OnThresholdsCrossed(fn func(crossed []string)) {
+	me.onThresholdsCrossed = fn
+}
+
 // StartThresholdCalculations spins up a new goroutine to crunch thresholds and
 // returns a callback that will stop the goroutine and finalizes calculations.
 func (me *MetricsEngine) StartThresholdCalculations(
@@ -163,7 +402,10 @@ func (me *MetricsEngine) StartThresholdCalculations(
 	abortRun func(error),
 	getCurrentTestRunDuration func() time.Duration,
 ) (finalize func() (breached []string)) {
-	if len(me.metricsWithThresholds) == 0 {
+	if ingester != nil && len(me.errorRateAborts) > 0 {
+		ingester.setAbortOnErrorRate(abortRun)
+	}
+	if len(me.metricsWithThresholds) == 0 && len(me.compositeThresholds) == 0 {
 		return nil // no thresholds were defined
 	}
 
@@ -175,10 +417,14 @@ func (me *MetricsEngine) StartThresholdCalculations(
 		ticker := time.NewTicker(thresholdsRate)
 		defer ticker.Stop()
 
+		var previouslyBreached map[string]struct{}
 		for {
 			select {
 			case <-ticker.C:
 				breached, shouldAbort := me.evaluateThresholds(true, getCurrentTestRunDuration)
+				if me.onThresholdsCrossed != nil {
+					previouslyBreached = me.notifyCrossedThresholds(previouslyBreached, breached)
+				}
 				if shouldAbort {
 					err := fmt.Errorf(
 						"thresholds on metrics '%s' were crossed; at least one has abortOnFail enabled, stopping test prematurely",
@@ -212,6 +458,24 @@ func (me *MetricsEngine) StartThresholdCalculations(
 	}
 }
 
+// notifyCrossedThresholds calls onThresholdsCrossed with the breached
+// thresholds which weren't breached the previous time, and returns the set of
+// the breached ones for the next time.
+func (me *MetricsEngine) notifyCrossedThresholds(previously map[string]struct{}, breached []string) map[string]struct{} {
+	current := make(map[string]struct{}, len(breached))
+	var crossed []string
+	for _, name := range breached {
+		current[name] = struct{}{}
+		if _, ok := previously[name]; !ok {
+			crossed = append(crossed, name)
+		}
+	}
+	if len(crossed) > 0 {
+		me.onThresholdsCrossed(crossed)
+	}
+	return current
+}
+
 // evaluateThresholds processes all of the thresholds.
 //
 // TODO: refactor, optimize
@@ -233,7 +497,12 @@ func (me *MetricsEngine) evaluateThresholds(
 		}
 		m.Tainted = null.BoolFrom(false)
 
-		succ, err := m.Thresholds.Run(m.Sink, t)
+		run := m.Thresholds.Run
+		if !ignoreEmptySinks {
+			// the final evaluation, at the end of the test run
+			run = m.Thresholds.RunFinal
+		}
+		succ, err := run(m.Sink, t)
 		if err != nil {
 			me.logger.WithField("metric_name", m.Name).WithError(err).Error("Threshold error")
 			continue
@@ -247,6 +516,24 @@ func (me *MetricsEngine) evaluateThresholds(
 			shouldAbort = true
 		}
 	}
+	for name, compositeThresholds := range me.compositeThresholds {
+		if ignoreEmptySinks && me.hasEmptySinks(compositeThresholds) {
+			continue
+		}
+
+		succ, err := compositeThresholds.Run(me.compositeMetrics, t)
+		if err != nil {
+			me.logger.WithField("composite_thresholds", name).WithError(err).Error("Threshold error")
+			continue
+		}
+		if succ {
+			continue
+		}
+		breachedThresholds = append(breachedThresholds, name)
+		if compositeThresholds.Abort {
+			shouldAbort = true
+		}
+	}
 	if len(breachedThresholds) > 0 {
 		sort.Strings(breachedThresholds)
 		me.logger.Debugf("Thresholds on %d metrics crossed: %v", len(breachedThresholds), breachedThresholds)
@@ -255,6 +542,37 @@ func (me *MetricsEngine) evaluateThresholds(
 	return breachedThresholds, shouldAbort
 }
 
+func (me *MetricsEngine) hasEmptySinks(compositeThresholds *metrics.CompositeThresholds) bool {
+	for _, metricName := range compositeThresholds.Metrics() {
+		if me.compositeMetrics[metricName].Sink.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckMetricCondition checks whether the given condition, in the same format as
+// a threshold, is met by the current aggregated values of the metric. Metrics
+// without any samples yet never meet conditions.
+func (me *MetricsEngine) CheckMetricCondition(cond lib.MetricCondition, timeSpentInTest time.Duration) (bool, error) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	metric, err := me.getThresholdMetricOrSubmetric(cond.Metric)
+	if err != nil {
+		return false, fmt.Errorf("invalid metric '%s' in the start condition: %w", cond.Metric, err)
+	}
+	if !metric.Observed || metric.Sink.IsEmpty() {
+		return false, nil
+	}
+
+	thresholds := metrics.NewThresholds([]string{cond.Condition})
+	if err = thresholds.Parse(); err != nil {
+		return false, err
+	}
+	return thresholds.Run(metric.Sink, timeSpentInTest)
+}
+
 // GetMetricsWithBreachedThresholdsCount returns the number of metrics for which
 // the thresholds were breached (failed) during the last processing phase. This
 // API is safe to use concurrently.
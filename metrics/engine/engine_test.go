@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -153,6 +154,67 @@ func TestMetricsEngineEvaluateIgnoreEmptySink(t *testing.T) {
 	assert.Empty(t, breached)
 }
 
+func TestMetricsEngineEvaluateCompositeThresholds(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	reqs, err := me.registry.NewMetric("reqs", metrics.Counter)
+	require.NoError(t, err)
+	errs, err := me.registry.NewMetric("errs", metrics.Counter)
+	require.NoError(t, err)
+
+	var composite metrics.CompositeThresholds
+	require.NoError(t, json.Unmarshal(
+		[]byte(`[{"threshold": "errs.count / reqs{status:500}.count < 0.5", "abortOnFail": true}]`), &composite))
+	require.NoError(t, composite.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		CompositeThresholds: map[string]metrics.CompositeThresholds{"error_ratio": composite},
+	}, false))
+	require.NotNil(t, reqs.Submetrics)
+
+	breached, abort := me.evaluateThresholds(true, zeroTestRunDuration)
+	assert.Empty(t, breached)
+	assert.False(t, abort)
+
+	reqs.Submetrics[0].Metric.Sink.Add(metrics.Sample{Value: 4})
+	errs.Sink.Add(metrics.Sample{Value: 1})
+	breached, abort = me.evaluateThresholds(true, zeroTestRunDuration)
+	assert.Empty(t, breached)
+	assert.False(t, abort)
+
+	errs.Sink.Add(metrics.Sample{Value: 1})
+	breached, abort = me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Equal(t, []string{"error_ratio"}, breached)
+	assert.True(t, abort)
+}
+
+func TestMetricsEngineCheckMetricCondition(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m1, err := me.registry.NewMetric("m1", metrics.Counter)
+	require.NoError(t, err)
+
+	cond := lib.MetricCondition{Metric: "m1", Condition: "count>=2"}
+	met, err := me.CheckMetricCondition(cond, 0)
+	require.NoError(t, err)
+	assert.False(t, met)
+
+	m1.Observed = true
+	m1.Sink.Add(metrics.Sample{Time: time.Now(), Value: 1})
+	met, err = me.CheckMetricCondition(cond, time.Second)
+	require.NoError(t, err)
+	assert.False(t, met)
+
+	m1.Sink.Add(metrics.Sample{Time: time.Now(), Value: 1})
+	met, err = me.CheckMetricCondition(cond, time.Second)
+	require.NoError(t, err)
+	assert.True(t, met)
+
+	_, err = me.CheckMetricCondition(lib.MetricCondition{Metric: "m2", Condition: "count>=2"}, 0)
+	assert.ErrorContains(t, err, "'m2' does not exist in the script")
+}
+
 func newTestMetricsEngine(t *testing.T) *MetricsEngine {
 	m, err := NewMetricsEngine(metrics.NewRegistry(), testutils.NewLogger(t))
 	require.NoError(t, err)
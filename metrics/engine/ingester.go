@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -10,6 +11,7 @@ import (
 
 const (
 	collectRate          = 50 * time.Millisecond
+	sloRate              = time.Second
 	timeSeriesFirstLimit = 100_000
 )
 
@@ -29,6 +31,15 @@ type OutputIngester struct {
 	metricsEngine   *MetricsEngine
 	periodicFlusher *output.PeriodicFlusher
 	cardinality     *cardinalityControl
+
+	// lastSLOSamples is when the samples of the SLO metrics were last added
+	lastSLOSamples time.Time
+	finalSLOs      sync.Once
+
+	// abortRun aborts the test when an abortOnErrorRate condition is met,
+	// only once
+	abortRun func(error)
+	aborted  bool
 }
 
 // Description returns a human-readable description of the output.
@@ -55,6 +66,14 @@ func (oi *OutputIngester) Stop() error {
 	oi.logger.Debug("Stopping...")
 	defer oi.logger.Debug("Stopped!")
 	oi.periodicFlusher.Stop()
+
+	// the final values of the SLO metrics, with all of the latencies; the
+	// ingester is stopped both by the thresholds and with the other outputs
+	oi.finalSLOs.Do(func() {
+		oi.metricsEngine.MetricsLock.Lock()
+		defer oi.metricsEngine.MetricsLock.Unlock()
+		oi.addSLOSamples(time.Now())
+	})
 	return nil
 }
 
@@ -85,23 +104,16 @@ func (oi *OutputIngester) flushMetrics() {
 		}
 
 		for _, sample := range samples {
-			m := sample.Metric               // this should have come from the Registry, no need to look it up
-			oi.metricsEngine.markObserved(m) // mark it as observed so it shows in the end-of-test summary
-			m.Sink.Add(sample)               // finally, add its value to its own sink
-
-			// and also to the same for any submetrics that match the metric sample
-			for _, sm := range m.Submetrics {
-				if !sample.Tags.Contains(sm.Tags) {
-					continue
-				}
-				oi.metricsEngine.markObserved(sm.Metric)
-				sm.Metric.Sink.Add(sample)
-			}
-
-			oi.cardinality.Add(sample.TimeSeries)
+			oi.ingest(sample)
 		}
 	}
 
+	now := time.Now()
+	if now.Sub(oi.lastSLOSamples) >= sloRate {
+		oi.addSLOSamples(now)
+	}
+	oi.checkErrorRates(now)
+
 	if oi.cardinality.LimitHit() {
 		// TODO: suggest using the Metadata API as an alternative, once it's
 		// available (e.g. move high-cardinality tags as Metadata)
@@ -120,6 +132,78 @@ func (oi *OutputIngester) flushMetrics() {
 	}
 }
 
+// ingest adds the sample to the sinks of its metric and of the matching
+// submetrics, and to the trackers of their SLOs.
+func (oi *OutputIngester) ingest(sample metrics.Sample) {
+	m := sample.Metric               // this should have come from the Registry, no need to look it up
+	oi.metricsEngine.markObserved(m) // mark it as observed so it shows in the end-of-test summary
+	m.Sink.Add(sample)               // finally, add its value to its own sink
+	m.Thresholds.AddSample(sample)   // and to the windows of its thresholds
+	for _, tracker := range oi.metricsEngine.sloTrackers[m] {
+		tracker.Add(sample)
+	}
+	for _, abort := range oi.metricsEngine.errorRateAborts[m] {
+		abort.tracker.Add(sample)
+	}
+
+	// and also to the same for any submetrics that match the metric sample,
+	// including the ones the summary is broken down in
+	oi.metricsEngine.breakDown(sample)
+	if oi.metricsEngine.timeBuckets != nil {
+		oi.metricsEngine.timeBuckets.Add(sample)
+	}
+	for _, sm := range m.Submetrics {
+		if !sample.Tags.Contains(sm.Tags) {
+			continue
+		}
+		oi.metricsEngine.markObserved(sm.Metric)
+		sm.Metric.Sink.Add(sample)
+		sm.Metric.Thresholds.AddSample(sample)
+		for _, tracker := range oi.metricsEngine.sloTrackers[sm.Metric] {
+			tracker.Add(sample)
+		}
+		for _, abort := range oi.metricsEngine.errorRateAborts[sm.Metric] {
+			abort.tracker.Add(sample)
+		}
+	}
+
+	oi.cardinality.Add(sample.TimeSeries)
+}
+
+// addSLOSamples adds the current apdex scores and burn rates of the SLOs to
+// their metrics, as if they were emitted by the test.
+func (oi *OutputIngester) addSLOSamples(now time.Time) {
+	oi.lastSLOSamples = now
+	for _, trackers := range oi.metricsEngine.sloTrackers {
+		for _, tracker := range trackers {
+			for _, sample := range tracker.Samples(now) {
+				oi.ingest(sample)
+			}
+		}
+	}
+}
+
+// setAbortOnErrorRate sets the function called to abort the test when an
+// abortOnErrorRate condition is met.
+func (oi *OutputIngester) setAbortOnErrorRate(abortRun func(error)) {
+	oi.metricsEngine.MetricsLock.Lock()
+	defer oi.metricsEngine.MetricsLock.Unlock()
+	oi.abortRun = abortRun
+}
+
+// checkErrorRates aborts the test, at most once, if an abortOnErrorRate
+// condition is met at now.
+func (oi *OutputIngester) checkErrorRates(now time.Time) {
+	if oi.abortRun == nil || oi.aborted {
+		return
+	}
+	if err := oi.metricsEngine.exceededErrorRate(now); err != nil {
+		oi.aborted = true
+		oi.logger.Debug(err.Error())
+		oi.abortRun(err)
+	}
+}
+
 type cardinalityControl struct {
 	seen            map[metrics.TimeSeries]struct{}
 	timeSeriesLimit int
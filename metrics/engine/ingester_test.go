@@ -3,12 +3,17 @@ package engine
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
 	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -98,6 +103,182 @@ func TestIngesterOutputFlushSubmetrics(t *testing.T) {
 	assert.IsType(t, &metrics.GaugeSink{}, metric.Sink)
 }
 
+func TestIngesterOutputSLOMetrics(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	me, err := NewMetricsEngine(piState.Registry, piState.Logger)
+	require.NoError(t, err)
+
+	thresholds := metrics.NewThresholds([]string{"value>0.9"})
+	require.NoError(t, thresholds.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		SLOs: map[string]metrics.SLO{"checkout": {
+			Metric:      "http_req_duration{scenario:checkout}",
+			Target:      types.Duration(100 * time.Millisecond),
+			ErrorBudget: 0.01,
+			Windows:     []types.Duration{types.Duration(time.Minute)},
+		}},
+		Thresholds: map[string]metrics.Thresholds{"slo_apdex{slo:checkout}": thresholds},
+	}, false))
+
+	ingester := me.CreateIngester()
+	require.NoError(t, ingester.Start())
+	now := time.Now()
+	for i, scenario := range []string{"checkout", "checkout", "other", "checkout", "checkout"} {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: piState.BuiltinMetrics.HTTPReqDuration,
+				Tags:   piState.Registry.RootTagSet().With("scenario", scenario),
+			},
+			Time:  now,
+			Value: float64(i * 100),
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+	require.NoError(t, ingester.Stop())
+
+	// 0 and 100 are satisfying, while 300 and 400 are only tolerated
+	apdex := me.ObservedMetrics["slo_apdex{slo:checkout}"]
+	require.NotNil(t, apdex)
+	assert.Equal(t, 0.75, apdex.Sink.(*metrics.GaugeSink).Value) //nolint:forcetypeassert
+	burnRate := me.ObservedMetrics["slo_burn_rate"]
+	require.NotNil(t, burnRate)
+	assert.Equal(t, 50.0, burnRate.Sink.(*metrics.GaugeSink).Value) //nolint:forcetypeassert
+
+	breached, _ := me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Equal(t, []string{"slo_apdex{slo:checkout}"}, breached)
+}
+
+func TestIngesterOutputAbortOnErrorRate(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	me, err := NewMetricsEngine(piState.Registry, piState.Logger)
+	require.NoError(t, err)
+
+	checkout := executor.NewSharedIterationsConfig("checkout")
+	checkout.AbortOnErrorRate = &metrics.ErrorRateAbort{
+		Rate: 0.5, Window: types.Duration(10 * time.Second), MinRequests: 4,
+	}
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		AbortOnErrorRate: &metrics.ErrorRateAbort{Rate: 0.9, Window: types.Duration(10 * time.Second)},
+		Scenarios:        lib.ScenarioConfigs{"checkout": checkout},
+	}, false))
+
+	ingester := me.CreateIngester()
+	var abortErrs []error
+	assert.Nil(t, me.StartThresholdCalculations(ingester, func(err error) {
+		abortErrs = append(abortErrs, err)
+	}, zeroTestRunDuration))
+	require.NoError(t, ingester.Start())
+
+	// 7 of the 9 requests failed, and 3 of the 4 of the checkout scenario
+	now := time.Now()
+	scenarios := []string{"other", "other", "other", "other", "other", "checkout", "checkout", "checkout", "checkout"}
+	for i, scenario := range scenarios {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: piState.BuiltinMetrics.HTTPReqFailed,
+				Tags:   piState.Registry.RootTagSet().With("scenario", scenario),
+			},
+			Time:  now,
+			Value: float64(i % 8),
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+	ingester.checkErrorRates(now)
+
+	require.Len(t, abortErrs, 1, "the test should only be aborted once")
+	assert.ErrorContains(t, abortErrs[0], "75.00% of the requests of the scenario 'checkout' failed over the last 10s,"+
+		" more than the 50% over 10s of abortOnErrorRate")
+	var ecerr errext.HasExitCode
+	require.ErrorAs(t, abortErrs[0], &ecerr)
+	assert.Equal(t, exitcodes.ErrorRateExceeded, ecerr.ExitCode())
+	var arerr errext.HasAbortReason
+	require.ErrorAs(t, abortErrs[0], &arerr)
+	assert.Equal(t, errext.AbortedByErrorRate, arerr.AbortReason())
+}
+
+func TestIngesterOutputSummaryBreakdown(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	me, err := NewMetricsEngine(piState.Registry, piState.Logger)
+	require.NoError(t, err)
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		SummaryBreakdown: []string{"scenario", "group"},
+	}, false))
+
+	ingester := me.CreateIngester()
+	require.NoError(t, ingester.Start())
+	for _, tags := range []map[string]string{
+		{"scenario": "browse", "group": ""},
+		{"scenario": "browse", "group": "::login"},
+		{"scenario": "checkout", "group": "::pay, then leave"},
+		{"scenario": "checkout", "group": "::pay, then leave"},
+	} {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: piState.BuiltinMetrics.Iterations,
+				Tags:   piState.Registry.RootTagSet().WithTagsFromMap(tags),
+			},
+			Value: 1,
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+
+	counts := make(map[string]float64)
+	for name, metric := range me.ObservedMetrics {
+		counts[name] = metric.Sink.(*metrics.CounterSink).Value //nolint:forcetypeassert
+	}
+	assert.Equal(t, map[string]float64{
+		"iterations":                          4,
+		"iterations{scenario:browse}":         2,
+		"iterations{scenario:checkout}":       2,
+		"iterations{group:::login}":           1,
+		"iterations{group:::pay, then leave}": 2,
+	}, counts)
+}
+
+func TestIngesterOutputSummaryTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	me, err := NewMetricsEngine(piState.Registry, piState.Logger)
+	require.NoError(t, err)
+	require.ErrorContains(t, me.InitSubMetricsAndThresholds(lib.Options{
+		SummaryTimeSeries: &metrics.SummaryTimeSeries{Interval: types.Duration(time.Second)},
+	}, false), "need at least a metric")
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		SummaryTrendStats: []string{"p(95)"},
+		SummaryTimeSeries: &metrics.SummaryTimeSeries{
+			Interval: types.Duration(10 * time.Second),
+			Metrics:  map[string][]string{"iteration_duration": nil},
+		},
+	}, false))
+
+	ingester := me.CreateIngester()
+	require.NoError(t, ingester.Start())
+	for _, m := range []*metrics.Metric{piState.BuiltinMetrics.Iterations, piState.BuiltinMetrics.IterationDuration} {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: piState.Registry.RootTagSet()},
+			Time:       time.Unix(10, 0),
+			Value:      1,
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+
+	timeSeries := me.SummaryTimeSeries()
+	require.NotNil(t, timeSeries)
+	assert.Equal(t, map[string][]string{"iteration_duration": {"p(95)"}}, timeSeries.Config().Metrics)
+	series := timeSeries.Series()
+	require.Len(t, series, 1)
+	assert.Equal(t, piState.BuiltinMetrics.IterationDuration, series[0].Metric)
+	require.Len(t, series[0].Buckets, 1)
+	assert.Equal(t, time.Unix(10, 0), series[0].Buckets[0].Time)
+}
+
 func TestOutputFlushMetricsTimeSeriesWarning(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// DefaultErrorRateMinRequests is the number of the requests in the window
+// under which the error rate is never considered too high.
+const DefaultErrorRateMinRequests = 10
+
+// ErrorRateAbort is a condition to abort the test, when the rate of the failed
+// requests over a window is higher than a limit, like `{"rate": 0.1, "window":
+// "30s"}` for more than 10% of the requests failed over the last 30 seconds.
+// The failed requests are the non-zero samples of the http_req_failed metric.
+type ErrorRateAbort struct {
+	// Rate is the ratio of the failed requests over which the test is aborted.
+	Rate float64 `json:"rate"`
+	// Window is the duration of the window the rate is computed over.
+	Window types.Duration `json:"window"`
+	// MinRequests is the number of the requests the window must have for the
+	// rate to be considered; it is DefaultErrorRateMinRequests when empty.
+	MinRequests int64 `json:"minRequests"`
+}
+
+// Validate checks that the rate and the window of the condition make sense.
+func (era ErrorRateAbort) Validate() error {
+	if era.Rate <= 0 || era.Rate >= 1 {
+		return fmt.Errorf("the rate of abortOnErrorRate must be between 0 and 1, got %g", era.Rate)
+	}
+	if time.Duration(era.Window) < time.Second {
+		return fmt.Errorf("the window of abortOnErrorRate must be at least 1s, got %s", era.Window)
+	}
+	if era.MinRequests < 0 {
+		return fmt.Errorf("the minRequests of abortOnErrorRate can't be negative, got %d", era.MinRequests)
+	}
+	return nil
+}
+
+// String returns a short description of the condition, e.g. "10% over 30s".
+func (era ErrorRateAbort) String() string {
+	return strconv.FormatFloat(era.Rate*100, 'f', -1, 64) + "% over " + era.Window.String()
+}
+
+// errorRateBucket counts the requests of a second, and those which failed.
+type errorRateBucket struct {
+	total, failed uint64
+}
+
+// ErrorRateTracker keeps track of the failed requests over the window of an
+// ErrorRateAbort condition.
+type ErrorRateTracker struct {
+	condition   ErrorRateAbort
+	minRequests uint64
+
+	// buckets are the counts of the requests of the window, by second
+	buckets map[int64]*errorRateBucket
+}
+
+// NewErrorRateTracker returns a tracker for the valid condition.
+func NewErrorRateTracker(condition ErrorRateAbort) *ErrorRateTracker {
+	minRequests := uint64(DefaultErrorRateMinRequests)
+	if condition.MinRequests > 0 {
+		minRequests = uint64(condition.MinRequests)
+	}
+	return &ErrorRateTracker{
+		condition:   condition,
+		minRequests: minRequests,
+		buckets:     make(map[int64]*errorRateBucket),
+	}
+}
+
+// Add adds a sample of the http_req_failed metric, or of a submetric of it.
+func (t *ErrorRateTracker) Add(s Sample) {
+	key := s.Time.Unix()
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &errorRateBucket{}
+		t.buckets[key] = b
+	}
+	b.total++
+	if s.Value != 0 {
+		b.failed++
+	}
+}
+
+// Exceeded returns the error rate over the window ending at now, and whether it
+// is higher than the rate of the condition. It also drops the counts older
+// than the window.
+func (t *ErrorRateTracker) Exceeded(now time.Time) (float64, bool) {
+	oldest := now.Add(-time.Duration(t.condition.Window)).Unix()
+	var total, failed uint64
+	for key, b := range t.buckets {
+		if key <= oldest {
+			delete(t.buckets, key)
+			continue
+		}
+		total += b.total
+		failed += b.failed
+	}
+	if total == 0 {
+		return 0, false
+	}
+	rate := float64(failed) / float64(total)
+	return rate, total >= t.minRequests && rate > t.condition.Rate
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestErrorRateAbortValidate(t *testing.T) {
+	t.Parallel()
+
+	window := types.Duration(30 * time.Second)
+	assert.NoError(t, ErrorRateAbort{Rate: 0.1, Window: window}.Validate())
+	assert.NoError(t, ErrorRateAbort{Rate: 0.5, Window: types.Duration(time.Second), MinRequests: 100}.Validate())
+
+	assert.ErrorContains(t, ErrorRateAbort{Window: window}.Validate(), "must be between 0 and 1, got 0")
+	assert.ErrorContains(t, ErrorRateAbort{Rate: 1, Window: window}.Validate(), "must be between 0 and 1, got 1")
+	assert.ErrorContains(t, ErrorRateAbort{Rate: 0.1}.Validate(), "must be at least 1s, got 0s")
+	assert.ErrorContains(t, ErrorRateAbort{Rate: 0.1, Window: window, MinRequests: -1}.Validate(),
+		"can't be negative, got -1")
+
+	assert.Equal(t, "12.5% over 30s", ErrorRateAbort{Rate: 0.125, Window: window}.String())
+}
+
+func TestErrorRateTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewErrorRateTracker(ErrorRateAbort{Rate: 0.2, Window: types.Duration(10 * time.Second)})
+	start := time.Unix(1000, 0)
+	_, exceeded := tracker.Exceeded(start)
+	assert.False(t, exceeded)
+
+	// the first 5 requests failed, but there aren't enough of them yet
+	for i := 0; i < 5; i++ {
+		tracker.Add(Sample{Time: start, Value: 1})
+	}
+	rate, exceeded := tracker.Exceeded(start)
+	assert.Equal(t, 1.0, rate)
+	assert.False(t, exceeded)
+
+	for i := 0; i < 15; i++ {
+		tracker.Add(Sample{Time: start.Add(5 * time.Second), Value: 0})
+	}
+	rate, exceeded = tracker.Exceeded(start.Add(5 * time.Second))
+	assert.Equal(t, 0.25, rate)
+	assert.True(t, exceeded)
+
+	// the failed requests are out of the window now
+	rate, exceeded = tracker.Exceeded(start.Add(10 * time.Second))
+	assert.Equal(t, 0.0, rate)
+	assert.False(t, exceeded)
+	assert.Len(t, tracker.buckets, 1)
+}
@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultHistogramSignificantDigits is the default precision of the histograms.
+const DefaultHistogramSignificantDigits = 3
+
+// Histogram is a sparse HDR histogram: the values are counted in buckets whose
+// widths grow with the values, so their relative error is bounded by the
+// precision whatever their magnitude, and its size only depends on the range
+// of the values, not on how many of them there are.
+//
+// Each power of two is split into 2^k buckets of the same width, with k the
+// smallest number for which the middle of a bucket is within 10^-d of all of
+// its values, for a precision of d significant digits.
+type Histogram struct {
+	significantDigits int
+	subBuckets        float64
+
+	positive map[int64]uint64
+	negative map[int64]uint64
+	zeros    uint64
+
+	count      uint64
+	sum        float64
+	sumSquares float64
+	min, max   float64
+}
+
+// NewHistogram returns a histogram with the precision of the given number of
+// significant digits, between 1 and 5.
+func NewHistogram(significantDigits int) (*Histogram, error) {
+	if significantDigits < 1 || significantDigits > 5 {
+		return nil, fmt.Errorf("the significant digits of a histogram must be between 1 and 5, got %d",
+			significantDigits)
+	}
+	bits := math.Ceil(math.Log2(math.Pow10(significantDigits) / 2))
+	return &Histogram{
+		significantDigits: significantDigits,
+		subBuckets:        math.Exp2(bits),
+		positive:          make(map[int64]uint64),
+		negative:          make(map[int64]uint64),
+	}, nil
+}
+
+// SignificantDigits returns the precision of the histogram.
+func (h *Histogram) SignificantDigits() int {
+	return h.significantDigits
+}
+
+// Add records a value.
+func (h *Histogram) Add(v float64) {
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+	h.sumSquares += v * v
+
+	switch {
+	case v > 0:
+		h.positive[h.bucket(v)]++
+	case v < 0:
+		h.negative[h.bucket(-v)]++
+	default:
+		h.zeros++
+	}
+}
+
+// Merge adds the values of the other histogram, which must have the same
+// precision.
+func (h *Histogram) Merge(other *Histogram) error {
+	if other.significantDigits != h.significantDigits {
+		return fmt.Errorf("can't merge a histogram with %d significant digits into one with %d",
+			other.significantDigits, h.significantDigits)
+	}
+	if other.count == 0 {
+		return nil
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if h.count == 0 || other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+	h.sumSquares += other.sumSquares
+	h.zeros += other.zeros
+	for b, c := range other.positive {
+		h.positive[b] += c
+	}
+	for b, c := range other.negative {
+		h.negative[b] += c
+	}
+	return nil
+}
+
+// Count returns the number of recorded values.
+func (h *Histogram) Count() uint64 { return h.count }
+
+// Sum returns the exact sum of the recorded values.
+func (h *Histogram) Sum() float64 { return h.sum }
+
+// Min returns the exact minimum of the recorded values.
+func (h *Histogram) Min() float64 { return h.min }
+
+// Max returns the exact maximum of the recorded values.
+func (h *Histogram) Max() float64 { return h.max }
+
+// Avg returns the exact average of the recorded values.
+func (h *Histogram) Avg() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / float64(h.count)
+}
+
+// StdDev returns the population standard deviation of the recorded values,
+// which is exact too.
+func (h *Histogram) StdDev() float64 {
+	return stdDev(h.count, h.sum, h.sumSquares)
+}
+
+// TrimmedMean returns the average of the values without the given fraction,
+// between 0 and 0.5, of the lowest and of the highest ones.
+func (h *Histogram) TrimmedMean(pct float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	lower, upper := trimmedRanks(h.count, pct)
+
+	var seen, kept uint64
+	var sum float64
+	visit := func(count uint64, value float64) bool {
+		from, to := seen, seen+count
+		seen = to
+		if from < lower {
+			from = lower
+		}
+		if to > upper {
+			to = upper
+		}
+		if from < to {
+			sum += float64(to-from) * h.clamp(value)
+			kept += to - from
+		}
+		return seen >= upper
+	}
+
+	done := false
+	negative := sortedBuckets(h.negative)
+	for i := len(negative) - 1; i >= 0 && !done; i-- {
+		done = visit(h.negative[negative[i]], -h.value(negative[i]))
+	}
+	if !done {
+		done = visit(h.zeros, 0)
+	}
+	for _, b := range sortedBuckets(h.positive) {
+		if done {
+			break
+		}
+		done = visit(h.positive[b], h.value(b))
+	}
+	return sum / float64(kept)
+}
+
+// Percentile returns the value at the given percentile, between 0 and 1,
+// interpolating between the closest ranks like TrendSink.P does.
+func (h *Histogram) Percentile(pct float64) float64 {
+	switch {
+	case h.count == 0:
+		return 0
+	case pct <= 0:
+		return h.min
+	case pct >= 1:
+		return h.max
+	}
+
+	i := pct * float64(h.count-1)
+	lower, upper := h.valuesAt(uint64(math.Floor(i)), uint64(math.Ceil(i)))
+	f := i - math.Floor(i)
+	return lower + (upper-lower)*f
+}
+
+// valuesAt returns the estimations of the values at the two given ranks,
+// lower <= upper, in a single walk of the buckets.
+func (h *Histogram) valuesAt(lower, upper uint64) (float64, float64) {
+	var lowerValue, upperValue float64
+	var seen uint64
+	found := false
+	visit := func(count uint64, value float64) bool {
+		seen += count
+		if !found && lower < seen {
+			lowerValue, found = value, true
+		}
+		if upper < seen {
+			upperValue = value
+			return true
+		}
+		return false
+	}
+
+	negative := sortedBuckets(h.negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		if visit(h.negative[negative[i]], -h.value(negative[i])) {
+			return h.clamp(lowerValue), h.clamp(upperValue)
+		}
+	}
+	if visit(h.zeros, 0) {
+		return h.clamp(lowerValue), h.clamp(upperValue)
+	}
+	for _, b := range sortedBuckets(h.positive) {
+		if visit(h.positive[b], h.value(b)) {
+			break
+		}
+	}
+	return h.clamp(lowerValue), h.clamp(upperValue)
+}
+
+// bucket returns the bucket of a positive value.
+func (h *Histogram) bucket(v float64) int64 {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, with frac in [0.5, 1)
+	sub := math.Floor((2*frac - 1) * h.subBuckets)
+	return int64(exp)*int64(h.subBuckets) + int64(sub)
+}
+
+// value returns the middle of the bucket of positive values.
+func (h *Histogram) value(b int64) float64 {
+	subBuckets := int64(h.subBuckets)
+	exp, sub := b/subBuckets, b%subBuckets
+	if sub < 0 {
+		exp, sub = exp-1, sub+subBuckets
+	}
+	return math.Ldexp((1+(float64(sub)+0.5)/h.subBuckets)/2, int(exp))
+}
+
+func (h *Histogram) clamp(v float64) float64 {
+	return math.Max(h.min, math.Min(h.max, v))
+}
+
+func sortedBuckets(buckets map[int64]uint64) []int64 {
+	keys := make([]int64, 0, len(buckets))
+	for b := range buckets {
+		keys = append(keys, b)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
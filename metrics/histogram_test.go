@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHistogram(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewHistogram(0)
+	assert.EqualError(t, err, "the significant digits of a histogram must be between 1 and 5, got 0")
+	_, err = NewHistogram(6)
+	assert.Error(t, err)
+
+	h, err := NewHistogram(3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, h.SignificantDigits())
+	assert.Equal(t, 0.0, h.Percentile(0.5))
+}
+
+func TestHistogramPercentiles(t *testing.T) {
+	t.Parallel()
+
+	for _, digits := range []int{1, 2, 3, 4} {
+		digits := digits
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+
+			r := rand.New(rand.NewSource(int64(digits))) //nolint:gosec
+			h, err := NewHistogram(digits)
+			require.NoError(t, err)
+			sink := NewTrendSink()
+			for i := 0; i < 10000; i++ {
+				v := r.ExpFloat64() * 250
+				if i%100 == 0 {
+					v = -v
+				}
+				h.Add(v)
+				sink.Add(Sample{Value: v})
+			}
+
+			assert.Equal(t, sink.Count(), h.Count())
+			assert.Equal(t, sink.Min(), h.Min())
+			assert.Equal(t, sink.Max(), h.Max())
+			assert.InDelta(t, sink.Avg(), h.Avg(), 1e-9)
+			tolerance := math.Pow10(-digits)
+			for _, pct := range []float64{0.001, 0.25, 0.5, 0.9, 0.95, 0.99, 0.999} {
+				expected := sink.P(pct)
+				assert.InEpsilon(t, expected, h.Percentile(pct), tolerance, "p(%g)", pct*100)
+			}
+		})
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewHistogram(2)
+	require.NoError(t, err)
+	b, err := NewHistogram(2)
+	require.NoError(t, err)
+	for i := 1; i <= 50; i++ {
+		a.Add(float64(i))
+		b.Add(float64(i + 50))
+	}
+	b.Add(0)
+	require.NoError(t, a.Merge(b))
+	assert.Equal(t, uint64(101), a.Count())
+	assert.Equal(t, 0.0, a.Min())
+	assert.Equal(t, 100.0, a.Max())
+	assert.Equal(t, 5050.0, a.Sum())
+	assert.InEpsilon(t, 50, a.Percentile(0.5), 0.01)
+
+	c, err := NewHistogram(3)
+	require.NoError(t, err)
+	assert.EqualError(t, a.Merge(c), "can't merge a histogram with 3 significant digits into one with 2")
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+)
+
+// maxInternedInt covers the HTTP status codes and the k6 error codes, which
+// are the integers most commonly used as tag values.
+const maxInternedInt = 2000
+
+//nolint:gochecknoglobals
+var (
+	internedInts     [maxInternedInt]string
+	internedIntsOnce sync.Once
+)
+
+// IntTagValue returns the decimal representation of n, like strconv.Itoa(),
+// but without allocating a new string for the small non-negative integers,
+// like the status and the error codes, which are added as tag values to
+// every sample of the requests.
+func IntTagValue(n int) string {
+	if n < 0 || n >= maxInternedInt {
+		return strconv.Itoa(n)
+	}
+	internedIntsOnce.Do(func() {
+		for i := range internedInts {
+			internedInts[i] = strconv.Itoa(i)
+		}
+	})
+	return internedInts[n]
+}
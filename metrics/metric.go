@@ -14,6 +14,9 @@ type Metric struct {
 	Name     string     `json:"name"`
 	Type     MetricType `json:"type"`
 	Contains ValueType  `json:"contains"`
+	// Description is the description of the custom metrics, which is shown
+	// by the outputs supporting it, like the HELP text of Prometheus.
+	Description string `json:"description,omitempty"`
 
 	// TODO: decouple the metrics from the sinks and thresholds... have them
 	// linked, but not in the same struct?
@@ -25,6 +28,23 @@ type Metric struct {
 	Observed   bool         `json:"-"`
 }
 
+// Describe sets the description of the metric. The metrics declared again,
+// like by the init context of every VU, have to keep the same description.
+func (m *Metric) Describe(description string) error {
+	m.registry.l.Lock()
+	defer m.registry.l.Unlock()
+
+	switch m.Description {
+	case description:
+		return nil
+	case "":
+		m.Description = description
+		return nil
+	default:
+		return fmt.Errorf("metric '%s' already exists but with the description '%s'", m.Name, m.Description)
+	}
+}
+
 // A Submetric represents a filtered dataset based on a parent metric.
 type Submetric struct {
 	Name   string  `json:"name"`
@@ -60,9 +80,20 @@ func (m *Metric) AddSubmetric(keyValues string) (*Submetric, error) {
 		tags = tags.With(key, value)
 	}
 
+	return m.addSubmetric(keyValues, tags), nil
+}
+
+// AddTagSubmetric creates a new submetric for the samples with the given tag
+// value, which can contain the characters of a threshold definition, and adds
+// it to the metric's submetrics list.
+func (m *Metric) AddTagSubmetric(key, value string) *Submetric {
+	return m.addSubmetric(key+":"+value, m.registry.RootTagSet().With(key, value))
+}
+
+func (m *Metric) addSubmetric(keyValues string, tags *TagSet) *Submetric {
 	for _, sm := range m.Submetrics {
 		if tags == sm.Tags {
-			return sm, nil
+			return sm
 		}
 	}
 
@@ -78,7 +109,7 @@ func (m *Metric) AddSubmetric(keyValues string) (*Submetric, error) {
 
 	m.Submetrics = append(m.Submetrics, subMetric)
 
-	return subMetric, nil
+	return subMetric
 }
 
 // ErrMetricNameParsing indicates parsing a metric name failed
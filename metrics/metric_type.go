@@ -101,7 +101,10 @@ func (t MetricType) supportedAggregationMethods() []string {
 			tokenMin,
 			tokenMax,
 			tokenMed,
+			tokenStdDev,
+			tokenIQR,
 			tokenPercentile,
+			tokenTrimmedMean,
 		}
 	default:
 		// unreachable!
@@ -13,13 +13,22 @@ type Registry struct {
 	metrics map[string]*Metric
 	l       sync.RWMutex
 
+	// namespaces are the namespaces of the metrics registered by the modules
+	// and the extensions, by name
+	namespaces map[string]string
+
 	rootTagSet *atlas.Node
+
+	// trendSignificantDigits is the precision of the histograms of the
+	// sinks of the Trend metrics, the default one if it's zero.
+	trendSignificantDigits int
 }
 
 // NewRegistry returns a new registry
 func NewRegistry() *Registry {
 	return &Registry{
-		metrics: make(map[string]*Metric),
+		metrics:    make(map[string]*Metric),
+		namespaces: make(map[string]string),
 		// All the new TagSts must branch out from this root, otherwise
 		// comparing them and using their Equals() method won't work correctly.
 		rootTagSet: atlas.New(),
@@ -32,7 +41,10 @@ const (
 		"and start with a letter or an underscore."
 )
 
-var compileNameRegex = regexp.MustCompile(nameRegexString)
+var (
+	compileNameRegex = regexp.MustCompile(nameRegexString)
+	namespaceRegex   = regexp.MustCompile(`^[a-zA-Z]([a-zA-Z0-9_]*[a-zA-Z0-9])?$`)
+)
 
 func checkName(name string) bool {
 	return compileNameRegex.Match([]byte(name))
@@ -41,6 +53,14 @@ func checkName(name string) bool {
 // NewMetric returns new metric registered to this registry
 // TODO have multiple versions returning specific metric types when we have such things
 func (r *Registry) NewMetric(name string, typ MetricType, t ...ValueType) (*Metric, error) {
+	return r.registerMetric("", name, typ, t...)
+}
+
+// registerMetric registers the metric of the namespace, or returns it if it
+// was already registered. The metrics of the namespaces can only be
+// registered again by their namespace, while the other ones, like the custom
+// metrics of the scripts, can be the metrics of any namespace.
+func (r *Registry) registerMetric(namespace, name string, typ MetricType, t ...ValueType) (*Metric, error) {
 	r.l.Lock()
 	defer r.l.Unlock()
 
@@ -52,8 +72,19 @@ func (r *Registry) NewMetric(name string, typ MetricType, t ...ValueType) (*Metr
 	if !ok {
 		m := r.newMetric(name, typ, t...)
 		r.metrics[name] = m
+		if namespace != "" {
+			r.namespaces[name] = namespace
+		}
 		return m, nil
 	}
+	if owner := r.namespaces[name]; namespace != "" && owner != namespace {
+		if owner == "" {
+			return nil, fmt.Errorf("metric '%s' of the namespace '%s' collides with a built-in or custom metric",
+				name, namespace)
+		}
+		return nil, fmt.Errorf("metric '%s' of the namespace '%s' collides with the metric of the namespace '%s'",
+			name, namespace, owner)
+	}
 	if oldMetric.Type != typ {
 		return nil, fmt.Errorf("metric '%s' already exists but with type %s, instead of %s", name, oldMetric.Type, typ)
 	}
@@ -75,6 +106,27 @@ func (r *Registry) MustNewMetric(name string, typ MetricType, t ...ValueType) *M
 	return m
 }
 
+// Namespace returns the registry of the metrics of a module or an extension,
+// which prefixes their names with the namespace, like redis_commands for the
+// commands metric of the redis namespace. The namespaced metrics can't have
+// the names of the metrics of the other namespaces, nor of the built-in ones,
+// so the metrics of the extensions of a test never clash.
+func (r *Registry) Namespace(namespace string) (*NamespacedRegistry, error) {
+	if !namespaceRegex.MatchString(namespace) {
+		return nil, fmt.Errorf("invalid metric namespace '%s', it must only include ASCII letters, "+
+			"numbers, or underscores, and start with a letter and not end with an underscore", namespace)
+	}
+	return &NamespacedRegistry{registry: r, namespace: namespace}, nil
+}
+
+// NamespaceOf returns the namespace of the metric, which is empty for the
+// built-in and custom metrics.
+func (r *Registry) NamespaceOf(m *Metric) string {
+	r.l.RLock()
+	defer r.l.RUnlock()
+	return r.namespaces[m.Name]
+}
+
 // All returns all the registered metrics.
 func (r *Registry) All() []*Metric {
 	r.l.RLock()
@@ -96,7 +148,13 @@ func (r *Registry) newMetric(name string, mt MetricType, vt ...ValueType) *Metri
 		valueType = vt[0]
 	}
 
-	sink := NewSink(mt)
+	var sink Sink
+	if mt == Trend && r.trendSignificantDigits != 0 {
+		// the precision has been checked when it was set
+		sink, _ = NewTrendSinkWithPrecision(r.trendSignificantDigits)
+	} else {
+		sink = NewSink(mt)
+	}
 	return &Metric{
 		registry: r,
 		Name:     name,
@@ -106,6 +164,32 @@ func (r *Registry) newMetric(name string, mt MetricType, vt ...ValueType) *Metri
 	}
 }
 
+// SetTrendSignificantDigits sets the precision of the histograms of the sinks
+// of the Trend metrics, both of the ones already registered, as long as they
+// are empty, and of the ones registered later.
+func (r *Registry) SetTrendSignificantDigits(significantDigits int) error {
+	if _, err := NewTrendSinkWithPrecision(significantDigits); err != nil {
+		return err
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.trendSignificantDigits = significantDigits
+	for _, m := range r.metrics {
+		if m.Type != Trend || !m.Sink.IsEmpty() {
+			continue
+		}
+		m.Sink, _ = NewTrendSinkWithPrecision(significantDigits)
+		for _, sm := range m.Submetrics {
+			if sm.Metric.Sink.IsEmpty() {
+				sm.Metric.Sink, _ = NewTrendSinkWithPrecision(significantDigits)
+			}
+		}
+	}
+	return nil
+}
+
 // Get returns the Metric with the given name. If that metric doesn't exist,
 // Get() will return a nil value.
 func (r *Registry) Get(name string) *Metric {
@@ -116,3 +200,30 @@ func (r *Registry) Get(name string) *Metric {
 func (r *Registry) RootTagSet() *TagSet {
 	return (*TagSet)(r.rootTagSet)
 }
+
+// NamespacedRegistry registers the metrics of a namespace, see
+// Registry.Namespace().
+type NamespacedRegistry struct {
+	registry  *Registry
+	namespace string
+}
+
+// Namespace returns the namespace of the registry.
+func (nr *NamespacedRegistry) Namespace() string {
+	return nr.namespace
+}
+
+// NewMetric returns the metric of the namespace with the name, like
+// redis_commands for the commands name of the redis namespace.
+func (nr *NamespacedRegistry) NewMetric(name string, typ MetricType, t ...ValueType) (*Metric, error) {
+	return nr.registry.registerMetric(nr.namespace, nr.namespace+"_"+name, typ, t...)
+}
+
+// MustNewMetric is like NewMetric, but will panic if there is an error
+func (nr *NamespacedRegistry) MustNewMetric(name string, typ MetricType, t ...ValueType) *Metric {
+	m, err := nr.NewMetric(name, typ, t...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
@@ -28,6 +28,53 @@ func TestRegistryNewMetric(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestRegistryNamespace(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+	custom := r.MustNewMetric("kafka_writer_errors", Counter)
+
+	redis, err := r.Namespace("redis")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", redis.Namespace())
+	commands, err := redis.NewMetric("commands", Counter)
+	require.NoError(t, err)
+	assert.Equal(t, "redis_commands", commands.Name)
+	assert.Equal(t, "redis", r.NamespaceOf(commands))
+	assert.Empty(t, r.NamespaceOf(custom))
+
+	// the metrics are registered again by the module instances of every VU
+	again, err := redis.NewMetric("commands", Counter)
+	require.NoError(t, err)
+	assert.Same(t, commands, again)
+	// and they can be the custom metrics of the scripts
+	again, err = r.NewMetric("redis_commands", Counter)
+	require.NoError(t, err)
+	assert.Same(t, commands, again)
+
+	redisCluster, err := r.Namespace("redis_cluster")
+	require.NoError(t, err)
+	_, err = redisCluster.NewMetric("commands", Counter)
+	require.NoError(t, err)
+	nodes := r.MustNewMetric("redis_cluster_nodes", Gauge)
+	_, err = redis.NewMetric("cluster_commands", Counter)
+	assert.EqualError(t, err, "metric 'redis_cluster_commands' of the namespace 'redis' "+
+		"collides with the metric of the namespace 'redis_cluster'")
+	_, err = redisCluster.NewMetric("nodes", Gauge)
+	assert.EqualError(t, err, "metric 'redis_cluster_nodes' of the namespace 'redis_cluster' "+
+		"collides with a built-in or custom metric")
+	assert.Empty(t, r.NamespaceOf(nodes))
+
+	kafka, err := r.Namespace("kafka")
+	require.NoError(t, err)
+	_, err = kafka.NewMetric("writer_errors", Counter)
+	assert.Error(t, err)
+
+	for _, namespace := range []string{"", "_redis", "redis_", "1redis", "re-dis"} {
+		_, err := r.Namespace(namespace)
+		assert.ErrorContains(t, err, "invalid metric namespace", namespace)
+	}
+}
+
 func TestMetricNames(t *testing.T) {
 	t.Parallel()
 	testMap := map[string]bool{
@@ -101,3 +148,25 @@ func TestRegistryAll(t *testing.T) {
 		assert.ElementsMatch(t, exp, names(metrics))
 	})
 }
+
+func TestRegistrySetTrendSignificantDigits(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	before := r.MustNewMetric("before", Trend)
+	sub, err := before.AddSubmetric("status:200")
+	require.NoError(t, err)
+	observed := r.MustNewMetric("observed", Trend)
+	observed.Sink.Add(Sample{Value: 1})
+
+	assert.Error(t, r.SetTrendSignificantDigits(0))
+	require.NoError(t, r.SetTrendSignificantDigits(2))
+	after := r.MustNewMetric("after", Trend)
+
+	for _, m := range []*Metric{before, sub.Metric, after} {
+		sink, ok := m.Sink.(*TrendSink)
+		require.True(t, ok)
+		assert.Equal(t, 2, sink.significantDigits, m.Name)
+	}
+	assert.Equal(t, 0, observed.Sink.(*TrendSink).significantDigits) //nolint:forcetypeassert
+}
@@ -81,6 +81,21 @@ func (cs ConnectedSamples) GetTime() time.Time {
 	return cs.Time
 }
 
+// SampleBatch is a batch of sample containers, sent together through the
+// channel of the samples to reduce the contention on it. The output manager
+// unpacks the batches, so the outputs receive the original containers.
+type SampleBatch []SampleContainer
+
+// GetSamples implements the SampleContainer interface and returns the samples
+// of all containers in the batch.
+func (sb SampleBatch) GetSamples() []Sample {
+	var samples []Sample
+	for _, sc := range sb {
+		samples = append(samples, sc.GetSamples()...)
+	}
+	return samples
+}
+
 // GetSamples implement the ConnectedSampleContainer interface
 // for a single Sample, since it's obviously connected with itself :)
 func (s Sample) GetSamples() []Sample {
@@ -103,6 +118,7 @@ func (s Sample) GetTime() time.Time {
 var (
 	_ SampleContainer = Sample{}
 	_ SampleContainer = Samples{}
+	_ SampleContainer = SampleBatch{}
 )
 
 var (
@@ -140,11 +156,13 @@ func PushIfNotDone(ctx context.Context, output chan<- SampleContainer, sample Sa
 // the summary output and then returns a map of the corresponding resolvers.
 func GetResolversForTrendColumns(trendColumns []string) (map[string]func(s *TrendSink) float64, error) {
 	staticResolvers := map[string]func(s *TrendSink) float64{
-		"avg":   func(s *TrendSink) float64 { return s.Avg() },
-		"min":   func(s *TrendSink) float64 { return s.Min() },
-		"med":   func(s *TrendSink) float64 { return s.P(0.5) },
-		"max":   func(s *TrendSink) float64 { return s.Max() },
-		"count": func(s *TrendSink) float64 { return float64(s.Count()) },
+		"avg":    func(s *TrendSink) float64 { return s.Avg() },
+		"min":    func(s *TrendSink) float64 { return s.Min() },
+		"med":    func(s *TrendSink) float64 { return s.P(0.5) },
+		"max":    func(s *TrendSink) float64 { return s.Max() },
+		"count":  func(s *TrendSink) float64 { return float64(s.Count()) },
+		"stddev": func(s *TrendSink) float64 { return s.StdDev() },
+		"iqr":    func(s *TrendSink) float64 { return s.IQR() },
 	}
 	dynamicResolver := func(percentile float64) func(s *TrendSink) float64 {
 		return func(s *TrendSink) float64 {
@@ -160,6 +178,15 @@ func GetResolversForTrendColumns(trendColumns []string) (map[string]func(s *Tren
 			continue
 		}
 
+		if strings.HasPrefix(stat, "tmean(") {
+			trimmed, err := parseTrimmedMean(stat)
+			if err != nil {
+				return nil, err
+			}
+			result[stat] = func(s *TrendSink) float64 { return s.TrimmedMean(trimmed / 100) }
+			continue
+		}
+
 		percentile, err := parsePercentile(stat)
 		if err != nil {
 			return nil, err
@@ -184,3 +211,19 @@ func parsePercentile(stat string) (float64, error) {
 
 	return percentile, nil
 }
+
+// parseTrimmedMean parses and validates the trimmed mean notation, tmean(10)
+// being the average without the lowest and the highest 10% of the values.
+func parseTrimmedMean(stat string) (float64, error) {
+	if !strings.HasPrefix(stat, "tmean(") || !strings.HasSuffix(stat, ")") {
+		return 0, fmt.Errorf("invalid trend stat '%s', unknown format", stat)
+	}
+
+	trimmed, err := strconv.ParseFloat(stat[6:len(stat)-1], 64)
+
+	if err != nil || (trimmed < 0) || (trimmed >= 50) {
+		return 0, fmt.Errorf("invalid trimmed mean trend stat value '%s', provide a number from 0 to less than 50", stat)
+	}
+
+	return trimmed, nil
+}
@@ -88,18 +88,39 @@ func (g *GaugeSink) Format(t time.Duration) map[string]float64 {
 	return map[string]float64{"value": g.Value}
 }
 
-// NewTrendSink makes a Trend sink with the OpenHistogram circllhist histogram.
+// trendSinkExactValues is how many values a TrendSink keeps, for exact
+// percentiles, before it switches to a histogram.
+const trendSinkExactValues = 10000
+
+// NewTrendSink makes a Trend sink, with the default precision of its histogram.
 func NewTrendSink() *TrendSink {
 	return &TrendSink{}
 }
 
+// NewTrendSinkWithPrecision makes a Trend sink whose histogram has the
+// precision of the given number of significant digits.
+func NewTrendSinkWithPrecision(significantDigits int) (*TrendSink, error) {
+	// check the precision right away, instead of when switching to the histogram
+	if _, err := NewHistogram(significantDigits); err != nil {
+		return nil, err
+	}
+	return &TrendSink{significantDigits: significantDigits}, nil
+}
+
+// TrendSink keeps the values of a Trend metric until there are too many of
+// them, then it switches to a Histogram, so its memory doesn't grow with the
+// duration of the test while the percentiles stay accurate.
 type TrendSink struct {
 	values []float64
 	sorted bool
 
-	count    uint64
-	min, max float64
-	sum      float64
+	histogram         *Histogram
+	significantDigits int
+
+	count      uint64
+	min, max   float64
+	sum        float64
+	sumSquares float64
 }
 
 // IsEmpty indicates whether the TrendSink is empty.
@@ -116,15 +137,39 @@ func (t *TrendSink) Add(s Sample) {
 			t.min = s.Value
 		}
 	}
+	t.count++
+	t.sum += s.Value
+	t.sumSquares += s.Value * s.Value
 
+	if t.histogram != nil {
+		t.histogram.Add(s.Value)
+		return
+	}
 	t.values = append(t.values, s.Value)
 	t.sorted = false
-	t.count++
-	t.sum += s.Value
+	if len(t.values) > trendSinkExactValues {
+		t.switchToHistogram()
+	}
+}
+
+func (t *TrendSink) switchToHistogram() {
+	digits := t.significantDigits
+	if digits == 0 {
+		digits = DefaultHistogramSignificantDigits
+	}
+	// the precision has been checked when the sink was created
+	t.histogram, _ = NewHistogram(digits)
+	for _, v := range t.values {
+		t.histogram.Add(v)
+	}
+	t.values, t.sorted = nil, false
 }
 
 // P calculates the given percentile from sink values.
 func (t *TrendSink) P(pct float64) float64 {
+	if t.histogram != nil {
+		return t.histogram.Percentile(pct)
+	}
 	switch t.count {
 	case 0:
 		return 0
@@ -170,6 +215,61 @@ func (t *TrendSink) Avg() float64 {
 	return 0
 }
 
+// StdDev returns the population standard deviation of the values.
+func (t *TrendSink) StdDev() float64 {
+	return stdDev(t.count, t.sum, t.sumSquares)
+}
+
+// IQR returns the interquartile range of the values, i.e. the difference
+// between their 75th and 25th percentiles.
+func (t *TrendSink) IQR() float64 {
+	return t.P(0.75) - t.P(0.25)
+}
+
+// TrimmedMean returns the average of the values without the given fraction,
+// between 0 and 0.5, of the lowest and of the highest ones, so it isn't skewed
+// by the outliers.
+func (t *TrendSink) TrimmedMean(pct float64) float64 {
+	if t.histogram != nil {
+		return t.histogram.TrimmedMean(pct)
+	}
+	if t.count == 0 {
+		return 0
+	}
+	if !t.sorted {
+		sort.Float64s(t.values)
+		t.sorted = true
+	}
+	lower, upper := trimmedRanks(t.count, pct)
+	var sum float64
+	for _, v := range t.values[lower:upper] {
+		sum += v
+	}
+	return sum / float64(upper-lower)
+}
+
+// stdDev returns the population standard deviation of count values from
+// their sum and the sum of their squares.
+func stdDev(count uint64, sum, sumSquares float64) float64 {
+	if count == 0 {
+		return 0
+	}
+	mean := sum / float64(count)
+	// the rounding errors can make the variance slightly negative
+	return math.Sqrt(math.Max(0, sumSquares/float64(count)-mean*mean))
+}
+
+// trimmedRanks returns the ranks of the count values which are kept once the
+// given fraction of the lowest and of the highest ones are trimmed, there's
+// always at least one of them.
+func trimmedRanks(count uint64, pct float64) (uint64, uint64) {
+	trimmed := uint64(math.Floor(float64(count) * pct))
+	if 2*trimmed >= count {
+		trimmed = (count - 1) / 2
+	}
+	return trimmed, count - trimmed
+}
+
 // Total returns the total (i.e. "sum") value for all measurements.
 func (t *TrendSink) Total() float64 {
 	return t.sum
@@ -226,6 +226,73 @@ func TestTrendSink(t *testing.T) {
 	})
 }
 
+func TestTrendSinkHistogram(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewTrendSinkWithPrecision(7)
+	assert.EqualError(t, err, "the significant digits of a histogram must be between 1 and 5, got 7")
+
+	sink, err := NewTrendSinkWithPrecision(4)
+	require.NoError(t, err)
+	exact := NewTrendSink()
+	for i := 0; i < 3*trendSinkExactValues; i++ {
+		v := float64((i * 7919) % 100000)
+		sink.Add(Sample{Value: v})
+		exact.values = append(exact.values, v)
+	}
+	exact.count = uint64(len(exact.values))
+	assert.Nil(t, sink.values)
+	require.NotNil(t, sink.histogram)
+	assert.Equal(t, 4, sink.histogram.SignificantDigits())
+	assert.Equal(t, uint64(3*trendSinkExactValues), sink.Count())
+	assert.Equal(t, 0.0, sink.Min())
+	assert.Equal(t, exact.P(1), sink.Max())
+	for _, pct := range []float64{0.5, 0.9, 0.99, 0.999} {
+		assert.InEpsilon(t, exact.P(pct), sink.P(pct), 1e-4, "p(%g)", pct*100)
+	}
+}
+
+func TestTrendSinkStatistics(t *testing.T) {
+	t.Parallel()
+
+	sink := NewTrendSink()
+	assert.Equal(t, 0.0, sink.StdDev())
+	assert.Equal(t, 0.0, sink.TrimmedMean(0.1))
+
+	// an outlier which skews the average but not the trimmed mean
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9, 1000, 1} {
+		sink.Add(Sample{Value: v})
+	}
+	assert.InDelta(t, 298.64, sink.StdDev(), 0.01)
+	assert.InDelta(t, 2.5, sink.IQR(), 0.000001)
+	assert.InDelta(t, 5, sink.TrimmedMean(0.1), 0.000001)
+	assert.InDelta(t, 104.1, sink.TrimmedMean(0), 0.000001)
+	assert.InDelta(t, 4.5, sink.TrimmedMean(0.49), 0.000001)
+
+	single := NewTrendSink()
+	single.Add(Sample{Value: 3})
+	assert.Equal(t, 0.0, single.StdDev())
+	assert.Equal(t, 3.0, single.TrimmedMean(0.4))
+
+	histogram, err := NewTrendSinkWithPrecision(4)
+	require.NoError(t, err)
+	exact := NewTrendSink()
+	for i := 0; i < 3*trendSinkExactValues; i++ {
+		v := float64((i * 7919) % 100000)
+		histogram.Add(Sample{Value: v})
+		exact.values = append(exact.values, v)
+		exact.count++
+		exact.sum += v
+		exact.sumSquares += v * v
+	}
+	require.NotNil(t, histogram.histogram)
+	assert.InEpsilon(t, exact.StdDev(), histogram.StdDev(), 1e-9)
+	assert.InEpsilon(t, exact.IQR(), histogram.IQR(), 1e-3)
+	for _, pct := range []float64{0, 0.05, 0.25} {
+		assert.InEpsilon(t, exact.TrimmedMean(pct), histogram.TrimmedMean(pct), 1e-4, "tmean(%g)", pct*100)
+	}
+}
+
 func TestRateSink(t *testing.T) {
 	samples6 := []float64{1.0, 0.0, 1.0, 0.0, 0.0, 1.0}
 
@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// DefaultSLOWindows are the windows the burn rates of an SLO are computed
+// over, when it doesn't define any.
+var DefaultSLOWindows = []types.Duration{ //nolint:gochecknoglobals
+	types.Duration(5 * time.Minute), types.Duration(time.Hour),
+}
+
+// SLO is a service level objective on the latency of a time trend metric,
+// like `{"target": "300ms", "errorBudget": 0.01}` for at most 1% of the
+// requests slower than 300ms.
+//
+// The metrics engine emits the apdex score of the SLO as the slo_apdex
+// metric, and its burn rates over the windows as the slo_burn_rate metric,
+// both tagged with the name of the SLO.
+type SLO struct {
+	// Metric is the trend metric, or submetric, of the latencies; it is
+	// http_req_duration when empty.
+	Metric string `json:"metric"`
+	// Target is the latency under which the requests satisfy the users.
+	Target types.Duration `json:"target"`
+	// Tolerating is the latency under which the requests are tolerated by the
+	// users for the apdex score; it is four times the target when empty.
+	Tolerating types.Duration `json:"tolerating"`
+	// ErrorBudget is the ratio of the requests allowed to be slower than the
+	// target, a burn rate of 1 spends exactly all of it.
+	ErrorBudget float64 `json:"errorBudget"`
+	// Windows are the durations of the windows the burn rates are computed
+	// over; they are tagged with the window tag.
+	Windows []types.Duration `json:"windows"`
+}
+
+// MetricName returns the name of the metric, or submetric, of the SLO.
+func (slo SLO) MetricName() string {
+	if slo.Metric == "" {
+		return HTTPReqDurationName
+	}
+	return slo.Metric
+}
+
+// Validate checks that the SLO with the given name is well-defined and that
+// its metric is a time trend registered in the registry.
+func (slo SLO) Validate(name string, r *Registry) error {
+	metricName, _, err := ParseMetricName(slo.MetricName())
+	if err != nil {
+		return fmt.Errorf("invalid metric of the SLO '%s': %w", name, err)
+	}
+	metric := r.Get(metricName)
+	if metric == nil {
+		return fmt.Errorf("invalid metric of the SLO '%s': no metric name %q found", name, metricName)
+	}
+	if metric.Type != Trend || metric.Contains != Time {
+		return fmt.Errorf("invalid metric of the SLO '%s': %q isn't a trend of durations", name, metricName)
+	}
+
+	if slo.Target <= 0 {
+		return fmt.Errorf("the target of the SLO '%s' must be a positive duration", name)
+	}
+	if slo.Tolerating != 0 && slo.Tolerating < slo.Target {
+		return fmt.Errorf("the tolerating latency of the SLO '%s' can't be lower than its target", name)
+	}
+	if slo.ErrorBudget <= 0 || slo.ErrorBudget >= 1 {
+		return fmt.Errorf("the error budget of the SLO '%s' must be between 0 and 1, got %g", name, slo.ErrorBudget)
+	}
+	for _, window := range slo.Windows {
+		if time.Duration(window) < time.Second {
+			return fmt.Errorf("the windows of the SLO '%s' must be at least 1s, got %s", name, window)
+		}
+	}
+	return nil
+}
+
+// sloBucket counts the requests of a second, and those slower than the target.
+type sloBucket struct {
+	total uint64
+	bad   uint64
+}
+
+// SLOTracker keeps track of the latencies of the metric of an SLO, in order
+// to compute its apdex score and its burn rates.
+type SLOTracker struct {
+	target, tolerating float64
+	errorBudget        float64
+	windows            []time.Duration
+
+	total, satisfied, tolerated uint64
+	// buckets are the counts of the requests of the last window, by second
+	buckets map[int64]*sloBucket
+
+	apdex     TimeSeries
+	burnRates []TimeSeries
+}
+
+// NewSLOTracker returns a tracker for the valid SLO with the given name, which
+// emits its samples on the built-in SLO metrics of the registry.
+func NewSLOTracker(name string, slo SLO, r *Registry) (*SLOTracker, error) {
+	apdex, burnRate := r.Get(SLOApdexName), r.Get(SLOBurnRateName)
+	if apdex == nil || burnRate == nil {
+		return nil, errors.New("the built-in SLO metrics aren't registered")
+	}
+
+	tolerating := slo.Tolerating
+	if tolerating == 0 {
+		tolerating = 4 * slo.Target
+	}
+	windows := slo.Windows
+	if len(windows) == 0 {
+		windows = DefaultSLOWindows
+	}
+
+	tags := r.RootTagSet().With("slo", name)
+	t := &SLOTracker{
+		target:      durationToMillis(slo.Target),
+		tolerating:  durationToMillis(tolerating),
+		errorBudget: slo.ErrorBudget,
+		buckets:     make(map[int64]*sloBucket),
+		apdex:       TimeSeries{Metric: apdex, Tags: tags},
+	}
+	for _, window := range windows {
+		t.windows = append(t.windows, time.Duration(window))
+		t.burnRates = append(t.burnRates, TimeSeries{
+			Metric: burnRate,
+			Tags:   tags.With("window", sloWindowTag(time.Duration(window))),
+		})
+	}
+	return t, nil
+}
+
+// sloWindowTag returns the duration of a window without its zero units, e.g.
+// 5m instead of 5m0s.
+func sloWindowTag(window time.Duration) string {
+	tag := window.String()
+	if strings.HasSuffix(tag, "m0s") {
+		tag = strings.TrimSuffix(tag, "0s")
+	}
+	if strings.HasSuffix(tag, "h0m") {
+		tag = strings.TrimSuffix(tag, "0m")
+	}
+	return tag
+}
+
+func durationToMillis(d types.Duration) float64 {
+	return float64(time.Duration(d)) / float64(time.Millisecond)
+}
+
+// Add adds the latency of a sample of the metric of the SLO.
+func (t *SLOTracker) Add(s Sample) {
+	t.total++
+	switch {
+	case s.Value <= t.target:
+		t.satisfied++
+	case s.Value <= t.tolerating:
+		t.tolerated++
+	}
+
+	key := s.Time.Unix()
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &sloBucket{}
+		t.buckets[key] = b
+	}
+	b.total++
+	if s.Value > t.target {
+		b.bad++
+	}
+}
+
+// Apdex returns the apdex score of all of the latencies so far, and false if
+// there hasn't been any.
+func (t *SLOTracker) Apdex() (float64, bool) {
+	if t.total == 0 {
+		return 0, false
+	}
+	return (float64(t.satisfied) + float64(t.tolerated)/2) / float64(t.total), true
+}
+
+// BurnRate returns how fast the error budget is spent over the window ending
+// at now, and false if there hasn't been any latency in it.
+func (t *SLOTracker) BurnRate(now time.Time, window time.Duration) (float64, bool) {
+	oldest := now.Add(-window).Unix()
+	var total, bad uint64
+	for key, b := range t.buckets {
+		if key <= oldest {
+			continue
+		}
+		total += b.total
+		bad += b.bad
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(bad) / float64(total) / t.errorBudget, true
+}
+
+// Samples returns the samples of the apdex score and the burn rates at now,
+// and drops the counts older than the longest window.
+func (t *SLOTracker) Samples(now time.Time) []Sample {
+	apdex, ok := t.Apdex()
+	if !ok {
+		return nil
+	}
+	samples := []Sample{{TimeSeries: t.apdex, Time: now, Value: apdex}}
+
+	var longest time.Duration
+	for i, window := range t.windows {
+		if window > longest {
+			longest = window
+		}
+		if burnRate, ok := t.BurnRate(now, window); ok {
+			samples = append(samples, Sample{TimeSeries: t.burnRates[i], Time: now, Value: burnRate})
+		}
+	}
+
+	oldest := now.Add(-longest).Unix()
+	for key := range t.buckets {
+		if key <= oldest {
+			delete(t.buckets, key)
+		}
+	}
+	return samples
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestSLOValidate(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	RegisterBuiltinMetrics(registry)
+	registry.MustNewMetric("sizes", Trend)
+
+	validate := func(source string) error {
+		var slo SLO
+		require.NoError(t, json.Unmarshal([]byte(source), &slo))
+		return slo.Validate("checkout", registry)
+	}
+
+	assert.NoError(t, validate(`{"target": "300ms", "errorBudget": 0.01}`))
+	assert.NoError(t, validate(`{"metric": "http_req_duration{scenario:checkout}", "target": 300,
+		"tolerating": "1s", "errorBudget": 0.001, "windows": ["5m", "30m", "6h"]}`))
+
+	invalid := map[string]string{
+		`{"metric": "missing", "target": "1s", "errorBudget": 0.01}`: `no metric name "missing" found`,
+		`{"metric": "sizes", "target": "1s", "errorBudget": 0.01}`:   `"sizes" isn't a trend of durations`,
+		`{"metric": "vus", "target": "1s", "errorBudget": 0.01}`:     `"vus" isn't a trend of durations`,
+		`{"metric": "vus{a}", "target": "1s", "errorBudget": 0.01}`:  "invalid metric of the SLO 'checkout'",
+		`{"errorBudget": 0.01}`: "must be a positive duration",
+		`{"target": "1s", "tolerating": "500ms", "errorBudget": 0.01}`: "can't be lower than its target",
+		`{"target": "1s"}`:                                            "must be between 0 and 1, got 0",
+		`{"target": "1s", "errorBudget": 1}`:                          "must be between 0 and 1, got 1",
+		`{"target": "1s", "errorBudget": 0.01, "windows": ["500ms"]}`: "must be at least 1s, got 500ms",
+	}
+	for source, expectedErr := range invalid {
+		assert.ErrorContains(t, validate(source), expectedErr, source)
+	}
+}
+
+func TestSLOTracker(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	builtin := RegisterBuiltinMetrics(registry)
+	tracker, err := NewSLOTracker("checkout", SLO{
+		Target:      types.Duration(100 * time.Millisecond),
+		ErrorBudget: 0.1,
+		Windows:     []types.Duration{types.Duration(10 * time.Second), types.Duration(time.Minute)},
+	}, registry)
+	require.NoError(t, err)
+
+	start := time.Unix(1000, 0)
+	assert.Empty(t, tracker.Samples(start))
+
+	// 6 satisfied, 2 tolerated and 2 frustrated requests over the first minute
+	for i, value := range []float64{10, 20, 50, 100, 100, 90, 150, 400, 401, 1000} {
+		tracker.Add(Sample{Time: start.Add(time.Duration(i) * 5 * time.Second), Value: value})
+	}
+	apdex, ok := tracker.Apdex()
+	require.True(t, ok)
+	assert.InDelta(t, 0.7, apdex, 0.0001)
+
+	now := start.Add(50 * time.Second)
+	burnRate, ok := tracker.BurnRate(now, 10*time.Second)
+	require.True(t, ok)
+	assert.InDelta(t, 10, burnRate, 0.0001) // the last two requests are slow
+	burnRate, ok = tracker.BurnRate(now, time.Minute)
+	require.True(t, ok)
+	assert.InDelta(t, 4, burnRate, 0.0001)
+
+	samples := tracker.Samples(now)
+	require.Len(t, samples, 3)
+	for _, sample := range samples {
+		assert.Equal(t, now, sample.Time)
+		slo, _ := sample.Tags.Get("slo")
+		assert.Equal(t, "checkout", slo)
+	}
+	assert.Equal(t, builtin.SLOApdex, samples[0].Metric)
+	assert.InDelta(t, 0.7, samples[0].Value, 0.0001)
+	assert.Equal(t, builtin.SLOBurnRate, samples[1].Metric)
+	assert.Equal(t, map[string]string{"slo": "checkout", "window": "10s"}, samples[1].Tags.Map())
+	assert.InDelta(t, 10, samples[1].Value, 0.0001)
+	assert.Equal(t, map[string]string{"slo": "checkout", "window": "1m"}, samples[2].Tags.Map())
+	assert.InDelta(t, 4, samples[2].Value, 0.0001)
+
+	// the windows without any requests don't have a burn rate
+	samples = tracker.Samples(start.Add(2 * time.Minute))
+	require.Len(t, samples, 1)
+	assert.Equal(t, builtin.SLOApdex, samples[0].Metric)
+	_, ok = tracker.BurnRate(start.Add(50*time.Second), time.Minute)
+	assert.False(t, ok, "the older requests should have been dropped")
+}
+
+func TestSLOWindowTag(t *testing.T) {
+	t.Parallel()
+
+	for window, expected := range map[time.Duration]string{
+		time.Second:                     "1s",
+		90 * time.Second:                "1m30s",
+		5 * time.Minute:                 "5m",
+		time.Hour:                       "1h",
+		time.Hour + 30*time.Minute:      "1h30m",
+		72 * time.Hour:                  "72h",
+		time.Hour + time.Minute + 100e6: "1h1m0.1s",
+	} {
+		assert.Equal(t, expected, sloWindowTag(window))
+	}
+}
@@ -38,10 +38,11 @@ const (
 	TagVU   // non-indexable
 	TagOCSPStatus
 	TagIP
+	TagFullURL // non-indexable
 )
 
 // DefaultSystemTagSet includes all of the system tags emitted with metrics by default.
-// Other tags that are not enabled by default include: iter, vu, ocsp_status, ip
+// Other tags that are not enabled by default include: iter, vu, ocsp_status, ip, full_url
 //
 //nolint:gochecknoglobals
 var DefaultSystemTagSet = SystemTagSet(
@@ -51,7 +52,7 @@ var DefaultSystemTagSet = SystemTagSet(
 // NonIndexableSystemTags are high cardinality system tags (i.e. metadata).
 //
 //nolint:gochecknoglobals
-var NonIndexableSystemTags = SystemTagSet(TagIter | TagVU)
+var NonIndexableSystemTags = SystemTagSet(TagIter | TagVU | TagFullURL)
 
 // Add adds a tag to tag set.
 func (i *SystemTagSet) Add(tag SystemTag) {
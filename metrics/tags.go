@@ -74,6 +74,10 @@ func (ts *TagSet) Map() map[string]string {
 	return ((*atlas.Node)(ts)).Path()
 }
 
+// smallTagsMapLen is the maximum number of tags WithTagsFromMap sorts without
+// allocating.
+const smallTagsMapLen = 16
+
 // WithTagsFromMap sorts the given tags by their keys and adds them to the
 // current tag set one by one, without branching out. This is generally
 // discouraged and sequential usage of the TagSet.With() method should be
@@ -92,11 +96,27 @@ func (ts *TagSet) WithTagsFromMap(m map[string]string) *TagSet {
 
 	// We sort the keys so the TagSet generation is consistent across multiple
 	// invocations. This should create fewer dead-end atlas Nodes.
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+	var keys []string
+	if len(m) <= smallTagsMapLen {
+		// The custom tags are usually only a few, so they are sorted on the
+		// stack with an insertion sort, without allocating for every sample.
+		var buf [smallTagsMapLen]string
+		keys = buf[:0]
+		for k := range m {
+			i := len(keys)
+			keys = append(keys, k)
+			for ; i > 0 && keys[i-1] > k; i-- {
+				keys[i] = keys[i-1]
+			}
+			keys[i] = k
+		}
+	} else {
+		keys = make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 	}
-	sort.Strings(keys)
 
 	tags := ts
 	for i := 0; i < len(keys); i++ {
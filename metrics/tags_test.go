@@ -2,6 +2,8 @@ package metrics
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -109,6 +111,47 @@ func TestTagSetContains(t *testing.T) {
 	assert.False(t, st.Contains(outer))
 }
 
+//nolint:paralleltest // AllocsPerRun can not be used in parallel tests
+func TestTagSetWithTagsFromMap(t *testing.T) {
+	root := NewRegistry().RootTagSet()
+	assert.True(t, root == root.WithTagsFromMap(nil))
+
+	small := map[string]string{"c": "3", "a": "1", "b": "2"}
+	assert.Equal(t, small, root.WithTagsFromMap(small).Map())
+	assert.True(t, root.WithTagsFromMap(small) == root.With("a", "1").With("b", "2").With("c", "3"))
+
+	large := make(map[string]string, smallTagsMapLen+1)
+	for i := 0; i <= smallTagsMapLen; i++ {
+		large[fmt.Sprintf("key%02d", i)] = strconv.Itoa(i)
+	}
+	assert.Equal(t, large, root.WithTagsFromMap(large).Map())
+
+	tags := root.WithTagsFromMap(small) // create the nodes before counting
+	allocs := testing.AllocsPerRun(100, func() {
+		tags = root.WithTagsFromMap(small)
+	})
+	assert.Zero(t, allocs)
+	assert.Equal(t, small, tags.Map())
+}
+
+//nolint:paralleltest // AllocsPerRun can not be used in parallel tests
+func TestIntTagValue(t *testing.T) {
+	for _, n := range []int{-1, 0, 7, 200, 1050, maxInternedInt - 1, maxInternedInt, 123456} {
+		assert.Equal(t, strconv.Itoa(n), IntTagValue(n))
+	}
+	assert.Zero(t, testing.AllocsPerRun(100, func() { _ = IntTagValue(404) }))
+}
+
+func BenchmarkTagSetWithTagsFromMap(b *testing.B) {
+	root := NewRegistry().RootTagSet()
+	tags := map[string]string{"endpoint": "login", "method": "POST", "status": "200", "group": "::auth"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.WithTagsFromMap(tags)
+	}
+}
+
 func TestTagsAndMetaSetTag(t *testing.T) {
 	t.Parallel()
 
@@ -26,6 +26,9 @@ type Threshold struct {
 	AbortGracePeriod types.NullDuration
 	// parsed is the threshold expression parsed from the Source
 	parsed *thresholdExpression
+	// window keeps the samples of the last window, if the threshold is
+	// evaluated over sliding windows
+	window *thresholdWindow
 }
 
 func newThreshold(src string, abortOnFail bool, gracePeriod types.NullDuration) *Threshold {
@@ -47,36 +50,37 @@ func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
 		return true, nil
 	}
 
-	// Apply the threshold expression operator to the left and
-	// right hand side values
-	var passes bool
-	switch t.parsed.Operator {
+	// Perform the actual threshold verification
+	return applyThresholdOperator(t.Source, lhs, t.parsed.Operator, t.parsed.Value)
+}
+
+// applyThresholdOperator applies the operator of the threshold with the given
+// source to the left and right hand side values.
+func applyThresholdOperator(source string, lhs float64, operator string, rhs float64) (bool, error) {
+	switch operator {
 	case ">":
-		passes = lhs > t.parsed.Value
+		return lhs > rhs, nil
 	case ">=":
-		passes = lhs >= t.parsed.Value
+		return lhs >= rhs, nil
 	case "<=":
-		passes = lhs <= t.parsed.Value
+		return lhs <= rhs, nil
 	case "<":
-		passes = lhs < t.parsed.Value
+		return lhs < rhs, nil
 	case "==", "===":
 		// Considering a sink always maps to float64 values,
 		// strictly equal is equivalent to loosely equal
-		passes = lhs == t.parsed.Value
+		return lhs == rhs, nil
 	case "!=":
-		passes = lhs != t.parsed.Value
+		return lhs != rhs, nil
 	default:
 		// The parseThresholdExpression function should ensure that no invalid
 		// operator gets through, but let's protect our future selves anyhow.
 		return false, fmt.Errorf("unable to apply threshold %s over metrics; "+
 			"reason: %s is an invalid operator",
-			t.Source,
-			t.parsed.Operator,
+			source,
+			operator,
 		)
 	}
-
-	// Perform the actual threshold verification
-	return passes, nil
 }
 
 func (t *Threshold) run(sinks map[string]float64) (bool, error) {
@@ -85,6 +89,36 @@ func (t *Threshold) run(sinks map[string]float64) (bool, error) {
 	return passes, err
 }
 
+// runWindow evaluates a threshold over the window ending at now. The windows
+// are only evaluated once there has been a whole one since the first sample,
+// unless the test run is over before that, then it's evaluated over all of
+// it. The threshold fails if it failed over any window.
+func (t *Threshold) runWindow(now time.Time, final bool) (bool, error) {
+	w := t.window
+	if !w.first.IsZero() && (now.Sub(w.first) >= w.duration || (final && !w.evaluated)) {
+		w.evaluated = true
+		passes, err := t.runNoTaint(w.sinked(now, t.parsed))
+		if err != nil {
+			return false, err
+		}
+		if !passes {
+			w.failed = true
+		}
+	}
+	t.LastFailed = w.failed
+	return !w.failed, nil
+}
+
+// setParsed sets the parsed expression of the threshold and the sliding
+// windows it's evaluated over, if any.
+func (t *Threshold) setParsed(parsed *thresholdExpression) {
+	t.parsed = parsed
+	t.window = nil
+	if parsed.Window > 0 {
+		t.window = newThresholdWindow(parsed.Window)
+	}
+}
+
 type thresholdConfig struct {
 	Threshold        string             `json:"threshold"`
 	AbortOnFail      bool               `json:"abortOnFail"`
@@ -142,10 +176,16 @@ func newThresholdsWithConfig(configs []thresholdConfig) Thresholds {
 	return Thresholds{thresholds, false, sinked}
 }
 
-func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
+func (ts *Thresholds) runAll(timeSpentInTest time.Duration, now time.Time, final bool) (bool, error) {
 	succeeded := true
 	for i, threshold := range ts.Thresholds {
-		b, err := threshold.run(ts.sinked)
+		var b bool
+		var err error
+		if threshold.window != nil {
+			b, err = threshold.runWindow(now, final)
+		} else {
+			b, err = threshold.run(ts.sinked)
+		}
 		if err != nil {
 			return false, fmt.Errorf("threshold %d run error: %w", i, err)
 		}
@@ -165,11 +205,58 @@ func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
 	return succeeded, nil
 }
 
+// AddSample adds the sample to the windows of the thresholds which are
+// evaluated over sliding windows.
+func (ts *Thresholds) AddSample(sample Sample) {
+	for _, t := range ts.Thresholds {
+		if t.window != nil {
+			t.window.add(sample)
+		}
+	}
+}
+
+// IsWindowed returns whether any of the parsed thresholds is evaluated over
+// sliding windows.
+func (ts *Thresholds) IsWindowed() bool {
+	for _, t := range ts.Thresholds {
+		if t.window != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // Run processes all the thresholds with the provided Sink at the provided time and returns if any
 // of them fails
 func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
-	// Initialize the sinks store
-	ts.sinked = make(map[string]float64)
+	return ts.run(sink, duration, time.Now(), false)
+}
+
+// RunFinal is like Run, for the end of the test run: the thresholds evaluated
+// over sliding windows are evaluated over all of it if it was shorter than
+// their windows.
+func (ts *Thresholds) RunFinal(sink Sink, duration time.Duration) (bool, error) {
+	return ts.run(sink, duration, time.Now(), true)
+}
+
+func (ts *Thresholds) run(sink Sink, duration time.Duration, now time.Time, final bool) (bool, error) {
+	expressions := make([]*thresholdExpression, len(ts.Thresholds))
+	for i, threshold := range ts.Thresholds {
+		expressions[i] = threshold.parsed
+	}
+
+	var err error
+	if ts.sinked, err = sinkValues(sink, duration, expressions); err != nil {
+		return false, err
+	}
+
+	return ts.runAll(duration, now, final)
+}
+
+// sinkValues returns the values of the sink for the aggregation methods of
+// the thresholds, including the parametric ones of the given expressions.
+func sinkValues(sink Sink, duration time.Duration, expressions []*thresholdExpression) (map[string]float64, error) {
+	sinked := make(map[string]float64)
 
 	// FIXME: Remove this comment as soon as the metrics.Sink does not expose Format anymore.
 	//
@@ -180,37 +267,38 @@ func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
 	// For more details, see https://github.com/grafana/k6/issues/2320
 	switch sinkImpl := sink.(type) {
 	case *CounterSink:
-		ts.sinked["count"] = sinkImpl.Value
-		ts.sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
+		sinked["count"] = sinkImpl.Value
+		sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
 	case *GaugeSink:
-		ts.sinked["value"] = sinkImpl.Value
+		sinked["value"] = sinkImpl.Value
 	case *TrendSink:
-		ts.sinked["min"] = sinkImpl.Min()
-		ts.sinked["max"] = sinkImpl.Max()
-		ts.sinked["avg"] = sinkImpl.Avg()
-		ts.sinked["med"] = sinkImpl.P(0.5)
-
-		// Parse the percentile thresholds and insert them in
-		// the sinks mapping.
-		for _, threshold := range ts.Thresholds {
-			if threshold.parsed.AggregationMethod != tokenPercentile {
-				continue
+		sinked["min"] = sinkImpl.Min()
+		sinked["max"] = sinkImpl.Max()
+		sinked["avg"] = sinkImpl.Avg()
+		sinked["med"] = sinkImpl.P(0.5)
+		sinked["stddev"] = sinkImpl.StdDev()
+		sinked["iqr"] = sinkImpl.IQR()
+
+		// Insert the percentiles and the trimmed means of the thresholds in the sinks mapping.
+		for _, expression := range expressions {
+			switch expression.AggregationMethod {
+			case tokenPercentile:
+				sinked[expression.SinkKey()] = sinkImpl.P(expression.AggregationValue.Float64 / 100)
+			case tokenTrimmedMean:
+				sinked[expression.SinkKey()] = sinkImpl.TrimmedMean(expression.AggregationValue.Float64 / 100)
 			}
-
-			key := fmt.Sprintf("p(%g)", threshold.parsed.AggregationValue.Float64)
-			ts.sinked[key] = sinkImpl.P(threshold.parsed.AggregationValue.Float64 / 100)
 		}
 	case *RateSink:
 		// We want to avoid division by zero, which
 		// would lead to [#2520](https://github.com/grafana/k6/issues/2520)
 		if sinkImpl.Total > 0 {
-			ts.sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
+			sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
 		}
 	default:
-		return false, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
+		return nil, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
 	}
 
-	return ts.runAll(duration)
+	return sinked, nil
 }
 
 // Parse parses the Thresholds and fills each Threshold.parsed field with the result.
@@ -222,7 +310,7 @@ func (ts *Thresholds) Parse() error {
 			return err
 		}
 
-		t.parsed = parsed
+		t.setParsed(parsed)
 	}
 
 	return nil
@@ -263,7 +351,7 @@ func (ts *Thresholds) Validate(metricName string, r *Registry) error {
 					"parsing threshold failed %w", threshold.Source, metricName, err)
 			}
 
-			threshold.parsed = thresholdExpression
+			threshold.setParsed(thresholdExpression)
 		}
 
 		// If the threshold's expression aggregation method is not
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/guregu/null.v3"
 )
@@ -27,6 +28,12 @@ type thresholdExpression struct {
 
 	// Value holds the value parsed from the threshold expression.
 	Value float64
+
+	// Window holds the duration of the sliding windows the expression is
+	// evaluated over, from its optional `over` suffix. For instance: an
+	// expression of the form p(95) < 300 over 1m would result in Window to be
+	// set to a minute. It's 0 when the expression applies to the whole test run.
+	Window time.Duration
 }
 
 // SinkKey computes the key used to index a thresholdExpression in the engine's sinks.
@@ -42,36 +49,51 @@ type thresholdExpression struct {
 // we recompute the whole "p(value)" expression in order to look for it in the
 // sinks.
 func (te *thresholdExpression) SinkKey() string {
-	if te.AggregationMethod == tokenPercentile {
-		return fmt.Sprintf("%s(%g)", tokenPercentile, te.AggregationValue.Float64)
+	if te.isParametric() {
+		return fmt.Sprintf("%s(%g)", te.AggregationMethod, te.AggregationValue.Float64)
 	}
 
 	return te.AggregationMethod
 }
 
+// isParametric returns whether the aggregation method has a value, which
+// has to be passed to the sink to compute it.
+func (te *thresholdExpression) isParametric() bool {
+	return te.AggregationMethod == tokenPercentile || te.AggregationMethod == tokenTrimmedMean
+}
+
 // parseThresholdAssertion parses a threshold condition expression,
 // as defined in a JS script (for instance p(95)<1000), into a thresholdExpression
 // instance.
 //
-// It is expected to be of the form: `aggregation_method operator value`.
+// It is expected to be of the form: `aggregation_method operator value`,
+// optionally followed by `over duration`.
 // As defined by the following BNF:
 // ```
+// expression          -> assertion (whitespace+ "over" whitespace+ duration)?
 // assertion           -> aggregation_method whitespace* operator whitespace* float
 // aggregation_method  -> trend | rate | gauge | counter
 // counter             -> "count" | "rate"
 // gauge               -> "value"
 // rate                -> "rate"
-// trend               -> "avg" | "min" | "max" | "med" | percentile
+// trend               -> "avg" | "min" | "max" | "med" | "stddev" | "iqr" | percentile | trimmed_mean
 // percentile          -> "p(" float ")"
+// trimmed_mean        -> "tmean(" float ")"
 // operator            -> ">" | ">=" | "<=" | "<" | "==" | "===" | "!="
 // float               -> digit+ ("." digit+)?
 // digit               -> "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9"
 // whitespace          -> " "
+// duration            -> a Go duration, e.g. "1m" or "30s"
 // ```
 func parseThresholdExpression(input string) (*thresholdExpression, error) {
+	assertion, window, err := parseThresholdWindow(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
+	}
+
 	// Scanning makes no assumption on the underlying values, and only
 	// checks that the expression has the right format.
-	method, operator, value, err := scanThresholdExpression(input)
+	method, operator, value, err := scanThresholdExpression(assertion)
 	if err != nil {
 		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
 	}
@@ -97,11 +119,35 @@ func parseThresholdExpression(input string) (*thresholdExpression, error) {
 		AggregationValue:  parsedMethodValue,
 		Operator:          operator,
 		Value:             parsedValue,
+		Window:            window,
 	}
 
 	return condition, nil
 }
 
+// tokenOver separates a threshold assertion from the duration of the sliding
+// windows it's evaluated over.
+const tokenOver = " over "
+
+// parseThresholdWindow splits the optional `over duration` suffix from a
+// threshold expression and parses its duration, which must be positive.
+func parseThresholdWindow(input string) (string, time.Duration, error) {
+	i := strings.LastIndex(input, tokenOver)
+	if i < 0 {
+		return input, 0, nil
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(input[i+len(tokenOver):]))
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed window duration; reason: %w", err)
+	}
+	if window <= 0 {
+		return "", 0, fmt.Errorf("the window duration must be positive, got %s", window)
+	}
+
+	return input[:i], window, nil
+}
+
 // Define accepted threshold expression operators tokens
 const (
 	tokenLessEqual     = "<="
@@ -161,7 +207,12 @@ const (
 	tokenMin        = "min"
 	tokenMed        = "med"
 	tokenMax        = "max"
+	tokenStdDev     = "stddev"
+	tokenIQR        = "iqr"
 	tokenPercentile = "p"
+	// tokenTrimmedMean is the average without the lowest and highest values,
+	// tmean(10) trims 10% of the values at each end.
+	tokenTrimmedMean = "tmean"
 )
 
 // aggregationMethodTokens defines the list of aggregation method
@@ -170,7 +221,7 @@ const (
 // It is meant to be used during the parsing of threshold expressions.
 // Although declared as a `var`, being an array, it is effectively
 // immutable and can be considered constant.
-var aggregationMethodTokens = [8]string{ //nolint:gochecknoglobals
+var aggregationMethodTokens = [11]string{ //nolint:gochecknoglobals
 	tokenValue,
 	tokenCount,
 	tokenRate,
@@ -178,7 +229,10 @@ var aggregationMethodTokens = [8]string{ //nolint:gochecknoglobals
 	tokenMin,
 	tokenMed,
 	tokenMax,
+	tokenStdDev,
+	tokenIQR,
 	tokenPercentile,
+	tokenTrimmedMean,
 }
 
 // parseThresholdMethod will parse a threshold condition expression's method.
@@ -206,6 +260,16 @@ func parseThresholdAggregationMethod(input string) (string, null.Float, error) {
 		return tokenPercentile, null.FloatFrom(aggregationValue), nil
 	}
 
+	// Or a trimmed mean expression, of the form tmean(value)
+	if strings.HasPrefix(input, tokenTrimmedMean+"(") && strings.HasSuffix(input, ")") {
+		trimmed, err := parseTrimmedMean(input)
+		if err != nil {
+			return "", null.Float{}, fmt.Errorf("malformed trimmed mean value; reason: %w", err)
+		}
+
+		return tokenTrimmedMean, null.FloatFrom(trimmed), nil
+	}
+
 	return "", null.Float{}, fmt.Errorf("failed parsing method from expression")
 }
 
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/guregu/null.v3"
@@ -40,6 +41,30 @@ func TestParseThresholdExpression(t *testing.T) {
 			wantExpression: &thresholdExpression{AggregationMethod: "count", Operator: ">", Value: 20},
 			wantErr:        false,
 		},
+		{
+			name:  "valid threshold expression over windows",
+			input: "p(95) < 300 over 1m",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: "p",
+				AggregationValue:  null.FloatFrom(95),
+				Operator:          "<",
+				Value:             300,
+				Window:            time.Minute,
+			},
+			wantErr: false,
+		},
+		{
+			name:           "malformed window duration fails",
+			input:          "count>20 over a minute",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "non positive window duration fails",
+			input:          "count>20 over 0s",
+			wantExpression: nil,
+			wantErr:        true,
+		},
 	}
 	for _, testCase := range tests {
 		testCase := testCase
@@ -143,6 +168,41 @@ func TestParseThresholdAggregationMethod(t *testing.T) {
 			wantMethodValue: null.FloatFrom(99.9),
 			wantErr:         false,
 		},
+		{
+			name:            "stddev method is parsed",
+			input:           "stddev",
+			wantMethod:      tokenStdDev,
+			wantMethodValue: null.Float{},
+			wantErr:         false,
+		},
+		{
+			name:            "iqr method is parsed",
+			input:           "iqr",
+			wantMethod:      tokenIQR,
+			wantMethodValue: null.Float{},
+			wantErr:         false,
+		},
+		{
+			name:            "trimmed mean method is parsed",
+			input:           "tmean(2.5)",
+			wantMethod:      tokenTrimmedMean,
+			wantMethodValue: null.FloatFrom(2.5),
+			wantErr:         false,
+		},
+		{
+			name:            "parsing out of range trimmed mean value fails",
+			input:           "tmean(50)",
+			wantMethod:      "",
+			wantMethodValue: null.Float{},
+			wantErr:         true,
+		},
+		{
+			name:            "parsing non-numerical trimmed mean value fails",
+			input:           "tmean(foo)",
+			wantMethod:      "",
+			wantMethodValue: null.Float{},
+			wantErr:         true,
+		},
 		{
 			name:            "parsing invalid method fails",
 			input:           "foo",
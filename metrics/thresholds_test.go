@@ -42,7 +42,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 	}{
 		{
 			name:             "valid expression using the > operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -50,7 +50,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the > operator over passing threshold and defined abort grace period",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(2 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -58,7 +58,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the >= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreaterEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreaterEqual, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -66,7 +66,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the <= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLessEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLessEqual, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -74,7 +74,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the < operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLess, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLess, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           true,
@@ -82,7 +82,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the == operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLooselyEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLooselyEqual, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -90,7 +90,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the === operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenStrictlyEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenStrictlyEqual, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -98,7 +98,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using != operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenBangEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenBangEqual, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.02},
 			wantOk:           true,
@@ -106,7 +106,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over failing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -114,7 +114,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over non-existing sink",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"med": 27.2},
 			wantOk:           true,
@@ -124,7 +124,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 			// The ParseThresholdCondition constructor should ensure that no invalid
 			// operator gets through, but let's protect our future selves anyhow.
 			name:             "invalid expression operator",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, "&", 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, "&", 0.01, 0},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -168,7 +168,7 @@ func BenchmarkRunNoTaint(b *testing.B) {
 		LastFailed:       false,
 		AbortOnFail:      false,
 		AbortGracePeriod: types.NullDurationFrom(2 * time.Second),
-		parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+		parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0},
 	}
 
 	sinks := map[string]float64{"rate": 1}
@@ -616,7 +616,7 @@ func TestThresholdsRunAll(t *testing.T) {
 
 			runDuration := 1500 * time.Millisecond
 
-			succeeded, err := thresholds.runAll(runDuration)
+			succeeded, err := thresholds.runAll(runDuration, time.Now(), false)
 
 			if data.err {
 				assert.Error(t, err)
@@ -730,6 +730,25 @@ func TestThresholdsRun(t *testing.T) {
 			},
 			want:    false,
 			wantErr: false,
+		}, {
+			name: "Running thresholds on trend sink with the stddev, iqr and trimmed mean statements succeeds",
+			args: args{
+				sink:                 getTrendSink(70, 80, 90, 100, 1000),
+				thresholdExpressions: []string{"stddev>300", "iqr==20", "tmean(20)==90", "tmean(0)==268"},
+				duration:             0,
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "Running threshold on trend sink with values and failing trimmed mean statement fails",
+			args: args{
+				sink:                 getTrendSink(70, 80, 90, 100, 1000),
+				thresholdExpressions: []string{"tmean(20)<90"},
+				duration:             0,
+			},
+			want:    false,
+			wantErr: false,
 		},
 	}
 	for _, testCase := range tests {
@@ -749,6 +768,96 @@ func TestThresholdsRun(t *testing.T) {
 	}
 }
 
+func TestThresholdsRunOverWindows(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	duration := registry.MustNewMetric("my_trend", Trend)
+	reqs := registry.MustNewMetric("my_counter", Counter)
+	start := time.Date(2023, time.October, 15, 10, 30, 0, 0, time.UTC)
+	sample := func(m *Metric, offset time.Duration, value float64) Sample {
+		return Sample{TimeSeries: TimeSeries{Metric: m, Tags: registry.RootTagSet()}, Time: start.Add(offset), Value: value}
+	}
+
+	t.Run("a degradation after a good start fails", func(t *testing.T) {
+		t.Parallel()
+
+		thresholds := NewThresholds([]string{"p(95)<300 over 1m", "p(95)<300"})
+		require.NoError(t, thresholds.Parse())
+		assert.True(t, thresholds.IsWindowed())
+		sink := NewTrendSink()
+		add := func(offset time.Duration, value float64) {
+			s := sample(duration, offset, value)
+			sink.Add(s)
+			thresholds.AddSample(s)
+		}
+
+		for i := 0; i < 1200; i++ {
+			add(time.Duration(i)*time.Second, 100)
+		}
+		ok, err := thresholds.run(sink, 20*time.Minute, start.Add(20*time.Minute), false)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		// a minute of degradation, which doesn't fail the cumulative p(95)
+		for i := 1200; i < 1260; i++ {
+			add(time.Duration(i)*time.Second, 1000)
+		}
+		ok, err = thresholds.run(sink, 21*time.Minute, start.Add(21*time.Minute), false)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.True(t, thresholds.Thresholds[0].LastFailed)
+		assert.False(t, thresholds.Thresholds[1].LastFailed)
+
+		// it stays failed once the degradation is over
+		for i := 1260; i < 1380; i++ {
+			add(time.Duration(i)*time.Second, 100)
+		}
+		ok, err = thresholds.run(sink, 23*time.Minute, start.Add(23*time.Minute), true)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("the windows are evaluated once whole", func(t *testing.T) {
+		t.Parallel()
+
+		thresholds := NewThresholds([]string{"count>=10 over 1m"})
+		require.NoError(t, thresholds.Parse())
+		sink := &CounterSink{}
+		for i := 0; i < 5; i++ {
+			s := sample(reqs, time.Duration(i)*time.Second, 1)
+			sink.Add(s)
+			thresholds.AddSample(s)
+		}
+
+		ok, err := thresholds.run(sink, 30*time.Second, start.Add(30*time.Second), false)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		// the test run is shorter than the window, so it's evaluated over all of it
+		ok, err = thresholds.run(sink, 30*time.Second, start.Add(30*time.Second), true)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("the samples out of the window are dropped", func(t *testing.T) {
+		t.Parallel()
+
+		thresholds := NewThresholds([]string{"count<=10 over 10s"})
+		require.NoError(t, thresholds.Parse())
+		sink := &CounterSink{}
+		for i := 0; i < 60; i++ {
+			s := sample(reqs, time.Duration(i)*time.Second, 1)
+			sink.Add(s)
+			thresholds.AddSample(s)
+			ok, err := thresholds.run(sink, time.Duration(i)*time.Second, s.Time, false)
+			require.NoError(t, err)
+			require.True(t, ok, "second %d", i)
+		}
+		assert.Len(t, thresholds.Thresholds[0].window.buckets, thresholdWindowBuckets)
+	})
+}
+
 func TestThresholdsJSON(t *testing.T) {
 	t.Parallel()
 
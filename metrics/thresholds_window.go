@@ -0,0 +1,132 @@
+package metrics
+
+import "time"
+
+// thresholdWindowBuckets is how many buckets the sliding windows of a
+// threshold are split into, so they slide by the duration of a bucket.
+const thresholdWindowBuckets = 10
+
+// windowBucket is the aggregation of the samples of a metric in a bucket.
+type windowBucket struct {
+	count     uint64
+	nonZero   uint64
+	sum       float64
+	last      float64
+	lastTime  time.Time
+	histogram *Histogram
+}
+
+// thresholdWindow keeps the samples of the last window of a threshold which
+// is evaluated over sliding windows, aggregated in buckets.
+type thresholdWindow struct {
+	duration   time.Duration
+	bucketSize time.Duration
+	buckets    map[int64]*windowBucket
+	metricType MetricType
+
+	// first is the time of the first sample, the windows are evaluated
+	// once there has been a whole one since it.
+	first     time.Time
+	evaluated bool
+	// failed is whether the threshold failed over any of the windows.
+	failed bool
+}
+
+func newThresholdWindow(duration time.Duration) *thresholdWindow {
+	bucketSize := duration / thresholdWindowBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	return &thresholdWindow{
+		duration:   duration,
+		bucketSize: bucketSize,
+		buckets:    make(map[int64]*windowBucket),
+	}
+}
+
+func (w *thresholdWindow) add(s Sample) {
+	if w.first.IsZero() || s.Time.Before(w.first) {
+		w.first = s.Time
+		w.metricType = s.Metric.Type
+	}
+
+	key := s.Time.UnixNano() / int64(w.bucketSize)
+	b, ok := w.buckets[key]
+	if !ok {
+		b = &windowBucket{}
+		if w.metricType == Trend {
+			b.histogram, _ = NewHistogram(DefaultHistogramSignificantDigits)
+		}
+		w.buckets[key] = b
+	}
+	b.count++
+	b.sum += s.Value
+	if s.Value != 0 {
+		b.nonZero++
+	}
+	if !s.Time.Before(b.lastTime) {
+		b.last, b.lastTime = s.Value, s.Time
+	}
+	if b.histogram != nil {
+		b.histogram.Add(s.Value)
+	}
+}
+
+// sinked returns the values of the metric over the window ending at now, for
+// the aggregation method of the expression, and drops the older buckets.
+func (w *thresholdWindow) sinked(now time.Time, expression *thresholdExpression) map[string]float64 {
+	oldest := now.UnixNano()/int64(w.bucketSize) - thresholdWindowBuckets + 1
+	var merged windowBucket
+	if w.metricType == Trend {
+		merged.histogram, _ = NewHistogram(DefaultHistogramSignificantDigits)
+	}
+	for key, b := range w.buckets {
+		if key < oldest {
+			delete(w.buckets, key)
+			continue
+		}
+		merged.count += b.count
+		merged.nonZero += b.nonZero
+		merged.sum += b.sum
+		if !b.lastTime.Before(merged.lastTime) {
+			merged.last, merged.lastTime = b.last, b.lastTime
+		}
+		if merged.histogram != nil {
+			_ = merged.histogram.Merge(b.histogram)
+		}
+	}
+
+	sinked := make(map[string]float64)
+	if merged.count == 0 {
+		return sinked
+	}
+	switch w.metricType {
+	case Counter:
+		// the test run can be shorter than the window
+		span := now.Sub(w.first)
+		if span > w.duration || span <= 0 {
+			span = w.duration
+		}
+		sinked["count"] = merged.sum
+		sinked["rate"] = merged.sum / span.Seconds()
+	case Gauge:
+		sinked["value"] = merged.last
+	case Rate:
+		sinked["rate"] = float64(merged.nonZero) / float64(merged.count)
+	case Trend:
+		h := merged.histogram
+		sinked["min"] = h.Min()
+		sinked["max"] = h.Max()
+		sinked["avg"] = h.Avg()
+		sinked["med"] = h.Percentile(0.5)
+		sinked["stddev"] = h.StdDev()
+		sinked["iqr"] = h.Percentile(0.75) - h.Percentile(0.25)
+		switch expression.AggregationMethod {
+		case tokenPercentile:
+			sinked[expression.SinkKey()] = h.Percentile(expression.AggregationValue.Float64 / 100)
+		case tokenTrimmedMean:
+			sinked[expression.SinkKey()] = h.TrimmedMean(expression.AggregationValue.Float64 / 100)
+		}
+	}
+	return sinked
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// SummaryTimeSeries configures the series of the values of some metrics over
+// fixed time buckets that are passed to handleSummary, like
+// `{"interval": "10s", "metrics": {"http_req_duration": ["p(95)"]}, "breakdown": ["scenario"]}`
+// for the 95th percentile of the request durations every 10 seconds, with a
+// series for each scenario.
+type SummaryTimeSeries struct {
+	// Interval is the duration of the buckets.
+	Interval types.Duration `json:"interval"`
+	// Metrics are the stats of the series of each metric, the ones of the
+	// end-of-test summary when empty.
+	Metrics map[string][]string `json:"metrics"`
+	// Breakdown are the tags the series of the metrics are split by, one
+	// for each combination of their values.
+	Breakdown []string `json:"breakdown"`
+}
+
+// summaryStats are the stats of the non-trend metrics in the summary.
+var summaryStats = map[MetricType][]string{ //nolint:gochecknoglobals
+	Counter: {"count", "rate"},
+	Gauge:   {"value", "min", "max"},
+	Rate:    {"rate", "passes", "fails"},
+}
+
+// Validate checks that the interval is at least one second, and that the
+// metrics are registered and have the requested stats.
+func (sts SummaryTimeSeries) Validate(r *Registry) error {
+	if time.Duration(sts.Interval) < time.Second {
+		return fmt.Errorf("the interval of the summary time series must be at least 1s, got %s", sts.Interval)
+	}
+	if len(sts.Metrics) == 0 {
+		return fmt.Errorf("the summary time series need at least a metric")
+	}
+	for name, stats := range sts.Metrics {
+		metric := r.Get(name)
+		if metric == nil {
+			return fmt.Errorf("invalid metric of the summary time series: no metric name %q found", name)
+		}
+		if metric.Type == Trend {
+			if _, err := GetResolversForTrendColumns(stats); err != nil {
+				return fmt.Errorf("invalid stats of the summary time series of %q: %w", name, err)
+			}
+			continue
+		}
+		for _, stat := range stats {
+			if !contains(summaryStats[metric.Type], stat) {
+				return fmt.Errorf("invalid stat %q of the summary time series of %q, a %s metric has %s",
+					stat, name, metric.Type, strings.Join(summaryStats[metric.Type], ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TimeBuckets aggregates the samples of the metrics of a SummaryTimeSeries in
+// a sink for each of their series and bucket. It isn't safe for concurrent
+// use.
+type TimeBuckets struct {
+	config SummaryTimeSeries
+	series map[bucketedSeriesKey]*BucketedSeries
+}
+
+type bucketedSeriesKey struct {
+	metric *Metric
+	tags   string
+}
+
+// BucketedSeries is the aggregation of the samples of a metric with the same
+// values of the breakdown tags.
+type BucketedSeries struct {
+	Metric *Metric
+	// Tags are the values of the breakdown tags, the missing ones are empty.
+	Tags map[string]string
+	// Buckets are sorted by time, the buckets without samples are skipped.
+	Buckets []Bucket
+
+	buckets map[int64]Sink
+}
+
+// Bucket is the aggregation of the samples of an interval.
+type Bucket struct {
+	Time time.Time
+	Sink Sink
+}
+
+// NewTimeBuckets returns the buckets of the validated config.
+func NewTimeBuckets(config SummaryTimeSeries) *TimeBuckets {
+	return &TimeBuckets{
+		config: config,
+		series: make(map[bucketedSeriesKey]*BucketedSeries),
+	}
+}
+
+// Config returns the config of the buckets.
+func (tb *TimeBuckets) Config() SummaryTimeSeries {
+	return tb.config
+}
+
+// Add adds the sample to the bucket of its time in its series, if its metric
+// is one of the config's.
+func (tb *TimeBuckets) Add(sample Sample) {
+	if _, ok := tb.config.Metrics[sample.Metric.Name]; !ok {
+		return
+	}
+
+	values := make([]string, len(tb.config.Breakdown))
+	for i, tag := range tb.config.Breakdown {
+		values[i], _ = sample.Tags.Get(tag)
+	}
+	key := bucketedSeriesKey{metric: sample.Metric, tags: strings.Join(values, "\x00")}
+	series, ok := tb.series[key]
+	if !ok {
+		tags := make(map[string]string, len(values))
+		for i, tag := range tb.config.Breakdown {
+			tags[tag] = values[i]
+		}
+		series = &BucketedSeries{Metric: sample.Metric, Tags: tags, buckets: make(map[int64]Sink)}
+		tb.series[key] = series
+	}
+
+	start := sample.Time.Truncate(time.Duration(tb.config.Interval)).UnixNano()
+	sink, ok := series.buckets[start]
+	if !ok {
+		sink = NewSink(sample.Metric.Type)
+		series.buckets[start] = sink
+	}
+	sink.Add(sample)
+}
+
+// Series returns the series with their sorted buckets, sorted by metric name
+// and tag values.
+func (tb *TimeBuckets) Series() []*BucketedSeries {
+	keys := make([]bucketedSeriesKey, 0, len(tb.series))
+	for key := range tb.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].metric.Name != keys[j].metric.Name {
+			return keys[i].metric.Name < keys[j].metric.Name
+		}
+		return keys[i].tags < keys[j].tags
+	})
+
+	result := make([]*BucketedSeries, len(keys))
+	for i, key := range keys {
+		series := tb.series[key]
+		series.Buckets = series.Buckets[:0]
+		for start, sink := range series.buckets {
+			series.Buckets = append(series.Buckets, Bucket{Time: time.Unix(0, start), Sink: sink})
+		}
+		sort.Slice(series.Buckets, func(i, j int) bool { return series.Buckets[i].Time.Before(series.Buckets[j].Time) })
+		result[i] = series
+	}
+	return result
+}
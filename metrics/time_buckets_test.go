@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestSummaryTimeSeriesValidate(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	RegisterBuiltinMetrics(registry)
+
+	validate := func(source string) error {
+		var sts SummaryTimeSeries
+		require.NoError(t, json.Unmarshal([]byte(source), &sts))
+		return sts.Validate(registry)
+	}
+
+	assert.NoError(t, validate(`{"interval": "10s", "metrics": {"http_req_duration": ["p(95)", "avg"]}}`))
+	assert.NoError(t, validate(`{"interval": 1000, "metrics": {"http_reqs": [], "vus": ["max"], "checks": ["fails"]},
+		"breakdown": ["scenario", "status"]}`))
+
+	invalid := map[string]string{
+		`{"metrics": {"http_reqs": []}}`:                  "must be at least 1s, got 0s",
+		`{"interval": "10s"}`:                             "need at least a metric",
+		`{"interval": "10s", "metrics": {"missing": []}}`: `no metric name "missing" found`,
+		`{"interval": "10s", "metrics": {"http_req_duration": ["p(x)"]}}`: `invalid stats of the summary time series of ` +
+			`"http_req_duration"`,
+		`{"interval": "10s", "metrics": {"http_reqs": ["p(95)"]}}`: `invalid stat "p(95)" of the summary time series of ` +
+			`"http_reqs", a counter metric has count, rate`,
+	}
+	for source, msg := range invalid {
+		assert.ErrorContains(t, validate(source), msg, source)
+	}
+}
+
+func TestTimeBuckets(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	reqs := registry.MustNewMetric("reqs", Counter)
+	vus := registry.MustNewMetric("vus", Gauge)
+	buckets := NewTimeBuckets(SummaryTimeSeries{
+		Interval:  types.Duration(time.Second),
+		Metrics:   map[string][]string{"reqs": nil},
+		Breakdown: []string{"status"},
+	})
+
+	start := time.Unix(10, 0)
+	add := func(metric *Metric, offset time.Duration, tags map[string]string) {
+		buckets.Add(Sample{
+			TimeSeries: TimeSeries{Metric: metric, Tags: registry.RootTagSet().WithTagsFromMap(tags)},
+			Time:       start.Add(offset),
+			Value:      1,
+		})
+	}
+	add(reqs, 2500*time.Millisecond, map[string]string{"status": "200"})
+	add(reqs, 500*time.Millisecond, map[string]string{"status": "200", "method": "GET"})
+	add(reqs, 700*time.Millisecond, map[string]string{"status": "200"})
+	add(reqs, 100*time.Millisecond, nil)
+	add(vus, 0, map[string]string{"status": "200"})
+
+	series := buckets.Series()
+	require.Len(t, series, 2)
+
+	assert.Equal(t, map[string]string{"status": ""}, series[0].Tags)
+	require.Len(t, series[0].Buckets, 1)
+	assert.Equal(t, start, series[0].Buckets[0].Time)
+
+	assert.Equal(t, map[string]string{"status": "200"}, series[1].Tags)
+	require.Len(t, series[1].Buckets, 2)
+	assert.Equal(t, start, series[1].Buckets[0].Time)
+	assert.Equal(t, 2.0, series[1].Buckets[0].Sink.(*CounterSink).Value)
+	assert.Equal(t, start.Add(2*time.Second), series[1].Buckets[1].Time)
+	assert.Equal(t, 1.0, series[1].Buckets[1].Sink.(*CounterSink).Value)
+}
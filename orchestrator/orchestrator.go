@@ -0,0 +1,145 @@
+// Package orchestrator notifies external controllers, like chaos tools,
+// autoscalers or CD pipelines, of the lifecycle of a test run, so they can
+// coordinate with it. The controllers are registered as hooks, which are
+// called with the events of the test run.
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// EventType is the type of the lifecycle events.
+type EventType string
+
+// The lifecycle events of a test run.
+const (
+	// TestStart is sent right before the test run starts. The test run is
+	// aborted if any of the hooks fails on it.
+	TestStart EventType = "test-start"
+	// ScenarioStart is sent when a scenario starts running its iterations.
+	ScenarioStart EventType = "scenario-start"
+	// StageChange is sent when a scenario with stages enters one of them.
+	StageChange EventType = "stage-change"
+	// ScenarioEnd is sent when a scenario has finished.
+	ScenarioEnd EventType = "scenario-end"
+	// ThresholdCrossed is sent when thresholds are crossed while the test runs.
+	ThresholdCrossed EventType = "threshold-crossed"
+	// TestEnd is sent when the test run has finished.
+	TestEnd EventType = "test-end"
+)
+
+// Event is an event of the lifecycle of a test run.
+type Event struct {
+	Type            EventType      `json:"type"`
+	Time            time.Time      `json:"time"`
+	TestRunDuration types.Duration `json:"testRunDuration"`
+
+	// Scenarios are the names of all of the scenarios, for TestStart.
+	Scenarios []string `json:"scenarios,omitempty"`
+	// Scenario is the name of the scenario of the scenario and stage events.
+	Scenario string `json:"scenario,omitempty"`
+	// Stage is the stage entered by the scenario, for StageChange.
+	Stage *Stage `json:"stage,omitempty"`
+	// Thresholds are the names of the metrics whose thresholds were crossed,
+	// for ThresholdCrossed and TestEnd.
+	Thresholds []string `json:"thresholds,omitempty"`
+	// Error is the error of the scenario or of the test run, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Stage is a stage of a scenario.
+type Stage struct {
+	Index    int            `json:"index"`
+	Duration types.Duration `json:"duration"`
+	Target   int64          `json:"target"`
+}
+
+// Hook is notified of the lifecycle events of the test runs.
+type Hook interface {
+	Notify(ctx context.Context, evt Event) error
+}
+
+// Notifier sends the lifecycle events to all of the hooks.
+type Notifier struct {
+	hooks                     []Hook
+	logger                    logrus.FieldLogger
+	getCurrentTestRunDuration func() time.Duration
+
+	// wg tracks the asynchronous notifications
+	wg sync.WaitGroup
+
+	mu         sync.Mutex
+	stopStages map[string]context.CancelFunc
+}
+
+// NewNotifier returns a notifier of the hooks. The duration of the test run
+// in the events is from getCurrentTestRunDuration.
+func NewNotifier(
+	logger logrus.FieldLogger, getCurrentTestRunDuration func() time.Duration, hooks ...Hook,
+) *Notifier {
+	return &Notifier{
+		hooks:                     hooks,
+		logger:                    logger.WithField("component", "orchestrator"),
+		getCurrentTestRunDuration: getCurrentTestRunDuration,
+		stopStages:                make(map[string]context.CancelFunc),
+	}
+}
+
+// Notify sends the event to all of the hooks concurrently and waits for them.
+// The failures are logged, and the first of them is returned.
+func (n *Notifier) Notify(ctx context.Context, evt Event) error {
+	evt.Time = time.Now()
+	evt.TestRunDuration = types.Duration(n.getCurrentTestRunDuration())
+
+	errs := make([]error, len(n.hooks))
+	var wg sync.WaitGroup
+	wg.Add(len(n.hooks))
+	for i, hook := range n.hooks {
+		i, hook := i, hook
+		go func() {
+			defer wg.Done()
+			errs[i] = hook.Notify(ctx, evt)
+		}()
+	}
+	wg.Wait()
+
+	var firstErr error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		n.logger.WithError(err).WithField("event", evt.Type).Warn("A lifecycle hook failed")
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// NotifyAsync sends the event to all of the hooks without waiting for them.
+// Wait() waits for all of these notifications.
+func (n *Notifier) NotifyAsync(ctx context.Context, evt Event) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		_ = n.Notify(ctx, evt)
+	}()
+}
+
+// Wait waits for the asynchronous notifications to be sent, and stops the
+// tracking of the stages of the scenarios.
+func (n *Notifier) Wait() {
+	n.mu.Lock()
+	for name, stop := range n.stopStages {
+		stop()
+		delete(n.stopStages, name)
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+}
@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestNotifierWebhooks(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan Event, 1)
+	ok := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var evt Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&evt))
+		received <- evt
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	okHook, err := NewWebhook(ok.URL, time.Second)
+	require.NoError(t, err)
+	failingHook, err := NewWebhook(failing.URL, time.Second)
+	require.NoError(t, err)
+
+	n := NewNotifier(testutils.NewLogger(t), func() time.Duration { return 3 * time.Second }, okHook, failingHook)
+	err = n.Notify(context.Background(), Event{Type: TestStart, Scenarios: []string{"default"}})
+	require.ErrorContains(t, err, "responded to the test-start event with status 503: not ready")
+
+	evt := <-received
+	assert.Equal(t, TestStart, evt.Type)
+	assert.Equal(t, types.Duration(3*time.Second), evt.TestRunDuration)
+	assert.Equal(t, []string{"default"}, evt.Scenarios)
+}
+
+func TestNewWebhookInvalidURL(t *testing.T) {
+	t.Parallel()
+
+	for _, rawURL := range []string{"localhost:8080", "ftp://localhost/hook", "http://"} {
+		_, err := NewWebhook(rawURL, time.Second)
+		assert.ErrorContains(t, err, "invalid lifecycle hook URL", rawURL)
+	}
+}
+
+func TestStageAt(t *testing.T) {
+	t.Parallel()
+
+	stages := []executor.Stage{
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(10)},
+		{Duration: types.NullDurationFrom(0), Target: null.IntFrom(20)},
+		{Duration: types.NullDurationFrom(5 * time.Second), Target: null.IntFrom(0)},
+	}
+	testCases := []struct {
+		offset time.Duration
+		index  int
+		ok     bool
+	}{
+		{0, 0, true},
+		{9 * time.Second, 0, true},
+		{10 * time.Second, 2, true},
+		{14 * time.Second, 2, true},
+		{15 * time.Second, 0, false},
+	}
+	for _, tc := range testCases {
+		index, ok := stageAt(stages, tc.offset)
+		assert.Equal(t, tc.ok, ok, tc.offset)
+		assert.Equal(t, tc.index, index, tc.offset)
+	}
+}
@@ -0,0 +1,112 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+)
+
+// stageCheckInterval is how often the current stages of the running scenarios
+// are checked, which can be changed while they run.
+const stageCheckInterval = 100 * time.Millisecond
+
+// ScenarioStarted notifies the hooks that the scenario has started, and
+// starts tracking its stages, if it has any.
+func (n *Notifier) ScenarioStarted(ctx context.Context, ex lib.Executor) {
+	name := ex.GetConfig().GetName()
+	_ = n.Notify(ctx, Event{Type: ScenarioStart, Scenario: name})
+
+	getStages := stagesOf(ex)
+	if getStages == nil {
+		return
+	}
+	stagesCtx, stop := context.WithCancel(ctx)
+	n.mu.Lock()
+	n.stopStages[name] = stop
+	n.mu.Unlock()
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.trackStages(stagesCtx, name, time.Now(), getStages)
+	}()
+}
+
+// ScenarioFinished stops the tracking of the stages of the scenario, and
+// notifies the hooks that it has finished.
+func (n *Notifier) ScenarioFinished(ctx context.Context, ex lib.Executor, err error) {
+	name := ex.GetConfig().GetName()
+	n.mu.Lock()
+	if stop, ok := n.stopStages[name]; ok {
+		stop()
+		delete(n.stopStages, name)
+	}
+	n.mu.Unlock()
+
+	evt := Event{Type: ScenarioEnd, Scenario: name}
+	if err != nil {
+		evt.Error = err.Error()
+	}
+	_ = n.Notify(ctx, evt)
+}
+
+// trackStages sends a StageChange event every time the scenario enters one
+// of its stages, until the context is done or the stages are over.
+func (n *Notifier) trackStages(ctx context.Context, name string, started time.Time, getStages func() []executor.Stage) {
+	ticker := time.NewTicker(stageCheckInterval)
+	defer ticker.Stop()
+
+	current := -1
+	for {
+		stages := getStages()
+		index, ok := stageAt(stages, time.Since(started))
+		if !ok {
+			return
+		}
+		if index != current {
+			current = index
+			_ = n.Notify(ctx, Event{
+				Type:     StageChange,
+				Scenario: name,
+				Stage: &Stage{
+					Index:    index,
+					Duration: stages[index].Duration.Duration,
+					Target:   stages[index].Target.Int64,
+				},
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stageAt returns the index of the stage at the offset since the start of the
+// scenario, and false if the stages are already over.
+func stageAt(stages []executor.Stage, offset time.Duration) (int, bool) {
+	var end time.Duration
+	for i, stage := range stages {
+		end += time.Duration(stage.Duration.Duration)
+		if offset < end {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// stagesOf returns the function returning the current stages of the executor,
+// or nil if it doesn't have any.
+func stagesOf(ex lib.Executor) func() []executor.Stage {
+	if withStages, ok := ex.(interface{ GetCurrentStages() []executor.Stage }); ok {
+		return withStages.GetCurrentStages
+	}
+	if config, ok := ex.GetConfig().(*executor.RampingArrivalRateConfig); ok {
+		return func() []executor.Stage { return config.Stages }
+	}
+	return nil
+}
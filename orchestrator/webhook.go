@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.k6.io/k6/lib/consts"
+)
+
+// webhook is a hook posting the events as JSON to a URL. The response has
+// to have a 2xx status, and the test run waits for it.
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+var _ Hook = &webhook{}
+
+// NewWebhook returns a hook posting the events to the HTTP(S) URL, which has
+// to respond within the timeout.
+func NewWebhook(rawURL string, timeout time.Duration) (Hook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lifecycle hook URL '%s': %w", rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid lifecycle hook URL '%s', it has to be an http or https URL", rawURL)
+	}
+	return &webhook{
+		url:    rawURL,
+		client: &http.Client{Timeout: timeout, Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}},
+	}, nil
+}
+
+func (w *webhook) Notify(ctx context.Context, evt Event) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "k6/"+consts.Version)
+	req.Header.Set("K6-Lifecycle-Event", string(evt.Type))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("the lifecycle hook %s failed: %w", w.url, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("the lifecycle hook %s responded to the %s event with status %d: %s",
+			w.url, evt.Type, res.StatusCode, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
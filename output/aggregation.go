@@ -0,0 +1,253 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// aggregationGracePeriod is how long after its end a window is closed, for
+// the samples which are received with some delay to still be in it.
+const aggregationGracePeriod = time.Second
+
+// AggregationConfig configures the aggregation of the samples of an output.
+type AggregationConfig struct {
+	// Window is the duration of the windows the samples are aggregated over.
+	Window types.NullDuration `json:"window"`
+	// SignificantDigits is the precision of the histograms of the Trend metrics.
+	SignificantDigits null.Int `json:"significantDigits"`
+}
+
+// Validate checks that the window and the precision of the histograms are valid.
+func (c AggregationConfig) Validate() error {
+	if c.Window.Valid && c.Window.Duration <= 0 {
+		return fmt.Errorf("the aggregation window must be positive, got %s", c.Window.Duration)
+	}
+	if c.SignificantDigits.Valid {
+		if _, err := metrics.NewHistogram(int(c.SignificantDigits.Int64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AggregatedSeries is the aggregation of the samples of a time series in a window.
+type AggregatedSeries struct {
+	metrics.TimeSeries
+
+	Count    uint64
+	Sum      float64
+	Min, Max float64
+	// Last is the last value of a Gauge metric.
+	Last float64
+	// NonZero is the number of non-zero values of a Rate metric.
+	NonZero uint64
+	// Histogram is the distribution of the values of a Trend metric.
+	Histogram *metrics.Histogram
+}
+
+func (s *AggregatedSeries) add(v float64) {
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Count++
+	s.Sum += v
+	s.Last = v
+	if v != 0 {
+		s.NonZero++
+	}
+	if s.Histogram != nil {
+		s.Histogram.Add(v)
+	}
+}
+
+// Value returns the value summarizing the series with a single sample: the
+// sum of a Counter, the last value of a Gauge, the ratio of non-zero values
+// of a Rate and the average of a Trend.
+func (s *AggregatedSeries) Value() float64 {
+	switch s.Metric.Type {
+	case metrics.Counter:
+		return s.Sum
+	case metrics.Gauge:
+		return s.Last
+	case metrics.Rate:
+		return float64(s.NonZero) / float64(s.Count)
+	default:
+		return s.Sum / float64(s.Count)
+	}
+}
+
+// AggregatedSamples are the series aggregated over a window. The outputs
+// which don't handle them specifically receive a single sample summarizing
+// each series, see AggregatedSeries.Value.
+type AggregatedSamples struct {
+	// Time is the start of the window.
+	Time   time.Time
+	Window time.Duration
+	Series []*AggregatedSeries
+}
+
+// GetSamples returns a sample summarizing each series.
+func (as AggregatedSamples) GetSamples() []metrics.Sample {
+	samples := make([]metrics.Sample, 0, len(as.Series))
+	for _, s := range as.Series {
+		samples = append(samples, metrics.Sample{TimeSeries: s.TimeSeries, Time: as.Time, Value: s.Value()})
+	}
+	return samples
+}
+
+// AggregatedOutput wraps an output, aggregating the samples it receives per
+// time series over windows, with histograms for the Trend metrics so their
+// percentiles stay accurate.
+type AggregatedOutput struct {
+	Output
+	SampleBuffer
+
+	window            time.Duration
+	significantDigits int
+	periodicFlusher   *PeriodicFlusher
+
+	windows map[int64]map[metrics.TimeSeries]*AggregatedSeries
+}
+
+var (
+	_ WithTestRunStop       = new(AggregatedOutput)
+	_ WithStopWithTestError = new(AggregatedOutput)
+)
+
+// NewAggregatedOutput wraps the output, aggregating its samples as configured.
+func NewAggregatedOutput(out Output, config AggregationConfig) (*AggregatedOutput, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	ao := &AggregatedOutput{
+		Output:            out,
+		window:            10 * time.Second,
+		significantDigits: metrics.DefaultHistogramSignificantDigits,
+		windows:           make(map[int64]map[metrics.TimeSeries]*AggregatedSeries),
+	}
+	if config.Window.Valid {
+		ao.window = config.Window.TimeDuration()
+	}
+	if config.SignificantDigits.Valid {
+		ao.significantDigits = int(config.SignificantDigits.Int64)
+	}
+	return ao, nil
+}
+
+// AddMetricSamples buffers the samples until they are aggregated.
+func (ao *AggregatedOutput) AddMetricSamples(samples []metrics.SampleContainer) {
+	ao.SampleBuffer.AddMetricSamples(samples)
+}
+
+// Start starts the wrapped output and the periodic aggregation of the samples.
+func (ao *AggregatedOutput) Start() error {
+	if err := ao.Output.Start(); err != nil {
+		return err
+	}
+	pf, err := NewPeriodicFlusher(time.Second, func() {
+		ao.flush(time.Now().Add(-aggregationGracePeriod))
+	})
+	if err != nil {
+		return err
+	}
+	ao.periodicFlusher = pf
+	return nil
+}
+
+// Stop passes all the remaining windows to the wrapped output and stops it.
+func (ao *AggregatedOutput) Stop() error {
+	ao.stopFlushing()
+	return ao.Output.Stop()
+}
+
+// SetTestRunStopCallback passes the callback to the wrapped output, if it can
+// stop the test run.
+func (ao *AggregatedOutput) SetTestRunStopCallback(callback func(error)) {
+	if out, ok := ao.Output.(WithTestRunStop); ok {
+		out.SetTestRunStopCallback(callback)
+	}
+}
+
+// StopWithTestError passes all the remaining windows to the wrapped output and
+// stops it, with the test's error if it can receive it.
+func (ao *AggregatedOutput) StopWithTestError(testRunErr error) error {
+	ao.stopFlushing()
+	if out, ok := ao.Output.(WithStopWithTestError); ok {
+		return out.StopWithTestError(testRunErr)
+	}
+	return ao.Output.Stop()
+}
+
+func (ao *AggregatedOutput) stopFlushing() {
+	if ao.periodicFlusher == nil {
+		return
+	}
+	ao.periodicFlusher.Stop()
+	ao.flush(time.Unix(0, 1<<62))
+}
+
+// flush aggregates the buffered samples and passes the windows which are over
+// before the deadline to the wrapped output.
+func (ao *AggregatedOutput) flush(deadline time.Time) {
+	for _, sc := range ao.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			ao.add(sample)
+		}
+	}
+
+	var starts []int64
+	for start := range ao.windows {
+		if start+int64(ao.window) <= deadline.UnixNano() {
+			starts = append(starts, start)
+		}
+	}
+	if len(starts) == 0 {
+		return
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	containers := make([]metrics.SampleContainer, 0, len(starts))
+	for _, start := range starts {
+		window := ao.windows[start]
+		delete(ao.windows, start)
+
+		as := AggregatedSamples{
+			Time:   time.Unix(0, start),
+			Window: ao.window,
+			Series: make([]*AggregatedSeries, 0, len(window)),
+		}
+		for _, s := range window {
+			as.Series = append(as.Series, s)
+		}
+		containers = append(containers, as)
+	}
+	ao.Output.AddMetricSamples(containers)
+}
+
+func (ao *AggregatedOutput) add(sample metrics.Sample) {
+	start := sample.Time.Truncate(ao.window).UnixNano()
+	window, ok := ao.windows[start]
+	if !ok {
+		window = make(map[metrics.TimeSeries]*AggregatedSeries)
+		ao.windows[start] = window
+	}
+	s, ok := window[sample.TimeSeries]
+	if !ok {
+		s = &AggregatedSeries{TimeSeries: sample.TimeSeries}
+		if sample.Metric.Type == metrics.Trend {
+			// the precision has been validated already
+			s.Histogram, _ = metrics.NewHistogram(ao.significantDigits)
+		}
+		window[sample.TimeSeries] = s
+	}
+	s.add(sample.Value)
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestAggregationConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, AggregationConfig{}.Validate())
+	assert.NoError(t, AggregationConfig{
+		Window: types.NullDurationFrom(time.Minute), SignificantDigits: null.IntFrom(2),
+	}.Validate())
+	assert.EqualError(t, AggregationConfig{Window: types.NullDurationFrom(0)}.Validate(),
+		"the aggregation window must be positive, got 0s")
+	assert.EqualError(t, AggregationConfig{SignificantDigits: null.IntFrom(9)}.Validate(),
+		"the significant digits of a histogram must be between 1 and 5, got 9")
+}
+
+func TestAggregatedOutput(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	failed := registry.MustNewMetric("http_req_failed", metrics.Rate)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+	tagsA := registry.RootTagSet().With("url", "a")
+	tagsB := registry.RootTagSet().With("url", "b")
+	start := time.Date(2023, time.October, 15, 10, 30, 0, 0, time.UTC)
+	sample := func(m *metrics.Metric, tags *metrics.TagSet, offset time.Duration, value float64) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags}, Time: start.Add(offset), Value: value}
+	}
+
+	ro := &recordingOutput{}
+	ao, err := NewAggregatedOutput(ro, AggregationConfig{Window: types.NullDurationFrom(10 * time.Second)})
+	require.NoError(t, err)
+	require.NoError(t, ao.Start())
+
+	var samples metrics.Samples
+	for i := 0; i < 100; i++ {
+		offset := time.Duration(i) * 50 * time.Millisecond
+		samples = append(samples,
+			sample(reqs, tagsA, offset, 1),
+			sample(duration, tagsA, offset, float64(i+1)),
+			sample(failed, tagsA, offset, float64(i%4/3)),
+		)
+	}
+	samples = append(samples,
+		sample(reqs, tagsB, time.Second, 1),
+		sample(vus, tagsA, time.Second, 5),
+		sample(vus, tagsA, 2*time.Second, 3),
+		sample(reqs, tagsA, 12*time.Second, 1),
+	)
+	ao.AddMetricSamples([]metrics.SampleContainer{samples})
+	require.NoError(t, ao.StopWithTestError(nil))
+
+	containers := ro.GetBufferedSamples()
+	require.Len(t, containers, 2)
+	first, ok := containers[0].(AggregatedSamples)
+	require.True(t, ok)
+	assert.Equal(t, start, first.Time.UTC())
+	assert.Equal(t, 10*time.Second, first.Window)
+	require.Len(t, first.Series, 5)
+
+	series := make(map[metrics.TimeSeries]*AggregatedSeries)
+	for _, s := range first.Series {
+		series[s.TimeSeries] = s
+	}
+	reqsA := series[metrics.TimeSeries{Metric: reqs, Tags: tagsA}]
+	assert.Equal(t, uint64(100), reqsA.Count)
+	assert.Equal(t, 100.0, reqsA.Value())
+	durationA := series[metrics.TimeSeries{Metric: duration, Tags: tagsA}]
+	assert.Equal(t, 50.5, durationA.Value())
+	assert.Equal(t, 1.0, durationA.Min)
+	assert.Equal(t, 100.0, durationA.Max)
+	assert.InEpsilon(t, 99.01, durationA.Histogram.Percentile(0.99), 0.001)
+	assert.Equal(t, 0.25, series[metrics.TimeSeries{Metric: failed, Tags: tagsA}].Value())
+	assert.Equal(t, 3.0, series[metrics.TimeSeries{Metric: vus, Tags: tagsA}].Value())
+	assert.Equal(t, 1.0, series[metrics.TimeSeries{Metric: reqs, Tags: tagsB}].Value())
+	assert.Nil(t, series[metrics.TimeSeries{Metric: reqs, Tags: tagsB}].Histogram)
+
+	second := containers[1].GetSamples()
+	require.Len(t, second, 1)
+	assert.Equal(t, start.Add(10*time.Second), second[0].Time.UTC())
+	assert.Equal(t, 1.0, second[0].Value)
+}
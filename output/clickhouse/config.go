@@ -0,0 +1,208 @@
+package clickhouse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// identifierRegexp matches the names which can be used unquoted in the
+// ClickHouse queries, the database, table and column names are checked against
+// it because they are interpolated in the queries.
+var identifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`) //nolint:gochecknoglobals
+
+// Config is the config for the ClickHouse output.
+type Config struct {
+	// URL is the address of the ClickHouse HTTP interface.
+	URL null.String `json:"url" envconfig:"K6_CLICKHOUSE_URL"`
+	// Username and Password are the credentials of the ClickHouse user.
+	Username null.String `json:"username" envconfig:"K6_CLICKHOUSE_USERNAME"`
+	Password null.String `json:"password" envconfig:"K6_CLICKHOUSE_PASSWORD"`
+	// Database and Table are where the samples are inserted.
+	Database null.String `json:"database" envconfig:"K6_CLICKHOUSE_DATABASE"`
+	Table    null.String `json:"table" envconfig:"K6_CLICKHOUSE_TABLE"`
+	// CreateTable defines if the database and the table are created on start,
+	// when they don't exist.
+	CreateTable null.Bool `json:"createTable" envconfig:"K6_CLICKHOUSE_CREATE_TABLE"`
+	// TagColumns are the tags stored in dedicated columns instead of the
+	// tags map column, so they can be queried and filtered efficiently.
+	TagColumns []string `json:"tagColumns" envconfig:"K6_CLICKHOUSE_TAG_COLUMNS"`
+	// TTL is how long the samples are retained by ClickHouse, zero disables it.
+	TTL types.NullDuration `json:"ttl" envconfig:"K6_CLICKHOUSE_TTL"`
+	// PushInterval is how often the buffered samples are inserted.
+	PushInterval types.NullDuration `json:"pushInterval" envconfig:"K6_CLICKHOUSE_PUSH_INTERVAL"`
+	// BatchSize is the maximum number of samples inserted with a single query.
+	BatchSize null.Int `json:"batchSize" envconfig:"K6_CLICKHOUSE_BATCH_SIZE"`
+}
+
+// NewConfig creates a new Config instance with default values for some fields.
+func NewConfig() Config {
+	return Config{
+		URL:          null.NewString("http://localhost:8123", false),
+		Username:     null.NewString("default", false),
+		Password:     null.NewString("", false),
+		Database:     null.NewString("k6", false),
+		Table:        null.NewString("samples", false),
+		CreateTable:  null.NewBool(true, false),
+		TagColumns:   []string{"scenario", "name", "method", "status", "group", "check"},
+		TTL:          types.NewNullDuration(0, false),
+		PushInterval: types.NewNullDuration(1*time.Second, false),
+		BatchSize:    null.NewInt(10000, false),
+	}
+}
+
+// Apply merges two configs by overwriting properties in the old config
+func (c Config) Apply(cfg Config) Config {
+	if cfg.URL.Valid {
+		c.URL = cfg.URL
+	}
+	if cfg.Username.Valid {
+		c.Username = cfg.Username
+	}
+	if cfg.Password.Valid {
+		c.Password = cfg.Password
+	}
+	if cfg.Database.Valid {
+		c.Database = cfg.Database
+	}
+	if cfg.Table.Valid {
+		c.Table = cfg.Table
+	}
+	if cfg.CreateTable.Valid {
+		c.CreateTable = cfg.CreateTable
+	}
+	if cfg.TagColumns != nil {
+		c.TagColumns = cfg.TagColumns
+	}
+	if cfg.TTL.Valid {
+		c.TTL = cfg.TTL
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	if cfg.BatchSize.Valid {
+		c.BatchSize = cfg.BatchSize
+	}
+	return c
+}
+
+// Validate returns an error if the config can't be used to store the samples.
+func (c Config) Validate() error {
+	if c.URL.String == "" {
+		return errors.New("the url is required")
+	}
+	for _, name := range append([]string{c.Database.String, c.Table.String}, c.TagColumns...) {
+		if !identifierRegexp.MatchString(name) {
+			return fmt.Errorf("%q isn't a valid ClickHouse identifier", name)
+		}
+	}
+	for _, column := range c.TagColumns {
+		if _, reserved := reservedColumns[column]; reserved {
+			return fmt.Errorf("the tag column %q clashes with a column of the table", column)
+		}
+	}
+	if c.TTL.Duration < 0 {
+		return fmt.Errorf("the ttl can't be negative, got %s", c.TTL.Duration)
+	}
+	if c.PushInterval.Duration <= 0 {
+		return fmt.Errorf("the push interval must be positive, got %s", c.PushInterval.Duration)
+	}
+	if c.BatchSize.Int64 <= 0 {
+		return fmt.Errorf("the batch size must be positive, got %d", c.BatchSize.Int64)
+	}
+	return nil
+}
+
+// ParseArg takes an arg string and converts it to a config, e.g.
+// "url=http://clickhouse:8123,database=perf,ttl=720h". A lone value without
+// any key is used as the url.
+func ParseArg(arg string) (Config, error) {
+	c := Config{}
+
+	if !strings.Contains(arg, "=") {
+		c.URL = null.StringFrom(arg)
+		return c, nil
+	}
+
+	for _, pair := range strings.Split(arg, ",") {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 {
+			return c, fmt.Errorf("couldn't parse %q as argument for clickhouse output", arg)
+		}
+		switch r[0] {
+		case "url":
+			c.URL = null.StringFrom(r[1])
+		case "username":
+			c.Username = null.StringFrom(r[1])
+		case "password":
+			c.Password = null.StringFrom(r[1])
+		case "database":
+			c.Database = null.StringFrom(r[1])
+		case "table":
+			c.Table = null.StringFrom(r[1])
+		case "createTable":
+			if err := c.CreateTable.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "ttl":
+			if err := c.TTL.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "pushInterval":
+			if err := c.PushInterval.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "batchSize":
+			if err := c.BatchSize.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		default:
+			return c, fmt.Errorf("unknown key %q as argument for clickhouse output", r[0])
+		}
+	}
+
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + arg config values}, and returns the final result.
+func GetConsolidatedConfig(
+	jsonRawConf json.RawMessage, env map[string]string, arg string,
+) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		// TODO: get rid of envconfig and actually use the env parameter...
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseArg(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.Validate()
+}
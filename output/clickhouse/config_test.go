@@ -0,0 +1,83 @@
+package clickhouse
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  func(c *Config)
+		err     string
+	}{
+		"default": {
+			config: func(*Config) {},
+		},
+		"consolidated": {
+			jsonRaw: json.RawMessage(`{"database":"perf","tagColumns":["scenario"],"ttl":"720h"}`),
+			env: map[string]string{
+				"K6_CLICKHOUSE_PASSWORD":   "secret",
+				"K6_CLICKHOUSE_BATCH_SIZE": "500",
+			},
+			arg: "url=http://clickhouse:8123,table=runs,createTable=false",
+			config: func(c *Config) {
+				c.URL = null.StringFrom("http://clickhouse:8123")
+				c.Password = null.StringFrom("secret")
+				c.Database = null.StringFrom("perf")
+				c.Table = null.StringFrom("runs")
+				c.CreateTable = null.BoolFrom(false)
+				c.TagColumns = []string{"scenario"}
+				c.TTL = types.NullDurationFrom(720 * time.Hour)
+				c.BatchSize = null.IntFrom(500)
+			},
+		},
+		"url only arg": {
+			arg: "https://clickhouse.example.com:8443",
+			config: func(c *Config) {
+				c.URL = null.StringFrom("https://clickhouse.example.com:8443")
+			},
+		},
+		"invalid table": {
+			arg: "table=samples; DROP TABLE users",
+			err: `"samples; DROP TABLE users" isn't a valid ClickHouse identifier`,
+		},
+		"reserved tag column": {
+			jsonRaw: json.RawMessage(`{"tagColumns":["value"]}`),
+			err:     `the tag column "value" clashes with a column of the table`,
+		},
+		"invalid batch size": {
+			arg: "batchSize=0",
+			err: "the batch size must be positive",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := GetConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			expected := NewConfig()
+			tc.config(&expected)
+			assert.Equal(t, expected, config)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// Package clickhouse implements an output inserting the samples into a
+// ClickHouse table, via its HTTP interface.
+//
+// The buffered samples are inserted periodically, in batches, with the
+// JSONEachRow format. Unless disabled, the database and the table are created
+// on start, with the configured tags promoted to their own columns and the
+// rest of them stored in a map column.
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// timestampFormat is the format of the DateTime64(6) timestamps.
+const timestampFormat = "2006-01-02 15:04:05.000000"
+
+// reservedColumns are the columns of the table which can't be used for tags.
+var reservedColumns = map[string]struct{}{ //nolint:gochecknoglobals
+	"timestamp": {}, "metric": {}, "metric_type": {}, "value": {}, "tags": {}, "metadata": {},
+}
+
+// Output implements the output.Output interface, inserting the samples into
+// ClickHouse.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	client          *http.Client
+	tagColumns      map[string]struct{}
+	periodicFlusher *output.PeriodicFlusher
+	logger          logrus.FieldLogger
+}
+
+var _ output.Output = new(Output)
+
+// New creates a new instance of the ClickHouse output.
+func New(params output.Params) (output.Output, error) {
+	config, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	tagColumns := make(map[string]struct{}, len(config.TagColumns))
+	for _, column := range config.TagColumns {
+		tagColumns[column] = struct{}{}
+	}
+
+	return &Output{
+		config:     config,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		tagColumns: tagColumns,
+		logger:     params.Logger.WithField("output", "clickhouse"),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("clickhouse (%s, %s.%s)", o.config.URL.String, o.config.Database.String, o.config.Table.String)
+}
+
+// Start creates the table, if required, and starts the periodic inserts.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	if o.config.CreateTable.Bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := o.query(ctx, "CREATE DATABASE IF NOT EXISTS "+quote(o.config.Database.String), nil); err != nil {
+			return fmt.Errorf("failed to create the database: %w", err)
+		}
+		if err := o.query(ctx, o.createTableQuery(), nil); err != nil {
+			return fmt.Errorf("failed to create the table: %w", err)
+		}
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop inserts the remaining samples and stops the periodic inserts.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return nil
+}
+
+func (o *Output) tableName() string {
+	return quote(o.config.Database.String) + "." + quote(o.config.Table.String)
+}
+
+func (o *Output) createTableQuery() string {
+	var b strings.Builder
+	b.WriteString("CREATE TABLE IF NOT EXISTS " + o.tableName() + " (\n")
+	b.WriteString("\t`timestamp` DateTime64(6, 'UTC'),\n")
+	b.WriteString("\t`metric` LowCardinality(String),\n")
+	b.WriteString("\t`metric_type` LowCardinality(String),\n")
+	b.WriteString("\t`value` Float64,\n")
+	for _, column := range o.config.TagColumns {
+		b.WriteString("\t" + quote(column) + " LowCardinality(String),\n")
+	}
+	b.WriteString("\t`tags` Map(String, String),\n")
+	b.WriteString("\t`metadata` Map(String, String)\n")
+	b.WriteString(") ENGINE = MergeTree\n")
+	b.WriteString("PARTITION BY toYYYYMMDD(`timestamp`)\n")
+	b.WriteString("ORDER BY (`metric`, `timestamp`)")
+	if ttl := o.config.TTL.TimeDuration(); ttl > 0 {
+		fmt.Fprintf(&b, "\nTTL toDateTime(`timestamp`) + INTERVAL %d SECOND", int64(ttl.Seconds()))
+	}
+	return b.String()
+}
+
+func (o *Output) flushMetrics() {
+	var (
+		start = time.Now()
+		batch bytes.Buffer
+		rows  int
+		total int
+	)
+	insert := func() {
+		if rows == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), o.config.PushInterval.TimeDuration()+30*time.Second)
+		defer cancel()
+		query := "INSERT INTO " + o.tableName() + " FORMAT JSONEachRow"
+		if err := o.query(ctx, query, &batch); err != nil {
+			o.logger.WithError(err).WithField("samples", rows).Error("Couldn't insert the samples")
+		} else {
+			total += rows
+		}
+		batch.Reset()
+		rows = 0
+	}
+
+	encoder := json.NewEncoder(&batch)
+	for _, sc := range o.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			if err := encoder.Encode(o.row(sample)); err != nil {
+				o.logger.WithError(err).Debugf("Couldn't encode a sample of %s", sample.Metric.Name)
+				continue
+			}
+			rows++
+			if rows >= int(o.config.BatchSize.Int64) {
+				insert()
+			}
+		}
+	}
+	insert()
+
+	if total > 0 {
+		o.logger.WithField("t", time.Since(start)).WithField("samples", total).Debug("Inserted the samples")
+	}
+}
+
+// row returns the row of the sample, as it's encoded with the JSONEachRow format.
+func (o *Output) row(sample metrics.Sample) map[string]interface{} {
+	metadata := sample.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	tags := make(map[string]string)
+	row := map[string]interface{}{
+		"timestamp":   sample.Time.UTC().Format(timestampFormat),
+		"metric":      sample.Metric.Name,
+		"metric_type": sample.Metric.Type.String(),
+		"value":       sample.Value,
+		"tags":        tags,
+		"metadata":    metadata,
+	}
+	for _, column := range o.config.TagColumns {
+		row[column] = ""
+	}
+	for k, v := range sample.Tags.Map() {
+		if _, ok := o.tagColumns[k]; ok {
+			row[k] = v
+		} else {
+			tags[k] = v
+		}
+	}
+	return row
+}
+
+// query executes the query, with the optional body as its data.
+func (o *Output) query(ctx context.Context, query string, body io.Reader) error {
+	u, err := url.Parse(o.config.URL.String)
+	if err != nil {
+		return err
+	}
+	params := u.Query()
+	params.Set("query", query)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-ClickHouse-User", o.config.Username.String)
+	if o.config.Password.String != "" {
+		req.Header.Set("X-ClickHouse-Key", o.config.Password.String)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("ClickHouse responded with %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func quote(identifier string) string {
+	return "`" + identifier + "`"
+}
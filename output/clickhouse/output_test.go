@@ -0,0 +1,148 @@
+package clickhouse
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+type query struct {
+	query string
+	rows  []map[string]interface{}
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, func() []query) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		queries []query
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "k6user", r.Header.Get("X-ClickHouse-User"))
+		assert.Equal(t, "secret", r.Header.Get("X-ClickHouse-Key"))
+
+		q := query{query: r.URL.Query().Get("query")}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			row := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+			q.rows = append(q.rows, row)
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		mu.Lock()
+		queries = append(queries, q)
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []query {
+		mu.Lock()
+		defer mu.Unlock()
+		return queries
+	}
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	srv, queries := newTestServer(t)
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		JSONConfig:     json.RawMessage(`{"tagColumns":["scenario","status"],"ttl":"168h"}`),
+		Environment:    map[string]string{"K6_CLICKHOUSE_USERNAME": "k6user", "K6_CLICKHOUSE_PASSWORD": "secret"},
+		ConfigArgument: "url=" + srv.URL + ",batchSize=2,pushInterval=1h",
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	now := time.Date(2023, time.October, 15, 10, 30, 0, 123456000, time.UTC)
+	sample := func(metric *metrics.Metric, value float64, tags map[string]string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet().WithTagsFromMap(tags)},
+			Time:       now,
+			Value:      value,
+			Metadata:   map[string]string{"trace_id": "abc"},
+		}
+	}
+	out.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(builtin.HTTPReqDuration, 120.5, map[string]string{"scenario": "default", "status": "200", "url": "/a"}),
+		sample(builtin.HTTPReqs, 1, map[string]string{"scenario": "default", "url": "/a"}),
+		sample(builtin.Iterations, 1, nil),
+	}})
+	require.NoError(t, out.Stop())
+
+	qs := queries()
+	require.Len(t, qs, 4)
+	assert.Equal(t, "CREATE DATABASE IF NOT EXISTS `k6`", qs[0].query)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS `k6`.`samples` (\n"+
+		"\t`timestamp` DateTime64(6, 'UTC'),\n"+
+		"\t`metric` LowCardinality(String),\n"+
+		"\t`metric_type` LowCardinality(String),\n"+
+		"\t`value` Float64,\n"+
+		"\t`scenario` LowCardinality(String),\n"+
+		"\t`status` LowCardinality(String),\n"+
+		"\t`tags` Map(String, String),\n"+
+		"\t`metadata` Map(String, String)\n"+
+		") ENGINE = MergeTree\n"+
+		"PARTITION BY toYYYYMMDD(`timestamp`)\n"+
+		"ORDER BY (`metric`, `timestamp`)\n"+
+		"TTL toDateTime(`timestamp`) + INTERVAL 604800 SECOND", qs[1].query)
+
+	// the samples are inserted in batches of 2
+	for _, q := range qs[2:] {
+		assert.Equal(t, "INSERT INTO `k6`.`samples` FORMAT JSONEachRow", q.query)
+	}
+	require.Len(t, qs[2].rows, 2)
+	require.Len(t, qs[3].rows, 1)
+
+	assert.Equal(t, map[string]interface{}{
+		"timestamp":   "2023-10-15 10:30:00.123456",
+		"metric":      "http_req_duration",
+		"metric_type": "trend",
+		"value":       120.5,
+		"scenario":    "default",
+		"status":      "200",
+		"tags":        map[string]interface{}{"url": "/a"},
+		"metadata":    map[string]interface{}{"trace_id": "abc"},
+	}, qs[2].rows[0])
+	assert.Equal(t, "", qs[2].rows[1]["status"])
+	assert.Equal(t, "iterations", qs[3].rows[0]["metric"])
+	assert.Equal(t, map[string]interface{}{}, qs[3].rows[0]["tags"])
+}
+
+func TestOutputStartError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("Code: 497. DB::Exception: default: Not enough privileges.\n"))
+	}))
+	defer srv.Close()
+
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: srv.URL,
+	})
+	require.NoError(t, err)
+	err = out.Start()
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "failed to create the database: ClickHouse responded with 403 Forbidden"))
+	assert.Contains(t, err.Error(), "Not enough privileges.")
+}
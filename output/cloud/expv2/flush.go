@@ -1,10 +1,13 @@
 package expv2
 
 import (
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"go.k6.io/k6/cloudapi"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/output/cloud/expv2/pbcloud"
 )
@@ -22,15 +25,25 @@ type metricsFlusher struct {
 	aggregationPeriodInSeconds uint32
 	maxSeriesInBatch           int
 	batchPushConcurrency       int
+
+	// maxBufferedBatches is the max number of batches kept in pending,
+	// when they couldn't be pushed because the remote service wasn't reachable.
+	// Zero disables the buffering.
+	maxBufferedBatches int
+	pending            []*pbcloud.MetricSet
 }
 
 // flush flushes the queued buckets sending them to the remote Cloud service.
 // If the number of time series collected is bigger than maximum batch size
 // then it splits in chunks.
+//
+// The batches that couldn't be pushed because of a temporary failure,
+// like a network error, are buffered and they are pushed again, before the
+// new ones, by the next flush.
 func (f *metricsFlusher) flush() error {
 	// drain the buffer
 	buckets := f.bq.PopAll()
-	if len(buckets) < 1 {
+	if len(buckets) < 1 && len(f.pending) < 1 {
 		return nil
 	}
 
@@ -82,10 +95,57 @@ func (f *metricsFlusher) flush() error {
 		f.reportDiscardedLabels(msb.discardedLabels)
 	}
 
-	return f.flushBatches(batches)
+	resumed := len(f.pending)
+	if resumed > 0 {
+		batches = append(f.pending, batches...)
+		f.pending = nil
+	}
+
+	unsent, err := f.flushBatches(batches)
+	if err == nil {
+		if resumed > 0 {
+			f.logger.WithField("batches", resumed).Info("The buffered metrics have been pushed to the cloud")
+		}
+		return nil
+	}
+	if f.maxBufferedBatches < 1 || !isTemporaryPushError(err) {
+		return err
+	}
+	f.buffer(unsent)
+	f.logger.WithError(err).WithField("batches", len(f.pending)).
+		Warn("Failed to push metrics to the cloud, they are buffered and the push will be retried")
+	return nil
+}
+
+func (f *metricsFlusher) buffered() int {
+	return len(f.pending)
 }
 
-func (f *metricsFlusher) flushBatches(batches []*pbcloud.MetricSet) error {
+// buffer adds the batches to the pending ones, dropping the oldest batches
+// when there are more than maxBufferedBatches.
+func (f *metricsFlusher) buffer(batches []*pbcloud.MetricSet) {
+	f.pending = append(f.pending, batches...)
+	if dropped := len(f.pending) - f.maxBufferedBatches; dropped > 0 {
+		f.logger.WithField("batches", dropped).
+			Error("The buffer of the metrics which couldn't be pushed to the cloud is full, the oldest ones are dropped")
+		f.pending = append([]*pbcloud.MetricSet(nil), f.pending[dropped:]...)
+	}
+}
+
+// isTemporaryPushError returns true when the push can succeed if retried
+// later, which is the case for the network errors and the server side errors.
+func isTemporaryPushError(err error) bool {
+	var errResp cloudapi.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return true
+	}
+	code := errResp.Response.StatusCode
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// flushBatches pushes the batches concurrently. It stops at the first error,
+// which is returned with the batches that weren't pushed.
+func (f *metricsFlusher) flushBatches(batches []*pbcloud.MetricSet) ([]*pbcloud.MetricSet, error) {
 	// TODO remove after go 1.21 becomes the minimum supported version - it has `min` in it
 	min := func(a, b int) int {
 		if a < b {
@@ -94,10 +154,16 @@ func (f *metricsFlusher) flushBatches(batches []*pbcloud.MetricSet) error {
 		return b
 	}
 
+	type failure struct {
+		chunk *pbcloud.MetricSet
+		err   error
+	}
+
 	var (
 		workers  = min(len(batches), f.batchPushConcurrency)
-		errs     = make(chan error, workers)
+		errs     = make(chan failure, workers)
 		feed     = make(chan *pbcloud.MetricSet)
+		unsent   []*pbcloud.MetricSet
 		finalErr error
 	)
 
@@ -105,20 +171,22 @@ func (f *metricsFlusher) flushBatches(batches []*pbcloud.MetricSet) error {
 		go func() {
 			for chunk := range feed {
 				if err := f.client.push(chunk); err != nil {
-					errs <- err
+					errs <- failure{chunk: chunk, err: err}
 					return
 				}
 			}
-			errs <- nil
+			errs <- failure{}
 		}()
 	}
 
 outer:
 	for i := 0; i < len(batches); i++ {
 		select {
-		case err := <-errs:
+		case fail := <-errs:
 			workers--
-			finalErr = err
+			finalErr = fail.err
+			unsent = append(unsent, fail.chunk)
+			unsent = append(unsent, batches[i:]...)
 			break outer
 		case feed <- batches[i]:
 		}
@@ -127,12 +195,16 @@ outer:
 	close(feed)
 
 	for ; workers != 0; workers-- {
-		err := <-errs
-		if err != nil && finalErr == nil {
-			finalErr = err
+		fail := <-errs
+		if fail.err == nil {
+			continue
+		}
+		unsent = append(unsent, fail.chunk)
+		if finalErr == nil {
+			finalErr = fail.err
 		}
 	}
-	return finalErr
+	return unsent, finalErr
 }
 
 func (f *metricsFlusher) reportDiscardedLabels(discardedLabels map[string]struct{}) {
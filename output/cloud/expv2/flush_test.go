@@ -2,6 +2,7 @@ package expv2
 
 import (
 	"errors"
+	"net/http"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"go.k6.io/k6/cloudapi"
 	"go.k6.io/k6/lib/testutils"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/output/cloud/expv2/pbcloud"
@@ -381,3 +383,109 @@ func TestMetricsFlusherErrorCase(t *testing.T) {
 	assert.LessOrEqual(t, pm.timesCalled(), mf.batchPushConcurrency)
 	assert.GreaterOrEqual(t, pm.timesCalled(), 1)
 }
+
+func TestMetricsFlusherBufferOnTemporaryError(t *testing.T) {
+	t.Parallel()
+
+	r := metrics.NewRegistry()
+	m1 := r.MustNewMetric("metric1", metrics.Counter)
+	pushBucket := func(bq *bucketQ, i int) {
+		ts := metrics.TimeSeries{
+			Metric: m1,
+			Tags:   r.RootTagSet().With("key1", "val"+strconv.Itoa(i)),
+		}
+		bq.Push([]timeBucket{{
+			Time:  int64(i) + 1,
+			Sinks: map[metrics.TimeSeries]metricValue{ts: &counter{Sum: float64(1)}},
+		}})
+	}
+
+	logger, _ := testutils.NewLoggerWithHook(t)
+	var (
+		m      sync.Mutex
+		failed = true
+		pushed []*pbcloud.MetricSet
+	)
+	pm := &pusherMock{
+		hook: func(ms *pbcloud.MetricSet) {
+			m.Lock()
+			defer m.Unlock()
+			if !failed {
+				pushed = append(pushed, ms)
+			}
+		},
+		errFn: func() error {
+			m.Lock()
+			defer m.Unlock()
+			if failed {
+				return errors.New("connection reset by peer")
+			}
+			return nil
+		},
+	}
+	bq := &bucketQ{}
+	mf := metricsFlusher{
+		bq:                   bq,
+		client:               pm,
+		logger:               logger,
+		discardedLabels:      make(map[string]struct{}),
+		maxSeriesInBatch:     1,
+		batchPushConcurrency: 1,
+		maxBufferedBatches:   2,
+	}
+
+	// the oldest batch is dropped since the buffer can keep only two of them
+	for i := 0; i < 3; i++ {
+		pushBucket(bq, i)
+	}
+	require.NoError(t, mf.flush())
+	assert.Equal(t, 2, mf.buffered())
+
+	m.Lock()
+	failed = false
+	m.Unlock()
+
+	pushBucket(bq, 3)
+	require.NoError(t, mf.flush())
+	assert.Equal(t, 0, mf.buffered())
+	require.Len(t, pushed, 3)
+	for i, ms := range pushed {
+		assert.Equal(t, "val"+strconv.Itoa(i+1), ms.Metrics[0].TimeSeries[0].Labels[0].Value)
+	}
+}
+
+func TestMetricsFlusherNoBufferOnPermanentError(t *testing.T) {
+	t.Parallel()
+
+	r := metrics.NewRegistry()
+	m1 := r.MustNewMetric("metric1", metrics.Counter)
+
+	logger, _ := testutils.NewLoggerWithHook(t)
+	bq := &bucketQ{}
+	pm := &pusherMock{
+		errFn: func() error {
+			return cloudapi.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusBadRequest},
+				Message:  "invalid payload",
+			}
+		},
+	}
+	mf := metricsFlusher{
+		bq:                   bq,
+		client:               pm,
+		logger:               logger,
+		discardedLabels:      make(map[string]struct{}),
+		maxSeriesInBatch:     1,
+		batchPushConcurrency: 1,
+		maxBufferedBatches:   10,
+	}
+	bq.Push([]timeBucket{{
+		Time: 1,
+		Sinks: map[metrics.TimeSeries]metricValue{
+			{Metric: m1, Tags: r.RootTagSet()}: &counter{Sum: float64(1)},
+		},
+	}})
+
+	require.ErrorContains(t, mf.flush(), "invalid payload")
+	assert.Equal(t, 0, mf.buffered())
+}
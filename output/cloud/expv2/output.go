@@ -26,8 +26,14 @@ import (
 // flusher is an interface for flushing data to the cloud.
 type flusher interface {
 	flush() error
+	// buffered returns the number of batches waiting to be pushed again.
+	buffered() int
 }
 
+// finalFlushRetries is how many times the buffered metrics are pushed again
+// when the output is stopped.
+const finalFlushRetries = 3
+
 // Output sends result data to the k6 Cloud service.
 type Output struct {
 	output.SampleBuffer
@@ -115,6 +121,7 @@ func (o *Output) Start() error {
 		// TODO: when the migration from v1 is over
 		// change the default of cloudapi.MetricPushConcurrency to use GOMAXPROCS(0)
 		batchPushConcurrency: int(o.config.MetricPushConcurrency.Int64),
+		maxBufferedBatches:   int(o.config.MetricBufferSize.Int64),
 	}
 
 	o.runPeriodicFlush()
@@ -169,6 +176,17 @@ func (o *Output) StopWithTestError(_ error) error {
 	o.collectSamples()
 	o.flushMetrics()
 
+	// Retry a few times the push of the metrics buffered because
+	// the cloud wasn't reachable, since there isn't a next flush.
+	for i := 0; i < finalFlushRetries && o.flushing.buffered() > 0; i++ {
+		time.Sleep(o.config.MetricPushInterval.TimeDuration())
+		o.flushMetrics()
+	}
+	if n := o.flushing.buffered(); n > 0 {
+		o.logger.WithField("batches", n).
+			Error("Failed to push the buffered metrics to the cloud, they are lost")
+	}
+
 	// Flush all the remaining request metadatas.
 	if insightsOutput.Enabled(o.config) {
 		o.flushRequestMetadatas()
@@ -370,6 +388,7 @@ func printableConfig(c cloudapi.Config) map[string]any {
 		"maxTimeSeriesInBatch":  c.MaxTimeSeriesInBatch.Int64,
 		"metricPushConcurrency": c.MetricPushConcurrency.Int64,
 		"metricPushInterval":    c.MetricPushInterval.String(),
+		"metricBufferSize":      c.MetricBufferSize.Int64,
 		"token":                 "",
 	}
 
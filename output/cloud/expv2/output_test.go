@@ -504,6 +504,10 @@ func (ff flusherFunc) flush() error {
 	return nil
 }
 
+func (ff flusherFunc) buffered() int {
+	return 0
+}
+
 func TestPrintableConfig(t *testing.T) {
 	t.Parallel()
 
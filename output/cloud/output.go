@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.k6.io/k6/cloudapi"
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/metrics"
@@ -62,6 +64,10 @@ type Output struct {
 
 	client       *cloudapi.Client
 	testStopFunc func(error)
+
+	// statusSyncStop stops the checks of the test run status in the cloud
+	statusSyncStop chan struct{}
+	statusSyncWg   sync.WaitGroup
 }
 
 // Verify that Output implements the wanted interfaces
@@ -199,6 +205,7 @@ func (out *Output) Start() error {
 	if err != nil {
 		return fmt.Errorf("the Gateway Output failed to start a versioned output: %w", err)
 	}
+	out.runTestRunStatusSync()
 
 	out.logger.WithFields(logrus.Fields{
 		"name":      out.config.Name,
@@ -240,6 +247,11 @@ func (out *Output) Stop() error {
 // all metric samples are emitted, it makes a cloud API call to finish the test
 // run. If testErr was specified, it extracts the RunStatus from it.
 func (out *Output) StopWithTestError(testErr error) error {
+	if out.statusSyncStop != nil {
+		close(out.statusSyncStop)
+		out.statusSyncWg.Wait()
+	}
+
 	err := out.versionedOutput.StopWithTestError(testErr)
 	if err != nil {
 		out.logger.WithError(err).Error("An error occurred stopping the output")
@@ -256,6 +268,75 @@ func (out *Output) StopWithTestError(testErr error) error {
 	return nil
 }
 
+// runTestRunStatusSync periodically checks the status of the test run in the
+// cloud, for the local test run to be stopped when it's stopped from the cloud.
+func (out *Output) runTestRunStatusSync() {
+	interval := out.config.TestRunStatusInterval.TimeDuration()
+	if interval <= 0 {
+		return
+	}
+
+	out.statusSyncStop = make(chan struct{})
+	out.statusSyncWg.Add(1)
+	go func() {
+		defer out.statusSyncWg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if out.checkTestRunStatus() {
+					return
+				}
+			case <-out.statusSyncStop:
+				return
+			}
+		}
+	}()
+}
+
+// checkTestRunStatus stops the test run if it was aborted in the cloud, like
+// by a user from the web app or by the thresholds. It returns true when the
+// test run has been stopped.
+func (out *Output) checkTestRunStatus() bool {
+	progress, err := out.client.GetTestProgress(out.testRunID)
+	if err != nil {
+		out.logger.WithError(err).Debug("Failed to get the status of the test run from the cloud")
+		return false
+	}
+
+	var stopErr error
+	switch progress.RunStatus {
+	case cloudapi.RunStatusAbortedUser:
+		stopErr = errext.WithAbortReasonIfNone(
+			errext.WithExitCodeIfNone(errors.New("the test run was stopped from the cloud"), exitcodes.ExternalAbort),
+			errext.AbortedByUser,
+		)
+	case cloudapi.RunStatusAbortedThreshold:
+		stopErr = errext.WithAbortReasonIfNone(
+			errext.WithExitCodeIfNone(errors.New("the test run was aborted by the thresholds in the cloud"),
+				exitcodes.ThresholdsHaveFailed),
+			errext.AbortedByThreshold,
+		)
+	case cloudapi.RunStatusTimedOut, cloudapi.RunStatusAbortedSystem, cloudapi.RunStatusAbortedLimit:
+		stopErr = errext.WithAbortReasonIfNone(
+			errext.WithExitCodeIfNone(
+				fmt.Errorf("the test run was aborted by the cloud with the run status %d", progress.RunStatus),
+				exitcodes.ExternalAbort),
+			errext.AbortedByOutput,
+		)
+	default:
+		return false
+	}
+
+	out.logger.WithError(stopErr).Warn("Stopping the test run")
+	if out.testStopFunc != nil {
+		out.testStopFunc(stopErr)
+	}
+	return true
+}
+
 func (out *Output) testFinished(testErr error) error {
 	if out.testRunID == "" || out.config.PushRefID.Valid {
 		return nil
@@ -295,7 +376,7 @@ func (out *Output) getRunStatus(testErr error) cloudapi.RunStatus {
 		switch abortReason {
 		case errext.AbortedByUser:
 			return cloudapi.RunStatusAbortedUser
-		case errext.AbortedByThreshold:
+		case errext.AbortedByThreshold, errext.AbortedByErrorRate:
 			return cloudapi.RunStatusAbortedThreshold
 		case errext.AbortedByScriptError:
 			return cloudapi.RunStatusAbortedScriptError
@@ -303,7 +384,7 @@ func (out *Output) getRunStatus(testErr error) cloudapi.RunStatus {
 			return cloudapi.RunStatusAbortedUser // TODO: have a better value than this?
 		case errext.AbortedByTimeout:
 			return cloudapi.RunStatusAbortedLimit
-		case errext.AbortedByOutput:
+		case errext.AbortedByOutput, errext.AbortedByMemoryLimit:
 			return cloudapi.RunStatusAbortedSystem
 		case errext.AbortedByThresholdsAfterTestEnd:
 			// The test run finished normally, it wasn't prematurely aborted by
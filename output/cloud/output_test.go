@@ -135,6 +135,53 @@ func TestOutputCreateTestWithConfigOverwrite(t *testing.T) {
 	require.NoError(t, out.StopWithTestError(nil))
 }
 
+func TestOutputTestRunStatusSync(t *testing.T) {
+	t.Parallel()
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/tests":
+			fmt.Fprint(w, `{"reference_id": "12345"}`)
+		case "/v1/test-progress/12345":
+			fmt.Fprint(w, `{"run_status": 5, "run_status_text": "Aborted (by user)"}`)
+		case "/v1/tests/12345":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "not expected path", http.StatusInternalServerError)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	out, err := newOutput(output.Params{
+		Logger: testutils.NewLogger(t),
+		Environment: map[string]string{
+			"K6_CLOUD_HOST":                     ts.URL,
+			"K6_CLOUD_TEST_RUN_STATUS_INTERVAL": "10ms",
+		},
+		ScriptOptions: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		ScriptPath: &url.URL{Path: "/script.js"},
+	})
+	require.NoError(t, err)
+
+	stopErr := make(chan error, 1)
+	out.SetTestRunStopCallback(func(err error) { stopErr <- err })
+	require.NoError(t, out.Start())
+
+	select {
+	case err := <-stopErr:
+		require.ErrorContains(t, err, "the test run was stopped from the cloud")
+		var abortErr errext.HasAbortReason
+		require.ErrorAs(t, err, &abortErr)
+		assert.Equal(t, errext.AbortedByUser, abortErr.AbortReason())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the test run wasn't stopped")
+	}
+	require.NoError(t, out.StopWithTestError(nil))
+}
+
 func TestOutputStartVersionError(t *testing.T) {
 	t.Parallel()
 	o, err := newOutput(output.Params{
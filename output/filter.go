@@ -0,0 +1,199 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+
+	"go.k6.io/k6/metrics"
+)
+
+// FilterConfig configures the samples an output receives. The metric names
+// can be patterns, with the syntax of path.Match, e.g. "http_req_*".
+type FilterConfig struct {
+	// IncludeMetrics are the metrics the output receives, all of them if empty.
+	IncludeMetrics []string `json:"includeMetrics"`
+	// ExcludeMetrics are the metrics the output doesn't receive.
+	ExcludeMetrics []string `json:"excludeMetrics"`
+	// DropTags are the tags removed from the samples.
+	DropTags []string `json:"dropTags"`
+	// HashTags are the tags whose values are replaced by a hash of them, to
+	// keep the series apart without sending the actual values.
+	HashTags []string `json:"hashTags"`
+	// RenameMetrics maps the names of the metrics to the ones the output
+	// receives them with.
+	RenameMetrics map[string]string `json:"renameMetrics"`
+	// TrendSampling is the number of samples of each Trend metric out of
+	// which only one is kept.
+	TrendSampling int64 `json:"trendSampling"`
+}
+
+// Validate checks that the patterns and the new names of the metrics are valid.
+func (c FilterConfig) Validate() error {
+	for _, patterns := range [][]string{c.IncludeMetrics, c.ExcludeMetrics} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid metric pattern '%s': %w", pattern, err)
+			}
+		}
+	}
+	for _, name := range c.RenameMetrics {
+		if _, err := metrics.NewRegistry().NewMetric(name, metrics.Counter); err != nil {
+			return err
+		}
+	}
+	if c.TrendSampling < 0 {
+		return fmt.Errorf("trendSampling can't be negative, got %d", c.TrendSampling)
+	}
+	return nil
+}
+
+// IsEmpty returns true if the filter doesn't change the samples at all.
+func (c FilterConfig) IsEmpty() bool {
+	return len(c.IncludeMetrics) == 0 && len(c.ExcludeMetrics) == 0 && len(c.DropTags) == 0 &&
+		len(c.HashTags) == 0 && len(c.RenameMetrics) == 0 && c.TrendSampling <= 1
+}
+
+// FilteredOutput wraps an output, filtering the samples it receives. The
+// filtered samples are always plain metrics.Samples or
+// metrics.ConnectedSamples, so outputs relying on specific sample containers,
+// like the cloud one, lose their specific handling.
+type FilteredOutput struct {
+	Output
+
+	config   FilterConfig
+	registry *metrics.Registry
+	renamed  map[*metrics.Metric]*metrics.Metric
+	counts   map[*metrics.Metric]int64
+}
+
+var (
+	_ WithTestRunStop       = new(FilteredOutput)
+	_ WithStopWithTestError = new(FilteredOutput)
+)
+
+// NewFilteredOutput wraps the output, filtering its samples as configured.
+func NewFilteredOutput(out Output, config FilterConfig) (*FilteredOutput, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &FilteredOutput{
+		Output:   out,
+		config:   config,
+		registry: metrics.NewRegistry(),
+		renamed:  make(map[*metrics.Metric]*metrics.Metric),
+		counts:   make(map[*metrics.Metric]int64),
+	}, nil
+}
+
+// AddMetricSamples filters the samples and passes the remaining ones to the
+// wrapped output.
+func (fo *FilteredOutput) AddMetricSamples(containers []metrics.SampleContainer) {
+	filtered := make([]metrics.SampleContainer, 0, len(containers))
+	for _, sc := range containers {
+		samples := sc.GetSamples()
+		kept := make([]metrics.Sample, 0, len(samples))
+		for _, s := range samples {
+			if s, ok := fo.filter(s); ok {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		if csc, ok := sc.(metrics.ConnectedSampleContainer); ok {
+			filtered = append(filtered, metrics.ConnectedSamples{
+				Samples: kept,
+				Tags:    fo.filterTags(csc.GetTags()),
+				Time:    csc.GetTime(),
+			})
+		} else {
+			filtered = append(filtered, metrics.Samples(kept))
+		}
+	}
+	fo.Output.AddMetricSamples(filtered)
+}
+
+// SetTestRunStopCallback passes the callback to the wrapped output, if it can
+// stop the test run.
+func (fo *FilteredOutput) SetTestRunStopCallback(callback func(error)) {
+	if out, ok := fo.Output.(WithTestRunStop); ok {
+		out.SetTestRunStopCallback(callback)
+	}
+}
+
+// StopWithTestError stops the wrapped output, with the test's error if it
+// can receive it.
+func (fo *FilteredOutput) StopWithTestError(testRunErr error) error {
+	if out, ok := fo.Output.(WithStopWithTestError); ok {
+		return out.StopWithTestError(testRunErr)
+	}
+	return fo.Output.Stop()
+}
+
+func (fo *FilteredOutput) filter(s metrics.Sample) (metrics.Sample, bool) {
+	if !fo.includes(s.Metric.Name) {
+		return s, false
+	}
+	if s.Metric.Type == metrics.Trend && fo.config.TrendSampling > 1 {
+		count := fo.counts[s.Metric]
+		fo.counts[s.Metric] = count + 1
+		if count%fo.config.TrendSampling != 0 {
+			return s, false
+		}
+	}
+	s.Metric = fo.rename(s.Metric)
+	s.Tags = fo.filterTags(s.Tags)
+	return s, true
+}
+
+func (fo *FilteredOutput) includes(name string) bool {
+	if len(fo.config.IncludeMetrics) > 0 && !matchAny(fo.config.IncludeMetrics, name) {
+		return false
+	}
+	return !matchAny(fo.config.ExcludeMetrics, name)
+}
+
+func (fo *FilteredOutput) rename(m *metrics.Metric) *metrics.Metric {
+	name, ok := fo.config.RenameMetrics[m.Name]
+	if !ok {
+		return m
+	}
+	if renamed, ok := fo.renamed[m]; ok {
+		return renamed
+	}
+	renamed, err := fo.registry.NewMetric(name, m.Type, m.Contains)
+	if err != nil {
+		// the metrics renamed to the same name have different types, so
+		// they are left as they are
+		renamed = m
+	}
+	fo.renamed[m] = renamed
+	return renamed
+}
+
+func (fo *FilteredOutput) filterTags(tags *metrics.TagSet) *metrics.TagSet {
+	if tags == nil {
+		return nil
+	}
+	for _, key := range fo.config.DropTags {
+		tags = tags.Without(key)
+	}
+	for _, key := range fo.config.HashTags {
+		if value, ok := tags.Get(key); ok {
+			hash := sha256.Sum256([]byte(value))
+			tags = tags.With(key, hex.EncodeToString(hash[:8]))
+		}
+	}
+	return tags
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package output
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+type recordingOutput struct {
+	SampleBuffer
+	stopErr error
+}
+
+func (ro *recordingOutput) Description() string { return "recording" }
+func (ro *recordingOutput) Start() error        { return nil }
+func (ro *recordingOutput) Stop() error         { return nil }
+
+func (ro *recordingOutput) StopWithTestError(testRunErr error) error {
+	ro.stopErr = testRunErr
+	return nil
+}
+
+func TestFilterConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, FilterConfig{IncludeMetrics: []string{"http_*"}, RenameMetrics: map[string]string{"a": "b_c"}}.Validate())
+	assert.EqualError(t, FilterConfig{ExcludeMetrics: []string{"[http"}}.Validate(),
+		"invalid metric pattern '[http': syntax error in pattern")
+	assert.ErrorContains(t, FilterConfig{RenameMetrics: map[string]string{"a": "b-c"}}.Validate(),
+		"Invalid metric name: 'b-c'")
+	assert.EqualError(t, FilterConfig{TrendSampling: -1}.Validate(), "trendSampling can't be negative, got -1")
+	assert.True(t, FilterConfig{TrendSampling: 1}.IsEmpty())
+	assert.False(t, FilterConfig{DropTags: []string{"url"}}.IsEmpty())
+}
+
+func TestFilteredOutput(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+	tags := registry.RootTagSet().WithTagsFromMap(map[string]string{"url": "http://example.com/1", "user": "alice"})
+	now := time.Now()
+	sample := func(m *metrics.Metric, value float64) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags}, Time: now, Value: value}
+	}
+
+	ro := &recordingOutput{}
+	fo, err := NewFilteredOutput(ro, FilterConfig{
+		IncludeMetrics: []string{"http_*"},
+		ExcludeMetrics: []string{"http_reqs"},
+		DropTags:       []string{"url"},
+		HashTags:       []string{"user"},
+		RenameMetrics:  map[string]string{"http_req_duration": "latency"},
+		TrendSampling:  2,
+	})
+	require.NoError(t, err)
+
+	fo.AddMetricSamples([]metrics.SampleContainer{
+		metrics.ConnectedSamples{
+			Samples: []metrics.Sample{sample(reqs, 1), sample(duration, 10)},
+			Tags:    tags,
+			Time:    now,
+		},
+		sample(duration, 20),
+		sample(vus, 1),
+		sample(duration, 30),
+	})
+
+	containers := ro.GetBufferedSamples()
+	require.Len(t, containers, 2)
+	connected, ok := containers[0].(metrics.ConnectedSamples)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"user": "2bd806c97f0e00af"}, connected.Tags.Map())
+	require.Len(t, connected.Samples, 1)
+	s := connected.Samples[0]
+	assert.Equal(t, "latency", s.Metric.Name)
+	assert.Equal(t, metrics.Trend, s.Metric.Type)
+	assert.Equal(t, metrics.Time, s.Metric.Contains)
+	assert.Equal(t, 10.0, s.Value)
+	assert.Equal(t, map[string]string{"user": "2bd806c97f0e00af"}, s.Tags.Map())
+
+	samples := containers[1].GetSamples()
+	require.Len(t, samples, 1)
+	assert.Equal(t, 30.0, samples[0].Value)
+	assert.Same(t, s.Metric, samples[0].Metric)
+
+	testErr := errors.New("test error")
+	require.NoError(t, fo.StopWithTestError(testErr))
+	assert.Equal(t, testErr, ro.stopErr)
+}
@@ -85,29 +85,46 @@ func newOutput(params output.Params) (*Output, error) {
 func (o *Output) extractTagsToValues(tags map[string]string, values map[string]interface{}) map[string]interface{} {
 	for tag, kind := range o.fieldKinds {
 		if val, ok := tags[tag]; ok {
-			var v interface{}
-			var err error
-			switch kind {
-			case String:
-				v = val
-			case Bool:
-				v, err = strconv.ParseBool(val)
-			case Float:
-				v, err = strconv.ParseFloat(val, 64)
-			case Int:
-				v, err = strconv.ParseInt(val, 10, 64)
-			}
-			if err == nil {
-				values[tag] = v
-			} else {
-				values[tag] = val
-			}
+			values[tag] = fieldValue(kind, val)
 			delete(tags, tag)
 		}
 	}
 	return values
 }
 
+// addMetadataToValues adds the metadata of a sample to its fields, since they
+// aren't indexed either; they are strings unless they have a configured type.
+func (o *Output) addMetadataToValues(metadata map[string]string, values map[string]interface{}) {
+	for key, val := range metadata {
+		kind, ok := o.fieldKinds[key]
+		if !ok {
+			kind = String
+		}
+		values[key] = fieldValue(kind, val)
+	}
+}
+
+// fieldValue converts the value to the kind of the field, it stays a string
+// if it can't be converted.
+func fieldValue(kind FieldKind, val string) interface{} {
+	var v interface{}
+	var err error
+	switch kind {
+	case String:
+		v = val
+	case Bool:
+		v, err = strconv.ParseBool(val)
+	case Float:
+		v, err = strconv.ParseFloat(val, 64)
+	case Int:
+		v, err = strconv.ParseInt(val, 10, 64)
+	}
+	if err != nil {
+		return val
+	}
+	return v
+}
+
 func (o *Output) batchFromSamples(containers []metrics.SampleContainer) (client.BatchPoints, error) {
 	batch, err := client.NewBatchPoints(o.BatchConf)
 	if err != nil {
@@ -124,16 +141,17 @@ func (o *Output) batchFromSamples(containers []metrics.SampleContainer) (client.
 		for _, sample := range samples {
 			var tags map[string]string
 			values := make(map[string]interface{})
-			if cached, ok := cache[sample.Tags]; ok {
-				tags = cached.tags
-				for k, v := range cached.values {
-					values[k] = v
-				}
-			} else {
-				tags = sample.Tags.Map()
-				o.extractTagsToValues(tags, values)
-				cache[sample.Tags] = cacheItem{tags, values}
+			cached, ok := cache[sample.Tags]
+			if !ok {
+				cached.tags = sample.Tags.Map()
+				cached.values = o.extractTagsToValues(cached.tags, make(map[string]interface{}))
+				cache[sample.Tags] = cached
+			}
+			tags = cached.tags
+			for k, v := range cached.values {
+				values[k] = v
 			}
+			o.addMetadataToValues(sample.Metadata, values)
 			values["value"] = sample.Value
 			var p *client.Point
 			p, err = client.NewPoint(
@@ -216,3 +216,45 @@ func TestExtractTagsToValues(t *testing.T) {
 	require.Equal(t, 3.14, values["floatField"])
 	require.Equal(t, int64(12345), values["intField"])
 }
+
+func TestBatchFromSamplesMetadata(t *testing.T) {
+	t.Parallel()
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "?tagsAsFields=vu:int",
+	})
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("test_trend", metrics.Trend)
+	require.NoError(t, err)
+	tags := registry.RootTagSet().With("name", "https://test.k6.io/?id=${}")
+	batch, err := o.batchFromSamples([]metrics.SampleContainer{metrics.Samples{
+		{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+			Time:       time.Now(),
+			Metadata:   map[string]string{"vu": "2", "full_url": "https://test.k6.io/?id=1"},
+			Value:      1,
+		},
+		{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+			Time:       time.Now(),
+			Value:      2,
+		},
+	}})
+	require.NoError(t, err)
+
+	points := batch.Points()
+	require.Len(t, points, 2)
+	assert.Equal(t, map[string]string{"name": "https://test.k6.io/?id=${}"}, points[0].Tags())
+	fields, err := points[0].Fields()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"value": 1.0, "vu": int64(2), "full_url": "https://test.k6.io/?id=1",
+	}, fields)
+
+	// the metadata of a sample isn't added to the other ones with the same tags
+	fields, err = points[1].Fields()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"value": 2.0}, fields)
+}
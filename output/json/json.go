@@ -2,6 +2,7 @@ package json
 
 import (
 	"bufio"
+	stdjson "encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -125,6 +126,11 @@ func (o *Output) flushMetrics() {
 	var count int
 	jw := new(jwriter.Writer)
 	for _, sc := range samples {
+		if as, ok := sc.(output.AggregatedSamples); ok {
+			count += len(as.Series)
+			o.writeAggregatedSamples(as, jw)
+			continue
+		}
 		samples := sc.GetSamples()
 		count += len(samples)
 		for _, sample := range samples {
@@ -144,6 +150,19 @@ func (o *Output) flushMetrics() {
 	}
 }
 
+func (o *Output) writeAggregatedSamples(as output.AggregatedSamples, jw *jwriter.Writer) {
+	for _, s := range as.Series {
+		o.handleMetric(s.Metric, jw)
+		data, err := stdjson.Marshal(wrapAggregatedSeries(as, s))
+		if err != nil {
+			o.logger.WithError(err).Error("Aggregated sample couldn't be marshalled to JSON")
+			continue
+		}
+		jw.Raw(data, nil)
+		jw.RawByte('\n')
+	}
+}
+
 func (o *Output) handleMetric(m *metrics.Metric, jw *jwriter.Writer) {
 	if _, ok := o.seenMetrics[m.Name]; ok {
 		return
@@ -225,3 +225,52 @@ func setThresholds(t *testing.T, out output.Output) {
 	ts := metrics.NewThresholds([]string{"rate<0.01", "p(99)<250"})
 	jout.SetThresholds(map[string]metrics.Thresholds{"my_metric1": ts})
 }
+
+func TestJsonOutputAggregatedSamples(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	trend, err := registry.NewMetric("my_trend", metrics.Trend)
+	require.NoError(t, err)
+	counter, err := registry.NewMetric("my_counter", metrics.Counter)
+	require.NoError(t, err)
+	tags := registry.RootTagSet().With("tag1", "val1")
+
+	histogram, err := metrics.NewHistogram(3)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		histogram.Add(2)
+	}
+
+	stdout := new(bytes.Buffer)
+	out, err := New(output.Params{
+		Logger: testutils.NewLogger(t),
+		StdOut: stdout,
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+	out.AddMetricSamples([]metrics.SampleContainer{output.AggregatedSamples{
+		Time:   time.Date(2021, time.February, 24, 13, 37, 20, 0, time.UTC),
+		Window: 10 * time.Second,
+		Series: []*output.AggregatedSeries{
+			{
+				TimeSeries: metrics.TimeSeries{Metric: trend, Tags: tags},
+				Count:      4, Sum: 8, Min: 2, Max: 2, Last: 2, NonZero: 4,
+				Histogram: histogram,
+			},
+			{
+				TimeSeries: metrics.TimeSeries{Metric: counter, Tags: tags},
+				Count:      2, Sum: 2, Min: 1, Max: 1, Last: 1, NonZero: 2,
+			},
+		},
+	}})
+	require.NoError(t, out.Stop())
+
+	getValidator(t, []string{
+		`{"type":"Metric","data":{"name":"my_trend","type":"trend","contains":"default","thresholds":[],"submetrics":null},"metric":"my_trend"}`,
+		`{"type":"AggregatedPoint","data":{"time":"2021-02-24T13:37:20Z","window":10,"tags":{"tag1":"val1"},"count":4,"value":2,"min":2,"max":2,` +
+			`"percentiles":{"p(50)":2,"p(90)":2,"p(95)":2,"p(99)":2,"p(99.9)":2}},"metric":"my_trend"}`,
+		`{"type":"Metric","data":{"name":"my_counter","type":"counter","contains":"default","thresholds":[],"submetrics":null},"metric":"my_counter"}`,
+		`{"type":"AggregatedPoint","data":{"time":"2021-02-24T13:37:20Z","window":10,"tags":{"tag1":"val1"},"count":2,"value":2,"min":1,"max":1},"metric":"my_counter"}`,
+	})(stdout)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
 )
 
 //go:generate easyjson -pkg -no_std_marshalers -gen_build_flags -mod=mod .
@@ -46,3 +47,48 @@ type metricEnvelope struct {
 	} `json:"data"`
 	Metric string `json:"metric"`
 }
+
+// aggregatedEnvelope is a time series aggregated over a window, it isn't
+// generated with easyjson since it's only written once per series and window.
+type aggregatedEnvelope struct {
+	Metric string `json:"metric"`
+	Type   string `json:"type"`
+	Data   struct {
+		Time        time.Time          `json:"time"`
+		Window      float64            `json:"window"`
+		Tags        *metrics.TagSet    `json:"tags"`
+		Count       uint64             `json:"count"`
+		Value       float64            `json:"value"`
+		Min         float64            `json:"min"`
+		Max         float64            `json:"max"`
+		Percentiles map[string]float64 `json:"percentiles,omitempty"`
+	} `json:"data"`
+}
+
+//nolint:gochecknoglobals
+var aggregatedPercentiles = map[string]float64{
+	"p(50)": 0.5, "p(90)": 0.9, "p(95)": 0.95, "p(99)": 0.99, "p(99.9)": 0.999,
+}
+
+// wrapAggregatedSeries packages a series aggregated over a window, with the
+// percentiles of its histogram for the trends.
+func wrapAggregatedSeries(as output.AggregatedSamples, s *output.AggregatedSeries) aggregatedEnvelope {
+	e := aggregatedEnvelope{
+		Type:   "AggregatedPoint",
+		Metric: s.Metric.Name,
+	}
+	e.Data.Time = as.Time
+	e.Data.Window = as.Window.Seconds()
+	e.Data.Tags = s.Tags
+	e.Data.Count = s.Count
+	e.Data.Value = s.Value()
+	e.Data.Min = s.Min
+	e.Data.Max = s.Max
+	if s.Histogram != nil {
+		e.Data.Percentiles = make(map[string]float64, len(aggregatedPercentiles))
+		for name, pct := range aggregatedPercentiles {
+			e.Data.Percentiles[name] = s.Histogram.Percentile(pct)
+		}
+	}
+	return e
+}
@@ -17,6 +17,7 @@ type Manager struct {
 	logger  logrus.FieldLogger
 
 	testStopCallback func(error)
+	cardinalityGuard *metrics.CardinalityGuard
 }
 
 // NewManager returns a new manager for the given outputs.
@@ -28,6 +29,12 @@ func NewManager(outputs []Output, logger logrus.FieldLogger, testStopCallback fu
 	}
 }
 
+// SetCardinalityGuard sets the guard the samples go through before they are
+// sent to the outputs. It must be called before Start.
+func (om *Manager) SetCardinalityGuard(guard *metrics.CardinalityGuard) {
+	om.cardinalityGuard = guard
+}
+
 // Start spins up all configured outputs and then starts a new goroutine that
 // pipes metrics from the given samples channel to them.
 //
@@ -48,6 +55,9 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 	wg.Add(1)
 
 	sendToOutputs := func(sampleContainers []metrics.SampleContainer) {
+		if om.cardinalityGuard != nil {
+			sampleContainers = om.cardinalityGuard.Process(sampleContainers)
+		}
 		for _, out := range om.outputs {
 			out.AddMetricSamples(sampleContainers)
 		}
@@ -66,7 +76,11 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 					sendToOutputs(buffer)
 					return
 				}
-				buffer = append(buffer, sampleContainer)
+				if batch, isBatch := sampleContainer.(metrics.SampleBatch); isBatch {
+					buffer = append(buffer, batch...)
+				} else {
+					buffer = append(buffer, sampleContainer)
+				}
 			case <-ticker.C:
 				sendToOutputs(buffer)
 				buffer = make([]metrics.SampleContainer, 0, cap(buffer))
@@ -0,0 +1,189 @@
+package otelmetrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// The supported aggregation temporalities.
+const (
+	TemporalityCumulative = "cumulative"
+	TemporalityDelta      = "delta"
+)
+
+// Config is the config for the OpenTelemetry metrics output.
+type Config struct {
+	// Endpoint is the host and port of the OTLP receiver.
+	Endpoint null.String `json:"endpoint" envconfig:"K6_OTEL_METRICS_ENDPOINT"`
+	// Protocol is the OTLP transport, either grpc or http.
+	Protocol null.String `json:"protocol" envconfig:"K6_OTEL_METRICS_PROTOCOL"`
+	// URLPath is the path to which the metrics are posted, only used with http.
+	URLPath null.String `json:"urlPath" envconfig:"K6_OTEL_METRICS_URL_PATH"`
+	// Insecure disables TLS for the connection to the receiver.
+	Insecure null.Bool `json:"insecure" envconfig:"K6_OTEL_METRICS_INSECURE"`
+	// Headers are sent with every export request, e.g. for authentication.
+	Headers map[string]string `json:"headers" envconfig:"K6_OTEL_METRICS_HEADERS"`
+	// Temporality is the aggregation temporality of the counters and histograms.
+	Temporality null.String `json:"temporality" envconfig:"K6_OTEL_METRICS_TEMPORALITY"`
+	// ServiceName is the service.name resource attribute of the metrics.
+	ServiceName null.String `json:"serviceName" envconfig:"K6_OTEL_METRICS_SERVICE_NAME"`
+	// ResourceAttributes are added to the resource of the metrics.
+	ResourceAttributes map[string]string `json:"resourceAttributes" envconfig:"K6_OTEL_METRICS_RESOURCE_ATTRIBUTES"`
+	// PushInterval is how often the aggregated metrics are exported.
+	PushInterval types.NullDuration `json:"pushInterval" envconfig:"K6_OTEL_METRICS_PUSH_INTERVAL"`
+}
+
+// NewConfig creates a new Config instance with default values for some fields.
+func NewConfig() Config {
+	return Config{
+		Endpoint:           null.NewString("127.0.0.1:4317", false),
+		Protocol:           null.NewString("grpc", false),
+		URLPath:            null.NewString("/v1/metrics", false),
+		Insecure:           null.NewBool(true, false),
+		Headers:            map[string]string{},
+		Temporality:        null.NewString(TemporalityCumulative, false),
+		ServiceName:        null.NewString("k6", false),
+		ResourceAttributes: map[string]string{},
+		PushInterval:       types.NewNullDuration(10*time.Second, false),
+	}
+}
+
+// Apply merges two configs by overwriting properties in the old config
+func (c Config) Apply(cfg Config) Config {
+	if cfg.Endpoint.Valid {
+		c.Endpoint = cfg.Endpoint
+	}
+	if cfg.Protocol.Valid {
+		c.Protocol = cfg.Protocol
+	}
+	if cfg.URLPath.Valid {
+		c.URLPath = cfg.URLPath
+	}
+	if cfg.Insecure.Valid {
+		c.Insecure = cfg.Insecure
+	}
+	for k, v := range cfg.Headers {
+		c.Headers[k] = v
+	}
+	if cfg.Temporality.Valid {
+		c.Temporality = cfg.Temporality
+	}
+	if cfg.ServiceName.Valid {
+		c.ServiceName = cfg.ServiceName
+	}
+	for k, v := range cfg.ResourceAttributes {
+		c.ResourceAttributes[k] = v
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	return c
+}
+
+// Validate returns an error if the config can't be used to export the metrics.
+func (c Config) Validate() error {
+	if c.Protocol.String != "grpc" && c.Protocol.String != "http" {
+		return fmt.Errorf("unsupported protocol %q, it must be grpc or http", c.Protocol.String)
+	}
+	if c.Temporality.String != TemporalityCumulative && c.Temporality.String != TemporalityDelta {
+		return fmt.Errorf("unsupported temporality %q, it must be %s or %s",
+			c.Temporality.String, TemporalityCumulative, TemporalityDelta)
+	}
+	if c.Endpoint.String == "" {
+		return errors.New("the endpoint is required")
+	}
+	if c.PushInterval.Duration <= 0 {
+		return fmt.Errorf("the push interval must be positive, got %s", c.PushInterval.Duration)
+	}
+	return nil
+}
+
+// ParseArg takes an arg string and converts it to a config, e.g.
+// "endpoint=collector:4318,protocol=http,temporality=delta,resource.env=staging".
+// A lone value without any key is used as the endpoint.
+func ParseArg(arg string) (Config, error) {
+	c := Config{Headers: map[string]string{}, ResourceAttributes: map[string]string{}}
+
+	if !strings.Contains(arg, "=") {
+		c.Endpoint = null.StringFrom(arg)
+		return c, nil
+	}
+
+	for _, pair := range strings.Split(arg, ",") {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 {
+			return c, fmt.Errorf("couldn't parse %q as argument for opentelemetry output", arg)
+		}
+		switch key := r[0]; {
+		case key == "endpoint":
+			c.Endpoint = null.StringFrom(r[1])
+		case key == "protocol":
+			c.Protocol = null.StringFrom(r[1])
+		case key == "urlPath":
+			c.URLPath = null.StringFrom(r[1])
+		case key == "insecure":
+			if err := c.Insecure.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case key == "temporality":
+			c.Temporality = null.StringFrom(r[1])
+		case key == "serviceName":
+			c.ServiceName = null.StringFrom(r[1])
+		case key == "pushInterval":
+			if err := c.PushInterval.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case strings.HasPrefix(key, "header."):
+			c.Headers[strings.TrimPrefix(key, "header.")] = r[1]
+		case strings.HasPrefix(key, "resource."):
+			c.ResourceAttributes[strings.TrimPrefix(key, "resource.")] = r[1]
+		default:
+			return c, fmt.Errorf("unknown key %q as argument for opentelemetry output", key)
+		}
+	}
+
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + arg config values}, and returns the final result.
+func GetConsolidatedConfig(
+	jsonRawConf json.RawMessage, env map[string]string, arg string,
+) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		// TODO: get rid of envconfig and actually use the env parameter...
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseArg(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.Validate()
+}
@@ -0,0 +1,84 @@
+package otelmetrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  Config
+		err     string
+	}{
+		"default": {
+			config: NewConfig(),
+		},
+		"consolidated": {
+			jsonRaw: json.RawMessage(`{"endpoint":"json:4317","resourceAttributes":{"env":"json","team":"json"}}`),
+			env: map[string]string{
+				"K6_OTEL_METRICS_PROTOCOL":            "http",
+				"K6_OTEL_METRICS_TEMPORALITY":         "delta",
+				"K6_OTEL_METRICS_RESOURCE_ATTRIBUTES": "env:staging",
+			},
+			arg: "endpoint=collector:4318,insecure=false,pushInterval=5s,header.Authorization=Bearer token,resource.region=eu",
+			config: Config{
+				Endpoint:           null.StringFrom("collector:4318"),
+				Protocol:           null.StringFrom("http"),
+				URLPath:            null.NewString("/v1/metrics", false),
+				Insecure:           null.BoolFrom(false),
+				Headers:            map[string]string{"Authorization": "Bearer token"},
+				Temporality:        null.StringFrom(TemporalityDelta),
+				ServiceName:        null.NewString("k6", false),
+				ResourceAttributes: map[string]string{"env": "staging", "team": "json", "region": "eu"},
+				PushInterval:       types.NullDurationFrom(5 * time.Second),
+			},
+		},
+		"endpoint only arg": {
+			arg: "otel-collector:4317",
+			config: func() Config {
+				c := NewConfig()
+				c.Endpoint = null.StringFrom("otel-collector:4317")
+				return c
+			}(),
+		},
+		"invalid protocol": {
+			arg: "protocol=udp",
+			err: `unsupported protocol "udp"`,
+		},
+		"invalid temporality": {
+			arg: "temporality=lowmemory",
+			err: `unsupported temporality "lowmemory"`,
+		},
+		"unknown key": {
+			arg: "endpoint=collector:4318,foo=bar",
+			err: `unknown key "foo"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := GetConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, config)
+		})
+	}
+}
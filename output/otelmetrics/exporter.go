@@ -0,0 +1,115 @@
+package otelmetrics
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// exporter sends the export requests to an OTLP receiver.
+type exporter interface {
+	export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) error
+	close() error
+}
+
+func newExporter(config Config) (exporter, error) {
+	if config.Protocol.String == "http" {
+		return newHTTPExporter(config), nil
+	}
+	return newGRPCExporter(config)
+}
+
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	client  collectorpb.MetricsServiceClient
+	headers metadata.MD
+}
+
+func newGRPCExporter(config Config) (*grpcExporter, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if config.Insecure.Bool {
+		creds = insecure.NewCredentials()
+	}
+	// the connection is established lazily, on the first export
+	conn, err := grpc.Dial(config.Endpoint.String, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP gRPC connection: %w", err)
+	}
+	return &grpcExporter{
+		conn:    conn,
+		client:  collectorpb.NewMetricsServiceClient(conn),
+		headers: metadata.New(config.Headers),
+	}, nil
+}
+
+func (e *grpcExporter) export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) error {
+	_, err := e.client.Export(metadata.NewOutgoingContext(ctx, e.headers), req)
+	return err
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func newHTTPExporter(config Config) *httpExporter {
+	scheme := "https"
+	if config.Insecure.Bool {
+		scheme = "http"
+	}
+	return &httpExporter{
+		client:  &http.Client{},
+		url:     scheme + "://" + config.Endpoint.String + config.URLPath.String,
+		headers: config.Headers,
+	}
+}
+
+func (e *httpExporter) export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode the metrics: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("the receiver responded with %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (*httpExporter) close() error {
+	return nil
+}
@@ -0,0 +1,382 @@
+// Package otelmetrics implements an output exporting the k6 metrics to an
+// OpenTelemetry receiver via OTLP, over either gRPC or HTTP.
+//
+// The samples are aggregated per time series between the pushes: counters
+// become monotonic sums, gauges keep their last value, trends become explicit
+// bucket histograms and rates are exported as two sums, one for the non-zero
+// samples and one for all of them. The sums and histograms are exported with
+// the configured aggregation temporality.
+package otelmetrics
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// histogramBounds are the explicit bucket boundaries of the trends, the same
+// as the default ones of the OpenTelemetry SDKs.
+var histogramBounds = []float64{ //nolint:gochecknoglobals
+	0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000,
+}
+
+// Output implements the output.Output interface, exporting the metrics to an
+// OTLP receiver.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	exporter        exporter
+	resource        *resourcepb.Resource
+	temporality     metricspb.AggregationTemporality
+	periodicFlusher *output.PeriodicFlusher
+	logger          logrus.FieldLogger
+
+	// startTime is the start of the current aggregation period, i.e. the
+	// start of the test with the cumulative temporality and the last push
+	// with the delta one.
+	startTime time.Time
+	series    map[metrics.TimeSeries]*series
+}
+
+var _ output.Output = new(Output)
+
+// New creates a new instance of the OpenTelemetry metrics output.
+func New(params output.Params) (output.Output, error) {
+	config, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	exp, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	temporality := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	if config.Temporality.String == TemporalityDelta {
+		temporality = metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	}
+
+	return &Output{
+		config:      config,
+		exporter:    exp,
+		resource:    newResource(config),
+		temporality: temporality,
+		logger:      params.Logger.WithField("output", "opentelemetry"),
+		series:      make(map[metrics.TimeSeries]*series),
+	}, nil
+}
+
+func newResource(config Config) *resourcepb.Resource {
+	keys := make([]string, 0, len(config.ResourceAttributes))
+	for k := range config.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := []*commonpb.KeyValue{
+		stringAttribute("service.name", config.ServiceName.String),
+		stringAttribute("service.version", consts.Version),
+	}
+	for _, k := range keys {
+		attributes = append(attributes, stringAttribute(k, config.ResourceAttributes[k]))
+	}
+	return &resourcepb.Resource{Attributes: attributes}
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("opentelemetry (%s://%s, %s)",
+		o.config.Protocol.String, o.config.Endpoint.String, o.config.Temporality.String)
+}
+
+// Start starts the periodic pushes of the aggregated metrics.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+	o.startTime = time.Now()
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop pushes the remaining metrics and closes the connection to the receiver.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return o.exporter.close()
+}
+
+func (o *Output) flushMetrics() {
+	for _, sc := range o.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			s, ok := o.series[sample.TimeSeries]
+			if !ok {
+				s = newSeries(sample.TimeSeries)
+				o.series[sample.TimeSeries] = s
+			}
+			s.add(sample)
+		}
+	}
+
+	now := time.Now()
+	ms := o.buildMetrics(now)
+	if o.temporality == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+		o.startTime = now
+		o.series = make(map[metrics.TimeSeries]*series)
+	}
+	if len(ms) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.PushInterval.TimeDuration())
+	defer cancel()
+	err := o.exporter.export(ctx, &collectorpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: o.resource,
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope:   &commonpb.InstrumentationScope{Name: "k6", Version: consts.Version},
+				Metrics: ms,
+			}},
+		}},
+	})
+	if err != nil {
+		o.logger.WithError(err).Error("Couldn't export the metrics")
+		return
+	}
+	o.logger.WithField("t", time.Since(now)).WithField("metrics", len(ms)).Debug("Exported the metrics")
+}
+
+// buildMetrics returns the OTLP metrics of all the aggregated series, sorted
+// by name.
+func (o *Output) buildMetrics(now time.Time) []*metricspb.Metric {
+	start, end := uint64(o.startTime.UnixNano()), uint64(now.UnixNano())
+
+	byName := make(map[string]*metricspb.Metric)
+	get := func(metric *metrics.Metric, name, unit string, newData func() *metricspb.Metric) *metricspb.Metric {
+		m, ok := byName[name]
+		if !ok {
+			m = newData()
+			m.Name, m.Unit, m.Description = name, unit, metric.Description
+			byName[name] = m
+		}
+		return m
+	}
+	newSum := func() *metricspb.Metric {
+		return &metricspb.Metric{Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: o.temporality,
+			IsMonotonic:            true,
+		}}}
+	}
+
+	for _, s := range o.series {
+		metric := s.timeSeries.Metric
+		unit := unitOf(metric)
+		switch metric.Type {
+		case metrics.Counter:
+			m := get(metric, metric.Name, unit, newSum)
+			sum := m.GetSum()
+			sum.DataPoints = append(sum.DataPoints, numberDataPoint(s.attributes, start, end, s.sum))
+		case metrics.Gauge:
+			m := get(metric, metric.Name, unit, func() *metricspb.Metric {
+				return &metricspb.Metric{Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}}
+			})
+			gauge := m.GetGauge()
+			gauge.DataPoints = append(gauge.DataPoints,
+				numberDataPoint(s.attributes, 0, uint64(s.lastTime.UnixNano()), s.last))
+		case metrics.Rate:
+			occurred := get(metric, metric.Name+".occurred", "1", newSum).GetSum()
+			occurred.DataPoints = append(occurred.DataPoints, numberDataPoint(s.attributes, start, end, s.sum))
+			total := get(metric, metric.Name+".total", "1", newSum).GetSum()
+			total.DataPoints = append(total.DataPoints,
+				numberDataPoint(s.attributes, start, end, float64(s.count)))
+		case metrics.Trend:
+			m := get(metric, metric.Name, unit, func() *metricspb.Metric {
+				return &metricspb.Metric{Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+					AggregationTemporality: o.temporality,
+				}}}
+			})
+			histogram := m.GetHistogram()
+			histogram.DataPoints = append(histogram.DataPoints, s.histogramDataPoint(start, end))
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ms := make([]*metricspb.Metric, 0, len(names))
+	for _, name := range names {
+		ms = append(ms, byName[name])
+	}
+	return ms
+}
+
+// series is the aggregation of the samples of a single time series.
+type series struct {
+	timeSeries metrics.TimeSeries
+	attributes []*commonpb.KeyValue
+
+	count    uint64
+	sum      float64
+	min, max float64
+	buckets  []uint64
+	last     float64
+	lastTime time.Time
+	exemplar *metricspb.Exemplar
+}
+
+func newSeries(ts metrics.TimeSeries) *series {
+	s := &series{
+		timeSeries: ts,
+		attributes: tagsAttributes(ts.Tags),
+		min:        math.Inf(1),
+		max:        math.Inf(-1),
+	}
+	if ts.Metric.Type == metrics.Trend {
+		s.buckets = make([]uint64, len(histogramBounds)+1)
+	}
+	return s
+}
+
+func (s *series) add(sample metrics.Sample) {
+	s.count++
+	s.last, s.lastTime = sample.Value, sample.Time
+
+	switch s.timeSeries.Metric.Type {
+	case metrics.Rate:
+		if sample.Value != 0 {
+			s.sum++
+		}
+	case metrics.Trend:
+		s.sum += sample.Value
+		s.min = math.Min(s.min, sample.Value)
+		s.max = math.Max(s.max, sample.Value)
+		s.buckets[sort.SearchFloat64s(histogramBounds, sample.Value)]++
+		if exemplar := exemplarFromSample(sample); exemplar != nil {
+			s.exemplar = exemplar
+		}
+	default:
+		s.sum += sample.Value
+	}
+}
+
+func (s *series) histogramDataPoint(start, end uint64) *metricspb.HistogramDataPoint {
+	dp := &metricspb.HistogramDataPoint{
+		Attributes:        s.attributes,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      end,
+		Count:             s.count,
+		Sum:               &s.sum,
+		Min:               &s.min,
+		Max:               &s.max,
+		BucketCounts:      s.buckets,
+		ExplicitBounds:    histogramBounds,
+	}
+	if s.exemplar != nil {
+		dp.Exemplars = []*metricspb.Exemplar{s.exemplar}
+	}
+	return dp
+}
+
+// exemplarFromSample returns an exemplar linking to the trace propagated by
+// the request measured by the sample, or nil if it didn't propagate one.
+func exemplarFromSample(sample metrics.Sample) *metricspb.Exemplar {
+	traceID, traceErr := hex.DecodeString(sample.Metadata["trace_id"])
+	spanID, spanErr := hex.DecodeString(sample.Metadata["span_id"])
+	if traceErr != nil || spanErr != nil || len(traceID) != 16 || len(spanID) != 8 {
+		return nil
+	}
+	return &metricspb.Exemplar{
+		FilteredAttributes: metadataAttributes(sample.Metadata),
+		TimeUnixNano:       uint64(sample.Time.UnixNano()),
+		Value:              &metricspb.Exemplar_AsDouble{AsDouble: sample.Value},
+		TraceId:            traceID,
+		SpanId:             spanID,
+	}
+}
+
+// metadataAttributes returns the metadata of a sample other than the trace
+// context, like the full URL or the VU, as the attributes of its exemplar.
+func metadataAttributes(metadata map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		if k != "trace_id" && k != "span_id" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	attributes := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attributes = append(attributes, stringAttribute(k, metadata[k]))
+	}
+	return attributes
+}
+
+func numberDataPoint(attributes []*commonpb.KeyValue, start, end uint64, value float64) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      end,
+		Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+// unitOf returns the UCUM unit of the metric's values.
+func unitOf(metric *metrics.Metric) string {
+	switch metric.Contains {
+	case metrics.Time:
+		return "ms"
+	case metrics.Data:
+		return "By"
+	default:
+		return ""
+	}
+}
+
+func tagsAttributes(tags *metrics.TagSet) []*commonpb.KeyValue {
+	tagsMap := tags.Map()
+	keys := make([]string, 0, len(tagsMap))
+	for k := range tagsMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attributes = append(attributes, stringAttribute(k, tagsMap[k]))
+	}
+	return attributes
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
@@ -0,0 +1,171 @@
+package otelmetrics
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	for _, temporality := range []string{TemporalityCumulative, TemporalityDelta} {
+		temporality := temporality
+		t.Run(temporality, func(t *testing.T) {
+			t.Parallel()
+			testOutput(t, temporality)
+		})
+	}
+}
+
+func testOutput(t *testing.T, temporality string) {
+	var (
+		mu       sync.Mutex
+		requests []*collectorpb.ExportMetricsServiceRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req := &collectorpb.ExportMetricsServiceRequest{}
+		require.NoError(t, proto.Unmarshal(body, req))
+
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	out, err := New(output.Params{
+		Logger: testutils.NewLogger(t),
+		ConfigArgument: "endpoint=" + strings.TrimPrefix(srv.URL, "http://") +
+			",protocol=http,header.Authorization=secret,resource.env=staging,temporality=" + temporality,
+	})
+	require.NoError(t, err)
+	o, ok := out.(*Output)
+	require.True(t, ok)
+	require.NoError(t, out.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	require.NoError(t, builtin.VUs.Describe("The active VUs."))
+	tags := registry.RootTagSet().With("scenario", "default")
+	now := time.Now()
+	sample := func(metric *metrics.Metric, value float64, metadata map[string]string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+			Time:       now,
+			Value:      value,
+			Metadata:   metadata,
+		}
+	}
+	traceID, spanID := "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+
+	// the periodic flusher is bypassed, so the pushes are deterministic
+	for i := 0; i < 2; i++ {
+		o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+			sample(builtin.Iterations, 1, nil),
+			sample(builtin.Iterations, 1, nil),
+			sample(builtin.VUs, 5, nil),
+			sample(builtin.Checks, 1, nil),
+			sample(builtin.Checks, 0, nil),
+			sample(builtin.HTTPReqDuration, 3, nil),
+			sample(builtin.HTTPReqDuration, 120, map[string]string{
+				"trace_id": traceID, "span_id": spanID, "vu": "3", "full_url": "https://test.k6.io/?id=42",
+			}),
+		}})
+		o.flushMetrics()
+	}
+	require.NoError(t, out.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	// with the cumulative temporality, the final push on Stop repeats the totals
+	if temporality == TemporalityDelta {
+		require.Len(t, requests, 2)
+	} else {
+		require.Len(t, requests, 3)
+		assert.True(t, proto.Equal(requests[1].ResourceMetrics[0].Resource, requests[2].ResourceMetrics[0].Resource))
+	}
+
+	expected := 4.0
+	if temporality == TemporalityDelta {
+		expected = 2
+	}
+	req := requests[1]
+	require.Len(t, req.ResourceMetrics, 1)
+	resource := req.ResourceMetrics[0].Resource
+	require.Len(t, resource.Attributes, 3)
+	assert.Equal(t, "service.name", resource.Attributes[0].Key)
+	assert.Equal(t, "env", resource.Attributes[2].Key)
+	assert.Equal(t, "staging", resource.Attributes[2].Value.GetStringValue())
+
+	byName := make(map[string]*metricspb.Metric)
+	for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+	require.Len(t, byName, 5)
+
+	iterations := byName[metrics.IterationsName].GetSum()
+	require.NotNil(t, iterations)
+	assert.True(t, iterations.IsMonotonic)
+	assert.Equal(t, temporality == TemporalityDelta,
+		iterations.AggregationTemporality == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA)
+	require.Len(t, iterations.DataPoints, 1)
+	assert.Equal(t, expected, iterations.DataPoints[0].GetAsDouble())
+	assert.Equal(t, "scenario", iterations.DataPoints[0].Attributes[0].Key)
+
+	vus := byName[metrics.VUsName].GetGauge()
+	require.NotNil(t, vus)
+	assert.Equal(t, 5.0, vus.DataPoints[0].GetAsDouble())
+	assert.Equal(t, "The active VUs.", byName[metrics.VUsName].Description)
+	assert.Empty(t, byName[metrics.IterationsName].Description)
+
+	assert.Equal(t, expected/2, byName[metrics.ChecksName+".occurred"].GetSum().DataPoints[0].GetAsDouble())
+	assert.Equal(t, expected, byName[metrics.ChecksName+".total"].GetSum().DataPoints[0].GetAsDouble())
+
+	duration := byName[metrics.HTTPReqDurationName]
+	assert.Equal(t, "ms", duration.Unit)
+	require.NotNil(t, duration.GetHistogram())
+	dp := duration.GetHistogram().DataPoints[0]
+	assert.Equal(t, uint64(expected), dp.Count)
+	assert.Equal(t, 123*expected/2, dp.GetSum())
+	assert.Equal(t, 3.0, dp.GetMin())
+	assert.Equal(t, 120.0, dp.GetMax())
+	require.Len(t, dp.BucketCounts, len(histogramBounds)+1)
+	assert.Equal(t, uint64(expected/2), dp.BucketCounts[1]) // (0, 5]
+	assert.Equal(t, uint64(expected/2), dp.BucketCounts[7]) // (100, 250]
+	require.Len(t, dp.Exemplars, 1)
+	assert.Equal(t, traceID, hex.EncodeToString(dp.Exemplars[0].TraceId))
+	assert.Equal(t, spanID, hex.EncodeToString(dp.Exemplars[0].SpanId))
+	require.Len(t, dp.Exemplars[0].FilteredAttributes, 2)
+	assert.Equal(t, "full_url", dp.Exemplars[0].FilteredAttributes[0].Key)
+	assert.Equal(t, "https://test.k6.io/?id=42", dp.Exemplars[0].FilteredAttributes[0].Value.GetStringValue())
+	assert.Equal(t, "vu", dp.Exemplars[0].FilteredAttributes[1].Key)
+
+	if temporality == TemporalityDelta {
+		assert.Equal(t, requests[0].ResourceMetrics[0].ScopeMetrics[0].Metrics[0].GetSum().DataPoints[0].TimeUnixNano,
+			iterations.DataPoints[0].StartTimeUnixNano)
+	} else {
+		assert.Equal(t, requests[0].ResourceMetrics[0].ScopeMetrics[0].Metrics[0].GetSum().DataPoints[0].StartTimeUnixNano,
+			iterations.DataPoints[0].StartTimeUnixNano)
+	}
+}
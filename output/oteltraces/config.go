@@ -0,0 +1,163 @@
+package oteltraces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// Config is the config for the OpenTelemetry traces output.
+type Config struct {
+	// Endpoint is the host and port of the OTLP receiver.
+	Endpoint null.String `json:"endpoint" envconfig:"K6_OTEL_TRACES_ENDPOINT"`
+	// Protocol is the OTLP transport, either grpc or http.
+	Protocol null.String `json:"protocol" envconfig:"K6_OTEL_TRACES_PROTOCOL"`
+	// URLPath is the path to which the spans are posted, only used with http.
+	URLPath null.String `json:"urlPath" envconfig:"K6_OTEL_TRACES_URL_PATH"`
+	// Insecure disables TLS for the connection to the receiver.
+	Insecure null.Bool `json:"insecure" envconfig:"K6_OTEL_TRACES_INSECURE"`
+	// Headers are sent with every export request, e.g. for authentication.
+	Headers map[string]string `json:"headers" envconfig:"K6_OTEL_TRACES_HEADERS"`
+	// ServiceName is the service.name resource attribute of the spans.
+	ServiceName null.String `json:"serviceName" envconfig:"K6_OTEL_TRACES_SERVICE_NAME"`
+	// FlushInterval is how often the buffered spans are exported.
+	FlushInterval types.NullDuration `json:"flushInterval" envconfig:"K6_OTEL_TRACES_FLUSH_INTERVAL"`
+}
+
+// NewConfig creates a new Config instance with default values for some fields.
+func NewConfig() Config {
+	return Config{
+		Endpoint:      null.NewString("127.0.0.1:4317", false),
+		Protocol:      null.NewString("grpc", false),
+		URLPath:       null.NewString("/v1/traces", false),
+		Insecure:      null.NewBool(true, false),
+		Headers:       map[string]string{},
+		ServiceName:   null.NewString("k6", false),
+		FlushInterval: types.NewNullDuration(1*time.Second, false),
+	}
+}
+
+// Apply merges two configs by overwriting properties in the old config
+func (c Config) Apply(cfg Config) Config {
+	if cfg.Endpoint.Valid {
+		c.Endpoint = cfg.Endpoint
+	}
+	if cfg.Protocol.Valid {
+		c.Protocol = cfg.Protocol
+	}
+	if cfg.URLPath.Valid {
+		c.URLPath = cfg.URLPath
+	}
+	if cfg.Insecure.Valid {
+		c.Insecure = cfg.Insecure
+	}
+	for k, v := range cfg.Headers {
+		c.Headers[k] = v
+	}
+	if cfg.ServiceName.Valid {
+		c.ServiceName = cfg.ServiceName
+	}
+	if cfg.FlushInterval.Valid {
+		c.FlushInterval = cfg.FlushInterval
+	}
+	return c
+}
+
+// Validate returns an error if the config can't be used to export the spans.
+func (c Config) Validate() error {
+	if c.Protocol.String != "grpc" && c.Protocol.String != "http" {
+		return fmt.Errorf("unsupported protocol %q, it must be grpc or http", c.Protocol.String)
+	}
+	if c.Endpoint.String == "" {
+		return errors.New("the endpoint is required")
+	}
+	if c.FlushInterval.Duration <= 0 {
+		return fmt.Errorf("the flush interval must be positive, got %s", c.FlushInterval.Duration)
+	}
+	return nil
+}
+
+// ParseArg takes an arg string and converts it to a config, e.g.
+// "endpoint=tempo:4318,protocol=http,header.Authorization=Bearer token".
+// A lone value without any key is used as the endpoint.
+func ParseArg(arg string) (Config, error) {
+	c := Config{Headers: map[string]string{}}
+
+	if !strings.Contains(arg, "=") {
+		c.Endpoint = null.StringFrom(arg)
+		return c, nil
+	}
+
+	for _, pair := range strings.Split(arg, ",") {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 {
+			return c, fmt.Errorf("couldn't parse %q as argument for opentelemetry-traces output", arg)
+		}
+		switch key := r[0]; {
+		case key == "endpoint":
+			c.Endpoint = null.StringFrom(r[1])
+		case key == "protocol":
+			c.Protocol = null.StringFrom(r[1])
+		case key == "urlPath":
+			c.URLPath = null.StringFrom(r[1])
+		case key == "insecure":
+			if err := c.Insecure.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case key == "serviceName":
+			c.ServiceName = null.StringFrom(r[1])
+		case key == "flushInterval":
+			if err := c.FlushInterval.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case strings.HasPrefix(key, "header."):
+			c.Headers[strings.TrimPrefix(key, "header.")] = r[1]
+		default:
+			return c, fmt.Errorf("unknown key %q as argument for opentelemetry-traces output", key)
+		}
+	}
+
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + arg config values}, and returns the final result.
+func GetConsolidatedConfig(
+	jsonRawConf json.RawMessage, env map[string]string, arg string,
+) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		// TODO: get rid of envconfig and actually use the env parameter...
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseArg(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.Validate()
+}
@@ -0,0 +1,77 @@
+package oteltraces
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  Config
+		err     string
+	}{
+		"default": {
+			config: NewConfig(),
+		},
+		"consolidated": {
+			jsonRaw: json.RawMessage(`{"endpoint":"json:4317","serviceName":"checkout","headers":{"a":"json"}}`),
+			env: map[string]string{
+				"K6_OTEL_TRACES_PROTOCOL": "http",
+				"K6_OTEL_TRACES_HEADERS":  "a:env,b:env",
+			},
+			arg: "endpoint=tempo:4318,insecure=false,flushInterval=5s,header.Authorization=Bearer token",
+			config: Config{
+				Endpoint:      null.StringFrom("tempo:4318"),
+				Protocol:      null.StringFrom("http"),
+				URLPath:       null.NewString("/v1/traces", false),
+				Insecure:      null.BoolFrom(false),
+				Headers:       map[string]string{"a": "env", "b": "env", "Authorization": "Bearer token"},
+				ServiceName:   null.StringFrom("checkout"),
+				FlushInterval: types.NullDurationFrom(5 * time.Second),
+			},
+		},
+		"endpoint only arg": {
+			arg: "otel-collector:4317",
+			config: func() Config {
+				c := NewConfig()
+				c.Endpoint = null.StringFrom("otel-collector:4317")
+				return c
+			}(),
+		},
+		"invalid protocol": {
+			arg: "protocol=udp",
+			err: `unsupported protocol "udp"`,
+		},
+		"unknown key": {
+			arg: "endpoint=tempo:4318,foo=bar",
+			err: `unknown key "foo"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := GetConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, config)
+		})
+	}
+}
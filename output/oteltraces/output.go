@@ -0,0 +1,276 @@
+// Package oteltraces implements an output exporting the iterations, groups
+// and requests of a test run as OpenTelemetry spans via OTLP.
+//
+// The spans are built from the duration samples, so they reflect what was
+// actually measured. Every iteration is its own trace, with its correlation
+// ID as the trace ID, and the groups and requests are nested inside of it.
+// Requests which propagated a sampled trace context are exported with the
+// propagated IDs instead, so they become the parents of the server-side spans,
+// and they link back to their iteration's trace.
+package oteltraces
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+const browserHTTPReqDurationName = "browser_http_req_duration"
+
+// Output implements the output.Output interface, exporting the spans to an
+// OTLP receiver.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	client          otlptrace.Client
+	resource        *resourcepb.Resource
+	periodicFlusher *output.PeriodicFlusher
+	logger          logrus.FieldLogger
+}
+
+var _ output.Output = new(Output)
+
+// New creates a new instance of the OpenTelemetry traces output.
+func New(params output.Params) (output.Output, error) {
+	config, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config:   config,
+		client:   newClient(config),
+		resource: newResource(config.ServiceName.String),
+		logger:   params.Logger.WithField("output", "opentelemetry-traces"),
+	}, nil
+}
+
+func newClient(config Config) otlptrace.Client {
+	if config.Protocol.String == "http" {
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(config.Endpoint.String),
+			otlptracehttp.WithURLPath(config.URLPath.String),
+			otlptracehttp.WithHeaders(config.Headers),
+		}
+		if config.Insecure.Bool {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.NewClient(opts...)
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(config.Endpoint.String),
+		otlptracegrpc.WithHeaders(config.Headers),
+	}
+	if config.Insecure.Bool {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.NewClient(opts...)
+}
+
+func newResource(serviceName string) *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Attributes: []*commonpb.KeyValue{
+			stringAttribute("service.name", serviceName),
+			stringAttribute("service.version", consts.Version),
+		},
+	}
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("opentelemetry-traces (%s://%s)", o.config.Protocol.String, o.config.Endpoint.String)
+}
+
+// Start connects to the receiver and starts the periodic exports.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+	if err := o.client.Start(context.Background()); err != nil {
+		return fmt.Errorf("failed to start the OTLP client: %w", err)
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.FlushInterval.TimeDuration(), o.flushSpans)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop exports the remaining spans and disconnects from the receiver.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return o.client.Stop(ctx)
+}
+
+func (o *Output) flushSpans() {
+	var spans []*tracepb.Span
+	for _, sc := range o.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			if span := spanFromSample(sample); span != nil {
+				spans = append(spans, span)
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return
+	}
+
+	start := time.Now()
+	err := o.client.UploadTraces(context.Background(), []*tracepb.ResourceSpans{{
+		Resource: o.resource,
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Scope: &commonpb.InstrumentationScope{Name: "k6", Version: consts.Version},
+			Spans: spans,
+		}},
+	}})
+	if err != nil {
+		o.logger.WithError(err).Error("Couldn't export the spans")
+		return
+	}
+	o.logger.WithField("t", time.Since(start)).WithField("spans", len(spans)).Debug("Exported the spans")
+}
+
+// spanFromSample returns the span measured by the sample, or nil if it isn't
+// a duration of an iteration, group or request made within an iteration.
+func spanFromSample(sample metrics.Sample) *tracepb.Span {
+	correlationID := sample.Metadata["correlation_id"]
+	iterationTraceID, err := hex.DecodeString(correlationID)
+	if err != nil || len(iterationTraceID) != 16 {
+		return nil
+	}
+	group, _ := sample.Tags.Get(metrics.TagGroup.String())
+
+	end := sample.Time
+	start := end.Add(-time.Duration(sample.Value * float64(time.Millisecond)))
+	span := &tracepb.Span{
+		TraceId:           iterationTraceID,
+		StartTimeUnixNano: uint64(start.UnixNano()),
+		EndTimeUnixNano:   uint64(end.UnixNano()),
+		Attributes:        tagsAttributes(sample.Tags),
+	}
+
+	switch sample.Metric.Name {
+	case metrics.IterationDurationName:
+		span.Name = "iteration"
+		span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+		span.SpanId = groupSpanID(correlationID, "")
+	case metrics.GroupDurationName:
+		span.Name = "group " + group
+		span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+		span.SpanId = groupSpanID(correlationID, group)
+		span.ParentSpanId = groupSpanID(correlationID, parentGroup(group))
+	case metrics.HTTPReqDurationName, browserHTTPReqDurationName, metrics.GRPCReqDurationName:
+		span.Name = requestSpanName(sample)
+		span.Kind = tracepb.Span_SPAN_KIND_CLIENT
+		span.SpanId = randomSpanID()
+		span.ParentSpanId = groupSpanID(correlationID, group)
+		if failed(sample.Tags) {
+			span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+		}
+
+		// the request propagated a sampled trace context, so the span is
+		// exported as the parent of the server-side spans
+		traceID, traceErr := hex.DecodeString(sample.Metadata["trace_id"])
+		spanID, spanErr := hex.DecodeString(sample.Metadata["span_id"])
+		if traceErr == nil && spanErr == nil && len(traceID) == 16 && len(spanID) == 8 {
+			span.Links = []*tracepb.Span_Link{{TraceId: span.TraceId, SpanId: span.ParentSpanId}}
+			span.TraceId, span.SpanId, span.ParentSpanId = traceID, spanID, nil
+		}
+	default:
+		return nil
+	}
+
+	return span
+}
+
+func requestSpanName(sample metrics.Sample) string {
+	if sample.Metric.Name == metrics.GRPCReqDurationName {
+		name, _ := sample.Tags.Get(metrics.TagName.String())
+		return name
+	}
+	method, _ := sample.Tags.Get(metrics.TagMethod.String())
+	if name, ok := sample.Tags.Get(metrics.TagName.String()); ok {
+		return method + " " + name
+	}
+	return method
+}
+
+func failed(tags *metrics.TagSet) bool {
+	if expected, ok := tags.Get(metrics.TagExpectedResponse.String()); ok && expected == "false" {
+		return true
+	}
+	_, hasError := tags.Get(metrics.TagError.String())
+	return hasError
+}
+
+// groupSpanID returns the span ID of a group in an iteration. It is derived
+// from them, so the spans of the nested groups and requests can reference
+// their parents before those have been measured. The root group is the
+// iteration itself.
+func groupSpanID(correlationID, group string) []byte {
+	sum := sha256.Sum256([]byte(correlationID + "|" + group))
+	return sum[:8]
+}
+
+// parentGroup returns the path of the group enclosing the one with the
+// provided path, e.g. "::a" for "::a::b" and "" for "::a".
+func parentGroup(group string) string {
+	if i := strings.LastIndex(group, "::"); i > 0 {
+		return group[:i]
+	}
+	return ""
+}
+
+func randomSpanID() []byte {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id) // it never returns an error
+	return id
+}
+
+func tagsAttributes(tags *metrics.TagSet) []*commonpb.KeyValue {
+	tagsMap := tags.Map()
+	keys := make([]string, 0, len(tagsMap))
+	for k := range tagsMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attributes = append(attributes, stringAttribute(k, tagsMap[k]))
+	}
+	return attributes
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
@@ -0,0 +1,124 @@
+package oteltraces
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+const (
+	correlationID = "0af7651916cd43dd8448eb211c80319c"
+	traceID       = "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanID        = "00f067aa0ba902b7"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		spans []*tracepb.Span
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req := &collectorpb.ExportTraceServiceRequest{}
+		require.NoError(t, proto.Unmarshal(body, req))
+
+		mu.Lock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(nil)
+	}))
+	defer srv.Close()
+
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "endpoint=" + strings.TrimPrefix(srv.URL, "http://") + ",protocol=http,header.Authorization=secret",
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	end := time.Now()
+	sample := func(metric *metrics.Metric, tags map[string]string, metadata map[string]string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet().WithTagsFromMap(tags)},
+			Time:       end,
+			Value:      100,
+			Metadata:   metadata,
+		}
+	}
+	meta := map[string]string{"correlation_id": correlationID}
+	out.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(builtin.HTTPReqDuration, map[string]string{"group": "::login", "method": "GET", "name": "/a"}, meta),
+		sample(builtin.HTTPReqDuration, map[string]string{
+			"group": "::login::form", "method": "POST", "name": "/b", "expected_response": "false",
+		}, map[string]string{"correlation_id": correlationID, "trace_id": traceID, "span_id": spanID}),
+		sample(builtin.GroupDuration, map[string]string{"group": "::login::form"}, meta),
+		sample(builtin.GroupDuration, map[string]string{"group": "::login"}, meta),
+		sample(builtin.IterationDuration, map[string]string{"group": ""}, meta),
+		sample(builtin.Checks, map[string]string{"group": ""}, meta),
+		sample(builtin.IterationDuration, map[string]string{"group": ""}, nil),
+	}})
+	require.NoError(t, out.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, spans, 5)
+	byName := make(map[string]*tracepb.Span, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+		assert.Equal(t, uint64(end.UnixNano()), span.EndTimeUnixNano)
+		assert.Equal(t, uint64(end.Add(-100*time.Millisecond).UnixNano()), span.StartTimeUnixNano)
+	}
+
+	iteration, login, form := byName["iteration"], byName["group ::login"], byName["group ::login::form"]
+	get, post := byName["GET /a"], byName["POST /b"]
+	require.NotNil(t, iteration)
+	require.NotNil(t, login)
+	require.NotNil(t, form)
+	require.NotNil(t, get)
+	require.NotNil(t, post)
+
+	assert.Equal(t, correlationID, hex.EncodeToString(iteration.TraceId))
+	assert.Empty(t, iteration.ParentSpanId)
+	assert.Equal(t, iteration.SpanId, login.ParentSpanId)
+	assert.Equal(t, login.SpanId, form.ParentSpanId)
+	assert.Equal(t, login.SpanId, get.ParentSpanId)
+	assert.Equal(t, tracepb.Span_SPAN_KIND_CLIENT, get.Kind)
+	assert.Nil(t, get.Status)
+
+	assert.Equal(t, traceID, hex.EncodeToString(post.TraceId))
+	assert.Equal(t, spanID, hex.EncodeToString(post.SpanId))
+	assert.Empty(t, post.ParentSpanId)
+	require.Len(t, post.Links, 1)
+	assert.Equal(t, iteration.TraceId, post.Links[0].TraceId)
+	assert.Equal(t, form.SpanId, post.Links[0].SpanId)
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, post.Status.Code)
+}
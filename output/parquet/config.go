@@ -0,0 +1,189 @@
+package parquet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// Compression is the compression codec of the data pages.
+type Compression uint8
+
+// The supported compression codecs.
+const (
+	CompressionNone Compression = iota
+	CompressionSnappy
+	CompressionGzip
+	CompressionZstd
+)
+
+var compressionNames = map[string]Compression{ //nolint:gochecknoglobals
+	"none":   CompressionNone,
+	"snappy": CompressionSnappy,
+	"gzip":   CompressionGzip,
+	"zstd":   CompressionZstd,
+}
+
+// thriftCodec returns the CompressionCodec of the Parquet format.
+func (c Compression) thriftCodec() int32 {
+	switch c {
+	case CompressionSnappy:
+		return 1
+	case CompressionGzip:
+		return 2
+	case CompressionZstd:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// Config is the config for the Parquet output.
+type Config struct {
+	// FileName is the path of the written file.
+	FileName null.String `json:"fileName" envconfig:"K6_PARQUET_FILENAME"`
+	// Compression is the codec of the data pages: none, snappy, gzip or zstd.
+	Compression null.String `json:"compression" envconfig:"K6_PARQUET_COMPRESSION"`
+	// RowGroupSize is the number of rows of each row group, the buffered rows
+	// are written once it's reached.
+	RowGroupSize null.Int `json:"rowGroupSize" envconfig:"K6_PARQUET_ROW_GROUP_SIZE"`
+	// AggregationWindow, when set, makes the output write a row per time
+	// series and window, with the count, sum, min and max of its samples,
+	// instead of a row per sample.
+	AggregationWindow types.NullDuration `json:"aggregationWindow" envconfig:"K6_PARQUET_AGGREGATION_WINDOW"`
+	// FlushInterval is how often the buffered samples are processed.
+	FlushInterval types.NullDuration `json:"flushInterval" envconfig:"K6_PARQUET_FLUSH_INTERVAL"`
+}
+
+// NewConfig creates a new Config instance with default values for some fields.
+func NewConfig() Config {
+	return Config{
+		FileName:          null.NewString("k6.parquet", false),
+		Compression:       null.NewString("snappy", false),
+		RowGroupSize:      null.NewInt(100000, false),
+		AggregationWindow: types.NewNullDuration(0, false),
+		FlushInterval:     types.NewNullDuration(1*time.Second, false),
+	}
+}
+
+// Apply merges two configs by overwriting properties in the old config
+func (c Config) Apply(cfg Config) Config {
+	if cfg.FileName.Valid {
+		c.FileName = cfg.FileName
+	}
+	if cfg.Compression.Valid {
+		c.Compression = cfg.Compression
+	}
+	if cfg.RowGroupSize.Valid {
+		c.RowGroupSize = cfg.RowGroupSize
+	}
+	if cfg.AggregationWindow.Valid {
+		c.AggregationWindow = cfg.AggregationWindow
+	}
+	if cfg.FlushInterval.Valid {
+		c.FlushInterval = cfg.FlushInterval
+	}
+	return c
+}
+
+// Validate returns an error if the config can't be used to write the file.
+func (c Config) Validate() error {
+	if c.FileName.String == "" {
+		return fmt.Errorf("the file name is required")
+	}
+	if _, ok := compressionNames[c.Compression.String]; !ok {
+		return fmt.Errorf("unsupported compression %q, it must be none, snappy, gzip or zstd", c.Compression.String)
+	}
+	if c.RowGroupSize.Int64 <= 0 {
+		return fmt.Errorf("the row group size must be positive, got %d", c.RowGroupSize.Int64)
+	}
+	if c.AggregationWindow.Duration < 0 {
+		return fmt.Errorf("the aggregation window can't be negative, got %s", c.AggregationWindow.Duration)
+	}
+	if c.FlushInterval.Duration <= 0 {
+		return fmt.Errorf("the flush interval must be positive, got %s", c.FlushInterval.Duration)
+	}
+	return nil
+}
+
+// ParseArg takes an arg string and converts it to a config, e.g.
+// "fileName=results.parquet,compression=zstd". A lone value without any key
+// is used as the file name.
+func ParseArg(arg string) (Config, error) {
+	c := Config{}
+
+	if !strings.Contains(arg, "=") {
+		c.FileName = null.StringFrom(arg)
+		return c, nil
+	}
+
+	for _, pair := range strings.Split(arg, ",") {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 {
+			return c, fmt.Errorf("couldn't parse %q as argument for parquet output", arg)
+		}
+		switch r[0] {
+		case "fileName":
+			c.FileName = null.StringFrom(r[1])
+		case "compression":
+			c.Compression = null.StringFrom(r[1])
+		case "rowGroupSize":
+			if err := c.RowGroupSize.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "aggregationWindow":
+			if err := c.AggregationWindow.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "flushInterval":
+			if err := c.FlushInterval.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		default:
+			return c, fmt.Errorf("unknown key %q as argument for parquet output", r[0])
+		}
+	}
+
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + arg config values}, and returns the final result.
+func GetConsolidatedConfig(
+	jsonRawConf json.RawMessage, env map[string]string, arg string,
+) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		// TODO: get rid of envconfig and actually use the env parameter...
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseArg(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.Validate()
+}
@@ -0,0 +1,76 @@
+package parquet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  func(c *Config)
+		err     string
+	}{
+		"default": {
+			config: func(*Config) {},
+		},
+		"consolidated": {
+			jsonRaw: json.RawMessage(`{"compression":"gzip","rowGroupSize":5000}`),
+			env:     map[string]string{"K6_PARQUET_AGGREGATION_WINDOW": "10s"},
+			arg:     "fileName=results.parquet,compression=zstd",
+			config: func(c *Config) {
+				c.FileName = null.StringFrom("results.parquet")
+				c.Compression = null.StringFrom("zstd")
+				c.RowGroupSize = null.IntFrom(5000)
+				c.AggregationWindow = types.NullDurationFrom(10 * time.Second)
+			},
+		},
+		"file name only arg": {
+			arg: "run.parquet",
+			config: func(c *Config) {
+				c.FileName = null.StringFrom("run.parquet")
+			},
+		},
+		"unsupported compression": {
+			arg: "compression=lzo",
+			err: `unsupported compression "lzo", it must be none, snappy, gzip or zstd`,
+		},
+		"invalid row group size": {
+			arg: "rowGroupSize=0",
+			err: "the row group size must be positive",
+		},
+		"unknown key": {
+			arg: "fileName=run.parquet,schema=v2",
+			err: `unknown key "schema" as argument for parquet output`,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := GetConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			expected := NewConfig()
+			tc.config(&expected)
+			assert.Equal(t, expected, config)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+// Package parquet implements an output writing the samples to a Parquet file,
+// so the results can be analyzed with columnar tools like DuckDB or Pandas.
+//
+// By default, every sample is written as a row with its timestamp, metric,
+// metric type, value, tags and metadata, the latter two as JSON columns. With
+// an aggregation window, a row is written per time series and window instead,
+// with the count, sum, min and max of the window's samples.
+package parquet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+//nolint:gochecknoglobals
+var (
+	sampleColumns = []column{
+		{name: "timestamp", typ: typeInt64, logical: "timestamp"},
+		{name: "metric", typ: typeByteArray, logical: "string"},
+		{name: "type", typ: typeByteArray, logical: "string"},
+		{name: "value", typ: typeDouble},
+		{name: "tags", typ: typeByteArray, logical: "json"},
+		{name: "metadata", typ: typeByteArray, logical: "json", optional: true},
+	}
+	aggregateColumns = []column{
+		{name: "window_start", typ: typeInt64, logical: "timestamp"},
+		{name: "metric", typ: typeByteArray, logical: "string"},
+		{name: "type", typ: typeByteArray, logical: "string"},
+		{name: "tags", typ: typeByteArray, logical: "json"},
+		{name: "count", typ: typeInt64},
+		{name: "sum", typ: typeDouble},
+		{name: "min", typ: typeDouble},
+		{name: "max", typ: typeDouble},
+	}
+)
+
+// Output implements the output.Output interface, writing the samples to a
+// Parquet file.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	fs              afero.Fs
+	logger          logrus.FieldLogger
+	periodicFlusher *output.PeriodicFlusher
+
+	file      afero.File
+	bw        *bufio.Writer
+	writer    *fileWriter
+	columns   []column
+	buffers   []*columnBuffer
+	rows      int
+	tagsJSON  map[*metrics.TagSet][]byte
+	windows   map[windowKey]*aggregate
+	window    time.Duration
+	totalRows int
+}
+
+var _ output.Output = new(Output)
+
+// windowKey identifies the aggregate of a time series in a window.
+type windowKey struct {
+	start  int64
+	series metrics.TimeSeries
+}
+
+// aggregate is the aggregation of the samples of a time series in a window.
+type aggregate struct {
+	count         int64
+	sum, min, max float64
+}
+
+// New creates a new instance of the Parquet output.
+func New(params output.Params) (output.Output, error) {
+	config, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Output{
+		config:   config,
+		fs:       params.FS,
+		window:   config.AggregationWindow.TimeDuration(),
+		tagsJSON: make(map[*metrics.TagSet][]byte),
+		logger: params.Logger.WithFields(logrus.Fields{
+			"output":   "parquet",
+			"filename": config.FileName.String,
+		}),
+	}
+	o.columns = sampleColumns
+	if o.window > 0 {
+		o.columns = aggregateColumns
+		o.windows = make(map[windowKey]*aggregate)
+	}
+	o.buffers = make([]*columnBuffer, len(o.columns))
+	for i := range o.buffers {
+		o.buffers[i] = &columnBuffer{}
+	}
+	return o, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	if o.window > 0 {
+		return fmt.Sprintf("parquet (%s, aggregated every %s)", o.config.FileName.String, o.window)
+	}
+	return fmt.Sprintf("parquet (%s)", o.config.FileName.String)
+}
+
+// Start creates the file and starts the periodic flushes.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	file, err := o.fs.Create(o.config.FileName.String)
+	if err != nil {
+		return err
+	}
+	o.file = file
+	o.bw = bufio.NewWriter(file)
+	o.writer, err = newFileWriter(o.bw, o.columns, compressionNames[o.config.Compression.String],
+		"k6 version "+consts.Version)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.FlushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop writes the remaining rows and the footer, and closes the file.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	if o.window > 0 {
+		o.writeWindows(math.MaxInt64)
+	}
+	err := o.writeRowGroup()
+	if err == nil {
+		err = o.writer.close()
+	}
+	if err == nil {
+		err = o.bw.Flush()
+	}
+	if closeErr := o.file.Close(); err == nil {
+		err = closeErr
+	}
+	o.logger.WithField("rows", o.totalRows).Debug("Wrote the file")
+	return err
+}
+
+func (o *Output) flushMetrics() {
+	samples := o.GetBufferedSamples()
+	for _, sc := range samples {
+		for _, sample := range sc.GetSamples() {
+			if o.window > 0 {
+				o.aggregate(sample)
+			} else {
+				o.addSample(sample)
+			}
+		}
+	}
+
+	if o.window > 0 {
+		// the samples are received with some delay, so a window is only
+		// written once the next one is over too
+		o.writeWindows(time.Now().Add(-2 * o.window).UnixNano())
+	}
+}
+
+func (o *Output) addSample(sample metrics.Sample) {
+	b := o.buffers
+	b[0].int64s = append(b[0].int64s, sample.Time.UnixMicro())
+	b[1].byteArrays = append(b[1].byteArrays, []byte(sample.Metric.Name))
+	b[2].byteArrays = append(b[2].byteArrays, []byte(sample.Metric.Type.String()))
+	b[3].float64s = append(b[3].float64s, sample.Value)
+	b[4].byteArrays = append(b[4].byteArrays, o.tags(sample.Tags))
+	if len(sample.Metadata) == 0 {
+		b[5].nulls = append(b[5].nulls, true)
+	} else {
+		metadata, err := json.Marshal(sample.Metadata)
+		if err != nil {
+			o.logger.WithError(err).Debug("Couldn't encode the metadata")
+		}
+		b[5].nulls = append(b[5].nulls, err != nil)
+		if err == nil {
+			b[5].byteArrays = append(b[5].byteArrays, metadata)
+		}
+	}
+	o.addRow()
+}
+
+func (o *Output) aggregate(sample metrics.Sample) {
+	key := windowKey{start: sample.Time.Truncate(o.window).UnixNano(), series: sample.TimeSeries}
+	agg, ok := o.windows[key]
+	if !ok {
+		agg = &aggregate{min: sample.Value, max: sample.Value}
+		o.windows[key] = agg
+	}
+	agg.count++
+	agg.sum += sample.Value
+	agg.min = math.Min(agg.min, sample.Value)
+	agg.max = math.Max(agg.max, sample.Value)
+}
+
+// writeWindows adds the rows of the windows which are over before the
+// deadline, as Unix nanoseconds, in the order of their start.
+func (o *Output) writeWindows(deadline int64) {
+	keys := make([]windowKey, 0, len(o.windows))
+	for key := range o.windows {
+		if key.start+int64(o.window) <= deadline {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].start != keys[j].start {
+			return keys[i].start < keys[j].start
+		}
+		if keys[i].series.Metric.Name != keys[j].series.Metric.Name {
+			return keys[i].series.Metric.Name < keys[j].series.Metric.Name
+		}
+		return string(o.tags(keys[i].series.Tags)) < string(o.tags(keys[j].series.Tags))
+	})
+
+	b := o.buffers
+	for _, key := range keys {
+		agg := o.windows[key]
+		delete(o.windows, key)
+		b[0].int64s = append(b[0].int64s, key.start/int64(time.Microsecond))
+		b[1].byteArrays = append(b[1].byteArrays, []byte(key.series.Metric.Name))
+		b[2].byteArrays = append(b[2].byteArrays, []byte(key.series.Metric.Type.String()))
+		b[3].byteArrays = append(b[3].byteArrays, o.tags(key.series.Tags))
+		b[4].int64s = append(b[4].int64s, agg.count)
+		b[5].float64s = append(b[5].float64s, agg.sum)
+		b[6].float64s = append(b[6].float64s, agg.min)
+		b[7].float64s = append(b[7].float64s, agg.max)
+		o.addRow()
+	}
+}
+
+// addRow counts a row added to the buffers and writes them as a row group
+// once the configured size is reached.
+func (o *Output) addRow() {
+	o.rows++
+	if o.rows < int(o.config.RowGroupSize.Int64) {
+		return
+	}
+	if err := o.writeRowGroup(); err != nil {
+		o.logger.WithError(err).Error("Couldn't write the row group")
+	}
+}
+
+func (o *Output) writeRowGroup() error {
+	rows := o.rows
+	o.rows = 0
+	defer func() {
+		for _, b := range o.buffers {
+			b.reset()
+		}
+	}()
+	if err := o.writer.writeRowGroup(o.buffers, rows); err != nil {
+		return err
+	}
+	o.totalRows += rows
+	return nil
+}
+
+// tags returns the JSON encoding of the tag set, which is cached since the
+// tag sets are shared by the samples of the same time series.
+func (o *Output) tags(tags *metrics.TagSet) []byte {
+	if encoded, ok := o.tagsJSON[tags]; ok {
+		return encoded
+	}
+	encoded, err := json.Marshal(tags.Map())
+	if err != nil {
+		encoded = []byte("{}")
+	}
+	o.tagsJSON[tags] = encoded
+	return encoded
+}
@@ -0,0 +1,305 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// thriftReader decodes the structs encoded with the Thrift compact protocol
+// into maps of the field ids to their values.
+type thriftReader struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.buf[r.pos:])
+	require.Positive(r.t, n)
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	require.Positive(r.t, n)
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		b := r.buf[r.pos]
+		r.pos++
+		if b == 0 {
+			return fields
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.varint())
+		}
+		fields[id] = r.readValue(b & 0x0f)
+		last = id
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) interface{} {
+	switch typ {
+	case thriftTrue:
+		return true
+	case thriftFalse:
+		return false
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return string(r.buf[r.pos-n : r.pos])
+	case thriftList:
+		header := r.buf[r.pos]
+		r.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		values := make([]interface{}, size)
+		for i := range values {
+			values[i] = r.readValue(header & 0x0f)
+		}
+		return values
+	case thriftStruct:
+		return r.readStruct()
+	default:
+		require.Failf(r.t, "unexpected type", "%d", typ)
+		return nil
+	}
+}
+
+// readFile decodes a file written by the output and returns its metadata and
+// the values of its columns, with the nulls as nil.
+func readFile(t *testing.T, data []byte) (map[int16]interface{}, map[string][]interface{}) {
+	t.Helper()
+
+	require.Equal(t, magic, string(data[:4]))
+	require.Equal(t, magic, string(data[len(data)-4:]))
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{t: t, buf: data[len(data)-8-footerLength : len(data)-8]}
+	meta := footer.readStruct()
+
+	schema := meta[2].([]interface{})
+	values := make(map[string][]interface{})
+	for _, rg := range meta[4].([]interface{}) {
+		for i, cc := range rg.(map[int16]interface{})[1].([]interface{}) {
+			element := schema[i+1].(map[int16]interface{})
+			name := element[4].(string)
+			optional := element[3].(int64) == 1
+			ccMeta := cc.(map[int16]interface{})[3].(map[int16]interface{})
+			values[name] = append(values[name],
+				readColumnChunk(t, data, ccMeta, optional)...)
+		}
+	}
+	return meta, values
+}
+
+func readColumnChunk(t *testing.T, data []byte, meta map[int16]interface{}, optional bool) []interface{} {
+	typ, codec := meta[1].(int64), meta[4].(int64)
+	numValues := int(meta[5].(int64))
+	pos := int(meta[9].(int64))
+
+	var values []interface{}
+	for len(values) < numValues {
+		r := &thriftReader{t: t, buf: data, pos: pos}
+		header := r.readStruct()
+		compressedSize := int(header[3].(int64))
+		page := decompress(t, codec, data[r.pos:r.pos+compressedSize])
+		require.Len(t, page, int(header[2].(int64)))
+		pos = r.pos + compressedSize
+
+		pageValues := int(header[5].(map[int16]interface{})[1].(int64))
+		nulls := make([]bool, pageValues)
+		if optional {
+			length := int(binary.LittleEndian.Uint32(page))
+			levels := &thriftReader{t: t, buf: page[4 : 4+length]}
+			for i := 0; levels.pos < len(levels.buf); {
+				run := int(levels.uvarint() >> 1)
+				isNull := levels.buf[levels.pos] == 0
+				levels.pos++
+				for ; run > 0; run-- {
+					nulls[i] = isNull
+					i++
+				}
+			}
+			page = page[4+length:]
+		}
+		for _, isNull := range nulls {
+			if isNull {
+				values = append(values, nil)
+				continue
+			}
+			switch typ {
+			case typeInt64:
+				values = append(values, int64(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			case typeDouble:
+				values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			case typeByteArray:
+				n := int(binary.LittleEndian.Uint32(page))
+				values = append(values, string(page[4:4+n]))
+				page = page[4+n:]
+			}
+		}
+		require.Empty(t, page)
+	}
+	return values
+}
+
+func decompress(t *testing.T, codec int64, page []byte) []byte {
+	var (
+		decoded []byte
+		err     error
+	)
+	switch codec {
+	case 0:
+		return page
+	case 1:
+		decoded, err = snappy.Decode(nil, page)
+	case 2:
+		var gr *gzip.Reader
+		gr, err = gzip.NewReader(bytes.NewReader(page))
+		require.NoError(t, err)
+		decoded, err = io.ReadAll(gr)
+	case 6:
+		var dec *zstd.Decoder
+		dec, err = zstd.NewReader(nil)
+		require.NoError(t, err)
+		decoded, err = dec.DecodeAll(page, nil)
+	}
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	for _, compression := range []string{"none", "snappy", "gzip", "zstd"} {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			t.Parallel()
+
+			fs := fsext.NewMemMapFs()
+			out, err := New(output.Params{
+				Logger:         testutils.NewLogger(t),
+				FS:             fs,
+				ConfigArgument: "fileName=results.parquet,rowGroupSize=2,compression=" + compression,
+			})
+			require.NoError(t, err)
+			require.NoError(t, out.Start())
+
+			registry := metrics.NewRegistry()
+			builtin := metrics.RegisterBuiltinMetrics(registry)
+			now := time.Date(2023, time.October, 15, 10, 30, 0, 123456000, time.UTC)
+			tags := registry.RootTagSet().WithTagsFromMap(map[string]string{"status": "200"})
+			out.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+				{
+					TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqDuration, Tags: tags},
+					Time:       now,
+					Value:      120.5,
+					Metadata:   map[string]string{"trace_id": "abc"},
+				},
+				{
+					TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqs, Tags: tags},
+					Time:       now,
+					Value:      1,
+				},
+				{
+					TimeSeries: metrics.TimeSeries{Metric: builtin.Iterations, Tags: registry.RootTagSet()},
+					Time:       now.Add(time.Second),
+					Value:      1,
+				},
+			}})
+			require.NoError(t, out.Stop())
+
+			data, err := afero.ReadFile(fs, "results.parquet")
+			require.NoError(t, err)
+			meta, values := readFile(t, data)
+			assert.EqualValues(t, 3, meta[3])
+			assert.Len(t, meta[4], 2) // the row groups
+			assert.Equal(t, "k6 version "+consts.Version, meta[6])
+
+			micros := now.UnixMicro()
+			assert.Equal(t, map[string][]interface{}{
+				"timestamp": {micros, micros, micros + 1e6},
+				"metric":    {"http_req_duration", "http_reqs", "iterations"},
+				"type":      {"trend", "counter", "counter"},
+				"value":     {120.5, 1.0, 1.0},
+				"tags":      {`{"status":"200"}`, `{"status":"200"}`, `{}`},
+				"metadata":  {`{"trace_id":"abc"}`, nil, nil},
+			}, values)
+		})
+	}
+}
+
+func TestOutputAggregated(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		JSONConfig:     json.RawMessage(`{"aggregationWindow":"10s","compression":"none"}`),
+		ConfigArgument: "agg.parquet",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "parquet (agg.parquet, aggregated every 10s)", out.Description())
+	require.NoError(t, out.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	start := time.Date(2023, time.October, 15, 10, 30, 0, 0, time.UTC)
+	sample := func(offset time.Duration, value float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqDuration, Tags: registry.RootTagSet()},
+			Time:       start.Add(offset),
+			Value:      value,
+		}
+	}
+	out.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(time.Second, 100), sample(9*time.Second, 300), sample(12*time.Second, 50),
+	}})
+	require.NoError(t, out.Stop())
+
+	data, err := afero.ReadFile(fs, "agg.parquet")
+	require.NoError(t, err)
+	_, values := readFile(t, data)
+	assert.Equal(t, map[string][]interface{}{
+		"window_start": {start.UnixMicro(), start.Add(10 * time.Second).UnixMicro()},
+		"metric":       {"http_req_duration", "http_req_duration"},
+		"type":         {"trend", "trend"},
+		"tags":         {`{}`, `{}`},
+		"count":        {int64(2), int64(1)},
+		"sum":          {400.0, 50.0},
+		"min":          {100.0, 50.0},
+		"max":          {300.0, 50.0},
+	}, values)
+}
@@ -0,0 +1,112 @@
+package parquet
+
+import "encoding/binary"
+
+// The types of the Thrift compact protocol, used by the metadata of the
+// Parquet files.
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the structs with the Thrift compact protocol. The
+// fields must be written in the increasing order of their ids.
+type thriftWriter struct {
+	buf []byte
+	// lastField is the id of the last field written in each of the structs
+	// being written.
+	lastField []int16
+}
+
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := w.lastField[len(w.lastField)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.buf = binary.AppendVarint(w.buf, int64(id))
+	}
+	w.lastField[len(w.lastField)-1] = id
+}
+
+func (w *thriftWriter) beginStruct() {
+	w.lastField = append(w.lastField, 0)
+}
+
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, 0)
+	w.lastField = w.lastField[:len(w.lastField)-1]
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *thriftWriter) bool(id int16, v bool) {
+	if v {
+		w.fieldHeader(id, thriftTrue)
+	} else {
+		w.fieldHeader(id, thriftFalse)
+	}
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// structField writes a field of a struct type, fn writes its fields.
+func (w *thriftWriter) structField(id int16, fn func()) {
+	w.fieldHeader(id, thriftStruct)
+	w.beginStruct()
+	fn()
+	w.endStruct()
+}
+
+func (w *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftList)
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xf0|elemType)
+		w.buf = binary.AppendUvarint(w.buf, uint64(size))
+	}
+}
+
+func (w *thriftWriter) i32List(id int16, values []int32) {
+	w.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		w.buf = binary.AppendVarint(w.buf, int64(v))
+	}
+}
+
+func (w *thriftWriter) stringList(id int16, values []string) {
+	w.listHeader(id, thriftBinary, len(values))
+	for _, v := range values {
+		w.buf = binary.AppendUvarint(w.buf, uint64(len(v)))
+		w.buf = append(w.buf, v...)
+	}
+}
+
+// structList writes a field of a list of structs, fn writes the fields of the
+// i-th struct.
+func (w *thriftWriter) structList(id int16, size int, fn func(i int)) {
+	w.listHeader(id, thriftStruct, size)
+	for i := 0; i < size; i++ {
+		w.beginStruct()
+		fn(i)
+		w.endStruct()
+	}
+}
@@ -0,0 +1,350 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// magic starts and ends every Parquet file.
+const magic = "PAR1"
+
+// pageRows is the maximum number of rows of a data page.
+const pageRows = 10000
+
+// The physical types of the columns.
+const (
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+)
+
+// The converted types of the columns, which are still used by some readers
+// instead of the logical types.
+const (
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+	convertedJSON            = 19
+)
+
+// The encodings of the values and of the definition levels.
+const (
+	encodingPlain = 0
+	encodingRLE   = 3
+)
+
+// column describes a column of the file, all the columns are at the top level
+// of the schema.
+type column struct {
+	name     string
+	typ      int32
+	optional bool
+	// logical is the logical type of the column, one of "string", "json",
+	// "timestamp" or "" when there isn't any.
+	logical string
+}
+
+// columnBuffer holds the values of a column for a row group, only the slice
+// of its column's type is used. The null values are only recorded in nulls.
+type columnBuffer struct {
+	nulls      []bool
+	int64s     []int64
+	float64s   []float64
+	byteArrays [][]byte
+}
+
+func (b *columnBuffer) reset() {
+	b.nulls = b.nulls[:0]
+	b.int64s = b.int64s[:0]
+	b.float64s = b.float64s[:0]
+	b.byteArrays = b.byteArrays[:0]
+}
+
+// columnChunkMeta is what's recorded in the footer about a written column chunk.
+type columnChunkMeta struct {
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+	dataPageOffset   int64
+}
+
+type rowGroupMeta struct {
+	numRows int64
+	columns []columnChunkMeta
+}
+
+// fileWriter writes a Parquet file, one row group at a time, with the values
+// PLAIN encoded in the data pages.
+type fileWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []column
+	codec     Compression
+	createdBy string
+
+	rowGroups []rowGroupMeta
+	numRows   int64
+	zstd      *zstd.Encoder
+}
+
+func newFileWriter(w io.Writer, columns []column, codec Compression, createdBy string) (*fileWriter, error) {
+	fw := &fileWriter{w: w, columns: columns, codec: codec, createdBy: createdBy}
+	if codec == CompressionZstd {
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		fw.zstd = enc
+	}
+	if err := fw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
+
+func (fw *fileWriter) write(b []byte) error {
+	n, err := fw.w.Write(b)
+	fw.offset += int64(n)
+	return err
+}
+
+// writeRowGroup writes the buffered values of all the columns as a row group.
+func (fw *fileWriter) writeRowGroup(buffers []*columnBuffer, numRows int) error {
+	if numRows == 0 {
+		return nil
+	}
+	rg := rowGroupMeta{numRows: int64(numRows), columns: make([]columnChunkMeta, len(fw.columns))}
+	for i, col := range fw.columns {
+		meta, err := fw.writeColumnChunk(col, buffers[i], numRows)
+		if err != nil {
+			return fmt.Errorf("failed to write the %s column: %w", col.name, err)
+		}
+		rg.columns[i] = meta
+	}
+	fw.rowGroups = append(fw.rowGroups, rg)
+	fw.numRows += int64(numRows)
+	return nil
+}
+
+func (fw *fileWriter) writeColumnChunk(col column, buf *columnBuffer, numRows int) (columnChunkMeta, error) {
+	meta := columnChunkMeta{numValues: int64(numRows), dataPageOffset: fw.offset}
+	valueIndex := 0
+	for start := 0; start < numRows; start += pageRows {
+		end := start + pageRows
+		if end > numRows {
+			end = numRows
+		}
+
+		var page bytes.Buffer
+		nonNull := end - start
+		if col.optional {
+			nonNull = 0
+			for _, isNull := range buf.nulls[start:end] {
+				if !isNull {
+					nonNull++
+				}
+			}
+			levels := encodeDefinitionLevels(buf.nulls[start:end])
+			_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+			page.Write(levels)
+		}
+		encodeValues(&page, col.typ, buf, valueIndex, valueIndex+nonNull)
+		valueIndex += nonNull
+
+		compressed, err := fw.compress(page.Bytes())
+		if err != nil {
+			return meta, err
+		}
+		header := pageHeader(end-start, page.Len(), len(compressed))
+		if err := fw.write(header); err != nil {
+			return meta, err
+		}
+		if err := fw.write(compressed); err != nil {
+			return meta, err
+		}
+		meta.uncompressedSize += int64(len(header) + page.Len())
+		meta.compressedSize += int64(len(header) + len(compressed))
+	}
+	return meta, nil
+}
+
+// encodeValues PLAIN encodes the non-null values in the [from, to) range.
+func encodeValues(page *bytes.Buffer, typ int32, buf *columnBuffer, from, to int) {
+	var b [8]byte
+	switch typ {
+	case typeInt64:
+		for _, v := range buf.int64s[from:to] {
+			binary.LittleEndian.PutUint64(b[:], uint64(v))
+			page.Write(b[:])
+		}
+	case typeDouble:
+		for _, v := range buf.float64s[from:to] {
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+			page.Write(b[:])
+		}
+	case typeByteArray:
+		for _, v := range buf.byteArrays[from:to] {
+			binary.LittleEndian.PutUint32(b[:4], uint32(len(v)))
+			page.Write(b[:4])
+			page.Write(v)
+		}
+	}
+}
+
+// encodeDefinitionLevels encodes the definition levels of an optional column
+// with the RLE/bit-packing hybrid encoding, as RLE runs of a bit width of 1.
+func encodeDefinitionLevels(nulls []bool) []byte {
+	var levels []byte
+	for i := 0; i < len(nulls); {
+		j := i + 1
+		for j < len(nulls) && nulls[j] == nulls[i] {
+			j++
+		}
+		levels = binary.AppendUvarint(levels, uint64(j-i)<<1)
+		if nulls[i] {
+			levels = append(levels, 0)
+		} else {
+			levels = append(levels, 1)
+		}
+		i = j
+	}
+	return levels
+}
+
+// pageHeader returns the PageHeader of a data page.
+func pageHeader(numValues, uncompressedSize, compressedSize int) []byte {
+	w := &thriftWriter{}
+	w.beginStruct()
+	w.i32(1, 0) // DATA_PAGE
+	w.i32(2, int32(uncompressedSize))
+	w.i32(3, int32(compressedSize))
+	w.structField(5, func() {
+		w.i32(1, int32(numValues))
+		w.i32(2, encodingPlain)
+		w.i32(3, encodingRLE)
+		w.i32(4, encodingRLE)
+	})
+	w.endStruct()
+	return w.buf
+}
+
+func (fw *fileWriter) compress(page []byte) ([]byte, error) {
+	switch fw.codec {
+	case CompressionSnappy:
+		return snappy.Encode(nil, page), nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(page); err != nil {
+			return nil, err
+		}
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		return fw.zstd.EncodeAll(page, nil), nil
+	default:
+		return page, nil
+	}
+}
+
+// close writes the footer of the file, it doesn't close the underlying writer.
+func (fw *fileWriter) close() error {
+	if fw.zstd != nil {
+		_ = fw.zstd.Close()
+	}
+	footer := fw.fileMetaData()
+	if err := fw.write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if err := fw.write(length[:]); err != nil {
+		return err
+	}
+	return fw.write([]byte(magic))
+}
+
+// fileMetaData returns the FileMetaData of the written row groups.
+func (fw *fileWriter) fileMetaData() []byte {
+	w := &thriftWriter{}
+	w.beginStruct()
+	w.i32(1, 1) // version
+	w.structList(2, len(fw.columns)+1, func(i int) {
+		if i == 0 {
+			w.string(4, "schema")
+			w.i32(5, int32(len(fw.columns)))
+			return
+		}
+		fw.schemaElement(w, fw.columns[i-1])
+	})
+	w.i64(3, fw.numRows)
+	w.structList(4, len(fw.rowGroups), func(i int) {
+		rg := fw.rowGroups[i]
+		var totalSize int64
+		for _, cc := range rg.columns {
+			totalSize += cc.uncompressedSize
+		}
+		w.structList(1, len(rg.columns), func(j int) {
+			fw.columnChunk(w, fw.columns[j], rg.columns[j])
+		})
+		w.i64(2, totalSize)
+		w.i64(3, rg.numRows)
+	})
+	w.string(6, fw.createdBy)
+	w.endStruct()
+	return w.buf
+}
+
+func (fw *fileWriter) schemaElement(w *thriftWriter, col column) {
+	w.i32(1, col.typ)
+	if col.optional {
+		w.i32(3, 1) // OPTIONAL
+	} else {
+		w.i32(3, 0) // REQUIRED
+	}
+	w.string(4, col.name)
+	switch col.logical {
+	case "string":
+		w.i32(6, convertedUTF8)
+		w.structField(10, func() {
+			w.structField(1, func() {})
+		})
+	case "json":
+		w.i32(6, convertedJSON)
+		w.structField(10, func() {
+			w.structField(12, func() {})
+		})
+	case "timestamp":
+		w.i32(6, convertedTimestampMicros)
+		w.structField(10, func() {
+			w.structField(8, func() {
+				w.bool(1, true) // isAdjustedToUTC
+				w.structField(2, func() {
+					w.structField(2, func() {}) // MICROS
+				})
+			})
+		})
+	}
+}
+
+func (fw *fileWriter) columnChunk(w *thriftWriter, col column, meta columnChunkMeta) {
+	w.i64(2, meta.dataPageOffset)
+	w.structField(3, func() {
+		w.i32(1, col.typ)
+		w.i32List(2, []int32{encodingPlain, encodingRLE})
+		w.stringList(3, []string{col.name})
+		w.i32(4, fw.codec.thriftCodec())
+		w.i64(5, meta.numValues)
+		w.i64(6, meta.uncompressedSize)
+		w.i64(7, meta.compressedSize)
+		w.i64(9, meta.dataPageOffset)
+	})
+}
@@ -0,0 +1,197 @@
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.k6.io/k6/metrics"
+)
+
+const namespace = "k6"
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`) //nolint:gochecknoglobals
+
+// collector aggregates the samples per time series and exposes them as
+// Prometheus metrics.
+type collector struct {
+	bufferedSamples func() []metrics.SampleContainer
+	trendBuckets    []float64
+
+	mu     sync.Mutex
+	series map[metrics.TimeSeries]*series
+}
+
+var _ prometheus.Collector = new(collector)
+
+func newCollector(bufferedSamples func() []metrics.SampleContainer, trendBuckets []float64) *collector {
+	return &collector{
+		bufferedSamples: bufferedSamples,
+		trendBuckets:    trendBuckets,
+		series:          make(map[metrics.TimeSeries]*series),
+	}
+}
+
+// Describe implements prometheus.Collector. It doesn't send any descriptor,
+// because the metrics and their labels are only known once the samples have
+// been received, so the collector is unchecked.
+func (*collector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.aggregate()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.series {
+		ch <- s.metric()
+	}
+}
+
+// aggregate drains the buffered samples into their time series.
+func (c *collector) aggregate() {
+	containers := c.bufferedSamples()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sc := range containers {
+		for _, sample := range sc.GetSamples() {
+			s, ok := c.series[sample.TimeSeries]
+			if !ok {
+				s = newSeries(sample.TimeSeries, c.trendBuckets)
+				c.series[sample.TimeSeries] = s
+			}
+			s.add(sample.Value)
+		}
+	}
+}
+
+// series is the aggregation of the samples of a single time series.
+type series struct {
+	desc      *prometheus.Desc
+	valueType metrics.MetricType
+	contains  metrics.ValueType
+
+	value   float64
+	count   uint64
+	bounds  []float64
+	buckets []uint64
+}
+
+func newSeries(ts metrics.TimeSeries, trendBuckets []float64) *series {
+	name := namespace + "_" + sanitizeName(ts.Metric.Name)
+	switch ts.Metric.Type {
+	case metrics.Counter:
+		name += "_total"
+	case metrics.Rate:
+		name += "_rate"
+	case metrics.Trend:
+		if unit := baseUnit(ts.Metric.Contains); unit != "" {
+			name += "_" + unit
+		}
+	case metrics.Gauge:
+	}
+
+	labels := make(prometheus.Labels)
+	for k, v := range ts.Tags.Map() {
+		if v != "" {
+			labels[sanitizeName(k)] = v
+		}
+	}
+
+	help := ts.Metric.Description
+	if help == "" {
+		help = fmt.Sprintf("The k6 %s %s metric.", ts.Metric.Name, ts.Metric.Type)
+	}
+	s := &series{
+		desc:      prometheus.NewDesc(name, help, nil, labels),
+		valueType: ts.Metric.Type,
+		contains:  ts.Metric.Contains,
+	}
+	if ts.Metric.Type == metrics.Trend {
+		s.bounds = trendBuckets
+		s.buckets = make([]uint64, len(trendBuckets))
+	}
+	return s
+}
+
+func (s *series) add(v float64) {
+	s.count++
+	switch s.valueType {
+	case metrics.Counter:
+		s.value += v
+	case metrics.Gauge:
+		s.value = v
+	case metrics.Rate:
+		if v != 0 {
+			s.value++
+		}
+	case metrics.Trend:
+		v = adaptUnit(s.contains, v)
+		s.value += v
+		for i, bound := range s.bounds {
+			if v <= bound {
+				s.buckets[i]++
+			}
+		}
+	}
+}
+
+func (s *series) metric() prometheus.Metric {
+	var (
+		m   prometheus.Metric
+		err error
+	)
+	switch s.valueType {
+	case metrics.Counter:
+		m, err = prometheus.NewConstMetric(s.desc, prometheus.CounterValue, s.value)
+	case metrics.Gauge:
+		m, err = prometheus.NewConstMetric(s.desc, prometheus.GaugeValue, s.value)
+	case metrics.Rate:
+		m, err = prometheus.NewConstMetric(s.desc, prometheus.GaugeValue, s.value/float64(s.count))
+	case metrics.Trend:
+		buckets := make(map[float64]uint64, len(s.bounds))
+		for i, bound := range s.bounds {
+			buckets[bound] = s.buckets[i]
+		}
+		m, err = prometheus.NewConstHistogram(s.desc, s.count, s.value, buckets)
+	}
+	if err != nil {
+		return prometheus.NewInvalidMetric(s.desc, err)
+	}
+	return m
+}
+
+// sanitizeName replaces the characters which aren't valid in the Prometheus'
+// metric and label names.
+func sanitizeName(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// baseUnit returns the unit suffix of the metric, following the Prometheus'
+// convention of using the base units.
+func baseUnit(vt metrics.ValueType) string {
+	switch vt {
+	case metrics.Time:
+		return "seconds"
+	case metrics.Data:
+		return "bytes"
+	default:
+		return ""
+	}
+}
+
+// adaptUnit converts the value to the base unit, k6 emits the times in
+// milliseconds and the data in bytes.
+func adaptUnit(vt metrics.ValueType, v float64) float64 {
+	if vt == metrics.Time {
+		return v / 1000
+	}
+	return v
+}
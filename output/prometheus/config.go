@@ -0,0 +1,126 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mstoykov/envconfig"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/guregu/null.v3"
+)
+
+// Config is the config for the Prometheus exposition endpoint output.
+type Config struct {
+	// Address is the host and port on which the endpoint listens.
+	Address null.String `json:"address" envconfig:"K6_PROMETHEUS_ADDRESS"`
+	// Path is the path of the endpoint.
+	Path null.String `json:"path" envconfig:"K6_PROMETHEUS_PATH"`
+	// TrendBuckets are the upper bounds of the Trend metrics' histogram
+	// buckets, in the base unit of the metric (e.g. seconds for the time metrics).
+	TrendBuckets []float64 `json:"trendBuckets" envconfig:"K6_PROMETHEUS_TREND_BUCKETS"`
+}
+
+// NewConfig creates a new Config instance with default values for some fields.
+func NewConfig() Config {
+	return Config{
+		Address:      null.NewString("localhost:5656", false),
+		Path:         null.NewString("/metrics", false),
+		TrendBuckets: prometheus.DefBuckets,
+	}
+}
+
+// Apply merges two configs by overwriting properties in the old config
+func (c Config) Apply(cfg Config) Config {
+	if cfg.Address.Valid {
+		c.Address = cfg.Address
+	}
+	if cfg.Path.Valid {
+		c.Path = cfg.Path
+	}
+	if len(cfg.TrendBuckets) > 0 {
+		c.TrendBuckets = cfg.TrendBuckets
+	}
+	return c
+}
+
+// Validate returns an error if the config can't be used to expose the metrics.
+func (c Config) Validate() error {
+	if c.Address.String == "" {
+		return errors.New("the address is required")
+	}
+	if !strings.HasPrefix(c.Path.String, "/") {
+		return fmt.Errorf("the path must start with a slash, got %q", c.Path.String)
+	}
+	for i := 1; i < len(c.TrendBuckets); i++ {
+		if c.TrendBuckets[i] <= c.TrendBuckets[i-1] {
+			return errors.New("the trend buckets must be in increasing order")
+		}
+	}
+	return nil
+}
+
+// ParseArg takes an arg string and converts it to a config, e.g.
+// "address=0.0.0.0:5656,path=/k6/metrics". A lone value without any key is
+// used as the address.
+func ParseArg(arg string) (Config, error) {
+	c := Config{}
+
+	if !strings.Contains(arg, "=") {
+		c.Address = null.StringFrom(arg)
+		return c, nil
+	}
+
+	for _, pair := range strings.Split(arg, ",") {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 {
+			return c, fmt.Errorf("couldn't parse %q as argument for prometheus output", arg)
+		}
+		switch r[0] {
+		case "address":
+			c.Address = null.StringFrom(r[1])
+		case "path":
+			c.Path = null.StringFrom(r[1])
+		default:
+			return c, fmt.Errorf("unknown key %q as argument for prometheus output", r[0])
+		}
+	}
+
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + arg config values}, and returns the final result.
+func GetConsolidatedConfig(
+	jsonRawConf json.RawMessage, env map[string]string, arg string,
+) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		// TODO: get rid of envconfig and actually use the env parameter...
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseArg(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.Validate()
+}
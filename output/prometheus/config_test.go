@@ -0,0 +1,71 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  Config
+		err     string
+	}{
+		"default": {
+			config: NewConfig(),
+		},
+		"consolidated": {
+			jsonRaw: json.RawMessage(`{"address":"json:5656","trendBuckets":[0.1,1]}`),
+			env:     map[string]string{"K6_PROMETHEUS_PATH": "/k6"},
+			arg:     "0.0.0.0:9000",
+			config: Config{
+				Address:      null.StringFrom("0.0.0.0:9000"),
+				Path:         null.StringFrom("/k6"),
+				TrendBuckets: []float64{0.1, 1},
+			},
+		},
+		"env buckets": {
+			env: map[string]string{"K6_PROMETHEUS_TREND_BUCKETS": "0.5,1,5"},
+			config: func() Config {
+				c := NewConfig()
+				c.TrendBuckets = []float64{0.5, 1, 5}
+				return c
+			}(),
+		},
+		"unsorted buckets": {
+			jsonRaw: json.RawMessage(`{"trendBuckets":[1,0.1]}`),
+			err:     "the trend buckets must be in increasing order",
+		},
+		"invalid path": {
+			arg: "path=metrics",
+			err: `the path must start with a slash, got "metrics"`,
+		},
+		"unknown key": {
+			arg: "address=localhost:5656,foo=bar",
+			err: `unknown key "foo"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := GetConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, config)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Package prometheus implements an output exposing the aggregated metrics of
+// a running test on an HTTP endpoint, in the Prometheus exposition format, so
+// they can be scraped by Prometheus or any compatible agent.
+//
+// The metrics are aggregated per time series from the start of the test, as
+// scrapers expect: counters are exposed as counters, gauges with their last
+// value, rates as the gauge of the ratio of the non-zero samples and trends
+// as histograms, in the metric's base unit.
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/output"
+)
+
+// aggregationInterval is how often the buffered samples are aggregated, they
+// are also aggregated on every scrape so the exposed values are up to date.
+const aggregationInterval = time.Second
+
+// Output implements the output.Output interface, exposing the metrics on an
+// HTTP endpoint.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	logger          logrus.FieldLogger
+	collector       *collector
+	listener        net.Listener
+	server          *http.Server
+	periodicFlusher *output.PeriodicFlusher
+}
+
+var _ output.Output = new(Output)
+
+// New creates a new instance of the Prometheus exposition endpoint output.
+func New(params output.Params) (output.Output, error) {
+	config, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Output{
+		config: config,
+		logger: params.Logger.WithField("output", "prometheus"),
+	}
+	o.collector = newCollector(o.GetBufferedSamples, config.TrendBuckets)
+	return o, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("prometheus (http://%s%s)", o.config.Address.String, o.config.Path.String)
+}
+
+// Start starts listening for the scrapes.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(o.collector); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(o.config.Path.String, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	listener, err := net.Listen("tcp", o.config.Address.String)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", o.config.Address.String, err)
+	}
+	o.listener = listener
+	o.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if serveErr := o.server.Serve(listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			o.logger.WithError(serveErr).Error("The metrics endpoint stopped unexpectedly")
+		}
+	}()
+
+	pf, err := output.NewPeriodicFlusher(aggregationInterval, o.collector.aggregate)
+	if err != nil {
+		return err
+	}
+	o.periodicFlusher = pf
+	o.logger.WithField("address", listener.Addr().String()).Debug("Started!")
+
+	return nil
+}
+
+// Stop stops listening for the scrapes.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return o.server.Close()
+}
@@ -0,0 +1,117 @@
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "address=127.0.0.1:0",
+		JSONConfig:     []byte(`{"trendBuckets":[0.1,1]}`),
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+	defer func() { require.NoError(t, out.Stop()) }()
+
+	o, ok := out.(*Output)
+	require.True(t, ok)
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	orders := registry.MustNewMetric("orders", metrics.Counter)
+	require.NoError(t, orders.Describe("The number of orders."))
+	tags := registry.RootTagSet().With("scenario", "default").With("my-tag", "value")
+	sample := func(metric *metrics.Metric, value float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+			Time:       time.Now(),
+			Value:      value,
+		}
+	}
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(builtin.Iterations, 1),
+		sample(builtin.Iterations, 1),
+		sample(builtin.VUs, 3),
+		sample(builtin.VUs, 5),
+		sample(builtin.Checks, 1),
+		sample(builtin.Checks, 0),
+		sample(builtin.HTTPReqDuration, 50),
+		sample(builtin.HTTPReqDuration, 500),
+		sample(builtin.HTTPReqDuration, 5000),
+		sample(orders, 3),
+	}})
+
+	// the samples are aggregated on scrape, without waiting for the periodic aggregation
+	resp, err := http.Get("http://" + o.listener.Addr().String() + "/metrics") //nolint:noctx
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		"# HELP k6_iterations_total The k6 iterations counter metric.",
+		"# TYPE k6_iterations_total counter",
+		`k6_iterations_total{my_tag="value",scenario="default"} 2`,
+		"# TYPE k6_vus gauge",
+		`k6_vus{my_tag="value",scenario="default"} 5`,
+		`k6_checks_rate{my_tag="value",scenario="default"} 0.5`,
+		"# TYPE k6_http_req_duration_seconds histogram",
+		`k6_http_req_duration_seconds_bucket{my_tag="value",scenario="default",le="0.1"} 1`,
+		`k6_http_req_duration_seconds_bucket{my_tag="value",scenario="default",le="1"} 2`,
+		`k6_http_req_duration_seconds_bucket{my_tag="value",scenario="default",le="+Inf"} 3`,
+		`k6_http_req_duration_seconds_sum{my_tag="value",scenario="default"} 5.55`,
+		`k6_http_req_duration_seconds_count{my_tag="value",scenario="default"} 3`,
+		"# HELP k6_orders_total The number of orders.",
+		`k6_orders_total{my_tag="value",scenario="default"} 3`,
+	} {
+		assert.Contains(t, string(body), line+"\n")
+	}
+}
+
+func TestOutputInconsistentLabels(t *testing.T) {
+	t.Parallel()
+
+	c := newCollector(func() []metrics.SampleContainer { return nil }, []float64{1})
+	registry := metrics.NewRegistry()
+	metric := registry.MustNewMetric("test_counter", metrics.Counter)
+	for _, tags := range []*metrics.TagSet{
+		registry.RootTagSet().With("status", "200"),
+		registry.RootTagSet().With("status", "0").With("error_code", "1211"),
+	} {
+		c.bufferedSamples = func() []metrics.SampleContainer {
+			return []metrics.SampleContainer{metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+				Value:      1,
+			}}
+		}
+		c.aggregate()
+	}
+
+	promRegistry := prometheusRegistry(t, c)
+	families, err := promRegistry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Len(t, families[0].Metric, 2)
+}
+
+func prometheusRegistry(t *testing.T, c *collector) *prom.Registry {
+	t.Helper()
+	r := prom.NewRegistry()
+	require.NoError(t, r.Register(c))
+	return r
+}
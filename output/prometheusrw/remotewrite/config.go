@@ -3,15 +3,18 @@ package remotewrite
 import (
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/grafana/xk6-output-prometheus-remote/pkg/remote"
-	"go.k6.io/k6/lib/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/output/prometheusrw/remote"
 )
 
 const (
@@ -19,6 +22,10 @@ const (
 	defaultTimeout      = 5 * time.Second
 	defaultPushInterval = 5 * time.Second
 	defaultMetricPrefix = "k6_"
+
+	// defaultNativeHistogramBucketFactor is the starting value suggested by Prometheus.
+	// It sounds good considering the general purpose it has to address.
+	defaultNativeHistogramBucketFactor = 1.1
 )
 
 //nolint:gochecknoglobals
@@ -62,11 +69,27 @@ type Config struct {
 	// should map to a Prometheus' Native Histogram.
 	TrendAsNativeHistogram null.Bool `json:"trendAsNativeHistogram"`
 
+	// NativeHistogramBucketFactor is the maximum growth factor between the boundaries
+	// of two consecutive buckets of the Native Histograms. A lower value means a higher
+	// resolution, it must be greater than 1.
+	NativeHistogramBucketFactor null.Float `json:"nativeHistogramBucketFactor"`
+
+	// NativeHistogramMaxBuckets limits the number of populated buckets of a Native Histogram,
+	// the resolution is reduced when the limit is exceeded. Zero means no limit.
+	NativeHistogramMaxBuckets null.Int `json:"nativeHistogramMaxBuckets"`
+
+	// NativeHistogramZeroThreshold is the width of the Native Histograms' zero bucket,
+	// in the base unit of the metric (e.g. seconds for the time metrics).
+	NativeHistogramZeroThreshold null.Float `json:"nativeHistogramZeroThreshold"`
+
 	// TrendStats defines the stats to flush for Trend metrics.
 	//
 	// TODO: should we support K6_SUMMARY_TREND_STATS?
 	TrendStats []string `json:"trendStats"`
 
+	// StaleMarkers defines if all the seen time series should be marked as stale
+	// when the test ends, so they don't continue to be returned by the queries
+	// for the next 5 minutes, as they would be by the Prometheus' lookback period.
 	StaleMarkers null.Bool `json:"staleMarkers"`
 }
 
@@ -80,7 +103,11 @@ func NewConfig() Config {
 		PushInterval:          types.NullDurationFrom(defaultPushInterval),
 		Headers:               make(map[string]string),
 		TrendStats:            defaultTrendStats,
-		StaleMarkers:          null.BoolFrom(false),
+		StaleMarkers:          null.BoolFrom(true),
+
+		NativeHistogramBucketFactor:  null.NewFloat(defaultNativeHistogramBucketFactor, false),
+		NativeHistogramMaxBuckets:    null.NewInt(0, false),
+		NativeHistogramZeroThreshold: null.NewFloat(prometheus.DefNativeHistogramZeroThreshold, false),
 	}
 }
 
@@ -157,6 +184,18 @@ func (conf Config) Apply(applied Config) Config {
 		conf.TrendAsNativeHistogram = applied.TrendAsNativeHistogram
 	}
 
+	if applied.NativeHistogramBucketFactor.Valid {
+		conf.NativeHistogramBucketFactor = applied.NativeHistogramBucketFactor
+	}
+
+	if applied.NativeHistogramMaxBuckets.Valid {
+		conf.NativeHistogramMaxBuckets = applied.NativeHistogramMaxBuckets
+	}
+
+	if applied.NativeHistogramZeroThreshold.Valid {
+		conf.NativeHistogramZeroThreshold = applied.NativeHistogramZeroThreshold
+	}
+
 	if applied.StaleMarkers.Valid {
 		conf.StaleMarkers = applied.StaleMarkers
 	}
@@ -217,7 +256,43 @@ func GetConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, _
 	//result = result.Apply(urlConf)
 	//}
 
-	return result, nil
+	return result, result.validate()
+}
+
+// validate returns an error if the Native Histograms' layout is invalid.
+func (conf Config) validate() error {
+	if conf.NativeHistogramBucketFactor.Float64 <= 1 {
+		return fmt.Errorf("the native histogram bucket factor must be greater than 1, got %v",
+			conf.NativeHistogramBucketFactor.Float64)
+	}
+	if conf.NativeHistogramMaxBuckets.Int64 < 0 {
+		return errors.New("the native histogram max buckets can't be negative")
+	}
+	if conf.NativeHistogramZeroThreshold.Float64 < 0 {
+		return errors.New("the native histogram zero threshold can't be negative")
+	}
+	return nil
+}
+
+// nativeHistogramLayout returns the buckets' layout of the Native Histograms.
+func (conf Config) nativeHistogramLayout() nativeHistogramLayout {
+	return nativeHistogramLayout{
+		bucketFactor:  conf.NativeHistogramBucketFactor.Float64,
+		maxBuckets:    uint32(conf.NativeHistogramMaxBuckets.Int64),
+		zeroThreshold: conf.NativeHistogramZeroThreshold.Float64,
+	}
+}
+
+func envFloat(env map[string]string, name string) (null.Float, error) {
+	if v, vDefined := env[name]; vDefined {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return null.NewFloat(0, false), err
+		}
+
+		return null.FloatFrom(f), nil
+	}
+	return null.NewFloat(0, false), nil
 }
 
 func envBool(env map[string]string, name string) (null.Bool, error) {
@@ -305,6 +380,24 @@ func parseEnvs(env map[string]string) (Config, error) {
 		c.TrendAsNativeHistogram = b
 	}
 
+	if f, err := envFloat(env, "K6_PROMETHEUS_RW_NATIVE_HISTOGRAM_BUCKET_FACTOR"); err != nil {
+		return c, err
+	} else if f.Valid {
+		c.NativeHistogramBucketFactor = f
+	}
+
+	if maxBuckets, maxBucketsDefined := env["K6_PROMETHEUS_RW_NATIVE_HISTOGRAM_MAX_BUCKETS"]; maxBucketsDefined {
+		if err := c.NativeHistogramMaxBuckets.UnmarshalText([]byte(maxBuckets)); err != nil {
+			return c, err
+		}
+	}
+
+	if f, err := envFloat(env, "K6_PROMETHEUS_RW_NATIVE_HISTOGRAM_ZERO_THRESHOLD"); err != nil {
+		return c, err
+	} else if f.Valid {
+		c.NativeHistogramZeroThreshold = f
+	}
+
 	if b, err := envBool(env, "K6_PROMETHEUS_RW_STALE_MARKERS"); err != nil {
 		return c, err
 	} else if b.Valid {
@@ -355,6 +448,22 @@ func parseArg(text string) (Config, error) {
 			if err := c.TrendAsNativeHistogram.UnmarshalText([]byte(v)); err != nil {
 				return c, fmt.Errorf("trendAsNativeHistogram value must be true or false, not %q", v)
 			}
+		case "nativeHistogramBucketFactor":
+			if err := c.NativeHistogramBucketFactor.UnmarshalText([]byte(v)); err != nil {
+				return c, fmt.Errorf("nativeHistogramBucketFactor value must be a number, not %q", v)
+			}
+		case "nativeHistogramMaxBuckets":
+			if err := c.NativeHistogramMaxBuckets.UnmarshalText([]byte(v)); err != nil {
+				return c, fmt.Errorf("nativeHistogramMaxBuckets value must be an integer, not %q", v)
+			}
+		case "nativeHistogramZeroThreshold":
+			if err := c.NativeHistogramZeroThreshold.UnmarshalText([]byte(v)); err != nil {
+				return c, fmt.Errorf("nativeHistogramZeroThreshold value must be a number, not %q", v)
+			}
+		case "staleMarkers":
+			if err := c.StaleMarkers.UnmarshalText([]byte(v)); err != nil {
+				return c, fmt.Errorf("staleMarkers value must be true or false, not %q", v)
+			}
 
 		// TODO: add the support for trendStats
 		// strvals doesn't support the same format used by --summary-trend-stats
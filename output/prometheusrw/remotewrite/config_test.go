@@ -0,0 +1,97 @@
+package remotewrite
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		config  func(c *Config)
+		err     string
+	}{
+		"default": {
+			config: func(*Config) {},
+		},
+		"native histogram layout": {
+			jsonRaw: json.RawMessage(`{"trendAsNativeHistogram":true,"nativeHistogramBucketFactor":2,"pushInterval":"1s"}`),
+			env: map[string]string{
+				"K6_PROMETHEUS_RW_NATIVE_HISTOGRAM_MAX_BUCKETS":    "160",
+				"K6_PROMETHEUS_RW_NATIVE_HISTOGRAM_ZERO_THRESHOLD": "0.001",
+				"K6_PROMETHEUS_RW_STALE_MARKERS":                   "false",
+			},
+			config: func(c *Config) {
+				c.TrendAsNativeHistogram = null.BoolFrom(true)
+				c.NativeHistogramBucketFactor = null.FloatFrom(2)
+				c.NativeHistogramMaxBuckets = null.IntFrom(160)
+				c.NativeHistogramZeroThreshold = null.FloatFrom(0.001)
+				c.StaleMarkers = null.BoolFrom(false)
+				c.PushInterval = types.NullDurationFrom(time.Second)
+			},
+		},
+		"invalid bucket factor": {
+			env: map[string]string{"K6_PROMETHEUS_RW_NATIVE_HISTOGRAM_BUCKET_FACTOR": "1"},
+			err: "the native histogram bucket factor must be greater than 1",
+		},
+		"negative max buckets": {
+			jsonRaw: json.RawMessage(`{"nativeHistogramMaxBuckets":-1}`),
+			err:     "the native histogram max buckets can't be negative",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := GetConsolidatedConfig(tc.jsonRaw, tc.env, "")
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			expected := NewConfig()
+			tc.config(&expected)
+			assert.Equal(t, expected, config)
+		})
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	t.Parallel()
+
+	c := NewConfig()
+	assert.True(t, c.StaleMarkers.Bool)
+	assert.Equal(t, nativeHistogramLayout{
+		bucketFactor:  1.1,
+		zeroThreshold: prometheus.DefNativeHistogramZeroThreshold,
+	}, c.nativeHistogramLayout())
+}
+
+func TestParseArg(t *testing.T) {
+	t.Parallel()
+
+	c, err := parseArg("url=http://prometheus:9090/api/v1/write,nativeHistogramBucketFactor=1.5," +
+		"nativeHistogramMaxBuckets=100,staleMarkers=false")
+	require.NoError(t, err)
+	assert.Equal(t, null.StringFrom("http://prometheus:9090/api/v1/write"), c.ServerURL)
+	assert.Equal(t, null.FloatFrom(1.5), c.NativeHistogramBucketFactor)
+	assert.Equal(t, null.IntFrom(100), c.NativeHistogramMaxBuckets)
+	assert.Equal(t, null.BoolFrom(false), c.StaleMarkers)
+
+	_, err = parseArg("nativeHistogramBucketFactor=high")
+	assert.ErrorContains(t, err, "nativeHistogramBucketFactor value must be a number")
+}
@@ -5,6 +5,7 @@ import (
 
 	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
 	"github.com/mstoykov/atlas"
+
 	"go.k6.io/k6/metrics"
 )
 
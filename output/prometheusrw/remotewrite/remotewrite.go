@@ -1,4 +1,8 @@
 // Package remotewrite is a k6 output that sends metrics to a Prometheus remote write endpoint.
+//
+// It was originally developed in github.com/grafana/xk6-output-prometheus-remote,
+// and like the rest of the output/prometheusrw packages, it's licensed under
+// the GNU AGPL v3, as it was there. See output/prometheusrw/LICENSE.
 package remotewrite
 
 import (
@@ -7,14 +11,13 @@ import (
 	"strings"
 	"time"
 
-	"github.com/grafana/xk6-output-prometheus-remote/pkg/remote"
-	"github.com/grafana/xk6-output-prometheus-remote/pkg/stale"
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	"github.com/sirupsen/logrus"
 
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/output"
-
-	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
-	"github.com/sirupsen/logrus"
+	"go.k6.io/k6/output/prometheusrw/remote"
+	"go.k6.io/k6/output/prometheusrw/stale"
 )
 
 var _ output.Output = new(Output)
@@ -131,12 +134,15 @@ func (o *Output) staleMarkers() []*prompb.TimeSeries {
 		// the unique exception where more than 1 is expected is when
 		// trend stats have been configured with multiple values.
 		for _, s := range series {
+			if len(s.Histograms) > 0 {
+				// a Native Histogram is marked as stale by its sum,
+				// it can't be mixed with a float sample in the same series
+				s.Histograms = []*prompb.Histogram{stale.HistogramMarker(timestamp)}
+				continue
+			}
 			if len(s.Samples) < 1 {
-				if len(s.Histograms) < 1 {
-					panic("data integrity check: samples and native histograms" +
-						" can't be empty at the same time")
-				}
-				s.Samples = append(s.Samples, &prompb.Sample{})
+				panic("data integrity check: samples and native histograms" +
+					" can't be empty at the same time")
 			}
 
 			s.Samples[0].Value = stale.Marker
@@ -254,7 +260,8 @@ func (o *Output) convertToPbSeries(samplesContainers []metrics.SampleContainer)
 			swm, ok := o.tsdb[sample.TimeSeries]
 			if !ok {
 				// TODO: encapsulate the trend arguments into a Trend Mapping factory
-				swm = newSeriesWithMeasure(sample.TimeSeries, o.config.TrendAsNativeHistogram.Bool, o.trendStatsResolver)
+				swm = newSeriesWithMeasure(sample.TimeSeries, o.config.TrendAsNativeHistogram.Bool,
+					o.config.nativeHistogramLayout(), o.trendStatsResolver)
 				swm.Latest = truncTime
 				o.tsdb[sample.TimeSeries] = swm
 				seen[sample.TimeSeries] = struct{}{}
@@ -374,6 +381,7 @@ type prompbMapper interface {
 func newSeriesWithMeasure(
 	series metrics.TimeSeries,
 	trendAsNativeHistogram bool,
+	layout nativeHistogramLayout,
 	tsr TrendStatsResolver,
 ) *seriesWithMeasure {
 	var sink metrics.Sink
@@ -385,7 +393,7 @@ func newSeriesWithMeasure(
 	case metrics.Trend:
 		// TODO: refactor encapsulating in a factory method
 		if trendAsNativeHistogram {
-			sink = newNativeHistogramSink(series.Metric)
+			sink = newNativeHistogramSink(series.Metric, layout)
 		} else {
 			var err error
 			sink, err = newExtendedTrendSink(tsr)
@@ -0,0 +1,119 @@
+package remotewrite
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+	"go.k6.io/k6/output/prometheusrw/stale"
+)
+
+func TestOutputStaleMarkers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests []*prompb.WriteRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compressed, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		body, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		req := &prompb.WriteRequest{}
+		require.NoError(t, proto.Unmarshal(body, req))
+
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	o, err := New(output.Params{
+		Logger: testutils.NewLogger(t),
+		Environment: map[string]string{
+			"K6_PROMETHEUS_RW_SERVER_URL":                srv.URL,
+			"K6_PROMETHEUS_RW_TREND_AS_NATIVE_HISTOGRAM": "true",
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("test_counter", metrics.Counter)
+	trend := registry.MustNewMetric("test_trend", metrics.Trend, metrics.Time)
+	now := time.Now()
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		{TimeSeries: metrics.TimeSeries{Metric: counter, Tags: registry.RootTagSet()}, Time: now, Value: 1},
+		{TimeSeries: metrics.TimeSeries{Metric: trend, Tags: registry.RootTagSet()}, Time: now, Value: 10},
+	}})
+	o.flush()
+	require.NoError(t, o.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 2)
+
+	markers := requests[1].Timeseries
+	require.Len(t, markers, 2)
+	for _, ts := range markers {
+		switch ts.Labels[0].Value {
+		case "k6_test_counter_total":
+			require.Len(t, ts.Samples, 1)
+			assert.Empty(t, ts.Histograms)
+			assert.Equal(t, math.Float64bits(stale.Marker), math.Float64bits(ts.Samples[0].Value))
+			assert.Greater(t, ts.Samples[0].Timestamp, now.UnixMilli())
+		case "k6_test_trend_seconds":
+			require.Len(t, ts.Histograms, 1)
+			assert.Empty(t, ts.Samples)
+			assert.Equal(t, math.Float64bits(stale.Marker), math.Float64bits(ts.Histograms[0].Sum))
+			assert.Zero(t, ts.Histograms[0].GetCountInt())
+		default:
+			t.Errorf("unexpected time series %s", ts.Labels[0].Value)
+		}
+	}
+}
+
+func TestOutputWithoutStaleMarkers(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	o, err := New(output.Params{
+		Logger: testutils.NewLogger(t),
+		Environment: map[string]string{
+			"K6_PROMETHEUS_RW_SERVER_URL":    srv.URL,
+			"K6_PROMETHEUS_RW_STALE_MARKERS": "false",
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("test_counter", metrics.Counter)
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: counter, Tags: registry.RootTagSet()}, Time: time.Now(), Value: 1,
+	}})
+	require.NoError(t, o.Stop())
+	assert.Equal(t, int64(1), requests.Load())
+}
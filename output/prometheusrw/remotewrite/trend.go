@@ -8,6 +8,7 @@ import (
 	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+
 	"go.k6.io/k6/metrics"
 )
 
@@ -118,20 +119,29 @@ func (tg *trendAsGauges) CacheNameIndex() {
 	}
 }
 
+// nativeHistogramLayout defines the buckets of the Native Histograms.
+type nativeHistogramLayout struct {
+	bucketFactor  float64
+	maxBuckets    uint32
+	zeroThreshold float64
+}
+
 type nativeHistogramSink struct {
 	H prometheus.Histogram
 }
 
-func newNativeHistogramSink(m *metrics.Metric) *nativeHistogramSink {
+func newNativeHistogramSink(m *metrics.Metric, layout nativeHistogramLayout) *nativeHistogramSink {
+	zeroThreshold := layout.zeroThreshold
+	if zeroThreshold == 0 {
+		// zero is used by the Prometheus' library for applying the default
+		zeroThreshold = prometheus.NativeHistogramZeroThresholdZero
+	}
 	return &nativeHistogramSink{
 		H: prometheus.NewHistogram(prometheus.HistogramOpts{
-			Name: m.Name,
-			// 1.1 is the starting value suggested by Prometheus'
-			// It sounds good considering the general purpose
-			// it have to address.
-			// In the future, we could consider to add more tuning
-			// if it will be required.
-			NativeHistogramBucketFactor: 1.1,
+			Name:                           m.Name,
+			NativeHistogramBucketFactor:    layout.bucketFactor,
+			NativeHistogramMaxBucketNumber: layout.maxBuckets,
+			NativeHistogramZeroThreshold:   zeroThreshold,
 		}),
 	}
 }
@@ -140,8 +150,8 @@ func (sink *nativeHistogramSink) Add(s metrics.Sample) {
 	// The Prometheus' convention is to use seconds
 	// as time unit.
 	//
-	// It isn't a requirement but having the current factor fixed to 1.1 then
-	// have seconds is beneficial for having a better resolution.
+	// It isn't a requirement but with the default factor of 1.1,
+	// having seconds is beneficial for having a better resolution.
 	//
 	// The assumption is that an higher precision is required
 	// in case of under-second and more relaxed in case of higher values.
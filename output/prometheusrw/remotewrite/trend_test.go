@@ -0,0 +1,58 @@
+package remotewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestNativeHistogramSinkLayout(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metric := registry.MustNewMetric("test_duration", metrics.Trend, metrics.Time)
+	series := metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()}
+
+	mapHistogram := func(t *testing.T, layout nativeHistogramLayout, values ...float64) (schema int32, zeroCount uint64) {
+		sink := newNativeHistogramSink(metric, layout)
+		for _, v := range values {
+			sink.Add(metrics.Sample{TimeSeries: series, Value: v})
+		}
+		pbseries := sink.MapPrompb(series, time.Now())
+		require.Len(t, pbseries, 1)
+		require.Len(t, pbseries[0].Histograms, 1)
+		h := pbseries[0].Histograms[0]
+		return h.Schema, h.GetZeroCountInt()
+	}
+
+	t.Run("bucket factor", func(t *testing.T) {
+		t.Parallel()
+
+		schema, _ := mapHistogram(t, nativeHistogramLayout{bucketFactor: 1.1}, 100)
+		assert.Equal(t, int32(3), schema)
+		schema, _ = mapHistogram(t, nativeHistogramLayout{bucketFactor: 2}, 100)
+		assert.Equal(t, int32(0), schema)
+	})
+
+	t.Run("max buckets", func(t *testing.T) {
+		t.Parallel()
+
+		values := []float64{1, 10, 100, 1000, 10000, 100000}
+		schema, _ := mapHistogram(t, nativeHistogramLayout{bucketFactor: 1.1, maxBuckets: 2}, values...)
+		assert.Less(t, schema, int32(3), "the resolution should have been reduced")
+	})
+
+	t.Run("zero threshold", func(t *testing.T) {
+		t.Parallel()
+
+		// the values are in ms, so 0.5ms is 0.0005s and it's below the 0.001s threshold
+		_, zeroCount := mapHistogram(t, nativeHistogramLayout{bucketFactor: 1.1, zeroThreshold: 0.001}, 0.5, 2)
+		assert.Equal(t, uint64(1), zeroCount)
+		_, zeroCount = mapHistogram(t, nativeHistogramLayout{bucketFactor: 1.1}, 0.5, 2)
+		assert.Equal(t, uint64(0), zeroCount)
+	})
+}
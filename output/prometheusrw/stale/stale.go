@@ -4,7 +4,11 @@
 // from the remote write package.
 package stale
 
-import "math"
+import (
+	"math"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+)
 
 // Marker is the Prometheus Remote Write special value for marking
 // a time series as stale.
@@ -22,3 +26,16 @@ import "math"
 //
 //nolint:gochecknoglobals
 var Marker = math.Float64frombits(0x7ff0000000000002)
+
+// HistogramMarker returns a Native Histogram marking its time series
+// as stale at the provided timestamp, in milliseconds.
+//
+// Prometheus considers a Native Histogram stale when its sum is the Marker.
+func HistogramMarker(timestamp int64) *prompb.Histogram {
+	return &prompb.Histogram{
+		Count:     &prompb.Histogram_CountInt{CountInt: 0},
+		ZeroCount: &prompb.Histogram_ZeroCountInt{ZeroCountInt: 0},
+		Sum:       Marker,
+		Timestamp: timestamp,
+	}
+}
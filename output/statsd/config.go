@@ -2,6 +2,7 @@ package statsd
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/mstoykov/envconfig"
@@ -19,8 +20,29 @@ type config struct {
 	PushInterval types.NullDuration  `json:"pushInterval,omitempty" envconfig:"K6_STATSD_PUSH_INTERVAL"`
 	TagBlocklist metrics.EnabledTags `json:"tagBlocklist,omitempty" envconfig:"K6_STATSD_TAG_BLOCKLIST"`
 	EnableTags   null.Bool           `json:"enableTags,omitempty" envconfig:"K6_STATSD_ENABLE_TAGS"`
+	// TrendType is the StatsD type used for the Trend metrics: timing, histogram or distribution.
+	TrendType null.String `json:"trendType,omitempty" envconfig:"K6_STATSD_TREND_TYPE"`
+	// TagCardinalityLimit is the maximum number of distinct values sent for
+	// every tag, the values over it are replaced with other. Zero means no limit.
+	TagCardinalityLimit null.Int `json:"tagCardinalityLimit,omitempty" envconfig:"K6_STATSD_TAG_CARDINALITY_LIMIT"`
+	// EntityID is sent as a tag with every metric, so DogStatsD can detect the
+	// container they originate from, e.g. the pod's UID in Kubernetes.
+	EntityID null.String `json:"entityId,omitempty" envconfig:"DD_ENTITY_ID"`
 }
 
+// The supported StatsD types for the Trend metrics.
+const (
+	trendTypeTiming       = "timing"
+	trendTypeHistogram    = "histogram"
+	trendTypeDistribution = "distribution"
+)
+
+// entityIDTag is the tag used by DogStatsD for the origin detection.
+const entityIDTag = "dd.internal.entity_id"
+
+// overflowTagValue replaces the tag values over the cardinality limit.
+const overflowTagValue = "other"
+
 func processTags(t metrics.EnabledTags, tags map[string]string) []string {
 	var res []string
 	for key, value := range tags {
@@ -51,6 +73,15 @@ func (c config) Apply(cfg config) config {
 	if cfg.EnableTags.Valid {
 		c.EnableTags = cfg.EnableTags
 	}
+	if cfg.TrendType.Valid {
+		c.TrendType = cfg.TrendType
+	}
+	if cfg.TagCardinalityLimit.Valid {
+		c.TagCardinalityLimit = cfg.TagCardinalityLimit
+	}
+	if cfg.EntityID.Valid {
+		c.EntityID = cfg.EntityID
+	}
 
 	return c
 }
@@ -64,13 +95,41 @@ func newConfig() config {
 		PushInterval: types.NewNullDuration(1*time.Second, false),
 		TagBlocklist: metrics.SystemTagSet(metrics.TagVU | metrics.TagIter | metrics.TagURL).Map(),
 		EnableTags:   null.NewBool(false, false),
+		TrendType:    null.NewString(trendTypeTiming, false),
+
+		TagCardinalityLimit: null.NewInt(0, false),
+		EntityID:            null.NewString("", false),
+	}
+}
+
+// newDatadogConfig creates a new Config instance with the default values for
+// DogStatsD, which supports the tags and the distributions.
+func newDatadogConfig() config {
+	c := newConfig()
+	c.EnableTags = null.NewBool(true, false)
+	c.TrendType = null.NewString(trendTypeDistribution, false)
+	return c
+}
+
+func (c config) validate() error {
+	switch c.TrendType.String {
+	case trendTypeTiming, trendTypeHistogram, trendTypeDistribution:
+	default:
+		return fmt.Errorf("unsupported trend type %q, it must be %s, %s or %s",
+			c.TrendType.String, trendTypeTiming, trendTypeHistogram, trendTypeDistribution)
+	}
+	if c.TagCardinalityLimit.Int64 < 0 {
+		return fmt.Errorf("the tag cardinality limit can't be negative, got %d", c.TagCardinalityLimit.Int64)
 	}
+	return nil
 }
 
 // getConsolidatedConfig combines {default config values + JSON config +
 // environment vars}, and returns the final result.
-func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, _ string) (config, error) {
-	result := newConfig()
+func getConsolidatedConfig(
+	defaults config, jsonRawConf json.RawMessage, env map[string]string, _ string,
+) (config, error) {
+	result := defaults
 	if jsonRawConf != nil {
 		jsonConf := config{}
 		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
@@ -89,5 +148,5 @@ func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, _
 	}
 	result = result.Apply(envConfig)
 
-	return result, nil
+	return result, result.validate()
 }
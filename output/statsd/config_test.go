@@ -0,0 +1,75 @@
+package statsd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		defaults config
+		jsonRaw  json.RawMessage
+		env      map[string]string
+		config   func(c *config)
+		err      string
+	}{
+		"default": {
+			defaults: newConfig(),
+			config:   func(*config) {},
+		},
+		"datadog defaults": {
+			defaults: newDatadogConfig(),
+			config: func(c *config) {
+				c.EnableTags = null.NewBool(true, false)
+				c.TrendType = null.NewString(trendTypeDistribution, false)
+			},
+		},
+		"consolidated": {
+			defaults: newConfig(),
+			jsonRaw:  json.RawMessage(`{"trendType":"histogram","tagCardinalityLimit":10}`),
+			env: map[string]string{
+				"K6_STATSD_TREND_TYPE": "distribution",
+				"DD_ENTITY_ID":         "3b1d5e4c-pod-uid",
+			},
+			config: func(c *config) {
+				c.TrendType = null.StringFrom(trendTypeDistribution)
+				c.TagCardinalityLimit = null.IntFrom(10)
+				c.EntityID = null.StringFrom("3b1d5e4c-pod-uid")
+			},
+		},
+		"invalid trend type": {
+			defaults: newConfig(),
+			env:      map[string]string{"K6_STATSD_TREND_TYPE": "set"},
+			err:      `unsupported trend type "set"`,
+		},
+		"negative cardinality limit": {
+			defaults: newConfig(),
+			jsonRaw:  json.RawMessage(`{"tagCardinalityLimit":-1}`),
+			err:      "the tag cardinality limit can't be negative",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := getConsolidatedConfig(tc.defaults, tc.jsonRaw, tc.env, "")
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			expected := newConfig()
+			tc.config(&expected)
+			assert.Equal(t, expected, c)
+		})
+	}
+}
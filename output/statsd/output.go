@@ -13,20 +13,30 @@ import (
 
 // New creates a new statsd connector client
 func New(params output.Params) (output.Output, error) {
-	return newOutput(params)
+	return newOutput(params, newConfig())
 }
 
-func newOutput(params output.Params) (*Output, error) {
-	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+// NewDatadog creates a new statsd connector client with the defaults for
+// DogStatsD, i.e. with the tags enabled and the trends sent as distributions.
+func NewDatadog(params output.Params) (output.Output, error) {
+	return newOutput(params, newDatadogConfig())
+}
+
+func newOutput(params output.Params, defaults config) (*Output, error) {
+	conf, err := getConsolidatedConfig(defaults, params.JSONConfig, params.Environment, params.ConfigArgument)
 	if err != nil {
 		return nil, err
 	}
 	logger := params.Logger.WithFields(logrus.Fields{"output": "statsd"})
 
-	return &Output{
+	o := &Output{
 		config: conf,
 		logger: logger,
-	}, nil
+	}
+	if limit := conf.TagCardinalityLimit.Int64; limit > 0 {
+		o.tagLimiter = newTagLimiter(int(limit), logger)
+	}
+	return o, nil
 }
 
 var _ output.Output = &Output{}
@@ -39,21 +49,33 @@ type Output struct {
 
 	config config
 
-	logger logrus.FieldLogger
-	client *statsd.Client
+	logger     logrus.FieldLogger
+	client     *statsd.Client
+	tagLimiter *tagLimiter
 }
 
 func (o *Output) dispatch(entry metrics.Sample) error {
 	var tagList []string
 	if o.config.EnableTags.Bool {
-		tagList = processTags(o.config.TagBlocklist, entry.Tags.Map())
+		tags := entry.Tags.Map()
+		if o.tagLimiter != nil {
+			tags = o.tagLimiter.limit(o.config.TagBlocklist, tags)
+		}
+		tagList = processTags(o.config.TagBlocklist, tags)
 	}
 
 	switch entry.Metric.Type {
 	case metrics.Counter:
 		return o.client.Count(entry.Metric.Name, int64(entry.Value), tagList, 1)
 	case metrics.Trend:
-		return o.client.TimeInMilliseconds(entry.Metric.Name, entry.Value, tagList, 1)
+		switch o.config.TrendType.String {
+		case trendTypeDistribution:
+			return o.client.Distribution(entry.Metric.Name, entry.Value, tagList, 1)
+		case trendTypeHistogram:
+			return o.client.Histogram(entry.Metric.Name, entry.Value, tagList, 1)
+		default:
+			return o.client.TimeInMilliseconds(entry.Metric.Name, entry.Value, tagList, 1)
+		}
 	case metrics.Gauge:
 		return o.client.Gauge(entry.Metric.Name, entry.Value, tagList, 1)
 	case metrics.Rate:
@@ -110,6 +132,9 @@ func (o *Output) Start() error {
 	if namespace := o.config.Namespace.String; namespace != "" {
 		o.client.Namespace = namespace
 	}
+	if entityID := o.config.EntityID.String; entityID != "" {
+		o.client.Tags = append(o.client.Tags, entityIDTag+":"+entityID)
+	}
 
 	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
 	if err != nil {
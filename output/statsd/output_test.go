@@ -3,8 +3,11 @@ package statsd
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -29,7 +32,7 @@ func getOutput(
 			"bufferSize": %d,
 			"pushInterval": "%s"
 		}`, addr.String, namespace.String, bufferSize.Int64, pushInterval.Duration.String())),
-		})
+		}, newConfig())
 }
 
 func TestStatsdOutput(t *testing.T) {
@@ -58,7 +61,7 @@ func TestStatsdEnabledTags(t *testing.T) {
 			"tagBlocklist": ["tag1", "tag2"],
 			"enableTags": true
 		}`, addr.String, namespace.String, bufferSize.Int64, pushInterval.Duration.String())),
-			})
+			}, newConfig())
 	}, func(t *testing.T, containers []metrics.SampleContainer, expectedOutput, output string) {
 		outputLines := strings.Split(output, "\n")
 		expectedOutputLines := strings.Split(expectedOutput, "\n")
@@ -119,3 +122,79 @@ func TestLinkReturnAddress(t *testing.T) {
 	}
 	require.Equal(t, fmt.Sprintf("statsd (%s)", bogusValue), c.Description())
 }
+
+func TestStatsdTrendTypes(t *testing.T) {
+	t.Parallel()
+
+	for trendType, expected := range map[string]string{
+		trendTypeTiming:       "k6.my_trend:14.000000|ms",
+		trendTypeHistogram:    "k6.my_trend:14.000000|h",
+		trendTypeDistribution: "k6.my_trend:14.000000|d",
+	} {
+		trendType, expected := trendType, expected
+		t.Run(trendType, func(t *testing.T) {
+			t.Parallel()
+
+			addr, err := net.ResolveUDPAddr("udp", "localhost:0")
+			require.NoError(t, err)
+			listener, err := net.ListenUDP("udp", addr)
+			require.NoError(t, err)
+			defer func() { _ = listener.Close() }()
+
+			out, err := newOutput(output.Params{
+				Logger: testutils.NewLogger(t),
+				JSONConfig: json.RawMessage(fmt.Sprintf(`{"addr":%q,"trendType":%q}`,
+					listener.LocalAddr().String(), trendType)),
+			}, newConfig())
+			require.NoError(t, err)
+			require.NoError(t, out.Start())
+
+			sendTrend(out)
+			require.NoError(t, out.Stop())
+
+			var buf [4096]byte
+			n, _, err := listener.ReadFromUDP(buf[:])
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(buf[:n]))
+		})
+	}
+}
+
+func TestStatsdDatadogOverUDS(t *testing.T) {
+	t.Parallel()
+
+	socket := filepath.Join(t.TempDir(), "dsd.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	out, err := NewDatadog(output.Params{
+		Logger:      testutils.NewLogger(t),
+		JSONConfig:  json.RawMessage(fmt.Sprintf(`{"addr":"unix://%s","tagBlocklist":[]}`, socket)),
+		Environment: map[string]string{"DD_ENTITY_ID": "pod-uid"},
+	})
+	require.NoError(t, err)
+	o, ok := out.(*Output)
+	require.True(t, ok)
+	require.NoError(t, o.Start())
+
+	sendTrend(o)
+	require.NoError(t, o.Stop())
+
+	var buf [4096]byte
+	n, err := conn.Read(buf[:])
+	require.NoError(t, err)
+	assert.Equal(t, "k6.my_trend:14.000000|d|#dd.internal.entity_id:pod-uid,tag1:value1", string(buf[:n]))
+}
+
+func sendTrend(o *Output) {
+	registry := metrics.NewRegistry()
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: registry.MustNewMetric("my_trend", metrics.Trend),
+			Tags:   registry.RootTagSet().With("tag1", "value1"),
+		},
+		Time:  time.Now(),
+		Value: 14,
+	}})
+}
@@ -0,0 +1,58 @@
+package statsd
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+)
+
+// tagLimiter limits the number of distinct values sent for every tag, so a
+// tag with unbounded values (e.g. an ID) can't blow up the number of the time
+// series in the StatsD backend.
+type tagLimiter struct {
+	max    int
+	values map[string]map[string]struct{}
+	logger logrus.FieldLogger
+}
+
+func newTagLimiter(limit int, logger logrus.FieldLogger) *tagLimiter {
+	return &tagLimiter{
+		max:    limit,
+		values: make(map[string]map[string]struct{}),
+		logger: logger,
+	}
+}
+
+// limit returns the tags with the values over the limit replaced with the
+// overflow value. The blocked tags aren't sent, so they aren't counted.
+func (l *tagLimiter) limit(blocklist metrics.EnabledTags, tags map[string]string) map[string]string {
+	limited := make(map[string]string, len(tags))
+	for key, value := range tags {
+		limited[key] = value
+		if value == "" || blocklist[key] {
+			continue
+		}
+
+		seen, ok := l.values[key]
+		if !ok {
+			seen = make(map[string]struct{})
+			l.values[key] = seen
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		if len(seen) < l.max {
+			seen[value] = struct{}{}
+			continue
+		}
+
+		if len(seen) == l.max {
+			// the overflow value is tracked too, so the warning is only logged once per tag
+			seen[overflowTagValue] = struct{}{}
+			l.logger.Warnf("The tag %q has more than %d distinct values, the next ones are sent as %q",
+				key, l.max, overflowTagValue)
+		}
+		limited[key] = overflowTagValue
+	}
+	return limited
+}
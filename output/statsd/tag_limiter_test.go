@@ -0,0 +1,27 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func TestTagLimiter(t *testing.T) {
+	t.Parallel()
+
+	l := newTagLimiter(2, testutils.NewLogger(t))
+	blocklist := metrics.EnabledTags{"vu": true}
+	limit := func(id, vu string) map[string]string {
+		return l.limit(blocklist, map[string]string{"id": id, "vu": vu, "method": "GET"})
+	}
+
+	assert.Equal(t, map[string]string{"id": "1", "vu": "1", "method": "GET"}, limit("1", "1"))
+	assert.Equal(t, map[string]string{"id": "2", "vu": "2", "method": "GET"}, limit("2", "2"))
+	assert.Equal(t, map[string]string{"id": "other", "vu": "3", "method": "GET"}, limit("3", "3"))
+	assert.Equal(t, map[string]string{"id": "other", "vu": "4", "method": "GET"}, limit("4", "4"))
+	// the values seen before reaching the limit are still sent as they are
+	assert.Equal(t, map[string]string{"id": "1", "vu": "5", "method": "GET"}, limit("1", "5"))
+}
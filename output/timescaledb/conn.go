@@ -0,0 +1,449 @@
+package timescaledb
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec // required by the md5 authentication of PostgreSQL
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// noDeadline resets the deadline of the connection.
+var noDeadline time.Time //nolint:gochecknoglobals
+
+// conn is a minimal client of the PostgreSQL frontend/backend protocol,
+// supporting only what the output needs: the simple queries and COPY FROM
+// STDIN, over a plain or a TLS connection, with the cleartext, md5 or
+// SCRAM-SHA-256 password authentication.
+type conn struct {
+	netConn net.Conn
+	r       *bufio.Reader
+}
+
+// pgError is an error reported by the server.
+type pgError struct {
+	severity, code, message string
+}
+
+func (e *pgError) Error() string {
+	return fmt.Sprintf("%s: %s (SQLSTATE %s)", e.severity, e.message, e.code)
+}
+
+// connect opens a connection to the server of the postgres:// URL.
+func connect(ctx context.Context, rawURL string) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("unsupported scheme %q, it must be postgres", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "5432")
+	}
+	user := u.User.Username()
+	password, _ := u.User.Password()
+	database := strings.TrimPrefix(u.Path, "/")
+	if database == "" {
+		database = user
+	}
+
+	var d net.Dialer
+	netConn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+
+	c := &conn{netConn: netConn, r: bufio.NewReader(netConn)}
+	if err = c.startTLS(u.Query().Get("sslmode"), u.Hostname()); err == nil {
+		err = c.startup(user, password, database)
+	}
+	if err != nil {
+		_ = c.netConn.Close()
+		return nil, err
+	}
+	_ = c.netConn.SetDeadline(noDeadline)
+	return c, nil
+}
+
+// startTLS upgrades the connection to TLS, depending on the sslmode.
+func (c *conn) startTLS(sslMode, serverName string) error {
+	var tlsConfig *tls.Config
+	switch sslMode {
+	case "", "disable":
+		return nil
+	case "require":
+		tlsConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // it's what require means
+	case "verify-full":
+		tlsConfig = &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+	default:
+		return fmt.Errorf("unsupported sslmode %q, it must be disable, require or verify-full", sslMode)
+	}
+
+	// SSLRequest
+	if _, err := c.netConn.Write([]byte{0, 0, 0, 8, 4, 210, 22, 47}); err != nil {
+		return err
+	}
+	answer, err := c.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if answer != 'S' {
+		return errors.New("the server doesn't support TLS")
+	}
+	c.netConn = tls.Client(c.netConn, tlsConfig)
+	c.r = bufio.NewReader(c.netConn)
+	return nil
+}
+
+func (c *conn) startup(user, password, database string) error {
+	var msg message
+	msg.int32(196608) // protocol version 3.0
+	msg.string("user")
+	msg.string(user)
+	msg.string("database")
+	msg.string(database)
+	msg.string("application_name")
+	msg.string("k6")
+	msg.byte(0)
+	if err := c.send(0, msg); err != nil {
+		return err
+	}
+
+	for {
+		typ, body, err := c.receive()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'R':
+			if err := c.authenticate(body, user, password); err != nil {
+				return err
+			}
+		case 'Z':
+			return nil
+		case 'S', 'K', 'N': // parameters, the backend's key data and notices
+		default:
+			return fmt.Errorf("unexpected message %q during the startup", typ)
+		}
+	}
+}
+
+func (c *conn) authenticate(body []byte, user, password string) error {
+	if len(body) < 4 {
+		return errors.New("malformed authentication message")
+	}
+	switch method := binary.BigEndian.Uint32(body); method {
+	case 0: // AuthenticationOk
+		return nil
+	case 3: // AuthenticationCleartextPassword
+		var msg message
+		msg.string(password)
+		return c.send('p', msg)
+	case 5: // AuthenticationMD5Password
+		if len(body) < 8 {
+			return errors.New("malformed md5 authentication message")
+		}
+		inner := md5.Sum([]byte(password + user))                                    //nolint:gosec
+		outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), body[4:8]...)) //nolint:gosec
+		var msg message
+		msg.string("md5" + hex.EncodeToString(outer[:]))
+		return c.send('p', msg)
+	case 10: // AuthenticationSASL
+		if !strings.Contains(string(body[4:]), "SCRAM-SHA-256\x00") {
+			return errors.New("the server doesn't support the SCRAM-SHA-256 authentication")
+		}
+		return c.scramSHA256(password)
+	default:
+		return fmt.Errorf("unsupported authentication method %d", method)
+	}
+}
+
+// scramSHA256 performs the SCRAM-SHA-256 authentication, as defined by RFC 5802
+// and RFC 7677. The user name is sent in the startup message, so it's empty.
+func (c *conn) scramSHA256(password string) error {
+	nonce := make([]byte, 18)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	clientFirstBare := "n=,r=" + base64.StdEncoding.EncodeToString(nonce)
+
+	var msg message
+	msg.string("SCRAM-SHA-256")
+	msg.int32(int32(len(clientFirstBare) + 3))
+	msg.bytes([]byte("n,," + clientFirstBare))
+	if err := c.send('p', msg); err != nil {
+		return err
+	}
+
+	serverFirst, err := c.receiveSASL(11)
+	if err != nil {
+		return err
+	}
+	attrs := scramAttributes(serverFirst)
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil {
+		return fmt.Errorf("invalid SCRAM salt: %w", err)
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil {
+		return fmt.Errorf("invalid SCRAM iteration count: %w", err)
+	}
+	if !strings.HasPrefix(attrs["r"], base64.StdEncoding.EncodeToString(nonce)) {
+		return errors.New("invalid SCRAM server nonce")
+	}
+
+	saltedPassword := pbkdf2.Key([]byte(password), salt, iterations, sha256.Size, sha256.New)
+	clientKey := hmacSHA256(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	clientFinalWithoutProof := "c=biws,r=" + attrs["r"]
+	authMessage := clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+	clientSignature := hmacSHA256(storedKey[:], authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+
+	msg = message{}
+	msg.bytes([]byte(clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)))
+	if err = c.send('p', msg); err != nil {
+		return err
+	}
+
+	serverFinal, err := c.receiveSASL(12)
+	if err != nil {
+		return err
+	}
+	serverKey := hmacSHA256(saltedPassword, "Server Key")
+	serverSignature := base64.StdEncoding.EncodeToString(hmacSHA256(serverKey, authMessage))
+	if scramAttributes(serverFinal)["v"] != serverSignature {
+		return errors.New("invalid SCRAM server signature")
+	}
+	return nil
+}
+
+func (c *conn) receiveSASL(expected uint32) (string, error) {
+	typ, body, err := c.receive()
+	if err != nil {
+		return "", err
+	}
+	if typ != 'R' || len(body) < 4 || binary.BigEndian.Uint32(body) != expected {
+		return "", fmt.Errorf("unexpected message %q during the SCRAM authentication", typ)
+	}
+	return string(body[4:]), nil
+}
+
+func scramAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for _, attr := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(attr, "="); ok {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// query executes a simple query and returns the values of its rows, the
+// NULL values are returned as nil.
+func (c *conn) query(ctx context.Context, sql string) ([][]*string, error) {
+	defer c.withContext(ctx)()
+
+	var msg message
+	msg.string(sql)
+	if err := c.send('Q', msg); err != nil {
+		return nil, err
+	}
+
+	var (
+		rows     [][]*string
+		queryErr error
+	)
+	for {
+		typ, body, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case 'D':
+			rows = append(rows, parseDataRow(body))
+		case 'E':
+			queryErr = parseError(body)
+		case 'Z':
+			return rows, queryErr
+		case 'T', 'C', 'I', 'N', 'S': // row description, command complete, empty query, notices and parameters
+		default:
+			return nil, fmt.Errorf("unexpected message %q during a query", typ)
+		}
+	}
+}
+
+// copyIn executes a COPY FROM STDIN query, sending the data in the text format.
+func (c *conn) copyIn(ctx context.Context, sql string, data []byte) error {
+	defer c.withContext(ctx)()
+
+	var msg message
+	msg.string(sql)
+	if err := c.send('Q', msg); err != nil {
+		return err
+	}
+
+	var copyErr error
+	for {
+		typ, body, err := c.receive()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'G': // CopyInResponse
+			for len(data) > 0 {
+				chunk := data
+				if len(chunk) > 1<<16 {
+					chunk = chunk[:1<<16]
+				}
+				data = data[len(chunk):]
+				if err := c.send('d', message(chunk)); err != nil {
+					return err
+				}
+			}
+			if err := c.send('c', nil); err != nil {
+				return err
+			}
+		case 'E':
+			copyErr = parseError(body)
+		case 'Z':
+			return copyErr
+		case 'C', 'N', 'S':
+		default:
+			return fmt.Errorf("unexpected message %q during a copy", typ)
+		}
+	}
+}
+
+// withContext applies the context's deadline to the connection, until the
+// returned function is called.
+func (c *conn) withContext(ctx context.Context) func() {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.netConn.SetDeadline(deadline)
+		return func() { _ = c.netConn.SetDeadline(noDeadline) }
+	}
+	return func() {}
+}
+
+func (c *conn) close() error {
+	_ = c.send('X', nil)
+	return c.netConn.Close()
+}
+
+func (c *conn) send(typ byte, body message) error {
+	buf := make([]byte, 0, len(body)+5)
+	if typ != 0 {
+		buf = append(buf, typ)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(body)+4))
+	buf = append(buf, body...)
+	_, err := c.netConn.Write(buf)
+	return err
+}
+
+func (c *conn) receive() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length < 4 {
+		return 0, nil, errors.New("malformed message length")
+	}
+	body := make([]byte, length-4)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+func parseDataRow(body []byte) []*string {
+	if len(body) < 2 {
+		return nil
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	values := make([]*string, 0, n)
+	for i := 0; i < n && len(body) >= 4; i++ {
+		size := int32(binary.BigEndian.Uint32(body))
+		body = body[4:]
+		if size < 0 || int(size) > len(body) {
+			values = append(values, nil)
+			continue
+		}
+		v := string(body[:size])
+		values = append(values, &v)
+		body = body[size:]
+	}
+	return values
+}
+
+func parseError(body []byte) error {
+	e := &pgError{}
+	for len(body) > 1 {
+		field := body[0]
+		end := strings.IndexByte(string(body[1:]), 0)
+		if end < 0 {
+			break
+		}
+		value := string(body[1 : end+1])
+		body = body[end+2:]
+		switch field {
+		case 'S':
+			e.severity = value
+		case 'C':
+			e.code = value
+		case 'M':
+			e.message = value
+		}
+	}
+	return e
+}
+
+// message is the body of a protocol message.
+type message []byte
+
+func (m *message) byte(b byte) {
+	*m = append(*m, b)
+}
+
+func (m *message) int32(i int32) {
+	*m = binary.BigEndian.AppendUint32(*m, uint32(i))
+}
+
+func (m *message) string(s string) {
+	*m = append(append(*m, s...), 0)
+}
+
+func (m *message) bytes(b []byte) {
+	*m = append(*m, b...)
+}
@@ -0,0 +1,217 @@
+// Package timescaledb implements an output copying the samples into a
+// PostgreSQL table, which is turned into a hypertable when the TimescaleDB
+// extension is available.
+//
+// The buffered samples are copied periodically with COPY FROM STDIN. The
+// schema of the table is migrated on start, the applied migrations are
+// recorded in the k6_schema_migrations table so the same table can be reused
+// across k6 versions.
+package timescaledb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// migrations are the statements creating the schema of the table, %[1]s is
+// the table name. They are applied in order and never changed once released,
+// new changes must be appended.
+var migrations = []string{ //nolint:gochecknoglobals
+	`CREATE TABLE IF NOT EXISTS "%[1]s" (
+	ts timestamptz NOT NULL,
+	metric text NOT NULL,
+	type text NOT NULL,
+	value double precision NOT NULL,
+	tags jsonb,
+	metadata jsonb
+)`,
+	`DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb') THEN
+		PERFORM create_hypertable('"%[1]s"', 'ts', if_not_exists => TRUE, migrate_data => TRUE);
+	END IF;
+END
+$$`,
+	`CREATE INDEX IF NOT EXISTS "%[1]s_metric_ts_idx" ON "%[1]s" (metric, ts DESC)`,
+}
+
+// Output implements the output.Output interface, copying the samples into
+// PostgreSQL.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	conn            *conn
+	periodicFlusher *output.PeriodicFlusher
+	logger          logrus.FieldLogger
+}
+
+var _ output.Output = new(Output)
+
+// New creates a new instance of the TimescaleDB output.
+func New(params output.Params) (output.Output, error) {
+	config, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config: config,
+		logger: params.Logger.WithField("output", "timescaledb"),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	u, err := url.Parse(o.config.URL.String)
+	if err != nil {
+		return "timescaledb"
+	}
+	return fmt.Sprintf("timescaledb (%s, %s)", u.Redacted(), o.config.Table.String)
+}
+
+// Start connects to the server, migrates the schema of the table and starts
+// the periodic copies.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	c, err := connect(ctx, o.config.URL.String)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the server: %w", err)
+	}
+	o.conn = c
+	if err = o.migrate(ctx); err != nil {
+		_ = c.close()
+		return fmt.Errorf("failed to migrate the schema: %w", err)
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop copies the remaining samples, stops the periodic copies and closes the
+// connection.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return o.conn.close()
+}
+
+// migrate applies the migrations which haven't been applied yet to the table.
+func (o *Output) migrate(ctx context.Context) error {
+	_, err := o.conn.query(ctx, `CREATE TABLE IF NOT EXISTS k6_schema_migrations (
+	table_name text NOT NULL,
+	version integer NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (table_name, version)
+)`)
+	if err != nil {
+		return err
+	}
+
+	rows, err := o.conn.query(ctx, fmt.Sprintf(
+		`SELECT coalesce(max(version), 0) FROM k6_schema_migrations WHERE table_name = '%s'`, o.config.Table.String))
+	if err != nil {
+		return err
+	}
+	version := 0
+	if len(rows) == 1 && len(rows[0]) == 1 && rows[0][0] != nil {
+		if version, err = strconv.Atoi(*rows[0][0]); err != nil {
+			return fmt.Errorf("invalid schema version: %w", err)
+		}
+	}
+
+	for i := version; i < len(migrations); i++ {
+		o.logger.WithField("version", i+1).Debug("Applying a schema migration")
+		_, err = o.conn.query(ctx, fmt.Sprintf("BEGIN;\n%s;\n"+
+			"INSERT INTO k6_schema_migrations (table_name, version) VALUES ('%s', %d);\nCOMMIT",
+			fmt.Sprintf(migrations[i], o.config.Table.String), o.config.Table.String, i+1))
+		if err != nil {
+			_, _ = o.conn.query(ctx, "ROLLBACK")
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (o *Output) flushMetrics() {
+	var (
+		start = time.Now()
+		data  bytes.Buffer
+		rows  int
+	)
+	for _, sc := range o.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			if err := writeRow(&data, sample); err != nil {
+				o.logger.WithError(err).Debugf("Couldn't encode a sample of %s", sample.Metric.Name)
+				continue
+			}
+			rows++
+		}
+	}
+	if rows == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.PushInterval.TimeDuration()+30*time.Second)
+	defer cancel()
+	query := fmt.Sprintf(`COPY "%s" (ts, metric, type, value, tags, metadata) FROM STDIN`, o.config.Table.String)
+	if err := o.conn.copyIn(ctx, query, data.Bytes()); err != nil {
+		o.logger.WithError(err).WithField("samples", rows).Error("Couldn't copy the samples")
+		return
+	}
+	o.logger.WithField("t", time.Since(start)).WithField("samples", rows).Debug("Copied the samples")
+}
+
+// writeRow writes the sample as a row of the COPY text format.
+func writeRow(buf *bytes.Buffer, sample metrics.Sample) error {
+	tags, err := json.Marshal(sample.Tags.Map())
+	if err != nil {
+		return err
+	}
+	metadata := []byte(`\N`)
+	if len(sample.Metadata) > 0 {
+		if metadata, err = json.Marshal(sample.Metadata); err != nil {
+			return err
+		}
+		metadata = []byte(copyEscaper.Replace(string(metadata)))
+	}
+
+	buf.WriteString(sample.Time.UTC().Format(time.RFC3339Nano))
+	buf.WriteByte('\t')
+	buf.WriteString(copyEscaper.Replace(sample.Metric.Name))
+	buf.WriteByte('\t')
+	buf.WriteString(sample.Metric.Type.String())
+	buf.WriteByte('\t')
+	buf.WriteString(strconv.FormatFloat(sample.Value, 'g', -1, 64))
+	buf.WriteByte('\t')
+	buf.WriteString(copyEscaper.Replace(string(tags)))
+	buf.WriteByte('\t')
+	buf.Write(metadata)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// copyEscaper escapes the characters which have a special meaning in the
+// COPY text format.
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`) //nolint:gochecknoglobals
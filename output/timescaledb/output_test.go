@@ -0,0 +1,243 @@
+package timescaledb
+
+import (
+	"bufio"
+	"crypto/md5" //nolint:gosec
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// fakeServer implements the server side of the protocol, enough for the
+// output: the md5 authentication, the simple queries and COPY FROM STDIN.
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	// version is the schema version of the table, as returned by the server.
+	version string
+	// fail is a substring of the queries failing with an error.
+	fail string
+
+	mu       sync.Mutex
+	queries  []string
+	copyData []byte
+}
+
+func newFakeServer(t *testing.T, version string) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &fakeServer{t: t, listener: listener, version: version}
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "postgres://k6user:secret@" + s.listener.Addr().String() + "/perf"
+}
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer func() { _ = c.Close() }()
+	r := bufio.NewReader(c)
+
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	startup := make([]byte, binary.BigEndian.Uint32(header[:])-4)
+	if _, err := io.ReadFull(r, startup); err != nil {
+		return
+	}
+	assert.Contains(s.t, string(startup), "user\x00k6user\x00database\x00perf\x00")
+
+	salt := []byte{1, 2, 3, 4}
+	s.send(c, 'R', append([]byte{0, 0, 0, 5}, salt...))
+	typ, body := s.receive(r)
+	require.Equal(s.t, byte('p'), typ)
+	inner := md5.Sum([]byte("secret" + "k6user"))                           //nolint:gosec
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...)) //nolint:gosec
+	assert.Equal(s.t, "md5"+hex.EncodeToString(outer[:])+"\x00", string(body))
+	s.send(c, 'R', []byte{0, 0, 0, 0})
+	s.send(c, 'Z', []byte{'I'})
+
+	for {
+		typ, body := s.receive(r)
+		switch typ {
+		case 'Q':
+			query := strings.TrimSuffix(string(body), "\x00")
+			s.mu.Lock()
+			s.queries = append(s.queries, query)
+			s.mu.Unlock()
+			s.query(c, r, query)
+			s.send(c, 'Z', []byte{'I'})
+		default:
+			return
+		}
+	}
+}
+
+func (s *fakeServer) query(c net.Conn, r *bufio.Reader, query string) {
+	switch {
+	case s.fail != "" && strings.Contains(query, s.fail):
+		s.send(c, 'E', []byte("SERROR\x00C42501\x00Mpermission denied for schema public\x00\x00"))
+	case strings.HasPrefix(query, "SELECT coalesce(max(version), 0)"):
+		s.send(c, 'T', []byte{0, 0})
+		row := binary.BigEndian.AppendUint16(nil, 1)
+		row = binary.BigEndian.AppendUint32(row, uint32(len(s.version)))
+		s.send(c, 'D', append(row, s.version...))
+		s.send(c, 'C', []byte("SELECT 1\x00"))
+	case strings.HasPrefix(query, "COPY "):
+		s.send(c, 'G', []byte{0, 0, 0})
+		for {
+			typ, body := s.receive(r)
+			if typ != 'd' {
+				require.Equal(s.t, byte('c'), typ)
+				break
+			}
+			s.mu.Lock()
+			s.copyData = append(s.copyData, body...)
+			s.mu.Unlock()
+		}
+		s.send(c, 'C', []byte("COPY\x00"))
+	default:
+		s.send(c, 'C', []byte("OK\x00"))
+	}
+}
+
+func (s *fakeServer) send(c net.Conn, typ byte, body []byte) {
+	buf := binary.BigEndian.AppendUint32([]byte{typ}, uint32(len(body)+4))
+	_, err := c.Write(append(buf, body...))
+	require.NoError(s.t, err)
+}
+
+func (s *fakeServer) receive(r *bufio.Reader) (byte, []byte) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil
+	}
+	return header[0], body
+}
+
+func (s *fakeServer) received() ([]string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries, string(s.copyData)
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeServer(t, "0")
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		Environment:    map[string]string{"K6_TIMESCALEDB_URL": srv.url()},
+		ConfigArgument: "table=results,pushInterval=1h",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "timescaledb (postgres://k6user:xxxxx@"+srv.listener.Addr().String()+"/perf, results)",
+		out.Description())
+	require.NoError(t, out.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	now := time.Date(2023, time.October, 15, 10, 30, 0, 123456000, time.UTC)
+	out.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtin.HTTPReqDuration,
+				Tags:   registry.RootTagSet().WithTagsFromMap(map[string]string{"url": "/a\tb"}),
+			},
+			Time:     now,
+			Value:    120.5,
+			Metadata: map[string]string{"trace_id": "abc"},
+		},
+		{
+			TimeSeries: metrics.TimeSeries{Metric: builtin.Iterations, Tags: registry.RootTagSet()},
+			Time:       now,
+			Value:      1,
+		},
+	}})
+	require.NoError(t, out.Stop())
+
+	queries, data := srv.received()
+	require.Len(t, queries, 6)
+	assert.True(t, strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS k6_schema_migrations ("))
+	assert.Equal(t,
+		"SELECT coalesce(max(version), 0) FROM k6_schema_migrations WHERE table_name = 'results'", queries[1])
+	for i, query := range queries[2:5] {
+		assert.True(t, strings.HasPrefix(query, "BEGIN;\n"))
+		assert.True(t, strings.HasSuffix(query, ";\nINSERT INTO k6_schema_migrations (table_name, version) "+
+			"VALUES ('results', "+string(rune('1'+i))+");\nCOMMIT"))
+	}
+	assert.Contains(t, queries[2], `CREATE TABLE IF NOT EXISTS "results" (`)
+	assert.Contains(t, queries[3], `create_hypertable('"results"', 'ts'`)
+	assert.Equal(t, `COPY "results" (ts, metric, type, value, tags, metadata) FROM STDIN`, queries[5])
+
+	assert.Equal(t,
+		"2023-10-15T10:30:00.123456Z\thttp_req_duration\ttrend\t120.5\t"+`{"url":"/a\\tb"}`+"\t"+`{"trace_id":"abc"}`+"\n"+
+			"2023-10-15T10:30:00.123456Z\titerations\tcounter\t1\t{}\t\\N\n",
+		data)
+}
+
+func TestOutputMigratedTable(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeServer(t, "3")
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: srv.url(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+	require.NoError(t, out.Stop())
+
+	// nothing to migrate and no samples to copy
+	queries, _ := srv.received()
+	require.Len(t, queries, 2)
+}
+
+func TestOutputMigrationError(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeServer(t, "1")
+	srv.fail = "create_hypertable"
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: srv.url(),
+	})
+	require.NoError(t, err)
+	err = out.Start()
+	require.EqualError(t, err,
+		"failed to migrate the schema: migration 2: ERROR: permission denied for schema public (SQLSTATE 42501)")
+
+	queries, _ := srv.received()
+	require.Len(t, queries, 4)
+	assert.Equal(t, "ROLLBACK", queries[3])
+}
@@ -0,0 +1,380 @@
+// Package recorder provides the capturing proxy of `k6 record`, which records
+// the traffic of a browser as a HAR recording. The HTTPS traffic is decrypted
+// with certificates signed by a certificate authority that the browser has to
+// trust.
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/lib/consts"
+)
+
+// hopHeaders are the headers of a single connection, which aren't forwarded
+// by the proxy nor recorded.
+//
+//nolint:gochecknoglobals
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Recorder is an HTTP proxy which records the requests made through it and
+// their responses.
+type Recorder struct {
+	ca        tls.Certificate
+	leafKey   *ecdsa.PrivateKey
+	transport http.RoundTripper
+	logger    logrus.FieldLogger
+
+	mu      sync.Mutex
+	certs   map[string]*tls.Certificate
+	tunnels map[net.Conn]struct{}
+	pages   []har.Page
+	entries []har.Entry
+}
+
+var _ http.Handler = &Recorder{}
+
+// New returns a recorder decrypting the HTTPS traffic with certificates signed
+// by the CA, which makes the requests with the transport.
+func New(ca tls.Certificate, transport http.RoundTripper, logger logrus.FieldLogger) (*Recorder, error) {
+	if ca.Leaf == nil {
+		return nil, errors.New("the recorder CA has to be parsed, use LoadCA()")
+	}
+	// the same key is used for the certificates of all of the hosts
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		ca:        ca,
+		leafKey:   leafKey,
+		transport: transport,
+		logger:    logger,
+		certs:     make(map[string]*tls.Certificate),
+		tunnels:   make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// ServeHTTP proxies the plain HTTP requests, and decrypts the HTTPS ones made
+// through CONNECT tunnels.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodConnect {
+		r.serveTunnel(w, req)
+		return
+	}
+	if !req.URL.IsAbs() {
+		http.Error(w, "k6 record is a proxy, only the requests with absolute URLs are supported", http.StatusBadRequest)
+		return
+	}
+
+	res, err := r.roundTrip(req)
+	if err != nil {
+		r.logger.WithError(err).Warnf("The request to %s failed", req.URL)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	for name, values := range res.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
+
+// serveTunnel serves the requests of a CONNECT tunnel, over TLS with a
+// certificate of the host signed by the CA.
+func (r *Recorder) serveTunnel(w http.ResponseWriter, req *http.Request) {
+	host, _, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		host = req.Host
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "the connection can't be hijacked", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		r.logger.WithError(err).Warn("Couldn't hijack the connection of a CONNECT request")
+		return
+	}
+	r.mu.Lock()
+	r.tunnels[conn] = struct{}{}
+	r.mu.Unlock()
+	defer func() {
+		_ = conn.Close()
+		r.mu.Lock()
+		delete(r.tunnels, conn)
+		r.mu.Unlock()
+	}()
+
+	if _, err = io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return r.certificate(hello.ServerName)
+			}
+			return r.certificate(host)
+		},
+	})
+	reader := bufio.NewReader(tlsConn)
+	for {
+		tunneled, err := http.ReadRequest(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				r.logger.WithError(err).Debugf("Couldn't read a request of the tunnel to %s", req.Host)
+			}
+			return
+		}
+		tunneled.URL.Scheme, tunneled.URL.Host = "https", tunneled.Host
+		if tunneled.URL.Host == "" {
+			tunneled.URL.Host = req.Host
+		}
+
+		res, err := r.roundTrip(tunneled)
+		if err != nil {
+			r.logger.WithError(err).Warnf("The request to %s failed", tunneled.URL)
+			res = &http.Response{
+				StatusCode: http.StatusBadGateway,
+				ProtoMajor: 1, ProtoMinor: 1,
+				Header:        http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+				Body:          io.NopCloser(strings.NewReader(err.Error())),
+				ContentLength: int64(len(err.Error())),
+			}
+		}
+		if err = res.Write(tlsConn); err != nil || tunneled.Close {
+			return
+		}
+	}
+}
+
+// certificate returns the certificate of the host, which is generated the
+// first time.
+func (r *Recorder) certificate(host string) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cert, ok := r.certs[host]; ok {
+		return cert, nil
+	}
+	cert, err := newLeafCertificate(r.ca, r.leafKey, host)
+	if err != nil {
+		return nil, err
+	}
+	r.certs[host] = cert
+	return cert, nil
+}
+
+// roundTrip makes the request and records it with its response, whose body is
+// buffered.
+func (r *Recorder) roundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, req.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	out.Header = req.Header.Clone()
+	removeHopHeaders(out.Header)
+	// the recorded responses have to be readable, so only the compression
+	// that can be decoded is accepted
+	if out.Header.Get("Accept-Encoding") != "" {
+		out.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	res, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	removeHopHeaders(res.Header)
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+	res.ContentLength = int64(len(resBody))
+	res.Header.Set("Content-Length", strconv.Itoa(len(resBody)))
+	res.TransferEncoding = nil
+	// the responses are written back over HTTP/1.1, whatever their protocol was
+	res.Proto, res.ProtoMajor, res.ProtoMinor = "HTTP/1.1", 1, 1
+
+	r.record(req, body, res, resBody, start, time.Since(start))
+	return res, nil
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, name := range header.Values("Connection") {
+		for _, field := range strings.Split(name, ",") {
+			header.Del(strings.TrimSpace(field))
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+// record adds the entry of the request. The navigations of the browser, like
+// the requests of the HTML documents, start new pages.
+func (r *Recorder) record(
+	req *http.Request, body []byte, res *http.Response, resBody []byte, start time.Time, duration time.Duration,
+) {
+	reqHeader := req.Header.Clone()
+	removeHopHeaders(reqHeader)
+	entry := har.Entry{
+		StartedDateTime: start,
+		Time:            float64(duration) / float64(time.Millisecond),
+		Request: har.Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: harHeaders(reqHeader),
+		},
+		Response: har.Response{
+			Status:  res.StatusCode,
+			Headers: harHeaders(res.Header),
+			Content: harContent(res.Header, resBody),
+		},
+	}
+	if len(body) > 0 {
+		entry.Request.PostData = &har.PostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if isNavigation(req) {
+		r.pages = append(r.pages, har.Page{
+			ID:              "page_" + strconv.Itoa(len(r.pages)+1),
+			Title:           req.URL.String(),
+			StartedDateTime: start,
+		})
+	}
+	if len(r.pages) > 0 {
+		entry.Pageref = r.pages[len(r.pages)-1].ID
+	}
+	r.entries = append(r.entries, entry)
+	r.logger.Debugf("Recorded %s %s: %d", req.Method, req.URL, res.StatusCode)
+}
+
+func isNavigation(req *http.Request) bool {
+	if dest := req.Header.Get("Sec-Fetch-Dest"); dest != "" {
+		return dest == "document"
+	}
+	return req.Method == http.MethodGet && strings.Contains(req.Header.Get("Accept"), "text/html")
+}
+
+func harHeaders(header http.Header) []har.Header {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]har.Header, 0, len(names))
+	for _, name := range names {
+		for _, value := range header[name] {
+			headers = append(headers, har.Header{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// harContent returns the content of the response body, as it is for the
+// textual ones and base64 encoded for the others.
+func harContent(header http.Header, body []byte) har.Content {
+	content := har.Content{MimeType: header.Get("Content-Type")}
+	if strings.EqualFold(header.Get("Content-Encoding"), "gzip") {
+		decoded, err := gunzip(body)
+		if err != nil {
+			content.Encoding, content.Text = "base64", base64.StdEncoding.EncodeToString(body)
+			return content
+		}
+		body = decoded
+	}
+	if isTextual(content.MimeType) {
+		content.Text = string(body)
+	} else if len(body) > 0 {
+		content.Encoding, content.Text = "base64", base64.StdEncoding.EncodeToString(body)
+	}
+	return content
+}
+
+func gunzip(body []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
+func isTextual(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	for _, textual := range []string{"json", "javascript", "xml", "x-www-form-urlencoded"} {
+		if strings.Contains(mediaType, textual) {
+			return true
+		}
+	}
+	return false
+}
+
+// HAR returns the recording of the requests made so far.
+func (r *Recorder) HAR() har.HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recording := har.HAR{Log: har.Log{
+		Version: "1.2",
+		Creator: har.Creator{Name: "k6", Version: consts.Version},
+		Pages:   make([]har.Page, len(r.pages)),
+		Entries: make([]har.Entry, len(r.entries)),
+	}}
+	copy(recording.Log.Pages, r.pages)
+	copy(recording.Log.Entries, r.entries)
+	return recording
+}
+
+// Close closes the CONNECT tunnels, which aren't closed by the shutdown of
+// the HTTP server since they are hijacked.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for conn := range r.tunnels {
+		if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) && firstErr == nil {
+			firstErr = fmt.Errorf("couldn't close a tunnel: %w", err)
+		}
+	}
+	return firstErr
+}
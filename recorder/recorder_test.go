@@ -0,0 +1,146 @@
+package recorder
+
+import (
+	"compress/gzip"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/lib/testutils"
+)
+
+func newTestRecorder(t *testing.T, transport http.RoundTripper) (*Recorder, *x509.CertPool, *url.URL) {
+	t.Helper()
+
+	certPEM, keyPEM, err := NewCA()
+	require.NoError(t, err)
+	ca, err := LoadCA(certPEM, keyPEM)
+	require.NoError(t, err)
+	rec, err := New(ca, transport, testutils.NewLogger(t))
+	require.NoError(t, err)
+
+	proxy := httptest.NewServer(rec)
+	t.Cleanup(func() {
+		assert.NoError(t, rec.Close())
+		proxy.Close()
+	})
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(certPEM))
+	return rec, pool, proxyURL
+}
+
+func TestRecorderHTTP(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(`{"echo": "` + string(body) + `", "method": "` + req.Method + `"}`))
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	rec, _, proxyURL := newTestRecorder(t, http.DefaultTransport)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/login?next=%2F", strings.NewReader("user=someone"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, `{"echo": "user=someone", "method": "POST"}`, string(body))
+
+	recording := rec.HAR()
+	assert.Equal(t, "1.2", recording.Log.Version)
+	assert.Empty(t, recording.Log.Pages)
+	require.Len(t, recording.Log.Entries, 1)
+	entry := recording.Log.Entries[0]
+	assert.Empty(t, entry.Pageref)
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, srv.URL+"/login?next=%2F", entry.Request.URL)
+	assert.Equal(t, &har.PostData{MimeType: "application/x-www-form-urlencoded", Text: "user=someone"},
+		entry.Request.PostData)
+	assert.Contains(t, entry.Request.Headers, har.Header{Name: "Accept-Encoding", Value: "gzip"})
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Equal(t, har.Content{
+		MimeType: "application/json",
+		Text:     `{"echo": "user=someone", "method": "POST"}`,
+	}, entry.Response.Content)
+}
+
+func TestRecorderHTTPS(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/logo.png" {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte{0x89, 'P', 'N', 'G'})
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<html></html>")
+	}))
+	defer srv.Close()
+
+	rec, pool, proxyURL := newTestRecorder(t, srv.Client().Transport)
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone(), //nolint:forcetypeassert
+	}}
+	client.Transport.(*http.Transport).TLSClientConfig.RootCAs = pool //nolint:forcetypeassert
+
+	for _, path := range []string{"/", "/logo.png"} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Sec-Fetch-Dest", map[string]string{"/": "document", "/logo.png": "image"}[path])
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	recording := rec.HAR()
+	require.Len(t, recording.Log.Pages, 1)
+	assert.Equal(t, har.Page{
+		ID: "page_1", Title: srv.URL + "/", StartedDateTime: recording.Log.Pages[0].StartedDateTime,
+	}, recording.Log.Pages[0])
+	require.Len(t, recording.Log.Entries, 2)
+	assert.Equal(t, "page_1", recording.Log.Entries[0].Pageref)
+	assert.Equal(t, "<html></html>", recording.Log.Entries[0].Response.Content.Text)
+	assert.Equal(t, "page_1", recording.Log.Entries[1].Pageref)
+	assert.Equal(t, srv.URL+"/logo.png", recording.Log.Entries[1].Request.URL)
+	assert.Equal(t, har.Content{MimeType: "image/png", Text: "iVBORw==", Encoding: "base64"},
+		recording.Log.Entries[1].Response.Content)
+}
+
+func TestLoadCA(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := NewCA()
+	require.NoError(t, err)
+	ca, err := LoadCA(certPEM, keyPEM)
+	require.NoError(t, err)
+	assert.True(t, ca.Leaf.IsCA)
+	assert.Equal(t, "k6 recorder CA", ca.Leaf.Subject.CommonName)
+
+	_, err = LoadCA(certPEM, []byte("invalid"))
+	assert.ErrorContains(t, err, "invalid recorder CA")
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+)
+
+// The dimensions of the charts, in SVG user units.
+const (
+	chartWidth  = 800
+	chartHeight = 260
+	chartLeft   = 70
+	chartRight  = 20
+	chartTop    = 10
+	chartBottom = 30
+	chartTicks  = 5
+)
+
+//nolint:gochecknoglobals
+var chartColors = []string{"#7d64ff", "#3dbd7d", "#f2a93b", "#e5484d", "#3a9bdc", "#b45fc4"}
+
+// chartSeries is a line of a chart, Value returns its value at a point of the
+// timeline, or false when the point doesn't have any.
+type chartSeries struct {
+	Name  string
+	Value func(Point) (float64, bool)
+}
+
+// renderChart renders the series as an inline SVG line chart. Every point has
+// a tooltip, and the series can be toggled by clicking on their legend.
+func renderChart(timeline []Point, series []chartSeries, format func(float64) string) template.HTML {
+	start, end := timeline[0].Time, timeline[len(timeline)-1].Time
+	span := end.Sub(start).Seconds()
+	if span == 0 {
+		span = 1
+	}
+	maxValue := 0.0
+	for _, s := range series {
+		for _, p := range timeline {
+			if v, ok := s.Value(p); ok && v > maxValue {
+				maxValue = v
+			}
+		}
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+	maxValue *= 1.1
+
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	x := func(t time.Time) float64 { return chartLeft + t.Sub(start).Seconds()/span*plotWidth }
+	y := func(v float64) float64 { return chartTop + plotHeight - v/maxValue*plotHeight }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, chartWidth, chartHeight)
+	for i := 0; i <= chartTicks; i++ {
+		v := maxValue * float64(i) / chartTicks
+		fmt.Fprintf(&b, `<line class="grid" x1="%d" x2="%d" y1="%.1f" y2="%.1f"/>`,
+			chartLeft, chartWidth-chartRight, y(v), y(v))
+		fmt.Fprintf(&b, `<text class="axis" x="%d" y="%.1f" text-anchor="end">%s</text>`,
+			chartLeft-6, y(v)+4, template.HTMLEscapeString(format(v)))
+
+		t := start.Add(time.Duration(span * float64(i) / chartTicks * float64(time.Second)))
+		fmt.Fprintf(&b, `<text class="axis" x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			x(t), chartHeight-8, t.Sub(start).Round(time.Second))
+	}
+
+	for i, s := range series {
+		color := chartColors[i%len(chartColors)]
+		var path strings.Builder
+		command := "M"
+		for _, p := range timeline {
+			v, ok := s.Value(p)
+			if !ok || math.IsNaN(v) {
+				command = "M"
+				continue
+			}
+			fmt.Fprintf(&path, "%s%.1f,%.1f ", command, x(p.Time), y(v))
+			command = "L"
+		}
+		fmt.Fprintf(&b, `<g class="series" data-series="%d"><path d="%s" stroke="%s"/>`,
+			i, strings.TrimSpace(path.String()), color)
+		for _, p := range timeline {
+			if v, ok := s.Value(p); ok && !math.IsNaN(v) {
+				fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="2.5" fill="%s"><title>%s at %s: %s</title></circle>`,
+					x(p.Time), y(v), color, template.HTMLEscapeString(s.Name),
+					p.Time.Sub(start).Round(time.Second), template.HTMLEscapeString(format(v)))
+			}
+		}
+		b.WriteString(`</g>`)
+	}
+	b.WriteString(`</svg><div class="legend">`)
+	for i, s := range series {
+		fmt.Fprintf(&b, `<button type="button" data-series="%d"><span style="background:%s"></span>%s</button>`,
+			i, chartColors[i%len(chartColors)], template.HTMLEscapeString(s.Name))
+	}
+	b.WriteString(`</div>`)
+
+	return template.HTML(b.String()) //nolint:gosec // all the dynamic values are escaped
+}
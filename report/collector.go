@@ -0,0 +1,257 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// maxPoints is roughly how many points the timelines have, the interval of
+// the points is derived from the duration of the test.
+const maxPoints = 120
+
+// flushInterval is how often the buffered samples are aggregated.
+const flushInterval = time.Second
+
+// Collector is an output collecting what the reports need which isn't in the
+// end-of-test summary: the metrics over time and the per-scenario breakdowns.
+type Collector struct {
+	output.SampleBuffer
+
+	interval        time.Duration
+	logger          logrus.FieldLogger
+	periodicFlusher *output.PeriodicFlusher
+
+	buckets   map[int64]*bucket
+	timeline  []Point
+	scenarios map[string]*Scenario
+}
+
+var _ output.Output = new(Collector)
+
+// Point is the aggregation of the metrics in an interval of the test, the
+// metrics are aggregated across all their time series.
+type Point struct {
+	Time time.Time
+	// Trends are the percentiles of the trend metrics.
+	Trends map[string]TrendPoint
+	// Counters are the per-second rates of the counter metrics.
+	Counters map[string]float64
+	// Gauges are the last values of the gauge metrics.
+	Gauges map[string]float64
+	// Rates are the ratios of the non-zero samples of the rate metrics.
+	Rates map[string]float64
+}
+
+// TrendPoint is the aggregation of a trend metric in an interval.
+type TrendPoint struct {
+	Avg, P50, P90, P95, P99, Max float64
+}
+
+// Scenario is the breakdown of the main metrics of a scenario.
+type Scenario struct {
+	Name              string
+	Iterations        int64
+	Requests          int64
+	FailedRequests    int64
+	ChecksPassed      int64
+	ChecksFailed      int64
+	IterationDuration *metrics.TrendSink
+	RequestDuration   *metrics.TrendSink
+}
+
+type bucket struct {
+	trends   map[string]*metrics.TrendSink
+	counters map[string]float64
+	gauges   map[string]float64
+	rates    map[string]*metrics.RateSink
+}
+
+// NewCollector creates a collector, aggregating the timelines over intervals
+// suited to the duration of the execution plan.
+func NewCollector(logger logrus.FieldLogger, executionPlan []lib.ExecutionStep) *Collector {
+	interval := time.Second
+	if duration, _ := lib.GetEndOffset(executionPlan); duration/maxPoints > interval {
+		interval = (duration / maxPoints).Round(time.Second)
+	}
+	return &Collector{
+		interval:  interval,
+		logger:    logger.WithField("component", "report-collector"),
+		buckets:   make(map[int64]*bucket),
+		scenarios: make(map[string]*Scenario),
+	}
+}
+
+// Description returns a human-readable description of the output.
+func (c *Collector) Description() string {
+	return "report collector"
+}
+
+// Start starts the periodic aggregation of the samples.
+func (c *Collector) Start() error {
+	pf, err := output.NewPeriodicFlusher(flushInterval, c.flush)
+	if err != nil {
+		return err
+	}
+	c.periodicFlusher = pf
+	return nil
+}
+
+// Stop aggregates the remaining samples and closes all the intervals.
+func (c *Collector) Stop() error {
+	c.periodicFlusher.Stop()
+	c.closeBuckets(time.Unix(0, 1<<62))
+	c.logger.WithField("points", len(c.timeline)).Debug("Collected the report's data")
+	return nil
+}
+
+// Interval returns the interval of the points of the timeline.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Timeline returns the points of the closed intervals, in chronological order.
+func (c *Collector) Timeline() []Point {
+	return c.timeline
+}
+
+// Scenarios returns the breakdowns of the scenarios, sorted by name.
+func (c *Collector) Scenarios() []*Scenario {
+	scenarios := make([]*Scenario, 0, len(c.scenarios))
+	for _, s := range c.scenarios {
+		scenarios = append(scenarios, s)
+	}
+	sort.Slice(scenarios, func(i, j int) bool { return scenarios[i].Name < scenarios[j].Name })
+	return scenarios
+}
+
+func (c *Collector) flush() {
+	for _, sc := range c.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			c.add(sample)
+		}
+	}
+	// the samples are received with some delay, so an interval is only
+	// closed once the next one is over too
+	c.closeBuckets(time.Now().Add(-c.interval))
+}
+
+func (c *Collector) add(sample metrics.Sample) {
+	start := sample.Time.Truncate(c.interval).UnixNano()
+	b, ok := c.buckets[start]
+	if !ok {
+		b = &bucket{
+			trends:   make(map[string]*metrics.TrendSink),
+			counters: make(map[string]float64),
+			gauges:   make(map[string]float64),
+			rates:    make(map[string]*metrics.RateSink),
+		}
+		c.buckets[start] = b
+	}
+
+	name := sample.Metric.Name
+	switch sample.Metric.Type {
+	case metrics.Trend:
+		sink, ok := b.trends[name]
+		if !ok {
+			sink = metrics.NewTrendSink()
+			b.trends[name] = sink
+		}
+		sink.Add(sample)
+	case metrics.Counter:
+		b.counters[name] += sample.Value
+	case metrics.Gauge:
+		b.gauges[name] = sample.Value
+	case metrics.Rate:
+		sink, ok := b.rates[name]
+		if !ok {
+			sink = &metrics.RateSink{}
+			b.rates[name] = sink
+		}
+		sink.Add(sample)
+	}
+
+	if scenario, ok := sample.Tags.Get("scenario"); ok && scenario != "" {
+		c.addToScenario(scenario, sample)
+	}
+}
+
+func (c *Collector) addToScenario(name string, sample metrics.Sample) {
+	s, ok := c.scenarios[name]
+	if !ok {
+		s = &Scenario{
+			Name:              name,
+			IterationDuration: metrics.NewTrendSink(),
+			RequestDuration:   metrics.NewTrendSink(),
+		}
+		c.scenarios[name] = s
+	}
+
+	switch sample.Metric.Name {
+	case metrics.IterationsName:
+		s.Iterations += int64(sample.Value)
+	case metrics.IterationDurationName:
+		s.IterationDuration.Add(sample)
+	case metrics.HTTPReqsName:
+		s.Requests += int64(sample.Value)
+	case metrics.HTTPReqDurationName:
+		s.RequestDuration.Add(sample)
+	case metrics.HTTPReqFailedName:
+		if sample.Value != 0 {
+			s.FailedRequests++
+		}
+	case metrics.ChecksName:
+		if sample.Value != 0 {
+			s.ChecksPassed++
+		} else {
+			s.ChecksFailed++
+		}
+	}
+}
+
+// closeBuckets turns the buckets which are over before the deadline into
+// points of the timeline.
+func (c *Collector) closeBuckets(deadline time.Time) {
+	var starts []int64
+	for start := range c.buckets {
+		if start+int64(c.interval) <= deadline.UnixNano() {
+			starts = append(starts, start)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	for _, start := range starts {
+		b := c.buckets[start]
+		delete(c.buckets, start)
+
+		p := Point{
+			Time:     time.Unix(0, start),
+			Trends:   make(map[string]TrendPoint, len(b.trends)),
+			Counters: make(map[string]float64, len(b.counters)),
+			Gauges:   b.gauges,
+			Rates:    make(map[string]float64, len(b.rates)),
+		}
+		for name, sink := range b.trends {
+			p.Trends[name] = TrendPoint{
+				Avg: sink.Avg(),
+				P50: sink.P(0.5),
+				P90: sink.P(0.9),
+				P95: sink.P(0.95),
+				P99: sink.P(0.99),
+				Max: sink.Max(),
+			}
+		}
+		for name, value := range b.counters {
+			p.Counters[name] = value / c.interval.Seconds()
+		}
+		for name, sink := range b.rates {
+			p.Rates[name] = sink.Format(0)["rate"]
+		}
+		c.timeline = append(c.timeline, p)
+	}
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func TestNewCollectorInterval(t *testing.T) {
+	t.Parallel()
+
+	logger := testutils.NewLogger(t)
+	plan := func(d time.Duration) []lib.ExecutionStep {
+		return []lib.ExecutionStep{{TimeOffset: 0, PlannedVUs: 1}, {TimeOffset: d}}
+	}
+	assert.Equal(t, time.Second, NewCollector(logger, plan(30*time.Second)).Interval())
+	assert.Equal(t, 30*time.Second, NewCollector(logger, plan(time.Hour)).Interval())
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	c := NewCollector(testutils.NewLogger(t), nil)
+	require.NoError(t, c.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	start := time.Date(2023, time.October, 15, 10, 30, 0, 0, time.UTC)
+	tags := func(scenario string) *metrics.TagSet {
+		if scenario == "" {
+			return registry.RootTagSet()
+		}
+		return registry.RootTagSet().With("scenario", scenario)
+	}
+	sample := func(m *metrics.Metric, offset time.Duration, value float64, scenario string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags(scenario)},
+			Time:       start.Add(offset),
+			Value:      value,
+		}
+	}
+	c.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(builtin.HTTPReqDuration, 100*time.Millisecond, 100, "browse"),
+		sample(builtin.HTTPReqDuration, 200*time.Millisecond, 300, "browse"),
+		sample(builtin.HTTPReqs, 100*time.Millisecond, 1, "browse"),
+		sample(builtin.HTTPReqs, 200*time.Millisecond, 1, "browse"),
+		sample(builtin.HTTPReqFailed, 200*time.Millisecond, 1, "browse"),
+		sample(builtin.HTTPReqFailed, 100*time.Millisecond, 0, "browse"),
+		sample(builtin.VUs, 500*time.Millisecond, 5, ""),
+		sample(builtin.Checks, 1500*time.Millisecond, 1, "checkout"),
+		sample(builtin.Checks, 1600*time.Millisecond, 0, "checkout"),
+		sample(builtin.Iterations, 1700*time.Millisecond, 1, "checkout"),
+		sample(builtin.VUs, 1800*time.Millisecond, 3, ""),
+	}})
+	require.NoError(t, c.Stop())
+
+	timeline := c.Timeline()
+	require.Len(t, timeline, 2)
+	assert.Equal(t, start, timeline[0].Time.UTC())
+	assert.Equal(t, TrendPoint{Avg: 200, P50: 200, P90: 280, P95: 290, P99: 298, Max: 300},
+		timeline[0].Trends[metrics.HTTPReqDurationName])
+	assert.Equal(t, map[string]float64{metrics.HTTPReqsName: 2}, timeline[0].Counters)
+	assert.Equal(t, map[string]float64{metrics.HTTPReqFailedName: 0.5}, timeline[0].Rates)
+	assert.Equal(t, map[string]float64{metrics.VUsName: 5}, timeline[0].Gauges)
+	assert.Equal(t, start.Add(time.Second), timeline[1].Time.UTC())
+	assert.Equal(t, map[string]float64{metrics.VUsName: 3}, timeline[1].Gauges)
+
+	scenarios := c.Scenarios()
+	require.Len(t, scenarios, 2)
+	browse := scenarios[0]
+	assert.Equal(t, "browse", browse.Name)
+	assert.Equal(t, int64(2), browse.Requests)
+	assert.Equal(t, int64(1), browse.FailedRequests)
+	assert.Equal(t, 200.0, browse.RequestDuration.Avg())
+	checkout := scenarios[1]
+	assert.Equal(t, int64(1), checkout.Iterations)
+	assert.Equal(t, int64(1), checkout.ChecksPassed)
+	assert.Equal(t, int64(1), checkout.ChecksFailed)
+}
@@ -0,0 +1,432 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+//go:embed html.tmpl
+var htmlTemplate string
+
+// webVital describes a browser web vital, with the thresholds of its good and
+// poor ratings, which apply to its 75th percentile.
+type webVital struct {
+	metric     string
+	name       string
+	good, poor float64
+}
+
+//nolint:gochecknoglobals
+var webVitals = []webVital{
+	{metric: "browser_web_vital_lcp", name: "Largest Contentful Paint", good: 2500, poor: 4000},
+	{metric: "browser_web_vital_fcp", name: "First Contentful Paint", good: 1800, poor: 3000},
+	{metric: "browser_web_vital_inp", name: "Interaction to Next Paint", good: 200, poor: 500},
+	{metric: "browser_web_vital_fid", name: "First Input Delay", good: 100, poor: 300},
+	{metric: "browser_web_vital_cls", name: "Cumulative Layout Shift", good: 0.1, poor: 0.25},
+	{metric: "browser_web_vital_ttfb", name: "Time to First Byte", good: 800, poor: 1800},
+}
+
+type htmlReport struct {
+	Title      string
+	Time       string
+	Passed     bool
+	Overview   []stat
+	Thresholds []thresholdRow
+	Checks     []checkRow
+	Charts     []chart
+	Trends     []chart
+	Scenarios  []scenarioRow
+	WebVitals  []webVitalRow
+	Metrics    []metricRow
+}
+
+type stat struct {
+	Label, Value string
+	// Status is "ok", "fail" or empty when the stat has no status.
+	Status string
+}
+
+type thresholdRow struct {
+	Metric, Source string
+	Passed         bool
+}
+
+type checkRow struct {
+	Group, Name   string
+	Passes, Fails int64
+	Rate          string
+}
+
+type chart struct {
+	ID, Title string
+	SVG       template.HTML
+}
+
+type scenarioRow struct {
+	Name, Iterations, Requests, FailedRequests, Checks string
+	IterationDuration, RequestDuration                 string
+}
+
+type webVitalRow struct {
+	Name, P75 string
+	// Rating is "good", "needs-improvement" or "poor".
+	Rating string
+}
+
+type metricRow struct {
+	Name, Type, Values string
+}
+
+func generateHTML(w io.Writer, data *Data) error {
+	tmpl, err := template.New("report").Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+
+	r := &htmlReport{
+		Title:  data.ScriptPath,
+		Time:   data.Time.Format(time.RFC1123),
+		Passed: true,
+	}
+	if r.Title == "" {
+		r.Title = "k6 test"
+	}
+	r.addThresholds(data.Summary)
+	r.addChecks(data.Summary.RootGroup)
+	r.addOverview(data.Summary)
+	if data.Collector != nil {
+		r.addCharts(data.Summary, data.Collector.Timeline())
+		r.addScenarios(data.Collector.Scenarios())
+	}
+	r.addWebVitals(data.Summary)
+	r.addMetrics(data.Summary)
+
+	return tmpl.Execute(w, r)
+}
+
+func (r *htmlReport) addThresholds(summary *lib.Summary) {
+	for _, name := range sortedMetricNames(summary) {
+		for _, t := range summary.Metrics[name].Thresholds.Thresholds {
+			r.Thresholds = append(r.Thresholds, thresholdRow{Metric: name, Source: t.Source, Passed: !t.LastFailed})
+			if t.LastFailed {
+				r.Passed = false
+			}
+		}
+	}
+}
+
+func (r *htmlReport) addChecks(group *lib.Group) {
+	if group == nil {
+		return
+	}
+	for _, check := range group.OrderedChecks {
+		r.Checks = append(r.Checks, checkRow{
+			Group:  groupName(group),
+			Name:   check.Name,
+			Passes: check.Passes,
+			Fails:  check.Fails,
+			Rate:   formatPercent(float64(check.Passes) / math.Max(1, float64(check.Passes+check.Fails))),
+		})
+	}
+	for _, g := range group.OrderedGroups {
+		r.addChecks(g)
+	}
+}
+
+func (r *htmlReport) addOverview(summary *lib.Summary) {
+	r.Overview = append(r.Overview, stat{Label: "Duration", Value: summary.TestRunDuration.Round(time.Millisecond).String()})
+
+	if sink, ok := sinkOf[*metrics.CounterSink](summary, metrics.HTTPReqsName); ok {
+		r.Overview = append(r.Overview, stat{
+			Label: "Requests",
+			Value: fmt.Sprintf("%s (%s/s)", formatNumber(sink.Value),
+				formatNumber(sink.Value/summary.TestRunDuration.Seconds())),
+		})
+	}
+	if sink, ok := sinkOf[*metrics.RateSink](summary, metrics.HTTPReqFailedName); ok {
+		status := "ok"
+		if sink.Trues > 0 {
+			status = "fail"
+		}
+		r.Overview = append(r.Overview, stat{
+			Label: "Failed requests", Value: formatPercent(sink.Format(0)["rate"]), Status: status,
+		})
+	}
+	if sink, ok := sinkOf[*metrics.TrendSink](summary, metrics.HTTPReqDurationName); ok {
+		r.Overview = append(r.Overview, stat{Label: "Request duration p(95)", Value: formatTime(sink.P(0.95))})
+	}
+	if sink, ok := sinkOf[*metrics.CounterSink](summary, metrics.IterationsName); ok {
+		r.Overview = append(r.Overview, stat{Label: "Iterations", Value: formatNumber(sink.Value)})
+	}
+	if sink, ok := sinkOf[*metrics.GaugeSink](summary, metrics.VUsMaxName); ok {
+		r.Overview = append(r.Overview, stat{Label: "Max VUs", Value: formatNumber(sink.Max)})
+	}
+	if len(r.Checks) > 0 {
+		var passes, total int64
+		for _, c := range r.Checks {
+			passes += c.Passes
+			total += c.Passes + c.Fails
+		}
+		status := "ok"
+		if passes < total {
+			status = "fail"
+		}
+		r.Overview = append(r.Overview, stat{
+			Label: "Checks", Value: formatPercent(float64(passes) / float64(total)), Status: status,
+		})
+	}
+	if len(r.Thresholds) > 0 {
+		passed := 0
+		for _, t := range r.Thresholds {
+			if t.Passed {
+				passed++
+			}
+		}
+		status := "ok"
+		if !r.Passed {
+			status = "fail"
+		}
+		r.Overview = append(r.Overview, stat{
+			Label: "Thresholds", Value: fmt.Sprintf("%d/%d passed", passed, len(r.Thresholds)), Status: status,
+		})
+	}
+}
+
+func (r *htmlReport) addCharts(summary *lib.Summary, timeline []Point) {
+	if len(timeline) == 0 {
+		return
+	}
+
+	percentiles := func(name string) []chartSeries {
+		return []chartSeries{
+			{Name: "p(50)", Value: trendValue(name, func(p TrendPoint) float64 { return p.P50 })},
+			{Name: "p(90)", Value: trendValue(name, func(p TrendPoint) float64 { return p.P90 })},
+			{Name: "p(95)", Value: trendValue(name, func(p TrendPoint) float64 { return p.P95 })},
+			{Name: "p(99)", Value: trendValue(name, func(p TrendPoint) float64 { return p.P99 })},
+		}
+	}
+
+	if _, ok := summary.Metrics[metrics.HTTPReqDurationName]; ok {
+		r.Charts = append(r.Charts, chart{
+			ID:    "http-req-duration",
+			Title: "HTTP request duration",
+			SVG:   renderChart(timeline, percentiles(metrics.HTTPReqDurationName), formatTime),
+		})
+	}
+	var throughput []chartSeries
+	for _, name := range []string{metrics.HTTPReqsName, metrics.IterationsName} {
+		if _, ok := summary.Metrics[name]; ok {
+			name := name
+			throughput = append(throughput, chartSeries{Name: name + "/s", Value: func(p Point) (float64, bool) {
+				v, ok := p.Counters[name]
+				return v, ok
+			}})
+		}
+	}
+	if len(throughput) > 0 {
+		r.Charts = append(r.Charts, chart{ID: "throughput", Title: "Throughput", SVG: renderChart(timeline, throughput, formatNumber)})
+	}
+	if _, ok := summary.Metrics[metrics.HTTPReqFailedName]; ok {
+		r.Charts = append(r.Charts, chart{
+			ID:    "http-req-failed",
+			Title: "Failed requests",
+			SVG: renderChart(timeline, []chartSeries{{Name: metrics.HTTPReqFailedName, Value: func(p Point) (float64, bool) {
+				v, ok := p.Rates[metrics.HTTPReqFailedName]
+				return v, ok
+			}}}, formatPercent),
+		})
+	}
+	if _, ok := summary.Metrics[metrics.VUsName]; ok {
+		r.Charts = append(r.Charts, chart{
+			ID:    "vus",
+			Title: "Virtual users",
+			SVG: renderChart(timeline, []chartSeries{{Name: metrics.VUsName, Value: func(p Point) (float64, bool) {
+				v, ok := p.Gauges[metrics.VUsName]
+				return v, ok
+			}}}, formatNumber),
+		})
+	}
+
+	for _, name := range sortedMetricNames(summary) {
+		m := summary.Metrics[name]
+		if m.Type != metrics.Trend || m.Sub != nil || name == metrics.HTTPReqDurationName {
+			continue
+		}
+		format := formatNumber
+		if m.Contains == metrics.Time {
+			format = formatTime
+		}
+		r.Trends = append(r.Trends, chart{
+			ID:    "trend-" + name,
+			Title: name,
+			SVG:   renderChart(timeline, percentiles(name), format),
+		})
+	}
+}
+
+func trendValue(name string, value func(TrendPoint) float64) func(Point) (float64, bool) {
+	return func(p Point) (float64, bool) {
+		tp, ok := p.Trends[name]
+		return value(tp), ok
+	}
+}
+
+func (r *htmlReport) addScenarios(scenarios []*Scenario) {
+	for _, s := range scenarios {
+		row := scenarioRow{
+			Name:           s.Name,
+			Iterations:     formatNumber(float64(s.Iterations)),
+			Requests:       formatNumber(float64(s.Requests)),
+			FailedRequests: "-",
+			Checks:         "-",
+		}
+		if s.Requests > 0 {
+			row.FailedRequests = formatPercent(float64(s.FailedRequests) / float64(s.Requests))
+		}
+		if total := s.ChecksPassed + s.ChecksFailed; total > 0 {
+			row.Checks = formatPercent(float64(s.ChecksPassed) / float64(total))
+		}
+		row.IterationDuration = formatTrend(s.IterationDuration)
+		row.RequestDuration = formatTrend(s.RequestDuration)
+		r.Scenarios = append(r.Scenarios, row)
+	}
+}
+
+func formatTrend(sink *metrics.TrendSink) string {
+	if sink.IsEmpty() {
+		return "-"
+	}
+	return fmt.Sprintf("avg=%s p(95)=%s", formatTime(sink.Avg()), formatTime(sink.P(0.95)))
+}
+
+func (r *htmlReport) addWebVitals(summary *lib.Summary) {
+	for _, wv := range webVitals {
+		sink, ok := sinkOf[*metrics.TrendSink](summary, wv.metric)
+		if !ok {
+			continue
+		}
+		p75 := sink.P(0.75)
+		row := webVitalRow{Name: wv.name, Rating: "good"}
+		switch {
+		case p75 > wv.poor:
+			row.Rating = "poor"
+		case p75 > wv.good:
+			row.Rating = "needs-improvement"
+		}
+		if summary.Metrics[wv.metric].Contains == metrics.Time {
+			row.P75 = formatTime(p75)
+		} else {
+			row.P75 = formatNumber(p75)
+		}
+		r.WebVitals = append(r.WebVitals, row)
+	}
+}
+
+func (r *htmlReport) addMetrics(summary *lib.Summary) {
+	for _, name := range sortedMetricNames(summary) {
+		m := summary.Metrics[name]
+		r.Metrics = append(r.Metrics, metricRow{
+			Name: name, Type: m.Type.String(), Values: formatValues(m, summary.TestRunDuration),
+		})
+	}
+}
+
+// formatValues formats the values of the metric's sink the way the
+// end-of-test summary does.
+func formatValues(m *metrics.Metric, duration time.Duration) string {
+	switch sink := m.Sink.(type) {
+	case *metrics.TrendSink:
+		format := formatNumber
+		if m.Contains == metrics.Time {
+			format = formatTime
+		}
+		return fmt.Sprintf("avg=%s min=%s med=%s max=%s p(90)=%s p(95)=%s",
+			format(sink.Avg()), format(sink.Min()), format(sink.P(0.5)), format(sink.Max()),
+			format(sink.P(0.9)), format(sink.P(0.95)))
+	case *metrics.CounterSink:
+		format := formatNumber
+		if m.Contains == metrics.Data {
+			format = formatData
+		}
+		return fmt.Sprintf("%s %s/s", format(sink.Value), format(sink.Value/duration.Seconds()))
+	case *metrics.GaugeSink:
+		return fmt.Sprintf("%s min=%s max=%s", formatNumber(sink.Value), formatNumber(sink.Min), formatNumber(sink.Max))
+	case *metrics.RateSink:
+		return fmt.Sprintf("%s %d out of %d", formatPercent(sink.Format(0)["rate"]), sink.Trues, sink.Total)
+	default:
+		return ""
+	}
+}
+
+// sinkOf returns the sink of the metric, if the metric was observed and its
+// sink is of the expected type.
+func sinkOf[T metrics.Sink](summary *lib.Summary, name string) (T, bool) {
+	var zero T
+	m, ok := summary.Metrics[name]
+	if !ok || m.Sink == nil {
+		return zero, false
+	}
+	sink, ok := m.Sink.(T)
+	return sink, ok
+}
+
+// groupName returns the path of the group, without the root group, for display.
+func groupName(group *lib.Group) string {
+	return strings.ReplaceAll(strings.TrimPrefix(group.Path, lib.GroupSeparator), lib.GroupSeparator, " › ")
+}
+
+func sortedMetricNames(summary *lib.Summary) []string {
+	names := make([]string, 0, len(summary.Metrics))
+	for name := range summary.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatTime formats a time in milliseconds, as k6 emits them.
+func formatTime(ms float64) string {
+	d := time.Duration(ms * float64(time.Millisecond))
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%.2fµs", ms*1000)
+	case d < time.Second:
+		return fmt.Sprintf("%.2fms", ms)
+	case d < time.Minute:
+		return fmt.Sprintf("%.2fs", ms/1000)
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+func formatData(bytes float64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	exp := int(math.Log(bytes) / math.Log(unit))
+	if exp > 4 {
+		exp = 4
+	}
+	return fmt.Sprintf("%.1f %cB", bytes/math.Pow(unit, float64(exp)), "kMGT"[exp-1])
+}
+
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
+
+func formatPercent(ratio float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", ratio*100), "0"), ".") + "%"
+}
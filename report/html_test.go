@@ -0,0 +1,103 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func TestGenerateHTML(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	lcp, err := registry.NewMetric("browser_web_vital_lcp", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+
+	start := time.Date(2023, time.October, 15, 10, 30, 0, 0, time.UTC)
+	tags := registry.RootTagSet().With("scenario", "browse")
+	c := NewCollector(testutils.NewLogger(t), nil)
+	require.NoError(t, c.Start())
+	summaryMetrics := make(map[string]*metrics.Metric)
+	var samples metrics.Samples
+	add := func(m *metrics.Metric, offset time.Duration, value float64) {
+		sample := metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags},
+			Time:       start.Add(offset),
+			Value:      value,
+		}
+		samples = append(samples, sample)
+		if _, ok := summaryMetrics[m.Name]; !ok {
+			m.Sink = metrics.NewSink(m.Type)
+			summaryMetrics[m.Name] = m
+		}
+		m.Sink.Add(sample)
+	}
+	for i := 0; i < 10; i++ {
+		offset := time.Duration(i) * time.Second
+		add(builtin.HTTPReqs, offset, 1)
+		add(builtin.HTTPReqDuration, offset, float64(100+i*10))
+		add(builtin.HTTPReqFailed, offset, float64(i%5/4))
+		add(builtin.Iterations, offset, 1)
+		add(builtin.VUs, offset, 2)
+		add(builtin.DataReceived, offset, 2048)
+	}
+	add(lcp, 0, 3000)
+	c.AddMetricSamples([]metrics.SampleContainer{samples})
+	require.NoError(t, c.Stop())
+
+	builtin.HTTPReqDuration.Thresholds = metrics.NewThresholds([]string{"p(95)<500"})
+	builtin.HTTPReqFailed.Thresholds = metrics.NewThresholds([]string{"rate<0.01"})
+	builtin.HTTPReqFailed.Thresholds.Thresholds[0].LastFailed = true
+
+	rootGroup, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	group, err := rootGroup.Group("login")
+	require.NoError(t, err)
+	check, err := group.Check("status is 200")
+	require.NoError(t, err)
+	check.Passes, check.Fails = 9, 1
+
+	var buf bytes.Buffer
+	require.NoError(t, Report{Type: "html"}.Generate(&buf, &Data{
+		Summary: &lib.Summary{
+			Metrics:         summaryMetrics,
+			RootGroup:       rootGroup,
+			TestRunDuration: 10 * time.Second,
+		},
+		Collector:  c,
+		ScriptPath: "file:///tests/<script>.js",
+		Time:       start.Add(10 * time.Second),
+	}))
+	html := buf.String()
+
+	assert.Contains(t, html, "<title>k6 report - file:///tests/&lt;script&gt;.js</title>")
+	assert.Contains(t, html, `<span class="badge fail">failed</span>`)
+	assert.Contains(t, html, `<div class="stat fail"><div class="label">Thresholds</div><div class="value">1/2 passed</div></div>`)
+	assert.Contains(t, html, `<div class="stat "><div class="label">Requests</div><div class="value">10 (1/s)</div></div>`)
+	assert.Contains(t, html, `<td>http_req_duration</td><td><code>p(95)&lt;500</code></td><td class="ok">✓ passed</td>`)
+	assert.Contains(t, html, `<td>http_req_failed</td><td><code>rate&lt;0.01</code></td><td class="fail">✗ failed</td>`)
+	assert.Contains(t, html, `<td>login</td><td>status is 200</td><td class="num">9</td><td class="num">1</td><td class="num fail">90%</td>`)
+	assert.Contains(t, html, `<div id="http-req-duration"><h3>HTTP request duration</h3><svg class="chart"`)
+	assert.Contains(t, html, `<title>p(95) at 9s: 190.00ms</title>`)
+	assert.Contains(t, html, `<option value="trend-browser_web_vital_lcp">browser_web_vital_lcp</option>`)
+	assert.Contains(t, html, `<td>browse</td><td class="num">10</td><td>-</td><td class="num">10</td><td class="num">20%</td>`)
+	assert.Contains(t, html, `<td>Largest Contentful Paint</td><td class="num">3.00s</td><td class="needs-improvement">needs-improvement</td>`)
+	assert.Contains(t, html, `<td>data_received</td><td>counter</td><td><code>20.5 kB 2.0 kB/s</code></td>`)
+}
+
+func TestFormatTime(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "500.00µs", formatTime(0.5))
+	assert.Equal(t, "123.46ms", formatTime(123.456))
+	assert.Equal(t, "1.50s", formatTime(1500))
+	assert.Equal(t, "2m5s", formatTime(125000))
+}
@@ -0,0 +1,142 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"go.k6.io/k6/lib"
+)
+
+// The JUnit XML report has a "thresholds" test suite, with a test case for
+// each threshold, and a "checks" one, with a test case for each check, so CI
+// systems can show the outcomes of the test like the ones of unit tests.
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func generateJUnit(w io.Writer, data *Data) error {
+	duration := data.Summary.TestRunDuration
+	newSuite := func(name string) junitTestSuite {
+		return junitTestSuite{
+			Name:      name,
+			Time:      formatSeconds(duration),
+			Timestamp: data.Time.Add(-duration).UTC().Format("2006-01-02T15:04:05"),
+		}
+	}
+
+	thresholds := newSuite("thresholds")
+	for _, name := range sortedMetricNames(data.Summary) {
+		m := data.Summary.Metrics[name]
+		for _, t := range m.Thresholds.Thresholds {
+			// the thresholds are evaluated over the whole test run
+			tc := junitTestCase{
+				Name:      t.Source,
+				Classname: name,
+				Time:      formatSeconds(duration),
+				SystemOut: formatValues(m, duration),
+			}
+			if t.LastFailed {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("threshold '%s' on metric '%s' has been crossed", t.Source, name),
+					Type:    "threshold",
+					Text:    tc.SystemOut,
+				}
+			}
+			thresholds.add(tc)
+		}
+	}
+
+	checks := newSuite("checks")
+	addJUnitChecks(&checks, data.Summary.RootGroup)
+
+	suites := junitTestSuites{Name: "k6", Time: formatSeconds(duration)}
+	for _, s := range []junitTestSuite{thresholds, checks} {
+		suites.Tests += s.Tests
+		suites.Failures += s.Failures
+		suites.Suites = append(suites.Suites, s)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func addJUnitChecks(suite *junitTestSuite, group *lib.Group) {
+	if group == nil {
+		return
+	}
+	classname := "checks"
+	if name := groupName(group); name != "" {
+		classname = name
+	}
+	for _, check := range group.OrderedChecks {
+		tc := junitTestCase{
+			Name:      check.Name,
+			Classname: classname,
+			Time:      "0",
+			SystemOut: fmt.Sprintf("%d passes, %d fails", check.Passes, check.Fails),
+		}
+		if check.Fails > 0 {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d out of %d checks failed", check.Fails, check.Passes+check.Fails),
+				Type:    "check",
+				Text:    tc.SystemOut,
+			}
+		}
+		suite.add(tc)
+	}
+	for _, g := range group.OrderedGroups {
+		addJUnitChecks(suite, g)
+	}
+}
+
+func (s *junitTestSuite) add(tc junitTestCase) {
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	s.Cases = append(s.Cases, tc)
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
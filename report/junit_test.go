@@ -0,0 +1,69 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestGenerateJUnit(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	builtin.HTTPReqDuration.Sink = metrics.NewSink(metrics.Trend)
+	builtin.HTTPReqDuration.Sink.Add(metrics.Sample{Value: 120})
+	builtin.HTTPReqDuration.Thresholds = metrics.NewThresholds([]string{"p(95)<500", "max<100"})
+	builtin.HTTPReqDuration.Thresholds.Thresholds[1].LastFailed = true
+
+	rootGroup, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	check, err := rootGroup.Check("status is 200")
+	require.NoError(t, err)
+	check.Passes = 10
+	group, err := rootGroup.Group("login")
+	require.NoError(t, err)
+	check, err = group.Check("has <token>")
+	require.NoError(t, err)
+	check.Passes, check.Fails = 9, 1
+
+	var buf bytes.Buffer
+	require.NoError(t, Report{Type: "junit"}.Generate(&buf, &Data{
+		Summary: &lib.Summary{
+			Metrics:         map[string]*metrics.Metric{metrics.HTTPReqDurationName: builtin.HTTPReqDuration},
+			RootGroup:       rootGroup,
+			TestRunDuration: 1500 * time.Millisecond,
+		},
+		Time: time.Date(2023, time.October, 15, 10, 30, 0, 0, time.UTC),
+	}))
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="k6" tests="4" failures="2" time="1.500">
+  <testsuite name="thresholds" tests="2" failures="1" time="1.500" timestamp="2023-10-15T10:29:58">
+    <testcase name="p(95)&lt;500" classname="http_req_duration" time="1.500">
+      <system-out>avg=120.00ms min=120.00ms med=120.00ms max=120.00ms p(90)=120.00ms p(95)=120.00ms</system-out>
+    </testcase>
+    <testcase name="max&lt;100" classname="http_req_duration" time="1.500">
+      <failure message="threshold &#39;max&lt;100&#39; on metric &#39;http_req_duration&#39; has been crossed" type="threshold">avg=120.00ms min=120.00ms med=120.00ms max=120.00ms p(90)=120.00ms p(95)=120.00ms</failure>
+      <system-out>avg=120.00ms min=120.00ms med=120.00ms max=120.00ms p(90)=120.00ms p(95)=120.00ms</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="checks" tests="2" failures="1" time="1.500" timestamp="2023-10-15T10:29:58">
+    <testcase name="status is 200" classname="checks" time="0">
+      <system-out>10 passes, 0 fails</system-out>
+    </testcase>
+    <testcase name="has &lt;token&gt;" classname="login" time="0">
+      <failure message="1 out of 10 checks failed" type="check">9 passes, 1 fails</failure>
+      <system-out>9 passes, 1 fails</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,88 @@
+// Package report generates the end-of-test reports requested with the
+// --report option, e.g. `k6 run --report html=report.html script.js`.
+// The supported types are html, for an interactive report, junit, for the
+// JUnit XML format read by the CI systems, markdown, e.g. for the comments of
+// pull requests, and template, for a text/template provided by the user with
+// the --summary-template option.
+//
+// The reports are generated from the end-of-test summary, with the thresholds
+// and the checks, and from the data of a Collector, an output aggregating the
+// metrics over time and per scenario during the test.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.k6.io/k6/lib"
+)
+
+// Data is what the reports are generated from.
+type Data struct {
+	Summary   *lib.Summary
+	Collector *Collector
+	// ScriptPath is the path or the URL of the test's script.
+	ScriptPath string
+	// Time is when the test finished.
+	Time time.Time
+	// Template is the template of the template reports, parsed with
+	// ParseTemplate; it's nil if there isn't one.
+	Template *template.Template
+}
+
+type generator func(w io.Writer, data *Data) error
+
+//nolint:gochecknoglobals
+var (
+	generators = map[string]generator{
+		"html":     generateHTML,
+		"junit":    generateJUnit,
+		"markdown": generateMarkdown,
+		"template": generateTemplate,
+	}
+	defaultPaths = map[string]string{
+		"html":     "report.html",
+		"junit":    "junit.xml",
+		"markdown": "summary.md",
+		"template": "summary.txt",
+	}
+)
+
+// Report is a requested report.
+type Report struct {
+	Type string
+	Path string
+}
+
+// Parse parses a report argument, with the `type=path` format. The path can
+// be omitted, in which case the type's default one is used.
+func Parse(arg string) (Report, error) {
+	typ, path, _ := strings.Cut(arg, "=")
+	if _, ok := generators[typ]; !ok {
+		return Report{}, fmt.Errorf("invalid report type '%s', available types are: %s",
+			typ, strings.Join(Types(), ", "))
+	}
+	if path == "" {
+		path = defaultPaths[typ]
+	}
+	return Report{Type: typ, Path: path}, nil
+}
+
+// Types returns the supported report types, sorted.
+func Types() []string {
+	types := make([]string, 0, len(generators))
+	for typ := range generators {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Generate writes the report to w.
+func (r Report) Generate(w io.Writer, data *Data) error {
+	return generators[r.Type](w, data)
+}
@@ -0,0 +1,23 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	r, err := Parse("html=results/run.html")
+	require.NoError(t, err)
+	assert.Equal(t, Report{Type: "html", Path: "results/run.html"}, r)
+
+	r, err = Parse("html")
+	require.NoError(t, err)
+	assert.Equal(t, Report{Type: "html", Path: "report.html"}, r)
+
+	_, err = Parse("pdf=report.pdf")
+	assert.EqualError(t, err, "invalid report type 'pdf', available types are: html, junit, markdown, template")
+}
@@ -0,0 +1,168 @@
+package report
+
+import (
+	_ "embed"
+	"errors"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+//go:embed markdown.tmpl
+var markdownTemplate string
+
+// TemplateData is what the templates of the markdown report and of the
+// template reports, the user-provided ones, are executed with.
+type TemplateData struct {
+	// Script is the path or the URL of the test's script.
+	Script string
+	// Time is when the test finished.
+	Time     time.Time
+	Duration time.Duration
+	// Passed is whether all the thresholds passed.
+	Passed     bool
+	Thresholds []TemplateThreshold
+	Checks     []TemplateCheck
+	// Metrics are sorted by name, the submetrics after their metric.
+	Metrics []*TemplateMetric
+	// Scenarios are the breakdowns of the scenarios, sorted by name.
+	Scenarios []*Scenario
+}
+
+// TemplateThreshold is the outcome of a threshold.
+type TemplateThreshold struct {
+	Metric, Source string
+	Passed         bool
+}
+
+// TemplateCheck is the outcome of a check, Group is the path of its group,
+// empty for the root group.
+type TemplateCheck struct {
+	Group, Name   string
+	Passes, Fails int64
+}
+
+// TemplateMetric is a metric with its values, the ones of the end-of-test
+// summary, in milliseconds for the times.
+type TemplateMetric struct {
+	Name, Type, Contains string
+	Values               map[string]float64
+	// Formatted are the values formatted like in the end-of-test summary.
+	Formatted string
+}
+
+// Metric returns the metric or submetric with the name, or nil if it wasn't
+// observed.
+func (d *TemplateData) Metric(name string) *TemplateMetric {
+	for _, m := range d.Metrics {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// ParseTemplate parses the source of a template report, with the functions
+// formatting the values like the reports.
+func ParseTemplate(name, source string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{
+		"time":    formatTime,
+		"number":  formatNumber,
+		"percent": formatPercent,
+		"data":    formatData,
+		"cell":    markdownCell,
+	}).Option("missingkey=zero").Parse(source)
+}
+
+func generateTemplate(w io.Writer, data *Data) error {
+	if data.Template == nil {
+		return errors.New("the template report needs a template, set with the --summary-template option")
+	}
+	return data.Template.Execute(w, newTemplateData(data))
+}
+
+func generateMarkdown(w io.Writer, data *Data) error {
+	tmpl, err := ParseTemplate("markdown", markdownTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, newTemplateData(data))
+}
+
+func newTemplateData(data *Data) *TemplateData {
+	summary := data.Summary
+	d := &TemplateData{
+		Script:   data.ScriptPath,
+		Time:     data.Time,
+		Duration: summary.TestRunDuration,
+		Passed:   true,
+	}
+	for _, name := range sortedMetricNames(summary) {
+		m := summary.Metrics[name]
+		for _, t := range m.Thresholds.Thresholds {
+			d.Thresholds = append(d.Thresholds, TemplateThreshold{Metric: name, Source: t.Source, Passed: !t.LastFailed})
+			d.Passed = d.Passed && !t.LastFailed
+		}
+		d.Metrics = append(d.Metrics, &TemplateMetric{
+			Name:      name,
+			Type:      m.Type.String(),
+			Contains:  m.Contains.String(),
+			Values:    metricValues(m, summary.TestRunDuration),
+			Formatted: formatValues(m, summary.TestRunDuration),
+		})
+	}
+	d.addChecks(summary.RootGroup)
+	if data.Collector != nil {
+		d.Scenarios = data.Collector.Scenarios()
+	}
+	return d
+}
+
+func (d *TemplateData) addChecks(group *lib.Group) {
+	if group == nil {
+		return
+	}
+	for _, check := range group.OrderedChecks {
+		d.Checks = append(d.Checks, TemplateCheck{
+			Group: groupName(group), Name: check.Name, Passes: check.Passes, Fails: check.Fails,
+		})
+	}
+	for _, g := range group.OrderedGroups {
+		d.addChecks(g)
+	}
+}
+
+// metricValues returns the values of the metric's sink, with the names of the
+// end-of-test summary.
+func metricValues(m *metrics.Metric, duration time.Duration) map[string]float64 {
+	switch sink := m.Sink.(type) {
+	case *metrics.TrendSink:
+		return map[string]float64{
+			"avg": sink.Avg(), "min": sink.Min(), "med": sink.P(0.5), "max": sink.Max(),
+			"p(90)": sink.P(0.9), "p(95)": sink.P(0.95), "p(99)": sink.P(0.99), "count": float64(sink.Count()),
+		}
+	case *metrics.CounterSink:
+		rate := 0.0
+		if duration > 0 {
+			rate = sink.Value / duration.Seconds()
+		}
+		return map[string]float64{"count": sink.Value, "rate": rate}
+	case *metrics.GaugeSink:
+		return map[string]float64{"value": sink.Value, "min": sink.Min, "max": sink.Max}
+	case *metrics.RateSink:
+		return map[string]float64{
+			"rate": sink.Format(0)["rate"], "passes": float64(sink.Trues), "fails": float64(sink.Total - sink.Trues),
+		}
+	default:
+		return map[string]float64{}
+	}
+}
+
+// markdownCell escapes the value for a cell of a markdown table.
+func markdownCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(value)
+}
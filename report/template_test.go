@@ -0,0 +1,106 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func newTemplateTestData(t *testing.T) *Data {
+	t.Helper()
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	builtin.HTTPReqDuration.Sink = metrics.NewSink(metrics.Trend)
+	builtin.HTTPReqDuration.Sink.Add(metrics.Sample{Value: 120})
+	builtin.HTTPReqDuration.Thresholds = metrics.NewThresholds([]string{"p(95)<500", "max<100"})
+	builtin.HTTPReqDuration.Thresholds.Thresholds[1].LastFailed = true
+	builtin.HTTPReqs.Sink = metrics.NewSink(metrics.Counter)
+	builtin.HTTPReqs.Sink.Add(metrics.Sample{Value: 3})
+
+	rootGroup, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	check, err := rootGroup.Check("status is 200")
+	require.NoError(t, err)
+	check.Passes = 10
+	group, err := rootGroup.Group("login")
+	require.NoError(t, err)
+	check, err = group.Check("has a | in it")
+	require.NoError(t, err)
+	check.Passes, check.Fails = 9, 1
+
+	return &Data{
+		Summary: &lib.Summary{
+			Metrics: map[string]*metrics.Metric{
+				metrics.HTTPReqDurationName: builtin.HTTPReqDuration,
+				metrics.HTTPReqsName:        builtin.HTTPReqs,
+			},
+			RootGroup:       rootGroup,
+			TestRunDuration: 1500 * time.Millisecond,
+		},
+		ScriptPath: "script.js",
+		Time:       time.Date(2023, time.October, 15, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, Report{Type: "markdown"}.Generate(&buf, newTemplateTestData(t)))
+
+	expected := "## ❌ k6 test `script.js`\n" +
+		"\n" +
+		"Duration: 1.5s · Requests: 3 (2/s) · Request duration p(95): 120.00ms\n" +
+		"\n" +
+		"### Thresholds\n" +
+		"\n" +
+		"| Metric | Threshold | Result |\n" +
+		"| --- | --- | --- |\n" +
+		"| http_req_duration | `p(95)<500` | ✅ passed |\n" +
+		"| http_req_duration | `max<100` | ❌ failed |\n" +
+		"\n" +
+		"### Checks\n" +
+		"\n" +
+		"| Group | Check | Passes | Fails |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"|  | ✅ status is 200 | 10 | 0 |\n" +
+		"| login | ❌ has a \\| in it | 9 | 1 |\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary>Metrics</summary>\n" +
+		"\n" +
+		"| Metric | Type | Values |\n" +
+		"| --- | --- | --- |\n" +
+		"| http_req_duration | trend | avg=120.00ms min=120.00ms med=120.00ms max=120.00ms p(90)=120.00ms p(95)=120.00ms |\n" +
+		"| http_reqs | counter | 3 2/s |\n" +
+		"\n" +
+		"</details>\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestGenerateTemplate(t *testing.T) {
+	t.Parallel()
+
+	data := newTemplateTestData(t)
+	var buf bytes.Buffer
+	err := Report{Type: "template"}.Generate(&buf, data)
+	require.EqualError(t, err, "the template report needs a template, set with the --summary-template option")
+
+	data.Template, err = ParseTemplate("ci.tmpl", `{{ if .Passed }}PASS{{ else }}FAIL{{ end }} {{ .Script }}
+{{- with .Metric "http_req_duration" }} p95={{ time (index .Values "p(95)") }}{{ end }}
+{{- with .Metric "missing" }} never{{ end }}
+{{- range .Checks }} {{ .Name }}={{ .Passes }}/{{ .Fails }}{{ end }}`)
+	require.NoError(t, err)
+	require.NoError(t, Report{Type: "template"}.Generate(&buf, data))
+	assert.Equal(t, "FAIL script.js p95=120.00ms status is 200=10/0 has a | in it=9/1", buf.String())
+
+	_, err = ParseTemplate("broken.tmpl", "{{ .Passed ")
+	assert.ErrorContains(t, err, "broken.tmpl")
+}
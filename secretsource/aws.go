@@ -0,0 +1,215 @@
+package secretsource
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The environment variables of the region and the credentials of AWS.
+const (
+	awsRegionEnvVar        = "AWS_REGION"
+	awsDefaultRegionEnvVar = "AWS_DEFAULT_REGION"
+	awsAccessKeyEnvVar     = "AWS_ACCESS_KEY_ID"
+	awsSecretKeyEnvVar     = "AWS_SECRET_ACCESS_KEY" //nolint:gosec
+	awsSessionTokenEnvVar  = "AWS_SESSION_TOKEN"     //nolint:gosec
+)
+
+const awsTimeout = 10 * time.Second
+
+// awsCredentials are the credentials requests are signed with.
+type awsCredentials struct {
+	accessKey, secretKey, sessionToken string
+}
+
+// awsSource has the secrets of AWS Secrets Manager, the keys are the names of
+// the secrets, with an optional prefix.
+type awsSource struct {
+	region, endpoint, prefix string
+	credentials              awsCredentials
+	client                   *http.Client
+	now                      func() time.Time
+}
+
+// NewAWSSource returns the source of AWS Secrets Manager, with the options of
+// the config argument, like `region=eu-west-1,prefix=k6/`. The region is
+// AWS_REGION by default, and the credentials are always the ones of the AWS
+// environment variables.
+func NewAWSSource(params Params) (Source, error) {
+	config, err := parseConfig("aws", params.ConfigArgument, "region", "endpoint", "prefix")
+	if err != nil {
+		return nil, err
+	}
+	as := &awsSource{
+		region:   config["region"],
+		endpoint: config["endpoint"],
+		prefix:   config["prefix"],
+		credentials: awsCredentials{
+			accessKey:    params.Environment[awsAccessKeyEnvVar],
+			secretKey:    params.Environment[awsSecretKeyEnvVar],
+			sessionToken: params.Environment[awsSessionTokenEnvVar],
+		},
+		client: &http.Client{Timeout: awsTimeout},
+		now:    time.Now,
+	}
+	if as.region == "" {
+		as.region = params.Environment[awsRegionEnvVar]
+	}
+	if as.region == "" {
+		as.region = params.Environment[awsDefaultRegionEnvVar]
+	}
+	switch {
+	case as.region == "":
+		return nil, fmt.Errorf("the aws secret source needs a region, with the region option or %s", awsRegionEnvVar)
+	case as.credentials.accessKey == "" || as.credentials.secretKey == "":
+		return nil, fmt.Errorf("the aws secret source needs the credentials of %s and %s",
+			awsAccessKeyEnvVar, awsSecretKeyEnvVar)
+	}
+	if as.endpoint == "" {
+		as.endpoint = "https://secretsmanager." + as.region + ".amazonaws.com"
+	}
+	return as, nil
+}
+
+func (as *awsSource) Description() string {
+	return "aws secrets manager " + as.region
+}
+
+// Get calls the GetSecretValue action for the secret with the prefixed key.
+func (as *awsSource) Get(key string) (string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": as.prefix + key})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, as.endpoint+"/", bytes.NewReader(body)) //nolint:noctx
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, body, as.credentials, as.region, "secretsmanager", as.now())
+
+	res, err := as.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(resBody, &awsErr)
+		if strings.HasSuffix(awsErr.Type, "ResourceNotFoundException") {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("aws returned the status %d: %s %s", res.StatusCode, awsErr.Type, awsErr.Message)
+	}
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err = json.Unmarshal(resBody, &secret); err != nil {
+		return "", fmt.Errorf("couldn't parse the response of aws: %w", err)
+	}
+	if secret.SecretString == nil {
+		return "", errors.New("only the string secrets are supported")
+	}
+	return *secret.SecretString, nil
+}
+
+// signAWSRequest signs the request with the AWS Signature Version 4, the Host,
+// the Content-Type and the X-Amz-* headers are signed.
+func signAWSRequest(req *http.Request, body []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, canonicalQuery(req.URL.Query()), canonicalHeaders.String(), signedHeaders, sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape escapes all of the characters but the unreserved ones of RFC 3986.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
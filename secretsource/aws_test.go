@@ -0,0 +1,89 @@
+package secretsource
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAWSRequest(t *testing.T) {
+	t.Parallel()
+
+	// the get-vanilla case of the AWS Signature Version 4 test suite
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	creds := awsCredentials{accessKey: "AKIDEXAMPLE", secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, "+
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestAWSSource(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKID/20240102/eu-west-1/secretsmanager/aws4_request, "+
+				"SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature="))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var input struct{ SecretId string } //nolint:revive,stylecheck
+		require.NoError(t, json.Unmarshal(body, &input))
+		switch input.SecretId {
+		case "k6/token":
+			_, _ = w.Write([]byte(`{"Name":"k6/token","SecretString":"s3cr3t"}`))
+		case "k6/denied":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"AccessDeniedException","message":"not allowed"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	source, err := NewAWSSource(Params{
+		ConfigArgument: "endpoint=" + srv.URL + ",prefix=k6/",
+		Environment: map[string]string{
+			"AWS_REGION":            "eu-west-1",
+			"AWS_ACCESS_KEY_ID":     "AKID",
+			"AWS_SECRET_ACCESS_KEY": "secret",
+			"AWS_SESSION_TOKEN":     "session",
+		},
+	})
+	require.NoError(t, err)
+	source.(*awsSource).now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	value, err := source.Get("token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+	_, err = source.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = source.Get("denied")
+	assert.EqualError(t, err, "aws returned the status 400: AccessDeniedException not allowed")
+}
+
+func TestNewAWSSourceErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewAWSSource(Params{Environment: map[string]string{}})
+	assert.ErrorContains(t, err, "needs a region")
+	_, err = NewAWSSource(Params{ConfigArgument: "region=eu-west-1", Environment: map[string]string{}})
+	assert.ErrorContains(t, err, "needs the credentials of AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	_, err = NewAWSSource(Params{ConfigArgument: "bucket=x"})
+	assert.EqualError(t, err, "invalid aws secret source option 'bucket=x', the options are region, endpoint, prefix")
+}
@@ -0,0 +1,37 @@
+package secretsource
+
+import "fmt"
+
+// envSource has the secret of a system environment variable, it's also given
+// to the scripts as an environment variable.
+type envSource struct {
+	name, value string
+}
+
+var _ EnvSource = &envSource{}
+
+// NewEnvSource returns the source of the environment variable of the config
+// argument, which must be set.
+func NewEnvSource(params Params) (Source, error) {
+	name := params.ConfigArgument
+	value, ok := params.Environment[name]
+	if !ok {
+		return nil, fmt.Errorf("the environment variable '%s' of the secret source isn't set", name)
+	}
+	return &envSource{name: name, value: value}, nil
+}
+
+func (es *envSource) Description() string {
+	return "environment variable " + es.name
+}
+
+func (es *envSource) Get(key string) (string, error) {
+	if key != es.name {
+		return "", ErrNotFound
+	}
+	return es.value, nil
+}
+
+func (es *envSource) EnvVars() map[string]string {
+	return map[string]string{es.name: es.value}
+}
@@ -0,0 +1,73 @@
+package secretsource
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+var envVarName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// fileSource has the secrets of a file with KEY=value lines, they're also
+// given to the scripts as environment variables.
+type fileSource struct {
+	path    string
+	secrets map[string]string
+}
+
+var _ EnvSource = &fileSource{}
+
+// NewFileSource reads the KEY=value lines of the file of the config argument,
+// the empty lines and the ones starting with # are skipped.
+func NewFileSource(params Params) (Source, error) {
+	path := params.ConfigArgument
+	if path == "" {
+		return nil, fmt.Errorf("the file secret source needs a path, like file=secrets.env")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(params.Cwd, path)
+	}
+	data, err := fsext.ReadFile(params.FS, path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the secret source: %w", err)
+	}
+
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		k, v, _ := strings.Cut(text, "=")
+		if !envVarName.MatchString(k) {
+			return nil, fmt.Errorf("invalid environment variable name '%s' on line %d of the secret source '%s'", k, line, path)
+		}
+		secrets[k] = v
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &fileSource{path: params.ConfigArgument, secrets: secrets}, nil
+}
+
+func (fs *fileSource) Description() string {
+	return "file " + fs.path
+}
+
+func (fs *fileSource) Get(key string) (string, error) {
+	value, ok := fs.secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (fs *fileSource) EnvVars() map[string]string {
+	return fs.secrets
+}
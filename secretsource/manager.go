@@ -0,0 +1,139 @@
+package secretsource
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedValue replaces the values of the secrets in the logs and the summary.
+const RedactedValue = "***SECRET_REDACTED***"
+
+// Manager gets the secrets from the sources, the first one with a secret wins,
+// and masks the values of the secrets it has given out. It's also a logrus
+// hook masking them in the log entries.
+type Manager struct {
+	sources []Source
+
+	mu       sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer
+}
+
+var _ logrus.Hook = &Manager{}
+
+// NewManager returns a Manager for the sources, the values of their
+// environment variables are masked from the start.
+func NewManager(sources []Source) *Manager {
+	m := &Manager{sources: sources, values: make(map[string]struct{})}
+	for _, source := range sources {
+		if envSource, ok := source.(EnvSource); ok {
+			for _, value := range envSource.EnvVars() {
+				m.AddValue(value)
+			}
+		}
+	}
+	return m
+}
+
+// Sources returns the sources of the manager.
+func (m *Manager) Sources() []Source {
+	return m.sources
+}
+
+// Get returns the secret with the key, from the first source which has it.
+func (m *Manager) Get(key string) (string, error) {
+	if len(m.sources) == 0 {
+		return "", errors.New("there are no secret sources, they're set with --secret-source")
+	}
+	for _, source := range m.sources {
+		value, err := source.Get(key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("couldn't get the secret '%s' from the %s: %w", key, source.Description(), err)
+		}
+		m.AddValue(value)
+		return value, nil
+	}
+	return "", fmt.Errorf("%w: '%s' isn't in any of the secret sources", ErrNotFound, key)
+}
+
+// AddValue adds a value to mask.
+func (m *Manager) AddValue(value string) {
+	if value == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[value]; ok {
+		return
+	}
+	m.values[value] = struct{}{}
+
+	// the longest values are replaced first, in case they contain others
+	values := make([]string, 0, len(m.values))
+	for v := range m.values {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	oldnew := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		oldnew = append(oldnew, v, RedactedValue)
+	}
+	m.replacer = strings.NewReplacer(oldnew...)
+}
+
+// Redact returns the text with the values of the secrets masked.
+func (m *Manager) Redact(text string) string {
+	m.mu.RLock()
+	replacer := m.replacer
+	m.mu.RUnlock()
+	if replacer == nil {
+		return text
+	}
+	return replacer.Replace(text)
+}
+
+// Levels implements logrus.Hook, all of the entries are masked.
+func (m *Manager) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook, it masks the secrets in the message and in the
+// string and error fields of the entry.
+func (m *Manager) Fire(entry *logrus.Entry) error {
+	entry.Message = m.Redact(entry.Message)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = m.Redact(v)
+		case error:
+			if redacted := m.Redact(v.Error()); redacted != v.Error() {
+				entry.Data[key] = redacted
+			}
+		}
+	}
+	return nil
+}
+
+// InstallHook adds the manager as the first hook of the logger, so the other
+// hooks, e.g. the ones sending the logs to Loki, get the masked entries.
+func (m *Manager) InstallHook(logger *logrus.Logger) { //nolint:forbidigo
+	hooks := make(logrus.LevelHooks)
+	hooks.Add(m)
+	for level, levelHooks := range logger.Hooks {
+		hooks[level] = append(hooks[level], levelHooks...)
+	}
+	logger.ReplaceHooks(hooks)
+}
@@ -0,0 +1,102 @@
+// Package secretsource provides the sources of the secrets of the tests, like
+// files, HashiCorp Vault or AWS Secrets Manager, which the scripts get with
+// the k6/secrets module. Their values are masked in the logs and in the
+// end-of-test summary.
+package secretsource
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/ext"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// ErrNotFound is returned by the sources which don't have a secret.
+var ErrNotFound = errors.New("secret not found")
+
+// Source is a source of secrets.
+type Source interface {
+	// Description returns a human-readable description of the source, e.g.
+	// "file secrets.env".
+	Description() string
+	// Get returns the secret with the key, or ErrNotFound.
+	Get(key string) (string, error)
+}
+
+// EnvSource is a source whose secrets are also given to the scripts as
+// environment variables, their values are redacted from the archives.
+type EnvSource interface {
+	Source
+	EnvVars() map[string]string
+}
+
+// Params contains all possible constructor parameters a secret source may need.
+type Params struct {
+	ConfigArgument string // --secret-source $SourceType=$ConfigArgument
+
+	Logger      logrus.FieldLogger
+	Environment map[string]string
+	FS          fsext.Fs
+	Cwd         string
+}
+
+// Constructor returns an instance of a secret source.
+type Constructor func(Params) (Source, error)
+
+// RegisterExtension registers the given secret source extension constructor.
+// This function panics if a module with the same name is already registered.
+func RegisterExtension(name string, c Constructor) {
+	ext.Register(name, ext.SecretSourceExtension, c)
+}
+
+// GetConstructors returns the constructors of the built-in secret sources and
+// of the extensions, by name.
+func GetConstructors() (map[string]Constructor, error) {
+	result := map[string]Constructor{
+		"file":  NewFileSource,
+		"env":   NewEnvSource,
+		"vault": NewVaultSource,
+		"aws":   NewAWSSource,
+	}
+	for _, e := range ext.Get(ext.SecretSourceExtension) {
+		if _, ok := result[e.Name]; ok {
+			return nil, fmt.Errorf("invalid secret source extension %s, built-in secret source with the same type already exists", e.Name)
+		}
+		c, ok := e.Module.(Constructor)
+		if !ok {
+			return nil, fmt.Errorf("unexpected secret source extension type %T", e.Module)
+		}
+		result[e.Name] = c
+	}
+	return result, nil
+}
+
+// parseConfig parses the key=value,key=value configuration of a source.
+func parseConfig(sourceType, arg string, allowed ...string) (map[string]string, error) {
+	config := make(map[string]string)
+	if arg == "" {
+		return config, nil
+	}
+	for _, kv := range strings.Split(arg, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !isAllowed(k, allowed) {
+			return nil, fmt.Errorf("invalid %s secret source option '%s', the options are %s",
+				sourceType, kv, strings.Join(allowed, ", "))
+		}
+		config[k] = v
+	}
+	return config, nil
+}
+
+func isAllowed(key string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == key {
+			return true
+		}
+	}
+	return false
+}
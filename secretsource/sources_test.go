@@ -0,0 +1,102 @@
+package secretsource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+func TestFileSource(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	require.NoError(t, fsext.WriteFile(fs, "/test/secrets.env",
+		[]byte("# the tokens\nAPI_TOKEN=abc=def\n\n  OTHER=ghi\n"), 0o600))
+	require.NoError(t, fsext.WriteFile(fs, "/test/invalid.env", []byte("OK=1\n1NVALID=2\n"), 0o600))
+
+	source, err := NewFileSource(Params{ConfigArgument: "secrets.env", FS: fs, Cwd: "/test"})
+	require.NoError(t, err)
+	assert.Equal(t, "file secrets.env", source.Description())
+	assert.Equal(t, map[string]string{"API_TOKEN": "abc=def", "OTHER": "ghi"}, source.(EnvSource).EnvVars())
+	value, err := source.Get("API_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "abc=def", value)
+	_, err = source.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = NewFileSource(Params{ConfigArgument: "invalid.env", FS: fs, Cwd: "/test"})
+	assert.EqualError(t, err,
+		"invalid environment variable name '1NVALID' on line 2 of the secret source '/test/invalid.env'")
+	_, err = NewFileSource(Params{ConfigArgument: "/missing.env", FS: fs, Cwd: "/test"})
+	assert.ErrorContains(t, err, "couldn't read the secret source")
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Parallel()
+
+	source, err := NewEnvSource(Params{ConfigArgument: "DB_PASSWORD", Environment: map[string]string{"DB_PASSWORD": "x"}})
+	require.NoError(t, err)
+	value, err := source.Get("DB_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "x", value)
+	_, err = source.Get("OTHER")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = NewEnvSource(Params{ConfigArgument: "MISSING", Environment: map[string]string{}})
+	assert.EqualError(t, err, "the environment variable 'MISSING' of the secret source isn't set")
+}
+
+func TestVaultSource(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.Header.Get("X-Vault-Token") != "token":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		case r.URL.Path == "/v1/secret/data/k6":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"hunter2","port":5432},"metadata":{"version":1}}}`))
+		case r.URL.Path == "/v1/kv/k6":
+			_, _ = w.Write([]byte(`{"data":{"password":"v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	get := func(config, token, key string) (string, error) {
+		source, err := NewVaultSource(Params{
+			ConfigArgument: config,
+			Environment:    map[string]string{"VAULT_ADDR": srv.URL, "VAULT_TOKEN": token},
+		})
+		require.NoError(t, err)
+		return source.Get(key)
+	}
+
+	value, err := get("path=/secret/data/k6/", "token", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+	value, err = get("path=secret/data/k6", "token", "port")
+	require.NoError(t, err)
+	assert.Equal(t, "5432", value)
+	value, err = get("path=kv/k6,address="+srv.URL+"/", "token", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", value)
+	_, err = get("path=secret/data/missing", "token", "password")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = get("path=secret/data/k6", "wrong", "password")
+	assert.EqualError(t, err, "vault returned the status 403: permission denied")
+	assert.Equal(t, 5, requests)
+
+	_, err = NewVaultSource(Params{ConfigArgument: "path=kv/k6", Environment: map[string]string{"VAULT_ADDR": srv.URL}})
+	assert.EqualError(t, err, "the vault secret source needs a token, with VAULT_TOKEN")
+	_, err = NewVaultSource(Params{ConfigArgument: "", Environment: map[string]string{}})
+	assert.ErrorContains(t, err, "needs the path of the secrets")
+}
@@ -0,0 +1,134 @@
+package secretsource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The environment variables of the address and the token of Vault.
+const (
+	vaultAddressEnvVar = "VAULT_ADDR"
+	vaultTokenEnvVar   = "VAULT_TOKEN" //nolint:gosec
+)
+
+const vaultTimeout = 10 * time.Second
+
+// vaultSource has the secrets of a path of a HashiCorp Vault KV secrets
+// engine, they're read once, with the first secret.
+type vaultSource struct {
+	address, path, token string
+	client               *http.Client
+
+	once    sync.Once
+	secrets map[string]string
+	err     error
+}
+
+// NewVaultSource returns the source of the Vault KV path of the config
+// argument, like `path=secret/data/k6,address=https://vault:8200`. The
+// address is VAULT_ADDR by default, and the token is always VAULT_TOKEN so it
+// isn't in the command line.
+func NewVaultSource(params Params) (Source, error) {
+	config, err := parseConfig("vault", params.ConfigArgument, "path", "address")
+	if err != nil {
+		return nil, err
+	}
+	vs := &vaultSource{
+		address: config["address"],
+		path:    strings.Trim(config["path"], "/"),
+		token:   params.Environment[vaultTokenEnvVar],
+		client:  &http.Client{Timeout: vaultTimeout},
+	}
+	if vs.address == "" {
+		vs.address = params.Environment[vaultAddressEnvVar]
+	}
+	switch {
+	case vs.path == "":
+		return nil, errors.New("the vault secret source needs the path of the secrets, like vault=path=secret/data/k6")
+	case vs.address == "":
+		return nil, fmt.Errorf("the vault secret source needs an address, with the address option or %s", vaultAddressEnvVar)
+	case vs.token == "":
+		return nil, fmt.Errorf("the vault secret source needs a token, with %s", vaultTokenEnvVar)
+	}
+	vs.address = strings.TrimRight(vs.address, "/")
+	return vs, nil
+}
+
+func (vs *vaultSource) Description() string {
+	return "vault " + vs.address + "/v1/" + vs.path
+}
+
+func (vs *vaultSource) Get(key string) (string, error) {
+	vs.once.Do(func() {
+		vs.secrets, vs.err = vs.read()
+	})
+	if vs.err != nil {
+		return "", vs.err
+	}
+	value, ok := vs.secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// read reads the secrets of the path, from either a KV version 2 engine,
+// whose secrets are in data.data, or a version 1 one.
+func (vs *vaultSource) read() (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, vs.address+"/v1/"+vs.path, nil) //nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", vs.token)
+	res, err := vs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(body, &vaultErr)
+		return nil, fmt.Errorf("vault returned the status %d: %s", res.StatusCode, strings.Join(vaultErr.Errors, ", "))
+	}
+
+	var payload struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("couldn't parse the response of vault: %w", err)
+	}
+	data := payload.Data
+	if kv2, ok := data["data"]; ok && data["metadata"] != nil {
+		data = nil
+		if err = json.Unmarshal(kv2, &data); err != nil {
+			return nil, fmt.Errorf("couldn't parse the response of vault: %w", err)
+		}
+	}
+
+	secrets := make(map[string]string, len(data))
+	for k, raw := range data {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			secrets[k] = s
+		} else {
+			secrets[k] = string(raw)
+		}
+	}
+	return secrets, nil
+}